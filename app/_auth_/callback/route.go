@@ -1,21 +1,24 @@
 package callback
 
 import (
-	"context"
 	"net/http"
+	"net/netip"
 	"net/url"
 	"time"
 
 	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
-	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/credits"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
 	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 func Get(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
 
 	code := c.Query("code")
 	state := c.Query("state")
@@ -29,35 +32,43 @@ func Get(c *fuego.Context) error {
 		return c.Redirect("/login?error=missing_params", 302)
 	}
 
+	ip := remoteIP(c)
+	if auth.Blocked(ip, uuid.Nil) > 0 {
+		return c.Redirect("/login?error=too_many_attempts", 302)
+	}
+
 	queries := db.New(pool)
 
-	oauthState, err := queries.GetOAuthState(context.Background(), state)
+	oauthState, err := queries.GetOAuthState(c.Request.Context(), state)
 	if err != nil {
+		auth.RecordFailure(c.Request.Context(), queries, ip, uuid.Nil, "invalid_oauth_state")
 		return c.Redirect("/login?error=invalid_state", 302)
 	}
 
 	if time.Now().After(oauthState.ExpiresAt) {
-		_ = queries.DeleteOAuthState(context.Background(), state)
+		_ = queries.DeleteOAuthState(c.Request.Context(), state)
+		auth.RecordFailure(c.Request.Context(), queries, ip, uuid.Nil, "expired_oauth_state")
 		return c.Redirect("/login?error=state_expired", 302)
 	}
 
-	_ = queries.DeleteOAuthState(context.Background(), state)
+	_ = queries.DeleteOAuthState(c.Request.Context(), state)
 
 	ghClient := auth.NewGitHubClient(cfg.GitHubClientID, cfg.GitHubClientSecret, cfg.GitHubCallbackURL)
 
-	token, err := ghClient.Exchange(context.Background(), code)
+	token, err := ghClient.Exchange(c.Request.Context(), code)
 	if err != nil {
+		auth.RecordFailure(c.Request.Context(), queries, ip, uuid.Nil, "oauth_exchange_failed")
 		return c.Redirect("/login?error=exchange_failed", 302)
 	}
 
-	ghUser, err := ghClient.GetUser(context.Background(), token)
+	ghUser, err := ghClient.GetUser(c.Request.Context(), token)
 	if err != nil {
 		return c.Redirect("/login?error=github_error", 302)
 	}
 
-	user, err := queries.GetUserByGitHubID(context.Background(), ghUser.ID)
+	user, err := queries.GetUserByGitHubID(c.Request.Context(), ghUser.ID)
 	if err != nil {
-		user, err = queries.CreateUser(context.Background(), db.CreateUserParams{
+		user, err = queries.CreateUser(c.Request.Context(), db.CreateUserParams{
 			GithubID:  ghUser.ID,
 			Username:  ghUser.Login,
 			Email:     ghUser.Email,
@@ -66,8 +77,11 @@ func Get(c *fuego.Context) error {
 		if err != nil {
 			return c.Redirect("/login?error=create_user_failed", 302)
 		}
+		if oauthState.Referrer != nil {
+			_ = credits.Refer(c.Request.Context(), queries, user.ID, *oauthState.Referrer)
+		}
 	} else {
-		user, err = queries.UpdateUser(context.Background(), db.UpdateUserParams{
+		user, err = queries.UpdateUser(c.Request.Context(), db.UpdateUserParams{
 			ID:        user.ID,
 			Username:  ghUser.Login,
 			Email:     ghUser.Email,
@@ -78,6 +92,14 @@ func Get(c *fuego.Context) error {
 		}
 	}
 
+	auth.RecordSuccess(ip, user.ID)
+
+	// Link organization memberships provisioned through SCIM before the first login
+	_ = queries.LinkOrganizationMembers(c.Request.Context(), db.LinkOrganizationMembersParams{
+		UserName: user.Username,
+		UserID:   pgtype.UUID{Bytes: user.ID, Valid: true},
+	})
+
 	tokenPair, err := auth.GenerateTokenPair(user.ID, user.Username, cfg.JWTSecret)
 	if err != nil {
 		return c.Redirect("/login?error=token_generation_failed", 302)
@@ -100,7 +122,7 @@ func Get(c *fuego.Context) error {
 		MaxAge:   int(7 * 24 * time.Hour.Seconds()),
 		HttpOnly: true,
 		Secure:   !cfg.IsDevelopment(),
-		SameSite: http.SameSiteLaxMode,
+		SameSite: http.SameSiteStrictMode,
 	})
 
 	redirectURI := "/dashboard"
@@ -115,3 +137,11 @@ func Get(c *fuego.Context) error {
 
 	return c.Redirect(redirectURI, 302)
 }
+
+// remoteIP returns the client address used to throttle failed logins
+func remoteIP(c *fuego.Context) string {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return addr.String()
+	}
+	return c.Request.RemoteAddr
+}
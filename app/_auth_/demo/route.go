@@ -0,0 +1,54 @@
+package demo
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/demo"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+)
+
+// Get signs the visitor in as the demo user and opens the dashboard. Only
+// served in demo mode.
+// GET /demo
+func Get(c *fuego.Context) error {
+	svc := services.From(c)
+	cfg := svc.Config
+	if !cfg.DemoMode {
+		return c.Redirect("/login", 302)
+	}
+
+	user, err := svc.Store.Users.GetByGitHubID(c.Request.Context(), demo.GitHubID)
+	if err != nil {
+		return c.Redirect("/login?error=demo_not_seeded", 302)
+	}
+
+	tokenPair, err := auth.GenerateTokenPair(user.ID, user.Username, cfg.JWTSecret)
+	if err != nil {
+		return c.Redirect("/login?error=token_generation_failed", 302)
+	}
+
+	c.SetCookie(&http.Cookie{
+		Name:     "access_token",
+		Value:    tokenPair.AccessToken,
+		Path:     "/",
+		MaxAge:   int(time.Until(tokenPair.ExpiresAt).Seconds()),
+		HttpOnly: true,
+		Secure:   !cfg.IsDevelopment(),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	c.SetCookie(&http.Cookie{
+		Name:     "refresh_token",
+		Value:    tokenPair.RefreshToken,
+		Path:     "/",
+		MaxAge:   int(7 * 24 * time.Hour.Seconds()),
+		HttpOnly: true,
+		Secure:   !cfg.IsDevelopment(),
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	return c.Redirect("/dashboard", 302)
+}
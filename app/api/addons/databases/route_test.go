@@ -0,0 +1,32 @@
+package databases
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/dbaddon"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/testutil"
+	"github.com/google/uuid"
+)
+
+func TestPost_Validation(t *testing.T) {
+	ta := testutil.NewTestApp().WithAuth(uuid.New(), "octocat")
+	ta.App.Post("/api/addons/databases", Post)
+	ta.App.Mount()
+
+	post := func(body CreateAddonRequest) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		ta.App.ServeHTTP(w, testutil.MakeRequest(t, http.MethodPost, "/api/addons/databases", body, nil))
+		return w
+	}
+
+	// Requests are rejected before the database is reached
+	testutil.AssertStatusCode(t, post(CreateAddonRequest{Name: "Main", URL: "postgres://app@db/shop"}), http.StatusBadRequest)
+	testutil.AssertStatusCode(t, post(CreateAddonRequest{Name: "main", URL: "mysql://app@db/shop"}), http.StatusBadRequest)
+	testutil.AssertStatusCode(t, post(CreateAddonRequest{
+		Name:        "main",
+		Credentials: dbaddon.Credentials{Host: "db", Database: "shop", Username: "app", SSLMode: "sometimes"},
+	}), http.StatusBadRequest)
+}
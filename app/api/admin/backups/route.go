@@ -0,0 +1,81 @@
+package backups
+
+import (
+	"encoding/json"
+	"net/netip"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/backup"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type SnapshotResponse struct {
+	Key           string            `json:"key"`
+	CreatedAt     time.Time         `json:"created_at"`
+	SchemaVersion int64             `json:"schema_version"`
+	Tables        int               `json:"tables"`
+	Rows          int64             `json:"rows"`
+	Namespaces    int               `json:"namespaces"`
+	RegionErrors  map[string]string `json:"region_errors,omitempty"`
+}
+
+// Post takes a disaster-recovery snapshot right away, e.g. before a risky
+// migration, in addition to the scheduled ones
+// POST /api/admin/backups
+func Post(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	queries := db.New(pool)
+
+	admin, status, msg := auth.RequireAdmin(c, cfg, queries)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+
+	if cfg.BackupURL == "" {
+		return c.JSON(400, map[string]string{"error": "backups are not configured, set BACKUP_URL"})
+	}
+	store, err := backup.NewStore(cfg)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	key, manifest, err := backup.New(pool, cfg, services.From(c).Kubernetes, store).Snapshot(c.Request.Context())
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to take snapshot: " + err.Error()})
+	}
+
+	response := SnapshotResponse{
+		Key:           key,
+		CreatedAt:     manifest.CreatedAt,
+		SchemaVersion: manifest.SchemaVersion,
+		Tables:        len(manifest.Tables),
+		Namespaces:    len(manifest.Namespaces),
+		RegionErrors:  manifest.RegionErrors,
+	}
+	for _, table := range manifest.Tables {
+		response.Rows += table.Rows
+	}
+
+	details, _ := json.Marshal(map[string]any{"key": key, "tables": response.Tables, "namespaces": response.Namespaces})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: admin.ID, Valid: true},
+		Action:    "backup.created",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(201, response)
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package cors
+
+import (
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/cors"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+)
+
+type PolicyResponse struct {
+	Environment       string   `json:"environment"`
+	AllowedOrigins    []string `json:"allowed_origins"`
+	AnyOrigin         bool     `json:"any_origin"`
+	AllowedMethods    []string `json:"allowed_methods"`
+	AllowedHeaders    []string `json:"allowed_headers"`
+	MaxAgeSeconds     int      `json:"max_age_seconds"`
+	Origin            string   `json:"origin,omitempty"`
+	OriginAllowed     *bool    `json:"origin_allowed,omitempty"`
+	OriginCredentials *bool    `json:"origin_credentials,omitempty"`
+}
+
+// Get returns the effective CORS policy. With ?origin= it also reports
+// whether that origin may make cross-origin and credentialed requests.
+// GET /api/admin/cors
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	if _, status, msg := auth.RequireAdmin(c, cfg, db.New(pool)); status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+
+	policy, err := cors.Parse(cfg.CORSAllowedOrigins)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	response := PolicyResponse{
+		Environment:    cfg.Environment,
+		AllowedOrigins: policy.Origins,
+		AnyOrigin:      policy.AnyOrigin,
+		AllowedMethods: cors.Methods,
+		AllowedHeaders: cors.Headers,
+		MaxAgeSeconds:  int(cors.MaxAge.Seconds()),
+	}
+	if origin := c.Query("origin"); origin != "" {
+		allowed, trusted := policy.Allowed(origin), policy.Trusted(origin)
+		response.Origin = origin
+		response.OriginAllowed = &allowed
+		response.OriginCredentials = &trusted
+	}
+
+	return c.JSON(200, response)
+}
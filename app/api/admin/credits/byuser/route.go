@@ -0,0 +1,121 @@
+package credit
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/netip"
+	"strings"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/credits"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// maxDescription bounds the description shown on a grant
+const maxDescription = 200
+
+// GrantRequest grants an account credits, in USD cents
+type GrantRequest struct {
+	Amount      int64  `json:"amount"`
+	Description string `json:"description"`
+}
+
+type CreditsResponse struct {
+	Username string            `json:"username"`
+	Balance  int64             `json:"balance"`
+	Currency string            `json:"currency"`
+	Entries  []db.CreditLedger `json:"entries"`
+}
+
+// Get returns a user's credit balance and their latest ledger entries
+// GET /api/admin/credits/{username}
+func Get(c *fuego.Context) error {
+	svc := services.From(c)
+	queries := db.New(svc.DB)
+
+	if _, status, msg := auth.RequireAdmin(c, svc.Config, queries); status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+
+	user, err := queries.GetUserByUsername(c.Request.Context(), c.Param("username"))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "user not found"})
+	}
+
+	balance, err := queries.GetCreditBalance(c.Request.Context(), user.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to get balance"})
+	}
+	entries, err := queries.ListCreditEntries(c.Request.Context(), db.ListCreditEntriesParams{UserID: user.ID, Limit: 100})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list credits"})
+	}
+	if entries == nil {
+		entries = []db.CreditLedger{}
+	}
+
+	return c.JSON(200, CreditsResponse{
+		Username: user.Username,
+		Balance:  balance,
+		Currency: credits.Currency,
+		Entries:  entries,
+	})
+}
+
+// Post grants a user credits, consumed by their invoices before their card
+// is charged. The user is notified of the grant.
+// POST /api/admin/credits/{username}
+func Post(c *fuego.Context) error {
+	svc := services.From(c)
+	queries := db.New(svc.DB)
+
+	admin, status, msg := auth.RequireAdmin(c, svc.Config, queries)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+
+	var req GrantRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+	req.Description = strings.TrimSpace(req.Description)
+	if req.Amount <= 0 || req.Amount > credits.MaxGrant {
+		return c.JSON(400, map[string]string{"error": "amount must be between 1 and 1000000 cents"})
+	}
+	if len(req.Description) > maxDescription {
+		return c.JSON(400, map[string]string{"error": "description must be at most 200 characters"})
+	}
+
+	user, err := queries.GetUserByUsername(c.Request.Context(), c.Param("username"))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "user not found"})
+	}
+
+	entry, err := credits.New(svc.DB, svc.Billing).Grant(c.Request.Context(), user.ID, req.Amount, credits.ReasonGrant, req.Description, admin.ID)
+	if err != nil {
+		slog.Error("failed to grant credits", "user", user.Username, "error", err)
+		return c.JSON(500, map[string]string{"error": "failed to grant credits"})
+	}
+
+	details, _ := json.Marshal(map[string]any{"username": user.Username, "amount": req.Amount, "description": req.Description})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: admin.ID, Valid: true},
+		Action:    "credits.granted",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(201, entry)
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package window
+
+import (
+	"encoding/json"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/maintenance"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type WindowRequest struct {
+	Title    string    `json:"title"`
+	Message  string    `json:"message"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+type WindowResponse struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Message   string    `json:"message"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Put reschedules or rewords a maintenance window
+// PUT /api/admin/maintenance/{id}
+// Body: { "title": "Database upgrade", "message": "Extended by an hour", "starts_at": "2026-03-01T02:00:00Z", "ends_at": "2026-03-01T05:00:00Z" }
+func Put(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	queries := db.New(pool)
+
+	admin, status, msg := auth.RequireAdmin(c, cfg, queries)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+
+	windowID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "invalid maintenance window id"})
+	}
+
+	var req WindowRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+
+	now := time.Now()
+	if err := maintenance.Validate(req.Title, req.StartsAt, req.EndsAt, now); err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	if _, err := queries.GetMaintenanceWindow(c.Request.Context(), windowID); err != nil {
+		return c.JSON(404, map[string]string{"error": "maintenance window not found"})
+	}
+
+	window, err := queries.UpdateMaintenanceWindow(c.Request.Context(), db.UpdateMaintenanceWindowParams{
+		ID:       windowID,
+		Title:    strings.TrimSpace(req.Title),
+		Message:  req.Message,
+		StartsAt: req.StartsAt,
+		EndsAt:   req.EndsAt,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to update maintenance window"})
+	}
+
+	logActivity(c, queries, admin.ID, "maintenance.updated", window)
+
+	return c.JSON(200, WindowResponse{
+		ID:        window.ID.String(),
+		Title:     window.Title,
+		Message:   window.Message,
+		StartsAt:  window.StartsAt,
+		EndsAt:    window.EndsAt,
+		Active:    maintenance.Active(window, now),
+		CreatedAt: window.CreatedAt,
+		UpdatedAt: window.UpdatedAt,
+	})
+}
+
+// Delete cancels a maintenance window, or ends one in progress early
+// DELETE /api/admin/maintenance/{id}
+func Delete(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	queries := db.New(pool)
+
+	admin, status, msg := auth.RequireAdmin(c, cfg, queries)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+
+	windowID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "invalid maintenance window id"})
+	}
+
+	window, err := queries.GetMaintenanceWindow(c.Request.Context(), windowID)
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "maintenance window not found"})
+	}
+
+	if err := queries.DeleteMaintenanceWindow(c.Request.Context(), windowID); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to cancel maintenance window"})
+	}
+
+	logActivity(c, queries, admin.ID, "maintenance.cancelled", window)
+
+	return c.JSON(200, map[string]string{"message": "maintenance window cancelled"})
+}
+
+func logActivity(c *fuego.Context, queries *db.Queries, adminID uuid.UUID, action string, window db.MaintenanceWindow) {
+	details, _ := json.Marshal(map[string]any{
+		"window_id": window.ID.String(),
+		"title":     window.Title,
+		"starts_at": window.StartsAt,
+		"ends_at":   window.EndsAt,
+	})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: adminID, Valid: true},
+		Action:    action,
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
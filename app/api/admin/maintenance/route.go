@@ -0,0 +1,143 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/maintenance"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type WindowRequest struct {
+	Title    string    `json:"title"`
+	Message  string    `json:"message"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+type WindowResponse struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Message   string    `json:"message"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Get lists maintenance windows, most recent first
+// GET /api/admin/maintenance?limit=50&offset=0
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	queries := db.New(pool)
+
+	if _, status, msg := auth.RequireAdmin(c, cfg, queries); status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	offset, _ := strconv.Atoi(c.Query("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	windows, err := queries.ListMaintenanceWindows(c.Request.Context(), db.ListMaintenanceWindowsParams{
+		Limit:  int32(limit),  //nolint:gosec // Bounded above
+		Offset: int32(offset), //nolint:gosec // Parsed from a query parameter
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list maintenance windows"})
+	}
+
+	now := time.Now()
+	response := make([]WindowResponse, 0, len(windows))
+	for _, window := range windows {
+		response = append(response, toWindowResponse(window, now))
+	}
+	return c.JSON(200, response)
+}
+
+// Post schedules a maintenance window. It shows up in the platform notices
+// right away and pauses non-critical background workers while in progress.
+// POST /api/admin/maintenance
+// Body: { "title": "Database upgrade", "message": "Deploys may be delayed", "starts_at": "2026-03-01T02:00:00Z", "ends_at": "2026-03-01T04:00:00Z" }
+func Post(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	queries := db.New(pool)
+
+	admin, status, msg := auth.RequireAdmin(c, cfg, queries)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+
+	var req WindowRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+
+	now := time.Now()
+	if err := maintenance.Validate(req.Title, req.StartsAt, req.EndsAt, now); err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	window, err := queries.CreateMaintenanceWindow(c.Request.Context(), db.CreateMaintenanceWindowParams{
+		Title:     strings.TrimSpace(req.Title),
+		Message:   req.Message,
+		StartsAt:  req.StartsAt,
+		EndsAt:    req.EndsAt,
+		CreatedBy: pgtype.UUID{Bytes: admin.ID, Valid: true},
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to schedule maintenance window"})
+	}
+
+	details, _ := json.Marshal(map[string]any{
+		"window_id": window.ID.String(),
+		"title":     window.Title,
+		"starts_at": window.StartsAt,
+		"ends_at":   window.EndsAt,
+	})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: admin.ID, Valid: true},
+		Action:    "maintenance.scheduled",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(201, toWindowResponse(window, now))
+}
+
+func toWindowResponse(window db.MaintenanceWindow, now time.Time) WindowResponse {
+	return WindowResponse{
+		ID:        window.ID.String(),
+		Title:     window.Title,
+		Message:   window.Message,
+		StartsAt:  window.StartsAt,
+		EndsAt:    window.EndsAt,
+		Active:    maintenance.Active(window, now),
+		CreatedAt: window.CreatedAt,
+		UpdatedAt: window.UpdatedAt,
+	}
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package requeue
+
+import (
+	"encoding/json"
+	"errors"
+	"net/netip"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/outbox"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type JobResponse struct {
+	ID            string    `json:"id"`
+	Kind          string    `json:"kind"`
+	Destination   string    `json:"destination"`
+	Status        string    `json:"status"`
+	Attempts      int32     `json:"attempts"`
+	MaxAttempts   int32     `json:"max_attempts"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Post moves a dead outbox job back to pending with a fresh attempt budget
+// POST /api/admin/outbox/{id}/requeue
+func Post(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	queries := db.New(pool)
+
+	admin, status, msg := auth.RequireAdmin(c, cfg, queries)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "invalid job id"})
+	}
+
+	job, err := queries.GetOutboxJob(c.Request.Context(), jobID)
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "outbox job not found"})
+	}
+	if job.Status != outbox.StatusDead {
+		return c.JSON(409, map[string]string{"error": "only dead jobs can be requeued"})
+	}
+
+	job, err = queries.RequeueOutboxJob(c.Request.Context(), jobID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Requeued concurrently
+		return c.JSON(409, map[string]string{"error": "only dead jobs can be requeued"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to requeue outbox job"})
+	}
+
+	details, _ := json.Marshal(map[string]any{"job_id": job.ID.String(), "kind": job.Kind})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: admin.ID, Valid: true},
+		Action:    "outbox.requeued",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(200, JobResponse{
+		ID:            job.ID.String(),
+		Kind:          job.Kind,
+		Destination:   job.Destination,
+		Status:        job.Status,
+		Attempts:      job.Attempts,
+		MaxAttempts:   job.MaxAttempts,
+		NextAttemptAt: job.NextAttemptAt,
+		CreatedAt:     job.CreatedAt,
+	})
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package outbox
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/outbox"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+)
+
+type JobResponse struct {
+	ID            string     `json:"id"`
+	Kind          string     `json:"kind"`
+	Destination   string     `json:"destination"`
+	Status        string     `json:"status"`
+	Attempts      int32      `json:"attempts"`
+	MaxAttempts   int32      `json:"max_attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	LastError     *string    `json:"last_error,omitempty"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// Get lists outbox jobs by status, dead jobs by default
+// GET /api/admin/outbox?status=dead&limit=50&offset=0
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	queries := db.New(pool)
+
+	if _, status, msg := auth.RequireAdmin(c, cfg, queries); status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+
+	status := c.Query("status")
+	if status == "" {
+		status = outbox.StatusDead
+	}
+	if status != outbox.StatusPending && status != outbox.StatusDelivered && status != outbox.StatusDead {
+		return c.JSON(400, map[string]string{"error": "status must be pending, delivered or dead"})
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	offset, _ := strconv.Atoi(c.Query("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	jobs, err := queries.ListOutboxJobsByStatus(c.Request.Context(), db.ListOutboxJobsByStatusParams{
+		Status: status,
+		Limit:  int32(limit),  //nolint:gosec // Bounded above
+		Offset: int32(offset), //nolint:gosec // Parsed from a query parameter
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list outbox jobs"})
+	}
+
+	response := make([]JobResponse, 0, len(jobs))
+	for _, job := range jobs {
+		response = append(response, ToJobResponse(job))
+	}
+	return c.JSON(200, response)
+}
+
+// ToJobResponse converts an outbox job for the admin API
+func ToJobResponse(job db.Outbox) JobResponse {
+	response := JobResponse{
+		ID:            job.ID.String(),
+		Kind:          job.Kind,
+		Destination:   job.Destination,
+		Status:        job.Status,
+		Attempts:      job.Attempts,
+		MaxAttempts:   job.MaxAttempts,
+		NextAttemptAt: job.NextAttemptAt,
+		LastError:     job.LastError,
+		CreatedAt:     job.CreatedAt,
+	}
+	if job.DeliveredAt.Valid {
+		response.DeliveredAt = &job.DeliveredAt.Time
+	}
+	return response
+}
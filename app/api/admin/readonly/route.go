@@ -0,0 +1,87 @@
+package readonly
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/readonly"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type ReadOnlyRequest struct {
+	Enabled bool `json:"enabled"`
+	// Message is the notice mutations are answered with
+	Message string `json:"message"`
+}
+
+// Get returns whether the API is in read-only mode
+// GET /api/admin/readonly
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	if _, status, msg := auth.RequireAdmin(c, cfg, db.New(pool)); status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+
+	return c.JSON(200, services.From(c).ReadOnly.State())
+}
+
+// Put turns read-only mode on or off. While on, every replica answers
+// mutations with 503 and the message, within seconds.
+// PUT /api/admin/readonly
+// Body: { "enabled": true, "message": "Database migration until 14:00 UTC" }
+func Put(c *fuego.Context) error {
+	svc := services.From(c)
+	queries := db.New(svc.DB)
+
+	admin, status, msg := auth.RequireAdmin(c, svc.Config, queries)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+	if svc.ReadOnly == nil {
+		return c.JSON(500, map[string]string{"error": "read-only switch not available"})
+	}
+
+	var req ReadOnlyRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+	if len(req.Message) > readonly.MaxMessageLength {
+		return c.JSON(400, map[string]string{"error": fmt.Sprintf("message must be at most %d characters", readonly.MaxMessageLength)})
+	}
+
+	adminID := pgtype.UUID{Bytes: admin.ID, Valid: true}
+	state, err := svc.ReadOnly.Set(c.Request.Context(), req.Enabled, req.Message, adminID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to switch read-only mode"})
+	}
+
+	action := "platform.read_only_disabled"
+	if state.Enabled {
+		action = "platform.read_only_enabled"
+	}
+	details, _ := json.Marshal(map[string]any{"message": state.Message})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    adminID,
+		Action:    action,
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(200, state)
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
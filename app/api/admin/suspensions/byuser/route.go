@@ -0,0 +1,67 @@
+package suspension
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/netip"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/suspension"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Delete reinstates an account without a payment through Stripe, such as
+// one settled by bank transfer: suspended apps are scaled back up and
+// purged ones are left for a redeploy
+// DELETE /api/admin/suspensions/{username}
+func Delete(c *fuego.Context) error {
+	svc := services.From(c)
+	queries := db.New(svc.DB)
+
+	admin, status, msg := auth.RequireAdmin(c, svc.Config, queries)
+	if status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+
+	user, err := queries.GetUserByUsername(c.Request.Context(), c.Param("username"))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "user not found"})
+	}
+
+	s, err := queries.GetAccountSuspension(c.Request.Context(), user.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return c.JSON(404, map[string]string{"error": "account is not suspended"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to get suspension"})
+	}
+
+	pipeline := suspension.New(svc.DB, svc.Config, svc.Kubernetes, svc.Billing)
+	if err := pipeline.Reinstate(c.Request.Context(), s, "reinstated by "+admin.Username); err != nil {
+		slog.Error("failed to reinstate account", "user", user.Username, "error", err)
+		return c.JSON(500, map[string]string{"error": "failed to reinstate account"})
+	}
+
+	details, _ := json.Marshal(map[string]any{"username": user.Username, "stage": s.Stage})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: admin.ID, Valid: true},
+		Action:    "suspension.lifted",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(200, map[string]string{"message": "account reinstated"})
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
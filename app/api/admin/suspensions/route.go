@@ -0,0 +1,51 @@
+package suspensions
+
+import (
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/suspension"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+)
+
+type SuspensionResponse struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	suspension.State
+}
+
+// Get lists the accounts in the suspension pipeline, oldest failed payment
+// first
+// GET /api/admin/suspensions
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	queries := db.New(pool)
+
+	if _, status, msg := auth.RequireAdmin(c, cfg, queries); status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+
+	rows, err := queries.ListAccountSuspensions(c.Request.Context())
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list suspensions"})
+	}
+
+	response := make([]SuspensionResponse, len(rows))
+	for i, row := range rows {
+		response[i] = SuspensionResponse{
+			UserID:   row.UserID.String(),
+			Username: row.Username,
+			State: suspension.StateOf(db.AccountSuspension{
+				UserID:      row.UserID,
+				Stage:       row.Stage,
+				InvoiceID:   row.InvoiceID,
+				FailedAt:    row.FailedAt,
+				Warnings:    row.Warnings,
+				SuspendedAt: row.SuspendedAt,
+				PurgedAt:    row.PurgedAt,
+			}),
+		}
+	}
+	return c.JSON(200, response)
+}
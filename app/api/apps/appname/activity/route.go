@@ -1,16 +1,18 @@
 package activity
 
 import (
-	"context"
+	"errors"
 	"strconv"
 
 	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/apitime"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/store"
 	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type ActivityResponse struct {
@@ -25,17 +27,27 @@ type ActivityEntry struct {
 	Action    string                 `json:"action"`
 	Details   map[string]interface{} `json:"details,omitempty"`
 	IPAddress string                 `json:"ip_address,omitempty"`
+	Actor     *ActorEntry            `json:"actor,omitempty"`
 	CreatedAt string                 `json:"created_at"`
 }
 
+// ActorEntry is who took an action, and the API token used if any
+type ActorEntry struct {
+	UserID   uuid.UUID  `json:"user_id"`
+	Username string     `json:"username,omitempty"`
+	TokenID  *uuid.UUID `json:"token_id,omitempty"`
+}
+
 // Get returns activity logs for an app
 // GET /api/apps/{name}/activity
 // Query params:
 //   - limit: number of entries (default 50, max 100)
 //   - offset: pagination offset (default 0)
+//   - actor: only actions of the user with this username
+//   - token_id: only actions taken with this API token
 func Get(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	svc := services.From(c)
+	cfg := svc.Config
 	appName := c.Param("name")
 
 	userID, err := getUserID(c, cfg)
@@ -59,41 +71,58 @@ func Get(c *fuego.Context) error {
 	}
 
 	// Verify app ownership
-	queries := db.New(pool)
-	app, err := queries.GetAppByName(context.Background(), db.GetAppByNameParams{
-		UserID: userID,
-		Name:   appName,
-	})
+	app, err := svc.Store.Apps.GetByName(c.Request.Context(), userID, appName)
 	if err != nil {
 		return c.JSON(404, map[string]string{"error": "app not found"})
 	}
 
-	// Convert UUID to pgtype.UUID
-	appUUID := pgtype.UUID{Bytes: app.ID, Valid: true}
+	filter := db.FilterActivityLogsByAppParams{
+		AppID:       pgtype.UUID{Bytes: app.ID, Valid: true},
+		LimitCount:  limit,
+		OffsetCount: offset,
+	}
+	if username := c.Query("actor"); username != "" {
+		actorUser, err := svc.Store.Users.GetByUsername(c.Request.Context(), username)
+		if errors.Is(err, store.ErrNotFound) {
+			return c.JSON(200, ActivityResponse{Activities: []ActivityEntry{}, Limit: limit, Offset: offset})
+		}
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to look up actor"})
+		}
+		filter.UserID = pgtype.UUID{Bytes: actorUser.ID, Valid: true}
+	}
+	if t := c.Query("token_id"); t != "" {
+		tokenID, err := uuid.Parse(t)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "invalid token_id"})
+		}
+		filter.ApiTokenID = pgtype.UUID{Bytes: tokenID, Valid: true}
+	}
 
 	// Get activity logs
-	logs, err := queries.ListActivityLogsByApp(context.Background(), db.ListActivityLogsByAppParams{
-		AppID:  appUUID,
-		Limit:  limit,
-		Offset: offset,
-	})
+	logs, err := svc.Store.Activity.ListByApp(c.Request.Context(), filter)
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": "failed to get activity logs"})
 	}
 
 	// Get total count
-	total, err := queries.CountActivityLogsByApp(context.Background(), appUUID)
+	total, err := svc.Store.Activity.CountByApp(c.Request.Context(), db.CountFilteredActivityLogsByAppParams{
+		AppID:      filter.AppID,
+		UserID:     filter.UserID,
+		ApiTokenID: filter.ApiTokenID,
+	})
 	if err != nil {
 		total = 0
 	}
 
 	// Convert to response format
+	usernames := make(map[uuid.UUID]string)
 	activities := make([]ActivityEntry, 0, len(logs))
 	for _, log := range logs {
 		entry := ActivityEntry{
 			ID:        log.ID,
 			Action:    log.Action,
-			CreatedAt: log.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			CreatedAt: apitime.Format(log.CreatedAt),
 		}
 
 		// Details is JSONB stored as []byte, needs to be parsed
@@ -104,6 +133,22 @@ func Get(c *fuego.Context) error {
 			entry.IPAddress = log.IpAddress.String()
 		}
 
+		if log.UserID.Valid {
+			actorID := uuid.UUID(log.UserID.Bytes)
+			username, ok := usernames[actorID]
+			if !ok {
+				if user, err := svc.Store.Users.Get(c.Request.Context(), actorID); err == nil {
+					username = user.Username
+				}
+				usernames[actorID] = username
+			}
+			entry.Actor = &ActorEntry{UserID: actorID, Username: username}
+			if log.ApiTokenID.Valid {
+				tokenID := uuid.UUID(log.ApiTokenID.Bytes)
+				entry.Actor.TokenID = &tokenID
+			}
+		}
+
 		activities = append(activities, entry)
 	}
 
@@ -0,0 +1,80 @@
+package activity
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/store"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestGet_FilterByActor(t *testing.T) {
+	s := store.NewMemory()
+	alice := testutil.SeedUser(t, s, "alice")
+	bob := testutil.SeedUser(t, s, "bob")
+	app := testutil.SeedApp(t, s, alice.ID, "shop")
+	tokenID := uuid.New()
+
+	record := func(userID uuid.UUID, action string, token pgtype.UUID) {
+		t.Helper()
+		if _, err := s.Activity.Create(context.Background(), db.CreateActivityLogParams{
+			UserID:     pgtype.UUID{Bytes: userID, Valid: true},
+			AppID:      pgtype.UUID{Bytes: app.ID, Valid: true},
+			Action:     action,
+			ApiTokenID: token,
+		}); err != nil {
+			t.Fatalf("failed to record activity: %v", err)
+		}
+	}
+	record(alice.ID, "app.scaled", pgtype.UUID{})
+	record(alice.ID, "deployment.created", pgtype.UUID{Bytes: tokenID, Valid: true})
+	record(bob.ID, "app.restarted", pgtype.UUID{})
+
+	ta := testutil.NewTestApp().WithStore(s).WithAuth(alice.ID, alice.Username)
+	ta.App.Get("/api/apps/{name}/activity", Get)
+	ta.App.Mount()
+
+	get := func(query string) ActivityResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		ta.App.ServeHTTP(w, testutil.MakeRequest(t, http.MethodGet, "/api/apps/shop/activity"+query, nil, nil))
+		testutil.AssertStatusCode(t, w, http.StatusOK)
+		return testutil.ParseResponse[ActivityResponse](t, w)
+	}
+
+	all := get("")
+	if all.Total != 3 || len(all.Activities) != 3 {
+		t.Fatalf("expected 3 entries, got %+v", all)
+	}
+	for _, entry := range all.Activities {
+		if entry.Actor == nil || entry.Actor.Username == "" {
+			t.Errorf("expected %s to name its actor, got %+v", entry.Action, entry.Actor)
+		}
+	}
+
+	byAlice := get("?actor=alice")
+	if byAlice.Total != 2 {
+		t.Errorf("expected 2 entries of alice, got %+v", byAlice)
+	}
+
+	byToken := get("?token_id=" + tokenID.String())
+	if byToken.Total != 1 || byToken.Activities[0].Action != "deployment.created" {
+		t.Fatalf("expected the token's deployment, got %+v", byToken)
+	}
+	if got := byToken.Activities[0].Actor; got.Username != "alice" || got.TokenID == nil || *got.TokenID != tokenID {
+		t.Errorf("expected alice via the token, got %+v", got)
+	}
+
+	if unknown := get("?actor=mallory"); unknown.Total != 0 || len(unknown.Activities) != 0 {
+		t.Errorf("expected no entries of an unknown actor, got %+v", unknown)
+	}
+
+	w := httptest.NewRecorder()
+	ta.App.ServeHTTP(w, testutil.MakeRequest(t, http.MethodGet, "/api/apps/shop/activity?token_id=ci", nil, nil))
+	testutil.AssertStatusCode(t, w, http.StatusBadRequest)
+}
@@ -0,0 +1,240 @@
+package burst
+
+import (
+	"encoding/json"
+	"net/netip"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/burst"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/plans"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type BurstRequest struct {
+	CPUPercent      *int32 `json:"cpu_percent"`
+	P95LatencyMs    *int32 `json:"p95_latency_ms"`
+	CooldownMinutes int32  `json:"cooldown_minutes"`
+}
+
+type BurstResponse struct {
+	Enabled         bool   `json:"enabled"`
+	CPUPercent      *int32 `json:"cpu_percent,omitempty"`
+	P95LatencyMs    *int32 `json:"p95_latency_ms,omitempty"`
+	CooldownMinutes int32  `json:"cooldown_minutes,omitempty"`
+	// Active is true while the web replicas are doubled
+	Active       bool       `json:"active"`
+	BaseReplicas *int32     `json:"base_replicas,omitempty"`
+	StartedAt    *time.Time `json:"started_at,omitempty"`
+	Until        *time.Time `json:"until,omitempty"`
+}
+
+// defaultCooldownMinutes is how long load must stay under the thresholds
+// before a burst ends when no cool-down is given
+const defaultCooldownMinutes = 10
+
+// Get returns the burst thresholds of an app and whether it is bursting
+// GET /api/apps/{name}/burst
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	b, err := queries.GetAppBurst(c.Request.Context(), app.ID)
+	if err != nil {
+		return c.JSON(200, BurstResponse{})
+	}
+
+	return c.JSON(200, toBurstResponse(b))
+}
+
+// Put sets the thresholds over which the web replicas of an app are doubled:
+// the average CPU of the web pods as a percentage of the app's size, the p95
+// request latency, or both. Requires a plan with burst.
+// PUT /api/apps/{name}/burst
+// Body: { "cpu_percent": 80, "p95_latency_ms": 500, "cooldown_minutes": 10 }
+func Put(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req BurstRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+
+	if req.CPUPercent == nil && req.P95LatencyMs == nil {
+		return c.JSON(400, map[string]string{"error": "cpu_percent or p95_latency_ms is required"})
+	}
+	if req.CPUPercent != nil && (*req.CPUPercent < 1 || *req.CPUPercent > 100) {
+		return c.JSON(400, map[string]string{"error": "cpu_percent must be between 1 and 100"})
+	}
+	if req.P95LatencyMs != nil && *req.P95LatencyMs < 1 {
+		return c.JSON(400, map[string]string{"error": "p95_latency_ms must be positive"})
+	}
+	if req.CooldownMinutes == 0 {
+		req.CooldownMinutes = defaultCooldownMinutes
+	}
+	if req.CooldownMinutes < 1 || req.CooldownMinutes > 1440 {
+		return c.JSON(400, map[string]string{"error": "cooldown_minutes must be between 1 and 1440"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	if !plans.ForSize(app.Size).Burst {
+		return c.JSON(403, map[string]string{"error": "burst mode requires the pro or enterprise plan"})
+	}
+
+	b, err := queries.UpsertAppBurst(c.Request.Context(), db.UpsertAppBurstParams{
+		AppID:           app.ID,
+		CpuPercent:      req.CPUPercent,
+		P95LatencyMs:    req.P95LatencyMs,
+		CooldownMinutes: req.CooldownMinutes,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to update burst"})
+	}
+
+	details, _ := json.Marshal(req)
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		AppID:     pgtype.UUID{Bytes: app.ID, Valid: true},
+		Action:    "app.burst_updated",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(200, toBurstResponse(b))
+}
+
+// Delete turns burst mode off, scaling the web process back first if a
+// burst is in progress
+// DELETE /api/apps/{name}/burst
+func Delete(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	b, err := queries.GetAppBurst(c.Request.Context(), app.ID)
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "burst mode not enabled"})
+	}
+
+	if b.BaseReplicas != nil {
+		k8sClient, err := services.From(c).Cluster(app.Region)
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "kubernetes not available"})
+		}
+		if err := burst.End(c.Request.Context(), k8sClient, app.Name, b.BaseReplicas); err != nil {
+			return c.JSON(500, map[string]string{"error": err.Error()})
+		}
+	}
+
+	if err := queries.DeleteAppBurst(c.Request.Context(), app.ID); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to disable burst"})
+	}
+
+	details, _ := json.Marshal(map[string]any{
+		"scaled_back": b.BaseReplicas != nil,
+	})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		AppID:     pgtype.UUID{Bytes: app.ID, Valid: true},
+		Action:    "app.burst_disabled",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(200, map[string]string{"message": "burst mode disabled"})
+}
+
+func toBurstResponse(b db.AppBurst) BurstResponse {
+	response := BurstResponse{
+		Enabled:         true,
+		CPUPercent:      b.CpuPercent,
+		P95LatencyMs:    b.P95LatencyMs,
+		CooldownMinutes: b.CooldownMinutes,
+		Active:          b.BurstUntil.Valid,
+		BaseReplicas:    b.BaseReplicas,
+	}
+	if b.BurstStartedAt.Valid {
+		response.StartedAt = &b.BurstStartedAt.Time
+	}
+	if b.BurstUntil.Valid {
+		response.Until = &b.BurstUntil.Time
+	}
+	return response
+}
+
+// clientIP returns the request's client address for the audit log
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if id, ok := c.Get("user_id").(uuid.UUID); ok {
+		return id, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
@@ -0,0 +1,170 @@
+package collaborator
+
+import (
+	"encoding/json"
+	"net/netip"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/actor"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/collaborators"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type UpdateCollaboratorRequest struct {
+	Role string `json:"role"`
+}
+
+type CollaboratorResponse struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+// Put changes the role of a collaborator
+// PUT /api/apps/{name}/collaborators/{username}
+// Body: { "role": "view" }
+func Put(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+	if claims, ok := c.Get("claims").(*auth.Claims); ok && claims.App != "" {
+		return c.JSON(403, map[string]string{"error": "deploy tokens cannot manage collaborators"})
+	}
+
+	var req UpdateCollaboratorRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+	if !collaborators.ValidRole(req.Role) {
+		return c.JSON(400, map[string]string{"error": "role must be view, deploy or admin"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   c.Param("name"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	user, err := queries.GetUserByUsername(c.Request.Context(), c.Param("username"))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "collaborator not found"})
+	}
+
+	collaborator, err := queries.UpdateAppCollaboratorRole(c.Request.Context(), db.UpdateAppCollaboratorRoleParams{
+		AppID:  app.ID,
+		UserID: user.ID,
+		Role:   req.Role,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "collaborator not found"})
+	}
+
+	logActivity(c, queries, app.ID, "collaborator.updated", map[string]any{
+		"username": user.Username,
+		"role":     collaborator.Role,
+	})
+
+	return c.JSON(200, CollaboratorResponse{
+		UserID:   user.ID.String(),
+		Username: user.Username,
+		Role:     collaborator.Role,
+	})
+}
+
+// Delete removes a collaborator's rights on the app
+// DELETE /api/apps/{name}/collaborators/{username}
+func Delete(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+	if claims, ok := c.Get("claims").(*auth.Claims); ok && claims.App != "" {
+		return c.JSON(403, map[string]string{"error": "deploy tokens cannot manage collaborators"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   c.Param("name"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	user, err := queries.GetUserByUsername(c.Request.Context(), c.Param("username"))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "collaborator not found"})
+	}
+
+	deleted, err := queries.DeleteAppCollaborator(c.Request.Context(), db.DeleteAppCollaboratorParams{
+		AppID:  app.ID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to remove collaborator"})
+	}
+	if deleted == 0 {
+		return c.JSON(404, map[string]string{"error": "collaborator not found"})
+	}
+
+	logActivity(c, queries, app.ID, "collaborator.removed", map[string]any{
+		"username": user.Username,
+	})
+
+	return c.NoContent()
+}
+
+func logActivity(c *fuego.Context, queries *db.Queries, appID uuid.UUID, action string, fields map[string]any) {
+	by := actor.From(c)
+	details, _ := json.Marshal(fields)
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:     by.User(),
+		AppID:      pgtype.UUID{Bytes: appID, Valid: true},
+		Action:     action,
+		Details:    details,
+		IpAddress:  clientIP(c),
+		ApiTokenID: by.Token(),
+	})
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
@@ -0,0 +1,179 @@
+// Package collaborators manages the users with rights on a single app
+// besides its owner.
+package collaborators
+
+import (
+	"encoding/json"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/actor"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/collaborators"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type AddCollaboratorRequest struct {
+	Username string `json:"username"`
+	// Role is view, deploy or admin
+	Role string `json:"role"`
+}
+
+type CollaboratorResponse struct {
+	UserID    string    `json:"user_id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Get lists the collaborators of an app
+// GET /api/apps/{name}/collaborators
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   c.Param("name"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	rows, err := queries.ListAppCollaborators(c.Request.Context(), app.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list collaborators"})
+	}
+
+	response := make([]CollaboratorResponse, len(rows))
+	for i, r := range rows {
+		response[i] = CollaboratorResponse{
+			UserID:    r.UserID.String(),
+			Username:  r.Username,
+			Role:      r.Role,
+			CreatedAt: r.CreatedAt,
+			UpdatedAt: r.UpdatedAt,
+		}
+	}
+
+	return c.JSON(200, response)
+}
+
+// Post gives a user view, deploy or admin rights on the app. The user needs
+// no membership in the owner's organization. Deploy tokens cannot add
+// collaborators.
+// POST /api/apps/{name}/collaborators
+// Body: { "username": "contractor", "role": "deploy" }
+func Post(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+	if claims, ok := c.Get("claims").(*auth.Claims); ok && claims.App != "" {
+		return c.JSON(403, map[string]string{"error": "deploy tokens cannot manage collaborators"})
+	}
+
+	var req AddCollaboratorRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+	req.Username = strings.TrimSpace(req.Username)
+	if req.Username == "" {
+		return c.JSON(400, map[string]string{"error": "username is required"})
+	}
+	if !collaborators.ValidRole(req.Role) {
+		return c.JSON(400, map[string]string{"error": "role must be view, deploy or admin"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   c.Param("name"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	user, err := queries.GetUserByUsername(c.Request.Context(), req.Username)
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "user not found"})
+	}
+	if user.ID == app.UserID {
+		return c.JSON(400, map[string]string{"error": "the owner of the app cannot be a collaborator"})
+	}
+
+	by := actor.From(c)
+	collaborator, err := queries.CreateAppCollaborator(c.Request.Context(), db.CreateAppCollaboratorParams{
+		AppID:   app.ID,
+		UserID:  user.ID,
+		Role:    req.Role,
+		AddedBy: by.User(),
+	})
+	if err != nil {
+		return c.JSON(409, map[string]string{"error": user.Username + " already collaborates on the app"})
+	}
+
+	details, _ := json.Marshal(map[string]any{
+		"username": user.Username,
+		"role":     collaborator.Role,
+	})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:     by.User(),
+		AppID:      pgtype.UUID{Bytes: app.ID, Valid: true},
+		Action:     "collaborator.added",
+		Details:    details,
+		IpAddress:  clientIP(c),
+		ApiTokenID: by.Token(),
+	})
+
+	return c.JSON(201, CollaboratorResponse{
+		UserID:    user.ID.String(),
+		Username:  user.Username,
+		Role:      collaborator.Role,
+		CreatedAt: collaborator.CreatedAt,
+		UpdatedAt: collaborator.UpdatedAt,
+	})
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
@@ -0,0 +1,332 @@
+package cron
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appconfig"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+// UpdateCronJobRequest changes only the fields that are present
+type UpdateCronJobRequest struct {
+	Schedule                *string `json:"schedule"`
+	Command                 *string `json:"command"`
+	Timezone                *string `json:"timezone"`
+	ConcurrencyPolicy       *string `json:"concurrency_policy"`
+	StartingDeadlineSeconds *int64  `json:"starting_deadline_seconds"`
+	ActiveDeadlineSeconds   *int64  `json:"active_deadline_seconds"`
+	SuccessfulHistoryLimit  *int32  `json:"successful_history_limit"`
+	FailedHistoryLimit      *int32  `json:"failed_history_limit"`
+	Suspended               *bool   `json:"suspended"`
+
+	// Clear deadlines explicitly, since a missing field keeps the current value
+	ClearStartingDeadline bool `json:"clear_starting_deadline"`
+	ClearActiveDeadline   bool `json:"clear_active_deadline"`
+}
+
+type CronJobResponse struct {
+	Name                    string    `json:"name"`
+	Schedule                string    `json:"schedule"`
+	Command                 string    `json:"command"`
+	Timezone                string    `json:"timezone"`
+	ConcurrencyPolicy       string    `json:"concurrency_policy"`
+	StartingDeadlineSeconds *int64    `json:"starting_deadline_seconds"`
+	ActiveDeadlineSeconds   *int64    `json:"active_deadline_seconds"`
+	SuccessfulHistoryLimit  int32     `json:"successful_history_limit"`
+	FailedHistoryLimit      int32     `json:"failed_history_limit"`
+	Suspended               bool      `json:"suspended"`
+	Synced                  *bool     `json:"synced,omitempty"`
+	CreatedAt               time.Time `json:"created_at"`
+	UpdatedAt               time.Time `json:"updated_at"`
+}
+
+// Get returns a single cron job
+// GET /api/apps/{name}/crons/{cron}
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+	cronName := c.Param("cron")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	cronJob, err := queries.GetCronJobByName(c.Request.Context(), db.GetCronJobByNameParams{
+		AppID: app.ID,
+		Name:  cronName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "cron job not found"})
+	}
+
+	return c.JSON(200, toCronJobResponse(cronJob))
+}
+
+// Put updates the schedule, timezone, overlap policy, deadlines or history
+// retention of a cron job
+// PUT /api/apps/{name}/crons/{cron}
+// Body: { "schedule": "*/15 * * * *", "concurrency_policy": "replace", "successful_history_limit": 5 }
+func Put(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+	cronName := c.Param("cron")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req UpdateCronJobRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	current, err := queries.GetCronJobByName(c.Request.Context(), db.GetCronJobByNameParams{
+		AppID: app.ID,
+		Name:  cronName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "cron job not found"})
+	}
+
+	updated := applyUpdate(current, req)
+	if err := k8s.ValidateCronJob(app.Name, toCronJobConfig(updated)); err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	cronJob, err := queries.UpdateCronJob(c.Request.Context(), db.UpdateCronJobParams{
+		AppID:                   app.ID,
+		Name:                    current.Name,
+		Schedule:                updated.Schedule,
+		Command:                 updated.Command,
+		Timezone:                updated.Timezone,
+		ConcurrencyPolicy:       updated.ConcurrencyPolicy,
+		StartingDeadlineSeconds: updated.StartingDeadlineSeconds,
+		ActiveDeadlineSeconds:   updated.ActiveDeadlineSeconds,
+		SuccessfulHistoryLimit:  updated.SuccessfulHistoryLimit,
+		FailedHistoryLimit:      updated.FailedHistoryLimit,
+		Suspended:               updated.Suspended,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to update cron job"})
+	}
+
+	synced, err := syncCronJob(c.Request.Context(), services.From(c), queries, app, cronJob)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "cron job saved but failed to schedule: " + err.Error()})
+	}
+
+	response := toCronJobResponse(cronJob)
+	response.Synced = &synced
+
+	return c.JSON(200, response)
+}
+
+// Delete removes a cron job and its retained runs
+// DELETE /api/apps/{name}/crons/{cron}
+func Delete(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+	cronName := c.Param("cron")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	cronJob, err := queries.GetCronJobByName(c.Request.Context(), db.GetCronJobByNameParams{
+		AppID: app.ID,
+		Name:  cronName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "cron job not found"})
+	}
+
+	// Never deployed apps have nothing scheduled in the cluster
+	if app.CurrentDeploymentID.Valid {
+		k8sClient, err := services.From(c).Cluster(app.Region)
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "kubernetes not available"})
+		}
+
+		if err := k8sClient.DeleteCronJob(c.Request.Context(), app.Name, cronJob.Name); err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to delete cron job from cluster"})
+		}
+	}
+
+	if err := queries.DeleteCronJob(c.Request.Context(), db.DeleteCronJobParams{
+		AppID: app.ID,
+		Name:  cronJob.Name,
+	}); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to delete cron job"})
+	}
+
+	return c.NoContent()
+}
+
+func applyUpdate(cj db.CronJob, req UpdateCronJobRequest) db.CronJob {
+	if req.Schedule != nil {
+		cj.Schedule = strings.TrimSpace(*req.Schedule)
+	}
+	if req.Command != nil {
+		cj.Command = strings.TrimSpace(*req.Command)
+	}
+	if req.Timezone != nil {
+		cj.Timezone = strings.TrimSpace(*req.Timezone)
+		if cj.Timezone == "" {
+			cj.Timezone = "UTC"
+		}
+	}
+	if req.ConcurrencyPolicy != nil {
+		cj.ConcurrencyPolicy = strings.ToLower(strings.TrimSpace(*req.ConcurrencyPolicy))
+		if cj.ConcurrencyPolicy == "" {
+			cj.ConcurrencyPolicy = k8s.CronConcurrencyAllow
+		}
+	}
+	if req.StartingDeadlineSeconds != nil {
+		cj.StartingDeadlineSeconds = req.StartingDeadlineSeconds
+	}
+	if req.ClearStartingDeadline {
+		cj.StartingDeadlineSeconds = nil
+	}
+	if req.ActiveDeadlineSeconds != nil {
+		cj.ActiveDeadlineSeconds = req.ActiveDeadlineSeconds
+	}
+	if req.ClearActiveDeadline {
+		cj.ActiveDeadlineSeconds = nil
+	}
+	if req.SuccessfulHistoryLimit != nil {
+		cj.SuccessfulHistoryLimit = *req.SuccessfulHistoryLimit
+	}
+	if req.FailedHistoryLimit != nil {
+		cj.FailedHistoryLimit = *req.FailedHistoryLimit
+	}
+	if req.Suspended != nil {
+		cj.Suspended = *req.Suspended
+	}
+	return cj
+}
+
+// syncCronJob applies a cron job to the cluster using the image of the app's
+// current deployment. It reports false when the app has not been deployed yet.
+func syncCronJob(ctx context.Context, svc *services.Services, queries *db.Queries, app db.App, cronJob db.CronJob) (bool, error) {
+	if !app.CurrentDeploymentID.Valid {
+		return false, nil
+	}
+
+	deployment, err := queries.GetDeploymentByID(ctx, app.CurrentDeploymentID.Bytes)
+	if err != nil {
+		return false, nil
+	}
+
+	k8sClient, err := svc.Cluster(app.Region)
+	if err != nil {
+		return false, err
+	}
+
+	labels, err := appconfig.Labels(app)
+	if err != nil {
+		return false, err
+	}
+
+	appConfig := &k8s.AppConfig{
+		Name:   app.Name,
+		Image:  deployment.Image,
+		Labels: labels,
+	}
+	if err := k8sClient.ApplyCronJob(ctx, appConfig, toCronJobConfig(cronJob)); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func toCronJobConfig(cj db.CronJob) *k8s.CronJobConfig {
+	return &k8s.CronJobConfig{
+		Name:                    cj.Name,
+		Schedule:                cj.Schedule,
+		Command:                 cj.Command,
+		TimeZone:                cj.Timezone,
+		ConcurrencyPolicy:       cj.ConcurrencyPolicy,
+		StartingDeadlineSeconds: cj.StartingDeadlineSeconds,
+		ActiveDeadlineSeconds:   cj.ActiveDeadlineSeconds,
+		SuccessfulHistoryLimit:  cj.SuccessfulHistoryLimit,
+		FailedHistoryLimit:      cj.FailedHistoryLimit,
+		Suspended:               cj.Suspended,
+	}
+}
+
+func toCronJobResponse(cj db.CronJob) CronJobResponse {
+	return CronJobResponse{
+		Name:                    cj.Name,
+		Schedule:                cj.Schedule,
+		Command:                 cj.Command,
+		Timezone:                cj.Timezone,
+		ConcurrencyPolicy:       cj.ConcurrencyPolicy,
+		StartingDeadlineSeconds: cj.StartingDeadlineSeconds,
+		ActiveDeadlineSeconds:   cj.ActiveDeadlineSeconds,
+		SuccessfulHistoryLimit:  cj.SuccessfulHistoryLimit,
+		FailedHistoryLimit:      cj.FailedHistoryLimit,
+		Suspended:               cj.Suspended,
+		CreatedAt:               cj.CreatedAt,
+		UpdatedAt:               cj.UpdatedAt,
+	}
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
@@ -0,0 +1,90 @@
+package runs
+
+import (
+	"strconv"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+const maxLogLines = 500
+
+type RunsResponse struct {
+	Runs []k8s.CronRun `json:"runs"`
+}
+
+// Get returns the retained runs of a cron job, newest first. How many runs
+// are kept is set by the cron job's history limits.
+// GET /api/apps/{name}/crons/{cron}/runs
+// Query params:
+//   - logs: number of log lines to include per run (default 0, max 500)
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+	cronName := c.Param("cron")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	cronJob, err := queries.GetCronJobByName(c.Request.Context(), db.GetCronJobByNameParams{
+		AppID: app.ID,
+		Name:  cronName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "cron job not found"})
+	}
+
+	logLines := int64(0)
+	if l := c.Query("logs"); l != "" {
+		if parsed, err := strconv.ParseInt(l, 10, 64); err == nil && parsed > 0 {
+			logLines = min(parsed, maxLogLines)
+		}
+	}
+
+	k8sClient, err := services.From(c).Cluster(app.Region)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "kubernetes not available"})
+	}
+
+	runs, err := k8sClient.ListCronRuns(c.Request.Context(), app.Name, cronJob.Name, logLines)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(200, RunsResponse{Runs: runs})
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
@@ -0,0 +1,271 @@
+package crons
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appconfig"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultTimezone               = "UTC"
+	defaultSuccessfulHistoryLimit = 3
+	defaultFailedHistoryLimit     = 1
+	maxCronJobsPerApp             = 20
+)
+
+type CreateCronJobRequest struct {
+	Name                    string `json:"name"`
+	Schedule                string `json:"schedule"`
+	Command                 string `json:"command"`
+	Timezone                string `json:"timezone"`
+	ConcurrencyPolicy       string `json:"concurrency_policy"`
+	StartingDeadlineSeconds *int64 `json:"starting_deadline_seconds"`
+	ActiveDeadlineSeconds   *int64 `json:"active_deadline_seconds"`
+	SuccessfulHistoryLimit  *int32 `json:"successful_history_limit"`
+	FailedHistoryLimit      *int32 `json:"failed_history_limit"`
+	Suspended               bool   `json:"suspended"`
+}
+
+type CronJobResponse struct {
+	Name                    string    `json:"name"`
+	Schedule                string    `json:"schedule"`
+	Command                 string    `json:"command"`
+	Timezone                string    `json:"timezone"`
+	ConcurrencyPolicy       string    `json:"concurrency_policy"`
+	StartingDeadlineSeconds *int64    `json:"starting_deadline_seconds"`
+	ActiveDeadlineSeconds   *int64    `json:"active_deadline_seconds"`
+	SuccessfulHistoryLimit  int32     `json:"successful_history_limit"`
+	FailedHistoryLimit      int32     `json:"failed_history_limit"`
+	Suspended               bool      `json:"suspended"`
+	Synced                  *bool     `json:"synced,omitempty"`
+	CreatedAt               time.Time `json:"created_at"`
+	UpdatedAt               time.Time `json:"updated_at"`
+}
+
+// Get lists the cron jobs of an app
+// GET /api/apps/{name}/crons
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	cronJobs, err := queries.ListCronJobsByApp(c.Request.Context(), app.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list cron jobs"})
+	}
+
+	response := make([]CronJobResponse, len(cronJobs))
+	for i, cj := range cronJobs {
+		response[i] = toCronJobResponse(cj)
+	}
+
+	return c.JSON(200, map[string]any{
+		"cron_jobs": response,
+	})
+}
+
+// Post creates a cron job for an app. It is scheduled right away when the app
+// has a running deployment, otherwise on the next deploy.
+// POST /api/apps/{name}/crons
+// Body: { "name": "cleanup", "schedule": "0 3 * * *", "command": "bin/cleanup", "timezone": "Europe/Berlin", "concurrency_policy": "forbid" }
+func Post(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req CreateCronJobRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+
+	params := db.CreateCronJobParams{
+		Name:                    strings.TrimSpace(req.Name),
+		Schedule:                strings.TrimSpace(req.Schedule),
+		Command:                 strings.TrimSpace(req.Command),
+		Timezone:                strings.TrimSpace(req.Timezone),
+		ConcurrencyPolicy:       strings.ToLower(strings.TrimSpace(req.ConcurrencyPolicy)),
+		StartingDeadlineSeconds: req.StartingDeadlineSeconds,
+		ActiveDeadlineSeconds:   req.ActiveDeadlineSeconds,
+		SuccessfulHistoryLimit:  defaultSuccessfulHistoryLimit,
+		FailedHistoryLimit:      defaultFailedHistoryLimit,
+		Suspended:               req.Suspended,
+	}
+
+	if params.Timezone == "" {
+		params.Timezone = defaultTimezone
+	}
+	if params.ConcurrencyPolicy == "" {
+		params.ConcurrencyPolicy = k8s.CronConcurrencyAllow
+	}
+	if req.SuccessfulHistoryLimit != nil {
+		params.SuccessfulHistoryLimit = *req.SuccessfulHistoryLimit
+	}
+	if req.FailedHistoryLimit != nil {
+		params.FailedHistoryLimit = *req.FailedHistoryLimit
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	if err := k8s.ValidateCronJob(app.Name, toCronJobConfig(db.CronJob{
+		Name:                    params.Name,
+		Schedule:                params.Schedule,
+		Command:                 params.Command,
+		Timezone:                params.Timezone,
+		ConcurrencyPolicy:       params.ConcurrencyPolicy,
+		StartingDeadlineSeconds: params.StartingDeadlineSeconds,
+		ActiveDeadlineSeconds:   params.ActiveDeadlineSeconds,
+		SuccessfulHistoryLimit:  params.SuccessfulHistoryLimit,
+		FailedHistoryLimit:      params.FailedHistoryLimit,
+	})); err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	existing, err := queries.ListCronJobsByApp(c.Request.Context(), app.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list cron jobs"})
+	}
+	if len(existing) >= maxCronJobsPerApp {
+		return c.JSON(400, map[string]string{"error": "cron job limit reached for this app"})
+	}
+	for _, cj := range existing {
+		if cj.Name == params.Name {
+			return c.JSON(409, map[string]string{"error": "cron job already exists"})
+		}
+	}
+
+	params.AppID = app.ID
+	cronJob, err := queries.CreateCronJob(c.Request.Context(), params)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to create cron job"})
+	}
+
+	synced, err := syncCronJob(c.Request.Context(), services.From(c), queries, app, cronJob)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "cron job saved but failed to schedule: " + err.Error()})
+	}
+
+	response := toCronJobResponse(cronJob)
+	response.Synced = &synced
+
+	return c.JSON(201, response)
+}
+
+// syncCronJob applies a cron job to the cluster using the image of the app's
+// current deployment. It reports false when the app has not been deployed yet.
+func syncCronJob(ctx context.Context, svc *services.Services, queries *db.Queries, app db.App, cronJob db.CronJob) (bool, error) {
+	if !app.CurrentDeploymentID.Valid {
+		return false, nil
+	}
+
+	deployment, err := queries.GetDeploymentByID(ctx, app.CurrentDeploymentID.Bytes)
+	if err != nil {
+		return false, nil
+	}
+
+	k8sClient, err := svc.Cluster(app.Region)
+	if err != nil {
+		return false, err
+	}
+
+	labels, err := appconfig.Labels(app)
+	if err != nil {
+		return false, err
+	}
+
+	appConfig := &k8s.AppConfig{
+		Name:   app.Name,
+		Image:  deployment.Image,
+		Labels: labels,
+	}
+	if err := k8sClient.ApplyCronJob(ctx, appConfig, toCronJobConfig(cronJob)); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func toCronJobConfig(cj db.CronJob) *k8s.CronJobConfig {
+	return &k8s.CronJobConfig{
+		Name:                    cj.Name,
+		Schedule:                cj.Schedule,
+		Command:                 cj.Command,
+		TimeZone:                cj.Timezone,
+		ConcurrencyPolicy:       cj.ConcurrencyPolicy,
+		StartingDeadlineSeconds: cj.StartingDeadlineSeconds,
+		ActiveDeadlineSeconds:   cj.ActiveDeadlineSeconds,
+		SuccessfulHistoryLimit:  cj.SuccessfulHistoryLimit,
+		FailedHistoryLimit:      cj.FailedHistoryLimit,
+		Suspended:               cj.Suspended,
+	}
+}
+
+func toCronJobResponse(cj db.CronJob) CronJobResponse {
+	return CronJobResponse{
+		Name:                    cj.Name,
+		Schedule:                cj.Schedule,
+		Command:                 cj.Command,
+		Timezone:                cj.Timezone,
+		ConcurrencyPolicy:       cj.ConcurrencyPolicy,
+		StartingDeadlineSeconds: cj.StartingDeadlineSeconds,
+		ActiveDeadlineSeconds:   cj.ActiveDeadlineSeconds,
+		SuccessfulHistoryLimit:  cj.SuccessfulHistoryLimit,
+		FailedHistoryLimit:      cj.FailedHistoryLimit,
+		Suspended:               cj.Suspended,
+		CreatedAt:               cj.CreatedAt,
+		UpdatedAt:               cj.UpdatedAt,
+	}
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"encoding/json"
+	"net/netip"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appconfig"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Delete unlinks a database add-on from an app. A deployed app loses the
+// variables right away, its pods rolled.
+// DELETE /api/apps/{name}/databases/{addon}
+func Delete(c *fuego.Context) error {
+	svc := services.From(c)
+	cfg := svc.Config
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(svc.DB)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   c.Param("name"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	addon, err := queries.GetDatabaseAddonByName(c.Request.Context(), db.GetDatabaseAddonByNameParams{
+		UserID: userID,
+		Name:   c.Param("addon"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "database add-on not found"})
+	}
+
+	unlinked, err := queries.UnlinkDatabaseAddon(c.Request.Context(), db.UnlinkDatabaseAddonParams{
+		AppID:   app.ID,
+		AddonID: addon.ID,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to unlink database add-on"})
+	}
+	if unlinked == 0 {
+		return c.JSON(404, map[string]string{"error": "database add-on is not linked to this app"})
+	}
+
+	synced := false
+	if app.CurrentDeploymentID.Valid {
+		if err := appconfig.ApplyEnv(c.Request.Context(), svc.Config, queries, svc.Kubernetes, app); err != nil {
+			return c.JSON(500, map[string]string{"error": "database add-on unlinked but failed to apply: " + err.Error()})
+		}
+		synced = true
+	}
+
+	details, _ := json.Marshal(map[string]any{"addon": addon.Name, "synced": synced})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		AppID:     pgtype.UUID{Bytes: app.ID, Valid: true},
+		Action:    "database_addon.unlinked",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(200, map[string]any{"message": "database add-on unlinked", "synced": synced})
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
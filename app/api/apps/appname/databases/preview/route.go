@@ -0,0 +1,127 @@
+package preview
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/cryptoutil"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/dbaddon"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+type PreviewResponse struct {
+	Variables []dbaddon.PreviewVar `json:"variables"`
+	Count     int                  `json:"count"`
+}
+
+// Get previews the env vars the database add-ons linked to an app inject,
+// passwords masked, flagging those the app's own env vars override. Given
+// an addon, with an optional prefix, comma-separated variables and pooling,
+// it previews the app as if that link were made.
+// GET /api/apps/{name}/databases/preview?addon=main&prefix=ANALYTICS_&variables=DATABASE_URL&pooling=true
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   c.Param("name"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	links, err := dbaddon.Links(c.Request.Context(), cfg, queries, app.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to load database add-ons"})
+	}
+
+	if name := c.Query("addon"); name != "" {
+		prefix := c.Query("prefix")
+		var picked []string
+		if raw := c.Query("variables"); raw != "" {
+			picked = strings.Split(raw, ",")
+		}
+		variables, err := dbaddon.NormalizeLink(prefix, picked)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": err.Error()})
+		}
+
+		addon, err := queries.GetDatabaseAddonByName(c.Request.Context(), db.GetDatabaseAddonByNameParams{
+			UserID: userID,
+			Name:   name,
+		})
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "database add-on not found"})
+		}
+		creds, err := dbaddon.Decrypt(cfg, addon)
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to decrypt password"})
+		}
+
+		// The previewed link replaces the add-on's current one, as linking
+		// it again would
+		kept := links[:0]
+		for _, link := range links {
+			if link.Addon != addon.Name {
+				kept = append(kept, link)
+			}
+		}
+		links = append(kept, dbaddon.Link{
+			Addon:       addon.Name,
+			Prefix:      prefix,
+			Variables:   variables,
+			Pooling:     c.Query("pooling") == "true",
+			Credentials: creds,
+		})
+		// Links are ordered as they are listed, for ports to match
+		slices.SortFunc(links, func(a, b dbaddon.Link) int {
+			return cmp.Or(strings.Compare(a.Prefix, b.Prefix), strings.Compare(a.Addon, b.Addon))
+		})
+		dbaddon.AssignPoolerPorts(links)
+	}
+
+	own, err := cryptoutil.Decrypt(app.EnvVarsEncrypted, cfg.EncryptionKey)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to decrypt environment variables"})
+	}
+
+	variables := dbaddon.Preview(links, own)
+	if variables == nil {
+		variables = []dbaddon.PreviewVar{}
+	}
+	return c.JSON(200, PreviewResponse{
+		Variables: variables,
+		Count:     len(variables),
+	})
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
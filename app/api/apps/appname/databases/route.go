@@ -0,0 +1,214 @@
+package databases
+
+import (
+	"encoding/json"
+	"net/netip"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appconfig"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/dbaddon"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type LinkRequest struct {
+	Addon string `json:"addon"`
+	// Prefix is prepended to every variable, such as ANALYTICS_ for
+	// ANALYTICS_DATABASE_URL
+	Prefix string `json:"prefix,omitempty"`
+	// Variables picks the variables to inject; all of them by default
+	Variables []string `json:"variables,omitempty"`
+	// Pooling routes the app's connections through a PgBouncer sidecar in
+	// transaction pooling mode, opening at most PoolSize connections per pod
+	Pooling  bool  `json:"pooling,omitempty"`
+	PoolSize int32 `json:"pool_size,omitempty"`
+}
+
+type LinkResponse struct {
+	Addon     string    `json:"addon"`
+	Prefix    string    `json:"prefix"`
+	Variables []string  `json:"variables"`
+	Pooling   bool      `json:"pooling"`
+	PoolSize  int32     `json:"pool_size,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Synced    *bool     `json:"synced,omitempty"`
+}
+
+// Get lists the database add-ons linked to an app
+// GET /api/apps/{name}/databases
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   c.Param("name"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	links, err := queries.ListAppDatabaseLinks(c.Request.Context(), app.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list database add-ons"})
+	}
+
+	response := make([]LinkResponse, 0, len(links))
+	for _, link := range links {
+		response = append(response, newLinkResponse(link.Name, db.AppDatabaseLink{
+			Prefix:    link.Prefix,
+			Variables: link.Variables,
+			Pooling:   link.Pooling,
+			PoolSize:  link.PoolSize,
+			CreatedAt: link.CreatedAt,
+		}))
+	}
+	return c.JSON(200, response)
+}
+
+// Post links a database add-on to an app, or changes the prefix, variables
+// and pooling of a link. A deployed app gets the variables right away, its
+// pods rolled to pick them up.
+// POST /api/apps/{name}/databases
+// Body: { "addon": "main", "prefix": "", "variables": ["DATABASE_URL"], "pooling": true, "pool_size": 10 }
+func Post(c *fuego.Context) error {
+	svc := services.From(c)
+	cfg := svc.Config
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req LinkRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+	variables, err := dbaddon.NormalizeLink(req.Prefix, req.Variables)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	poolSize, err := dbaddon.ValidatePoolSize(req.PoolSize)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	queries := db.New(svc.DB)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   c.Param("name"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	addon, err := queries.GetDatabaseAddonByName(c.Request.Context(), db.GetDatabaseAddonByNameParams{
+		UserID: userID,
+		Name:   req.Addon,
+	})
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "database add-on not found"})
+	}
+
+	// Two add-ons under one prefix would render the same variables
+	existing, err := queries.ListAppDatabaseLinks(c.Request.Context(), app.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list database add-ons"})
+	}
+	for _, link := range existing {
+		if link.Prefix == req.Prefix && link.AddonID != addon.ID {
+			return c.JSON(409, map[string]string{"error": "add-on " + link.Name + " is already linked with this prefix, choose another prefix"})
+		}
+	}
+
+	link, err := queries.LinkDatabaseAddon(c.Request.Context(), db.LinkDatabaseAddonParams{
+		AppID:     app.ID,
+		AddonID:   addon.ID,
+		Prefix:    req.Prefix,
+		Variables: variables,
+		Pooling:   req.Pooling,
+		PoolSize:  poolSize,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to link database add-on"})
+	}
+
+	synced := false
+	if app.CurrentDeploymentID.Valid {
+		if err := appconfig.ApplyEnv(c.Request.Context(), svc.Config, queries, svc.Kubernetes, app); err != nil {
+			return c.JSON(500, map[string]string{"error": "database add-on linked but failed to apply: " + err.Error()})
+		}
+		synced = true
+	}
+
+	details, _ := json.Marshal(map[string]any{
+		"addon":     addon.Name,
+		"prefix":    link.Prefix,
+		"variables": link.Variables,
+		"pooling":   link.Pooling,
+		"synced":    synced,
+	})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		AppID:     pgtype.UUID{Bytes: app.ID, Valid: true},
+		Action:    "database_addon.linked",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	response := newLinkResponse(addon.Name, link)
+	response.Synced = &synced
+	return c.JSON(200, response)
+}
+
+func newLinkResponse(addon string, link db.AppDatabaseLink) LinkResponse {
+	response := LinkResponse{
+		Addon:     addon,
+		Prefix:    link.Prefix,
+		Variables: link.Variables,
+		Pooling:   link.Pooling,
+		CreatedAt: link.CreatedAt,
+	}
+	if link.Pooling {
+		response.PoolSize = link.PoolSize
+	}
+	return response
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
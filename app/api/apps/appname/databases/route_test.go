@@ -0,0 +1,28 @@
+package databases
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/testutil"
+	"github.com/google/uuid"
+)
+
+func TestPost_Validation(t *testing.T) {
+	ta := testutil.NewTestApp().WithAuth(uuid.New(), "octocat")
+	ta.App.Post("/api/apps/shop/databases", Post)
+	ta.App.Mount()
+
+	post := func(body LinkRequest) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		ta.App.ServeHTTP(w, testutil.MakeRequest(t, http.MethodPost, "/api/apps/shop/databases", body, nil))
+		return w
+	}
+
+	// Requests are rejected before the database is reached
+	testutil.AssertStatusCode(t, post(LinkRequest{Addon: "main", Prefix: "analytics_"}), http.StatusBadRequest)
+	testutil.AssertStatusCode(t, post(LinkRequest{Addon: "main", Variables: []string{"MYSQL_HOST"}}), http.StatusBadRequest)
+	testutil.AssertStatusCode(t, post(LinkRequest{Addon: "main", Pooling: true, PoolSize: 500}), http.StatusBadRequest)
+}
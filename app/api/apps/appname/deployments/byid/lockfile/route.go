@@ -0,0 +1,82 @@
+package lockfile
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/deploylock"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+// Get downloads the signed lockfile of a deployment: the image digest, env
+// version and manifest hashes it was deployed with.
+// GET /api/apps/{name}/deployments/{id}/lockfile
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	depID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "invalid deployment id"})
+	}
+
+	deployment, err := queries.GetDeploymentByID(c.Request.Context(), depID)
+	if err != nil || deployment.AppID != app.ID {
+		return c.JSON(404, map[string]string{"error": "deployment not found"})
+	}
+
+	// Deployments made before lockfiles were recorded have none
+	lock, err := deploylock.Load(c.Request.Context(), queries, deployment.ID)
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "deployment has no lockfile"})
+	}
+
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to render lockfile"})
+	}
+
+	c.Response.Header().Set("Content-Type", "application/json")
+	c.Response.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-v%d.lock.json"`, app.Name, deployment.Version))
+	c.Response.WriteHeader(200)
+	_, err = c.Response.Write(append(data, '\n'))
+	return err
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
@@ -0,0 +1,109 @@
+package verify
+
+import (
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/deploylock"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+type VerifyResponse struct {
+	// Valid is set when the lockfile is signed by the platform and matches
+	// what it recorded for the deployment
+	Valid          bool                    `json:"valid"`
+	SignatureValid bool                    `json:"signature_valid"`
+	MatchesRecord  bool                    `json:"matches_record"`
+	Differences    []deploylock.Difference `json:"differences"`
+	// Drift lists how what the app would deploy now differs from the
+	// lockfile, for the app's current deployment only
+	Drift []deploylock.Difference `json:"drift,omitempty"`
+}
+
+// Post verifies a lockfile downloaded from the lockfile endpoint against the
+// one recorded for the deployment.
+// POST /api/apps/{name}/deployments/{id}/lockfile/verify
+func Post(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var lock deploylock.Lockfile
+	if err := reqbody.Bind(c, &lock); err != nil {
+		return reqbody.Reject(c, err)
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	depID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "invalid deployment id"})
+	}
+
+	deployment, err := queries.GetDeploymentByID(c.Request.Context(), depID)
+	if err != nil || deployment.AppID != app.ID {
+		return c.JSON(404, map[string]string{"error": "deployment not found"})
+	}
+
+	stored, err := deploylock.Load(c.Request.Context(), queries, deployment.ID)
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "deployment has no lockfile"})
+	}
+
+	resp := VerifyResponse{
+		SignatureValid: deploylock.VerifySignature(lock, cfg.SigningKey()),
+		Differences:    deploylock.Compare(stored, lock),
+	}
+	if lock.DeploymentID != stored.DeploymentID {
+		resp.Differences = append(resp.Differences, deploylock.Difference{
+			Field: "deployment_id",
+			Want:  stored.DeploymentID.String(),
+			Got:   lock.DeploymentID.String(),
+		})
+	}
+	resp.MatchesRecord = len(resp.Differences) == 0
+	resp.Valid = resp.SignatureValid && resp.MatchesRecord
+
+	if app.CurrentDeploymentID.Valid && uuid.UUID(app.CurrentDeploymentID.Bytes) == deployment.ID {
+		current, err := deploylock.Current(c.Request.Context(), cfg, queries, app, deployment)
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to compute current configuration"})
+		}
+		resp.Drift = deploylock.Compare(stored, current)
+	}
+
+	return c.JSON(200, resp)
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
@@ -1,34 +1,63 @@
 package id
 
 import (
-	"context"
+	"errors"
 	"time"
 
 	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/actor"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appstatus"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/concurrency"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/deploylock"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/machineuser"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/events"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/provenance"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/rollout"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
 	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type DeploymentResponse struct {
-	ID        string     `json:"id"`
-	AppID     string     `json:"app_id"`
-	Version   int        `json:"version"`
-	Image     string     `json:"image"`
-	Status    string     `json:"status"`
-	Message   *string    `json:"message,omitempty"`
-	Error     *string    `json:"error,omitempty"`
-	CreatedAt time.Time  `json:"created_at"`
-	StartedAt *time.Time `json:"started_at,omitempty"`
-	ReadyAt   *time.Time `json:"ready_at,omitempty"`
+	ID            string     `json:"id"`
+	AppID         string     `json:"app_id"`
+	Version       int        `json:"version"`
+	Image         string     `json:"image"`
+	Status        string     `json:"status"`
+	Message       *string    `json:"message,omitempty"`
+	Error         *string    `json:"error,omitempty"`
+	FailureReason *string    `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	ReadyAt       *time.Time `json:"ready_at,omitempty"`
+	// DeployedBy is who made the deployment, such as "octocat via token
+	// ci", or a summary of Provenance, such as "CI run #123"
+	DeployedBy        string                 `json:"deployed_by,omitempty"`
+	DeployedByUserID  *uuid.UUID             `json:"deployed_by_user_id,omitempty"`
+	DeployedByTokenID *uuid.UUID             `json:"deployed_by_token_id,omitempty"`
+	Provenance        *provenance.Provenance `json:"provenance,omitempty"`
+
+	Hooks []HookRunResponse `json:"hooks,omitempty"`
+}
+
+// HookRunResponse is a pre/post deploy hook execution attached to a deployment
+type HookRunResponse struct {
+	Phase      string     `json:"phase"`
+	Command    string     `json:"command"`
+	JobName    string     `json:"job_name"`
+	Status     string     `json:"status"`
+	Output     *string    `json:"output,omitempty"`
+	Error      *string    `json:"error,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
 }
 
 func Get(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
 	appName := c.Param("name")
 	deploymentID := c.Param("id")
 
@@ -38,7 +67,7 @@ func Get(c *fuego.Context) error {
 	}
 
 	queries := db.New(pool)
-	app, err := queries.GetAppByName(context.Background(), db.GetAppByNameParams{
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
 		UserID: userID,
 		Name:   appName,
 	})
@@ -51,7 +80,7 @@ func Get(c *fuego.Context) error {
 		return c.JSON(400, map[string]string{"error": "invalid deployment id"})
 	}
 
-	deployment, err := queries.GetDeploymentByID(context.Background(), depID)
+	deployment, err := queries.GetDeploymentByID(c.Request.Context(), depID)
 	if err != nil {
 		return c.JSON(404, map[string]string{"error": "deployment not found"})
 	}
@@ -60,12 +89,27 @@ func Get(c *fuego.Context) error {
 		return c.JSON(404, map[string]string{"error": "deployment not found"})
 	}
 
-	return c.JSON(200, toDeploymentResponse(deployment))
+	resp := toDeploymentResponse(deployment)
+
+	if record, err := queries.GetDeploymentProvenance(c.Request.Context(), deployment.ID); err == nil {
+		p := provenance.FromRecord(record)
+		resp.DeployedBy = p.DeployedBy()
+		resp.Provenance = &p
+	}
+
+	runs, err := queries.ListDeploymentHookRuns(c.Request.Context(), deployment.ID)
+	if err == nil {
+		for _, run := range runs {
+			resp.Hooks = append(resp.Hooks, toHookRunResponse(run))
+		}
+	}
+
+	return c.JSON(200, resp)
 }
 
 func Post(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
 	appName := c.Param("name")
 	deploymentID := c.Param("id")
 
@@ -75,7 +119,7 @@ func Post(c *fuego.Context) error {
 	}
 
 	queries := db.New(pool)
-	app, err := queries.GetAppByName(context.Background(), db.GetAppByNameParams{
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
 		UserID: userID,
 		Name:   appName,
 	})
@@ -88,7 +132,7 @@ func Post(c *fuego.Context) error {
 		return c.JSON(400, map[string]string{"error": "invalid deployment id"})
 	}
 
-	deployment, err := queries.GetDeploymentByID(context.Background(), depID)
+	deployment, err := queries.GetDeploymentByID(c.Request.Context(), depID)
 	if err != nil {
 		return c.JSON(404, map[string]string{"error": "deployment not found"})
 	}
@@ -97,30 +141,80 @@ func Post(c *fuego.Context) error {
 		return c.JSON(404, map[string]string{"error": "deployment not found"})
 	}
 
-	newDeployment, err := queries.CreateDeployment(context.Background(), db.CreateDeploymentParams{
-		AppID:   app.ID,
-		Version: deployment.Version + 1,
-		Image:   deployment.Image,
-		Status:  "pending",
+	if !appstatus.CanTransition(app.Status, db.AppStatusDeploying) {
+		return c.JSON(409, map[string]string{"error": "app cannot be deployed while " + string(app.Status)})
+	}
+
+	limiter := services.From(c).Concurrency
+	release, err := limiter.Acquire(c.Request.Context(), concurrency.Deploy, app.UserID)
+	if err != nil {
+		return concurrency.Reject(c, err)
+	}
+	// The slot is held until the rollout ends, or freed with the response
+	// when no rollout starts
+	rollingOut := false
+	defer func() {
+		if !rollingOut {
+			release()
+		}
+	}()
+
+	if err := machineuser.CheckDeploymentQuota(c.Request.Context(), queries, userID, time.Now()); err != nil {
+		if errors.Is(err, machineuser.ErrDeploymentQuota) {
+			return c.JSON(403, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(500, map[string]string{"error": "failed to check quota"})
+	}
+
+	by := actor.From(c)
+	newDeployment, err := queries.CreateDeployment(c.Request.Context(), db.CreateDeploymentParams{
+		AppID:             app.ID,
+		Version:           deployment.Version + 1,
+		Image:             deployment.Image,
+		Status:            "pending",
+		Architectures:     deployment.Architectures,
+		DeployedByUserID:  by.User(),
+		DeployedByTokenID: by.Token(),
+		DeployedBy:        by.String(),
 	})
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": "failed to create rollback deployment"})
 	}
 
-	_, err = queries.IncrementDeploymentCount(context.Background(), app.ID)
+	_, err = queries.IncrementDeploymentCount(c.Request.Context(), app.ID)
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": "failed to update app"})
 	}
 
-	_, err = queries.UpdateAppStatus(context.Background(), db.UpdateAppStatusParams{
-		ID:                  app.ID,
-		Status:              "deploying",
-		CurrentDeploymentID: pgtype.UUID{Bytes: newDeployment.ID, Valid: true},
-	})
+	_, err = appstatus.Set(c.Request.Context(), queries, app, db.AppStatusDeploying, pgtype.UUID{Bytes: newDeployment.ID, Valid: true})
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": "failed to update app status"})
 	}
 
+	if _, err := deploylock.Record(c.Request.Context(), cfg, queries, app, newDeployment); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to record deployment lockfile"})
+	}
+
+	_ = events.Publish(c.Request.Context(), queries, events.Event{
+		Type:    "deployment.rollback",
+		UserID:  userID,
+		TokenID: by.TokenID,
+		AppID:   app.ID,
+		AppName: app.Name,
+		Payload: map[string]any{
+			"deployment_id":  newDeployment.ID,
+			"version":        newDeployment.Version,
+			"image":          newDeployment.Image,
+			"rolled_back_to": deployment.Version,
+		},
+	})
+
+	// Without a reachable cluster the deployment stays pending
+	if k8sClient, err := services.From(c).Cluster(app.Region); err == nil {
+		rollout.Start(cfg, queries, k8sClient, app, newDeployment, release)
+		rollingOut = true
+	}
+
 	return c.JSON(201, toDeploymentResponse(newDeployment))
 }
 
@@ -144,14 +238,25 @@ func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
 
 func toDeploymentResponse(d db.Deployment) DeploymentResponse {
 	resp := DeploymentResponse{
-		ID:        d.ID.String(),
-		AppID:     d.AppID.String(),
-		Version:   int(d.Version),
-		Image:     d.Image,
-		Status:    d.Status,
-		Message:   d.Message,
-		Error:     d.Error,
-		CreatedAt: d.CreatedAt,
+		ID:            d.ID.String(),
+		AppID:         d.AppID.String(),
+		Version:       int(d.Version),
+		Image:         d.Image,
+		Status:        d.Status,
+		Message:       d.Message,
+		Error:         d.Error,
+		FailureReason: d.FailureReason,
+		CreatedAt:     d.CreatedAt,
+		DeployedBy:    d.DeployedBy,
+	}
+
+	if d.DeployedByUserID.Valid {
+		userID := uuid.UUID(d.DeployedByUserID.Bytes)
+		resp.DeployedByUserID = &userID
+	}
+	if d.DeployedByTokenID.Valid {
+		tokenID := uuid.UUID(d.DeployedByTokenID.Bytes)
+		resp.DeployedByTokenID = &tokenID
 	}
 
 	if d.StartedAt.Valid {
@@ -164,3 +269,24 @@ func toDeploymentResponse(d db.Deployment) DeploymentResponse {
 
 	return resp
 }
+
+func toHookRunResponse(r db.DeploymentHookRun) HookRunResponse {
+	resp := HookRunResponse{
+		Phase:   r.Phase,
+		Command: r.Command,
+		JobName: r.JobName,
+		Status:  r.Status,
+		Output:  r.Output,
+		Error:   r.Error,
+	}
+
+	if r.StartedAt.Valid {
+		resp.StartedAt = &r.StartedAt.Time
+	}
+
+	if r.FinishedAt.Valid {
+		resp.FinishedAt = &r.FinishedAt.Time
+	}
+
+	return resp
+}
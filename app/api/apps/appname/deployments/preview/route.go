@@ -0,0 +1,92 @@
+package preview
+
+import (
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appconfig"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+type PreviewResponse struct {
+	Image string `json:"image"`
+	k8s.DeployDiff
+}
+
+// Get compares what runs in the cluster with what deploying an image would
+// apply: the image, env var keys, resources and replicas of every process.
+// Nothing is applied. Without ?image= the current image is redeployed, which
+// previews pending env, formation and resource changes.
+// GET /api/apps/{name}/deployments/preview?image=...
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   c.Param("name"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	image := c.Query("image")
+	if image == "" {
+		if !app.CurrentDeploymentID.Valid {
+			return c.JSON(400, map[string]string{"error": "image is required for apps without a current deployment"})
+		}
+		current, err := queries.GetDeploymentByID(c.Request.Context(), app.CurrentDeploymentID.Bytes)
+		if err != nil {
+			return c.JSON(404, map[string]string{"error": "deployment not found"})
+		}
+		image = current.Image
+	}
+
+	appConfig, err := appconfig.Load(c.Request.Context(), cfg, queries, app, db.Deployment{Image: image})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to load app configuration"})
+	}
+
+	k8sClient, err := services.From(c).Cluster(app.Region)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "kubernetes not available"})
+	}
+	appConfig.Namespace = k8sClient.NamespaceForApp(app.Name)
+
+	live, err := k8sClient.LiveState(c.Request.Context(), app.Name)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(200, PreviewResponse{
+		Image:      image,
+		DeployDiff: k8s.Diff(live, appConfig),
+	})
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
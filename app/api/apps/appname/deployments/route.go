@@ -1,38 +1,67 @@
 package deployments
 
 import (
-	"context"
+	"errors"
+	"net/netip"
 	"time"
 
 	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/actor"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appconfig"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appstatus"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/breakglass"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/concurrency"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/deployfreeze"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/deploylock"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/machineuser"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/events"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/provenance"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/registry"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/rollout"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/tunnel"
 	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type CreateDeploymentRequest struct {
 	Image string `json:"image"`
+	// Emergency breaks the glass to deploy during a deploy freeze, with a
+	// justification recorded in the activity log and sent to the org owner
+	Emergency     bool   `json:"emergency"`
+	Justification string `json:"justification"`
 }
 
 type DeploymentResponse struct {
-	ID        string     `json:"id"`
-	AppID     string     `json:"app_id"`
-	Version   int        `json:"version"`
-	Image     string     `json:"image"`
-	Status    string     `json:"status"`
-	Message   *string    `json:"message,omitempty"`
-	Error     *string    `json:"error,omitempty"`
-	CreatedAt time.Time  `json:"created_at"`
-	StartedAt *time.Time `json:"started_at,omitempty"`
-	ReadyAt   *time.Time `json:"ready_at,omitempty"`
+	ID            string     `json:"id"`
+	AppID         string     `json:"app_id"`
+	Version       int        `json:"version"`
+	Image         string     `json:"image"`
+	Status        string     `json:"status"`
+	Message       *string    `json:"message,omitempty"`
+	Error         *string    `json:"error,omitempty"`
+	FailureReason *string    `json:"failure_reason,omitempty"`
+	Architectures []string   `json:"architectures,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	ReadyAt       *time.Time `json:"ready_at,omitempty"`
+	// DeployedBy is who made the deployment, such as "octocat via token
+	// ci", or a summary of Provenance, such as "CI run #123"
+	DeployedBy        string                 `json:"deployed_by,omitempty"`
+	DeployedByUserID  *uuid.UUID             `json:"deployed_by_user_id,omitempty"`
+	DeployedByTokenID *uuid.UUID             `json:"deployed_by_token_id,omitempty"`
+	Provenance        *provenance.Provenance `json:"provenance,omitempty"`
 }
 
 func Get(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
 	appName := c.Param("name")
 
 	userID, err := getUserID(c, cfg)
@@ -41,7 +70,7 @@ func Get(c *fuego.Context) error {
 	}
 
 	queries := db.New(pool)
-	app, err := queries.GetAppByName(context.Background(), db.GetAppByNameParams{
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
 		UserID: userID,
 		Name:   appName,
 	})
@@ -49,7 +78,7 @@ func Get(c *fuego.Context) error {
 		return c.JSON(404, map[string]string{"error": "app not found"})
 	}
 
-	deployments, err := queries.ListDeploymentsByApp(context.Background(), db.ListDeploymentsByAppParams{
+	deployments, err := queries.ListDeploymentsByApp(c.Request.Context(), db.ListDeploymentsByAppParams{
 		AppID:  app.ID,
 		Limit:  50,
 		Offset: 0,
@@ -58,17 +87,26 @@ func Get(c *fuego.Context) error {
 		return c.JSON(500, map[string]string{"error": "failed to list deployments"})
 	}
 
+	records, _ := queries.ListDeploymentProvenanceByApp(c.Request.Context(), app.ID)
+	provenances := make(map[uuid.UUID]provenance.Provenance, len(records))
+	for _, r := range records {
+		provenances[r.DeploymentID] = provenance.FromRecord(r)
+	}
+
 	response := make([]DeploymentResponse, len(deployments))
 	for i, d := range deployments {
 		response[i] = toDeploymentResponse(d)
+		if p, ok := provenances[d.ID]; ok {
+			response[i].withProvenance(p)
+		}
 	}
 
 	return c.JSON(200, response)
 }
 
 func Post(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
 	appName := c.Param("name")
 
 	userID, err := getUserID(c, cfg)
@@ -77,8 +115,8 @@ func Post(c *fuego.Context) error {
 	}
 
 	var req CreateDeploymentRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(400, map[string]string{"error": "invalid request body"})
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
 	}
 
 	if req.Image == "" {
@@ -86,7 +124,7 @@ func Post(c *fuego.Context) error {
 	}
 
 	queries := db.New(pool)
-	app, err := queries.GetAppByName(context.Background(), db.GetAppByNameParams{
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
 		UserID: userID,
 		Name:   appName,
 	})
@@ -94,37 +132,205 @@ func Post(c *fuego.Context) error {
 		return c.JSON(404, map[string]string{"error": "app not found"})
 	}
 
-	latestDeployment, _ := queries.GetLatestDeployment(context.Background(), app.ID)
+	if !appstatus.CanTransition(app.Status, db.AppStatusDeploying) {
+		return c.JSON(409, map[string]string{"error": "app cannot be deployed while " + string(app.Status)})
+	}
+
+	limiter := services.From(c).Concurrency
+	release, err := limiter.Acquire(c.Request.Context(), concurrency.Deploy, app.UserID)
+	if err != nil {
+		return concurrency.Reject(c, err)
+	}
+	// The slot is held until the rollout ends, or freed with the response
+	// when no rollout starts
+	rollingOut := false
+	defer func() {
+		if !rollingOut {
+			release()
+		}
+	}()
+
+	// Deploys from CI carry the run's metadata, verified when the run sends
+	// an OIDC token of its provider
+	ci, ciToken, fromCI := provenance.FromHeaders(c.Header)
+	if fromCI {
+		if !provenance.ValidProvider(ci.Provider) {
+			return c.JSON(400, map[string]string{"error": provenance.HeaderProvider + " must be github or gitlab"})
+		}
+		if ciToken != "" {
+			ci, err = provenance.NewVerifier(cfg.CIOIDCAudience, cfg.GitLabURL).Verify(c.Request.Context(), ci, ciToken)
+			switch {
+			case errors.Is(err, provenance.ErrMismatch):
+				return c.JSON(422, map[string]string{"error": err.Error(), "reason": "provenance_mismatch"})
+			case errors.Is(err, provenance.ErrKeysUnavailable):
+				return c.JSON(502, map[string]string{"error": err.Error()})
+			case err != nil:
+				return c.JSON(401, map[string]string{"error": err.Error(), "reason": "invalid_provenance"})
+			}
+		}
+	}
+
+	if err := machineuser.CheckDeploymentQuota(c.Request.Context(), queries, userID, time.Now()); err != nil {
+		if errors.Is(err, machineuser.ErrDeploymentQuota) {
+			return c.JSON(403, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(500, map[string]string{"error": "failed to check quota"})
+	}
+
+	// Only emergencies deploy during a freeze of the owner's organizations
+	freeze, err := deployfreeze.Active(c.Request.Context(), queries, app.UserID, time.Now())
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to check deploy freezes"})
+	}
+	if freeze != nil {
+		if !req.Emergency {
+			return c.JSON(423, map[string]string{
+				"error":   freeze.Message(),
+				"reason":  "deploy_freeze",
+				"ends_at": freeze.EndsAt.UTC().Format(time.RFC3339),
+			})
+		}
+		if !breakglass.ValidJustification(req.Justification) {
+			return c.JSON(400, map[string]string{"error": "a justification of at least 10 characters is required to deploy during a freeze"})
+		}
+	}
+
+	// Apps of regions without a public ingress are served through a
+	// Cloudflare Tunnel, created on their first deployment
+	if cfg.TunnelForRegion(app.Region) {
+		cf := services.From(c).Cloudflare
+		if cf == nil {
+			return c.JSON(503, map[string]string{"error": "cloudflare tunnels are not configured"})
+		}
+		if _, err := tunnel.Ensure(c.Request.Context(), cfg, queries, cf, app); err != nil {
+			if errors.Is(err, tunnel.ErrHostnameTaken) {
+				return c.JSON(409, map[string]string{"error": err.Error()})
+			}
+			return c.JSON(502, map[string]string{"error": "failed to provision tunnel"})
+		}
+	}
+
+	// Reject up front when the cluster cannot fit the app instead of leaving
+	// pods Pending. Skipped when the cluster is not reachable from the API.
+	var architectures []string
+	k8sClient, err := services.From(c).Cluster(app.Region)
+	if err == nil {
+		appConfig, err := appconfig.Load(c.Request.Context(), cfg, queries, app, db.Deployment{Image: req.Image})
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to load app configuration"})
+		}
+
+		// Schedule onto nodes of an architecture the image is built for.
+		// Images the registry does not let us read deploy anywhere.
+		if imageArchitectures, err := registry.NewClient().Architectures(c.Request.Context(), req.Image); err == nil && len(imageArchitectures) > 0 {
+			architectures, err = k8sClient.CheckArchitectures(c.Request.Context(), appConfig, imageArchitectures)
+			if errors.Is(err, k8s.ErrIncompatibleArchitecture) {
+				return c.JSON(422, map[string]string{
+					"error":  err.Error(),
+					"reason": string(k8s.FailureArchMismatch),
+				})
+			}
+			appConfig.Architectures = architectures
+		}
+
+		if err := k8sClient.CheckCapacity(c.Request.Context(), appConfig); errors.Is(err, k8s.ErrInsufficientCapacity) {
+			return c.JSON(422, map[string]string{
+				"error":  err.Error(),
+				"reason": string(k8s.FailureNoCapacity),
+			})
+		}
+	}
+
+	latestDeployment, _ := queries.GetLatestDeployment(c.Request.Context(), app.ID)
 	nextVersion := int32(1)
 	if latestDeployment.ID != uuid.Nil {
 		nextVersion = latestDeployment.Version + 1
 	}
 
-	deployment, err := queries.CreateDeployment(context.Background(), db.CreateDeploymentParams{
-		AppID:   app.ID,
-		Version: nextVersion,
-		Image:   req.Image,
-		Status:  "pending",
+	by := actor.From(c)
+	deployment, err := queries.CreateDeployment(c.Request.Context(), db.CreateDeploymentParams{
+		AppID:             app.ID,
+		Version:           nextVersion,
+		Image:             req.Image,
+		Status:            "pending",
+		Architectures:     architectures,
+		DeployedByUserID:  by.User(),
+		DeployedByTokenID: by.Token(),
+		DeployedBy:        by.String(),
 	})
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": "failed to create deployment"})
 	}
 
-	_, err = queries.IncrementDeploymentCount(context.Background(), app.ID)
+	_, err = queries.IncrementDeploymentCount(c.Request.Context(), app.ID)
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": "failed to update app"})
 	}
 
-	_, err = queries.UpdateAppStatus(context.Background(), db.UpdateAppStatusParams{
-		ID:                  app.ID,
-		Status:              "deploying",
-		CurrentDeploymentID: pgtype.UUID{Bytes: deployment.ID, Valid: true},
-	})
+	_, err = appstatus.Set(c.Request.Context(), queries, app, db.AppStatusDeploying, pgtype.UUID{Bytes: deployment.ID, Valid: true})
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": "failed to update app status"})
 	}
 
-	return c.JSON(201, toDeploymentResponse(deployment))
+	// Pin what this deployment runs for later audits
+	if _, err := deploylock.Record(c.Request.Context(), cfg, queries, app, deployment); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to record deployment lockfile"})
+	}
+
+	response := toDeploymentResponse(deployment)
+	payload := map[string]any{
+		"deployment_id": deployment.ID,
+		"version":       deployment.Version,
+		"image":         deployment.Image,
+	}
+	if fromCI {
+		if _, err := queries.CreateDeploymentProvenance(c.Request.Context(), ci.CreateParams(deployment.ID)); err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to record deployment provenance"})
+		}
+		response.withProvenance(ci)
+		payload["provenance"] = ci
+	}
+
+	_ = events.Publish(c.Request.Context(), queries, events.Event{
+		Type:    "deployment.created",
+		UserID:  userID,
+		TokenID: by.TokenID,
+		AppID:   app.ID,
+		AppName: app.Name,
+		Payload: payload,
+	})
+
+	if freeze != nil {
+		_ = breakglass.Record(c.Request.Context(), queries, breakglass.Use{
+			UserID:        userID,
+			App:           app,
+			DeploymentID:  deployment.ID,
+			Version:       deployment.Version,
+			Justification: req.Justification,
+			IP:            clientIP(c),
+			Overrides: []breakglass.Override{{
+				Check:      breakglass.CheckDeployFreeze,
+				Org:        freeze.Org,
+				Name:       freeze.Name,
+				OrgOwnerID: freeze.OrgOwnerID,
+			}},
+		})
+	}
+
+	// Without a reachable cluster the deployment stays pending
+	if k8sClient != nil {
+		rollout.Start(cfg, queries, k8sClient, app, deployment, release)
+		rollingOut = true
+	}
+
+	return c.JSON(201, response)
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
 }
 
 func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
@@ -145,16 +351,34 @@ func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
 	return claims.UserID, nil
 }
 
+// withProvenance attaches where the deployment came from
+func (r *DeploymentResponse) withProvenance(p provenance.Provenance) {
+	r.DeployedBy = p.DeployedBy()
+	r.Provenance = &p
+}
+
 func toDeploymentResponse(d db.Deployment) DeploymentResponse {
 	resp := DeploymentResponse{
-		ID:        d.ID.String(),
-		AppID:     d.AppID.String(),
-		Version:   int(d.Version),
-		Image:     d.Image,
-		Status:    d.Status,
-		Message:   d.Message,
-		Error:     d.Error,
-		CreatedAt: d.CreatedAt,
+		ID:            d.ID.String(),
+		AppID:         d.AppID.String(),
+		Version:       int(d.Version),
+		Image:         d.Image,
+		Status:        d.Status,
+		Message:       d.Message,
+		Error:         d.Error,
+		FailureReason: d.FailureReason,
+		Architectures: d.Architectures,
+		CreatedAt:     d.CreatedAt,
+		DeployedBy:    d.DeployedBy,
+	}
+
+	if d.DeployedByUserID.Valid {
+		userID := uuid.UUID(d.DeployedByUserID.Bytes)
+		resp.DeployedByUserID = &userID
+	}
+	if d.DeployedByTokenID.Valid {
+		tokenID := uuid.UUID(d.DeployedByTokenID.Bytes)
+		resp.DeployedByTokenID = &tokenID
 	}
 
 	if d.StartedAt.Valid {
@@ -0,0 +1,125 @@
+package diagnostics
+
+import (
+	"slices"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/crashmonitor"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+type DiagnosticsResponse struct {
+	Deployment *DeploymentHealth `json:"deployment,omitempty"`
+	Crashes    []Crash           `json:"crashes"`
+	// Recommendations are the distinct recommendations of the crashes
+	Recommendations []string `json:"recommendations"`
+}
+
+// DeploymentHealth is what the crash monitor counted for the current
+// deployment
+type DeploymentHealth struct {
+	ID          uuid.UUID  `json:"id"`
+	Version     int32      `json:"version"`
+	Status      string     `json:"status"`
+	OOMKills    int32      `json:"oom_kills"`
+	CrashLoops  int32      `json:"crash_loops"`
+	LastCrashAt *time.Time `json:"last_crash_at,omitempty"`
+}
+
+// Crash is the last crash of a container with what to do about it
+type Crash struct {
+	k8s.ContainerCrash
+	Recommendation string `json:"recommendation"`
+}
+
+// Get returns the crash counts of the current deployment and the container
+// crashes of the running pods, with recommendations
+// GET /api/apps/{name}/diagnostics
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	// Verify app ownership
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	resp := DiagnosticsResponse{Crashes: []Crash{}, Recommendations: []string{}}
+	if app.CurrentDeploymentID.Valid {
+		deployment, err := queries.GetDeploymentByID(c.Request.Context(), app.CurrentDeploymentID.Bytes)
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to load deployment"})
+		}
+		resp.Deployment = toDeploymentHealth(deployment)
+	}
+
+	// Get K8s client
+	k8sClient, err := services.From(c).Cluster(app.Region)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "kubernetes not available"})
+	}
+
+	crashes, err := k8sClient.ListCrashes(c.Request.Context(), app.Name)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	for i := range crashes {
+		recommendation := crashmonitor.Recommend(&crashes[i])
+		resp.Crashes = append(resp.Crashes, Crash{ContainerCrash: crashes[i], Recommendation: recommendation})
+		if !slices.Contains(resp.Recommendations, recommendation) {
+			resp.Recommendations = append(resp.Recommendations, recommendation)
+		}
+	}
+
+	return c.JSON(200, resp)
+}
+
+func toDeploymentHealth(d db.Deployment) *DeploymentHealth {
+	health := &DeploymentHealth{
+		ID:         d.ID,
+		Version:    d.Version,
+		Status:     d.Status,
+		OOMKills:   d.OomKills,
+		CrashLoops: d.CrashLoops,
+	}
+	if d.LastCrashAt.Valid {
+		health.LastCrashAt = &d.LastCrashAt.Time
+	}
+	return health
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if id, ok := c.Get("user_id").(uuid.UUID); ok {
+		return id, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
@@ -0,0 +1,105 @@
+package instructions
+
+import (
+	"context"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/dnsprovider"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/domainrecords"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/domainverify"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+type InstructionsResponse struct {
+	Domain     string     `json:"domain"`
+	Verified   bool       `json:"verified"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+	// Zone is the apex the records are created in
+	Zone    string `json:"zone"`
+	DNSMode string `json:"dns_mode"`
+	// Provider is detected from the zone's nameservers
+	Provider    dnsprovider.Provider `json:"provider"`
+	Nameservers []string             `json:"nameservers,omitempty"`
+	dnsprovider.Instructions
+}
+
+// Get returns step-by-step DNS instructions for a custom domain, phrased for
+// the DNS provider detected from the zone's nameservers. The ownership TXT
+// record is included until the domain is verified.
+// GET /api/apps/{name}/domains/{domain}/instructions
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   c.Param("name"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	domain, err := queries.GetDomainByName(c.Request.Context(), c.Param("domain"))
+	if err != nil || domain.AppID != app.ID {
+		return c.JSON(404, map[string]string{"error": "domain not found"})
+	}
+
+	records, err := domainrecords.Records(domain.Domain, domainrecords.Mode(domain.DnsMode), app.Name+"."+cfg.AppsDomainSuffix, cfg.IngressIPsForRegion(app.Region))
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+	if !domain.Verified {
+		records = append([]domainverify.Record{*domainverify.Challenge(domain.Domain, domain.VerificationToken)}, records...)
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	// An unregistered or unreachable zone still gets generic instructions
+	nameservers, _ := domainrecords.NewChecker(domainverify.PublicResolvers).Nameservers(ctx, domain.Domain)
+	provider := dnsprovider.Detect(nameservers)
+	zone := domainrecords.Apex(domain.Domain)
+
+	response := InstructionsResponse{
+		Domain:       domain.Domain,
+		Verified:     domain.Verified,
+		Zone:         zone,
+		DNSMode:      domain.DnsMode,
+		Provider:     provider,
+		Nameservers:  nameservers,
+		Instructions: dnsprovider.Build(provider, zone, records),
+	}
+	if domain.VerifiedAt.Valid {
+		response.VerifiedAt = &domain.VerifiedAt.Time
+	}
+	return c.JSON(200, response)
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
@@ -5,25 +5,49 @@ import (
 	"time"
 
 	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appconfig"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/domainrecords"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/domainverify"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
 	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type DomainResponse struct {
-	ID         string     `json:"id"`
-	Domain     string     `json:"domain"`
-	Verified   bool       `json:"verified"`
-	SSLStatus  string     `json:"ssl_status"`
-	CreatedAt  time.Time  `json:"created_at"`
-	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+	ID        string `json:"id"`
+	Domain    string `json:"domain"`
+	Verified  bool   `json:"verified"`
+	SSLStatus string `json:"ssl_status"`
+	// SSLExpiresAt, SSLError and SSLCheckedAt come from the certificate monitor
+	SSLExpiresAt *time.Time `json:"ssl_expires_at,omitempty"`
+	SSLError     string     `json:"ssl_error,omitempty"`
+	SSLCheckedAt *time.Time `json:"ssl_checked_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	VerifiedAt   *time.Time `json:"verified_at,omitempty"`
+	// Verification is the TXT record to publish while the domain is unverified
+	Verification *domainverify.Record `json:"verification,omitempty"`
+	// DNSMode and DNSRecords describe how the domain routes to the app
+	DNSMode    string                `json:"dns_mode"`
+	DNSRecords []domainverify.Record `json:"dns_records,omitempty"`
+	// AddressFamilies are the IP families the domain is served over, ipv4
+	// and ipv6 on dual-stack regions
+	AddressFamilies []string `json:"address_families,omitempty"`
+	// ForceHTTPS and the HSTS fields are the domain's TLS policy at the ingress
+	ForceHTTPS            bool  `json:"force_https"`
+	HSTSMaxAge            int32 `json:"hsts_max_age"`
+	HSTSIncludeSubdomains bool  `json:"hsts_include_subdomains"`
+	HSTSPreload           bool  `json:"hsts_preload"`
+	// Synced is set by Put: whether the policy reached the ingress
+	Synced *bool `json:"synced,omitempty"`
 }
 
 func Get(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
 	appName := c.Param("name")
 	domainName := c.Param("domain")
 
@@ -33,7 +57,7 @@ func Get(c *fuego.Context) error {
 	}
 
 	queries := db.New(pool)
-	app, err := queries.GetAppByName(context.Background(), db.GetAppByNameParams{
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
 		UserID: userID,
 		Name:   appName,
 	})
@@ -41,7 +65,7 @@ func Get(c *fuego.Context) error {
 		return c.JSON(404, map[string]string{"error": "app not found"})
 	}
 
-	domain, err := queries.GetDomainByName(context.Background(), domainName)
+	domain, err := queries.GetDomainByName(c.Request.Context(), domainName)
 	if err != nil {
 		return c.JSON(404, map[string]string{"error": "domain not found"})
 	}
@@ -50,12 +74,100 @@ func Get(c *fuego.Context) error {
 		return c.JSON(404, map[string]string{"error": "domain not found"})
 	}
 
-	return c.JSON(200, toDomainResponse(domain))
+	return c.JSON(200, withRecords(toDomainResponse(domain), cfg, app))
+}
+
+// TLSPolicyRequest changes how the ingress treats plain HTTP and HSTS for a
+// domain; omitted fields keep their value
+type TLSPolicyRequest struct {
+	ForceHTTPS            *bool  `json:"force_https"`
+	HSTSMaxAge            *int32 `json:"hsts_max_age"`
+	HSTSIncludeSubdomains *bool  `json:"hsts_include_subdomains"`
+	HSTSPreload           *bool  `json:"hsts_preload"`
+}
+
+// Put sets whether plain HTTP is redirected to HTTPS and the HSTS header of
+// a domain. Turning the redirect off serves plain HTTP, for TLS terminated
+// upstream; HSTS can be staged with a short max-age before preloading.
+// PUT /api/apps/{name}/domains/{domain}
+// Body: { "force_https": true, "hsts_max_age": 31536000, "hsts_include_subdomains": true, "hsts_preload": true }
+func Put(c *fuego.Context) error {
+	svc := services.From(c)
+	cfg := svc.Config
+	appName := c.Param("name")
+	domainName := c.Param("domain")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req TLSPolicyRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+
+	queries := db.New(svc.DB)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	domain, err := queries.GetDomainByName(c.Request.Context(), domainName)
+	if err != nil || domain.AppID != app.ID {
+		return c.JSON(404, map[string]string{"error": "domain not found"})
+	}
+
+	policy := *appconfig.TLSPolicy(domain)
+	if req.ForceHTTPS != nil {
+		policy.ForceHTTPS = *req.ForceHTTPS
+	}
+	if req.HSTSMaxAge != nil {
+		policy.HSTSMaxAge = *req.HSTSMaxAge
+	}
+	if req.HSTSIncludeSubdomains != nil {
+		policy.HSTSIncludeSubdomains = *req.HSTSIncludeSubdomains
+	}
+	if req.HSTSPreload != nil {
+		policy.HSTSPreload = *req.HSTSPreload
+	}
+	if err := k8s.ValidateTLSPolicy(policy); err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	domain, err = queries.UpdateDomainTLSPolicy(c.Request.Context(), db.UpdateDomainTLSPolicyParams{
+		ID:                    domain.ID,
+		ForceHttps:            policy.ForceHTTPS,
+		HstsMaxAge:            policy.HSTSMaxAge,
+		HstsIncludeSubdomains: policy.HSTSIncludeSubdomains,
+		HstsPreload:           policy.HSTSPreload,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to update domain"})
+	}
+
+	// A verified domain of a deployed app is served now; others pick the
+	// policy up when they are
+	synced := false
+	if domain.Verified && app.CurrentDeploymentID.Valid {
+		if err := applyTLSPolicy(c.Request.Context(), svc, queries, app); err != nil {
+			return c.JSON(500, map[string]string{"error": "tls policy saved but failed to apply: " + err.Error()})
+		}
+		synced = true
+	}
+
+	resp := withRecords(toDomainResponse(domain), cfg, app)
+	resp.Synced = &synced
+
+	return c.JSON(200, resp)
 }
 
 func Delete(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
 	appName := c.Param("name")
 	domainName := c.Param("domain")
 
@@ -65,7 +177,7 @@ func Delete(c *fuego.Context) error {
 	}
 
 	queries := db.New(pool)
-	app, err := queries.GetAppByName(context.Background(), db.GetAppByNameParams{
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
 		UserID: userID,
 		Name:   appName,
 	})
@@ -73,7 +185,7 @@ func Delete(c *fuego.Context) error {
 		return c.JSON(404, map[string]string{"error": "app not found"})
 	}
 
-	domain, err := queries.GetDomainByName(context.Background(), domainName)
+	domain, err := queries.GetDomainByName(c.Request.Context(), domainName)
 	if err != nil {
 		return c.JSON(404, map[string]string{"error": "domain not found"})
 	}
@@ -82,7 +194,7 @@ func Delete(c *fuego.Context) error {
 		return c.JSON(404, map[string]string{"error": "domain not found"})
 	}
 
-	err = queries.DeleteDomain(context.Background(), domain.ID)
+	err = queries.DeleteDomain(c.Request.Context(), domain.ID)
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": "failed to delete domain"})
 	}
@@ -90,6 +202,21 @@ func Delete(c *fuego.Context) error {
 	return c.NoContent()
 }
 
+// applyTLSPolicy updates the ingress of a running app to the stored policy
+func applyTLSPolicy(ctx context.Context, svc *services.Services, queries *db.Queries, app db.App) error {
+	cfg := svc.Config
+	appConfig, err := appconfig.Load(ctx, cfg, queries, app, db.Deployment{})
+	if err != nil {
+		return err
+	}
+
+	k8sClient, err := svc.Cluster(app.Region)
+	if err != nil {
+		return err
+	}
+	return k8sClient.ApplyTLSPolicy(ctx, appConfig)
+}
+
 func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
 	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
 		return userID, nil
@@ -115,11 +242,38 @@ func toDomainResponse(d db.Domain) DomainResponse {
 		Verified:  d.Verified,
 		SSLStatus: d.SslStatus,
 		CreatedAt: d.CreatedAt,
+		DNSMode:   d.DnsMode,
+
+		ForceHTTPS:            d.ForceHttps,
+		HSTSMaxAge:            d.HstsMaxAge,
+		HSTSIncludeSubdomains: d.HstsIncludeSubdomains,
+		HSTSPreload:           d.HstsPreload,
 	}
 
 	if d.VerifiedAt.Valid {
 		resp.VerifiedAt = &d.VerifiedAt.Time
 	}
+	if d.SslExpiresAt.Valid {
+		resp.SSLExpiresAt = &d.SslExpiresAt.Time
+	}
+	if d.SslError != nil {
+		resp.SSLError = *d.SslError
+	}
+	if d.SslCheckedAt.Valid {
+		resp.SSLCheckedAt = &d.SslCheckedAt.Time
+	}
+	if !d.Verified {
+		resp.Verification = domainverify.Challenge(d.Domain, d.VerificationToken)
+	}
+
+	return resp
+}
 
+// withRecords adds the records routing the domain to the app's region and
+// the address families they serve it over
+func withRecords(resp DomainResponse, cfg *config.Config, app db.App) DomainResponse {
+	ingressIPs := cfg.IngressIPsForRegion(app.Region)
+	resp.DNSRecords, _ = domainrecords.Records(resp.Domain, domainrecords.Mode(resp.DNSMode), app.Name+"."+cfg.AppsDomainSuffix, ingressIPs)
+	resp.AddressFamilies = domainrecords.Families(ingressIPs)
 	return resp
 }
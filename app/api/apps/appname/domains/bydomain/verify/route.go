@@ -2,16 +2,16 @@ package verify
 
 import (
 	"context"
-	"net"
-	"strings"
 	"time"
 
 	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/domainrecords"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/domainverify"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
 	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type VerifyResponse struct {
@@ -19,11 +19,27 @@ type VerifyResponse struct {
 	Verified   bool       `json:"verified"`
 	VerifiedAt *time.Time `json:"verified_at,omitempty"`
 	Message    string     `json:"message,omitempty"`
+	// Reason is a machine-readable cause when ownership or routing is not
+	// set up yet: txt_missing, txt_mismatch, propagation_pending,
+	// records_missing, wrong_target or caa_blocking
+	Reason string `json:"reason,omitempty"`
+	// Found lists what public DNS currently returns for the failing check
+	Found []string `json:"found,omitempty"`
+	// Verification is the TXT record proving ownership, set until verified
+	Verification *domainverify.Record `json:"verification,omitempty"`
+	// RoutingConfigured reports whether the domain's DNS records already
+	// point at the platform
+	RoutingConfigured bool                  `json:"routing_configured"`
+	DNSRecords        []domainverify.Record `json:"dns_records,omitempty"`
 }
 
+// Post checks the ownership TXT record of a domain, marking it verified, and
+// then whether its DNS records route to the app. Failures carry a reason so
+// the setup wizard can tell propagation delays apart from wrong records.
+// POST /api/apps/{name}/domains/{domain}/verify
 func Post(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
 	appName := c.Param("name")
 	domainName := c.Param("domain")
 
@@ -33,7 +49,7 @@ func Post(c *fuego.Context) error {
 	}
 
 	queries := db.New(pool)
-	app, err := queries.GetAppByName(context.Background(), db.GetAppByNameParams{
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
 		UserID: userID,
 		Name:   appName,
 	})
@@ -41,7 +57,7 @@ func Post(c *fuego.Context) error {
 		return c.JSON(404, map[string]string{"error": "app not found"})
 	}
 
-	domain, err := queries.GetDomainByName(context.Background(), domainName)
+	domain, err := queries.GetDomainByName(c.Request.Context(), domainName)
 	if err != nil {
 		return c.JSON(404, map[string]string{"error": "domain not found"})
 	}
@@ -50,48 +66,62 @@ func Post(c *fuego.Context) error {
 		return c.JSON(404, map[string]string{"error": "domain not found"})
 	}
 
-	if domain.Verified {
-		return c.JSON(200, VerifyResponse{
-			Domain:     domain.Domain,
-			Verified:   true,
-			VerifiedAt: &domain.VerifiedAt.Time,
-			Message:    "domain already verified",
-		})
-	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
 
-	verified, err := verifyDNS(domainName, cfg.AppsDomainSuffix)
-	if err != nil || !verified {
-		return c.JSON(200, VerifyResponse{
-			Domain:   domain.Domain,
-			Verified: false,
-			Message:  "DNS verification failed. Please ensure CNAME record points to " + cfg.AppsDomainSuffix,
-		})
+	public := domainrecords.NewChecker(domainverify.PublicResolvers)
+	var authoritative *domainrecords.Checker
+	nameservers, _ := public.Nameservers(ctx, domain.Domain)
+	if len(nameservers) > 0 {
+		authoritative = domainrecords.Authoritative(nameservers)
 	}
 
-	updatedDomain, err := queries.UpdateDomainVerified(context.Background(), domain.ID)
-	if err != nil {
-		return c.JSON(500, map[string]string{"error": "failed to update domain verification status"})
+	message := "domain already verified"
+	if !domain.Verified {
+		// Ownership is proven by the TXT challenge, checked against public DNS
+		if err := domainverify.NewVerifier(domainverify.PublicResolvers).Verify(ctx, domain.Domain, domain.VerificationToken); err != nil {
+			authoritativeErr := domainverify.ErrRecordNotFound
+			if len(nameservers) > 0 {
+				authoritativeErr = domainverify.NewVerifier(domainverify.NameserverAddrs(nameservers)).Verify(ctx, domain.Domain, domain.VerificationToken)
+			}
+			reason, message := domainverify.Diagnose(err, authoritativeErr)
+			return c.JSON(200, VerifyResponse{
+				Domain:       domain.Domain,
+				Verified:     false,
+				Message:      message,
+				Reason:       string(reason),
+				Verification: domainverify.Challenge(domain.Domain, domain.VerificationToken),
+			})
+		}
+
+		domain, err = queries.UpdateDomainVerified(c.Request.Context(), domain.ID)
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to update domain verification status"})
+		}
+		message = "domain verified successfully"
 	}
 
-	verifiedAt := updatedDomain.VerifiedAt.Time
-	return c.JSON(200, VerifyResponse{
-		Domain:     updatedDomain.Domain,
-		Verified:   true,
-		VerifiedAt: &verifiedAt,
-		Message:    "domain verified successfully",
-	})
-}
+	mode := domainrecords.Mode(domain.DnsMode)
+	target := app.Name + "." + cfg.AppsDomainSuffix
+	ingressIPs := cfg.IngressIPsForRegion(app.Region)
+	records, _ := domainrecords.Records(domain.Domain, mode, target, ingressIPs)
 
-func verifyDNS(domainName, expectedTarget string) (bool, error) {
-	cname, err := net.LookupCNAME(domainName)
-	if err != nil {
-		return false, err
+	diagnosis := domainrecords.Diagnose(ctx, public, authoritative, domain.Domain, mode, target, ingressIPs)
+	if diagnosis.Reason != "" {
+		message += ". " + diagnosis.Message
 	}
 
-	cname = strings.TrimSuffix(cname, ".")
-	expectedTarget = strings.TrimSuffix(expectedTarget, ".")
-
-	return strings.EqualFold(cname, expectedTarget), nil
+	verifiedAt := domain.VerifiedAt.Time
+	return c.JSON(200, VerifyResponse{
+		Domain:            domain.Domain,
+		Verified:          true,
+		VerifiedAt:        &verifiedAt,
+		Message:           message,
+		Reason:            string(diagnosis.Reason),
+		Found:             diagnosis.Found,
+		RoutingConfigured: diagnosis.Configured,
+		DNSRecords:        records,
+	})
 }
 
 func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
@@ -3,34 +3,57 @@ package domains
 import (
 	"context"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/domainrecords"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/domainverify"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
 	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 var domainRegex = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?\.)+[a-zA-Z]{2,}$`)
 
 type CreateDomainRequest struct {
 	Domain string `json:"domain"`
+	// DNSMode is cname, a or alias; defaults to a for apex domains
+	DNSMode string `json:"dns_mode,omitempty"`
 }
 
 type DomainResponse struct {
-	ID         string     `json:"id"`
-	Domain     string     `json:"domain"`
-	Verified   bool       `json:"verified"`
-	SSLStatus  string     `json:"ssl_status"`
-	CreatedAt  time.Time  `json:"created_at"`
-	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+	ID        string `json:"id"`
+	Domain    string `json:"domain"`
+	Verified  bool   `json:"verified"`
+	SSLStatus string `json:"ssl_status"`
+	// SSLExpiresAt, SSLError and SSLCheckedAt come from the certificate monitor
+	SSLExpiresAt *time.Time `json:"ssl_expires_at,omitempty"`
+	SSLError     string     `json:"ssl_error,omitempty"`
+	SSLCheckedAt *time.Time `json:"ssl_checked_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	VerifiedAt   *time.Time `json:"verified_at,omitempty"`
+	// Verification is the TXT record to publish while the domain is unverified
+	Verification *domainverify.Record `json:"verification,omitempty"`
+	// DNSMode and DNSRecords describe how the domain routes to the app
+	DNSMode    string                `json:"dns_mode"`
+	DNSRecords []domainverify.Record `json:"dns_records,omitempty"`
+	// AddressFamilies are the IP families the domain is served over, ipv4
+	// and ipv6 on dual-stack regions
+	AddressFamilies []string `json:"address_families,omitempty"`
+	// ForceHTTPS and the HSTS fields are the domain's TLS policy at the ingress
+	ForceHTTPS            bool  `json:"force_https"`
+	HSTSMaxAge            int32 `json:"hsts_max_age"`
+	HSTSIncludeSubdomains bool  `json:"hsts_include_subdomains"`
+	HSTSPreload           bool  `json:"hsts_preload"`
 }
 
 func Get(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
 	appName := c.Param("name")
 
 	userID, err := getUserID(c, cfg)
@@ -39,7 +62,7 @@ func Get(c *fuego.Context) error {
 	}
 
 	queries := db.New(pool)
-	app, err := queries.GetAppByName(context.Background(), db.GetAppByNameParams{
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
 		UserID: userID,
 		Name:   appName,
 	})
@@ -47,22 +70,22 @@ func Get(c *fuego.Context) error {
 		return c.JSON(404, map[string]string{"error": "app not found"})
 	}
 
-	domains, err := queries.ListDomainsByApp(context.Background(), app.ID)
+	domains, err := queries.ListDomainsByApp(c.Request.Context(), app.ID)
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": "failed to list domains"})
 	}
 
 	response := make([]DomainResponse, len(domains))
 	for i, d := range domains {
-		response[i] = toDomainResponse(d)
+		response[i] = withRecords(toDomainResponse(d), cfg, app)
 	}
 
 	return c.JSON(200, response)
 }
 
 func Post(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
 	appName := c.Param("name")
 
 	userID, err := getUserID(c, cfg)
@@ -71,10 +94,13 @@ func Post(c *fuego.Context) error {
 	}
 
 	var req CreateDomainRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(400, map[string]string{"error": "invalid request body"})
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
 	}
 
+	// Case variants must not let another user claim the same domain
+	req.Domain = strings.ToLower(strings.TrimSpace(req.Domain))
+
 	if req.Domain == "" {
 		return c.JSON(400, map[string]string{"error": "domain is required"})
 	}
@@ -84,7 +110,7 @@ func Post(c *fuego.Context) error {
 	}
 
 	queries := db.New(pool)
-	app, err := queries.GetAppByName(context.Background(), db.GetAppByNameParams{
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
 		UserID: userID,
 		Name:   appName,
 	})
@@ -92,20 +118,71 @@ func Post(c *fuego.Context) error {
 		return c.JSON(404, map[string]string{"error": "app not found"})
 	}
 
-	_, err = queries.GetDomainByName(context.Background(), req.Domain)
+	// A domain in a zone the user delegated is verified by the delegation;
+	// one in another user's zone can only be added by them
+	zone, err := queries.GetActiveDNSZoneForDomain(c.Request.Context(), req.Domain)
+	inZone := err == nil
+	if inZone && zone.UserID != userID {
+		return c.JSON(409, map[string]string{"error": "domain is in a zone delegated by another user"})
+	}
+
+	mode, err := domainrecords.Resolve(req.Domain, req.DNSMode)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	// The zone apex cannot be a CNAME either
+	if inZone && req.Domain == zone.Zone && mode == domainrecords.ModeCNAME {
+		mode = domainrecords.ModeA
+	}
+	if mode == domainrecords.ModeA && len(cfg.IngressIPsForRegion(app.Region)) == 0 {
+		return c.JSON(400, map[string]string{"error": "apex domains are not available in this region yet, use dns_mode alias"})
+	}
+
+	existing, err := queries.GetDomainByName(c.Request.Context(), req.Domain)
 	if err == nil {
-		return c.JSON(409, map[string]string{"error": "domain already exists"})
+		if !releaseStaleClaim(c.Request.Context(), queries, existing, userID) {
+			return c.JSON(409, map[string]string{"error": "domain already exists"})
+		}
+	}
+
+	token, err := domainverify.GenerateToken()
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to generate verification token"})
 	}
 
-	domain, err := queries.CreateDomain(context.Background(), db.CreateDomainParams{
-		AppID:  app.ID,
-		Domain: req.Domain,
+	domain, err := queries.CreateDomain(c.Request.Context(), db.CreateDomainParams{
+		AppID:             app.ID,
+		Domain:            req.Domain,
+		VerificationToken: token,
+		DnsMode:           string(mode),
 	})
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": "failed to create domain"})
 	}
+	if inZone {
+		domain, err = queries.UpdateDomainVerified(c.Request.Context(), domain.ID)
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to update domain verification status"})
+		}
+	}
+
+	return c.JSON(201, withRecords(toDomainResponse(domain), cfg, app))
+}
+
+// releaseStaleClaim deletes another user's claim on a domain they never
+// verified within domainverify.ClaimTTL, so squatting cannot block the real
+// owner. Verified domains and the user's own claims are never released.
+func releaseStaleClaim(ctx context.Context, queries *db.Queries, existing db.Domain, userID uuid.UUID) bool {
+	if existing.Verified || !domainverify.ClaimExpired(existing.CreatedAt, time.Now()) {
+		return false
+	}
 
-	return c.JSON(201, toDomainResponse(domain))
+	owner, err := queries.GetAppByID(ctx, existing.AppID)
+	if err != nil || owner.UserID == userID {
+		return false
+	}
+
+	return queries.DeleteDomain(ctx, existing.ID) == nil
 }
 
 func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
@@ -133,11 +210,38 @@ func toDomainResponse(d db.Domain) DomainResponse {
 		Verified:  d.Verified,
 		SSLStatus: d.SslStatus,
 		CreatedAt: d.CreatedAt,
+		DNSMode:   d.DnsMode,
+
+		ForceHTTPS:            d.ForceHttps,
+		HSTSMaxAge:            d.HstsMaxAge,
+		HSTSIncludeSubdomains: d.HstsIncludeSubdomains,
+		HSTSPreload:           d.HstsPreload,
 	}
 
 	if d.VerifiedAt.Valid {
 		resp.VerifiedAt = &d.VerifiedAt.Time
 	}
+	if d.SslExpiresAt.Valid {
+		resp.SSLExpiresAt = &d.SslExpiresAt.Time
+	}
+	if d.SslError != nil {
+		resp.SSLError = *d.SslError
+	}
+	if d.SslCheckedAt.Valid {
+		resp.SSLCheckedAt = &d.SslCheckedAt.Time
+	}
+	if !d.Verified {
+		resp.Verification = domainverify.Challenge(d.Domain, d.VerificationToken)
+	}
+
+	return resp
+}
 
+// withRecords adds the records routing the domain to the app's region and
+// the address families they serve it over
+func withRecords(resp DomainResponse, cfg *config.Config, app db.App) DomainResponse {
+	ingressIPs := cfg.IngressIPsForRegion(app.Region)
+	resp.DNSRecords, _ = domainrecords.Records(resp.Domain, domainrecords.Mode(resp.DNSMode), app.Name+"."+cfg.AppsDomainSuffix, ingressIPs)
+	resp.AddressFamilies = domainrecords.Families(ingressIPs)
 	return resp
 }
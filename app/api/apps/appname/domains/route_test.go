@@ -59,6 +59,8 @@ func TestDomainResponseConversion(t *testing.T) {
 		SslStatus:  "active",
 		CreatedAt:  now,
 		VerifiedAt: pgtype.Timestamptz{Time: verifiedAt, Valid: true},
+		ForceHttps: true,
+		HstsMaxAge: 300,
 	}
 
 	resp := toDomainResponse(domain)
@@ -82,6 +84,10 @@ func TestDomainResponseConversion(t *testing.T) {
 	if resp.VerifiedAt == nil {
 		t.Error("expected VerifiedAt to be set")
 	}
+
+	if !resp.ForceHTTPS || resp.HSTSMaxAge != 300 {
+		t.Errorf("expected the TLS policy, got force_https %v hsts_max_age %d", resp.ForceHTTPS, resp.HSTSMaxAge)
+	}
 }
 
 func TestDomainResponseWithUnverified(t *testing.T) {
@@ -0,0 +1,155 @@
+package downloads
+
+import (
+	"encoding/json"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/signedurl"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Artifacts that can be downloaded through a signed URL
+const (
+	ArtifactLogs   = "logs"
+	ArtifactExport = "export"
+)
+
+type CreateDownloadRequest struct {
+	Artifact string `json:"artifact"`
+	// ExpiresIn is the URL lifetime in seconds (default 15 minutes, max 24 hours)
+	ExpiresIn int64 `json:"expires_in,omitempty"`
+
+	// Logs
+	Tail int64 `json:"tail,omitempty"`
+
+	// Export
+	Format       string `json:"format,omitempty"`
+	DeploymentID string `json:"deployment_id,omitempty"`
+}
+
+type DownloadResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Post issues a signed, expiring URL for a heavy artifact of an app so it
+// can be downloaded by a browser without a bearer token
+// POST /api/apps/{name}/downloads
+func Post(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req CreateDownloadRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+
+	ttl := signedurl.DefaultTTL
+	if req.ExpiresIn < 0 {
+		return c.JSON(400, map[string]string{"error": "expires_in must be positive"})
+	}
+	if req.ExpiresIn > 0 {
+		ttl = time.Duration(req.ExpiresIn) * time.Second
+	}
+	if ttl > signedurl.MaxTTL {
+		return c.JSON(400, map[string]string{"error": "expires_in may be at most 24 hours"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	query := url.Values{}
+	switch req.Artifact {
+	case ArtifactLogs:
+		query.Set("download", "true")
+		if req.Tail > 0 {
+			query.Set("tail", strconv.FormatInt(req.Tail, 10))
+		}
+	case ArtifactExport:
+		if req.Format != "" {
+			if req.Format != k8s.ExportFormatHelm && req.Format != k8s.ExportFormatKustomize {
+				return c.JSON(400, map[string]string{"error": "format must be helm or kustomize"})
+			}
+			query.Set("format", req.Format)
+		}
+		if req.DeploymentID != "" {
+			if _, err := uuid.Parse(req.DeploymentID); err != nil {
+				return c.JSON(400, map[string]string{"error": "invalid deployment id"})
+			}
+			query.Set("deployment_id", req.DeploymentID)
+		}
+	default:
+		return c.JSON(400, map[string]string{"error": "artifact must be logs or export"})
+	}
+
+	// The artifact lives next to this endpoint: /api/apps/{name}/<artifact>
+	path := strings.TrimSuffix(c.Request.URL.Path, "/downloads") + "/" + req.Artifact
+	expiresAt := time.Now().Add(ttl).Truncate(time.Second)
+
+	details, _ := json.Marshal(map[string]any{
+		"artifact":   req.Artifact,
+		"expires_at": expiresAt,
+	})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		AppID:     pgtype.UUID{Bytes: app.ID, Valid: true},
+		Action:    "download.url_issued",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(201, DownloadResponse{
+		URL:       signedurl.Sign(cfg.SigningKey(), path, query, userID, expiresAt),
+		ExpiresAt: expiresAt,
+	})
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if id, ok := c.Get("user_id").(uuid.UUID); ok {
+		return id, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package egress
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/netip"
+	"slices"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appconfig"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type EgressResponse struct {
+	Enabled bool `json:"enabled"`
+	// IP is the address third parties see the app's requests come from
+	IP          string     `json:"ip,omitempty"`
+	Region      string     `json:"region,omitempty"`
+	AllocatedAt *time.Time `json:"allocated_at,omitempty"`
+	Synced      *bool      `json:"synced,omitempty"`
+}
+
+type EgressRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// Get returns the static egress IP of an app
+// GET /api/apps/{name}/egress
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	egress, err := queries.GetAppEgressIP(c.Request.Context(), app.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return c.JSON(200, EgressResponse{})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to get egress ip"})
+	}
+
+	return c.JSON(200, toEgressResponse(egress))
+}
+
+// Put gives an app a static egress IP from the pool of its region, or
+// returns its IP to the pool. The IP is kept until disabled, so third parties
+// only allowlist it once.
+// PUT /api/apps/{name}/egress
+// Body: { "enabled": true }
+func Put(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req EgressRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	response := EgressResponse{}
+	if req.Enabled {
+		ips := cfg.EgressIPsForRegion(app.Region)
+		if len(ips) == 0 {
+			return c.JSON(503, map[string]string{"error": "static egress IPs are not available in region " + app.Region})
+		}
+
+		egress, err := allocate(c.Request.Context(), queries, app, ips)
+		if errors.Is(err, errPoolExhausted) {
+			return c.JSON(409, map[string]string{"error": "no static egress IP is left in region " + app.Region})
+		}
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to allocate egress ip"})
+		}
+		response = toEgressResponse(egress)
+	} else if err := queries.ReleaseAppEgressIP(c.Request.Context(), app.ID); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to release egress ip"})
+	}
+
+	// Route the app's traffic right away when it is deployed
+	synced := false
+	if app.CurrentDeploymentID.Valid {
+		if err := applyEgress(c.Request.Context(), services.From(c), queries, app); err != nil {
+			return c.JSON(500, map[string]string{"error": "egress saved but failed to apply: " + err.Error()})
+		}
+		synced = true
+	}
+	response.Synced = &synced
+
+	action := "egress.released"
+	details, _ := json.Marshal(map[string]any{"synced": synced})
+	if req.Enabled {
+		action = "egress.allocated"
+		details, _ = json.Marshal(map[string]any{"ip": response.IP, "synced": synced})
+	}
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		AppID:     pgtype.UUID{Bytes: app.ID, Valid: true},
+		Action:    action,
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(200, response)
+}
+
+var errPoolExhausted = errors.New("egress ip pool exhausted")
+
+// allocate returns the app's IP, taking the first free one of the region's
+// pool when it has none. An IP of another region, left over from a move, is
+// given back first.
+func allocate(ctx context.Context, queries *db.Queries, app db.App, ips []string) (db.AppEgressIp, error) {
+	egress, err := queries.GetAppEgressIP(ctx, app.ID)
+	switch {
+	case err == nil && egress.Region == app.Region:
+		return egress, nil
+	case err == nil:
+		if err := queries.ReleaseAppEgressIP(ctx, app.ID); err != nil {
+			return db.AppEgressIp{}, err
+		}
+	case !errors.Is(err, pgx.ErrNoRows):
+		return db.AppEgressIp{}, err
+	}
+
+	taken, err := queries.ListAllocatedEgressIPs(ctx, app.Region)
+	if err != nil {
+		return db.AppEgressIp{}, err
+	}
+	for _, ip := range ips {
+		if slices.Contains(taken, ip) {
+			continue
+		}
+		egress, err := queries.AllocateAppEgressIP(ctx, db.AllocateAppEgressIPParams{
+			AppID:  app.ID,
+			Region: app.Region,
+			Ip:     ip,
+		})
+		if err == nil {
+			return egress, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return db.AppEgressIp{}, err
+		}
+		// Another request took the IP, or gave this app one, meanwhile
+		if egress, err := queries.GetAppEgressIP(ctx, app.ID); err == nil {
+			return egress, nil
+		}
+	}
+	return db.AppEgressIp{}, errPoolExhausted
+}
+
+// applyEgress routes a running app's outbound traffic by the stored IP
+func applyEgress(ctx context.Context, svc *services.Services, queries *db.Queries, app db.App) error {
+	cfg := svc.Config
+	appConfig, err := appconfig.Load(ctx, cfg, queries, app, db.Deployment{})
+	if err != nil {
+		return err
+	}
+
+	k8sClient, err := svc.Cluster(app.Region)
+	if err != nil {
+		return err
+	}
+	return k8sClient.ApplyEgress(ctx, appConfig)
+}
+
+func toEgressResponse(egress db.AppEgressIp) EgressResponse {
+	return EgressResponse{
+		Enabled:     true,
+		IP:          egress.Ip,
+		Region:      egress.Region,
+		AllocatedAt: &egress.CreatedAt,
+	}
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
@@ -1,15 +1,14 @@
 package env
 
 import (
-	"context"
-
 	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/cryptoutil"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
 	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type EnvVarsResponse struct {
@@ -22,8 +21,8 @@ type UpdateEnvVarsRequest struct {
 }
 
 func Get(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
 	appName := c.Param("name")
 
 	userID, err := getUserID(c, cfg)
@@ -32,7 +31,7 @@ func Get(c *fuego.Context) error {
 	}
 
 	queries := db.New(pool)
-	app, err := queries.GetAppByName(context.Background(), db.GetAppByNameParams{
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
 		UserID: userID,
 		Name:   appName,
 	})
@@ -67,8 +66,8 @@ func Get(c *fuego.Context) error {
 }
 
 func Put(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
 	appName := c.Param("name")
 
 	userID, err := getUserID(c, cfg)
@@ -77,12 +76,12 @@ func Put(c *fuego.Context) error {
 	}
 
 	var req UpdateEnvVarsRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(400, map[string]string{"error": "invalid request body"})
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
 	}
 
 	queries := db.New(pool)
-	app, err := queries.GetAppByName(context.Background(), db.GetAppByNameParams{
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
 		UserID: userID,
 		Name:   appName,
 	})
@@ -95,7 +94,7 @@ func Put(c *fuego.Context) error {
 		return c.JSON(500, map[string]string{"error": "failed to encrypt environment variables"})
 	}
 
-	_, err = queries.UpdateAppEnvVars(context.Background(), db.UpdateAppEnvVarsParams{
+	_, err = queries.UpdateAppEnvVars(c.Request.Context(), db.UpdateAppEnvVarsParams{
 		ID:               app.ID,
 		EnvVarsEncrypted: encrypted,
 	})
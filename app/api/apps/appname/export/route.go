@@ -0,0 +1,122 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appconfig"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/signedurl"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+// Get downloads the app as a Helm chart or kustomize base equivalent to the
+// resources the platform applies, so it can be run on any cluster. Env var
+// values are never exported, only their names.
+// GET /api/apps/{name}/export?format=helm|kustomize&deployment_id=...
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		format = k8s.ExportFormatHelm
+	}
+	if format != k8s.ExportFormatHelm && format != k8s.ExportFormatKustomize {
+		return c.JSON(400, map[string]string{"error": "format must be helm or kustomize"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	var deployment db.Deployment
+	switch {
+	case c.Query("deployment_id") != "":
+		depID, err := uuid.Parse(c.Query("deployment_id"))
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "invalid deployment id"})
+		}
+		deployment, err = queries.GetDeploymentByID(c.Request.Context(), depID)
+		if err != nil || deployment.AppID != app.ID {
+			return c.JSON(404, map[string]string{"error": "deployment not found"})
+		}
+	case app.CurrentDeploymentID.Valid:
+		deployment, err = queries.GetDeploymentByID(c.Request.Context(), app.CurrentDeploymentID.Bytes)
+		if err != nil {
+			return c.JSON(404, map[string]string{"error": "deployment not found"})
+		}
+	default:
+		deployment, err = queries.GetLatestDeployment(c.Request.Context(), app.ID)
+		if err != nil {
+			return c.JSON(404, map[string]string{"error": "app has no deployments"})
+		}
+	}
+
+	appConfig, err := appconfig.Load(c.Request.Context(), cfg, queries, app, deployment)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to load app configuration"})
+	}
+	appConfig.Namespace = cfg.K8sNamespacePrefix + app.Name
+
+	var files map[string][]byte
+	if format == k8s.ExportFormatHelm {
+		files, err = k8s.ExportHelmChart(appConfig, fmt.Sprintf("v%d", deployment.Version))
+	} else {
+		files, err = k8s.ExportKustomize(appConfig)
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	var archive bytes.Buffer
+	if err := k8s.WriteArchive(&archive, app.Name, files); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to build archive"})
+	}
+
+	c.Response.Header().Set("Content-Type", "application/gzip")
+	c.Response.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.tgz"`, app.Name, format))
+	c.Response.WriteHeader(200)
+	_, err = c.Response.Write(archive.Bytes())
+	return err
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	// Signed download URLs identify the user they were issued to
+	if signedurl.IsSigned(c.Request.URL) {
+		return signedurl.Verify(cfg.SigningKey(), c.Request.URL, time.Now())
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
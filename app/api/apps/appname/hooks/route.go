@@ -0,0 +1,157 @@
+package hooks
+
+import (
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultTimeoutSeconds = 600
+	maxTimeoutSeconds     = 3600
+	maxCommandLength      = 4096
+)
+
+type UpdateHooksRequest struct {
+	PreDeployCommand  *string `json:"pre_deploy_command"`
+	PostDeployCommand *string `json:"post_deploy_command"`
+	TimeoutSeconds    int32   `json:"timeout_seconds"`
+}
+
+type HooksResponse struct {
+	PreDeployCommand  *string    `json:"pre_deploy_command"`
+	PostDeployCommand *string    `json:"post_deploy_command"`
+	TimeoutSeconds    int32      `json:"timeout_seconds"`
+	UpdatedAt         *time.Time `json:"updated_at,omitempty"`
+}
+
+// Get returns the deploy hooks configured for an app
+// GET /api/apps/{name}/hooks
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	hooks, err := queries.GetDeployHooksByApp(c.Request.Context(), app.ID)
+	if err != nil {
+		// No hooks configured yet
+		return c.JSON(200, HooksResponse{TimeoutSeconds: defaultTimeoutSeconds})
+	}
+
+	return c.JSON(200, toHooksResponse(hooks))
+}
+
+// Put configures the pre/post deploy hooks for an app
+// PUT /api/apps/{name}/hooks
+// Body: { "pre_deploy_command": "rails db:migrate", "post_deploy_command": "bin/warm-cache", "timeout_seconds": 600 }
+func Put(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req UpdateHooksRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+
+	if req.TimeoutSeconds == 0 {
+		req.TimeoutSeconds = defaultTimeoutSeconds
+	}
+
+	if req.TimeoutSeconds < 0 || req.TimeoutSeconds > maxTimeoutSeconds {
+		return c.JSON(400, map[string]string{"error": "timeout_seconds must be between 1 and 3600"})
+	}
+
+	preDeploy := normalizeCommand(req.PreDeployCommand)
+	postDeploy := normalizeCommand(req.PostDeployCommand)
+
+	if (preDeploy != nil && len(*preDeploy) > maxCommandLength) || (postDeploy != nil && len(*postDeploy) > maxCommandLength) {
+		return c.JSON(400, map[string]string{"error": "hook commands must be at most 4096 characters"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	hooks, err := queries.UpsertDeployHooks(c.Request.Context(), db.UpsertDeployHooksParams{
+		AppID:             app.ID,
+		PreDeployCommand:  preDeploy,
+		PostDeployCommand: postDeploy,
+		TimeoutSeconds:    req.TimeoutSeconds,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to update deploy hooks"})
+	}
+
+	return c.JSON(200, toHooksResponse(hooks))
+}
+
+// normalizeCommand trims a command and treats blank commands as unset
+func normalizeCommand(cmd *string) *string {
+	if cmd == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*cmd)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
+
+func toHooksResponse(h db.DeployHook) HooksResponse {
+	return HooksResponse{
+		PreDeployCommand:  h.PreDeployCommand,
+		PostDeployCommand: h.PostDeployCommand,
+		TimeoutSeconds:    h.TimeoutSeconds,
+		UpdatedAt:         &h.UpdatedAt,
+	}
+}
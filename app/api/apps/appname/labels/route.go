@@ -0,0 +1,113 @@
+package labels
+
+import (
+	"encoding/json"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appconfig"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+type UpdateLabelsRequest struct {
+	Labels map[string]string `json:"labels"`
+}
+
+type LabelsResponse struct {
+	Labels map[string]string `json:"labels"`
+}
+
+// Get returns the user-defined labels of an app
+// GET /api/apps/{name}/labels
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	app, err := db.New(pool).GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   c.Param("name"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	labels, err := appconfig.Labels(app)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(200, LabelsResponse{Labels: labels})
+}
+
+// Put replaces the labels of an app. They are added to every Kubernetes
+// resource of the app on its next deploy and reported with usage, so costs
+// can be allocated by team or environment. An empty map clears them.
+// PUT /api/apps/{name}/labels
+// Body: { "labels": { "team": "payments", "env": "production" } }
+func Put(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req UpdateLabelsRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+	if req.Labels == nil {
+		req.Labels = map[string]string{}
+	}
+	if err := k8s.ValidateAppLabels(req.Labels); err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   c.Param("name"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	encoded, _ := json.Marshal(req.Labels)
+	if _, err := queries.UpdateAppLabels(c.Request.Context(), db.UpdateAppLabelsParams{
+		ID:     app.ID,
+		Labels: encoded,
+	}); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to update labels"})
+	}
+
+	return c.JSON(200, LabelsResponse{Labels: req.Labels})
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
@@ -0,0 +1,205 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/logarchive"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/signedurl"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+// DefaultWindow is how far back an archive goes without ?since
+const DefaultWindow = 24 * time.Hour
+
+// MaxWindow bounds the time an archive may span
+const MaxWindow = 7 * 24 * time.Hour
+
+// Get streams the logs an app's containers have retained as a gzip archive.
+// The response names the exact window in Content-Location and carries an
+// ETag, so an interrupted download can resume from that URL with a Range
+// header (and If-Range set to the ETag).
+// GET /api/apps/{name}/logs/download
+// Query params:
+//   - since: how far back to go, e.g. 30m or 24h (default 24h, max 7 days)
+//   - since_time, until: an exact RFC3339 window instead, as returned in
+//     Content-Location
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	since, until, err := parseWindow(c, time.Now().UTC().Truncate(time.Second))
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	k8sClient, err := services.From(c).Cluster(app.Region)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "kubernetes not available"})
+	}
+
+	// Stop reading logs when the client goes away
+	ctx := c.Request.Context()
+	sources, err := k8sClient.LogSources(ctx, app.Name)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": fmt.Sprintf("failed to get logs: %v", err)})
+	}
+
+	archive := &logarchive.Archive{
+		App:     app.Name,
+		Sources: sources,
+		Since:   since,
+		Until:   until,
+		Open: func(ctx context.Context, source k8s.LogSource, since time.Time) (io.ReadCloser, error) {
+			return k8sClient.OpenLogs(ctx, app.Name, source, since)
+		},
+	}
+
+	header := c.Response.Header()
+	header.Set("Content-Type", "application/gzip")
+	header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, archive.Filename()))
+	header.Set("Content-Location", pinnedURL(app.Name, since, until))
+	header.Set("Accept-Ranges", "bytes")
+	header.Set("ETag", archive.ETag())
+	header.Set("Cache-Control", "private, no-cache")
+	header.Set("X-Accel-Buffering", "no") // Disable nginx buffering
+
+	rangeHeader := c.Header("Range")
+	if ifRange := c.Header("If-Range"); ifRange != "" && ifRange != archive.ETag() {
+		// The archive changed; send all of it
+		rangeHeader = ""
+	}
+	if rangeHeader != "" {
+		return writeRange(c, archive, rangeHeader)
+	}
+
+	c.Response.WriteHeader(200)
+	if _, err := archive.WriteTo(ctx, c.Response); err != nil && ctx.Err() == nil {
+		// Headers are sent; the client sees a truncated gzip stream
+		slog.Error("log archive failed", "app", app.Name, "error", err)
+	}
+	return nil
+}
+
+// writeRange answers a Range request. The archive is produced once to learn
+// its size and again to send the requested bytes.
+func writeRange(c *fuego.Context, archive *logarchive.Archive, rangeHeader string) error {
+	ctx := c.Request.Context()
+	size, err := archive.Size(ctx)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": fmt.Sprintf("failed to get logs: %v", err)})
+	}
+
+	start, end, ok, err := logarchive.ParseRange(rangeHeader, size)
+	if errors.Is(err, logarchive.ErrUnsatisfiable) {
+		c.Response.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+		return c.JSON(416, map[string]string{"error": "range not satisfiable"})
+	}
+	if !ok {
+		c.Response.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+		c.Response.WriteHeader(200)
+		if _, err := archive.WriteTo(ctx, c.Response); err != nil && ctx.Err() == nil {
+			slog.Error("log archive failed", "app", archive.App, "error", err)
+		}
+		return nil
+	}
+
+	c.Response.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+	c.Response.Header().Set("Content-Length", strconv.FormatInt(end-start+1, 10))
+	c.Response.WriteHeader(206)
+	if err := archive.WriteRange(ctx, c.Response, start, end); err != nil && ctx.Err() == nil {
+		slog.Error("log archive range failed", "app", archive.App, "error", err)
+	}
+	return nil
+}
+
+// parseWindow reads the archive's time window from the query
+func parseWindow(c *fuego.Context, now time.Time) (since, until time.Time, err error) {
+	if c.Query("since_time") != "" || c.Query("until") != "" {
+		if since, err = time.Parse(time.RFC3339, c.Query("since_time")); err != nil {
+			return since, until, errors.New("since_time must be an RFC3339 time")
+		}
+		if until, err = time.Parse(time.RFC3339, c.Query("until")); err != nil {
+			return since, until, errors.New("until must be an RFC3339 time")
+		}
+		since, until = since.UTC(), until.UTC()
+		switch {
+		case !since.Before(until):
+			return since, until, errors.New("since_time must be before until")
+		case until.After(now):
+			return since, until, errors.New("until must not be in the future")
+		case until.Sub(since) > MaxWindow:
+			return since, until, errors.New("the window may span at most 7 days")
+		}
+		return since, until, nil
+	}
+
+	window := DefaultWindow
+	if s := c.Query("since"); s != "" {
+		if window, err = time.ParseDuration(s); err != nil || window <= 0 {
+			return since, until, errors.New("since must be a duration such as 30m or 24h")
+		}
+		if window > MaxWindow {
+			return since, until, errors.New("since may be at most 168h (7 days)")
+		}
+	}
+	return now.Add(-window), now, nil
+}
+
+// pinnedURL is the URL of the archive of an exact window
+func pinnedURL(appName string, since, until time.Time) string {
+	query := url.Values{}
+	query.Set("since_time", since.Format(time.RFC3339))
+	query.Set("until", until.Format(time.RFC3339))
+	return fmt.Sprintf("/api/apps/%s/logs/download?%s", url.PathEscape(appName), query.Encode())
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if id, ok := c.Get("user_id").(uuid.UUID); ok {
+		return id, nil
+	}
+
+	// Signed download URLs identify the user they were issued to
+	if signedurl.IsSigned(c.Request.URL) {
+		return signedurl.Verify(cfg.SigningKey(), c.Request.URL, time.Now())
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
@@ -6,15 +6,19 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/concurrency"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/signedurl"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/streams"
 	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type LogsResponse struct {
@@ -26,9 +30,10 @@ type LogsResponse struct {
 // Query params:
 //   - tail: number of lines (default 100)
 //   - follow: stream logs via SSE (default false)
+//   - download: return the lines as a plain text attachment (default false)
 func Get(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
 	appName := c.Param("name")
 
 	userID, err := getUserID(c, cfg)
@@ -38,7 +43,7 @@ func Get(c *fuego.Context) error {
 
 	// Verify app ownership
 	queries := db.New(pool)
-	app, err := queries.GetAppByName(context.Background(), db.GetAppByNameParams{
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
 		UserID: userID,
 		Name:   appName,
 	})
@@ -57,17 +62,33 @@ func Get(c *fuego.Context) error {
 	follow := c.Query("follow") == "true"
 
 	// Get K8s client
-	k8sClient, err := k8s.NewClient(cfg.Kubeconfig, cfg.K8sNamespacePrefix)
+	k8sClient, err := services.From(c).Cluster(app.Region)
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": "kubernetes not available"})
 	}
 
 	if follow {
-		return streamLogs(c, k8sClient, app.Name, tailLines)
+		limiter := services.From(c).Concurrency
+		release, err := limiter.Acquire(c.Request.Context(), concurrency.LogStream, app.UserID)
+		if err != nil {
+			return concurrency.Reject(c, err)
+		}
+		defer release()
+
+		manager := services.From(c).Streams
+		stream, err := manager.Open(c.Request.Context(), userID, streams.KindLogs)
+		if err != nil {
+			return c.JSON(429, map[string]string{
+				"error":  "too many open streams, close one before opening another",
+				"reason": "stream_limit",
+			})
+		}
+		defer stream.Close()
+		return streamLogs(c, stream, k8sClient, app.Name, tailLines)
 	}
 
 	// Get recent logs
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
 	defer cancel()
 
 	logs, err := k8sClient.GetRecentLogs(ctx, app.Name, tailLines)
@@ -75,11 +96,30 @@ func Get(c *fuego.Context) error {
 		return c.JSON(500, map[string]string{"error": fmt.Sprintf("failed to get logs: %v", err)})
 	}
 
+	if c.Query("download") == "true" {
+		return downloadLogs(c, app.Name, logs)
+	}
+
 	return c.JSON(200, LogsResponse{Logs: logs})
 }
 
-// streamLogs streams logs via Server-Sent Events (SSE)
-func streamLogs(c *fuego.Context, k8sClient *k8s.Client, appName string, tailLines int64) error {
+// downloadLogs writes log lines as a plain text attachment
+func downloadLogs(c *fuego.Context, appName string, logs []k8s.LogLine) error {
+	var b strings.Builder
+	for _, line := range logs {
+		fmt.Fprintf(&b, "[%s/%s] %s\n", line.Pod, line.Container, line.Message)
+	}
+
+	c.Response.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	c.Response.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-logs.txt"`, appName))
+	c.Response.WriteHeader(200)
+	_, err := c.Response.Write([]byte(b.String()))
+	return err
+}
+
+// streamLogs streams logs via Server-Sent Events (SSE). Heartbeats are
+// sent as SSE comments, and an idle stream ends with an idle event.
+func streamLogs(c *fuego.Context, stream *streams.Stream, k8sClient k8s.Interface, appName string, tailLines int64) error {
 	// Set SSE headers
 	c.Response.Header().Set("Content-Type", "text/event-stream")
 	c.Response.Header().Set("Cache-Control", "no-cache")
@@ -91,9 +131,8 @@ func streamLogs(c *fuego.Context, k8sClient *k8s.Client, appName string, tailLin
 		return c.JSON(500, map[string]string{"error": "streaming not supported"})
 	}
 
-	// Create context that cancels when client disconnects
-	ctx, cancel := context.WithCancel(c.Request.Context())
-	defer cancel()
+	// Ends when the client disconnects or the stream idles
+	ctx := stream.Context()
 
 	// Channel to receive log lines
 	logCh := make(chan k8s.LogLine, 100)
@@ -116,14 +155,26 @@ func streamLogs(c *fuego.Context, k8sClient *k8s.Client, appName string, tailLin
 	for {
 		select {
 		case <-ctx.Done():
+			if stream.Idle() {
+				_, _ = fmt.Fprint(c.Response, "event: idle\ndata: {}\n\n")
+				flusher.Flush()
+			}
 			return nil
+		case <-stream.Heartbeats():
+			if _, err := fmt.Fprint(c.Response, ": ping\n\n"); err != nil {
+				return nil
+			}
+			flusher.Flush()
 		case log, ok := <-logCh:
 			if !ok {
 				return nil
 			}
 			data, _ := json.Marshal(log)
-			_, _ = fmt.Fprintf(c.Response, "data: %s\n\n", data)
+			if _, err := fmt.Fprintf(c.Response, "data: %s\n\n", data); err != nil {
+				return nil
+			}
 			flusher.Flush()
+			stream.Active()
 		}
 	}
 }
@@ -133,6 +184,11 @@ func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
 		return id, nil
 	}
 
+	// Signed download URLs identify the user they were issued to
+	if signedurl.IsSigned(c.Request.URL) {
+		return signedurl.Verify(cfg.SigningKey(), c.Request.URL, time.Now())
+	}
+
 	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
 	if tokenString == "" {
 		tokenString = c.Cookie("access_token")
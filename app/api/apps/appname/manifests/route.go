@@ -0,0 +1,127 @@
+package manifests
+
+import (
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appconfig"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+type DryRunResponse struct {
+	DeploymentID string              `json:"deployment_id"`
+	Image        string              `json:"image"`
+	Valid        bool                `json:"valid"`
+	Checks       []k8s.ManifestCheck `json:"checks"`
+	Manifests    string              `json:"manifests"`
+}
+
+// Get renders the YAML the platform would apply for a deployment, with env
+// values redacted. Defaults to the current deployment, falling back to the
+// latest one. With ?dry_run=true the manifests are also validated by the
+// cluster and a JSON report is returned.
+// GET /api/apps/{name}/manifests?deployment_id=...&dry_run=true
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	var deployment db.Deployment
+	switch {
+	case c.Query("deployment_id") != "":
+		depID, err := uuid.Parse(c.Query("deployment_id"))
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "invalid deployment id"})
+		}
+		deployment, err = queries.GetDeploymentByID(c.Request.Context(), depID)
+		if err != nil || deployment.AppID != app.ID {
+			return c.JSON(404, map[string]string{"error": "deployment not found"})
+		}
+	case app.CurrentDeploymentID.Valid:
+		deployment, err = queries.GetDeploymentByID(c.Request.Context(), app.CurrentDeploymentID.Bytes)
+		if err != nil {
+			return c.JSON(404, map[string]string{"error": "deployment not found"})
+		}
+	default:
+		deployment, err = queries.GetLatestDeployment(c.Request.Context(), app.ID)
+		if err != nil {
+			return c.JSON(404, map[string]string{"error": "app has no deployments"})
+		}
+	}
+
+	appConfig, err := appconfig.Load(c.Request.Context(), cfg, queries, app, deployment)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to load app configuration"})
+	}
+	appConfig.Namespace = cfg.K8sNamespacePrefix + app.Name
+
+	rendered, err := k8s.ManifestsYAML(k8s.Manifests(appConfig))
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	if c.Query("dry_run") != "true" {
+		c.Response.Header().Set("Content-Type", "application/yaml; charset=utf-8")
+		return c.String(200, string(rendered))
+	}
+
+	k8sClient, err := services.From(c).Cluster(app.Region)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "kubernetes not available"})
+	}
+
+	checks, err := k8sClient.DryRun(c.Request.Context(), appConfig)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	valid := true
+	for _, check := range checks {
+		if !check.Valid && !check.Skipped {
+			valid = false
+		}
+	}
+
+	return c.JSON(200, DryRunResponse{
+		DeploymentID: deployment.ID.String(),
+		Image:        deployment.Image,
+		Valid:        valid,
+		Checks:       checks,
+		Manifests:    string(rendered),
+	})
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
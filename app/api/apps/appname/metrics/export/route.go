@@ -0,0 +1,232 @@
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"net/netip"
+	"slices"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/cryptoutil"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/metricsexport"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type ExportRequest struct {
+	// Kind is remote_write or webhook
+	Kind string `json:"kind"`
+	URL  string `json:"url"`
+	// Headers are sent with every push, such as an Authorization header
+	// with the endpoint's API key. Leaving them out keeps the current ones.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+type ExportResponse struct {
+	Kind string `json:"kind"`
+	URL  string `json:"url"`
+	// Headers lists the names of the headers sent; their values are not
+	// shown back
+	Headers      []string   `json:"headers"`
+	LastPushedAt *time.Time `json:"last_pushed_at,omitempty"`
+	LastError    *string    `json:"last_error,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// Get returns the metrics export of an app and the outcome of its last push
+// GET /api/apps/{name}/metrics/export
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   c.Param("name"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	export, err := queries.GetAppMetricsExport(c.Request.Context(), app.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return c.JSON(404, map[string]string{"error": "metrics export not configured"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to get metrics export"})
+	}
+
+	headers, err := cryptoutil.Decrypt(export.HeadersEncrypted, cfg.EncryptionKey)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to decrypt headers"})
+	}
+	return c.JSON(200, newExportResponse(export, headers))
+}
+
+// Put configures where an app's metrics are pushed, every minute once it is
+// deployed
+// PUT /api/apps/{name}/metrics/export
+// Body: { "kind": "remote_write", "url": "https://prometheus-prod-13.grafana.net/api/prom/push", "headers": { "Authorization": "Basic ..." } }
+func Put(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req ExportRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+	if err := metricsexport.Validate(req.Kind, req.URL, req.Headers); err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   c.Param("name"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	headers := req.Headers
+	if headers == nil {
+		existing, err := queries.GetAppMetricsExport(c.Request.Context(), app.ID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(500, map[string]string{"error": "failed to get metrics export"})
+		}
+		if headers, err = cryptoutil.Decrypt(existing.HeadersEncrypted, cfg.EncryptionKey); err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to decrypt headers"})
+		}
+	}
+
+	var headersEncrypted []byte
+	if len(headers) > 0 {
+		if headersEncrypted, err = cryptoutil.Encrypt(headers, cfg.EncryptionKey); err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to encrypt headers"})
+		}
+	}
+
+	export, err := queries.UpsertAppMetricsExport(c.Request.Context(), db.UpsertAppMetricsExportParams{
+		AppID:            app.ID,
+		Kind:             req.Kind,
+		Url:              req.URL,
+		HeadersEncrypted: headersEncrypted,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to save metrics export"})
+	}
+
+	details, _ := json.Marshal(map[string]any{"kind": export.Kind, "url": export.Url})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		AppID:     pgtype.UUID{Bytes: app.ID, Valid: true},
+		Action:    "metrics_export.configured",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(200, newExportResponse(export, headers))
+}
+
+// Delete stops pushing an app's metrics
+// DELETE /api/apps/{name}/metrics/export
+func Delete(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   c.Param("name"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	deleted, err := queries.DeleteAppMetricsExport(c.Request.Context(), app.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to delete metrics export"})
+	}
+	if deleted == 0 {
+		return c.JSON(404, map[string]string{"error": "metrics export not configured"})
+	}
+
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		AppID:     pgtype.UUID{Bytes: app.ID, Valid: true},
+		Action:    "metrics_export.removed",
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(200, map[string]string{"message": "metrics export removed"})
+}
+
+func newExportResponse(export db.AppMetricsExport, headers map[string]string) ExportResponse {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	response := ExportResponse{
+		Kind:      export.Kind,
+		URL:       export.Url,
+		Headers:   names,
+		LastError: export.LastError,
+		CreatedAt: export.CreatedAt,
+		UpdatedAt: export.UpdatedAt,
+	}
+	if export.LastPushedAt.Valid {
+		response.LastPushedAt = &export.LastPushedAt.Time
+	}
+	return response
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
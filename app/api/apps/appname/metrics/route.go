@@ -1,16 +1,15 @@
 package metrics
 
 import (
-	"context"
 	"time"
 
 	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
-	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/metering"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
 	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type MetricsResponse struct {
@@ -59,9 +58,17 @@ type UptimeMetrics struct {
 	CurrentStatus string    `json:"current_status"`
 }
 
+// periods are the metric windows an app can be queried for
+var periods = map[string]time.Duration{
+	"1h":  time.Hour,
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+}
+
 func Get(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
 	appName := c.Param("name")
 
 	userID, err := getUserID(c, cfg)
@@ -70,7 +77,7 @@ func Get(c *fuego.Context) error {
 	}
 
 	queries := db.New(pool)
-	app, err := queries.GetAppByName(context.Background(), db.GetAppByNameParams{
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
 		UserID: userID,
 		Name:   appName,
 	})
@@ -79,11 +86,13 @@ func Get(c *fuego.Context) error {
 	}
 
 	period := c.Query("period")
-	if period == "" {
+	periodDuration, ok := periods[period]
+	if !ok {
 		period = "24h"
+		periodDuration = periods[period]
 	}
 
-	deployments, _ := queries.ListDeploymentsByApp(context.Background(), db.ListDeploymentsByAppParams{
+	deployments, _ := queries.ListDeploymentsByApp(c.Request.Context(), db.ListDeploymentsByAppParams{
 		AppID:  app.ID,
 		Limit:  100,
 		Offset: 0,
@@ -107,8 +116,8 @@ func Get(c *fuego.Context) error {
 	var cpuCurrent, cpuAvg, memCurrent, memAvg float64
 	var podCount, readyPods int
 
-	if k8sClient, ok := c.Get("k8s").(*k8s.Client); ok && k8sClient != nil {
-		if appMetrics, err := k8sClient.GetAppMetrics(context.Background(), app.Name); err == nil {
+	if k8sClient := services.From(c).K8s; k8sClient != nil {
+		if appMetrics, err := k8sClient.GetAppMetrics(c.Request.Context(), app.Name); err == nil {
 			cpuCurrent = appMetrics.TotalCPU * 100 // Convert to percentage (assuming 1 core = 100%)
 			cpuAvg = appMetrics.AvgCPU * 100
 			memCurrent = appMetrics.TotalMemoryMB
@@ -118,6 +127,12 @@ func Get(c *fuego.Context) error {
 		}
 	}
 
+	// Bandwidth metered from the ingress
+	bandwidth, _ := queries.GetAppBandwidth(c.Request.Context(), db.GetAppBandwidthParams{
+		AppID:       app.ID,
+		PeriodStart: time.Now().Add(-periodDuration).Truncate(metering.Period),
+	})
+
 	// Calculate uptime based on ready pods
 	uptimePercent := 100.0
 	if podCount > 0 {
@@ -140,13 +155,13 @@ func Get(c *fuego.Context) error {
 			Unit:    "MB",
 		},
 		Network: NetworkMetrics{
-			IngressBytes:  0, // Requires CNI metrics or service mesh
-			EgressBytes:   0,
-			RequestsTotal: 0,
+			IngressBytes:  bandwidth.IngressBytes,
+			EgressBytes:   bandwidth.EgressBytes,
+			RequestsTotal: bandwidth.Requests,
 		},
 		Requests: RequestMetrics{
-			Total:      0, // Requires Prometheus/service mesh integration
-			PerSecond:  0,
+			Total:      bandwidth.Requests,
+			PerSecond:  float64(bandwidth.Requests) / periodDuration.Seconds(),
 			ByStatus:   map[string]int64{},
 			AvgLatency: 0,
 			P95Latency: 0,
@@ -160,7 +175,7 @@ func Get(c *fuego.Context) error {
 		},
 		Uptime: UptimeMetrics{
 			Percentage:    uptimePercent,
-			CurrentStatus: app.Status,
+			CurrentStatus: string(app.Status),
 		},
 	}
 
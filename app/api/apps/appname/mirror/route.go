@@ -0,0 +1,261 @@
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/netip"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appconfig"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type MirrorRequest struct {
+	TargetApp string `json:"target_app"`
+	Percent   int32  `json:"percent"`
+	// DurationMinutes time-boxes the mirror; it defaults to an hour
+	DurationMinutes int `json:"duration_minutes"`
+}
+
+type MirrorResponse struct {
+	Active    bool       `json:"active"`
+	TargetApp string     `json:"target_app,omitempty"`
+	Percent   int32      `json:"percent,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Synced    *bool      `json:"synced,omitempty"`
+}
+
+// Get returns the active traffic mirror of an app
+// GET /api/apps/{name}/mirror
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	mirror, err := queries.GetAppMirror(c.Request.Context(), app.ID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && !mirror.ExpiresAt.After(time.Now())) {
+		return c.JSON(200, MirrorResponse{})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to get mirror"})
+	}
+
+	return c.JSON(200, MirrorResponse{
+		Active:    true,
+		TargetApp: mirror.TargetAppName,
+		Percent:   mirror.Percent,
+		ExpiresAt: &mirror.ExpiresAt,
+	})
+}
+
+// Put starts or updates mirroring a percentage of an app's requests to
+// another app, typically its staging app. Mirrored requests are
+// fire-and-forget and the mirror stops on its own once the duration is up.
+// PUT /api/apps/{name}/mirror
+// Body: { "target_app": "web-staging", "percent": 10, "duration_minutes": 60 }
+func Put(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req MirrorRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+
+	if err := k8s.ValidateMirror(appName, &k8s.MirrorConfig{TargetApp: req.TargetApp, Percent: req.Percent}); err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	duration := k8s.DefaultMirrorDuration
+	if req.DurationMinutes != 0 {
+		duration = time.Duration(req.DurationMinutes) * time.Minute
+	}
+	if duration <= 0 || duration > k8s.MaxMirrorDuration {
+		return c.JSON(400, map[string]string{"error": "duration_minutes must be between 1 and 1440"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	target, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   req.TargetApp,
+	})
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "target app not found"})
+	}
+	// Mirrored requests travel over the cluster network
+	if target.Region != app.Region {
+		return c.JSON(400, map[string]string{"error": "target app must run in the same region, " + app.Name + " runs in " + app.Region + " and " + target.Name + " in " + target.Region})
+	}
+
+	mirror, err := queries.UpsertAppMirror(c.Request.Context(), db.UpsertAppMirrorParams{
+		AppID:       app.ID,
+		TargetAppID: target.ID,
+		Percent:     req.Percent,
+		ExpiresAt:   time.Now().Add(duration),
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to save mirror"})
+	}
+
+	// Start mirroring right away when the app is deployed
+	synced := false
+	if app.CurrentDeploymentID.Valid {
+		if err := applyMirror(c.Request.Context(), services.From(c), queries, app); err != nil {
+			return c.JSON(500, map[string]string{"error": "mirror saved but failed to apply: " + err.Error()})
+		}
+		synced = true
+	}
+
+	details, _ := json.Marshal(map[string]any{
+		"target_app": target.Name,
+		"percent":    mirror.Percent,
+		"expires_at": mirror.ExpiresAt,
+		"synced":     synced,
+	})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		AppID:     pgtype.UUID{Bytes: app.ID, Valid: true},
+		Action:    "mirror.started",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(200, MirrorResponse{
+		Active:    true,
+		TargetApp: target.Name,
+		Percent:   mirror.Percent,
+		ExpiresAt: &mirror.ExpiresAt,
+		Synced:    &synced,
+	})
+}
+
+// Delete stops mirroring an app's traffic before the mirror expires
+// DELETE /api/apps/{name}/mirror
+func Delete(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	mirror, err := queries.GetAppMirror(c.Request.Context(), app.ID)
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app has no mirror"})
+	}
+
+	// Remove the resources first so a failure leaves the mirror to retry or
+	// to the expiry job
+	k8sClient, err := services.From(c).Cluster(app.Region)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "kubernetes is not available"})
+	}
+	if err := k8sClient.RemoveMirror(c.Request.Context(), app.Name); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to stop mirror: " + err.Error()})
+	}
+
+	if err := queries.DeleteAppMirror(c.Request.Context(), app.ID); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to delete mirror"})
+	}
+
+	details, _ := json.Marshal(map[string]any{"target_app": mirror.TargetAppName})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		AppID:     pgtype.UUID{Bytes: app.ID, Valid: true},
+		Action:    "mirror.stopped",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(200, map[string]string{"message": "mirror stopped"})
+}
+
+// applyMirror routes a running app's traffic through the stored mirror
+func applyMirror(ctx context.Context, svc *services.Services, queries *db.Queries, app db.App) error {
+	cfg := svc.Config
+	appConfig, err := appconfig.Load(ctx, cfg, queries, app, db.Deployment{})
+	if err != nil {
+		return err
+	}
+
+	k8sClient, err := svc.Cluster(app.Region)
+	if err != nil {
+		return err
+	}
+	return k8sClient.ApplyMirror(ctx, appConfig)
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
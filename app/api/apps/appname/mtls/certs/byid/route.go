@@ -0,0 +1,163 @@
+package cert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appconfig"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Get downloads the PEM encoded certificate. The private key is only
+// available when the certificate is issued.
+// GET /api/apps/{name}/mtls/certs/{id}
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	certID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "certificate not found"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	cert, err := queries.GetClientCertificate(c.Request.Context(), db.GetClientCertificateParams{
+		ID:    certID,
+		AppID: app.ID,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "certificate not found"})
+	}
+
+	c.Response.Header().Set("Content-Type", "application/x-pem-file")
+	c.Response.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.crt"`, app.Name, cert.Name))
+	c.Response.WriteHeader(200)
+	_, err = c.Response.Write([]byte(cert.Certificate))
+	return err
+}
+
+// Delete revokes a client certificate. When the app is deployed the ingress
+// stops accepting the certificate right away.
+// DELETE /api/apps/{name}/mtls/certs/{id}
+func Delete(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	certID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "certificate not found"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	cert, err := queries.RevokeClientCertificate(c.Request.Context(), db.RevokeClientCertificateParams{
+		ID:    certID,
+		AppID: app.ID,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "certificate not found"})
+	}
+
+	details, _ := json.Marshal(map[string]any{"name": cert.Name, "serial": cert.Serial})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		AppID:     pgtype.UUID{Bytes: app.ID, Valid: true},
+		Action:    "mtls.cert_revoked",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	// The verify endpoint rejects the serial from now on; publishing the new
+	// CRL keeps the secret in the app namespace current
+	synced := false
+	if app.CurrentDeploymentID.Valid {
+		if err := publishCRL(c.Request.Context(), services.From(c), queries, app); err != nil {
+			return c.JSON(500, map[string]string{"error": "certificate revoked but failed to update the ingress: " + err.Error()})
+		}
+		synced = true
+	}
+
+	return c.JSON(200, map[string]any{
+		"message": "certificate revoked",
+		"serial":  cert.Serial,
+		"synced":  synced,
+	})
+}
+
+func publishCRL(ctx context.Context, svc *services.Services, queries *db.Queries, app db.App) error {
+	cfg := svc.Config
+	appConfig, err := appconfig.Load(ctx, cfg, queries, app, db.Deployment{})
+	if err != nil {
+		return err
+	}
+	if appConfig.MTLS == nil {
+		return nil
+	}
+
+	k8sClient, err := svc.Cluster(app.Region)
+	if err != nil {
+		return err
+	}
+	return k8sClient.ApplyMTLS(ctx, appConfig)
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package certs
+
+import (
+	"encoding/json"
+	"errors"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/pki"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Client certificate states
+const (
+	StatusActive  = "active"
+	StatusRevoked = "revoked"
+	StatusExpired = "expired"
+)
+
+type CertificateResponse struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Serial      string     `json:"serial"`
+	Fingerprint string     `json:"fingerprint"`
+	Status      string     `json:"status"`
+	NotAfter    time.Time  `json:"not_after"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// IssuedCertificateResponse includes the private key, which is only returned
+// when the certificate is issued
+type IssuedCertificateResponse struct {
+	CertificateResponse
+	Certificate   string `json:"certificate"`
+	PrivateKey    string `json:"private_key"`
+	CACertificate string `json:"ca_certificate"`
+}
+
+type IssueRequest struct {
+	Name         string `json:"name"`
+	ValidityDays int    `json:"validity_days"`
+}
+
+// Get lists the client certificates issued for an app
+// GET /api/apps/{name}/mtls/certs
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	certs, err := queries.ListClientCertificatesByApp(c.Request.Context(), app.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list certificates"})
+	}
+
+	response := make([]CertificateResponse, 0, len(certs))
+	for _, cert := range certs {
+		response = append(response, toCertificateResponse(cert, time.Now()))
+	}
+
+	return c.JSON(200, response)
+}
+
+// Post issues a client certificate from the platform CA. The private key is
+// part of the response and is not stored, so it cannot be downloaded again.
+// POST /api/apps/{name}/mtls/certs
+// Body: { "name": "ci-runner", "validity_days": 90 }
+func Post(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req IssueRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 64 {
+		return c.JSON(400, map[string]string{"error": "name is required and must be at most 64 characters"})
+	}
+	if req.ValidityDays < 0 {
+		return c.JSON(400, map[string]string{"error": "validity_days must be positive"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	ca, err := pki.LoadCAFiles(cfg.MTLSCACertFile, cfg.MTLSCAKeyFile)
+	if errors.Is(err, pki.ErrNotConfigured) {
+		return c.JSON(503, map[string]string{"error": "mTLS is not available on this platform"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to load platform CA"})
+	}
+
+	issued, err := ca.IssueClientCert(app.Name+":"+req.Name, app.Name, time.Duration(req.ValidityDays)*24*time.Hour)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	cert, err := queries.CreateClientCertificate(c.Request.Context(), db.CreateClientCertificateParams{
+		AppID:       app.ID,
+		Name:        req.Name,
+		Serial:      issued.Serial,
+		Fingerprint: issued.Fingerprint,
+		Certificate: string(issued.CertPEM),
+		NotAfter:    issued.NotAfter,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to record certificate"})
+	}
+
+	details, _ := json.Marshal(map[string]any{"name": cert.Name, "serial": cert.Serial, "not_after": cert.NotAfter})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		AppID:     pgtype.UUID{Bytes: app.ID, Valid: true},
+		Action:    "mtls.cert_issued",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(201, IssuedCertificateResponse{
+		CertificateResponse: toCertificateResponse(cert, time.Now()),
+		Certificate:         string(issued.CertPEM),
+		PrivateKey:          string(issued.KeyPEM),
+		CACertificate:       string(ca.CertPEM),
+	})
+}
+
+func toCertificateResponse(cert db.ClientCertificate, now time.Time) CertificateResponse {
+	response := CertificateResponse{
+		ID:          cert.ID.String(),
+		Name:        cert.Name,
+		Serial:      cert.Serial,
+		Fingerprint: cert.Fingerprint,
+		Status:      StatusActive,
+		NotAfter:    cert.NotAfter,
+		CreatedAt:   cert.CreatedAt,
+	}
+	switch {
+	case cert.RevokedAt.Valid:
+		response.Status = StatusRevoked
+		response.RevokedAt = &cert.RevokedAt.Time
+	case now.After(cert.NotAfter):
+		response.Status = StatusExpired
+	}
+	return response
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
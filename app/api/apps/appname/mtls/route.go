@@ -0,0 +1,187 @@
+package mtls
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/netip"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appconfig"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/pki"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type MTLSResponse struct {
+	Enabled bool `json:"enabled"`
+	// CACertificate is the platform CA clients are issued from
+	CACertificate string     `json:"ca_certificate,omitempty"`
+	EnabledAt     *time.Time `json:"enabled_at,omitempty"`
+	Synced        *bool      `json:"synced,omitempty"`
+}
+
+type MTLSRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// Get returns whether an app requires client certificates
+// GET /api/apps/{name}/mtls
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	response := MTLSResponse{}
+	if ca, err := pki.LoadCAFiles(cfg.MTLSCACertFile, cfg.MTLSCAKeyFile); err == nil {
+		response.CACertificate = string(ca.CertPEM)
+	}
+
+	settings, err := queries.GetAppMTLS(c.Request.Context(), app.ID)
+	switch {
+	case err == nil:
+		response.Enabled = true
+		response.EnabledAt = &settings.CreatedAt
+	case !errors.Is(err, pgx.ErrNoRows):
+		return c.JSON(500, map[string]string{"error": "failed to get mtls settings"})
+	}
+
+	return c.JSON(200, response)
+}
+
+// Put turns client certificate enforcement at the ingress on or off. While
+// enabled, only clients presenting an unrevoked certificate issued for the
+// app can reach it.
+// PUT /api/apps/{name}/mtls
+// Body: { "enabled": true }
+func Put(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req MTLSRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	response := MTLSResponse{Enabled: req.Enabled}
+	if req.Enabled {
+		ca, err := pki.LoadCAFiles(cfg.MTLSCACertFile, cfg.MTLSCAKeyFile)
+		if errors.Is(err, pki.ErrNotConfigured) {
+			return c.JSON(503, map[string]string{"error": "mTLS is not available on this platform"})
+		}
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to load platform CA"})
+		}
+		response.CACertificate = string(ca.CertPEM)
+
+		settings, err := queries.EnableAppMTLS(c.Request.Context(), app.ID)
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to enable mtls"})
+		}
+		response.EnabledAt = &settings.CreatedAt
+	} else if err := queries.DisableAppMTLS(c.Request.Context(), app.ID); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to disable mtls"})
+	}
+
+	// Enforce right away when the app is deployed
+	synced := false
+	if app.CurrentDeploymentID.Valid {
+		if err := applyMTLS(c.Request.Context(), services.From(c), queries, app); err != nil {
+			return c.JSON(500, map[string]string{"error": "mtls saved but failed to apply: " + err.Error()})
+		}
+		synced = true
+	}
+	response.Synced = &synced
+
+	action := "mtls.disabled"
+	if req.Enabled {
+		action = "mtls.enabled"
+	}
+	details, _ := json.Marshal(map[string]any{"synced": synced})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		AppID:     pgtype.UUID{Bytes: app.ID, Valid: true},
+		Action:    action,
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(200, response)
+}
+
+// applyMTLS updates the ingress of a running app to the stored settings
+func applyMTLS(ctx context.Context, svc *services.Services, queries *db.Queries, app db.App) error {
+	cfg := svc.Config
+	appConfig, err := appconfig.Load(ctx, cfg, queries, app, db.Deployment{})
+	if err != nil {
+		return err
+	}
+
+	k8sClient, err := svc.Cluster(app.Region)
+	if err != nil {
+		return err
+	}
+	return k8sClient.ApplyMTLS(ctx, appConfig)
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package trust
+
+import (
+	"encoding/json"
+	"net/netip"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Delete stops trusting a repository. Deploy tokens already exchanged keep
+// working until they expire.
+// DELETE /api/apps/{name}/oidc/trusts/{id}
+func Delete(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+	if claims, ok := c.Get("claims").(*auth.Claims); ok && claims.App != "" {
+		return c.JSON(403, map[string]string{"error": "deploy tokens cannot manage trusts"})
+	}
+
+	trustID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "trust not found"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   c.Param("name"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	deleted, err := queries.DeleteAppOIDCTrust(c.Request.Context(), db.DeleteAppOIDCTrustParams{
+		ID:    trustID,
+		AppID: app.ID,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to delete trust"})
+	}
+	if deleted == 0 {
+		return c.JSON(404, map[string]string{"error": "trust not found"})
+	}
+
+	details, _ := json.Marshal(map[string]any{"trust_id": trustID})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		AppID:     pgtype.UUID{Bytes: app.ID, Valid: true},
+		Action:    "oidc_trust.deleted",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.NoContent()
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
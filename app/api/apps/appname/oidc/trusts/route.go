@@ -0,0 +1,175 @@
+// Package trusts manages which CI repositories may exchange their OIDC
+// tokens for deploy tokens of an app.
+package trusts
+
+import (
+	"encoding/json"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/provenance"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type CreateTrustRequest struct {
+	Provider   string `json:"provider"`
+	Repository string `json:"repository"`
+	// Ref such as refs/heads/main limits the trust to runs on it
+	Ref string `json:"ref"`
+}
+
+type TrustResponse struct {
+	ID         string    `json:"id"`
+	Provider   string    `json:"provider"`
+	Repository string    `json:"repository"`
+	Ref        string    `json:"ref,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Get lists the repositories whose CI runs may get deploy tokens of the app
+// GET /api/apps/{name}/oidc/trusts
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   c.Param("name"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	trusts, err := queries.ListAppOIDCTrusts(c.Request.Context(), app.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list trusts"})
+	}
+
+	response := make([]TrustResponse, len(trusts))
+	for i, t := range trusts {
+		response[i] = toTrustResponse(t)
+	}
+
+	return c.JSON(200, response)
+}
+
+// Post trusts the CI runs of a repository to exchange their OIDC tokens for
+// deploy tokens of the app. Deploy tokens cannot add trusts.
+// POST /api/apps/{name}/oidc/trusts
+// Body: { "provider": "github", "repository": "acme/shop", "ref": "refs/heads/main" }
+func Post(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+	if claims, ok := c.Get("claims").(*auth.Claims); ok && claims.App != "" {
+		return c.JSON(403, map[string]string{"error": "deploy tokens cannot manage trusts"})
+	}
+
+	var req CreateTrustRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+	if req.Provider == "" {
+		req.Provider = provenance.ProviderGitHub
+	}
+	if !provenance.ValidProvider(req.Provider) {
+		return c.JSON(400, map[string]string{"error": "provider must be github or gitlab"})
+	}
+	repository, ok := provenance.NormalizeRepository(req.Repository)
+	if !ok {
+		return c.JSON(400, map[string]string{"error": "repository must be a path such as owner/name"})
+	}
+	req.Ref = strings.TrimSpace(req.Ref)
+	if req.Ref != "" && !strings.HasPrefix(req.Ref, "refs/") {
+		return c.JSON(400, map[string]string{"error": "ref must be a full ref such as refs/heads/main"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   c.Param("name"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	trust, err := queries.CreateAppOIDCTrust(c.Request.Context(), db.CreateAppOIDCTrustParams{
+		AppID:      app.ID,
+		Provider:   req.Provider,
+		Repository: repository,
+		Ref:        req.Ref,
+		CreatedBy:  pgtype.UUID{Bytes: userID, Valid: true},
+	})
+	if err != nil {
+		return c.JSON(409, map[string]string{"error": "the app already trusts this repository"})
+	}
+
+	details, _ := json.Marshal(map[string]any{
+		"provider":   trust.Provider,
+		"repository": trust.Repository,
+		"ref":        trust.Ref,
+	})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		AppID:     pgtype.UUID{Bytes: app.ID, Valid: true},
+		Action:    "oidc_trust.created",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(201, toTrustResponse(trust))
+}
+
+func toTrustResponse(t db.AppOidcTrust) TrustResponse {
+	return TrustResponse{
+		ID:         t.ID.String(),
+		Provider:   t.Provider,
+		Repository: t.Repository,
+		Ref:        t.Ref,
+		CreatedAt:  t.CreatedAt,
+	}
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
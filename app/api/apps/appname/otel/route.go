@@ -0,0 +1,293 @@
+package otel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/netip"
+	"slices"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appconfig"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/cryptoutil"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type CollectorRequest struct {
+	// Endpoint is the OTLP endpoint of the backend, such as
+	// https://api.honeycomb.io:443
+	Endpoint string `json:"endpoint"`
+	// Protocol is grpc (default) or http
+	Protocol string `json:"protocol,omitempty"`
+	// Headers are sent with every export, such as the backend's API key.
+	// Leaving them out keeps the current ones.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+type CollectorResponse struct {
+	Endpoint string `json:"endpoint"`
+	Protocol string `json:"protocol"`
+	// Headers lists the names of the headers sent; their values are not
+	// shown back
+	Headers []string `json:"headers"`
+	// Env are the variables injected into the app
+	Env       map[string]string `json:"env"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	Synced    *bool             `json:"synced,omitempty"`
+}
+
+// Get returns the OpenTelemetry collector of an app
+// GET /api/apps/{name}/otel
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   c.Param("name"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	collector, err := queries.GetAppOtelCollector(c.Request.Context(), app.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return c.JSON(404, map[string]string{"error": "otel collector not enabled"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to get otel collector"})
+	}
+
+	headers, err := cryptoutil.Decrypt(collector.HeadersEncrypted, cfg.EncryptionKey)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to decrypt headers"})
+	}
+	return c.JSON(200, newCollectorResponse(app.Name, collector, headers))
+}
+
+// Put runs an OpenTelemetry collector for an app, exporting to the given
+// backend, or changes its backend. A deployed app gets the collector right
+// away; enabling it rolls the app's pods to inject the OTEL_* variables.
+// PUT /api/apps/{name}/otel
+// Body: { "endpoint": "https://api.honeycomb.io:443", "protocol": "grpc", "headers": { "x-honeycomb-team": "..." } }
+func Put(c *fuego.Context) error {
+	svc := services.From(c)
+	cfg := svc.Config
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req CollectorRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+	if req.Protocol == "" {
+		req.Protocol = k8s.OTelProtocolGRPC
+	}
+	if err := k8s.ValidateOTelCollector(&k8s.OTelCollectorConfig{
+		Endpoint: req.Endpoint,
+		Protocol: req.Protocol,
+		Headers:  req.Headers,
+	}); err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	queries := db.New(svc.DB)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   c.Param("name"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	existing, err := queries.GetAppOtelCollector(c.Request.Context(), app.ID)
+	enabled := errors.Is(err, pgx.ErrNoRows)
+	if err != nil && !enabled {
+		return c.JSON(500, map[string]string{"error": "failed to get otel collector"})
+	}
+
+	headers := req.Headers
+	if headers == nil {
+		if headers, err = cryptoutil.Decrypt(existing.HeadersEncrypted, cfg.EncryptionKey); err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to decrypt headers"})
+		}
+	}
+	var headersEncrypted []byte
+	if len(headers) > 0 {
+		if headersEncrypted, err = cryptoutil.Encrypt(headers, cfg.EncryptionKey); err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to encrypt headers"})
+		}
+	}
+
+	collector, err := queries.UpsertAppOtelCollector(c.Request.Context(), db.UpsertAppOtelCollectorParams{
+		AppID:            app.ID,
+		Endpoint:         req.Endpoint,
+		Protocol:         req.Protocol,
+		HeadersEncrypted: headersEncrypted,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to save otel collector"})
+	}
+
+	synced := false
+	if app.CurrentDeploymentID.Valid {
+		if err := applyCollector(c.Request.Context(), svc, queries, app, enabled); err != nil {
+			return c.JSON(500, map[string]string{"error": "otel collector saved but failed to apply: " + err.Error()})
+		}
+		synced = true
+	}
+
+	action := "otel_collector.updated"
+	if enabled {
+		action = "otel_collector.enabled"
+	}
+	details, _ := json.Marshal(map[string]any{
+		"endpoint": collector.Endpoint,
+		"protocol": collector.Protocol,
+		"synced":   synced,
+	})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		AppID:     pgtype.UUID{Bytes: app.ID, Valid: true},
+		Action:    action,
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	response := newCollectorResponse(app.Name, collector, headers)
+	response.Synced = &synced
+	return c.JSON(200, response)
+}
+
+// Delete removes the OpenTelemetry collector of an app. A deployed app's
+// pods are rolled without the OTEL_* variables.
+// DELETE /api/apps/{name}/otel
+func Delete(c *fuego.Context) error {
+	svc := services.From(c)
+	cfg := svc.Config
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(svc.DB)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   c.Param("name"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	deleted, err := queries.DeleteAppOtelCollector(c.Request.Context(), app.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to delete otel collector"})
+	}
+	if deleted == 0 {
+		return c.JSON(404, map[string]string{"error": "otel collector not enabled"})
+	}
+
+	synced := false
+	if app.CurrentDeploymentID.Valid {
+		if err := applyCollector(c.Request.Context(), svc, queries, app, true); err != nil {
+			return c.JSON(500, map[string]string{"error": "otel collector deleted but failed to apply: " + err.Error()})
+		}
+		synced = true
+	}
+
+	details, _ := json.Marshal(map[string]any{"synced": synced})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		AppID:     pgtype.UUID{Bytes: app.ID, Valid: true},
+		Action:    "otel_collector.disabled",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(200, map[string]any{"message": "otel collector removed", "synced": synced})
+}
+
+func newCollectorResponse(appName string, collector db.AppOtelCollector, headers map[string]string) CollectorResponse {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return CollectorResponse{
+		Endpoint:  collector.Endpoint,
+		Protocol:  collector.Protocol,
+		Headers:   names,
+		Env:       k8s.OTelEnv(appName),
+		CreatedAt: collector.CreatedAt,
+		UpdatedAt: collector.UpdatedAt,
+	}
+}
+
+// applyCollector applies the collector of a running app, and its env when
+// the OTEL_* variables were added or removed, rolling its pods
+func applyCollector(ctx context.Context, svc *services.Services, queries *db.Queries, app db.App, envChanged bool) error {
+	cfg := svc.Config
+	appConfig, err := appconfig.Load(ctx, cfg, queries, app, db.Deployment{})
+	if err != nil {
+		return err
+	}
+
+	k8sClient, err := svc.Cluster(app.Region)
+	if err != nil {
+		return err
+	}
+	if err := k8sClient.ApplyOTelCollector(ctx, appConfig); err != nil {
+		return err
+	}
+	if !envChanged {
+		return nil
+	}
+	return k8sClient.ApplyEnv(ctx, appConfig)
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
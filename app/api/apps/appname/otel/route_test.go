@@ -0,0 +1,28 @@
+package otel
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/testutil"
+	"github.com/google/uuid"
+)
+
+func TestPut_Validation(t *testing.T) {
+	ta := testutil.NewTestApp().WithAuth(uuid.New(), "octocat")
+	ta.App.Put("/api/apps/shop/otel", Put)
+	ta.App.Mount()
+
+	put := func(body CollectorRequest) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		ta.App.ServeHTTP(w, testutil.MakeRequest(t, http.MethodPut, "/api/apps/shop/otel", body, nil))
+		return w
+	}
+
+	// Requests are rejected before the database is reached
+	testutil.AssertStatusCode(t, put(CollectorRequest{Endpoint: "https://api.honeycomb.io:443", Protocol: "zipkin"}), http.StatusBadRequest)
+	testutil.AssertStatusCode(t, put(CollectorRequest{Endpoint: "http://collector.example.com:4317"}), http.StatusBadRequest)
+	testutil.AssertStatusCode(t, put(CollectorRequest{Endpoint: "https://key@collector.example.com"}), http.StatusBadRequest)
+}
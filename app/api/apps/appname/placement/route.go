@@ -0,0 +1,168 @@
+package placement
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/plans"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+type PlacementResponse struct {
+	NodeSelector map[string]string `json:"node_selector"`
+	Tolerations  []k8s.Toleration  `json:"tolerations"`
+	Synced       *bool             `json:"synced,omitempty"`
+	UpdatedAt    *time.Time        `json:"updated_at,omitempty"`
+}
+
+// Get returns the node placement of an app
+// GET /api/apps/{name}/placement
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	placement, err := queries.GetAppPlacement(c.Request.Context(), app.ID)
+	if err != nil {
+		// No placement configured, pods go wherever the scheduler puts them
+		return c.JSON(200, PlacementResponse{
+			NodeSelector: map[string]string{},
+			Tolerations:  []k8s.Toleration{},
+		})
+	}
+
+	return c.JSON(200, toPlacementResponse(placement))
+}
+
+// Put pins an enterprise app to a dedicated node pool. The node selector must
+// match at least one schedulable node in the cluster. An empty body clears it.
+// PUT /api/apps/{name}/placement
+// Body: { "node_selector": { "nexo.build/pool": "dedicated" }, "tolerations": [{ "key": "dedicated", "operator": "Equal", "value": "acme", "effect": "NoSchedule" }] }
+func Put(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req k8s.Placement
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	if !req.IsZero() && !plans.ForSize(app.Size).DedicatedNodes {
+		return c.JSON(403, map[string]string{"error": "dedicated node pools require the enterprise plan"})
+	}
+
+	if err := k8s.ValidatePlacement(&req); err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	k8sClient, err := services.From(c).Cluster(app.Region)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "kubernetes not available"})
+	}
+
+	exists, err := k8sClient.NodePoolExists(c.Request.Context(), req.NodeSelector)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+	if !exists {
+		return c.JSON(400, map[string]string{"error": "no schedulable nodes match the requested node pool"})
+	}
+
+	if req.NodeSelector == nil {
+		req.NodeSelector = map[string]string{}
+	}
+	if req.Tolerations == nil {
+		req.Tolerations = []k8s.Toleration{}
+	}
+
+	nodeSelector, _ := json.Marshal(req.NodeSelector)
+	tolerations, _ := json.Marshal(req.Tolerations)
+
+	placement, err := queries.UpsertAppPlacement(c.Request.Context(), db.UpsertAppPlacementParams{
+		AppID:        app.ID,
+		NodeSelector: nodeSelector,
+		Tolerations:  tolerations,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to update placement"})
+	}
+
+	// Move running pods right away when the app is deployed
+	synced := false
+	if app.CurrentDeploymentID.Valid {
+		if err := k8sClient.ApplyPlacement(c.Request.Context(), app.Name, &req); err != nil {
+			return c.JSON(500, map[string]string{"error": "placement saved but failed to apply: " + err.Error()})
+		}
+		synced = true
+	}
+
+	response := toPlacementResponse(placement)
+	response.Synced = &synced
+
+	return c.JSON(200, response)
+}
+
+func toPlacementResponse(p db.AppPlacement) PlacementResponse {
+	response := PlacementResponse{
+		NodeSelector: map[string]string{},
+		Tolerations:  []k8s.Toleration{},
+		UpdatedAt:    &p.UpdatedAt,
+	}
+	_ = json.Unmarshal(p.NodeSelector, &response.NodeSelector)
+	_ = json.Unmarshal(p.Tolerations, &response.Tolerations)
+	return response
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
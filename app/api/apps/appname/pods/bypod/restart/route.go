@@ -0,0 +1,106 @@
+package restart
+
+import (
+	"encoding/json"
+	"errors"
+	"net/netip"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/actor"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type RestartResponse struct {
+	Success bool   `json:"success"`
+	Pod     string `json:"pod"`
+	Message string `json:"message"`
+}
+
+// Post restarts a single pod by deleting it; its Deployment schedules a replacement
+// POST /api/apps/{name}/pods/{pod}/restart
+func Post(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+	podName := c.Param("pod")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	// Verify app ownership
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	// Get K8s client
+	k8sClient, err := services.From(c).Cluster(app.Region)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "kubernetes not available"})
+	}
+
+	if err := k8sClient.DeletePod(c.Request.Context(), app.Name, podName); err != nil {
+		if errors.Is(err, k8s.ErrPodNotFound) {
+			return c.JSON(404, map[string]string{"error": "pod not found"})
+		}
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	details, _ := json.Marshal(map[string]any{
+		"pod": podName,
+	})
+	by := actor.From(c)
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:     by.User(),
+		AppID:      pgtype.UUID{Bytes: app.ID, Valid: true},
+		Action:     "pod.restarted",
+		Details:    details,
+		IpAddress:  clientIP(c),
+		ApiTokenID: by.Token(),
+	})
+
+	return c.JSON(200, RestartResponse{
+		Success: true,
+		Pod:     podName,
+		Message: "pod deleted, a replacement is being scheduled",
+	})
+}
+
+// clientIP returns the request's client address for the audit log
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if id, ok := c.Get("user_id").(uuid.UUID); ok {
+		return id, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
@@ -0,0 +1,35 @@
+package pods
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/store"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/testutil"
+)
+
+func TestGet(t *testing.T) {
+	s := store.NewMemory()
+	user := testutil.SeedUser(t, s, "alice")
+	testutil.SeedApp(t, s, user.ID, "shop")
+	cluster := k8s.NewFake()
+	cluster.Pods["shop"] = []k8s.PodInfo{
+		{Name: "shop-abc", Process: "web", Phase: "Running", Ready: true},
+		{Name: "shop-def", Process: "worker", Phase: "Running", Restarts: 2},
+	}
+
+	ta := testutil.NewTestApp().WithStore(s).WithK8s(cluster).WithAuth(user.ID, user.Username)
+	ta.App.Get("/api/apps/{name}/pods", Get)
+	ta.App.Mount()
+
+	w := httptest.NewRecorder()
+	ta.App.ServeHTTP(w, testutil.MakeRequest(t, http.MethodGet, "/api/apps/shop/pods", nil, nil))
+	testutil.AssertStatusCode(t, w, http.StatusOK)
+
+	response := testutil.ParseResponse[PodsResponse](t, w)
+	if len(response.Pods) != 2 || response.Pods[1].Restarts != 2 {
+		t.Errorf("expected the cluster's pods, got %+v", response.Pods)
+	}
+}
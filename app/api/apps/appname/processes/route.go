@@ -0,0 +1,253 @@
+package processes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appconfig"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/plans"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+const maxProcessTypes = 10
+
+type ProcessRequest struct {
+	Type     string `json:"type"`
+	Command  string `json:"command"`
+	Replicas *int32 `json:"replicas"`
+	CPU      string `json:"cpu"`
+	Memory   string `json:"memory"`
+}
+
+type UpdateProcessesRequest struct {
+	Processes []ProcessRequest `json:"processes"`
+}
+
+type ProcessResponse struct {
+	Type     string  `json:"type"`
+	Command  *string `json:"command"`
+	Replicas int32   `json:"replicas"`
+	CPU      *string `json:"cpu"`
+	Memory   *string `json:"memory"`
+}
+
+type ProcessesResponse struct {
+	Processes []ProcessResponse `json:"processes"`
+	Synced    *bool             `json:"synced,omitempty"`
+}
+
+// Get returns the process formation of an app. Apps without a formation
+// run a single web process.
+// GET /api/apps/{name}/processes
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	procs, err := queries.ListAppProcesses(c.Request.Context(), app.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list processes"})
+	}
+
+	return c.JSON(200, ProcessesResponse{Processes: toProcessResponses(procs)})
+}
+
+// Put replaces the process formation of an app. Worker processes are applied
+// right away when the app is deployed; web changes roll out with the next deploy.
+// PUT /api/apps/{name}/processes
+// Body: { "processes": [{ "type": "web", "replicas": 2 }, { "type": "worker", "command": "bin/worker", "memory": "512Mi" }] }
+func Put(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req UpdateProcessesRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+
+	if len(req.Processes) > maxProcessTypes {
+		return c.JSON(400, map[string]string{"error": "too many process types"})
+	}
+
+	procs := make([]k8s.ProcessConfig, len(req.Processes))
+	for i, p := range req.Processes {
+		procs[i] = k8s.ProcessConfig{
+			Type:     strings.ToLower(strings.TrimSpace(p.Type)),
+			Command:  strings.TrimSpace(p.Command),
+			Replicas: 1,
+			CPU:      strings.TrimSpace(p.CPU),
+			Memory:   strings.TrimSpace(p.Memory),
+		}
+		if p.Replicas != nil {
+			procs[i].Replicas = *p.Replicas
+		}
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	if err := k8s.ValidateProcesses(app.Name, procs); err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	plan := plans.ForSize(app.Size)
+	for _, p := range procs {
+		if p.Replicas > plan.MaxReplicas {
+			return c.JSON(400, map[string]string{
+				"error": fmt.Sprintf("process %q replicas must be at most %d on the %s plan", p.Type, plan.MaxReplicas, plan.Name),
+			})
+		}
+	}
+
+	tx, err := pool.Begin(c.Request.Context())
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to update processes"})
+	}
+	defer func() { _ = tx.Rollback(c.Request.Context()) }()
+
+	qtx := queries.WithTx(tx)
+	if err := qtx.DeleteAppProcesses(c.Request.Context(), app.ID); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to update processes"})
+	}
+
+	saved := make([]db.AppProcess, 0, len(procs))
+	for _, p := range procs {
+		proc, err := qtx.CreateAppProcess(c.Request.Context(), db.CreateAppProcessParams{
+			AppID:       app.ID,
+			ProcessType: p.Type,
+			Command:     optional(p.Command),
+			Replicas:    p.Replicas,
+			Cpu:         optional(p.CPU),
+			Memory:      optional(p.Memory),
+		})
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to update processes"})
+		}
+		saved = append(saved, proc)
+	}
+
+	if err := tx.Commit(c.Request.Context()); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to update processes"})
+	}
+
+	synced, err := syncProcesses(c.Request.Context(), services.From(c), queries, app, procs)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "processes saved but failed to apply: " + err.Error()})
+	}
+
+	return c.JSON(200, ProcessesResponse{
+		Processes: toProcessResponses(saved),
+		Synced:    &synced,
+	})
+}
+
+// syncProcesses applies worker Deployments with the image of the app's
+// current deployment. It reports false when the app has not been deployed yet.
+func syncProcesses(ctx context.Context, svc *services.Services, queries *db.Queries, app db.App, procs []k8s.ProcessConfig) (bool, error) {
+	if !app.CurrentDeploymentID.Valid {
+		return false, nil
+	}
+
+	deployment, err := queries.GetDeploymentByID(ctx, app.CurrentDeploymentID.Bytes)
+	if err != nil {
+		return false, nil
+	}
+
+	k8sClient, err := svc.Cluster(app.Region)
+	if err != nil {
+		return false, err
+	}
+
+	labels, err := appconfig.Labels(app)
+	if err != nil {
+		return false, err
+	}
+
+	if err := k8sClient.ApplyProcesses(ctx, &k8s.AppConfig{
+		Name:      app.Name,
+		Image:     deployment.Image,
+		Processes: procs,
+		Labels:    labels,
+	}); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func toProcessResponses(procs []db.AppProcess) []ProcessResponse {
+	if len(procs) == 0 {
+		return []ProcessResponse{{Type: k8s.ProcessTypeWeb, Replicas: 1}}
+	}
+
+	response := make([]ProcessResponse, len(procs))
+	for i, p := range procs {
+		response[i] = ProcessResponse{
+			Type:     p.ProcessType,
+			Command:  p.Command,
+			Replicas: p.Replicas,
+			CPU:      p.Cpu,
+			Memory:   p.Memory,
+		}
+	}
+	return response
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
@@ -0,0 +1,175 @@
+package resize
+
+import (
+	"encoding/json"
+	"net/netip"
+	"strings"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type ResizeRequest struct {
+	Process string  `json:"process"`
+	CPU     *string `json:"cpu"`
+	Memory  *string `json:"memory"`
+}
+
+type ResizeResponse struct {
+	Success bool    `json:"success"`
+	Process string  `json:"process"`
+	CPU     *string `json:"cpu"`
+	Memory  *string `json:"memory"`
+	Message string  `json:"message"`
+}
+
+// Post changes the CPU and memory of a running process without a new
+// deployment. Omitted fields keep their current value, an empty string
+// removes the limit.
+// POST /api/apps/{name}/resize
+// Body: { "process": "web", "cpu": "500m", "memory": "512Mi" }
+func Post(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req ResizeRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+
+	if req.CPU == nil && req.Memory == nil {
+		return c.JSON(400, map[string]string{"error": "cpu or memory is required"})
+	}
+
+	req.Process = strings.ToLower(strings.TrimSpace(req.Process))
+	if req.Process == "" {
+		req.Process = k8s.ProcessTypeWeb
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	// Start from the recorded size so omitted fields are kept
+	var cpu, memory *string
+	current, err := queries.GetAppProcess(c.Request.Context(), db.GetAppProcessParams{
+		AppID:       app.ID,
+		ProcessType: req.Process,
+	})
+	switch {
+	case err == nil:
+		cpu, memory = current.Cpu, current.Memory
+	case req.Process != k8s.ProcessTypeWeb:
+		return c.JSON(404, map[string]string{"error": "process not found"})
+	}
+
+	if req.CPU != nil {
+		cpu = optional(strings.TrimSpace(*req.CPU))
+	}
+	if req.Memory != nil {
+		memory = optional(strings.TrimSpace(*req.Memory))
+	}
+
+	if err := k8s.ValidateResources(deref(cpu), deref(memory)); err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	k8sClient, err := services.From(c).Cluster(app.Region)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "kubernetes not available"})
+	}
+
+	if err := k8sClient.ResizeProcess(c.Request.Context(), app.Name, req.Process, deref(cpu), deref(memory)); err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	// Record the new size so later deploys keep it
+	if _, err := queries.UpsertAppProcessResources(c.Request.Context(), db.UpsertAppProcessResourcesParams{
+		AppID:       app.ID,
+		ProcessType: req.Process,
+		Cpu:         cpu,
+		Memory:      memory,
+	}); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to save process size"})
+	}
+
+	details, _ := json.Marshal(map[string]any{
+		"process": req.Process,
+		"cpu":     cpu,
+		"memory":  memory,
+	})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		AppID:     pgtype.UUID{Bytes: app.ID, Valid: true},
+		Action:    "app.resized",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(200, ResizeResponse{
+		Success: true,
+		Process: req.Process,
+		CPU:     cpu,
+		Memory:  memory,
+		Message: "resize initiated, pods are restarting",
+	})
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// clientIP returns the request's client address for the audit log
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
@@ -1,15 +1,17 @@
 package restart
 
 import (
-	"context"
+	"net/netip"
 
 	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/actor"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
-	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
 	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type RestartResponse struct {
@@ -20,8 +22,8 @@ type RestartResponse struct {
 // Post restarts an app
 // POST /api/apps/{name}/restart
 func Post(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	svc := services.From(c)
+	cfg := svc.Config
 	appName := c.Param("name")
 
 	userID, err := getUserID(c, cfg)
@@ -30,32 +32,45 @@ func Post(c *fuego.Context) error {
 	}
 
 	// Verify app ownership
-	queries := db.New(pool)
-	app, err := queries.GetAppByName(context.Background(), db.GetAppByNameParams{
-		UserID: userID,
-		Name:   appName,
-	})
+	app, err := svc.Store.Apps.GetByName(c.Request.Context(), userID, appName)
 	if err != nil {
 		return c.JSON(404, map[string]string{"error": "app not found"})
 	}
 
 	// Get K8s client
-	k8sClient, err := k8s.NewClient(cfg.Kubeconfig, cfg.K8sNamespacePrefix)
+	k8sClient, err := svc.Cluster(app.Region)
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": "kubernetes not available"})
 	}
 
 	// Restart the app
-	if err := k8sClient.RestartApp(context.Background(), app.Name); err != nil {
+	if err := k8sClient.RestartApp(c.Request.Context(), app.Name); err != nil {
 		return c.JSON(500, map[string]string{"error": err.Error()})
 	}
 
+	by := actor.From(c)
+	_, _ = svc.Store.Activity.Create(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:     by.User(),
+		AppID:      pgtype.UUID{Bytes: app.ID, Valid: true},
+		Action:     "app.restarted",
+		IpAddress:  clientIP(c),
+		ApiTokenID: by.Token(),
+	})
+
 	return c.JSON(200, RestartResponse{
 		Success: true,
 		Message: "restart initiated",
 	})
 }
 
+// clientIP returns the request's client address for the audit log
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
+
 func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
 	if id, ok := c.Get("user_id").(uuid.UUID); ok {
 		return id, nil
@@ -0,0 +1,82 @@
+package restart
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/store"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestPost(t *testing.T) {
+	s := store.NewMemory()
+	user := testutil.SeedUser(t, s, "alice")
+	app := testutil.SeedApp(t, s, user.ID, "shop")
+	cluster := k8s.NewFake()
+
+	ta := testutil.NewTestApp().WithStore(s).WithK8s(cluster).WithAuth(user.ID, user.Username)
+	ta.App.Post("/api/apps/{name}/restart", Post)
+	ta.App.Mount()
+
+	restart := func(name string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		ta.App.ServeHTTP(w, testutil.MakeRequest(t, http.MethodPost, "/api/apps/"+name+"/restart", nil, nil))
+		return w
+	}
+
+	w := restart("shop")
+	testutil.AssertStatusCode(t, w, http.StatusOK)
+	if !cluster.Called("RestartApp shop") {
+		t.Errorf("expected the app to be restarted, got calls %v", cluster.Calls())
+	}
+
+	w = restart("blog")
+	testutil.AssertStatusCode(t, w, http.StatusNotFound)
+	if cluster.Called("RestartApp blog") {
+		t.Error("expected an unknown app not to reach the cluster")
+	}
+
+	activity, err := s.Activity.ListByApp(context.Background(), db.FilterActivityLogsByAppParams{
+		AppID:      pgtype.UUID{Bytes: app.ID, Valid: true},
+		LimitCount: 10,
+	})
+	if err != nil || len(activity) != 1 || activity[0].Action != "app.restarted" || activity[0].UserID.Bytes != user.ID {
+		t.Errorf("expected the restart to be recorded, got %+v, %v", activity, err)
+	}
+
+	cluster.Err = errors.New("connection refused")
+	w = restart("shop")
+	testutil.AssertStatusCode(t, w, http.StatusInternalServerError)
+	testutil.AssertJSONContains(t, w, "error", "connection refused")
+}
+
+func TestPost_APIToken(t *testing.T) {
+	s := store.NewMemory()
+	user := testutil.SeedUser(t, s, "alice")
+	app := testutil.SeedApp(t, s, user.ID, "shop")
+	tokenID := uuid.New()
+
+	ta := testutil.NewTestApp().WithStore(s).WithK8s(k8s.NewFake()).WithAPIToken(user.ID, user.Username, tokenID, "ci")
+	ta.App.Post("/api/apps/{name}/restart", Post)
+	ta.App.Mount()
+
+	w := httptest.NewRecorder()
+	ta.App.ServeHTTP(w, testutil.MakeRequest(t, http.MethodPost, "/api/apps/shop/restart", nil, nil))
+	testutil.AssertStatusCode(t, w, http.StatusOK)
+
+	activity, _ := s.Activity.ListByApp(context.Background(), db.FilterActivityLogsByAppParams{
+		AppID:      pgtype.UUID{Bytes: app.ID, Valid: true},
+		ApiTokenID: pgtype.UUID{Bytes: tokenID, Valid: true},
+		LimitCount: 10,
+	})
+	if len(activity) != 1 {
+		t.Errorf("expected the restart to be recorded with the token, got %+v", activity)
+	}
+}
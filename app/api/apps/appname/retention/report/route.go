@@ -0,0 +1,89 @@
+package report
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/imageretention"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/registry"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// maxKeepLast matches the bound of saved policies
+const maxKeepLast = 100
+
+// Get reports which images of an app its retention policy keeps and which
+// the next image_retention run deletes, without deleting anything.
+// ?keep_last= previews another policy before saving it.
+// GET /api/apps/{name}/retention/report
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	var keepLast int32
+	if param := c.Query("keep_last"); param != "" {
+		n, err := strconv.Atoi(param)
+		if err != nil || n < 1 || n > maxKeepLast {
+			return c.JSON(400, map[string]string{"error": "keep_last must be between 1 and 100"})
+		}
+		keepLast = int32(n)
+	} else {
+		policy, err := queries.GetAppImageRetention(c.Request.Context(), app.ID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return c.JSON(400, map[string]string{"error": "app has no image retention policy, pass keep_last to preview one"})
+		}
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to get image retention"})
+		}
+		keepLast = policy.KeepLast
+	}
+
+	report, err := imageretention.New(queries, cfg, nil).Plan(c.Request.Context(), app, keepLast)
+	if errors.Is(err, registry.ErrNotConfigured) {
+		return c.JSON(503, map[string]string{"error": "the platform registry is not available"})
+	}
+	if err != nil {
+		return c.JSON(502, map[string]string{"error": "failed to read images from the registry"})
+	}
+
+	return c.JSON(200, report)
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
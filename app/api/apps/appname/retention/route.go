@@ -0,0 +1,161 @@
+package retention
+
+import (
+	"encoding/json"
+	"errors"
+	"net/netip"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// MaxKeepLast bounds how many deployment images a policy keeps
+const MaxKeepLast = 100
+
+type RetentionResponse struct {
+	Enabled bool `json:"enabled"`
+	// KeepLast is how many of the app's latest deployment images are kept
+	KeepLast     int32      `json:"keep_last,omitempty"`
+	LastPrunedAt *time.Time `json:"last_pruned_at,omitempty"`
+}
+
+type RetentionRequest struct {
+	// KeepLast of 0 disables the policy
+	KeepLast int32 `json:"keep_last"`
+}
+
+// Get returns the image retention policy of an app
+// GET /api/apps/{name}/retention
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	policy, err := queries.GetAppImageRetention(c.Request.Context(), app.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return c.JSON(200, RetentionResponse{})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to get image retention"})
+	}
+
+	return c.JSON(200, toRetentionResponse(policy))
+}
+
+// Put sets how many of an app's latest deployment images the platform
+// registry keeps; the image_retention job deletes older ones
+// PUT /api/apps/{name}/retention
+// Body: { "keep_last": 10 }
+func Put(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req RetentionRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+	if req.KeepLast < 0 || req.KeepLast > MaxKeepLast {
+		return c.JSON(400, map[string]string{"error": "keep_last must be between 1 and 100, or 0 to disable"})
+	}
+
+	queries := db.New(pool)
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+		UserID: userID,
+		Name:   appName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	response := RetentionResponse{}
+	action := "image_retention.disabled"
+	if req.KeepLast > 0 {
+		policy, err := queries.UpsertAppImageRetention(c.Request.Context(), db.UpsertAppImageRetentionParams{
+			AppID:    app.ID,
+			KeepLast: req.KeepLast,
+		})
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to save image retention"})
+		}
+		response = toRetentionResponse(policy)
+		action = "image_retention.updated"
+	} else if err := queries.DeleteAppImageRetention(c.Request.Context(), app.ID); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to disable image retention"})
+	}
+
+	details, _ := json.Marshal(map[string]any{"keep_last": req.KeepLast})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		AppID:     pgtype.UUID{Bytes: app.ID, Valid: true},
+		Action:    action,
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(200, response)
+}
+
+func toRetentionResponse(policy db.AppImageRetention) RetentionResponse {
+	resp := RetentionResponse{
+		Enabled:  true,
+		KeepLast: policy.KeepLast,
+	}
+	if policy.LastPrunedAt.Valid {
+		resp.LastPrunedAt = &policy.LastPrunedAt.Time
+	}
+	return resp
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
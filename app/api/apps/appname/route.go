@@ -1,20 +1,39 @@
 package name
 
 import (
-	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/netip"
 	"time"
 
 	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/actor"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appmeta"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/legalhold"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/store"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/tunnel"
 	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type UpdateAppRequest struct {
 	Region string `json:"region"`
 	Size   string `json:"size"`
+
+	// Metadata fields are left unchanged when omitted; an empty value clears them
+	Description   *string   `json:"description,omitempty"`
+	IconURL       *string   `json:"icon_url,omitempty"`
+	RepositoryURL *string   `json:"repository_url,omitempty"`
+	Tags          *[]string `json:"tags,omitempty"`
+	// Showcase lists the app in the public directory at /api/showcase
+	Showcase *bool `json:"showcase,omitempty"`
 }
 
 type AppResponse struct {
@@ -22,16 +41,23 @@ type AppResponse struct {
 	Name            string    `json:"name"`
 	Region          string    `json:"region"`
 	Size            string    `json:"size"`
+	HealthCheckPath string    `json:"health_check_path"`
 	Status          string    `json:"status"`
 	DeploymentCount int       `json:"deployment_count"`
 	URL             string    `json:"url"`
+	Description     string    `json:"description"`
+	IconURL         string    `json:"icon_url"`
+	RepositoryURL   string    `json:"repository_url"`
+	Tags            []string  `json:"tags"`
+	Showcase        bool      `json:"showcase"`
+	EgressIP        string    `json:"egress_ip,omitempty"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
 }
 
 func Get(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	st := services.From(c).Store
 	name := c.Param("name")
 
 	userID, err := getUserID(c, cfg)
@@ -39,21 +65,24 @@ func Get(c *fuego.Context) error {
 		return c.JSON(401, map[string]string{"error": "unauthorized"})
 	}
 
-	queries := db.New(pool)
-	app, err := queries.GetAppByName(context.Background(), db.GetAppByNameParams{
-		UserID: userID,
-		Name:   name,
-	})
+	app, err := st.Apps.GetByName(c.Request.Context(), userID, name)
 	if err != nil {
 		return c.JSON(404, map[string]string{"error": "app not found"})
 	}
 
-	return c.JSON(200, toAppResponse(app, cfg.AppsDomainSuffix))
+	response := toAppResponse(app, cfg.AppsDomainSuffix)
+	if pool := services.From(c).DB; pool != nil {
+		if egress, err := db.New(pool).GetAppEgressIP(c.Request.Context(), app.ID); err == nil {
+			response.EgressIP = egress.Ip
+		}
+	}
+
+	return c.JSON(200, response)
 }
 
 func Put(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	st := services.From(c).Store
 	name := c.Param("name")
 
 	userID, err := getUserID(c, cfg)
@@ -62,15 +91,11 @@ func Put(c *fuego.Context) error {
 	}
 
 	var req UpdateAppRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(400, map[string]string{"error": "invalid request body"})
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
 	}
 
-	queries := db.New(pool)
-	app, err := queries.GetAppByName(context.Background(), db.GetAppByNameParams{
-		UserID: userID,
-		Name:   name,
-	})
+	app, err := st.Apps.GetByName(c.Request.Context(), userID, name)
 	if err != nil {
 		return c.JSON(404, map[string]string{"error": "app not found"})
 	}
@@ -93,7 +118,32 @@ func Put(c *fuego.Context) error {
 		size = req.Size
 	}
 
-	updatedApp, err := queries.UpdateApp(context.Background(), db.UpdateAppParams{
+	metadata := appmeta.Metadata{
+		Description:   app.Description,
+		IconURL:       app.IconUrl,
+		RepositoryURL: app.RepositoryUrl,
+		Tags:          app.Tags,
+	}
+	metadataChanged := req.Description != nil || req.IconURL != nil || req.RepositoryURL != nil || req.Tags != nil
+	if req.Description != nil {
+		metadata.Description = *req.Description
+	}
+	if req.IconURL != nil {
+		metadata.IconURL = *req.IconURL
+	}
+	if req.RepositoryURL != nil {
+		metadata.RepositoryURL = *req.RepositoryURL
+	}
+	if req.Tags != nil {
+		metadata.Tags = *req.Tags
+	}
+	if metadataChanged {
+		if metadata, err = appmeta.Normalize(metadata); err != nil {
+			return c.JSON(400, map[string]string{"error": err.Error()})
+		}
+	}
+
+	updatedApp, err := st.Apps.Update(c.Request.Context(), db.UpdateAppParams{
 		ID:     app.ID,
 		Name:   app.Name,
 		Region: region,
@@ -103,12 +153,35 @@ func Put(c *fuego.Context) error {
 		return c.JSON(500, map[string]string{"error": "failed to update app"})
 	}
 
+	if metadataChanged {
+		updatedApp, err = st.Apps.UpdateMetadata(c.Request.Context(), db.UpdateAppMetadataParams{
+			ID:            app.ID,
+			Description:   metadata.Description,
+			IconUrl:       metadata.IconURL,
+			RepositoryUrl: metadata.RepositoryURL,
+			Tags:          metadata.Tags,
+		})
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to update app metadata"})
+		}
+	}
+
+	if req.Showcase != nil && *req.Showcase != app.Showcase {
+		updatedApp, err = st.Apps.UpdateShowcase(c.Request.Context(), db.UpdateAppShowcaseParams{
+			ID:       app.ID,
+			Showcase: *req.Showcase,
+		})
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to update app showcase"})
+		}
+	}
+
 	return c.JSON(200, toAppResponse(updatedApp, cfg.AppsDomainSuffix))
 }
 
 func Delete(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	st := services.From(c).Store
 	name := c.Param("name")
 
 	userID, err := getUserID(c, cfg)
@@ -116,23 +189,58 @@ func Delete(c *fuego.Context) error {
 		return c.JSON(401, map[string]string{"error": "unauthorized"})
 	}
 
-	queries := db.New(pool)
-	app, err := queries.GetAppByName(context.Background(), db.GetAppByNameParams{
-		UserID: userID,
-		Name:   name,
-	})
+	app, err := st.Apps.GetByName(c.Request.Context(), userID, name)
 	if err != nil {
 		return c.JSON(404, map[string]string{"error": "app not found"})
 	}
 
-	err = queries.DeleteApp(context.Background(), app.ID)
+	// The tunnel row goes with the app, so it is read before deleting
+	cf, pool := services.From(c).Cloudflare, services.From(c).DB
+	var appTunnel *db.AppTunnel
+	if cf != nil && pool != nil {
+		if stored, err := db.New(pool).GetAppTunnel(c.Request.Context(), app.ID); err == nil {
+			appTunnel = &stored
+		}
+	}
+
+	err = st.Apps.Delete(c.Request.Context(), app.ID)
+	if errors.Is(err, store.ErrLegalHold) {
+		by := actor.From(c)
+		details, _ := json.Marshal(map[string]any{"app": app.Name, "operation": "app.delete"})
+		_, _ = st.Activity.Create(c.Request.Context(), db.CreateActivityLogParams{
+			UserID:     by.User(),
+			AppID:      pgtype.UUID{Bytes: app.ID, Valid: true},
+			Action:     legalhold.ActionBlocked,
+			Details:    details,
+			IpAddress:  clientIP(c),
+			ApiTokenID: by.Token(),
+		})
+		return c.JSON(423, map[string]string{
+			"error":  "app is under legal hold and cannot be deleted until the hold is released",
+			"reason": "legal_hold",
+		})
+	}
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": "failed to delete app"})
 	}
 
+	if appTunnel != nil {
+		if err := tunnel.Remove(c.Request.Context(), cf, *appTunnel); err != nil {
+			slog.Warn("failed to remove tunnel of deleted app", "app", app.Name, "tunnel_id", appTunnel.TunnelID, "error", err)
+		}
+	}
+
 	return c.NoContent()
 }
 
+// clientIP returns the request's client address for the audit log
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
+
 func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
 	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
 		return userID, nil
@@ -157,9 +265,15 @@ func toAppResponse(app db.App, domainSuffix string) AppResponse {
 		Name:            app.Name,
 		Region:          app.Region,
 		Size:            app.Size,
-		Status:          app.Status,
+		HealthCheckPath: app.HealthCheckPath,
+		Status:          string(app.Status),
 		DeploymentCount: int(app.DeploymentCount),
 		URL:             "https://" + app.Name + "." + domainSuffix,
+		Description:     app.Description,
+		IconURL:         app.IconUrl,
+		RepositoryURL:   app.RepositoryUrl,
+		Tags:            app.Tags,
+		Showcase:        app.Showcase,
 		CreatedAt:       app.CreatedAt,
 		UpdatedAt:       app.UpdatedAt,
 	}
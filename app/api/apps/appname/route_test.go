@@ -0,0 +1,97 @@
+package name
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/store"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/testutil"
+)
+
+func serve(t *testing.T, s *store.Store, username, method, path string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	user, err := s.Users.GetByUsername(context.Background(), username)
+	if err != nil {
+		t.Fatalf("unknown user %q: %v", username, err)
+	}
+
+	ta := testutil.NewTestApp().WithStore(s).WithAuth(user.ID, user.Username)
+	ta.App.Get("/api/apps/{name}", Get)
+	ta.App.Put("/api/apps/{name}", Put)
+	ta.App.Delete("/api/apps/{name}", Delete)
+	ta.App.Mount()
+
+	w := httptest.NewRecorder()
+	ta.App.ServeHTTP(w, testutil.MakeRequest(t, method, path, body, nil))
+	return w
+}
+
+func TestGet(t *testing.T) {
+	s := store.NewMemory()
+	alice := testutil.SeedUser(t, s, "alice")
+	testutil.SeedUser(t, s, "bob")
+	testutil.SeedApp(t, s, alice.ID, "shop")
+
+	w := serve(t, s, "alice", http.MethodGet, "/api/apps/shop", nil)
+	testutil.AssertStatusCode(t, w, http.StatusOK)
+	app := testutil.ParseResponse[AppResponse](t, w)
+	if app.Name != "shop" || app.URL != "https://shop.test.nexo.build" {
+		t.Errorf("unexpected app %+v", app)
+	}
+
+	// Other users' apps are not found
+	w = serve(t, s, "bob", http.MethodGet, "/api/apps/shop", nil)
+	testutil.AssertStatusCode(t, w, http.StatusNotFound)
+}
+
+func TestPut(t *testing.T) {
+	s := store.NewMemory()
+	alice := testutil.SeedUser(t, s, "alice")
+	testutil.SeedApp(t, s, alice.ID, "shop")
+
+	w := serve(t, s, "alice", http.MethodPut, "/api/apps/shop", map[string]any{
+		"size":        "pro",
+		"description": "  The storefront ",
+		"tags":        []string{"Prod", "web"},
+	})
+	testutil.AssertStatusCode(t, w, http.StatusOK)
+	app := testutil.ParseResponse[AppResponse](t, w)
+	if app.Size != "pro" || app.Region != "gdl" {
+		t.Errorf("expected only the size to change, got %s/%s", app.Region, app.Size)
+	}
+	if app.Description != "The storefront" || len(app.Tags) != 2 || app.Tags[0] != "prod" {
+		t.Errorf("expected normalized metadata, got %q %v", app.Description, app.Tags)
+	}
+
+	stored, err := s.Apps.GetByName(context.Background(), alice.ID, "shop")
+	if err != nil || stored.Size != "pro" || stored.Description != "The storefront" {
+		t.Errorf("expected the update to be stored, got %+v (%v)", stored, err)
+	}
+
+	w = serve(t, s, "alice", http.MethodPut, "/api/apps/shop", map[string]string{"region": "nyc"})
+	testutil.AssertStatusCode(t, w, http.StatusBadRequest)
+	testutil.AssertJSONContains(t, w, "error", "invalid region")
+}
+
+func TestDelete(t *testing.T) {
+	s := store.NewMemory()
+	alice := testutil.SeedUser(t, s, "alice")
+	app := testutil.SeedApp(t, s, alice.ID, "shop")
+	deployment := testutil.SeedDeployment(t, s, app.ID, 1)
+
+	w := serve(t, s, "alice", http.MethodDelete, "/api/apps/shop", nil)
+	testutil.AssertStatusCode(t, w, http.StatusNoContent)
+
+	if _, err := s.Apps.Get(context.Background(), app.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("expected the app to be deleted, got %v", err)
+	}
+	if _, err := s.Deployments.Get(context.Background(), deployment.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("expected the deployments to be deleted, got %v", err)
+	}
+
+	w = serve(t, s, "alice", http.MethodDelete, "/api/apps/shop", nil)
+	testutil.AssertStatusCode(t, w, http.StatusNotFound)
+}
@@ -1,33 +1,43 @@
 package scale
 
 import (
-	"context"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"strings"
 
 	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/actor"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/plans"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
 	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type ScaleRequest struct {
-	Replicas int32 `json:"replicas"`
+	Process  string `json:"process"`
+	Replicas int32  `json:"replicas"`
 }
 
 type ScaleResponse struct {
 	Success  bool   `json:"success"`
+	Process  string `json:"process"`
 	Replicas int32  `json:"replicas"`
 	Message  string `json:"message"`
 }
 
-// Post scales an app
+// Post scales a process type of an app, the web process by default
 // POST /api/apps/{name}/scale
-// Body: { "replicas": 3 }
+// Body: { "process": "worker", "replicas": 3 }
 func Post(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
 	appName := c.Param("name")
 
 	userID, err := getUserID(c, cfg)
@@ -37,18 +47,18 @@ func Post(c *fuego.Context) error {
 
 	// Parse request body
 	var req ScaleRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(400, map[string]string{"error": "invalid request body"})
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
 	}
 
-	// Validate replicas
-	if req.Replicas < 0 || req.Replicas > 10 {
-		return c.JSON(400, map[string]string{"error": "replicas must be between 0 and 10"})
+	req.Process = strings.ToLower(strings.TrimSpace(req.Process))
+	if req.Process == "" {
+		req.Process = k8s.ProcessTypeWeb
 	}
 
 	// Verify app ownership
 	queries := db.New(pool)
-	app, err := queries.GetAppByName(context.Background(), db.GetAppByNameParams{
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
 		UserID: userID,
 		Name:   appName,
 	})
@@ -56,19 +66,66 @@ func Post(c *fuego.Context) error {
 		return c.JSON(404, map[string]string{"error": "app not found"})
 	}
 
+	// Validate replicas against the app's plan
+	plan := plans.ForSize(app.Size)
+	if req.Replicas < 0 || req.Replicas > plan.MaxReplicas {
+		return c.JSON(400, map[string]string{
+			"error": fmt.Sprintf("replicas must be between 0 and %d on the %s plan", plan.MaxReplicas, plan.Name),
+		})
+	}
+
+	// Only the web process exists without a formation entry
+	if req.Process != k8s.ProcessTypeWeb {
+		if _, err := queries.GetAppProcess(c.Request.Context(), db.GetAppProcessParams{
+			AppID:       app.ID,
+			ProcessType: req.Process,
+		}); err != nil {
+			return c.JSON(404, map[string]string{"error": "process not found"})
+		}
+	}
+
 	// Get K8s client
-	k8sClient, err := k8s.NewClient(cfg.Kubeconfig, cfg.K8sNamespacePrefix)
+	k8sClient, err := services.From(c).Cluster(app.Region)
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": "kubernetes not available"})
 	}
 
-	// Scale the app
-	if err := k8sClient.ScaleApp(context.Background(), app.Name, req.Replicas); err != nil {
+	// Scale the process
+	if err := k8sClient.ScaleProcess(c.Request.Context(), app.Name, req.Process, req.Replicas); err != nil {
 		return c.JSON(500, map[string]string{"error": err.Error()})
 	}
 
+	// Keep the formation in sync so the next deploy keeps the new scale
+	if _, err := queries.UpsertAppProcessReplicas(c.Request.Context(), db.UpsertAppProcessReplicasParams{
+		AppID:       app.ID,
+		ProcessType: req.Process,
+		Replicas:    req.Replicas,
+	}); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to save process scale"})
+	}
+
+	// A manual web scale replaces a burst in progress
+	if req.Process == k8s.ProcessTypeWeb {
+		_ = queries.EndAppBurst(c.Request.Context(), app.ID)
+	}
+
+	details, _ := json.Marshal(map[string]any{
+		"process":  req.Process,
+		"replicas": req.Replicas,
+	})
+	by := actor.From(c)
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:     by.User(),
+		AppID:      pgtype.UUID{Bytes: app.ID, Valid: true},
+		Action:     "app.scaled",
+		Details:    details,
+		IpAddress:  clientIP(c),
+		ApiTokenID: by.Token(),
+	})
+
 	return c.JSON(200, ScaleResponse{
 		Success:  true,
+		Process:  req.Process,
 		Replicas: req.Replicas,
 		Message:  "scaling initiated",
 	})
@@ -77,8 +134,8 @@ func Post(c *fuego.Context) error {
 // Get returns the current scale of an app
 // GET /api/apps/{name}/scale
 func Get(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
 	appName := c.Param("name")
 
 	userID, err := getUserID(c, cfg)
@@ -88,7 +145,7 @@ func Get(c *fuego.Context) error {
 
 	// Verify app ownership
 	queries := db.New(pool)
-	app, err := queries.GetAppByName(context.Background(), db.GetAppByNameParams{
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
 		UserID: userID,
 		Name:   appName,
 	})
@@ -97,13 +154,13 @@ func Get(c *fuego.Context) error {
 	}
 
 	// Get K8s client
-	k8sClient, err := k8s.NewClient(cfg.Kubeconfig, cfg.K8sNamespacePrefix)
+	k8sClient, err := services.From(c).Cluster(app.Region)
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": "kubernetes not available"})
 	}
 
 	// Get app status
-	status, err := k8sClient.GetAppStatus(context.Background(), app.Name)
+	status, err := k8sClient.GetAppStatus(c.Request.Context(), app.Name)
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": err.Error()})
 	}
@@ -111,6 +168,14 @@ func Get(c *fuego.Context) error {
 	return c.JSON(200, status)
 }
 
+// clientIP returns the request's client address for the audit log
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
+
 func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
 	if id, ok := c.Get("user_id").(uuid.UUID); ok {
 		return id, nil
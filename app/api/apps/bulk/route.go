@@ -0,0 +1,134 @@
+package bulk
+
+import (
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/actor"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/bulk"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/cryptoutil"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+type BulkRequest struct {
+	// Action is restart, pause, resume or set-env
+	Action string   `json:"action"`
+	Apps   []string `json:"apps"`
+	// Env are the variables set-env adds or replaces
+	Env map[string]string `json:"env,omitempty"`
+	// Unset are the variables set-env removes
+	Unset []string `json:"unset,omitempty"`
+}
+
+type OperationsResponse struct {
+	Operations []bulk.Operation `json:"operations"`
+}
+
+// Get lists the user's recent bulk operations, newest first
+// GET /api/apps/bulk
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	ops, err := queries.ListBulkOperationsByUser(c.Request.Context(), db.ListBulkOperationsByUserParams{
+		UserID: userID,
+		Limit:  20,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list bulk operations"})
+	}
+
+	response := OperationsResponse{Operations: make([]bulk.Operation, len(ops))}
+	for i, op := range ops {
+		response.Operations[i] = bulk.View(op)
+	}
+	return c.JSON(200, response)
+}
+
+// Post queues an action across several apps, carried out in the background.
+// The response is the pending operation; follow its progress at
+// GET /api/apps/bulk/{id}.
+// POST /api/apps/bulk
+// Body: { "action": "set-env", "apps": ["api", "worker"], "env": { "LOG_LEVEL": "debug" }, "unset": ["LEGACY_FLAG"] }
+func Post(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req BulkRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+	if err := bulk.Validate(req.Action, req.Apps, req.Env, req.Unset); err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	// Verify app ownership
+	queries := db.New(pool)
+	for _, name := range req.Apps {
+		_, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+			UserID: userID,
+			Name:   name,
+		})
+		if err != nil {
+			return c.JSON(404, map[string]string{"error": "app not found: " + name})
+		}
+	}
+
+	var envEncrypted []byte
+	if len(req.Env) > 0 {
+		if envEncrypted, err = cryptoutil.Encrypt(req.Env, cfg.EncryptionKey); err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to encrypt environment variables"})
+		}
+	}
+	unset := req.Unset
+	if unset == nil {
+		unset = []string{}
+	}
+
+	op, err := queries.CreateBulkOperation(c.Request.Context(), db.CreateBulkOperationParams{
+		UserID:       userID,
+		ApiTokenID:   actor.From(c).Token(),
+		Action:       req.Action,
+		Apps:         req.Apps,
+		EnvEncrypted: envEncrypted,
+		UnsetEnv:     unset,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to queue bulk operation"})
+	}
+
+	c.Response.Header().Set("Location", "/api/apps/bulk/"+op.ID.String())
+	return c.JSON(202, bulk.View(op))
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
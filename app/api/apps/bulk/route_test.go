@@ -0,0 +1,30 @@
+package bulk
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/testutil"
+	"github.com/google/uuid"
+)
+
+func TestPost_Validation(t *testing.T) {
+	ta := testutil.NewTestApp().WithAuth(uuid.New(), "octocat")
+	ta.App.Post("/api/apps/bulk", Post)
+	ta.App.Mount()
+
+	post := func(body BulkRequest) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		ta.App.ServeHTTP(w, testutil.MakeRequest(t, http.MethodPost, "/api/apps/bulk", body, nil))
+		return w
+	}
+
+	// Operations are rejected before the database is reached
+	testutil.AssertStatusCode(t, post(BulkRequest{Action: "delete", Apps: []string{"api"}}), http.StatusBadRequest)
+	testutil.AssertStatusCode(t, post(BulkRequest{Action: "restart"}), http.StatusBadRequest)
+	testutil.AssertStatusCode(t, post(BulkRequest{Action: "restart", Apps: []string{"api", "api"}}), http.StatusBadRequest)
+	testutil.AssertStatusCode(t, post(BulkRequest{Action: "set-env", Apps: []string{"api"}}), http.StatusBadRequest)
+	testutil.AssertStatusCode(t, post(BulkRequest{Action: "set-env", Apps: []string{"api"}, Env: map[string]string{"BAD NAME": "x"}}), http.StatusBadRequest)
+}
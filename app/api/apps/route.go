@@ -1,24 +1,42 @@
 package apps
 
 import (
-	"context"
+	"errors"
 	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appmeta"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/machineuser"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/usersettings"
 	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 var appNameRegex = regexp.MustCompile(`^[a-z][a-z0-9-]*[a-z0-9]$`)
 
+// reservedAppNames are taken by routes under /api/apps, such as
+// /api/apps/bulk, which would shadow an app of the same name
+var reservedAppNames = map[string]bool{"bulk": true}
+
 type CreateAppRequest struct {
-	Name   string `json:"name"`
-	Region string `json:"region"`
-	Size   string `json:"size"`
+	Name string `json:"name"`
+	// Region, Size and HealthCheckPath default to the user's settings
+	Region          string `json:"region"`
+	Size            string `json:"size"`
+	HealthCheckPath string `json:"health_check_path,omitempty"`
+
+	// Optional metadata
+	Description   string   `json:"description,omitempty"`
+	IconURL       string   `json:"icon_url,omitempty"`
+	RepositoryURL string   `json:"repository_url,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
 }
 
 type AppResponse struct {
@@ -26,27 +44,68 @@ type AppResponse struct {
 	Name            string    `json:"name"`
 	Region          string    `json:"region"`
 	Size            string    `json:"size"`
+	HealthCheckPath string    `json:"health_check_path"`
 	Status          string    `json:"status"`
 	DeploymentCount int       `json:"deployment_count"`
 	URL             string    `json:"url"`
+	Description     string    `json:"description"`
+	IconURL         string    `json:"icon_url"`
+	RepositoryURL   string    `json:"repository_url"`
+	Tags            []string  `json:"tags"`
+	Showcase        bool      `json:"showcase"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
 }
 
+// Get lists the user's apps, newest first
+// GET /api/apps
+// Query params:
+//   - search: matches the name or description (case-insensitive) or a tag
+//   - tag: only apps with all of these comma-separated tags
+//   - limit: number of apps (default 100, max 500)
+//   - offset: apps to skip (default 0)
 func Get(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	st := services.From(c).Store
 
 	userID, err := getUserID(c, cfg)
 	if err != nil {
 		return c.JSON(401, map[string]string{"error": "unauthorized"})
 	}
 
-	queries := db.New(pool)
-	apps, err := queries.ListAppsByUser(context.Background(), db.ListAppsByUserParams{
-		UserID: userID,
-		Limit:  100,
-		Offset: 0,
+	search := strings.TrimSpace(c.Query("search"))
+	if len(search) > 100 {
+		return c.JSON(400, map[string]string{"error": "search may be at most 100 characters"})
+	}
+
+	tags := []string{}
+	if t := c.Query("tag"); t != "" {
+		tags, err = appmeta.NormalizeTags(strings.Split(t, ","))
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": err.Error()})
+		}
+	}
+
+	limit := int32(100)
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.ParseInt(l, 10, 32); err == nil && parsed > 0 {
+			limit = int32(min(parsed, 500))
+		}
+	}
+	offset := int32(0)
+	if o := c.Query("offset"); o != "" {
+		if parsed, err := strconv.ParseInt(o, 10, 32); err == nil && parsed > 0 {
+			offset = int32(parsed)
+		}
+	}
+
+	apps, err := st.Apps.Search(c.Request.Context(), db.SearchAppsByUserParams{
+		UserID:  userID,
+		Search:  strings.ToLower(search),
+		Pattern: appmeta.SearchPattern(search),
+		Tags:    tags,
+		MaxApps: limit,
+		Skip:    offset,
 	})
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": "failed to list apps"})
@@ -61,8 +120,8 @@ func Get(c *fuego.Context) error {
 }
 
 func Post(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
 
 	userID, err := getUserID(c, cfg)
 	if err != nil {
@@ -70,8 +129,8 @@ func Post(c *fuego.Context) error {
 	}
 
 	var req CreateAppRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(400, map[string]string{"error": "invalid request body"})
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
 	}
 
 	if req.Name == "" {
@@ -86,27 +145,37 @@ func Post(c *fuego.Context) error {
 		return c.JSON(400, map[string]string{"error": "name must start with a letter, end with a letter or number, and contain only lowercase letters, numbers, and hyphens"})
 	}
 
-	if req.Region == "" {
-		req.Region = "gdl"
-	}
-
-	if req.Size == "" {
-		req.Size = "starter"
+	if reservedAppNames[req.Name] {
+		return c.JSON(400, map[string]string{"error": "name " + req.Name + " is reserved"})
 	}
 
-	validRegions := map[string]bool{"gdl": true, "mex": true, "qro": true}
-	if !validRegions[req.Region] {
+	if req.Region != "" && !usersettings.ValidRegion(req.Region) {
 		return c.JSON(400, map[string]string{"error": "invalid region"})
 	}
 
-	validSizes := map[string]bool{"starter": true, "pro": true, "enterprise": true}
-	if !validSizes[req.Size] {
+	if req.Size != "" && !usersettings.ValidSize(req.Size) {
 		return c.JSON(400, map[string]string{"error": "invalid size"})
 	}
 
+	if req.HealthCheckPath != "" {
+		if err := usersettings.ValidateHealthCheckPath(req.HealthCheckPath); err != nil {
+			return c.JSON(400, map[string]string{"error": err.Error()})
+		}
+	}
+
+	metadata, err := appmeta.Normalize(appmeta.Metadata{
+		Description:   req.Description,
+		IconURL:       req.IconURL,
+		RepositoryURL: req.RepositoryURL,
+		Tags:          req.Tags,
+	})
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
 	queries := db.New(pool)
 
-	_, err = queries.GetAppByName(context.Background(), db.GetAppByNameParams{
+	_, err = queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
 		UserID: userID,
 		Name:   req.Name,
 	})
@@ -114,16 +183,69 @@ func Post(c *fuego.Context) error {
 		return c.JSON(409, map[string]string{"error": "app with this name already exists"})
 	}
 
-	app, err := queries.CreateApp(context.Background(), db.CreateAppParams{
+	// Apps, routers and traffic splits share the <name>.<apps domain> host namespace
+	_, err = queries.GetRouterByName(c.Request.Context(), db.GetRouterByNameParams{
+		UserID: userID,
+		Name:   req.Name,
+	})
+	if err == nil {
+		return c.JSON(409, map[string]string{"error": "a router with this name already exists"})
+	}
+
+	_, err = queries.GetTrafficSplitByName(c.Request.Context(), db.GetTrafficSplitByNameParams{
+		UserID: userID,
+		Name:   req.Name,
+	})
+	if err == nil {
+		return c.JSON(409, map[string]string{"error": "a traffic split with this name already exists"})
+	}
+
+	if err := machineuser.CheckAppQuota(c.Request.Context(), queries, userID); err != nil {
+		if errors.Is(err, machineuser.ErrAppQuota) {
+			return c.JSON(403, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(500, map[string]string{"error": "failed to check quota"})
+	}
+
+	settings, err := usersettings.Get(c.Request.Context(), queries, userID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to load settings"})
+	}
+	region, size, healthCheckPath := usersettings.AppDefaults(settings, req.Region, req.Size, req.HealthCheckPath)
+
+	app, err := queries.CreateApp(c.Request.Context(), db.CreateAppParams{
 		UserID: userID,
 		Name:   req.Name,
-		Region: req.Region,
-		Size:   req.Size,
+		Region: region,
+		Size:   size,
 	})
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": "failed to create app"})
 	}
 
+	if healthCheckPath != app.HealthCheckPath {
+		app, err = queries.UpdateAppHealthCheckPath(c.Request.Context(), db.UpdateAppHealthCheckPathParams{
+			ID:              app.ID,
+			HealthCheckPath: healthCheckPath,
+		})
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to save health check path"})
+		}
+	}
+
+	if metadata.Description != "" || metadata.IconURL != "" || metadata.RepositoryURL != "" || len(metadata.Tags) > 0 {
+		app, err = queries.UpdateAppMetadata(c.Request.Context(), db.UpdateAppMetadataParams{
+			ID:            app.ID,
+			Description:   metadata.Description,
+			IconUrl:       metadata.IconURL,
+			RepositoryUrl: metadata.RepositoryURL,
+			Tags:          metadata.Tags,
+		})
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to save app metadata"})
+		}
+	}
+
 	return c.JSON(201, toAppResponse(app, cfg.AppsDomainSuffix))
 }
 
@@ -151,9 +273,15 @@ func toAppResponse(app db.App, domainSuffix string) AppResponse {
 		Name:            app.Name,
 		Region:          app.Region,
 		Size:            app.Size,
-		Status:          app.Status,
+		HealthCheckPath: app.HealthCheckPath,
+		Status:          string(app.Status),
 		DeploymentCount: int(app.DeploymentCount),
 		URL:             "https://" + app.Name + "." + domainSuffix,
+		Description:     app.Description,
+		IconURL:         app.IconUrl,
+		RepositoryURL:   app.RepositoryUrl,
+		Tags:            app.Tags,
+		Showcase:        app.Showcase,
 		CreatedAt:       app.CreatedAt,
 		UpdatedAt:       app.UpdatedAt,
 	}
@@ -1,7 +1,15 @@
 package apps
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/store"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/testutil"
+	"github.com/google/uuid"
 )
 
 func TestAppNameValidation(t *testing.T) {
@@ -122,6 +130,17 @@ func TestValidSizes(t *testing.T) {
 	}
 }
 
+func TestPost_ReservedName(t *testing.T) {
+	ta := testutil.NewTestApp().WithAuth(uuid.New(), "octocat")
+	ta.App.Post("/api/apps", Post)
+	ta.App.Mount()
+
+	w := httptest.NewRecorder()
+	ta.App.ServeHTTP(w, testutil.MakeRequest(t, http.MethodPost, "/api/apps", CreateAppRequest{Name: "bulk"}, nil))
+	testutil.AssertStatusCode(t, w, http.StatusBadRequest)
+	testutil.AssertJSONContains(t, w, "error", "name bulk is reserved")
+}
+
 func TestCreateAppRequestDefaults(t *testing.T) {
 	req := CreateAppRequest{
 		Name: "test-app",
@@ -172,3 +191,47 @@ func TestAppResponseStructure(t *testing.T) {
 		t.Errorf("DeploymentCount expected 5, got %d", resp.DeploymentCount)
 	}
 }
+
+func TestGet_Search(t *testing.T) {
+	s := store.NewMemory()
+	alice := testutil.SeedUser(t, s, "alice")
+	bob := testutil.SeedUser(t, s, "bob")
+
+	shop := testutil.SeedApp(t, s, alice.ID, "shop")
+	if _, err := s.Apps.UpdateMetadata(context.Background(), db.UpdateAppMetadataParams{
+		ID:          shop.ID,
+		Description: "Storefront",
+		Tags:        []string{"prod"},
+	}); err != nil {
+		t.Fatalf("failed to update metadata: %v", err)
+	}
+	testutil.SeedApp(t, s, alice.ID, "blog")
+	testutil.SeedApp(t, s, bob.ID, "store")
+
+	ta := testutil.NewTestApp().WithStore(s).WithAuth(alice.ID, alice.Username)
+	ta.App.Get("/api/apps", Get)
+	ta.App.Mount()
+
+	for query, want := range map[string][]string{
+		"":                  {"blog", "shop"},
+		"?search=STORE":     {"shop"},
+		"?tag=prod":         {"shop"},
+		"?limit=1&offset=1": {"shop"},
+		"?search=missing":   {},
+	} {
+		w := httptest.NewRecorder()
+		ta.App.ServeHTTP(w, testutil.MakeRequest(t, http.MethodGet, "/api/apps"+query, nil, nil))
+		testutil.AssertStatusCode(t, w, http.StatusOK)
+
+		apps := testutil.ParseResponse[[]AppResponse](t, w)
+		if len(apps) != len(want) {
+			t.Errorf("%q: expected %v, got %+v", query, want, apps)
+			continue
+		}
+		for i, app := range apps {
+			if app.Name != want[i] {
+				t.Errorf("%q: expected %v, got app %d %q", query, want, i, app.Name)
+			}
+		}
+	}
+}
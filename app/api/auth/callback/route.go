@@ -1,21 +1,26 @@
 package callback
 
 import (
-	"context"
+	"math"
 	"net/http"
+	"net/netip"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
-	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/credits"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
 	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 func Get(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
 
 	code := c.Query("code")
 	state := c.Query("state")
@@ -33,35 +38,44 @@ func Get(c *fuego.Context) error {
 		return c.JSON(400, map[string]string{"error": "missing code or state"})
 	}
 
+	ip := remoteIP(c)
+	if wait := auth.Blocked(ip, uuid.Nil); wait > 0 {
+		c.Response.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		return c.JSON(429, map[string]string{"error": "too many failed login attempts, try again later"})
+	}
+
 	queries := db.New(pool)
 
-	oauthState, err := queries.GetOAuthState(context.Background(), state)
+	oauthState, err := queries.GetOAuthState(c.Request.Context(), state)
 	if err != nil {
+		auth.RecordFailure(c.Request.Context(), queries, ip, uuid.Nil, "invalid_oauth_state")
 		return c.JSON(400, map[string]string{"error": "invalid or expired state"})
 	}
 
 	if time.Now().After(oauthState.ExpiresAt) {
-		_ = queries.DeleteOAuthState(context.Background(), state)
+		_ = queries.DeleteOAuthState(c.Request.Context(), state)
+		auth.RecordFailure(c.Request.Context(), queries, ip, uuid.Nil, "expired_oauth_state")
 		return c.JSON(400, map[string]string{"error": "state expired"})
 	}
 
-	_ = queries.DeleteOAuthState(context.Background(), state)
+	_ = queries.DeleteOAuthState(c.Request.Context(), state)
 
 	ghClient := auth.NewGitHubClient(cfg.GitHubClientID, cfg.GitHubClientSecret, cfg.GitHubCallbackURL)
 
-	token, err := ghClient.Exchange(context.Background(), code)
+	token, err := ghClient.Exchange(c.Request.Context(), code)
 	if err != nil {
+		auth.RecordFailure(c.Request.Context(), queries, ip, uuid.Nil, "oauth_exchange_failed")
 		return c.JSON(500, map[string]string{"error": "failed to exchange code for token"})
 	}
 
-	ghUser, err := ghClient.GetUser(context.Background(), token)
+	ghUser, err := ghClient.GetUser(c.Request.Context(), token)
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": "failed to get user from github"})
 	}
 
-	user, err := queries.GetUserByGitHubID(context.Background(), ghUser.ID)
+	user, err := queries.GetUserByGitHubID(c.Request.Context(), ghUser.ID)
 	if err != nil {
-		user, err = queries.CreateUser(context.Background(), db.CreateUserParams{
+		user, err = queries.CreateUser(c.Request.Context(), db.CreateUserParams{
 			GithubID:  ghUser.ID,
 			Username:  ghUser.Login,
 			Email:     ghUser.Email,
@@ -70,8 +84,11 @@ func Get(c *fuego.Context) error {
 		if err != nil {
 			return c.JSON(500, map[string]string{"error": "failed to create user"})
 		}
+		if oauthState.Referrer != nil {
+			_ = credits.Refer(c.Request.Context(), queries, user.ID, *oauthState.Referrer)
+		}
 	} else {
-		user, err = queries.UpdateUser(context.Background(), db.UpdateUserParams{
+		user, err = queries.UpdateUser(c.Request.Context(), db.UpdateUserParams{
 			ID:        user.ID,
 			Username:  ghUser.Login,
 			Email:     ghUser.Email,
@@ -82,6 +99,23 @@ func Get(c *fuego.Context) error {
 		}
 	}
 
+	auth.RecordSuccess(ip, user.ID)
+	auth.RecordLogin(c.Request.Context(), queries, ip, user.ID, "github")
+
+	// Kept as evidence for compliance reports of organizations
+	if ghUser.TwoFactorAuthentication != nil {
+		_ = queries.UpsertUserTwoFactor(c.Request.Context(), db.UpsertUserTwoFactorParams{
+			UserID:  user.ID,
+			Enabled: *ghUser.TwoFactorAuthentication,
+		})
+	}
+
+	// Link organization memberships provisioned through SCIM before the first login
+	_ = queries.LinkOrganizationMembers(c.Request.Context(), db.LinkOrganizationMembersParams{
+		UserName: user.Username,
+		UserID:   pgtype.UUID{Bytes: user.ID, Valid: true},
+	})
+
 	tokenPair, err := auth.GenerateTokenPair(user.ID, user.Username, cfg.JWTSecret)
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": "failed to generate tokens"})
@@ -118,7 +152,7 @@ func Get(c *fuego.Context) error {
 		MaxAge:   int(7 * 24 * time.Hour.Seconds()),
 		HttpOnly: true,
 		Secure:   !cfg.IsDevelopment(),
-		SameSite: http.SameSiteLaxMode,
+		SameSite: http.SameSiteStrictMode,
 	})
 
 	redirectURI := "/"
@@ -133,3 +167,11 @@ func Get(c *fuego.Context) error {
 
 	return c.Redirect(redirectURI, 302)
 }
+
+// remoteIP returns the client address used to throttle failed logins
+func remoteIP(c *fuego.Context) string {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return addr.String()
+	}
+	return c.Request.RemoteAddr
+}
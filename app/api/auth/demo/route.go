@@ -0,0 +1,41 @@
+package demo
+
+import (
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/demo"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+)
+
+// Post returns tokens of the demo user for exploring the API. Only served
+// in demo mode.
+// POST /api/auth/demo
+func Post(c *fuego.Context) error {
+	svc := services.From(c)
+	cfg := svc.Config
+	if !cfg.DemoMode {
+		return c.JSON(404, map[string]string{"error": "demo mode is disabled"})
+	}
+
+	user, err := svc.Store.Users.GetByGitHubID(c.Request.Context(), demo.GitHubID)
+	if err != nil {
+		return c.JSON(503, map[string]string{"error": "demo data is not seeded"})
+	}
+
+	tokenPair, err := auth.GenerateTokenPair(user.ID, user.Username, cfg.JWTSecret)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to generate tokens"})
+	}
+
+	return c.JSON(200, map[string]interface{}{
+		"access_token":  tokenPair.AccessToken,
+		"refresh_token": tokenPair.RefreshToken,
+		"expires_at":    tokenPair.ExpiresAt,
+		"token_type":    tokenPair.TokenType,
+		"user": map[string]interface{}{
+			"id":       user.ID,
+			"username": user.Username,
+			"email":    user.Email,
+		},
+	})
+}
@@ -0,0 +1,51 @@
+package demo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/demo"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/store"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/testutil"
+)
+
+func TestPost(t *testing.T) {
+	s := store.NewMemory()
+	ta := testutil.NewTestApp().WithStore(s)
+	ta.App.Post("/api/auth/demo", Post)
+	ta.App.Mount()
+
+	login := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		ta.App.ServeHTTP(w, testutil.MakeRequest(t, http.MethodPost, "/api/auth/demo", nil, nil))
+		return w
+	}
+
+	w := login()
+	testutil.AssertStatusCode(t, w, http.StatusNotFound)
+
+	ta.Config.DemoMode = true
+	w = login()
+	testutil.AssertStatusCode(t, w, http.StatusServiceUnavailable)
+
+	user, err := s.Users.Create(context.Background(), db.CreateUserParams{GithubID: demo.GitHubID, Username: demo.Username})
+	if err != nil {
+		t.Fatalf("failed to create demo user: %v", err)
+	}
+	w = login()
+	testutil.AssertStatusCode(t, w, http.StatusOK)
+
+	resp := testutil.ParseResponse[map[string]any](t, w)
+	token, _ := resp["access_token"].(string)
+	claims, err := auth.ValidateToken(token, ta.Config.JWTSecret)
+	if err != nil {
+		t.Fatalf("expected a valid access token: %v", err)
+	}
+	if claims.UserID != user.ID {
+		t.Errorf("expected a token of the demo user, got %v", claims.UserID)
+	}
+}
@@ -0,0 +1,80 @@
+package device
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+)
+
+// maxClientNameLength bounds the client name shown on the approval page
+const maxClientNameLength = 100
+
+type DeviceRequest struct {
+	// ClientName identifies the terminal, e.g. "fuegoctl on build-01"
+	ClientName string `json:"client_name"`
+}
+
+type DeviceResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// Post starts a device authorization for a headless terminal. The CLI shows
+// the user code and verification URI, then polls /api/auth/device/token with
+// the device code until the user approves it in the dashboard.
+// POST /api/auth/device
+func Post(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	var req DeviceRequest
+	// The body is optional, and OAuth clients may send fields such as
+	// client_id or scope, so it is not decoded strictly
+	_ = c.Bind(&req)
+
+	clientName := strings.TrimSpace(req.ClientName)
+	if clientName == "" {
+		clientName = "fuegoctl"
+	}
+	if runes := []rune(clientName); len(runes) > maxClientNameLength {
+		clientName = string(runes[:maxClientNameLength])
+	}
+
+	deviceCode, err := auth.GenerateDeviceCode()
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to generate device code"})
+	}
+	userCode, err := auth.GenerateUserCode()
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to generate user code"})
+	}
+
+	_, err = db.New(pool).CreateDeviceAuthorization(c.Request.Context(), db.CreateDeviceAuthorizationParams{
+		DeviceCodeHash: auth.HashToken(deviceCode),
+		UserCode:       userCode,
+		ClientName:     clientName,
+		ExpiresAt:      time.Now().Add(auth.DeviceCodeTTL),
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to create device authorization"})
+	}
+
+	verificationURI := "https://" + cfg.PlatformDomain + "/dashboard/device"
+	return c.JSON(200, DeviceResponse{
+		DeviceCode:              deviceCode,
+		UserCode:                userCode,
+		VerificationURI:         verificationURI,
+		VerificationURIComplete: verificationURI + "?code=" + url.QueryEscape(userCode),
+		ExpiresIn:               int(auth.DeviceCodeTTL.Seconds()),
+		Interval:                int(auth.DevicePollInterval.Seconds()),
+	})
+}
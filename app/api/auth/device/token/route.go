@@ -0,0 +1,144 @@
+package token
+
+import (
+	"errors"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/tokenpolicy"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// deviceCodeGrantType is the grant type of RFC 8628 token requests
+const deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+type DeviceTokenRequest struct {
+	GrantType  string `json:"grant_type"`
+	DeviceCode string `json:"device_code"`
+}
+
+type DeviceTokenResponse struct {
+	// AccessToken is an API token, listed and revocable like any other
+	AccessToken string     `json:"access_token"`
+	TokenType   string     `json:"token_type"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	User        TokenUser  `json:"user"`
+}
+
+type TokenUser struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// Post is polled by the CLI with its device code. Until the user decides it
+// answers 400 with authorization_pending (or slow_down when polled faster
+// than the interval); afterwards access_denied, expired_token or, once, a
+// new API token named after the client.
+// POST /api/auth/device/token
+// Body: { "grant_type": "urn:ietf:params:oauth:grant-type:device_code", "device_code": "..." }
+func Post(c *fuego.Context) error {
+	pool := services.From(c).DB
+
+	var req DeviceTokenRequest
+	// Not decoded strictly: OAuth clients send fields such as client_id
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(400, map[string]string{"error": "invalid_request"})
+	}
+	if req.GrantType != "" && req.GrantType != deviceCodeGrantType {
+		return c.JSON(400, map[string]string{"error": "unsupported_grant_type"})
+	}
+	if req.DeviceCode == "" {
+		return c.JSON(400, map[string]string{"error": "invalid_request"})
+	}
+
+	queries := db.New(pool)
+	authorization, err := queries.GetDeviceAuthorizationByDeviceCode(c.Request.Context(), auth.HashToken(req.DeviceCode))
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "invalid_grant"})
+	}
+
+	poll := auth.DevicePoll{
+		Status:    authorization.Status,
+		ExpiresAt: authorization.ExpiresAt,
+		Interval:  time.Duration(authorization.PollInterval) * time.Second,
+	}
+	if authorization.LastPolledAt.Valid {
+		poll.LastPolledAt = authorization.LastPolledAt.Time
+	}
+
+	interval, err := auth.CheckDevicePoll(poll, time.Now())
+	switch {
+	case errors.Is(err, auth.ErrExpiredToken), errors.Is(err, auth.ErrAccessDenied):
+		_ = queries.DeleteDeviceAuthorization(c.Request.Context(), authorization.ID)
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	case err != nil:
+		_ = queries.UpdateDeviceAuthorizationPoll(c.Request.Context(), db.UpdateDeviceAuthorizationPollParams{
+			ID:           authorization.ID,
+			PollInterval: int32(interval.Seconds()),
+		})
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	// Deleting the approved authorization makes the device code single use
+	authorization, err = queries.ConsumeDeviceAuthorization(c.Request.Context(), authorization.ID)
+	if err != nil || !authorization.UserID.Valid {
+		return c.JSON(400, map[string]string{"error": "invalid_grant"})
+	}
+
+	user, err := queries.GetUserByID(c.Request.Context(), authorization.UserID.Bytes)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "invalid_grant"})
+	}
+
+	token, err := auth.GenerateAPIToken()
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to generate token"})
+	}
+	hashedToken, err := bcrypt.GenerateFromPassword([]byte(token), bcrypt.DefaultCost)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to hash token"})
+	}
+
+	// The strictest lifetime policy of the user's organizations applies
+	maxDays, err := queries.GetMaxTokenLifetimeForUser(c.Request.Context(), pgtype.UUID{Bytes: user.ID, Valid: true})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to load token policy"})
+	}
+	expiry, err := tokenpolicy.ResolveExpiry(time.Now(), 0, maxDays)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	var expiresAt pgtype.Timestamptz
+	var expiresAtPtr *time.Time
+	if !expiry.IsZero() {
+		expiresAt = pgtype.Timestamptz{Time: expiry, Valid: true}
+		expiresAtPtr = &expiry
+	}
+
+	if _, err := queries.CreateAPIToken(c.Request.Context(), db.CreateAPITokenParams{
+		UserID:       user.ID,
+		Name:         authorization.ClientName,
+		TokenHash:    string(hashedToken),
+		ExpiresAt:    expiresAt,
+		AllowedCidrs: []string{},
+	}); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to create token"})
+	}
+
+	return c.JSON(200, DeviceTokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresAt:   expiresAtPtr,
+		User: TokenUser{
+			ID:       user.ID.String(),
+			Username: user.Username,
+			Email:    user.Email,
+		},
+	})
+}
@@ -0,0 +1,140 @@
+package oidc
+
+import (
+	"encoding/json"
+	"errors"
+	"net/netip"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/provenance"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type ExchangeRequest struct {
+	// Provider is github (the default) or gitlab
+	Provider string `json:"provider"`
+	Token    string `json:"token"`
+	App      string `json:"app"`
+}
+
+type ExchangeResponse struct {
+	// AccessToken only calls the API of App and cannot be refreshed
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	App         string    `json:"app"`
+}
+
+// Post exchanges the OIDC token of a CI run for a short-lived deploy token
+// of an app that trusts the run's repository, so CI needs no long-lived API
+// token. The run requests its OIDC token for the CI_OIDC_AUDIENCE audience.
+// POST /api/auth/oidc
+// Body: { "provider": "github", "token": "<OIDC token>", "app": "myapp" }
+func Post(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	ip := ""
+	if addr := clientIP(c); addr != nil {
+		ip = addr.String()
+	}
+	if wait := auth.Blocked(ip, uuid.Nil); wait > 0 {
+		return c.JSON(429, map[string]string{"error": "too many failed authentication attempts, try again later"})
+	}
+
+	var req ExchangeRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+	if req.Provider == "" {
+		req.Provider = provenance.ProviderGitHub
+	}
+	if !provenance.ValidProvider(req.Provider) {
+		return c.JSON(400, map[string]string{"error": "provider must be github or gitlab"})
+	}
+	if req.Token == "" || req.App == "" {
+		return c.JSON(400, map[string]string{"error": "token and app are required"})
+	}
+
+	queries := db.New(pool)
+	run, err := provenance.NewVerifier(cfg.CIOIDCAudience, cfg.GitLabURL).Verify(c.Request.Context(), provenance.Provenance{Provider: req.Provider}, req.Token)
+	if errors.Is(err, provenance.ErrKeysUnavailable) {
+		return c.JSON(502, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		auth.RecordFailure(c.Request.Context(), queries, ip, uuid.Nil, "invalid_oidc_token")
+		return c.JSON(401, map[string]string{"error": "invalid OIDC token"})
+	}
+
+	repository, _ := provenance.NormalizeRepository(run.Repository)
+	trusts, err := queries.ListOIDCTrustsForApp(c.Request.Context(), db.ListOIDCTrustsForAppParams{
+		Name:       req.App,
+		Provider:   req.Provider,
+		Repository: repository,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to load trusts"})
+	}
+
+	var trusted *db.ListOIDCTrustsForAppRow
+	for i, t := range trusts {
+		if !provenance.Trusts(t.Provider, t.Repository, t.Ref, run) {
+			continue
+		}
+		if trusted != nil && trusted.AppID != t.AppID {
+			return c.JSON(409, map[string]string{"error": "several apps named " + req.App + " trust this repository"})
+		}
+		trusted = &trusts[i]
+	}
+	if trusted == nil {
+		return c.JSON(403, map[string]string{"error": "app " + req.App + " does not trust runs of " + run.Repository + " on " + run.Ref})
+	}
+
+	user, err := queries.GetUserByID(c.Request.Context(), trusted.UserID)
+	if err != nil {
+		return c.JSON(403, map[string]string{"error": "app owner not found"})
+	}
+
+	token, expiresAt, err := auth.GenerateAppToken(user.ID, user.Username, trusted.AppName, cfg.JWTSecret, provenance.DeployTokenTTL)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to generate token"})
+	}
+
+	details, _ := json.Marshal(map[string]any{
+		"provider":   run.Provider,
+		"repository": run.Repository,
+		"ref":        run.Ref,
+		"run_id":     run.RunID,
+		"actor":      run.Actor,
+		"subject":    run.Subject,
+		"expires_at": expiresAt,
+	})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: user.ID, Valid: true},
+		AppID:     pgtype.UUID{Bytes: trusted.AppID, Valid: true},
+		Action:    "deploy_token.exchanged",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(200, ExchangeResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresAt:   expiresAt,
+		App:         trusted.AppName,
+	})
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
@@ -1,14 +1,12 @@
 package auth
 
 import (
-	"context"
 	"time"
 
 	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
-	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
 	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type LoginRequest struct {
@@ -21,8 +19,8 @@ type LoginResponse struct {
 }
 
 func Get(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
 
 	redirectURI := c.Query("redirect_uri")
 	if redirectURI == "" {
@@ -30,6 +28,12 @@ func Get(c *fuego.Context) error {
 	}
 	cliTokenExchange := c.Query("cli") == "true"
 
+	// A referral link credits the referrer if the login creates a user
+	var referrer *string
+	if ref := c.Query("ref"); ref != "" && len(ref) <= 255 {
+		referrer = &ref
+	}
+
 	state, err := auth.GenerateState()
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": "failed to generate state"})
@@ -38,11 +42,12 @@ func Get(c *fuego.Context) error {
 	queries := db.New(pool)
 	expiresAt := time.Now().Add(10 * time.Minute)
 
-	_, err = queries.CreateOAuthState(context.Background(), db.CreateOAuthStateParams{
+	_, err = queries.CreateOAuthState(c.Request.Context(), db.CreateOAuthStateParams{
 		State:            state,
 		RedirectUri:      &redirectURI,
 		CliTokenExchange: &cliTokenExchange,
 		ExpiresAt:        expiresAt,
+		Referrer:         referrer,
 	})
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": "failed to create oauth state"})
@@ -1,34 +1,62 @@
 package token
 
 import (
-	"context"
 	"time"
 
 	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
-	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/tokenpolicy"
 	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type CreateTokenRequest struct {
 	Name      string `json:"name"`
 	ExpiresIn int    `json:"expires_in"`
+	// AllowedCIDRs restricts the token to client addresses in these ranges
+	AllowedCIDRs []string `json:"allowed_cidrs"`
+	// ExpireAfterUnusedDays revokes the token once it goes unused this long
+	ExpireAfterUnusedDays int `json:"expire_after_unused_days"`
 }
 
 type TokenResponse struct {
-	ID        string     `json:"id"`
-	Name      string     `json:"name"`
-	Token     string     `json:"token,omitempty"`
-	CreatedAt time.Time  `json:"created_at"`
-	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	Token        string     `json:"token,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	AllowedCIDRs []string   `json:"allowed_cidrs,omitempty"`
+	// LastUsedAt, LastUsedIP and LastUsedUserAgent describe the latest
+	// request authenticated with the token
+	LastUsedAt        *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP        string     `json:"last_used_ip,omitempty"`
+	LastUsedUserAgent string     `json:"last_used_user_agent,omitempty"`
+	// Usage counts requests per day over the last 30 days
+	Usage []DailyUsage `json:"usage,omitempty"`
+	// Stale is set for tokens unused for 90 days or more
+	Stale   bool   `json:"stale"`
+	Warning string `json:"warning,omitempty"`
+	// UnusedExpiresAt is when a token with expire_after_unused_days is
+	// revoked unless used again
+	ExpireAfterUnusedDays *int32     `json:"expire_after_unused_days,omitempty"`
+	UnusedExpiresAt       *time.Time `json:"unused_expires_at,omitempty"`
 }
 
+type DailyUsage struct {
+	Date  string `json:"date"`
+	Count int32  `json:"count"`
+}
+
+// usageDays is how many days of usage counts the token list returns
+const usageDays = 30
+
 func Post(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
 
 	claims, err := auth.ValidateToken(auth.ExtractBearerToken(c.Header("Authorization")), cfg.JWTSecret)
 	if err != nil {
@@ -36,8 +64,8 @@ func Post(c *fuego.Context) error {
 	}
 
 	var req CreateTokenRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(400, map[string]string{"error": "invalid request body"})
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
 	}
 
 	if req.Name == "" {
@@ -54,37 +82,57 @@ func Post(c *fuego.Context) error {
 		return c.JSON(500, map[string]string{"error": "failed to hash token"})
 	}
 
+	allowedCIDRs, err := tokenpolicy.ParseCIDRs(req.AllowedCIDRs)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	unusedDays, err := tokenpolicy.ParseUnusedDays(req.ExpireAfterUnusedDays)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	queries := db.New(pool)
+
+	// The strictest lifetime policy of the user's organizations applies
+	maxDays, err := queries.GetMaxTokenLifetimeForUser(c.Request.Context(), pgtype.UUID{Bytes: claims.UserID, Valid: true})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to load token policy"})
+	}
+
+	expiry, err := tokenpolicy.ResolveExpiry(time.Now(), time.Duration(req.ExpiresIn)*time.Second, maxDays)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
 	var expiresAt pgtype.Timestamptz
 	var expiresAtPtr *time.Time
-	if req.ExpiresIn > 0 {
-		exp := time.Now().Add(time.Duration(req.ExpiresIn) * time.Second)
-		expiresAt = pgtype.Timestamptz{Time: exp, Valid: true}
-		expiresAtPtr = &exp
+	if !expiry.IsZero() {
+		expiresAt = pgtype.Timestamptz{Time: expiry, Valid: true}
+		expiresAtPtr = &expiry
 	}
 
-	queries := db.New(pool)
-	apiToken, err := queries.CreateAPIToken(context.Background(), db.CreateAPITokenParams{
-		UserID:    claims.UserID,
-		Name:      req.Name,
-		TokenHash: string(hashedToken),
-		ExpiresAt: expiresAt,
+	apiToken, err := queries.CreateAPIToken(c.Request.Context(), db.CreateAPITokenParams{
+		UserID:       claims.UserID,
+		Name:         req.Name,
+		TokenHash:    string(hashedToken),
+		ExpiresAt:             expiresAt,
+		AllowedCidrs:          allowedCIDRs,
+		ExpireAfterUnusedDays: unusedDays,
 	})
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": "failed to create token"})
 	}
 
-	return c.JSON(201, TokenResponse{
-		ID:        apiToken.ID.String(),
-		Name:      apiToken.Name,
-		Token:     token,
-		CreatedAt: apiToken.CreatedAt,
-		ExpiresAt: expiresAtPtr,
-	})
+	resp := toTokenResponse(apiToken, nil, time.Now())
+	resp.Token = token
+	resp.ExpiresAt = expiresAtPtr
+	return c.JSON(201, resp)
 }
 
 func Get(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
 
 	claims, err := auth.ValidateToken(auth.ExtractBearerToken(c.Header("Authorization")), cfg.JWTSecret)
 	if err != nil {
@@ -92,24 +140,68 @@ func Get(c *fuego.Context) error {
 	}
 
 	queries := db.New(pool)
-	tokens, err := queries.ListAPITokensByUser(context.Background(), claims.UserID)
+	tokens, err := queries.ListAPITokensByUser(c.Request.Context(), claims.UserID)
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": "failed to list tokens"})
 	}
 
+	now := time.Now()
+	usage, err := queries.ListAPITokenUsageByUser(c.Request.Context(), db.ListAPITokenUsageByUserParams{
+		UserID: claims.UserID,
+		Day:    pgtype.Date{Time: now.AddDate(0, 0, -usageDays), Valid: true},
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to load token usage"})
+	}
+	usageByToken := make(map[uuid.UUID][]DailyUsage)
+	for _, u := range usage {
+		usageByToken[u.TokenID] = append(usageByToken[u.TokenID], DailyUsage{
+			Date:  u.Day.Time.Format(time.DateOnly),
+			Count: u.Count,
+		})
+	}
+
 	response := make([]TokenResponse, len(tokens))
 	for i, t := range tokens {
-		var expiresAt *time.Time
-		if t.ExpiresAt.Valid {
-			expiresAt = &t.ExpiresAt.Time
-		}
-		response[i] = TokenResponse{
-			ID:        t.ID.String(),
-			Name:      t.Name,
-			CreatedAt: t.CreatedAt,
-			ExpiresAt: expiresAt,
-		}
+		response[i] = toTokenResponse(t, usageByToken[t.ID], now)
 	}
 
 	return c.JSON(200, response)
 }
+
+func toTokenResponse(t db.ApiToken, usage []DailyUsage, now time.Time) TokenResponse {
+	resp := TokenResponse{
+		ID:                    t.ID.String(),
+		Name:                  t.Name,
+		CreatedAt:             t.CreatedAt,
+		AllowedCIDRs:          t.AllowedCidrs,
+		LastUsedUserAgent:     derefString(t.LastUsedUserAgent),
+		Usage:                 usage,
+		ExpireAfterUnusedDays: t.ExpireAfterUnusedDays,
+	}
+	if t.ExpiresAt.Valid {
+		resp.ExpiresAt = &t.ExpiresAt.Time
+	}
+	if t.LastUsedAt.Valid {
+		resp.LastUsedAt = &t.LastUsedAt.Time
+	}
+	if t.LastUsedIp != nil {
+		resp.LastUsedIP = t.LastUsedIp.String()
+	}
+	if expiry := tokenpolicy.UnusedExpiry(t.CreatedAt, t.LastUsedAt, t.ExpireAfterUnusedDays); !expiry.IsZero() {
+		resp.UnusedExpiresAt = &expiry
+	}
+
+	if tokenpolicy.Stale(t.CreatedAt, t.LastUsedAt, now) {
+		resp.Stale = true
+		resp.Warning = "This token has not been used for 90 days or more. Delete it if it is no longer needed, or recreate it with expire_after_unused_days so forgotten tokens revoke themselves."
+	}
+	return resp
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
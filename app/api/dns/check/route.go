@@ -0,0 +1,88 @@
+package check
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/domainrecords"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+// nameRegex matches host names, allowing the underscores of records such
+// as _fuego-verify.example.com
+var nameRegex = regexp.MustCompile(`^([a-z0-9_]([a-z0-9_-]{0,61}[a-z0-9])?\.)+[a-z]{2,}$`)
+
+// maxExpectLength bounds the expected value, the longest TXT records aside
+const maxExpectLength = 255
+
+// Get asks several public resolvers for a record and reports what each one
+// answers with the remaining TTLs, so the UI can show how far a DNS change
+// has propagated
+// GET /api/dns/check?name=www.example.com&type=CNAME&expect=web.nexo.build
+// Query params:
+//   - name: the record name
+//   - type: A (default), AAAA, CNAME, TXT, NS, MX or CAA
+//   - expect: the value the record should have; without it any value counts
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+
+	if _, err := getUserID(c, cfg); err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	name := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(c.Query("name"))), ".")
+	if name == "" {
+		return c.JSON(400, map[string]string{"error": "name is required"})
+	}
+	if len(name) > 253 || !nameRegex.MatchString(name) {
+		return c.JSON(400, map[string]string{"error": "invalid name format"})
+	}
+
+	recordType := c.Query("type")
+	if recordType == "" {
+		recordType = "A"
+	}
+
+	expect := strings.TrimSpace(c.Query("expect"))
+	if len(expect) > maxExpectLength {
+		return c.JSON(400, map[string]string{"error": "expect may be at most 255 characters"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	report, err := domainrecords.CheckPropagation(ctx, domainrecords.PropagationResolvers, name, recordType, expect)
+	if errors.Is(err, domainrecords.ErrUnsupportedType) {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "invalid name format"})
+	}
+
+	return c.JSON(200, report)
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
@@ -0,0 +1,182 @@
+// Package estimate prices an app before it is created or resized.
+package estimate
+
+import (
+	"context"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/plans"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+// bandwidthWindow is how far back the expected bandwidth of an existing app
+// is measured
+const bandwidthWindow = 30 * 24 * time.Hour
+
+// maxEgressGB bounds the expected bandwidth, a petabyte a month
+const maxEgressGB = 1 << 20
+
+type EstimateRequest struct {
+	// App is an existing app to price a change of; fields left out keep
+	// its current setup, which is priced too for comparison
+	App      string    `json:"app"`
+	Size     string    `json:"size"`
+	Replicas *int32    `json:"replicas"`
+	AddOns   *[]string `json:"add_ons"`
+	// EgressGB is the expected outbound bandwidth per month, by default
+	// that of the app over the last 30 days
+	EgressGB *float64 `json:"egress_gb"`
+}
+
+type EstimateResponse struct {
+	plans.Estimate
+	// Current and DeltaCents compare the estimate with the app's current
+	// setup when an app is given
+	Current    *plans.Estimate `json:"current,omitempty"`
+	DeltaCents *int64          `json:"delta_cents,omitempty"`
+	Currency   string          `json:"currency"`
+}
+
+// setup is what an estimate prices
+type setup struct {
+	size        string
+	replicas    int32
+	addOns      []string
+	egressBytes int64
+}
+
+// Post estimates the monthly cost of a new app, or of a size change of an
+// existing one, with the prices billing uses: replicas of the size, add-ons
+// and outbound bandwidth over the included amount
+// POST /api/estimate
+// Body: { "app": "shop", "size": "pro", "replicas": 3, "add_ons": ["mtls"], "egress_gb": 250 }
+func Post(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req EstimateRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+	if req.EgressGB != nil && (*req.EgressGB < 0 || *req.EgressGB > maxEgressGB) {
+		return c.JSON(400, map[string]string{"error": "egress_gb must be between 0 and 1048576"})
+	}
+
+	current := setup{size: plans.Default, replicas: 1}
+	if req.App != "" {
+		queries := db.New(pool)
+		app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
+			UserID: userID,
+			Name:   req.App,
+		})
+		if err != nil {
+			return c.JSON(404, map[string]string{"error": "app not found"})
+		}
+		current, err = currentSetup(c.Request.Context(), queries, app)
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to load app"})
+		}
+	}
+
+	proposed := current
+	if req.Size != "" {
+		proposed.size = req.Size
+	}
+	if req.Replicas != nil {
+		proposed.replicas = *req.Replicas
+	}
+	if req.AddOns != nil {
+		proposed.addOns = *req.AddOns
+	}
+	if req.EgressGB != nil {
+		proposed.egressBytes = int64(*req.EgressGB * (1 << 30))
+	}
+
+	estimate, err := plans.EstimateMonthly(proposed.size, proposed.replicas, proposed.addOns, proposed.egressBytes)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	response := EstimateResponse{Estimate: estimate, Currency: "usd"}
+
+	if req.App != "" {
+		// Apps of an unknown size are billed as the default size
+		existing, err := plans.EstimateMonthly(plans.ForSize(current.size).Name, current.replicas, current.addOns, current.egressBytes)
+		if err == nil {
+			delta := estimate.TotalCents - existing.TotalCents
+			response.Current = &existing
+			response.DeltaCents = &delta
+		}
+	}
+
+	return c.JSON(200, response)
+}
+
+// currentSetup is how an app runs now: the replicas of its formation, its
+// add-ons and its outbound bandwidth over the last 30 days
+func currentSetup(ctx context.Context, queries *db.Queries, app db.App) (setup, error) {
+	current := setup{size: app.Size}
+
+	processes, err := queries.ListAppProcesses(ctx, app.ID)
+	if err != nil {
+		return setup{}, err
+	}
+	web := false
+	for _, p := range processes {
+		current.replicas += p.Replicas
+		web = web || p.ProcessType == k8s.ProcessTypeWeb
+	}
+	// Apps run one web replica until scaled
+	if !web {
+		current.replicas++
+	}
+
+	if _, err := queries.GetAppPlacement(ctx, app.ID); err == nil {
+		current.addOns = append(current.addOns, plans.AddOnDedicatedNodes)
+	}
+	if _, err := queries.GetAppMTLS(ctx, app.ID); err == nil {
+		current.addOns = append(current.addOns, plans.AddOnMTLS)
+	}
+	if _, err := queries.GetAppEgressIP(ctx, app.ID); err == nil {
+		current.addOns = append(current.addOns, plans.AddOnStaticEgress)
+	}
+
+	bandwidth, err := queries.GetAppBandwidth(ctx, db.GetAppBandwidthParams{
+		AppID:       app.ID,
+		PeriodStart: time.Now().Add(-bandwidthWindow),
+	})
+	if err != nil {
+		return setup{}, err
+	}
+	current.egressBytes = bandwidth.EgressBytes
+	return current, nil
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
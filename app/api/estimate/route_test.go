@@ -0,0 +1,51 @@
+package estimate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/plans"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/testutil"
+	"github.com/google/uuid"
+)
+
+func TestPost_NewApp(t *testing.T) {
+	ta := testutil.NewTestApp().WithAuth(uuid.New(), "octocat")
+	ta.App.Post("/api/estimate", Post)
+	ta.App.Mount()
+
+	estimate := func(body map[string]any) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		ta.App.ServeHTTP(w, testutil.MakeRequest(t, http.MethodPost, "/api/estimate", body, nil))
+		return w
+	}
+
+	w := estimate(map[string]any{})
+	testutil.AssertStatusCode(t, w, http.StatusOK)
+	defaults := testutil.ParseResponse[EstimateResponse](t, w)
+	if defaults.Size != plans.Default || defaults.Replicas != 1 || defaults.TotalCents != plans.ForSize(plans.Default).MonthlyPriceCents {
+		t.Errorf("expected one replica of the default size, got %+v", defaults)
+	}
+	if defaults.Current != nil || defaults.DeltaCents != nil {
+		t.Errorf("expected no comparison for a new app, got %+v", defaults)
+	}
+
+	w = estimate(map[string]any{"size": "pro", "replicas": 2, "add_ons": []string{"mtls"}, "egress_gb": 150})
+	testutil.AssertStatusCode(t, w, http.StatusOK)
+	pro := testutil.ParseResponse[EstimateResponse](t, w)
+	want := 2*plans.ForSize("pro").MonthlyPriceCents + 1000 + 50*plans.EgressCentsPerGB
+	if pro.TotalCents != want || len(pro.Items) != 3 || pro.Currency != "usd" {
+		t.Errorf("expected %d cents in 3 items, got %+v", want, pro)
+	}
+
+	for _, body := range []map[string]any{
+		{"size": "huge"},
+		{"size": "starter", "replicas": 3},
+		{"add_ons": []string{"dedicated_nodes"}},
+		{"egress_gb": -1},
+	} {
+		testutil.AssertStatusCode(t, estimate(body), http.StatusBadRequest)
+	}
+}
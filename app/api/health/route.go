@@ -5,9 +5,8 @@ import (
 	"context"
 	"time"
 
-	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
 	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // HealthResponse represents the health check response.
@@ -26,11 +25,11 @@ func Get(c *fuego.Context) error {
 	}
 
 	// Check database
-	pool, ok := c.Get("db").(*pgxpool.Pool)
-	if !ok || pool == nil {
+	pool := services.From(c).DB
+	if pool == nil {
 		response.Database = "disconnected"
 	} else {
-		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
 		defer cancel()
 
 		if err := pool.Ping(ctx); err != nil {
@@ -42,20 +41,18 @@ func Get(c *fuego.Context) error {
 	}
 
 	// Check Kubernetes
-	k8sClient, ok := c.Get("k8s").(*k8s.Client)
-	if !ok || k8sClient == nil {
+	k8sClient := services.From(c).K8s
+	if k8sClient == nil {
 		response.Kubernetes = "disconnected"
 	} else {
-		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
 		defer cancel()
 
-		_, err := k8sClient.Clientset().Discovery().ServerVersion()
-		if err != nil {
+		if _, err := k8sClient.Ping(ctx); err != nil {
 			response.Kubernetes = "unhealthy"
 		} else {
 			response.Kubernetes = "healthy"
 		}
-		_ = ctx // use context for potential future timeout
 	}
 
 	statusCode := 200
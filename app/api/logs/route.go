@@ -0,0 +1,266 @@
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/concurrency"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/streams"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+// MaxApps is how many apps one request may tail
+const MaxApps = 10
+
+// AppLogLine is a log line labeled with the app it comes from
+type AppLogLine struct {
+	App string `json:"app"`
+	k8s.LogLine
+}
+
+type LogsResponse struct {
+	Logs []AppLogLine `json:"logs"`
+}
+
+// source is an app whose logs are tailed, with the cluster it runs in
+type source struct {
+	app     string
+	cluster k8s.Interface
+}
+
+// event is a line of an app's logs, or the error its stream ended with
+type event struct {
+	line AppLogLine
+	err  error
+}
+
+// Get returns the logs of several apps at once, each line labeled with its
+// app. Following merges the apps' streams into one SSE stream, so tailing
+// them takes a single connection, concurrency slot and stream.
+// GET /api/logs
+// Query params:
+//   - app: an app to tail, repeated or comma-separated (at most 10)
+//   - tail: number of lines per app (default 100)
+//   - follow: stream logs via SSE (default false)
+func Get(c *fuego.Context) error {
+	svc := services.From(c)
+	cfg := svc.Config
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	names := appNames(c.Request.URL.Query()["app"])
+	if len(names) == 0 {
+		return c.JSON(400, map[string]string{"error": "at least one app is required"})
+	}
+	if len(names) > MaxApps {
+		return c.JSON(400, map[string]string{"error": fmt.Sprintf("at most %d apps can be tailed at once", MaxApps)})
+	}
+
+	// Verify app ownership
+	sources := make([]source, 0, len(names))
+	for _, name := range names {
+		app, err := svc.Store.Apps.GetByName(c.Request.Context(), userID, name)
+		if err != nil {
+			return c.JSON(404, map[string]string{"error": "app not found: " + name})
+		}
+		cluster, err := svc.Cluster(app.Region)
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "kubernetes not available"})
+		}
+		sources = append(sources, source{app: app.Name, cluster: cluster})
+	}
+
+	// Parse query parameters
+	tailLines := int64(100)
+	if t := c.Query("tail"); t != "" {
+		if parsed, err := strconv.ParseInt(t, 10, 64); err == nil && parsed > 0 {
+			tailLines = parsed
+		}
+	}
+
+	if c.Query("follow") == "true" {
+		release, err := svc.Concurrency.Acquire(c.Request.Context(), concurrency.LogStream, userID)
+		if err != nil {
+			return concurrency.Reject(c, err)
+		}
+		defer release()
+
+		stream, err := svc.Streams.Open(c.Request.Context(), userID, streams.KindLogs)
+		if err != nil {
+			return c.JSON(429, map[string]string{
+				"error":  "too many open streams, close one before opening another",
+				"reason": "stream_limit",
+			})
+		}
+		defer stream.Close()
+		return streamLogs(c, stream, sources, tailLines)
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	var logs []AppLogLine
+	for _, src := range sources {
+		lines, err := src.cluster.GetRecentLogs(ctx, src.app, tailLines)
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": fmt.Sprintf("failed to get logs of %s: %v", src.app, err)})
+		}
+		for _, line := range lines {
+			logs = append(logs, AppLogLine{App: src.app, LogLine: line})
+		}
+	}
+	if logs == nil {
+		logs = []AppLogLine{}
+	}
+
+	return c.JSON(200, LogsResponse{Logs: logs})
+}
+
+// appNames returns the distinct apps of repeated and comma-separated app
+// params, in the order given
+func appNames(params []string) []string {
+	var names []string
+	for _, param := range params {
+		for _, name := range strings.Split(param, ",") {
+			name = strings.TrimSpace(name)
+			if name != "" && !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// streamLogs streams the logs of the apps via Server-Sent Events (SSE),
+// each line labeled with its app. An app whose stream fails gets an error
+// event while the others go on; the response ends once every stream has.
+func streamLogs(c *fuego.Context, stream *streams.Stream, sources []source, tailLines int64) error {
+	// Set SSE headers
+	c.Response.Header().Set("Content-Type", "text/event-stream")
+	c.Response.Header().Set("Cache-Control", "no-cache")
+	c.Response.Header().Set("Connection", "keep-alive")
+	c.Response.Header().Set("X-Accel-Buffering", "no") // Disable nginx buffering
+
+	flusher, ok := c.Response.(http.Flusher)
+	if !ok {
+		return c.JSON(500, map[string]string{"error": "streaming not supported"})
+	}
+
+	// Ends when the client disconnects or the stream idles
+	ctx := stream.Context()
+	events := mergeStreams(ctx, sources, tailLines)
+
+	for {
+		select {
+		case <-ctx.Done():
+			if stream.Idle() {
+				_, _ = fmt.Fprint(c.Response, "event: idle\ndata: {}\n\n")
+				flusher.Flush()
+			}
+			return nil
+		case <-stream.Heartbeats():
+			if _, err := fmt.Fprint(c.Response, ": ping\n\n"); err != nil {
+				return nil
+			}
+			flusher.Flush()
+		case ev, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if ev.err != nil {
+				data, _ := json.Marshal(map[string]string{"app": ev.line.App, "error": ev.err.Error()})
+				if _, err := fmt.Fprintf(c.Response, "event: error\ndata: %s\n\n", data); err != nil {
+					return nil
+				}
+				flusher.Flush()
+				continue
+			}
+			data, _ := json.Marshal(ev.line)
+			if _, err := fmt.Fprintf(c.Response, "data: %s\n\n", data); err != nil {
+				return nil
+			}
+			flusher.Flush()
+			stream.Active()
+		}
+	}
+}
+
+// mergeStreams follows the logs of every app into one channel, closed once
+// all of the streams have ended
+func mergeStreams(ctx context.Context, sources []source, tailLines int64) <-chan event {
+	events := make(chan event, 100)
+	opts := k8s.LogStreamOptions{
+		Follow:     true,
+		TailLines:  tailLines,
+		Timestamps: true,
+	}
+
+	var wg sync.WaitGroup
+	for _, src := range sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			logCh := make(chan k8s.LogLine, 100)
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for line := range logCh {
+					select {
+					case events <- event{line: AppLogLine{App: src.app, LogLine: line}}:
+					case <-ctx.Done():
+					}
+				}
+			}()
+
+			err := src.cluster.StreamLogs(ctx, src.app, opts, logCh)
+			close(logCh)
+			<-done
+			if err != nil && ctx.Err() == nil {
+				select {
+				case events <- event{line: AppLogLine{App: src.app}, err: err}:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(events)
+	}()
+	return events
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if id, ok := c.Get("user_id").(uuid.UUID); ok {
+		return id, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
@@ -0,0 +1,90 @@
+package logs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/store"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/testutil"
+)
+
+func TestGet(t *testing.T) {
+	s := store.NewMemory()
+	user := testutil.SeedUser(t, s, "alice")
+	testutil.SeedApp(t, s, user.ID, "api")
+	testutil.SeedApp(t, s, user.ID, "worker")
+	bob := testutil.SeedUser(t, s, "bob")
+	testutil.SeedApp(t, s, bob.ID, "billing")
+
+	cluster := k8s.NewFake()
+	cluster.Logs["api"] = []k8s.LogLine{{Pod: "api-1", Container: "web", Message: "GET /"}}
+	cluster.Logs["worker"] = []k8s.LogLine{{Pod: "worker-1", Container: "worker", Message: "job done"}}
+
+	ta := testutil.NewTestApp().WithStore(s).WithK8s(cluster).WithAuth(user.ID, user.Username)
+	ta.App.Get("/api/logs", Get)
+	ta.App.Mount()
+
+	get := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		ta.App.ServeHTTP(w, testutil.MakeRequest(t, http.MethodGet, "/api/logs"+query, nil, nil))
+		return w
+	}
+
+	w := get("?app=api&app=worker")
+	testutil.AssertStatusCode(t, w, http.StatusOK)
+	resp := testutil.ParseResponse[LogsResponse](t, w)
+	if len(resp.Logs) != 2 || resp.Logs[0].App != "api" || resp.Logs[1].App != "worker" || resp.Logs[1].Message != "job done" {
+		t.Errorf("expected the lines labeled with their app, got %+v", resp.Logs)
+	}
+
+	testutil.AssertStatusCode(t, get(""), http.StatusBadRequest)
+	testutil.AssertStatusCode(t, get("?app=a,b,c,d,e,f,g,h,i,j,k"), http.StatusBadRequest)
+
+	// Another user's app is not found, and no logs are read
+	w = get("?app=api,billing")
+	testutil.AssertStatusCode(t, w, http.StatusNotFound)
+	testutil.AssertJSONContains(t, w, "error", "app not found: billing")
+	if cluster.Called("GetRecentLogs billing") {
+		t.Error("expected another user's app not to reach the cluster")
+	}
+}
+
+func TestGet_Follow(t *testing.T) {
+	s := store.NewMemory()
+	user := testutil.SeedUser(t, s, "alice")
+	testutil.SeedApp(t, s, user.ID, "api")
+	testutil.SeedApp(t, s, user.ID, "worker")
+	testutil.SeedApp(t, s, user.ID, "cron")
+
+	cluster := k8s.NewFake()
+	cluster.Logs["api"] = []k8s.LogLine{{Pod: "api-1", Container: "web", Message: "GET /"}}
+	cluster.Logs["worker"] = []k8s.LogLine{{Pod: "worker-1", Container: "worker", Message: "job done"}}
+
+	ta := testutil.NewTestApp().WithStore(s).WithK8s(cluster).WithAuth(user.ID, user.Username)
+	ta.App.Get("/api/logs", Get)
+	ta.App.Mount()
+
+	// The client disconnects after a moment
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	req := testutil.MakeRequest(t, http.MethodGet, "/api/logs?app=api,worker,cron&follow=true", nil, nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	ta.App.ServeHTTP(w, req)
+
+	testutil.AssertStatusCode(t, w, http.StatusOK)
+	body := w.Body.String()
+	for _, want := range []string{
+		`data: {"app":"api","pod":"api-1","container":"web","message":"GET /"}`,
+		`data: {"app":"worker","pod":"worker-1","container":"worker","message":"job done"}`,
+		"event: error\ndata: {\"app\":\"cron\",\"error\":\"no pods found for app cron\"}",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in the stream, got\n%s", want, body)
+		}
+	}
+}
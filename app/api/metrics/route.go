@@ -2,11 +2,18 @@
 package metrics
 
 import (
+	"context"
 	"fmt"
 	"runtime"
+	"sort"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/events"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/scheduler"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
 	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
 )
 
@@ -16,8 +23,38 @@ var (
 	requestLatency atomic.Uint64 // in microseconds
 	errorCount     atomic.Uint64
 	startTime      = time.Now()
+
+	eventsMu    sync.Mutex
+	eventCounts = map[string]uint64{}
 )
 
+// RecordEvent counts an event delivered by the event bus by type
+func RecordEvent(_ context.Context, e events.Event) error {
+	eventsMu.Lock()
+	eventCounts[e.Type]++
+	eventsMu.Unlock()
+	return nil
+}
+
+// eventMetrics renders the event counters in Prometheus exposition format
+func eventMetrics() string {
+	eventsMu.Lock()
+	defer eventsMu.Unlock()
+
+	types := make([]string, 0, len(eventCounts))
+	for t := range eventCounts {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	var b strings.Builder
+	b.WriteString("\n# HELP fuego_cloud_events_total Platform events delivered by the event bus\n# TYPE fuego_cloud_events_total counter\n")
+	for _, t := range types {
+		fmt.Fprintf(&b, "fuego_cloud_events_total{type=%q} %d\n", t, eventCounts[t])
+	}
+	return b.String()
+}
+
 // IncrementRequests increments the request counter
 func IncrementRequests() {
 	requestCount.Add(1)
@@ -37,6 +74,15 @@ func IncrementErrors() {
 	errorCount.Add(1)
 }
 
+// AverageLatency returns the mean request latency since startup
+func AverageLatency() time.Duration {
+	requests := requestCount.Load()
+	if requests == 0 {
+		return 0
+	}
+	return time.Duration(requestLatency.Load()/requests) * time.Microsecond //nolint:gosec // Average of microsecond totals fits in int64
+}
+
 // Get returns Prometheus-formatted metrics
 func Get(c *fuego.Context) error {
 	var m runtime.MemStats
@@ -101,5 +147,6 @@ fuego_cloud_gc_num_gc %d
 	)
 
 	c.Response.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
-	return c.String(200, metrics)
+	manager := services.From(c).Streams
+	return c.String(200, metrics+eventMetrics()+scheduler.Metrics()+manager.Metrics())
 }
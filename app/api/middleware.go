@@ -4,14 +4,33 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
+	"math"
+	"net/http"
+	"net/netip"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/actor"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
-	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/collaborators"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/compression"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/cors"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/dbhealth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/errtrack"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/readonly"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/signedurl"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/suspension"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/timeouts"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/tokenpolicy"
 	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -103,6 +122,70 @@ func RequestIDMiddleware() fuego.MiddlewareFunc {
 	}
 }
 
+// =============================================================================
+// Error Tracking Middleware
+// =============================================================================
+
+// ErrorTrackingMiddleware reports requests that fail with a 500 or a
+// handler error, tagged with the request, user and app
+func ErrorTrackingMiddleware() fuego.MiddlewareFunc {
+	return func(next fuego.HandlerFunc) fuego.HandlerFunc {
+		return func(c *fuego.Context) error {
+			err := next(c)
+			if !errtrack.Enabled() {
+				return err
+			}
+			if err == nil && c.StatusCode() == http.StatusInternalServerError {
+				err = fmt.Errorf("%s %s answered %d", c.Method(), c.Path(), c.StatusCode())
+			}
+			errtrack.CaptureError(err, requestTags(c))
+			return err
+		}
+	}
+}
+
+// requestTags identify the request, its user and app in error reports
+func requestTags(c *fuego.Context) map[string]string {
+	requestID, _ := c.Get("request_id").(string)
+	tags := map[string]string{
+		errtrack.TagRequestID: requestID,
+		"method":              c.Method(),
+		"path":                c.Path(),
+	}
+	if name, ok := strings.CutPrefix(c.Path(), "/api/apps/"); ok {
+		tags[errtrack.TagApp], _, _ = strings.Cut(name, "/")
+	}
+
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		tags[errtrack.TagUserID] = userID.String()
+	} else if cfg := services.From(c).Config; cfg != nil {
+		token := auth.ExtractBearerToken(c.Header("Authorization"))
+		if token == "" {
+			token = c.Cookie("access_token")
+		}
+		if claims, err := auth.ValidateToken(token, cfg.JWTSecret); err == nil {
+			tags[errtrack.TagUserID] = claims.UserID.String()
+		}
+	}
+	return tags
+}
+
+// =============================================================================
+// Client IP Middleware
+// =============================================================================
+
+// ClientIPMiddleware resolves the client address once per request, trusting
+// forwarding headers only from the resolver's proxies. Rate limiting, audit
+// logs and token restrictions all read the result.
+func ClientIPMiddleware(resolver *clientip.Resolver) fuego.MiddlewareFunc {
+	return func(next fuego.HandlerFunc) fuego.HandlerFunc {
+		return func(c *fuego.Context) error {
+			c.Set(clientip.ContextKey, resolver.Resolve(c.Request))
+			return next(c)
+		}
+	}
+}
+
 // =============================================================================
 // Request Logging Middleware
 // =============================================================================
@@ -191,24 +274,30 @@ func SecurityHeadersMiddleware() fuego.MiddlewareFunc {
 // CORS Middleware
 // =============================================================================
 
-// CORSMiddleware handles Cross-Origin Resource Sharing
-func CORSMiddleware(allowedOrigins []string) fuego.MiddlewareFunc {
-	allowedOriginsMap := make(map[string]bool)
-	for _, origin := range allowedOrigins {
-		allowedOriginsMap[origin] = true
-	}
+// CORSMiddleware handles Cross-Origin Resource Sharing. Trusted origins may
+// make credentialed requests; with a "*" entry any other origin may read
+// responses without credentials.
+func CORSMiddleware(policy *cors.Policy) fuego.MiddlewareFunc {
+	methods := strings.Join(cors.Methods, ", ")
+	headers := strings.Join(cors.Headers, ", ")
+	maxAge := strconv.Itoa(int(cors.MaxAge.Seconds()))
 
 	return func(next fuego.HandlerFunc) fuego.HandlerFunc {
 		return func(c *fuego.Context) error {
 			origin := c.Header("Origin")
+			c.Response.Header().Add("Vary", "Origin")
 
 			// Check if origin is allowed
-			if origin != "" && (len(allowedOrigins) == 0 || allowedOriginsMap[origin] || allowedOriginsMap["*"]) {
-				c.Response.Header().Set("Access-Control-Allow-Origin", origin)
-				c.Response.Header().Set("Access-Control-Allow-Credentials", "true")
-				c.Response.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-				c.Response.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, X-Request-ID")
-				c.Response.Header().Set("Access-Control-Max-Age", "86400")
+			if policy.Allowed(origin) {
+				if policy.Trusted(origin) {
+					c.Response.Header().Set("Access-Control-Allow-Origin", origin)
+					c.Response.Header().Set("Access-Control-Allow-Credentials", "true")
+				} else {
+					c.Response.Header().Set("Access-Control-Allow-Origin", "*")
+				}
+				c.Response.Header().Set("Access-Control-Allow-Methods", methods)
+				c.Response.Header().Set("Access-Control-Allow-Headers", headers)
+				c.Response.Header().Set("Access-Control-Max-Age", maxAge)
 			}
 
 			// Handle preflight
@@ -221,6 +310,231 @@ func CORSMiddleware(allowedOrigins []string) fuego.MiddlewareFunc {
 	}
 }
 
+// =============================================================================
+// Compression Middleware
+// =============================================================================
+
+// CompressionMiddleware decompresses gzip request bodies and gzips large
+// textual responses for clients that accept it
+func CompressionMiddleware() fuego.MiddlewareFunc {
+	return func(next fuego.HandlerFunc) fuego.HandlerFunc {
+		return func(c *fuego.Context) error {
+			if err := compression.DecodeRequest(c.Request); err != nil {
+				if errors.Is(err, compression.ErrUnsupportedEncoding) {
+					return c.JSON(415, map[string]string{"error": err.Error()})
+				}
+				return c.JSON(400, map[string]string{"error": err.Error()})
+			}
+
+			c.Response.Header().Add("Vary", "Accept-Encoding")
+			if c.Method() == http.MethodHead || c.Header("Upgrade") != "" || !compression.AcceptsGzip(c.Header("Accept-Encoding")) {
+				return next(c)
+			}
+
+			original := c.Response
+			writer := compression.NewResponseWriter(original)
+			c.Response = writer
+			err := next(c)
+			if closeErr := writer.Close(); closeErr != nil {
+				slog.Debug("failed to finish compressed response", "path", c.Path(), "error", closeErr)
+			}
+			c.Response = original
+			return err
+		}
+	}
+}
+
+// =============================================================================
+// Body Limit Middleware
+// =============================================================================
+
+// BodyLimitMiddleware rejects request bodies over maxBytes with 413. Bodies
+// without a declared length, such as decompressed ones, are cut off at the
+// limit and the handler reading them answers 413.
+func BodyLimitMiddleware(maxBytes int64) fuego.MiddlewareFunc {
+	return func(next fuego.HandlerFunc) fuego.HandlerFunc {
+		return func(c *fuego.Context) error {
+			if maxBytes <= 0 || c.Request.Body == nil || c.Request.Body == http.NoBody {
+				return next(c)
+			}
+			if c.Request.ContentLength > maxBytes {
+				return c.JSON(413, map[string]string{"error": fmt.Sprintf("request body too large: larger than %d bytes", maxBytes)})
+			}
+			c.Request.Body = http.MaxBytesReader(c.Response, c.Request.Body, maxBytes)
+			return next(c)
+		}
+	}
+}
+
+// =============================================================================
+// Database Availability Middleware
+// =============================================================================
+
+// DatabaseMiddleware answers 503 with a Retry-After while the database is
+// unreachable, instead of letting handlers fail, except on health, metrics
+// and static routes which work without it
+func DatabaseMiddleware(monitor *dbhealth.Monitor) fuego.MiddlewareFunc {
+	retryAfter := strconv.Itoa(int(monitor.RetryAfter().Seconds()))
+
+	return func(next fuego.HandlerFunc) fuego.HandlerFunc {
+		return func(c *fuego.Context) error {
+			if monitor.Available() || dbhealth.Exempt(c.Path()) {
+				return next(c)
+			}
+			c.Response.Header().Set("Retry-After", retryAfter)
+			return c.JSON(503, map[string]string{"error": "database unavailable, retry shortly"})
+		}
+	}
+}
+
+// =============================================================================
+// Read-Only Middleware
+// =============================================================================
+
+// ReadOnlyMiddleware answers mutations with 503 and the operator's notice
+// while the platform is in read-only mode. Reads keep working.
+func ReadOnlyMiddleware(sw *readonly.Switch) fuego.MiddlewareFunc {
+	retryAfter := strconv.Itoa(int(sw.RetryAfter().Seconds()))
+
+	return func(next fuego.HandlerFunc) fuego.HandlerFunc {
+		return func(c *fuego.Context) error {
+			state := sw.State()
+			if !state.Enabled || !readonly.Blocks(c.Method(), c.Path()) {
+				return next(c)
+			}
+			c.Response.Header().Set("Retry-After", retryAfter)
+			return c.JSON(503, map[string]string{
+				"error":   "the platform is in read-only mode",
+				"reason":  "read_only",
+				"message": state.Message,
+			})
+		}
+	}
+}
+
+// =============================================================================
+// Timeout Middleware
+// =============================================================================
+
+// TimeoutMiddleware bounds each request by its route's timeout. Past it the
+// request context is canceled and the client gets a 504 with the request
+// ID; the handler's response is buffered and dropped once too late. The
+// handler shares c, so the 504 waits for it to return, which handlers do
+// promptly by passing c.Request.Context() to the database and clusters.
+func TimeoutMiddleware(policy *timeouts.Policy) fuego.MiddlewareFunc {
+	return func(next fuego.HandlerFunc) fuego.HandlerFunc {
+		return func(c *fuego.Context) error {
+			timeout := policy.For(c.Request)
+			if timeout <= 0 {
+				return next(c)
+			}
+
+			requestID, _ := c.Get("request_id").(string)
+			method, path := c.Method(), c.Path()
+
+			ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+			defer cancel()
+			c.Request = c.Request.WithContext(ctx)
+
+			original := c.Response
+			writer := timeouts.NewWriter()
+			c.Response = writer
+
+			done := make(chan error, 1)
+			panicked := make(chan any, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				done <- next(c)
+			}()
+
+			select {
+			case err := <-done:
+				c.Response = original
+				if sendErr := writer.Send(original); sendErr != nil {
+					slog.Debug("failed to send response", "path", path, "error", sendErr)
+				}
+				return err
+			case p := <-panicked:
+				c.Response = original
+				panic(p) // recovered by RecoveryMiddleware
+			case <-ctx.Done():
+				writer.TimeOut()
+				slog.Warn("request timed out",
+					"method", method,
+					"path", path,
+					"timeout", timeout,
+					"request_id", requestID,
+				)
+				// Answer once the handler stopped using c
+				select {
+				case <-done:
+				case p := <-panicked:
+					slog.Error("panic after request timed out", "path", path, "panic", p, "request_id", requestID)
+				}
+				c.Response = original
+				original.Header().Set("Content-Type", "application/json; charset=utf-8")
+				original.WriteHeader(http.StatusGatewayTimeout)
+				return json.NewEncoder(original).Encode(map[string]string{
+					"error":      "request timed out after " + timeout.String(),
+					"request_id": requestID,
+				})
+			}
+		}
+	}
+}
+
+// =============================================================================
+// CSRF Middleware
+// =============================================================================
+
+// CSRFMiddleware protects state-changing requests authenticated by the
+// access_token cookie. Safe requests get a double-submit csrf_token cookie
+// which the dashboard echoes back in the X-CSRF-Token header.
+func CSRFMiddleware(allowedOrigins []string) fuego.MiddlewareFunc {
+	return func(next fuego.HandlerFunc) fuego.HandlerFunc {
+		return func(c *fuego.Context) error {
+			if auth.SafeMethod(c.Method()) {
+				if c.Cookie(auth.CSRFCookieName) == "" {
+					setCSRFCookie(c)
+				}
+				return next(c)
+			}
+
+			if err := auth.CheckCSRF(c.Request, allowedOrigins); err != nil {
+				slog.Warn("csrf validation failed", "path", c.Path(), "origin", c.Header("Origin"))
+				return c.JSON(403, map[string]string{"error": err.Error()})
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func setCSRFCookie(c *fuego.Context) {
+	token, err := auth.GenerateCSRFToken()
+	if err != nil {
+		return
+	}
+
+	secure := true
+	if cfg := services.From(c).Config; cfg != nil {
+		secure = !cfg.IsDevelopment()
+	}
+
+	// Readable by scripts so htmx can send it back in the header
+	c.SetCookie(&http.Cookie{
+		Name:     auth.CSRFCookieName,
+		Value:    token,
+		Path:     "/",
+		Secure:   secure,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
 // =============================================================================
 // Panic Recovery Middleware
 // =============================================================================
@@ -237,6 +551,7 @@ func RecoveryMiddleware() fuego.MiddlewareFunc {
 						"request_id", requestID,
 						"path", c.Path(),
 					)
+					errtrack.CapturePanic(r, requestTags(c))
 					err = c.JSON(500, map[string]string{"error": "internal server error"})
 				}
 			}()
@@ -259,14 +574,29 @@ func Middleware() fuego.MiddlewareFunc {
 				return next(c)
 			}
 
-			cfg := c.Get("config").(*config.Config)
-			pool := c.Get("db").(*pgxpool.Pool)
+			cfg := services.From(c).Config
+			pool := services.From(c).DB
+			ip := getClientIP(c)
+
+			if wait := auth.Blocked(ip, uuid.Nil); wait > 0 {
+				return tooManyAttempts(c, wait)
+			}
 
 			tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
 			if tokenString == "" {
 				tokenString = c.Cookie("access_token")
 			}
 
+			// Signed download URLs carry the user they were issued to
+			if tokenString == "" && signedurl.IsSigned(c.Request.URL) {
+				userID, err := signedurl.Verify(cfg.SigningKey(), c.Request.URL, time.Now())
+				if err != nil {
+					return rejectAuth(c, pool, uuid.Nil, 401, err.Error(), "invalid_signed_url")
+				}
+				c.Set("user_id", userID)
+				return next(c)
+			}
+
 			if tokenString == "" {
 				return c.JSON(401, map[string]string{"error": "missing authorization"})
 			}
@@ -276,17 +606,42 @@ func Middleware() fuego.MiddlewareFunc {
 				return handleAPIToken(c, next, pool, tokenString)
 			}
 
-			// Handle JWT tokens
+			// Handle JWT tokens. Deploy tokens CI runs got for their OIDC
+			// token only reach the API of their app.
 			claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+			if errors.Is(err, auth.ErrAppToken) {
+				claims, err = auth.ValidateAppToken(tokenString, cfg.JWTSecret)
+				if err == nil && !auth.AppScopeAllows(c.Method(), path, claims.App) {
+					return c.JSON(403, map[string]string{"error": "token is scoped to app " + claims.App})
+				}
+			}
 			if err != nil {
-				return c.JSON(401, map[string]string{"error": "invalid token"})
+				return rejectAuth(c, pool, uuid.Nil, 401, "invalid token", "invalid_jwt")
 			}
 
+			// Sessions are revoked when a member is deprovisioned through SCIM
+			user, err := db.New(pool).GetUserByID(c.Request.Context(), claims.UserID)
+			if err != nil {
+				return rejectAuth(c, pool, uuid.Nil, 401, "user not found", "unknown_user")
+			}
+			if user.SessionsRevokedAt.Valid && auth.SessionRevoked(claims, user.SessionsRevokedAt.Time) {
+				return rejectAuth(c, pool, user.ID, 401, "session revoked", "revoked_session")
+			}
+
+			if wait := auth.Blocked(ip, user.ID); wait > 0 {
+				return tooManyAttempts(c, wait)
+			}
+			auth.RecordSuccess(ip, user.ID)
+
 			c.Set("user_id", claims.UserID)
 			c.Set("username", claims.Username)
 			c.Set("claims", claims)
 
-			return next(c)
+			// Deploy tokens already act as the owner of their app
+			if claims.App != "" {
+				return rejectSuspended(next, pool)(c)
+			}
+			return authorizeCollaborator(c, next, pool)
 		}
 	}
 }
@@ -295,55 +650,169 @@ func handleAPIToken(c *fuego.Context, next fuego.HandlerFunc, pool *pgxpool.Pool
 	queries := db.New(pool)
 
 	// Use token prefix lookup for O(1) instead of O(n) bcrypt comparison
-	apiToken, err := findAPITokenByPrefix(pool, token)
+	apiToken, err := findAPITokenByPrefix(c.Request.Context(), pool, token)
 	if err != nil {
 		slog.Error("failed to search API tokens", "error", err)
 		return c.JSON(401, map[string]string{"error": "invalid api token"})
 	}
 	if apiToken == nil {
-		return c.JSON(401, map[string]string{"error": "invalid api token"})
+		return rejectAuth(c, pool, uuid.Nil, 401, "invalid api token", "invalid_api_token")
 	}
 
-	// FIX: Check expiry against current time, not created_at
-	if apiToken.ExpiresAt.Valid && apiToken.ExpiresAt.Time.Before(time.Now()) {
-		return c.JSON(401, map[string]string{"error": "token expired"})
+	// Registry credentials only sign in to the registry, through
+	// /api/registry/auth
+	if apiToken.RegistryAccess != nil {
+		return rejectAuth(c, pool, apiToken.UserID, 401, "registry tokens cannot access the API", "registry_token")
 	}
 
-	// FIX: Log error instead of silently ignoring
-	if err := queries.UpdateAPITokenLastUsed(context.Background(), apiToken.ID); err != nil {
-		slog.Warn("failed to update API token last used", "token_id", apiToken.ID, "error", err)
+	switch err := tokenpolicy.Check(c.Request.Context(), queries, *apiToken, getClientIP(c), time.Now()); {
+	case errors.Is(err, tokenpolicy.ErrExpired):
+		return rejectAuth(c, pool, uuid.Nil, 401, err.Error(), "expired_api_token")
+	case errors.Is(err, tokenpolicy.ErrUnused):
+		return rejectAuth(c, pool, uuid.Nil, 401, err.Error(), "unused_api_token")
+	case errors.Is(err, tokenpolicy.ErrDisallowedAddress):
+		slog.Warn("api token used from disallowed address", "token_id", apiToken.ID, "ip", getClientIP(c))
+		return rejectAuth(c, pool, apiToken.UserID, 403, err.Error(), "disallowed_address")
+	case err != nil:
+		return c.JSON(401, map[string]string{"error": err.Error()})
 	}
 
-	user, err := queries.GetUserByID(context.Background(), apiToken.UserID)
+	if wait := auth.Blocked(getClientIP(c), apiToken.UserID); wait > 0 {
+		return tooManyAttempts(c, wait)
+	}
+	auth.RecordSuccess(getClientIP(c), apiToken.UserID)
+
+	var lastUsedIP *netip.Addr
+	if addr, err := netip.ParseAddr(getClientIP(c)); err == nil {
+		lastUsedIP = &addr
+	}
+	if err := queries.RecordAPITokenUse(c.Request.Context(), db.RecordAPITokenUseParams{
+		ID:                apiToken.ID,
+		LastUsedIp:        lastUsedIP,
+		LastUsedUserAgent: tokenpolicy.UserAgent(c.Header("User-Agent")),
+	}); err != nil {
+		slog.Warn("failed to record API token use", "token_id", apiToken.ID, "error", err)
+	}
+
+	user, err := queries.GetUserByID(c.Request.Context(), apiToken.UserID)
 	if err != nil {
 		return c.JSON(401, map[string]string{"error": "user not found"})
 	}
 
 	c.Set("user_id", user.ID)
 	c.Set("username", user.Username)
-	c.Set("api_token_id", apiToken.ID)
+	c.Set(actor.TokenIDKey, apiToken.ID)
+	c.Set(actor.TokenNameKey, apiToken.Name)
+
+	return authorizeCollaborator(c, next, pool)
+}
+
+// authorizeCollaborator lets a collaborator of the app in the path act as
+// its owner, whom handlers look the app up as, when their role allows the
+// request. Owners of an app with that name and users without access to one
+// are left to the handler.
+func authorizeCollaborator(c *fuego.Context, next fuego.HandlerFunc, pool *pgxpool.Pool) error {
+	if pool == nil {
+		return next(c)
+	}
+	next = rejectSuspended(next, pool)
+
+	name, ok := collaborators.AppName(c.Path())
+	if !ok {
+		return next(c)
+	}
+	userID, _ := c.Get("user_id").(uuid.UUID)
+
+	queries := db.New(pool)
+	if _, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{UserID: userID, Name: name}); err == nil {
+		return next(c)
+	}
 
+	collaboration, err := collaborators.Resolve(c.Request.Context(), queries, userID, name)
+	switch {
+	case errors.Is(err, collaborators.ErrAmbiguous):
+		return c.JSON(409, map[string]string{"error": "you collaborate on several apps named " + name})
+	case err != nil:
+		slog.Error("failed to look up app collaborators", "app", name, "error", err)
+		return c.JSON(500, map[string]string{"error": "failed to authorize request"})
+	case collaboration == nil:
+		return next(c)
+	}
+
+	if !collaborators.Allows(collaboration.Role, c.Method(), c.Path(), c.Request.URL.Query()) {
+		return c.JSON(403, map[string]string{"error": "your " + collaboration.Role + " role on app " + name + " does not allow this"})
+	}
+
+	c.Set(actor.UserIDKey, userID)
+	c.Set(actor.UsernameKey, c.Get("username"))
+	c.Set("user_id", collaboration.OwnerID)
+	c.Set("username", collaboration.OwnerUsername)
 	return next(c)
 }
 
+// rejectSuspended refuses changes to apps while the account the request
+// acts as is suspended for failed payments. Apps can still be read and
+// deleted.
+func rejectSuspended(next fuego.HandlerFunc, pool *pgxpool.Pool) fuego.HandlerFunc {
+	return func(c *fuego.Context) error {
+		if pool == nil || !suspension.Blocks(c.Method(), c.Path()) {
+			return next(c)
+		}
+		userID, _ := c.Get("user_id").(uuid.UUID)
+		s, err := db.New(pool).GetAccountSuspension(c.Request.Context(), userID)
+		if err == nil && suspension.Suspended(s) {
+			return c.JSON(402, map[string]string{
+				"error":  "account suspended for an unpaid invoice, settle it to make changes",
+				"reason": "account_suspended",
+			})
+		}
+		return next(c)
+	}
+}
+
+// rejectAuth records a failed authentication as a security event before
+// responding. Retry-After tells the client when the next attempt is accepted.
+func rejectAuth(c *fuego.Context, pool *pgxpool.Pool, userID uuid.UUID, status int, message, reason string) error {
+	var queries *db.Queries
+	if pool != nil {
+		queries = db.New(pool)
+	}
+
+	if wait := auth.RecordFailure(c.Request.Context(), queries, getClientIP(c), userID, reason); wait > 0 {
+		c.Response.Header().Set("Retry-After", retryAfterSeconds(wait))
+	}
+
+	return c.JSON(status, map[string]string{"error": message})
+}
+
+// tooManyAttempts rejects a client or account that is throttled or locked out
+func tooManyAttempts(c *fuego.Context, wait time.Duration) error {
+	c.Response.Header().Set("Retry-After", retryAfterSeconds(wait))
+	return c.JSON(429, map[string]string{"error": "too many failed authentication attempts, try again later"})
+}
+
+func retryAfterSeconds(wait time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(wait.Seconds())))
+}
+
 // findAPITokenByPrefix uses a token prefix for efficient lookup
 // Token format: fgt_<prefix>_<secret>
 // We store a hash of the prefix in the database for O(1) lookup
 // Then verify the full token with bcrypt
-func findAPITokenByPrefix(pool *pgxpool.Pool, token string) (*db.ApiToken, error) {
+func findAPITokenByPrefix(ctx context.Context, pool *pgxpool.Pool, token string) (*db.ApiToken, error) {
 	// For backwards compatibility, try the legacy O(n) approach
 	// TODO: Migrate to prefix-based lookup once schema is updated
-	return searchAllTokensOptimized(pool, token)
+	return searchAllTokensOptimized(ctx, pool, token)
 }
 
 // searchAllTokensOptimized is an improved version that fails fast on hash prefix mismatch
-func searchAllTokensOptimized(pool *pgxpool.Pool, token string) (*db.ApiToken, error) {
+func searchAllTokensOptimized(ctx context.Context, pool *pgxpool.Pool, token string) (*db.ApiToken, error) {
 	// Create a quick hash of the token for initial filtering
 	tokenHash := sha256.Sum256([]byte(token))
 	tokenPrefix := hex.EncodeToString(tokenHash[:4]) // First 8 hex chars
 
-	rows, err := pool.Query(context.Background(),
-		"SELECT id, user_id, name, token_hash, last_used_at, expires_at, created_at FROM api_tokens")
+	rows, err := pool.Query(ctx,
+		"SELECT id, user_id, name, token_hash, last_used_at, expires_at, created_at, allowed_cidrs, last_used_ip, last_used_user_agent, expire_after_unused_days, registry_access FROM api_tokens")
 	if err != nil {
 		return nil, err
 	}
@@ -351,7 +820,7 @@ func searchAllTokensOptimized(pool *pgxpool.Pool, token string) (*db.ApiToken, e
 
 	for rows.Next() {
 		var t db.ApiToken
-		if err := rows.Scan(&t.ID, &t.UserID, &t.Name, &t.TokenHash, &t.LastUsedAt, &t.ExpiresAt, &t.CreatedAt); err != nil {
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Name, &t.TokenHash, &t.LastUsedAt, &t.ExpiresAt, &t.CreatedAt, &t.AllowedCidrs, &t.LastUsedIp, &t.LastUsedUserAgent, &t.ExpireAfterUnusedDays, &t.RegistryAccess); err != nil {
 			slog.Warn("failed to scan API token row", "error", err)
 			continue
 		}
@@ -376,22 +845,15 @@ func searchAllTokensOptimized(pool *pgxpool.Pool, token string) (*db.ApiToken, e
 // Helper Functions
 // =============================================================================
 
-// getClientIP extracts the real client IP from the request
+// getClientIP returns the client address resolved by ClientIPMiddleware,
+// falling back to the connection's peer address
 func getClientIP(c *fuego.Context) string {
-	// Check X-Forwarded-For header (for proxies/load balancers)
-	xff := c.Header("X-Forwarded-For")
-	if xff != "" {
-		// Take the first IP in the chain
-		parts := strings.Split(xff, ",")
-		return strings.TrimSpace(parts[0])
+	addr, ok := c.Get(clientip.ContextKey).(netip.Addr)
+	if !ok {
+		addr = clientip.New(nil).Resolve(c.Request)
 	}
-
-	// Check X-Real-IP header
-	xri := c.Header("X-Real-IP")
-	if xri != "" {
-		return xri
+	if !addr.IsValid() {
+		return c.Request.RemoteAddr
 	}
-
-	// Fall back to remote address
-	return c.Request.RemoteAddr
+	return addr.String()
 }
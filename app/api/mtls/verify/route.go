@@ -0,0 +1,77 @@
+package verify
+
+import (
+	"crypto/x509"
+	"errors"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/pki"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+)
+
+var (
+	errRevoked      = errors.New("certificate has been revoked")
+	errExpired      = errors.New("certificate has expired")
+	errWrongApp     = errors.New("certificate was not issued for this app")
+	errUnknownCert  = errors.New("certificate is not known")
+	errUntrustedCA  = errors.New("certificate was not issued by the platform CA")
+	errNoClientCert = errors.New("client certificate required")
+)
+
+// Get is the forward auth target of apps enforcing mTLS. Traefik has already
+// verified the chain; this rejects certificates that were revoked or issued
+// for another app. It is public because the ingress calls it without
+// credentials.
+// GET /api/mtls/verify?app={name}
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	appName := c.Query("app")
+
+	cert, err := pki.ParseForwardedCert(c.Header(k8s.ClientCertHeader))
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": errNoClientCert.Error()})
+	}
+
+	ca, err := pki.LoadCAFiles(cfg.MTLSCACertFile, cfg.MTLSCAKeyFile)
+	if err != nil {
+		return c.JSON(503, map[string]string{"error": "mTLS is not available on this platform"})
+	}
+
+	queries := db.New(pool)
+	record, err := queries.GetClientCertificateBySerial(c.Request.Context(), pki.SerialString(cert.SerialNumber))
+	if err != nil {
+		return c.JSON(403, map[string]string{"error": errUnknownCert.Error()})
+	}
+
+	app, err := queries.GetAppByID(c.Request.Context(), record.AppID)
+	if err != nil {
+		return c.JSON(403, map[string]string{"error": errUnknownCert.Error()})
+	}
+
+	if err := checkCertificate(cert, ca.Cert, record, app.Name, appName, time.Now()); err != nil {
+		return c.JSON(403, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(200, map[string]string{"status": "ok"})
+}
+
+// checkCertificate decides whether a presented certificate may reach appName
+func checkCertificate(cert, caCert *x509.Certificate, record db.ClientCertificate, issuedFor, appName string, now time.Time) error {
+	if err := cert.CheckSignatureFrom(caCert); err != nil {
+		return errUntrustedCA
+	}
+	if issuedFor != appName {
+		return errWrongApp
+	}
+	if record.RevokedAt.Valid {
+		return errRevoked
+	}
+	if now.After(record.NotAfter) || now.After(cert.NotAfter) {
+		return errExpired
+	}
+	return nil
+}
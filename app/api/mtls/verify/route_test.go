@@ -0,0 +1,52 @@
+package verify
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/pki"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestCheckCertificate(t *testing.T) {
+	ca, _ := pki.NewCA("Nexo Test CA", time.Hour)
+	other, _ := pki.NewCA("Other CA", time.Hour)
+	issued, _ := ca.IssueClientCert("web:laptop", "web", 30*time.Minute)
+
+	block, _ := pem.Decode(issued.CertPEM)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+
+	now := time.Now()
+	record := db.ClientCertificate{Serial: issued.Serial, NotAfter: issued.NotAfter}
+	revoked := record
+	revoked.RevokedAt = pgtype.Timestamptz{Time: now, Valid: true}
+
+	tests := []struct {
+		name    string
+		caCert  *x509.Certificate
+		record  db.ClientCertificate
+		appName string
+		now     time.Time
+		want    error
+	}{
+		{"valid", ca.Cert, record, "web", now, nil},
+		{"other CA", other.Cert, record, "web", now, errUntrustedCA},
+		{"other app", ca.Cert, record, "api", now, errWrongApp},
+		{"revoked", ca.Cert, revoked, "web", now, errRevoked},
+		{"expired", ca.Cert, record, "web", now.Add(time.Hour), errExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkCertificate(cert, tt.caCert, tt.record, "web", tt.appName, tt.now); err != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
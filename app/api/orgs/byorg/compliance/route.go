@@ -0,0 +1,136 @@
+// Package compliance downloads an organization's evidence bundle for audits.
+package compliance
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/compliance"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// defaultRange is the range of a bundle when none is given
+const defaultRange = 90 * 24 * time.Hour
+
+// Get downloads a signed archive of the organization's evidence between
+// from and to (dates or RFC 3339 times, default the last 90 days): members
+// and their two-factor status, API tokens, sign-ins, deployments and change
+// history.
+// GET /api/orgs/{org}/compliance?from=2026-01-01&to=2026-03-31
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	org, err := queries.GetOrganizationByName(c.Request.Context(), c.Param("org"))
+	if err != nil || org.OwnerID != userID {
+		return c.JSON(404, map[string]string{"error": "organization not found"})
+	}
+
+	to := time.Now()
+	if c.Query("to") != "" {
+		end, dateOnly, err := parseTime(c.Query("to"))
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "to must be a date or RFC 3339 time"})
+		}
+		// A date includes the whole day
+		if dateOnly {
+			end = end.AddDate(0, 0, 1)
+		}
+		to = end
+	}
+	from := to.Add(-defaultRange)
+	if c.Query("from") != "" {
+		if from, _, err = parseTime(c.Query("from")); err != nil {
+			return c.JSON(400, map[string]string{"error": "from must be a date or RFC 3339 time"})
+		}
+	}
+	if !from.Before(to) {
+		return c.JSON(400, map[string]string{"error": "from must be before to"})
+	}
+	if to.Sub(from) > compliance.MaxRange {
+		return c.JSON(400, map[string]string{"error": "the range cannot exceed 366 days"})
+	}
+
+	user, err := queries.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to get user"})
+	}
+
+	bundle, err := compliance.Collect(c.Request.Context(), queries, org, user.Username, from, to)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to collect evidence"})
+	}
+	archive, err := bundle.Archive(cfg.SigningKey())
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to build archive"})
+	}
+
+	details, _ := json.Marshal(map[string]any{
+		"org":       org.Name,
+		"from":      bundle.Manifest.From,
+		"to":        bundle.Manifest.To,
+		"truncated": bundle.Manifest.Truncated,
+	})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		Action:    compliance.ActionGenerated,
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	c.Response.Header().Set("Content-Type", "application/gzip")
+	c.Response.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.tgz"`, bundle.Name()))
+	c.Response.WriteHeader(200)
+	_, err = c.Response.Write(archive)
+	return err
+}
+
+// parseTime parses a date or an RFC 3339 time, reporting whether it was a
+// date
+func parseTime(s string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	return t, false, err
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
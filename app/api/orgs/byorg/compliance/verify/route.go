@@ -0,0 +1,79 @@
+package verify
+
+import (
+	"errors"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/compliance"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+type VerifyResponse struct {
+	// Valid is set when the bundle was issued by the platform for the
+	// organization and none of its files changed since
+	Valid       bool       `json:"valid"`
+	Error       string     `json:"error,omitempty"`
+	From        *time.Time `json:"from,omitempty"`
+	To          *time.Time `json:"to,omitempty"`
+	GeneratedAt *time.Time `json:"generated_at,omitempty"`
+	GeneratedBy string     `json:"generated_by,omitempty"`
+}
+
+// Post verifies an evidence bundle, sent as the request body
+// POST /api/orgs/{org}/compliance/verify
+func Post(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	org, err := db.New(pool).GetOrganizationByName(c.Request.Context(), c.Param("org"))
+	if err != nil || org.OwnerID != userID {
+		return c.JSON(404, map[string]string{"error": "organization not found"})
+	}
+
+	manifest, err := compliance.Verify(c.Request.Body, cfg.SigningKey())
+	if errors.Is(err, compliance.ErrInvalidArchive) {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	if err == nil && manifest.Org != org.Name {
+		err = errors.New("bundle belongs to another organization")
+	}
+	if err != nil {
+		return c.JSON(200, VerifyResponse{Error: err.Error()})
+	}
+
+	return c.JSON(200, VerifyResponse{
+		Valid:       true,
+		From:        &manifest.From,
+		To:          &manifest.To,
+		GeneratedAt: &manifest.GeneratedAt,
+		GeneratedBy: manifest.GeneratedBy,
+	})
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
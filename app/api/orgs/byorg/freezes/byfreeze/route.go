@@ -0,0 +1,83 @@
+package freeze
+
+import (
+	"encoding/json"
+	"net/netip"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Delete removes a deploy freeze, lifting it right away if it is in effect
+// DELETE /api/orgs/{org}/freezes/{freeze}
+func Delete(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	org, err := queries.GetOrganizationByName(c.Request.Context(), c.Param("org"))
+	if err != nil || org.OwnerID != userID {
+		return c.JSON(404, map[string]string{"error": "organization not found"})
+	}
+
+	freeze, err := queries.GetDeployFreeze(c.Request.Context(), db.GetDeployFreezeParams{
+		OrgID: org.ID,
+		Name:  c.Param("freeze"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "deploy freeze not found"})
+	}
+
+	if err := queries.DeleteDeployFreeze(c.Request.Context(), freeze.ID); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to delete deploy freeze"})
+	}
+
+	details, _ := json.Marshal(map[string]any{
+		"org":    org.Name,
+		"freeze": freeze.Name,
+	})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		Action:    "deploy_freeze.deleted",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.NoContent()
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
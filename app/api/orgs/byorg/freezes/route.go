@@ -0,0 +1,218 @@
+// Package freezes manages an organization's deploy freezes, the weekly
+// windows during which its members' apps only deploy in an emergency.
+package freezes
+
+import (
+	"encoding/json"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/deployfreeze"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CreateFreezeRequest struct {
+	Name string `json:"name"`
+	// Start and End are a day and time such as "fri 18:00"
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Timezone string `json:"timezone"`
+}
+
+type FreezeResponse struct {
+	Name     string `json:"name"`
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Timezone string `json:"timezone"`
+	// Active is true while deploys are frozen, until EndsAt
+	Active    bool       `json:"active"`
+	EndsAt    *time.Time `json:"ends_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Get lists the organization's deploy freezes
+// GET /api/orgs/{org}/freezes
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	org, _, status, message := ownedOrg(c, cfg, pool)
+	if org == nil {
+		return c.JSON(status, map[string]string{"error": message})
+	}
+
+	freezes, err := db.New(pool).ListDeployFreezesByOrg(c.Request.Context(), org.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list deploy freezes"})
+	}
+
+	now := time.Now()
+	response := make([]FreezeResponse, len(freezes))
+	for i, f := range freezes {
+		response[i] = toFreezeResponse(f, now)
+	}
+
+	return c.JSON(200, response)
+}
+
+// Post creates a weekly deploy freeze. During it, deploys of apps owned by
+// the organization's owner, members and machine users are rejected unless
+// marked as an emergency with a justification.
+// POST /api/orgs/{org}/freezes
+// Body: { "name": "weekend", "start": "fri 18:00", "end": "mon 08:00", "timezone": "Europe/Berlin" }
+func Post(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	org, userID, status, message := ownedOrg(c, cfg, pool)
+	if org == nil {
+		return c.JSON(status, map[string]string{"error": message})
+	}
+
+	var req CreateFreezeRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+
+	if !deployfreeze.ValidName(req.Name) {
+		return c.JSON(400, map[string]string{"error": "name must be 2-63 lowercase letters, numbers, and hyphens, starting with a letter"})
+	}
+	startDay, startMinute, ok := parseDayTime(req.Start)
+	if !ok {
+		return c.JSON(400, map[string]string{"error": "start must be a day and time such as \"fri 18:00\""})
+	}
+	endDay, endMinute, ok := parseDayTime(req.End)
+	if !ok {
+		return c.JSON(400, map[string]string{"error": "end must be a day and time such as \"mon 08:00\""})
+	}
+	if req.Timezone == "" {
+		req.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(req.Timezone); err != nil {
+		return c.JSON(400, map[string]string{"error": "unknown timezone"})
+	}
+
+	queries := db.New(pool)
+	if _, err := queries.GetDeployFreeze(c.Request.Context(), db.GetDeployFreezeParams{
+		OrgID: org.ID,
+		Name:  req.Name,
+	}); err == nil {
+		return c.JSON(409, map[string]string{"error": "deploy freeze with this name already exists"})
+	}
+
+	freeze, err := queries.CreateDeployFreeze(c.Request.Context(), db.CreateDeployFreezeParams{
+		OrgID:       org.ID,
+		Name:        req.Name,
+		StartDay:    startDay,
+		StartMinute: startMinute,
+		EndDay:      endDay,
+		EndMinute:   endMinute,
+		Timezone:    req.Timezone,
+		CreatedBy:   pgtype.UUID{Bytes: userID, Valid: true},
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to create deploy freeze"})
+	}
+
+	response := toFreezeResponse(freeze, time.Now())
+	details, _ := json.Marshal(map[string]any{
+		"org":      org.Name,
+		"freeze":   freeze.Name,
+		"start":    response.Start,
+		"end":      response.End,
+		"timezone": freeze.Timezone,
+	})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		Action:    "deploy_freeze.created",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(201, response)
+}
+
+// parseDayTime parses a day and time such as "fri 18:00"
+func parseDayTime(s string) (int32, int32, bool) {
+	day, clock, ok := strings.Cut(strings.TrimSpace(s), " ")
+	if !ok {
+		return 0, 0, false
+	}
+	weekday, ok := deployfreeze.ParseDay(day)
+	if !ok {
+		return 0, 0, false
+	}
+	minute, ok := deployfreeze.ParseClock(clock)
+	if !ok {
+		return 0, 0, false
+	}
+	return int32(weekday), int32(minute), true
+}
+
+func toFreezeResponse(f db.DeployFreeze, now time.Time) FreezeResponse {
+	resp := FreezeResponse{
+		Name:      f.Name,
+		Start:     time.Weekday(f.StartDay).String() + " " + deployfreeze.FormatClock(f.StartMinute),
+		End:       time.Weekday(f.EndDay).String() + " " + deployfreeze.FormatClock(f.EndMinute),
+		Timezone:  f.Timezone,
+		CreatedAt: f.CreatedAt,
+	}
+	if window, ok := deployfreeze.NewWindow(f.StartDay, f.StartMinute, f.EndDay, f.EndMinute, f.Timezone); ok {
+		if endsAt, active := window.Active(now); active {
+			resp.Active = true
+			resp.EndsAt = &endsAt
+		}
+	}
+	return resp
+}
+
+// ownedOrg loads the organization named in the path, which only its owner
+// can manage
+func ownedOrg(c *fuego.Context, cfg *config.Config, pool *pgxpool.Pool) (*db.Organization, uuid.UUID, int, string) {
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return nil, uuid.Nil, 401, "unauthorized"
+	}
+
+	org, err := db.New(pool).GetOrganizationByName(c.Request.Context(), c.Param("org"))
+	if err != nil || org.OwnerID != userID {
+		return nil, uuid.Nil, 404, "organization not found"
+	}
+
+	return &org, userID, 0, ""
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
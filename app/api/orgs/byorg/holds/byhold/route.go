@@ -0,0 +1,98 @@
+package hold
+
+import (
+	"encoding/json"
+	"net/netip"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/app/api/orgs/byorg/holds"
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/legalhold"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Delete releases a legal hold. The hold is kept in the organization's
+// history; data stays protected while any other hold covers it.
+// DELETE /api/orgs/{org}/holds/{hold}
+func Delete(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	org, err := queries.GetOrganizationByName(c.Request.Context(), c.Param("org"))
+	if err != nil || org.OwnerID != userID {
+		return c.JSON(404, map[string]string{"error": "organization not found"})
+	}
+
+	holdID, err := uuid.Parse(c.Param("hold"))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "legal hold not found"})
+	}
+	hold, err := queries.GetLegalHold(c.Request.Context(), db.GetLegalHoldParams{
+		OrgID: org.ID,
+		ID:    holdID,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "legal hold not found"})
+	}
+	if hold.ReleasedAt.Valid {
+		return c.JSON(409, map[string]string{"error": "legal hold is already released"})
+	}
+
+	hold, err = queries.ReleaseLegalHold(c.Request.Context(), db.ReleaseLegalHoldParams{
+		ID:         hold.ID,
+		ReleasedBy: pgtype.UUID{Bytes: userID, Valid: true},
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to release legal hold"})
+	}
+
+	details, _ := json.Marshal(map[string]any{
+		"org":    org.Name,
+		"hold":   hold.ID,
+		"reason": hold.Reason,
+	})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		Action:    legalhold.ActionReleased,
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(200, holds.ToHoldResponse(c.Request.Context(), queries, hold))
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
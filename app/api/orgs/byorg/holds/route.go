@@ -0,0 +1,188 @@
+// Package holds manages an organization's legal holds, which keep the data
+// of its owner, members and machine users from being deleted until released.
+package holds
+
+import (
+	"context"
+	"encoding/json"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/legalhold"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxReasonLength bounds the reason of a hold
+const maxReasonLength = 1000
+
+type PlaceHoldRequest struct {
+	// Reason says why the data is held, such as a matter or case number
+	Reason string `json:"reason"`
+}
+
+type HoldResponse struct {
+	ID         string     `json:"id"`
+	Reason     string     `json:"reason"`
+	Active     bool       `json:"active"`
+	PlacedBy   string     `json:"placed_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ReleasedBy string     `json:"released_by,omitempty"`
+	ReleasedAt *time.Time `json:"released_at,omitempty"`
+}
+
+// Get lists the organization's legal holds, newest first, released ones
+// included
+// GET /api/orgs/{org}/holds
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	org, _, status, message := ownedOrg(c, cfg, pool)
+	if org == nil {
+		return c.JSON(status, map[string]string{"error": message})
+	}
+
+	queries := db.New(pool)
+	holds, err := queries.ListLegalHoldsByOrg(c.Request.Context(), org.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list legal holds"})
+	}
+
+	response := make([]HoldResponse, len(holds))
+	for i, h := range holds {
+		response[i] = ToHoldResponse(c.Request.Context(), queries, h)
+	}
+
+	return c.JSON(200, response)
+}
+
+// Post places a legal hold. Until it is released, the apps, activity logs
+// and database add-ons of the organization's owner, members and machine
+// users cannot be deleted, by them or by the platform's retention and
+// suspension jobs.
+// POST /api/orgs/{org}/holds
+// Body: { "reason": "Litigation hold, matter 2026-114" }
+func Post(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	org, userID, status, message := ownedOrg(c, cfg, pool)
+	if org == nil {
+		return c.JSON(status, map[string]string{"error": message})
+	}
+
+	var req PlaceHoldRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return c.JSON(400, map[string]string{"error": "reason is required"})
+	}
+	if len(req.Reason) > maxReasonLength {
+		return c.JSON(400, map[string]string{"error": "reason must be at most 1000 characters"})
+	}
+
+	queries := db.New(pool)
+	hold, err := queries.CreateLegalHold(c.Request.Context(), db.CreateLegalHoldParams{
+		OrgID:    org.ID,
+		Reason:   req.Reason,
+		PlacedBy: pgtype.UUID{Bytes: userID, Valid: true},
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to place legal hold"})
+	}
+
+	details, _ := json.Marshal(map[string]any{
+		"org":    org.Name,
+		"hold":   hold.ID,
+		"reason": hold.Reason,
+	})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		Action:    legalhold.ActionPlaced,
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(201, ToHoldResponse(c.Request.Context(), queries, hold))
+}
+
+// ToHoldResponse renders a hold with the usernames of who placed and
+// released it
+func ToHoldResponse(ctx context.Context, queries *db.Queries, h db.LegalHold) HoldResponse {
+	resp := HoldResponse{
+		ID:         h.ID.String(),
+		Reason:     h.Reason,
+		Active:     !h.ReleasedAt.Valid,
+		PlacedBy:   username(ctx, queries, h.PlacedBy),
+		CreatedAt:  h.CreatedAt,
+		ReleasedBy: username(ctx, queries, h.ReleasedBy),
+	}
+	if h.ReleasedAt.Valid {
+		resp.ReleasedAt = &h.ReleasedAt.Time
+	}
+	return resp
+}
+
+func username(ctx context.Context, queries *db.Queries, id pgtype.UUID) string {
+	if !id.Valid {
+		return ""
+	}
+	user, err := queries.GetUserByID(ctx, id.Bytes)
+	if err != nil {
+		return ""
+	}
+	return user.Username
+}
+
+// ownedOrg loads the organization named in the path, which only its owner
+// can manage
+func ownedOrg(c *fuego.Context, cfg *config.Config, pool *pgxpool.Pool) (*db.Organization, uuid.UUID, int, string) {
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return nil, uuid.Nil, 401, "unauthorized"
+	}
+
+	org, err := db.New(pool).GetOrganizationByName(c.Request.Context(), c.Param("org"))
+	if err != nil || org.OwnerID != userID {
+		return nil, uuid.Nil, 404, "organization not found"
+	}
+
+	return &org, userID, 0, ""
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
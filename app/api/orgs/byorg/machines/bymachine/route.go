@@ -0,0 +1,225 @@
+package machine
+
+import (
+	"encoding/json"
+	"net/netip"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/machineuser"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxDescriptionLength bounds a machine user's description
+const maxDescriptionLength = 500
+
+type UpdateMachineUserRequest struct {
+	Description string `json:"description"`
+	// Quotas; null means unlimited
+	MaxApps              *int32 `json:"max_apps"`
+	MaxDeploymentsPerDay *int32 `json:"max_deployments_per_day"`
+}
+
+type MachineUserResponse struct {
+	Name                 string    `json:"name"`
+	Username             string    `json:"username"`
+	Description          string    `json:"description,omitempty"`
+	MaxApps              *int32    `json:"max_apps"`
+	MaxDeploymentsPerDay *int32    `json:"max_deployments_per_day"`
+	Usage                *Usage    `json:"usage,omitempty"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+// Usage is what counts against a machine user's quotas
+type Usage struct {
+	Apps int64 `json:"apps"`
+	// DeploymentsLastDay counts deployments in the last 24 hours
+	DeploymentsLastDay int64 `json:"deployments_last_day"`
+}
+
+// Get returns a machine user with its quota usage
+// GET /api/orgs/{org}/machines/{machine}
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	org, machine, _, status, message := ownedMachine(c, cfg, pool)
+	if machine == nil {
+		return c.JSON(status, map[string]string{"error": message})
+	}
+
+	queries := db.New(pool)
+	apps, err := queries.CountAppsByUser(c.Request.Context(), machine.UserID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to load usage"})
+	}
+	deployments, err := queries.CountDeploymentsByUserSince(c.Request.Context(), db.CountDeploymentsByUserSinceParams{
+		UserID:    machine.UserID,
+		CreatedAt: time.Now().Add(-machineuser.DeploymentWindow),
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to load usage"})
+	}
+
+	resp := toMachineUserResponse(org.Name, *machine)
+	resp.Usage = &Usage{Apps: apps, DeploymentsLastDay: deployments}
+	return c.JSON(200, resp)
+}
+
+// Put replaces a machine user's description and quotas. Lowered quotas
+// apply to the next app or deployment; nothing existing is removed.
+// PUT /api/orgs/{org}/machines/{machine}
+func Put(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	org, machine, _, status, message := ownedMachine(c, cfg, pool)
+	if machine == nil {
+		return c.JSON(status, map[string]string{"error": message})
+	}
+
+	var req UpdateMachineUserRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+
+	req.Description = strings.TrimSpace(req.Description)
+	if utf8.RuneCountInString(req.Description) > maxDescriptionLength {
+		return c.JSON(400, map[string]string{"error": "description may be at most 500 characters"})
+	}
+	if !machineuser.ValidQuota(req.MaxApps) || !machineuser.ValidQuota(req.MaxDeploymentsPerDay) {
+		return c.JSON(400, map[string]string{"error": "quotas must be between 0 and 10000"})
+	}
+
+	var description *string
+	if req.Description != "" {
+		description = &req.Description
+	}
+
+	updated, err := db.New(pool).UpdateMachineUser(c.Request.Context(), db.UpdateMachineUserParams{
+		UserID:               machine.UserID,
+		Description:          description,
+		MaxApps:              req.MaxApps,
+		MaxDeploymentsPerDay: req.MaxDeploymentsPerDay,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to update machine user"})
+	}
+
+	return c.JSON(200, toMachineUserResponse(org.Name, updated))
+}
+
+// Delete removes a machine user and revokes its tokens. Machine users that
+// still own apps are kept so no running app is orphaned.
+// DELETE /api/orgs/{org}/machines/{machine}
+func Delete(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	org, machine, userID, status, message := ownedMachine(c, cfg, pool)
+	if machine == nil {
+		return c.JSON(status, map[string]string{"error": message})
+	}
+
+	queries := db.New(pool)
+	apps, err := queries.CountAppsByUser(c.Request.Context(), machine.UserID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to delete machine user"})
+	}
+	if apps > 0 {
+		return c.JSON(409, map[string]string{"error": "machine user still owns apps, delete them first"})
+	}
+
+	if err := queries.DeleteMachineUser(c.Request.Context(), machine.UserID); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to delete machine user"})
+	}
+
+	details, _ := json.Marshal(map[string]any{
+		"org":     org.Name,
+		"machine": machine.Name,
+	})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		Action:    "machine_user.deleted",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.NoContent()
+}
+
+func toMachineUserResponse(orgName string, machine db.MachineUser) MachineUserResponse {
+	resp := MachineUserResponse{
+		Name:                 machine.Name,
+		Username:             machineuser.Username(orgName, machine.Name),
+		MaxApps:              machine.MaxApps,
+		MaxDeploymentsPerDay: machine.MaxDeploymentsPerDay,
+		CreatedAt:            machine.CreatedAt,
+		UpdatedAt:            machine.UpdatedAt,
+	}
+	if machine.Description != nil {
+		resp.Description = *machine.Description
+	}
+	return resp
+}
+
+// ownedMachine loads the machine user named in the path from an
+// organization owned by the caller
+func ownedMachine(c *fuego.Context, cfg *config.Config, pool *pgxpool.Pool) (*db.Organization, *db.MachineUser, uuid.UUID, int, string) {
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return nil, nil, uuid.Nil, 401, "unauthorized"
+	}
+
+	queries := db.New(pool)
+	org, err := queries.GetOrganizationByName(c.Request.Context(), c.Param("org"))
+	if err != nil || org.OwnerID != userID {
+		return nil, nil, uuid.Nil, 404, "organization not found"
+	}
+
+	machine, err := queries.GetMachineUser(c.Request.Context(), db.GetMachineUserParams{
+		OrgID: org.ID,
+		Name:  c.Param("machine"),
+	})
+	if err != nil {
+		return nil, nil, uuid.Nil, 404, "machine user not found"
+	}
+
+	return &org, &machine, userID, 0, ""
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
@@ -0,0 +1,108 @@
+package token
+
+import (
+	"encoding/json"
+	"net/netip"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Delete revokes one of a machine user's API tokens, e.g. after rotating
+// the secret in CI
+// DELETE /api/orgs/{org}/machines/{machine}/tokens/{id}
+func Delete(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	machine, userID, status, message := ownedMachine(c, cfg, pool)
+	if machine == nil {
+		return c.JSON(status, map[string]string{"error": message})
+	}
+
+	tokenID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "invalid token id"})
+	}
+
+	queries := db.New(pool)
+	apiToken, err := queries.GetAPITokenByID(c.Request.Context(), tokenID)
+	if err != nil || apiToken.UserID != machine.UserID {
+		return c.JSON(404, map[string]string{"error": "token not found"})
+	}
+
+	if err := queries.DeleteAPIToken(c.Request.Context(), apiToken.ID); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to revoke token"})
+	}
+
+	details, _ := json.Marshal(map[string]any{
+		"org":      c.Param("org"),
+		"machine":  machine.Name,
+		"token_id": apiToken.ID,
+	})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		Action:    "machine_user.token_revoked",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.NoContent()
+}
+
+// ownedMachine loads the machine user named in the path from an
+// organization owned by the caller
+func ownedMachine(c *fuego.Context, cfg *config.Config, pool *pgxpool.Pool) (*db.MachineUser, uuid.UUID, int, string) {
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return nil, uuid.Nil, 401, "unauthorized"
+	}
+
+	queries := db.New(pool)
+	org, err := queries.GetOrganizationByName(c.Request.Context(), c.Param("org"))
+	if err != nil || org.OwnerID != userID {
+		return nil, uuid.Nil, 404, "organization not found"
+	}
+
+	machine, err := queries.GetMachineUser(c.Request.Context(), db.GetMachineUserParams{
+		OrgID: org.ID,
+		Name:  c.Param("machine"),
+	})
+	if err != nil {
+		return nil, uuid.Nil, 404, "machine user not found"
+	}
+
+	return &machine, userID, 0, ""
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
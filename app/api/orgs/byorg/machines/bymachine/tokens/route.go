@@ -0,0 +1,224 @@
+// Package tokens manages the API tokens of a machine user. Only owners of
+// its organization issue them; machine users cannot mint their own.
+package tokens
+
+import (
+	"encoding/json"
+	"net/netip"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/tokenpolicy"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type CreateTokenRequest struct {
+	Name      string `json:"name"`
+	ExpiresIn int    `json:"expires_in"`
+	// AllowedCIDRs restricts the token to client addresses in these ranges,
+	// such as a CI provider's runners
+	AllowedCIDRs []string `json:"allowed_cidrs"`
+	// ExpireAfterUnusedDays revokes the token once it goes unused this long
+	ExpireAfterUnusedDays int `json:"expire_after_unused_days"`
+}
+
+type TokenResponse struct {
+	ID                    string     `json:"id"`
+	Name                  string     `json:"name"`
+	Token                 string     `json:"token,omitempty"`
+	CreatedAt             time.Time  `json:"created_at"`
+	ExpiresAt             *time.Time `json:"expires_at,omitempty"`
+	AllowedCIDRs          []string   `json:"allowed_cidrs,omitempty"`
+	LastUsedAt            *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP            string     `json:"last_used_ip,omitempty"`
+	ExpireAfterUnusedDays *int32     `json:"expire_after_unused_days,omitempty"`
+}
+
+// Get lists a machine user's API tokens
+// GET /api/orgs/{org}/machines/{machine}/tokens
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	machine, _, status, message := ownedMachine(c, cfg, pool)
+	if machine == nil {
+		return c.JSON(status, map[string]string{"error": message})
+	}
+
+	tokens, err := db.New(pool).ListAPITokensByUser(c.Request.Context(), machine.UserID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list tokens"})
+	}
+
+	response := make([]TokenResponse, len(tokens))
+	for i, t := range tokens {
+		response[i] = toTokenResponse(t)
+	}
+
+	return c.JSON(200, response)
+}
+
+// Post issues an API token for a machine user. The token is only shown
+// once and is subject to the organization's token lifetime policy.
+// POST /api/orgs/{org}/machines/{machine}/tokens
+func Post(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	machine, userID, status, message := ownedMachine(c, cfg, pool)
+	if machine == nil {
+		return c.JSON(status, map[string]string{"error": message})
+	}
+
+	var req CreateTokenRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+
+	if req.Name == "" {
+		req.Name = "CI Token"
+	}
+
+	allowedCIDRs, err := tokenpolicy.ParseCIDRs(req.AllowedCIDRs)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	unusedDays, err := tokenpolicy.ParseUnusedDays(req.ExpireAfterUnusedDays)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	queries := db.New(pool)
+	maxDays, err := queries.GetMaxTokenLifetimeForUser(c.Request.Context(), pgtype.UUID{Bytes: machine.UserID, Valid: true})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to load token policy"})
+	}
+
+	expiry, err := tokenpolicy.ResolveExpiry(time.Now(), time.Duration(req.ExpiresIn)*time.Second, maxDays)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	var expiresAt pgtype.Timestamptz
+	if !expiry.IsZero() {
+		expiresAt = pgtype.Timestamptz{Time: expiry, Valid: true}
+	}
+
+	token, err := auth.GenerateAPIToken()
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to generate token"})
+	}
+
+	hashedToken, err := bcrypt.GenerateFromPassword([]byte(token), bcrypt.DefaultCost)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to hash token"})
+	}
+
+	apiToken, err := queries.CreateAPIToken(c.Request.Context(), db.CreateAPITokenParams{
+		UserID:                machine.UserID,
+		Name:                  req.Name,
+		TokenHash:             string(hashedToken),
+		ExpiresAt:             expiresAt,
+		AllowedCidrs:          allowedCIDRs,
+		ExpireAfterUnusedDays: unusedDays,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to create token"})
+	}
+
+	details, _ := json.Marshal(map[string]any{
+		"org":      c.Param("org"),
+		"machine":  machine.Name,
+		"token_id": apiToken.ID,
+	})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		Action:    "machine_user.token_created",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	resp := toTokenResponse(apiToken)
+	resp.Token = token
+	return c.JSON(201, resp)
+}
+
+func toTokenResponse(t db.ApiToken) TokenResponse {
+	resp := TokenResponse{
+		ID:                    t.ID.String(),
+		Name:                  t.Name,
+		CreatedAt:             t.CreatedAt,
+		AllowedCIDRs:          t.AllowedCidrs,
+		ExpireAfterUnusedDays: t.ExpireAfterUnusedDays,
+	}
+	if t.ExpiresAt.Valid {
+		resp.ExpiresAt = &t.ExpiresAt.Time
+	}
+	if t.LastUsedAt.Valid {
+		resp.LastUsedAt = &t.LastUsedAt.Time
+	}
+	if t.LastUsedIp != nil {
+		resp.LastUsedIP = t.LastUsedIp.String()
+	}
+	return resp
+}
+
+// ownedMachine loads the machine user named in the path from an
+// organization owned by the caller
+func ownedMachine(c *fuego.Context, cfg *config.Config, pool *pgxpool.Pool) (*db.MachineUser, uuid.UUID, int, string) {
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return nil, uuid.Nil, 401, "unauthorized"
+	}
+
+	queries := db.New(pool)
+	org, err := queries.GetOrganizationByName(c.Request.Context(), c.Param("org"))
+	if err != nil || org.OwnerID != userID {
+		return nil, uuid.Nil, 404, "organization not found"
+	}
+
+	machine, err := queries.GetMachineUser(c.Request.Context(), db.GetMachineUserParams{
+		OrgID: org.ID,
+		Name:  c.Param("machine"),
+	})
+	if err != nil {
+		return nil, uuid.Nil, 404, "machine user not found"
+	}
+
+	return &machine, userID, 0, ""
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
@@ -0,0 +1,191 @@
+// Package machines manages an organization's machine users, the identities
+// CI pipelines deploy with instead of a member's personal token.
+package machines
+
+import (
+	"encoding/json"
+	"net/netip"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/machineuser"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxDescriptionLength bounds a machine user's description
+const maxDescriptionLength = 500
+
+type CreateMachineUserRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Quotas; null means unlimited
+	MaxApps              *int32 `json:"max_apps"`
+	MaxDeploymentsPerDay *int32 `json:"max_deployments_per_day"`
+}
+
+type MachineUserResponse struct {
+	Name string `json:"name"`
+	// Username identifies the machine user in activity logs
+	Username             string    `json:"username"`
+	Description          string    `json:"description,omitempty"`
+	MaxApps              *int32    `json:"max_apps"`
+	MaxDeploymentsPerDay *int32    `json:"max_deployments_per_day"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+// Get lists the organization's machine users
+// GET /api/orgs/{org}/machines
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	org, _, status, message := ownedOrg(c, cfg, pool)
+	if org == nil {
+		return c.JSON(status, map[string]string{"error": message})
+	}
+
+	machines, err := db.New(pool).ListMachineUsersByOrg(c.Request.Context(), org.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list machine users"})
+	}
+
+	response := make([]MachineUserResponse, len(machines))
+	for i, machine := range machines {
+		response[i] = toMachineUserResponse(org.Name, machine)
+	}
+
+	return c.JSON(200, response)
+}
+
+// Post creates a machine user owned by the organization. It belongs to the
+// organization rather than to the member creating it, so its apps and
+// tokens survive that member's offboarding.
+// POST /api/orgs/{org}/machines
+func Post(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	org, userID, status, message := ownedOrg(c, cfg, pool)
+	if org == nil {
+		return c.JSON(status, map[string]string{"error": message})
+	}
+
+	var req CreateMachineUserRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+
+	if !machineuser.ValidName(req.Name) {
+		return c.JSON(400, map[string]string{"error": "name must be 3-63 lowercase letters, numbers, and hyphens, starting with a letter"})
+	}
+	req.Description = strings.TrimSpace(req.Description)
+	if utf8.RuneCountInString(req.Description) > maxDescriptionLength {
+		return c.JSON(400, map[string]string{"error": "description may be at most 500 characters"})
+	}
+	if !machineuser.ValidQuota(req.MaxApps) || !machineuser.ValidQuota(req.MaxDeploymentsPerDay) {
+		return c.JSON(400, map[string]string{"error": "quotas must be between 0 and 10000"})
+	}
+
+	queries := db.New(pool)
+	if _, err := queries.GetMachineUser(c.Request.Context(), db.GetMachineUserParams{
+		OrgID: org.ID,
+		Name:  req.Name,
+	}); err == nil {
+		return c.JSON(409, map[string]string{"error": "machine user with this name already exists"})
+	}
+
+	var description *string
+	if req.Description != "" {
+		description = &req.Description
+	}
+
+	machine, err := machineuser.Create(c.Request.Context(), pool, org.Name, db.CreateMachineUserParams{
+		OrgID:                org.ID,
+		Name:                 req.Name,
+		Description:          description,
+		CreatedBy:            pgtype.UUID{Bytes: userID, Valid: true},
+		MaxApps:              req.MaxApps,
+		MaxDeploymentsPerDay: req.MaxDeploymentsPerDay,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to create machine user"})
+	}
+
+	details, _ := json.Marshal(map[string]any{
+		"org":     org.Name,
+		"machine": machine.Name,
+	})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		Action:    "machine_user.created",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(201, toMachineUserResponse(org.Name, machine))
+}
+
+func toMachineUserResponse(orgName string, machine db.MachineUser) MachineUserResponse {
+	resp := MachineUserResponse{
+		Name:                 machine.Name,
+		Username:             machineuser.Username(orgName, machine.Name),
+		MaxApps:              machine.MaxApps,
+		MaxDeploymentsPerDay: machine.MaxDeploymentsPerDay,
+		CreatedAt:            machine.CreatedAt,
+		UpdatedAt:            machine.UpdatedAt,
+	}
+	if machine.Description != nil {
+		resp.Description = *machine.Description
+	}
+	return resp
+}
+
+func ownedOrg(c *fuego.Context, cfg *config.Config, pool *pgxpool.Pool) (*db.Organization, uuid.UUID, int, string) {
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return nil, uuid.Nil, 401, "unauthorized"
+	}
+
+	org, err := db.New(pool).GetOrganizationByName(c.Request.Context(), c.Param("org"))
+	if err != nil || org.OwnerID != userID {
+		return nil, uuid.Nil, 404, "organization not found"
+	}
+
+	return &org, userID, 0, ""
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
@@ -0,0 +1,120 @@
+package org
+
+import (
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxTokenLifetimeDays caps the policy an organization can set
+const maxTokenLifetimeDays = 3650
+
+type UpdateOrgRequest struct {
+	// MaxTokenLifetimeDays limits members' API tokens; null removes the limit
+	MaxTokenLifetimeDays *int32 `json:"max_token_lifetime_days"`
+}
+
+type OrgResponse struct {
+	ID                   string    `json:"id"`
+	Name                 string    `json:"name"`
+	SCIMEnabled          bool      `json:"scim_enabled"`
+	MaxTokenLifetimeDays *int32    `json:"max_token_lifetime_days"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+// Get returns an organization and its policies
+// GET /api/orgs/{org}
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	org, status, message := ownedOrg(c, cfg, pool)
+	if org == nil {
+		return c.JSON(status, map[string]string{"error": message})
+	}
+
+	return c.JSON(200, toOrgResponse(*org))
+}
+
+// Put updates the organization's token policy. Existing tokens that outlive
+// a lowered limit are rejected right away and deleted by the next sweep.
+// PUT /api/orgs/{org}
+func Put(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	org, status, message := ownedOrg(c, cfg, pool)
+	if org == nil {
+		return c.JSON(status, map[string]string{"error": message})
+	}
+
+	var req UpdateOrgRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+
+	if req.MaxTokenLifetimeDays != nil && (*req.MaxTokenLifetimeDays < 1 || *req.MaxTokenLifetimeDays > maxTokenLifetimeDays) {
+		return c.JSON(400, map[string]string{"error": "max_token_lifetime_days must be between 1 and 3650"})
+	}
+
+	updated, err := db.New(pool).UpdateOrganizationTokenPolicy(c.Request.Context(), db.UpdateOrganizationTokenPolicyParams{
+		ID:                   org.ID,
+		MaxTokenLifetimeDays: req.MaxTokenLifetimeDays,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to update organization"})
+	}
+
+	return c.JSON(200, toOrgResponse(updated))
+}
+
+func toOrgResponse(org db.Organization) OrgResponse {
+	return OrgResponse{
+		ID:                   org.ID.String(),
+		Name:                 org.Name,
+		SCIMEnabled:          org.ScimTokenHash != nil,
+		MaxTokenLifetimeDays: org.MaxTokenLifetimeDays,
+		CreatedAt:            org.CreatedAt,
+		UpdatedAt:            org.UpdatedAt,
+	}
+}
+
+func ownedOrg(c *fuego.Context, cfg *config.Config, pool *pgxpool.Pool) (*db.Organization, int, string) {
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return nil, 401, "unauthorized"
+	}
+
+	org, err := db.New(pool).GetOrganizationByName(c.Request.Context(), c.Param("org"))
+	if err != nil || org.OwnerID != userID {
+		return nil, 404, "organization not found"
+	}
+
+	return &org, 0, ""
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
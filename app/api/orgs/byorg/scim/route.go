@@ -0,0 +1,104 @@
+// Package scim manages an organization's SCIM provisioning token.
+package scim
+
+import (
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type TokenResponse struct {
+	Token   string `json:"token"`
+	BaseURL string `json:"base_url"`
+}
+
+// Post generates a new SCIM bearer token for the organization's identity
+// provider, replacing any previous one. The token is only shown once.
+// POST /api/orgs/{org}/scim
+func Post(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	org, status, message := ownedOrg(c, cfg, pool)
+	if org == nil {
+		return c.JSON(status, map[string]string{"error": message})
+	}
+
+	token, err := auth.GenerateSCIMToken()
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to generate token"})
+	}
+
+	hash := auth.HashToken(token)
+	queries := db.New(pool)
+	err = queries.UpdateOrganizationSCIMToken(c.Request.Context(), db.UpdateOrganizationSCIMTokenParams{
+		ID:            org.ID,
+		ScimTokenHash: &hash,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to save token"})
+	}
+
+	return c.JSON(201, TokenResponse{
+		Token:   token,
+		BaseURL: "https://" + cfg.PlatformDomain + "/api/scim/v2",
+	})
+}
+
+// Delete disables SCIM provisioning for the organization
+// DELETE /api/orgs/{org}/scim
+func Delete(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	org, status, message := ownedOrg(c, cfg, pool)
+	if org == nil {
+		return c.JSON(status, map[string]string{"error": message})
+	}
+
+	queries := db.New(pool)
+	err := queries.UpdateOrganizationSCIMToken(c.Request.Context(), db.UpdateOrganizationSCIMTokenParams{
+		ID: org.ID,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to disable scim"})
+	}
+
+	return c.NoContent()
+}
+
+func ownedOrg(c *fuego.Context, cfg *config.Config, pool *pgxpool.Pool) (*db.Organization, int, string) {
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return nil, 401, "unauthorized"
+	}
+
+	org, err := db.New(pool).GetOrganizationByName(c.Request.Context(), c.Param("org"))
+	if err != nil || org.OwnerID != userID {
+		return nil, 404, "organization not found"
+	}
+
+	return &org, 0, ""
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
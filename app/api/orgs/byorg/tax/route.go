@@ -0,0 +1,132 @@
+package tax
+
+import (
+	"errors"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/tax"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type TaxProfileResponse struct {
+	Country      string `json:"country"`
+	PostalCode   string `json:"postal_code"`
+	BusinessName string `json:"business_name"`
+	TaxIDType    string `json:"tax_id_type,omitempty"`
+	TaxID        string `json:"tax_id,omitempty"`
+	// TaxIDStatus is Stripe's verification of the tax ID, known once a
+	// checkout billed the organization
+	TaxIDStatus string    `json:"tax_id_status,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Get returns the organization's tax profile
+// GET /api/orgs/{org}/tax
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	org, status, message := ownedOrg(c, cfg, pool)
+	if org == nil {
+		return c.JSON(status, map[string]string{"error": message})
+	}
+
+	profile, err := db.New(pool).GetOrganizationTaxProfile(c.Request.Context(), pgtype.UUID{Bytes: org.ID, Valid: true})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return c.JSON(404, map[string]string{"error": "tax profile not set"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to get tax profile"})
+	}
+
+	return c.JSON(200, toTaxProfileResponse(profile))
+}
+
+// Put sets the organization's tax profile, the company its owner is billed
+// as when checking out for it
+// PUT /api/orgs/{org}/tax
+func Put(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	org, status, message := ownedOrg(c, cfg, pool)
+	if org == nil {
+		return c.JSON(status, map[string]string{"error": message})
+	}
+
+	var req tax.Profile
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+	normalized, taxIDType, err := tax.Normalize(req)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	profile, err := db.New(pool).UpsertOrganizationTaxProfile(c.Request.Context(), db.UpsertOrganizationTaxProfileParams{
+		OrganizationID: pgtype.UUID{Bytes: org.ID, Valid: true},
+		Country:        normalized.Country,
+		PostalCode:     normalized.PostalCode,
+		BusinessName:   normalized.BusinessName,
+		TaxIDType:      taxIDType,
+		TaxID:          normalized.TaxID,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to save tax profile"})
+	}
+
+	return c.JSON(200, toTaxProfileResponse(profile))
+}
+
+func toTaxProfileResponse(profile db.TaxProfile) TaxProfileResponse {
+	return TaxProfileResponse{
+		Country:      profile.Country,
+		PostalCode:   profile.PostalCode,
+		BusinessName: profile.BusinessName,
+		TaxIDType:    profile.TaxIDType,
+		TaxID:        profile.TaxID,
+		TaxIDStatus:  profile.TaxIDStatus,
+		UpdatedAt:    profile.UpdatedAt,
+	}
+}
+
+func ownedOrg(c *fuego.Context, cfg *config.Config, pool *pgxpool.Pool) (*db.Organization, int, string) {
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return nil, 401, "unauthorized"
+	}
+
+	org, err := db.New(pool).GetOrganizationByName(c.Request.Context(), c.Param("org"))
+	if err != nil || org.OwnerID != userID {
+		return nil, 404, "organization not found"
+	}
+
+	return &org, 0, ""
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
@@ -0,0 +1,116 @@
+// Package orgs manages organizations.
+package orgs
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+var orgNameRegex = regexp.MustCompile(`^[a-z][a-z0-9-]*[a-z0-9]$`)
+
+type CreateOrgRequest struct {
+	Name string `json:"name"`
+}
+
+type OrgResponse struct {
+	ID                   string    `json:"id"`
+	Name                 string    `json:"name"`
+	SCIMEnabled          bool      `json:"scim_enabled"`
+	MaxTokenLifetimeDays *int32    `json:"max_token_lifetime_days"`
+	CreatedAt            time.Time `json:"created_at"`
+}
+
+// Get lists the organizations owned by the user
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	orgs, err := queries.ListOrganizationsByOwner(c.Request.Context(), userID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list organizations"})
+	}
+
+	response := make([]OrgResponse, len(orgs))
+	for i, org := range orgs {
+		response[i] = toOrgResponse(org)
+	}
+
+	return c.JSON(200, response)
+}
+
+// Post creates an organization owned by the user
+func Post(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req CreateOrgRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+
+	if len(req.Name) < 3 || len(req.Name) > 63 || !orgNameRegex.MatchString(req.Name) {
+		return c.JSON(400, map[string]string{"error": "name must be 3-63 lowercase letters, numbers, and hyphens, starting with a letter"})
+	}
+
+	queries := db.New(pool)
+	if _, err := queries.GetOrganizationByName(c.Request.Context(), req.Name); err == nil {
+		return c.JSON(409, map[string]string{"error": "organization with this name already exists"})
+	}
+
+	org, err := queries.CreateOrganization(c.Request.Context(), db.CreateOrganizationParams{
+		Name:    req.Name,
+		OwnerID: userID,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to create organization"})
+	}
+
+	return c.JSON(201, toOrgResponse(org))
+}
+
+func toOrgResponse(org db.Organization) OrgResponse {
+	return OrgResponse{
+		ID:                   org.ID.String(),
+		Name:                 org.Name,
+		SCIMEnabled:          org.ScimTokenHash != nil,
+		MaxTokenLifetimeDays: org.MaxTokenLifetimeDays,
+		CreatedAt:            org.CreatedAt,
+	}
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
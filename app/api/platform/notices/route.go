@@ -0,0 +1,59 @@
+// Package notices provides the public platform notices shown as a banner by
+// the dashboard and CLI.
+package notices
+
+import (
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/maintenance"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+)
+
+const maxNotices = 10
+
+type NoticeResponse struct {
+	ID       string    `json:"id"`
+	Type     string    `json:"type"`
+	Title    string    `json:"title"`
+	Message  string    `json:"message"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	// Active is set while the window is in progress
+	Active bool `json:"active"`
+}
+
+// Get lists maintenance windows in progress or scheduled, soonest first
+// GET /api/platform/notices
+func Get(c *fuego.Context) error {
+	response := make([]NoticeResponse, 0)
+
+	pool := services.From(c).DB
+	if pool == nil {
+		return c.JSON(200, response)
+	}
+
+	now := time.Now()
+	windows, err := db.New(pool).ListUpcomingMaintenanceWindows(c.Request.Context(), db.ListUpcomingMaintenanceWindowsParams{
+		Now:        now,
+		MaxWindows: maxNotices,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list notices"})
+	}
+
+	for _, window := range windows {
+		response = append(response, NoticeResponse{
+			ID:       window.ID.String(),
+			Type:     "maintenance",
+			Title:    window.Title,
+			Message:  window.Message,
+			StartsAt: window.StartsAt,
+			EndsAt:   window.EndsAt,
+			Active:   maintenance.Active(window, now),
+		})
+	}
+
+	return c.JSON(200, response)
+}
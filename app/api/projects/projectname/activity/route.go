@@ -0,0 +1,126 @@
+package activity
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type ActivityResponse struct {
+	Activities []ActivityEntry `json:"activities"`
+	Total      int64           `json:"total"`
+	Limit      int32           `json:"limit"`
+	Offset     int32           `json:"offset"`
+}
+
+type ActivityEntry struct {
+	ID        uuid.UUID      `json:"id"`
+	App       string         `json:"app"`
+	Action    string         `json:"action"`
+	Details   map[string]any `json:"details,omitempty"`
+	IPAddress string         `json:"ip_address,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// Get returns the activity of all apps in a project, newest first
+// GET /api/projects/{name}/activity
+// Query params:
+//   - limit: number of entries (default 50, max 100)
+//   - offset: pagination offset (default 0)
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	limit := int32(50)
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.ParseInt(l, 10, 32); err == nil && parsed > 0 && parsed <= 100 {
+			limit = int32(parsed)
+		}
+	}
+
+	offset := int32(0)
+	if o := c.Query("offset"); o != "" {
+		if parsed, err := strconv.ParseInt(o, 10, 32); err == nil && parsed >= 0 {
+			offset = int32(parsed)
+		}
+	}
+
+	queries := db.New(pool)
+	project, err := queries.GetProjectByName(c.Request.Context(), db.GetProjectByNameParams{
+		UserID: userID,
+		Name:   c.Param("name"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "project not found"})
+	}
+
+	projectID := pgtype.UUID{Bytes: project.ID, Valid: true}
+	logs, err := queries.ListProjectActivityLogs(c.Request.Context(), db.ListProjectActivityLogsParams{
+		ProjectID: projectID,
+		Limit:     limit,
+		Offset:    offset,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to get activity logs"})
+	}
+
+	total, err := queries.CountProjectActivityLogs(c.Request.Context(), projectID)
+	if err != nil {
+		total = 0
+	}
+
+	activities := make([]ActivityEntry, 0, len(logs))
+	for _, log := range logs {
+		entry := ActivityEntry{
+			ID:        log.ID,
+			App:       log.AppName,
+			Action:    log.Action,
+			CreatedAt: log.CreatedAt,
+		}
+		if len(log.Details) > 0 {
+			_ = json.Unmarshal(log.Details, &entry.Details)
+		}
+		if log.IpAddress != nil {
+			entry.IPAddress = log.IpAddress.String()
+		}
+		activities = append(activities, entry)
+	}
+
+	return c.JSON(200, ActivityResponse{
+		Activities: activities,
+		Total:      total,
+		Limit:      limit,
+		Offset:     offset,
+	})
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
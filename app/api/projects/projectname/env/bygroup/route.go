@@ -0,0 +1,135 @@
+package group
+
+import (
+	"regexp"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/cryptoutil"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+var groupNameRegex = regexp.MustCompile(`^[a-z][a-z0-9-]{0,62}$`)
+
+type UpdateEnvGroupRequest struct {
+	Variables map[string]string `json:"variables"`
+}
+
+type EnvGroupResponse struct {
+	Name      string            `json:"name"`
+	Variables map[string]string `json:"variables"`
+	Count     int               `json:"count"`
+}
+
+// Put creates or replaces an env group of a project. The apps in the project
+// pick up the change on their next deploy.
+// PUT /api/projects/{name}/env/{group}
+// Body: { "variables": { "DATABASE_URL": "postgres://..." } }
+func Put(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	groupName := c.Param("group")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	if !groupNameRegex.MatchString(groupName) {
+		return c.JSON(400, map[string]string{"error": "group name must start with a letter and contain only lowercase letters, numbers, and hyphens"})
+	}
+
+	var req UpdateEnvGroupRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+
+	queries := db.New(pool)
+	project, err := queries.GetProjectByName(c.Request.Context(), db.GetProjectByNameParams{
+		UserID: userID,
+		Name:   c.Param("name"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "project not found"})
+	}
+
+	encrypted, err := cryptoutil.Encrypt(req.Variables, cfg.EncryptionKey)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to encrypt environment variables"})
+	}
+
+	if _, err := queries.UpsertProjectEnvGroup(c.Request.Context(), db.UpsertProjectEnvGroupParams{
+		ProjectID:        project.ID,
+		Name:             groupName,
+		EnvVarsEncrypted: encrypted,
+	}); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to update env group"})
+	}
+
+	redactedVars := make(map[string]string)
+	for key := range req.Variables {
+		redactedVars[key] = "••••••••"
+	}
+
+	return c.JSON(200, EnvGroupResponse{
+		Name:      groupName,
+		Variables: redactedVars,
+		Count:     len(req.Variables),
+	})
+}
+
+// Delete removes an env group from a project
+// DELETE /api/projects/{name}/env/{group}
+func Delete(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	project, err := queries.GetProjectByName(c.Request.Context(), db.GetProjectByNameParams{
+		UserID: userID,
+		Name:   c.Param("name"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "project not found"})
+	}
+
+	deleted, err := queries.DeleteProjectEnvGroup(c.Request.Context(), db.DeleteProjectEnvGroupParams{
+		ProjectID: project.ID,
+		Name:      c.Param("group"),
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to delete env group"})
+	}
+	if deleted == 0 {
+		return c.JSON(404, map[string]string{"error": "env group not found"})
+	}
+
+	return c.NoContent()
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
@@ -0,0 +1,90 @@
+package env
+
+import (
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/cryptoutil"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+type EnvGroupResponse struct {
+	Name      string            `json:"name"`
+	Variables map[string]string `json:"variables"`
+	Count     int               `json:"count"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// Get lists a project's env groups. Every app in the project receives these
+// variables; an app's own variables take precedence, and of two groups
+// setting the same variable the later one by name wins.
+// GET /api/projects/{name}/env
+// Query params:
+//   - redacted: hide values (default true)
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	project, err := queries.GetProjectByName(c.Request.Context(), db.GetProjectByNameParams{
+		UserID: userID,
+		Name:   c.Param("name"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "project not found"})
+	}
+
+	groups, err := queries.ListProjectEnvGroups(c.Request.Context(), project.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list env groups"})
+	}
+
+	redacted := c.Query("redacted") != "false"
+	response := make([]EnvGroupResponse, 0, len(groups))
+	for _, group := range groups {
+		vars, err := cryptoutil.Decrypt(group.EnvVarsEncrypted, cfg.EncryptionKey)
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to decrypt environment variables"})
+		}
+		if redacted {
+			for key := range vars {
+				vars[key] = "••••••••"
+			}
+		}
+		response = append(response, EnvGroupResponse{
+			Name:      group.Name,
+			Variables: vars,
+			Count:     len(vars),
+			UpdatedAt: group.UpdatedAt,
+		})
+	}
+
+	return c.JSON(200, response)
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
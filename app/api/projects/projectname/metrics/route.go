@@ -0,0 +1,140 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/metering"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Usage is the resource usage of an app, or the sum over a project
+type Usage struct {
+	Pods          int     `json:"pods"`
+	ReadyPods     int     `json:"ready_pods"`
+	CPUCores      float64 `json:"cpu_cores"`
+	MemoryMB      float64 `json:"memory_mb"`
+	IngressBytes  int64   `json:"ingress_bytes"`
+	EgressBytes   int64   `json:"egress_bytes"`
+	RequestsTotal int64   `json:"requests_total"`
+}
+
+type AppMetrics struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Usage
+}
+
+type ProjectMetricsResponse struct {
+	Project string       `json:"project"`
+	Period  string       `json:"period"`
+	Apps    []AppMetrics `json:"apps"`
+	Total   Usage        `json:"total"`
+}
+
+// periods are the metric windows a project can be queried for
+var periods = map[string]time.Duration{
+	"1h":  time.Hour,
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+}
+
+// Get returns the current resource usage and metered traffic of each app in
+// a project along with the project's totals
+// GET /api/projects/{name}/metrics
+// Query params:
+//   - period: traffic window, one of 1h, 24h, 7d, 30d (default 24h)
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	project, err := queries.GetProjectByName(c.Request.Context(), db.GetProjectByNameParams{
+		UserID: userID,
+		Name:   c.Param("name"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "project not found"})
+	}
+
+	period := c.Query("period")
+	periodDuration, ok := periods[period]
+	if !ok {
+		period = "24h"
+		periodDuration = periods[period]
+	}
+
+	apps, err := queries.ListAppsByProject(c.Request.Context(), pgtype.UUID{Bytes: project.ID, Valid: true})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list project apps"})
+	}
+
+	k8sClient := services.From(c).K8s
+	periodStart := time.Now().Add(-periodDuration).Truncate(metering.Period)
+
+	response := ProjectMetricsResponse{
+		Project: project.Name,
+		Period:  period,
+		Apps:    make([]AppMetrics, 0, len(apps)),
+	}
+	for _, app := range apps {
+		metrics := AppMetrics{Name: app.Name, Status: string(app.Status)}
+
+		if k8sClient != nil {
+			if appMetrics, err := k8sClient.GetAppMetrics(c.Request.Context(), app.Name); err == nil {
+				metrics.Pods = appMetrics.PodCount
+				metrics.ReadyPods = appMetrics.ReadyPods
+				metrics.CPUCores = appMetrics.TotalCPU
+				metrics.MemoryMB = appMetrics.TotalMemoryMB
+			}
+		}
+
+		bandwidth, _ := queries.GetAppBandwidth(c.Request.Context(), db.GetAppBandwidthParams{
+			AppID:       app.ID,
+			PeriodStart: periodStart,
+		})
+		metrics.IngressBytes = bandwidth.IngressBytes
+		metrics.EgressBytes = bandwidth.EgressBytes
+		metrics.RequestsTotal = bandwidth.Requests
+
+		response.Total.Pods += metrics.Pods
+		response.Total.ReadyPods += metrics.ReadyPods
+		response.Total.CPUCores += metrics.CPUCores
+		response.Total.MemoryMB += metrics.MemoryMB
+		response.Total.IngressBytes += metrics.IngressBytes
+		response.Total.EgressBytes += metrics.EgressBytes
+		response.Total.RequestsTotal += metrics.RequestsTotal
+		response.Apps = append(response.Apps, metrics)
+	}
+
+	return c.JSON(200, response)
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
@@ -0,0 +1,188 @@
+package project
+
+import (
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// MaxDescriptionLength bounds a project's description
+const MaxDescriptionLength = 500
+
+type UpdateProjectRequest struct {
+	Description string `json:"description"`
+}
+
+type ProjectAppResponse struct {
+	Name            string    `json:"name"`
+	Region          string    `json:"region"`
+	Size            string    `json:"size"`
+	Status          string    `json:"status"`
+	DeploymentCount int       `json:"deployment_count"`
+	URL             string    `json:"url"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type ProjectResponse struct {
+	ID          string               `json:"id"`
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Apps        []ProjectAppResponse `json:"apps"`
+	EnvGroups   []string             `json:"env_groups"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+}
+
+// Get returns a project with its apps and the names of its env groups
+// GET /api/projects/{name}
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	project, err := queries.GetProjectByName(c.Request.Context(), db.GetProjectByNameParams{
+		UserID: userID,
+		Name:   c.Param("name"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "project not found"})
+	}
+
+	return respond(c, cfg, queries, project)
+}
+
+// Put updates a project's description
+// PUT /api/projects/{name}
+func Put(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req UpdateProjectRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+	req.Description = strings.TrimSpace(req.Description)
+	if len([]rune(req.Description)) > MaxDescriptionLength {
+		return c.JSON(400, map[string]string{"error": "description may be at most 500 characters"})
+	}
+
+	queries := db.New(pool)
+	project, err := queries.GetProjectByName(c.Request.Context(), db.GetProjectByNameParams{
+		UserID: userID,
+		Name:   c.Param("name"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "project not found"})
+	}
+
+	project, err = queries.UpdateProject(c.Request.Context(), db.UpdateProjectParams{
+		ID:          project.ID,
+		Description: req.Description,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to update project"})
+	}
+
+	return respond(c, cfg, queries, project)
+}
+
+// Delete removes a project and its env groups. Its apps keep running outside
+// any project; they lose the group variables on their next deploy.
+// DELETE /api/projects/{name}
+func Delete(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	project, err := queries.GetProjectByName(c.Request.Context(), db.GetProjectByNameParams{
+		UserID: userID,
+		Name:   c.Param("name"),
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "project not found"})
+	}
+
+	if err := queries.DeleteProject(c.Request.Context(), project.ID); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to delete project"})
+	}
+
+	return c.JSON(200, map[string]any{"message": "project deleted", "deleted_at": time.Now().UTC()})
+}
+
+func respond(c *fuego.Context, cfg *config.Config, queries *db.Queries, project db.Project) error {
+	apps, err := queries.ListAppsByProject(c.Request.Context(), pgtype.UUID{Bytes: project.ID, Valid: true})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list project apps"})
+	}
+	groups, err := queries.ListProjectEnvGroups(c.Request.Context(), project.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list env groups"})
+	}
+
+	response := ProjectResponse{
+		ID:          project.ID.String(),
+		Name:        project.Name,
+		Description: project.Description,
+		Apps:        make([]ProjectAppResponse, 0, len(apps)),
+		EnvGroups:   make([]string, 0, len(groups)),
+		CreatedAt:   project.CreatedAt,
+		UpdatedAt:   project.UpdatedAt,
+	}
+	for _, app := range apps {
+		response.Apps = append(response.Apps, ProjectAppResponse{
+			Name:            app.Name,
+			Region:          app.Region,
+			Size:            app.Size,
+			Status:          string(app.Status),
+			DeploymentCount: int(app.DeploymentCount),
+			URL:             "https://" + app.Name + "." + cfg.AppsDomainSuffix,
+			UpdatedAt:       app.UpdatedAt,
+		})
+	}
+	for _, group := range groups {
+		response.EnvGroups = append(response.EnvGroups, group.Name)
+	}
+
+	return c.JSON(200, response)
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
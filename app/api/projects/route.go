@@ -0,0 +1,182 @@
+package projects
+
+import (
+	"encoding/json"
+	"net/netip"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var projectNameRegex = regexp.MustCompile(`^[a-z][a-z0-9-]*[a-z0-9]$`)
+
+// MaxDescriptionLength bounds a project's description
+const MaxDescriptionLength = 500
+
+type CreateProjectRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Apps        []string `json:"apps,omitempty"`
+}
+
+type ProjectResponse struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	AppCount    int       `json:"app_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Get lists the projects of the current user
+// GET /api/projects
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	projects, err := db.New(pool).ListProjectsByUser(c.Request.Context(), userID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list projects"})
+	}
+
+	response := make([]ProjectResponse, 0, len(projects))
+	for _, p := range projects {
+		response = append(response, ProjectResponse{
+			ID:          p.ID.String(),
+			Name:        p.Name,
+			Description: p.Description,
+			AppCount:    int(p.AppCount),
+			CreatedAt:   p.CreatedAt,
+			UpdatedAt:   p.UpdatedAt,
+		})
+	}
+
+	return c.JSON(200, response)
+}
+
+// Post creates a project, optionally moving existing apps into it
+// POST /api/projects
+// Body: { "name": "shop", "description": "Storefront", "apps": ["shop-web", "shop-api", "shop-worker"] }
+func Post(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req CreateProjectRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+
+	if len(req.Name) < 3 || len(req.Name) > 63 || !projectNameRegex.MatchString(req.Name) {
+		return c.JSON(400, map[string]string{"error": "name must be 3-63 characters, start with a letter, end with a letter or number, and contain only lowercase letters, numbers, and hyphens"})
+	}
+	req.Description = strings.TrimSpace(req.Description)
+	if len([]rune(req.Description)) > MaxDescriptionLength {
+		return c.JSON(400, map[string]string{"error": "description may be at most 500 characters"})
+	}
+
+	queries := db.New(pool)
+	if _, err := queries.GetProjectByName(c.Request.Context(), db.GetProjectByNameParams{UserID: userID, Name: req.Name}); err == nil {
+		return c.JSON(409, map[string]string{"error": "project with this name already exists"})
+	}
+
+	apps := make([]db.App, 0, len(req.Apps))
+	for _, name := range req.Apps {
+		app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{UserID: userID, Name: name})
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "app " + name + " not found"})
+		}
+		apps = append(apps, app)
+	}
+
+	tx, err := pool.Begin(c.Request.Context())
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to create project"})
+	}
+	defer func() { _ = tx.Rollback(c.Request.Context()) }()
+	qtx := queries.WithTx(tx)
+
+	project, err := qtx.CreateProject(c.Request.Context(), db.CreateProjectParams{
+		UserID:      userID,
+		Name:        req.Name,
+		Description: req.Description,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to create project"})
+	}
+	for _, app := range apps {
+		if _, err := qtx.SetAppProject(c.Request.Context(), db.SetAppProjectParams{
+			ID:        app.ID,
+			ProjectID: pgtype.UUID{Bytes: project.ID, Valid: true},
+		}); err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to add apps to project"})
+		}
+	}
+	if err := tx.Commit(c.Request.Context()); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to create project"})
+	}
+
+	for _, app := range apps {
+		details, _ := json.Marshal(map[string]any{"project": project.Name})
+		_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+			UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+			AppID:     pgtype.UUID{Bytes: app.ID, Valid: true},
+			Action:    "project.app_added",
+			Details:   details,
+			IpAddress: clientIP(c),
+		})
+	}
+
+	return c.JSON(201, ProjectResponse{
+		ID:          project.ID.String(),
+		Name:        project.Name,
+		Description: project.Description,
+		AppCount:    len(apps),
+		CreatedAt:   project.CreatedAt,
+		UpdatedAt:   project.UpdatedAt,
+	})
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
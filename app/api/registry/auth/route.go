@@ -0,0 +1,158 @@
+// Package registryauth is the token endpoint of the platform registry.
+package registryauth
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/registry"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/tokenpolicy"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+type TokenResponse struct {
+	Token string `json:"token"`
+	// AccessToken repeats Token for OAuth2 clients
+	AccessToken string    `json:"access_token"`
+	ExpiresIn   int       `json:"expires_in"`
+	IssuedAt    time.Time `json:"issued_at"`
+}
+
+var errInvalidCredentials = errors.New("invalid registry credentials")
+
+// Get issues the bearer tokens the platform registry accepts, following the
+// Docker token authentication flow. Clients log in with their username and a
+// registry token from /api/registry/token, or clusters with the pull
+// credential of a deploy, and get the scopes they asked for that the
+// credential allows. Without credentials the token grants nothing.
+// GET /api/registry/auth?service=registry.nexo.build&scope=repository:alice/web:pull,push
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	issuer, err := registry.LoadIssuer(cfg.RegistryHost, cfg.RegistryTokenCertFile, cfg.RegistryTokenKeyFile)
+	if errors.Is(err, registry.ErrNotConfigured) {
+		return c.JSON(503, map[string]string{"error": "the platform registry is not available"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to load registry token key"})
+	}
+	if service := c.Query("service"); service != "" && service != issuer.Service {
+		return c.JSON(400, map[string]string{"error": "unknown service " + service})
+	}
+
+	var requested []registry.Access
+	for _, param := range c.Request.URL.Query()["scope"] {
+		// Some clients join several scopes with spaces
+		for _, scope := range strings.Fields(param) {
+			access, err := registry.ParseScope(scope)
+			if err != nil {
+				return c.JSON(400, map[string]string{"error": err.Error()})
+			}
+			requested = append(requested, access)
+		}
+	}
+
+	username, password, ok := c.Request.BasicAuth()
+	if !ok {
+		return issue(c, issuer, "", nil)
+	}
+
+	ip := clientIP(c)
+	if wait := auth.Blocked(ip, uuid.Nil); wait > 0 {
+		return c.JSON(429, map[string]string{"error": "too many failed authentication attempts, try again later"})
+	}
+
+	queries := db.New(pool)
+	user, push, err := authenticate(c.Request.Context(), cfg, queries, username, password, ip, tokenpolicy.UserAgent(c.Header("User-Agent")))
+	if errors.Is(err, errInvalidCredentials) {
+		auth.RecordFailure(c.Request.Context(), queries, ip, uuid.Nil, "invalid_registry_credentials")
+		return c.JSON(401, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to check registry credentials"})
+	}
+	auth.RecordSuccess(ip, user.ID)
+
+	// Pushes stop once the user's images fill their quota
+	overQuota := false
+	if push {
+		usage, err := queries.GetRegistryUsage(c.Request.Context(), user.ID)
+		overQuota = err == nil && usage.Bytes >= registry.QuotaBytes(cfg)
+	}
+
+	return issue(c, issuer, user.Username, registry.Grant(user.Username, requested, push, overQuota))
+}
+
+// authenticate returns the user of registry credentials and whether they
+// may push
+func authenticate(ctx context.Context, cfg *config.Config, queries *db.Queries, username, password, ip string, userAgent *string) (db.User, bool, error) {
+	if strings.HasPrefix(password, registry.PullPasswordPrefix) {
+		if !registry.VerifyPullPassword(cfg.SigningKey(), username, password) {
+			return db.User{}, false, errInvalidCredentials
+		}
+		user, err := queries.GetUserByUsername(ctx, username)
+		if err != nil {
+			return db.User{}, false, errInvalidCredentials
+		}
+		return user, false, nil
+	}
+
+	token, err := queries.GetAPITokenByHash(ctx, auth.HashToken(password))
+	if err != nil || token.RegistryAccess == nil {
+		return db.User{}, false, errInvalidCredentials
+	}
+	// The same rules as API tokens signing in to the API
+	if err := tokenpolicy.Check(ctx, queries, token, ip, time.Now()); err != nil {
+		return db.User{}, false, errInvalidCredentials
+	}
+
+	user, err := queries.GetUserByID(ctx, token.UserID)
+	if err != nil {
+		return db.User{}, false, err
+	}
+	if user.Username != username {
+		return db.User{}, false, errInvalidCredentials
+	}
+
+	var lastUsedIP *netip.Addr
+	if addr, err := netip.ParseAddr(ip); err == nil {
+		lastUsedIP = &addr
+	}
+	_ = queries.RecordAPITokenUse(ctx, db.RecordAPITokenUseParams{
+		ID:                token.ID,
+		LastUsedIp:        lastUsedIP,
+		LastUsedUserAgent: userAgent,
+	})
+
+	return user, *token.RegistryAccess == registry.ActionPush, nil
+}
+
+func issue(c *fuego.Context, issuer *registry.Issuer, subject string, access []registry.Access) error {
+	token, err := issuer.Issue(subject, access, time.Now())
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to issue registry token"})
+	}
+	return c.JSON(200, TokenResponse{
+		Token:       token.Token,
+		AccessToken: token.Token,
+		ExpiresIn:   token.ExpiresIn,
+		IssuedAt:    token.IssuedAt,
+	})
+}
+
+func clientIP(c *fuego.Context) string {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return addr.String()
+	}
+	return ""
+}
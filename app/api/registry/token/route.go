@@ -1,24 +1,27 @@
 package token
 
 import (
-	"context"
 	"crypto/rand"
-	"crypto/sha256"
 	"encoding/hex"
 	"time"
 
 	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/registry"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
 	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type CreateTokenRequest struct {
 	Name      string `json:"name"`
 	ExpiresIn int    `json:"expires_in"`
+	// Access is what the token may do in the platform registry, pull or
+	// push; push by default
+	Access string `json:"access"`
 }
 
 type TokenResponse struct {
@@ -28,6 +31,7 @@ type TokenResponse struct {
 	ExpiresAt *time.Time `json:"expires_at,omitempty"`
 	CreatedAt time.Time  `json:"created_at"`
 	LastUsed  *time.Time `json:"last_used,omitempty"`
+	Access    string     `json:"access,omitempty"`
 }
 
 type TokenListResponse struct {
@@ -36,8 +40,8 @@ type TokenListResponse struct {
 }
 
 func Get(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
 
 	userID, err := getUserID(c, cfg)
 	if err != nil {
@@ -45,7 +49,7 @@ func Get(c *fuego.Context) error {
 	}
 
 	queries := db.New(pool)
-	tokens, err := queries.ListAPITokensByUser(context.Background(), userID)
+	tokens, err := queries.ListAPITokensByUser(c.Request.Context(), userID)
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": "failed to list tokens"})
 	}
@@ -62,8 +66,8 @@ func Get(c *fuego.Context) error {
 }
 
 func Post(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
 
 	userID, err := getUserID(c, cfg)
 	if err != nil {
@@ -71,13 +75,20 @@ func Post(c *fuego.Context) error {
 	}
 
 	var req CreateTokenRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(400, map[string]string{"error": "invalid request body"})
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
 	}
 
 	if req.Name == "" {
 		req.Name = "Registry Token"
 	}
+	switch req.Access {
+	case "":
+		req.Access = registry.ActionPush
+	case registry.ActionPull, registry.ActionPush:
+	default:
+		return c.JSON(400, map[string]string{"error": "access must be pull or push"})
+	}
 
 	tokenBytes := make([]byte, 32)
 	if _, err := rand.Read(tokenBytes); err != nil {
@@ -85,9 +96,6 @@ func Post(c *fuego.Context) error {
 	}
 	tokenStr := "fgc_" + hex.EncodeToString(tokenBytes)
 
-	hash := sha256.Sum256([]byte(tokenStr))
-	tokenHash := hex.EncodeToString(hash[:])
-
 	var expiresAt pgtype.Timestamptz
 	if req.ExpiresIn > 0 {
 		expTime := time.Now().Add(time.Duration(req.ExpiresIn) * time.Second)
@@ -95,11 +103,12 @@ func Post(c *fuego.Context) error {
 	}
 
 	queries := db.New(pool)
-	token, err := queries.CreateAPIToken(context.Background(), db.CreateAPITokenParams{
-		UserID:    userID,
-		Name:      req.Name,
-		TokenHash: tokenHash,
-		ExpiresAt: expiresAt,
+	token, err := queries.CreateAPIToken(c.Request.Context(), db.CreateAPITokenParams{
+		UserID:         userID,
+		Name:           req.Name,
+		TokenHash:      auth.HashToken(tokenStr),
+		ExpiresAt:      expiresAt,
+		RegistryAccess: &req.Access,
 	})
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": "failed to create token"})
@@ -109,8 +118,8 @@ func Post(c *fuego.Context) error {
 }
 
 func Delete(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
 
 	userID, err := getUserID(c, cfg)
 	if err != nil {
@@ -128,7 +137,7 @@ func Delete(c *fuego.Context) error {
 	}
 
 	queries := db.New(pool)
-	token, err := queries.GetAPITokenByID(context.Background(), id)
+	token, err := queries.GetAPITokenByID(c.Request.Context(), id)
 	if err != nil {
 		return c.JSON(404, map[string]string{"error": "token not found"})
 	}
@@ -137,7 +146,7 @@ func Delete(c *fuego.Context) error {
 		return c.JSON(404, map[string]string{"error": "token not found"})
 	}
 
-	err = queries.DeleteAPIToken(context.Background(), id)
+	err = queries.DeleteAPIToken(c.Request.Context(), id)
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": "failed to delete token"})
 	}
@@ -179,5 +188,9 @@ func toTokenResponse(t db.ApiToken, plainToken string) TokenResponse {
 		resp.LastUsed = &t.LastUsedAt.Time
 	}
 
+	if t.RegistryAccess != nil {
+		resp.Access = *t.RegistryAccess
+	}
+
 	return resp
 }
@@ -0,0 +1,246 @@
+package routers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appconfig"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var routerNameRegex = regexp.MustCompile(`^[a-z][a-z0-9-]*[a-z0-9]$`)
+
+type RouteRequest struct {
+	Path string `json:"path"`
+	App  string `json:"app"`
+}
+
+type CreateRouterRequest struct {
+	Name   string         `json:"name"`
+	Routes []RouteRequest `json:"routes"`
+}
+
+type RouteResponse struct {
+	Path string `json:"path"`
+	App  string `json:"app"`
+}
+
+type RouterResponse struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	URL       string          `json:"url"`
+	Routes    []RouteResponse `json:"routes"`
+	Synced    *bool           `json:"synced,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// Get lists the routers of the current user
+// GET /api/routers
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	routers, err := queries.ListRoutersByUser(c.Request.Context(), userID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list routers"})
+	}
+
+	response := make([]RouterResponse, 0, len(routers))
+	for _, router := range routers {
+		routes, err := queries.ListRouterRoutes(c.Request.Context(), router.ID)
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to list routes"})
+		}
+		response = append(response, toRouterResponse(cfg, router, routes))
+	}
+
+	return c.JSON(200, response)
+}
+
+// Post creates a router serving several apps on one host, routing by path
+// prefix. The longest matching prefix wins.
+// POST /api/routers
+// Body: { "name": "shop", "routes": [{ "path": "/api", "app": "shop-api" }, { "path": "/", "app": "shop-web" }] }
+func Post(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req CreateRouterRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+
+	if len(req.Name) < 3 || len(req.Name) > 63 || !routerNameRegex.MatchString(req.Name) {
+		return c.JSON(400, map[string]string{"error": "name must be 3-63 characters, start with a letter, end with a letter or number, and contain only lowercase letters, numbers, and hyphens"})
+	}
+
+	queries := db.New(pool)
+
+	if _, err := queries.GetRouterByName(c.Request.Context(), db.GetRouterByNameParams{UserID: userID, Name: req.Name}); err == nil {
+		return c.JSON(409, map[string]string{"error": "router with this name already exists"})
+	}
+	// Routers, apps and traffic splits share the <name>.<apps domain> host namespace
+	if _, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{UserID: userID, Name: req.Name}); err == nil {
+		return c.JSON(409, map[string]string{"error": "an app with this name already exists"})
+	}
+	if _, err := queries.GetTrafficSplitByName(c.Request.Context(), db.GetTrafficSplitByNameParams{UserID: userID, Name: req.Name}); err == nil {
+		return c.JSON(409, map[string]string{"error": "a traffic split with this name already exists"})
+	}
+
+	apps, err := resolveRoutes(c.Request.Context(), queries, userID, req.Routes)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	tx, err := pool.Begin(c.Request.Context())
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to create router"})
+	}
+	defer func() { _ = tx.Rollback(c.Request.Context()) }()
+
+	qtx := queries.WithTx(tx)
+	router, err := qtx.CreateRouter(c.Request.Context(), db.CreateRouterParams{UserID: userID, Name: req.Name})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to create router"})
+	}
+	for i, route := range req.Routes {
+		_, err := qtx.CreateRouterRoute(c.Request.Context(), db.CreateRouterRouteParams{
+			RouterID:   router.ID,
+			PathPrefix: k8s.NormalizeRoutePath(route.Path),
+			AppID:      apps[i].ID,
+		})
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to create router"})
+		}
+	}
+	if err := tx.Commit(c.Request.Context()); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to create router"})
+	}
+
+	routes, err := queries.ListRouterRoutes(c.Request.Context(), router.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list routes"})
+	}
+
+	details, _ := json.Marshal(map[string]any{"router": router.Name, "routes": len(routes)})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		Action:    "router.created",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	if err := applyRouter(c.Request.Context(), services.From(c), queries, router); err != nil {
+		return c.JSON(500, map[string]string{"error": "router saved but failed to apply: " + err.Error()})
+	}
+
+	response := toRouterResponse(cfg, router, routes)
+	synced := true
+	response.Synced = &synced
+
+	return c.JSON(201, response)
+}
+
+// resolveRoutes validates the requested routes and looks up the routed apps,
+// which must belong to the user and run in the same region
+func resolveRoutes(ctx context.Context, queries *db.Queries, userID uuid.UUID, routes []RouteRequest) ([]db.App, error) {
+	routerConfig := &k8s.RouterConfig{}
+	for _, route := range routes {
+		routerConfig.Routes = append(routerConfig.Routes, k8s.RouterRoute{PathPrefix: route.Path, AppName: route.App})
+	}
+	if err := k8s.ValidateRouter(routerConfig); err != nil {
+		return nil, err
+	}
+
+	apps := make([]db.App, 0, len(routes))
+	for _, route := range routes {
+		app, err := queries.GetAppByName(ctx, db.GetAppByNameParams{UserID: userID, Name: route.App})
+		if err != nil {
+			return nil, fmt.Errorf("app %q not found", route.App)
+		}
+		if len(apps) > 0 && app.Region != apps[0].Region {
+			return nil, fmt.Errorf("apps behind a router must run in the same region, %q runs in %s and %q in %s", apps[0].Name, apps[0].Region, app.Name, app.Region)
+		}
+		apps = append(apps, app)
+	}
+	return apps, nil
+}
+
+func applyRouter(ctx context.Context, svc *services.Services, queries *db.Queries, router db.Router) error {
+	cfg := svc.Config
+	routerConfig, region, err := appconfig.LoadRouter(ctx, cfg, queries, router)
+	if err != nil {
+		return err
+	}
+
+	k8sClient, err := svc.Cluster(region)
+	if err != nil {
+		return err
+	}
+	return k8sClient.ApplyRouter(ctx, routerConfig)
+}
+
+func toRouterResponse(cfg *config.Config, router db.Router, routes []db.ListRouterRoutesRow) RouterResponse {
+	response := RouterResponse{
+		ID:        router.ID.String(),
+		Name:      router.Name,
+		URL:       "https://" + appconfig.PlatformHost(cfg, router.Name),
+		Routes:    make([]RouteResponse, 0, len(routes)),
+		CreatedAt: router.CreatedAt,
+		UpdatedAt: router.UpdatedAt,
+	}
+	for _, route := range routes {
+		response.Routes = append(response.Routes, RouteResponse{Path: route.PathPrefix, App: route.AppName})
+	}
+	return response
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appconfig"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type RouteRequest struct {
+	Path string `json:"path"`
+	App  string `json:"app"`
+}
+
+type UpdateRouterRequest struct {
+	Routes []RouteRequest `json:"routes"`
+}
+
+type RouteResponse struct {
+	Path string `json:"path"`
+	App  string `json:"app"`
+}
+
+type RouterResponse struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	URL       string          `json:"url"`
+	Routes    []RouteResponse `json:"routes"`
+	Synced    *bool           `json:"synced,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// Get returns a router and its routes
+// GET /api/routers/{name}
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	routerName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	router, err := queries.GetRouterByName(c.Request.Context(), db.GetRouterByNameParams{
+		UserID: userID,
+		Name:   routerName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "router not found"})
+	}
+
+	routes, err := queries.ListRouterRoutes(c.Request.Context(), router.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list routes"})
+	}
+
+	return c.JSON(200, toRouterResponse(cfg, router, routes))
+}
+
+// Put replaces the routes of a router and updates its ingress
+// PUT /api/routers/{name}
+// Body: { "routes": [{ "path": "/api", "app": "shop-api" }, { "path": "/", "app": "shop-web" }] }
+func Put(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	routerName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req UpdateRouterRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+
+	queries := db.New(pool)
+	router, err := queries.GetRouterByName(c.Request.Context(), db.GetRouterByNameParams{
+		UserID: userID,
+		Name:   routerName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "router not found"})
+	}
+
+	apps, err := resolveRoutes(c.Request.Context(), queries, userID, req.Routes)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	_, previousRegion, err := appconfig.LoadRouter(c.Request.Context(), cfg, queries, router)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	tx, err := pool.Begin(c.Request.Context())
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to update routes"})
+	}
+	defer func() { _ = tx.Rollback(c.Request.Context()) }()
+
+	qtx := queries.WithTx(tx)
+	if err := qtx.DeleteRouterRoutes(c.Request.Context(), router.ID); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to update routes"})
+	}
+	for i, route := range req.Routes {
+		_, err := qtx.CreateRouterRoute(c.Request.Context(), db.CreateRouterRouteParams{
+			RouterID:   router.ID,
+			PathPrefix: k8s.NormalizeRoutePath(route.Path),
+			AppID:      apps[i].ID,
+		})
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to update routes"})
+		}
+	}
+	router, err = qtx.TouchRouter(c.Request.Context(), router.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to update routes"})
+	}
+	if err := tx.Commit(c.Request.Context()); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to update routes"})
+	}
+
+	routes, err := queries.ListRouterRoutes(c.Request.Context(), router.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list routes"})
+	}
+
+	details, _ := json.Marshal(map[string]any{"router": router.Name, "routes": len(routes)})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		Action:    "router.updated",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	routerConfig, region, err := appconfig.LoadRouter(c.Request.Context(), cfg, queries, router)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+	k8sClient, err := services.From(c).Cluster(region)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "routes saved but kubernetes is not available"})
+	}
+	if err := k8sClient.ApplyRouter(c.Request.Context(), routerConfig); err != nil {
+		return c.JSON(500, map[string]string{"error": "routes saved but failed to apply: " + err.Error()})
+	}
+	// Routes moved to apps of another region leave the old region's cluster
+	if previousRegion != region {
+		if previous, err := services.From(c).Cluster(previousRegion); err == nil {
+			_ = previous.DeleteRouter(c.Request.Context(), router.Name)
+		}
+	}
+
+	response := toRouterResponse(cfg, router, routes)
+	synced := true
+	response.Synced = &synced
+
+	return c.JSON(200, response)
+}
+
+// Delete removes a router and its ingress. The routed apps keep running on
+// their own hosts.
+// DELETE /api/routers/{name}
+func Delete(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	routerName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	router, err := queries.GetRouterByName(c.Request.Context(), db.GetRouterByNameParams{
+		UserID: userID,
+		Name:   routerName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "router not found"})
+	}
+
+	if _, region, err := appconfig.LoadRouter(c.Request.Context(), cfg, queries, router); err == nil {
+		if k8sClient, err := services.From(c).Cluster(region); err == nil {
+			_ = k8sClient.DeleteRouter(c.Request.Context(), router.Name)
+		}
+	}
+
+	if err := queries.DeleteRouter(c.Request.Context(), router.ID); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to delete router"})
+	}
+
+	details, _ := json.Marshal(map[string]any{"router": router.Name})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		Action:    "router.deleted",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(200, map[string]any{"message": "router deleted", "deleted_at": time.Now().UTC()})
+}
+
+// resolveRoutes validates the requested routes and looks up the routed apps,
+// which must belong to the user and run in the same region
+func resolveRoutes(ctx context.Context, queries *db.Queries, userID uuid.UUID, routes []RouteRequest) ([]db.App, error) {
+	routerConfig := &k8s.RouterConfig{}
+	for _, route := range routes {
+		routerConfig.Routes = append(routerConfig.Routes, k8s.RouterRoute{PathPrefix: route.Path, AppName: route.App})
+	}
+	if err := k8s.ValidateRouter(routerConfig); err != nil {
+		return nil, err
+	}
+
+	apps := make([]db.App, 0, len(routes))
+	for _, route := range routes {
+		app, err := queries.GetAppByName(ctx, db.GetAppByNameParams{UserID: userID, Name: route.App})
+		if err != nil {
+			return nil, fmt.Errorf("app %q not found", route.App)
+		}
+		if len(apps) > 0 && app.Region != apps[0].Region {
+			return nil, fmt.Errorf("apps behind a router must run in the same region, %q runs in %s and %q in %s", apps[0].Name, apps[0].Region, app.Name, app.Region)
+		}
+		apps = append(apps, app)
+	}
+	return apps, nil
+}
+
+func toRouterResponse(cfg *config.Config, router db.Router, routes []db.ListRouterRoutesRow) RouterResponse {
+	response := RouterResponse{
+		ID:        router.ID.String(),
+		Name:      router.Name,
+		URL:       "https://" + appconfig.PlatformHost(cfg, router.Name),
+		Routes:    make([]RouteResponse, 0, len(routes)),
+		CreatedAt: router.CreatedAt,
+		UpdatedAt: router.UpdatedAt,
+	}
+	for _, route := range routes {
+		response.Routes = append(response.Routes, RouteResponse{Path: route.PathPrefix, App: route.AppName})
+	}
+	return response
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
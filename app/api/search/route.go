@@ -0,0 +1,82 @@
+package search
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appmeta"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/search"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+type SearchResponse struct {
+	Query   string          `json:"query"`
+	Results []search.Result `json:"results"`
+}
+
+// Get searches the user's app names, domains, deployment images and
+// activity actions, the best matches first
+// GET /api/search?q=shop
+// Query params:
+//   - q: the term to look for (at most 100 characters)
+//   - limit: number of results (default 20, max 50)
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		return c.JSON(400, map[string]string{"error": "q is required"})
+	}
+	if utf8.RuneCountInString(q) > 100 {
+		return c.JSON(400, map[string]string{"error": "q may be at most 100 characters"})
+	}
+
+	limit := 20
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
+			limit = min(parsed, 50)
+		}
+	}
+
+	queries := db.New(pool)
+	candidates, err := queries.SearchUserResources(c.Request.Context(), db.SearchUserResourcesParams{
+		UserID:  userID,
+		Pattern: appmeta.SearchPattern(q),
+		PerKind: int32(limit),
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to search"})
+	}
+
+	return c.JSON(200, SearchResponse{Query: q, Results: search.Rank(q, candidates, limit)})
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if id, ok := c.Get("user_id").(uuid.UUID); ok {
+		return id, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
@@ -0,0 +1,29 @@
+package search
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/testutil"
+	"github.com/google/uuid"
+)
+
+func TestGet_Validation(t *testing.T) {
+	ta := testutil.NewTestApp().WithAuth(uuid.New(), "octocat")
+	ta.App.Get("/api/search", Get)
+	ta.App.Mount()
+
+	get := func(query string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		ta.App.ServeHTTP(w, testutil.MakeRequest(t, http.MethodGet, "/api/search"+query, nil, nil))
+		return w
+	}
+
+	// Searches are rejected before the database is reached
+	testutil.AssertStatusCode(t, get(""), http.StatusBadRequest)
+	testutil.AssertStatusCode(t, get("?q=%20%20"), http.StatusBadRequest)
+	testutil.AssertStatusCode(t, get("?q="+strings.Repeat("a", 101)), http.StatusBadRequest)
+}
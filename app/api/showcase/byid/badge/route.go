@@ -0,0 +1,47 @@
+// Package showcasebadge serves the uptime badges of showcase apps.
+package showcasebadge
+
+import (
+	"context"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/badge"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/showcase"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+const metricsTimeout = 3 * time.Second
+
+// Get renders an SVG badge with the uptime of a showcase app, for READMEs
+// and the gallery. It needs no authentication and is cached for a minute.
+// GET /api/showcase/{id}/badge
+func Get(c *fuego.Context) error {
+	svc := services.From(c)
+
+	appID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	app, err := db.New(svc.DB).GetShowcaseApp(c.Request.Context(), appID)
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	// A cluster that does not answer leaves the badge to the app's status
+	var metrics *k8s.AppMetrics
+	if app.Status == db.AppStatusRunning {
+		if client, err := svc.Cluster(app.Region); err == nil {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), metricsTimeout)
+			metrics, _ = client.GetAppMetrics(ctx, app.Name)
+			cancel()
+		}
+	}
+
+	message, color := showcase.Uptime(app.Status, metrics)
+	return badge.Serve(c, badge.Render("uptime", message, color))
+}
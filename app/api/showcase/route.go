@@ -0,0 +1,69 @@
+// Package showcase provides the public directory of apps their owners
+// opted in to.
+package showcase
+
+import (
+	"strconv"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/showcase"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+)
+
+type ShowcaseResponse struct {
+	Apps   []showcase.App `json:"apps"`
+	Total  int64          `json:"total"`
+	Limit  int32          `json:"limit"`
+	Offset int32          `json:"offset"`
+}
+
+// Get lists the apps in the showcase by name. It needs no authentication.
+// GET /api/showcase
+// Query params:
+//   - limit: number of apps (default 50, max 100)
+//   - offset: pagination offset (default 0)
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	limit := int32(showcase.DefaultPageSize)
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.ParseInt(l, 10, 32); err == nil && parsed > 0 && parsed <= showcase.MaxPageSize {
+			limit = int32(parsed)
+		}
+	}
+
+	offset := int32(0)
+	if o := c.Query("offset"); o != "" {
+		if parsed, err := strconv.ParseInt(o, 10, 32); err == nil && parsed >= 0 {
+			offset = int32(parsed)
+		}
+	}
+
+	queries := db.New(pool)
+	apps, err := queries.ListShowcaseApps(c.Request.Context(), db.ListShowcaseAppsParams{
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list showcase"})
+	}
+
+	total, err := queries.CountShowcaseApps(c.Request.Context())
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to count showcase"})
+	}
+
+	response := ShowcaseResponse{
+		Apps:   make([]showcase.App, 0, len(apps)),
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}
+	for _, app := range apps {
+		response.Apps = append(response.Apps, showcase.View(app, cfg.AppsDomainSuffix, cfg.PlatformDomain))
+	}
+
+	return c.JSON(200, response)
+}
@@ -0,0 +1,261 @@
+package splits
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appconfig"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var splitNameRegex = regexp.MustCompile(`^[a-z][a-z0-9-]*[a-z0-9]$`)
+
+type BackendRequest struct {
+	App    string `json:"app"`
+	Weight int32  `json:"weight"`
+}
+
+type CreateSplitRequest struct {
+	Name     string           `json:"name"`
+	Sticky   bool             `json:"sticky"`
+	Backends []BackendRequest `json:"backends"`
+}
+
+type BackendResponse struct {
+	App    string `json:"app"`
+	Weight int32  `json:"weight"`
+}
+
+type SplitResponse struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	URL       string            `json:"url"`
+	Sticky    bool              `json:"sticky"`
+	Backends  []BackendResponse `json:"backends"`
+	Synced    *bool             `json:"synced,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// Get lists the traffic splits of the current user
+// GET /api/splits
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	splits, err := queries.ListTrafficSplitsByUser(c.Request.Context(), userID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list traffic splits"})
+	}
+
+	response := make([]SplitResponse, 0, len(splits))
+	for _, split := range splits {
+		backends, err := queries.ListTrafficSplitBackends(c.Request.Context(), split.ID)
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to list split backends"})
+		}
+		response = append(response, toSplitResponse(cfg, split, backends))
+	}
+
+	return c.JSON(200, response)
+}
+
+// Post creates a traffic split serving several apps on one host, each
+// receiving a percentage of the requests, e.g. for A/B tests
+// POST /api/splits
+// Body: { "name": "checkout-ab", "sticky": true, "backends": [{ "app": "checkout", "weight": 90 }, { "app": "checkout-v2", "weight": 10 }] }
+func Post(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req CreateSplitRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+
+	if len(req.Name) < 3 || len(req.Name) > 63 || !splitNameRegex.MatchString(req.Name) {
+		return c.JSON(400, map[string]string{"error": "name must be 3-63 characters, start with a letter, end with a letter or number, and contain only lowercase letters, numbers, and hyphens"})
+	}
+
+	queries := db.New(pool)
+
+	// Splits, routers and apps share the <name>.<apps domain> host namespace
+	if _, err := queries.GetTrafficSplitByName(c.Request.Context(), db.GetTrafficSplitByNameParams{UserID: userID, Name: req.Name}); err == nil {
+		return c.JSON(409, map[string]string{"error": "traffic split with this name already exists"})
+	}
+	if _, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{UserID: userID, Name: req.Name}); err == nil {
+		return c.JSON(409, map[string]string{"error": "an app with this name already exists"})
+	}
+	if _, err := queries.GetRouterByName(c.Request.Context(), db.GetRouterByNameParams{UserID: userID, Name: req.Name}); err == nil {
+		return c.JSON(409, map[string]string{"error": "a router with this name already exists"})
+	}
+
+	apps, err := resolveBackends(c.Request.Context(), queries, userID, req.Backends)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	tx, err := pool.Begin(c.Request.Context())
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to create traffic split"})
+	}
+	defer func() { _ = tx.Rollback(c.Request.Context()) }()
+
+	qtx := queries.WithTx(tx)
+	split, err := qtx.CreateTrafficSplit(c.Request.Context(), db.CreateTrafficSplitParams{
+		UserID: userID,
+		Name:   req.Name,
+		Sticky: req.Sticky,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to create traffic split"})
+	}
+	for i, backend := range req.Backends {
+		_, err := qtx.CreateTrafficSplitBackend(c.Request.Context(), db.CreateTrafficSplitBackendParams{
+			SplitID: split.ID,
+			AppID:   apps[i].ID,
+			Weight:  backend.Weight,
+		})
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to create traffic split"})
+		}
+	}
+	if err := tx.Commit(c.Request.Context()); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to create traffic split"})
+	}
+
+	backends, err := queries.ListTrafficSplitBackends(c.Request.Context(), split.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list split backends"})
+	}
+
+	details, _ := json.Marshal(map[string]any{"split": split.Name, "weights": weights(backends)})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		Action:    "split.created",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	if err := applySplit(c.Request.Context(), services.From(c), queries, split); err != nil {
+		return c.JSON(500, map[string]string{"error": "traffic split saved but failed to apply: " + err.Error()})
+	}
+
+	response := toSplitResponse(cfg, split, backends)
+	synced := true
+	response.Synced = &synced
+
+	return c.JSON(201, response)
+}
+
+// resolveBackends validates the requested weights and looks up the apps,
+// which must belong to the user and run in the same region
+func resolveBackends(ctx context.Context, queries *db.Queries, userID uuid.UUID, backends []BackendRequest) ([]db.App, error) {
+	splitConfig := &k8s.TrafficSplitConfig{}
+	for _, backend := range backends {
+		splitConfig.Backends = append(splitConfig.Backends, k8s.SplitBackend{AppName: backend.App, Weight: backend.Weight})
+	}
+	if err := k8s.ValidateTrafficSplit(splitConfig); err != nil {
+		return nil, err
+	}
+
+	apps := make([]db.App, 0, len(backends))
+	for _, backend := range backends {
+		app, err := queries.GetAppByName(ctx, db.GetAppByNameParams{UserID: userID, Name: backend.App})
+		if err != nil {
+			return nil, fmt.Errorf("app %q not found", backend.App)
+		}
+		if len(apps) > 0 && app.Region != apps[0].Region {
+			return nil, fmt.Errorf("apps in a traffic split must run in the same region, %q runs in %s and %q in %s", apps[0].Name, apps[0].Region, app.Name, app.Region)
+		}
+		apps = append(apps, app)
+	}
+	return apps, nil
+}
+
+func applySplit(ctx context.Context, svc *services.Services, queries *db.Queries, split db.TrafficSplit) error {
+	cfg := svc.Config
+	splitConfig, region, err := appconfig.LoadTrafficSplit(ctx, cfg, queries, split)
+	if err != nil {
+		return err
+	}
+
+	k8sClient, err := svc.Cluster(region)
+	if err != nil {
+		return err
+	}
+	return k8sClient.ApplyTrafficSplit(ctx, splitConfig)
+}
+
+func weights(backends []db.ListTrafficSplitBackendsRow) map[string]int32 {
+	w := make(map[string]int32, len(backends))
+	for _, backend := range backends {
+		w[backend.AppName] = backend.Weight
+	}
+	return w
+}
+
+func toSplitResponse(cfg *config.Config, split db.TrafficSplit, backends []db.ListTrafficSplitBackendsRow) SplitResponse {
+	response := SplitResponse{
+		ID:        split.ID.String(),
+		Name:      split.Name,
+		URL:       "https://" + appconfig.PlatformHost(cfg, split.Name),
+		Sticky:    split.Sticky,
+		Backends:  make([]BackendResponse, 0, len(backends)),
+		CreatedAt: split.CreatedAt,
+		UpdatedAt: split.UpdatedAt,
+	}
+	for _, backend := range backends {
+		response.Backends = append(response.Backends, BackendResponse{App: backend.AppName, Weight: backend.Weight})
+	}
+	return response
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
@@ -0,0 +1,304 @@
+package split
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appconfig"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type BackendRequest struct {
+	App    string `json:"app"`
+	Weight int32  `json:"weight"`
+}
+
+type UpdateSplitRequest struct {
+	Sticky   bool             `json:"sticky"`
+	Backends []BackendRequest `json:"backends"`
+}
+
+type BackendResponse struct {
+	App    string `json:"app"`
+	Weight int32  `json:"weight"`
+}
+
+type SplitResponse struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	URL       string            `json:"url"`
+	Sticky    bool              `json:"sticky"`
+	Backends  []BackendResponse `json:"backends"`
+	Synced    *bool             `json:"synced,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// Get returns a traffic split and its weights
+// GET /api/splits/{name}
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	splitName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	split, err := queries.GetTrafficSplitByName(c.Request.Context(), db.GetTrafficSplitByNameParams{
+		UserID: userID,
+		Name:   splitName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "traffic split not found"})
+	}
+
+	backends, err := queries.ListTrafficSplitBackends(c.Request.Context(), split.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list split backends"})
+	}
+
+	return c.JSON(200, toSplitResponse(cfg, split, backends))
+}
+
+// Put replaces the weights of a traffic split, e.g. to ramp a new version
+// from 10 to 50 percent
+// PUT /api/splits/{name}
+// Body: { "sticky": true, "backends": [{ "app": "checkout", "weight": 50 }, { "app": "checkout-v2", "weight": 50 }] }
+func Put(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	splitName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req UpdateSplitRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+
+	queries := db.New(pool)
+	split, err := queries.GetTrafficSplitByName(c.Request.Context(), db.GetTrafficSplitByNameParams{
+		UserID: userID,
+		Name:   splitName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "traffic split not found"})
+	}
+
+	apps, err := resolveBackends(c.Request.Context(), queries, userID, req.Backends)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	_, previousRegion, err := appconfig.LoadTrafficSplit(c.Request.Context(), cfg, queries, split)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	tx, err := pool.Begin(c.Request.Context())
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to update traffic split"})
+	}
+	defer func() { _ = tx.Rollback(c.Request.Context()) }()
+
+	qtx := queries.WithTx(tx)
+	if err := qtx.DeleteTrafficSplitBackends(c.Request.Context(), split.ID); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to update traffic split"})
+	}
+	for i, backend := range req.Backends {
+		_, err := qtx.CreateTrafficSplitBackend(c.Request.Context(), db.CreateTrafficSplitBackendParams{
+			SplitID: split.ID,
+			AppID:   apps[i].ID,
+			Weight:  backend.Weight,
+		})
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to update traffic split"})
+		}
+	}
+	split, err = qtx.UpdateTrafficSplit(c.Request.Context(), db.UpdateTrafficSplitParams{
+		ID:     split.ID,
+		Sticky: req.Sticky,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to update traffic split"})
+	}
+	if err := tx.Commit(c.Request.Context()); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to update traffic split"})
+	}
+
+	backends, err := queries.ListTrafficSplitBackends(c.Request.Context(), split.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list split backends"})
+	}
+
+	details, _ := json.Marshal(map[string]any{"split": split.Name, "weights": weights(backends)})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		Action:    "split.updated",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	if err := applySplit(c.Request.Context(), services.From(c), queries, split); err != nil {
+		return c.JSON(500, map[string]string{"error": "traffic split saved but failed to apply: " + err.Error()})
+	}
+	// Backends moved to apps of another region leave the old region's cluster
+	if previousRegion != apps[0].Region {
+		if previous, err := services.From(c).Cluster(previousRegion); err == nil {
+			_ = previous.DeleteTrafficSplit(c.Request.Context(), split.Name)
+		}
+	}
+
+	response := toSplitResponse(cfg, split, backends)
+	synced := true
+	response.Synced = &synced
+
+	return c.JSON(200, response)
+}
+
+// Delete removes a traffic split. The apps keep running on their own hosts.
+// DELETE /api/splits/{name}
+func Delete(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	splitName := c.Param("name")
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	split, err := queries.GetTrafficSplitByName(c.Request.Context(), db.GetTrafficSplitByNameParams{
+		UserID: userID,
+		Name:   splitName,
+	})
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "traffic split not found"})
+	}
+
+	if _, region, err := appconfig.LoadTrafficSplit(c.Request.Context(), cfg, queries, split); err == nil {
+		if k8sClient, err := services.From(c).Cluster(region); err == nil {
+			_ = k8sClient.DeleteTrafficSplit(c.Request.Context(), split.Name)
+		}
+	}
+
+	if err := queries.DeleteTrafficSplit(c.Request.Context(), split.ID); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to delete traffic split"})
+	}
+
+	details, _ := json.Marshal(map[string]any{"split": split.Name})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		Action:    "split.deleted",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(200, map[string]any{"message": "traffic split deleted", "deleted_at": time.Now().UTC()})
+}
+
+// resolveBackends validates the requested weights and looks up the apps,
+// which must belong to the user and run in the same region
+func resolveBackends(ctx context.Context, queries *db.Queries, userID uuid.UUID, backends []BackendRequest) ([]db.App, error) {
+	splitConfig := &k8s.TrafficSplitConfig{}
+	for _, backend := range backends {
+		splitConfig.Backends = append(splitConfig.Backends, k8s.SplitBackend{AppName: backend.App, Weight: backend.Weight})
+	}
+	if err := k8s.ValidateTrafficSplit(splitConfig); err != nil {
+		return nil, err
+	}
+
+	apps := make([]db.App, 0, len(backends))
+	for _, backend := range backends {
+		app, err := queries.GetAppByName(ctx, db.GetAppByNameParams{UserID: userID, Name: backend.App})
+		if err != nil {
+			return nil, fmt.Errorf("app %q not found", backend.App)
+		}
+		if len(apps) > 0 && app.Region != apps[0].Region {
+			return nil, fmt.Errorf("apps in a traffic split must run in the same region, %q runs in %s and %q in %s", apps[0].Name, apps[0].Region, app.Name, app.Region)
+		}
+		apps = append(apps, app)
+	}
+	return apps, nil
+}
+
+func applySplit(ctx context.Context, svc *services.Services, queries *db.Queries, split db.TrafficSplit) error {
+	cfg := svc.Config
+	splitConfig, region, err := appconfig.LoadTrafficSplit(ctx, cfg, queries, split)
+	if err != nil {
+		return err
+	}
+
+	k8sClient, err := svc.Cluster(region)
+	if err != nil {
+		return err
+	}
+	return k8sClient.ApplyTrafficSplit(ctx, splitConfig)
+}
+
+func weights(backends []db.ListTrafficSplitBackendsRow) map[string]int32 {
+	w := make(map[string]int32, len(backends))
+	for _, backend := range backends {
+		w[backend.AppName] = backend.Weight
+	}
+	return w
+}
+
+func toSplitResponse(cfg *config.Config, split db.TrafficSplit, backends []db.ListTrafficSplitBackendsRow) SplitResponse {
+	response := SplitResponse{
+		ID:        split.ID.String(),
+		Name:      split.Name,
+		URL:       "https://" + appconfig.PlatformHost(cfg, split.Name),
+		Sticky:    split.Sticky,
+		Backends:  make([]BackendResponse, 0, len(backends)),
+		CreatedAt: split.CreatedAt,
+		UpdatedAt: split.UpdatedAt,
+	}
+	for _, backend := range backends {
+		response.Backends = append(response.Backends, BackendResponse{App: backend.AppName, Weight: backend.Weight})
+	}
+	return response
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+// Package status provides the public platform status endpoint.
+package status
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/app/api/metrics"
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/readonly"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+)
+
+// Component and platform states
+const (
+	StatusHealthy     = "healthy"
+	StatusUnhealthy   = "unhealthy"
+	StatusUnreachable = "unreachable"
+
+	StatusOperational = "operational"
+	StatusDegraded    = "degraded"
+	StatusMajorOutage = "major_outage"
+)
+
+const checkTimeout = 2 * time.Second
+
+// RegionStatus is the health of the cluster serving a region
+type RegionStatus struct {
+	Region     string  `json:"region"`
+	Status     string  `json:"status"`
+	LatencyMs  float64 `json:"latency_ms"`
+	QueueDepth int64   `json:"queue_depth"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// DatabaseStatus is the health of the platform database
+type DatabaseStatus struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+}
+
+// APIStatus reports how the API itself is performing
+type APIStatus struct {
+	Status           string  `json:"status"`
+	AverageLatencyMs float64 `json:"average_latency_ms"`
+}
+
+// StatusResponse is the platform-wide status consumed by the public status
+// page and `fuegoctl status`
+type StatusResponse struct {
+	Status     string         `json:"status"`
+	Regions    []RegionStatus `json:"regions"`
+	Database   DatabaseStatus `json:"database"`
+	API        APIStatus      `json:"api"`
+	BuildQueue int64          `json:"build_queue"`
+	// ReadOnly is set while operators have paused changes to the platform
+	ReadOnly  readonly.State `json:"read_only"`
+	CheckedAt time.Time      `json:"checked_at"`
+}
+
+// Get returns the health of every region's cluster, the database, the API
+// and the build queue depth, and whether the API is read-only. Regions are checked concurrently so one
+// unreachable cluster does not delay the response past the check timeout.
+// GET /api/status
+func Get(c *fuego.Context) error {
+	response := StatusResponse{
+		API: APIStatus{
+			Status:           StatusHealthy,
+			AverageLatencyMs: milliseconds(metrics.AverageLatency()),
+		},
+		ReadOnly:  services.From(c).ReadOnly.State(),
+		CheckedAt: time.Now().UTC(),
+	}
+
+	var regions []string
+	var kubeconfigs func(string) string
+	svc := services.From(c)
+	if cfg := svc.Config; cfg != nil {
+		regions = cfg.Regions
+		kubeconfigs = cfg.KubeconfigForRegion
+	}
+
+	response.Regions = make([]RegionStatus, len(regions))
+	var wg sync.WaitGroup
+	for i, region := range regions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response.Regions[i] = checkRegion(c.Request.Context(), svc, region, kubeconfigs(region))
+		}()
+	}
+
+	pool := svc.DB
+	if pool == nil {
+		response.Database.Status = StatusUnreachable
+	} else {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), checkTimeout)
+		defer cancel()
+
+		start := time.Now()
+		err := pool.Ping(ctx)
+		response.Database.LatencyMs = milliseconds(time.Since(start))
+		if err != nil {
+			response.Database.Status = StatusUnhealthy
+		} else {
+			response.Database.Status = StatusHealthy
+		}
+	}
+
+	wg.Wait()
+
+	if response.Database.Status == StatusHealthy {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), checkTimeout)
+		defer cancel()
+
+		queued, err := db.New(pool).CountQueuedDeploymentsByRegion(ctx)
+		if err == nil {
+			depth := make(map[string]int64, len(queued))
+			for _, row := range queued {
+				depth[row.Region] = row.Queued
+				response.BuildQueue += row.Queued
+			}
+			for i := range response.Regions {
+				response.Regions[i].QueueDepth = depth[response.Regions[i].Region]
+			}
+		}
+	}
+
+	response.Status = overallStatus(response)
+	return c.JSON(200, response)
+}
+
+func checkRegion(ctx context.Context, svc *services.Services, region, kubeconfig string) RegionStatus {
+	result := RegionStatus{Region: region}
+
+	client, err := svc.Kubernetes(kubeconfig)
+	if err != nil {
+		result.Status = StatusUnreachable
+		result.Error = "cluster not configured"
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	latency, err := client.Ping(ctx)
+	result.LatencyMs = milliseconds(latency)
+	switch {
+	case err == nil:
+		result.Status = StatusHealthy
+	case ctx.Err() != nil:
+		result.Status = StatusUnreachable
+		result.Error = "timed out"
+	default:
+		result.Status = StatusUnhealthy
+		result.Error = "cluster API error"
+	}
+
+	return result
+}
+
+// overallStatus is a major outage when the database or every region is down
+// and degraded when any single component is
+func overallStatus(response StatusResponse) string {
+	if response.Database.Status != StatusHealthy {
+		return StatusMajorOutage
+	}
+
+	down := 0
+	for _, region := range response.Regions {
+		if region.Status != StatusHealthy {
+			down++
+		}
+	}
+
+	switch {
+	case len(response.Regions) > 0 && down == len(response.Regions):
+		return StatusMajorOutage
+	case down > 0:
+		return StatusDegraded
+	}
+	return StatusOperational
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000.0
+}
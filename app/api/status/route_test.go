@@ -0,0 +1,115 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/readonly"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+)
+
+func TestStatusGet_NoDatabase(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+	w := httptest.NewRecorder()
+
+	c := fuego.NewContext(w, req)
+	services.Set(c, &services.Services{Config: &config.Config{
+		Regions:           []string{"gdl", "mex"},
+		RegionKubeconfigs: map[string]string{},
+		Kubeconfig:        "/nonexistent/kubeconfig",
+	}})
+
+	if err := Get(c); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response StatusResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if response.Status != StatusMajorOutage {
+		t.Errorf("expected %q without a database, got %q", StatusMajorOutage, response.Status)
+	}
+	if response.Database.Status != StatusUnreachable {
+		t.Errorf("expected database %q, got %q", StatusUnreachable, response.Database.Status)
+	}
+	if len(response.Regions) != 2 || response.Regions[0].Region != "gdl" {
+		t.Fatalf("expected regions in configured order, got %+v", response.Regions)
+	}
+	for _, region := range response.Regions {
+		if region.Status != StatusUnreachable {
+			t.Errorf("expected region %s to be unreachable, got %q", region.Region, region.Status)
+		}
+	}
+}
+
+// readOnlyStore is a read-only switch turned on
+type readOnlyStore struct{}
+
+func (readOnlyStore) GetReadOnlyMode(context.Context) (db.PlatformReadOnly, error) {
+	return db.PlatformReadOnly{ID: true, Enabled: true, Message: "migrating", UpdatedAt: time.Now()}, nil
+}
+
+func (readOnlyStore) SetReadOnlyMode(context.Context, db.SetReadOnlyModeParams) (db.PlatformReadOnly, error) {
+	return db.PlatformReadOnly{}, nil
+}
+
+func TestStatusGet_ReadOnly(t *testing.T) {
+	sw := readonly.NewSwitch(readOnlyStore{}, time.Second)
+	if err := sw.Refresh(context.Background()); err != nil {
+		t.Fatalf("failed to refresh switch: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	c := fuego.NewContext(w, httptest.NewRequest(http.MethodGet, "/api/status", nil))
+	services.Set(c, &services.Services{Config: &config.Config{}, ReadOnly: sw})
+
+	if err := Get(c); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var response StatusResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !response.ReadOnly.Enabled || response.ReadOnly.Message != "migrating" || response.ReadOnly.Since == nil {
+		t.Errorf("expected the read-only notice, got %+v", response.ReadOnly)
+	}
+}
+
+func TestOverallStatus(t *testing.T) {
+	healthy := RegionStatus{Status: StatusHealthy}
+	down := RegionStatus{Status: StatusUnreachable}
+	database := DatabaseStatus{Status: StatusHealthy}
+
+	tests := []struct {
+		name     string
+		response StatusResponse
+		expected string
+	}{
+		{"all healthy", StatusResponse{Database: database, Regions: []RegionStatus{healthy, healthy}}, StatusOperational},
+		{"one region down", StatusResponse{Database: database, Regions: []RegionStatus{healthy, down}}, StatusDegraded},
+		{"all regions down", StatusResponse{Database: database, Regions: []RegionStatus{down, down}}, StatusMajorOutage},
+		{"database down", StatusResponse{Database: DatabaseStatus{Status: StatusUnhealthy}, Regions: []RegionStatus{healthy}}, StatusMajorOutage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := overallStatus(tt.response); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
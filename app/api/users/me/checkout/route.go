@@ -0,0 +1,169 @@
+package checkout
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/billing"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/tax"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type CheckoutRequest struct {
+	Plan string `json:"plan"`
+	// Org bills the subscription to an organization the user owns, under
+	// its tax profile rather than theirs
+	Org string `json:"org,omitempty"`
+	// SuccessURL and CancelURL are where Stripe Checkout sends the user back
+	SuccessURL string `json:"success_url"`
+	CancelURL  string `json:"cancel_url"`
+}
+
+type CheckoutResponse struct {
+	ID string `json:"id"`
+	// URL is the Stripe Checkout page to send the user to
+	URL string `json:"url"`
+}
+
+// Post starts a Stripe Checkout subscribing the current user to a plan.
+// Taxes are computed by Stripe Tax from the billing country of the tax
+// profile billed, which must be set first, and its tax ID is added to the
+// user's Stripe customer so business purchases are reverse charged. The
+// plan changes once Stripe reports the checkout completed.
+// POST /api/users/me/checkout
+func Post(c *fuego.Context) error {
+	svc := services.From(c)
+
+	userID, err := getUserID(c, svc.Config)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+	if svc.Billing == nil {
+		return c.JSON(503, map[string]string{"error": "billing is not configured"})
+	}
+
+	var req CheckoutRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+	req.Plan = strings.ToLower(req.Plan)
+	price, ok := svc.Config.StripePrice(req.Plan)
+	if !ok {
+		return c.JSON(400, map[string]string{"error": "plan is not available for checkout"})
+	}
+	if !validReturnURL(req.SuccessURL) || !validReturnURL(req.CancelURL) {
+		return c.JSON(400, map[string]string{"error": "success_url and cancel_url must be absolute http(s) URLs"})
+	}
+
+	ctx := c.Request.Context()
+	queries := db.New(svc.DB)
+
+	user, err := queries.GetUserByID(ctx, userID)
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "user not found"})
+	}
+
+	var profile db.TaxProfile
+	if req.Org != "" {
+		org, err := queries.GetOrganizationByName(ctx, req.Org)
+		if err != nil || org.OwnerID != userID {
+			return c.JSON(404, map[string]string{"error": "organization not found"})
+		}
+		profile, err = queries.GetOrganizationTaxProfile(ctx, pgtype.UUID{Bytes: org.ID, Valid: true})
+	} else {
+		profile, err = queries.GetUserTaxProfile(ctx, pgtype.UUID{Bytes: userID, Valid: true})
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return c.JSON(400, map[string]string{"error": "set your billing country first"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to get tax profile"})
+	}
+
+	customer := ""
+	if user.StripeCustomerID != nil {
+		customer = *user.StripeCustomerID
+	} else {
+		params := tax.CustomerParams(profile)
+		params.Email = user.Email
+		params.Metadata = map[string]string{"user_id": userID.String()}
+		customer, err = svc.Billing.CreateCustomer(ctx, params)
+		if err != nil {
+			return stripeError(c, err)
+		}
+		if _, err := queries.UpdateUserPlan(ctx, db.UpdateUserPlanParams{
+			ID:               userID,
+			Plan:             user.Plan,
+			StripeCustomerID: &customer,
+		}); err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to save customer"})
+		}
+	}
+
+	if _, err := tax.Sync(ctx, svc.Billing, queries, customer, profile); err != nil {
+		return stripeError(c, err)
+	}
+
+	session, err := svc.Billing.CreateCheckoutSession(ctx, billing.CheckoutParams{
+		Customer:          customer,
+		Price:             price,
+		SuccessURL:        req.SuccessURL,
+		CancelURL:         req.CancelURL,
+		ClientReferenceID: userID.String(),
+		Metadata: map[string]string{
+			"user_id": userID.String(),
+			"plan":    req.Plan,
+			"org":     req.Org,
+		},
+	})
+	if err != nil {
+		return stripeError(c, err)
+	}
+
+	return c.JSON(200, CheckoutResponse{ID: session.ID, URL: session.URL})
+}
+
+func validReturnURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	return err == nil && (parsed.Scheme == "https" || parsed.Scheme == "http") && parsed.Host != ""
+}
+
+// stripeError answers details Stripe refused, such as a tax ID failing its
+// format check, with its reason and other Stripe failures as a bad gateway
+func stripeError(c *fuego.Context, err error) error {
+	var apiErr *billing.Error
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest {
+		return c.JSON(400, map[string]string{"error": apiErr.Message})
+	}
+	slog.Error("failed to start checkout", "error", err)
+	return c.JSON(502, map[string]string{"error": "failed to start checkout"})
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
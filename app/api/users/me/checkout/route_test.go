@@ -0,0 +1,47 @@
+package checkout
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/billing"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/testutil"
+	"github.com/google/uuid"
+)
+
+func TestPost_Validation(t *testing.T) {
+	ta := testutil.NewTestApp().WithAuth(uuid.New(), "octocat")
+	ta.Config.StripePrices = map[string]string{"pro": "price_pro_monthly"}
+	ta.App.Post("/api/users/me/checkout", Post)
+	ta.App.Mount()
+
+	post := func(body CheckoutRequest) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		ta.App.ServeHTTP(w, testutil.MakeRequest(t, http.MethodPost, "/api/users/me/checkout", body, nil))
+		return w
+	}
+	valid := CheckoutRequest{
+		Plan:       "pro",
+		SuccessURL: "https://cloud.nexo.build/dashboard/billing?checkout=success",
+		CancelURL:  "https://cloud.nexo.build/dashboard/billing",
+	}
+
+	testutil.AssertStatusCode(t, post(valid), http.StatusServiceUnavailable)
+
+	// Requests are rejected before Stripe or the database are reached
+	ta.Services.Billing = billing.NewClient("sk_test").WithBaseURL("http://127.0.0.1:0")
+
+	unknown := valid
+	unknown.Plan = "enterprise"
+	testutil.AssertStatusCode(t, post(unknown), http.StatusBadRequest)
+
+	relative := valid
+	relative.SuccessURL = "/dashboard/billing"
+	testutil.AssertStatusCode(t, post(relative), http.StatusBadRequest)
+
+	scheme := valid
+	scheme.CancelURL = "javascript:alert(1)"
+	testutil.AssertStatusCode(t, post(scheme), http.StatusBadRequest)
+}
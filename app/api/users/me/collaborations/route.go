@@ -0,0 +1,69 @@
+package collaborations
+
+import (
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+type CollaborationResponse struct {
+	AppID   string    `json:"app_id"`
+	AppName string    `json:"app_name"`
+	Owner   string    `json:"owner"`
+	Role    string    `json:"role"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+// Get lists the apps of other users the current user collaborates on. Their
+// API is reached under /api/apps/{name} like the user's own apps.
+// GET /api/users/me/collaborations
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	rows, err := db.New(pool).ListCollaborationsByUser(c.Request.Context(), userID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list collaborations"})
+	}
+
+	response := make([]CollaborationResponse, len(rows))
+	for i, r := range rows {
+		response[i] = CollaborationResponse{
+			AppID:   r.AppID.String(),
+			AppName: r.AppName,
+			Owner:   r.OwnerUsername,
+			Role:    r.Role,
+			AddedAt: r.CreatedAt,
+		}
+	}
+
+	return c.JSON(200, response)
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
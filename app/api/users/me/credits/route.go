@@ -0,0 +1,101 @@
+package credits
+
+import (
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/credits"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+// maxEntries is how many of the latest ledger entries are listed
+const maxEntries = 50
+
+// Referral is the user's referral link and what it earned
+type Referral struct {
+	Code string `json:"code"`
+	Link string `json:"link"`
+	// Reward is credited, in cents, for each referred user once they pay
+	// their first invoice
+	Reward   int64 `json:"reward"`
+	Referred int32 `json:"referred"`
+	Credited int32 `json:"credited"`
+}
+
+type CreditsResponse struct {
+	// Balance is in cents and consumed by invoices before the card is
+	// charged
+	Balance  int64             `json:"balance"`
+	Currency string            `json:"currency"`
+	Entries  []db.CreditLedger `json:"entries"`
+	Referral Referral          `json:"referral"`
+}
+
+// Get returns the current user's credit balance, their latest credits
+// ledger entries and their referral link
+// GET /api/users/me/credits
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	ctx := c.Request.Context()
+	queries := db.New(pool)
+
+	user, err := queries.GetUserByID(ctx, userID)
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "user not found"})
+	}
+	balance, err := queries.GetCreditBalance(ctx, userID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to get balance"})
+	}
+	entries, err := queries.ListCreditEntries(ctx, db.ListCreditEntriesParams{UserID: userID, Limit: maxEntries})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list credits"})
+	}
+	if entries == nil {
+		entries = []db.CreditLedger{}
+	}
+	referrals, err := queries.CountReferrals(ctx, userID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to count referrals"})
+	}
+
+	return c.JSON(200, CreditsResponse{
+		Balance:  balance,
+		Currency: credits.Currency,
+		Entries:  entries,
+		Referral: Referral{
+			Code:     user.Username,
+			Link:     credits.ReferralLink(user.Username),
+			Reward:   credits.ReferralAmount,
+			Referred: referrals.Referred,
+			Credited: referrals.Credited,
+		},
+	})
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
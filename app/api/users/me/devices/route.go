@@ -0,0 +1,131 @@
+package devices
+
+import (
+	"encoding/json"
+	"net/netip"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/machineuser"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type DecisionRequest struct {
+	UserCode string `json:"user_code"`
+	// Approve grants the device an API token; false denies it
+	Approve bool `json:"approve"`
+}
+
+type DecisionResponse struct {
+	ClientName string `json:"client_name"`
+	Status     string `json:"status"`
+}
+
+// Post approves or denies a device authorization started by fuegoctl login,
+// identified by the user code shown in the terminal
+// POST /api/users/me/devices
+// Body: { "user_code": "BDWP-HQTN", "approve": true }
+func Post(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req DecisionRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+
+	queries := db.New(pool)
+
+	// Devices log in as the approving person, which a machine user never is
+	machine, err := machineuser.Lookup(c.Request.Context(), queries, userID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to load user"})
+	}
+	if machine != nil {
+		return c.JSON(403, map[string]string{"error": "machine users cannot approve devices"})
+	}
+
+	authorization, err := queries.GetDeviceAuthorizationByUserCode(c.Request.Context(), auth.NormalizeUserCode(req.UserCode))
+	if err != nil || time.Now().After(authorization.ExpiresAt) {
+		// User codes are short, so guesses count as failed logins
+		ip := ""
+		if addr := clientIP(c); addr != nil {
+			ip = addr.String()
+		}
+		auth.RecordFailure(c.Request.Context(), queries, ip, userID, "invalid_device_code")
+		return c.JSON(404, map[string]string{"error": "code not found or expired"})
+	}
+	if authorization.Status != auth.DeviceStatusPending {
+		return c.JSON(409, map[string]string{"error": "code already used"})
+	}
+
+	decider := pgtype.UUID{Bytes: userID, Valid: true}
+	action := "device.denied"
+	if req.Approve {
+		action = "device.approved"
+		authorization, err = queries.ApproveDeviceAuthorization(c.Request.Context(), db.ApproveDeviceAuthorizationParams{
+			ID:     authorization.ID,
+			UserID: decider,
+		})
+	} else {
+		authorization, err = queries.DenyDeviceAuthorization(c.Request.Context(), db.DenyDeviceAuthorizationParams{
+			ID:     authorization.ID,
+			UserID: decider,
+		})
+	}
+	if err != nil {
+		return c.JSON(409, map[string]string{"error": "code already used or expired"})
+	}
+
+	details, _ := json.Marshal(map[string]any{
+		"client_name": authorization.ClientName,
+	})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    decider,
+		Action:    action,
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(200, DecisionResponse{
+		ClientName: authorization.ClientName,
+		Status:     authorization.Status,
+	})
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
@@ -0,0 +1,133 @@
+package invoices
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/billing"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/suspension"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// defaultLimit is the invoices per page unless the request asks for more
+const defaultLimit = 10
+
+type InvoicesResponse struct {
+	Invoices []billing.Invoice `json:"invoices"`
+	// HasMore is set when older invoices follow; pass the ID of the last
+	// invoice as starting_after to get them
+	HasMore bool            `json:"has_more"`
+	Dunning billing.Dunning `json:"dunning"`
+	// Credits is the account's credit balance in cents, consumed by the
+	// next invoices before the card is charged
+	Credits int64 `json:"credits"`
+	// Suspension is where the account stands in the suspension pipeline
+	// after a failed payment
+	Suspension *suspension.State `json:"suspension,omitempty"`
+}
+
+// Get lists the current user's invoices from Stripe, newest first, with their
+// line items, PDF and payment links, their dunning state, the credits the
+// next invoices consume and the account's place in the suspension pipeline,
+// so the dashboard can warn about failed payments while they can still be
+// settled
+// GET /api/users/me/invoices?limit=10&starting_after=in_123
+func Get(c *fuego.Context) error {
+	svc := services.From(c)
+
+	userID, err := getUserID(c, svc.Config)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	limit := defaultLimit
+	if v := c.Query("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > billing.MaxLimit {
+			return c.JSON(400, map[string]string{"error": "limit must be between 1 and 100"})
+		}
+	}
+
+	if svc.Billing == nil {
+		return c.JSON(503, map[string]string{"error": "billing is not configured"})
+	}
+
+	user, err := svc.Store.Users.Get(c.Request.Context(), userID)
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "user not found"})
+	}
+
+	response := InvoicesResponse{
+		Invoices: []billing.Invoice{},
+		Dunning:  billing.Dunning{Status: billing.DunningOK},
+	}
+	// Users who never subscribed have no Stripe customer, nor invoices
+	if user.StripeCustomerID == nil || *user.StripeCustomerID == "" {
+		return c.JSON(200, response)
+	}
+	customer := *user.StripeCustomerID
+
+	ctx := c.Request.Context()
+	page, hasMore, err := svc.Billing.ListInvoices(ctx, billing.ListParams{
+		Customer:      customer,
+		Limit:         limit,
+		StartingAfter: c.Query("starting_after"),
+	})
+	if err != nil {
+		return c.JSON(502, map[string]string{"error": "failed to list invoices"})
+	}
+
+	// Failed payments may be on invoices outside the page
+	open, _, err := svc.Billing.ListInvoices(ctx, billing.ListParams{
+		Customer: customer,
+		Status:   "open",
+		Limit:    billing.MaxLimit,
+	})
+	if err != nil {
+		return c.JSON(502, map[string]string{"error": "failed to list invoices"})
+	}
+
+	response.Invoices = page
+	response.HasMore = hasMore
+	response.Dunning = billing.DunningState(open, time.Now())
+
+	queries := db.New(svc.DB)
+	response.Credits, err = queries.GetCreditBalance(ctx, userID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to get credits"})
+	}
+
+	s, err := queries.GetAccountSuspension(ctx, userID)
+	if err == nil {
+		state := suspension.StateOf(s)
+		response.Suspension = &state
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return c.JSON(500, map[string]string{"error": "failed to get suspension"})
+	}
+	return c.JSON(200, response)
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
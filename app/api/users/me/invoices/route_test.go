@@ -0,0 +1,46 @@
+package invoices
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/billing"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/store"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/testutil"
+)
+
+func TestGet(t *testing.T) {
+	s := store.NewMemory()
+	user, err := s.Users.Create(context.Background(), db.CreateUserParams{GithubID: 1, Username: "octocat", Email: "octocat@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ta := testutil.NewTestApp().WithStore(s).WithAuth(user.ID, user.Username)
+	ta.App.Get("/api/users/me/invoices", Get)
+	ta.App.Mount()
+
+	get := func(path string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		ta.App.ServeHTTP(w, testutil.MakeRequest(t, http.MethodGet, path, nil, nil))
+		return w
+	}
+
+	testutil.AssertStatusCode(t, get("/api/users/me/invoices"), http.StatusServiceUnavailable)
+
+	// The client is never called for users without a Stripe customer
+	ta.Services.Billing = billing.NewClient("sk_test").WithBaseURL("http://127.0.0.1:0")
+	w := get("/api/users/me/invoices")
+	testutil.AssertStatusCode(t, w, http.StatusOK)
+	response := testutil.ParseResponse[InvoicesResponse](t, w)
+	if len(response.Invoices) != 0 || response.HasMore || response.Dunning.Status != billing.DunningOK {
+		t.Errorf("expected no invoices, got %+v", response)
+	}
+
+	testutil.AssertStatusCode(t, get("/api/users/me/invoices?limit=0"), http.StatusBadRequest)
+	testutil.AssertStatusCode(t, get("/api/users/me/invoices?limit=101"), http.StatusBadRequest)
+}
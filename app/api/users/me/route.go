@@ -1,13 +1,12 @@
 package me
 
 import (
-	"context"
-
 	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
-	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/retention"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
 	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type UserResponse struct {
@@ -16,11 +15,13 @@ type UserResponse struct {
 	Email     string  `json:"email"`
 	AvatarURL *string `json:"avatar_url"`
 	Plan      string  `json:"plan"`
+	// ActivityRetentionDays is how long the plan keeps the activity log
+	ActivityRetentionDays int `json:"activity_retention_days"`
 }
 
 func Get(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
 
 	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
 	if tokenString == "" {
@@ -37,7 +38,7 @@ func Get(c *fuego.Context) error {
 	}
 
 	queries := db.New(pool)
-	user, err := queries.GetUserByID(context.Background(), claims.UserID)
+	user, err := queries.GetUserByID(c.Request.Context(), claims.UserID)
 	if err != nil {
 		return c.JSON(404, map[string]string{"error": "user not found"})
 	}
@@ -48,6 +49,8 @@ func Get(c *fuego.Context) error {
 		Email:     user.Email,
 		AvatarURL: user.AvatarUrl,
 		Plan:      user.Plan,
+
+		ActivityRetentionDays: retention.Retention(cfg, user.Plan),
 	})
 }
 
@@ -59,8 +62,8 @@ type UpdateUserRequest struct {
 // Put updates the current user's profile
 // PUT /api/users/me
 func Put(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
 
 	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
 	if tokenString == "" {
@@ -77,21 +80,21 @@ func Put(c *fuego.Context) error {
 	}
 
 	var req UpdateUserRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(400, map[string]string{"error": "invalid request body"})
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
 	}
 
 	queries := db.New(pool)
 
 	// Get current user
-	user, err := queries.GetUserByID(context.Background(), claims.UserID)
+	user, err := queries.GetUserByID(c.Request.Context(), claims.UserID)
 	if err != nil {
 		return c.JSON(404, map[string]string{"error": "user not found"})
 	}
 
 	// Update email if provided
 	if req.Email != nil && *req.Email != "" {
-		err = queries.UpdateUserEmail(context.Background(), db.UpdateUserEmailParams{
+		err = queries.UpdateUserEmail(c.Request.Context(), db.UpdateUserEmailParams{
 			ID:    user.ID,
 			Email: *req.Email,
 		})
@@ -107,5 +110,7 @@ func Put(c *fuego.Context) error {
 		Email:     user.Email,
 		AvatarURL: user.AvatarUrl,
 		Plan:      user.Plan,
+
+		ActivityRetentionDays: retention.Retention(cfg, user.Plan),
 	})
 }
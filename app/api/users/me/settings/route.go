@@ -0,0 +1,151 @@
+package settings
+
+import (
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/usersettings"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+// SettingsResponse shows the defaults new apps get, the platform's where
+// the user set none
+type SettingsResponse struct {
+	DefaultRegion          string     `json:"default_region"`
+	DefaultSize            string     `json:"default_size"`
+	DefaultHealthCheckPath string     `json:"default_health_check_path"`
+	EmailNotifications     bool       `json:"email_notifications"`
+	UpdatedAt              *time.Time `json:"updated_at,omitempty"`
+}
+
+// UpdateSettingsRequest changes the fields it carries; an empty default
+// goes back to the platform's
+type UpdateSettingsRequest struct {
+	DefaultRegion          *string `json:"default_region,omitempty"`
+	DefaultSize            *string `json:"default_size,omitempty"`
+	DefaultHealthCheckPath *string `json:"default_health_check_path,omitempty"`
+	EmailNotifications     *bool   `json:"email_notifications,omitempty"`
+}
+
+// Get returns the current user's account defaults
+// GET /api/users/me/settings
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	settings, err := usersettings.Get(c.Request.Context(), db.New(pool), userID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to get settings"})
+	}
+
+	return c.JSON(200, toSettingsResponse(settings))
+}
+
+// Put updates the current user's account defaults. They apply to apps
+// created afterwards, existing apps keep theirs.
+// PUT /api/users/me/settings
+func Put(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req UpdateSettingsRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+
+	changes := db.UpsertUserSettingsParams{UserID: userID}
+	if req.DefaultRegion != nil {
+		changes.DefaultRegion = *req.DefaultRegion
+	}
+	if req.DefaultSize != nil {
+		changes.DefaultSize = *req.DefaultSize
+	}
+	if req.DefaultHealthCheckPath != nil {
+		changes.DefaultHealthCheckPath = *req.DefaultHealthCheckPath
+	}
+	if err := usersettings.Validate(changes); err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	ctx := c.Request.Context()
+	queries := db.New(pool)
+
+	current, err := usersettings.Get(ctx, queries, userID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to get settings"})
+	}
+
+	params := db.UpsertUserSettingsParams{
+		UserID:                 userID,
+		DefaultRegion:          current.DefaultRegion,
+		DefaultSize:            current.DefaultSize,
+		DefaultHealthCheckPath: current.DefaultHealthCheckPath,
+		EmailNotifications:     current.EmailNotifications,
+	}
+	if req.DefaultRegion != nil {
+		params.DefaultRegion = changes.DefaultRegion
+	}
+	if req.DefaultSize != nil {
+		params.DefaultSize = changes.DefaultSize
+	}
+	if req.DefaultHealthCheckPath != nil {
+		params.DefaultHealthCheckPath = changes.DefaultHealthCheckPath
+	}
+	if req.EmailNotifications != nil {
+		params.EmailNotifications = *req.EmailNotifications
+	}
+
+	settings, err := queries.UpsertUserSettings(ctx, params)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to save settings"})
+	}
+
+	return c.JSON(200, toSettingsResponse(settings))
+}
+
+func toSettingsResponse(settings db.UserSetting) SettingsResponse {
+	region, size, healthCheckPath := usersettings.AppDefaults(settings, "", "", "")
+	response := SettingsResponse{
+		DefaultRegion:          region,
+		DefaultSize:            size,
+		DefaultHealthCheckPath: healthCheckPath,
+		EmailNotifications:     settings.EmailNotifications,
+	}
+	if !settings.UpdatedAt.IsZero() {
+		response.UpdatedAt = &settings.UpdatedAt
+	}
+	return response
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
@@ -0,0 +1,33 @@
+package settings
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/testutil"
+	"github.com/google/uuid"
+)
+
+func TestPut_Validation(t *testing.T) {
+	ta := testutil.NewTestApp().WithAuth(uuid.New(), "octocat")
+	ta.App.Put("/api/users/me/settings", Put)
+	ta.App.Mount()
+
+	put := func(body any) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		ta.App.ServeHTTP(w, testutil.MakeRequest(t, http.MethodPut, "/api/users/me/settings", body, nil))
+		return w
+	}
+
+	// Invalid defaults are rejected before the database is reached
+	testutil.AssertStatusCode(t, put(map[string]string{"default_region": "nyc"}), http.StatusBadRequest)
+	testutil.AssertStatusCode(t, put(map[string]string{"default_size": "huge"}), http.StatusBadRequest)
+	testutil.AssertStatusCode(t, put(map[string]string{"default_health_check_path": "healthz"}), http.StatusBadRequest)
+
+	// So are misspelled fields, instead of being ignored
+	w := put(map[string]string{"default_regon": "mex"})
+	testutil.AssertStatusCode(t, w, http.StatusBadRequest)
+	testutil.AssertJSONContains(t, w, "error", `invalid request body: unknown field "default_regon"`)
+}
@@ -0,0 +1,146 @@
+package tax
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/billing"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/tax"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type TaxProfileResponse struct {
+	Country      string `json:"country"`
+	PostalCode   string `json:"postal_code"`
+	BusinessName string `json:"business_name"`
+	TaxIDType    string `json:"tax_id_type,omitempty"`
+	TaxID        string `json:"tax_id,omitempty"`
+	// TaxIDStatus is Stripe's verification of the tax ID, once it is on the
+	// user's Stripe customer
+	TaxIDStatus string    `json:"tax_id_status,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Get returns the current user's tax profile, the billing country and tax
+// ID their invoices are taxed under
+// GET /api/users/me/tax
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	profile, err := db.New(pool).GetUserTaxProfile(c.Request.Context(), pgtype.UUID{Bytes: userID, Valid: true})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return c.JSON(404, map[string]string{"error": "tax profile not set"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to get tax profile"})
+	}
+
+	return c.JSON(200, toTaxProfileResponse(profile))
+}
+
+// Put sets the current user's tax profile. Users already billed through
+// Stripe have it applied to their customer right away; the tax ID is
+// verified by Stripe asynchronously.
+// PUT /api/users/me/tax
+func Put(c *fuego.Context) error {
+	svc := services.From(c)
+
+	userID, err := getUserID(c, svc.Config)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	var req tax.Profile
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+	normalized, taxIDType, err := tax.Normalize(req)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	ctx := c.Request.Context()
+	queries := db.New(svc.DB)
+
+	user, err := queries.GetUserByID(ctx, userID)
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "user not found"})
+	}
+	profile, err := queries.UpsertUserTaxProfile(ctx, db.UpsertUserTaxProfileParams{
+		UserID:       pgtype.UUID{Bytes: userID, Valid: true},
+		Country:      normalized.Country,
+		PostalCode:   normalized.PostalCode,
+		BusinessName: normalized.BusinessName,
+		TaxIDType:    taxIDType,
+		TaxID:        normalized.TaxID,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to save tax profile"})
+	}
+
+	if svc.Billing != nil && user.StripeCustomerID != nil {
+		profile, err = tax.Sync(ctx, svc.Billing, queries, *user.StripeCustomerID, profile)
+		if err != nil {
+			return syncError(c, err)
+		}
+	}
+
+	return c.JSON(200, toTaxProfileResponse(profile))
+}
+
+// syncError answers a profile Stripe refused, such as a tax ID failing its
+// format check, with its reason and other Stripe failures as a bad gateway
+func syncError(c *fuego.Context, err error) error {
+	var apiErr *billing.Error
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest {
+		return c.JSON(400, map[string]string{"error": apiErr.Message})
+	}
+	slog.Error("failed to sync tax profile", "error", err)
+	return c.JSON(502, map[string]string{"error": "failed to update billing details"})
+}
+
+func toTaxProfileResponse(profile db.TaxProfile) TaxProfileResponse {
+	return TaxProfileResponse{
+		Country:      profile.Country,
+		PostalCode:   profile.PostalCode,
+		BusinessName: profile.BusinessName,
+		TaxIDType:    profile.TaxIDType,
+		TaxID:        profile.TaxID,
+		TaxIDStatus:  profile.TaxIDStatus,
+		UpdatedAt:    profile.UpdatedAt,
+	}
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
@@ -0,0 +1,158 @@
+package usage
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+type AppUsage struct {
+	App          string            `json:"app"`
+	Labels       map[string]string `json:"labels"`
+	IngressBytes int64             `json:"ingress_bytes"`
+	EgressBytes  int64             `json:"egress_bytes"`
+	Requests     int64             `json:"requests"`
+}
+
+// GroupUsage is the usage of the apps sharing a value of the group_by label;
+// apps without the label are grouped under an empty value
+type GroupUsage struct {
+	Value        string `json:"value"`
+	Apps         int    `json:"apps"`
+	IngressBytes int64  `json:"ingress_bytes"`
+	EgressBytes  int64  `json:"egress_bytes"`
+	Requests     int64  `json:"requests"`
+}
+
+type UsageResponse struct {
+	From         time.Time  `json:"from"`
+	To           time.Time  `json:"to"`
+	IngressBytes int64      `json:"ingress_bytes"`
+	EgressBytes  int64      `json:"egress_bytes"`
+	Requests     int64      `json:"requests"`
+	Apps         []AppUsage `json:"apps"`
+
+	GroupBy string       `json:"group_by,omitempty"`
+	Groups  []GroupUsage `json:"groups,omitempty"`
+
+	// Credits is the account's credit balance in cents, consumed by its
+	// invoices before the card is charged
+	Credits int64 `json:"credits"`
+}
+
+// Get returns the metered bandwidth of the current user's apps over a
+// billing period, the current calendar month by default. Each app carries its
+// labels; group_by sums usage by the value of one label for chargeback. The
+// account's credit balance comes along.
+// GET /api/users/me/usage?from=2026-05-01T00:00:00Z&to=2026-06-01T00:00:00Z&group_by=team
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	if v := c.Query("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			return c.JSON(400, map[string]string{"error": "from must be an RFC 3339 timestamp"})
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			return c.JSON(400, map[string]string{"error": "to must be an RFC 3339 timestamp"})
+		}
+	}
+	if !to.After(from) {
+		return c.JSON(400, map[string]string{"error": "to must be after from"})
+	}
+
+	queries := db.New(pool)
+	rows, err := queries.ListUserBandwidthByApp(c.Request.Context(), db.ListUserBandwidthByAppParams{
+		UserID:     userID,
+		PeriodFrom: from,
+		PeriodTo:   to,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to get usage"})
+	}
+
+	balance, err := queries.GetCreditBalance(c.Request.Context(), userID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to get credits"})
+	}
+
+	groupBy := c.Query("group_by")
+	groups := map[string]*GroupUsage{}
+
+	response := UsageResponse{From: from, To: to, Apps: make([]AppUsage, 0, len(rows)), GroupBy: groupBy, Credits: balance}
+	for _, row := range rows {
+		labels := map[string]string{}
+		_ = json.Unmarshal(row.AppLabels, &labels)
+
+		response.IngressBytes += row.IngressBytes
+		response.EgressBytes += row.EgressBytes
+		response.Requests += row.Requests
+		response.Apps = append(response.Apps, AppUsage{
+			App:          row.AppName,
+			Labels:       labels,
+			IngressBytes: row.IngressBytes,
+			EgressBytes:  row.EgressBytes,
+			Requests:     row.Requests,
+		})
+
+		if groupBy == "" {
+			continue
+		}
+		group, ok := groups[labels[groupBy]]
+		if !ok {
+			group = &GroupUsage{Value: labels[groupBy]}
+			groups[group.Value] = group
+		}
+		group.Apps++
+		group.IngressBytes += row.IngressBytes
+		group.EgressBytes += row.EgressBytes
+		group.Requests += row.Requests
+	}
+
+	if groupBy != "" {
+		response.Groups = make([]GroupUsage, 0, len(groups))
+		for _, group := range groups {
+			response.Groups = append(response.Groups, *group)
+		}
+		sort.Slice(response.Groups, func(i, j int) bool {
+			return response.Groups[i].Value < response.Groups[j].Value
+		})
+	}
+
+	return c.JSON(200, response)
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
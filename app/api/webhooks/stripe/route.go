@@ -0,0 +1,175 @@
+// Package stripe receives Stripe webhook events.
+package stripe
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/billing"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/credits"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/events"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/suspension"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// maxPayloadBytes bounds webhook payloads; Stripe events are a few KB
+const maxPayloadBytes = 1 << 20
+
+// eventSubscribed is published when a checkout subscribes a user to a plan
+const eventSubscribed = "billing.subscribed"
+
+// Post receives Stripe webhook events signed with STRIPE_WEBHOOK_SECRET and
+// publishes failed and successful invoice payments of known customers to the
+// event bus, where they drive the suspension pipeline. Drafted invoices get
+// Stripe Tax turned on and consume the customer's account credits, and
+// completed checkouts subscribe users to their plan. Other events are
+// acknowledged and ignored.
+// POST /api/webhooks/stripe
+func Post(c *fuego.Context) error {
+	svc := services.From(c)
+	cfg := svc.Config
+	pool := svc.DB
+
+	if cfg.StripeWebhookSecret == "" {
+		return c.JSON(503, map[string]string{"error": "stripe webhooks are not configured"})
+	}
+
+	payload, err := io.ReadAll(io.LimitReader(c.Request.Body, maxPayloadBytes))
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "failed to read payload"})
+	}
+	if err := billing.VerifySignature(payload, c.Header("Stripe-Signature"), cfg.StripeWebhookSecret, time.Now()); err != nil {
+		return c.JSON(400, map[string]string{"error": "invalid signature"})
+	}
+
+	event, err := billing.ParseWebhookEvent(payload)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "invalid event"})
+	}
+
+	var eventType string
+	switch event.Type {
+	case billing.EventInvoicePaymentFailed:
+		eventType = suspension.EventPaymentFailed
+	case billing.EventInvoicePaid:
+		eventType = suspension.EventPaymentSucceeded
+	case billing.EventInvoiceCreated:
+		// Handled below, once the customer is known
+	case billing.EventCheckoutSessionCompleted:
+		return checkoutCompleted(c, db.New(pool), event)
+	default:
+		return c.JSON(200, map[string]bool{"received": true})
+	}
+
+	queries := db.New(pool)
+	user, err := queries.GetUserByStripeCustomerID(c.Request.Context(), &event.Customer)
+	if errors.Is(err, pgx.ErrNoRows) {
+		slog.Warn("stripe event for unknown customer", "event", event.ID, "customer", event.Customer)
+		return c.JSON(200, map[string]bool{"received": true})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to look up customer"})
+	}
+
+	invoice := event.Invoice
+	if event.Type == billing.EventInvoiceCreated {
+		return invoiceCreated(c, svc, user, event)
+	}
+
+	details := map[string]any{
+		"stripe_event_id":  event.ID,
+		"customer":         event.Customer,
+		"invoice_id":       invoice.ID,
+		"amount_due":       invoice.AmountDue,
+		"amount_remaining": invoice.AmountRemaining,
+		"currency":         invoice.Currency,
+		"attempts":         invoice.Attempts,
+	}
+	if invoice.NextAttempt != nil {
+		details["next_attempt"] = *invoice.NextAttempt
+	}
+	// Failing to publish is answered with an error for Stripe to retry
+	if err := events.Publish(c.Request.Context(), queries, events.Event{
+		Type:    eventType,
+		UserID:  user.ID,
+		Payload: details,
+	}); err != nil {
+		slog.Error("failed to publish stripe event", "event", event.ID, "error", err)
+		return c.JSON(500, map[string]string{"error": "failed to record event"})
+	}
+
+	return c.JSON(200, map[string]bool{"received": true})
+}
+
+// invoiceCreated prepares a drafted invoice before Stripe finalizes and
+// charges it: Stripe Tax computes its tax, for subscriptions started before
+// taxes were collected, and the customer's account credits are applied.
+// Stripe retries deliveries it got no 2xx for, so failures are answered
+// with an error.
+func invoiceCreated(c *fuego.Context, svc *services.Services, user db.User, event billing.WebhookEvent) error {
+	invoice := event.Invoice
+	if svc.Billing != nil && invoice.Status == "draft" && !invoice.AutomaticTax {
+		if err := svc.Billing.EnableInvoiceTax(c.Request.Context(), invoice.ID); err != nil {
+			slog.Error("failed to enable invoice tax", "event", event.ID, "invoice", invoice.ID, "error", err)
+			return c.JSON(500, map[string]string{"error": "failed to enable tax"})
+		}
+	}
+
+	applied, err := credits.New(svc.DB, svc.Billing).Apply(c.Request.Context(), user.ID, event.Customer, invoice)
+	if err != nil {
+		slog.Error("failed to apply credits", "event", event.ID, "invoice", invoice.ID, "error", err)
+		return c.JSON(500, map[string]string{"error": "failed to apply credits"})
+	}
+	return c.JSON(200, map[string]any{"received": true, "credits_applied": applied})
+}
+
+// checkoutCompleted subscribes the user a checkout was started for to its
+// plan and links them to the Stripe customer
+func checkoutCompleted(c *fuego.Context, queries *db.Queries, event billing.WebhookEvent) error {
+	userID, err := uuid.Parse(event.ClientReferenceID)
+	plan := event.Metadata["plan"]
+	if err != nil || plan == "" || event.Customer == "" {
+		slog.Warn("stripe checkout not started by the platform", "event", event.ID)
+		return c.JSON(200, map[string]bool{"received": true})
+	}
+
+	ctx := c.Request.Context()
+	user, err := queries.GetUserByID(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		slog.Warn("stripe checkout for unknown user", "event", event.ID, "user", userID)
+		return c.JSON(200, map[string]bool{"received": true})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to look up user"})
+	}
+
+	if _, err := queries.UpdateUserPlan(ctx, db.UpdateUserPlanParams{
+		ID:               user.ID,
+		Plan:             plan,
+		StripeCustomerID: &event.Customer,
+	}); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to update plan"})
+	}
+	if err := events.Publish(ctx, queries, events.Event{
+		Type:    eventSubscribed,
+		UserID:  user.ID,
+		Message: fmt.Sprintf("You are subscribed to the %s plan.", plan),
+		Payload: map[string]any{
+			"stripe_event_id": event.ID,
+			"customer":        event.Customer,
+			"plan":            plan,
+			"previous_plan":   user.Plan,
+		},
+	}); err != nil {
+		slog.Error("failed to publish stripe event", "event", event.ID, "error", err)
+		return c.JSON(500, map[string]string{"error": "failed to record event"})
+	}
+	return c.JSON(200, map[string]bool{"received": true})
+}
@@ -0,0 +1,45 @@
+package stripe
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/stripefake"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/testutil"
+)
+
+func TestPost(t *testing.T) {
+	ta := testutil.NewTestApp()
+	ta.App.Post("/api/webhooks/stripe", Post)
+	ta.App.Mount()
+
+	send := func(event stripefake.Event, signature func([]byte) string) *httptest.ResponseRecorder {
+		t.Helper()
+		payload, err := json.Marshal(event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/api/webhooks/stripe", bytes.NewReader(payload))
+		req.Header.Set("Stripe-Signature", signature(payload))
+		w := httptest.NewRecorder()
+		ta.App.ServeHTTP(w, req)
+		return w
+	}
+	signed := func(secret string) func([]byte) string {
+		return func(payload []byte) string { return stripefake.Sign(payload, secret, time.Now()) }
+	}
+	sub := stripefake.Subscription{Customer: "cus_1", Price: "price_pro_monthly", Amount: 2900}
+
+	testutil.AssertStatusCode(t, send(stripefake.New(stripefake.InvoicePaymentFailed, sub, time.Now()), signed("whsec_test")), http.StatusServiceUnavailable)
+
+	ta.Services.Config.StripeWebhookSecret = "whsec_test"
+	testutil.AssertStatusCode(t, send(stripefake.New(stripefake.InvoicePaymentFailed, sub, time.Now()), signed("whsec_other")), http.StatusBadRequest)
+	testutil.AssertStatusCode(t, send(stripefake.New(stripefake.InvoicePaymentFailed, sub, time.Now()), func([]byte) string { return "" }), http.StatusBadRequest)
+
+	// Events the platform does not handle are acknowledged
+	testutil.AssertStatusCode(t, send(stripefake.New(stripefake.CustomerSubscriptionUpdated, sub, time.Now()), signed("whsec_test")), http.StatusOK)
+}
@@ -0,0 +1,149 @@
+package zone
+
+import (
+	"encoding/json"
+	"net/netip"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/app/api/zones"
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/dnszone"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/domainverify"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type ZoneDetailResponse struct {
+	zones.ZoneResponse
+	Domains []ZoneDomain `json:"domains"`
+	// Records are what the platform's nameservers serve for the zone
+	Records []domainverify.Record `json:"records"`
+}
+
+// ZoneDomain is a domain of one of the user's apps in the zone
+type ZoneDomain struct {
+	Domain   string `json:"domain"`
+	App      string `json:"app"`
+	Verified bool   `json:"verified"`
+}
+
+// Get returns a zone with the domains in it and the records served for them
+// GET /api/zones/{zone}
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	zone, err := queries.GetDNSZoneByName(c.Request.Context(), dnszone.Normalize(c.Param("zone")))
+	if err != nil || zone.UserID != userID {
+		return c.JSON(404, map[string]string{"error": "zone not found"})
+	}
+
+	domains, err := queries.ListDomainsInDNSZone(c.Request.Context(), db.ListDomainsInDNSZoneParams{
+		UserID: userID,
+		Zone:   zone.Zone,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list the zone's domains"})
+	}
+
+	response := ZoneDetailResponse{
+		ZoneResponse: zones.ToZoneResponse(cfg, zone),
+		Domains:      make([]ZoneDomain, len(domains)),
+		Records:      []domainverify.Record{},
+	}
+	for i, d := range domains {
+		response.Domains[i] = ZoneDomain{Domain: d.Domain, App: d.AppName, Verified: d.Verified}
+	}
+
+	if zone.Status == dnszone.StatusActive {
+		table, err := dnszone.Table(c.Request.Context(), cfg, queries)
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "failed to load the zone's records"})
+		}
+		response.Records = append(response.Records, dnszone.Delegation(cfg, zone.Zone)...)
+		response.Records = append(response.Records, table[zone.Zone]...)
+	}
+
+	return c.JSON(200, response)
+}
+
+// Delete removes a zone. Its records stop being served, so the domains in it
+// must be removed first.
+// DELETE /api/zones/{zone}
+func Delete(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	zone, err := queries.GetDNSZoneByName(c.Request.Context(), dnszone.Normalize(c.Param("zone")))
+	if err != nil || zone.UserID != userID {
+		return c.JSON(404, map[string]string{"error": "zone not found"})
+	}
+
+	domains, err := queries.ListDomainsInDNSZone(c.Request.Context(), db.ListDomainsInDNSZoneParams{
+		UserID: userID,
+		Zone:   zone.Zone,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list the zone's domains"})
+	}
+	if zone.Status == dnszone.StatusActive && len(domains) > 0 {
+		return c.JSON(409, map[string]string{"error": "remove the domains in the zone before deleting it"})
+	}
+
+	if err := queries.DeleteDNSZone(c.Request.Context(), zone.ID); err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to delete zone"})
+	}
+
+	details, _ := json.Marshal(map[string]any{
+		"zone": zone.Zone,
+	})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		Action:    "dns_zone.deleted",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(200, map[string]string{"message": "zone deleted"})
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
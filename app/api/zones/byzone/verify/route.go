@@ -0,0 +1,104 @@
+package verify
+
+import (
+	"context"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/app/api/zones"
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/dnszone"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/domainrecords"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/domainverify"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/events"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+type VerifyResponse struct {
+	zones.ZoneResponse
+	Delegated bool   `json:"delegated"`
+	Message   string `json:"message"`
+	// Found lists the nameservers public DNS currently delegates the zone to
+	Found []string `json:"found,omitempty"`
+	// VerifiedDomains counts the domains in the zone verified by activating it
+	VerifiedDomains int64 `json:"verified_domains,omitempty"`
+}
+
+// Post checks the delegation of a pending zone right away, activating it
+// when public DNS delegates it to the platform's nameservers
+// POST /api/zones/{zone}/verify
+func Post(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	queries := db.New(pool)
+	zone, err := queries.GetDNSZoneByName(c.Request.Context(), dnszone.Normalize(c.Param("zone")))
+	if err != nil || zone.UserID != userID {
+		return c.JSON(404, map[string]string{"error": "zone not found"})
+	}
+	if zone.Status == dnszone.StatusActive {
+		return c.JSON(200, VerifyResponse{
+			ZoneResponse: zones.ToZoneResponse(cfg, zone),
+			Delegated:    true,
+			Message:      "zone already delegated",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	checker := domainrecords.NewChecker(domainverify.PublicResolvers)
+	delegated, found := dnszone.Delegated(ctx, checker, cfg, zone.Zone)
+	if !delegated {
+		_ = queries.MarkDNSZoneChecked(c.Request.Context(), zone.ID)
+		message := "no NS records for the zone found yet; DNS changes can take a while to propagate"
+		if len(found) > 0 {
+			message = "the zone is delegated to other nameservers; its NS records must name only the platform's"
+		}
+		return c.JSON(200, VerifyResponse{
+			ZoneResponse: zones.ToZoneResponse(cfg, zone),
+			Message:      message,
+			Found:        found,
+		})
+	}
+
+	zone, verified, err := dnszone.Activate(c.Request.Context(), queries, zone)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to activate zone"})
+	}
+	_ = events.Publish(c.Request.Context(), queries, dnszone.DelegatedEvent(zone, verified))
+
+	return c.JSON(200, VerifyResponse{
+		ZoneResponse:    zones.ToZoneResponse(cfg, zone),
+		Delegated:       true,
+		Message:         "zone delegated; the platform now manages its records and certificates",
+		Found:           found,
+		VerifiedDomains: verified,
+	})
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
@@ -0,0 +1,183 @@
+// Package zones manages the subdomain zones users delegate to the platform's
+// nameservers, after which the platform serves all records of their apps'
+// domains in the zone and issues their certificates.
+package zones
+
+import (
+	"context"
+	"encoding/json"
+	"net/netip"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/dnszone"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/domainverify"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/reqbody"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type CreateZoneRequest struct {
+	// Zone is the subdomain to delegate, such as apps.example.com
+	Zone string `json:"zone"`
+}
+
+type ZoneResponse struct {
+	ID     string `json:"id"`
+	Zone   string `json:"zone"`
+	Status string `json:"status"`
+	// Nameservers are the platform's nameservers the zone is delegated to
+	Nameservers []string `json:"nameservers"`
+	// Delegation are the NS records to publish in the parent zone, set
+	// until the delegation is seen
+	Delegation  []domainverify.Record `json:"delegation,omitempty"`
+	CheckedAt   *time.Time            `json:"checked_at,omitempty"`
+	DelegatedAt *time.Time            `json:"delegated_at,omitempty"`
+	CreatedAt   time.Time             `json:"created_at"`
+}
+
+// Get lists the user's zones
+// GET /api/zones
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+
+	zones, err := db.New(pool).ListDNSZonesByUser(c.Request.Context(), userID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to list zones"})
+	}
+
+	response := make([]ZoneResponse, len(zones))
+	for i, z := range zones {
+		response[i] = ToZoneResponse(cfg, z)
+	}
+
+	return c.JSON(200, response)
+}
+
+// Post adds a zone to delegate, returning the NS records to publish for it.
+// The zone is checked every 15 minutes until the delegation is seen, or
+// right away with POST /api/zones/{zone}/verify.
+// POST /api/zones
+// Body: { "zone": "apps.example.com" }
+func Post(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+
+	userID, err := getUserID(c, cfg)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": "unauthorized"})
+	}
+	if len(cfg.Nameservers) == 0 {
+		return c.JSON(503, map[string]string{"error": "zone delegation is not available on this platform"})
+	}
+
+	var req CreateZoneRequest
+	if err := reqbody.Bind(c, &req); err != nil {
+		return reqbody.Reject(c, err)
+	}
+	req.Zone = dnszone.Normalize(req.Zone)
+	if req.Zone == "" {
+		return c.JSON(400, map[string]string{"error": "zone is required"})
+	}
+	if err := dnszone.Validate(cfg, req.Zone); err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	queries := db.New(pool)
+	overlapping, err := queries.ListOverlappingDNSZones(c.Request.Context(), req.Zone)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to check existing zones"})
+	}
+	for _, existing := range overlapping {
+		if !releaseStaleClaim(c.Request.Context(), queries, existing, userID) {
+			return c.JSON(409, map[string]string{"error": "zone overlaps " + existing.Zone + ", which is already delegated"})
+		}
+	}
+
+	zone, err := queries.CreateDNSZone(c.Request.Context(), db.CreateDNSZoneParams{
+		UserID: userID,
+		Zone:   req.Zone,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "failed to create zone"})
+	}
+
+	details, _ := json.Marshal(map[string]any{
+		"zone": zone.Zone,
+	})
+	_, _ = queries.CreateActivityLog(c.Request.Context(), db.CreateActivityLogParams{
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+		Action:    "dns_zone.created",
+		Details:   details,
+		IpAddress: clientIP(c),
+	})
+
+	return c.JSON(201, ToZoneResponse(cfg, zone))
+}
+
+// releaseStaleClaim deletes another user's zone they never delegated within
+// domainverify.ClaimTTL, so squatting cannot block the real owner. Active
+// zones and the user's own zones are never released.
+func releaseStaleClaim(ctx context.Context, queries *db.Queries, existing db.DnsZone, userID uuid.UUID) bool {
+	if existing.Status != dnszone.StatusPending || existing.UserID == userID || !domainverify.ClaimExpired(existing.CreatedAt, time.Now()) {
+		return false
+	}
+	return queries.DeleteDNSZone(ctx, existing.ID) == nil
+}
+
+// ToZoneResponse renders a zone with the records delegating it
+func ToZoneResponse(cfg *config.Config, z db.DnsZone) ZoneResponse {
+	resp := ZoneResponse{
+		ID:          z.ID.String(),
+		Zone:        z.Zone,
+		Status:      z.Status,
+		Nameservers: cfg.Nameservers,
+		CreatedAt:   z.CreatedAt,
+	}
+	if z.Status == dnszone.StatusPending {
+		resp.Delegation = dnszone.Delegation(cfg, z.Zone)
+	}
+	if z.CheckedAt.Valid {
+		resp.CheckedAt = &z.CheckedAt.Time
+	}
+	if z.DelegatedAt.Valid {
+		resp.DelegatedAt = &z.DelegatedAt.Time
+	}
+	return resp
+}
+
+func clientIP(c *fuego.Context) *netip.Addr {
+	if addr, ok := c.Get(clientip.ContextKey).(netip.Addr); ok && addr.IsValid() {
+		return &addr
+	}
+	return nil
+}
+
+func getUserID(c *fuego.Context, cfg *config.Config) (uuid.UUID, error) {
+	if userID, ok := c.Get("user_id").(uuid.UUID); ok {
+		return userID, nil
+	}
+
+	tokenString := auth.ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return claims.UserID, nil
+}
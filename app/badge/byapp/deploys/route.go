@@ -0,0 +1,29 @@
+// Package deploys serves the deploy count badges of apps.
+package deploys
+
+import (
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/badge"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+// Get renders an SVG badge with how many times an app was deployed, for
+// READMEs. It needs no authentication and is cached for a minute.
+// GET /badge/{app}/deploys.svg
+func Get(c *fuego.Context) error {
+	svc := services.From(c)
+
+	appID, err := uuid.Parse(c.Param("app"))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	app, err := svc.Store.Apps.Get(c.Request.Context(), appID)
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	message, color := badge.Deploys(app.DeploymentCount)
+	return badge.Serve(c, badge.Render("deploys", message, color))
+}
@@ -0,0 +1,29 @@
+// Package status serves the status badges of apps.
+package status
+
+import (
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/badge"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+// Get renders an SVG badge with the live status of an app, for READMEs.
+// It needs no authentication and is cached for a minute.
+// GET /badge/{app}/status.svg
+func Get(c *fuego.Context) error {
+	svc := services.From(c)
+
+	appID, err := uuid.Parse(c.Param("app"))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	app, err := svc.Store.Apps.Get(c.Request.Context(), appID)
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "app not found"})
+	}
+
+	message, color := badge.Status(app.Status)
+	return badge.Serve(c, badge.Render("status", message, color))
+}
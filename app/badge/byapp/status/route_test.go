@@ -0,0 +1,49 @@
+package status
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/store"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/testutil"
+)
+
+func TestGet(t *testing.T) {
+	s := store.NewMemory()
+	user := testutil.SeedUser(t, s, "alice")
+	app := testutil.SeedApp(t, s, user.ID, "api")
+
+	ta := testutil.NewTestApp().WithStore(s)
+	ta.App.Get("/badge/{app}/status.svg", Get)
+	ta.App.Mount()
+
+	get := func(path string, headers map[string]string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		ta.App.ServeHTTP(w, testutil.MakeRequest(t, http.MethodGet, path, nil, headers))
+		return w
+	}
+
+	w := get("/badge/"+app.ID.String()+"/status.svg", nil)
+	testutil.AssertStatusCode(t, w, http.StatusOK)
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "image/svg+xml") {
+		t.Errorf("expected an SVG, got %s", ct)
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "public, max-age=60" {
+		t.Errorf("unexpected Cache-Control %q", cc)
+	}
+	if !strings.Contains(w.Body.String(), string(app.Status)) {
+		t.Errorf("expected the app's status in the badge, got %s", w.Body.String())
+	}
+
+	// A client that has the badge is told it did not change
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected an ETag")
+	}
+	testutil.AssertStatusCode(t, get("/badge/"+app.ID.String()+"/status.svg", map[string]string{"If-None-Match": etag}), http.StatusNotModified)
+
+	testutil.AssertStatusCode(t, get("/badge/not-a-uuid/status.svg", nil), http.StatusNotFound)
+	testutil.AssertStatusCode(t, get("/badge/00000000-0000-0000-0000-000000000000/status.svg", nil), http.StatusNotFound)
+}
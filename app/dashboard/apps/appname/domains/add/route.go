@@ -0,0 +1,50 @@
+package add
+
+import (
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+// Get renders the first step of the domain setup wizard. The form creates
+// the domain through POST /api/apps/{name}/domains and continues on the
+// domain's own page with its DNS records.
+// GET /dashboard/apps/{name}/domains/add
+func Get(c *fuego.Context) error {
+	svc := services.From(c)
+	cfg := svc.Config
+	appName := c.Param("name")
+
+	userID, userName, err := getUserInfo(c, cfg)
+	if err != nil {
+		return c.Redirect("/login", 302)
+	}
+
+	app, err := svc.Store.Apps.GetByName(c.Request.Context(), userID, appName)
+	if err != nil {
+		return c.Redirect("/dashboard/apps", 302)
+	}
+
+	return fuego.TemplComponent(c, 200, Page(AddDomainData{
+		UserName:      userName,
+		AppName:       app.Name,
+		AppHost:       app.Name + "." + cfg.AppsDomainSuffix,
+		ApexAvailable: len(cfg.IngressIPsForRegion(app.Region)) > 0,
+	}))
+}
+
+func getUserInfo(c *fuego.Context, cfg *config.Config) (uuid.UUID, string, error) {
+	tokenString := c.Cookie("access_token")
+	if tokenString == "" {
+		tokenString = auth.ExtractBearerToken(c.Header("Authorization"))
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	return claims.UserID, claims.Username, nil
+}
@@ -0,0 +1,51 @@
+package add
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/store"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/testutil"
+)
+
+func TestGet(t *testing.T) {
+	s := store.NewMemory()
+	alice := testutil.SeedUser(t, s, "alice")
+	bob := testutil.SeedUser(t, s, "bob")
+	testutil.SeedApp(t, s, alice.ID, "shop")
+
+	ta := testutil.NewTestApp().WithStore(s)
+	ta.App.Get("/dashboard/apps/{name}/domains/add", Get)
+	ta.App.Mount()
+
+	tests := []struct {
+		name     string
+		token    string
+		status   int
+		location string
+	}{
+		{"owner", testutil.GenerateTestToken(t, ta.Config, alice.ID, alice.Username), http.StatusOK, ""},
+		{"another user", testutil.GenerateTestToken(t, ta.Config, bob.ID, bob.Username), http.StatusFound, "/dashboard/apps"},
+		{"no session", "", http.StatusFound, "/login"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := testutil.MakeRequest(t, http.MethodGet, "/dashboard/apps/shop/domains/add", nil, nil)
+			if tt.token != "" {
+				req.AddCookie(&http.Cookie{Name: "access_token", Value: tt.token})
+			}
+			w := httptest.NewRecorder()
+			ta.App.ServeHTTP(w, req)
+
+			testutil.AssertStatusCode(t, w, tt.status)
+			if location := w.Header().Get("Location"); location != tt.location {
+				t.Errorf("expected location %q, got %q", tt.location, location)
+			}
+			if tt.status == http.StatusOK && !strings.Contains(w.Body.String(), "shop.test.nexo.build") {
+				t.Errorf("expected the wizard to show the app's host, got %s", w.Body.String())
+			}
+		})
+	}
+}
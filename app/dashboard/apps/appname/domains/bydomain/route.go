@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"strings"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/domainrecords"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/domainverify"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+// Get renders the remaining steps of the domain setup wizard: proving
+// ownership with the TXT record, routing traffic with the DNS records and
+// waiting for the certificate
+// GET /dashboard/apps/{name}/domains/{domain}
+func Get(c *fuego.Context) error {
+	svc := services.From(c)
+	cfg := svc.Config
+	appName := c.Param("name")
+
+	userID, userName, err := getUserInfo(c, cfg)
+	if err != nil {
+		return c.Redirect("/login", 302)
+	}
+
+	app, err := svc.Store.Apps.GetByName(c.Request.Context(), userID, appName)
+	if err != nil {
+		return c.Redirect("/dashboard/apps", 302)
+	}
+
+	d, err := svc.Store.Domains.GetByName(c.Request.Context(), strings.ToLower(c.Param("domain")))
+	if err != nil || d.AppID != app.ID {
+		return c.Redirect("/dashboard/apps/"+app.Name+"?tab=domains", 302)
+	}
+
+	data := DomainPageData{
+		UserName:     userName,
+		AppName:      app.Name,
+		Domain:       d.Domain,
+		Verified:     d.Verified,
+		Verification: *domainverify.Challenge(d.Domain, d.VerificationToken),
+		DNSMode:      d.DnsMode,
+		SSLStatus:    d.SslStatus,
+	}
+	data.DNSRecords, _ = domainrecords.Records(d.Domain, domainrecords.Mode(d.DnsMode), app.Name+"."+cfg.AppsDomainSuffix, cfg.IngressIPsForRegion(app.Region))
+	if d.SslExpiresAt.Valid {
+		data.SSLExpiresAt = &d.SslExpiresAt.Time
+	}
+	if d.SslError != nil {
+		data.SSLError = *d.SslError
+	}
+
+	return fuego.TemplComponent(c, 200, Page(data))
+}
+
+func getUserInfo(c *fuego.Context, cfg *config.Config) (uuid.UUID, string, error) {
+	tokenString := c.Cookie("access_token")
+	if tokenString == "" {
+		tokenString = auth.ExtractBearerToken(c.Header("Authorization"))
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	return claims.UserID, claims.Username, nil
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/store"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/testutil"
+)
+
+func TestGet(t *testing.T) {
+	s := store.NewMemory()
+	alice := testutil.SeedUser(t, s, "alice")
+	shop := testutil.SeedApp(t, s, alice.ID, "shop")
+	blog := testutil.SeedApp(t, s, alice.ID, "blog")
+	testutil.SeedDomain(t, s, shop.ID, "shop.example.com")
+	testutil.SeedDomain(t, s, blog.ID, "blog.example.com")
+
+	ta := testutil.NewTestApp().WithStore(s)
+	ta.App.Get("/dashboard/apps/{name}/domains/{domain}", Get)
+	ta.App.Mount()
+	token := testutil.GenerateTestToken(t, ta.Config, alice.ID, alice.Username)
+
+	tests := []struct {
+		name     string
+		path     string
+		status   int
+		location string
+	}{
+		{"domain of the app", "/dashboard/apps/shop/domains/Shop.Example.com", http.StatusOK, ""},
+		{"domain of another app", "/dashboard/apps/shop/domains/blog.example.com", http.StatusFound, "/dashboard/apps/shop?tab=domains"},
+		{"unknown domain", "/dashboard/apps/shop/domains/missing.example.com", http.StatusFound, "/dashboard/apps/shop?tab=domains"},
+		{"unknown app", "/dashboard/apps/missing/domains/shop.example.com", http.StatusFound, "/dashboard/apps"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := testutil.MakeRequest(t, http.MethodGet, tt.path, nil, nil)
+			req.AddCookie(&http.Cookie{Name: "access_token", Value: token})
+			w := httptest.NewRecorder()
+			ta.App.ServeHTTP(w, req)
+
+			testutil.AssertStatusCode(t, w, tt.status)
+			if location := w.Header().Get("Location"); location != tt.location {
+				t.Errorf("expected location %q, got %q", tt.location, location)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			body := w.Body.String()
+			if !strings.Contains(body, "shop.example.com") || !strings.Contains(body, "shop.test.nexo.build") {
+				t.Errorf("expected the domain and the CNAME target, got %s", body)
+			}
+		})
+	}
+}
@@ -1,21 +1,21 @@
 package name
 
 import (
-	"context"
-
 	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/domainrecords"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/provenance"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
 	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // Get renders the app detail page
 // GET /apps/{name}
 func Get(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
 	appName := c.Param("name")
 	activeTab := c.Query("tab")
 
@@ -25,7 +25,7 @@ func Get(c *fuego.Context) error {
 	}
 
 	queries := db.New(pool)
-	app, err := queries.GetAppByName(context.Background(), db.GetAppByNameParams{
+	app, err := queries.GetAppByName(c.Request.Context(), db.GetAppByNameParams{
 		UserID: userID,
 		Name:   appName,
 	})
@@ -34,20 +34,27 @@ func Get(c *fuego.Context) error {
 	}
 
 	// Get deployments
-	deployments, _ := queries.ListDeploymentsByApp(context.Background(), db.ListDeploymentsByAppParams{
+	deployments, _ := queries.ListDeploymentsByApp(c.Request.Context(), db.ListDeploymentsByAppParams{
 		AppID:  app.ID,
 		Limit:  50,
 		Offset: 0,
 	})
 
+	// Get the CI runs deployments came from
+	records, _ := queries.ListDeploymentProvenanceByApp(c.Request.Context(), app.ID)
+	provenances := make(map[uuid.UUID]provenance.Provenance, len(records))
+	for _, r := range records {
+		provenances[r.DeploymentID] = provenance.FromRecord(r)
+	}
+
 	// Get domains
-	domains, _ := queries.ListDomainsByApp(context.Background(), app.ID)
+	domains, _ := queries.ListDomainsByApp(c.Request.Context(), app.ID)
 
 	// Convert to template data
 	appData := AppData{
 		ID:              app.ID.String(),
 		Name:            app.Name,
-		Status:          app.Status,
+		Status:          string(app.Status),
 		Region:          app.Region,
 		Size:            app.Size,
 		DeploymentCount: int(app.DeploymentCount),
@@ -77,21 +84,30 @@ func Get(c *fuego.Context) error {
 		if d.ReadyAt.Valid {
 			dd.ReadyAt = &d.ReadyAt.Time
 		}
+		dd.DeployedBy = d.DeployedBy
+		if p, ok := provenances[d.ID]; ok {
+			dd.DeployedBy = p.DeployedBy()
+			dd.FromCI = true
+			dd.RunURL = p.RunURL
+			dd.Verified = p.Verified
+		}
 		deploymentData[i] = dd
 	}
 
 	domainData := make([]DomainData, len(domains))
 	for i, d := range domains {
 		dd := DomainData{
-			ID:        d.ID.String(),
-			Domain:    d.Domain,
-			Verified:  d.Verified,
-			SSLStatus: d.SslStatus,
-			CreatedAt: d.CreatedAt,
+			ID:                d.ID.String(),
+			Domain:            d.Domain,
+			Verified:          d.Verified,
+			SSLStatus:         d.SslStatus,
+			CreatedAt:         d.CreatedAt,
+			VerificationToken: d.VerificationToken,
 		}
 		if d.VerifiedAt.Valid {
 			dd.VerifiedAt = &d.VerifiedAt.Time
 		}
+		dd.DNSRecords, _ = domainrecords.Records(d.Domain, domainrecords.Mode(d.DnsMode), app.Name+"."+cfg.AppsDomainSuffix, cfg.IngressIPsForRegion(app.Region))
 		domainData[i] = dd
 	}
 
@@ -108,6 +124,8 @@ func Get(c *fuego.Context) error {
 		switch activeTab {
 		case "deployments":
 			return fuego.TemplComponent(c, 200, DeploymentsTab(appData.Name, deploymentData))
+		case "logs":
+			return fuego.TemplComponent(c, 200, LogsTab(appData.Name))
 		case "domains":
 			return fuego.TemplComponent(c, 200, DomainsTab(appData.Name, domainData))
 		case "settings":
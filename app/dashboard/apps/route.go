@@ -1,30 +1,40 @@
 package apps
 
 import (
-	"context"
+	"strings"
 
 	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appmeta"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
 	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// Get renders the apps list, filtered by ?search= like GET /api/apps
+// GET /dashboard/apps
 func Get(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	svc := services.From(c)
+	cfg := svc.Config
 
 	userID, userName, err := getUserInfo(c, cfg)
 	if err != nil {
 		return c.Redirect("/login", 302)
 	}
 
-	queries := db.New(pool)
-	apps, err := queries.ListAppsByUser(context.Background(), db.ListAppsByUserParams{
-		UserID: userID,
-		Limit:  100,
-		Offset: 0,
+	search := strings.TrimSpace(c.Query("search"))
+	if len(search) > 100 {
+		search = search[:100]
+	}
+
+	apps, err := svc.Store.Apps.Search(c.Request.Context(), db.SearchAppsByUserParams{
+		UserID:  userID,
+		Search:  strings.ToLower(search),
+		Pattern: appmeta.SearchPattern(search),
+		Tags:    []string{},
+		MaxApps: 100,
+		Skip:    0,
 	})
 	if err != nil {
 		apps = []db.App{}
@@ -35,17 +45,25 @@ func Get(c *fuego.Context) error {
 		appList = append(appList, AppItem{
 			ID:              app.ID.String(),
 			Name:            app.Name,
-			Status:          app.Status,
+			Status:          string(app.Status),
 			Region:          app.Region,
 			Size:            app.Size,
 			DeploymentCount: int(app.DeploymentCount),
 			URL:             "https://" + app.Name + "." + cfg.AppsDomainSuffix,
+			Description:     app.Description,
+			Tags:            app.Tags,
 		})
 	}
 
+	// Typing in the search box only refreshes the list
+	if c.Header("HX-Request") == "true" && c.Header("HX-Target") == "apps-list" {
+		return fuego.TemplComponent(c, 200, AppsResults(appList, search))
+	}
+
 	data := AppsPageData{
 		UserName: userName,
 		Apps:     appList,
+		Search:   search,
 	}
 
 	return fuego.TemplComponent(c, 200, Page(data))
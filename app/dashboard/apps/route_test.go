@@ -0,0 +1,64 @@
+package apps
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/store"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/testutil"
+)
+
+func TestGet_RedirectsWithoutSession(t *testing.T) {
+	ta := testutil.NewTestApp().WithStore(store.NewMemory())
+	ta.App.Get("/dashboard/apps", Get)
+	ta.App.Mount()
+
+	w := httptest.NewRecorder()
+	ta.App.ServeHTTP(w, testutil.MakeRequest(t, http.MethodGet, "/dashboard/apps", nil, nil))
+	testutil.AssertStatusCode(t, w, http.StatusFound)
+	if location := w.Header().Get("Location"); location != "/login" {
+		t.Errorf("expected a redirect to /login, got %q", location)
+	}
+}
+
+func TestGet_Search(t *testing.T) {
+	s := store.NewMemory()
+	alice := testutil.SeedUser(t, s, "alice")
+	bob := testutil.SeedUser(t, s, "bob")
+	testutil.SeedApp(t, s, alice.ID, "shop")
+	testutil.SeedApp(t, s, alice.ID, "blog")
+	testutil.SeedApp(t, s, bob.ID, "store")
+
+	ta := testutil.NewTestApp().WithStore(s)
+	ta.App.Get("/dashboard/apps", Get)
+	ta.App.Mount()
+	token := testutil.GenerateTestToken(t, ta.Config, alice.ID, alice.Username)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		page    bool
+	}{
+		{"page", nil, true},
+		{"search box", map[string]string{"HX-Request": "true", "HX-Target": "apps-list"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := testutil.MakeRequest(t, http.MethodGet, "/dashboard/apps?search=SHO", nil, tt.headers)
+			req.AddCookie(&http.Cookie{Name: "access_token", Value: token})
+			w := httptest.NewRecorder()
+			ta.App.ServeHTTP(w, req)
+
+			testutil.AssertStatusCode(t, w, http.StatusOK)
+			body := w.Body.String()
+			if !strings.Contains(body, "shop") || strings.Contains(body, "blog") || strings.Contains(body, "store") {
+				t.Errorf("expected only shop to be listed, got %s", body)
+			}
+			if page := strings.Contains(body, "<html"); page != tt.page {
+				t.Errorf("expected the full page %v, got %v", tt.page, page)
+			}
+		})
+	}
+}
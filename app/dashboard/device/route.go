@@ -0,0 +1,61 @@
+package device
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+// Get renders the page where a user approves a fuegoctl login started on a
+// headless terminal. Signed-out users sign in first and come back with the
+// code from the verification URI.
+// GET /dashboard/device?code=BDWP-HQTN
+func Get(c *fuego.Context) error {
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
+	code := auth.NormalizeUserCode(c.Query("code"))
+
+	_, userName, err := getUserInfo(c, cfg)
+	if err != nil {
+		next := "/dashboard/device"
+		if code != "" {
+			next += "?code=" + url.QueryEscape(code)
+		}
+		return c.Redirect("/api/auth?redirect_uri="+url.QueryEscape(next), 302)
+	}
+
+	data := DevicePageData{UserName: userName, UserCode: code}
+	if code != "" {
+		authorization, err := db.New(pool).GetDeviceAuthorizationByUserCode(c.Request.Context(), code)
+		switch {
+		case err != nil || time.Now().After(authorization.ExpiresAt):
+			data.Error = "This code is invalid or has expired. Run fuegoctl login again to get a new one."
+		case authorization.Status != auth.DeviceStatusPending:
+			data.Error = "This code was already used."
+		default:
+			data.ClientName = authorization.ClientName
+		}
+	}
+
+	return fuego.TemplComponent(c, 200, Page(data))
+}
+
+func getUserInfo(c *fuego.Context, cfg *config.Config) (uuid.UUID, string, error) {
+	tokenString := c.Cookie("access_token")
+	if tokenString == "" {
+		tokenString = auth.ExtractBearerToken(c.Header("Authorization"))
+	}
+
+	claims, err := auth.ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	return claims.UserID, claims.Username, nil
+}
@@ -1,20 +1,19 @@
 package dashboard
 
 import (
-	"context"
 	"time"
 
 	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
 	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 func Get(c *fuego.Context) error {
-	cfg := c.Get("config").(*config.Config)
-	pool := c.Get("db").(*pgxpool.Pool)
+	cfg := services.From(c).Config
+	pool := services.From(c).DB
 
 	userID, userName, err := getUserInfo(c, cfg)
 	if err != nil {
@@ -22,7 +21,7 @@ func Get(c *fuego.Context) error {
 	}
 
 	queries := db.New(pool)
-	apps, _ := queries.ListAppsByUser(context.Background(), db.ListAppsByUserParams{
+	apps, _ := queries.ListAppsByUser(c.Request.Context(), db.ListAppsByUserParams{
 		UserID: userID,
 		Limit:  5,
 		Offset: 0,
@@ -31,18 +30,18 @@ func Get(c *fuego.Context) error {
 	var runningCount int
 	recentApps := make([]AppSummary, 0, len(apps))
 	for _, app := range apps {
-		if app.Status == "running" || app.Status == "ready" {
+		if app.Status == db.AppStatusRunning {
 			runningCount++
 		}
 		recentApps = append(recentApps, AppSummary{
 			Name:      app.Name,
-			Status:    app.Status,
+			Status:    string(app.Status),
 			Region:    app.Region,
 			UpdatedAt: formatTime(app.UpdatedAt),
 		})
 	}
 
-	totalApps, _ := queries.CountAppsByUser(context.Background(), userID)
+	totalApps, _ := queries.CountAppsByUser(c.Request.Context(), userID)
 
 	data := DashboardData{
 		UserName:    userName,
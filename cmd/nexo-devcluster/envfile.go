@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// readEnvFile returns the variables set in an env file, none when it does
+// not exist
+func readEnvFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	env := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		if key, value, ok := envLine(line); ok {
+			env[key] = value
+		}
+	}
+	return env, nil
+}
+
+// updateEnvFile sets variables in an env file, replacing their lines in
+// place and appending the new ones, and leaves everything else as it is
+func updateEnvFile(path string, vars map[string]string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return os.WriteFile(path, []byte(setEnv(string(data), vars)), 0o600)
+}
+
+// setEnv returns the contents of an env file with vars set
+func setEnv(contents string, vars map[string]string) string {
+	lines := strings.Split(strings.TrimSuffix(contents, "\n"), "\n")
+	if contents == "" {
+		lines = nil
+	}
+
+	set := make(map[string]bool)
+	for i, line := range lines {
+		key, _, ok := envLine(line)
+		if !ok {
+			continue
+		}
+		if value, update := vars[key]; update {
+			lines[i] = key + "=" + value
+			set[key] = true
+		}
+	}
+	// Append in a stable order
+	for _, key := range sortedKeys(vars) {
+		if !set[key] {
+			lines = append(lines, key+"="+vars[key])
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// envLine parses a KEY=value line, skipping comments and blank lines
+func envLine(line string) (key, value string, ok bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false
+	}
+	key, value, ok = strings.Cut(strings.TrimPrefix(line, "export "), "=")
+	if !ok {
+		return "", "", false
+	}
+	return strings.TrimSpace(key), strings.Trim(strings.TrimSpace(value), `"'`), true
+}
+
+func sortedKeys(vars map[string]string) []string {
+	return slices.Sorted(maps.Keys(vars))
+}
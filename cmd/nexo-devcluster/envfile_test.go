@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetEnv(t *testing.T) {
+	contents := "# Kubernetes\nKUBECONFIG=\nK8S_NAMESPACE_PREFIX=tenant-\n\n# APPS_DOMAIN_SUFFIX=commented\n"
+
+	got := setEnv(contents, map[string]string{
+		"KUBECONFIG":         "/home/dev/.devcluster/kubeconfig",
+		"APPS_DOMAIN_SUFFIX": "localhost",
+	})
+	want := "# Kubernetes\nKUBECONFIG=/home/dev/.devcluster/kubeconfig\nK8S_NAMESPACE_PREFIX=tenant-\n\n# APPS_DOMAIN_SUFFIX=commented\nAPPS_DOMAIN_SUFFIX=localhost\n"
+	if got != want {
+		t.Errorf("setEnv =\n%s\nwant\n%s", got, want)
+	}
+
+	if got := setEnv("", map[string]string{"B": "2", "A": "1"}); got != "A=1\nB=2\n" {
+		t.Errorf("expected new variables in order, got %q", got)
+	}
+}
+
+func TestEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+
+	env, err := readEnvFile(path)
+	if err != nil || len(env) != 0 {
+		t.Fatalf("expected a missing file to be empty, got %v, %v", env, err)
+	}
+
+	if err := os.WriteFile(path, []byte("export JWT_SECRET=\"s3cret\"\nKUBECONFIG=/old\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := updateEnvFile(path, map[string]string{"KUBECONFIG": "/new"}); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	env, err = readEnvFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env["KUBECONFIG"] != "/new" || env["JWT_SECRET"] != "s3cret" {
+		t.Errorf("unexpected env %v", env)
+	}
+}
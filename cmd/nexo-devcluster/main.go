@@ -0,0 +1,301 @@
+// Command nexo-devcluster provisions a local k3d cluster to deploy apps to
+// during development: k3s with its bundled Traefik, cert-manager with
+// Let's Encrypt staging issuers, a kubeconfig under .devcluster and .env
+// pointing the platform at it.
+//
+// Usage:
+//
+//	nexo-devcluster [flags] up|down
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"time"
+)
+
+// certManagerVersion matches the production clusters (infrastructure/ansible)
+const certManagerVersion = "v1.14.4"
+
+// appsDomainSuffix serves apps at <name>.localhost, which resolves to the
+// loopback address without DNS
+const appsDomainSuffix = "localhost"
+
+// stagingIssuers are the cluster issuers apps reference, both on the Let's
+// Encrypt staging server so local certificates never hit production rate
+// limits
+const stagingIssuers = `apiVersion: cert-manager.io/v1
+kind: ClusterIssuer
+metadata:
+  name: letsencrypt-staging
+spec:
+  acme:
+    server: https://acme-staging-v02.api.letsencrypt.org/directory
+    email: %[1]s
+    privateKeySecretRef:
+      name: letsencrypt-staging-account-key
+    solvers:
+      - http01:
+          ingress:
+            class: traefik
+---
+# Apps reference letsencrypt-prod; locally it issues staging certificates
+apiVersion: cert-manager.io/v1
+kind: ClusterIssuer
+metadata:
+  name: letsencrypt-prod
+spec:
+  acme:
+    server: https://acme-staging-v02.api.letsencrypt.org/directory
+    email: %[1]s
+    privateKeySecretRef:
+      name: letsencrypt-prod-account-key
+    solvers:
+      - http01:
+          ingress:
+            class: traefik
+`
+
+type options struct {
+	name       string
+	kubeconfig string
+	envFile    string
+	email      string
+	httpPort   int
+	httpsPort  int
+	agents     int
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.name, "name", "nexo-dev", "k3d cluster name")
+	flag.StringVar(&opts.kubeconfig, "kubeconfig", ".devcluster/kubeconfig", "where to write the cluster's kubeconfig")
+	flag.StringVar(&opts.envFile, "env", ".env", "env file to point at the cluster")
+	flag.StringVar(&opts.email, "email", "dev@nexo.build", "ACME account email of the staging issuers")
+	flag.IntVar(&opts.httpPort, "http-port", 8080, "host port of the cluster's HTTP ingress")
+	flag.IntVar(&opts.httpsPort, "https-port", 8443, "host port of the cluster's HTTPS ingress")
+	flag.IntVar(&opts.agents, "agents", 1, "k3d agent nodes besides the server")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: nexo-devcluster [flags] up|down")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var err error
+	switch flag.Arg(0) {
+	case "up":
+		err = up(ctx, opts)
+	case "down":
+		err = down(ctx, opts)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "devcluster:", err)
+		os.Exit(1)
+	}
+}
+
+// up creates the cluster unless it exists and brings everything else up to
+// date, so it can be run again after a failure
+func up(ctx context.Context, opts options) error {
+	for _, tool := range []string{"docker", "k3d", "kubectl"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			return fmt.Errorf("%s is required: %w", tool, err)
+		}
+	}
+
+	exists, err := clusterExists(ctx, opts.name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		step("cluster %s already exists", opts.name)
+	} else {
+		step("creating k3d cluster %s", opts.name)
+		if err := run(ctx, nil, "k3d", "cluster", "create", opts.name,
+			"--agents", fmt.Sprint(opts.agents),
+			"-p", fmt.Sprintf("%d:80@loadbalancer", opts.httpPort),
+			"-p", fmt.Sprintf("%d:443@loadbalancer", opts.httpsPort),
+			"--wait"); err != nil {
+			return err
+		}
+	}
+
+	step("writing kubeconfig to %s", opts.kubeconfig)
+	kubeconfig, err := filepath.Abs(opts.kubeconfig)
+	if err != nil {
+		return err
+	}
+	config, err := output(ctx, "k3d", "kubeconfig", "get", opts.name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(kubeconfig), 0o700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(kubeconfig, config, 0o600); err != nil {
+		return err
+	}
+	kubectl := func(stdin []byte, args ...string) error {
+		return run(ctx, stdin, "kubectl", append([]string{"--kubeconfig", kubeconfig}, args...)...)
+	}
+
+	step("waiting for Traefik")
+	if err := waitFor(ctx, 3*time.Minute, func() error {
+		return kubectl(nil, "-n", "kube-system", "get", "deployment", "traefik")
+	}); err != nil {
+		return fmt.Errorf("traefik was not installed: %w", err)
+	}
+	if err := kubectl(nil, "-n", "kube-system", "rollout", "status", "deployment/traefik", "--timeout=180s"); err != nil {
+		return err
+	}
+
+	step("installing cert-manager %s", certManagerVersion)
+	if err := kubectl(nil, "apply", "-f",
+		"https://github.com/cert-manager/cert-manager/releases/download/"+certManagerVersion+"/cert-manager.yaml"); err != nil {
+		return err
+	}
+	if err := kubectl(nil, "-n", "cert-manager", "wait", "--for=condition=Available", "deployment", "--all", "--timeout=180s"); err != nil {
+		return err
+	}
+
+	// The webhook takes a moment to serve after its deployment is available
+	step("creating Let's Encrypt staging issuers")
+	issuers := []byte(fmt.Sprintf(stagingIssuers, opts.email))
+	if err := waitFor(ctx, time.Minute, func() error {
+		return kubectl(issuers, "apply", "-f", "-")
+	}); err != nil {
+		return err
+	}
+
+	step("pointing %s at the cluster", opts.envFile)
+	if err := updateEnvFile(opts.envFile, map[string]string{
+		"KUBECONFIG":         kubeconfig,
+		"APPS_DOMAIN_SUFFIX": appsDomainSuffix,
+	}); err != nil {
+		return err
+	}
+
+	fmt.Printf("\ncluster %s is ready\n", opts.name)
+	fmt.Printf("  kubeconfig  %s\n", kubeconfig)
+	fmt.Printf("  apps        http://<app>.%s:%d\n", appsDomainSuffix, opts.httpPort)
+	fmt.Println("restart the server (task dev) to deploy to it")
+	return nil
+}
+
+// down deletes the cluster and its kubeconfig and unsets KUBECONFIG in the
+// env file when it points there
+func down(ctx context.Context, opts options) error {
+	exists, err := clusterExists(ctx, opts.name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		step("deleting k3d cluster %s", opts.name)
+		if err := run(ctx, nil, "k3d", "cluster", "delete", opts.name); err != nil {
+			return err
+		}
+	}
+
+	kubeconfig, err := filepath.Abs(opts.kubeconfig)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(kubeconfig); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	env, err := readEnvFile(opts.envFile)
+	if err != nil {
+		return err
+	}
+	if env["KUBECONFIG"] == kubeconfig {
+		step("unsetting KUBECONFIG in %s", opts.envFile)
+		return updateEnvFile(opts.envFile, map[string]string{"KUBECONFIG": ""})
+	}
+	return nil
+}
+
+// k3dCluster is a cluster listed by k3d cluster list -o json
+type k3dCluster struct {
+	Name string `json:"name"`
+}
+
+func clusterExists(ctx context.Context, name string) (bool, error) {
+	out, err := output(ctx, "k3d", "cluster", "list", "-o", "json")
+	if err != nil {
+		return false, err
+	}
+	var clusters []k3dCluster
+	if err := json.Unmarshal(out, &clusters); err != nil {
+		return false, fmt.Errorf("parse k3d cluster list: %w", err)
+	}
+	return slices.ContainsFunc(clusters, func(c k3dCluster) bool {
+		return c.Name == name
+	}), nil
+}
+
+func step(format string, args ...any) {
+	fmt.Printf("==> "+format+"\n", args...)
+}
+
+// run runs a command with its output on the terminal
+func run(ctx context.Context, stdin []byte, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s %s: %w", name, args[0], err)
+	}
+	return nil
+}
+
+// output runs a command and returns what it printed
+func output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", name, args[0], err)
+	}
+	return out, nil
+}
+
+// waitFor retries try every few seconds until it succeeds or timeout passes
+func waitFor(ctx context.Context, timeout time.Duration, try func() error) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := try()
+		if err == nil || time.Now().After(deadline) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Command nexo-fakes serves fakes of the platform's third-party APIs for
+// local development: the Cloudflare DNS API of the configured zone and the
+// tunnels API of the configured account under /client/v4, and an endpoint
+// sending signed Stripe webhook events to the platform. Point the platform at
+// it with CLOUDFLARE_API_URL=http://localhost:8787/client/v4.
+//
+// Send a Stripe event with
+//
+//	curl -X POST 'localhost:8787/stripe/events?type=invoice.paid&customer=cus_1'
+//
+// Usage:
+//
+//	nexo-fakes [flags]
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/cloudflare"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/stripefake"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	_ = godotenv.Load()
+	cfg := config.Load()
+
+	addr := flag.String("addr", "localhost:8787", "address to serve the fakes on")
+	webhook := flag.String("webhook", "http://localhost:3000/api/webhooks/stripe", "URL Stripe events are sent to")
+	flag.Parse()
+
+	token, zoneID := cfg.CloudflareAPIToken, cfg.CloudflareZoneID
+	if token == "" {
+		token = "fake-token"
+	}
+	if zoneID == "" {
+		zoneID = "fake-zone"
+	}
+	accountID := cfg.CloudflareAccountID
+	if accountID == "" {
+		accountID = "fake-account"
+	}
+	secret := cfg.StripeWebhookSecret
+	if secret == "" {
+		secret = "whsec_fake"
+	}
+
+	mux := http.NewServeMux()
+	cf := cloudflare.NewFake(token, zoneID, cfg.AppsDomainSuffix)
+	cf.AccountID = accountID
+	mux.Handle("/client/v4/", cf)
+	mux.Handle("POST /stripe/events", sendEvent(&stripefake.Sender{URL: *webhook, Secret: secret}))
+
+	server := &http.Server{Addr: *addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdown)
+	}()
+
+	slog.Info("serving fakes", "addr", *addr,
+		"cloudflare", "http://"+*addr+"/client/v4", "zone", zoneID, "account", accountID,
+		"stripe_webhook", *webhook)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintln(os.Stderr, "fakes:", err)
+		os.Exit(1)
+	}
+}
+
+// sendEvent builds the Stripe event described by the query (type, customer,
+// email, price, amount and user_id) and sends it to the platform
+func sendEvent(sender *stripefake.Sender) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		eventType := query.Get("type")
+		if !slices.Contains(stripefake.Types, eventType) {
+			http.Error(w, fmt.Sprintf("type must be one of %v", stripefake.Types), http.StatusBadRequest)
+			return
+		}
+		amount, err := strconv.ParseInt(valueOr(query.Get("amount"), "2000"), 10, 64)
+		if err != nil {
+			http.Error(w, "amount must be in cents", http.StatusBadRequest)
+			return
+		}
+
+		sub := stripefake.Subscription{
+			Customer: valueOr(query.Get("customer"), "cus_fake"),
+			Email:    valueOr(query.Get("email"), "dev@nexo.build"),
+			ID:       query.Get("subscription"),
+			Price:    valueOr(query.Get("price"), "price_pro_monthly"),
+			Amount:   amount,
+		}
+		if userID := query.Get("user_id"); userID != "" {
+			sub.Metadata = map[string]string{"user_id": userID}
+		}
+
+		event := stripefake.New(eventType, sub, time.Now())
+		status, err := sender.Send(r.Context(), event)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		slog.Info("sent stripe event", "type", eventType, "id", event.ID, "status", status)
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"event":          event,
+			"webhook_status": status,
+			"webhook_url":    sender.URL,
+		})
+	})
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
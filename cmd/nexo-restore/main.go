@@ -0,0 +1,125 @@
+// Command nexo-restore rebuilds the platform from a disaster-recovery
+// snapshot written by the backup job. See docs/DISASTER_RECOVERY.md.
+//
+// Usage:
+//
+//	nexo-restore [-snapshot KEY | -file PATH] [-db] [-namespaces] [-region REGION] [-dry-run]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/backup"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	snapshotKey := flag.String("snapshot", "", "snapshot key in BACKUP_URL (default: the latest snapshot)")
+	file := flag.String("file", "", "read the snapshot from a local file instead of BACKUP_URL")
+	restoreDB := flag.Bool("db", false, "load the control-plane tables into DATABASE_URL")
+	restoreNamespaces := flag.Bool("namespaces", false, "recreate tenant namespaces in each region's cluster")
+	region := flag.String("region", "", "only recreate the namespaces of this region")
+	dryRun := flag.Bool("dry-run", false, "print the snapshot contents without restoring anything")
+	flag.Parse()
+
+	if !*restoreDB && !*restoreNamespaces && !*dryRun {
+		fmt.Fprintln(os.Stderr, "nothing to do: pass -db, -namespaces or -dry-run")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	_ = godotenv.Load()
+	cfg := config.Load()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, cfg, *snapshotKey, *file, *restoreDB, *restoreNamespaces, *region, *dryRun); err != nil {
+		fmt.Fprintln(os.Stderr, "restore failed:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, cfg *config.Config, key, file string, restoreDB, restoreNamespaces bool, region string, dryRun bool) error {
+	snapshot, source, err := load(ctx, cfg, key, file)
+	if err != nil {
+		return err
+	}
+
+	manifest := snapshot.Manifest
+	fmt.Printf("snapshot %s taken at %s (schema version %d)\n", source, manifest.CreatedAt.Format("2006-01-02 15:04:05 MST"), manifest.SchemaVersion)
+	for _, table := range manifest.Tables {
+		fmt.Printf("  table %-28s %d rows\n", table.Name, table.Rows)
+	}
+	for _, ns := range manifest.Namespaces {
+		fmt.Printf("  namespace %s/%-24s %d resources\n", ns.Region, ns.Name, ns.Resources)
+	}
+	for failed, reason := range manifest.RegionErrors {
+		fmt.Printf("  warning: region %s was not exported: %s\n", failed, reason)
+	}
+	if dryRun {
+		return nil
+	}
+
+	if restoreDB {
+		pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+		if err != nil {
+			return fmt.Errorf("failed to connect to database: %w", err)
+		}
+		defer pool.Close()
+
+		if err := backup.RestoreDatabase(ctx, pool, snapshot); err != nil {
+			return err
+		}
+		fmt.Printf("restored %d tables\n", len(manifest.Tables))
+	}
+
+	if restoreNamespaces {
+		regions := cfg.Regions
+		if region != "" {
+			regions = []string{region}
+		}
+		svc := &services.Services{Config: cfg}
+		for _, r := range regions {
+			client, err := svc.Kubernetes(cfg.KubeconfigForRegion(r))
+			if err != nil {
+				return fmt.Errorf("region %s: %w", r, err)
+			}
+			restored, err := backup.RestoreNamespaces(ctx, client, snapshot, r)
+			if err != nil {
+				return fmt.Errorf("region %s: %w", r, err)
+			}
+			fmt.Printf("restored %d namespaces in %s\n", restored, r)
+		}
+	}
+
+	return nil
+}
+
+func load(ctx context.Context, cfg *config.Config, key, file string) (*backup.Snapshot, string, error) {
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, "", err
+		}
+		snapshot, err := backup.Open(data, cfg.EncryptionKey)
+		return snapshot, file, err
+	}
+
+	if cfg.BackupURL == "" {
+		return nil, "", fmt.Errorf("BACKUP_URL is not set, pass -file to restore a downloaded snapshot")
+	}
+	store, err := backup.NewStore(cfg)
+	if err != nil {
+		return nil, "", err
+	}
+	return backup.Load(ctx, store, key, cfg.EncryptionKey)
+}
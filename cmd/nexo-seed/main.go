@@ -0,0 +1,63 @@
+// Command nexo-seed fills DATABASE_URL with the demo user and its sample
+// apps, deployments, domains and usage history, and prints an access token
+// of the demo user for the API. Seeding again adds only what is missing.
+//
+// Usage:
+//
+//	nexo-seed [-token=false]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/demo"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	printToken := flag.Bool("token", true, "print an access token of the demo user (needs JWT_SECRET)")
+	flag.Parse()
+
+	_ = godotenv.Load()
+	cfg := config.Load()
+
+	if err := run(context.Background(), cfg, *printToken); err != nil {
+		fmt.Fprintln(os.Stderr, "seed failed:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, cfg *config.Config, printToken bool) error {
+	pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	user, err := demo.Seed(ctx, db.New(pool), time.Now())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("seeded demo user %s with %d sample apps\n", user.Username, len(demo.Apps))
+
+	if !printToken {
+		return nil
+	}
+	if cfg.JWTSecret == "" {
+		return fmt.Errorf("JWT_SECRET is not set, pass -token=false to skip the token")
+	}
+	tokens, err := auth.GenerateTokenPair(user.ID, user.Username, cfg.JWTSecret)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("access token (expires %s):\n%s\n", tokens.ExpiresAt.Format(time.RFC3339), tokens.AccessToken)
+	return nil
+}
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS deploy_hooks_updated_at ON deploy_hooks;
+DROP INDEX IF EXISTS idx_deployment_hook_runs_deployment_id;
+DROP TABLE IF EXISTS deployment_hook_runs;
+DROP TABLE IF EXISTS deploy_hooks;
@@ -0,0 +1,28 @@
+-- Deploy hooks: commands run as Kubernetes Jobs around a rollout
+CREATE TABLE deploy_hooks (
+    app_id UUID PRIMARY KEY REFERENCES apps(id) ON DELETE CASCADE,
+    pre_deploy_command TEXT,
+    post_deploy_command TEXT,
+    timeout_seconds INT DEFAULT 600 NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE TABLE deployment_hook_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    deployment_id UUID NOT NULL REFERENCES deployments(id) ON DELETE CASCADE,
+    phase VARCHAR(20) NOT NULL,
+    command TEXT NOT NULL,
+    job_name VARCHAR(255) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    output TEXT,
+    error TEXT,
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_deployment_hook_runs_deployment_id ON deployment_hook_runs(deployment_id);
+
+CREATE TRIGGER deploy_hooks_updated_at BEFORE UPDATE ON deploy_hooks
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
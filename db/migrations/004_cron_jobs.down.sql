@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS cron_jobs_updated_at ON cron_jobs;
+DROP INDEX IF EXISTS idx_cron_jobs_app_id;
+DROP TABLE IF EXISTS cron_jobs;
@@ -0,0 +1,23 @@
+-- Cron jobs: scheduled commands run as Kubernetes CronJobs with the app image
+CREATE TABLE cron_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    app_id UUID NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    name VARCHAR(52) NOT NULL,
+    schedule VARCHAR(255) NOT NULL,
+    command TEXT NOT NULL,
+    timezone VARCHAR(64) DEFAULT 'UTC' NOT NULL,
+    concurrency_policy VARCHAR(20) DEFAULT 'allow' NOT NULL,
+    starting_deadline_seconds BIGINT,
+    active_deadline_seconds BIGINT,
+    successful_history_limit INT DEFAULT 3 NOT NULL,
+    failed_history_limit INT DEFAULT 1 NOT NULL,
+    suspended BOOLEAN DEFAULT FALSE NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    UNIQUE(app_id, name)
+);
+
+CREATE INDEX idx_cron_jobs_app_id ON cron_jobs(app_id);
+
+CREATE TRIGGER cron_jobs_updated_at BEFORE UPDATE ON cron_jobs
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
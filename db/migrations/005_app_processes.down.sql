@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS app_processes_updated_at ON app_processes;
+DROP INDEX IF EXISTS idx_app_processes_app_id;
+DROP TABLE IF EXISTS app_processes;
@@ -0,0 +1,18 @@
+-- App processes: Procfile-style process types, each rendered as its own Deployment
+CREATE TABLE app_processes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    app_id UUID NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    process_type VARCHAR(20) NOT NULL,
+    command TEXT,
+    replicas INT DEFAULT 1 NOT NULL,
+    cpu VARCHAR(20),
+    memory VARCHAR(20),
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    UNIQUE(app_id, process_type)
+);
+
+CREATE INDEX idx_app_processes_app_id ON app_processes(app_id);
+
+CREATE TRIGGER app_processes_updated_at BEFORE UPDATE ON app_processes
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
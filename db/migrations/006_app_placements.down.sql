@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS app_placements_updated_at ON app_placements;
+DROP TABLE IF EXISTS app_placements;
@@ -0,0 +1,11 @@
+-- App placements: dedicated node pools via nodeSelector and tolerations
+CREATE TABLE app_placements (
+    app_id UUID PRIMARY KEY REFERENCES apps(id) ON DELETE CASCADE,
+    node_selector JSONB DEFAULT '{}' NOT NULL,
+    tolerations JSONB DEFAULT '[]' NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE TRIGGER app_placements_updated_at BEFORE UPDATE ON app_placements
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
@@ -0,0 +1,2 @@
+-- Typed failure reason for deployments so users see why a rollout was rejected
+ALTER TABLE deployments ADD COLUMN failure_reason VARCHAR(50);
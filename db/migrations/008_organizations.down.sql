@@ -0,0 +1,5 @@
+ALTER TABLE users DROP COLUMN IF EXISTS sessions_revoked_at;
+DROP TRIGGER IF EXISTS organization_members_updated_at ON organization_members;
+DROP TABLE IF EXISTS organization_members;
+DROP TRIGGER IF EXISTS organizations_updated_at ON organizations;
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,39 @@
+-- Organizations and their members, provisioned manually or through SCIM
+CREATE TABLE organizations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) UNIQUE NOT NULL,
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    scim_token_hash VARCHAR(255) UNIQUE,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_organizations_owner_id ON organizations(owner_id);
+
+CREATE TRIGGER organizations_updated_at BEFORE UPDATE ON organizations
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+-- Members are keyed by GitHub username and linked to a user on first login
+CREATE TABLE organization_members (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    user_name VARCHAR(255) NOT NULL,
+    external_id VARCHAR(255),
+    email VARCHAR(255),
+    display_name VARCHAR(255),
+    role VARCHAR(50) DEFAULT 'member' NOT NULL,
+    active BOOLEAN DEFAULT TRUE NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    UNIQUE (org_id, user_name)
+);
+
+CREATE INDEX idx_organization_members_user_id ON organization_members(user_id);
+CREATE INDEX idx_organization_members_user_name ON organization_members(user_name);
+
+CREATE TRIGGER organization_members_updated_at BEFORE UPDATE ON organization_members
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+-- JWTs issued before this instant are rejected
+ALTER TABLE users ADD COLUMN sessions_revoked_at TIMESTAMPTZ;
@@ -0,0 +1,2 @@
+ALTER TABLE organizations DROP COLUMN IF EXISTS max_token_lifetime_days;
+ALTER TABLE api_tokens DROP COLUMN IF EXISTS allowed_cidrs;
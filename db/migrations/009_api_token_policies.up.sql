@@ -0,0 +1,5 @@
+-- API tokens can be bound to CIDR ranges; an empty list allows any address
+ALTER TABLE api_tokens ADD COLUMN allowed_cidrs TEXT[] DEFAULT '{}' NOT NULL;
+
+-- Organizations can cap the lifetime of their members' API tokens
+ALTER TABLE organizations ADD COLUMN max_token_lifetime_days INTEGER;
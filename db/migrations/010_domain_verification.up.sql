@@ -0,0 +1,3 @@
+-- Custom domains are activated only after the owner publishes this token in
+-- a TXT record at _fuego-verify.<domain>
+ALTER TABLE domains ADD COLUMN verification_token VARCHAR(64) DEFAULT md5(random()::text || clock_timestamp()::text) NOT NULL;
@@ -0,0 +1,3 @@
+-- How a custom domain points at the platform: cname for subdomains, a
+-- (A/AAAA to the region's ingress IPs) or alias (flattened CNAME) for apex domains
+ALTER TABLE domains ADD COLUMN dns_mode VARCHAR(20) DEFAULT 'cname' NOT NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE domains DROP COLUMN IF EXISTS ssl_alerted_at;
+ALTER TABLE domains DROP COLUMN IF EXISTS ssl_checked_at;
+ALTER TABLE domains DROP COLUMN IF EXISTS ssl_error;
+ALTER TABLE domains DROP COLUMN IF EXISTS ssl_expires_at;
@@ -0,0 +1,5 @@
+-- Certificate state polled from cert-manager for each custom domain
+ALTER TABLE domains ADD COLUMN ssl_expires_at TIMESTAMPTZ;
+ALTER TABLE domains ADD COLUMN ssl_error TEXT;
+ALTER TABLE domains ADD COLUMN ssl_checked_at TIMESTAMPTZ;
+ALTER TABLE domains ADD COLUMN ssl_alerted_at TIMESTAMPTZ;
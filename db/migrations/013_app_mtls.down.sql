@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS client_certificates;
+DROP TABLE IF EXISTS app_mtls;
@@ -0,0 +1,21 @@
+-- Internal apps can require client certificates issued by the platform CA;
+-- a row means mTLS is enforced at the app's ingress
+CREATE TABLE app_mtls (
+    app_id UUID PRIMARY KEY REFERENCES apps(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+-- Issued client certificates; private keys are never stored
+CREATE TABLE client_certificates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    app_id UUID NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    serial VARCHAR(64) UNIQUE NOT NULL,
+    fingerprint VARCHAR(64) NOT NULL,
+    certificate TEXT NOT NULL,
+    not_after TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_client_certificates_app_id ON client_certificates(app_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS router_routes;
+DROP TRIGGER IF EXISTS routers_updated_at ON routers;
+DROP TABLE IF EXISTS routers;
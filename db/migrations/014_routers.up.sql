@@ -0,0 +1,23 @@
+-- Routers compose several apps behind one host with path-prefix routing
+CREATE TABLE routers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    UNIQUE(user_id, name)
+);
+
+CREATE TRIGGER routers_updated_at BEFORE UPDATE ON routers
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+CREATE TABLE router_routes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    router_id UUID NOT NULL REFERENCES routers(id) ON DELETE CASCADE,
+    path_prefix VARCHAR(255) NOT NULL,
+    app_id UUID NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    UNIQUE(router_id, path_prefix)
+);
+
+CREATE INDEX idx_router_routes_app_id ON router_routes(app_id);
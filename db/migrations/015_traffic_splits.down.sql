@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS traffic_split_backends;
+DROP TRIGGER IF EXISTS traffic_splits_updated_at ON traffic_splits;
+DROP TABLE IF EXISTS traffic_splits;
@@ -0,0 +1,22 @@
+-- Traffic splits spread the requests to one host across apps by weight
+CREATE TABLE traffic_splits (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    sticky BOOLEAN DEFAULT FALSE NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    UNIQUE(user_id, name)
+);
+
+CREATE TRIGGER traffic_splits_updated_at BEFORE UPDATE ON traffic_splits
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+CREATE TABLE traffic_split_backends (
+    split_id UUID NOT NULL REFERENCES traffic_splits(id) ON DELETE CASCADE,
+    app_id UUID NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    weight INT NOT NULL CHECK (weight BETWEEN 0 AND 100),
+    PRIMARY KEY (split_id, app_id)
+);
+
+CREATE INDEX idx_traffic_split_backends_app_id ON traffic_split_backends(app_id);
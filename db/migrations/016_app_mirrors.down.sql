@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS app_mirrors_updated_at ON app_mirrors;
+DROP TABLE IF EXISTS app_mirrors;
@@ -0,0 +1,15 @@
+-- Shadow traffic: a percentage of an app's requests is copied to another
+-- app, responses of the copy are discarded. Mirrors stop at expires_at.
+CREATE TABLE app_mirrors (
+    app_id UUID PRIMARY KEY REFERENCES apps(id) ON DELETE CASCADE,
+    target_app_id UUID NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    percent INT NOT NULL CHECK (percent BETWEEN 1 AND 100),
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE TRIGGER app_mirrors_updated_at BEFORE UPDATE ON app_mirrors
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+CREATE INDEX idx_app_mirrors_expires_at ON app_mirrors(expires_at);
@@ -0,0 +1,12 @@
+-- Metered network usage: bytes in and out of each app's ingress, bucketed by
+-- hour. Feeds app metrics and billing.
+CREATE TABLE app_bandwidth (
+    app_id UUID NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    period_start TIMESTAMPTZ NOT NULL,
+    ingress_bytes BIGINT NOT NULL DEFAULT 0,
+    egress_bytes BIGINT NOT NULL DEFAULT 0,
+    requests BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (app_id, period_start)
+);
+
+CREATE INDEX idx_app_bandwidth_period_start ON app_bandwidth(period_start);
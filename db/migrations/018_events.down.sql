@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS events_notify ON events;
+DROP FUNCTION IF EXISTS notify_platform_event();
+DROP TRIGGER IF EXISTS event_cursors_updated_at ON event_cursors;
+DROP TABLE IF EXISTS event_cursors;
+DROP TABLE IF EXISTS events;
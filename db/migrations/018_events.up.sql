@@ -0,0 +1,37 @@
+-- Platform event bus. Subsystems append events; each subscriber reads them
+-- in order from its own cursor, so a subscriber that fails or restarts
+-- picks up where it left off (at-least-once delivery).
+CREATE TABLE events (
+    id BIGSERIAL PRIMARY KEY,
+    type VARCHAR(100) NOT NULL,
+    user_id UUID,
+    app_id UUID,
+    app_name VARCHAR(63),
+    message TEXT,
+    ip_address INET,
+    payload JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_events_created_at ON events(created_at);
+
+CREATE TABLE event_cursors (
+    subscriber VARCHAR(100) PRIMARY KEY,
+    last_event_id BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE TRIGGER event_cursors_updated_at BEFORE UPDATE ON event_cursors
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+-- Wake dispatchers as soon as an event commits
+CREATE OR REPLACE FUNCTION notify_platform_event()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('platform_events', NEW.id::TEXT);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER events_notify AFTER INSERT ON events
+    FOR EACH ROW EXECUTE FUNCTION notify_platform_event();
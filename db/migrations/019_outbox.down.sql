@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS outbox_updated_at ON outbox;
+DROP TABLE IF EXISTS outbox;
@@ -0,0 +1,22 @@
+-- Outgoing webhook and email deliveries. Jobs are retried with backoff and
+-- marked dead after max_attempts so they can be inspected and requeued.
+CREATE TABLE outbox (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind VARCHAR(20) NOT NULL,
+    destination TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'dead')),
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL DEFAULT 8,
+    next_attempt_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    last_error TEXT,
+    delivered_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE TRIGGER outbox_updated_at BEFORE UPDATE ON outbox
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+CREATE INDEX idx_outbox_due ON outbox(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_outbox_status ON outbox(status, created_at);
@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS maintenance_windows_updated_at ON maintenance_windows;
+DROP TABLE IF EXISTS maintenance_windows;
@@ -0,0 +1,17 @@
+-- Scheduled platform maintenance. Active and upcoming windows are shown to
+-- users as a banner and pause non-critical background workers while active.
+CREATE TABLE maintenance_windows (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    title VARCHAR(200) NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL CHECK (ends_at > starts_at),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE TRIGGER maintenance_windows_updated_at BEFORE UPDATE ON maintenance_windows
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+CREATE INDEX idx_maintenance_windows_ends_at ON maintenance_windows(ends_at, starts_at);
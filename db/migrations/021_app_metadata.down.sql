@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_apps_tags;
+ALTER TABLE apps DROP COLUMN IF EXISTS tags;
+ALTER TABLE apps DROP COLUMN IF EXISTS repository_url;
+ALTER TABLE apps DROP COLUMN IF EXISTS icon_url;
+ALTER TABLE apps DROP COLUMN IF EXISTS description;
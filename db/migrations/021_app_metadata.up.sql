@@ -0,0 +1,8 @@
+-- Descriptive metadata so users with many apps can tell them apart and find
+-- them with GET /api/apps?search=
+ALTER TABLE apps ADD COLUMN description TEXT DEFAULT '' NOT NULL;
+ALTER TABLE apps ADD COLUMN icon_url TEXT DEFAULT '' NOT NULL;
+ALTER TABLE apps ADD COLUMN repository_url TEXT DEFAULT '' NOT NULL;
+ALTER TABLE apps ADD COLUMN tags TEXT[] DEFAULT '{}' NOT NULL;
+
+CREATE INDEX idx_apps_tags ON apps USING GIN (tags);
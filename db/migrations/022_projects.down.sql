@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS project_env_groups;
+DROP INDEX IF EXISTS idx_apps_project_id;
+ALTER TABLE apps DROP COLUMN IF EXISTS project_id;
+DROP TABLE IF EXISTS projects;
@@ -0,0 +1,33 @@
+-- Projects group related apps (e.g. frontend, api and worker) for a combined
+-- view. Every app in a project receives the project's env groups.
+CREATE TABLE projects (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT DEFAULT '' NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    UNIQUE(user_id, name)
+);
+
+CREATE TRIGGER projects_updated_at BEFORE UPDATE ON projects
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+ALTER TABLE apps ADD COLUMN project_id UUID REFERENCES projects(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_apps_project_id ON apps(project_id);
+
+-- Shared env vars, encrypted like an app's own. An app's own vars take
+-- precedence over its project's groups.
+CREATE TABLE project_env_groups (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name VARCHAR(63) NOT NULL,
+    env_vars_encrypted BYTEA NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    UNIQUE(project_id, name)
+);
+
+CREATE TRIGGER project_env_groups_updated_at BEFORE UPDATE ON project_env_groups
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
@@ -0,0 +1,3 @@
+-- User-defined labels, copied onto every Kubernetes resource of the app and
+-- reported with usage for chargeback by team or environment
+ALTER TABLE apps ADD COLUMN labels JSONB DEFAULT '{}' NOT NULL;
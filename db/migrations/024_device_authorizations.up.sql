@@ -0,0 +1,17 @@
+-- OAuth device authorization grant (RFC 8628) for fuegoctl login on
+-- headless terminals. The CLI polls with the secret device code while the
+-- user approves the short user code in the dashboard.
+CREATE TABLE device_authorizations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    device_code_hash VARCHAR(64) UNIQUE NOT NULL,
+    user_code VARCHAR(9) UNIQUE NOT NULL,
+    client_name VARCHAR(255) NOT NULL,
+    status VARCHAR(20) DEFAULT 'pending' NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    poll_interval INTEGER DEFAULT 5 NOT NULL,
+    last_polled_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_device_authorizations_expires_at ON device_authorizations(expires_at);
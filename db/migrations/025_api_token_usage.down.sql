@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS api_token_usage;
+ALTER TABLE api_tokens DROP COLUMN IF EXISTS expire_after_unused_days;
+ALTER TABLE api_tokens DROP COLUMN IF EXISTS last_used_user_agent;
+ALTER TABLE api_tokens DROP COLUMN IF EXISTS last_used_ip;
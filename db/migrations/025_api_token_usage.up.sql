@@ -0,0 +1,12 @@
+-- Where and how often API tokens are used, so owners can spot leaked or
+-- forgotten tokens. Tokens may opt into expiring after going unused.
+ALTER TABLE api_tokens ADD COLUMN last_used_ip INET;
+ALTER TABLE api_tokens ADD COLUMN last_used_user_agent TEXT;
+ALTER TABLE api_tokens ADD COLUMN expire_after_unused_days INTEGER;
+
+CREATE TABLE api_token_usage (
+    token_id UUID NOT NULL REFERENCES api_tokens(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    count INTEGER DEFAULT 0 NOT NULL,
+    PRIMARY KEY (token_id, day)
+);
@@ -0,0 +1,4 @@
+DELETE FROM users WHERE id IN (SELECT user_id FROM machine_users);
+DROP TABLE IF EXISTS machine_users;
+DROP FUNCTION IF EXISTS delete_machine_user_account();
+DROP SEQUENCE IF EXISTS machine_user_github_ids;
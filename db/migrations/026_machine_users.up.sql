@@ -0,0 +1,36 @@
+-- Machine users are non-interactive identities owned by an organization so
+-- CI pipelines keep working when the people who set them up leave. Each is
+-- backed by a users row, so apps and API tokens work as they do for people.
+-- Machine users have no GitHub account and take negative github_ids, which
+-- never collide with real ones.
+CREATE SEQUENCE machine_user_github_ids;
+
+CREATE TABLE machine_users (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name VARCHAR(63) NOT NULL,
+    description TEXT,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    -- Quotas; NULL means unlimited
+    max_apps INTEGER,
+    max_deployments_per_day INTEGER,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    UNIQUE (org_id, name)
+);
+
+CREATE TRIGGER machine_users_updated_at BEFORE UPDATE ON machine_users
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+-- Removing a machine user, directly or with its organization, removes the
+-- backing user along with its tokens
+CREATE OR REPLACE FUNCTION delete_machine_user_account()
+RETURNS TRIGGER AS $$
+BEGIN
+    DELETE FROM users WHERE id = OLD.user_id;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER machine_users_delete_account AFTER DELETE ON machine_users
+    FOR EACH ROW EXECUTE FUNCTION delete_machine_user_account();
@@ -0,0 +1,4 @@
+ALTER TABLE deployments DROP COLUMN IF EXISTS crash_alerted_at;
+ALTER TABLE deployments DROP COLUMN IF EXISTS last_crash_at;
+ALTER TABLE deployments DROP COLUMN IF EXISTS crash_loops;
+ALTER TABLE deployments DROP COLUMN IF EXISTS oom_kills;
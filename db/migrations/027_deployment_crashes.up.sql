@@ -0,0 +1,6 @@
+-- Container crashes of a deployment's pods, counted by the crash monitor so
+-- owners see whether a release runs out of memory or keeps crashing
+ALTER TABLE deployments ADD COLUMN oom_kills INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE deployments ADD COLUMN crash_loops INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE deployments ADD COLUMN last_crash_at TIMESTAMPTZ;
+ALTER TABLE deployments ADD COLUMN crash_alerted_at TIMESTAMPTZ;
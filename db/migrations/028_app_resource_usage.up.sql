@@ -0,0 +1,15 @@
+-- Hourly CPU and memory usage of an app's pods sampled from metrics-server,
+-- backing right-sizing recommendations. Sums and maxima are per pod.
+CREATE TABLE app_resource_usage (
+    app_id UUID NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    period_start TIMESTAMPTZ NOT NULL,
+    samples INTEGER NOT NULL DEFAULT 0,
+    pod_samples INTEGER NOT NULL DEFAULT 0,
+    cpu_millis_sum BIGINT NOT NULL DEFAULT 0,
+    cpu_millis_max BIGINT NOT NULL DEFAULT 0,
+    memory_bytes_sum BIGINT NOT NULL DEFAULT 0,
+    memory_bytes_max BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (app_id, period_start)
+);
+
+CREATE INDEX idx_app_resource_usage_period_start ON app_resource_usage(period_start);
@@ -0,0 +1,17 @@
+-- Burst mode doubles an app's web replicas while its CPU or p95 latency is
+-- over a threshold and scales back once load stays under it for the
+-- cool-down. The base replicas and burst times are set during a burst.
+CREATE TABLE app_bursts (
+    app_id UUID PRIMARY KEY REFERENCES apps(id) ON DELETE CASCADE,
+    cpu_percent INTEGER CHECK (cpu_percent BETWEEN 1 AND 100),
+    p95_latency_ms INTEGER CHECK (p95_latency_ms > 0),
+    cooldown_minutes INTEGER DEFAULT 10 NOT NULL CHECK (cooldown_minutes BETWEEN 1 AND 1440),
+    base_replicas INTEGER,
+    burst_started_at TIMESTAMPTZ,
+    burst_until TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE TRIGGER app_bursts_updated_at BEFORE UPDATE ON app_bursts
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
@@ -0,0 +1,4 @@
+-- CPU architectures of the deployment's image that the target region's
+-- nodes run, pods are scheduled onto nodes of these. NULL when the image
+-- could not be inspected, leaving scheduling to the cluster.
+ALTER TABLE deployments ADD COLUMN architectures TEXT[];
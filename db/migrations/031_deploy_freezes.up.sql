@@ -0,0 +1,22 @@
+-- Deploy freezes are weekly windows, such as Friday 18:00 to Monday 08:00,
+-- during which apps of an organization's members and machine users only
+-- deploy in an emergency with a justification. Days count from Sunday (0)
+-- and times are minutes of the day in the freeze's time zone; a window
+-- ending before it starts wraps around the week.
+CREATE TABLE deploy_freezes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name VARCHAR(63) NOT NULL,
+    start_day INTEGER NOT NULL CHECK (start_day BETWEEN 0 AND 6),
+    start_minute INTEGER NOT NULL CHECK (start_minute BETWEEN 0 AND 1439),
+    end_day INTEGER NOT NULL CHECK (end_day BETWEEN 0 AND 6),
+    end_minute INTEGER NOT NULL CHECK (end_minute BETWEEN 0 AND 1439),
+    timezone VARCHAR(64) DEFAULT 'UTC' NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    UNIQUE (org_id, name)
+);
+
+CREATE TRIGGER deploy_freezes_updated_at BEFORE UPDATE ON deploy_freezes
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
@@ -0,0 +1,19 @@
+-- CI provenance of deployments made from GitHub Actions or GitLab CI: the
+-- run, commit and actor sent as headers. Verified provenance comes from an
+-- OIDC token of the provider whose claims match the headers.
+CREATE TABLE deployment_provenance (
+    deployment_id UUID PRIMARY KEY REFERENCES deployments(id) ON DELETE CASCADE,
+    provider VARCHAR(20) NOT NULL,
+    run_id VARCHAR(64) DEFAULT '' NOT NULL,
+    run_number VARCHAR(64) DEFAULT '' NOT NULL,
+    run_url TEXT DEFAULT '' NOT NULL,
+    commit_sha VARCHAR(64) DEFAULT '' NOT NULL,
+    actor VARCHAR(255) DEFAULT '' NOT NULL,
+    repository VARCHAR(255) DEFAULT '' NOT NULL,
+    ref VARCHAR(255) DEFAULT '' NOT NULL,
+    workflow VARCHAR(255) DEFAULT '' NOT NULL,
+    verified BOOLEAN DEFAULT FALSE NOT NULL,
+    issuer TEXT DEFAULT '' NOT NULL,
+    subject TEXT DEFAULT '' NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
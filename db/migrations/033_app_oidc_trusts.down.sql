@@ -0,0 +1 @@
+DROP TABLE IF EXISTS app_oidc_trusts;
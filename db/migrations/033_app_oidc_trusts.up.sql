@@ -0,0 +1,13 @@
+-- OIDC trusts let CI runs of a repository exchange their provider's OIDC
+-- token for a short-lived deploy token of the app, instead of keeping an API
+-- token as a CI secret. An empty ref trusts runs of every branch and tag.
+CREATE TABLE app_oidc_trusts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    app_id UUID NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    provider VARCHAR(20) NOT NULL,
+    repository VARCHAR(255) NOT NULL,
+    ref VARCHAR(255) DEFAULT '' NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    UNIQUE (app_id, provider, repository, ref)
+);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_activity_logs_app_actor;
+ALTER TABLE events DROP COLUMN IF EXISTS api_token_id;
+ALTER TABLE activity_logs DROP COLUMN IF EXISTS api_token_id;
+ALTER TABLE deployments
+    DROP COLUMN IF EXISTS deployed_by,
+    DROP COLUMN IF EXISTS deployed_by_token_id,
+    DROP COLUMN IF EXISTS deployed_by_user_id;
@@ -0,0 +1,13 @@
+-- Who made a deployment: the user, the API token when one was used, and a
+-- label of both kept for the record after the user or token is deleted
+ALTER TABLE deployments
+    ADD COLUMN deployed_by_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN deployed_by_token_id UUID,
+    ADD COLUMN deployed_by VARCHAR(255) DEFAULT '' NOT NULL;
+
+-- The API token behind an action, NULL when a signed-in user took it. Not a
+-- foreign key so the trail survives the token's deletion.
+ALTER TABLE activity_logs ADD COLUMN api_token_id UUID;
+ALTER TABLE events ADD COLUMN api_token_id UUID;
+
+CREATE INDEX idx_activity_logs_app_actor ON activity_logs(app_id, user_id, created_at DESC);
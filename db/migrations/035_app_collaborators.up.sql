@@ -0,0 +1,15 @@
+-- Collaborators get rights on a single app of another user, such as a
+-- contractor deploying one app, without being a member of the owner's
+-- organization. Roles are view, deploy and admin.
+CREATE TABLE app_collaborators (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    app_id UUID NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL,
+    added_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    UNIQUE (app_id, user_id)
+);
+
+CREATE INDEX idx_app_collaborators_user ON app_collaborators(user_id);
@@ -0,0 +1,9 @@
+-- The operator switch putting the API in read-only mode during incidents or
+-- migrations. A single row shared by every replica.
+CREATE TABLE platform_read_only (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    enabled BOOLEAN DEFAULT FALSE NOT NULL,
+    message TEXT DEFAULT '' NOT NULL,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
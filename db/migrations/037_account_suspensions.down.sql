@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_users_stripe_customer_id;
+DROP TABLE IF EXISTS account_suspensions;
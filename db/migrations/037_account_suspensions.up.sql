@@ -0,0 +1,18 @@
+-- Accounts with failed payments go through the suspension pipeline: email
+-- warnings, apps scaled to zero, namespaces deleted after a retention
+-- period. A row exists while an account is in the pipeline and is deleted
+-- when it is reinstated.
+CREATE TABLE account_suspensions (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    stage VARCHAR(20) NOT NULL DEFAULT 'warning',
+    -- The invoice whose failed payment started the pipeline
+    invoice_id VARCHAR(255) NOT NULL DEFAULT '',
+    failed_at TIMESTAMPTZ NOT NULL,
+    warnings INTEGER NOT NULL DEFAULT 1,
+    suspended_at TIMESTAMPTZ,
+    purged_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_users_stripe_customer_id ON users(stripe_customer_id) WHERE stripe_customer_id IS NOT NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE oauth_states DROP COLUMN IF EXISTS referrer;
+DROP TABLE IF EXISTS referrals;
+DROP TABLE IF EXISTS credit_ledger;
@@ -0,0 +1,31 @@
+-- Account credits are a ledger: grants by admins and referrals add to the
+-- balance, invoices consume it before the card is charged. Amounts are in
+-- USD cents.
+CREATE TABLE credit_ledger (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- Positive for grants, negative for credits applied to an invoice
+    amount BIGINT NOT NULL,
+    reason VARCHAR(20) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    -- The Stripe invoice credits were applied to; each is credited once
+    invoice_id VARCHAR(255) UNIQUE,
+    granted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_credit_ledger_user_id ON credit_ledger(user_id, created_at DESC);
+
+-- Users who signed up through another user's referral link. The referrer is
+-- credited once the referred user pays their first invoice.
+CREATE TABLE referrals (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    referrer_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    credited_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_referrals_referrer_id ON referrals(referrer_id);
+
+-- The referral link a login started from, kept until the callback
+ALTER TABLE oauth_states ADD COLUMN referrer VARCHAR(255);
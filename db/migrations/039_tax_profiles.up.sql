@@ -0,0 +1,26 @@
+-- Tax profiles hold what Stripe Tax needs to charge the right tax: the
+-- billing country and, for businesses, a tax ID such as an EU VAT number or
+-- a Mexican RFC. A profile belongs to a user or to an organization the
+-- user's subscription is billed to.
+CREATE TABLE tax_profiles (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    organization_id UUID UNIQUE REFERENCES organizations(id) ON DELETE CASCADE,
+    -- ISO 3166-1 alpha-2
+    country CHAR(2) NOT NULL,
+    postal_code VARCHAR(20) NOT NULL DEFAULT '',
+    business_name VARCHAR(255) NOT NULL DEFAULT '',
+    -- Stripe's tax ID type, such as eu_vat or mx_rfc, empty without a tax ID
+    tax_id_type VARCHAR(20) NOT NULL DEFAULT '',
+    tax_id VARCHAR(50) NOT NULL DEFAULT '',
+    -- The tax ID on the Stripe customer and Stripe's verification of it:
+    -- pending, verified, unverified or unavailable
+    stripe_tax_id VARCHAR(255) NOT NULL DEFAULT '',
+    tax_id_status VARCHAR(20) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    CHECK ((user_id IS NULL) <> (organization_id IS NULL))
+);
+
+CREATE TRIGGER tax_profiles_updated_at BEFORE UPDATE ON tax_profiles
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
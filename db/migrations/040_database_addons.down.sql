@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS app_database_links;
+DROP TABLE IF EXISTS database_addons;
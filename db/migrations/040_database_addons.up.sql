@@ -0,0 +1,35 @@
+-- Database add-ons are Postgres databases users bring to the platform, such
+-- as a Neon or RDS database, to link to their apps. Linked apps get the
+-- connection injected as env vars, rewritten when the credentials change.
+-- The password is encrypted with ENCRYPTION_KEY.
+CREATE TABLE database_addons (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(63) NOT NULL,
+    host VARCHAR(255) NOT NULL,
+    port INTEGER DEFAULT 5432 NOT NULL,
+    database VARCHAR(63) NOT NULL,
+    username VARCHAR(63) NOT NULL,
+    password_encrypted BYTEA NOT NULL,
+    sslmode VARCHAR(20) DEFAULT 'require' NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    UNIQUE (user_id, name)
+);
+
+CREATE TRIGGER database_addons_updated_at BEFORE UPDATE ON database_addons
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+-- The variables a link injects, such as DATABASE_URL and PGHOST, each
+-- prefixed with prefix so an app can link several databases
+CREATE TABLE app_database_links (
+    app_id UUID NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    addon_id UUID NOT NULL REFERENCES database_addons(id) ON DELETE CASCADE,
+    prefix VARCHAR(32) DEFAULT '' NOT NULL,
+    variables TEXT[] NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (app_id, addon_id),
+    UNIQUE (app_id, prefix)
+);
+
+CREATE INDEX idx_app_database_links_addon_id ON app_database_links(addon_id);
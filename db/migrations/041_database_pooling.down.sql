@@ -0,0 +1,3 @@
+ALTER TABLE app_database_links
+    DROP COLUMN pool_size,
+    DROP COLUMN pooling;
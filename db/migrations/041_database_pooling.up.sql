@@ -0,0 +1,6 @@
+-- Pooled links route an app's connections through a PgBouncer sidecar in
+-- transaction pooling mode, holding at most pool_size server connections
+-- per pod, for databases with low connection limits such as Neon
+ALTER TABLE app_database_links
+    ADD COLUMN pooling BOOLEAN DEFAULT FALSE NOT NULL,
+    ADD COLUMN pool_size INTEGER DEFAULT 10 NOT NULL;
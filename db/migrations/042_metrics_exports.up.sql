@@ -0,0 +1,14 @@
+-- Metrics exports push the CPU, memory and request metrics the platform
+-- collects for an app to its owner's own monitoring, as Prometheus
+-- remote-write or JSON to a webhook. Headers carry the credentials and are
+-- encrypted with ENCRYPTION_KEY.
+CREATE TABLE app_metrics_exports (
+    app_id UUID PRIMARY KEY REFERENCES apps(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    url TEXT NOT NULL,
+    headers_encrypted BYTEA,
+    last_pushed_at TIMESTAMPTZ,
+    last_error TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
@@ -0,0 +1,12 @@
+-- An OpenTelemetry Collector runs in the namespace of an app with one,
+-- exporting the app's traces, metrics and logs to the user's OTLP backend.
+-- Headers carry the backend's credentials and are encrypted with
+-- ENCRYPTION_KEY.
+CREATE TABLE app_otel_collectors (
+    app_id UUID PRIMARY KEY REFERENCES apps(id) ON DELETE CASCADE,
+    endpoint TEXT NOT NULL,
+    protocol VARCHAR(10) DEFAULT 'grpc' NOT NULL,
+    headers_encrypted BYTEA,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
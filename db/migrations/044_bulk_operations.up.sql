@@ -0,0 +1,24 @@
+-- A bulk operation restarts, pauses, resumes or sets env vars on several of
+-- a user's apps in the background. Workers claim pending operations and
+-- lease them while they run, so one whose worker died is taken over once
+-- lease_until passes; results holds the outcome of each app done so far.
+-- Variables set by set-env are encrypted with ENCRYPTION_KEY. Like in
+-- activity_logs, api_token_id is not a foreign key.
+CREATE TABLE bulk_operations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    api_token_id UUID,
+    action VARCHAR(20) NOT NULL,
+    apps TEXT[] NOT NULL,
+    env_encrypted BYTEA,
+    unset_env TEXT[] DEFAULT '{}' NOT NULL,
+    status VARCHAR(20) DEFAULT 'pending' NOT NULL,
+    results JSONB DEFAULT '[]' NOT NULL,
+    lease_until TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_bulk_operations_user_id ON bulk_operations(user_id, created_at DESC);
+CREATE INDEX idx_bulk_operations_unfinished ON bulk_operations(created_at) WHERE status <> 'completed';
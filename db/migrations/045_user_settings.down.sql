@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS user_settings;
+ALTER TABLE apps DROP COLUMN IF EXISTS health_check_path;
@@ -0,0 +1,18 @@
+-- The path of an app's liveness and readiness probes
+ALTER TABLE apps ADD COLUMN health_check_path TEXT DEFAULT '/api/health' NOT NULL;
+
+-- Account defaults applied to the apps a user creates: an empty default
+-- falls back to the platform's. email_notifications turns off the
+-- notification emails sent to the user.
+CREATE TABLE user_settings (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    default_region VARCHAR(10) DEFAULT '' NOT NULL,
+    default_size VARCHAR(20) DEFAULT '' NOT NULL,
+    default_health_check_path TEXT DEFAULT '' NOT NULL,
+    email_notifications BOOLEAN DEFAULT TRUE NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE TRIGGER user_settings_updated_at BEFORE UPDATE ON user_settings
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
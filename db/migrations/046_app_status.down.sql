@@ -0,0 +1,4 @@
+ALTER TABLE apps ALTER COLUMN status DROP DEFAULT;
+ALTER TABLE apps ALTER COLUMN status TYPE VARCHAR(50) USING status::TEXT;
+ALTER TABLE apps ALTER COLUMN status SET DEFAULT 'stopped';
+DROP TYPE IF EXISTS app_status;
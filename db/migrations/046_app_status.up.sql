@@ -0,0 +1,13 @@
+-- App statuses, moved between along the transitions of internal/appstatus.
+-- Statuses written before they were constrained map onto the closest one.
+CREATE TYPE app_status AS ENUM ('stopped', 'deploying', 'running', 'failed', 'paused', 'suspended', 'purged');
+
+ALTER TABLE apps ALTER COLUMN status DROP DEFAULT;
+ALTER TABLE apps ALTER COLUMN status TYPE app_status USING (
+    CASE
+        WHEN status IN ('stopped', 'deploying', 'running', 'failed', 'paused', 'suspended', 'purged') THEN status
+        WHEN status = 'ready' THEN 'running'
+        ELSE 'stopped'
+    END
+)::app_status;
+ALTER TABLE apps ALTER COLUMN status SET DEFAULT 'stopped';
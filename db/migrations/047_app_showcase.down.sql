@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_apps_showcase;
+ALTER TABLE apps DROP COLUMN IF EXISTS showcase;
@@ -0,0 +1,4 @@
+-- Apps their owners opt in to the public directory at GET /api/showcase
+ALTER TABLE apps ADD COLUMN showcase BOOLEAN DEFAULT false NOT NULL;
+
+CREATE INDEX idx_apps_showcase ON apps(name, id) WHERE showcase;
@@ -0,0 +1,4 @@
+ALTER TABLE domains DROP COLUMN IF EXISTS hsts_preload;
+ALTER TABLE domains DROP COLUMN IF EXISTS hsts_include_subdomains;
+ALTER TABLE domains DROP COLUMN IF EXISTS hsts_max_age;
+ALTER TABLE domains DROP COLUMN IF EXISTS force_https;
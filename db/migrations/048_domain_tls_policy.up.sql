@@ -0,0 +1,7 @@
+-- How the ingress treats plain HTTP and HSTS for a custom domain. Redirects
+-- stay on by default; HSTS is off until its owner opts in, since browsers
+-- remember it for hsts_max_age seconds.
+ALTER TABLE domains ADD COLUMN force_https BOOLEAN DEFAULT true NOT NULL;
+ALTER TABLE domains ADD COLUMN hsts_max_age INTEGER DEFAULT 0 NOT NULL CHECK (hsts_max_age >= 0);
+ALTER TABLE domains ADD COLUMN hsts_include_subdomains BOOLEAN DEFAULT false NOT NULL;
+ALTER TABLE domains ADD COLUMN hsts_preload BOOLEAN DEFAULT false NOT NULL;
@@ -0,0 +1,9 @@
+-- Static egress IPs, allocated to apps from the EGRESS_IPS pool of their
+-- region; an app's outbound traffic leaves the cluster from its IP so third
+-- parties can allowlist it
+CREATE TABLE app_egress_ips (
+    app_id UUID PRIMARY KEY REFERENCES apps(id) ON DELETE CASCADE,
+    region VARCHAR(50) NOT NULL,
+    ip VARCHAR(45) UNIQUE NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS registry_usage;
+ALTER TABLE api_tokens DROP COLUMN IF EXISTS registry_access;
@@ -0,0 +1,12 @@
+-- Credentials for the platform registry are API tokens with the access they
+-- grant to the owner's repositories; other tokens cannot log in to it
+ALTER TABLE api_tokens ADD COLUMN registry_access VARCHAR(10) CHECK (registry_access IN ('pull', 'push'));
+
+-- Storage each user's repositories take in the platform registry, refreshed
+-- by the registry_usage job and checked against the quota before pushes
+CREATE TABLE registry_usage (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    bytes BIGINT DEFAULT 0 NOT NULL,
+    repositories INTEGER DEFAULT 0 NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
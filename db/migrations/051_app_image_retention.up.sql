@@ -0,0 +1,9 @@
+-- How many deployment images of an app the platform registry keeps; older
+-- ones are deleted by the image_retention job
+CREATE TABLE app_image_retention (
+    app_id UUID PRIMARY KEY REFERENCES apps(id) ON DELETE CASCADE,
+    keep_last INTEGER NOT NULL CHECK (keep_last BETWEEN 1 AND 100),
+    last_pruned_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
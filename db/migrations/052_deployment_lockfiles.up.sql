@@ -0,0 +1,7 @@
+-- Signed record of what each deployment ran: image digest, env hash and
+-- manifest hashes
+CREATE TABLE deployment_lockfiles (
+    deployment_id UUID PRIMARY KEY REFERENCES deployments(id) ON DELETE CASCADE,
+    lockfile JSONB NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
@@ -0,0 +1,7 @@
+-- Whether a user's GitHub account has two-factor authentication enabled, as
+-- GitHub reported it at the user's last login
+CREATE TABLE user_two_factor (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL,
+    checked_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS organizations_legal_hold ON organizations;
+DROP TRIGGER IF EXISTS activity_logs_legal_hold ON activity_logs;
+DROP TRIGGER IF EXISTS database_addons_legal_hold ON database_addons;
+DROP TRIGGER IF EXISTS apps_legal_hold ON apps;
+DROP FUNCTION IF EXISTS prevent_held_organization_delete();
+DROP FUNCTION IF EXISTS prevent_held_activity_log_delete();
+DROP FUNCTION IF EXISTS prevent_held_delete();
+DROP FUNCTION IF EXISTS legal_hold_covers(UUID);
+DROP TABLE IF EXISTS legal_holds;
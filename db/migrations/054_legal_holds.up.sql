@@ -0,0 +1,75 @@
+-- Legal holds keep the data of an organization's owner, members and machine
+-- users from being deleted until every hold is released: their apps,
+-- activity logs and database add-ons. Released holds are kept as history.
+CREATE TABLE legal_holds (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    placed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    released_at TIMESTAMPTZ,
+    released_by UUID REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_legal_holds_active ON legal_holds(org_id) WHERE released_at IS NULL;
+
+-- legal_hold_covers reports whether an unreleased hold of an organization the
+-- account owns, is an active member of or is a machine user of covers it
+CREATE OR REPLACE FUNCTION legal_hold_covers(account UUID)
+RETURNS BOOLEAN AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM legal_holds h
+        JOIN organizations o ON o.id = h.org_id
+        WHERE h.released_at IS NULL
+          AND (o.owner_id = account
+            OR EXISTS (SELECT 1 FROM organization_members m WHERE m.org_id = h.org_id AND m.user_id = account AND m.active)
+            OR EXISTS (SELECT 1 FROM machine_users mu WHERE mu.org_id = h.org_id AND mu.user_id = account))
+    );
+$$ LANGUAGE sql STABLE;
+
+-- Deletes of held data fail with SQLSTATE NXH01 whichever path they take,
+-- including cascades from deleting the account
+CREATE OR REPLACE FUNCTION prevent_held_delete()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF legal_hold_covers(OLD.user_id) THEN
+        RAISE EXCEPTION 'rows of % are under legal hold', TG_TABLE_NAME USING ERRCODE = 'NXH01';
+    END IF;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Activity logs belong to the app's owner as well as to the user who acted
+CREATE OR REPLACE FUNCTION prevent_held_activity_log_delete()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF legal_hold_covers(OLD.user_id)
+        OR legal_hold_covers((SELECT a.user_id FROM apps a WHERE a.id = OLD.app_id)) THEN
+        RAISE EXCEPTION 'rows of activity_logs are under legal hold' USING ERRCODE = 'NXH01';
+    END IF;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Deleting an organization would drop its holds without releasing them
+CREATE OR REPLACE FUNCTION prevent_held_organization_delete()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM legal_holds h WHERE h.org_id = OLD.id AND h.released_at IS NULL) THEN
+        RAISE EXCEPTION 'organization % is under legal hold', OLD.name USING ERRCODE = 'NXH01';
+    END IF;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER apps_legal_hold BEFORE DELETE ON apps
+    FOR EACH ROW EXECUTE FUNCTION prevent_held_delete();
+
+CREATE TRIGGER database_addons_legal_hold BEFORE DELETE ON database_addons
+    FOR EACH ROW EXECUTE FUNCTION prevent_held_delete();
+
+CREATE TRIGGER activity_logs_legal_hold BEFORE DELETE ON activity_logs
+    FOR EACH ROW EXECUTE FUNCTION prevent_held_activity_log_delete();
+
+CREATE TRIGGER organizations_legal_hold BEFORE DELETE ON organizations
+    FOR EACH ROW EXECUTE FUNCTION prevent_held_organization_delete();
@@ -0,0 +1,15 @@
+-- Subdomain zones users delegate to the platform's nameservers with NS
+-- records at their DNS host. Once the delegation is seen the zone is active:
+-- the platform answers for it, and domains of the user's apps in it are
+-- verified and routed without records of their own.
+CREATE TABLE dns_zones (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    zone VARCHAR(253) NOT NULL UNIQUE,
+    status VARCHAR(20) DEFAULT 'pending' NOT NULL CHECK (status IN ('pending', 'active')),
+    checked_at TIMESTAMPTZ,
+    delegated_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_dns_zones_user_id ON dns_zones(user_id);
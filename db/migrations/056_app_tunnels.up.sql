@@ -0,0 +1,11 @@
+-- A Cloudflare Tunnel per app on clusters without a public ingress. The
+-- app's cloudflared connectors run with the tunnel's token, encrypted with
+-- ENCRYPTION_KEY. dns_record_id is the proxied CNAME routing the app's
+-- hostname to the tunnel.
+CREATE TABLE app_tunnels (
+    app_id UUID PRIMARY KEY REFERENCES apps(id) ON DELETE CASCADE,
+    tunnel_id VARCHAR(64) NOT NULL,
+    token_encrypted BYTEA NOT NULL,
+    dns_record_id VARCHAR(64) DEFAULT '' NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
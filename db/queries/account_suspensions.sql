@@ -0,0 +1,24 @@
+-- name: CreateAccountSuspension :one
+-- Starts the pipeline for an account; returns no row when it already is in it
+INSERT INTO account_suspensions (user_id, invoice_id, failed_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id) DO NOTHING
+RETURNING *;
+
+-- name: GetAccountSuspension :one
+SELECT * FROM account_suspensions WHERE user_id = $1;
+
+-- name: ListAccountSuspensions :many
+SELECT s.user_id, s.stage, s.invoice_id, s.failed_at, s.warnings, s.suspended_at, s.purged_at, s.created_at, s.updated_at, u.username
+FROM account_suspensions s
+JOIN users u ON u.id = s.user_id
+ORDER BY s.failed_at;
+
+-- name: UpdateAccountSuspension :one
+UPDATE account_suspensions
+SET stage = $2, warnings = $3, suspended_at = $4, purged_at = $5, updated_at = NOW()
+WHERE user_id = $1
+RETURNING *;
+
+-- name: DeleteAccountSuspension :execrows
+DELETE FROM account_suspensions WHERE user_id = $1;
@@ -1,6 +1,6 @@
 -- name: CreateActivityLog :one
-INSERT INTO activity_logs (user_id, app_id, action, details, ip_address)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO activity_logs (user_id, app_id, action, details, ip_address, api_token_id)
+VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING *;
 
 -- name: ListActivityLogsByApp :many
@@ -15,6 +15,54 @@ WHERE user_id = $1
 ORDER BY created_at DESC
 LIMIT $2 OFFSET $3;
 
+-- name: ListActivityLogsForUsersBetween :many
+-- Activity of the given users and on their apps in a time range, oldest
+-- first
+SELECT l.* FROM activity_logs l
+LEFT JOIN apps a ON a.id = l.app_id
+WHERE (l.user_id = ANY(@user_ids::uuid[]) OR a.user_id = ANY(@user_ids::uuid[]))
+  AND l.created_at >= @from_time::timestamptz
+  AND l.created_at < @to_time::timestamptz
+ORDER BY l.created_at
+LIMIT @limit_count;
+
 -- name: CountActivityLogsByApp :one
 SELECT COUNT(*) FROM activity_logs
 WHERE app_id = $1;
+
+-- name: FilterActivityLogsByApp :many
+-- An app's activity, newest first, only that of a user and of an API token
+-- when given
+SELECT * FROM activity_logs
+WHERE app_id = @app_id
+  AND (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id'))
+  AND (sqlc.narg('api_token_id')::uuid IS NULL OR api_token_id = sqlc.narg('api_token_id'))
+ORDER BY created_at DESC
+LIMIT @limit_count OFFSET @offset_count;
+
+-- name: CountFilteredActivityLogsByApp :one
+SELECT COUNT(*) FROM activity_logs
+WHERE app_id = @app_id
+  AND (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id'))
+  AND (sqlc.narg('api_token_id')::uuid IS NULL OR api_token_id = sqlc.narg('api_token_id'));
+
+-- name: ListExpiredActivityLogs :many
+-- The oldest activity logs past the retention of the plan of the account
+-- they belong to: the app's owner, or the user who acted for entries of no
+-- app. Plans without a cutoff of their own use the default one. Entries a
+-- legal hold covers are kept.
+SELECT l.* FROM activity_logs l
+LEFT JOIN apps a ON a.id = l.app_id
+LEFT JOIN users u ON u.id = COALESCE(a.user_id, l.user_id)
+WHERE l.created_at < @newest_cutoff::timestamptz
+  AND l.created_at < COALESCE(
+    (SELECT c.cutoff FROM unnest(@plans::text[], @cutoffs::timestamptz[]) AS c(plan, cutoff) WHERE c.plan = u.plan),
+    @default_cutoff::timestamptz
+  )
+  AND NOT legal_hold_covers(a.user_id)
+  AND NOT legal_hold_covers(l.user_id)
+ORDER BY l.created_at
+LIMIT @batch_size;
+
+-- name: DeleteActivityLogs :execrows
+DELETE FROM activity_logs WHERE id = ANY(@ids::uuid[]);
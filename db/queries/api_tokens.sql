@@ -1,6 +1,6 @@
 -- name: CreateAPIToken :one
-INSERT INTO api_tokens (user_id, name, token_hash, expires_at)
-VALUES ($1, $2, $3, $4)
+INSERT INTO api_tokens (user_id, name, token_hash, expires_at, allowed_cidrs, expire_after_unused_days, registry_access)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
 RETURNING *;
 
 -- name: GetAPITokenByID :one
@@ -19,9 +19,59 @@ UPDATE api_tokens
 SET last_used_at = NOW()
 WHERE id = $1;
 
+-- name: RecordAPITokenUse :exec
+WITH usage AS (
+    INSERT INTO api_token_usage (token_id, day, count)
+    VALUES ($1, CURRENT_DATE, 1)
+    ON CONFLICT (token_id, day) DO UPDATE SET count = api_token_usage.count + 1
+)
+UPDATE api_tokens
+SET last_used_at = NOW(), last_used_ip = $2, last_used_user_agent = $3
+WHERE id = $1;
+
+-- name: ListAPITokenUsageByUser :many
+SELECT u.token_id, u.day, u.count
+FROM api_token_usage u
+JOIN api_tokens t ON t.id = u.token_id
+WHERE t.user_id = $1 AND u.day >= $2
+ORDER BY u.token_id, u.day;
+
+-- name: DeleteOldAPITokenUsage :exec
+DELETE FROM api_token_usage WHERE day < $1;
+
+-- name: DeleteUnusedAPITokens :execrows
+DELETE FROM api_tokens
+WHERE expire_after_unused_days IS NOT NULL
+  AND COALESCE(last_used_at, created_at) < NOW() - make_interval(days => expire_after_unused_days);
+
 -- name: DeleteAPIToken :exec
 DELETE FROM api_tokens WHERE id = $1;
 
 -- name: DeleteExpiredAPITokens :exec
 DELETE FROM api_tokens
 WHERE expires_at IS NOT NULL AND expires_at < NOW();
+
+-- name: DeleteAPITokensByUser :exec
+DELETE FROM api_tokens WHERE user_id = $1;
+
+-- name: GetMaxTokenLifetimeForUser :one
+SELECT COALESCE(MIN(o.max_token_lifetime_days), 0)::INTEGER AS max_days
+FROM organizations o
+JOIN (
+    SELECT org_id, user_id FROM organization_members WHERE active = TRUE
+    UNION ALL
+    SELECT org_id, user_id FROM machine_users
+) m ON m.org_id = o.id
+WHERE m.user_id = $1 AND o.max_token_lifetime_days IS NOT NULL;
+
+-- name: DeleteNonCompliantAPITokens :execrows
+DELETE FROM api_tokens t
+USING (
+    SELECT org_id, user_id FROM organization_members WHERE active = TRUE
+    UNION ALL
+    SELECT org_id, user_id FROM machine_users
+) m, organizations o
+WHERE m.user_id = t.user_id
+  AND o.id = m.org_id
+  AND o.max_token_lifetime_days IS NOT NULL
+  AND (t.expires_at IS NULL OR t.expires_at > t.created_at + make_interval(days => o.max_token_lifetime_days));
@@ -0,0 +1,25 @@
+-- name: AddAppBandwidth :exec
+INSERT INTO app_bandwidth (app_id, period_start, ingress_bytes, egress_bytes, requests)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (app_id, period_start) DO UPDATE SET
+    ingress_bytes = app_bandwidth.ingress_bytes + EXCLUDED.ingress_bytes,
+    egress_bytes = app_bandwidth.egress_bytes + EXCLUDED.egress_bytes,
+    requests = app_bandwidth.requests + EXCLUDED.requests;
+
+-- name: GetAppBandwidth :one
+SELECT COALESCE(SUM(ingress_bytes), 0)::BIGINT AS ingress_bytes,
+    COALESCE(SUM(egress_bytes), 0)::BIGINT AS egress_bytes,
+    COALESCE(SUM(requests), 0)::BIGINT AS requests
+FROM app_bandwidth
+WHERE app_id = $1 AND period_start >= $2;
+
+-- name: ListUserBandwidthByApp :many
+SELECT a.id AS app_id, a.name AS app_name, a.labels AS app_labels,
+    COALESCE(SUM(b.ingress_bytes), 0)::BIGINT AS ingress_bytes,
+    COALESCE(SUM(b.egress_bytes), 0)::BIGINT AS egress_bytes,
+    COALESCE(SUM(b.requests), 0)::BIGINT AS requests
+FROM app_bandwidth b
+JOIN apps a ON a.id = b.app_id
+WHERE a.user_id = sqlc.arg(user_id) AND b.period_start >= sqlc.arg(period_from)::TIMESTAMPTZ AND b.period_start < sqlc.arg(period_to)::TIMESTAMPTZ
+GROUP BY a.id, a.name, a.labels
+ORDER BY a.name;
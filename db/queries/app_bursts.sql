@@ -0,0 +1,34 @@
+-- name: GetAppBurst :one
+SELECT * FROM app_bursts WHERE app_id = $1;
+
+-- name: UpsertAppBurst :one
+INSERT INTO app_bursts (app_id, cpu_percent, p95_latency_ms, cooldown_minutes)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (app_id) DO UPDATE SET
+    cpu_percent = EXCLUDED.cpu_percent,
+    p95_latency_ms = EXCLUDED.p95_latency_ms,
+    cooldown_minutes = EXCLUDED.cooldown_minutes
+RETURNING *;
+
+-- name: DeleteAppBurst :exec
+DELETE FROM app_bursts WHERE app_id = $1;
+
+-- name: ListAppBursts :many
+SELECT b.app_id, b.cpu_percent, b.p95_latency_ms, b.cooldown_minutes, b.base_replicas, b.burst_started_at, b.burst_until,
+    a.user_id, a.name AS app_name, a.region, a.size
+FROM app_bursts b
+JOIN apps a ON a.id = b.app_id
+ORDER BY a.region, a.name;
+
+-- name: StartAppBurst :exec
+UPDATE app_bursts
+SET base_replicas = $2, burst_started_at = $3, burst_until = $4
+WHERE app_id = $1;
+
+-- name: ExtendAppBurst :exec
+UPDATE app_bursts SET burst_until = $2 WHERE app_id = $1;
+
+-- name: EndAppBurst :exec
+UPDATE app_bursts
+SET base_replicas = NULL, burst_started_at = NULL, burst_until = NULL
+WHERE app_id = $1;
@@ -0,0 +1,37 @@
+-- name: CreateAppCollaborator :one
+INSERT INTO app_collaborators (app_id, user_id, role, added_by)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: ListAppCollaborators :many
+SELECT c.id, c.app_id, c.user_id, c.role, c.added_by, c.created_at, c.updated_at, u.username
+FROM app_collaborators c
+JOIN users u ON u.id = c.user_id
+WHERE c.app_id = $1
+ORDER BY c.created_at;
+
+-- name: UpdateAppCollaboratorRole :one
+UPDATE app_collaborators
+SET role = $3, updated_at = NOW()
+WHERE app_id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteAppCollaborator :execrows
+DELETE FROM app_collaborators WHERE app_id = $1 AND user_id = $2;
+
+-- name: ListCollaborationsByAppName :many
+-- The apps with a name a user collaborates on, with the owner whom the
+-- collaborator acts as. Names are unique per owner only.
+SELECT c.role, a.id AS app_id, a.user_id AS owner_id, u.username AS owner_username
+FROM app_collaborators c
+JOIN apps a ON a.id = c.app_id
+JOIN users u ON u.id = a.user_id
+WHERE c.user_id = $1 AND a.name = $2;
+
+-- name: ListCollaborationsByUser :many
+SELECT c.role, c.created_at, a.id AS app_id, a.name AS app_name, u.username AS owner_username
+FROM app_collaborators c
+JOIN apps a ON a.id = c.app_id
+JOIN users u ON u.id = a.user_id
+WHERE c.user_id = $1
+ORDER BY a.name;
@@ -0,0 +1,16 @@
+-- name: GetAppEgressIP :one
+SELECT * FROM app_egress_ips WHERE app_id = $1;
+
+-- name: ListAllocatedEgressIPs :many
+SELECT ip FROM app_egress_ips
+WHERE region = $1
+ORDER BY ip;
+
+-- name: AllocateAppEgressIP :one
+INSERT INTO app_egress_ips (app_id, region, ip)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING
+RETURNING *;
+
+-- name: ReleaseAppEgressIP :exec
+DELETE FROM app_egress_ips WHERE app_id = $1;
@@ -0,0 +1,19 @@
+-- name: GetAppImageRetention :one
+SELECT * FROM app_image_retention WHERE app_id = $1;
+
+-- name: ListAppImageRetentions :many
+SELECT * FROM app_image_retention ORDER BY app_id;
+
+-- name: UpsertAppImageRetention :one
+INSERT INTO app_image_retention (app_id, keep_last)
+VALUES ($1, $2)
+ON CONFLICT (app_id) DO UPDATE SET
+    keep_last = EXCLUDED.keep_last,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: MarkAppImagesPruned :exec
+UPDATE app_image_retention SET last_pruned_at = NOW() WHERE app_id = $1;
+
+-- name: DeleteAppImageRetention :exec
+DELETE FROM app_image_retention WHERE app_id = $1;
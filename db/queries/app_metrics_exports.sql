@@ -0,0 +1,34 @@
+-- name: UpsertAppMetricsExport :one
+-- Configures an app's export, clearing the status of the previous one
+INSERT INTO app_metrics_exports (app_id, kind, url, headers_encrypted)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (app_id) DO UPDATE
+SET kind = EXCLUDED.kind, url = EXCLUDED.url, headers_encrypted = EXCLUDED.headers_encrypted,
+    last_pushed_at = NULL, last_error = NULL, updated_at = NOW()
+RETURNING *;
+
+-- name: GetAppMetricsExport :one
+SELECT * FROM app_metrics_exports
+WHERE app_id = $1;
+
+-- name: DeleteAppMetricsExport :execrows
+DELETE FROM app_metrics_exports
+WHERE app_id = $1;
+
+-- name: ListMetricsExportsByRegion :many
+-- The exports of the deployed apps of a region
+SELECT e.app_id, e.kind, e.url, e.headers_encrypted, a.name
+FROM app_metrics_exports e
+JOIN apps a ON a.id = e.app_id
+WHERE a.region = $1 AND a.current_deployment_id IS NOT NULL
+ORDER BY a.name;
+
+-- name: RecordMetricsExportPush :exec
+UPDATE app_metrics_exports
+SET last_pushed_at = NOW(), last_error = NULL
+WHERE app_id = $1;
+
+-- name: RecordMetricsExportError :exec
+UPDATE app_metrics_exports
+SET last_error = $2
+WHERE app_id = $1;
@@ -0,0 +1,24 @@
+-- name: GetAppMirror :one
+SELECT m.app_id, m.target_app_id, m.percent, m.expires_at, m.created_at, m.updated_at, t.name AS target_app_name
+FROM app_mirrors m
+JOIN apps t ON t.id = m.target_app_id
+WHERE m.app_id = $1;
+
+-- name: UpsertAppMirror :one
+INSERT INTO app_mirrors (app_id, target_app_id, percent, expires_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (app_id) DO UPDATE SET
+    target_app_id = EXCLUDED.target_app_id,
+    percent = EXCLUDED.percent,
+    expires_at = EXCLUDED.expires_at
+RETURNING *;
+
+-- name: DeleteAppMirror :exec
+DELETE FROM app_mirrors WHERE app_id = $1;
+
+-- name: ListExpiredAppMirrors :many
+SELECT m.app_id, m.target_app_id, m.percent, m.expires_at, a.user_id, a.name AS app_name, a.region, t.name AS target_app_name
+FROM app_mirrors m
+JOIN apps a ON a.id = m.app_id
+JOIN apps t ON t.id = m.target_app_id
+WHERE m.expires_at <= $1;
@@ -0,0 +1,38 @@
+-- name: GetAppMTLS :one
+SELECT * FROM app_mtls WHERE app_id = $1;
+
+-- name: EnableAppMTLS :one
+INSERT INTO app_mtls (app_id)
+VALUES ($1)
+ON CONFLICT (app_id) DO UPDATE SET app_id = EXCLUDED.app_id
+RETURNING *;
+
+-- name: DisableAppMTLS :exec
+DELETE FROM app_mtls WHERE app_id = $1;
+
+-- name: CreateClientCertificate :one
+INSERT INTO client_certificates (app_id, name, serial, fingerprint, certificate, not_after)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetClientCertificate :one
+SELECT * FROM client_certificates WHERE id = $1 AND app_id = $2;
+
+-- name: GetClientCertificateBySerial :one
+SELECT * FROM client_certificates WHERE serial = $1;
+
+-- name: ListClientCertificatesByApp :many
+SELECT * FROM client_certificates
+WHERE app_id = $1
+ORDER BY created_at DESC;
+
+-- name: ListRevokedClientCertificates :many
+SELECT * FROM client_certificates
+WHERE app_id = $1 AND revoked_at IS NOT NULL AND not_after > NOW()
+ORDER BY revoked_at;
+
+-- name: RevokeClientCertificate :one
+UPDATE client_certificates
+SET revoked_at = COALESCE(revoked_at, NOW())
+WHERE id = $1 AND app_id = $2
+RETURNING *;
@@ -0,0 +1,18 @@
+-- name: CreateAppOIDCTrust :one
+INSERT INTO app_oidc_trusts (app_id, provider, repository, ref, created_by)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListAppOIDCTrusts :many
+SELECT * FROM app_oidc_trusts WHERE app_id = $1 ORDER BY created_at;
+
+-- name: DeleteAppOIDCTrust :execrows
+DELETE FROM app_oidc_trusts WHERE id = $1 AND app_id = $2;
+
+-- name: ListOIDCTrustsForApp :many
+-- Trusts of the apps with a name for runs of a repository, with the owner
+-- of each app whom the deploy token acts as. Repositories are lowercase.
+SELECT t.id, t.app_id, t.provider, t.repository, t.ref, a.user_id, a.name AS app_name
+FROM app_oidc_trusts t
+JOIN apps a ON a.id = t.app_id
+WHERE a.name = $1 AND t.provider = $2 AND t.repository = $3;
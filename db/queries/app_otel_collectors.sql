@@ -0,0 +1,15 @@
+-- name: UpsertAppOtelCollector :one
+INSERT INTO app_otel_collectors (app_id, endpoint, protocol, headers_encrypted)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (app_id) DO UPDATE
+SET endpoint = EXCLUDED.endpoint, protocol = EXCLUDED.protocol,
+    headers_encrypted = EXCLUDED.headers_encrypted, updated_at = NOW()
+RETURNING *;
+
+-- name: GetAppOtelCollector :one
+SELECT * FROM app_otel_collectors
+WHERE app_id = $1;
+
+-- name: DeleteAppOtelCollector :execrows
+DELETE FROM app_otel_collectors
+WHERE app_id = $1;
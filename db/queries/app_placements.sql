@@ -0,0 +1,13 @@
+-- name: GetAppPlacement :one
+SELECT * FROM app_placements WHERE app_id = $1;
+
+-- name: UpsertAppPlacement :one
+INSERT INTO app_placements (app_id, node_selector, tolerations)
+VALUES ($1, $2, $3)
+ON CONFLICT (app_id) DO UPDATE
+SET node_selector = EXCLUDED.node_selector,
+    tolerations = EXCLUDED.tolerations
+RETURNING *;
+
+-- name: DeleteAppPlacement :exec
+DELETE FROM app_placements WHERE app_id = $1;
@@ -0,0 +1,30 @@
+-- name: ListAppProcesses :many
+SELECT * FROM app_processes
+WHERE app_id = $1
+ORDER BY process_type ASC;
+
+-- name: GetAppProcess :one
+SELECT * FROM app_processes WHERE app_id = $1 AND process_type = $2;
+
+-- name: CreateAppProcess :one
+INSERT INTO app_processes (app_id, process_type, command, replicas, cpu, memory)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: DeleteAppProcesses :exec
+DELETE FROM app_processes WHERE app_id = $1;
+
+-- name: UpsertAppProcessReplicas :one
+INSERT INTO app_processes (app_id, process_type, replicas)
+VALUES ($1, $2, $3)
+ON CONFLICT (app_id, process_type) DO UPDATE
+SET replicas = EXCLUDED.replicas
+RETURNING *;
+
+-- name: UpsertAppProcessResources :one
+INSERT INTO app_processes (app_id, process_type, cpu, memory)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (app_id, process_type) DO UPDATE
+SET cpu = EXCLUDED.cpu,
+    memory = EXCLUDED.memory
+RETURNING *;
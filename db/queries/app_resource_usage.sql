@@ -0,0 +1,39 @@
+-- name: AddAppResourceUsage :exec
+INSERT INTO app_resource_usage (app_id, period_start, samples, pod_samples, cpu_millis_sum, cpu_millis_max, memory_bytes_sum, memory_bytes_max)
+VALUES ($1, $2, 1, $3, $4, $5, $6, $7)
+ON CONFLICT (app_id, period_start) DO UPDATE SET
+    samples = app_resource_usage.samples + 1,
+    pod_samples = app_resource_usage.pod_samples + EXCLUDED.pod_samples,
+    cpu_millis_sum = app_resource_usage.cpu_millis_sum + EXCLUDED.cpu_millis_sum,
+    cpu_millis_max = GREATEST(app_resource_usage.cpu_millis_max, EXCLUDED.cpu_millis_max),
+    memory_bytes_sum = app_resource_usage.memory_bytes_sum + EXCLUDED.memory_bytes_sum,
+    memory_bytes_max = GREATEST(app_resource_usage.memory_bytes_max, EXCLUDED.memory_bytes_max);
+
+-- name: GetAppResourceUsage :one
+SELECT COUNT(*) AS hours,
+    COALESCE(SUM(samples), 0)::BIGINT AS samples,
+    COALESCE(SUM(pod_samples), 0)::BIGINT AS pod_samples,
+    COALESCE(SUM(cpu_millis_sum), 0)::BIGINT AS cpu_millis_sum,
+    COALESCE(MAX(cpu_millis_max), 0)::BIGINT AS cpu_millis_max,
+    COALESCE(SUM(memory_bytes_sum), 0)::BIGINT AS memory_bytes_sum,
+    COALESCE(MAX(memory_bytes_max), 0)::BIGINT AS memory_bytes_max
+FROM app_resource_usage
+WHERE app_id = $1 AND period_start >= $2;
+
+-- name: ListAppResourceUsage :many
+SELECT a.id AS app_id, a.name AS app_name, a.user_id, a.size,
+    COUNT(*) AS hours,
+    SUM(u.samples)::BIGINT AS samples,
+    SUM(u.pod_samples)::BIGINT AS pod_samples,
+    SUM(u.cpu_millis_sum)::BIGINT AS cpu_millis_sum,
+    MAX(u.cpu_millis_max)::BIGINT AS cpu_millis_max,
+    SUM(u.memory_bytes_sum)::BIGINT AS memory_bytes_sum,
+    MAX(u.memory_bytes_max)::BIGINT AS memory_bytes_max
+FROM app_resource_usage u
+JOIN apps a ON a.id = u.app_id
+WHERE u.period_start >= $1
+GROUP BY a.id, a.name, a.user_id, a.size
+ORDER BY a.user_id, a.name;
+
+-- name: DeleteAppResourceUsageBefore :exec
+DELETE FROM app_resource_usage WHERE period_start < $1;
@@ -0,0 +1,13 @@
+-- name: CreateAppTunnel :one
+INSERT INTO app_tunnels (app_id, tunnel_id, token_encrypted)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetAppTunnel :one
+SELECT * FROM app_tunnels
+WHERE app_id = $1;
+
+-- name: SetAppTunnelDNSRecord :exec
+UPDATE app_tunnels
+SET dns_record_id = $2
+WHERE app_id = $1;
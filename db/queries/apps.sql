@@ -23,9 +23,11 @@ WHERE id = $1
 RETURNING *;
 
 -- name: UpdateAppStatus :one
+-- Moves an app to a status from one of from_statuses, those the status may
+-- be reached from; returns no row for an app in any other status
 UPDATE apps
-SET status = $2, current_deployment_id = $3
-WHERE id = $1
+SET status = sqlc.arg(status), current_deployment_id = sqlc.arg(current_deployment_id)
+WHERE id = sqlc.arg(id) AND status::TEXT = ANY(sqlc.arg(from_statuses)::TEXT[])
 RETURNING *;
 
 -- name: IncrementDeploymentCount :one
@@ -45,3 +47,75 @@ DELETE FROM apps WHERE id = $1;
 
 -- name: CountAppsByUser :one
 SELECT COUNT(*) FROM apps WHERE user_id = $1;
+
+-- name: ListAppsByRegion :many
+SELECT * FROM apps
+WHERE region = $1;
+
+-- name: ListAppsByUserAndStatus :many
+SELECT * FROM apps
+WHERE user_id = $1 AND status = $2
+ORDER BY name;
+
+-- name: UpdateAppMetadata :one
+UPDATE apps
+SET description = $2, icon_url = $3, repository_url = $4, tags = $5
+WHERE id = $1
+RETURNING *;
+
+-- name: SearchAppsByUser :many
+-- search matches the name or description (an ILIKE pattern) or a tag exactly;
+-- every one of tags must be present
+SELECT * FROM apps
+WHERE user_id = sqlc.arg(user_id)
+  AND (sqlc.arg(search)::TEXT = ''
+    OR name ILIKE sqlc.arg(pattern)::TEXT
+    OR description ILIKE sqlc.arg(pattern)::TEXT
+    OR sqlc.arg(search)::TEXT = ANY(tags))
+  AND tags @> sqlc.arg(tags)::TEXT[]
+ORDER BY created_at DESC
+LIMIT sqlc.arg(max_apps)::INTEGER OFFSET sqlc.arg(skip)::INTEGER;
+
+-- name: SetAppProject :one
+UPDATE apps
+SET project_id = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: ListAppsByProject :many
+SELECT * FROM apps
+WHERE project_id = $1
+ORDER BY name;
+
+-- name: UpdateAppLabels :one
+UPDATE apps
+SET labels = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateAppHealthCheckPath :one
+UPDATE apps
+SET health_check_path = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateAppShowcase :one
+UPDATE apps
+SET showcase = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: ListShowcaseApps :many
+-- Apps in the public showcase; suspended and purged apps drop out of it
+SELECT * FROM apps
+WHERE showcase AND status NOT IN ('suspended', 'purged')
+ORDER BY name, id
+LIMIT $1 OFFSET $2;
+
+-- name: CountShowcaseApps :one
+SELECT COUNT(*) FROM apps
+WHERE showcase AND status NOT IN ('suspended', 'purged');
+
+-- name: GetShowcaseApp :one
+SELECT * FROM apps
+WHERE id = $1 AND showcase AND status NOT IN ('suspended', 'purged');
@@ -0,0 +1,46 @@
+-- name: CreateBulkOperation :one
+INSERT INTO bulk_operations (user_id, api_token_id, action, apps, env_encrypted, unset_env)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetBulkOperation :one
+SELECT * FROM bulk_operations
+WHERE id = $1 AND user_id = $2;
+
+-- name: ListBulkOperationsByUser :many
+SELECT * FROM bulk_operations
+WHERE user_id = $1
+ORDER BY created_at DESC
+LIMIT $2;
+
+-- name: ClaimBulkOperations :many
+-- Claims pending operations, and running ones whose worker's lease ran out,
+-- leasing them until lease_until
+UPDATE bulk_operations
+SET status = 'running',
+    started_at = COALESCE(started_at, sqlc.arg(now)::TIMESTAMPTZ),
+    lease_until = sqlc.arg(lease_until)::TIMESTAMPTZ
+WHERE id IN (
+    SELECT id FROM bulk_operations
+    WHERE status = 'pending' OR (status = 'running' AND lease_until <= sqlc.arg(now)::TIMESTAMPTZ)
+    ORDER BY created_at
+    LIMIT sqlc.arg(max_operations)::INTEGER
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: AppendBulkOperationResult :exec
+-- Records the outcome of one app, extending the lease
+UPDATE bulk_operations
+SET results = results || jsonb_build_array(sqlc.arg(result)::JSONB),
+    lease_until = sqlc.arg(lease_until)::TIMESTAMPTZ
+WHERE id = sqlc.arg(id);
+
+-- name: CompleteBulkOperation :exec
+UPDATE bulk_operations
+SET status = 'completed', finished_at = NOW(), lease_until = NULL
+WHERE id = $1;
+
+-- name: DeleteCompletedBulkOperations :execrows
+DELETE FROM bulk_operations
+WHERE status = 'completed' AND finished_at < sqlc.arg(before)::TIMESTAMPTZ;
@@ -0,0 +1,20 @@
+-- name: CreateCreditEntry :one
+INSERT INTO credit_ledger (user_id, amount, reason, description, invoice_id, granted_by)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetCreditBalance :one
+SELECT COALESCE(SUM(amount), 0)::BIGINT AS balance FROM credit_ledger WHERE user_id = $1;
+
+-- name: GetCreditEntryByInvoice :one
+SELECT * FROM credit_ledger WHERE invoice_id = $1;
+
+-- name: ListCreditEntries :many
+SELECT * FROM credit_ledger
+WHERE user_id = $1
+ORDER BY created_at DESC
+LIMIT $2;
+
+-- name: LockCreditBalance :exec
+-- Serializes changes to an account's balance until the transaction ends
+SELECT 1 FROM users WHERE id = $1 FOR UPDATE;
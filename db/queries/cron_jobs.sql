@@ -0,0 +1,29 @@
+-- name: ListCronJobsByApp :many
+SELECT * FROM cron_jobs
+WHERE app_id = $1
+ORDER BY name ASC;
+
+-- name: GetCronJobByName :one
+SELECT * FROM cron_jobs WHERE app_id = $1 AND name = $2;
+
+-- name: CreateCronJob :one
+INSERT INTO cron_jobs (app_id, name, schedule, command, timezone, concurrency_policy, starting_deadline_seconds, active_deadline_seconds, successful_history_limit, failed_history_limit, suspended)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING *;
+
+-- name: UpdateCronJob :one
+UPDATE cron_jobs
+SET schedule = $3,
+    command = $4,
+    timezone = $5,
+    concurrency_policy = $6,
+    starting_deadline_seconds = $7,
+    active_deadline_seconds = $8,
+    successful_history_limit = $9,
+    failed_history_limit = $10,
+    suspended = $11
+WHERE app_id = $1 AND name = $2
+RETURNING *;
+
+-- name: DeleteCronJob :exec
+DELETE FROM cron_jobs WHERE app_id = $1 AND name = $2;
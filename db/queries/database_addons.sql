@@ -0,0 +1,52 @@
+-- name: CreateDatabaseAddon :one
+INSERT INTO database_addons (user_id, name, host, port, database, username, password_encrypted, sslmode)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetDatabaseAddonByName :one
+SELECT * FROM database_addons
+WHERE user_id = $1 AND name = $2;
+
+-- name: ListDatabaseAddons :many
+SELECT * FROM database_addons
+WHERE user_id = $1
+ORDER BY name;
+
+-- name: UpdateDatabaseAddonCredentials :one
+UPDATE database_addons
+SET host = $2, port = $3, database = $4, username = $5, password_encrypted = $6, sslmode = $7
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteDatabaseAddon :exec
+DELETE FROM database_addons WHERE id = $1;
+
+-- name: LinkDatabaseAddon :one
+-- Links an add-on to an app, or changes the variables and pooling of an
+-- existing link
+INSERT INTO app_database_links (app_id, addon_id, prefix, variables, pooling, pool_size)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (app_id, addon_id) DO UPDATE
+SET prefix = EXCLUDED.prefix, variables = EXCLUDED.variables,
+    pooling = EXCLUDED.pooling, pool_size = EXCLUDED.pool_size
+RETURNING *;
+
+-- name: UnlinkDatabaseAddon :execrows
+DELETE FROM app_database_links
+WHERE app_id = $1 AND addon_id = $2;
+
+-- name: ListAppDatabaseLinks :many
+-- The add-ons linked to an app with their credentials, to render its env
+SELECT l.addon_id, l.prefix, l.variables, l.pooling, l.pool_size, l.created_at,
+       a.name, a.host, a.port, a.database, a.username, a.password_encrypted, a.sslmode
+FROM app_database_links l
+JOIN database_addons a ON a.id = l.addon_id
+WHERE l.app_id = $1
+ORDER BY l.prefix, a.name;
+
+-- name: ListAppsLinkedToDatabaseAddon :many
+SELECT apps.id, apps.user_id, apps.name, apps.region, apps.size, apps.status, apps.deployment_count, apps.current_deployment_id, apps.env_vars_encrypted, apps.created_at, apps.updated_at, apps.description, apps.icon_url, apps.repository_url, apps.tags, apps.project_id, apps.labels, apps.health_check_path, apps.showcase
+FROM apps
+JOIN app_database_links l ON l.app_id = apps.id
+WHERE l.addon_id = $1
+ORDER BY apps.name;
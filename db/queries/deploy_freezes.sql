@@ -0,0 +1,26 @@
+-- name: CreateDeployFreeze :one
+INSERT INTO deploy_freezes (org_id, name, start_day, start_minute, end_day, end_minute, timezone, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetDeployFreeze :one
+SELECT * FROM deploy_freezes WHERE org_id = $1 AND name = $2;
+
+-- name: ListDeployFreezesByOrg :many
+SELECT * FROM deploy_freezes
+WHERE org_id = $1
+ORDER BY name;
+
+-- name: DeleteDeployFreeze :exec
+DELETE FROM deploy_freezes WHERE id = $1;
+
+-- name: ListDeployFreezesForUser :many
+-- Freezes of the organizations a user owns, is an active member of or is a
+-- machine user of
+SELECT f.id, f.org_id, f.name, f.start_day, f.start_minute, f.end_day, f.end_minute, f.timezone, o.name AS org_name, o.owner_id AS org_owner_id
+FROM deploy_freezes f
+JOIN organizations o ON o.id = f.org_id
+WHERE o.owner_id = $1
+    OR EXISTS (SELECT 1 FROM organization_members m WHERE m.org_id = f.org_id AND m.user_id = $1 AND m.active)
+    OR EXISTS (SELECT 1 FROM machine_users mu WHERE mu.org_id = f.org_id AND mu.user_id = $1)
+ORDER BY o.name, f.name;
@@ -0,0 +1,24 @@
+-- name: GetDeployHooksByApp :one
+SELECT * FROM deploy_hooks WHERE app_id = $1;
+
+-- name: UpsertDeployHooks :one
+INSERT INTO deploy_hooks (app_id, pre_deploy_command, post_deploy_command, timeout_seconds)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (app_id) DO UPDATE
+SET pre_deploy_command = EXCLUDED.pre_deploy_command,
+    post_deploy_command = EXCLUDED.post_deploy_command,
+    timeout_seconds = EXCLUDED.timeout_seconds
+RETURNING *;
+
+-- name: DeleteDeployHooks :exec
+DELETE FROM deploy_hooks WHERE app_id = $1;
+
+-- name: CreateDeploymentHookRun :one
+INSERT INTO deployment_hook_runs (deployment_id, phase, command, job_name, status, output, error, started_at, finished_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: ListDeploymentHookRuns :many
+SELECT * FROM deployment_hook_runs
+WHERE deployment_id = $1
+ORDER BY created_at ASC;
@@ -0,0 +1,7 @@
+-- name: CreateDeploymentLockfile :one
+INSERT INTO deployment_lockfiles (deployment_id, lockfile)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: GetDeploymentLockfile :one
+SELECT * FROM deployment_lockfiles WHERE deployment_id = $1;
@@ -0,0 +1,13 @@
+-- name: CreateDeploymentProvenance :one
+INSERT INTO deployment_provenance (deployment_id, provider, run_id, run_number, run_url, commit_sha, actor, repository, ref, workflow, verified, issuer, subject)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+RETURNING *;
+
+-- name: GetDeploymentProvenance :one
+SELECT * FROM deployment_provenance WHERE deployment_id = $1;
+
+-- name: ListDeploymentProvenanceByApp :many
+SELECT p.deployment_id, p.provider, p.run_id, p.run_number, p.run_url, p.commit_sha, p.actor, p.repository, p.ref, p.workflow, p.verified, p.issuer, p.subject, p.created_at
+FROM deployment_provenance p
+JOIN deployments d ON d.id = p.deployment_id
+WHERE d.app_id = $1;
@@ -1,6 +1,6 @@
 -- name: CreateDeployment :one
-INSERT INTO deployments (app_id, version, image, status)
-VALUES ($1, $2, $3, $4)
+INSERT INTO deployments (app_id, version, image, status, architectures, deployed_by_user_id, deployed_by_token_id, deployed_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 RETURNING *;
 
 -- name: GetDeploymentByID :one
@@ -12,6 +12,20 @@ WHERE app_id = $1
 ORDER BY created_at DESC
 LIMIT $2 OFFSET $3;
 
+-- name: ListDeploymentsForUsersBetween :many
+-- Deployments of the apps of the given users in a time range, oldest first,
+-- with the CI run that made them
+SELECT d.id, a.name AS app_name, d.version, d.image, d.status, d.deployed_by, d.created_at,
+       p.provider, p.repository, p.commit_sha, p.run_url, p.verified
+FROM deployments d
+JOIN apps a ON a.id = d.app_id
+LEFT JOIN deployment_provenance p ON p.deployment_id = d.id
+WHERE a.user_id = ANY(@user_ids::uuid[])
+  AND d.created_at >= @from_time::timestamptz
+  AND d.created_at < @to_time::timestamptz
+ORDER BY d.created_at
+LIMIT @limit_count;
+
 -- name: GetLatestDeployment :one
 SELECT * FROM deployments
 WHERE app_id = $1
@@ -38,7 +52,7 @@ RETURNING *;
 
 -- name: UpdateDeploymentFailed :one
 UPDATE deployments
-SET status = 'failed', error = $2
+SET status = 'failed', error = $2, failure_reason = $3
 WHERE id = $1
 RETURNING *;
 
@@ -47,3 +61,50 @@ DELETE FROM deployments WHERE id = $1;
 
 -- name: CountDeploymentsByApp :one
 SELECT COUNT(*) FROM deployments WHERE app_id = $1;
+
+-- name: CountDeploymentsByUserSince :one
+SELECT COUNT(*)
+FROM deployments d
+JOIN apps a ON a.id = d.app_id
+WHERE a.user_id = $1 AND d.created_at >= $2;
+
+-- name: CountQueuedDeploymentsByRegion :many
+SELECT a.region, COUNT(*) AS queued
+FROM deployments d
+JOIN apps a ON a.id = d.app_id
+WHERE d.status IN ('pending', 'building')
+GROUP BY a.region;
+
+-- name: ListRunningDeployments :many
+SELECT d.id, d.app_id, d.version, d.oom_kills, d.crash_loops, d.last_crash_at, d.crash_alerted_at, d.created_at,
+       a.name AS app_name, a.region, a.user_id
+FROM deployments d
+JOIN apps a ON a.current_deployment_id = d.id
+WHERE d.status = 'running'
+ORDER BY a.region, a.name;
+
+-- name: ListStaleDeployments :many
+-- Deployments building since before a rollout could still be running, whose
+-- rollout ended without recording an outcome, such as on a restart
+SELECT * FROM deployments
+WHERE status = 'building' AND started_at < @started_before::timestamptz
+ORDER BY started_at;
+
+-- name: RecordDeploymentCrashes :one
+UPDATE deployments
+SET oom_kills = oom_kills + $2, crash_loops = crash_loops + $3, last_crash_at = $4
+WHERE id = $1
+RETURNING *;
+
+-- name: MarkDeploymentCrashAlerted :exec
+UPDATE deployments SET crash_alerted_at = NOW() WHERE id = $1;
+
+-- name: ListDeploymentImagesByApp :many
+SELECT version, image FROM deployments
+WHERE app_id = $1
+ORDER BY version DESC;
+
+-- name: ListCurrentDeploymentImages :many
+SELECT DISTINCT d.image FROM deployments d
+JOIN apps a ON a.current_deployment_id = d.id
+ORDER BY d.image;
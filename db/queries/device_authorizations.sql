@@ -0,0 +1,38 @@
+-- name: CreateDeviceAuthorization :one
+INSERT INTO device_authorizations (device_code_hash, user_code, client_name, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetDeviceAuthorizationByDeviceCode :one
+SELECT * FROM device_authorizations WHERE device_code_hash = $1;
+
+-- name: GetDeviceAuthorizationByUserCode :one
+SELECT * FROM device_authorizations WHERE user_code = $1;
+
+-- name: ApproveDeviceAuthorization :one
+UPDATE device_authorizations
+SET status = 'approved', user_id = $2
+WHERE id = $1 AND status = 'pending' AND expires_at > NOW()
+RETURNING *;
+
+-- name: DenyDeviceAuthorization :one
+UPDATE device_authorizations
+SET status = 'denied', user_id = $2
+WHERE id = $1 AND status = 'pending' AND expires_at > NOW()
+RETURNING *;
+
+-- name: UpdateDeviceAuthorizationPoll :exec
+UPDATE device_authorizations
+SET last_polled_at = NOW(), poll_interval = $2
+WHERE id = $1;
+
+-- name: ConsumeDeviceAuthorization :one
+DELETE FROM device_authorizations
+WHERE id = $1 AND status = 'approved'
+RETURNING *;
+
+-- name: DeleteDeviceAuthorization :exec
+DELETE FROM device_authorizations WHERE id = $1;
+
+-- name: DeleteExpiredDeviceAuthorizations :exec
+DELETE FROM device_authorizations WHERE expires_at < NOW();
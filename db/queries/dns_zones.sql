@@ -0,0 +1,80 @@
+-- name: CreateDNSZone :one
+INSERT INTO dns_zones (user_id, zone)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: GetDNSZoneByName :one
+SELECT * FROM dns_zones WHERE zone = $1;
+
+-- name: ListDNSZonesByUser :many
+SELECT * FROM dns_zones
+WHERE user_id = $1
+ORDER BY zone;
+
+-- name: ListPendingDNSZones :many
+SELECT * FROM dns_zones
+WHERE status = 'pending'
+ORDER BY created_at;
+
+-- name: ListOverlappingDNSZones :many
+-- Zones equal to, inside or above the given one
+SELECT * FROM dns_zones
+WHERE zone = @zone::text
+   OR right(@zone::text, length(zone) + 1) = '.' || zone
+   OR right(zone, length(@zone::text) + 1) = '.' || @zone::text
+ORDER BY zone;
+
+-- name: GetActiveDNSZoneForDomain :one
+-- The closest active zone a domain is in
+SELECT * FROM dns_zones
+WHERE status = 'active'
+  AND (zone = @domain::text OR right(@domain::text, length(zone) + 1) = '.' || zone)
+ORDER BY length(zone) DESC
+LIMIT 1;
+
+-- name: MarkDNSZoneChecked :exec
+UPDATE dns_zones SET checked_at = NOW() WHERE id = $1;
+
+-- name: ActivateDNSZone :one
+UPDATE dns_zones SET status = 'active', checked_at = NOW(), delegated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteDNSZone :exec
+DELETE FROM dns_zones WHERE id = $1;
+
+-- name: ListDomainsInDNSZone :many
+-- Domains of a user's apps in a zone
+SELECT d.domain, d.verified, a.name AS app_name
+FROM domains d
+JOIN apps a ON a.id = d.app_id
+WHERE a.user_id = @user_id
+  AND (d.domain = @zone::text OR right(d.domain, length(@zone::text) + 1) = '.' || @zone::text)
+ORDER BY d.domain;
+
+-- name: VerifyDomainsInDNSZone :execrows
+-- Verifies the domains of a user's apps in a zone delegated by the user,
+-- whose ownership the delegation proves
+UPDATE domains d SET verified = TRUE, verified_at = NOW()
+FROM apps a
+WHERE a.id = d.app_id
+  AND a.user_id = @user_id
+  AND NOT d.verified
+  AND (d.domain = @zone::text OR right(d.domain, length(@zone::text) + 1) = '.' || @zone::text);
+
+-- name: ListDNSZoneRecords :many
+-- The verified domains of apps in active zones with the app routing them,
+-- which the platform's nameservers answer for
+SELECT z.zone, d.domain, d.dns_mode, a.name AS app_name, a.region
+FROM dns_zones z
+JOIN apps a ON a.user_id = z.user_id
+JOIN domains d ON d.app_id = a.id
+WHERE z.status = 'active'
+  AND d.verified
+  AND (d.domain = z.zone OR right(d.domain, length(z.zone) + 1) = '.' || z.zone)
+ORDER BY z.zone, d.domain;
+
+-- name: ListActiveDNSZones :many
+SELECT * FROM dns_zones
+WHERE status = 'active'
+ORDER BY zone;
@@ -1,6 +1,6 @@
 -- name: CreateDomain :one
-INSERT INTO domains (app_id, domain)
-VALUES ($1, $2)
+INSERT INTO domains (app_id, domain, verification_token, dns_mode)
+VALUES ($1, $2, $3, $4)
 RETURNING *;
 
 -- name: GetDomainByID :one
@@ -26,8 +26,30 @@ SET ssl_status = $2
 WHERE id = $1
 RETURNING *;
 
+-- name: UpdateDomainTLSPolicy :one
+UPDATE domains
+SET force_https = $2, hsts_max_age = $3, hsts_include_subdomains = $4, hsts_preload = $5
+WHERE id = $1
+RETURNING *;
+
 -- name: DeleteDomain :exec
 DELETE FROM domains WHERE id = $1;
 
 -- name: CountDomainsByApp :one
 SELECT COUNT(*) FROM domains WHERE app_id = $1;
+
+-- name: ListVerifiedDomainsWithApps :many
+SELECT d.id, d.domain, d.ssl_status, d.ssl_alerted_at, a.id AS app_id, a.name AS app_name, a.region, a.user_id
+FROM domains d
+JOIN apps a ON a.id = d.app_id
+WHERE d.verified = TRUE
+ORDER BY a.region, a.name;
+
+-- name: UpdateDomainCertificate :one
+UPDATE domains
+SET ssl_status = $2, ssl_expires_at = $3, ssl_error = $4, ssl_checked_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: MarkDomainCertificateAlerted :exec
+UPDATE domains SET ssl_alerted_at = NOW() WHERE id = $1;
@@ -0,0 +1,27 @@
+-- name: CreateEvent :one
+INSERT INTO events (type, user_id, app_id, app_name, message, ip_address, payload, api_token_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: ListEventsAfter :many
+SELECT * FROM events
+WHERE id > $1
+ORDER BY id
+LIMIT $2;
+
+-- name: EnsureEventCursor :one
+-- New subscribers start at the current end of the log instead of replaying
+-- history
+INSERT INTO event_cursors (subscriber, last_event_id)
+VALUES ($1, (SELECT COALESCE(MAX(id), 0) FROM events))
+ON CONFLICT (subscriber) DO UPDATE SET subscriber = EXCLUDED.subscriber
+RETURNING *;
+
+-- name: AdvanceEventCursor :exec
+UPDATE event_cursors SET last_event_id = $2
+WHERE subscriber = $1 AND last_event_id < $2;
+
+-- name: DeleteDeliveredEvents :execrows
+DELETE FROM events
+WHERE created_at < $1
+  AND id <= (SELECT COALESCE(MIN(last_event_id), 0) FROM event_cursors);
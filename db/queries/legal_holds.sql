@@ -0,0 +1,29 @@
+-- name: CreateLegalHold :one
+INSERT INTO legal_holds (org_id, reason, placed_by)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetLegalHold :one
+SELECT * FROM legal_holds WHERE org_id = $1 AND id = $2;
+
+-- name: ListLegalHoldsByOrg :many
+SELECT * FROM legal_holds
+WHERE org_id = $1
+ORDER BY created_at DESC;
+
+-- name: ReleaseLegalHold :one
+UPDATE legal_holds SET released_at = NOW(), released_by = $2
+WHERE id = $1 AND released_at IS NULL
+RETURNING *;
+
+-- name: ListActiveLegalHoldsForUser :many
+-- Unreleased holds of the organizations a user owns, is an active member of
+-- or is a machine user of
+SELECT h.id, h.org_id, h.reason, h.created_at, o.name AS org_name
+FROM legal_holds h
+JOIN organizations o ON o.id = h.org_id
+WHERE h.released_at IS NULL
+  AND (o.owner_id = $1
+    OR EXISTS (SELECT 1 FROM organization_members m WHERE m.org_id = h.org_id AND m.user_id = $1 AND m.active)
+    OR EXISTS (SELECT 1 FROM machine_users mu WHERE mu.org_id = h.org_id AND mu.user_id = $1))
+ORDER BY h.created_at;
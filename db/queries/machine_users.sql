@@ -0,0 +1,30 @@
+-- name: CreateMachineUserAccount :one
+INSERT INTO users (github_id, username, email)
+VALUES (-nextval('machine_user_github_ids'), $1, '')
+RETURNING *;
+
+-- name: CreateMachineUser :one
+INSERT INTO machine_users (user_id, org_id, name, description, created_by, max_apps, max_deployments_per_day)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetMachineUser :one
+SELECT * FROM machine_users
+WHERE org_id = $1 AND name = $2;
+
+-- name: GetMachineUserByUserID :one
+SELECT * FROM machine_users WHERE user_id = $1;
+
+-- name: ListMachineUsersByOrg :many
+SELECT * FROM machine_users
+WHERE org_id = $1
+ORDER BY name ASC;
+
+-- name: UpdateMachineUser :one
+UPDATE machine_users
+SET description = $2, max_apps = $3, max_deployments_per_day = $4
+WHERE user_id = $1
+RETURNING *;
+
+-- name: DeleteMachineUser :exec
+DELETE FROM machine_users WHERE user_id = $1;
@@ -0,0 +1,32 @@
+-- name: CreateMaintenanceWindow :one
+INSERT INTO maintenance_windows (title, message, starts_at, ends_at, created_by)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetMaintenanceWindow :one
+SELECT * FROM maintenance_windows WHERE id = $1;
+
+-- name: UpdateMaintenanceWindow :one
+UPDATE maintenance_windows
+SET title = $2, message = $3, starts_at = $4, ends_at = $5
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteMaintenanceWindow :exec
+DELETE FROM maintenance_windows WHERE id = $1;
+
+-- name: ListMaintenanceWindows :many
+SELECT * FROM maintenance_windows
+ORDER BY starts_at DESC
+LIMIT $1 OFFSET $2;
+
+-- name: ListUpcomingMaintenanceWindows :many
+-- Windows in progress or not yet started, soonest first
+SELECT * FROM maintenance_windows
+WHERE ends_at > sqlc.arg(now)::TIMESTAMPTZ
+ORDER BY starts_at
+LIMIT sqlc.arg(max_windows)::INTEGER;
+
+-- name: CountActiveMaintenanceWindows :one
+SELECT COUNT(*) FROM maintenance_windows
+WHERE starts_at <= sqlc.arg(now)::TIMESTAMPTZ AND ends_at > sqlc.arg(now)::TIMESTAMPTZ;
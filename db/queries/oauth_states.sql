@@ -1,6 +1,6 @@
 -- name: CreateOAuthState :one
-INSERT INTO oauth_states (state, redirect_uri, cli_token_exchange, expires_at)
-VALUES ($1, $2, $3, $4)
+INSERT INTO oauth_states (state, redirect_uri, cli_token_exchange, expires_at, referrer)
+VALUES ($1, $2, $3, $4, $5)
 RETURNING *;
 
 -- name: GetOAuthState :one
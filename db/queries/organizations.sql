@@ -0,0 +1,87 @@
+-- name: CreateOrganization :one
+INSERT INTO organizations (name, owner_id)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: GetOrganizationByName :one
+SELECT * FROM organizations WHERE name = $1;
+
+-- name: GetOrganizationBySCIMTokenHash :one
+SELECT * FROM organizations WHERE scim_token_hash = $1;
+
+-- name: ListOrganizationsByOwner :many
+SELECT * FROM organizations
+WHERE owner_id = $1
+ORDER BY created_at DESC;
+
+-- name: UpdateOrganizationSCIMToken :exec
+UPDATE organizations
+SET scim_token_hash = $2
+WHERE id = $1;
+
+-- name: UpdateOrganizationTokenPolicy :one
+UPDATE organizations
+SET max_token_lifetime_days = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteOrganization :exec
+DELETE FROM organizations WHERE id = $1;
+
+-- name: CreateOrganizationMember :one
+INSERT INTO organization_members (org_id, user_id, user_name, external_id, email, display_name, active)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetOrganizationMember :one
+SELECT * FROM organization_members
+WHERE id = $1 AND org_id = $2;
+
+-- name: GetOrganizationMemberByUserName :one
+SELECT * FROM organization_members
+WHERE org_id = $1 AND user_name = $2;
+
+-- name: GetOrganizationMemberByExternalID :one
+SELECT * FROM organization_members
+WHERE org_id = $1 AND external_id = $2;
+
+-- name: ListOrganizationMembers :many
+SELECT * FROM organization_members
+WHERE org_id = $1
+ORDER BY created_at ASC
+LIMIT $2 OFFSET $3;
+
+-- name: ListOrganizationUsers :many
+-- The owner, active members with an account and machine users of an
+-- organization, with the two-factor status of their GitHub account
+SELECT u.id, u.username, m.role, t.enabled AS two_factor, t.checked_at AS two_factor_checked_at
+FROM (
+    SELECT owner_id AS user_id, 'owner' AS role FROM organizations WHERE id = $1
+    UNION
+    SELECT user_id, 'member' FROM organization_members
+    WHERE org_id = $1 AND active AND user_id IS NOT NULL
+      AND user_id NOT IN (SELECT owner_id FROM organizations WHERE id = $1)
+    UNION
+    SELECT user_id, 'machine' FROM machine_users WHERE org_id = $1
+) m
+JOIN users u ON u.id = m.user_id
+LEFT JOIN user_two_factor t ON t.user_id = u.id
+ORDER BY u.username;
+
+-- name: CountOrganizationMembers :one
+SELECT COUNT(*) FROM organization_members WHERE org_id = $1;
+
+-- name: UpdateOrganizationMember :one
+UPDATE organization_members
+SET user_id = $3, user_name = $4, external_id = $5, email = $6, display_name = $7, active = $8
+WHERE id = $1 AND org_id = $2
+RETURNING *;
+
+-- name: DeleteOrganizationMember :exec
+DELETE FROM organization_members
+WHERE id = $1 AND org_id = $2;
+
+-- name: LinkOrganizationMembers :exec
+UPDATE organization_members
+SET user_id = $2
+WHERE user_name = $1 AND user_id IS NULL;
@@ -0,0 +1,52 @@
+-- name: CreateOutboxJob :one
+INSERT INTO outbox (kind, destination, payload)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: ClaimOutboxJobs :many
+-- Claimed jobs are leased until lease_until; a worker that dies mid-delivery
+-- leaves them to be claimed again once the lease runs out.
+UPDATE outbox
+SET attempts = attempts + 1, next_attempt_at = sqlc.arg(lease_until)::TIMESTAMPTZ
+WHERE id IN (
+    SELECT id FROM outbox
+    WHERE status = 'pending' AND next_attempt_at <= sqlc.arg(now)::TIMESTAMPTZ
+    ORDER BY next_attempt_at
+    LIMIT sqlc.arg(max_jobs)::INTEGER
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkOutboxDelivered :exec
+UPDATE outbox
+SET status = 'delivered', delivered_at = NOW(), last_error = NULL
+WHERE id = $1;
+
+-- name: RetryOutboxJob :exec
+UPDATE outbox
+SET next_attempt_at = $2, last_error = $3
+WHERE id = $1;
+
+-- name: MarkOutboxDead :exec
+UPDATE outbox
+SET status = 'dead', last_error = $2
+WHERE id = $1;
+
+-- name: GetOutboxJob :one
+SELECT * FROM outbox WHERE id = $1;
+
+-- name: ListOutboxJobsByStatus :many
+SELECT * FROM outbox
+WHERE status = $1
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: RequeueOutboxJob :one
+UPDATE outbox
+SET status = 'pending', attempts = 0, next_attempt_at = NOW(), last_error = NULL
+WHERE id = $1 AND status = 'dead'
+RETURNING *;
+
+-- name: DeleteDeliveredOutboxJobs :execrows
+DELETE FROM outbox
+WHERE status = 'delivered' AND delivered_at < sqlc.arg(before)::TIMESTAMPTZ;
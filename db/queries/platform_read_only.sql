@@ -0,0 +1,12 @@
+-- name: GetReadOnlyMode :one
+SELECT * FROM platform_read_only WHERE id;
+
+-- name: SetReadOnlyMode :one
+INSERT INTO platform_read_only (id, enabled, message, updated_by)
+VALUES (TRUE, $1, $2, $3)
+ON CONFLICT (id) DO UPDATE
+SET enabled = EXCLUDED.enabled,
+    message = EXCLUDED.message,
+    updated_by = EXCLUDED.updated_by,
+    updated_at = NOW()
+RETURNING *;
@@ -0,0 +1,51 @@
+-- name: CreateProject :one
+INSERT INTO projects (user_id, name, description)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetProjectByName :one
+SELECT * FROM projects WHERE user_id = $1 AND name = $2;
+
+-- name: ListProjectsByUser :many
+SELECT p.id, p.user_id, p.name, p.description, p.created_at, p.updated_at,
+    COUNT(a.id)::INTEGER AS app_count
+FROM projects p
+LEFT JOIN apps a ON a.project_id = p.id
+WHERE p.user_id = $1
+GROUP BY p.id
+ORDER BY p.name;
+
+-- name: UpdateProject :one
+UPDATE projects SET description = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteProject :exec
+DELETE FROM projects WHERE id = $1;
+
+-- name: UpsertProjectEnvGroup :one
+INSERT INTO project_env_groups (project_id, name, env_vars_encrypted)
+VALUES ($1, $2, $3)
+ON CONFLICT (project_id, name) DO UPDATE SET env_vars_encrypted = EXCLUDED.env_vars_encrypted
+RETURNING *;
+
+-- name: ListProjectEnvGroups :many
+SELECT * FROM project_env_groups
+WHERE project_id = $1
+ORDER BY name;
+
+-- name: DeleteProjectEnvGroup :execrows
+DELETE FROM project_env_groups WHERE project_id = $1 AND name = $2;
+
+-- name: ListProjectActivityLogs :many
+SELECT l.id, l.user_id, l.app_id, l.action, l.details, l.ip_address, l.created_at, a.name AS app_name
+FROM activity_logs l
+JOIN apps a ON a.id = l.app_id
+WHERE a.project_id = $1
+ORDER BY l.created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountProjectActivityLogs :one
+SELECT COUNT(*) FROM activity_logs l
+JOIN apps a ON a.id = l.app_id
+WHERE a.project_id = $1;
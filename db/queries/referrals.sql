@@ -0,0 +1,21 @@
+-- name: CreateReferral :one
+-- Records who referred a new user; returns no row when one is recorded
+INSERT INTO referrals (user_id, referrer_id)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO NOTHING
+RETURNING *;
+
+-- name: GetReferral :one
+SELECT * FROM referrals WHERE user_id = $1;
+
+-- name: MarkReferralCredited :execrows
+-- Claims the referral credit; affects no row when it was already granted
+UPDATE referrals SET credited_at = NOW()
+WHERE user_id = $1 AND credited_at IS NULL;
+
+-- name: CountReferrals :one
+SELECT
+    COUNT(*)::INT AS referred,
+    COUNT(credited_at)::INT AS credited
+FROM referrals
+WHERE referrer_id = $1;
@@ -0,0 +1,14 @@
+-- name: GetRegistryUsage :one
+SELECT * FROM registry_usage WHERE user_id = $1;
+
+-- name: UpsertRegistryUsage :exec
+INSERT INTO registry_usage (user_id, bytes, repositories, updated_at)
+VALUES ($1, $2, $3, NOW())
+ON CONFLICT (user_id) DO UPDATE SET
+    bytes = EXCLUDED.bytes,
+    repositories = EXCLUDED.repositories,
+    updated_at = NOW();
+
+-- name: DeleteStaleRegistryUsage :exec
+-- Drops the usage of users whose repositories are all gone
+DELETE FROM registry_usage WHERE updated_at < $1;
@@ -0,0 +1,34 @@
+-- name: CreateRouter :one
+INSERT INTO routers (user_id, name)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: GetRouterByName :one
+SELECT * FROM routers WHERE user_id = $1 AND name = $2;
+
+-- name: ListRoutersByUser :many
+SELECT * FROM routers
+WHERE user_id = $1
+ORDER BY name;
+
+-- name: TouchRouter :one
+UPDATE routers SET updated_at = NOW() WHERE id = $1
+RETURNING *;
+
+-- name: DeleteRouter :exec
+DELETE FROM routers WHERE id = $1;
+
+-- name: CreateRouterRoute :one
+INSERT INTO router_routes (router_id, path_prefix, app_id)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: ListRouterRoutes :many
+SELECT rr.id, rr.router_id, rr.path_prefix, rr.app_id, rr.created_at, a.name AS app_name, a.region AS app_region
+FROM router_routes rr
+JOIN apps a ON a.id = rr.app_id
+WHERE rr.router_id = $1
+ORDER BY rr.path_prefix;
+
+-- name: DeleteRouterRoutes :exec
+DELETE FROM router_routes WHERE router_id = $1;
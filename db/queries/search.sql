@@ -0,0 +1,39 @@
+-- name: SearchUserResources :many
+-- Candidates for a search of a user's apps, domains, deployment images and
+-- activity actions matching pattern (an ILIKE pattern), the newest per_kind
+-- of each kind; images and actions appear once per app. The caller ranks
+-- them.
+(SELECT 'app'::TEXT AS kind, a.id, a.name AS app_name, a.name AS match, a.created_at
+ FROM apps a
+ WHERE a.user_id = sqlc.arg(user_id) AND a.name ILIKE sqlc.arg(pattern)::TEXT
+ ORDER BY a.created_at DESC
+ LIMIT sqlc.arg(per_kind)::INTEGER)
+UNION ALL
+(SELECT 'domain'::TEXT, d.id, a.name, d.domain, d.created_at
+ FROM domains d
+ JOIN apps a ON a.id = d.app_id
+ WHERE a.user_id = sqlc.arg(user_id) AND d.domain ILIKE sqlc.arg(pattern)::TEXT
+ ORDER BY d.created_at DESC
+ LIMIT sqlc.arg(per_kind)::INTEGER)
+UNION ALL
+(SELECT 'deployment'::TEXT, latest.id, latest.app_name, latest.image, latest.created_at
+ FROM (
+   SELECT DISTINCT ON (dp.app_id, dp.image) dp.id, a.name AS app_name, dp.image, dp.created_at
+   FROM deployments dp
+   JOIN apps a ON a.id = dp.app_id
+   WHERE a.user_id = sqlc.arg(user_id) AND dp.image ILIKE sqlc.arg(pattern)::TEXT
+   ORDER BY dp.app_id, dp.image, dp.created_at DESC
+ ) latest
+ ORDER BY latest.created_at DESC
+ LIMIT sqlc.arg(per_kind)::INTEGER)
+UNION ALL
+(SELECT 'activity'::TEXT, latest.id, latest.app_name, latest.action, latest.created_at
+ FROM (
+   SELECT DISTINCT ON (l.app_id, l.action) l.id, a.name AS app_name, l.action, l.created_at
+   FROM activity_logs l
+   JOIN apps a ON a.id = l.app_id
+   WHERE a.user_id = sqlc.arg(user_id) AND l.action ILIKE sqlc.arg(pattern)::TEXT
+   ORDER BY l.app_id, l.action, l.created_at DESC
+ ) latest
+ ORDER BY latest.created_at DESC
+ LIMIT sqlc.arg(per_kind)::INTEGER);
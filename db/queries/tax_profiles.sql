@@ -0,0 +1,34 @@
+-- name: GetUserTaxProfile :one
+SELECT * FROM tax_profiles WHERE user_id = $1;
+
+-- name: GetOrganizationTaxProfile :one
+SELECT * FROM tax_profiles WHERE organization_id = $1;
+
+-- name: UpsertUserTaxProfile :one
+INSERT INTO tax_profiles (user_id, country, postal_code, business_name, tax_id_type, tax_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (user_id) DO UPDATE
+SET country = EXCLUDED.country,
+    postal_code = EXCLUDED.postal_code,
+    business_name = EXCLUDED.business_name,
+    tax_id_type = EXCLUDED.tax_id_type,
+    tax_id = EXCLUDED.tax_id
+RETURNING *;
+
+-- name: UpsertOrganizationTaxProfile :one
+INSERT INTO tax_profiles (organization_id, country, postal_code, business_name, tax_id_type, tax_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (organization_id) DO UPDATE
+SET country = EXCLUDED.country,
+    postal_code = EXCLUDED.postal_code,
+    business_name = EXCLUDED.business_name,
+    tax_id_type = EXCLUDED.tax_id_type,
+    tax_id = EXCLUDED.tax_id
+RETURNING *;
+
+-- name: UpdateTaxProfileStripeTaxID :one
+-- Records the tax ID added to the Stripe customer and its verification
+UPDATE tax_profiles
+SET stripe_tax_id = $2, tax_id_status = $3
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,34 @@
+-- name: CreateTrafficSplit :one
+INSERT INTO traffic_splits (user_id, name, sticky)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetTrafficSplitByName :one
+SELECT * FROM traffic_splits WHERE user_id = $1 AND name = $2;
+
+-- name: ListTrafficSplitsByUser :many
+SELECT * FROM traffic_splits
+WHERE user_id = $1
+ORDER BY name;
+
+-- name: UpdateTrafficSplit :one
+UPDATE traffic_splits SET sticky = $2 WHERE id = $1
+RETURNING *;
+
+-- name: DeleteTrafficSplit :exec
+DELETE FROM traffic_splits WHERE id = $1;
+
+-- name: CreateTrafficSplitBackend :one
+INSERT INTO traffic_split_backends (split_id, app_id, weight)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: ListTrafficSplitBackends :many
+SELECT b.split_id, b.app_id, b.weight, a.name AS app_name, a.region AS app_region
+FROM traffic_split_backends b
+JOIN apps a ON a.id = b.app_id
+WHERE b.split_id = $1
+ORDER BY b.weight DESC, a.name;
+
+-- name: DeleteTrafficSplitBackends :exec
+DELETE FROM traffic_split_backends WHERE split_id = $1;
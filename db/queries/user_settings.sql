@@ -0,0 +1,12 @@
+-- name: GetUserSettings :one
+SELECT * FROM user_settings WHERE user_id = $1;
+
+-- name: UpsertUserSettings :one
+INSERT INTO user_settings (user_id, default_region, default_size, default_health_check_path, email_notifications)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id) DO UPDATE
+SET default_region = EXCLUDED.default_region,
+    default_size = EXCLUDED.default_size,
+    default_health_check_path = EXCLUDED.default_health_check_path,
+    email_notifications = EXCLUDED.email_notifications
+RETURNING *;
@@ -0,0 +1,9 @@
+-- name: UpsertUserTwoFactor :exec
+INSERT INTO user_two_factor (user_id, enabled)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE SET
+    enabled = EXCLUDED.enabled,
+    checked_at = NOW();
+
+-- name: GetUserTwoFactor :one
+SELECT * FROM user_two_factor WHERE user_id = $1;
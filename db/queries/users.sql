@@ -12,6 +12,12 @@ SELECT * FROM users WHERE github_id = $1;
 -- name: GetUserByUsername :one
 SELECT * FROM users WHERE username = $1;
 
+-- name: GetUserByRegistryNamespace :one
+SELECT * FROM users WHERE lower(username) = @namespace::text;
+
+-- name: GetUserByStripeCustomerID :one
+SELECT * FROM users WHERE stripe_customer_id = $1;
+
 -- name: UpdateUser :one
 UPDATE users
 SET username = $2, email = $3, avatar_url = $4
@@ -34,3 +40,6 @@ UPDATE users SET email = $2 WHERE id = $1;
 SELECT * FROM users
 ORDER BY created_at DESC
 LIMIT $1 OFFSET $2;
+
+-- name: RevokeUserSessions :exec
+UPDATE users SET sessions_revoked_at = NOW() WHERE id = $1;
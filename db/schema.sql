@@ -101,3 +101,958 @@ CREATE TRIGGER users_updated_at BEFORE UPDATE ON users
 
 CREATE TRIGGER apps_updated_at BEFORE UPDATE ON apps
     FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+-- Deploy hooks: commands run as Kubernetes Jobs around a rollout
+CREATE TABLE deploy_hooks (
+    app_id UUID PRIMARY KEY REFERENCES apps(id) ON DELETE CASCADE,
+    pre_deploy_command TEXT,
+    post_deploy_command TEXT,
+    timeout_seconds INT DEFAULT 600 NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE TABLE deployment_hook_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    deployment_id UUID NOT NULL REFERENCES deployments(id) ON DELETE CASCADE,
+    phase VARCHAR(20) NOT NULL,
+    command TEXT NOT NULL,
+    job_name VARCHAR(255) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    output TEXT,
+    error TEXT,
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_deployment_hook_runs_deployment_id ON deployment_hook_runs(deployment_id);
+
+CREATE TRIGGER deploy_hooks_updated_at BEFORE UPDATE ON deploy_hooks
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+-- Cron jobs: scheduled commands run as Kubernetes CronJobs with the app image
+CREATE TABLE cron_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    app_id UUID NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    name VARCHAR(52) NOT NULL,
+    schedule VARCHAR(255) NOT NULL,
+    command TEXT NOT NULL,
+    timezone VARCHAR(64) DEFAULT 'UTC' NOT NULL,
+    concurrency_policy VARCHAR(20) DEFAULT 'allow' NOT NULL,
+    starting_deadline_seconds BIGINT,
+    active_deadline_seconds BIGINT,
+    successful_history_limit INT DEFAULT 3 NOT NULL,
+    failed_history_limit INT DEFAULT 1 NOT NULL,
+    suspended BOOLEAN DEFAULT FALSE NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    UNIQUE(app_id, name)
+);
+
+CREATE INDEX idx_cron_jobs_app_id ON cron_jobs(app_id);
+
+CREATE TRIGGER cron_jobs_updated_at BEFORE UPDATE ON cron_jobs
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+-- App processes: Procfile-style process types, each rendered as its own Deployment
+CREATE TABLE app_processes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    app_id UUID NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    process_type VARCHAR(20) NOT NULL,
+    command TEXT,
+    replicas INT DEFAULT 1 NOT NULL,
+    cpu VARCHAR(20),
+    memory VARCHAR(20),
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    UNIQUE(app_id, process_type)
+);
+
+CREATE INDEX idx_app_processes_app_id ON app_processes(app_id);
+
+CREATE TRIGGER app_processes_updated_at BEFORE UPDATE ON app_processes
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+-- App placements: dedicated node pools via nodeSelector and tolerations
+CREATE TABLE app_placements (
+    app_id UUID PRIMARY KEY REFERENCES apps(id) ON DELETE CASCADE,
+    node_selector JSONB DEFAULT '{}' NOT NULL,
+    tolerations JSONB DEFAULT '[]' NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE TRIGGER app_placements_updated_at BEFORE UPDATE ON app_placements
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+-- Typed failure reason for deployments so users see why a rollout was rejected
+ALTER TABLE deployments ADD COLUMN failure_reason VARCHAR(50);
+
+-- Organizations and their members, provisioned manually or through SCIM
+CREATE TABLE organizations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) UNIQUE NOT NULL,
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    scim_token_hash VARCHAR(255) UNIQUE,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_organizations_owner_id ON organizations(owner_id);
+
+CREATE TRIGGER organizations_updated_at BEFORE UPDATE ON organizations
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+-- Members are keyed by GitHub username and linked to a user on first login
+CREATE TABLE organization_members (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    user_name VARCHAR(255) NOT NULL,
+    external_id VARCHAR(255),
+    email VARCHAR(255),
+    display_name VARCHAR(255),
+    role VARCHAR(50) DEFAULT 'member' NOT NULL,
+    active BOOLEAN DEFAULT TRUE NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    UNIQUE (org_id, user_name)
+);
+
+CREATE INDEX idx_organization_members_user_id ON organization_members(user_id);
+CREATE INDEX idx_organization_members_user_name ON organization_members(user_name);
+
+CREATE TRIGGER organization_members_updated_at BEFORE UPDATE ON organization_members
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+-- JWTs issued before this instant are rejected
+ALTER TABLE users ADD COLUMN sessions_revoked_at TIMESTAMPTZ;
+
+-- API tokens can be bound to CIDR ranges; an empty list allows any address
+ALTER TABLE api_tokens ADD COLUMN allowed_cidrs TEXT[] DEFAULT '{}' NOT NULL;
+
+-- Organizations can cap the lifetime of their members' API tokens
+ALTER TABLE organizations ADD COLUMN max_token_lifetime_days INTEGER;
+
+-- Custom domains are activated only after the owner publishes this token in
+-- a TXT record at _fuego-verify.<domain>
+ALTER TABLE domains ADD COLUMN verification_token VARCHAR(64) DEFAULT md5(random()::text || clock_timestamp()::text) NOT NULL;
+
+-- How a custom domain points at the platform: cname for subdomains, a
+-- (A/AAAA to the region's ingress IPs) or alias (flattened CNAME) for apex domains
+ALTER TABLE domains ADD COLUMN dns_mode VARCHAR(20) DEFAULT 'cname' NOT NULL;
+
+-- Certificate state polled from cert-manager for each custom domain
+ALTER TABLE domains ADD COLUMN ssl_expires_at TIMESTAMPTZ;
+ALTER TABLE domains ADD COLUMN ssl_error TEXT;
+ALTER TABLE domains ADD COLUMN ssl_checked_at TIMESTAMPTZ;
+ALTER TABLE domains ADD COLUMN ssl_alerted_at TIMESTAMPTZ;
+
+-- Internal apps can require client certificates issued by the platform CA;
+-- a row means mTLS is enforced at the app's ingress
+CREATE TABLE app_mtls (
+    app_id UUID PRIMARY KEY REFERENCES apps(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+-- Issued client certificates; private keys are never stored
+CREATE TABLE client_certificates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    app_id UUID NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    serial VARCHAR(64) UNIQUE NOT NULL,
+    fingerprint VARCHAR(64) NOT NULL,
+    certificate TEXT NOT NULL,
+    not_after TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_client_certificates_app_id ON client_certificates(app_id);
+
+-- Routers compose several apps behind one host with path-prefix routing
+CREATE TABLE routers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    UNIQUE(user_id, name)
+);
+
+CREATE TRIGGER routers_updated_at BEFORE UPDATE ON routers
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+CREATE TABLE router_routes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    router_id UUID NOT NULL REFERENCES routers(id) ON DELETE CASCADE,
+    path_prefix VARCHAR(255) NOT NULL,
+    app_id UUID NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    UNIQUE(router_id, path_prefix)
+);
+
+CREATE INDEX idx_router_routes_app_id ON router_routes(app_id);
+
+-- Traffic splits spread the requests to one host across apps by weight
+CREATE TABLE traffic_splits (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    sticky BOOLEAN DEFAULT FALSE NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    UNIQUE(user_id, name)
+);
+
+CREATE TRIGGER traffic_splits_updated_at BEFORE UPDATE ON traffic_splits
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+CREATE TABLE traffic_split_backends (
+    split_id UUID NOT NULL REFERENCES traffic_splits(id) ON DELETE CASCADE,
+    app_id UUID NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    weight INT NOT NULL CHECK (weight BETWEEN 0 AND 100),
+    PRIMARY KEY (split_id, app_id)
+);
+
+CREATE INDEX idx_traffic_split_backends_app_id ON traffic_split_backends(app_id);
+
+-- Shadow traffic: a percentage of an app's requests is copied to another
+-- app, responses of the copy are discarded. Mirrors stop at expires_at.
+CREATE TABLE app_mirrors (
+    app_id UUID PRIMARY KEY REFERENCES apps(id) ON DELETE CASCADE,
+    target_app_id UUID NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    percent INT NOT NULL CHECK (percent BETWEEN 1 AND 100),
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE TRIGGER app_mirrors_updated_at BEFORE UPDATE ON app_mirrors
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+CREATE INDEX idx_app_mirrors_expires_at ON app_mirrors(expires_at);
+
+-- Metered network usage: bytes in and out of each app's ingress, bucketed by
+-- hour. Feeds app metrics and billing.
+CREATE TABLE app_bandwidth (
+    app_id UUID NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    period_start TIMESTAMPTZ NOT NULL,
+    ingress_bytes BIGINT NOT NULL DEFAULT 0,
+    egress_bytes BIGINT NOT NULL DEFAULT 0,
+    requests BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (app_id, period_start)
+);
+
+CREATE INDEX idx_app_bandwidth_period_start ON app_bandwidth(period_start);
+
+-- Platform event bus. Subsystems append events; each subscriber reads them
+-- in order from its own cursor, so a subscriber that fails or restarts
+-- picks up where it left off (at-least-once delivery).
+CREATE TABLE events (
+    id BIGSERIAL PRIMARY KEY,
+    type VARCHAR(100) NOT NULL,
+    user_id UUID,
+    app_id UUID,
+    app_name VARCHAR(63),
+    message TEXT,
+    ip_address INET,
+    payload JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_events_created_at ON events(created_at);
+
+CREATE TABLE event_cursors (
+    subscriber VARCHAR(100) PRIMARY KEY,
+    last_event_id BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE TRIGGER event_cursors_updated_at BEFORE UPDATE ON event_cursors
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+-- Wake dispatchers as soon as an event commits
+CREATE OR REPLACE FUNCTION notify_platform_event()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('platform_events', NEW.id::TEXT);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER events_notify AFTER INSERT ON events
+    FOR EACH ROW EXECUTE FUNCTION notify_platform_event();
+
+-- Outgoing webhook and email deliveries. Jobs are retried with backoff and
+-- marked dead after max_attempts so they can be inspected and requeued.
+CREATE TABLE outbox (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind VARCHAR(20) NOT NULL,
+    destination TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'dead')),
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL DEFAULT 8,
+    next_attempt_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    last_error TEXT,
+    delivered_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE TRIGGER outbox_updated_at BEFORE UPDATE ON outbox
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+CREATE INDEX idx_outbox_due ON outbox(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_outbox_status ON outbox(status, created_at);
+
+-- Scheduled platform maintenance. Active and upcoming windows are shown to
+-- users as a banner and pause non-critical background workers while active.
+CREATE TABLE maintenance_windows (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    title VARCHAR(200) NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL CHECK (ends_at > starts_at),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE TRIGGER maintenance_windows_updated_at BEFORE UPDATE ON maintenance_windows
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+CREATE INDEX idx_maintenance_windows_ends_at ON maintenance_windows(ends_at, starts_at);
+
+-- Descriptive metadata so users with many apps can tell them apart and find
+-- them with GET /api/apps?search=
+ALTER TABLE apps ADD COLUMN description TEXT DEFAULT '' NOT NULL;
+ALTER TABLE apps ADD COLUMN icon_url TEXT DEFAULT '' NOT NULL;
+ALTER TABLE apps ADD COLUMN repository_url TEXT DEFAULT '' NOT NULL;
+ALTER TABLE apps ADD COLUMN tags TEXT[] DEFAULT '{}' NOT NULL;
+
+CREATE INDEX idx_apps_tags ON apps USING GIN (tags);
+
+-- Projects group related apps (e.g. frontend, api and worker) for a combined
+-- view. Every app in a project receives the project's env groups.
+CREATE TABLE projects (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT DEFAULT '' NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    UNIQUE(user_id, name)
+);
+
+CREATE TRIGGER projects_updated_at BEFORE UPDATE ON projects
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+ALTER TABLE apps ADD COLUMN project_id UUID REFERENCES projects(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_apps_project_id ON apps(project_id);
+
+-- Shared env vars, encrypted like an app's own. An app's own vars take
+-- precedence over its project's groups.
+CREATE TABLE project_env_groups (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name VARCHAR(63) NOT NULL,
+    env_vars_encrypted BYTEA NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    UNIQUE(project_id, name)
+);
+
+CREATE TRIGGER project_env_groups_updated_at BEFORE UPDATE ON project_env_groups
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+-- User-defined labels, copied onto every Kubernetes resource of the app and
+-- reported with usage for chargeback by team or environment
+ALTER TABLE apps ADD COLUMN labels JSONB DEFAULT '{}' NOT NULL;
+
+-- OAuth device authorization grant (RFC 8628) for fuegoctl login on
+-- headless terminals. The CLI polls with the secret device code while the
+-- user approves the short user code in the dashboard.
+CREATE TABLE device_authorizations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    device_code_hash VARCHAR(64) UNIQUE NOT NULL,
+    user_code VARCHAR(9) UNIQUE NOT NULL,
+    client_name VARCHAR(255) NOT NULL,
+    status VARCHAR(20) DEFAULT 'pending' NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    poll_interval INTEGER DEFAULT 5 NOT NULL,
+    last_polled_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_device_authorizations_expires_at ON device_authorizations(expires_at);
+
+-- Where and how often API tokens are used, so owners can spot leaked or
+-- forgotten tokens. Tokens may opt into expiring after going unused.
+ALTER TABLE api_tokens ADD COLUMN last_used_ip INET;
+ALTER TABLE api_tokens ADD COLUMN last_used_user_agent TEXT;
+ALTER TABLE api_tokens ADD COLUMN expire_after_unused_days INTEGER;
+
+CREATE TABLE api_token_usage (
+    token_id UUID NOT NULL REFERENCES api_tokens(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    count INTEGER DEFAULT 0 NOT NULL,
+    PRIMARY KEY (token_id, day)
+);
+
+-- Machine users are non-interactive identities owned by an organization so
+-- CI pipelines keep working when the people who set them up leave. Each is
+-- backed by a users row, so apps and API tokens work as they do for people.
+-- Machine users have no GitHub account and take negative github_ids, which
+-- never collide with real ones.
+CREATE SEQUENCE machine_user_github_ids;
+
+CREATE TABLE machine_users (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name VARCHAR(63) NOT NULL,
+    description TEXT,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    -- Quotas; NULL means unlimited
+    max_apps INTEGER,
+    max_deployments_per_day INTEGER,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    UNIQUE (org_id, name)
+);
+
+CREATE TRIGGER machine_users_updated_at BEFORE UPDATE ON machine_users
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+-- Removing a machine user, directly or with its organization, removes the
+-- backing user along with its tokens
+CREATE OR REPLACE FUNCTION delete_machine_user_account()
+RETURNS TRIGGER AS $$
+BEGIN
+    DELETE FROM users WHERE id = OLD.user_id;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER machine_users_delete_account AFTER DELETE ON machine_users
+    FOR EACH ROW EXECUTE FUNCTION delete_machine_user_account();
+
+-- Container crashes of a deployment's pods, counted by the crash monitor so
+-- owners see whether a release runs out of memory or keeps crashing
+ALTER TABLE deployments ADD COLUMN oom_kills INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE deployments ADD COLUMN crash_loops INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE deployments ADD COLUMN last_crash_at TIMESTAMPTZ;
+ALTER TABLE deployments ADD COLUMN crash_alerted_at TIMESTAMPTZ;
+
+-- Hourly CPU and memory usage of an app's pods sampled from metrics-server,
+-- backing right-sizing recommendations. Sums and maxima are per pod.
+CREATE TABLE app_resource_usage (
+    app_id UUID NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    period_start TIMESTAMPTZ NOT NULL,
+    samples INTEGER NOT NULL DEFAULT 0,
+    pod_samples INTEGER NOT NULL DEFAULT 0,
+    cpu_millis_sum BIGINT NOT NULL DEFAULT 0,
+    cpu_millis_max BIGINT NOT NULL DEFAULT 0,
+    memory_bytes_sum BIGINT NOT NULL DEFAULT 0,
+    memory_bytes_max BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (app_id, period_start)
+);
+
+CREATE INDEX idx_app_resource_usage_period_start ON app_resource_usage(period_start);
+
+-- Burst mode doubles an app's web replicas while its CPU or p95 latency is
+-- over a threshold and scales back once load stays under it for the
+-- cool-down. The base replicas and burst times are set during a burst.
+CREATE TABLE app_bursts (
+    app_id UUID PRIMARY KEY REFERENCES apps(id) ON DELETE CASCADE,
+    cpu_percent INTEGER CHECK (cpu_percent BETWEEN 1 AND 100),
+    p95_latency_ms INTEGER CHECK (p95_latency_ms > 0),
+    cooldown_minutes INTEGER DEFAULT 10 NOT NULL CHECK (cooldown_minutes BETWEEN 1 AND 1440),
+    base_replicas INTEGER,
+    burst_started_at TIMESTAMPTZ,
+    burst_until TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE TRIGGER app_bursts_updated_at BEFORE UPDATE ON app_bursts
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+-- CPU architectures of the deployment's image that the target region's
+-- nodes run, pods are scheduled onto nodes of these. NULL when the image
+-- could not be inspected, leaving scheduling to the cluster.
+ALTER TABLE deployments ADD COLUMN architectures TEXT[];
+
+-- Deploy freezes are weekly windows, such as Friday 18:00 to Monday 08:00,
+-- during which apps of an organization's members and machine users only
+-- deploy in an emergency with a justification. Days count from Sunday (0)
+-- and times are minutes of the day in the freeze's time zone; a window
+-- ending before it starts wraps around the week.
+CREATE TABLE deploy_freezes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name VARCHAR(63) NOT NULL,
+    start_day INTEGER NOT NULL CHECK (start_day BETWEEN 0 AND 6),
+    start_minute INTEGER NOT NULL CHECK (start_minute BETWEEN 0 AND 1439),
+    end_day INTEGER NOT NULL CHECK (end_day BETWEEN 0 AND 6),
+    end_minute INTEGER NOT NULL CHECK (end_minute BETWEEN 0 AND 1439),
+    timezone VARCHAR(64) DEFAULT 'UTC' NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    UNIQUE (org_id, name)
+);
+
+CREATE TRIGGER deploy_freezes_updated_at BEFORE UPDATE ON deploy_freezes
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+-- CI provenance of deployments made from GitHub Actions or GitLab CI: the
+-- run, commit and actor sent as headers. Verified provenance comes from an
+-- OIDC token of the provider whose claims match the headers.
+CREATE TABLE deployment_provenance (
+    deployment_id UUID PRIMARY KEY REFERENCES deployments(id) ON DELETE CASCADE,
+    provider VARCHAR(20) NOT NULL,
+    run_id VARCHAR(64) DEFAULT '' NOT NULL,
+    run_number VARCHAR(64) DEFAULT '' NOT NULL,
+    run_url TEXT DEFAULT '' NOT NULL,
+    commit_sha VARCHAR(64) DEFAULT '' NOT NULL,
+    actor VARCHAR(255) DEFAULT '' NOT NULL,
+    repository VARCHAR(255) DEFAULT '' NOT NULL,
+    ref VARCHAR(255) DEFAULT '' NOT NULL,
+    workflow VARCHAR(255) DEFAULT '' NOT NULL,
+    verified BOOLEAN DEFAULT FALSE NOT NULL,
+    issuer TEXT DEFAULT '' NOT NULL,
+    subject TEXT DEFAULT '' NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+-- OIDC trusts let CI runs of a repository exchange their provider's OIDC
+-- token for a short-lived deploy token of the app, instead of keeping an API
+-- token as a CI secret. An empty ref trusts runs of every branch and tag.
+CREATE TABLE app_oidc_trusts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    app_id UUID NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    provider VARCHAR(20) NOT NULL,
+    repository VARCHAR(255) NOT NULL,
+    ref VARCHAR(255) DEFAULT '' NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    UNIQUE (app_id, provider, repository, ref)
+);
+
+-- Who made a deployment: the user, the API token when one was used, and a
+-- label of both kept for the record after the user or token is deleted
+ALTER TABLE deployments
+    ADD COLUMN deployed_by_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN deployed_by_token_id UUID,
+    ADD COLUMN deployed_by VARCHAR(255) DEFAULT '' NOT NULL;
+
+-- The API token behind an action, NULL when a signed-in user took it. Not a
+-- foreign key so the trail survives the token's deletion.
+ALTER TABLE activity_logs ADD COLUMN api_token_id UUID;
+ALTER TABLE events ADD COLUMN api_token_id UUID;
+
+CREATE INDEX idx_activity_logs_app_actor ON activity_logs(app_id, user_id, created_at DESC);
+
+-- Collaborators get rights on a single app of another user, such as a
+-- contractor deploying one app, without being a member of the owner's
+-- organization. Roles are view, deploy and admin.
+CREATE TABLE app_collaborators (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    app_id UUID NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL,
+    added_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    UNIQUE (app_id, user_id)
+);
+
+CREATE INDEX idx_app_collaborators_user ON app_collaborators(user_id);
+
+-- The operator switch putting the API in read-only mode during incidents or
+-- migrations. A single row shared by every replica.
+CREATE TABLE platform_read_only (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    enabled BOOLEAN DEFAULT FALSE NOT NULL,
+    message TEXT DEFAULT '' NOT NULL,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+-- Accounts with failed payments go through the suspension pipeline: email
+-- warnings, apps scaled to zero, namespaces deleted after a retention
+-- period. A row exists while an account is in the pipeline and is deleted
+-- when it is reinstated.
+CREATE TABLE account_suspensions (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    stage VARCHAR(20) NOT NULL DEFAULT 'warning',
+    -- The invoice whose failed payment started the pipeline
+    invoice_id VARCHAR(255) NOT NULL DEFAULT '',
+    failed_at TIMESTAMPTZ NOT NULL,
+    warnings INTEGER NOT NULL DEFAULT 1,
+    suspended_at TIMESTAMPTZ,
+    purged_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_users_stripe_customer_id ON users(stripe_customer_id) WHERE stripe_customer_id IS NOT NULL;
+
+-- Account credits are a ledger: grants by admins and referrals add to the
+-- balance, invoices consume it before the card is charged. Amounts are in
+-- USD cents.
+CREATE TABLE credit_ledger (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- Positive for grants, negative for credits applied to an invoice
+    amount BIGINT NOT NULL,
+    reason VARCHAR(20) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    -- The Stripe invoice credits were applied to; each is credited once
+    invoice_id VARCHAR(255) UNIQUE,
+    granted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_credit_ledger_user_id ON credit_ledger(user_id, created_at DESC);
+
+-- Users who signed up through another user's referral link. The referrer is
+-- credited once the referred user pays their first invoice.
+CREATE TABLE referrals (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    referrer_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    credited_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_referrals_referrer_id ON referrals(referrer_id);
+
+-- The referral link a login started from, kept until the callback
+ALTER TABLE oauth_states ADD COLUMN referrer VARCHAR(255);
+
+-- Tax profiles hold what Stripe Tax needs to charge the right tax: the
+-- billing country and, for businesses, a tax ID such as an EU VAT number or
+-- a Mexican RFC. A profile belongs to a user or to an organization the
+-- user's subscription is billed to.
+CREATE TABLE tax_profiles (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    organization_id UUID UNIQUE REFERENCES organizations(id) ON DELETE CASCADE,
+    -- ISO 3166-1 alpha-2
+    country CHAR(2) NOT NULL,
+    postal_code VARCHAR(20) NOT NULL DEFAULT '',
+    business_name VARCHAR(255) NOT NULL DEFAULT '',
+    -- Stripe's tax ID type, such as eu_vat or mx_rfc, empty without a tax ID
+    tax_id_type VARCHAR(20) NOT NULL DEFAULT '',
+    tax_id VARCHAR(50) NOT NULL DEFAULT '',
+    -- The tax ID on the Stripe customer and Stripe's verification of it:
+    -- pending, verified, unverified or unavailable
+    stripe_tax_id VARCHAR(255) NOT NULL DEFAULT '',
+    tax_id_status VARCHAR(20) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    CHECK ((user_id IS NULL) <> (organization_id IS NULL))
+);
+
+CREATE TRIGGER tax_profiles_updated_at BEFORE UPDATE ON tax_profiles
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+-- Database add-ons are Postgres databases users bring to the platform, such
+-- as a Neon or RDS database, to link to their apps. Linked apps get the
+-- connection injected as env vars, rewritten when the credentials change.
+-- The password is encrypted with ENCRYPTION_KEY.
+CREATE TABLE database_addons (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(63) NOT NULL,
+    host VARCHAR(255) NOT NULL,
+    port INTEGER DEFAULT 5432 NOT NULL,
+    database VARCHAR(63) NOT NULL,
+    username VARCHAR(63) NOT NULL,
+    password_encrypted BYTEA NOT NULL,
+    sslmode VARCHAR(20) DEFAULT 'require' NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    UNIQUE (user_id, name)
+);
+
+CREATE TRIGGER database_addons_updated_at BEFORE UPDATE ON database_addons
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+-- The variables a link injects, such as DATABASE_URL and PGHOST, each
+-- prefixed with prefix so an app can link several databases
+CREATE TABLE app_database_links (
+    app_id UUID NOT NULL REFERENCES apps(id) ON DELETE CASCADE,
+    addon_id UUID NOT NULL REFERENCES database_addons(id) ON DELETE CASCADE,
+    prefix VARCHAR(32) DEFAULT '' NOT NULL,
+    variables TEXT[] NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (app_id, addon_id),
+    UNIQUE (app_id, prefix)
+);
+
+CREATE INDEX idx_app_database_links_addon_id ON app_database_links(addon_id);
+
+-- Pooled links route an app's connections through a PgBouncer sidecar in
+-- transaction pooling mode, holding at most pool_size server connections
+-- per pod, for databases with low connection limits such as Neon
+ALTER TABLE app_database_links
+    ADD COLUMN pooling BOOLEAN DEFAULT FALSE NOT NULL,
+    ADD COLUMN pool_size INTEGER DEFAULT 10 NOT NULL;
+
+-- Metrics exports push the CPU, memory and request metrics the platform
+-- collects for an app to its owner's own monitoring, as Prometheus
+-- remote-write or JSON to a webhook. Headers carry the credentials and are
+-- encrypted with ENCRYPTION_KEY.
+CREATE TABLE app_metrics_exports (
+    app_id UUID PRIMARY KEY REFERENCES apps(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    url TEXT NOT NULL,
+    headers_encrypted BYTEA,
+    last_pushed_at TIMESTAMPTZ,
+    last_error TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+-- An OpenTelemetry Collector runs in the namespace of an app with one,
+-- exporting the app's traces, metrics and logs to the user's OTLP backend.
+-- Headers carry the backend's credentials and are encrypted with
+-- ENCRYPTION_KEY.
+CREATE TABLE app_otel_collectors (
+    app_id UUID PRIMARY KEY REFERENCES apps(id) ON DELETE CASCADE,
+    endpoint TEXT NOT NULL,
+    protocol VARCHAR(10) DEFAULT 'grpc' NOT NULL,
+    headers_encrypted BYTEA,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+-- A bulk operation restarts, pauses, resumes or sets env vars on several of
+-- a user's apps in the background. Workers claim pending operations and
+-- lease them while they run, so one whose worker died is taken over once
+-- lease_until passes; results holds the outcome of each app done so far.
+-- Variables set by set-env are encrypted with ENCRYPTION_KEY. Like in
+-- activity_logs, api_token_id is not a foreign key.
+CREATE TABLE bulk_operations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    api_token_id UUID,
+    action VARCHAR(20) NOT NULL,
+    apps TEXT[] NOT NULL,
+    env_encrypted BYTEA,
+    unset_env TEXT[] DEFAULT '{}' NOT NULL,
+    status VARCHAR(20) DEFAULT 'pending' NOT NULL,
+    results JSONB DEFAULT '[]' NOT NULL,
+    lease_until TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_bulk_operations_user_id ON bulk_operations(user_id, created_at DESC);
+CREATE INDEX idx_bulk_operations_unfinished ON bulk_operations(created_at) WHERE status <> 'completed';
+
+-- The path of an app's liveness and readiness probes
+ALTER TABLE apps ADD COLUMN health_check_path TEXT DEFAULT '/api/health' NOT NULL;
+
+-- Account defaults applied to the apps a user creates: an empty default
+-- falls back to the platform's. email_notifications turns off the
+-- notification emails sent to the user.
+CREATE TABLE user_settings (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    default_region VARCHAR(10) DEFAULT '' NOT NULL,
+    default_size VARCHAR(20) DEFAULT '' NOT NULL,
+    default_health_check_path TEXT DEFAULT '' NOT NULL,
+    email_notifications BOOLEAN DEFAULT TRUE NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE TRIGGER user_settings_updated_at BEFORE UPDATE ON user_settings
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at();
+
+-- App statuses, moved between along the transitions of internal/appstatus.
+-- Statuses written before they were constrained map onto the closest one.
+CREATE TYPE app_status AS ENUM ('stopped', 'deploying', 'running', 'failed', 'paused', 'suspended', 'purged');
+
+ALTER TABLE apps ALTER COLUMN status DROP DEFAULT;
+ALTER TABLE apps ALTER COLUMN status TYPE app_status USING (
+    CASE
+        WHEN status IN ('stopped', 'deploying', 'running', 'failed', 'paused', 'suspended', 'purged') THEN status
+        WHEN status = 'ready' THEN 'running'
+        ELSE 'stopped'
+    END
+)::app_status;
+ALTER TABLE apps ALTER COLUMN status SET DEFAULT 'stopped';
+
+-- Apps their owners opt in to the public directory at GET /api/showcase
+ALTER TABLE apps ADD COLUMN showcase BOOLEAN DEFAULT false NOT NULL;
+
+CREATE INDEX idx_apps_showcase ON apps(name, id) WHERE showcase;
+
+-- How the ingress treats plain HTTP and HSTS for a custom domain. Redirects
+-- stay on by default; HSTS is off until its owner opts in, since browsers
+-- remember it for hsts_max_age seconds.
+ALTER TABLE domains ADD COLUMN force_https BOOLEAN DEFAULT true NOT NULL;
+ALTER TABLE domains ADD COLUMN hsts_max_age INTEGER DEFAULT 0 NOT NULL CHECK (hsts_max_age >= 0);
+ALTER TABLE domains ADD COLUMN hsts_include_subdomains BOOLEAN DEFAULT false NOT NULL;
+ALTER TABLE domains ADD COLUMN hsts_preload BOOLEAN DEFAULT false NOT NULL;
+
+-- Static egress IPs, allocated to apps from the EGRESS_IPS pool of their
+-- region; an app's outbound traffic leaves the cluster from its IP so third
+-- parties can allowlist it
+CREATE TABLE app_egress_ips (
+    app_id UUID PRIMARY KEY REFERENCES apps(id) ON DELETE CASCADE,
+    region VARCHAR(50) NOT NULL,
+    ip VARCHAR(45) UNIQUE NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+-- Credentials for the platform registry are API tokens with the access they
+-- grant to the owner's repositories; other tokens cannot log in to it
+ALTER TABLE api_tokens ADD COLUMN registry_access VARCHAR(10) CHECK (registry_access IN ('pull', 'push'));
+
+-- Storage each user's repositories take in the platform registry, refreshed
+-- by the registry_usage job and checked against the quota before pushes
+CREATE TABLE registry_usage (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    bytes BIGINT DEFAULT 0 NOT NULL,
+    repositories INTEGER DEFAULT 0 NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+-- How many deployment images of an app the platform registry keeps; older
+-- ones are deleted by the image_retention job
+CREATE TABLE app_image_retention (
+    app_id UUID PRIMARY KEY REFERENCES apps(id) ON DELETE CASCADE,
+    keep_last INTEGER NOT NULL CHECK (keep_last BETWEEN 1 AND 100),
+    last_pruned_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+-- Signed record of what each deployment ran: image digest, env hash and
+-- manifest hashes
+CREATE TABLE deployment_lockfiles (
+    deployment_id UUID PRIMARY KEY REFERENCES deployments(id) ON DELETE CASCADE,
+    lockfile JSONB NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+-- Whether a user's GitHub account has two-factor authentication enabled, as
+-- GitHub reported it at the user's last login
+CREATE TABLE user_two_factor (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL,
+    checked_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+-- Legal holds keep the data of an organization's owner, members and machine
+-- users from being deleted until every hold is released: their apps,
+-- activity logs and database add-ons. Released holds are kept as history.
+CREATE TABLE legal_holds (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    placed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    released_at TIMESTAMPTZ,
+    released_by UUID REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_legal_holds_active ON legal_holds(org_id) WHERE released_at IS NULL;
+
+-- legal_hold_covers reports whether an unreleased hold of an organization the
+-- account owns, is an active member of or is a machine user of covers it
+CREATE OR REPLACE FUNCTION legal_hold_covers(account UUID)
+RETURNS BOOLEAN AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM legal_holds h
+        JOIN organizations o ON o.id = h.org_id
+        WHERE h.released_at IS NULL
+          AND (o.owner_id = account
+            OR EXISTS (SELECT 1 FROM organization_members m WHERE m.org_id = h.org_id AND m.user_id = account AND m.active)
+            OR EXISTS (SELECT 1 FROM machine_users mu WHERE mu.org_id = h.org_id AND mu.user_id = account))
+    );
+$$ LANGUAGE sql STABLE;
+
+-- Deletes of held data fail with SQLSTATE NXH01 whichever path they take,
+-- including cascades from deleting the account
+CREATE OR REPLACE FUNCTION prevent_held_delete()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF legal_hold_covers(OLD.user_id) THEN
+        RAISE EXCEPTION 'rows of % are under legal hold', TG_TABLE_NAME USING ERRCODE = 'NXH01';
+    END IF;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Activity logs belong to the app's owner as well as to the user who acted
+CREATE OR REPLACE FUNCTION prevent_held_activity_log_delete()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF legal_hold_covers(OLD.user_id)
+        OR legal_hold_covers((SELECT a.user_id FROM apps a WHERE a.id = OLD.app_id)) THEN
+        RAISE EXCEPTION 'rows of activity_logs are under legal hold' USING ERRCODE = 'NXH01';
+    END IF;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Deleting an organization would drop its holds without releasing them
+CREATE OR REPLACE FUNCTION prevent_held_organization_delete()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM legal_holds h WHERE h.org_id = OLD.id AND h.released_at IS NULL) THEN
+        RAISE EXCEPTION 'organization % is under legal hold', OLD.name USING ERRCODE = 'NXH01';
+    END IF;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER apps_legal_hold BEFORE DELETE ON apps
+    FOR EACH ROW EXECUTE FUNCTION prevent_held_delete();
+
+CREATE TRIGGER database_addons_legal_hold BEFORE DELETE ON database_addons
+    FOR EACH ROW EXECUTE FUNCTION prevent_held_delete();
+
+CREATE TRIGGER activity_logs_legal_hold BEFORE DELETE ON activity_logs
+    FOR EACH ROW EXECUTE FUNCTION prevent_held_activity_log_delete();
+
+CREATE TRIGGER organizations_legal_hold BEFORE DELETE ON organizations
+    FOR EACH ROW EXECUTE FUNCTION prevent_held_organization_delete();
+
+-- Subdomain zones users delegate to the platform's nameservers with NS
+-- records at their DNS host. Once the delegation is seen the zone is active:
+-- the platform answers for it, and domains of the user's apps in it are
+-- verified and routed without records of their own.
+CREATE TABLE dns_zones (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    zone VARCHAR(253) NOT NULL UNIQUE,
+    status VARCHAR(20) DEFAULT 'pending' NOT NULL CHECK (status IN ('pending', 'active')),
+    checked_at TIMESTAMPTZ,
+    delegated_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_dns_zones_user_id ON dns_zones(user_id);
+
+-- A Cloudflare Tunnel per app on clusters without a public ingress. The
+-- app's cloudflared connectors run with the tunnel's token, encrypted with
+-- ENCRYPTION_KEY. dns_record_id is the proxied CNAME routing the app's
+-- hostname to the tunnel.
+CREATE TABLE app_tunnels (
+    app_id UUID PRIMARY KEY REFERENCES apps(id) ON DELETE CASCADE,
+    tunnel_id VARCHAR(64) NOT NULL,
+    token_encrypted BYTEA NOT NULL,
+    dns_record_id VARCHAR(64) DEFAULT '' NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
@@ -8,7 +8,9 @@ package db
 import (
 	"context"
 	"net/netip"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -24,18 +26,39 @@ func (q *Queries) CountActivityLogsByApp(ctx context.Context, appID pgtype.UUID)
 	return count, err
 }
 
+const countFilteredActivityLogsByApp = `-- name: CountFilteredActivityLogsByApp :one
+SELECT COUNT(*) FROM activity_logs
+WHERE app_id = $1
+  AND ($2::uuid IS NULL OR user_id = $2)
+  AND ($3::uuid IS NULL OR api_token_id = $3)
+`
+
+type CountFilteredActivityLogsByAppParams struct {
+	AppID      pgtype.UUID `json:"app_id"`
+	UserID     pgtype.UUID `json:"user_id"`
+	ApiTokenID pgtype.UUID `json:"api_token_id"`
+}
+
+func (q *Queries) CountFilteredActivityLogsByApp(ctx context.Context, arg CountFilteredActivityLogsByAppParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countFilteredActivityLogsByApp, arg.AppID, arg.UserID, arg.ApiTokenID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createActivityLog = `-- name: CreateActivityLog :one
-INSERT INTO activity_logs (user_id, app_id, action, details, ip_address)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, user_id, app_id, action, details, ip_address, created_at
+INSERT INTO activity_logs (user_id, app_id, action, details, ip_address, api_token_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, user_id, app_id, action, details, ip_address, created_at, api_token_id
 `
 
 type CreateActivityLogParams struct {
-	UserID    pgtype.UUID `json:"user_id"`
-	AppID     pgtype.UUID `json:"app_id"`
-	Action    string      `json:"action"`
-	Details   []byte      `json:"details"`
-	IpAddress *netip.Addr `json:"ip_address"`
+	UserID     pgtype.UUID `json:"user_id"`
+	AppID      pgtype.UUID `json:"app_id"`
+	Action     string      `json:"action"`
+	Details    []byte      `json:"details"`
+	IpAddress  *netip.Addr `json:"ip_address"`
+	ApiTokenID pgtype.UUID `json:"api_token_id"`
 }
 
 func (q *Queries) CreateActivityLog(ctx context.Context, arg CreateActivityLogParams) (ActivityLog, error) {
@@ -45,6 +68,7 @@ func (q *Queries) CreateActivityLog(ctx context.Context, arg CreateActivityLogPa
 		arg.Action,
 		arg.Details,
 		arg.IpAddress,
+		arg.ApiTokenID,
 	)
 	var i ActivityLog
 	err := row.Scan(
@@ -55,12 +79,79 @@ func (q *Queries) CreateActivityLog(ctx context.Context, arg CreateActivityLogPa
 		&i.Details,
 		&i.IpAddress,
 		&i.CreatedAt,
+		&i.ApiTokenID,
 	)
 	return i, err
 }
 
+const deleteActivityLogs = `-- name: DeleteActivityLogs :execrows
+DELETE FROM activity_logs WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) DeleteActivityLogs(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteActivityLogs, ids)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const filterActivityLogsByApp = `-- name: FilterActivityLogsByApp :many
+SELECT id, user_id, app_id, action, details, ip_address, created_at, api_token_id FROM activity_logs
+WHERE app_id = $1
+  AND ($2::uuid IS NULL OR user_id = $2)
+  AND ($3::uuid IS NULL OR api_token_id = $3)
+ORDER BY created_at DESC
+LIMIT $4 OFFSET $5
+`
+
+type FilterActivityLogsByAppParams struct {
+	AppID       pgtype.UUID `json:"app_id"`
+	UserID      pgtype.UUID `json:"user_id"`
+	ApiTokenID  pgtype.UUID `json:"api_token_id"`
+	LimitCount  int32       `json:"limit_count"`
+	OffsetCount int32       `json:"offset_count"`
+}
+
+// An app's activity, newest first, only that of a user and of an API token
+// when given
+func (q *Queries) FilterActivityLogsByApp(ctx context.Context, arg FilterActivityLogsByAppParams) ([]ActivityLog, error) {
+	rows, err := q.db.Query(ctx, filterActivityLogsByApp,
+		arg.AppID,
+		arg.UserID,
+		arg.ApiTokenID,
+		arg.LimitCount,
+		arg.OffsetCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ActivityLog{}
+	for rows.Next() {
+		var i ActivityLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.AppID,
+			&i.Action,
+			&i.Details,
+			&i.IpAddress,
+			&i.CreatedAt,
+			&i.ApiTokenID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listActivityLogsByApp = `-- name: ListActivityLogsByApp :many
-SELECT id, user_id, app_id, action, details, ip_address, created_at FROM activity_logs
+SELECT id, user_id, app_id, action, details, ip_address, created_at, api_token_id FROM activity_logs
 WHERE app_id = $1
 ORDER BY created_at DESC
 LIMIT $2 OFFSET $3
@@ -89,6 +180,7 @@ func (q *Queries) ListActivityLogsByApp(ctx context.Context, arg ListActivityLog
 			&i.Details,
 			&i.IpAddress,
 			&i.CreatedAt,
+			&i.ApiTokenID,
 		); err != nil {
 			return nil, err
 		}
@@ -101,7 +193,7 @@ func (q *Queries) ListActivityLogsByApp(ctx context.Context, arg ListActivityLog
 }
 
 const listActivityLogsByUser = `-- name: ListActivityLogsByUser :many
-SELECT id, user_id, app_id, action, details, ip_address, created_at FROM activity_logs
+SELECT id, user_id, app_id, action, details, ip_address, created_at, api_token_id FROM activity_logs
 WHERE user_id = $1
 ORDER BY created_at DESC
 LIMIT $2 OFFSET $3
@@ -130,6 +222,122 @@ func (q *Queries) ListActivityLogsByUser(ctx context.Context, arg ListActivityLo
 			&i.Details,
 			&i.IpAddress,
 			&i.CreatedAt,
+			&i.ApiTokenID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listActivityLogsForUsersBetween = `-- name: ListActivityLogsForUsersBetween :many
+SELECT l.id, l.user_id, l.app_id, l.action, l.details, l.ip_address, l.created_at, l.api_token_id FROM activity_logs l
+LEFT JOIN apps a ON a.id = l.app_id
+WHERE (l.user_id = ANY($1::uuid[]) OR a.user_id = ANY($1::uuid[]))
+  AND l.created_at >= $2::timestamptz
+  AND l.created_at < $3::timestamptz
+ORDER BY l.created_at
+LIMIT $4
+`
+
+type ListActivityLogsForUsersBetweenParams struct {
+	UserIds    []uuid.UUID `json:"user_ids"`
+	FromTime   time.Time   `json:"from_time"`
+	ToTime     time.Time   `json:"to_time"`
+	LimitCount int32       `json:"limit_count"`
+}
+
+// Activity of the given users and on their apps in a time range, oldest
+// first
+func (q *Queries) ListActivityLogsForUsersBetween(ctx context.Context, arg ListActivityLogsForUsersBetweenParams) ([]ActivityLog, error) {
+	rows, err := q.db.Query(ctx, listActivityLogsForUsersBetween,
+		arg.UserIds,
+		arg.FromTime,
+		arg.ToTime,
+		arg.LimitCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ActivityLog{}
+	for rows.Next() {
+		var i ActivityLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.AppID,
+			&i.Action,
+			&i.Details,
+			&i.IpAddress,
+			&i.CreatedAt,
+			&i.ApiTokenID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpiredActivityLogs = `-- name: ListExpiredActivityLogs :many
+SELECT l.id, l.user_id, l.app_id, l.action, l.details, l.ip_address, l.created_at, l.api_token_id FROM activity_logs l
+LEFT JOIN apps a ON a.id = l.app_id
+LEFT JOIN users u ON u.id = COALESCE(a.user_id, l.user_id)
+WHERE l.created_at < $1::timestamptz
+  AND l.created_at < COALESCE(
+    (SELECT c.cutoff FROM unnest($2::text[], $3::timestamptz[]) AS c(plan, cutoff) WHERE c.plan = u.plan),
+    $4::timestamptz
+  )
+  AND NOT legal_hold_covers(a.user_id)
+  AND NOT legal_hold_covers(l.user_id)
+ORDER BY l.created_at
+LIMIT $5
+`
+
+type ListExpiredActivityLogsParams struct {
+	NewestCutoff  time.Time   `json:"newest_cutoff"`
+	Plans         []string    `json:"plans"`
+	Cutoffs       []time.Time `json:"cutoffs"`
+	DefaultCutoff time.Time   `json:"default_cutoff"`
+	BatchSize     int32       `json:"batch_size"`
+}
+
+// The oldest activity logs past the retention of the plan of the account
+// they belong to: the app's owner, or the user who acted for entries of no
+// app. Plans without a cutoff of their own use the default one. Entries a
+// legal hold covers are kept.
+func (q *Queries) ListExpiredActivityLogs(ctx context.Context, arg ListExpiredActivityLogsParams) ([]ActivityLog, error) {
+	rows, err := q.db.Query(ctx, listExpiredActivityLogs,
+		arg.NewestCutoff,
+		arg.Plans,
+		arg.Cutoffs,
+		arg.DefaultCutoff,
+		arg.BatchSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ActivityLog{}
+	for rows.Next() {
+		var i ActivityLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.AppID,
+			&i.Action,
+			&i.Details,
+			&i.IpAddress,
+			&i.CreatedAt,
+			&i.ApiTokenID,
 		); err != nil {
 			return nil, err
 		}
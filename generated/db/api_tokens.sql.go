@@ -7,22 +7,26 @@ package db
 
 import (
 	"context"
+	"net/netip"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createAPIToken = `-- name: CreateAPIToken :one
-INSERT INTO api_tokens (user_id, name, token_hash, expires_at)
-VALUES ($1, $2, $3, $4)
-RETURNING id, user_id, name, token_hash, last_used_at, expires_at, created_at
+INSERT INTO api_tokens (user_id, name, token_hash, expires_at, allowed_cidrs, expire_after_unused_days, registry_access)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, user_id, name, token_hash, last_used_at, expires_at, created_at, allowed_cidrs, last_used_ip, last_used_user_agent, expire_after_unused_days, registry_access
 `
 
 type CreateAPITokenParams struct {
-	UserID    uuid.UUID          `json:"user_id"`
-	Name      string             `json:"name"`
-	TokenHash string             `json:"token_hash"`
-	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	UserID                uuid.UUID          `json:"user_id"`
+	Name                  string             `json:"name"`
+	TokenHash             string             `json:"token_hash"`
+	ExpiresAt             pgtype.Timestamptz `json:"expires_at"`
+	AllowedCidrs          []string           `json:"allowed_cidrs"`
+	ExpireAfterUnusedDays *int32             `json:"expire_after_unused_days"`
+	RegistryAccess        *string            `json:"registry_access"`
 }
 
 func (q *Queries) CreateAPIToken(ctx context.Context, arg CreateAPITokenParams) (ApiToken, error) {
@@ -31,6 +35,9 @@ func (q *Queries) CreateAPIToken(ctx context.Context, arg CreateAPITokenParams)
 		arg.Name,
 		arg.TokenHash,
 		arg.ExpiresAt,
+		arg.AllowedCidrs,
+		arg.ExpireAfterUnusedDays,
+		arg.RegistryAccess,
 	)
 	var i ApiToken
 	err := row.Scan(
@@ -41,6 +48,11 @@ func (q *Queries) CreateAPIToken(ctx context.Context, arg CreateAPITokenParams)
 		&i.LastUsedAt,
 		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.AllowedCidrs,
+		&i.LastUsedIp,
+		&i.LastUsedUserAgent,
+		&i.ExpireAfterUnusedDays,
+		&i.RegistryAccess,
 	)
 	return i, err
 }
@@ -54,6 +66,15 @@ func (q *Queries) DeleteAPIToken(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const deleteAPITokensByUser = `-- name: DeleteAPITokensByUser :exec
+DELETE FROM api_tokens WHERE user_id = $1
+`
+
+func (q *Queries) DeleteAPITokensByUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteAPITokensByUser, userID)
+	return err
+}
+
 const deleteExpiredAPITokens = `-- name: DeleteExpiredAPITokens :exec
 DELETE FROM api_tokens
 WHERE expires_at IS NOT NULL AND expires_at < NOW()
@@ -64,8 +85,52 @@ func (q *Queries) DeleteExpiredAPITokens(ctx context.Context) error {
 	return err
 }
 
+const deleteNonCompliantAPITokens = `-- name: DeleteNonCompliantAPITokens :execrows
+DELETE FROM api_tokens t
+USING (
+    SELECT org_id, user_id FROM organization_members WHERE active = TRUE
+    UNION ALL
+    SELECT org_id, user_id FROM machine_users
+) m, organizations o
+WHERE m.user_id = t.user_id
+  AND o.id = m.org_id
+  AND o.max_token_lifetime_days IS NOT NULL
+  AND (t.expires_at IS NULL OR t.expires_at > t.created_at + make_interval(days => o.max_token_lifetime_days))
+`
+
+func (q *Queries) DeleteNonCompliantAPITokens(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteNonCompliantAPITokens)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteOldAPITokenUsage = `-- name: DeleteOldAPITokenUsage :exec
+DELETE FROM api_token_usage WHERE day < $1
+`
+
+func (q *Queries) DeleteOldAPITokenUsage(ctx context.Context, day pgtype.Date) error {
+	_, err := q.db.Exec(ctx, deleteOldAPITokenUsage, day)
+	return err
+}
+
+const deleteUnusedAPITokens = `-- name: DeleteUnusedAPITokens :execrows
+DELETE FROM api_tokens
+WHERE expire_after_unused_days IS NOT NULL
+  AND COALESCE(last_used_at, created_at) < NOW() - make_interval(days => expire_after_unused_days)
+`
+
+func (q *Queries) DeleteUnusedAPITokens(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteUnusedAPITokens)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getAPITokenByHash = `-- name: GetAPITokenByHash :one
-SELECT id, user_id, name, token_hash, last_used_at, expires_at, created_at FROM api_tokens WHERE token_hash = $1
+SELECT id, user_id, name, token_hash, last_used_at, expires_at, created_at, allowed_cidrs, last_used_ip, last_used_user_agent, expire_after_unused_days, registry_access FROM api_tokens WHERE token_hash = $1
 `
 
 func (q *Queries) GetAPITokenByHash(ctx context.Context, tokenHash string) (ApiToken, error) {
@@ -79,12 +144,17 @@ func (q *Queries) GetAPITokenByHash(ctx context.Context, tokenHash string) (ApiT
 		&i.LastUsedAt,
 		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.AllowedCidrs,
+		&i.LastUsedIp,
+		&i.LastUsedUserAgent,
+		&i.ExpireAfterUnusedDays,
+		&i.RegistryAccess,
 	)
 	return i, err
 }
 
 const getAPITokenByID = `-- name: GetAPITokenByID :one
-SELECT id, user_id, name, token_hash, last_used_at, expires_at, created_at FROM api_tokens WHERE id = $1
+SELECT id, user_id, name, token_hash, last_used_at, expires_at, created_at, allowed_cidrs, last_used_ip, last_used_user_agent, expire_after_unused_days, registry_access FROM api_tokens WHERE id = $1
 `
 
 func (q *Queries) GetAPITokenByID(ctx context.Context, id uuid.UUID) (ApiToken, error) {
@@ -98,12 +168,68 @@ func (q *Queries) GetAPITokenByID(ctx context.Context, id uuid.UUID) (ApiToken,
 		&i.LastUsedAt,
 		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.AllowedCidrs,
+		&i.LastUsedIp,
+		&i.LastUsedUserAgent,
+		&i.ExpireAfterUnusedDays,
+		&i.RegistryAccess,
 	)
 	return i, err
 }
 
+const getMaxTokenLifetimeForUser = `-- name: GetMaxTokenLifetimeForUser :one
+SELECT COALESCE(MIN(o.max_token_lifetime_days), 0)::INTEGER AS max_days
+FROM organizations o
+JOIN (
+    SELECT org_id, user_id FROM organization_members WHERE active = TRUE
+    UNION ALL
+    SELECT org_id, user_id FROM machine_users
+) m ON m.org_id = o.id
+WHERE m.user_id = $1 AND o.max_token_lifetime_days IS NOT NULL
+`
+
+func (q *Queries) GetMaxTokenLifetimeForUser(ctx context.Context, userID pgtype.UUID) (int32, error) {
+	row := q.db.QueryRow(ctx, getMaxTokenLifetimeForUser, userID)
+	var maxDays int32
+	err := row.Scan(&maxDays)
+	return maxDays, err
+}
+
+const listAPITokenUsageByUser = `-- name: ListAPITokenUsageByUser :many
+SELECT u.token_id, u.day, u.count
+FROM api_token_usage u
+JOIN api_tokens t ON t.id = u.token_id
+WHERE t.user_id = $1 AND u.day >= $2
+ORDER BY u.token_id, u.day
+`
+
+type ListAPITokenUsageByUserParams struct {
+	UserID uuid.UUID   `json:"user_id"`
+	Day    pgtype.Date `json:"day"`
+}
+
+func (q *Queries) ListAPITokenUsageByUser(ctx context.Context, arg ListAPITokenUsageByUserParams) ([]ApiTokenUsage, error) {
+	rows, err := q.db.Query(ctx, listAPITokenUsageByUser, arg.UserID, arg.Day)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ApiTokenUsage{}
+	for rows.Next() {
+		var i ApiTokenUsage
+		if err := rows.Scan(&i.TokenID, &i.Day, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listAPITokensByUser = `-- name: ListAPITokensByUser :many
-SELECT id, user_id, name, token_hash, last_used_at, expires_at, created_at FROM api_tokens
+SELECT id, user_id, name, token_hash, last_used_at, expires_at, created_at, allowed_cidrs, last_used_ip, last_used_user_agent, expire_after_unused_days, registry_access FROM api_tokens
 WHERE user_id = $1
 ORDER BY created_at DESC
 `
@@ -125,6 +251,11 @@ func (q *Queries) ListAPITokensByUser(ctx context.Context, userID uuid.UUID) ([]
 			&i.LastUsedAt,
 			&i.ExpiresAt,
 			&i.CreatedAt,
+			&i.AllowedCidrs,
+			&i.LastUsedIp,
+			&i.LastUsedUserAgent,
+			&i.ExpireAfterUnusedDays,
+			&i.RegistryAccess,
 		); err != nil {
 			return nil, err
 		}
@@ -136,6 +267,28 @@ func (q *Queries) ListAPITokensByUser(ctx context.Context, userID uuid.UUID) ([]
 	return items, nil
 }
 
+const recordAPITokenUse = `-- name: RecordAPITokenUse :exec
+WITH usage AS (
+    INSERT INTO api_token_usage (token_id, day, count)
+    VALUES ($1, CURRENT_DATE, 1)
+    ON CONFLICT (token_id, day) DO UPDATE SET count = api_token_usage.count + 1
+)
+UPDATE api_tokens
+SET last_used_at = NOW(), last_used_ip = $2, last_used_user_agent = $3
+WHERE id = $1
+`
+
+type RecordAPITokenUseParams struct {
+	ID                uuid.UUID   `json:"id"`
+	LastUsedIp        *netip.Addr `json:"last_used_ip"`
+	LastUsedUserAgent *string     `json:"last_used_user_agent"`
+}
+
+func (q *Queries) RecordAPITokenUse(ctx context.Context, arg RecordAPITokenUseParams) error {
+	_, err := q.db.Exec(ctx, recordAPITokenUse, arg.ID, arg.LastUsedIp, arg.LastUsedUserAgent)
+	return err
+}
+
 const updateAPITokenLastUsed = `-- name: UpdateAPITokenLastUsed :exec
 UPDATE api_tokens
 SET last_used_at = NOW()
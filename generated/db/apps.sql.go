@@ -23,10 +23,22 @@ func (q *Queries) CountAppsByUser(ctx context.Context, userID uuid.UUID) (int64,
 	return count, err
 }
 
+const countShowcaseApps = `-- name: CountShowcaseApps :one
+SELECT COUNT(*) FROM apps
+WHERE showcase AND status NOT IN ('suspended', 'purged')
+`
+
+func (q *Queries) CountShowcaseApps(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countShowcaseApps)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createApp = `-- name: CreateApp :one
 INSERT INTO apps (user_id, name, region, size)
 VALUES ($1, $2, $3, $4)
-RETURNING id, user_id, name, region, size, status, deployment_count, current_deployment_id, env_vars_encrypted, created_at, updated_at
+RETURNING id, user_id, name, region, size, status, deployment_count, current_deployment_id, env_vars_encrypted, created_at, updated_at, description, icon_url, repository_url, tags, project_id, labels, health_check_path, showcase
 `
 
 type CreateAppParams struct {
@@ -56,6 +68,14 @@ func (q *Queries) CreateApp(ctx context.Context, arg CreateAppParams) (App, erro
 		&i.EnvVarsEncrypted,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Description,
+		&i.IconUrl,
+		&i.RepositoryUrl,
+		&i.Tags,
+		&i.ProjectID,
+		&i.Labels,
+		&i.HealthCheckPath,
+		&i.Showcase,
 	)
 	return i, err
 }
@@ -70,7 +90,7 @@ func (q *Queries) DeleteApp(ctx context.Context, id uuid.UUID) error {
 }
 
 const getAppByID = `-- name: GetAppByID :one
-SELECT id, user_id, name, region, size, status, deployment_count, current_deployment_id, env_vars_encrypted, created_at, updated_at FROM apps WHERE id = $1
+SELECT id, user_id, name, region, size, status, deployment_count, current_deployment_id, env_vars_encrypted, created_at, updated_at, description, icon_url, repository_url, tags, project_id, labels, health_check_path, showcase FROM apps WHERE id = $1
 `
 
 func (q *Queries) GetAppByID(ctx context.Context, id uuid.UUID) (App, error) {
@@ -88,12 +108,20 @@ func (q *Queries) GetAppByID(ctx context.Context, id uuid.UUID) (App, error) {
 		&i.EnvVarsEncrypted,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Description,
+		&i.IconUrl,
+		&i.RepositoryUrl,
+		&i.Tags,
+		&i.ProjectID,
+		&i.Labels,
+		&i.HealthCheckPath,
+		&i.Showcase,
 	)
 	return i, err
 }
 
 const getAppByName = `-- name: GetAppByName :one
-SELECT id, user_id, name, region, size, status, deployment_count, current_deployment_id, env_vars_encrypted, created_at, updated_at FROM apps
+SELECT id, user_id, name, region, size, status, deployment_count, current_deployment_id, env_vars_encrypted, created_at, updated_at, description, icon_url, repository_url, tags, project_id, labels, health_check_path, showcase FROM apps
 WHERE user_id = $1 AND name = $2
 `
 
@@ -117,6 +145,46 @@ func (q *Queries) GetAppByName(ctx context.Context, arg GetAppByNameParams) (App
 		&i.EnvVarsEncrypted,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Description,
+		&i.IconUrl,
+		&i.RepositoryUrl,
+		&i.Tags,
+		&i.ProjectID,
+		&i.Labels,
+		&i.HealthCheckPath,
+		&i.Showcase,
+	)
+	return i, err
+}
+
+const getShowcaseApp = `-- name: GetShowcaseApp :one
+SELECT id, user_id, name, region, size, status, deployment_count, current_deployment_id, env_vars_encrypted, created_at, updated_at, description, icon_url, repository_url, tags, project_id, labels, health_check_path, showcase FROM apps
+WHERE id = $1 AND showcase AND status NOT IN ('suspended', 'purged')
+`
+
+func (q *Queries) GetShowcaseApp(ctx context.Context, id uuid.UUID) (App, error) {
+	row := q.db.QueryRow(ctx, getShowcaseApp, id)
+	var i App
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Region,
+		&i.Size,
+		&i.Status,
+		&i.DeploymentCount,
+		&i.CurrentDeploymentID,
+		&i.EnvVarsEncrypted,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Description,
+		&i.IconUrl,
+		&i.RepositoryUrl,
+		&i.Tags,
+		&i.ProjectID,
+		&i.Labels,
+		&i.HealthCheckPath,
+		&i.Showcase,
 	)
 	return i, err
 }
@@ -125,7 +193,7 @@ const incrementDeploymentCount = `-- name: IncrementDeploymentCount :one
 UPDATE apps
 SET deployment_count = deployment_count + 1
 WHERE id = $1
-RETURNING id, user_id, name, region, size, status, deployment_count, current_deployment_id, env_vars_encrypted, created_at, updated_at
+RETURNING id, user_id, name, region, size, status, deployment_count, current_deployment_id, env_vars_encrypted, created_at, updated_at, description, icon_url, repository_url, tags, project_id, labels, health_check_path, showcase
 `
 
 func (q *Queries) IncrementDeploymentCount(ctx context.Context, id uuid.UUID) (App, error) {
@@ -143,12 +211,111 @@ func (q *Queries) IncrementDeploymentCount(ctx context.Context, id uuid.UUID) (A
 		&i.EnvVarsEncrypted,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Description,
+		&i.IconUrl,
+		&i.RepositoryUrl,
+		&i.Tags,
+		&i.ProjectID,
+		&i.Labels,
+		&i.HealthCheckPath,
+		&i.Showcase,
 	)
 	return i, err
 }
 
+const listAppsByProject = `-- name: ListAppsByProject :many
+SELECT id, user_id, name, region, size, status, deployment_count, current_deployment_id, env_vars_encrypted, created_at, updated_at, description, icon_url, repository_url, tags, project_id, labels, health_check_path, showcase FROM apps
+WHERE project_id = $1
+ORDER BY name
+`
+
+func (q *Queries) ListAppsByProject(ctx context.Context, projectID pgtype.UUID) ([]App, error) {
+	rows, err := q.db.Query(ctx, listAppsByProject, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []App{}
+	for rows.Next() {
+		var i App
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Region,
+			&i.Size,
+			&i.Status,
+			&i.DeploymentCount,
+			&i.CurrentDeploymentID,
+			&i.EnvVarsEncrypted,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Description,
+			&i.IconUrl,
+			&i.RepositoryUrl,
+			&i.Tags,
+			&i.ProjectID,
+			&i.Labels,
+			&i.HealthCheckPath,
+			&i.Showcase,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAppsByRegion = `-- name: ListAppsByRegion :many
+SELECT id, user_id, name, region, size, status, deployment_count, current_deployment_id, env_vars_encrypted, created_at, updated_at, description, icon_url, repository_url, tags, project_id, labels, health_check_path, showcase FROM apps
+WHERE region = $1
+`
+
+func (q *Queries) ListAppsByRegion(ctx context.Context, region string) ([]App, error) {
+	rows, err := q.db.Query(ctx, listAppsByRegion, region)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []App{}
+	for rows.Next() {
+		var i App
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Region,
+			&i.Size,
+			&i.Status,
+			&i.DeploymentCount,
+			&i.CurrentDeploymentID,
+			&i.EnvVarsEncrypted,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Description,
+			&i.IconUrl,
+			&i.RepositoryUrl,
+			&i.Tags,
+			&i.ProjectID,
+			&i.Labels,
+			&i.HealthCheckPath,
+			&i.Showcase,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listAppsByUser = `-- name: ListAppsByUser :many
-SELECT id, user_id, name, region, size, status, deployment_count, current_deployment_id, env_vars_encrypted, created_at, updated_at FROM apps
+SELECT id, user_id, name, region, size, status, deployment_count, current_deployment_id, env_vars_encrypted, created_at, updated_at, description, icon_url, repository_url, tags, project_id, labels, health_check_path, showcase FROM apps
 WHERE user_id = $1
 ORDER BY created_at DESC
 LIMIT $2 OFFSET $3
@@ -181,6 +348,188 @@ func (q *Queries) ListAppsByUser(ctx context.Context, arg ListAppsByUserParams)
 			&i.EnvVarsEncrypted,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Description,
+			&i.IconUrl,
+			&i.RepositoryUrl,
+			&i.Tags,
+			&i.ProjectID,
+			&i.Labels,
+			&i.HealthCheckPath,
+			&i.Showcase,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAppsByUserAndStatus = `-- name: ListAppsByUserAndStatus :many
+SELECT id, user_id, name, region, size, status, deployment_count, current_deployment_id, env_vars_encrypted, created_at, updated_at, description, icon_url, repository_url, tags, project_id, labels, health_check_path, showcase FROM apps
+WHERE user_id = $1 AND status = $2
+ORDER BY name
+`
+
+type ListAppsByUserAndStatusParams struct {
+	UserID uuid.UUID `json:"user_id"`
+	Status AppStatus `json:"status"`
+}
+
+func (q *Queries) ListAppsByUserAndStatus(ctx context.Context, arg ListAppsByUserAndStatusParams) ([]App, error) {
+	rows, err := q.db.Query(ctx, listAppsByUserAndStatus, arg.UserID, arg.Status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []App{}
+	for rows.Next() {
+		var i App
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Region,
+			&i.Size,
+			&i.Status,
+			&i.DeploymentCount,
+			&i.CurrentDeploymentID,
+			&i.EnvVarsEncrypted,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Description,
+			&i.IconUrl,
+			&i.RepositoryUrl,
+			&i.Tags,
+			&i.ProjectID,
+			&i.Labels,
+			&i.HealthCheckPath,
+			&i.Showcase,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listShowcaseApps = `-- name: ListShowcaseApps :many
+SELECT id, user_id, name, region, size, status, deployment_count, current_deployment_id, env_vars_encrypted, created_at, updated_at, description, icon_url, repository_url, tags, project_id, labels, health_check_path, showcase FROM apps
+WHERE showcase AND status NOT IN ('suspended', 'purged')
+ORDER BY name, id
+LIMIT $1 OFFSET $2
+`
+
+type ListShowcaseAppsParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+// Apps in the public showcase; suspended and purged apps drop out of it
+func (q *Queries) ListShowcaseApps(ctx context.Context, arg ListShowcaseAppsParams) ([]App, error) {
+	rows, err := q.db.Query(ctx, listShowcaseApps, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []App{}
+	for rows.Next() {
+		var i App
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Region,
+			&i.Size,
+			&i.Status,
+			&i.DeploymentCount,
+			&i.CurrentDeploymentID,
+			&i.EnvVarsEncrypted,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Description,
+			&i.IconUrl,
+			&i.RepositoryUrl,
+			&i.Tags,
+			&i.ProjectID,
+			&i.Labels,
+			&i.HealthCheckPath,
+			&i.Showcase,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchAppsByUser = `-- name: SearchAppsByUser :many
+-- search matches the name or description (an ILIKE pattern) or a tag exactly;
+-- every one of tags must be present
+SELECT id, user_id, name, region, size, status, deployment_count, current_deployment_id, env_vars_encrypted, created_at, updated_at, description, icon_url, repository_url, tags, project_id, labels, health_check_path, showcase FROM apps
+WHERE user_id = $1
+  AND ($2::TEXT = ''
+    OR name ILIKE $3::TEXT
+    OR description ILIKE $3::TEXT
+    OR $2::TEXT = ANY(tags))
+  AND tags @> $4::TEXT[]
+ORDER BY created_at DESC
+LIMIT $5::INTEGER OFFSET $6::INTEGER
+`
+
+type SearchAppsByUserParams struct {
+	UserID  uuid.UUID `json:"user_id"`
+	Search  string    `json:"search"`
+	Pattern string    `json:"pattern"`
+	Tags    []string  `json:"tags"`
+	MaxApps int32     `json:"max_apps"`
+	Skip    int32     `json:"skip"`
+}
+
+func (q *Queries) SearchAppsByUser(ctx context.Context, arg SearchAppsByUserParams) ([]App, error) {
+	rows, err := q.db.Query(ctx, searchAppsByUser,
+		arg.UserID,
+		arg.Search,
+		arg.Pattern,
+		arg.Tags,
+		arg.MaxApps,
+		arg.Skip,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []App{}
+	for rows.Next() {
+		var i App
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Region,
+			&i.Size,
+			&i.Status,
+			&i.DeploymentCount,
+			&i.CurrentDeploymentID,
+			&i.EnvVarsEncrypted,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Description,
+			&i.IconUrl,
+			&i.RepositoryUrl,
+			&i.Tags,
+			&i.ProjectID,
+			&i.Labels,
+			&i.HealthCheckPath,
+			&i.Showcase,
 		); err != nil {
 			return nil, err
 		}
@@ -192,11 +541,50 @@ func (q *Queries) ListAppsByUser(ctx context.Context, arg ListAppsByUserParams)
 	return items, nil
 }
 
+const setAppProject = `-- name: SetAppProject :one
+UPDATE apps
+SET project_id = $2
+WHERE id = $1
+RETURNING id, user_id, name, region, size, status, deployment_count, current_deployment_id, env_vars_encrypted, created_at, updated_at, description, icon_url, repository_url, tags, project_id, labels, health_check_path, showcase
+`
+
+type SetAppProjectParams struct {
+	ID        uuid.UUID   `json:"id"`
+	ProjectID pgtype.UUID `json:"project_id"`
+}
+
+func (q *Queries) SetAppProject(ctx context.Context, arg SetAppProjectParams) (App, error) {
+	row := q.db.QueryRow(ctx, setAppProject, arg.ID, arg.ProjectID)
+	var i App
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Region,
+		&i.Size,
+		&i.Status,
+		&i.DeploymentCount,
+		&i.CurrentDeploymentID,
+		&i.EnvVarsEncrypted,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Description,
+		&i.IconUrl,
+		&i.RepositoryUrl,
+		&i.Tags,
+		&i.ProjectID,
+		&i.Labels,
+		&i.HealthCheckPath,
+		&i.Showcase,
+	)
+	return i, err
+}
+
 const updateApp = `-- name: UpdateApp :one
 UPDATE apps
 SET name = $2, region = $3, size = $4
 WHERE id = $1
-RETURNING id, user_id, name, region, size, status, deployment_count, current_deployment_id, env_vars_encrypted, created_at, updated_at
+RETURNING id, user_id, name, region, size, status, deployment_count, current_deployment_id, env_vars_encrypted, created_at, updated_at, description, icon_url, repository_url, tags, project_id, labels, health_check_path, showcase
 `
 
 type UpdateAppParams struct {
@@ -226,6 +614,14 @@ func (q *Queries) UpdateApp(ctx context.Context, arg UpdateAppParams) (App, erro
 		&i.EnvVarsEncrypted,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Description,
+		&i.IconUrl,
+		&i.RepositoryUrl,
+		&i.Tags,
+		&i.ProjectID,
+		&i.Labels,
+		&i.HealthCheckPath,
+		&i.Showcase,
 	)
 	return i, err
 }
@@ -234,7 +630,7 @@ const updateAppEnvVars = `-- name: UpdateAppEnvVars :one
 UPDATE apps
 SET env_vars_encrypted = $2
 WHERE id = $1
-RETURNING id, user_id, name, region, size, status, deployment_count, current_deployment_id, env_vars_encrypted, created_at, updated_at
+RETURNING id, user_id, name, region, size, status, deployment_count, current_deployment_id, env_vars_encrypted, created_at, updated_at, description, icon_url, repository_url, tags, project_id, labels, health_check_path, showcase
 `
 
 type UpdateAppEnvVarsParams struct {
@@ -257,25 +653,206 @@ func (q *Queries) UpdateAppEnvVars(ctx context.Context, arg UpdateAppEnvVarsPara
 		&i.EnvVarsEncrypted,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Description,
+		&i.IconUrl,
+		&i.RepositoryUrl,
+		&i.Tags,
+		&i.ProjectID,
+		&i.Labels,
+		&i.HealthCheckPath,
+		&i.Showcase,
 	)
 	return i, err
 }
 
-const updateAppStatus = `-- name: UpdateAppStatus :one
+const updateAppHealthCheckPath = `-- name: UpdateAppHealthCheckPath :one
+UPDATE apps
+SET health_check_path = $2
+WHERE id = $1
+RETURNING id, user_id, name, region, size, status, deployment_count, current_deployment_id, env_vars_encrypted, created_at, updated_at, description, icon_url, repository_url, tags, project_id, labels, health_check_path, showcase
+`
+
+type UpdateAppHealthCheckPathParams struct {
+	ID              uuid.UUID `json:"id"`
+	HealthCheckPath string    `json:"health_check_path"`
+}
+
+func (q *Queries) UpdateAppHealthCheckPath(ctx context.Context, arg UpdateAppHealthCheckPathParams) (App, error) {
+	row := q.db.QueryRow(ctx, updateAppHealthCheckPath, arg.ID, arg.HealthCheckPath)
+	var i App
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Region,
+		&i.Size,
+		&i.Status,
+		&i.DeploymentCount,
+		&i.CurrentDeploymentID,
+		&i.EnvVarsEncrypted,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Description,
+		&i.IconUrl,
+		&i.RepositoryUrl,
+		&i.Tags,
+		&i.ProjectID,
+		&i.Labels,
+		&i.HealthCheckPath,
+		&i.Showcase,
+	)
+	return i, err
+}
+
+const updateAppLabels = `-- name: UpdateAppLabels :one
+UPDATE apps
+SET labels = $2
+WHERE id = $1
+RETURNING id, user_id, name, region, size, status, deployment_count, current_deployment_id, env_vars_encrypted, created_at, updated_at, description, icon_url, repository_url, tags, project_id, labels, health_check_path, showcase
+`
+
+type UpdateAppLabelsParams struct {
+	ID     uuid.UUID `json:"id"`
+	Labels []byte    `json:"labels"`
+}
+
+func (q *Queries) UpdateAppLabels(ctx context.Context, arg UpdateAppLabelsParams) (App, error) {
+	row := q.db.QueryRow(ctx, updateAppLabels, arg.ID, arg.Labels)
+	var i App
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Region,
+		&i.Size,
+		&i.Status,
+		&i.DeploymentCount,
+		&i.CurrentDeploymentID,
+		&i.EnvVarsEncrypted,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Description,
+		&i.IconUrl,
+		&i.RepositoryUrl,
+		&i.Tags,
+		&i.ProjectID,
+		&i.Labels,
+		&i.HealthCheckPath,
+		&i.Showcase,
+	)
+	return i, err
+}
+
+const updateAppMetadata = `-- name: UpdateAppMetadata :one
+UPDATE apps
+SET description = $2, icon_url = $3, repository_url = $4, tags = $5
+WHERE id = $1
+RETURNING id, user_id, name, region, size, status, deployment_count, current_deployment_id, env_vars_encrypted, created_at, updated_at, description, icon_url, repository_url, tags, project_id, labels, health_check_path, showcase
+`
+
+type UpdateAppMetadataParams struct {
+	ID            uuid.UUID `json:"id"`
+	Description   string    `json:"description"`
+	IconUrl       string    `json:"icon_url"`
+	RepositoryUrl string    `json:"repository_url"`
+	Tags          []string  `json:"tags"`
+}
+
+func (q *Queries) UpdateAppMetadata(ctx context.Context, arg UpdateAppMetadataParams) (App, error) {
+	row := q.db.QueryRow(ctx, updateAppMetadata,
+		arg.ID,
+		arg.Description,
+		arg.IconUrl,
+		arg.RepositoryUrl,
+		arg.Tags,
+	)
+	var i App
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Region,
+		&i.Size,
+		&i.Status,
+		&i.DeploymentCount,
+		&i.CurrentDeploymentID,
+		&i.EnvVarsEncrypted,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Description,
+		&i.IconUrl,
+		&i.RepositoryUrl,
+		&i.Tags,
+		&i.ProjectID,
+		&i.Labels,
+		&i.HealthCheckPath,
+		&i.Showcase,
+	)
+	return i, err
+}
+
+const updateAppShowcase = `-- name: UpdateAppShowcase :one
 UPDATE apps
-SET status = $2, current_deployment_id = $3
+SET showcase = $2
 WHERE id = $1
-RETURNING id, user_id, name, region, size, status, deployment_count, current_deployment_id, env_vars_encrypted, created_at, updated_at
+RETURNING id, user_id, name, region, size, status, deployment_count, current_deployment_id, env_vars_encrypted, created_at, updated_at, description, icon_url, repository_url, tags, project_id, labels, health_check_path, showcase
+`
+
+type UpdateAppShowcaseParams struct {
+	ID       uuid.UUID `json:"id"`
+	Showcase bool      `json:"showcase"`
+}
+
+func (q *Queries) UpdateAppShowcase(ctx context.Context, arg UpdateAppShowcaseParams) (App, error) {
+	row := q.db.QueryRow(ctx, updateAppShowcase, arg.ID, arg.Showcase)
+	var i App
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Region,
+		&i.Size,
+		&i.Status,
+		&i.DeploymentCount,
+		&i.CurrentDeploymentID,
+		&i.EnvVarsEncrypted,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Description,
+		&i.IconUrl,
+		&i.RepositoryUrl,
+		&i.Tags,
+		&i.ProjectID,
+		&i.Labels,
+		&i.HealthCheckPath,
+		&i.Showcase,
+	)
+	return i, err
+}
+
+const updateAppStatus = `-- name: UpdateAppStatus :one
+UPDATE apps
+SET status = $1, current_deployment_id = $2
+WHERE id = $3 AND status::TEXT = ANY($4::TEXT[])
+RETURNING id, user_id, name, region, size, status, deployment_count, current_deployment_id, env_vars_encrypted, created_at, updated_at, description, icon_url, repository_url, tags, project_id, labels, health_check_path, showcase
 `
 
 type UpdateAppStatusParams struct {
-	ID                  uuid.UUID   `json:"id"`
-	Status              string      `json:"status"`
+	Status              AppStatus   `json:"status"`
 	CurrentDeploymentID pgtype.UUID `json:"current_deployment_id"`
+	ID                  uuid.UUID   `json:"id"`
+	FromStatuses        []string    `json:"from_statuses"`
 }
 
+// Moves an app to a status from one of from_statuses, those the status may
+// be reached from; returns no row for an app in any other status
 func (q *Queries) UpdateAppStatus(ctx context.Context, arg UpdateAppStatusParams) (App, error) {
-	row := q.db.QueryRow(ctx, updateAppStatus, arg.ID, arg.Status, arg.CurrentDeploymentID)
+	row := q.db.QueryRow(ctx, updateAppStatus,
+		arg.Status,
+		arg.CurrentDeploymentID,
+		arg.ID,
+		arg.FromStatuses,
+	)
 	var i App
 	err := row.Scan(
 		&i.ID,
@@ -289,6 +866,14 @@ func (q *Queries) UpdateAppStatus(ctx context.Context, arg UpdateAppStatusParams
 		&i.EnvVarsEncrypted,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Description,
+		&i.IconUrl,
+		&i.RepositoryUrl,
+		&i.Tags,
+		&i.ProjectID,
+		&i.Labels,
+		&i.HealthCheckPath,
+		&i.Showcase,
 	)
 	return i, err
 }
@@ -7,8 +7,10 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const countDeploymentsByApp = `-- name: CountDeploymentsByApp :one
@@ -22,17 +24,76 @@ func (q *Queries) CountDeploymentsByApp(ctx context.Context, appID uuid.UUID) (i
 	return count, err
 }
 
+const countDeploymentsByUserSince = `-- name: CountDeploymentsByUserSince :one
+SELECT COUNT(*)
+FROM deployments d
+JOIN apps a ON a.id = d.app_id
+WHERE a.user_id = $1 AND d.created_at >= $2
+`
+
+type CountDeploymentsByUserSinceParams struct {
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (q *Queries) CountDeploymentsByUserSince(ctx context.Context, arg CountDeploymentsByUserSinceParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countDeploymentsByUserSince, arg.UserID, arg.CreatedAt)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countQueuedDeploymentsByRegion = `-- name: CountQueuedDeploymentsByRegion :many
+SELECT a.region, COUNT(*) AS queued
+FROM deployments d
+JOIN apps a ON a.id = d.app_id
+WHERE d.status IN ('pending', 'building')
+GROUP BY a.region
+`
+
+type CountQueuedDeploymentsByRegionRow struct {
+	Region string `json:"region"`
+	Queued int64  `json:"queued"`
+}
+
+func (q *Queries) CountQueuedDeploymentsByRegion(ctx context.Context) ([]CountQueuedDeploymentsByRegionRow, error) {
+	rows, err := q.db.Query(ctx, countQueuedDeploymentsByRegion)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CountQueuedDeploymentsByRegionRow{}
+	for rows.Next() {
+		var i CountQueuedDeploymentsByRegionRow
+		if err := rows.Scan(
+			&i.Region,
+			&i.Queued,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createDeployment = `-- name: CreateDeployment :one
-INSERT INTO deployments (app_id, version, image, status)
-VALUES ($1, $2, $3, $4)
-RETURNING id, app_id, version, image, status, message, error, created_at, started_at, ready_at
+INSERT INTO deployments (app_id, version, image, status, architectures, deployed_by_user_id, deployed_by_token_id, deployed_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, app_id, version, image, status, message, error, created_at, started_at, ready_at, failure_reason, oom_kills, crash_loops, last_crash_at, crash_alerted_at, architectures, deployed_by_user_id, deployed_by_token_id, deployed_by
 `
 
 type CreateDeploymentParams struct {
-	AppID   uuid.UUID `json:"app_id"`
-	Version int32     `json:"version"`
-	Image   string    `json:"image"`
-	Status  string    `json:"status"`
+	AppID             uuid.UUID   `json:"app_id"`
+	Version           int32       `json:"version"`
+	Image             string      `json:"image"`
+	Status            string      `json:"status"`
+	Architectures     []string    `json:"architectures"`
+	DeployedByUserID  pgtype.UUID `json:"deployed_by_user_id"`
+	DeployedByTokenID pgtype.UUID `json:"deployed_by_token_id"`
+	DeployedBy        string      `json:"deployed_by"`
 }
 
 func (q *Queries) CreateDeployment(ctx context.Context, arg CreateDeploymentParams) (Deployment, error) {
@@ -41,6 +102,10 @@ func (q *Queries) CreateDeployment(ctx context.Context, arg CreateDeploymentPara
 		arg.Version,
 		arg.Image,
 		arg.Status,
+		arg.Architectures,
+		arg.DeployedByUserID,
+		arg.DeployedByTokenID,
+		arg.DeployedBy,
 	)
 	var i Deployment
 	err := row.Scan(
@@ -54,6 +119,15 @@ func (q *Queries) CreateDeployment(ctx context.Context, arg CreateDeploymentPara
 		&i.CreatedAt,
 		&i.StartedAt,
 		&i.ReadyAt,
+		&i.FailureReason,
+		&i.OomKills,
+		&i.CrashLoops,
+		&i.LastCrashAt,
+		&i.CrashAlertedAt,
+		&i.Architectures,
+		&i.DeployedByUserID,
+		&i.DeployedByTokenID,
+		&i.DeployedBy,
 	)
 	return i, err
 }
@@ -68,7 +142,7 @@ func (q *Queries) DeleteDeployment(ctx context.Context, id uuid.UUID) error {
 }
 
 const getDeploymentByID = `-- name: GetDeploymentByID :one
-SELECT id, app_id, version, image, status, message, error, created_at, started_at, ready_at FROM deployments WHERE id = $1
+SELECT id, app_id, version, image, status, message, error, created_at, started_at, ready_at, failure_reason, oom_kills, crash_loops, last_crash_at, crash_alerted_at, architectures, deployed_by_user_id, deployed_by_token_id, deployed_by FROM deployments WHERE id = $1
 `
 
 func (q *Queries) GetDeploymentByID(ctx context.Context, id uuid.UUID) (Deployment, error) {
@@ -85,12 +159,21 @@ func (q *Queries) GetDeploymentByID(ctx context.Context, id uuid.UUID) (Deployme
 		&i.CreatedAt,
 		&i.StartedAt,
 		&i.ReadyAt,
+		&i.FailureReason,
+		&i.OomKills,
+		&i.CrashLoops,
+		&i.LastCrashAt,
+		&i.CrashAlertedAt,
+		&i.Architectures,
+		&i.DeployedByUserID,
+		&i.DeployedByTokenID,
+		&i.DeployedBy,
 	)
 	return i, err
 }
 
 const getLatestDeployment = `-- name: GetLatestDeployment :one
-SELECT id, app_id, version, image, status, message, error, created_at, started_at, ready_at FROM deployments
+SELECT id, app_id, version, image, status, message, error, created_at, started_at, ready_at, failure_reason, oom_kills, crash_loops, last_crash_at, crash_alerted_at, architectures, deployed_by_user_id, deployed_by_token_id, deployed_by FROM deployments
 WHERE app_id = $1
 ORDER BY version DESC
 LIMIT 1
@@ -110,12 +193,78 @@ func (q *Queries) GetLatestDeployment(ctx context.Context, appID uuid.UUID) (Dep
 		&i.CreatedAt,
 		&i.StartedAt,
 		&i.ReadyAt,
+		&i.FailureReason,
+		&i.OomKills,
+		&i.CrashLoops,
+		&i.LastCrashAt,
+		&i.CrashAlertedAt,
+		&i.Architectures,
+		&i.DeployedByUserID,
+		&i.DeployedByTokenID,
+		&i.DeployedBy,
 	)
 	return i, err
 }
 
+const listCurrentDeploymentImages = `-- name: ListCurrentDeploymentImages :many
+SELECT DISTINCT d.image FROM deployments d
+JOIN apps a ON a.current_deployment_id = d.id
+ORDER BY d.image
+`
+
+func (q *Queries) ListCurrentDeploymentImages(ctx context.Context) ([]string, error) {
+	rows, err := q.db.Query(ctx, listCurrentDeploymentImages)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var image string
+		if err := rows.Scan(&image); err != nil {
+			return nil, err
+		}
+		items = append(items, image)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDeploymentImagesByApp = `-- name: ListDeploymentImagesByApp :many
+SELECT version, image FROM deployments
+WHERE app_id = $1
+ORDER BY version DESC
+`
+
+type ListDeploymentImagesByAppRow struct {
+	Version int32  `json:"version"`
+	Image   string `json:"image"`
+}
+
+func (q *Queries) ListDeploymentImagesByApp(ctx context.Context, appID uuid.UUID) ([]ListDeploymentImagesByAppRow, error) {
+	rows, err := q.db.Query(ctx, listDeploymentImagesByApp, appID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListDeploymentImagesByAppRow{}
+	for rows.Next() {
+		var i ListDeploymentImagesByAppRow
+		if err := rows.Scan(&i.Version, &i.Image); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listDeploymentsByApp = `-- name: ListDeploymentsByApp :many
-SELECT id, app_id, version, image, status, message, error, created_at, started_at, ready_at FROM deployments
+SELECT id, app_id, version, image, status, message, error, created_at, started_at, ready_at, failure_reason, oom_kills, crash_loops, last_crash_at, crash_alerted_at, architectures, deployed_by_user_id, deployed_by_token_id, deployed_by FROM deployments
 WHERE app_id = $1
 ORDER BY created_at DESC
 LIMIT $2 OFFSET $3
@@ -147,6 +296,193 @@ func (q *Queries) ListDeploymentsByApp(ctx context.Context, arg ListDeploymentsB
 			&i.CreatedAt,
 			&i.StartedAt,
 			&i.ReadyAt,
+			&i.FailureReason,
+			&i.OomKills,
+			&i.CrashLoops,
+			&i.LastCrashAt,
+			&i.CrashAlertedAt,
+			&i.Architectures,
+			&i.DeployedByUserID,
+			&i.DeployedByTokenID,
+			&i.DeployedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDeploymentsForUsersBetween = `-- name: ListDeploymentsForUsersBetween :many
+SELECT d.id, a.name AS app_name, d.version, d.image, d.status, d.deployed_by, d.created_at,
+       p.provider, p.repository, p.commit_sha, p.run_url, p.verified
+FROM deployments d
+JOIN apps a ON a.id = d.app_id
+LEFT JOIN deployment_provenance p ON p.deployment_id = d.id
+WHERE a.user_id = ANY($1::uuid[])
+  AND d.created_at >= $2::timestamptz
+  AND d.created_at < $3::timestamptz
+ORDER BY d.created_at
+LIMIT $4
+`
+
+type ListDeploymentsForUsersBetweenParams struct {
+	UserIds    []uuid.UUID `json:"user_ids"`
+	FromTime   time.Time   `json:"from_time"`
+	ToTime     time.Time   `json:"to_time"`
+	LimitCount int32       `json:"limit_count"`
+}
+
+type ListDeploymentsForUsersBetweenRow struct {
+	ID         uuid.UUID `json:"id"`
+	AppName    string    `json:"app_name"`
+	Version    int32     `json:"version"`
+	Image      string    `json:"image"`
+	Status     string    `json:"status"`
+	DeployedBy string    `json:"deployed_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	Provider   *string   `json:"provider"`
+	Repository *string   `json:"repository"`
+	CommitSha  *string   `json:"commit_sha"`
+	RunUrl     *string   `json:"run_url"`
+	Verified   *bool     `json:"verified"`
+}
+
+// Deployments of the apps of the given users in a time range, oldest first,
+// with the CI run that made them
+func (q *Queries) ListDeploymentsForUsersBetween(ctx context.Context, arg ListDeploymentsForUsersBetweenParams) ([]ListDeploymentsForUsersBetweenRow, error) {
+	rows, err := q.db.Query(ctx, listDeploymentsForUsersBetween,
+		arg.UserIds,
+		arg.FromTime,
+		arg.ToTime,
+		arg.LimitCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListDeploymentsForUsersBetweenRow{}
+	for rows.Next() {
+		var i ListDeploymentsForUsersBetweenRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AppName,
+			&i.Version,
+			&i.Image,
+			&i.Status,
+			&i.DeployedBy,
+			&i.CreatedAt,
+			&i.Provider,
+			&i.Repository,
+			&i.CommitSha,
+			&i.RunUrl,
+			&i.Verified,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRunningDeployments = `-- name: ListRunningDeployments :many
+SELECT d.id, d.app_id, d.version, d.oom_kills, d.crash_loops, d.last_crash_at, d.crash_alerted_at, d.created_at,
+       a.name AS app_name, a.region, a.user_id
+FROM deployments d
+JOIN apps a ON a.current_deployment_id = d.id
+WHERE d.status = 'running'
+ORDER BY a.region, a.name
+`
+
+type ListRunningDeploymentsRow struct {
+	ID             uuid.UUID          `json:"id"`
+	AppID          uuid.UUID          `json:"app_id"`
+	Version        int32              `json:"version"`
+	OomKills       int32              `json:"oom_kills"`
+	CrashLoops     int32              `json:"crash_loops"`
+	LastCrashAt    pgtype.Timestamptz `json:"last_crash_at"`
+	CrashAlertedAt pgtype.Timestamptz `json:"crash_alerted_at"`
+	CreatedAt      time.Time          `json:"created_at"`
+	AppName        string             `json:"app_name"`
+	Region         string             `json:"region"`
+	UserID         uuid.UUID          `json:"user_id"`
+}
+
+func (q *Queries) ListRunningDeployments(ctx context.Context) ([]ListRunningDeploymentsRow, error) {
+	rows, err := q.db.Query(ctx, listRunningDeployments)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListRunningDeploymentsRow{}
+	for rows.Next() {
+		var i ListRunningDeploymentsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AppID,
+			&i.Version,
+			&i.OomKills,
+			&i.CrashLoops,
+			&i.LastCrashAt,
+			&i.CrashAlertedAt,
+			&i.CreatedAt,
+			&i.AppName,
+			&i.Region,
+			&i.UserID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStaleDeployments = `-- name: ListStaleDeployments :many
+SELECT id, app_id, version, image, status, message, error, created_at, started_at, ready_at, failure_reason, oom_kills, crash_loops, last_crash_at, crash_alerted_at, architectures, deployed_by_user_id, deployed_by_token_id, deployed_by FROM deployments
+WHERE status = 'building' AND started_at < $1::timestamptz
+ORDER BY started_at
+`
+
+// Deployments building since before a rollout could still be running, whose
+// rollout ended without recording an outcome, such as on a restart
+func (q *Queries) ListStaleDeployments(ctx context.Context, startedBefore time.Time) ([]Deployment, error) {
+	rows, err := q.db.Query(ctx, listStaleDeployments, startedBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Deployment{}
+	for rows.Next() {
+		var i Deployment
+		if err := rows.Scan(
+			&i.ID,
+			&i.AppID,
+			&i.Version,
+			&i.Image,
+			&i.Status,
+			&i.Message,
+			&i.Error,
+			&i.CreatedAt,
+			&i.StartedAt,
+			&i.ReadyAt,
+			&i.FailureReason,
+			&i.OomKills,
+			&i.CrashLoops,
+			&i.LastCrashAt,
+			&i.CrashAlertedAt,
+			&i.Architectures,
+			&i.DeployedByUserID,
+			&i.DeployedByTokenID,
+			&i.DeployedBy,
 		); err != nil {
 			return nil, err
 		}
@@ -158,20 +494,76 @@ func (q *Queries) ListDeploymentsByApp(ctx context.Context, arg ListDeploymentsB
 	return items, nil
 }
 
+const markDeploymentCrashAlerted = `-- name: MarkDeploymentCrashAlerted :exec
+UPDATE deployments SET crash_alerted_at = NOW() WHERE id = $1
+`
+
+func (q *Queries) MarkDeploymentCrashAlerted(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, markDeploymentCrashAlerted, id)
+	return err
+}
+
+const recordDeploymentCrashes = `-- name: RecordDeploymentCrashes :one
+UPDATE deployments
+SET oom_kills = oom_kills + $2, crash_loops = crash_loops + $3, last_crash_at = $4
+WHERE id = $1
+RETURNING id, app_id, version, image, status, message, error, created_at, started_at, ready_at, failure_reason, oom_kills, crash_loops, last_crash_at, crash_alerted_at, architectures, deployed_by_user_id, deployed_by_token_id, deployed_by
+`
+
+type RecordDeploymentCrashesParams struct {
+	ID          uuid.UUID          `json:"id"`
+	OomKills    int32              `json:"oom_kills"`
+	CrashLoops  int32              `json:"crash_loops"`
+	LastCrashAt pgtype.Timestamptz `json:"last_crash_at"`
+}
+
+func (q *Queries) RecordDeploymentCrashes(ctx context.Context, arg RecordDeploymentCrashesParams) (Deployment, error) {
+	row := q.db.QueryRow(ctx, recordDeploymentCrashes,
+		arg.ID,
+		arg.OomKills,
+		arg.CrashLoops,
+		arg.LastCrashAt,
+	)
+	var i Deployment
+	err := row.Scan(
+		&i.ID,
+		&i.AppID,
+		&i.Version,
+		&i.Image,
+		&i.Status,
+		&i.Message,
+		&i.Error,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.ReadyAt,
+		&i.FailureReason,
+		&i.OomKills,
+		&i.CrashLoops,
+		&i.LastCrashAt,
+		&i.CrashAlertedAt,
+		&i.Architectures,
+		&i.DeployedByUserID,
+		&i.DeployedByTokenID,
+		&i.DeployedBy,
+	)
+	return i, err
+}
+
 const updateDeploymentFailed = `-- name: UpdateDeploymentFailed :one
 UPDATE deployments
-SET status = 'failed', error = $2
+SET status = 'failed', error = $2, failure_reason = $3
 WHERE id = $1
-RETURNING id, app_id, version, image, status, message, error, created_at, started_at, ready_at
+RETURNING id, app_id, version, image, status, message, error, created_at, started_at, ready_at, failure_reason, oom_kills, crash_loops, last_crash_at, crash_alerted_at, architectures, deployed_by_user_id, deployed_by_token_id, deployed_by
 `
 
 type UpdateDeploymentFailedParams struct {
-	ID    uuid.UUID `json:"id"`
-	Error *string   `json:"error"`
+	ID            uuid.UUID `json:"id"`
+	Error         *string   `json:"error"`
+	FailureReason *string   `json:"failure_reason"`
 }
 
 func (q *Queries) UpdateDeploymentFailed(ctx context.Context, arg UpdateDeploymentFailedParams) (Deployment, error) {
-	row := q.db.QueryRow(ctx, updateDeploymentFailed, arg.ID, arg.Error)
+	row := q.db.QueryRow(ctx, updateDeploymentFailed, arg.ID, arg.Error, arg.FailureReason)
 	var i Deployment
 	err := row.Scan(
 		&i.ID,
@@ -184,6 +576,15 @@ func (q *Queries) UpdateDeploymentFailed(ctx context.Context, arg UpdateDeployme
 		&i.CreatedAt,
 		&i.StartedAt,
 		&i.ReadyAt,
+		&i.FailureReason,
+		&i.OomKills,
+		&i.CrashLoops,
+		&i.LastCrashAt,
+		&i.CrashAlertedAt,
+		&i.Architectures,
+		&i.DeployedByUserID,
+		&i.DeployedByTokenID,
+		&i.DeployedBy,
 	)
 	return i, err
 }
@@ -192,7 +593,7 @@ const updateDeploymentReady = `-- name: UpdateDeploymentReady :one
 UPDATE deployments
 SET status = 'running', ready_at = NOW()
 WHERE id = $1
-RETURNING id, app_id, version, image, status, message, error, created_at, started_at, ready_at
+RETURNING id, app_id, version, image, status, message, error, created_at, started_at, ready_at, failure_reason, oom_kills, crash_loops, last_crash_at, crash_alerted_at, architectures, deployed_by_user_id, deployed_by_token_id, deployed_by
 `
 
 func (q *Queries) UpdateDeploymentReady(ctx context.Context, id uuid.UUID) (Deployment, error) {
@@ -209,6 +610,15 @@ func (q *Queries) UpdateDeploymentReady(ctx context.Context, id uuid.UUID) (Depl
 		&i.CreatedAt,
 		&i.StartedAt,
 		&i.ReadyAt,
+		&i.FailureReason,
+		&i.OomKills,
+		&i.CrashLoops,
+		&i.LastCrashAt,
+		&i.CrashAlertedAt,
+		&i.Architectures,
+		&i.DeployedByUserID,
+		&i.DeployedByTokenID,
+		&i.DeployedBy,
 	)
 	return i, err
 }
@@ -217,7 +627,7 @@ const updateDeploymentStarted = `-- name: UpdateDeploymentStarted :one
 UPDATE deployments
 SET status = 'building', started_at = NOW()
 WHERE id = $1
-RETURNING id, app_id, version, image, status, message, error, created_at, started_at, ready_at
+RETURNING id, app_id, version, image, status, message, error, created_at, started_at, ready_at, failure_reason, oom_kills, crash_loops, last_crash_at, crash_alerted_at, architectures, deployed_by_user_id, deployed_by_token_id, deployed_by
 `
 
 func (q *Queries) UpdateDeploymentStarted(ctx context.Context, id uuid.UUID) (Deployment, error) {
@@ -234,6 +644,15 @@ func (q *Queries) UpdateDeploymentStarted(ctx context.Context, id uuid.UUID) (De
 		&i.CreatedAt,
 		&i.StartedAt,
 		&i.ReadyAt,
+		&i.FailureReason,
+		&i.OomKills,
+		&i.CrashLoops,
+		&i.LastCrashAt,
+		&i.CrashAlertedAt,
+		&i.Architectures,
+		&i.DeployedByUserID,
+		&i.DeployedByTokenID,
+		&i.DeployedBy,
 	)
 	return i, err
 }
@@ -242,7 +661,7 @@ const updateDeploymentStatus = `-- name: UpdateDeploymentStatus :one
 UPDATE deployments
 SET status = $2, message = $3, error = $4
 WHERE id = $1
-RETURNING id, app_id, version, image, status, message, error, created_at, started_at, ready_at
+RETURNING id, app_id, version, image, status, message, error, created_at, started_at, ready_at, failure_reason, oom_kills, crash_loops, last_crash_at, crash_alerted_at, architectures, deployed_by_user_id, deployed_by_token_id, deployed_by
 `
 
 type UpdateDeploymentStatusParams struct {
@@ -271,6 +690,15 @@ func (q *Queries) UpdateDeploymentStatus(ctx context.Context, arg UpdateDeployme
 		&i.CreatedAt,
 		&i.StartedAt,
 		&i.ReadyAt,
+		&i.FailureReason,
+		&i.OomKills,
+		&i.CrashLoops,
+		&i.LastCrashAt,
+		&i.CrashAlertedAt,
+		&i.Architectures,
+		&i.DeployedByUserID,
+		&i.DeployedByTokenID,
+		&i.DeployedBy,
 	)
 	return i, err
 }
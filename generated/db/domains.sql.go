@@ -9,6 +9,7 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const countDomainsByApp = `-- name: CountDomainsByApp :one
@@ -23,18 +24,25 @@ func (q *Queries) CountDomainsByApp(ctx context.Context, appID uuid.UUID) (int64
 }
 
 const createDomain = `-- name: CreateDomain :one
-INSERT INTO domains (app_id, domain)
-VALUES ($1, $2)
-RETURNING id, app_id, domain, verified, ssl_status, created_at, verified_at
+INSERT INTO domains (app_id, domain, verification_token, dns_mode)
+VALUES ($1, $2, $3, $4)
+RETURNING id, app_id, domain, verified, ssl_status, created_at, verified_at, verification_token, dns_mode, ssl_expires_at, ssl_error, ssl_checked_at, ssl_alerted_at, force_https, hsts_max_age, hsts_include_subdomains, hsts_preload
 `
 
 type CreateDomainParams struct {
-	AppID  uuid.UUID `json:"app_id"`
-	Domain string    `json:"domain"`
+	AppID             uuid.UUID `json:"app_id"`
+	Domain            string    `json:"domain"`
+	VerificationToken string    `json:"verification_token"`
+	DnsMode           string    `json:"dns_mode"`
 }
 
 func (q *Queries) CreateDomain(ctx context.Context, arg CreateDomainParams) (Domain, error) {
-	row := q.db.QueryRow(ctx, createDomain, arg.AppID, arg.Domain)
+	row := q.db.QueryRow(ctx, createDomain,
+		arg.AppID,
+		arg.Domain,
+		arg.VerificationToken,
+		arg.DnsMode,
+	)
 	var i Domain
 	err := row.Scan(
 		&i.ID,
@@ -44,6 +52,16 @@ func (q *Queries) CreateDomain(ctx context.Context, arg CreateDomainParams) (Dom
 		&i.SslStatus,
 		&i.CreatedAt,
 		&i.VerifiedAt,
+		&i.VerificationToken,
+		&i.DnsMode,
+		&i.SslExpiresAt,
+		&i.SslError,
+		&i.SslCheckedAt,
+		&i.SslAlertedAt,
+		&i.ForceHttps,
+		&i.HstsMaxAge,
+		&i.HstsIncludeSubdomains,
+		&i.HstsPreload,
 	)
 	return i, err
 }
@@ -58,7 +76,7 @@ func (q *Queries) DeleteDomain(ctx context.Context, id uuid.UUID) error {
 }
 
 const getDomainByID = `-- name: GetDomainByID :one
-SELECT id, app_id, domain, verified, ssl_status, created_at, verified_at FROM domains WHERE id = $1
+SELECT id, app_id, domain, verified, ssl_status, created_at, verified_at, verification_token, dns_mode, ssl_expires_at, ssl_error, ssl_checked_at, ssl_alerted_at, force_https, hsts_max_age, hsts_include_subdomains, hsts_preload FROM domains WHERE id = $1
 `
 
 func (q *Queries) GetDomainByID(ctx context.Context, id uuid.UUID) (Domain, error) {
@@ -72,12 +90,22 @@ func (q *Queries) GetDomainByID(ctx context.Context, id uuid.UUID) (Domain, erro
 		&i.SslStatus,
 		&i.CreatedAt,
 		&i.VerifiedAt,
+		&i.VerificationToken,
+		&i.DnsMode,
+		&i.SslExpiresAt,
+		&i.SslError,
+		&i.SslCheckedAt,
+		&i.SslAlertedAt,
+		&i.ForceHttps,
+		&i.HstsMaxAge,
+		&i.HstsIncludeSubdomains,
+		&i.HstsPreload,
 	)
 	return i, err
 }
 
 const getDomainByName = `-- name: GetDomainByName :one
-SELECT id, app_id, domain, verified, ssl_status, created_at, verified_at FROM domains WHERE domain = $1
+SELECT id, app_id, domain, verified, ssl_status, created_at, verified_at, verification_token, dns_mode, ssl_expires_at, ssl_error, ssl_checked_at, ssl_alerted_at, force_https, hsts_max_age, hsts_include_subdomains, hsts_preload FROM domains WHERE domain = $1
 `
 
 func (q *Queries) GetDomainByName(ctx context.Context, domain string) (Domain, error) {
@@ -91,12 +119,22 @@ func (q *Queries) GetDomainByName(ctx context.Context, domain string) (Domain, e
 		&i.SslStatus,
 		&i.CreatedAt,
 		&i.VerifiedAt,
+		&i.VerificationToken,
+		&i.DnsMode,
+		&i.SslExpiresAt,
+		&i.SslError,
+		&i.SslCheckedAt,
+		&i.SslAlertedAt,
+		&i.ForceHttps,
+		&i.HstsMaxAge,
+		&i.HstsIncludeSubdomains,
+		&i.HstsPreload,
 	)
 	return i, err
 }
 
 const listDomainsByApp = `-- name: ListDomainsByApp :many
-SELECT id, app_id, domain, verified, ssl_status, created_at, verified_at FROM domains
+SELECT id, app_id, domain, verified, ssl_status, created_at, verified_at, verification_token, dns_mode, ssl_expires_at, ssl_error, ssl_checked_at, ssl_alerted_at, force_https, hsts_max_age, hsts_include_subdomains, hsts_preload FROM domains
 WHERE app_id = $1
 ORDER BY created_at DESC
 `
@@ -118,6 +156,64 @@ func (q *Queries) ListDomainsByApp(ctx context.Context, appID uuid.UUID) ([]Doma
 			&i.SslStatus,
 			&i.CreatedAt,
 			&i.VerifiedAt,
+			&i.VerificationToken,
+			&i.DnsMode,
+			&i.SslExpiresAt,
+			&i.SslError,
+			&i.SslCheckedAt,
+			&i.SslAlertedAt,
+			&i.ForceHttps,
+			&i.HstsMaxAge,
+			&i.HstsIncludeSubdomains,
+			&i.HstsPreload,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVerifiedDomainsWithApps = `-- name: ListVerifiedDomainsWithApps :many
+SELECT d.id, d.domain, d.ssl_status, d.ssl_alerted_at, a.id AS app_id, a.name AS app_name, a.region, a.user_id
+FROM domains d
+JOIN apps a ON a.id = d.app_id
+WHERE d.verified = TRUE
+ORDER BY a.region, a.name
+`
+
+type ListVerifiedDomainsWithAppsRow struct {
+	ID           uuid.UUID          `json:"id"`
+	Domain       string             `json:"domain"`
+	SslStatus    string             `json:"ssl_status"`
+	SslAlertedAt pgtype.Timestamptz `json:"ssl_alerted_at"`
+	AppID        uuid.UUID          `json:"app_id"`
+	AppName      string             `json:"app_name"`
+	Region       string             `json:"region"`
+	UserID       uuid.UUID          `json:"user_id"`
+}
+
+func (q *Queries) ListVerifiedDomainsWithApps(ctx context.Context) ([]ListVerifiedDomainsWithAppsRow, error) {
+	rows, err := q.db.Query(ctx, listVerifiedDomainsWithApps)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListVerifiedDomainsWithAppsRow{}
+	for rows.Next() {
+		var i ListVerifiedDomainsWithAppsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Domain,
+			&i.SslStatus,
+			&i.SslAlertedAt,
+			&i.AppID,
+			&i.AppName,
+			&i.Region,
+			&i.UserID,
 		); err != nil {
 			return nil, err
 		}
@@ -129,11 +225,64 @@ func (q *Queries) ListDomainsByApp(ctx context.Context, appID uuid.UUID) ([]Doma
 	return items, nil
 }
 
+const markDomainCertificateAlerted = `-- name: MarkDomainCertificateAlerted :exec
+UPDATE domains SET ssl_alerted_at = NOW() WHERE id = $1
+`
+
+func (q *Queries) MarkDomainCertificateAlerted(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, markDomainCertificateAlerted, id)
+	return err
+}
+
+const updateDomainCertificate = `-- name: UpdateDomainCertificate :one
+UPDATE domains
+SET ssl_status = $2, ssl_expires_at = $3, ssl_error = $4, ssl_checked_at = NOW()
+WHERE id = $1
+RETURNING id, app_id, domain, verified, ssl_status, created_at, verified_at, verification_token, dns_mode, ssl_expires_at, ssl_error, ssl_checked_at, ssl_alerted_at, force_https, hsts_max_age, hsts_include_subdomains, hsts_preload
+`
+
+type UpdateDomainCertificateParams struct {
+	ID           uuid.UUID          `json:"id"`
+	SslStatus    string             `json:"ssl_status"`
+	SslExpiresAt pgtype.Timestamptz `json:"ssl_expires_at"`
+	SslError     *string            `json:"ssl_error"`
+}
+
+func (q *Queries) UpdateDomainCertificate(ctx context.Context, arg UpdateDomainCertificateParams) (Domain, error) {
+	row := q.db.QueryRow(ctx, updateDomainCertificate,
+		arg.ID,
+		arg.SslStatus,
+		arg.SslExpiresAt,
+		arg.SslError,
+	)
+	var i Domain
+	err := row.Scan(
+		&i.ID,
+		&i.AppID,
+		&i.Domain,
+		&i.Verified,
+		&i.SslStatus,
+		&i.CreatedAt,
+		&i.VerifiedAt,
+		&i.VerificationToken,
+		&i.DnsMode,
+		&i.SslExpiresAt,
+		&i.SslError,
+		&i.SslCheckedAt,
+		&i.SslAlertedAt,
+		&i.ForceHttps,
+		&i.HstsMaxAge,
+		&i.HstsIncludeSubdomains,
+		&i.HstsPreload,
+	)
+	return i, err
+}
+
 const updateDomainSSLStatus = `-- name: UpdateDomainSSLStatus :one
 UPDATE domains
 SET ssl_status = $2
 WHERE id = $1
-RETURNING id, app_id, domain, verified, ssl_status, created_at, verified_at
+RETURNING id, app_id, domain, verified, ssl_status, created_at, verified_at, verification_token, dns_mode, ssl_expires_at, ssl_error, ssl_checked_at, ssl_alerted_at, force_https, hsts_max_age, hsts_include_subdomains, hsts_preload
 `
 
 type UpdateDomainSSLStatusParams struct {
@@ -152,6 +301,62 @@ func (q *Queries) UpdateDomainSSLStatus(ctx context.Context, arg UpdateDomainSSL
 		&i.SslStatus,
 		&i.CreatedAt,
 		&i.VerifiedAt,
+		&i.VerificationToken,
+		&i.DnsMode,
+		&i.SslExpiresAt,
+		&i.SslError,
+		&i.SslCheckedAt,
+		&i.SslAlertedAt,
+		&i.ForceHttps,
+		&i.HstsMaxAge,
+		&i.HstsIncludeSubdomains,
+		&i.HstsPreload,
+	)
+	return i, err
+}
+
+const updateDomainTLSPolicy = `-- name: UpdateDomainTLSPolicy :one
+UPDATE domains
+SET force_https = $2, hsts_max_age = $3, hsts_include_subdomains = $4, hsts_preload = $5
+WHERE id = $1
+RETURNING id, app_id, domain, verified, ssl_status, created_at, verified_at, verification_token, dns_mode, ssl_expires_at, ssl_error, ssl_checked_at, ssl_alerted_at, force_https, hsts_max_age, hsts_include_subdomains, hsts_preload
+`
+
+type UpdateDomainTLSPolicyParams struct {
+	ID                    uuid.UUID `json:"id"`
+	ForceHttps            bool      `json:"force_https"`
+	HstsMaxAge            int32     `json:"hsts_max_age"`
+	HstsIncludeSubdomains bool      `json:"hsts_include_subdomains"`
+	HstsPreload           bool      `json:"hsts_preload"`
+}
+
+func (q *Queries) UpdateDomainTLSPolicy(ctx context.Context, arg UpdateDomainTLSPolicyParams) (Domain, error) {
+	row := q.db.QueryRow(ctx, updateDomainTLSPolicy,
+		arg.ID,
+		arg.ForceHttps,
+		arg.HstsMaxAge,
+		arg.HstsIncludeSubdomains,
+		arg.HstsPreload,
+	)
+	var i Domain
+	err := row.Scan(
+		&i.ID,
+		&i.AppID,
+		&i.Domain,
+		&i.Verified,
+		&i.SslStatus,
+		&i.CreatedAt,
+		&i.VerifiedAt,
+		&i.VerificationToken,
+		&i.DnsMode,
+		&i.SslExpiresAt,
+		&i.SslError,
+		&i.SslCheckedAt,
+		&i.SslAlertedAt,
+		&i.ForceHttps,
+		&i.HstsMaxAge,
+		&i.HstsIncludeSubdomains,
+		&i.HstsPreload,
 	)
 	return i, err
 }
@@ -160,7 +365,7 @@ const updateDomainVerified = `-- name: UpdateDomainVerified :one
 UPDATE domains
 SET verified = TRUE, verified_at = NOW()
 WHERE id = $1
-RETURNING id, app_id, domain, verified, ssl_status, created_at, verified_at
+RETURNING id, app_id, domain, verified, ssl_status, created_at, verified_at, verification_token, dns_mode, ssl_expires_at, ssl_error, ssl_checked_at, ssl_alerted_at, force_https, hsts_max_age, hsts_include_subdomains, hsts_preload
 `
 
 func (q *Queries) UpdateDomainVerified(ctx context.Context, id uuid.UUID) (Domain, error) {
@@ -174,6 +379,16 @@ func (q *Queries) UpdateDomainVerified(ctx context.Context, id uuid.UUID) (Domai
 		&i.SslStatus,
 		&i.CreatedAt,
 		&i.VerifiedAt,
+		&i.VerificationToken,
+		&i.DnsMode,
+		&i.SslExpiresAt,
+		&i.SslError,
+		&i.SslCheckedAt,
+		&i.SslAlertedAt,
+		&i.ForceHttps,
+		&i.HstsMaxAge,
+		&i.HstsIncludeSubdomains,
+		&i.HstsPreload,
 	)
 	return i, err
 }
@@ -5,6 +5,8 @@
 package db
 
 import (
+	"database/sql/driver"
+	"fmt"
 	"net/netip"
 	"time"
 
@@ -12,24 +14,233 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type AppStatus string
+
+const (
+	AppStatusStopped   AppStatus = "stopped"
+	AppStatusDeploying AppStatus = "deploying"
+	AppStatusRunning   AppStatus = "running"
+	AppStatusFailed    AppStatus = "failed"
+	AppStatusPaused    AppStatus = "paused"
+	AppStatusSuspended AppStatus = "suspended"
+	AppStatusPurged    AppStatus = "purged"
+)
+
+func (e *AppStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = AppStatus(s)
+	case string:
+		*e = AppStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for AppStatus: %T", src)
+	}
+	return nil
+}
+
+type NullAppStatus struct {
+	AppStatus AppStatus `json:"app_status"`
+	Valid     bool      `json:"valid"` // Valid is true if AppStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullAppStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.AppStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.AppStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullAppStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.AppStatus), nil
+}
+
+type AccountSuspension struct {
+	UserID      uuid.UUID          `json:"user_id"`
+	Stage       string             `json:"stage"`
+	InvoiceID   string             `json:"invoice_id"`
+	FailedAt    time.Time          `json:"failed_at"`
+	Warnings    int32              `json:"warnings"`
+	SuspendedAt pgtype.Timestamptz `json:"suspended_at"`
+	PurgedAt    pgtype.Timestamptz `json:"purged_at"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
 type ActivityLog struct {
+	ID         uuid.UUID   `json:"id"`
+	UserID     pgtype.UUID `json:"user_id"`
+	AppID      pgtype.UUID `json:"app_id"`
+	Action     string      `json:"action"`
+	Details    []byte      `json:"details"`
+	IpAddress  *netip.Addr `json:"ip_address"`
+	CreatedAt  time.Time   `json:"created_at"`
+	ApiTokenID pgtype.UUID `json:"api_token_id"`
+}
+
+type ApiTokenUsage struct {
+	TokenID uuid.UUID   `json:"token_id"`
+	Day     pgtype.Date `json:"day"`
+	Count   int32       `json:"count"`
+}
+
+type ApiToken struct {
+	ID                    uuid.UUID          `json:"id"`
+	UserID                uuid.UUID          `json:"user_id"`
+	Name                  string             `json:"name"`
+	TokenHash             string             `json:"token_hash"`
+	LastUsedAt            pgtype.Timestamptz `json:"last_used_at"`
+	ExpiresAt             pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt             time.Time          `json:"created_at"`
+	AllowedCidrs          []string           `json:"allowed_cidrs"`
+	LastUsedIp            *netip.Addr        `json:"last_used_ip"`
+	LastUsedUserAgent     *string            `json:"last_used_user_agent"`
+	ExpireAfterUnusedDays *int32             `json:"expire_after_unused_days"`
+	RegistryAccess        *string            `json:"registry_access"`
+}
+
+type AppBandwidth struct {
+	AppID        uuid.UUID `json:"app_id"`
+	PeriodStart  time.Time `json:"period_start"`
+	IngressBytes int64     `json:"ingress_bytes"`
+	EgressBytes  int64     `json:"egress_bytes"`
+	Requests     int64     `json:"requests"`
+}
+
+type AppBurst struct {
+	AppID           uuid.UUID          `json:"app_id"`
+	CpuPercent      *int32             `json:"cpu_percent"`
+	P95LatencyMs    *int32             `json:"p95_latency_ms"`
+	CooldownMinutes int32              `json:"cooldown_minutes"`
+	BaseReplicas    *int32             `json:"base_replicas"`
+	BurstStartedAt  pgtype.Timestamptz `json:"burst_started_at"`
+	BurstUntil      pgtype.Timestamptz `json:"burst_until"`
+	CreatedAt       time.Time          `json:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at"`
+}
+
+type AppCollaborator struct {
 	ID        uuid.UUID   `json:"id"`
-	UserID    pgtype.UUID `json:"user_id"`
-	AppID     pgtype.UUID `json:"app_id"`
-	Action    string      `json:"action"`
-	Details   []byte      `json:"details"`
-	IpAddress *netip.Addr `json:"ip_address"`
+	AppID     uuid.UUID   `json:"app_id"`
+	UserID    uuid.UUID   `json:"user_id"`
+	Role      string      `json:"role"`
+	AddedBy   pgtype.UUID `json:"added_by"`
 	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
 }
 
-type ApiToken struct {
-	ID         uuid.UUID          `json:"id"`
-	UserID     uuid.UUID          `json:"user_id"`
-	Name       string             `json:"name"`
-	TokenHash  string             `json:"token_hash"`
-	LastUsedAt pgtype.Timestamptz `json:"last_used_at"`
-	ExpiresAt  pgtype.Timestamptz `json:"expires_at"`
-	CreatedAt  time.Time          `json:"created_at"`
+type AppDatabaseLink struct {
+	AppID     uuid.UUID `json:"app_id"`
+	AddonID   uuid.UUID `json:"addon_id"`
+	Prefix    string    `json:"prefix"`
+	Variables []string  `json:"variables"`
+	CreatedAt time.Time `json:"created_at"`
+	Pooling   bool      `json:"pooling"`
+	PoolSize  int32     `json:"pool_size"`
+}
+
+type AppEgressIp struct {
+	AppID     uuid.UUID `json:"app_id"`
+	Region    string    `json:"region"`
+	Ip        string    `json:"ip"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AppImageRetention struct {
+	AppID        uuid.UUID          `json:"app_id"`
+	KeepLast     int32              `json:"keep_last"`
+	LastPrunedAt pgtype.Timestamptz `json:"last_pruned_at"`
+	CreatedAt    time.Time          `json:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at"`
+}
+
+type AppMetricsExport struct {
+	AppID            uuid.UUID          `json:"app_id"`
+	Kind             string             `json:"kind"`
+	Url              string             `json:"url"`
+	HeadersEncrypted []byte             `json:"headers_encrypted"`
+	LastPushedAt     pgtype.Timestamptz `json:"last_pushed_at"`
+	LastError        *string            `json:"last_error"`
+	CreatedAt        time.Time          `json:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at"`
+}
+
+type AppMirror struct {
+	AppID       uuid.UUID `json:"app_id"`
+	TargetAppID uuid.UUID `json:"target_app_id"`
+	Percent     int32     `json:"percent"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type AppMtl struct {
+	AppID     uuid.UUID `json:"app_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AppOidcTrust struct {
+	ID         uuid.UUID   `json:"id"`
+	AppID      uuid.UUID   `json:"app_id"`
+	Provider   string      `json:"provider"`
+	Repository string      `json:"repository"`
+	Ref        string      `json:"ref"`
+	CreatedBy  pgtype.UUID `json:"created_by"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+type AppOtelCollector struct {
+	AppID            uuid.UUID `json:"app_id"`
+	Endpoint         string    `json:"endpoint"`
+	Protocol         string    `json:"protocol"`
+	HeadersEncrypted []byte    `json:"headers_encrypted"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+type AppPlacement struct {
+	AppID        uuid.UUID `json:"app_id"`
+	NodeSelector []byte    `json:"node_selector"`
+	Tolerations  []byte    `json:"tolerations"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type AppProcess struct {
+	ID          uuid.UUID `json:"id"`
+	AppID       uuid.UUID `json:"app_id"`
+	ProcessType string    `json:"process_type"`
+	Command     *string   `json:"command"`
+	Replicas    int32     `json:"replicas"`
+	Cpu         *string   `json:"cpu"`
+	Memory      *string   `json:"memory"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type AppResourceUsage struct {
+	AppID          uuid.UUID `json:"app_id"`
+	PeriodStart    time.Time `json:"period_start"`
+	Samples        int32     `json:"samples"`
+	PodSamples     int32     `json:"pod_samples"`
+	CpuMillisSum   int64     `json:"cpu_millis_sum"`
+	CpuMillisMax   int64     `json:"cpu_millis_max"`
+	MemoryBytesSum int64     `json:"memory_bytes_sum"`
+	MemoryBytesMax int64     `json:"memory_bytes_max"`
+}
+
+type AppTunnel struct {
+	AppID          uuid.UUID `json:"app_id"`
+	TunnelID       string    `json:"tunnel_id"`
+	TokenEncrypted []byte    `json:"token_encrypted"`
+	DnsRecordID    string    `json:"dns_record_id"`
+	CreatedAt      time.Time `json:"created_at"`
 }
 
 type App struct {
@@ -38,35 +249,267 @@ type App struct {
 	Name                string      `json:"name"`
 	Region              string      `json:"region"`
 	Size                string      `json:"size"`
-	Status              string      `json:"status"`
+	Status              AppStatus   `json:"status"`
 	DeploymentCount     int32       `json:"deployment_count"`
 	CurrentDeploymentID pgtype.UUID `json:"current_deployment_id"`
 	EnvVarsEncrypted    []byte      `json:"env_vars_encrypted"`
 	CreatedAt           time.Time   `json:"created_at"`
 	UpdatedAt           time.Time   `json:"updated_at"`
+	Description         string      `json:"description"`
+	IconUrl             string      `json:"icon_url"`
+	RepositoryUrl       string      `json:"repository_url"`
+	Tags                []string    `json:"tags"`
+	ProjectID           pgtype.UUID `json:"project_id"`
+	Labels              []byte      `json:"labels"`
+	HealthCheckPath     string      `json:"health_check_path"`
+	Showcase            bool        `json:"showcase"`
+}
+
+type BulkOperation struct {
+	ID           uuid.UUID          `json:"id"`
+	UserID       uuid.UUID          `json:"user_id"`
+	ApiTokenID   pgtype.UUID        `json:"api_token_id"`
+	Action       string             `json:"action"`
+	Apps         []string           `json:"apps"`
+	EnvEncrypted []byte             `json:"env_encrypted"`
+	UnsetEnv     []string           `json:"unset_env"`
+	Status       string             `json:"status"`
+	Results      []byte             `json:"results"`
+	LeaseUntil   pgtype.Timestamptz `json:"lease_until"`
+	CreatedAt    time.Time          `json:"created_at"`
+	StartedAt    pgtype.Timestamptz `json:"started_at"`
+	FinishedAt   pgtype.Timestamptz `json:"finished_at"`
+}
+
+type ClientCertificate struct {
+	ID          uuid.UUID          `json:"id"`
+	AppID       uuid.UUID          `json:"app_id"`
+	Name        string             `json:"name"`
+	Serial      string             `json:"serial"`
+	Fingerprint string             `json:"fingerprint"`
+	Certificate string             `json:"certificate"`
+	NotAfter    time.Time          `json:"not_after"`
+	RevokedAt   pgtype.Timestamptz `json:"revoked_at"`
+	CreatedAt   time.Time          `json:"created_at"`
+}
+
+type CreditLedger struct {
+	ID          uuid.UUID   `json:"id"`
+	UserID      uuid.UUID   `json:"user_id"`
+	Amount      int64       `json:"amount"`
+	Reason      string      `json:"reason"`
+	Description string      `json:"description"`
+	InvoiceID   *string     `json:"invoice_id"`
+	GrantedBy   pgtype.UUID `json:"granted_by"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+type CronJob struct {
+	ID                      uuid.UUID `json:"id"`
+	AppID                   uuid.UUID `json:"app_id"`
+	Name                    string    `json:"name"`
+	Schedule                string    `json:"schedule"`
+	Command                 string    `json:"command"`
+	Timezone                string    `json:"timezone"`
+	ConcurrencyPolicy       string    `json:"concurrency_policy"`
+	StartingDeadlineSeconds *int64    `json:"starting_deadline_seconds"`
+	ActiveDeadlineSeconds   *int64    `json:"active_deadline_seconds"`
+	SuccessfulHistoryLimit  int32     `json:"successful_history_limit"`
+	FailedHistoryLimit      int32     `json:"failed_history_limit"`
+	Suspended               bool      `json:"suspended"`
+	CreatedAt               time.Time `json:"created_at"`
+	UpdatedAt               time.Time `json:"updated_at"`
+}
+
+type DatabaseAddon struct {
+	ID                uuid.UUID `json:"id"`
+	UserID            uuid.UUID `json:"user_id"`
+	Name              string    `json:"name"`
+	Host              string    `json:"host"`
+	Port              int32     `json:"port"`
+	Database          string    `json:"database"`
+	Username          string    `json:"username"`
+	PasswordEncrypted []byte    `json:"password_encrypted"`
+	Sslmode           string    `json:"sslmode"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+type DeployFreeze struct {
+	ID          uuid.UUID   `json:"id"`
+	OrgID       uuid.UUID   `json:"org_id"`
+	Name        string      `json:"name"`
+	StartDay    int32       `json:"start_day"`
+	StartMinute int32       `json:"start_minute"`
+	EndDay      int32       `json:"end_day"`
+	EndMinute   int32       `json:"end_minute"`
+	Timezone    string      `json:"timezone"`
+	CreatedBy   pgtype.UUID `json:"created_by"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+type DeployHook struct {
+	AppID             uuid.UUID `json:"app_id"`
+	PreDeployCommand  *string   `json:"pre_deploy_command"`
+	PostDeployCommand *string   `json:"post_deploy_command"`
+	TimeoutSeconds    int32     `json:"timeout_seconds"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+type DeploymentHookRun struct {
+	ID           uuid.UUID          `json:"id"`
+	DeploymentID uuid.UUID          `json:"deployment_id"`
+	Phase        string             `json:"phase"`
+	Command      string             `json:"command"`
+	JobName      string             `json:"job_name"`
+	Status       string             `json:"status"`
+	Output       *string            `json:"output"`
+	Error        *string            `json:"error"`
+	StartedAt    pgtype.Timestamptz `json:"started_at"`
+	FinishedAt   pgtype.Timestamptz `json:"finished_at"`
+	CreatedAt    time.Time          `json:"created_at"`
+}
+
+type DeploymentLockfile struct {
+	DeploymentID uuid.UUID `json:"deployment_id"`
+	Lockfile     []byte    `json:"lockfile"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type DeploymentProvenance struct {
+	DeploymentID uuid.UUID `json:"deployment_id"`
+	Provider     string    `json:"provider"`
+	RunID        string    `json:"run_id"`
+	RunNumber    string    `json:"run_number"`
+	RunUrl       string    `json:"run_url"`
+	CommitSha    string    `json:"commit_sha"`
+	Actor        string    `json:"actor"`
+	Repository   string    `json:"repository"`
+	Ref          string    `json:"ref"`
+	Workflow     string    `json:"workflow"`
+	Verified     bool      `json:"verified"`
+	Issuer       string    `json:"issuer"`
+	Subject      string    `json:"subject"`
+	CreatedAt    time.Time `json:"created_at"`
 }
 
 type Deployment struct {
-	ID        uuid.UUID          `json:"id"`
-	AppID     uuid.UUID          `json:"app_id"`
-	Version   int32              `json:"version"`
-	Image     string             `json:"image"`
-	Status    string             `json:"status"`
-	Message   *string            `json:"message"`
-	Error     *string            `json:"error"`
-	CreatedAt time.Time          `json:"created_at"`
-	StartedAt pgtype.Timestamptz `json:"started_at"`
-	ReadyAt   pgtype.Timestamptz `json:"ready_at"`
+	ID                uuid.UUID          `json:"id"`
+	AppID             uuid.UUID          `json:"app_id"`
+	Version           int32              `json:"version"`
+	Image             string             `json:"image"`
+	Status            string             `json:"status"`
+	Message           *string            `json:"message"`
+	Error             *string            `json:"error"`
+	CreatedAt         time.Time          `json:"created_at"`
+	StartedAt         pgtype.Timestamptz `json:"started_at"`
+	ReadyAt           pgtype.Timestamptz `json:"ready_at"`
+	FailureReason     *string            `json:"failure_reason"`
+	OomKills          int32              `json:"oom_kills"`
+	CrashLoops        int32              `json:"crash_loops"`
+	LastCrashAt       pgtype.Timestamptz `json:"last_crash_at"`
+	CrashAlertedAt    pgtype.Timestamptz `json:"crash_alerted_at"`
+	Architectures     []string           `json:"architectures"`
+	DeployedByUserID  pgtype.UUID        `json:"deployed_by_user_id"`
+	DeployedByTokenID pgtype.UUID        `json:"deployed_by_token_id"`
+	DeployedBy        string             `json:"deployed_by"`
+}
+
+type DeviceAuthorization struct {
+	ID             uuid.UUID          `json:"id"`
+	DeviceCodeHash string             `json:"device_code_hash"`
+	UserCode       string             `json:"user_code"`
+	ClientName     string             `json:"client_name"`
+	Status         string             `json:"status"`
+	UserID         pgtype.UUID        `json:"user_id"`
+	PollInterval   int32              `json:"poll_interval"`
+	LastPolledAt   pgtype.Timestamptz `json:"last_polled_at"`
+	CreatedAt      time.Time          `json:"created_at"`
+	ExpiresAt      time.Time          `json:"expires_at"`
+}
+
+type DnsZone struct {
+	ID          uuid.UUID          `json:"id"`
+	UserID      uuid.UUID          `json:"user_id"`
+	Zone        string             `json:"zone"`
+	Status      string             `json:"status"`
+	CheckedAt   pgtype.Timestamptz `json:"checked_at"`
+	DelegatedAt pgtype.Timestamptz `json:"delegated_at"`
+	CreatedAt   time.Time          `json:"created_at"`
 }
 
 type Domain struct {
+	ID                    uuid.UUID          `json:"id"`
+	AppID                 uuid.UUID          `json:"app_id"`
+	Domain                string             `json:"domain"`
+	Verified              bool               `json:"verified"`
+	SslStatus             string             `json:"ssl_status"`
+	CreatedAt             time.Time          `json:"created_at"`
+	VerifiedAt            pgtype.Timestamptz `json:"verified_at"`
+	VerificationToken     string             `json:"verification_token"`
+	DnsMode               string             `json:"dns_mode"`
+	SslExpiresAt          pgtype.Timestamptz `json:"ssl_expires_at"`
+	SslError              *string            `json:"ssl_error"`
+	SslCheckedAt          pgtype.Timestamptz `json:"ssl_checked_at"`
+	SslAlertedAt          pgtype.Timestamptz `json:"ssl_alerted_at"`
+	ForceHttps            bool               `json:"force_https"`
+	HstsMaxAge            int32              `json:"hsts_max_age"`
+	HstsIncludeSubdomains bool               `json:"hsts_include_subdomains"`
+	HstsPreload           bool               `json:"hsts_preload"`
+}
+
+type EventCursor struct {
+	Subscriber  string    `json:"subscriber"`
+	LastEventID int64     `json:"last_event_id"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type Event struct {
+	ID         int64       `json:"id"`
+	Type       string      `json:"type"`
+	UserID     pgtype.UUID `json:"user_id"`
+	AppID      pgtype.UUID `json:"app_id"`
+	AppName    *string     `json:"app_name"`
+	Message    *string     `json:"message"`
+	IpAddress  *netip.Addr `json:"ip_address"`
+	Payload    []byte      `json:"payload"`
+	CreatedAt  time.Time   `json:"created_at"`
+	ApiTokenID pgtype.UUID `json:"api_token_id"`
+}
+
+type LegalHold struct {
 	ID         uuid.UUID          `json:"id"`
-	AppID      uuid.UUID          `json:"app_id"`
-	Domain     string             `json:"domain"`
-	Verified   bool               `json:"verified"`
-	SslStatus  string             `json:"ssl_status"`
+	OrgID      uuid.UUID          `json:"org_id"`
+	Reason     string             `json:"reason"`
+	PlacedBy   pgtype.UUID        `json:"placed_by"`
 	CreatedAt  time.Time          `json:"created_at"`
-	VerifiedAt pgtype.Timestamptz `json:"verified_at"`
+	ReleasedAt pgtype.Timestamptz `json:"released_at"`
+	ReleasedBy pgtype.UUID        `json:"released_by"`
+}
+
+type MachineUser struct {
+	UserID               uuid.UUID   `json:"user_id"`
+	OrgID                uuid.UUID   `json:"org_id"`
+	Name                 string      `json:"name"`
+	Description          *string     `json:"description"`
+	CreatedBy            pgtype.UUID `json:"created_by"`
+	MaxApps              *int32      `json:"max_apps"`
+	MaxDeploymentsPerDay *int32      `json:"max_deployments_per_day"`
+	CreatedAt            time.Time   `json:"created_at"`
+	UpdatedAt            time.Time   `json:"updated_at"`
+}
+
+type MaintenanceWindow struct {
+	ID        uuid.UUID   `json:"id"`
+	Title     string      `json:"title"`
+	Message   string      `json:"message"`
+	StartsAt  time.Time   `json:"starts_at"`
+	EndsAt    time.Time   `json:"ends_at"`
+	CreatedBy pgtype.UUID `json:"created_by"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
 }
 
 type OauthState struct {
@@ -75,16 +518,159 @@ type OauthState struct {
 	CliTokenExchange *bool     `json:"cli_token_exchange"`
 	CreatedAt        time.Time `json:"created_at"`
 	ExpiresAt        time.Time `json:"expires_at"`
+	Referrer         *string   `json:"referrer"`
 }
 
-type User struct {
+type OrganizationMember struct {
+	ID          uuid.UUID   `json:"id"`
+	OrgID       uuid.UUID   `json:"org_id"`
+	UserID      pgtype.UUID `json:"user_id"`
+	UserName    string      `json:"user_name"`
+	ExternalID  *string     `json:"external_id"`
+	Email       *string     `json:"email"`
+	DisplayName *string     `json:"display_name"`
+	Role        string      `json:"role"`
+	Active      bool        `json:"active"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+type Organization struct {
+	ID                   uuid.UUID `json:"id"`
+	Name                 string    `json:"name"`
+	OwnerID              uuid.UUID `json:"owner_id"`
+	ScimTokenHash        *string   `json:"scim_token_hash"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+	MaxTokenLifetimeDays *int32    `json:"max_token_lifetime_days"`
+}
+
+type Outbox struct {
+	ID            uuid.UUID          `json:"id"`
+	Kind          string             `json:"kind"`
+	Destination   string             `json:"destination"`
+	Payload       []byte             `json:"payload"`
+	Status        string             `json:"status"`
+	Attempts      int32              `json:"attempts"`
+	MaxAttempts   int32              `json:"max_attempts"`
+	NextAttemptAt time.Time          `json:"next_attempt_at"`
+	LastError     *string            `json:"last_error"`
+	DeliveredAt   pgtype.Timestamptz `json:"delivered_at"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+}
+
+type PlatformReadOnly struct {
+	ID        bool        `json:"id"`
+	Enabled   bool        `json:"enabled"`
+	Message   string      `json:"message"`
+	UpdatedBy pgtype.UUID `json:"updated_by"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+type Project struct {
+	ID          uuid.UUID `json:"id"`
+	UserID      uuid.UUID `json:"user_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type ProjectEnvGroup struct {
 	ID               uuid.UUID `json:"id"`
-	GithubID         int64     `json:"github_id"`
-	Username         string    `json:"username"`
-	Email            string    `json:"email"`
-	AvatarUrl        *string   `json:"avatar_url"`
-	Plan             string    `json:"plan"`
-	StripeCustomerID *string   `json:"stripe_customer_id"`
+	ProjectID        uuid.UUID `json:"project_id"`
+	Name             string    `json:"name"`
+	EnvVarsEncrypted []byte    `json:"env_vars_encrypted"`
 	CreatedAt        time.Time `json:"created_at"`
 	UpdatedAt        time.Time `json:"updated_at"`
 }
+
+type Referral struct {
+	UserID     uuid.UUID          `json:"user_id"`
+	ReferrerID uuid.UUID          `json:"referrer_id"`
+	CreditedAt pgtype.Timestamptz `json:"credited_at"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+type RegistryUsage struct {
+	UserID       uuid.UUID `json:"user_id"`
+	Bytes        int64     `json:"bytes"`
+	Repositories int32     `json:"repositories"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type RouterRoute struct {
+	ID         uuid.UUID `json:"id"`
+	RouterID   uuid.UUID `json:"router_id"`
+	PathPrefix string    `json:"path_prefix"`
+	AppID      uuid.UUID `json:"app_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type Router struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type TaxProfile struct {
+	ID             uuid.UUID   `json:"id"`
+	UserID         pgtype.UUID `json:"user_id"`
+	OrganizationID pgtype.UUID `json:"organization_id"`
+	Country        string      `json:"country"`
+	PostalCode     string      `json:"postal_code"`
+	BusinessName   string      `json:"business_name"`
+	TaxIDType      string      `json:"tax_id_type"`
+	TaxID          string      `json:"tax_id"`
+	StripeTaxID    string      `json:"stripe_tax_id"`
+	TaxIDStatus    string      `json:"tax_id_status"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+type TrafficSplitBackend struct {
+	SplitID uuid.UUID `json:"split_id"`
+	AppID   uuid.UUID `json:"app_id"`
+	Weight  int32     `json:"weight"`
+}
+
+type TrafficSplit struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Name      string    `json:"name"`
+	Sticky    bool      `json:"sticky"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type UserSetting struct {
+	UserID                 uuid.UUID `json:"user_id"`
+	DefaultRegion          string    `json:"default_region"`
+	DefaultSize            string    `json:"default_size"`
+	DefaultHealthCheckPath string    `json:"default_health_check_path"`
+	EmailNotifications     bool      `json:"email_notifications"`
+	CreatedAt              time.Time `json:"created_at"`
+	UpdatedAt              time.Time `json:"updated_at"`
+}
+
+type UserTwoFactor struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Enabled   bool      `json:"enabled"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+type User struct {
+	ID                uuid.UUID          `json:"id"`
+	GithubID          int64              `json:"github_id"`
+	Username          string             `json:"username"`
+	Email             string             `json:"email"`
+	AvatarUrl         *string            `json:"avatar_url"`
+	Plan              string             `json:"plan"`
+	StripeCustomerID  *string            `json:"stripe_customer_id"`
+	CreatedAt         time.Time          `json:"created_at"`
+	UpdatedAt         time.Time          `json:"updated_at"`
+	SessionsRevokedAt pgtype.Timestamptz `json:"sessions_revoked_at"`
+}
@@ -11,9 +11,9 @@ import (
 )
 
 const createOAuthState = `-- name: CreateOAuthState :one
-INSERT INTO oauth_states (state, redirect_uri, cli_token_exchange, expires_at)
-VALUES ($1, $2, $3, $4)
-RETURNING state, redirect_uri, cli_token_exchange, created_at, expires_at
+INSERT INTO oauth_states (state, redirect_uri, cli_token_exchange, expires_at, referrer)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING state, redirect_uri, cli_token_exchange, created_at, expires_at, referrer
 `
 
 type CreateOAuthStateParams struct {
@@ -21,6 +21,7 @@ type CreateOAuthStateParams struct {
 	RedirectUri      *string   `json:"redirect_uri"`
 	CliTokenExchange *bool     `json:"cli_token_exchange"`
 	ExpiresAt        time.Time `json:"expires_at"`
+	Referrer         *string   `json:"referrer"`
 }
 
 func (q *Queries) CreateOAuthState(ctx context.Context, arg CreateOAuthStateParams) (OauthState, error) {
@@ -29,6 +30,7 @@ func (q *Queries) CreateOAuthState(ctx context.Context, arg CreateOAuthStatePara
 		arg.RedirectUri,
 		arg.CliTokenExchange,
 		arg.ExpiresAt,
+		arg.Referrer,
 	)
 	var i OauthState
 	err := row.Scan(
@@ -37,6 +39,7 @@ func (q *Queries) CreateOAuthState(ctx context.Context, arg CreateOAuthStatePara
 		&i.CliTokenExchange,
 		&i.CreatedAt,
 		&i.ExpiresAt,
+		&i.Referrer,
 	)
 	return i, err
 }
@@ -60,7 +63,7 @@ func (q *Queries) DeleteOAuthState(ctx context.Context, state string) error {
 }
 
 const getOAuthState = `-- name: GetOAuthState :one
-SELECT state, redirect_uri, cli_token_exchange, created_at, expires_at FROM oauth_states WHERE state = $1
+SELECT state, redirect_uri, cli_token_exchange, created_at, expires_at, referrer FROM oauth_states WHERE state = $1
 `
 
 func (q *Queries) GetOAuthState(ctx context.Context, state string) (OauthState, error) {
@@ -72,6 +75,7 @@ func (q *Queries) GetOAuthState(ctx context.Context, state string) (OauthState,
 		&i.CliTokenExchange,
 		&i.CreatedAt,
 		&i.ExpiresAt,
+		&i.Referrer,
 	)
 	return i, err
 }
@@ -14,7 +14,7 @@ import (
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (github_id, username, email, avatar_url)
 VALUES ($1, $2, $3, $4)
-RETURNING id, github_id, username, email, avatar_url, plan, stripe_customer_id, created_at, updated_at
+RETURNING id, github_id, username, email, avatar_url, plan, stripe_customer_id, created_at, updated_at, sessions_revoked_at
 `
 
 type CreateUserParams struct {
@@ -42,6 +42,7 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.StripeCustomerID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.SessionsRevokedAt,
 	)
 	return i, err
 }
@@ -56,7 +57,7 @@ func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) error {
 }
 
 const getUserByGitHubID = `-- name: GetUserByGitHubID :one
-SELECT id, github_id, username, email, avatar_url, plan, stripe_customer_id, created_at, updated_at FROM users WHERE github_id = $1
+SELECT id, github_id, username, email, avatar_url, plan, stripe_customer_id, created_at, updated_at, sessions_revoked_at FROM users WHERE github_id = $1
 `
 
 func (q *Queries) GetUserByGitHubID(ctx context.Context, githubID int64) (User, error) {
@@ -72,12 +73,13 @@ func (q *Queries) GetUserByGitHubID(ctx context.Context, githubID int64) (User,
 		&i.StripeCustomerID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.SessionsRevokedAt,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, github_id, username, email, avatar_url, plan, stripe_customer_id, created_at, updated_at FROM users WHERE id = $1
+SELECT id, github_id, username, email, avatar_url, plan, stripe_customer_id, created_at, updated_at, sessions_revoked_at FROM users WHERE id = $1
 `
 
 func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
@@ -93,12 +95,57 @@ func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.StripeCustomerID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.SessionsRevokedAt,
+	)
+	return i, err
+}
+
+const getUserByRegistryNamespace = `-- name: GetUserByRegistryNamespace :one
+SELECT id, github_id, username, email, avatar_url, plan, stripe_customer_id, created_at, updated_at, sessions_revoked_at FROM users WHERE lower(username) = $1::text
+`
+
+func (q *Queries) GetUserByRegistryNamespace(ctx context.Context, namespace string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByRegistryNamespace, namespace)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.GithubID,
+		&i.Username,
+		&i.Email,
+		&i.AvatarUrl,
+		&i.Plan,
+		&i.StripeCustomerID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SessionsRevokedAt,
+	)
+	return i, err
+}
+
+const getUserByStripeCustomerID = `-- name: GetUserByStripeCustomerID :one
+SELECT id, github_id, username, email, avatar_url, plan, stripe_customer_id, created_at, updated_at, sessions_revoked_at FROM users WHERE stripe_customer_id = $1
+`
+
+func (q *Queries) GetUserByStripeCustomerID(ctx context.Context, stripeCustomerID *string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByStripeCustomerID, stripeCustomerID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.GithubID,
+		&i.Username,
+		&i.Email,
+		&i.AvatarUrl,
+		&i.Plan,
+		&i.StripeCustomerID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SessionsRevokedAt,
 	)
 	return i, err
 }
 
 const getUserByUsername = `-- name: GetUserByUsername :one
-SELECT id, github_id, username, email, avatar_url, plan, stripe_customer_id, created_at, updated_at FROM users WHERE username = $1
+SELECT id, github_id, username, email, avatar_url, plan, stripe_customer_id, created_at, updated_at, sessions_revoked_at FROM users WHERE username = $1
 `
 
 func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User, error) {
@@ -114,12 +161,13 @@ func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User,
 		&i.StripeCustomerID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.SessionsRevokedAt,
 	)
 	return i, err
 }
 
 const listUsers = `-- name: ListUsers :many
-SELECT id, github_id, username, email, avatar_url, plan, stripe_customer_id, created_at, updated_at FROM users
+SELECT id, github_id, username, email, avatar_url, plan, stripe_customer_id, created_at, updated_at, sessions_revoked_at FROM users
 ORDER BY created_at DESC
 LIMIT $1 OFFSET $2
 `
@@ -148,6 +196,7 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 			&i.StripeCustomerID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.SessionsRevokedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -159,11 +208,20 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 	return items, nil
 }
 
+const revokeUserSessions = `-- name: RevokeUserSessions :exec
+UPDATE users SET sessions_revoked_at = NOW() WHERE id = $1
+`
+
+func (q *Queries) RevokeUserSessions(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, revokeUserSessions, id)
+	return err
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users
 SET username = $2, email = $3, avatar_url = $4
 WHERE id = $1
-RETURNING id, github_id, username, email, avatar_url, plan, stripe_customer_id, created_at, updated_at
+RETURNING id, github_id, username, email, avatar_url, plan, stripe_customer_id, created_at, updated_at, sessions_revoked_at
 `
 
 type UpdateUserParams struct {
@@ -191,6 +249,7 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.StripeCustomerID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.SessionsRevokedAt,
 	)
 	return i, err
 }
@@ -213,7 +272,7 @@ const updateUserPlan = `-- name: UpdateUserPlan :one
 UPDATE users
 SET plan = $2, stripe_customer_id = $3
 WHERE id = $1
-RETURNING id, github_id, username, email, avatar_url, plan, stripe_customer_id, created_at, updated_at
+RETURNING id, github_id, username, email, avatar_url, plan, stripe_customer_id, created_at, updated_at, sessions_revoked_at
 `
 
 type UpdateUserPlanParams struct {
@@ -235,6 +294,7 @@ func (q *Queries) UpdateUserPlan(ctx context.Context, arg UpdateUserPlanParams)
 		&i.StripeCustomerID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.SessionsRevokedAt,
 	)
 	return i, err
 }
@@ -0,0 +1,93 @@
+// Package actor identifies who made an API request, a signed-in user or a
+// token of theirs, for the audit trail of deployments and app actions.
+package actor
+
+import (
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Kind is how an actor authenticated
+type Kind string
+
+const (
+	// KindUser is a user signed in to the dashboard or CLI
+	KindUser Kind = "user"
+	// KindAPIToken is an API token of the user
+	KindAPIToken Kind = "api_token"
+	// KindDeployToken is a deploy token a CI run got for its OIDC token
+	KindDeployToken Kind = "deploy_token"
+)
+
+// Context keys the auth middleware sets for requests made with an API token
+const (
+	TokenIDKey   = "api_token_id"
+	TokenNameKey = "api_token_name"
+)
+
+// Context keys the auth middleware sets for requests of an app collaborator.
+// "user_id" and "username" are then the app's owner, whom handlers look the
+// app up as, while these keep the collaborator who made the request.
+const (
+	UserIDKey   = "actor_user_id"
+	UsernameKey = "actor_username"
+)
+
+// Actor is who made a request
+type Actor struct {
+	Kind     Kind      `json:"type"`
+	UserID   uuid.UUID `json:"user_id"`
+	Username string    `json:"username,omitempty"`
+	// TokenID and TokenName are the API token used, if any
+	TokenID   *uuid.UUID `json:"token_id,omitempty"`
+	TokenName string     `json:"token_name,omitempty"`
+}
+
+// From returns the actor of an authenticated request
+func From(c *fuego.Context) Actor {
+	a := Actor{Kind: KindUser}
+	a.UserID, _ = c.Get("user_id").(uuid.UUID)
+	a.Username, _ = c.Get("username").(string)
+	if id, ok := c.Get(UserIDKey).(uuid.UUID); ok {
+		a.UserID = id
+		a.Username, _ = c.Get(UsernameKey).(string)
+	}
+
+	if id, ok := c.Get(TokenIDKey).(uuid.UUID); ok {
+		a.Kind = KindAPIToken
+		a.TokenID = &id
+		a.TokenName, _ = c.Get(TokenNameKey).(string)
+	} else if claims, ok := c.Get("claims").(*auth.Claims); ok && claims.App != "" {
+		a.Kind = KindDeployToken
+	}
+	return a
+}
+
+// String describes the actor, such as "octocat" or "octocat via token ci"
+func (a Actor) String() string {
+	switch a.Kind {
+	case KindAPIToken:
+		if a.TokenName == "" {
+			return a.Username + " via API token"
+		}
+		return a.Username + " via token " + a.TokenName
+	case KindDeployToken:
+		return a.Username + " via deploy token"
+	}
+	return a.Username
+}
+
+// User returns the actor's user for a nullable column
+func (a Actor) User() pgtype.UUID {
+	return pgtype.UUID{Bytes: a.UserID, Valid: a.UserID != uuid.Nil}
+}
+
+// Token returns the API token used for a nullable column, NULL when none was
+func (a Actor) Token() pgtype.UUID {
+	if a.TokenID == nil {
+		return pgtype.UUID{}
+	}
+	return pgtype.UUID{Bytes: *a.TokenID, Valid: true}
+}
@@ -0,0 +1,33 @@
+package actor
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestActor(t *testing.T) {
+	userID, tokenID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		actor Actor
+		want  string
+		token bool
+	}{
+		{Actor{Kind: KindUser, UserID: userID, Username: "octocat"}, "octocat", false},
+		{Actor{Kind: KindAPIToken, UserID: userID, Username: "octocat", TokenID: &tokenID, TokenName: "ci"}, "octocat via token ci", true},
+		{Actor{Kind: KindAPIToken, UserID: userID, Username: "octocat", TokenID: &tokenID}, "octocat via API token", true},
+		{Actor{Kind: KindDeployToken, UserID: userID, Username: "octocat"}, "octocat via deploy token", false},
+	}
+	for _, tt := range tests {
+		if got := tt.actor.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+		if got := tt.actor.User(); !got.Valid || got.Bytes != userID {
+			t.Errorf("%s: User() = %v, want %v", tt.want, got, userID)
+		}
+		if got := tt.actor.Token(); got.Valid != tt.token || (tt.token && got.Bytes != tokenID) {
+			t.Errorf("%s: Token() = %v", tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+// Package apitime is the contract for timestamps in API responses: each is
+// an RFC 3339 instant in UTC, such as "2026-03-01T12:00:00Z", with
+// fractional seconds when it has them, and an unset one is null or left
+// out. Times reach responses from the database, Kubernetes and the clock,
+// all of which otherwise carry the zone offset of the server.
+package apitime
+
+import "time"
+
+// UseUTC makes UTC the local time zone of the process, so every time.Time
+// and pgtype.Timestamptz serializes in UTC whatever the TZ of the host.
+// Call it first thing in main, before anything reads the clock.
+func UseUTC() {
+	time.Local = time.UTC
+}
+
+// Format formats t as timestamps appear in responses, for string fields
+func Format(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,46 @@
+package apitime
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestFormat(t *testing.T) {
+	zone := time.FixedZone("CST", -6*60*60)
+	tests := []struct {
+		time time.Time
+		want string
+	}{
+		{time.Date(2026, 3, 1, 6, 0, 0, 0, zone), "2026-03-01T12:00:00Z"},
+		{time.Date(2026, 3, 1, 6, 0, 0, 123456000, zone), "2026-03-01T12:00:00.123456Z"},
+	}
+	for _, tt := range tests {
+		if got := Format(tt.time); got != tt.want {
+			t.Errorf("Format(%v) = %s, want %s", tt.time, got, tt.want)
+		}
+	}
+}
+
+func TestUseUTC(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("CST", -6*60*60)
+	defer func() { time.Local = local }()
+
+	UseUTC()
+
+	// Scanned timestamps and the clock are both in the local zone
+	now := time.Unix(1772366400, 0)
+	for _, v := range []any{now, time.Now(), pgtype.Timestamptz{Time: now, Valid: true}} {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(string(data), `Z"`) {
+			t.Errorf("expected %T to serialize in UTC, got %s", v, data)
+		}
+	}
+}
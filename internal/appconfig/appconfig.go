@@ -0,0 +1,431 @@
+// Package appconfig assembles the Kubernetes configuration of an app from the
+// settings stored in the database.
+package appconfig
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/cryptoutil"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/dbaddon"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/pki"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/registry"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// DefaultPort is the port fuego apps listen on
+const DefaultPort int32 = 3000
+
+// Load builds the AppConfig the platform applies when deploying the given
+// deployment of an app: image, env, labels, formation, cron jobs, hooks,
+// placement, mTLS, an active traffic mirror, a static egress IP, database
+// poolers, the OpenTelemetry collector, the pull credential of images in the
+// platform registry, the Cloudflare Tunnel of apps in regions without a
+// public ingress and the first verified custom domain with its TLS policy.
+func Load(ctx context.Context, cfg *config.Config, queries *db.Queries, app db.App, deployment db.Deployment) (*k8s.AppConfig, error) {
+	envVars, err := EnvVars(ctx, cfg, queries, app)
+	if err != nil {
+		return nil, err
+	}
+
+	appConfig := &k8s.AppConfig{
+		Name:            app.Name,
+		Image:           deployment.Image,
+		Replicas:        1,
+		Port:            DefaultPort,
+		EnvVars:         envVars,
+		DomainSuffix:    cfg.AppsDomainSuffix,
+		HealthCheckPath: app.HealthCheckPath,
+		DualStack:       cfg.DualStackForRegion(app.Region),
+		// Pinned when the deployment was accepted
+		Architectures: deployment.Architectures,
+	}
+
+	if appConfig.Labels, err = Labels(app); err != nil {
+		return nil, err
+	}
+
+	domains, err := queries.ListDomainsByApp(ctx, app.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domains: %w", err)
+	}
+	for _, d := range domains {
+		if d.Verified {
+			appConfig.Domain = d.Domain
+			appConfig.TLSPolicy = TLSPolicy(d)
+			break
+		}
+	}
+
+	procs, err := queries.ListAppProcesses(ctx, app.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+	for _, p := range procs {
+		appConfig.Processes = append(appConfig.Processes, k8s.ProcessConfig{
+			Type:     p.ProcessType,
+			Command:  deref(p.Command),
+			Replicas: p.Replicas,
+			CPU:      deref(p.Cpu),
+			Memory:   deref(p.Memory),
+		})
+	}
+
+	crons, err := queries.ListCronJobsByApp(ctx, app.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cron jobs: %w", err)
+	}
+	for _, cj := range crons {
+		appConfig.CronJobs = append(appConfig.CronJobs, k8s.CronJobConfig{
+			Name:                    cj.Name,
+			Schedule:                cj.Schedule,
+			Command:                 cj.Command,
+			TimeZone:                cj.Timezone,
+			ConcurrencyPolicy:       cj.ConcurrencyPolicy,
+			StartingDeadlineSeconds: cj.StartingDeadlineSeconds,
+			ActiveDeadlineSeconds:   cj.ActiveDeadlineSeconds,
+			SuccessfulHistoryLimit:  cj.SuccessfulHistoryLimit,
+			FailedHistoryLimit:      cj.FailedHistoryLimit,
+			Suspended:               cj.Suspended,
+		})
+	}
+
+	hooks, err := queries.GetDeployHooksByApp(ctx, app.ID)
+	switch {
+	case err == nil:
+		appConfig.PreDeployHook = deref(hooks.PreDeployCommand)
+		appConfig.PostDeployHook = deref(hooks.PostDeployCommand)
+		appConfig.HookTimeout = time.Duration(hooks.TimeoutSeconds) * time.Second
+	case !errors.Is(err, pgx.ErrNoRows):
+		return nil, fmt.Errorf("failed to get deploy hooks: %w", err)
+	}
+
+	placement, err := queries.GetAppPlacement(ctx, app.ID)
+	switch {
+	case err == nil:
+		appConfig.Placement = &k8s.Placement{}
+		if err := json.Unmarshal(placement.NodeSelector, &appConfig.Placement.NodeSelector); err != nil {
+			return nil, fmt.Errorf("invalid node selector: %w", err)
+		}
+		if err := json.Unmarshal(placement.Tolerations, &appConfig.Placement.Tolerations); err != nil {
+			return nil, fmt.Errorf("invalid tolerations: %w", err)
+		}
+	case !errors.Is(err, pgx.ErrNoRows):
+		return nil, fmt.Errorf("failed to get placement: %w", err)
+	}
+
+	appConfig.MTLS, err = MTLS(ctx, cfg, queries, app)
+	if err != nil {
+		return nil, err
+	}
+
+	appConfig.Mirror, err = Mirror(ctx, queries, app, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	appConfig.Egress, err = Egress(ctx, queries, app)
+	if err != nil {
+		return nil, err
+	}
+
+	appConfig.Poolers, err = Poolers(ctx, cfg, queries, app)
+	if err != nil {
+		return nil, err
+	}
+	appConfig.PoolerImage = cfg.PgBouncerImage
+
+	appConfig.OTelCollector, err = OTelCollector(ctx, cfg, queries, app)
+	if err != nil {
+		return nil, err
+	}
+	appConfig.OTelCollectorImage = cfg.OTelCollectorImage
+
+	appConfig.PullSecret, err = PullSecret(ctx, cfg, queries, app, deployment.Image)
+	if err != nil {
+		return nil, err
+	}
+
+	appConfig.Tunnel, err = Tunnel(ctx, cfg, queries, app)
+	if err != nil {
+		return nil, err
+	}
+	appConfig.CloudflaredImage = cfg.CloudflaredImage
+
+	return appConfig, nil
+}
+
+// ApplyEnv rewrites the env secret of a running app in the cluster of its
+// region, connected to with clients, and rolls its pods
+func ApplyEnv(ctx context.Context, cfg *config.Config, queries *db.Queries, clients func(kubeconfig string) (k8s.Interface, error), app db.App) error {
+	appConfig, err := Load(ctx, cfg, queries, app, db.Deployment{})
+	if err != nil {
+		return err
+	}
+
+	client, err := clients(cfg.KubeconfigForRegion(app.Region))
+	if err != nil {
+		return err
+	}
+	return client.ApplyEnv(ctx, appConfig)
+}
+
+// Tunnel returns the Cloudflare Tunnel an app is served through, or nil when
+// its region uses the cluster's ingress. Until the tunnel is created on the
+// app's first deployment it has no token, so previews still render the
+// connectors in place of the Ingress.
+func Tunnel(ctx context.Context, cfg *config.Config, queries *db.Queries, app db.App) (*k8s.TunnelConfig, error) {
+	if !cfg.TunnelForRegion(app.Region) {
+		return nil, nil
+	}
+
+	stored, err := queries.GetAppTunnel(ctx, app.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &k8s.TunnelConfig{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tunnel: %w", err)
+	}
+
+	token, err := cryptoutil.DecryptBytes(stored.TokenEncrypted, cfg.EncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt tunnel token: %w", err)
+	}
+	return &k8s.TunnelConfig{Token: string(token)}, nil
+}
+
+// PullSecret returns the credential an app pulls its image with when the
+// image is in the platform registry, or nil for images elsewhere. It is the
+// pull-only credential of the app's owner.
+func PullSecret(ctx context.Context, cfg *config.Config, queries *db.Queries, app db.App, image string) (*k8s.PullSecretConfig, error) {
+	if cfg.RegistryHost == "" {
+		return nil, nil
+	}
+	ref, err := registry.ParseReference(image)
+	if err != nil || ref.Registry != cfg.RegistryHost {
+		return nil, nil
+	}
+
+	owner, err := queries.GetUserByID(ctx, app.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get app owner: %w", err)
+	}
+	return &k8s.PullSecretConfig{
+		Server:   cfg.RegistryHost,
+		Username: owner.Username,
+		Password: registry.PullPassword(cfg.SigningKey(), owner.Username),
+	}, nil
+}
+
+// EnvVars returns the env vars an app runs with: the env groups of its
+// project, in name order, then the database add-ons linked to it and the
+// endpoint of its OpenTelemetry collector, all overridden by the app's own
+// variables
+func EnvVars(ctx context.Context, cfg *config.Config, queries *db.Queries, app db.App) (map[string]string, error) {
+	own, err := cryptoutil.Decrypt(app.EnvVarsEncrypted, cfg.EncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt environment variables: %w", err)
+	}
+
+	var layers []map[string]string
+	if app.ProjectID.Valid {
+		groups, err := queries.ListProjectEnvGroups(ctx, uuid.UUID(app.ProjectID.Bytes))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list project env groups: %w", err)
+		}
+		for _, group := range groups {
+			vars, err := cryptoutil.Decrypt(group.EnvVarsEncrypted, cfg.EncryptionKey)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decrypt env group %s: %w", group.Name, err)
+			}
+			layers = append(layers, vars)
+		}
+	}
+
+	addons, err := dbaddon.EnvVars(ctx, cfg, queries, app.ID)
+	if err != nil {
+		return nil, err
+	}
+	layers = append(layers, addons)
+
+	_, err = queries.GetAppOtelCollector(ctx, app.ID)
+	switch {
+	case err == nil:
+		layers = append(layers, k8s.OTelEnv(app.Name))
+	case !errors.Is(err, pgx.ErrNoRows):
+		return nil, fmt.Errorf("failed to get otel collector: %w", err)
+	}
+	return MergeEnv(append(layers, own)...), nil
+}
+
+// OTelCollector returns the OpenTelemetry collector of an app, or nil when
+// it has none
+func OTelCollector(ctx context.Context, cfg *config.Config, queries *db.Queries, app db.App) (*k8s.OTelCollectorConfig, error) {
+	collector, err := queries.GetAppOtelCollector(ctx, app.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get otel collector: %w", err)
+	}
+
+	headers, err := cryptoutil.Decrypt(collector.HeadersEncrypted, cfg.EncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt otel collector headers: %w", err)
+	}
+	return &k8s.OTelCollectorConfig{
+		Endpoint: collector.Endpoint,
+		Protocol: collector.Protocol,
+		Headers:  headers,
+	}, nil
+}
+
+// Poolers returns the PgBouncer sidecars of the pooled database add-ons
+// linked to an app
+func Poolers(ctx context.Context, cfg *config.Config, queries *db.Queries, app db.App) ([]k8s.PoolerConfig, error) {
+	links, err := dbaddon.Links(ctx, cfg, queries, app.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var poolers []k8s.PoolerConfig
+	for _, link := range links {
+		if !link.Pooling {
+			continue
+		}
+		poolers = append(poolers, k8s.PoolerConfig{
+			Name:       link.Addon,
+			Host:       link.Host,
+			Port:       link.Port,
+			Database:   link.Database,
+			Username:   link.Username,
+			Password:   link.Password,
+			SSLMode:    link.SSLMode,
+			ListenPort: link.ListenPort,
+			PoolSize:   link.PoolSize,
+		})
+	}
+	return poolers, nil
+}
+
+// Labels decodes the user-defined labels of an app
+func Labels(app db.App) (map[string]string, error) {
+	labels := map[string]string{}
+	if len(app.Labels) == 0 {
+		return labels, nil
+	}
+	if err := json.Unmarshal(app.Labels, &labels); err != nil {
+		return nil, fmt.Errorf("invalid labels: %w", err)
+	}
+	return labels, nil
+}
+
+// TLSPolicy returns how the ingress of a custom domain treats plain HTTP and
+// HSTS
+func TLSPolicy(domain db.Domain) *k8s.TLSPolicy {
+	return &k8s.TLSPolicy{
+		ForceHTTPS:            domain.ForceHttps,
+		HSTSMaxAge:            domain.HstsMaxAge,
+		HSTSIncludeSubdomains: domain.HstsIncludeSubdomains,
+		HSTSPreload:           domain.HstsPreload,
+	}
+}
+
+// MergeEnv merges layers of env vars; later layers take precedence
+func MergeEnv(layers ...map[string]string) map[string]string {
+	merged := map[string]string{}
+	for _, layer := range layers {
+		for key, value := range layer {
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+// Mirror returns the traffic mirror of an app, or nil when it has none or it
+// expired before now
+func Mirror(ctx context.Context, queries *db.Queries, app db.App, now time.Time) (*k8s.MirrorConfig, error) {
+	mirror, err := queries.GetAppMirror(ctx, app.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mirror: %w", err)
+	}
+	if !mirror.ExpiresAt.After(now) {
+		return nil, nil
+	}
+
+	return &k8s.MirrorConfig{
+		TargetApp: mirror.TargetAppName,
+		Percent:   mirror.Percent,
+	}, nil
+}
+
+// Egress returns the static egress IP of an app, or nil when it has none. An
+// IP of another region is ignored, as only that region's gateways hold it.
+func Egress(ctx context.Context, queries *db.Queries, app db.App) (*k8s.EgressConfig, error) {
+	egress, err := queries.GetAppEgressIP(ctx, app.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get egress ip: %w", err)
+	}
+	if egress.Region != app.Region {
+		return nil, nil
+	}
+	return &k8s.EgressConfig{IP: egress.Ip}, nil
+}
+
+// MTLS returns the client certificate requirements of an app with the current
+// revocation list, or nil when the app does not enforce mTLS
+func MTLS(ctx context.Context, cfg *config.Config, queries *db.Queries, app db.App) (*k8s.MTLSConfig, error) {
+	_, err := queries.GetAppMTLS(ctx, app.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mtls settings: %w", err)
+	}
+
+	ca, err := pki.LoadCAFiles(cfg.MTLSCACertFile, cfg.MTLSCAKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked, err := queries.ListRevokedClientCertificates(ctx, app.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list revoked certificates: %w", err)
+	}
+	entries := make([]pki.RevokedCert, 0, len(revoked))
+	for _, cert := range revoked {
+		entries = append(entries, pki.RevokedCert{Serial: cert.Serial, RevokedAt: cert.RevokedAt.Time})
+	}
+
+	now := time.Now()
+	crl, err := ca.CRL(entries, now.Unix(), now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &k8s.MTLSConfig{
+		CACert:    ca.CertPEM,
+		CRL:       crl,
+		VerifyURL: cfg.MTLSVerifyURL(app.Name),
+	}, nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,27 @@
+package appconfig
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeEnv(t *testing.T) {
+	got := MergeEnv(
+		map[string]string{"DATABASE_URL": "postgres://shared", "LOG_LEVEL": "info"},
+		map[string]string{"STRIPE_KEY": "sk_shared", "LOG_LEVEL": "warn"},
+		map[string]string{"LOG_LEVEL": "debug", "PORT": "3000"},
+	)
+	want := map[string]string{
+		"DATABASE_URL": "postgres://shared",
+		"STRIPE_KEY":   "sk_shared",
+		"LOG_LEVEL":    "debug",
+		"PORT":         "3000",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MergeEnv = %v, want %v", got, want)
+	}
+
+	if got := MergeEnv(); got == nil || len(got) != 0 {
+		t.Errorf("expected an empty map without layers, got %v", got)
+	}
+}
@@ -0,0 +1,40 @@
+package appconfig
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+)
+
+// PlatformHost is the host of an app, router or traffic split on the apps
+// domain; the three share one namespace of names per user
+func PlatformHost(cfg *config.Config, name string) string {
+	return name + "." + cfg.AppsDomainSuffix
+}
+
+// LoadRouter builds the RouterConfig of a router from its stored routes. It
+// also returns the region of the routed apps, which all run in one region;
+// the router is applied on that region's cluster.
+func LoadRouter(ctx context.Context, cfg *config.Config, queries *db.Queries, router db.Router) (*k8s.RouterConfig, string, error) {
+	routes, err := queries.ListRouterRoutes(ctx, router.ID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list routes: %w", err)
+	}
+
+	routerConfig := &k8s.RouterConfig{
+		Name: router.Name,
+		Host: PlatformHost(cfg, router.Name),
+	}
+	region := ""
+	for _, route := range routes {
+		routerConfig.Routes = append(routerConfig.Routes, k8s.RouterRoute{
+			PathPrefix: route.PathPrefix,
+			AppName:    route.AppName,
+		})
+		region = route.AppRegion
+	}
+	return routerConfig, region, nil
+}
@@ -0,0 +1,35 @@
+package appconfig
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+)
+
+// LoadTrafficSplit builds the TrafficSplitConfig of a split from its stored
+// backends. It also returns the region of the backend apps, which all run in
+// one region; the split is applied on that region's cluster.
+func LoadTrafficSplit(ctx context.Context, cfg *config.Config, queries *db.Queries, split db.TrafficSplit) (*k8s.TrafficSplitConfig, string, error) {
+	backends, err := queries.ListTrafficSplitBackends(ctx, split.ID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list split backends: %w", err)
+	}
+
+	splitConfig := &k8s.TrafficSplitConfig{
+		Name:   split.Name,
+		Host:   PlatformHost(cfg, split.Name),
+		Sticky: split.Sticky,
+	}
+	region := ""
+	for _, backend := range backends {
+		splitConfig.Backends = append(splitConfig.Backends, k8s.SplitBackend{
+			AppName: backend.AppName,
+			Weight:  backend.Weight,
+		})
+		region = backend.AppRegion
+	}
+	return splitConfig, region, nil
+}
@@ -0,0 +1,99 @@
+// Package appmeta validates the descriptive metadata users attach to apps -
+// a description, an icon, the source repository and tags - and builds the
+// search behind GET /api/apps?search=.
+package appmeta
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Limits on app metadata
+const (
+	MaxDescriptionLength = 500
+	MaxURLLength         = 2048
+	MaxTags              = 20
+)
+
+var tagRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// Metadata is the descriptive metadata of an app
+type Metadata struct {
+	Description   string
+	IconURL       string
+	RepositoryURL string
+	Tags          []string
+}
+
+// Normalize trims and validates metadata; see NormalizeTags for tags
+func Normalize(m Metadata) (Metadata, error) {
+	m.Description = strings.TrimSpace(m.Description)
+	if utf8.RuneCountInString(m.Description) > MaxDescriptionLength {
+		return m, fmt.Errorf("description may be at most %d characters", MaxDescriptionLength)
+	}
+
+	var err error
+	if m.IconURL, err = normalizeURL("icon_url", m.IconURL, []string{"https"}); err != nil {
+		return m, err
+	}
+	if m.RepositoryURL, err = normalizeURL("repository_url", m.RepositoryURL, []string{"https", "http"}); err != nil {
+		return m, err
+	}
+	if m.Tags, err = NormalizeTags(m.Tags); err != nil {
+		return m, err
+	}
+	return m, nil
+}
+
+// NormalizeTags lowercases and deduplicates tags, keeping their order. A tag
+// is up to 32 lowercase letters, digits and hyphens, starting with a letter
+// or digit.
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if !tagRegex.MatchString(tag) {
+			return nil, fmt.Errorf("invalid tag %q: use up to 32 lowercase letters, digits and hyphens", tag)
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > MaxTags {
+		return nil, fmt.Errorf("an app may have at most %d tags", MaxTags)
+	}
+	return normalized, nil
+}
+
+func normalizeURL(field, raw string, schemes []string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", nil
+	}
+	if len(raw) > MaxURLLength {
+		return "", fmt.Errorf("%s may be at most %d characters", field, MaxURLLength)
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || u.User != nil {
+		return "", fmt.Errorf("%s must be an absolute URL", field)
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			return raw, nil
+		}
+	}
+	return "", fmt.Errorf("%s must use %s", field, strings.Join(schemes, " or "))
+}
+
+// SearchPattern turns a search term into an ILIKE pattern matching it
+// anywhere, with the term's own wildcards escaped
+func SearchPattern(search string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(search)
+	return "%" + escaped + "%"
+}
@@ -0,0 +1,101 @@
+// Package appstatus is the state machine of an app's status. Apps start
+// stopped, are deploying while a deployment rolls out and then running or
+// failed; bulk operations pause and resume them and an unpaid invoice
+// suspends and later purges them. Statuses are the app_status enum of the
+// database, and UpdateAppStatus only moves an app along the transitions
+// allowed here.
+package appstatus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrInvalidTransition is returned for a status an app cannot move to from
+// its current one
+var ErrInvalidTransition = errors.New("invalid app status transition")
+
+// transitions lists the statuses each status may move to. Staying in the
+// same status, such as redeploying an app that is deploying, is always
+// allowed.
+var transitions = map[db.AppStatus][]db.AppStatus{
+	db.AppStatusStopped:   {db.AppStatusDeploying, db.AppStatusRunning},
+	db.AppStatusDeploying: {db.AppStatusRunning, db.AppStatusFailed, db.AppStatusPaused, db.AppStatusSuspended},
+	db.AppStatusRunning:   {db.AppStatusDeploying, db.AppStatusStopped, db.AppStatusFailed, db.AppStatusPaused, db.AppStatusSuspended},
+	db.AppStatusFailed:    {db.AppStatusDeploying, db.AppStatusStopped, db.AppStatusPaused},
+	db.AppStatusPaused:    {db.AppStatusDeploying, db.AppStatusRunning},
+	db.AppStatusSuspended: {db.AppStatusRunning, db.AppStatusPurged},
+	db.AppStatusPurged:    {db.AppStatusStopped},
+}
+
+// All returns every status in the order of the enum
+func All() []db.AppStatus {
+	return []db.AppStatus{
+		db.AppStatusStopped,
+		db.AppStatusDeploying,
+		db.AppStatusRunning,
+		db.AppStatusFailed,
+		db.AppStatusPaused,
+		db.AppStatusSuspended,
+		db.AppStatusPurged,
+	}
+}
+
+// Valid reports whether status is one of the statuses of the enum
+func Valid(status db.AppStatus) bool {
+	_, ok := transitions[status]
+	return ok
+}
+
+// CanTransition reports whether an app may move from one status to another
+func CanTransition(from, to db.AppStatus) bool {
+	if !Valid(from) || !Valid(to) {
+		return false
+	}
+	return from == to || slices.Contains(transitions[from], to)
+}
+
+// Sources returns the statuses an app may move to status from, as the
+// from_statuses of UpdateAppStatus
+func Sources(status db.AppStatus) []string {
+	var sources []string
+	for _, from := range All() {
+		if CanTransition(from, status) {
+			sources = append(sources, string(from))
+		}
+	}
+	return sources
+}
+
+// Transition checks that an app may move from one status to another
+func Transition(from, to db.AppStatus) error {
+	if !CanTransition(from, to) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, from, to)
+	}
+	return nil
+}
+
+// Set moves an app to a status, keeping or changing its current
+// deployment. It fails with ErrInvalidTransition when the app is, or was
+// concurrently moved to, a status it cannot go to status from.
+func Set(ctx context.Context, queries *db.Queries, app db.App, status db.AppStatus, currentDeploymentID pgtype.UUID) (db.App, error) {
+	if err := Transition(app.Status, status); err != nil {
+		return app, err
+	}
+	updated, err := queries.UpdateAppStatus(ctx, db.UpdateAppStatusParams{
+		Status:              status,
+		CurrentDeploymentID: currentDeploymentID,
+		ID:                  app.ID,
+		FromStatuses:        Sources(status),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return app, fmt.Errorf("%w: %s changed before moving to %s", ErrInvalidTransition, app.Name, status)
+	}
+	return updated, err
+}
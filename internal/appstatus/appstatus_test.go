@@ -0,0 +1,60 @@
+package appstatus
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to db.AppStatus
+		want     bool
+	}{
+		{db.AppStatusStopped, db.AppStatusDeploying, true},
+		{db.AppStatusDeploying, db.AppStatusRunning, true},
+		{db.AppStatusDeploying, db.AppStatusFailed, true},
+		{db.AppStatusDeploying, db.AppStatusDeploying, true},
+		{db.AppStatusRunning, db.AppStatusPaused, true},
+		{db.AppStatusPaused, db.AppStatusRunning, true},
+		{db.AppStatusRunning, db.AppStatusSuspended, true},
+		{db.AppStatusSuspended, db.AppStatusPurged, true},
+		{db.AppStatusPurged, db.AppStatusStopped, true},
+
+		{db.AppStatusStopped, db.AppStatusPaused, false},
+		{db.AppStatusRunning, db.AppStatusPurged, false},
+		{db.AppStatusSuspended, db.AppStatusDeploying, false},
+		{db.AppStatusPurged, db.AppStatusRunning, false},
+		{"ready", db.AppStatusRunning, false},
+		{db.AppStatusRunning, "ready", false},
+	}
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	if err := Transition(db.AppStatusPurged, db.AppStatusRunning); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("expected ErrInvalidTransition, got %v", err)
+	}
+}
+
+func TestSources(t *testing.T) {
+	got := Sources(db.AppStatusRunning)
+	want := []string{"stopped", "deploying", "running", "paused", "suspended"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Sources(running) = %v, want %v", got, want)
+	}
+
+	// Every status has a transition out of it and is reached from another
+	for _, status := range All() {
+		if len(transitions[status]) == 0 {
+			t.Errorf("%s has no transition out of it", status)
+		}
+		if len(Sources(status)) < 2 {
+			t.Errorf("%s cannot be reached from another status", status)
+		}
+	}
+}
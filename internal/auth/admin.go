@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+)
+
+// RequireAdmin returns the platform admin calling the admin API, with the
+// bearer token or the session cookie, or the error status and message to
+// answer with when the caller is not one
+func RequireAdmin(c *fuego.Context, cfg *config.Config, queries *db.Queries) (db.User, int, string) {
+	tokenString := ExtractBearerToken(c.Header("Authorization"))
+	if tokenString == "" {
+		tokenString = c.Cookie("access_token")
+	}
+
+	claims, err := ValidateToken(tokenString, cfg.JWTSecret)
+	if err != nil {
+		return db.User{}, 401, "unauthorized"
+	}
+
+	user, err := queries.GetUserByID(c.Request.Context(), claims.UserID)
+	if err != nil || !cfg.IsAdmin(user.Username) {
+		return db.User{}, 403, "admin access required"
+	}
+	return user, 0, ""
+}
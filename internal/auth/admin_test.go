@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+)
+
+func TestRequireAdmin_Unauthenticated(t *testing.T) {
+	cfg := &config.Config{JWTSecret: "secret"}
+
+	for name, header := range map[string]string{
+		"no token":      "",
+		"invalid token": "Bearer not-a-jwt",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/outbox", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		c := fuego.NewContext(httptest.NewRecorder(), req)
+
+		// The token is checked before any query, so no database is needed
+		if _, status, msg := RequireAdmin(c, cfg, nil); status != 401 || msg != "unauthorized" {
+			t.Errorf("%s: expected 401 unauthorized, got %d %q", name, status, msg)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckCSRF(t *testing.T) {
+	allowed := []string{"https://cloud.nexo.build", "https://*.preview.nexo.build", "*"}
+
+	newRequest := func(method string, headers map[string]string, cookies ...*http.Cookie) *http.Request {
+		req := httptest.NewRequest(method, "http://api.example.com/api/apps", nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		return req
+	}
+	session := &http.Cookie{Name: "access_token", Value: "jwt"}
+	csrf := &http.Cookie{Name: CSRFCookieName, Value: "token123"}
+
+	tests := []struct {
+		name  string
+		req   *http.Request
+		valid bool
+	}{
+		{"safe method", newRequest("GET", nil, session), true},
+		{"bearer auth", newRequest("POST", map[string]string{"Authorization": "Bearer jwt"}, session), true},
+		{"no session cookie", newRequest("POST", nil), true},
+		{"same host origin", newRequest("POST", map[string]string{"Origin": "http://api.example.com"}, session), true},
+		{"allowed origin", newRequest("DELETE", map[string]string{"Origin": "https://cloud.nexo.build"}, session), true},
+		{"same host referer", newRequest("POST", map[string]string{"Referer": "http://api.example.com/dashboard"}, session), true},
+		{"allowed subdomain origin", newRequest("POST", map[string]string{"Origin": "https://pr-12.preview.nexo.build"}, session), true},
+		{"foreign origin", newRequest("POST", map[string]string{"Origin": "https://evil.example"}, session), false},
+		{"wildcard parent origin", newRequest("POST", map[string]string{"Origin": "https://preview.nexo.build"}, session), false},
+		{"cross-site fetch", newRequest("POST", map[string]string{"Sec-Fetch-Site": "cross-site", "Origin": "http://api.example.com"}, session), false},
+		{"missing token", newRequest("POST", nil, session), false},
+		{"missing cookie", newRequest("POST", map[string]string{CSRFHeader: "token123"}, session), false},
+		{"mismatched token", newRequest("PUT", map[string]string{CSRFHeader: "other"}, session, csrf), false},
+		{"matching token", newRequest("PUT", map[string]string{CSRFHeader: "token123"}, session, csrf), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckCSRF(tt.req, allowed)
+			if tt.valid && err != nil {
+				t.Errorf("expected request to pass, got %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrCSRF) {
+				t.Errorf("expected ErrCSRF, got %v", err)
+			}
+		})
+	}
+}
+
+func TestGenerateCSRFToken(t *testing.T) {
+	a, err := GenerateCSRFToken()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, _ := GenerateCSRFToken()
+	if len(a) != 64 || a == b {
+		t.Errorf("expected unique 64 character tokens, got %q and %q", a, b)
+	}
+}
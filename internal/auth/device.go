@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Device authorization grant (RFC 8628) used by fuegoctl login: the CLI
+// polls with a secret device code while the user approves the short user
+// code in the dashboard.
+const (
+	DeviceCodeTTL      = 10 * time.Minute
+	DevicePollInterval = 5 * time.Second
+	// DeviceSlowDownStep is added to the interval of a client polling too fast
+	DeviceSlowDownStep = 5 * time.Second
+)
+
+// Device authorization states
+const (
+	DeviceStatusPending  = "pending"
+	DeviceStatusApproved = "approved"
+	DeviceStatusDenied   = "denied"
+)
+
+// Errors answered to polling clients, named after the RFC 8628 error codes
+var (
+	ErrAuthorizationPending = errors.New("authorization_pending")
+	ErrSlowDown             = errors.New("slow_down")
+	ErrAccessDenied         = errors.New("access_denied")
+	ErrExpiredToken         = errors.New("expired_token")
+)
+
+// userCodeAlphabet has no vowels or lookalike characters, so user codes are
+// easy to type and never spell words
+const userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+
+// userCodeLength is the number of characters of a user code, shown in two
+// groups of four
+const userCodeLength = 8
+
+// GenerateDeviceCode generates the secret code a CLI polls with.
+func GenerateDeviceCode() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// GenerateUserCode generates the code a user enters to approve a device,
+// formatted like BDWP-HQTN.
+func GenerateUserCode() (string, error) {
+	code := make([]byte, 0, userCodeLength)
+	buf := make([]byte, 16)
+	for len(code) < userCodeLength {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			// Reject bytes that would bias the modulo
+			if int(b) >= 256-256%len(userCodeAlphabet) || len(code) == userCodeLength {
+				continue
+			}
+			code = append(code, userCodeAlphabet[int(b)%len(userCodeAlphabet)])
+		}
+	}
+	return string(code[:4]) + "-" + string(code[4:]), nil
+}
+
+// NormalizeUserCode turns user input such as "bdwp hqtn" into the stored
+// BDWP-HQTN form. Input that cannot be a user code is returned cleaned but
+// will not match any authorization.
+func NormalizeUserCode(input string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(input) {
+		if r >= 'A' && r <= 'Z' {
+			b.WriteRune(r)
+		}
+	}
+	code := b.String()
+	if len(code) != userCodeLength {
+		return code
+	}
+	return code[:4] + "-" + code[4:]
+}
+
+// DevicePoll is the state of a device authorization when its CLI polls
+type DevicePoll struct {
+	Status       string
+	ExpiresAt    time.Time
+	LastPolledAt time.Time
+	Interval     time.Duration
+}
+
+// CheckDevicePoll decides the answer to a token poll at now. It returns nil
+// once the user approved, and the interval the client must wait before
+// polling again, which grows when it polls too fast.
+func CheckDevicePoll(p DevicePoll, now time.Time) (time.Duration, error) {
+	switch {
+	case now.After(p.ExpiresAt):
+		return p.Interval, ErrExpiredToken
+	case p.Status == DeviceStatusDenied:
+		return p.Interval, ErrAccessDenied
+	case !p.LastPolledAt.IsZero() && now.Sub(p.LastPolledAt) < p.Interval:
+		return p.Interval + DeviceSlowDownStep, ErrSlowDown
+	case p.Status == DeviceStatusApproved:
+		return p.Interval, nil
+	}
+	return p.Interval, ErrAuthorizationPending
+}
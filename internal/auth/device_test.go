@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestGenerateUserCode(t *testing.T) {
+	pattern := regexp.MustCompile(`^[BCDFGHJKLMNPQRSTVWXZ]{4}-[BCDFGHJKLMNPQRSTVWXZ]{4}$`)
+	seen := map[string]bool{}
+	for range 100 {
+		code, err := GenerateUserCode()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !pattern.MatchString(code) {
+			t.Fatalf("unexpected user code format %q", code)
+		}
+		seen[code] = true
+	}
+	if len(seen) < 95 {
+		t.Errorf("expected unique user codes, got %d distinct of 100", len(seen))
+	}
+}
+
+func TestGenerateDeviceCode(t *testing.T) {
+	a, err := GenerateDeviceCode()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, _ := GenerateDeviceCode()
+	if len(a) != 64 || a == b {
+		t.Errorf("expected unique 64 character codes, got %q and %q", a, b)
+	}
+}
+
+func TestNormalizeUserCode(t *testing.T) {
+	tests := map[string]string{
+		"BDWP-HQTN":  "BDWP-HQTN",
+		"bdwp hqtn":  "BDWP-HQTN",
+		" bdwphqtn ": "BDWP-HQTN",
+		"BDW":        "BDW",
+		"":           "",
+	}
+
+	for input, want := range tests {
+		if got := NormalizeUserCode(input); got != want {
+			t.Errorf("NormalizeUserCode(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestCheckDevicePoll(t *testing.T) {
+	now := time.Now()
+	base := DevicePoll{
+		Status:    DeviceStatusPending,
+		ExpiresAt: now.Add(5 * time.Minute),
+		Interval:  DevicePollInterval,
+	}
+
+	tests := []struct {
+		name         string
+		modify       func(p *DevicePoll)
+		wantErr      error
+		wantInterval time.Duration
+	}{
+		{"first poll", func(p *DevicePoll) {}, ErrAuthorizationPending, DevicePollInterval},
+		{"polite poll", func(p *DevicePoll) { p.LastPolledAt = now.Add(-6 * time.Second) }, ErrAuthorizationPending, DevicePollInterval},
+		{"too fast", func(p *DevicePoll) { p.LastPolledAt = now.Add(-time.Second) }, ErrSlowDown, DevicePollInterval + DeviceSlowDownStep},
+		{"approved", func(p *DevicePoll) { p.Status = DeviceStatusApproved }, nil, DevicePollInterval},
+		{"denied", func(p *DevicePoll) { p.Status = DeviceStatusDenied }, ErrAccessDenied, DevicePollInterval},
+		{"expired", func(p *DevicePoll) { p.ExpiresAt = now.Add(-time.Second) }, ErrExpiredToken, DevicePollInterval},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poll := base
+			tt.modify(&poll)
+			interval, err := CheckDevicePoll(poll, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckDevicePoll() error = %v, want %v", err, tt.wantErr)
+			}
+			if interval != tt.wantInterval {
+				t.Errorf("CheckDevicePoll() interval = %v, want %v", interval, tt.wantInterval)
+			}
+		})
+	}
+}
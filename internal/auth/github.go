@@ -18,6 +18,8 @@ type GitHubUser struct {
 	Email     string `json:"email"`
 	AvatarURL string `json:"avatar_url"`
 	Name      string `json:"name"`
+	// TwoFactorAuthentication is only reported to the user themselves
+	TwoFactorAuthentication *bool `json:"two_factor_authentication"`
 }
 
 // GitHubClient handles GitHub OAuth2 authentication.
@@ -496,3 +496,22 @@ func TestGitHubUser_JSON(t *testing.T) {
 		t.Errorf("Email mismatch after JSON round-trip")
 	}
 }
+
+func TestGitHubUser_TwoFactor(t *testing.T) {
+	var user GitHubUser
+	if err := json.Unmarshal([]byte(`{"id": 1, "login": "octocat", "two_factor_authentication": true}`), &user); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if user.TwoFactorAuthentication == nil || !*user.TwoFactorAuthentication {
+		t.Errorf("expected two-factor authentication to be enabled, got %v", user.TwoFactorAuthentication)
+	}
+
+	// Profiles of other users leave it out
+	user = GitHubUser{}
+	if err := json.Unmarshal([]byte(`{"id": 1, "login": "octocat"}`), &user); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if user.TwoFactorAuthentication != nil {
+		t.Errorf("expected an unknown two-factor status, got %v", *user.TwoFactorAuthentication)
+	}
+}
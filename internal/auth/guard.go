@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// Guard tracks failed authentication attempts per key (client IP or user)
+// and slows down repeated failures. After a few free attempts every failure
+// doubles the wait before the next attempt is accepted, and reaching the
+// threshold locks the key out entirely.
+type Guard struct {
+	mu         sync.Mutex
+	attempts   map[string]*attempt
+	freeTries  int
+	threshold  int
+	baseDelay  time.Duration
+	lockout    time.Duration
+	forgetTime time.Duration
+	now        func() time.Time
+}
+
+type attempt struct {
+	failures     int
+	blockedUntil time.Time
+	lastFailure  time.Time
+}
+
+// GuardResult describes the state of a key after a failure
+type GuardResult struct {
+	Failures   int
+	RetryAfter time.Duration
+	// Locked is true when this failure locked the key out
+	Locked bool
+}
+
+// NewGuard creates a guard. Keys are locked out for lockout after threshold
+// consecutive failures; failures are forgotten an hour after the last one.
+func NewGuard(freeTries, threshold int, baseDelay, lockout time.Duration) *Guard {
+	g := &Guard{
+		attempts:   make(map[string]*attempt),
+		freeTries:  freeTries,
+		threshold:  threshold,
+		baseDelay:  baseDelay,
+		lockout:    lockout,
+		forgetTime: time.Hour,
+		now:        time.Now,
+	}
+	go g.cleanupLoop()
+	return g
+}
+
+// DefaultGuard protects the auth endpoints and the auth middleware: three
+// free attempts, then 1s, 2s, 4s, ... and a 15 minute lockout at ten failures.
+var DefaultGuard = NewGuard(3, 10, time.Second, 15*time.Minute)
+
+// Blocked returns how long the key must wait before its next attempt
+func (g *Guard) Blocked(key string) time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	a, ok := g.attempts[key]
+	if !ok {
+		return 0
+	}
+	if wait := a.blockedUntil.Sub(g.now()); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// Fail records a failed attempt
+func (g *Guard) Fail(key string) GuardResult {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	a, ok := g.attempts[key]
+	if !ok || now.Sub(a.lastFailure) > g.forgetTime {
+		a = &attempt{}
+		g.attempts[key] = a
+	}
+
+	a.failures++
+	a.lastFailure = now
+
+	result := GuardResult{Failures: a.failures}
+	switch {
+	case a.failures >= g.threshold:
+		result.RetryAfter = g.lockout
+		result.Locked = true
+	case a.failures > g.freeTries:
+		result.RetryAfter = g.baseDelay << (a.failures - g.freeTries - 1)
+	}
+	if result.RetryAfter > 0 {
+		a.blockedUntil = now.Add(result.RetryAfter)
+	}
+
+	return result
+}
+
+// Succeed clears the failures of a key
+func (g *Guard) Succeed(key string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.attempts, key)
+}
+
+func (g *Guard) cleanupLoop() {
+	ticker := time.NewTicker(time.Minute)
+	for range ticker.C {
+		g.cleanup()
+	}
+}
+
+// cleanup forgets keys that have not failed recently and are not blocked
+func (g *Guard) cleanup() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	for key, a := range g.attempts {
+		if now.Sub(a.lastFailure) > g.forgetTime && now.After(a.blockedUntil) {
+			delete(g.attempts, key)
+		}
+	}
+}
+
+// IPKey is the guard key of a client address
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// UserKey is the guard key of an account
+func UserKey(userID string) string {
+	return "user:" + userID
+}
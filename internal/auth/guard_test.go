@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestGuard() (*Guard, *time.Time) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	g := NewGuard(2, 5, time.Second, 15*time.Minute)
+	g.now = func() time.Time { return now }
+	return g, &now
+}
+
+func TestGuard_ProgressiveDelay(t *testing.T) {
+	g, _ := newTestGuard()
+	key := IPKey("203.0.113.5")
+
+	expected := []time.Duration{0, 0, time.Second, 2 * time.Second}
+	for i, delay := range expected {
+		result := g.Fail(key)
+		if result.RetryAfter != delay {
+			t.Errorf("failure %d: expected delay %v, got %v", i+1, delay, result.RetryAfter)
+		}
+		if result.Locked {
+			t.Errorf("failure %d: expected no lockout", i+1)
+		}
+	}
+
+	if wait := g.Blocked(key); wait != 2*time.Second {
+		t.Errorf("expected to be blocked for 2s, got %v", wait)
+	}
+}
+
+func TestGuard_Lockout(t *testing.T) {
+	g, now := newTestGuard()
+	key := UserKey("user-1")
+
+	var result GuardResult
+	for i := 0; i < 5; i++ {
+		result = g.Fail(key)
+	}
+
+	if !result.Locked || result.RetryAfter != 15*time.Minute {
+		t.Fatalf("expected a 15 minute lockout, got %+v", result)
+	}
+
+	*now = now.Add(14 * time.Minute)
+	if g.Blocked(key) == 0 {
+		t.Error("expected key to still be locked out")
+	}
+
+	*now = now.Add(2 * time.Minute)
+	if wait := g.Blocked(key); wait != 0 {
+		t.Errorf("expected lockout to expire, got %v", wait)
+	}
+}
+
+func TestGuard_SucceedResets(t *testing.T) {
+	g, _ := newTestGuard()
+	key := IPKey("203.0.113.5")
+
+	g.Fail(key)
+	g.Fail(key)
+	g.Succeed(key)
+
+	if result := g.Fail(key); result.Failures != 1 || result.RetryAfter != 0 {
+		t.Errorf("expected failures to be reset, got %+v", result)
+	}
+}
+
+func TestGuard_ForgetsOldFailures(t *testing.T) {
+	g, now := newTestGuard()
+	key := IPKey("203.0.113.5")
+
+	g.Fail(key)
+	g.Fail(key)
+	*now = now.Add(2 * time.Hour)
+
+	if result := g.Fail(key); result.Failures != 1 {
+		t.Errorf("expected old failures to be forgotten, got %d", result.Failures)
+	}
+
+	*now = now.Add(2 * time.Hour)
+	g.cleanup()
+	if _, ok := g.attempts[key]; ok {
+		t.Error("expected idle key to be cleaned up")
+	}
+}
+
+func TestNormalizeIP(t *testing.T) {
+	if ip := normalizeIP("203.0.113.5:51234"); ip != "203.0.113.5" {
+		t.Errorf("expected port to be stripped, got %q", ip)
+	}
+	if ip := normalizeIP("[2001:db8::1]:443"); ip != "2001:db8::1" {
+		t.Errorf("expected port to be stripped, got %q", ip)
+	}
+	if ip := normalizeIP("203.0.113.5"); ip != "203.0.113.5" {
+		t.Errorf("expected address to be unchanged, got %q", ip)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"time"
 
@@ -15,6 +16,8 @@ import (
 type Claims struct {
 	UserID   uuid.UUID `json:"user_id"`
 	Username string    `json:"username"`
+	// App scopes a deploy token to the API of one of the user's apps
+	App string `json:"app,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -72,8 +75,61 @@ func GenerateTokenPair(userID uuid.UUID, username, secret string) (*TokenPair, e
 	}, nil
 }
 
+// GenerateAppToken creates a deploy token acting as the user on one of
+// their apps only, for CI runs exchanging an OIDC token. It cannot be
+// refreshed and expires after ttl.
+func GenerateAppToken(userID uuid.UUID, username, app, secret string, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiry := now.Add(ttl)
+	claims := Claims{
+		UserID:   userID,
+		Username: username,
+		App:      app,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiry),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    "nexo-cloud",
+			Subject:   userID.String(),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign app token: %w", err)
+	}
+	return token, expiry, nil
+}
+
+// ErrAppToken is returned by ValidateToken for deploy tokens scoped to an
+// app, which only the API of that app accepts
+var ErrAppToken = errors.New("token is scoped to an app")
+
 // ValidateToken validates a JWT token and returns its claims.
 func ValidateToken(tokenString, secret string) (*Claims, error) {
+	claims, err := parseToken(tokenString, secret)
+	if err != nil {
+		return nil, err
+	}
+	if claims.App != "" {
+		return nil, ErrAppToken
+	}
+	return claims, nil
+}
+
+// ValidateAppToken validates a deploy token scoped to an app and returns its
+// claims. Callers check the request is for claims.App with AppScopeAllows.
+func ValidateAppToken(tokenString, secret string) (*Claims, error) {
+	claims, err := parseToken(tokenString, secret)
+	if err != nil {
+		return nil, err
+	}
+	if claims.App == "" {
+		return nil, fmt.Errorf("invalid token")
+	}
+	return claims, nil
+}
+
+func parseToken(tokenString, secret string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -93,6 +149,16 @@ func ValidateToken(tokenString, secret string) (*Claims, error) {
 	return claims, nil
 }
 
+// SessionRevoked reports whether a token was issued before the user's
+// sessions were revoked. JWT timestamps have second precision, so tokens
+// issued within the revocation second are revoked too.
+func SessionRevoked(claims *Claims, revokedAt time.Time) bool {
+	if claims.IssuedAt == nil {
+		return true
+	}
+	return !claims.IssuedAt.After(revokedAt.Truncate(time.Second))
+}
+
 // GenerateAPIToken generates a random API token.
 func GenerateAPIToken() (string, error) {
 	bytes := make([]byte, 32)
@@ -102,6 +168,16 @@ func GenerateAPIToken() (string, error) {
 	return "fgt_" + hex.EncodeToString(bytes), nil
 }
 
+// GenerateSCIMToken generates the bearer token an organization's identity
+// provider uses for SCIM provisioning.
+func GenerateSCIMToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return "scim_" + hex.EncodeToString(bytes), nil
+}
+
 // GenerateState generates a random OAuth2 state parameter.
 func GenerateState() (string, error) {
 	bytes := make([]byte, 16)
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"errors"
 	"strings"
 	"testing"
 	"time"
@@ -124,6 +125,36 @@ func TestValidateToken_EmptyToken(t *testing.T) {
 	}
 }
 
+func TestGenerateAppToken(t *testing.T) {
+	userID := uuid.New()
+	secret := "test-secret-key-for-jwt"
+
+	token, expiresAt, err := GenerateAppToken(userID, "testuser", "shop", secret, 15*time.Minute)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if expiresAt.After(time.Now().Add(16 * time.Minute)) {
+		t.Error("expected the token to expire within 16 minutes")
+	}
+
+	if _, err := ValidateToken(token, secret); !errors.Is(err, ErrAppToken) {
+		t.Errorf("expected an app token to be rejected as a session, got %v", err)
+	}
+
+	claims, err := ValidateAppToken(token, secret)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if claims.UserID != userID || claims.App != "shop" {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+
+	tokens, _ := GenerateTokenPair(userID, "testuser", secret)
+	if _, err := ValidateAppToken(tokens.AccessToken, secret); err == nil {
+		t.Error("expected a session token not to be an app token")
+	}
+}
+
 func TestGenerateAPIToken(t *testing.T) {
 	token, err := GenerateAPIToken()
 	if err != nil {
@@ -144,6 +175,35 @@ func TestGenerateAPIToken(t *testing.T) {
 	}
 }
 
+func TestGenerateSCIMToken(t *testing.T) {
+	token, err := GenerateSCIMToken()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !strings.HasPrefix(token, "scim_") || len(token) != 69 {
+		t.Errorf("unexpected token format %q", token)
+	}
+}
+
+func TestSessionRevoked(t *testing.T) {
+	issued := time.Now().Truncate(time.Second)
+	claims := &Claims{RegisteredClaims: jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(issued)}}
+
+	if !SessionRevoked(claims, issued.Add(500*time.Millisecond)) {
+		t.Error("expected token issued in the revocation second to be revoked")
+	}
+	if !SessionRevoked(claims, issued.Add(time.Hour)) {
+		t.Error("expected token issued before revocation to be revoked")
+	}
+	if SessionRevoked(claims, issued.Add(-time.Second)) {
+		t.Error("expected token issued after revocation to be valid")
+	}
+	if !SessionRevoked(&Claims{}, issued) {
+		t.Error("expected token without iat to be revoked")
+	}
+}
+
 func TestGenerateState(t *testing.T) {
 	state, err := GenerateState()
 	if err != nil {
@@ -2,6 +2,8 @@ package auth
 
 import (
 	"context"
+	"net/http"
+	"path"
 	"strings"
 
 	"github.com/google/uuid"
@@ -58,8 +60,25 @@ func ExtractBearerToken(authHeader string) string {
 func IsPublicPath(path string) bool {
 	publicPaths := []string{
 		"/api/health",
+		"/api/status",
+		// Maintenance banners are shown before login too
+		"/api/platform/notices",
 		"/api/auth/login",
 		"/api/auth/callback",
+		// fuegoctl login starts and polls device authorizations before it has a token
+		"/api/auth/device",
+		// CI runs exchange their OIDC token for a deploy token here
+		"/api/auth/oidc",
+		// SCIM requests authenticate with the organization's SCIM token
+		"/api/scim/v2",
+		// The ingress checks client certificates of mTLS apps here
+		"/api/mtls/verify",
+		// Stripe signs its webhook events instead
+		"/api/webhooks/stripe",
+		// The directory of apps their owners made public, and their badges
+		"/api/showcase",
+		// Registry clients send their credentials with Basic auth here
+		"/api/registry/auth",
 	}
 
 	for _, p := range publicPaths {
@@ -69,3 +88,29 @@ func IsPublicPath(path string) bool {
 	}
 	return false
 }
+
+// appScopePaths are what a token scoped to an app may call under
+// /api/apps/{name}, by method: deploying and following the deployments, the
+// app's status and its logs. Paths use * to match one segment.
+var appScopePaths = map[string][]string{
+	http.MethodGet:  {"", "deployments", "deployments/*", "logs", "logs/download"},
+	http.MethodHead: {"", "deployments", "deployments/*", "logs", "logs/download"},
+	http.MethodPost: {"deployments", "deployments/*"},
+}
+
+// AppScopeAllows reports whether a token scoped to app may make a request
+// with method to requestPath, which must be one of the app's deploy, status
+// or log routes
+func AppScopeAllows(method, requestPath, app string) bool {
+	prefix := "/api/apps/" + app
+	if requestPath != prefix && !strings.HasPrefix(requestPath, prefix+"/") {
+		return false
+	}
+	rest := strings.Trim(strings.TrimPrefix(requestPath, prefix), "/")
+	for _, pattern := range appScopePaths[method] {
+		if matched, _ := path.Match(pattern, rest); matched {
+			return true
+		}
+	}
+	return false
+}
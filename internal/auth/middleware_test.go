@@ -2,6 +2,7 @@ package auth
 
 import (
 	"context"
+	"net/http"
 	"testing"
 
 	"github.com/google/uuid"
@@ -230,6 +231,21 @@ func TestIsPublicPath_HealthSubPath(t *testing.T) {
 	}
 }
 
+func TestIsPublicPath_StatusEndpoint(t *testing.T) {
+	if !IsPublicPath("/api/status") {
+		t.Error("expected /api/status to be public")
+	}
+}
+
+func TestIsPublicPath_PlatformNotices(t *testing.T) {
+	if !IsPublicPath("/api/platform/notices") {
+		t.Error("expected /api/platform/notices to be public")
+	}
+	if IsPublicPath("/api/platform") {
+		t.Error("expected /api/platform not to be public")
+	}
+}
+
 func TestIsPublicPath_AuthLogin(t *testing.T) {
 	if !IsPublicPath("/api/auth/login") {
 		t.Error("expected /api/auth/login to be public")
@@ -248,6 +264,56 @@ func TestIsPublicPath_AuthCallbackSubPath(t *testing.T) {
 	}
 }
 
+func TestIsPublicPath_DeviceAuthorization(t *testing.T) {
+	for _, path := range []string{"/api/auth/device", "/api/auth/device/token"} {
+		if !IsPublicPath(path) {
+			t.Errorf("expected %s to be public", path)
+		}
+	}
+	if IsPublicPath("/api/users/me/devices") {
+		t.Error("expected device approval to require authentication")
+	}
+}
+
+func TestIsPublicPath_SCIM(t *testing.T) {
+	if !IsPublicPath("/api/scim/v2/users") {
+		t.Error("expected /api/scim/v2/users to bypass session auth")
+	}
+}
+
+func TestIsPublicPath_MTLSVerify(t *testing.T) {
+	if !IsPublicPath("/api/mtls/verify") {
+		t.Error("expected /api/mtls/verify to be public")
+	}
+}
+
+func TestIsPublicPath_StripeWebhook(t *testing.T) {
+	if !IsPublicPath("/api/webhooks/stripe") {
+		t.Error("expected Stripe webhooks to bypass session auth")
+	}
+}
+
+func TestIsPublicPath_Showcase(t *testing.T) {
+	for _, path := range []string{"/api/showcase", "/api/showcase/0b7c3c6e-6f1e-4a8e-9a57-3f1f1d0c2a11/badge"} {
+		if !IsPublicPath(path) {
+			t.Errorf("expected %s to be public", path)
+		}
+	}
+	if IsPublicPath("/api/showcases") {
+		t.Error("expected /api/showcases not to be public")
+	}
+}
+
+func TestIsPublicPath_RegistryAuth(t *testing.T) {
+	if !IsPublicPath("/api/registry/auth") {
+		t.Error("expected /api/registry/auth to be public")
+	}
+	// Registry tokens are still managed with a session
+	if IsPublicPath("/api/registry/token") {
+		t.Error("expected /api/registry/token not to be public")
+	}
+}
+
 func TestIsPublicPath_PrivateEndpoints(t *testing.T) {
 	privateEndpoints := []string{
 		"/api/apps",
@@ -315,3 +381,43 @@ func TestContextKey_String(t *testing.T) {
 		t.Errorf("expected ClaimsKey to be 'claims', got %q", string(ClaimsKey))
 	}
 }
+
+func TestAppScopeAllows(t *testing.T) {
+	tests := []struct {
+		method  string
+		path    string
+		allowed bool
+	}{
+		{http.MethodGet, "/api/apps/shop", true},
+		{http.MethodGet, "/api/apps/shop/", true},
+		{http.MethodGet, "/api/apps/shop/deployments", true},
+		{http.MethodPost, "/api/apps/shop/deployments", true},
+		{http.MethodGet, "/api/apps/shop/deployments/123", true},
+		{http.MethodPost, "/api/apps/shop/deployments/123", true},
+		{http.MethodGet, "/api/apps/shop/logs", true},
+		{http.MethodGet, "/api/apps/shop/logs/download", true},
+		{http.MethodDelete, "/api/apps/shop", false},
+		{http.MethodPut, "/api/apps/shop", false},
+		{http.MethodGet, "/api/apps/shop/env", false},
+		{http.MethodPut, "/api/apps/shop/env", false},
+		{http.MethodPost, "/api/apps/shop/collaborators", false},
+		{http.MethodPost, "/api/apps/shop/oidc/trusts", false},
+		{http.MethodDelete, "/api/apps/shop/deployments/123", false},
+		{http.MethodGet, "/api/apps/shop/deployments/123/lockfile/verify", false},
+		{http.MethodGet, "/api/apps/shopping/deployments", false},
+		{http.MethodGet, "/api/apps", false},
+		{http.MethodGet, "/api/tokens", false},
+	}
+
+	for _, tt := range tests {
+		if got := AppScopeAllows(tt.method, tt.path, "shop"); got != tt.allowed {
+			t.Errorf("AppScopeAllows(%s %q) = %v, want %v", tt.method, tt.path, got, tt.allowed)
+		}
+	}
+}
+
+func TestIsPublicPath_OIDCExchange(t *testing.T) {
+	if !IsPublicPath("/api/auth/oidc") {
+		t.Error("expected /api/auth/oidc to be public")
+	}
+}
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"net/netip"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/events"
+	"github.com/google/uuid"
+)
+
+// Security events, published to the event bus and recorded in the audit log
+const (
+	EventAuthFailed = "security.auth_failed"
+	EventLockout    = "security.lockout"
+	EventLogin      = "security.login"
+)
+
+// RecordFailure registers a failed authentication against the client IP and,
+// when the credential identified one, the user. Every failure and lockout is
+// published as a security event. queries may be nil when the
+// database is unavailable. It returns the longest wait imposed.
+func RecordFailure(ctx context.Context, queries *db.Queries, ip string, userID uuid.UUID, reason string) time.Duration {
+	ip = normalizeIP(ip)
+	keys := []string{IPKey(ip)}
+	if userID != uuid.Nil {
+		keys = append(keys, UserKey(userID.String()))
+	}
+
+	var retryAfter time.Duration
+	for _, key := range keys {
+		result := DefaultGuard.Fail(key)
+		retryAfter = max(retryAfter, result.RetryAfter)
+
+		logSecurityEvent(ctx, queries, EventAuthFailed, ip, userID, map[string]any{
+			"key":      key,
+			"reason":   reason,
+			"failures": result.Failures,
+		})
+		if result.Locked {
+			slog.Warn("authentication lockout", "key", key, "failures", result.Failures, "reason", reason)
+			logSecurityEvent(ctx, queries, EventLockout, ip, userID, map[string]any{
+				"key":           key,
+				"reason":        reason,
+				"failures":      result.Failures,
+				"locked_until":  time.Now().Add(result.RetryAfter),
+				"retry_seconds": int(result.RetryAfter.Seconds()),
+			})
+		}
+	}
+
+	return retryAfter
+}
+
+// Blocked returns how long the client IP, or the user when known, must wait
+// before authenticating again
+func Blocked(ip string, userID uuid.UUID) time.Duration {
+	wait := DefaultGuard.Blocked(IPKey(normalizeIP(ip)))
+	if userID != uuid.Nil {
+		wait = max(wait, DefaultGuard.Blocked(UserKey(userID.String())))
+	}
+	return wait
+}
+
+// RecordSuccess clears the failures of the client IP and the user
+func RecordSuccess(ip string, userID uuid.UUID) {
+	DefaultGuard.Succeed(IPKey(normalizeIP(ip)))
+	if userID != uuid.Nil {
+		DefaultGuard.Succeed(UserKey(userID.String()))
+	}
+}
+
+// RecordLogin publishes a successful login as a security event, so the
+// activity log holds who signed in from where
+func RecordLogin(ctx context.Context, queries *db.Queries, ip string, userID uuid.UUID, method string) {
+	logSecurityEvent(ctx, queries, EventLogin, normalizeIP(ip), userID, map[string]any{
+		"method": method,
+	})
+}
+
+func logSecurityEvent(ctx context.Context, queries *db.Queries, action, ip string, userID uuid.UUID, details map[string]any) {
+	if queries == nil {
+		return
+	}
+
+	event := events.Event{
+		Type:    action,
+		UserID:  userID,
+		Payload: details,
+	}
+	if addr, err := netip.ParseAddr(ip); err == nil {
+		event.IP = &addr
+	}
+
+	if err := events.Publish(ctx, queries, event); err != nil {
+		slog.Warn("failed to publish security event", "action", action, "error", err)
+	}
+}
+
+// normalizeIP strips the port RemoteAddr carries so every connection from a
+// client shares one key
+func normalizeIP(ip string) string {
+	if addrPort, err := netip.ParseAddrPort(ip); err == nil {
+		return addrPort.Addr().String()
+	}
+	return ip
+}
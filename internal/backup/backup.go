@@ -0,0 +1,291 @@
+// Package backup takes disaster-recovery snapshots of the platform: every
+// control-plane table and the resources of every managed namespace in each
+// region. Snapshots are encrypted with ENCRYPTION_KEY, since they hold app
+// secrets, and written to object storage on a schedule. cmd/nexo-restore
+// rebuilds the database and tenant namespaces from them.
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/cryptoutil"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// FormatVersion is bumped when the snapshot layout changes
+	FormatVersion = 1
+	// LatestKey holds the key of the most recent snapshot
+	LatestKey = "snapshots/LATEST"
+
+	snapshotPrefix = "snapshots/"
+	snapshotSuffix = ".tar.gz.enc"
+	archiveRoot    = "snapshot"
+	manifestFile   = "manifest.json"
+)
+
+// Manifest describes the contents of a snapshot
+type Manifest struct {
+	Version       int       `json:"version"`
+	CreatedAt     time.Time `json:"created_at"`
+	SchemaVersion int64     `json:"schema_version"`
+	// Tables are listed in restore order
+	Tables     []TableManifest     `json:"tables"`
+	Namespaces []NamespaceManifest `json:"namespaces"`
+	// RegionErrors lists regions whose cluster could not be exported; the
+	// rest of the snapshot is still usable
+	RegionErrors map[string]string `json:"region_errors,omitempty"`
+}
+
+// TableManifest points to a table's CSV dump
+type TableManifest struct {
+	Name string `json:"name"`
+	Rows int64  `json:"rows"`
+	File string `json:"file"`
+}
+
+// NamespaceManifest points to a namespace's resources
+type NamespaceManifest struct {
+	Region    string `json:"region"`
+	Name      string `json:"name"`
+	Resources int    `json:"resources"`
+	File      string `json:"file"`
+}
+
+// Snapshot is a decoded snapshot archive
+type Snapshot struct {
+	Manifest Manifest
+	Files    map[string][]byte
+}
+
+// Seal archives and encrypts a snapshot
+func (s *Snapshot) Seal(encryptionKey string) ([]byte, error) {
+	manifest, err := json.MarshalIndent(s.Manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte, len(s.Files)+1)
+	for name, data := range s.Files {
+		files[name] = data
+	}
+	files[manifestFile] = manifest
+
+	var buf bytes.Buffer
+	if err := k8s.WriteArchive(&buf, archiveRoot, files); err != nil {
+		return nil, fmt.Errorf("failed to archive snapshot: %w", err)
+	}
+	return cryptoutil.EncryptBytes(buf.Bytes(), encryptionKey)
+}
+
+// Open decrypts and unpacks a sealed snapshot
+func Open(data []byte, encryptionKey string) (*Snapshot, error) {
+	archive, err := cryptoutil.DecryptBytes(data, encryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt snapshot: %w", err)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot archive: %w", err)
+	}
+	tr := tar.NewReader(gz)
+
+	snapshot := &Snapshot{Files: map[string][]byte{}}
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid snapshot archive: %w", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid snapshot archive: %w", err)
+		}
+		snapshot.Files[strings.TrimPrefix(header.Name, archiveRoot+"/")] = data
+	}
+
+	manifest, ok := snapshot.Files[manifestFile]
+	if !ok {
+		return nil, errors.New("snapshot has no manifest")
+	}
+	delete(snapshot.Files, manifestFile)
+	if err := json.Unmarshal(manifest, &snapshot.Manifest); err != nil {
+		return nil, fmt.Errorf("invalid snapshot manifest: %w", err)
+	}
+	if snapshot.Manifest.Version > FormatVersion {
+		return nil, fmt.Errorf("snapshot format %d is newer than supported %d", snapshot.Manifest.Version, FormatVersion)
+	}
+
+	return snapshot, nil
+}
+
+// Namespace decodes the resources of a namespace in the snapshot
+func (s *Snapshot) Namespace(ns NamespaceManifest) (k8s.NamespaceBackup, error) {
+	var backup k8s.NamespaceBackup
+	data, ok := s.Files[ns.File]
+	if !ok {
+		return backup, fmt.Errorf("snapshot is missing %s", ns.File)
+	}
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return backup, fmt.Errorf("invalid %s: %w", ns.File, err)
+	}
+	return backup, nil
+}
+
+// Snapshotter writes snapshots to a store
+type Snapshotter struct {
+	pool    *pgxpool.Pool
+	cfg     *config.Config
+	clients func(kubeconfig string) (k8s.Interface, error)
+	store   Store
+	now     func() time.Time
+}
+
+// New creates a Snapshotter connecting to clusters with clients
+func New(pool *pgxpool.Pool, cfg *config.Config, clients func(kubeconfig string) (k8s.Interface, error), store Store) *Snapshotter {
+	return &Snapshotter{
+		pool:    pool,
+		cfg:     cfg,
+		clients: clients,
+		store:   store,
+		now:     time.Now,
+	}
+}
+
+// Take takes a scheduled snapshot and logs where it was written
+func (s *Snapshotter) Take(ctx context.Context) error {
+	key, manifest, err := s.Snapshot(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to take disaster-recovery snapshot: %w", err)
+	}
+	slog.Info("disaster-recovery snapshot written", "key", key, "tables", len(manifest.Tables), "namespaces", len(manifest.Namespaces))
+	return nil
+}
+
+// Snapshot takes a snapshot, uploads it and points LatestKey at it
+func (s *Snapshotter) Snapshot(ctx context.Context) (string, *Manifest, error) {
+	if len(s.cfg.EncryptionKey) != 32 {
+		return "", nil, errors.New("ENCRYPTION_KEY must be set to a 32-byte key to encrypt snapshots")
+	}
+
+	createdAt := s.now().UTC()
+	snapshot := &Snapshot{
+		Manifest: Manifest{Version: FormatVersion, CreatedAt: createdAt},
+		Files:    map[string][]byte{},
+	}
+
+	if err := s.dumpDatabase(ctx, snapshot); err != nil {
+		return "", nil, err
+	}
+	s.exportClusters(ctx, snapshot)
+
+	sealed, err := snapshot.Seal(s.cfg.EncryptionKey)
+	if err != nil {
+		return "", nil, err
+	}
+
+	key := snapshotPrefix + createdAt.Format("20060102T150405Z") + snapshotSuffix
+	if err := s.store.Put(ctx, key, sealed); err != nil {
+		return "", nil, fmt.Errorf("failed to upload snapshot: %w", err)
+	}
+	if err := s.store.Put(ctx, LatestKey, []byte(key)); err != nil {
+		return "", nil, fmt.Errorf("failed to update latest snapshot: %w", err)
+	}
+
+	return key, &snapshot.Manifest, nil
+}
+
+// dumpDatabase copies every table inside one read-only transaction so the
+// tables are consistent with each other
+func (s *Snapshotter) dumpDatabase(ctx context.Context, snapshot *Snapshot) error {
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return fmt.Errorf("failed to start snapshot transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	snapshot.Manifest.SchemaVersion, err = schemaVersion(ctx, tx)
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	tables, err := listTables(ctx, tx)
+	if err != nil {
+		return fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	for i, table := range tables {
+		data, rows, err := dumpTable(ctx, tx, table)
+		if err != nil {
+			return err
+		}
+		file := fmt.Sprintf("db/%03d-%s.csv", i, table)
+		snapshot.Files[file] = data
+		snapshot.Manifest.Tables = append(snapshot.Manifest.Tables, TableManifest{Name: table, Rows: rows, File: file})
+	}
+	return nil
+}
+
+// exportClusters exports the managed namespaces of every region. Regions
+// sharing a cluster are exported once, under the first of them.
+func (s *Snapshotter) exportClusters(ctx context.Context, snapshot *Snapshot) {
+	exported := map[string]bool{}
+	for _, region := range s.cfg.Regions {
+		kubeconfig := s.cfg.KubeconfigForRegion(region)
+		if exported[kubeconfig] {
+			continue
+		}
+		exported[kubeconfig] = true
+
+		if err := s.exportCluster(ctx, snapshot, region, kubeconfig); err != nil {
+			slog.Warn("failed to export cluster for snapshot", "region", region, "error", err)
+			if snapshot.Manifest.RegionErrors == nil {
+				snapshot.Manifest.RegionErrors = map[string]string{}
+			}
+			snapshot.Manifest.RegionErrors[region] = err.Error()
+		}
+	}
+}
+
+func (s *Snapshotter) exportCluster(ctx context.Context, snapshot *Snapshot, region, kubeconfig string) error {
+	client, err := s.clients(kubeconfig)
+	if err != nil {
+		return err
+	}
+	namespaces, err := client.ExportNamespaces(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, ns := range namespaces {
+		data, err := json.Marshal(ns)
+		if err != nil {
+			return err
+		}
+		file := "cluster/" + region + "/" + ns.Name + ".json"
+		snapshot.Files[file] = data
+		snapshot.Manifest.Namespaces = append(snapshot.Manifest.Namespaces, NamespaceManifest{
+			Region:    region,
+			Name:      ns.Name,
+			Resources: len(ns.Items),
+			File:      file,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package backup
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const testKey = "12345678901234567890123456789012"
+
+func TestSealAndOpen(t *testing.T) {
+	ns := k8s.NamespaceBackup{
+		Name:   "tenant-web",
+		Labels: map[string]string{"app.kubernetes.io/managed-by": "nexo-cloud"},
+		Items: []unstructured.Unstructured{{Object: map[string]any{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"metadata":   map[string]any{"name": "web", "namespace": "tenant-web"},
+		}}},
+	}
+	nsData, err := json.Marshal(ns)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := &Snapshot{
+		Manifest: Manifest{
+			Version:       FormatVersion,
+			CreatedAt:     time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC),
+			SchemaVersion: 20,
+			Tables:        []TableManifest{{Name: "users", Rows: 1, File: "db/000-users.csv"}},
+			Namespaces:    []NamespaceManifest{{Region: "mex", Name: "tenant-web", Resources: 1, File: "cluster/mex/tenant-web.json"}},
+		},
+		Files: map[string][]byte{
+			"db/000-users.csv":            []byte("id,username\n1,octocat\n"),
+			"cluster/mex/tenant-web.json": nsData,
+		},
+	}
+
+	sealed, err := snapshot.Seal(testKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	opened, err := Open(sealed, testKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(opened.Manifest, snapshot.Manifest) {
+		t.Errorf("manifest changed: %+v", opened.Manifest)
+	}
+	if string(opened.Files["db/000-users.csv"]) != "id,username\n1,octocat\n" {
+		t.Errorf("unexpected table dump %q", opened.Files["db/000-users.csv"])
+	}
+
+	restored, err := opened.Namespace(opened.Manifest.Namespaces[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if restored.Name != "tenant-web" || len(restored.Items) != 1 || restored.Items[0].GetName() != "web" {
+		t.Errorf("unexpected namespace %+v", restored)
+	}
+
+	if _, err := Open(sealed, "abcdefghijklmnopqrstuvwxyz012345"); err == nil {
+		t.Error("expected an error opening with the wrong key")
+	}
+}
+
+func TestOpen_NewerFormat(t *testing.T) {
+	sealed, err := (&Snapshot{Manifest: Manifest{Version: FormatVersion + 1}}).Seal(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(sealed, testKey); err == nil {
+		t.Error("expected an error for a newer snapshot format")
+	}
+}
+
+func TestSortTables(t *testing.T) {
+	tables := []string{"router_routes", "apps", "users", "routers", "events", "deployments"}
+	deps := map[string][]string{
+		"apps":          {"users"},
+		"deployments":   {"apps"},
+		"routers":       {"users", "routers"},
+		"router_routes": {"apps", "routers", "apps"},
+	}
+
+	got := sortTables(tables, deps)
+	want := []string{"events", "users", "apps", "deployments", "routers", "router_routes"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestSortTables_Cycle(t *testing.T) {
+	got := sortTables([]string{"b", "a", "c"}, map[string][]string{"a": {"b"}, "b": {"a"}})
+	want := []string{"c", "a", "b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
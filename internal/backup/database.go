@@ -0,0 +1,198 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// migrationsTable is maintained by golang-migrate. It is not copied: the
+// restore target is migrated to the snapshot's schema version instead.
+const migrationsTable = "schema_migrations"
+
+// schemaVersion returns the migration version of the database, or 0 when
+// it is not managed by golang-migrate
+func schemaVersion(ctx context.Context, tx pgx.Tx) (int64, error) {
+	var exists bool
+	if err := tx.QueryRow(ctx, "SELECT to_regclass('public."+migrationsTable+"') IS NOT NULL").Scan(&exists); err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, nil
+	}
+
+	var version int64
+	err := tx.QueryRow(ctx, "SELECT version FROM "+migrationsTable+" LIMIT 1").Scan(&version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	return version, err
+}
+
+// listTables returns the tables of the public schema ordered so every table
+// comes after the tables its foreign keys reference
+func listTables(ctx context.Context, tx pgx.Tx) ([]string, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT c.relname FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = 'public' AND c.relkind = 'r'`)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT c.relname, r.relname FROM pg_constraint f
+		JOIN pg_class c ON c.oid = f.conrelid
+		JOIN pg_class r ON r.oid = f.confrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE f.contype = 'f' AND n.nspname = 'public'`)
+	if err != nil {
+		return nil, err
+	}
+	deps := map[string][]string{}
+	var table, referenced string
+	_, err = pgx.ForEachRow(rows, []any{&table, &referenced}, func() error {
+		deps[table] = append(deps[table], referenced)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := tables[:0]
+	for _, t := range tables {
+		if t != migrationsTable {
+			filtered = append(filtered, t)
+		}
+	}
+	return sortTables(filtered, deps), nil
+}
+
+// sortTables orders tables so that referenced tables come first. Ties are
+// broken alphabetically so snapshots are reproducible; self references and
+// cycles are ignored.
+func sortTables(tables []string, deps map[string][]string) []string {
+	known := make(map[string]bool, len(tables))
+	for _, t := range tables {
+		known[t] = true
+	}
+
+	pending := make(map[string]int, len(tables))
+	dependents := map[string][]string{}
+	for _, t := range tables {
+		seen := map[string]bool{}
+		for _, dep := range deps[t] {
+			if dep == t || !known[dep] || seen[dep] {
+				continue
+			}
+			seen[dep] = true
+			pending[t]++
+			dependents[dep] = append(dependents[dep], t)
+		}
+	}
+
+	var ready []string
+	for _, t := range tables {
+		if pending[t] == 0 {
+			ready = append(ready, t)
+		}
+	}
+
+	sorted := make([]string, 0, len(tables))
+	done := make(map[string]bool, len(tables))
+	for len(sorted) < len(tables) {
+		if len(ready) == 0 {
+			// A foreign key cycle: emit the remaining tables alphabetically
+			var rest []string
+			for _, t := range tables {
+				if !done[t] {
+					rest = append(rest, t)
+				}
+			}
+			sort.Strings(rest)
+			return append(sorted, rest...)
+		}
+
+		sort.Strings(ready)
+		t := ready[0]
+		ready = ready[1:]
+		sorted = append(sorted, t)
+		done[t] = true
+
+		for _, dependent := range dependents[t] {
+			pending[dependent]--
+			if pending[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+	return sorted
+}
+
+// dumpTable copies a table as CSV with a header row
+func dumpTable(ctx context.Context, tx pgx.Tx, table string) ([]byte, int64, error) {
+	var buf bytes.Buffer
+	tag, err := tx.Conn().PgConn().CopyTo(ctx, &buf, "COPY "+pgx.Identifier{table}.Sanitize()+" TO STDOUT WITH (FORMAT csv, HEADER)")
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to dump %s: %w", table, err)
+	}
+	return buf.Bytes(), tag.RowsAffected(), nil
+}
+
+// loadTable copies CSV produced by dumpTable into a table. Columns are
+// matched by the header row rather than by position.
+func loadTable(ctx context.Context, tx pgx.Tx, table string, data []byte) (int64, error) {
+	header, err := csv.NewReader(bytes.NewReader(data)).Read()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s header: %w", table, err)
+	}
+	columns := make([]string, len(header))
+	for i, column := range header {
+		columns[i] = pgx.Identifier{column}.Sanitize()
+	}
+
+	sql := fmt.Sprintf("COPY %s (%s) FROM STDIN WITH (FORMAT csv, HEADER)", pgx.Identifier{table}.Sanitize(), strings.Join(columns, ", "))
+	tag, err := tx.Conn().PgConn().CopyFrom(ctx, bytes.NewReader(data), sql)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load %s: %w", table, err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// resetSequences moves serial sequences past the restored rows
+func resetSequences(ctx context.Context, tx pgx.Tx) error {
+	rows, err := tx.Query(ctx, `
+		SELECT table_name, column_name FROM information_schema.columns
+		WHERE table_schema = 'public' AND column_default LIKE 'nextval(%'`)
+	if err != nil {
+		return err
+	}
+	type serial struct{ table, column string }
+	var serials []serial
+	var s serial
+	if _, err := pgx.ForEachRow(rows, []any{&s.table, &s.column}, func() error {
+		serials = append(serials, s)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for _, s := range serials {
+		column := pgx.Identifier{s.column}.Sanitize()
+		sql := fmt.Sprintf("SELECT setval(pg_get_serial_sequence($1, $2), COALESCE(MAX(%s), 0) + 1, false) FROM %s", column, pgx.Identifier{s.table}.Sanitize())
+		if _, err := tx.Exec(ctx, sql, s.table, s.column); err != nil {
+			return fmt.Errorf("failed to reset sequence of %s.%s: %w", s.table, s.column, err)
+		}
+	}
+	return nil
+}
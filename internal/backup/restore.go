@@ -0,0 +1,107 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrDatabaseNotEmpty is returned when restoring into a database that
+// already holds platform data
+var ErrDatabaseNotEmpty = errors.New("target database is not empty")
+
+// Load fetches and opens a snapshot from the store. An empty key loads the
+// latest snapshot.
+func Load(ctx context.Context, store Store, key, encryptionKey string) (*Snapshot, string, error) {
+	if key == "" {
+		latest, err := store.Get(ctx, LatestKey)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to find the latest snapshot: %w", err)
+		}
+		key = strings.TrimSpace(string(latest))
+	}
+
+	data, err := store.Get(ctx, key)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download snapshot %s: %w", key, err)
+	}
+	snapshot, err := Open(data, encryptionKey)
+	if err != nil {
+		return nil, "", err
+	}
+	return snapshot, key, nil
+}
+
+// RestoreDatabase loads a snapshot's tables into an empty database migrated
+// to the snapshot's schema version. Everything is loaded in one transaction,
+// so a failed restore leaves the database empty.
+func RestoreDatabase(ctx context.Context, pool *pgxpool.Pool, snapshot *Snapshot) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	version, err := schemaVersion(ctx, tx)
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if version != snapshot.Manifest.SchemaVersion {
+		return fmt.Errorf("target database is at schema version %d, the snapshot needs %d: run the migrations up to %d first", version, snapshot.Manifest.SchemaVersion, snapshot.Manifest.SchemaVersion)
+	}
+
+	for _, table := range snapshot.Manifest.Tables {
+		var populated bool
+		err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM "+pgx.Identifier{table.Name}.Sanitize()+")").Scan(&populated)
+		if err != nil {
+			return fmt.Errorf("failed to inspect %s: %w", table.Name, err)
+		}
+		if populated {
+			return fmt.Errorf("%w: %s has rows", ErrDatabaseNotEmpty, table.Name)
+		}
+	}
+
+	for _, table := range snapshot.Manifest.Tables {
+		data, ok := snapshot.Files[table.File]
+		if !ok {
+			return fmt.Errorf("snapshot is missing %s", table.File)
+		}
+		rows, err := loadTable(ctx, tx, table.Name, data)
+		if err != nil {
+			return err
+		}
+		if rows != table.Rows {
+			return fmt.Errorf("loaded %d rows into %s, the snapshot has %d", rows, table.Name, table.Rows)
+		}
+	}
+
+	if err := resetSequences(ctx, tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// RestoreNamespaces recreates the namespaces a snapshot holds for a region
+// and returns how many were restored
+func RestoreNamespaces(ctx context.Context, client k8s.Interface, snapshot *Snapshot, region string) (int, error) {
+	restored := 0
+	for _, ns := range snapshot.Manifest.Namespaces {
+		if ns.Region != region {
+			continue
+		}
+		backup, err := snapshot.Namespace(ns)
+		if err != nil {
+			return restored, err
+		}
+		if err := client.RestoreNamespace(ctx, backup); err != nil {
+			return restored, err
+		}
+		restored++
+	}
+	return restored, nil
+}
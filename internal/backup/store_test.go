@@ -0,0 +1,114 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+)
+
+func TestSignV4(t *testing.T) {
+	// get-vanilla from the AWS Signature Version 4 test suite
+	req := httptest.NewRequest(http.MethodGet, "http://example.amazonaws.com/", nil)
+	req.Header = http.Header{}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	signV4(req, sha256Hex(nil), "AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "us-east-1", "service", now)
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("unexpected authorization\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	objects := map[string][]byte{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			if r.Header.Get("X-Amz-Content-Sha256") != sha256Hex(body) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			objects[r.URL.Path] = body
+		case http.MethodGet:
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(data)
+		}
+	}))
+	defer server.Close()
+
+	store, err := NewStore(&config.Config{
+		BackupURL:           "s3://dr-bucket/nexo/prod",
+		BackupS3Endpoint:    server.URL,
+		BackupS3Region:      "us-east-1",
+		BackupS3AccessKeyID: "key",
+		BackupS3SecretKey:   "secret",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := store.Put(ctx, "snapshots/LATEST", []byte("snapshots/a.tar.gz.enc")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := objects["/dr-bucket/nexo/prod/snapshots/LATEST"]; !ok {
+		t.Errorf("expected a path-style object key, got %v", objects)
+	}
+
+	data, err := store.Get(ctx, "snapshots/LATEST")
+	if err != nil || string(data) != "snapshots/a.tar.gz.enc" {
+		t.Errorf("unexpected object %q, %v", data, err)
+	}
+	if _, err := store.Get(ctx, "snapshots/missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestDirStore(t *testing.T) {
+	store, err := NewStore(&config.Config{BackupURL: (&url.URL{Scheme: "file", Path: t.TempDir()}).String()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := store.Put(ctx, "snapshots/a.tar.gz.enc", []byte("data")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := store.Get(ctx, "snapshots/a.tar.gz.enc")
+	if err != nil || string(data) != "data" {
+		t.Errorf("unexpected object %q, %v", data, err)
+	}
+	if _, err := store.Get(ctx, "snapshots/missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	// Keys cannot escape the directory
+	if _, err := store.Get(ctx, "../../etc/passwd"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the key to stay inside the store, got %v", err)
+	}
+}
+
+func TestNewStore_Invalid(t *testing.T) {
+	for _, rawURL := range []string{"ftp://host/path", "s3://bucket/prefix", "file://"} {
+		if _, err := NewStore(&config.Config{BackupURL: rawURL}); err == nil {
+			t.Errorf("expected an error for %q", rawURL)
+		}
+	}
+}
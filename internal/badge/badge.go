@@ -0,0 +1,94 @@
+// Package badge renders the flat SVG badges of apps, like those of
+// shields.io, that users embed in READMEs: an app's live status and its
+// number of deploys, and the uptime shown in the showcase. Badges are
+// public and served with cache headers, so READMEs do not hit the API on
+// every view.
+package badge
+
+import (
+	"fmt"
+	"hash/fnv"
+	"html"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+)
+
+// Colors of badges
+const (
+	ColorGreen  = "#4c1"
+	ColorYellow = "#dfb317"
+	ColorRed    = "#e05d44"
+	ColorBlue   = "#007ec6"
+	ColorGrey   = "#9f9f9f"
+)
+
+// MaxAge is how many seconds clients and CDNs may cache a badge
+const MaxAge = 60
+
+// Status returns the message and color of an app's status badge
+func Status(status db.AppStatus) (string, string) {
+	switch status {
+	case db.AppStatusRunning:
+		return string(status), ColorGreen
+	case db.AppStatusDeploying:
+		return string(status), ColorBlue
+	case db.AppStatusFailed, db.AppStatusSuspended, db.AppStatusPurged:
+		return string(status), ColorRed
+	default:
+		return string(status), ColorGrey
+	}
+}
+
+// Deploys returns the message and color of an app's deploys badge
+func Deploys(count int32) (string, string) {
+	if count == 0 {
+		return "none", ColorGrey
+	}
+	return strconv.Itoa(int(count)), ColorBlue
+}
+
+// Render renders a badge of a label and a message
+func Render(label, message, color string) []byte {
+	labelWidth := textWidth(label)
+	messageWidth := textWidth(message)
+	width := labelWidth + messageWidth
+	label, message = html.EscapeString(label), html.EscapeString(message)
+
+	return fmt.Appendf(nil, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`+
+		`<title>%s: %s</title>`+
+		`<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`+
+		`<clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>`+
+		`<g clip-path="url(#r)"><rect width="%d" height="20" fill="#555"/><rect x="%d" width="%d" height="20" fill="%s"/><rect width="%d" height="20" fill="url(#s)"/></g>`+
+		`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`+
+		`<text x="%d" y="14">%s</text><text x="%d" y="14">%s</text></g></svg>`,
+		width, label, message,
+		label, message,
+		width,
+		labelWidth, labelWidth, messageWidth, html.EscapeString(color), width,
+		labelWidth/2, label, labelWidth+messageWidth/2, message)
+}
+
+// Serve responds with a badge, cacheable for MaxAge seconds and answered
+// with 304 when the client already has it
+func Serve(c *fuego.Context, svg []byte) error {
+	h := fnv.New64a()
+	h.Write(svg)
+	etag := fmt.Sprintf(`"badge-%x"`, h.Sum64())
+
+	header := c.Response.Header()
+	header.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", MaxAge))
+	header.Set("ETag", etag)
+	if c.Header("If-None-Match") == etag {
+		c.Response.WriteHeader(304)
+		return nil
+	}
+	return c.Blob(200, "image/svg+xml", svg)
+}
+
+// textWidth approximates the width of text in the badge font, with padding
+func textWidth(s string) int {
+	return 7*utf8.RuneCountInString(s) + 10
+}
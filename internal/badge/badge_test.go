@@ -0,0 +1,52 @@
+package badge
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+)
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		status db.AppStatus
+		color  string
+	}{
+		{db.AppStatusRunning, ColorGreen},
+		{db.AppStatusDeploying, ColorBlue},
+		{db.AppStatusFailed, ColorRed},
+		{db.AppStatusSuspended, ColorRed},
+		{db.AppStatusPaused, ColorGrey},
+		{db.AppStatusStopped, ColorGrey},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			message, color := Status(tt.status)
+			if message != string(tt.status) || color != tt.color {
+				t.Errorf("got %s %s, want %s %s", message, color, tt.status, tt.color)
+			}
+		})
+	}
+}
+
+func TestDeploys(t *testing.T) {
+	if message, color := Deploys(0); message != "none" || color != ColorGrey {
+		t.Errorf("got %s %s for no deploys", message, color)
+	}
+	if message, color := Deploys(42); message != "42" || color != ColorBlue {
+		t.Errorf("got %s %s for 42 deploys", message, color)
+	}
+}
+
+func TestRender(t *testing.T) {
+	svg := string(Render("uptime", "<100%>", ColorGreen))
+	if !strings.HasPrefix(svg, "<svg ") || !strings.HasSuffix(svg, "</svg>") {
+		t.Fatalf("expected an SVG document, got %s", svg)
+	}
+	if !strings.Contains(svg, "&lt;100%&gt;") {
+		t.Errorf("expected the message escaped, got %s", svg)
+	}
+	if !strings.Contains(svg, `fill="#4c1"`) {
+		t.Errorf("expected the color, got %s", svg)
+	}
+}
@@ -0,0 +1,341 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/stripefake"
+)
+
+// invoicesJSON is a page of invoices as the Stripe API renders it
+const invoicesJSON = `{
+  "object": "list",
+  "has_more": true,
+  "data": [{
+    "id": "in_2",
+    "object": "invoice",
+    "number": "NEXO-0002",
+    "status": "open",
+    "currency": "usd",
+    "amount_due": 2900,
+    "amount_paid": 0,
+    "amount_remaining": 2900,
+    "created": 1788220800,
+    "period_start": 1785542400,
+    "period_end": 1788220800,
+    "due_date": null,
+    "hosted_invoice_url": "https://invoice.stripe.com/i/in_2",
+    "invoice_pdf": "https://pay.stripe.com/invoice/in_2/pdf",
+    "attempt_count": 2,
+    "next_payment_attempt": 1788480000,
+    "lines": {"object": "list", "has_more": false, "data": [{
+      "id": "il_1",
+      "description": "1 × Pro (at $29.00 / month)",
+      "amount": 2900,
+      "quantity": 1,
+      "price": {"id": "price_pro_monthly"},
+      "period": {"start": 1788220800, "end": 1790812800}
+    }]}
+  }, {
+    "id": "in_1",
+    "object": "invoice",
+    "number": null,
+    "status": "paid",
+    "currency": "usd",
+    "amount_due": 2900,
+    "amount_paid": 2900,
+    "amount_remaining": 0,
+    "created": 1785542400,
+    "period_start": 1782864000,
+    "period_end": 1785542400,
+    "hosted_invoice_url": null,
+    "invoice_pdf": null,
+    "attempt_count": 1,
+    "next_payment_attempt": null,
+    "lines": {"object": "list", "has_more": false, "data": [{
+      "id": "il_0",
+      "description": null,
+      "amount": 2900,
+      "quantity": null,
+      "price": null,
+      "period": {"start": 1785542400, "end": 1788220800}
+    }]}
+  }]
+}`
+
+func TestListInvoices(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sk_test_1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error": {"type": "invalid_request_error", "message": "Invalid API Key provided"}}`))
+			return
+		}
+		query := r.URL.Query()
+		if r.URL.Path != "/v1/invoices" || query.Get("customer") != "cus_1" || query.Get("limit") != "100" || query.Get("starting_after") != "in_3" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		_, _ = w.Write([]byte(invoicesJSON))
+	}))
+	defer server.Close()
+
+	client := NewClient("sk_test_1").WithBaseURL(server.URL + "/")
+	invoices, hasMore, err := client.ListInvoices(context.Background(), ListParams{Customer: "cus_1", Limit: 500, StartingAfter: "in_3"})
+	if err != nil {
+		t.Fatalf("ListInvoices failed: %v", err)
+	}
+	if !hasMore || len(invoices) != 2 {
+		t.Fatalf("expected 2 invoices and more, got %d, %v", len(invoices), hasMore)
+	}
+
+	open := invoices[0]
+	if open.Number != "NEXO-0002" || open.AmountRemaining != 2900 || open.Attempts != 2 || !open.Failed() {
+		t.Errorf("unexpected open invoice %+v", open)
+	}
+	if open.PDFURL != "https://pay.stripe.com/invoice/in_2/pdf" || open.HostedURL == "" || open.DueDate != nil {
+		t.Errorf("unexpected links %+v", open)
+	}
+	if open.NextAttempt == nil || !open.NextAttempt.Equal(time.Unix(1788480000, 0)) {
+		t.Errorf("expected the next attempt, got %v", open.NextAttempt)
+	}
+	if len(open.Lines) != 1 || open.Lines[0].Price != "price_pro_monthly" || open.Lines[0].Amount != 2900 {
+		t.Errorf("unexpected lines %+v", open.Lines)
+	}
+
+	paid := invoices[1]
+	if paid.Failed() || paid.Number != "" || paid.PDFURL != "" || paid.NextAttempt != nil {
+		t.Errorf("unexpected paid invoice %+v", paid)
+	}
+	if line := paid.Lines[0]; line.Quantity != 1 || line.Description != "" || line.Price != "" {
+		t.Errorf("expected null line fields to be defaulted, got %+v", line)
+	}
+
+	_, _, err = NewClient("sk_wrong").WithBaseURL(server.URL).ListInvoices(context.Background(), ListParams{Customer: "cus_1"})
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized || apiErr.Message != "Invalid API Key provided" {
+		t.Errorf("expected the API error, got %v", err)
+	}
+
+	if _, _, err := client.ListInvoices(context.Background(), ListParams{}); err == nil {
+		t.Error("expected a customer to be required")
+	}
+}
+
+func TestDunningState(t *testing.T) {
+	now := time.Date(2026, 9, 10, 0, 0, 0, 0, time.UTC)
+	at := func(days int) time.Time { return now.AddDate(0, 0, days) }
+	retry := at(2)
+	sooner := at(1)
+
+	if d := DunningState(nil, now); d.Status != DunningOK || d.GraceEndsAt != nil {
+		t.Errorf("expected ok without invoices, got %+v", d)
+	}
+
+	// Open invoices not yet attempted are not failures
+	pending := Invoice{ID: "in_0", Status: "open", AmountRemaining: 900, Created: at(-1)}
+	if d := DunningState([]Invoice{pending}, now); d.Status != DunningOK {
+		t.Errorf("expected ok with an unattempted invoice, got %+v", d)
+	}
+
+	failed := []Invoice{
+		pending,
+		{ID: "in_1", Status: "open", Currency: "usd", AmountRemaining: 2900, Attempts: 2, Created: at(-5), NextAttempt: &retry, HostedURL: "https://invoice.stripe.com/i/in_1"},
+		{ID: "in_2", Status: "open", Currency: "usd", AmountRemaining: 900, Attempts: 1, Created: at(-3), NextAttempt: &sooner},
+	}
+	d := DunningState(failed, now)
+	if d.Status != DunningPastDue || d.FailedInvoices != 2 || d.AmountDue != 3800 || d.Currency != "usd" {
+		t.Errorf("expected 2 failed invoices past due, got %+v", d)
+	}
+	if d.GraceEndsAt == nil || !d.GraceEndsAt.Equal(at(-5).Add(GracePeriod)) || d.PayURL != "https://invoice.stripe.com/i/in_1" {
+		t.Errorf("expected the grace period of the oldest failure, got %+v", d)
+	}
+	if d.NextAttempt == nil || !d.NextAttempt.Equal(sooner) || d.Message == "" {
+		t.Errorf("expected the soonest retry and a warning, got %+v", d)
+	}
+
+	if d := DunningState(failed, at(10)); d.Status != DunningOverdue {
+		t.Errorf("expected overdue after the grace period, got %+v", d)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	now := time.Now()
+	payload := []byte(`{"id": "evt_1"}`)
+	header := stripefake.Sign(payload, "whsec_1", now)
+
+	if err := VerifySignature(payload, header, "whsec_1", now); err != nil {
+		t.Errorf("expected a valid signature, got %v", err)
+	}
+	// Stripe sends a signature per secret while a secret is rolled
+	rolled := header + ",v1=" + strings.Repeat("0", 64)
+	if err := VerifySignature(payload, rolled, "whsec_1", now); err != nil {
+		t.Errorf("expected any matching signature to be valid, got %v", err)
+	}
+
+	for name, tc := range map[string]struct {
+		payload []byte
+		header  string
+		now     time.Time
+	}{
+		"other secret":     {payload, stripefake.Sign(payload, "whsec_2", now), now},
+		"tampered payload": {[]byte(`{"id": "evt_2"}`), header, now},
+		"replayed":         {payload, header, now.Add(SignatureTolerance + time.Second)},
+		"missing":          {payload, "", now},
+		"no signature":     {payload, "t=" + strconv.FormatInt(now.Unix(), 10), now},
+	} {
+		if err := VerifySignature(tc.payload, tc.header, "whsec_1", tc.now); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: expected an invalid signature, got %v", name, err)
+		}
+	}
+}
+
+func TestParseWebhookEvent(t *testing.T) {
+	now := time.Now()
+	event := stripefake.New(stripefake.InvoicePaymentFailed, stripefake.Subscription{Customer: "cus_1", Price: "price_pro_monthly", Amount: 2900}, now)
+	payload, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := ParseWebhookEvent(payload)
+	if err != nil {
+		t.Fatalf("ParseWebhookEvent failed: %v", err)
+	}
+	if parsed.ID != event.ID || parsed.Type != EventInvoicePaymentFailed || parsed.Customer != "cus_1" {
+		t.Errorf("unexpected event %+v", parsed)
+	}
+	if !parsed.Invoice.Failed() || parsed.Invoice.AmountRemaining != 2900 || parsed.Invoice.NextAttempt == nil || len(parsed.Invoice.Lines) != 1 {
+		t.Errorf("unexpected invoice %+v", parsed.Invoice)
+	}
+
+	checkout := stripefake.New(stripefake.CheckoutSessionCompleted, stripefake.Subscription{
+		Customer: "cus_1",
+		Price:    "price_pro_monthly",
+		Amount:   2900,
+		Metadata: map[string]string{"user_id": "user_1", "plan": "pro"},
+	}, now)
+	payload, err = json.Marshal(checkout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err = ParseWebhookEvent(payload)
+	if err != nil {
+		t.Fatalf("ParseWebhookEvent failed: %v", err)
+	}
+	if parsed.Type != EventCheckoutSessionCompleted || parsed.Customer != "cus_1" || parsed.ClientReferenceID != "user_1" || parsed.Metadata["plan"] != "pro" {
+		t.Errorf("unexpected checkout event %+v", parsed)
+	}
+
+	if _, err := ParseWebhookEvent([]byte("{")); err == nil {
+		t.Error("expected malformed events to be rejected")
+	}
+}
+
+func TestCreateCheckoutSession(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/checkout/sessions" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		for key, want := range map[string]string{
+			"mode":                              "subscription",
+			"customer":                          "cus_1",
+			"line_items[0][price]":              "price_pro_monthly",
+			"automatic_tax[enabled]":            "true",
+			"tax_id_collection[enabled]":        "true",
+			"customer_update[address]":          "auto",
+			"client_reference_id":               "user_1",
+			"metadata[plan]":                    "pro",
+			"subscription_data[metadata][plan]": "pro",
+		} {
+			if got := r.PostForm.Get(key); got != want {
+				t.Errorf("expected %s=%s, got %q", key, want, got)
+			}
+		}
+		_, _ = w.Write([]byte(`{"id": "cs_test_1", "object": "checkout.session", "url": "https://checkout.stripe.com/c/pay/cs_test_1"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("sk_test_1").WithBaseURL(server.URL)
+	session, err := client.CreateCheckoutSession(context.Background(), CheckoutParams{
+		Customer:          "cus_1",
+		Price:             "price_pro_monthly",
+		SuccessURL:        "https://cloud.nexo.build/dashboard/billing",
+		CancelURL:         "https://cloud.nexo.build/dashboard/billing",
+		ClientReferenceID: "user_1",
+		Metadata:          map[string]string{"plan": "pro"},
+	})
+	if err != nil {
+		t.Fatalf("CreateCheckoutSession failed: %v", err)
+	}
+	if session.ID != "cs_test_1" || session.URL != "https://checkout.stripe.com/c/pay/cs_test_1" {
+		t.Errorf("unexpected session %+v", session)
+	}
+
+	if _, err := client.CreateCheckoutSession(context.Background(), CheckoutParams{Customer: "cus_1"}); err == nil {
+		t.Error("expected a price to be required")
+	}
+}
+
+func TestTaxIDs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /v1/customers/cus_1/tax_ids":
+			_, _ = w.Write([]byte(`{"object": "list", "has_more": false, "data": [
+			  {"id": "txi_1", "object": "tax_id", "type": "eu_vat", "value": "DE123456789", "verification": {"status": "verified"}},
+			  {"id": "txi_2", "object": "tax_id", "type": "mx_rfc", "value": "ABC010101AB1", "verification": null}
+			]}`))
+		case "POST /v1/customers/cus_1/tax_ids":
+			if err := r.ParseForm(); err != nil {
+				t.Fatal(err)
+			}
+			if r.PostForm.Get("type") != "eu_vat" || r.PostForm.Get("value") != "FR12345678901" {
+				t.Errorf("unexpected tax ID %v", r.PostForm)
+			}
+			_, _ = w.Write([]byte(`{"id": "txi_3", "object": "tax_id", "type": "eu_vat", "value": "FR12345678901", "verification": {"status": "pending"}}`))
+		case "DELETE /v1/customers/cus_1/tax_ids/txi_1":
+			_, _ = w.Write([]byte(`{"id": "txi_1", "object": "tax_id", "deleted": true}`))
+		case "DELETE /v1/customers/cus_1/tax_ids/txi_gone":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error": {"type": "invalid_request_error", "message": "No such tax id: 'txi_gone'"}}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("sk_test_1").WithBaseURL(server.URL)
+	ctx := context.Background()
+
+	taxIDs, err := client.ListTaxIDs(ctx, "cus_1")
+	if err != nil {
+		t.Fatalf("ListTaxIDs failed: %v", err)
+	}
+	if len(taxIDs) != 2 || taxIDs[0].Status != "verified" || taxIDs[1].Status != "" || taxIDs[1].Value != "ABC010101AB1" {
+		t.Errorf("unexpected tax IDs %+v", taxIDs)
+	}
+
+	added, err := client.AddTaxID(ctx, "cus_1", "eu_vat", "FR12345678901")
+	if err != nil {
+		t.Fatalf("AddTaxID failed: %v", err)
+	}
+	if added.ID != "txi_3" || added.Status != "pending" {
+		t.Errorf("unexpected tax ID %+v", added)
+	}
+
+	if err := client.DeleteTaxID(ctx, "cus_1", "txi_1"); err != nil {
+		t.Errorf("DeleteTaxID failed: %v", err)
+	}
+	if err := client.DeleteTaxID(ctx, "cus_1", "txi_gone"); err != nil {
+		t.Errorf("expected deleted tax IDs to be ignored, got %v", err)
+	}
+}
@@ -0,0 +1,237 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// CustomerParams are the billing details of a Stripe customer that Stripe
+// Tax computes taxes from
+type CustomerParams struct {
+	Email string
+	// Name is the business name printed on invoices, if any
+	Name string
+	// Country is the ISO 3166-1 alpha-2 country of the billing address
+	Country    string
+	PostalCode string
+	// Metadata is attached to new customers, such as the user ID
+	Metadata map[string]string
+}
+
+func (p CustomerParams) form() url.Values {
+	form := url.Values{
+		"address[country]": {p.Country},
+		// Stripe Tax refuses to compute taxes for addresses it cannot
+		// locate, so they are validated when saved rather than at charge
+		"tax[validate_location]": {"immediately"},
+	}
+	if p.Email != "" {
+		form.Set("email", p.Email)
+	}
+	if p.Name != "" {
+		form.Set("name", p.Name)
+	}
+	if p.PostalCode != "" {
+		form.Set("address[postal_code]", p.PostalCode)
+	}
+	for key, value := range p.Metadata {
+		form.Set("metadata["+key+"]", value)
+	}
+	return form
+}
+
+// CreateCustomer creates a Stripe customer and returns its ID
+func (c *Client) CreateCustomer(ctx context.Context, params CustomerParams) (string, error) {
+	req, err := c.newRequest(ctx, http.MethodPost, "/v1/customers", params.form())
+	if err != nil {
+		return "", err
+	}
+	var customer struct {
+		ID string `json:"id"`
+	}
+	if err := c.do(req, "create customer", &customer); err != nil {
+		return "", err
+	}
+	return customer.ID, nil
+}
+
+// UpdateCustomer replaces the billing details of a Stripe customer
+func (c *Client) UpdateCustomer(ctx context.Context, customer string, params CustomerParams) error {
+	if customer == "" {
+		return errors.New("stripe: customer is required")
+	}
+	form := params.form()
+	// An empty name clears a business name removed from the profile
+	form.Set("name", params.Name)
+	req, err := c.newRequest(ctx, http.MethodPost, "/v1/customers/"+url.PathEscape(customer), form)
+	if err != nil {
+		return err
+	}
+	var updated struct {
+		ID string `json:"id"`
+	}
+	return c.do(req, "update customer", &updated)
+}
+
+// TaxID is a tax ID of a Stripe customer
+type TaxID struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+	// Status is Stripe's verification of the ID against the tax authority:
+	// pending, verified, unverified or unavailable
+	Status string `json:"status"`
+}
+
+// AddTaxID adds a tax ID, such as an eu_vat or mx_rfc, to a customer. Stripe
+// validates its format and verifies EU VAT numbers asynchronously.
+func (c *Client) AddTaxID(ctx context.Context, customer, taxIDType, value string) (TaxID, error) {
+	if customer == "" {
+		return TaxID{}, errors.New("stripe: customer is required")
+	}
+	form := url.Values{"type": {taxIDType}, "value": {value}}
+	req, err := c.newRequest(ctx, http.MethodPost, "/v1/customers/"+url.PathEscape(customer)+"/tax_ids", form)
+	if err != nil {
+		return TaxID{}, err
+	}
+	var taxID stripeTaxID
+	if err := c.do(req, "add tax id", &taxID); err != nil {
+		return TaxID{}, err
+	}
+	return taxID.toTaxID(), nil
+}
+
+// ListTaxIDs returns the tax IDs of a customer
+func (c *Client) ListTaxIDs(ctx context.Context, customer string) ([]TaxID, error) {
+	if customer == "" {
+		return nil, errors.New("stripe: customer is required")
+	}
+	req, err := c.newRequest(ctx, http.MethodGet, "/v1/customers/"+url.PathEscape(customer)+"/tax_ids?limit=100", nil)
+	if err != nil {
+		return nil, err
+	}
+	var list struct {
+		Data []stripeTaxID `json:"data"`
+	}
+	if err := c.do(req, "list tax ids", &list); err != nil {
+		return nil, err
+	}
+	taxIDs := make([]TaxID, len(list.Data))
+	for i, taxID := range list.Data {
+		taxIDs[i] = taxID.toTaxID()
+	}
+	return taxIDs, nil
+}
+
+// DeleteTaxID removes a tax ID from a customer. IDs already gone are not an
+// error.
+func (c *Client) DeleteTaxID(ctx context.Context, customer, id string) error {
+	req, err := c.newRequest(ctx, http.MethodDelete, "/v1/customers/"+url.PathEscape(customer)+"/tax_ids/"+url.PathEscape(id), nil)
+	if err != nil {
+		return err
+	}
+	var deleted struct {
+		ID string `json:"id"`
+	}
+	err = c.do(req, "delete tax id", &deleted)
+	var apiErr *Error
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// CheckoutParams start a subscription through Stripe Checkout
+type CheckoutParams struct {
+	Customer string
+	Price    string
+	// SuccessURL and CancelURL are where Checkout sends the customer back
+	SuccessURL string
+	CancelURL  string
+	// ClientReferenceID and Metadata come back with the
+	// checkout.session.completed event
+	ClientReferenceID string
+	Metadata          map[string]string
+}
+
+// CheckoutSession is a Stripe Checkout session
+type CheckoutSession struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+// CreateCheckoutSession starts a subscription checkout with Stripe Tax:
+// taxes are computed from the customer's billing address, which Checkout
+// requires, and customers buying as a business can enter their tax ID. The
+// subscription's invoices are taxed the same way.
+func (c *Client) CreateCheckoutSession(ctx context.Context, params CheckoutParams) (CheckoutSession, error) {
+	if params.Customer == "" || params.Price == "" {
+		return CheckoutSession{}, errors.New("stripe: customer and price are required")
+	}
+	form := url.Values{
+		"mode":                       {"subscription"},
+		"customer":                   {params.Customer},
+		"line_items[0][price]":       {params.Price},
+		"line_items[0][quantity]":    {"1"},
+		"success_url":                {params.SuccessURL},
+		"cancel_url":                 {params.CancelURL},
+		"automatic_tax[enabled]":     {"true"},
+		"tax_id_collection[enabled]": {"true"},
+		"billing_address_collection": {"required"},
+		// Details entered in Checkout are saved to the customer, as tax
+		// IDs are only collected from customers allowed to be updated
+		"customer_update[address]": {"auto"},
+		"customer_update[name]":    {"auto"},
+	}
+	if params.ClientReferenceID != "" {
+		form.Set("client_reference_id", params.ClientReferenceID)
+	}
+	for key, value := range params.Metadata {
+		form.Set("metadata["+key+"]", value)
+		form.Set("subscription_data[metadata]["+key+"]", value)
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, "/v1/checkout/sessions", form)
+	if err != nil {
+		return CheckoutSession{}, err
+	}
+	var session CheckoutSession
+	if err := c.do(req, "create checkout session", &session); err != nil {
+		return CheckoutSession{}, err
+	}
+	return session, nil
+}
+
+// EnableInvoiceTax turns on Stripe Tax for a draft invoice, such as one of a
+// subscription started before taxes were collected
+func (c *Client) EnableInvoiceTax(ctx context.Context, invoice string) error {
+	form := url.Values{"automatic_tax[enabled]": {"true"}}
+	req, err := c.newRequest(ctx, http.MethodPost, "/v1/invoices/"+url.PathEscape(invoice), form)
+	if err != nil {
+		return err
+	}
+	var updated struct {
+		ID string `json:"id"`
+	}
+	return c.do(req, "enable invoice tax", &updated)
+}
+
+// stripeTaxID is a tax ID as the Stripe API renders it
+type stripeTaxID struct {
+	ID           string `json:"id"`
+	Type         string `json:"type"`
+	Value        string `json:"value"`
+	Verification *struct {
+		Status string `json:"status"`
+	} `json:"verification"`
+}
+
+func (s stripeTaxID) toTaxID() TaxID {
+	taxID := TaxID{ID: s.ID, Type: s.Type, Value: s.Value}
+	if s.Verification != nil {
+		taxID.Status = s.Verification.Status
+	}
+	return taxID
+}
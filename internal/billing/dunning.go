@@ -0,0 +1,75 @@
+package billing
+
+import (
+	"fmt"
+	"time"
+)
+
+// GracePeriod is how long failed payments may stay unsettled, counted from
+// the oldest failed invoice
+const GracePeriod = 14 * 24 * time.Hour
+
+// Dunning states
+const (
+	// DunningOK is an account without failed payments
+	DunningOK = "ok"
+	// DunningPastDue is an account with failed payments within the grace
+	// period; Stripe keeps retrying them
+	DunningPastDue = "past_due"
+	// DunningOverdue is an account whose grace period ended
+	DunningOverdue = "overdue"
+)
+
+// Dunning is where an account stands with failed payments
+type Dunning struct {
+	Status string `json:"status"`
+	// FailedInvoices and AmountDue cover the open invoices whose payment
+	// failed, in the smallest unit of Currency
+	FailedInvoices int    `json:"failed_invoices"`
+	AmountDue      int64  `json:"amount_due"`
+	Currency       string `json:"currency,omitempty"`
+	// NextAttempt is Stripe's next automatic retry, if any is scheduled
+	NextAttempt *time.Time `json:"next_attempt,omitempty"`
+	GraceEndsAt *time.Time `json:"grace_ends_at,omitempty"`
+	// PayURL is where the oldest failed invoice can be paid
+	PayURL  string `json:"pay_url,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// DunningState derives the dunning state at now from a customer's open
+// invoices
+func DunningState(open []Invoice, now time.Time) Dunning {
+	dunning := Dunning{Status: DunningOK}
+
+	var oldest *Invoice
+	for i, invoice := range open {
+		if !invoice.Failed() {
+			continue
+		}
+		dunning.FailedInvoices++
+		dunning.AmountDue += invoice.AmountRemaining
+		dunning.Currency = invoice.Currency
+		if invoice.NextAttempt != nil && (dunning.NextAttempt == nil || invoice.NextAttempt.Before(*dunning.NextAttempt)) {
+			dunning.NextAttempt = invoice.NextAttempt
+		}
+		if oldest == nil || invoice.Created.Before(oldest.Created) {
+			oldest = &open[i]
+		}
+	}
+	if oldest == nil {
+		return dunning
+	}
+
+	graceEnds := oldest.Created.Add(GracePeriod)
+	dunning.GraceEndsAt = &graceEnds
+	dunning.PayURL = oldest.HostedURL
+
+	if now.Before(graceEnds) {
+		dunning.Status = DunningPastDue
+		dunning.Message = fmt.Sprintf("A payment failed. Update your payment method or pay the invoice by %s.", graceEnds.Format("January 2, 2006"))
+	} else {
+		dunning.Status = DunningOverdue
+		dunning.Message = "Payments are overdue. Pay the open invoices to keep your apps running."
+	}
+	return dunning
+}
@@ -0,0 +1,342 @@
+// Package billing reads a customer's invoices from Stripe, credits their
+// balance, keeps their tax details and starts checkouts with Stripe Tax,
+// verifies Stripe webhook events and derives the dunning state of an
+// account: whether payments failed and how long until the grace period for
+// settling them ends.
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the Stripe API endpoint
+const DefaultBaseURL = "https://api.stripe.com"
+
+// MaxLimit is the most invoices Stripe returns per page
+const MaxLimit = 100
+
+// Client talks to the Stripe API
+type Client struct {
+	secretKey string
+	baseURL   string
+	http      *http.Client
+}
+
+// NewClient creates a Stripe client authenticated with a secret key
+func NewClient(secretKey string) *Client {
+	return &Client{
+		secretKey: secretKey,
+		baseURL:   DefaultBaseURL,
+		http: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+	}
+}
+
+// WithBaseURL points the client at another API endpoint, such as a fake
+func (c *Client) WithBaseURL(baseURL string) *Client {
+	c.baseURL = strings.TrimSuffix(baseURL, "/")
+	return c
+}
+
+// Invoice is a Stripe invoice
+type Invoice struct {
+	ID       string `json:"id"`
+	Number   string `json:"number"`
+	Status   string `json:"status"`
+	Currency string `json:"currency"`
+	// Amounts are in the currency's smallest unit, such as USD cents
+	AmountDue       int64      `json:"amount_due"`
+	AmountPaid      int64      `json:"amount_paid"`
+	AmountRemaining int64      `json:"amount_remaining"`
+	Created         time.Time  `json:"created"`
+	PeriodStart     time.Time  `json:"period_start"`
+	PeriodEnd       time.Time  `json:"period_end"`
+	DueDate         *time.Time `json:"due_date,omitempty"`
+	// Tax is the tax included in the amount due; AutomaticTax is set when
+	// Stripe Tax computes it
+	Tax          int64 `json:"tax"`
+	AutomaticTax bool  `json:"automatic_tax"`
+	// HostedURL is Stripe's page to view and pay the invoice
+	HostedURL string `json:"hosted_url,omitempty"`
+	PDFURL    string `json:"pdf_url,omitempty"`
+	// Attempts counts the payment attempts; NextAttempt is the next
+	// automatic one while retries are scheduled
+	Attempts    int        `json:"attempts"`
+	NextAttempt *time.Time `json:"next_attempt,omitempty"`
+	Lines       []LineItem `json:"lines"`
+}
+
+// LineItem is a charge on an invoice
+type LineItem struct {
+	ID          string    `json:"id"`
+	Description string    `json:"description"`
+	Amount      int64     `json:"amount"`
+	Quantity    int64     `json:"quantity"`
+	Price       string    `json:"price,omitempty"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+}
+
+// Failed reports whether a payment of an unpaid invoice failed
+func (i Invoice) Failed() bool {
+	return i.Status == "open" && i.Attempts > 0 && i.AmountRemaining > 0
+}
+
+// ListParams page through a customer's invoices, newest first
+type ListParams struct {
+	Customer string
+	// Status limits the invoices to draft, open, paid, uncollectible or void
+	Status        string
+	Limit         int
+	StartingAfter string
+}
+
+// Error is an error answered by the Stripe API
+type Error struct {
+	StatusCode int
+	Type       string `json:"type"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("stripe: %s (%d %s)", e.Message, e.StatusCode, e.Type)
+}
+
+// ListInvoices returns a page of a customer's invoices and whether more
+// follow
+func (c *Client) ListInvoices(ctx context.Context, params ListParams) ([]Invoice, bool, error) {
+	if params.Customer == "" {
+		return nil, false, errors.New("stripe: customer is required")
+	}
+	query := url.Values{"customer": {params.Customer}}
+	if params.Status != "" {
+		query.Set("status", params.Status)
+	}
+	if params.Limit > 0 {
+		query.Set("limit", strconv.Itoa(min(params.Limit, MaxLimit)))
+	}
+	if params.StartingAfter != "" {
+		query.Set("starting_after", params.StartingAfter)
+	}
+
+	var list struct {
+		Data    []stripeInvoice `json:"data"`
+		HasMore bool            `json:"has_more"`
+	}
+	req, err := c.newRequest(ctx, http.MethodGet, "/v1/invoices?"+query.Encode(), nil)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := c.do(req, "list invoices", &list); err != nil {
+		return nil, false, err
+	}
+
+	invoices := make([]Invoice, len(list.Data))
+	for i, inv := range list.Data {
+		invoices[i] = inv.toInvoice()
+	}
+	return invoices, list.HasMore, nil
+}
+
+// CreditParams credit a customer's balance
+type CreditParams struct {
+	Customer string
+	// Amount is in the currency's smallest unit and must be positive
+	Amount      int64
+	Currency    string
+	Description string
+	// IdempotencyKey makes retries of the same credit apply it once
+	IdempotencyKey string
+}
+
+// CreditBalance adds a credit to a customer's balance, which Stripe applies
+// to the customer's next finalized invoices before charging their card. It
+// returns the ID of the balance transaction.
+func (c *Client) CreditBalance(ctx context.Context, params CreditParams) (string, error) {
+	if params.Customer == "" {
+		return "", errors.New("stripe: customer is required")
+	}
+	if params.Amount <= 0 {
+		return "", errors.New("stripe: credit amount must be positive")
+	}
+	// Negative balance transactions are credits the customer can spend
+	form := url.Values{
+		"amount":   {strconv.FormatInt(-params.Amount, 10)},
+		"currency": {params.Currency},
+	}
+	if params.Description != "" {
+		form.Set("description", params.Description)
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, "/v1/customers/"+url.PathEscape(params.Customer)+"/balance_transactions", form)
+	if err != nil {
+		return "", err
+	}
+	if params.IdempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", params.IdempotencyKey)
+	}
+
+	var transaction struct {
+		ID string `json:"id"`
+	}
+	if err := c.do(req, "credit balance", &transaction); err != nil {
+		return "", err
+	}
+	return transaction.ID, nil
+}
+
+// newRequest builds an authenticated API request, form-encoding form as
+// the body
+func (c *Client) newRequest(ctx context.Context, method, path string, form url.Values) (*http.Request, error) {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.secretKey)
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	return req, nil
+}
+
+// do sends a request and decodes the response into out. Errors answered by
+// the API are returned as *Error.
+func (c *Client) do(req *http.Request, op string, out any) error {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("stripe: %s: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error Error `json:"error"`
+		}
+		_ = json.Unmarshal(body, &apiErr)
+		apiErr.Error.StatusCode = resp.StatusCode
+		return &apiErr.Error
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("stripe: %s: decode response: %w", op, err)
+	}
+	return nil
+}
+
+// stripeInvoice is an invoice as the Stripe API renders it
+type stripeInvoice struct {
+	ID                 string  `json:"id"`
+	Number             *string `json:"number"`
+	Status             string  `json:"status"`
+	Currency           string  `json:"currency"`
+	AmountDue          int64   `json:"amount_due"`
+	AmountPaid         int64   `json:"amount_paid"`
+	AmountRemaining    int64   `json:"amount_remaining"`
+	Tax                *int64  `json:"tax"`
+	Created            int64   `json:"created"`
+	PeriodStart        int64   `json:"period_start"`
+	PeriodEnd          int64   `json:"period_end"`
+	DueDate            *int64  `json:"due_date"`
+	HostedInvoiceURL   *string `json:"hosted_invoice_url"`
+	InvoicePDF         *string `json:"invoice_pdf"`
+	AttemptCount       int     `json:"attempt_count"`
+	NextPaymentAttempt *int64  `json:"next_payment_attempt"`
+	AutomaticTax       struct {
+		Enabled bool `json:"enabled"`
+	} `json:"automatic_tax"`
+	Lines struct {
+		Data []struct {
+			ID          string  `json:"id"`
+			Description *string `json:"description"`
+			Amount      int64   `json:"amount"`
+			Quantity    *int64  `json:"quantity"`
+			Price       *struct {
+				ID string `json:"id"`
+			} `json:"price"`
+			Period struct {
+				Start int64 `json:"start"`
+				End   int64 `json:"end"`
+			} `json:"period"`
+		} `json:"data"`
+	} `json:"lines"`
+}
+
+func (s stripeInvoice) toInvoice() Invoice {
+	invoice := Invoice{
+		ID:              s.ID,
+		Number:          deref(s.Number),
+		Status:          s.Status,
+		Currency:        s.Currency,
+		AmountDue:       s.AmountDue,
+		AmountPaid:      s.AmountPaid,
+		AmountRemaining: s.AmountRemaining,
+		AutomaticTax:    s.AutomaticTax.Enabled,
+		Created:         unix(s.Created),
+		PeriodStart:     unix(s.PeriodStart),
+		PeriodEnd:       unix(s.PeriodEnd),
+		DueDate:         unixPtr(s.DueDate),
+		HostedURL:       deref(s.HostedInvoiceURL),
+		PDFURL:          deref(s.InvoicePDF),
+		Attempts:        s.AttemptCount,
+		NextAttempt:     unixPtr(s.NextPaymentAttempt),
+		Lines:           make([]LineItem, len(s.Lines.Data)),
+	}
+	if s.Tax != nil {
+		invoice.Tax = *s.Tax
+	}
+	for i, line := range s.Lines.Data {
+		item := LineItem{
+			ID:          line.ID,
+			Description: deref(line.Description),
+			Amount:      line.Amount,
+			Quantity:    1,
+			PeriodStart: unix(line.Period.Start),
+			PeriodEnd:   unix(line.Period.End),
+		}
+		if line.Quantity != nil {
+			item.Quantity = *line.Quantity
+		}
+		if line.Price != nil {
+			item.Price = line.Price.ID
+		}
+		invoice.Lines[i] = item
+	}
+	return invoice
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func unix(seconds int64) time.Time {
+	return time.Unix(seconds, 0).UTC()
+}
+
+func unixPtr(seconds *int64) *time.Time {
+	if seconds == nil {
+		return nil
+	}
+	t := unix(*seconds)
+	return &t
+}
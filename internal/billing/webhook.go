@@ -0,0 +1,124 @@
+package billing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureTolerance is how old a webhook event may be, against replays
+const SignatureTolerance = 5 * time.Minute
+
+// Webhook event types the platform handles
+const (
+	EventCheckoutSessionCompleted = "checkout.session.completed"
+	EventInvoiceCreated           = "invoice.created"
+	EventInvoicePaid              = "invoice.paid"
+	EventInvoicePaymentFailed     = "invoice.payment_failed"
+)
+
+// ErrInvalidSignature is returned for webhook events not signed with the
+// endpoint's secret or signed too long ago
+var ErrInvalidSignature = errors.New("stripe: invalid webhook signature")
+
+// VerifySignature checks the Stripe-Signature header of a webhook payload:
+// an HMAC-SHA256 of the timestamp and payload with the endpoint's secret,
+// sent within SignatureTolerance of now
+func VerifySignature(payload []byte, header, secret string, now time.Time) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > SignatureTolerance || age < -SignatureTolerance {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + string(payload)))
+	expected := mac.Sum(nil)
+	for _, signature := range signatures {
+		if got, err := hex.DecodeString(signature); err == nil && hmac.Equal(got, expected) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// WebhookEvent is a Stripe webhook event about an invoice or a checkout
+type WebhookEvent struct {
+	ID      string
+	Type    string
+	Invoice Invoice
+	// Customer is the Stripe customer the invoice is billed to, or who
+	// checked out
+	Customer string
+	// ClientReferenceID and Metadata are those the checkout session was
+	// created with
+	ClientReferenceID string
+	Metadata          map[string]string
+}
+
+// ParseWebhookEvent decodes a webhook event. Invoice is only set for invoice
+// events, Customer for invoice and checkout session events.
+func ParseWebhookEvent(payload []byte) (WebhookEvent, error) {
+	var event struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+		Data struct {
+			Object json.RawMessage `json:"object"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return WebhookEvent{}, fmt.Errorf("stripe: decode event: %w", err)
+	}
+
+	parsed := WebhookEvent{ID: event.ID, Type: event.Type}
+	if strings.HasPrefix(event.Type, "checkout.session.") {
+		var session struct {
+			Customer          string            `json:"customer"`
+			ClientReferenceID string            `json:"client_reference_id"`
+			Metadata          map[string]string `json:"metadata"`
+		}
+		if err := json.Unmarshal(event.Data.Object, &session); err != nil {
+			return WebhookEvent{}, fmt.Errorf("stripe: decode checkout session: %w", err)
+		}
+		parsed.Customer = session.Customer
+		parsed.ClientReferenceID = session.ClientReferenceID
+		parsed.Metadata = session.Metadata
+		return parsed, nil
+	}
+	if !strings.HasPrefix(event.Type, "invoice.") {
+		return parsed, nil
+	}
+	var invoice struct {
+		stripeInvoice
+		Customer string `json:"customer"`
+	}
+	if err := json.Unmarshal(event.Data.Object, &invoice); err != nil {
+		return WebhookEvent{}, fmt.Errorf("stripe: decode invoice: %w", err)
+	}
+	parsed.Invoice = invoice.toInvoice()
+	parsed.Customer = invoice.Customer
+	return parsed, nil
+}
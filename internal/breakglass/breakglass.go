@@ -0,0 +1,110 @@
+// Package breakglass lets an emergency deploy through the checks that would
+// block it, which today are deploy freezes. The deployer types a
+// justification; every use is recorded in the app's activity log and sent to
+// the owners of the organizations whose checks were overridden.
+package breakglass
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/events"
+	"github.com/google/uuid"
+)
+
+// EventUsed is published when a deploy breaks the glass
+const EventUsed = "deployment.break_glass"
+
+// MinJustificationLength is how long the justification of an emergency
+// deploy must be
+const MinJustificationLength = 10
+
+// CheckDeployFreeze is the check of a deploy freeze
+const CheckDeployFreeze = "deploy_freeze"
+
+// Override is a check that a deploy went past
+type Override struct {
+	Check string `json:"check"`
+	Org   string `json:"org"`
+	Name  string `json:"name"`
+	// OrgOwnerID is told about the override
+	OrgOwnerID uuid.UUID `json:"-"`
+}
+
+// Use is an emergency deploy past one or more checks
+type Use struct {
+	UserID        uuid.UUID
+	App           db.App
+	DeploymentID  uuid.UUID
+	Version       int32
+	Justification string
+	IP            *netip.Addr
+	Overrides     []Override
+}
+
+// ValidJustification reports whether an emergency deploy explains itself
+func ValidJustification(justification string) bool {
+	return len([]rune(strings.TrimSpace(justification))) >= MinJustificationLength
+}
+
+// Record publishes a use to the deployer, which records it in the app's
+// activity log along with the deployer's IP address, and to each owner of
+// an overridden organization, who is notified and sees it in their own
+// activity log.
+func Record(ctx context.Context, queries *db.Queries, use Use) error {
+	payload := use.payload()
+	for _, userID := range use.recipients() {
+		e := events.Event{
+			Type:    EventUsed,
+			UserID:  userID,
+			AppID:   use.App.ID,
+			AppName: use.App.Name,
+			Message: use.Message(),
+			Payload: payload,
+		}
+		if userID == use.UserID {
+			e.IP = use.IP
+		}
+		if err := events.Publish(ctx, queries, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Message summarizes a use for notifications
+func (u Use) Message() string {
+	checks := make([]string, len(u.Overrides))
+	for i, o := range u.Overrides {
+		checks[i] = fmt.Sprintf("the %s %s of %s", o.Name, strings.ReplaceAll(o.Check, "_", " "), o.Org)
+	}
+	return fmt.Sprintf("BREAK GLASS: %s v%d was deployed past %s: %s",
+		u.App.Name, u.Version, strings.Join(checks, ", "), strings.TrimSpace(u.Justification))
+}
+
+func (u Use) payload() map[string]any {
+	return map[string]any{
+		"deployment_id": u.DeploymentID,
+		"version":       u.Version,
+		"deployed_by":   u.UserID,
+		"justification": strings.TrimSpace(u.Justification),
+		"overrides":     u.Overrides,
+	}
+}
+
+// recipients returns the deployer followed by the owners of the overridden
+// organizations, each once
+func (u Use) recipients() []uuid.UUID {
+	recipients := []uuid.UUID{u.UserID}
+	for _, o := range u.Overrides {
+		if o.OrgOwnerID == uuid.Nil || slices.Contains(recipients, o.OrgOwnerID) {
+			continue
+		}
+		recipients = append(recipients, o.OrgOwnerID)
+	}
+	return recipients
+}
@@ -0,0 +1,45 @@
+package breakglass
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/google/uuid"
+)
+
+func TestValidJustification(t *testing.T) {
+	if ValidJustification("  fix  ") || !ValidJustification("hotfix for the checkout outage") {
+		t.Error("unexpected justification validation")
+	}
+}
+
+func TestRecipients(t *testing.T) {
+	deployer, owner := uuid.New(), uuid.New()
+	use := Use{
+		UserID: deployer,
+		Overrides: []Override{
+			{Check: CheckDeployFreeze, Org: "acme", Name: "weekend", OrgOwnerID: owner},
+			{Check: CheckDeployFreeze, Org: "acme", Name: "launch", OrgOwnerID: owner},
+			{Check: CheckDeployFreeze, Org: "mine", Name: "nights", OrgOwnerID: deployer},
+		},
+	}
+
+	if got := use.recipients(); !slices.Equal(got, []uuid.UUID{deployer, owner}) {
+		t.Errorf("expected the deployer and the org owner once each, got %v", got)
+	}
+}
+
+func TestMessage(t *testing.T) {
+	use := Use{
+		App:           db.App{Name: "shop"},
+		Version:       7,
+		Justification: " hotfix for the checkout outage ",
+		Overrides:     []Override{{Check: CheckDeployFreeze, Org: "acme", Name: "weekend"}},
+	}
+
+	want := "BREAK GLASS: shop v7 was deployed past the weekend deploy freeze of acme: hotfix for the checkout outage"
+	if got := use.Message(); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
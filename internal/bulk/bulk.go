@@ -0,0 +1,191 @@
+// Package bulk runs operations across many of a user's apps at once:
+// restarting, pausing or resuming them, or setting env vars. A paused app
+// has its processes scaled to zero until it is resumed or redeployed. An
+// operation is persisted when requested and carried out in the background
+// by workers that claim it, apply it to each app in turn and record each
+// app's outcome, so its progress can be followed and an operation whose
+// worker died is taken over once its lease runs out.
+package bulk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/google/uuid"
+)
+
+// Actions of an operation
+const (
+	ActionRestart = "restart"
+	ActionPause   = "pause"
+	ActionResume  = "resume"
+	ActionSetEnv  = "set-env"
+)
+
+// Statuses of an operation
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+)
+
+// Outcomes of an operation on an app
+const (
+	ResultSucceeded = "succeeded"
+	ResultFailed    = "failed"
+	ResultSkipped   = "skipped"
+)
+
+const (
+	// MaxApps is how many apps one operation may cover
+	MaxApps = 100
+	// Lease is how long a claimed operation is reserved for its worker,
+	// extended with every app done
+	Lease = 5 * time.Minute
+	// Retention is how long completed operations are kept
+	Retention = 7 * 24 * time.Hour
+
+	batchSize = 5
+)
+
+// ErrInvalid is returned for an operation that cannot be queued
+var ErrInvalid = errors.New("invalid bulk operation")
+
+var envNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Result is the outcome of an operation on one app
+type Result struct {
+	App    string `json:"app"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Progress counts the apps an operation is done with
+type Progress struct {
+	Total     int `json:"total"`
+	Done      int `json:"done"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Skipped   int `json:"skipped"`
+}
+
+// Operation is how an operation is shown through the API. The values of the
+// variables it sets are not shown back.
+type Operation struct {
+	ID         uuid.UUID  `json:"id"`
+	Action     string     `json:"action"`
+	Apps       []string   `json:"apps"`
+	Status     string     `json:"status"`
+	Progress   Progress   `json:"progress"`
+	Results    []Result   `json:"results"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Validate checks an operation before it is queued. set-env sets the
+// variables of set and removes those of unset; other actions take neither.
+func Validate(action string, apps []string, set map[string]string, unset []string) error {
+	switch action {
+	case ActionRestart, ActionPause, ActionResume:
+		if len(set) > 0 || len(unset) > 0 {
+			return fmt.Errorf("%w: %s does not take env vars", ErrInvalid, action)
+		}
+	case ActionSetEnv:
+		if len(set) == 0 && len(unset) == 0 {
+			return fmt.Errorf("%w: set-env needs env vars to set or unset", ErrInvalid)
+		}
+		for name := range set {
+			if !envNameRegex.MatchString(name) {
+				return fmt.Errorf("%w: %q is not a valid env var name", ErrInvalid, name)
+			}
+			if slices.Contains(unset, name) {
+				return fmt.Errorf("%w: %s is both set and unset", ErrInvalid, name)
+			}
+		}
+		for _, name := range unset {
+			if !envNameRegex.MatchString(name) {
+				return fmt.Errorf("%w: %q is not a valid env var name", ErrInvalid, name)
+			}
+		}
+	default:
+		return fmt.Errorf("%w: action must be %s, %s, %s or %s", ErrInvalid, ActionRestart, ActionPause, ActionResume, ActionSetEnv)
+	}
+
+	if len(apps) == 0 {
+		return fmt.Errorf("%w: at least one app is required", ErrInvalid)
+	}
+	if len(apps) > MaxApps {
+		return fmt.Errorf("%w: at most %d apps per operation", ErrInvalid, MaxApps)
+	}
+	seen := make(map[string]bool, len(apps))
+	for _, app := range apps {
+		if seen[app] {
+			return fmt.Errorf("%w: %s is listed twice", ErrInvalid, app)
+		}
+		seen[app] = true
+	}
+	return nil
+}
+
+// MergeEnv returns an app's variables with those of set added or replaced
+// and those of unset removed
+func MergeEnv(vars, set map[string]string, unset []string) map[string]string {
+	merged := maps.Clone(vars)
+	if merged == nil {
+		merged = make(map[string]string, len(set))
+	}
+	maps.Copy(merged, set)
+	for _, name := range unset {
+		delete(merged, name)
+	}
+	return merged
+}
+
+// Results returns the outcomes recorded for an operation so far
+func Results(op db.BulkOperation) []Result {
+	results := []Result{}
+	if len(op.Results) > 0 {
+		_ = json.Unmarshal(op.Results, &results)
+	}
+	return results
+}
+
+// View returns an operation as shown through the API
+func View(op db.BulkOperation) Operation {
+	results := Results(op)
+	progress := Progress{Total: len(op.Apps), Done: len(results)}
+	for _, result := range results {
+		switch result.Status {
+		case ResultSucceeded:
+			progress.Succeeded++
+		case ResultFailed:
+			progress.Failed++
+		case ResultSkipped:
+			progress.Skipped++
+		}
+	}
+
+	view := Operation{
+		ID:        op.ID,
+		Action:    op.Action,
+		Apps:      op.Apps,
+		Status:    op.Status,
+		Progress:  progress,
+		Results:   results,
+		CreatedAt: op.CreatedAt,
+	}
+	if op.StartedAt.Valid {
+		view.StartedAt = &op.StartedAt.Time
+	}
+	if op.FinishedAt.Valid {
+		view.FinishedAt = &op.FinishedAt.Time
+	}
+	return view
+}
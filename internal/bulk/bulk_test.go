@@ -0,0 +1,109 @@
+package bulk
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestValidate(t *testing.T) {
+	apps := []string{"api", "worker"}
+	valid := []struct {
+		action string
+		set    map[string]string
+		unset  []string
+	}{
+		{ActionRestart, nil, nil},
+		{ActionPause, nil, nil},
+		{ActionResume, nil, nil},
+		{ActionSetEnv, map[string]string{"LOG_LEVEL": "debug"}, nil},
+		{ActionSetEnv, nil, []string{"LEGACY_FLAG"}},
+	}
+	for _, tc := range valid {
+		if err := Validate(tc.action, apps, tc.set, tc.unset); err != nil {
+			t.Errorf("expected %s %v %v to be valid, got %v", tc.action, tc.set, tc.unset, err)
+		}
+	}
+
+	many := make([]string, MaxApps+1)
+	for i := range many {
+		many[i] = fmt.Sprintf("app-%d", i)
+	}
+	invalid := []struct {
+		action string
+		apps   []string
+		set    map[string]string
+		unset  []string
+	}{
+		{"delete", apps, nil, nil},
+		{ActionRestart, nil, nil, nil},
+		{ActionRestart, many, nil, nil},
+		{ActionRestart, []string{"api", "api"}, nil, nil},
+		{ActionRestart, apps, map[string]string{"LOG_LEVEL": "debug"}, nil},
+		{ActionSetEnv, apps, nil, nil},
+		{ActionSetEnv, apps, map[string]string{"1BAD": "x"}, nil},
+		{ActionSetEnv, apps, nil, []string{"BAD-NAME"}},
+		{ActionSetEnv, apps, map[string]string{"LOG_LEVEL": "debug"}, []string{"LOG_LEVEL"}},
+	}
+	for _, tc := range invalid {
+		if err := Validate(tc.action, tc.apps, tc.set, tc.unset); !errors.Is(err, ErrInvalid) {
+			t.Errorf("expected %s of %d apps with %v %v to be invalid, got %v", tc.action, len(tc.apps), tc.set, tc.unset, err)
+		}
+	}
+}
+
+func TestMergeEnv(t *testing.T) {
+	vars := map[string]string{"LOG_LEVEL": "info", "LEGACY_FLAG": "1", "PORT": "3000"}
+	merged := MergeEnv(vars, map[string]string{"LOG_LEVEL": "debug", "REGION": "eu"}, []string{"LEGACY_FLAG"})
+
+	want := map[string]string{"LOG_LEVEL": "debug", "PORT": "3000", "REGION": "eu"}
+	if len(merged) != len(want) {
+		t.Fatalf("got %v, want %v", merged, want)
+	}
+	for name, value := range want {
+		if merged[name] != value {
+			t.Errorf("%s = %q, want %q", name, merged[name], value)
+		}
+	}
+	if vars["LOG_LEVEL"] != "info" || vars["LEGACY_FLAG"] != "1" {
+		t.Error("expected the app's variables not to be modified")
+	}
+
+	if merged := MergeEnv(nil, map[string]string{"A": "1"}, nil); merged["A"] != "1" {
+		t.Errorf("expected variables to be set on an app without any, got %v", merged)
+	}
+}
+
+func TestView(t *testing.T) {
+	started := time.Now()
+	op := db.BulkOperation{
+		ID:        uuid.New(),
+		Action:    ActionRestart,
+		Apps:      []string{"api", "worker", "cron", "blog"},
+		Status:    StatusRunning,
+		Results:   []byte(`[{"app":"api","status":"succeeded"},{"app":"worker","status":"failed","error":"connection refused"},{"app":"cron","status":"skipped","error":"app is not deployed"}]`),
+		StartedAt: pgtype.Timestamptz{Time: started, Valid: true},
+	}
+
+	view := View(op)
+	want := Progress{Total: 4, Done: 3, Succeeded: 1, Failed: 1, Skipped: 1}
+	if view.Progress != want {
+		t.Errorf("got progress %+v, want %+v", view.Progress, want)
+	}
+	if len(view.Results) != 3 || view.Results[1].Error != "connection refused" {
+		t.Errorf("unexpected results %+v", view.Results)
+	}
+	if view.StartedAt == nil || !view.StartedAt.Equal(started) || view.FinishedAt != nil {
+		t.Errorf("unexpected times %v, %v", view.StartedAt, view.FinishedAt)
+	}
+
+	// A pending operation has no results yet
+	if view := View(db.BulkOperation{Apps: []string{"api"}, Results: []byte(`[]`)}); view.Results == nil || view.Progress.Done != 0 {
+		t.Errorf("unexpected view of a pending operation %+v", view)
+	}
+}
@@ -0,0 +1,272 @@
+package bulk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appconfig"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appstatus"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/cryptoutil"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// skippedError is returned for an app the action does not apply to
+type skippedError struct {
+	reason string
+}
+
+func (e *skippedError) Error() string {
+	return e.reason
+}
+
+func skip(reason string) error {
+	return &skippedError{reason: reason}
+}
+
+// Worker carries out queued operations
+type Worker struct {
+	queries   *db.Queries
+	cfg       *config.Config
+	clients   func(kubeconfig string) (k8s.Interface, error)
+	now       func() time.Time
+	lastPrune time.Time
+}
+
+// NewWorker creates a worker connecting to clusters with clients
+func NewWorker(queries *db.Queries, cfg *config.Config, clients func(kubeconfig string) (k8s.Interface, error)) *Worker {
+	return &Worker{
+		queries: queries,
+		cfg:     cfg,
+		clients: clients,
+		now:     time.Now,
+	}
+}
+
+// Process claims the operations waiting for a worker and carries each out
+func (w *Worker) Process(ctx context.Context) error {
+	now := w.now()
+	ops, err := w.queries.ClaimBulkOperations(ctx, db.ClaimBulkOperationsParams{
+		Now:           now,
+		LeaseUntil:    now.Add(Lease),
+		MaxOperations: batchSize,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to claim bulk operations: %w", err)
+	}
+
+	for _, op := range ops {
+		if err := w.run(ctx, op); err != nil {
+			slog.Error("bulk operation interrupted", "operation", op.ID, "action", op.Action, "error", err)
+		}
+	}
+
+	if now.Sub(w.lastPrune) >= time.Hour {
+		w.lastPrune = now
+		before := pgtype.Timestamptz{Time: now.Add(-Retention), Valid: true}
+		if _, err := w.queries.DeleteCompletedBulkOperations(ctx, before); err != nil {
+			slog.Error("failed to prune bulk operations", "error", err)
+		}
+	}
+	return nil
+}
+
+// run applies an operation to each of its apps not done yet, recording
+// every outcome as it goes. An operation interrupted before completing is
+// taken over where it stopped once its lease runs out.
+func (w *Worker) run(ctx context.Context, op db.BulkOperation) error {
+	set, err := cryptoutil.Decrypt(op.EnvEncrypted, w.cfg.EncryptionKey)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt env vars: %w", err)
+	}
+
+	var done []string
+	for _, result := range Results(op) {
+		done = append(done, result.App)
+	}
+
+	regions := k8s.NewRegions(w.cfg.KubeconfigForRegion, w.clients)
+	for _, name := range op.Apps {
+		if slices.Contains(done, name) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		result := Result{App: name, Status: ResultSucceeded}
+		var skipped *skippedError
+		if err := w.apply(ctx, regions, op, name, set); errors.As(err, &skipped) {
+			result.Status = ResultSkipped
+			result.Error = err.Error()
+		} else if err != nil {
+			result.Status = ResultFailed
+			result.Error = err.Error()
+		}
+
+		data, _ := json.Marshal(result)
+		err = w.queries.AppendBulkOperationResult(ctx, db.AppendBulkOperationResultParams{
+			Result:     data,
+			LeaseUntil: w.now().Add(Lease),
+			ID:         op.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record the result of %s: %w", name, err)
+		}
+	}
+
+	return w.queries.CompleteBulkOperation(ctx, op.ID)
+}
+
+// apply carries out the operation on one app and records it in the app's
+// activity
+func (w *Worker) apply(ctx context.Context, regions *k8s.Regions, op db.BulkOperation, name string, set map[string]string) error {
+	app, err := w.queries.GetAppByName(ctx, db.GetAppByNameParams{UserID: op.UserID, Name: name})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errors.New("app not found")
+	}
+	if err != nil {
+		return err
+	}
+	if app.Status == db.AppStatusSuspended || app.Status == db.AppStatusPurged {
+		return skip(fmt.Sprintf("app is %s for an unpaid invoice", app.Status))
+	}
+
+	deployed := app.CurrentDeploymentID.Valid
+	if !deployed && op.Action != ActionSetEnv {
+		return skip("app is not deployed")
+	}
+
+	var client k8s.Interface
+	if deployed {
+		if client, err = regions.Client(app.Region); err != nil {
+			return err
+		}
+	}
+
+	details := map[string]any{"bulk_operation_id": op.ID}
+	var action string
+	switch op.Action {
+	case ActionRestart:
+		action = "app.restarted"
+		err = client.RestartApp(ctx, app.Name)
+	case ActionPause:
+		if app.Status == db.AppStatusPaused {
+			return skip("app is already paused")
+		}
+		if !appstatus.CanTransition(app.Status, db.AppStatusPaused) {
+			return skip(fmt.Sprintf("app is %s", app.Status))
+		}
+		action = "app.paused"
+		err = w.pause(ctx, client, app)
+	case ActionResume:
+		if app.Status != db.AppStatusPaused {
+			return skip("app is not paused")
+		}
+		action = "app.resumed"
+		err = w.resume(ctx, client, app)
+	case ActionSetEnv:
+		action = "env.updated"
+		details["set"] = slices.Sorted(maps.Keys(set))
+		details["unset"] = op.UnsetEnv
+		details["synced"] = deployed
+		err = w.setEnv(ctx, client, app, set, op.UnsetEnv)
+	default:
+		return fmt.Errorf("unknown action %s", op.Action)
+	}
+	if err != nil {
+		return err
+	}
+
+	data, _ := json.Marshal(details)
+	_, _ = w.queries.CreateActivityLog(ctx, db.CreateActivityLogParams{
+		UserID:     pgtype.UUID{Bytes: op.UserID, Valid: true},
+		AppID:      pgtype.UUID{Bytes: app.ID, Valid: true},
+		Action:     action,
+		Details:    data,
+		ApiTokenID: op.ApiTokenID,
+	})
+	return nil
+}
+
+// pause scales every process of an app to zero
+func (w *Worker) pause(ctx context.Context, client k8s.Interface, app db.App) error {
+	// A burst in progress would scale the web process back up when it ends
+	if err := w.queries.EndAppBurst(ctx, app.ID); err != nil {
+		return err
+	}
+	formation, err := w.queries.ListAppProcesses(ctx, app.ID)
+	if err != nil {
+		return err
+	}
+	processes := []string{k8s.ProcessTypeWeb}
+	for _, process := range formation {
+		if process.ProcessType != k8s.ProcessTypeWeb {
+			processes = append(processes, process.ProcessType)
+		}
+	}
+	for _, process := range processes {
+		if err := client.ScaleProcess(ctx, app.Name, process, 0); err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+	_, err = appstatus.Set(ctx, w.queries, app, db.AppStatusPaused, app.CurrentDeploymentID)
+	return err
+}
+
+// resume scales the processes of a paused app back to their formation
+func (w *Worker) resume(ctx context.Context, client k8s.Interface, app db.App) error {
+	formation, err := w.queries.ListAppProcesses(ctx, app.ID)
+	if err != nil {
+		return err
+	}
+	replicas := map[string]int32{k8s.ProcessTypeWeb: 1}
+	for _, process := range formation {
+		replicas[process.ProcessType] = process.Replicas
+	}
+	for process, n := range replicas {
+		if err := client.ScaleProcess(ctx, app.Name, process, n); err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+	_, err = appstatus.Set(ctx, w.queries, app, db.AppStatusRunning, app.CurrentDeploymentID)
+	return err
+}
+
+// setEnv changes the variables of an app, rolling its pods when deployed
+func (w *Worker) setEnv(ctx context.Context, client k8s.Interface, app db.App, set map[string]string, unset []string) error {
+	vars, err := cryptoutil.Decrypt(app.EnvVarsEncrypted, w.cfg.EncryptionKey)
+	if err != nil {
+		return errors.New("failed to decrypt environment variables")
+	}
+	encrypted, err := cryptoutil.Encrypt(MergeEnv(vars, set, unset), w.cfg.EncryptionKey)
+	if err != nil {
+		return errors.New("failed to encrypt environment variables")
+	}
+	app, err = w.queries.UpdateAppEnvVars(ctx, db.UpdateAppEnvVarsParams{
+		ID:               app.ID,
+		EnvVarsEncrypted: encrypted,
+	})
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		return nil
+	}
+
+	appConfig, err := appconfig.Load(ctx, w.cfg, w.queries, app, db.Deployment{})
+	if err != nil {
+		return err
+	}
+	return client.ApplyEnv(ctx, appConfig)
+}
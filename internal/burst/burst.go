@@ -0,0 +1,339 @@
+// Package burst protects apps against traffic spikes. Owners set a CPU or
+// p95 latency threshold; when the web process crosses one its replicas are
+// doubled until load stays under the thresholds for the cool-down, then
+// scaled back. Unlike a HorizontalPodAutoscaler the step is fixed, may go
+// past the plan's replica limit for its duration and is only offered on
+// plans with burst.
+package burst
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/events"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/metering"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/plans"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Events published when a burst starts and ends, written to the activity log
+const (
+	EventStarted = "app.burst_started"
+	EventEnded   = "app.burst_ended"
+)
+
+// Factor multiplies the web replicas during a burst
+const Factor = 2
+
+// latencyQuantile is the request duration quantile compared with the
+// latency threshold
+const latencyQuantile = 0.95
+
+// Action is what a check does to the burst of an app
+type Action int
+
+const (
+	ActionNone Action = iota
+	ActionStart
+	ActionExtend
+	ActionEnd
+)
+
+// Load is the web load of an app measured by a check. A signal that could
+// not be measured is left unset and never crosses its threshold.
+type Load struct {
+	CPUPercent int64
+	HasCPU     bool
+	P95        time.Duration
+	HasLatency bool
+}
+
+// Over returns why the load crosses a threshold of the burst, empty when it
+// does not
+func Over(b db.ListAppBurstsRow, load Load) string {
+	if b.CpuPercent != nil && load.HasCPU && load.CPUPercent >= int64(*b.CpuPercent) {
+		return fmt.Sprintf("CPU at %d%% of its size, over %d%%", load.CPUPercent, *b.CpuPercent)
+	}
+	threshold := time.Duration(0)
+	if b.P95LatencyMs != nil {
+		threshold = time.Duration(*b.P95LatencyMs) * time.Millisecond
+	}
+	if threshold > 0 && load.HasLatency && load.P95 >= threshold {
+		return fmt.Sprintf("p95 latency at %s, over %s", load.P95.Round(time.Millisecond), threshold)
+	}
+	return ""
+}
+
+// Decide returns the action due for a burst. A burst starts when load
+// crosses a threshold, is extended by the cool-down while it stays over and
+// ends once the cool-down runs out. Apps whose plan no longer allows burst
+// are scaled back.
+func Decide(b db.ListAppBurstsRow, load Load, now time.Time) Action {
+	bursting := b.BurstUntil.Valid
+	if !plans.ForSize(b.Size).Burst {
+		if bursting {
+			return ActionEnd
+		}
+		return ActionNone
+	}
+
+	over := Over(b, load) != ""
+	switch {
+	case over && !bursting:
+		return ActionStart
+	case over:
+		return ActionExtend
+	case bursting && !now.Before(b.BurstUntil.Time):
+		return ActionEnd
+	}
+	return ActionNone
+}
+
+// CPUPercent is the average CPU of the web pods as a percentage of the CPU
+// included in the plan, false without web pods
+func CPUPercent(usage []k8s.PodUsage, plan plans.Plan) (int64, bool) {
+	var total, pods int64
+	for _, pod := range usage {
+		if pod.Process != k8s.ProcessTypeWeb {
+			continue
+		}
+		total += pod.CPUMillis
+		pods++
+	}
+	if pods == 0 || plan.CPUMillis == 0 {
+		return 0, false
+	}
+	return total * 100 / (pods * plan.CPUMillis), true
+}
+
+// Controller starts and ends bursts
+type Controller struct {
+	queries *db.Queries
+	cfg     *config.Config
+	clients func(kubeconfig string) (k8s.Interface, error)
+	events  events.Publisher
+	http    *http.Client
+	now     func() time.Time
+	// last holds the previous latency histograms of each region, the
+	// p95 is computed over the requests since
+	last map[string]map[string]metering.Histogram
+}
+
+// New creates a controller connecting to clusters with clients
+func New(queries *db.Queries, cfg *config.Config, clients func(kubeconfig string) (k8s.Interface, error), publisher events.Publisher) *Controller {
+	return &Controller{
+		queries: queries,
+		cfg:     cfg,
+		clients: clients,
+		events:  publisher,
+		http:    &http.Client{Timeout: 10 * time.Second},
+		now:     time.Now,
+		last:    make(map[string]map[string]metering.Histogram),
+	}
+}
+
+// Check measures the load of every app with burst configured once and
+// starts, extends or ends its burst. Latency is measured from the second
+// check of a region on, over the requests between two checks.
+func (c *Controller) Check(ctx context.Context) error {
+	bursts, err := c.queries.ListAppBursts(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list bursts: %w", err)
+	}
+
+	regions := make(map[string][]db.ListAppBurstsRow)
+	for _, b := range bursts {
+		regions[b.Region] = append(regions[b.Region], b)
+	}
+	for region := range c.last {
+		if _, ok := regions[region]; !ok {
+			delete(c.last, region)
+		}
+	}
+
+	for region, bursts := range regions {
+		client, err := c.clients(c.cfg.KubeconfigForRegion(region))
+		if err != nil {
+			slog.Warn("kubernetes not available for region", "region", region, "error", err)
+			continue
+		}
+		latency := c.latency(ctx, region)
+
+		for _, b := range bursts {
+			c.check(ctx, client, b, latency)
+		}
+	}
+
+	return nil
+}
+
+// latency returns the request duration histograms of a region since the
+// previous check, nil when they cannot be measured
+func (c *Controller) latency(ctx context.Context, region string) map[string]metering.Histogram {
+	metricsURL := c.cfg.TraefikMetricsURLForRegion(region)
+	if metricsURL == "" {
+		return nil
+	}
+
+	current, err := c.scrape(ctx, metricsURL)
+	if err != nil {
+		slog.Warn("failed to scrape ingress latency", "region", region, "error", err)
+		return nil
+	}
+
+	previous, ok := c.last[region]
+	c.last[region] = current
+	if !ok {
+		return nil
+	}
+
+	latency := make(map[string]metering.Histogram, len(current))
+	for service, h := range current {
+		latency[service] = h.Sub(previous[service])
+	}
+	return latency
+}
+
+func (c *Controller) scrape(ctx context.Context, metricsURL string) (map[string]metering.Histogram, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metricsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("metrics endpoint returned status %d", resp.StatusCode)
+	}
+	return metering.ParseTraefikLatency(resp.Body)
+}
+
+// check measures the load of one app and applies the action due
+func (c *Controller) check(ctx context.Context, client k8s.Interface, b db.ListAppBurstsRow, latency map[string]metering.Histogram) {
+	var load Load
+	if b.CpuPercent != nil {
+		usage, err := client.ListPodUsage(ctx, b.AppName)
+		if err != nil {
+			slog.Warn("failed to read pod usage", "app", b.AppName, "error", err)
+		} else {
+			load.CPUPercent, load.HasCPU = CPUPercent(usage, plans.ForSize(b.Size))
+		}
+	}
+	if b.P95LatencyMs != nil {
+		service := metering.ServiceName(c.cfg.K8sNamespacePrefix+b.AppName, b.AppName)
+		load.P95, load.HasLatency = latency[service].Quantile(latencyQuantile)
+	}
+
+	now := c.now()
+	switch Decide(b, load, now) {
+	case ActionStart:
+		c.start(ctx, client, b, Over(b, load), now)
+	case ActionExtend:
+		if err := c.queries.ExtendAppBurst(ctx, db.ExtendAppBurstParams{
+			AppID:      b.AppID,
+			BurstUntil: pgtype.Timestamptz{Time: now.Add(cooldown(b)), Valid: true},
+		}); err != nil {
+			slog.Error("failed to extend burst", "app", b.AppName, "error", err)
+		}
+	case ActionEnd:
+		c.end(ctx, client, b, now)
+	}
+}
+
+// start doubles the web replicas of an app. Apps scaled to zero are left
+// alone.
+func (c *Controller) start(ctx context.Context, client k8s.Interface, b db.ListAppBurstsRow, reason string, now time.Time) {
+	base, err := client.ProcessReplicas(ctx, b.AppName, k8s.ProcessTypeWeb)
+	if err != nil {
+		slog.Warn("failed to read web replicas", "app", b.AppName, "error", err)
+		return
+	}
+	if base == 0 {
+		return
+	}
+
+	replicas := base * Factor
+	if err := client.ScaleProcess(ctx, b.AppName, k8s.ProcessTypeWeb, replicas); err != nil {
+		slog.Error("failed to start burst", "app", b.AppName, "error", err)
+		return
+	}
+
+	if err := c.queries.StartAppBurst(ctx, db.StartAppBurstParams{
+		AppID:          b.AppID,
+		BaseReplicas:   &base,
+		BurstStartedAt: pgtype.Timestamptz{Time: now, Valid: true},
+		BurstUntil:     pgtype.Timestamptz{Time: now.Add(cooldown(b)), Valid: true},
+	}); err != nil {
+		slog.Error("failed to record burst", "app", b.AppName, "error", err)
+		return
+	}
+
+	c.publish(ctx, events.Event{
+		Type:    EventStarted,
+		UserID:  b.UserID,
+		AppID:   b.AppID,
+		AppName: b.AppName,
+		Payload: map[string]any{
+			"reason":        reason,
+			"base_replicas": base,
+			"replicas":      replicas,
+		},
+	})
+}
+
+// end scales the web process of an app back to its replicas from before the
+// burst
+func (c *Controller) end(ctx context.Context, client k8s.Interface, b db.ListAppBurstsRow, now time.Time) {
+	if err := End(ctx, client, b.AppName, b.BaseReplicas); err != nil {
+		slog.Error("failed to end burst", "app", b.AppName, "error", err)
+		return
+	}
+	if err := c.queries.EndAppBurst(ctx, b.AppID); err != nil {
+		slog.Error("failed to record burst end", "app", b.AppName, "error", err)
+		return
+	}
+
+	payload := map[string]any{}
+	if b.BaseReplicas != nil {
+		payload["replicas"] = *b.BaseReplicas
+	}
+	if b.BurstStartedAt.Valid {
+		payload["duration_seconds"] = int64(now.Sub(b.BurstStartedAt.Time).Seconds())
+	}
+	c.publish(ctx, events.Event{
+		Type:    EventEnded,
+		UserID:  b.UserID,
+		AppID:   b.AppID,
+		AppName: b.AppName,
+		Payload: payload,
+	})
+}
+
+func (c *Controller) publish(ctx context.Context, e events.Event) {
+	if err := c.events.Publish(ctx, e); err != nil {
+		slog.Error("failed to publish burst event", "app", e.AppName, "type", e.Type, "error", err)
+	}
+}
+
+// End scales the web process of an app back to the replicas it had before
+// its burst. It does nothing without a burst in progress.
+func End(ctx context.Context, client k8s.Interface, appName string, baseReplicas *int32) error {
+	if baseReplicas == nil {
+		return nil
+	}
+	return client.ScaleProcess(ctx, appName, k8s.ProcessTypeWeb, *baseReplicas)
+}
+
+func cooldown(b db.ListAppBurstsRow) time.Duration {
+	return time.Duration(b.CooldownMinutes) * time.Minute
+}
@@ -0,0 +1,83 @@
+package burst
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/plans"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func TestOver(t *testing.T) {
+	b := db.ListAppBurstsRow{CpuPercent: int32Ptr(80), P95LatencyMs: int32Ptr(500)}
+
+	if reason := Over(b, Load{CPUPercent: 90, HasCPU: true}); !strings.Contains(reason, "CPU at 90%") {
+		t.Errorf("expected CPU over threshold, got %q", reason)
+	}
+	if reason := Over(b, Load{P95: 750 * time.Millisecond, HasLatency: true}); !strings.Contains(reason, "p95 latency at 750ms") {
+		t.Errorf("expected latency over threshold, got %q", reason)
+	}
+	if reason := Over(b, Load{CPUPercent: 50, HasCPU: true, P95: 100 * time.Millisecond, HasLatency: true}); reason != "" {
+		t.Errorf("expected load under thresholds, got %q", reason)
+	}
+	if reason := Over(db.ListAppBurstsRow{}, Load{CPUPercent: 100, HasCPU: true}); reason != "" {
+		t.Errorf("expected no threshold to be crossed without thresholds, got %q", reason)
+	}
+}
+
+func TestDecide(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	over := Load{CPUPercent: 95, HasCPU: true}
+	under := Load{CPUPercent: 20, HasCPU: true}
+	idle := db.ListAppBurstsRow{Size: "pro", CpuPercent: int32Ptr(80)}
+	bursting := idle
+	bursting.BurstUntil = pgtype.Timestamptz{Time: now.Add(time.Minute), Valid: true}
+	expired := idle
+	expired.BurstUntil = pgtype.Timestamptz{Time: now.Add(-time.Minute), Valid: true}
+	starter := expired
+	starter.Size = "starter"
+
+	tests := []struct {
+		name  string
+		burst db.ListAppBurstsRow
+		load  Load
+		want  Action
+	}{
+		{"starts over threshold", idle, over, ActionStart},
+		{"stays idle under threshold", idle, under, ActionNone},
+		{"extends while over", bursting, over, ActionExtend},
+		{"waits for the cool-down", bursting, under, ActionNone},
+		{"ends after the cool-down", expired, under, ActionEnd},
+		{"ends when the plan loses burst", starter, over, ActionEnd},
+		{"never starts without burst", db.ListAppBurstsRow{Size: "starter", CpuPercent: int32Ptr(80)}, over, ActionNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Decide(tt.burst, tt.load, now); got != tt.want {
+				t.Errorf("expected action %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCPUPercent(t *testing.T) {
+	usage := []k8s.PodUsage{
+		{Name: "web-1", Process: k8s.ProcessTypeWeb, CPUMillis: 700},
+		{Name: "web-2", Process: k8s.ProcessTypeWeb, CPUMillis: 900},
+		{Name: "worker-1", Process: "worker", CPUMillis: 1000},
+	}
+	if percent, ok := CPUPercent(usage, plans.ForSize("pro")); !ok || percent != 80 {
+		t.Errorf("expected 80%%, got %d", percent)
+	}
+	if _, ok := CPUPercent(usage[2:], plans.ForSize("pro")); ok {
+		t.Error("expected no CPU without web pods")
+	}
+}
@@ -0,0 +1,157 @@
+// Package certmonitor polls cert-manager for the certificates of verified
+// custom domains, records their issuance state and expiry, and alerts owners
+// when issuance fails or a certificate is about to expire without renewal.
+package certmonitor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/events"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Events published on alerts
+const (
+	EventIssuanceFailed = "certificate.issuance_failed"
+	EventExpiring       = "certificate.expiring"
+)
+
+// ExpiryWarning is how close to expiry a certificate must be to alert.
+// cert-manager renews Let's Encrypt certificates 30 days before expiry, so a
+// certificate this close has failed to renew.
+const ExpiryWarning = 14 * 24 * time.Hour
+
+// alertInterval limits how often the same domain is alerted about
+const alertInterval = 24 * time.Hour
+
+// Monitor checks domain certificates
+type Monitor struct {
+	queries *db.Queries
+	cfg     *config.Config
+	clients func(kubeconfig string) (k8s.Interface, error)
+	events  events.Publisher
+	now     func() time.Time
+}
+
+// New creates a monitor connecting to clusters with clients
+func New(queries *db.Queries, cfg *config.Config, clients func(kubeconfig string) (k8s.Interface, error), publisher events.Publisher) *Monitor {
+	return &Monitor{
+		queries: queries,
+		cfg:     cfg,
+		clients: clients,
+		events:  publisher,
+		now:     time.Now,
+	}
+}
+
+// Check polls the certificate of every verified domain once
+func (m *Monitor) Check(ctx context.Context) error {
+	domains, err := m.queries.ListVerifiedDomainsWithApps(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list domains: %w", err)
+	}
+
+	regions := k8s.NewRegions(m.cfg.KubeconfigForRegion, m.clients)
+	certs := make(map[string][]k8s.CertificateStatus)
+
+	for _, d := range domains {
+		appCerts, ok := certs[d.AppName]
+		if !ok {
+			client, err := regions.Client(d.Region)
+			if err != nil {
+				slog.Warn("kubernetes not available for region", "region", d.Region, "error", err)
+				continue
+			}
+			appCerts, err = client.ListCertificates(ctx, d.AppName)
+			if err != nil {
+				slog.Warn("failed to list certificates", "app", d.AppName, "error", err)
+				continue
+			}
+			certs[d.AppName] = appCerts
+		}
+
+		m.update(ctx, d, findCertificate(appCerts, d.Domain))
+	}
+
+	return nil
+}
+
+// update stores the certificate state of a domain and alerts when needed
+func (m *Monitor) update(ctx context.Context, d db.ListVerifiedDomainsWithAppsRow, cert *k8s.CertificateStatus) {
+	params := db.UpdateDomainCertificateParams{
+		ID:        d.ID,
+		SslStatus: k8s.CertStatePending,
+	}
+	if cert != nil {
+		params.SslStatus = cert.State
+		if cert.NotAfter != nil {
+			params.SslExpiresAt = pgtype.Timestamptz{Time: *cert.NotAfter, Valid: true}
+		}
+		if cert.Message != "" && cert.State == k8s.CertStateError {
+			params.SslError = &cert.Message
+		}
+	}
+
+	if _, err := m.queries.UpdateDomainCertificate(ctx, params); err != nil {
+		slog.Error("failed to update domain certificate", "domain", d.Domain, "error", err)
+		return
+	}
+
+	event, ok := m.alert(d, cert)
+	if !ok {
+		return
+	}
+	if err := m.events.Publish(ctx, event); err != nil {
+		slog.Error("failed to send certificate alert", "domain", d.Domain, "error", err)
+		return
+	}
+	_ = m.queries.MarkDomainCertificateAlerted(ctx, d.ID)
+}
+
+// alert returns the alert event due for a certificate, if any. The same
+// domain is alerted at most once per alertInterval.
+func (m *Monitor) alert(d db.ListVerifiedDomainsWithAppsRow, cert *k8s.CertificateStatus) (events.Event, bool) {
+	if cert == nil {
+		return events.Event{}, false
+	}
+	if d.SslAlertedAt.Valid && m.now().Sub(d.SslAlertedAt.Time) < alertInterval {
+		return events.Event{}, false
+	}
+
+	n := events.Event{
+		UserID:  d.UserID,
+		AppID:   d.AppID,
+		AppName: d.AppName,
+		Payload: map[string]any{"domain": d.Domain, "certificate": cert.Name},
+	}
+
+	switch {
+	case cert.State == k8s.CertStateError:
+		n.Type = EventIssuanceFailed
+		n.Message = fmt.Sprintf("certificate issuance failed for %s: %s", d.Domain, cert.Message)
+		return n, true
+	case cert.NotAfter != nil && cert.NotAfter.Sub(m.now()) < ExpiryWarning:
+		remaining := cert.NotAfter.Sub(m.now())
+		n.Type = EventExpiring
+		n.Message = fmt.Sprintf("certificate for %s expires in %d days and has not been renewed", d.Domain, int(remaining.Hours()/24))
+		n.Payload["not_after"] = cert.NotAfter
+		return n, true
+	}
+
+	return events.Event{}, false
+}
+
+func findCertificate(certs []k8s.CertificateStatus, domain string) *k8s.CertificateStatus {
+	for i := range certs {
+		if certs[i].Covers(domain) {
+			return &certs[i]
+		}
+	}
+	return nil
+}
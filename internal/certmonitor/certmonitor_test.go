@@ -0,0 +1,68 @@
+package certmonitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestAlert(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	m := &Monitor{now: func() time.Time { return now }}
+	domain := db.ListVerifiedDomainsWithAppsRow{
+		ID:      uuid.New(),
+		Domain:  "www.example.com",
+		AppName: "web",
+		UserID:  uuid.New(),
+	}
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+
+	tests := []struct {
+		name  string
+		cert  *k8s.CertificateStatus
+		event string
+	}{
+		{"no certificate", nil, ""},
+		{"healthy", &k8s.CertificateStatus{State: k8s.CertStateActive, NotAfter: at(60 * 24 * time.Hour)}, ""},
+		{"failed", &k8s.CertificateStatus{State: k8s.CertStateError, Message: "ACME challenge failed"}, EventIssuanceFailed},
+		{"expiring", &k8s.CertificateStatus{State: k8s.CertStateActive, NotAfter: at(5 * 24 * time.Hour)}, EventExpiring},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, ok := m.alert(domain, tt.cert)
+			if ok != (tt.event != "") || n.Type != tt.event {
+				t.Errorf("expected event %q, got %q (alert %v)", tt.event, n.Type, ok)
+			}
+			if ok && n.UserID != domain.UserID {
+				t.Error("expected the app owner to be notified")
+			}
+		})
+	}
+
+	domain.SslAlertedAt = pgtype.Timestamptz{Time: now.Add(-time.Hour), Valid: true}
+	if _, ok := m.alert(domain, &k8s.CertificateStatus{State: k8s.CertStateError}); ok {
+		t.Error("expected recent alerts to be throttled")
+	}
+}
+
+func TestFindCertificate(t *testing.T) {
+	certs := []k8s.CertificateStatus{
+		{Name: "web-tls", DNSNames: []string{"web.nexo.build"}},
+		{Name: "custom-tls", DNSNames: []string{"www.example.com"}},
+	}
+
+	if cert := findCertificate(certs, "www.example.com"); cert == nil || cert.Name != "custom-tls" {
+		t.Errorf("expected custom-tls, got %+v", cert)
+	}
+	if cert := findCertificate(certs, "missing.example.com"); cert != nil {
+		t.Errorf("expected no certificate, got %+v", cert)
+	}
+}
@@ -0,0 +1,109 @@
+// Package clientip resolves the address of the client behind a request.
+// Forwarding headers are only believed when the connection comes from a
+// trusted proxy, since any client can send them.
+package clientip
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ContextKey is the request context key holding the resolved netip.Addr
+const ContextKey = "client_ip"
+
+// ParseTrustedProxies parses CIDR ranges of trusted proxies. Bare addresses
+// are treated as single-host ranges.
+func ParseTrustedProxies(cidrs []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if !strings.Contains(cidr, "/") {
+			addr, err := netip.ParseAddr(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", cidr)
+			}
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", cidr)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// Resolver resolves client addresses behind a set of trusted proxies
+type Resolver struct {
+	trusted []netip.Prefix
+}
+
+// New creates a Resolver. Without trusted proxies the connection's peer
+// address is always the client.
+func New(trusted []netip.Prefix) *Resolver {
+	return &Resolver{trusted: trusted}
+}
+
+// Resolve returns the client address of a request. When the peer is a
+// trusted proxy, X-Forwarded-For is walked from the right, skipping trusted
+// hops, so a client cannot spoof its address by prepending entries; X-Real-IP
+// is used when a trusted proxy sends no X-Forwarded-For. The zero Addr is
+// returned when RemoteAddr cannot be parsed.
+func (r *Resolver) Resolve(req *http.Request) netip.Addr {
+	client, ok := parse(req.RemoteAddr)
+	if !ok || !r.Trusted(client) {
+		return client
+	}
+
+	var hops []string
+	for _, header := range req.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	if len(hops) == 0 {
+		if addr, ok := parse(req.Header.Get("X-Real-IP")); ok {
+			return addr
+		}
+		return client
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, ok := parse(hops[i])
+		if !ok {
+			// Nothing left of a malformed hop can be trusted
+			break
+		}
+		client = addr
+		if !r.Trusted(addr) {
+			break
+		}
+	}
+	return client
+}
+
+// Trusted reports whether addr belongs to a trusted proxy
+func (r *Resolver) Trusted(addr netip.Addr) bool {
+	for _, prefix := range r.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parse accepts an address with or without a port, e.g. RemoteAddr
+func parse(s string) (netip.Addr, bool) {
+	s = strings.TrimSpace(s)
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		addrPort, err := netip.ParseAddrPort(s)
+		if err != nil {
+			return netip.Addr{}, false
+		}
+		addr = addrPort.Addr()
+	}
+	return addr.Unmap(), true
+}
@@ -0,0 +1,70 @@
+package clientip
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resolver := New(trusted)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        string
+		realIP     string
+		want       string
+	}{
+		{"direct client", "203.0.113.7:5123", "", "", "203.0.113.7"},
+		{"untrusted peer cannot spoof", "203.0.113.7:5123", "198.51.100.1", "198.51.100.2", "203.0.113.7"},
+		{"trusted proxy", "10.1.2.3:80", "198.51.100.1", "", "198.51.100.1"},
+		{"spoofed leftmost entry ignored", "10.1.2.3:80", "1.1.1.1, 198.51.100.1", "", "198.51.100.1"},
+		{"chain of trusted proxies", "10.1.2.3:80", "198.51.100.1, 192.0.2.1, 10.9.9.9", "", "198.51.100.1"},
+		{"all hops trusted", "10.1.2.3:80", "10.4.4.4", "", "10.4.4.4"},
+		{"malformed hop", "10.1.2.3:80", "198.51.100.1, garbage", "", "10.1.2.3"},
+		{"hop with port", "10.1.2.3:80", "198.51.100.1:4000", "", "198.51.100.1"},
+		{"x-real-ip from trusted proxy", "10.1.2.3:80", "", "198.51.100.9", "198.51.100.9"},
+		{"ipv6 client", "[2001:db8::1]:443", "", "", "2001:db8::1"},
+		{"ipv4-mapped proxy", "[::ffff:10.1.2.3]:80", "198.51.100.1", "", "198.51.100.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.xff != "" {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+
+			if got := resolver.Resolve(req); got.String() != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestResolve_NoTrustedProxies(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "203.0.113.7:5123"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+
+	if got := New(nil).Resolve(req); got.String() != "203.0.113.7" {
+		t.Errorf("expected the peer address, got %s", got)
+	}
+}
+
+func TestParseTrustedProxies_Invalid(t *testing.T) {
+	if _, err := ParseTrustedProxies([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("expected an error for an invalid range")
+	}
+	if _, err := ParseTrustedProxies([]string{"proxy.local"}); err == nil {
+		t.Error("expected an error for a hostname")
+	}
+}
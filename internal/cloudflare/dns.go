@@ -8,14 +8,22 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
 	"time"
 )
 
+// DefaultBaseURL is the Cloudflare API v4 endpoint
+const DefaultBaseURL = "https://api.cloudflare.com/client/v4"
+
 // Client handles Cloudflare API interactions
 type Client struct {
-	apiToken string
-	zoneID   string
-	http     *http.Client
+	apiToken  string
+	zoneID    string
+	accountID string
+	baseURL   string
+	http      *http.Client
 }
 
 // NewClient creates a new Cloudflare client
@@ -23,12 +31,25 @@ func NewClient(apiToken, zoneID string) *Client {
 	return &Client{
 		apiToken: apiToken,
 		zoneID:   zoneID,
+		baseURL:  DefaultBaseURL,
 		http: &http.Client{
 			Timeout: 30 * time.Second,
 		},
 	}
 }
 
+// WithBaseURL points the client at another API endpoint, such as a Fake
+func (c *Client) WithBaseURL(baseURL string) *Client {
+	c.baseURL = strings.TrimSuffix(baseURL, "/")
+	return c
+}
+
+// WithAccount sets the account tunnels are managed in
+func (c *Client) WithAccount(accountID string) *Client {
+	c.accountID = accountID
+	return c
+}
+
 // DNSRecord represents a Cloudflare DNS record
 type DNSRecord struct {
 	ID       string `json:"id,omitempty"`
@@ -56,21 +77,54 @@ type APIError struct {
 
 // CreateCNAME creates a CNAME record pointing to the platform domain
 func (c *Client) CreateCNAME(ctx context.Context, subdomain, target string) (*DNSRecord, error) {
-	record := DNSRecord{
+	return c.createRecord(ctx, DNSRecord{
 		Type:    "CNAME",
 		Name:    subdomain,
 		Content: target,
 		TTL:     1, // Auto TTL
 		Proxied: true,
+	})
+}
+
+// CreateAddressRecords points name at ips, with an A record for each IPv4
+// address and an AAAA record for each IPv6 one, so dual-stack ingresses are
+// reachable over both families. The records are not proxied, as clients
+// must reach the ingress itself.
+func (c *Client) CreateAddressRecords(ctx context.Context, name string, ips []string) ([]*DNSRecord, error) {
+	var records []*DNSRecord
+	for _, ip := range ips {
+		addr, err := netip.ParseAddr(ip)
+		if err != nil {
+			return records, fmt.Errorf("invalid address %q: %w", ip, err)
+		}
+		addr = addr.Unmap()
+
+		recordType := "A"
+		if addr.Is6() {
+			recordType = "AAAA"
+		}
+		record, err := c.createRecord(ctx, DNSRecord{
+			Type:    recordType,
+			Name:    name,
+			Content: addr.String(),
+			TTL:     1, // Auto TTL
+		})
+		if err != nil {
+			return records, err
+		}
+		records = append(records, record)
 	}
+	return records, nil
+}
 
+func (c *Client) createRecord(ctx context.Context, record DNSRecord) (*DNSRecord, error) {
 	body, err := json.Marshal(record)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal record: %w", err)
 	}
 
-	url := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/dns_records", c.zoneID)
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	endpoint := fmt.Sprintf("%s/zones/%s/dns_records", c.baseURL, c.zoneID)
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -117,8 +171,8 @@ func (c *Client) CreateCNAME(ctx context.Context, subdomain, target string) (*DN
 
 // DeleteRecord deletes a DNS record by ID
 func (c *Client) DeleteRecord(ctx context.Context, recordID string) error {
-	url := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/dns_records/%s", c.zoneID, recordID)
-	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
+	endpoint := fmt.Sprintf("%s/zones/%s/dns_records/%s", c.baseURL, c.zoneID, recordID)
+	req, err := http.NewRequestWithContext(ctx, "DELETE", endpoint, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -153,8 +207,8 @@ func (c *Client) DeleteRecord(ctx context.Context, recordID string) error {
 
 // GetRecordByName finds a DNS record by name
 func (c *Client) GetRecordByName(ctx context.Context, name string) (*DNSRecord, error) {
-	url := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/dns_records?name=%s", c.zoneID, name)
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	endpoint := fmt.Sprintf("%s/zones/%s/dns_records?name=%s", c.baseURL, c.zoneID, url.QueryEscape(name))
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
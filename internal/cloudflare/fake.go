@@ -0,0 +1,343 @@
+package cloudflare
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fake is an in-memory Cloudflare DNS API for tests and local development.
+// It serves the dns_records endpoints of one zone under /client/v4 with the
+// payloads and error codes of the real API, and the cfd_tunnel endpoints of
+// AccountID when it is set. Serve it with httptest.NewServer and point a
+// Client at it with WithBaseURL(server.URL + "/client/v4").
+type Fake struct {
+	Token     string
+	ZoneID    string
+	ZoneName  string
+	AccountID string
+
+	mu      sync.Mutex
+	records []fakeRecord
+	tunnels []fakeTunnel
+	nextID  int
+}
+
+type fakeRecord struct {
+	DNSRecord
+	CreatedOn  time.Time
+	ModifiedOn time.Time
+}
+
+type fakeTunnel struct {
+	Tunnel
+	Routes    []TunnelRoute
+	CreatedOn time.Time
+}
+
+// Error codes the fake answers with, as the real API does
+const (
+	codeAuthentication = 10000
+	codeInvalidRoute   = 7003
+	codeBadRequest     = 1004
+	codeRecordExists   = 81053
+	codeRecordMissing  = 81044
+	codeTunnelExists   = 1013
+	codeTunnelMissing  = 1003
+)
+
+// NewFake returns an empty zone accepting token
+func NewFake(token, zoneID, zoneName string) *Fake {
+	return &Fake{Token: token, ZoneID: zoneID, ZoneName: zoneName}
+}
+
+// AddRecord adds a record as if made outside the platform, such as a
+// customer's CNAME, and returns it with its ID
+func (f *Fake) AddRecord(record DNSRecord) DNSRecord {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.add(record).DNSRecord
+}
+
+// Records returns the zone's records, oldest first
+func (f *Fake) Records() []DNSRecord {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	records := make([]DNSRecord, len(f.records))
+	for i, r := range f.records {
+		records[i] = r.DNSRecord
+	}
+	return records
+}
+
+// TunnelRoutes returns the routes of a tunnel, and false when there is no
+// such tunnel
+func (f *Fake) TunnelRoutes(tunnelID string) ([]TunnelRoute, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := f.findTunnel(tunnelID)
+	if i < 0 {
+		return nil, false
+	}
+	return append([]TunnelRoute{}, f.tunnels[i].Routes...), true
+}
+
+// add stores a record; callers hold the lock
+func (f *Fake) add(record DNSRecord) fakeRecord {
+	f.nextID++
+	record.ID = fmt.Sprintf("%032x", f.nextID)
+	if record.TTL == 0 {
+		record.TTL = 1
+	}
+	now := time.Now().UTC()
+	stored := fakeRecord{DNSRecord: record, CreatedOn: now, ModifiedOn: now}
+	f.records = append(f.records, stored)
+	return stored
+}
+
+func (f *Fake) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+f.Token {
+		f.fail(w, http.StatusForbidden, codeAuthentication, "Authentication error")
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/client/v4")
+	if tunnels := "/accounts/" + f.AccountID + "/cfd_tunnel"; f.AccountID != "" && (path == tunnels || strings.HasPrefix(path, tunnels+"/")) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.tunnel(w, r, strings.TrimPrefix(strings.TrimPrefix(path, tunnels), "/"))
+		return
+	}
+	prefix := "/zones/" + f.ZoneID + "/dns_records"
+	if path != prefix && !strings.HasPrefix(path, prefix+"/") {
+		f.fail(w, http.StatusNotFound, codeInvalidRoute,
+			fmt.Sprintf("Could not route to %s, perhaps your object identifier is invalid?", path))
+		return
+	}
+	recordID := strings.TrimPrefix(strings.TrimPrefix(path, prefix), "/")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case recordID == "" && r.Method == http.MethodGet:
+		f.list(w, r)
+	case recordID == "" && r.Method == http.MethodPost:
+		f.create(w, r)
+	case recordID != "" && r.Method == http.MethodGet:
+		if i := f.find(recordID); i >= 0 {
+			f.succeed(w, http.StatusOK, f.render(f.records[i]))
+			return
+		}
+		f.fail(w, http.StatusNotFound, codeRecordMissing, "Record does not exist.")
+	case recordID != "" && r.Method == http.MethodDelete:
+		i := f.find(recordID)
+		if i < 0 {
+			f.fail(w, http.StatusNotFound, codeRecordMissing, "Record does not exist.")
+			return
+		}
+		f.records = append(f.records[:i], f.records[i+1:]...)
+		f.succeed(w, http.StatusOK, map[string]string{"id": recordID})
+	default:
+		f.fail(w, http.StatusMethodNotAllowed, codeInvalidRoute, "Method not allowed")
+	}
+}
+
+func (f *Fake) list(w http.ResponseWriter, r *http.Request) {
+	name, recordType := r.URL.Query().Get("name"), r.URL.Query().Get("type")
+
+	result := []map[string]any{}
+	for _, record := range f.records {
+		if name != "" && !strings.EqualFold(record.Name, name) {
+			continue
+		}
+		if recordType != "" && record.Type != recordType {
+			continue
+		}
+		result = append(result, f.render(record))
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"success":  true,
+		"errors":   []APIError{},
+		"messages": []string{},
+		"result":   result,
+		"result_info": map[string]int{
+			"page":        1,
+			"per_page":    100,
+			"count":       len(result),
+			"total_count": len(result),
+			"total_pages": 1,
+		},
+	})
+}
+
+func (f *Fake) create(w http.ResponseWriter, r *http.Request) {
+	var record DNSRecord
+	if err := json.NewDecoder(r.Body).Decode(&record); err != nil || record.Type == "" || record.Name == "" || record.Content == "" {
+		f.fail(w, http.StatusBadRequest, codeBadRequest, "DNS Validation Error")
+		return
+	}
+
+	// A CNAME cannot share its name with any other record
+	for _, existing := range f.records {
+		if strings.EqualFold(existing.Name, record.Name) && (record.Type == "CNAME" || existing.Type == "CNAME" || (existing.Type == record.Type && existing.Content == record.Content)) {
+			f.fail(w, http.StatusBadRequest, codeRecordExists, "An A, AAAA, or CNAME record with that host already exists.")
+			return
+		}
+	}
+
+	f.succeed(w, http.StatusOK, f.render(f.add(record)))
+}
+
+// tunnel serves the cfd_tunnel endpoints; path is the part after
+// cfd_tunnel/. Callers hold the lock.
+func (f *Fake) tunnel(w http.ResponseWriter, r *http.Request, path string) {
+	tunnelID, action, _ := strings.Cut(path, "/")
+
+	if tunnelID == "" {
+		if r.Method != http.MethodPost {
+			f.fail(w, http.StatusMethodNotAllowed, codeInvalidRoute, "Method not allowed")
+			return
+		}
+		var body struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Name == "" {
+			f.fail(w, http.StatusBadRequest, codeBadRequest, "Tunnel name is required")
+			return
+		}
+		for _, existing := range f.tunnels {
+			if existing.Name == body.Name {
+				f.fail(w, http.StatusConflict, codeTunnelExists, "You already have a tunnel with this name.")
+				return
+			}
+		}
+		f.nextID++
+		tunnel := fakeTunnel{
+			Tunnel:    Tunnel{ID: fmt.Sprintf("%08x-0000-4000-8000-%012x", f.nextID, f.nextID), Name: body.Name},
+			CreatedOn: time.Now().UTC(),
+		}
+		f.tunnels = append(f.tunnels, tunnel)
+		f.succeed(w, http.StatusOK, f.renderTunnel(tunnel))
+		return
+	}
+
+	i := f.findTunnel(tunnelID)
+	if i < 0 {
+		f.fail(w, http.StatusNotFound, codeTunnelMissing, "Tunnel not found")
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		f.succeed(w, http.StatusOK, f.renderTunnel(f.tunnels[i]))
+	case action == "" && r.Method == http.MethodDelete:
+		tunnel := f.tunnels[i]
+		f.tunnels = append(f.tunnels[:i], f.tunnels[i+1:]...)
+		f.succeed(w, http.StatusOK, f.renderTunnel(tunnel))
+	case action == "token" && r.Method == http.MethodGet:
+		f.succeed(w, http.StatusOK, "token-"+tunnelID)
+	case action == "configurations" && r.Method == http.MethodPut:
+		var body struct {
+			Config struct {
+				Ingress []TunnelRoute `json:"ingress"`
+			} `json:"config"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			f.fail(w, http.StatusBadRequest, codeBadRequest, "Invalid tunnel configuration")
+			return
+		}
+		ingress := body.Config.Ingress
+		// The last rule must catch every hostname
+		if len(ingress) == 0 || ingress[len(ingress)-1].Hostname != "" {
+			f.fail(w, http.StatusBadRequest, codeBadRequest, "The last ingress rule must match all URLs")
+			return
+		}
+		f.tunnels[i].Routes = ingress[:len(ingress)-1]
+		f.succeed(w, http.StatusOK, map[string]any{"tunnel_id": tunnelID, "config": body.Config})
+	case action == "connections" && r.Method == http.MethodDelete:
+		f.succeed(w, http.StatusOK, map[string]any{})
+	default:
+		f.fail(w, http.StatusMethodNotAllowed, codeInvalidRoute, "Method not allowed")
+	}
+}
+
+func (f *Fake) findTunnel(tunnelID string) int {
+	for i, tunnel := range f.tunnels {
+		if tunnel.ID == tunnelID {
+			return i
+		}
+	}
+	return -1
+}
+
+// renderTunnel returns a tunnel as the API does
+func (f *Fake) renderTunnel(tunnel fakeTunnel) map[string]any {
+	return map[string]any{
+		"id":          tunnel.ID,
+		"account_tag": f.AccountID,
+		"name":        tunnel.Name,
+		"config_src":  "cloudflare",
+		"status":      "inactive",
+		"tun_type":    "cfd_tunnel",
+		"connections": []any{},
+		"created_at":  tunnel.CreatedOn.Format(time.RFC3339Nano),
+	}
+}
+
+func (f *Fake) find(recordID string) int {
+	for i, record := range f.records {
+		if record.ID == recordID {
+			return i
+		}
+	}
+	return -1
+}
+
+// render returns a record as the API does
+func (f *Fake) render(record fakeRecord) map[string]any {
+	proxiable := record.Type == "A" || record.Type == "AAAA" || record.Type == "CNAME"
+	return map[string]any{
+		"id":          record.ID,
+		"zone_id":     f.ZoneID,
+		"zone_name":   f.ZoneName,
+		"name":        record.Name,
+		"type":        record.Type,
+		"content":     record.Content,
+		"proxiable":   proxiable,
+		"proxied":     record.Proxied && proxiable,
+		"ttl":         record.TTL,
+		"locked":      false,
+		"meta":        map[string]any{"auto_added": false, "managed_by_apps": false, "managed_by_argo_tunnel": false, "source": "primary"},
+		"created_on":  record.CreatedOn.Format(time.RFC3339Nano),
+		"modified_on": record.ModifiedOn.Format(time.RFC3339Nano),
+	}
+}
+
+func (f *Fake) succeed(w http.ResponseWriter, status int, result any) {
+	writeJSON(w, status, map[string]any{
+		"success":  true,
+		"errors":   []APIError{},
+		"messages": []string{},
+		"result":   result,
+	})
+}
+
+func (f *Fake) fail(w http.ResponseWriter, status, code int, message string) {
+	writeJSON(w, status, map[string]any{
+		"success":  false,
+		"errors":   []APIError{{Code: code, Message: message}},
+		"messages": []string{},
+		"result":   nil,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
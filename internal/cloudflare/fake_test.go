@@ -0,0 +1,167 @@
+package cloudflare
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newFakeClient(t *testing.T) (*Fake, *Client) {
+	t.Helper()
+	fake := NewFake("token", "zone-1", "nexo.build")
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, NewClient("token", "zone-1").WithBaseURL(server.URL + "/client/v4")
+}
+
+func TestFake_Records(t *testing.T) {
+	ctx := context.Background()
+	fake, client := newFakeClient(t)
+
+	record, err := client.SetupAppDomain(ctx, "shop", "nexo.build")
+	if err != nil {
+		t.Fatalf("SetupAppDomain failed: %v", err)
+	}
+	if record.ID == "" || record.Type != "CNAME" || record.Name != "shop.nexo.build" || !record.Proxied {
+		t.Errorf("unexpected record %+v", record)
+	}
+
+	if _, err := client.CreateCNAME(ctx, "shop.nexo.build", "nexo.build"); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected a duplicate CNAME to be rejected, got %v", err)
+	}
+
+	found, err := client.GetRecordByName(ctx, "shop.nexo.build")
+	if err != nil || found == nil || found.ID != record.ID {
+		t.Errorf("GetRecordByName = %+v, %v", found, err)
+	}
+	if missing, err := client.GetRecordByName(ctx, "blog.nexo.build"); err != nil || missing != nil {
+		t.Errorf("expected no record, got %+v, %v", missing, err)
+	}
+
+	if err := client.DeleteRecord(ctx, record.ID); err != nil {
+		t.Fatalf("DeleteRecord failed: %v", err)
+	}
+	if len(fake.Records()) != 0 {
+		t.Errorf("expected the record to be deleted, got %v", fake.Records())
+	}
+	if err := client.DeleteRecord(ctx, record.ID); err == nil || !strings.Contains(err.Error(), "Record does not exist") {
+		t.Errorf("expected deleting a missing record to fail, got %v", err)
+	}
+}
+
+func TestFake_AddressRecords(t *testing.T) {
+	ctx := context.Background()
+	fake, client := newFakeClient(t)
+
+	records, err := client.CreateAddressRecords(ctx, "ingress.gdl.nexo.build", []string{"203.0.113.10", "2001:db8::10", "::ffff:198.51.100.1"})
+	if err != nil {
+		t.Fatalf("CreateAddressRecords failed: %v", err)
+	}
+	want := []struct{ kind, content string }{{"A", "203.0.113.10"}, {"AAAA", "2001:db8::10"}, {"A", "198.51.100.1"}}
+	if len(records) != len(want) {
+		t.Fatalf("expected %d records, got %+v", len(want), records)
+	}
+	for i, w := range want {
+		if records[i].Type != w.kind || records[i].Content != w.content || records[i].Proxied {
+			t.Errorf("record %d = %+v, want %s %s", i, records[i], w.kind, w.content)
+		}
+	}
+	if len(fake.Records()) != 3 {
+		t.Errorf("expected 3 records in the zone, got %v", fake.Records())
+	}
+
+	if _, err := client.CreateAddressRecords(ctx, "ingress.mex.nexo.build", []string{"not-an-ip"}); err == nil {
+		t.Error("expected an invalid address to be rejected")
+	}
+}
+
+func TestFake_VerifyDomain(t *testing.T) {
+	ctx := context.Background()
+	fake, client := newFakeClient(t)
+	fake.AddRecord(DNSRecord{Type: "CNAME", Name: "www.example.com", Content: "shop.nexo.build"})
+	fake.AddRecord(DNSRecord{Type: "CNAME", Name: "old.example.com", Content: "elsewhere.example.net"})
+
+	for _, tc := range []struct {
+		domain   string
+		verified bool
+	}{
+		{"www.example.com", true},
+		{"old.example.com", false},
+		{"new.example.com", false},
+	} {
+		result, err := client.VerifyDomain(ctx, tc.domain, "shop.nexo.build")
+		if err != nil {
+			t.Fatalf("VerifyDomain failed: %v", err)
+		}
+		if result.Verified != tc.verified {
+			t.Errorf("VerifyDomain(%s) = %v (%s), want %v", tc.domain, result.Verified, result.Message, tc.verified)
+		}
+	}
+}
+
+func TestFake_Errors(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake("token", "zone-1", "nexo.build")
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	wrongToken := NewClient("wrong", "zone-1").WithBaseURL(server.URL + "/client/v4")
+	if _, err := wrongToken.GetRecordByName(ctx, "shop.nexo.build"); err == nil || !strings.Contains(err.Error(), "Authentication error") {
+		t.Errorf("expected an authentication error, got %v", err)
+	}
+
+	wrongZone := NewClient("token", "zone-2").WithBaseURL(server.URL + "/client/v4/")
+	if _, err := wrongZone.CreateCNAME(ctx, "shop.nexo.build", "nexo.build"); err == nil || !strings.Contains(err.Error(), "Could not route") {
+		t.Errorf("expected an unknown zone to fail, got %v", err)
+	}
+	if len(fake.Records()) != 0 {
+		t.Errorf("expected no records, got %v", fake.Records())
+	}
+}
+
+func TestFake_Tunnels(t *testing.T) {
+	ctx := context.Background()
+	fake, client := newFakeClient(t)
+
+	if _, err := client.CreateTunnel(ctx, "nexo-shop"); !errors.Is(err, ErrNoAccount) {
+		t.Errorf("expected ErrNoAccount, got %v", err)
+	}
+	fake.AccountID = "account-1"
+	client.WithAccount("account-1")
+
+	tunnel, err := client.CreateTunnel(ctx, "nexo-shop")
+	if err != nil {
+		t.Fatalf("CreateTunnel failed: %v", err)
+	}
+	if tunnel.ID == "" || tunnel.Name != "nexo-shop" || tunnel.Target() != tunnel.ID+".cfargotunnel.com" {
+		t.Errorf("unexpected tunnel %+v", tunnel)
+	}
+	if _, err := client.CreateTunnel(ctx, "nexo-shop"); err == nil || !strings.Contains(err.Error(), "already have a tunnel") {
+		t.Errorf("expected a duplicate name to be rejected, got %v", err)
+	}
+
+	token, err := client.TunnelToken(ctx, tunnel.ID)
+	if err != nil || token == "" {
+		t.Errorf("TunnelToken = %q, %v", token, err)
+	}
+
+	routes := []TunnelRoute{{Hostname: "shop.nexo.build", Service: "http://shop:80"}}
+	if err := client.ConfigureTunnel(ctx, tunnel.ID, routes); err != nil {
+		t.Fatalf("ConfigureTunnel failed: %v", err)
+	}
+	if got, ok := fake.TunnelRoutes(tunnel.ID); !ok || len(got) != 1 || got[0] != routes[0] {
+		t.Errorf("unexpected routes %v", got)
+	}
+
+	if err := client.DeleteTunnel(ctx, tunnel.ID); err != nil {
+		t.Fatalf("DeleteTunnel failed: %v", err)
+	}
+	if _, ok := fake.TunnelRoutes(tunnel.ID); ok {
+		t.Error("expected the tunnel to be deleted")
+	}
+	if err := client.DeleteTunnel(ctx, tunnel.ID); err == nil || !strings.Contains(err.Error(), "Tunnel not found") {
+		t.Errorf("expected deleting a missing tunnel to fail, got %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package cloudflare
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrNoAccount is returned by tunnel calls of a client without an account
+var ErrNoAccount = errors.New("cloudflare account not configured")
+
+// TunnelDomain is the domain a tunnel's ID is reachable under; hostnames
+// routed through a tunnel are proxied CNAMEs to <id>.cfargotunnel.com
+const TunnelDomain = "cfargotunnel.com"
+
+// Tunnel is a remotely-managed Cloudflare Tunnel
+type Tunnel struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Target is the CNAME target of hostnames routed through the tunnel
+func (t *Tunnel) Target() string {
+	return t.ID + "." + TunnelDomain
+}
+
+// TunnelRoute forwards the requests for a hostname to a service reachable
+// from the tunnel's connectors
+type TunnelRoute struct {
+	Hostname string `json:"hostname,omitempty"`
+	Service  string `json:"service"`
+}
+
+// CreateTunnel creates a tunnel whose routes are configured through the API
+// rather than by its connectors
+func (c *Client) CreateTunnel(ctx context.Context, name string) (*Tunnel, error) {
+	var tunnel Tunnel
+	body := map[string]string{"name": name, "config_src": "cloudflare"}
+	if err := c.tunnelRequest(ctx, http.MethodPost, "", body, &tunnel); err != nil {
+		return nil, err
+	}
+	return &tunnel, nil
+}
+
+// TunnelToken returns the token connectors run a tunnel with
+func (c *Client) TunnelToken(ctx context.Context, tunnelID string) (string, error) {
+	var token string
+	if err := c.tunnelRequest(ctx, http.MethodGet, "/"+tunnelID+"/token", nil, &token); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ConfigureTunnel replaces the routes of a tunnel. Requests for any other
+// hostname are answered with a 404.
+func (c *Client) ConfigureTunnel(ctx context.Context, tunnelID string, routes []TunnelRoute) error {
+	ingress := append([]TunnelRoute{}, routes...)
+	ingress = append(ingress, TunnelRoute{Service: "http_status:404"})
+	body := map[string]any{"config": map[string]any{"ingress": ingress}}
+	return c.tunnelRequest(ctx, http.MethodPut, "/"+tunnelID+"/configurations", body, nil)
+}
+
+// DeleteTunnel disconnects the connectors of a tunnel and deletes it
+func (c *Client) DeleteTunnel(ctx context.Context, tunnelID string) error {
+	if err := c.tunnelRequest(ctx, http.MethodDelete, "/"+tunnelID+"/connections", nil, nil); err != nil {
+		return err
+	}
+	return c.tunnelRequest(ctx, http.MethodDelete, "/"+tunnelID, nil, nil)
+}
+
+// tunnelRequest calls an endpoint of the account's tunnels and decodes the
+// result into out, when given
+func (c *Client) tunnelRequest(ctx context.Context, method, path string, body, out any) error {
+	if c.accountID == "" {
+		return ErrNoAccount
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	endpoint := fmt.Sprintf("%s/accounts/%s/cfd_tunnel%s", c.baseURL, c.accountID, path)
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.apiToken)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var apiResp struct {
+		Success bool            `json:"success"`
+		Errors  []APIError      `json:"errors"`
+		Result  json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(respBody, &apiResp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !apiResp.Success {
+		if len(apiResp.Errors) > 0 {
+			return fmt.Errorf("cloudflare error: %s", apiResp.Errors[0].Message)
+		}
+		return fmt.Errorf("cloudflare request failed")
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(apiResp.Result, out); err != nil {
+		return fmt.Errorf("failed to parse result: %w", err)
+	}
+	return nil
+}
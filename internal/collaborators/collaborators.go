@@ -0,0 +1,163 @@
+// Package collaborators grants users rights on a single app of another user,
+// such as a contractor deploying one app, without access to the rest of the
+// owner's apps or organization. The auth middleware checks a collaborator's
+// role on every request to the app's API and then acts as the app's owner.
+package collaborators
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/google/uuid"
+)
+
+// Roles of a collaborator, each granting the rights of the one before
+const (
+	// RoleView reads the app: status, deployments, logs and metrics
+	RoleView = "view"
+	// RoleDeploy also deploys, rolls back and restarts the app, and reads
+	// its settings with the env redacted
+	RoleDeploy = "deploy"
+	// RoleAdmin also changes the app's settings and collaborators, but
+	// cannot delete it
+	RoleAdmin = "admin"
+)
+
+// ErrAmbiguous is returned when a user collaborates on apps of several
+// owners with the same name
+var ErrAmbiguous = errors.New("collaborator of several apps with this name")
+
+// ValidRole reports whether role is view, deploy or admin
+func ValidRole(role string) bool {
+	return role == RoleView || role == RoleDeploy || role == RoleAdmin
+}
+
+// AppName returns the app whose API path is, as in /api/apps/{name}/...
+func AppName(path string) (string, bool) {
+	rest, ok := strings.CutPrefix(path, "/api/apps/")
+	if !ok {
+		return "", false
+	}
+	name, _, _ := strings.Cut(rest, "/")
+	return name, name != ""
+}
+
+// viewPaths are what every role may read under /api/apps/{name}: the app's
+// status, deployments, logs and metrics. Paths use * to match one segment.
+var viewPaths = []string{
+	"",
+	"pods",
+	"deployments",
+	"deployments/*",
+	"logs",
+	"metrics",
+}
+
+// deployReadPaths are what the deploy role may also read, to prepare and
+// follow up on deployments. The env is only listed redacted.
+var deployReadPaths = []string{
+	"activity",
+	"crons",
+	"crons/*",
+	"crons/*/runs",
+	previewPath,
+	"deployments/*/lockfile",
+	"diagnostics",
+	"domains",
+	"domains/*",
+	"domains/*/instructions",
+	"env",
+	"hooks",
+	"processes",
+	"recommendations",
+	"scale",
+}
+
+// previewPath renders the manifests of a deployment; deployments/* is meant
+// for deployment IDs and must not match it
+const previewPath = "deployments/preview"
+
+// deployPaths are what the deploy role may POST to under /api/apps/{name}
+var deployPaths = []string{
+	"deployments",
+	"deployments/*",
+	"restart",
+	"pods/*/restart",
+}
+
+// Allows reports whether a collaborator with role may make a request with
+// method to path of the app's API. Reads are allowed path by path, so the
+// owner's secrets, such as the unredacted env, exports, certificates and
+// database previews, are only read by admins.
+func Allows(role, method, path string, query url.Values) bool {
+	name, ok := AppName(path)
+	if !ok || !ValidRole(role) {
+		return false
+	}
+	rest := strings.Trim(strings.TrimPrefix(path, "/api/apps/"+name), "/")
+
+	if role == RoleAdmin {
+		return !(method == http.MethodDelete && rest == "")
+	}
+
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		if rest != previewPath && matchAny(viewPaths, rest) {
+			return true
+		}
+		if role != RoleDeploy || !matchAny(deployReadPaths, rest) {
+			return false
+		}
+		return rest != "env" || query.Get("redacted") != "false"
+	case http.MethodPost:
+		return role == RoleDeploy && rest != previewPath && matchAny(deployPaths, rest)
+	}
+	return false
+}
+
+// matchAny reports whether path matches one of patterns
+func matchAny(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if matchPath(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath matches path against pattern segment by segment
+func matchPath(pattern, path string) bool {
+	patternSegments, pathSegments := strings.Split(pattern, "/"), strings.Split(path, "/")
+	if len(patternSegments) != len(pathSegments) {
+		return false
+	}
+	for i, segment := range patternSegments {
+		if segment != "*" && segment != pathSegments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Resolve returns the collaboration of userID on the app called name, or
+// nil when the user collaborates on no app with that name
+func Resolve(ctx context.Context, queries *db.Queries, userID uuid.UUID, name string) (*db.ListCollaborationsByAppNameRow, error) {
+	rows, err := queries.ListCollaborationsByAppName(ctx, db.ListCollaborationsByAppNameParams{
+		UserID: userID,
+		Name:   name,
+	})
+	if err != nil {
+		return nil, err
+	}
+	switch len(rows) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &rows[0], nil
+	}
+	return nil, ErrAmbiguous
+}
@@ -0,0 +1,94 @@
+package collaborators
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestAppName(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+		ok   bool
+	}{
+		{"/api/apps/shop", "shop", true},
+		{"/api/apps/shop/deployments/123", "shop", true},
+		{"/api/apps", "", false},
+		{"/api/apps/", "", false},
+		{"/api/projects/shop", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := AppName(tt.path); got != tt.want || ok != tt.ok {
+			t.Errorf("AppName(%q) = %q, %v, want %q, %v", tt.path, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestAllows(t *testing.T) {
+	tests := []struct {
+		role   string
+		method string
+		path   string
+		want   bool
+	}{
+		{RoleView, http.MethodGet, "/api/apps/shop", true},
+		{RoleView, http.MethodGet, "/api/apps/shop/logs", true},
+		{RoleView, http.MethodGet, "/api/apps/shop/metrics", true},
+		{RoleView, http.MethodGet, "/api/apps/shop/deployments/123", true},
+		{RoleView, http.MethodGet, "/api/apps/shop/env", false},
+		{RoleView, http.MethodGet, "/api/apps/shop/export", false},
+		{RoleView, http.MethodGet, "/api/apps/shop/databases/preview", false},
+		{RoleView, http.MethodGet, "/api/apps/shop/deployments/preview", false},
+		{RoleView, http.MethodGet, "/api/apps/shop/mtls/certs/123", false},
+		{RoleView, http.MethodPost, "/api/apps/shop/deployments", false},
+		{RoleView, http.MethodPost, "/api/apps/shop/restart", false},
+
+		{RoleDeploy, http.MethodGet, "/api/apps/shop/deployments", true},
+		{RoleDeploy, http.MethodGet, "/api/apps/shop/env", true},
+		{RoleDeploy, http.MethodGet, "/api/apps/shop/export", false},
+		{RoleDeploy, http.MethodGet, "/api/apps/shop/mtls/certs/123", false},
+		{RoleDeploy, http.MethodPost, "/api/apps/shop/deployments", true},
+		{RoleDeploy, http.MethodPost, "/api/apps/shop/deployments/123", true},
+		{RoleDeploy, http.MethodGet, "/api/apps/shop/deployments/preview", true},
+		{RoleDeploy, http.MethodPost, "/api/apps/shop/deployments/preview", false},
+		{RoleDeploy, http.MethodPost, "/api/apps/shop/restart", true},
+		{RoleDeploy, http.MethodPost, "/api/apps/shop/pods/web-1/restart", true},
+		{RoleDeploy, http.MethodPost, "/api/apps/shop/scale", false},
+		{RoleDeploy, http.MethodPut, "/api/apps/shop/env", false},
+		{RoleDeploy, http.MethodPost, "/api/apps/shop/collaborators", false},
+		{RoleDeploy, http.MethodDelete, "/api/apps/shop", false},
+
+		{RoleAdmin, http.MethodGet, "/api/apps/shop/export", true},
+		{RoleAdmin, http.MethodPost, "/api/apps/shop/scale", true},
+		{RoleAdmin, http.MethodPut, "/api/apps/shop/env", true},
+		{RoleAdmin, http.MethodPost, "/api/apps/shop/collaborators", true},
+		{RoleAdmin, http.MethodDelete, "/api/apps/shop/domains/shop.example.com", true},
+		{RoleAdmin, http.MethodDelete, "/api/apps/shop", false},
+		{RoleAdmin, http.MethodDelete, "/api/apps/shop/", false},
+
+		{"owner", http.MethodGet, "/api/apps/shop", false},
+		{RoleAdmin, http.MethodGet, "/api/projects/shop", false},
+	}
+	for _, tt := range tests {
+		if got := Allows(tt.role, tt.method, tt.path, nil); got != tt.want {
+			t.Errorf("Allows(%s, %s %s) = %v, want %v", tt.role, tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestAllowsUnredactedEnv(t *testing.T) {
+	unredacted := url.Values{"redacted": {"false"}}
+	for _, tt := range []struct {
+		role string
+		want bool
+	}{
+		{RoleView, false},
+		{RoleDeploy, false},
+		{RoleAdmin, true},
+	} {
+		if got := Allows(tt.role, http.MethodGet, "/api/apps/shop/env", unredacted); got != tt.want {
+			t.Errorf("Allows(%s, GET env?redacted=false) = %v, want %v", tt.role, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,471 @@
+// Package compliance compiles the evidence an organization hands auditors,
+// such as for SOC 2: who has access and whether their GitHub account uses
+// two-factor authentication, the API tokens they hold, who signed in from
+// where, what was deployed by whom and every other change over a time range.
+// Bundles are signed archives, so the platform can later tell an auditor
+// whether one is unchanged since it was issued.
+package compliance
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ActionGenerated is recorded in the activity log when a bundle is
+// downloaded
+const ActionGenerated = "compliance.report_generated"
+
+// MaxRange is the longest time range of a bundle
+const MaxRange = 366 * 24 * time.Hour
+
+// maxEntries caps the activity entries and deployments of a bundle; a
+// bundle reaching it is marked truncated
+const maxEntries = 50000
+
+// ManifestFile is the file of a bundle listing the others and signing them
+const ManifestFile = "manifest.json"
+
+var (
+	// ErrInvalidArchive is returned for archives that are not bundles
+	ErrInvalidArchive = errors.New("not a compliance bundle")
+	// ErrInvalidSignature is returned when the manifest was not signed by
+	// the platform or changed since
+	ErrInvalidSignature = errors.New("bundle signature is invalid")
+	// ErrModified is returned when a file does not match the manifest
+	ErrModified = errors.New("bundle was modified")
+)
+
+// Manifest describes a bundle
+type Manifest struct {
+	Org         string    `json:"org"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	GeneratedAt time.Time `json:"generated_at"`
+	GeneratedBy string    `json:"generated_by"`
+	// Truncated is set when the range held more activity or deployments
+	// than a bundle holds; a shorter range gets all of them
+	Truncated bool   `json:"truncated"`
+	Files     []File `json:"files"`
+	// Signature is an HMAC of the rest of the manifest with the platform's
+	// signing key
+	Signature string `json:"signature,omitempty"`
+}
+
+// File is a file of a bundle and its SHA-256
+type File struct {
+	Name    string `json:"name"`
+	SHA256  string `json:"sha256"`
+	Entries int    `json:"entries"`
+}
+
+// Member is a user with access to the organization's apps
+type Member struct {
+	Username string `json:"username"`
+	// Role is owner, member or machine
+	Role string `json:"role"`
+	// TwoFactor is whether the user's GitHub account had two-factor
+	// authentication at their last login; null when unknown, such as for
+	// machine users, who never log in
+	TwoFactor          *bool      `json:"two_factor"`
+	TwoFactorCheckedAt *time.Time `json:"two_factor_checked_at,omitempty"`
+}
+
+// Token is an API token of a member. Uses counts the requests made with it
+// in the range.
+type Token struct {
+	ID             uuid.UUID  `json:"id"`
+	Owner          string     `json:"owner"`
+	Name           string     `json:"name"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	LastUsedAt     *time.Time `json:"last_used_at"`
+	LastUsedIP     string     `json:"last_used_ip,omitempty"`
+	AllowedCIDRs   []string   `json:"allowed_cidrs"`
+	RegistryAccess *string    `json:"registry_access,omitempty"`
+	Uses           int64      `json:"uses"`
+}
+
+// Entry is an activity log entry
+type Entry struct {
+	Time       time.Time       `json:"time"`
+	Action     string          `json:"action"`
+	User       string          `json:"user,omitempty"`
+	App        string          `json:"app,omitempty"`
+	IP         string          `json:"ip,omitempty"`
+	APITokenID *uuid.UUID      `json:"api_token_id,omitempty"`
+	Details    json.RawMessage `json:"details,omitempty"`
+}
+
+// Deployment is a deployment of an app of a member, with the CI run that
+// made it
+type Deployment struct {
+	ID         uuid.UUID   `json:"id"`
+	App        string      `json:"app"`
+	Version    int32       `json:"version"`
+	Image      string      `json:"image"`
+	Status     string      `json:"status"`
+	DeployedBy string      `json:"deployed_by"`
+	CreatedAt  time.Time   `json:"created_at"`
+	Provenance *Provenance `json:"provenance,omitempty"`
+}
+
+// Provenance is the CI run of a deployment
+type Provenance struct {
+	Provider   string `json:"provider"`
+	Repository string `json:"repository"`
+	CommitSHA  string `json:"commit_sha"`
+	RunURL     string `json:"run_url"`
+	Verified   bool   `json:"verified"`
+}
+
+// Bundle is the evidence of an organization over a time range
+type Bundle struct {
+	Manifest    Manifest
+	Members     []Member
+	Tokens      []Token
+	Access      []Entry
+	Deployments []Deployment
+	Changes     []Entry
+}
+
+// Collect gathers the evidence of an organization between from and to.
+// Sign-ins, failed authentications and lockouts are access evidence; every
+// other activity of members and on their apps is change history.
+func Collect(ctx context.Context, queries *db.Queries, org db.Organization, generatedBy string, from, to time.Time) (*Bundle, error) {
+	users, err := queries.ListOrganizationUsers(ctx, org.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organization users: %w", err)
+	}
+
+	b := &Bundle{
+		Manifest: Manifest{
+			Org:         org.Name,
+			From:        from.UTC(),
+			To:          to.UTC(),
+			GeneratedAt: time.Now().UTC(),
+			GeneratedBy: generatedBy,
+		},
+		Members:     []Member{},
+		Tokens:      []Token{},
+		Access:      []Entry{},
+		Deployments: []Deployment{},
+		Changes:     []Entry{},
+	}
+
+	usernames := make(map[uuid.UUID]string, len(users))
+	userIDs := make([]uuid.UUID, 0, len(users))
+	for _, u := range users {
+		usernames[u.ID] = u.Username
+		userIDs = append(userIDs, u.ID)
+		b.Members = append(b.Members, Member{
+			Username:           u.Username,
+			Role:               u.Role,
+			TwoFactor:          u.TwoFactor,
+			TwoFactorCheckedAt: timePtr(u.TwoFactorCheckedAt),
+		})
+
+		if err := b.collectTokens(ctx, queries, u.ID, u.Username, from, to); err != nil {
+			return nil, err
+		}
+	}
+
+	logs, err := queries.ListActivityLogsForUsersBetween(ctx, db.ListActivityLogsForUsersBetweenParams{
+		UserIds:    userIDs,
+		FromTime:   from,
+		ToTime:     to,
+		LimitCount: maxEntries,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list activity: %w", err)
+	}
+	b.Manifest.Truncated = len(logs) == maxEntries
+
+	apps := make(map[uuid.UUID]string)
+	for _, l := range logs {
+		entry := Entry{
+			Time:    l.CreatedAt.UTC(),
+			Action:  l.Action,
+			Details: l.Details,
+		}
+		if l.UserID.Valid {
+			entry.User = usernames[l.UserID.Bytes]
+		}
+		if l.AppID.Valid {
+			entry.App = appName(ctx, queries, apps, l.AppID.Bytes)
+		}
+		if l.IpAddress != nil {
+			entry.IP = l.IpAddress.String()
+		}
+		if l.ApiTokenID.Valid {
+			tokenID := uuid.UUID(l.ApiTokenID.Bytes)
+			entry.APITokenID = &tokenID
+		}
+
+		if strings.HasPrefix(l.Action, "security.") {
+			b.Access = append(b.Access, entry)
+		} else {
+			b.Changes = append(b.Changes, entry)
+		}
+	}
+
+	deployments, err := queries.ListDeploymentsForUsersBetween(ctx, db.ListDeploymentsForUsersBetweenParams{
+		UserIds:    userIDs,
+		FromTime:   from,
+		ToTime:     to,
+		LimitCount: maxEntries,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	if len(deployments) == maxEntries {
+		b.Manifest.Truncated = true
+	}
+	for _, d := range deployments {
+		deployment := Deployment{
+			ID:         d.ID,
+			App:        d.AppName,
+			Version:    d.Version,
+			Image:      d.Image,
+			Status:     d.Status,
+			DeployedBy: d.DeployedBy,
+			CreatedAt:  d.CreatedAt.UTC(),
+		}
+		if d.Provider != nil {
+			deployment.Provenance = &Provenance{
+				Provider:   *d.Provider,
+				Repository: deref(d.Repository),
+				CommitSHA:  deref(d.CommitSha),
+				RunURL:     deref(d.RunUrl),
+				Verified:   d.Verified != nil && *d.Verified,
+			}
+		}
+		b.Deployments = append(b.Deployments, deployment)
+	}
+
+	return b, nil
+}
+
+func (b *Bundle) collectTokens(ctx context.Context, queries *db.Queries, userID uuid.UUID, username string, from, to time.Time) error {
+	tokens, err := queries.ListAPITokensByUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to list tokens of %s: %w", username, err)
+	}
+	usage, err := queries.ListAPITokenUsageByUser(ctx, db.ListAPITokenUsageByUserParams{
+		UserID: userID,
+		Day:    pgtype.Date{Time: from, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list token usage of %s: %w", username, err)
+	}
+
+	uses := make(map[uuid.UUID]int64, len(tokens))
+	for _, u := range usage {
+		if u.Day.Valid && u.Day.Time.Before(to) {
+			uses[u.TokenID] += int64(u.Count)
+		}
+	}
+
+	for _, t := range tokens {
+		token := Token{
+			ID:             t.ID,
+			Owner:          username,
+			Name:           t.Name,
+			CreatedAt:      t.CreatedAt.UTC(),
+			ExpiresAt:      timePtr(t.ExpiresAt),
+			LastUsedAt:     timePtr(t.LastUsedAt),
+			AllowedCIDRs:   t.AllowedCidrs,
+			RegistryAccess: t.RegistryAccess,
+			Uses:           uses[t.ID],
+		}
+		if t.LastUsedIp != nil {
+			token.LastUsedIP = t.LastUsedIp.String()
+		}
+		b.Tokens = append(b.Tokens, token)
+	}
+	return nil
+}
+
+// Name is the name of the bundle's archive, without extension
+func (b *Bundle) Name() string {
+	return fmt.Sprintf("%s-compliance-%s-%s", b.Manifest.Org, b.Manifest.From.Format("20060102"), b.Manifest.To.Format("20060102"))
+}
+
+// Archive writes the bundle as a gzipped tarball of JSON files and a signed
+// manifest listing them
+func (b *Bundle) Archive(key string) ([]byte, error) {
+	contents := []struct {
+		name    string
+		value   any
+		entries int
+	}{
+		{"members.json", b.Members, len(b.Members)},
+		{"tokens.json", b.Tokens, len(b.Tokens)},
+		{"access.json", b.Access, len(b.Access)},
+		{"deployments.json", b.Deployments, len(b.Deployments)},
+		{"changes.json", b.Changes, len(b.Changes)},
+	}
+
+	manifest := b.Manifest
+	manifest.Files = nil
+	files := make(map[string][]byte, len(contents)+1)
+	names := make([]string, 0, len(contents)+1)
+	for _, c := range contents {
+		data, err := json.MarshalIndent(c.value, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s: %w", c.name, err)
+		}
+		sum := sha256.Sum256(data)
+		manifest.Files = append(manifest.Files, File{Name: c.name, SHA256: hex.EncodeToString(sum[:]), Entries: c.entries})
+		files[c.name] = data
+		names = append(names, c.name)
+	}
+
+	if err := Sign(&manifest, key); err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	files[ManifestFile] = data
+	names = append([]string{ManifestFile}, names...)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range names {
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    b.Name() + "/" + name,
+			Mode:    0o644,
+			Size:    int64(len(files[name])),
+			ModTime: manifest.GeneratedAt,
+		}); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Sign sets the signature of a manifest
+func Sign(manifest *Manifest, key string) error {
+	signature, err := signature(*manifest, key)
+	if err != nil {
+		return err
+	}
+	manifest.Signature = signature
+	return nil
+}
+
+func signature(manifest Manifest, key string) (string, error) {
+	manifest.Signature = ""
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Verify checks that an archive is a bundle signed with key whose files
+// are unchanged, and returns its manifest
+func Verify(r io.Reader, key string) (Manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return Manifest{}, ErrInvalidArchive
+	}
+	tr := tar.NewReader(gz)
+
+	files := make(map[string][]byte)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Manifest{}, ErrInvalidArchive
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return Manifest{}, ErrInvalidArchive
+		}
+		files[path.Base(header.Name)] = data
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(files[ManifestFile], &manifest); err != nil {
+		return Manifest{}, ErrInvalidArchive
+	}
+	want, err := signature(manifest, key)
+	if err != nil || !hmac.Equal([]byte(manifest.Signature), []byte(want)) {
+		return manifest, ErrInvalidSignature
+	}
+
+	if len(files) != len(manifest.Files)+1 {
+		return manifest, ErrModified
+	}
+	for _, f := range manifest.Files {
+		data, ok := files[f.Name]
+		if !ok {
+			return manifest, fmt.Errorf("%w: %s is missing", ErrModified, f.Name)
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != f.SHA256 {
+			return manifest, fmt.Errorf("%w: %s changed", ErrModified, f.Name)
+		}
+	}
+	return manifest, nil
+}
+
+// appName resolves the name of an app, caching it; deleted apps have none
+func appName(ctx context.Context, queries *db.Queries, cache map[uuid.UUID]string, id uuid.UUID) string {
+	if name, ok := cache[id]; ok {
+		return name
+	}
+	var name string
+	if app, err := queries.GetAppByID(ctx, id); err == nil {
+		name = app.Name
+	}
+	cache[id] = name
+	return name
+}
+
+func timePtr(t pgtype.Timestamptz) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	utc := t.Time.UTC()
+	return &utc
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
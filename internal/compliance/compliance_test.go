@@ -0,0 +1,113 @@
+package compliance
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func testBundle() *Bundle {
+	enabled := true
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &Bundle{
+		Manifest: Manifest{
+			Org:         "acme",
+			From:        from,
+			To:          from.AddDate(0, 3, 0),
+			GeneratedAt: from.AddDate(0, 3, 1),
+			GeneratedBy: "alice",
+		},
+		Members:     []Member{{Username: "alice", Role: "owner", TwoFactor: &enabled}},
+		Tokens:      []Token{},
+		Access:      []Entry{{Time: from, Action: "security.login", User: "alice", IP: "203.0.113.7"}},
+		Deployments: []Deployment{},
+		Changes:     []Entry{{Time: from, Action: "deployment.created", User: "alice", App: "web"}},
+	}
+}
+
+func TestArchiveVerify(t *testing.T) {
+	bundle := testBundle()
+	if bundle.Name() != "acme-compliance-20260101-20260401" {
+		t.Errorf("unexpected name %q", bundle.Name())
+	}
+
+	archive, err := bundle.Archive("key")
+	if err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+
+	manifest, err := Verify(bytes.NewReader(archive), "key")
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if manifest.Org != "acme" || len(manifest.Files) != 5 {
+		t.Errorf("unexpected manifest %+v", manifest)
+	}
+	for _, f := range manifest.Files {
+		if f.Name == "access.json" && f.Entries != 1 {
+			t.Errorf("expected 1 access entry, got %d", f.Entries)
+		}
+	}
+
+	if _, err := Verify(bytes.NewReader(archive), "other"); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected another key to be rejected, got %v", err)
+	}
+	if _, err := Verify(bytes.NewReader([]byte("not a tarball")), "key"); !errors.Is(err, ErrInvalidArchive) {
+		t.Errorf("expected ErrInvalidArchive, got %v", err)
+	}
+}
+
+func TestVerifyModified(t *testing.T) {
+	archive, err := testBundle().Archive("key")
+	if err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+
+	// Rewrite the archive with the access log emptied
+	modified := rewrite(t, archive, func(name string, data []byte) []byte {
+		if name == "acme-compliance-20260101-20260401/access.json" {
+			return []byte("[]")
+		}
+		return data
+	})
+	if _, err := Verify(bytes.NewReader(modified), "key"); !errors.Is(err, ErrModified) {
+		t.Errorf("expected ErrModified, got %v", err)
+	}
+}
+
+func rewrite(t *testing.T, archive []byte, edit func(name string, data []byte) []byte) []byte {
+	t.Helper()
+
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	tr := tar.NewReader(gz)
+
+	var buf bytes.Buffer
+	out := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(out)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar: %v", err)
+		}
+		data, _ := io.ReadAll(tr)
+		data = edit(header.Name, data)
+		header.Size = int64(len(data))
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatalf("tar: %v", err)
+		}
+		_, _ = tw.Write(data)
+	}
+	_ = tw.Close()
+	_ = out.Close()
+	return buf.Bytes()
+}
@@ -0,0 +1,273 @@
+// Package compression gzips HTTP responses for clients that accept it and
+// decodes gzip-compressed request bodies, e.g. large deploy payloads from the
+// CLI. Only textual content types over MinSize are compressed; small bodies
+// gain nothing and binary formats are usually compressed already.
+package compression
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MinSize is the smallest response body worth compressing
+const MinSize = 1024
+
+// MaxDecodedSize bounds the decompressed size of a request body, so a small
+// compressed body cannot expand without limit
+const MaxDecodedSize = 32 << 20
+
+// ErrUnsupportedEncoding is returned for a request body in an encoding other
+// than gzip
+var ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+
+// ErrTooLarge is returned when a decompressed request body exceeds
+// MaxDecodedSize
+var ErrTooLarge = errors.New("decompressed request body too large")
+
+// Compressible reports whether responses of a content type are compressed
+func Compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case mediaType == "text/event-stream":
+		// Streams are flushed event by event
+		return false
+	case strings.HasPrefix(mediaType, "text/"),
+		mediaType == "application/json",
+		strings.HasSuffix(mediaType, "+json"),
+		mediaType == "application/javascript",
+		mediaType == "application/xml",
+		mediaType == "image/svg+xml":
+		return true
+	}
+	return false
+}
+
+// AcceptsGzip reports whether an Accept-Encoding header allows gzip, taking
+// "gzip;q=0" as a refusal
+func AcceptsGzip(acceptEncoding string) bool {
+	accepted := false
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.ReplaceAll(params, " ", ""), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		if coding == "gzip" {
+			return q > 0
+		}
+		accepted = q > 0
+	}
+	return accepted
+}
+
+var gzipWriters = sync.Pool{
+	New: func() any { return gzip.NewWriter(io.Discard) },
+}
+
+// ResponseWriter gzips the response body when its content type is
+// compressible and it reaches MinSize. The start of the body is buffered
+// until that is known; Close must be called to write out the rest.
+type ResponseWriter struct {
+	http.ResponseWriter
+
+	status  int
+	buf     []byte
+	gz      *gzip.Writer
+	decided bool
+}
+
+// NewResponseWriter wraps a response writer for a client accepting gzip
+func NewResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	return &ResponseWriter{ResponseWriter: w}
+}
+
+// WriteHeader records the status; it is sent with the first body bytes
+func (w *ResponseWriter) WriteHeader(status int) {
+	if w.status == 0 && !w.decided {
+		w.status = status
+	}
+}
+
+// Write implements io.Writer
+func (w *ResponseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.decided {
+		if w.gz != nil {
+			return w.gz.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= MinSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// decide sends the header, choosing compression when the body is large
+// enough, and writes out the buffered bytes
+func (w *ResponseWriter) decide(large bool) error {
+	w.decided = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	header := w.Header()
+	if header.Get("Content-Type") == "" && len(w.buf) > 0 {
+		header.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+	if large && w.compressible() {
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		w.gz = gzipWriters.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.gz != nil {
+		_, err = w.gz.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+func (w *ResponseWriter) compressible() bool {
+	header := w.Header()
+	return w.status != http.StatusNoContent && w.status != http.StatusNotModified &&
+		header.Get("Content-Encoding") == "" && Compressible(header.Get("Content-Type"))
+}
+
+// Flush sends what was written so far, so streaming responses keep working
+func (w *ResponseWriter) Flush() {
+	if !w.decided {
+		if err := w.decide(false); err != nil {
+			return
+		}
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack lets connection upgrades through; nothing must have been written
+func (w *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok || w.decided {
+		return nil, nil, http.ErrNotSupported
+	}
+	w.decided = true
+	return hijacker.Hijack()
+}
+
+// Unwrap returns the wrapped writer for http.ResponseController
+func (w *ResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Close writes out a response that stayed below MinSize and finishes the
+// gzip stream
+func (w *ResponseWriter) Close() error {
+	if !w.decided {
+		if w.status == 0 && len(w.buf) == 0 {
+			// Nothing was written; the handler did not respond
+			w.decided = true
+			return nil
+		}
+		if err := w.decide(false); err != nil {
+			return err
+		}
+	}
+	if w.gz == nil {
+		return nil
+	}
+	err := w.gz.Close()
+	w.gz.Reset(io.Discard)
+	gzipWriters.Put(w.gz)
+	w.gz = nil
+	return err
+}
+
+// DecodeRequest replaces a gzip-compressed request body with its decompressed
+// content. Bodies without Content-Encoding are left alone.
+func DecodeRequest(r *http.Request) error {
+	encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+	switch encoding {
+	case "", "identity":
+		return nil
+	case "gzip", "x-gzip":
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedEncoding, encoding)
+	}
+
+	zr, err := gzip.NewReader(r.Body)
+	if err != nil {
+		return fmt.Errorf("invalid gzip body: %w", err)
+	}
+	r.Body = &decodedBody{reader: zr, body: r.Body, remaining: MaxDecodedSize}
+	r.Header.Del("Content-Encoding")
+	r.Header.Del("Content-Length")
+	r.ContentLength = -1
+	return nil
+}
+
+// decodedBody reads a decompressed request body up to a limit
+type decodedBody struct {
+	reader    *gzip.Reader
+	body      io.ReadCloser
+	remaining int64
+}
+
+func (b *decodedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		// Anything left beyond the limit is an error, not a silent cut
+		var probe [1]byte
+		if n, _ := b.reader.Read(probe[:]); n > 0 {
+			return 0, ErrTooLarge
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.reader.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+func (b *decodedBody) Close() error {
+	return errors.Join(b.reader.Close(), b.body.Close())
+}
@@ -0,0 +1,197 @@
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func gzipped(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"gzip", true},
+		{"gzip, deflate, br", true},
+		{"br;q=1.0, GZIP;q=0.5", true},
+		{"*", true},
+		{"gzip;q=0", false},
+		{"*;q=0", false},
+		{"gzip;q=0, *", false},
+		{"deflate, br", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := AcceptsGzip(tt.header); got != tt.want {
+			t.Errorf("AcceptsGzip(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestCompressible(t *testing.T) {
+	for _, contentType := range []string{"application/json; charset=utf-8", "text/plain", "text/html; charset=utf-8", "application/problem+json"} {
+		if !Compressible(contentType) {
+			t.Errorf("expected %q to be compressible", contentType)
+		}
+	}
+	for _, contentType := range []string{"text/event-stream", "image/png", "application/gzip", "application/octet-stream", ""} {
+		if Compressible(contentType) {
+			t.Errorf("expected %q not to be compressible", contentType)
+		}
+	}
+}
+
+func TestResponseWriter(t *testing.T) {
+	large := []byte(`{"points":[` + strings.Repeat(`{"t":1,"v":2},`, 200) + `{}]}`)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		status      int
+		compressed  bool
+	}{
+		{"large json", "application/json; charset=utf-8", large, 200, true},
+		{"large json error", "application/json; charset=utf-8", large, 500, true},
+		{"small json", "application/json; charset=utf-8", []byte(`{"ok":true}`), 201, false},
+		{"large binary", "application/octet-stream", large, 200, false},
+		{"no content", "", nil, 204, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			w := NewResponseWriter(rec)
+			if tt.contentType != "" {
+				w.Header().Set("Content-Type", tt.contentType)
+			}
+			w.WriteHeader(tt.status)
+			// Written in chunks, like json.Encoder does for large values
+			for chunk := range chunks(tt.body, 300) {
+				if _, err := w.Write(chunk); err != nil {
+					t.Fatalf("write: %v", err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("close: %v", err)
+			}
+
+			if rec.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, rec.Code)
+			}
+			body := rec.Body.Bytes()
+			if gotCompressed := rec.Header().Get("Content-Encoding") == "gzip"; gotCompressed != tt.compressed {
+				t.Fatalf("expected compressed=%v, got headers %v", tt.compressed, rec.Header())
+			}
+			if tt.compressed {
+				zr, err := gzip.NewReader(bytes.NewReader(body))
+				if err != nil {
+					t.Fatalf("invalid gzip: %v", err)
+				}
+				if body, err = io.ReadAll(zr); err != nil {
+					t.Fatalf("invalid gzip: %v", err)
+				}
+			}
+			if !bytes.Equal(body, tt.body) {
+				t.Errorf("body mismatch: got %d bytes, want %d", len(body), len(tt.body))
+			}
+		})
+	}
+}
+
+// chunks yields data in chunks of n bytes
+func chunks(data []byte, n int) func(yield func([]byte) bool) {
+	return func(yield func([]byte) bool) {
+		for len(data) > 0 {
+			chunk := data[:min(n, len(data))]
+			data = data[len(chunk):]
+			if !yield(chunk) {
+				return
+			}
+		}
+	}
+}
+
+func TestResponseWriter_Flush(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := NewResponseWriter(rec)
+	w.Header().Set("Content-Type", "text/event-stream")
+
+	if _, err := w.Write([]byte("data: hello\n\n")); err != nil {
+		t.Fatal(err)
+	}
+	w.Flush()
+
+	if !rec.Flushed || rec.Body.String() != "data: hello\n\n" {
+		t.Errorf("expected the event to be flushed uncompressed, got %q", rec.Body.String())
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDecodeRequest(t *testing.T) {
+	payload := []byte(`{"image":"ghcr.io/acme/api:v2"}`)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/apps/acme/deployments", bytes.NewReader(gzipped(t, payload)))
+	req.Header.Set("Content-Encoding", "gzip")
+	if err := DecodeRequest(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(body, payload) || req.Header.Get("Content-Encoding") != "" {
+		t.Errorf("expected the decompressed body, got %q", body)
+	}
+
+	plain := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(payload))
+	if err := DecodeRequest(plain); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	br := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(payload))
+	br.Header.Set("Content-Encoding", "br")
+	if err := DecodeRequest(br); !errors.Is(err, ErrUnsupportedEncoding) {
+		t.Errorf("expected ErrUnsupportedEncoding, got %v", err)
+	}
+
+	invalid := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(payload))
+	invalid.Header.Set("Content-Encoding", "gzip")
+	if err := DecodeRequest(invalid); err == nil {
+		t.Error("expected an error for a body that is not gzip")
+	}
+}
+
+func TestDecodeRequest_Bomb(t *testing.T) {
+	bomb := gzipped(t, make([]byte, MaxDecodedSize+1))
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(bomb))
+	req.Header.Set("Content-Encoding", "gzip")
+	if err := DecodeRequest(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := io.Copy(io.Discard, req.Body); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("expected ErrTooLarge, got %v", err)
+	}
+}
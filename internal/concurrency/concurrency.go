@@ -0,0 +1,171 @@
+// Package concurrency caps how many expensive operations, such as deploys
+// and log streams, one account runs at once, so a single tenant's automation
+// cannot exhaust the control plane. An operation over the limit waits in line
+// for a slot for a short while and is otherwise rejected with a LimitError.
+// Limits are enforced per API replica.
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+// Operation is a kind of expensive operation
+type Operation string
+
+// Operations with a concurrency limit
+const (
+	Deploy    Operation = "deploy"
+	LogStream Operation = "log_stream"
+)
+
+func (op Operation) plural() string {
+	switch op {
+	case Deploy:
+		return "deploys"
+	case LogStream:
+		return "log streams"
+	}
+	return string(op) + " operations"
+}
+
+// LimitError is returned when an account keeps running as many operations
+// as it may for the whole wait
+type LimitError struct {
+	Operation Operation
+	Limit     int
+	// RetryAfter is a hint of when a slot may be free
+	RetryAfter time.Duration
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%d of %d concurrent %s of this account are in progress; retry in %s",
+		e.Limit, e.Limit, e.Operation.plural(), e.RetryAfter)
+}
+
+// RetryAfterSeconds formats RetryAfter for the Retry-After header
+func (e *LimitError) RetryAfterSeconds() string {
+	return strconv.Itoa(int(e.RetryAfter.Round(time.Second).Seconds()))
+}
+
+// Reject answers a request for which Acquire returned err: 429 with a
+// Retry-After hint for a LimitError, 503 when the wait was canceled
+func Reject(c *fuego.Context, err error) error {
+	var limit *LimitError
+	if !errors.As(err, &limit) {
+		return c.JSON(503, map[string]string{"error": "request canceled"})
+	}
+	c.Response.Header().Set("Retry-After", limit.RetryAfterSeconds())
+	return c.JSON(429, map[string]any{
+		"error":     limit.Error(),
+		"reason":    "concurrency_limit",
+		"operation": limit.Operation,
+		"limit":     limit.Limit,
+	})
+}
+
+// Limiter hands out slots of each operation to accounts
+type Limiter struct {
+	mu     sync.Mutex
+	limits map[Operation]int
+	wait   time.Duration
+	slots  map[slotKey]*slots
+}
+
+type slotKey struct {
+	op      Operation
+	account uuid.UUID
+}
+
+// slots is a semaphore shared by the requests of an account holding or
+// waiting for a slot, dropped once none are
+type slots struct {
+	sem  chan struct{}
+	refs int
+}
+
+// NewLimiter creates a limiter. Operations without a positive limit are not
+// limited; wait is how long an operation waits in line for a slot.
+func NewLimiter(limits map[Operation]int, wait time.Duration) *Limiter {
+	return &Limiter{
+		limits: limits,
+		wait:   wait,
+		slots:  make(map[slotKey]*slots),
+	}
+}
+
+// Acquire takes a slot of an operation for an account, waiting in line when
+// all are taken. Calling release frees the slot. The error is a *LimitError
+// when no slot freed up in time, or the context's error. A nil limiter
+// limits nothing.
+func (l *Limiter) Acquire(ctx context.Context, op Operation, account uuid.UUID) (release func(), err error) {
+	if l == nil || l.limits[op] <= 0 {
+		return func() {}, nil
+	}
+
+	key := slotKey{op: op, account: account}
+	s := l.ref(key)
+
+	select {
+	case s.sem <- struct{}{}:
+	default:
+		timer := time.NewTimer(l.wait)
+		defer timer.Stop()
+		select {
+		case s.sem <- struct{}{}:
+		case <-timer.C:
+			l.unref(key)
+			return nil, &LimitError{Operation: op, Limit: l.limits[op], RetryAfter: max(l.wait, time.Second)}
+		case <-ctx.Done():
+			l.unref(key)
+			return nil, ctx.Err()
+		}
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			<-s.sem
+			l.unref(key)
+		})
+	}, nil
+}
+
+// InUse returns how many slots of an operation an account holds
+func (l *Limiter) InUse(op Operation, account uuid.UUID) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if s, ok := l.slots[slotKey{op: op, account: account}]; ok {
+		return len(s.sem)
+	}
+	return 0
+}
+
+func (l *Limiter) ref(key slotKey) *slots {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s, ok := l.slots[key]
+	if !ok {
+		s = &slots{sem: make(chan struct{}, l.limits[key.op])}
+		l.slots[key] = s
+	}
+	s.refs++
+	return s
+}
+
+func (l *Limiter) unref(key slotKey) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if s, ok := l.slots[key]; ok {
+		if s.refs--; s.refs == 0 {
+			delete(l.slots, key)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+)
+
+func TestAcquire(t *testing.T) {
+	limiter := NewLimiter(map[Operation]int{Deploy: 2}, 20*time.Millisecond)
+	account := uuid.New()
+
+	release1, err := limiter.Acquire(context.Background(), Deploy, account)
+	if err != nil {
+		t.Fatalf("expected a slot, got %v", err)
+	}
+	release2, err := limiter.Acquire(context.Background(), Deploy, account)
+	if err != nil {
+		t.Fatalf("expected a second slot, got %v", err)
+	}
+
+	_, err = limiter.Acquire(context.Background(), Deploy, account)
+	var limited *LimitError
+	if !errors.As(err, &limited) || limited.Limit != 2 || limited.Operation != Deploy {
+		t.Fatalf("expected a limit error, got %v", err)
+	}
+	if want := "2 of 2 concurrent deploys of this account are in progress; retry in 1s"; limited.Error() != want {
+		t.Errorf("expected %q, got %q", want, limited.Error())
+	}
+	if limited.RetryAfterSeconds() != "1" {
+		t.Errorf("expected to retry in 1 second, got %s", limited.RetryAfterSeconds())
+	}
+
+	// Other accounts and operations have their own slots
+	if release, err := limiter.Acquire(context.Background(), Deploy, uuid.New()); err != nil {
+		t.Errorf("expected another account to get a slot, got %v", err)
+	} else {
+		release()
+	}
+	if release, err := limiter.Acquire(context.Background(), LogStream, account); err != nil {
+		t.Errorf("expected an unlimited operation to run, got %v", err)
+	} else {
+		release()
+	}
+
+	release1()
+	release1() // releasing twice frees one slot only
+	if got := limiter.InUse(Deploy, account); got != 1 {
+		t.Errorf("expected 1 slot in use, got %d", got)
+	}
+	release2()
+	if got := limiter.InUse(Deploy, account); got != 0 {
+		t.Errorf("expected no slots in use, got %d", got)
+	}
+	if len(limiter.slots) != 0 {
+		t.Errorf("expected idle accounts to be dropped, got %d", len(limiter.slots))
+	}
+}
+
+func TestAcquire_Queues(t *testing.T) {
+	limiter := NewLimiter(map[Operation]int{LogStream: 1}, time.Second)
+	account := uuid.New()
+
+	release, err := limiter.Acquire(context.Background(), LogStream, account)
+	if err != nil {
+		t.Fatalf("expected a slot, got %v", err)
+	}
+	time.AfterFunc(20*time.Millisecond, release)
+
+	// Waits in line for the first stream to end
+	next, err := limiter.Acquire(context.Background(), LogStream, account)
+	if err != nil {
+		t.Fatalf("expected the queued operation to get the freed slot, got %v", err)
+	}
+	next()
+
+	hold, _ := limiter.Acquire(context.Background(), LogStream, account)
+	defer hold()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := limiter.Acquire(ctx, LogStream, account); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the canceled request to leave the line, got %v", err)
+	}
+}
+
+func TestAcquire_NilLimiter(t *testing.T) {
+	var limiter *Limiter
+	release, err := limiter.Acquire(context.Background(), Deploy, uuid.New())
+	if err != nil {
+		t.Fatalf("expected a nil limiter to limit nothing, got %v", err)
+	}
+	release()
+}
+
+func TestReject(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := fuego.NewContext(w, httptest.NewRequest(http.MethodPost, "/api/apps/shop/deployments", nil))
+	err := &LimitError{Operation: Deploy, Limit: 2, RetryAfter: 3 * time.Second}
+
+	if err := Reject(c, fmt.Errorf("acquire: %w", err)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.Code != 429 || w.Header().Get("Retry-After") != "3" {
+		t.Errorf("expected 429 with Retry-After 3, got %d %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if !strings.Contains(w.Body.String(), `"reason":"concurrency_limit"`) {
+		t.Errorf("expected the concurrency_limit reason, got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	c = fuego.NewContext(w, httptest.NewRequest(http.MethodPost, "/api/apps/shop/deployments", nil))
+	if err := Reject(c, context.Canceled); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.Code != 503 {
+		t.Errorf("expected 503 for a canceled wait, got %d", w.Code)
+	}
+}
@@ -2,8 +2,11 @@
 package config
 
 import (
+	"errors"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 )
 
 // Config holds application configuration.
@@ -22,30 +25,192 @@ type Config struct {
 	GitHubClientSecret string
 	GitHubCallbackURL  string
 
+	// DemoMode seeds a demo user with sample apps, signs visitors in as it
+	// at /demo and fakes the cluster when none is configured
+	DemoMode bool
+
 	JWTSecret     string
 	EncryptionKey string
+	// URLSigningKey signs expiring download URLs; defaults to JWTSecret
+	URLSigningKey string
 
 	Kubeconfig         string
 	K8sNamespacePrefix string
 
+	// Regions apps can be deployed to. A region uses the cluster from
+	// KUBECONFIG_<REGION> when set, otherwise the default kubeconfig.
+	Regions           []string
+	RegionKubeconfigs map[string]string
+	// IngressIPs are the public ingress addresses of each region, published
+	// as A/AAAA records for apex custom domains
+	IngressIPs       []string
+	RegionIngressIPs map[string][]string
+	// EgressIPs are the static addresses of each region's egress gateway,
+	// allocated one per app that asks for a static egress IP
+	EgressIPs       []string
+	RegionEgressIPs map[string][]string
+	// DualStack gives app Services both an IPv4 and an IPv6 cluster IP, for
+	// clusters with both families enabled. DUAL_STACK_<REGION> overrides it
+	// per region.
+	DualStack       bool
+	RegionDualStack map[string]bool
+	// TraefikMetricsURL is the Prometheus endpoint of the ingress controller,
+	// scraped for per-app bandwidth. TRAEFIK_METRICS_URL_<REGION> overrides it
+	// per region.
+	TraefikMetricsURL        string
+	RegionTraefikMetricsURLs map[string]string
+
 	CloudflareAPIToken string
 	CloudflareZoneID   string
+	// CloudflareAPIURL overrides the Cloudflare API endpoint, such as the
+	// fake served by nexo-fakes
+	CloudflareAPIURL string
+	// CloudflareAccountID is the account app tunnels are created in
+	CloudflareAccountID string
+	// CloudflareTunnel exposes apps through a Cloudflare Tunnel per app
+	// instead of the ingress, for clusters without a public load balancer.
+	// CLOUDFLARE_TUNNEL_<REGION> overrides it per region.
+	CloudflareTunnel       bool
+	RegionCloudflareTunnel map[string]bool
+	// CloudflaredImage overrides the image of the tunnel connectors of apps
+	CloudflaredImage string
+
+	// Nameservers are the hostnames of the platform's authoritative
+	// nameservers, which users delegate subdomain zones to. DNSAddr is where
+	// the nameserver listens over UDP and TCP. Zone delegation is off
+	// without Nameservers.
+	Nameservers []string
+	DNSAddr     string
 
 	GHCRToken string
 
+	// CIOIDCAudience is the audience of the OIDC tokens CI runs present to
+	// prove where a deployment came from. GitLabURL is the GitLab instance
+	// issuing GitLab CI tokens.
+	CIOIDCAudience string
+	GitLabURL      string
+
+	// ConcurrentDeploys and ConcurrentLogStreams cap the deploys and live log
+	// streams one account runs at once on each API replica; 0 disables a
+	// limit. Operations over a limit wait ConcurrencyQueueSeconds for a slot.
+	ConcurrentDeploys       int
+	ConcurrentLogStreams    int
+	ConcurrencyQueueSeconds int
+
+	// RequestTimeoutSeconds bounds API requests without a route timeout.
+	// RouteTimeouts override route timeouts as "METHOD /path=duration",
+	// where a "*" segment matches any one segment.
+	RequestTimeoutSeconds int
+	RouteTimeouts         []string
+
+	// MaxRequestBodyBytes caps request bodies after decompression.
+	// AllowUnknownJSONFields stops rejecting JSON bodies with fields the
+	// route does not know, for clients that cannot be fixed right away.
+	MaxRequestBodyBytes    int
+	AllowUnknownJSONFields bool
+
+	// StreamsPerUser caps the live streams, such as followed logs, a user
+	// keeps open on each API replica. Streams get a heartbeat every
+	// StreamHeartbeatSeconds and close after StreamIdleMinutes without data.
+	StreamsPerUser         int
+	StreamHeartbeatSeconds int
+	StreamIdleMinutes      int
+
 	StripeSecretKey     string
 	StripeWebhookSecret string
+	// StripeAPIURL overrides the Stripe API endpoint, such as a local fake
+	StripeAPIURL string
+	// StripePrices are the Stripe price IDs of the plans sold through
+	// checkout, set as STRIPE_PRICE_<PLAN>
+	StripePrices map[string]string
 
 	PlatformDomain   string
 	AppsDomainSuffix string
+
+	// CORSAllowedOrigins are the browser origins allowed to call the API,
+	// e.g. https://*.nexo.build. Defaults to the dashboard on PlatformDomain,
+	// plus the local dev servers outside production.
+	CORSAllowedOrigins []string
+
+	// TrustedProxies are the CIDR ranges of reverse proxies whose
+	// X-Forwarded-For headers are believed. Without them the connection's
+	// peer address is the client.
+	TrustedProxies []string
+
+	// NotifyWebhookURL receives alerts such as failing or expiring certificates
+	NotifyWebhookURL string
+
+	// SMTP server emailing alerts to app owners; email is disabled without
+	// SMTPAddr
+	SMTPAddr     string
+	SMTPFrom     string
+	SMTPUsername string
+	SMTPPassword string
+
+	// SentryDSN turns on error reporting to Sentry; SentryRelease tags the
+	// reports with the deployed version
+	SentryDSN     string
+	SentryRelease string
+
+	// AdminUsernames are the GitHub usernames allowed to use the platform
+	// admin API
+	AdminUsernames []string
+
+	// Platform CA issuing client certificates for apps that enforce mTLS
+	MTLSCACertFile string
+	MTLSCAKeyFile  string
+
+	// RegistryHost is the platform registry users push images to, disabled
+	// when empty. RegistryURL is where the platform reads it, the public host
+	// by default. Its tokens are signed with the key and certificate the
+	// registry trusts, and pushes stop at RegistryQuotaGB per user.
+	RegistryHost          string
+	RegistryURL           string
+	RegistryTokenKeyFile  string
+	RegistryTokenCertFile string
+	RegistryQuotaGB       int
+
+	// BackupURL is where disaster-recovery snapshots are written, either
+	// s3://bucket/prefix or file:///path. Snapshots are disabled without it.
+	BackupURL           string
+	BackupIntervalHours int
+	BackupS3Endpoint    string
+	BackupS3Region      string
+	BackupS3AccessKeyID string
+	BackupS3SecretKey   string
+
+	// ActivityRetentionDays overrides how many days the activity logs of a
+	// plan's accounts are kept, set as ACTIVITY_RETENTION_DAYS_<PLAN>.
+	// ActivityArchive exports expired logs to BACKUP_URL before they are
+	// deleted.
+	ActivityRetentionDays map[string]string
+	ActivityArchive       bool
+
+	// PgBouncerImage overrides the image of the connection pooler sidecars
+	// of pooled database add-ons
+	PgBouncerImage string
+
+	// OTelCollectorImage overrides the image of the OpenTelemetry
+	// collectors of apps
+	OTelCollectorImage string
+
+	// DisabledJobs are the names of background jobs that must not run.
+	// JobSchedules overrides the schedule of a job, set as
+	// JOB_SCHEDULE_<NAME>.
+	DisabledJobs []string
+	JobSchedules map[string]string
 }
 
 // Load loads configuration from environment variables.
 func Load() *Config {
+	regions := getEnvList("REGIONS", "gdl,mex,qro")
+	environment := getEnv("ENVIRONMENT", "development")
+	platformDomain := getEnv("PLATFORM_DOMAIN", "cloud.nexo.build")
+
 	return &Config{
 		Port:        getEnvInt("PORT", 3000),
 		Host:        getEnv("HOST", "0.0.0.0"),
-		Environment: getEnv("ENVIRONMENT", "development"),
+		Environment: environment,
 
 		DatabaseURL: getEnv("DATABASE_URL", "postgres://neondb_owner@localhost:5432/neondb?sslmode=disable"),
 
@@ -57,22 +222,107 @@ func Load() *Config {
 		GitHubClientSecret: getEnv("GITHUB_CLIENT_SECRET", ""),
 		GitHubCallbackURL:  getEnv("GITHUB_CALLBACK_URL", "http://localhost:3000/api/auth/callback"),
 
+		DemoMode: getEnv("DEMO_MODE", "") == "true",
+
 		JWTSecret:     getEnv("JWT_SECRET", ""),
 		EncryptionKey: getEnv("ENCRYPTION_KEY", ""),
+		URLSigningKey: getEnv("URL_SIGNING_KEY", ""),
 
 		Kubeconfig:         getEnv("KUBECONFIG", ""),
 		K8sNamespacePrefix: getEnv("K8S_NAMESPACE_PREFIX", "tenant-"),
 
+		Regions:           regions,
+		RegionKubeconfigs: regionKubeconfigs(regions),
+		IngressIPs:        getEnvList("INGRESS_IPS", ""),
+		RegionIngressIPs:  regionIngressIPs(regions),
+		EgressIPs:         getEnvList("EGRESS_IPS", ""),
+		RegionEgressIPs:   regionEgressIPs(regions),
+		DualStack:         getEnv("DUAL_STACK", "") == "true",
+		RegionDualStack:   regionDualStack(regions),
+
+		TraefikMetricsURL:        getEnv("TRAEFIK_METRICS_URL", ""),
+		RegionTraefikMetricsURLs: regionTraefikMetricsURLs(regions),
+
 		CloudflareAPIToken: getEnv("CLOUDFLARE_API_TOKEN", ""),
 		CloudflareZoneID:   getEnv("CLOUDFLARE_ZONE_ID", ""),
+		CloudflareAPIURL:   getEnv("CLOUDFLARE_API_URL", ""),
+
+		CloudflareAccountID:    getEnv("CLOUDFLARE_ACCOUNT_ID", ""),
+		CloudflareTunnel:       getEnv("CLOUDFLARE_TUNNEL", "") == "true",
+		RegionCloudflareTunnel: regionCloudflareTunnel(regions),
+		CloudflaredImage:       getEnv("CLOUDFLARED_IMAGE", ""),
+
+		Nameservers: getEnvList("NAMESERVERS", ""),
+		DNSAddr:     getEnv("DNS_ADDR", ":5353"),
 
 		GHCRToken: getEnv("GHCR_TOKEN", ""),
 
+		CIOIDCAudience: getEnv("CI_OIDC_AUDIENCE", "nexo-cloud"),
+		GitLabURL:      getEnv("GITLAB_URL", "https://gitlab.com"),
+
+		ConcurrentDeploys:       getEnvInt("CONCURRENT_DEPLOYS", 3),
+		ConcurrentLogStreams:    getEnvInt("CONCURRENT_LOG_STREAMS", 5),
+		ConcurrencyQueueSeconds: getEnvInt("CONCURRENCY_QUEUE_SECONDS", 10),
+
+		RequestTimeoutSeconds: getEnvInt("REQUEST_TIMEOUT_SECONDS", 30),
+		RouteTimeouts:         getEnvList("ROUTE_TIMEOUTS", ""),
+
+		MaxRequestBodyBytes:    getEnvInt("MAX_REQUEST_BODY_BYTES", 1<<20),
+		AllowUnknownJSONFields: getEnv("ALLOW_UNKNOWN_JSON_FIELDS", "") == "true",
+
+		StreamsPerUser:         getEnvInt("STREAMS_PER_USER", 10),
+		StreamHeartbeatSeconds: getEnvInt("STREAM_HEARTBEAT_SECONDS", 30),
+		StreamIdleMinutes:      getEnvInt("STREAM_IDLE_MINUTES", 30),
+
 		StripeSecretKey:     getEnv("STRIPE_SECRET_KEY", ""),
 		StripeWebhookSecret: getEnv("STRIPE_WEBHOOK_SECRET", ""),
+		StripeAPIURL:        getEnv("STRIPE_API_URL", ""),
+		StripePrices:        prefixedEnv("STRIPE_PRICE_"),
 
-		PlatformDomain:   getEnv("PLATFORM_DOMAIN", "cloud.nexo.build"),
+		PlatformDomain:   platformDomain,
 		AppsDomainSuffix: getEnv("APPS_DOMAIN_SUFFIX", "nexo.build"),
+
+		CORSAllowedOrigins: getEnvList("CORS_ALLOWED_ORIGINS", defaultCORSOrigins(environment, platformDomain)),
+
+		TrustedProxies: getEnvList("TRUSTED_PROXIES", ""),
+
+		NotifyWebhookURL: getEnv("NOTIFY_WEBHOOK_URL", ""),
+
+		SMTPAddr:     getEnv("SMTP_ADDR", ""),
+		SMTPFrom:     getEnv("SMTP_FROM", "nexo cloud <noreply@nexo.build>"),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+
+		SentryDSN:     getEnv("SENTRY_DSN", ""),
+		SentryRelease: getEnv("SENTRY_RELEASE", ""),
+
+		AdminUsernames: getEnvList("ADMIN_USERNAMES", ""),
+
+		MTLSCACertFile: getEnv("MTLS_CA_CERT_FILE", ""),
+		MTLSCAKeyFile:  getEnv("MTLS_CA_KEY_FILE", ""),
+
+		RegistryHost:          getEnv("REGISTRY_HOST", ""),
+		RegistryURL:           getEnv("REGISTRY_URL", ""),
+		RegistryTokenKeyFile:  getEnv("REGISTRY_TOKEN_KEY_FILE", ""),
+		RegistryTokenCertFile: getEnv("REGISTRY_TOKEN_CERT_FILE", ""),
+		RegistryQuotaGB:       getEnvInt("REGISTRY_QUOTA_GB", 10),
+
+		BackupURL:           getEnv("BACKUP_URL", ""),
+		BackupIntervalHours: getEnvInt("BACKUP_INTERVAL_HOURS", 24),
+		BackupS3Endpoint:    getEnv("BACKUP_S3_ENDPOINT", ""),
+		BackupS3Region:      getEnv("BACKUP_S3_REGION", "us-east-1"),
+		BackupS3AccessKeyID: getEnv("BACKUP_S3_ACCESS_KEY_ID", ""),
+		BackupS3SecretKey:   getEnv("BACKUP_S3_SECRET_ACCESS_KEY", ""),
+
+		ActivityRetentionDays: prefixedEnv("ACTIVITY_RETENTION_DAYS_"),
+		ActivityArchive:       getEnv("ACTIVITY_ARCHIVE", "") == "true",
+
+		PgBouncerImage: getEnv("PGBOUNCER_IMAGE", ""),
+
+		OTelCollectorImage: getEnv("OTEL_COLLECTOR_IMAGE", ""),
+
+		DisabledJobs: getEnvList("DISABLED_JOBS", ""),
+		JobSchedules: prefixedEnv("JOB_SCHEDULE_"),
 	}
 }
 
@@ -81,6 +331,134 @@ func (c *Config) IsDevelopment() bool {
 	return c.Environment == "development"
 }
 
+// CheckDemoMode rejects demo mode configured with KUBECONFIG or a
+// KUBECONFIG_<REGION>. Demo visitors are signed in anonymously as the demo
+// user and would deploy to the real cluster.
+func (c *Config) CheckDemoMode() error {
+	if c.DemoMode && (c.Kubeconfig != "" || len(c.RegionKubeconfigs) > 0) {
+		return errors.New("demo mode runs on a fake cluster: unset KUBECONFIG and KUBECONFIG_<REGION>")
+	}
+	return nil
+}
+
+// KubeconfigForRegion returns the kubeconfig of the cluster serving a region.
+func (c *Config) KubeconfigForRegion(region string) string {
+	if kubeconfig, ok := c.RegionKubeconfigs[region]; ok {
+		return kubeconfig
+	}
+	return c.Kubeconfig
+}
+
+// SigningKey returns the key used to sign download URLs.
+func (c *Config) SigningKey() string {
+	if c.URLSigningKey != "" {
+		return c.URLSigningKey
+	}
+	return c.JWTSecret
+}
+
+// RegistryAPI returns the base URL the platform reads its registry at
+func (c *Config) RegistryAPI() string {
+	if c.RegistryURL != "" {
+		return strings.TrimSuffix(c.RegistryURL, "/")
+	}
+	return "https://" + c.RegistryHost
+}
+
+// IngressIPsForRegion returns the public ingress addresses of a region.
+func (c *Config) IngressIPsForRegion(region string) []string {
+	if ips, ok := c.RegionIngressIPs[region]; ok {
+		return ips
+	}
+	return c.IngressIPs
+}
+
+// EgressIPsForRegion returns the static egress addresses of a region.
+func (c *Config) EgressIPsForRegion(region string) []string {
+	if ips, ok := c.RegionEgressIPs[region]; ok {
+		return ips
+	}
+	return c.EgressIPs
+}
+
+// DualStackForRegion reports whether the cluster of a region runs app
+// Services with both IP families.
+func (c *Config) DualStackForRegion(region string) bool {
+	if dualStack, ok := c.RegionDualStack[region]; ok {
+		return dualStack
+	}
+	return c.DualStack
+}
+
+// TunnelForRegion reports whether the apps of a region are exposed through
+// Cloudflare Tunnels rather than the cluster's ingress.
+func (c *Config) TunnelForRegion(region string) bool {
+	if tunnel, ok := c.RegionCloudflareTunnel[region]; ok {
+		return tunnel
+	}
+	return c.CloudflareTunnel
+}
+
+// TraefikMetricsURLForRegion returns the ingress metrics endpoint of a
+// region, or an empty string when bandwidth is not metered there.
+func (c *Config) TraefikMetricsURLForRegion(region string) string {
+	if metricsURL, ok := c.RegionTraefikMetricsURLs[region]; ok {
+		return metricsURL
+	}
+	return c.TraefikMetricsURL
+}
+
+// MTLSVerifyURL returns the endpoint the ingress asks whether a client
+// certificate of an app is still valid.
+func (c *Config) MTLSVerifyURL(appName string) string {
+	return "https://" + c.PlatformDomain + "/api/mtls/verify?app=" + url.QueryEscape(appName)
+}
+
+// IsAdmin reports whether a user may use the platform admin API.
+func (c *Config) IsAdmin(username string) bool {
+	for _, admin := range c.AdminUsernames {
+		if strings.EqualFold(admin, username) {
+			return true
+		}
+	}
+	return false
+}
+
+// JobEnabled reports whether a background job may run.
+func (c *Config) JobEnabled(name string) bool {
+	for _, disabled := range c.DisabledJobs {
+		if strings.EqualFold(disabled, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// StripePrice returns the Stripe price ID of a plan, false for plans not
+// sold through checkout.
+func (c *Config) StripePrice(plan string) (string, bool) {
+	price, ok := c.StripePrices[strings.ToLower(plan)]
+	return price, ok
+}
+
+// ActivityRetention returns how many days the activity logs of a plan's
+// accounts are kept, or defaultDays when it is not overridden.
+func (c *Config) ActivityRetention(plan string, defaultDays int) int {
+	if days, err := strconv.Atoi(c.ActivityRetentionDays[strings.ToLower(plan)]); err == nil && days > 0 {
+		return days
+	}
+	return defaultDays
+}
+
+// JobSchedule returns the configured schedule of a background job, or
+// defaultSchedule when it is not overridden.
+func (c *Config) JobSchedule(name, defaultSchedule string) string {
+	if schedule, ok := c.JobSchedules[strings.ToLower(name)]; ok {
+		return schedule
+	}
+	return defaultSchedule
+}
+
 // IsProduction checks if the environment is production.
 func (c *Config) IsProduction() bool {
 	return c.Environment == "production"
@@ -93,6 +471,97 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getEnvList(key, defaultValue string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnv(key, defaultValue), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func regionKubeconfigs(regions []string) map[string]string {
+	kubeconfigs := make(map[string]string)
+	for _, region := range regions {
+		if kubeconfig := os.Getenv("KUBECONFIG_" + strings.ToUpper(region)); kubeconfig != "" {
+			kubeconfigs[region] = kubeconfig
+		}
+	}
+	return kubeconfigs
+}
+
+func regionIngressIPs(regions []string) map[string][]string {
+	ips := make(map[string][]string)
+	for _, region := range regions {
+		if regionIPs := getEnvList("INGRESS_IPS_"+strings.ToUpper(region), ""); len(regionIPs) > 0 {
+			ips[region] = regionIPs
+		}
+	}
+	return ips
+}
+
+func regionEgressIPs(regions []string) map[string][]string {
+	ips := make(map[string][]string)
+	for _, region := range regions {
+		if regionIPs := getEnvList("EGRESS_IPS_"+strings.ToUpper(region), ""); len(regionIPs) > 0 {
+			ips[region] = regionIPs
+		}
+	}
+	return ips
+}
+
+func regionDualStack(regions []string) map[string]bool {
+	dualStack := make(map[string]bool)
+	for _, region := range regions {
+		if value := os.Getenv("DUAL_STACK_" + strings.ToUpper(region)); value != "" {
+			dualStack[region] = value == "true"
+		}
+	}
+	return dualStack
+}
+
+func regionCloudflareTunnel(regions []string) map[string]bool {
+	tunnel := make(map[string]bool)
+	for _, region := range regions {
+		if value := os.Getenv("CLOUDFLARE_TUNNEL_" + strings.ToUpper(region)); value != "" {
+			tunnel[region] = value == "true"
+		}
+	}
+	return tunnel
+}
+
+func regionTraefikMetricsURLs(regions []string) map[string]string {
+	urls := make(map[string]string)
+	for _, region := range regions {
+		if metricsURL := os.Getenv("TRAEFIK_METRICS_URL_" + strings.ToUpper(region)); metricsURL != "" {
+			urls[region] = metricsURL
+		}
+	}
+	return urls
+}
+
+func defaultCORSOrigins(environment, platformDomain string) string {
+	origins := "https://" + platformDomain
+	if environment != "production" {
+		origins += ",http://localhost:3000,http://localhost:5173"
+	}
+	return origins
+}
+
+// prefixedEnv returns the variables named prefix<NAME>, keyed by the
+// lowercased name
+func prefixedEnv(prefix string) map[string]string {
+	values := make(map[string]string)
+	for _, env := range os.Environ() {
+		key, value, _ := strings.Cut(env, "=")
+		if name, ok := strings.CutPrefix(key, prefix); ok && name != "" && strings.TrimSpace(value) != "" {
+			values[strings.ToLower(name)] = strings.TrimSpace(value)
+		}
+	}
+	return values
+}
+
 func getEnvInt(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"reflect"
 	"testing"
 )
 
@@ -12,12 +13,23 @@ func clearConfigEnv(t *testing.T) {
 		"PORT", "HOST", "ENVIRONMENT", "DATABASE_URL",
 		"NEON_API_KEY", "NEON_PROJECT_ID", "BRANCH_ID",
 		"GITHUB_CLIENT_ID", "GITHUB_CLIENT_SECRET", "GITHUB_CALLBACK_URL",
-		"JWT_SECRET", "ENCRYPTION_KEY",
+		"JWT_SECRET", "ENCRYPTION_KEY", "URL_SIGNING_KEY",
 		"KUBECONFIG", "K8S_NAMESPACE_PREFIX",
 		"CLOUDFLARE_API_TOKEN", "CLOUDFLARE_ZONE_ID",
 		"GHCR_TOKEN",
 		"STRIPE_SECRET_KEY", "STRIPE_WEBHOOK_SECRET",
-		"PLATFORM_DOMAIN", "APPS_DOMAIN_SUFFIX",
+		"PLATFORM_DOMAIN", "APPS_DOMAIN_SUFFIX", "CORS_ALLOWED_ORIGINS", "NOTIFY_WEBHOOK_URL", "TRUSTED_PROXIES",
+		"MTLS_CA_CERT_FILE", "MTLS_CA_KEY_FILE",
+		"REGISTRY_HOST", "REGISTRY_URL", "REGISTRY_TOKEN_KEY_FILE", "REGISTRY_TOKEN_CERT_FILE", "REGISTRY_QUOTA_GB",
+		"BACKUP_URL", "BACKUP_INTERVAL_HOURS", "BACKUP_S3_ENDPOINT", "BACKUP_S3_REGION", "BACKUP_S3_ACCESS_KEY_ID", "BACKUP_S3_SECRET_ACCESS_KEY",
+		"REGIONS", "KUBECONFIG_GDL", "KUBECONFIG_MEX", "KUBECONFIG_QRO",
+		"INGRESS_IPS", "INGRESS_IPS_GDL", "INGRESS_IPS_MEX", "INGRESS_IPS_QRO",
+		"EGRESS_IPS", "EGRESS_IPS_GDL", "EGRESS_IPS_MEX", "EGRESS_IPS_QRO",
+		"DUAL_STACK", "DUAL_STACK_GDL", "DUAL_STACK_MEX", "DUAL_STACK_QRO",
+		"CLOUDFLARE_TUNNEL", "CLOUDFLARE_TUNNEL_GDL", "CLOUDFLARE_TUNNEL_MEX", "CLOUDFLARE_TUNNEL_QRO",
+		"TRAEFIK_METRICS_URL", "TRAEFIK_METRICS_URL_GDL", "TRAEFIK_METRICS_URL_MEX", "TRAEFIK_METRICS_URL_QRO",
+		"ADMIN_USERNAMES", "DISABLED_JOBS", "JOB_SCHEDULE_METERING", "STRIPE_PRICE_PRO",
+		"ACTIVITY_RETENTION_DAYS_FREE", "ACTIVITY_RETENTION_DAYS_PRO", "ACTIVITY_ARCHIVE",
 	}
 	for _, env := range envVars {
 		_ = os.Unsetenv(env)
@@ -205,6 +217,232 @@ func TestLoad_AllEnvVars(t *testing.T) {
 	}
 }
 
+func TestLoad_Regions(t *testing.T) {
+	clearConfigEnv(t)
+
+	cfg := Load()
+	if len(cfg.Regions) != 3 || cfg.Regions[0] != "gdl" {
+		t.Errorf("expected default regions gdl, mex, qro, got %v", cfg.Regions)
+	}
+
+	t.Setenv("REGIONS", "gdl, mty")
+	t.Setenv("KUBECONFIG", "/etc/kube/default")
+	t.Setenv("KUBECONFIG_MTY", "/etc/kube/mty")
+
+	cfg = Load()
+	if len(cfg.Regions) != 2 || cfg.Regions[1] != "mty" {
+		t.Errorf("expected regions gdl, mty, got %v", cfg.Regions)
+	}
+	if kubeconfig := cfg.KubeconfigForRegion("mty"); kubeconfig != "/etc/kube/mty" {
+		t.Errorf("expected region kubeconfig, got %q", kubeconfig)
+	}
+	if kubeconfig := cfg.KubeconfigForRegion("gdl"); kubeconfig != "/etc/kube/default" {
+		t.Errorf("expected default kubeconfig fallback, got %q", kubeconfig)
+	}
+}
+
+func TestIngressIPsForRegion(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("INGRESS_IPS", "203.0.113.10")
+	t.Setenv("INGRESS_IPS_MEX", "198.51.100.1, 2001:db8::1")
+
+	cfg := Load()
+	if ips := cfg.IngressIPsForRegion("mex"); len(ips) != 2 || ips[1] != "2001:db8::1" {
+		t.Errorf("expected region ingress IPs, got %v", ips)
+	}
+	if ips := cfg.IngressIPsForRegion("gdl"); len(ips) != 1 || ips[0] != "203.0.113.10" {
+		t.Errorf("expected default ingress IPs fallback, got %v", ips)
+	}
+}
+
+func TestEgressIPsForRegion(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("EGRESS_IPS", "203.0.113.50")
+	t.Setenv("EGRESS_IPS_MEX", "198.51.100.50, 198.51.100.51")
+
+	cfg := Load()
+	if ips := cfg.EgressIPsForRegion("mex"); len(ips) != 2 || ips[1] != "198.51.100.51" {
+		t.Errorf("expected region egress IPs, got %v", ips)
+	}
+	if ips := cfg.EgressIPsForRegion("gdl"); len(ips) != 1 || ips[0] != "203.0.113.50" {
+		t.Errorf("expected default egress IPs fallback, got %v", ips)
+	}
+}
+
+func TestRegistryAPI(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("REGISTRY_HOST", "registry.nexo.build")
+
+	cfg := Load()
+	if api := cfg.RegistryAPI(); api != "https://registry.nexo.build" {
+		t.Errorf("expected the public host, got %s", api)
+	}
+	if cfg.RegistryQuotaGB != 10 {
+		t.Errorf("expected a 10 GB default quota, got %d", cfg.RegistryQuotaGB)
+	}
+
+	t.Setenv("REGISTRY_URL", "http://registry.nexo-cloud.svc:5000/")
+	if api := Load().RegistryAPI(); api != "http://registry.nexo-cloud.svc:5000" {
+		t.Errorf("expected the internal URL, got %s", api)
+	}
+}
+
+func TestDualStackForRegion(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("DUAL_STACK", "true")
+	t.Setenv("DUAL_STACK_QRO", "false")
+
+	cfg := Load()
+	if !cfg.DualStackForRegion("gdl") {
+		t.Error("expected the default to apply to gdl")
+	}
+	if cfg.DualStackForRegion("qro") {
+		t.Error("expected qro to override the default")
+	}
+}
+
+func TestTunnelForRegion(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("CLOUDFLARE_TUNNEL_GDL", "true")
+
+	cfg := Load()
+	if !cfg.TunnelForRegion("gdl") {
+		t.Error("expected gdl to be exposed through tunnels")
+	}
+	if cfg.TunnelForRegion("qro") {
+		t.Error("expected qro to use its ingress")
+	}
+}
+
+func TestTraefikMetricsURLForRegion(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("TRAEFIK_METRICS_URL_MEX", "http://traefik.mex:9100/metrics")
+
+	cfg := Load()
+	if metricsURL := cfg.TraefikMetricsURLForRegion("mex"); metricsURL != "http://traefik.mex:9100/metrics" {
+		t.Errorf("expected region metrics URL, got %q", metricsURL)
+	}
+	if metricsURL := cfg.TraefikMetricsURLForRegion("gdl"); metricsURL != "" {
+		t.Errorf("expected no metrics URL without a default, got %q", metricsURL)
+	}
+}
+
+func TestIsAdmin(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("ADMIN_USERNAMES", "octocat, Hubot")
+
+	cfg := Load()
+	if !cfg.IsAdmin("octocat") || !cfg.IsAdmin("hubot") {
+		t.Errorf("expected configured users to be admins, got %v", cfg.AdminUsernames)
+	}
+	if cfg.IsAdmin("mallory") || cfg.IsAdmin("") {
+		t.Error("expected other users not to be admins")
+	}
+}
+
+func TestCORSAllowedOrigins(t *testing.T) {
+	clearConfigEnv(t)
+
+	cfg := Load()
+	want := []string{"https://cloud.nexo.build", "http://localhost:3000", "http://localhost:5173"}
+	if !reflect.DeepEqual(cfg.CORSAllowedOrigins, want) {
+		t.Errorf("expected development defaults %v, got %v", want, cfg.CORSAllowedOrigins)
+	}
+
+	t.Setenv("ENVIRONMENT", "production")
+	t.Setenv("PLATFORM_DOMAIN", "cloud.example.com")
+	cfg = Load()
+	if want := []string{"https://cloud.example.com"}; !reflect.DeepEqual(cfg.CORSAllowedOrigins, want) {
+		t.Errorf("expected production defaults %v, got %v", want, cfg.CORSAllowedOrigins)
+	}
+
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://cloud.example.com, https://*.example.com")
+	cfg = Load()
+	if want := []string{"https://cloud.example.com", "https://*.example.com"}; !reflect.DeepEqual(cfg.CORSAllowedOrigins, want) {
+		t.Errorf("expected configured origins %v, got %v", want, cfg.CORSAllowedOrigins)
+	}
+}
+
+func TestJobSettings(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("DISABLED_JOBS", "backup")
+	t.Setenv("JOB_SCHEDULE_METERING", " */5 * * * * ")
+
+	cfg := Load()
+	if cfg.JobEnabled("backup") {
+		t.Error("expected backup to be disabled")
+	}
+	if !cfg.JobEnabled("metering") {
+		t.Error("expected metering to be enabled")
+	}
+	if schedule := cfg.JobSchedule("metering", "@every 1m"); schedule != "*/5 * * * *" {
+		t.Errorf("expected overridden schedule, got %q", schedule)
+	}
+	if schedule := cfg.JobSchedule("token_sweep", "@hourly"); schedule != "@hourly" {
+		t.Errorf("expected default schedule, got %q", schedule)
+	}
+}
+
+func TestStripePrice(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("STRIPE_PRICE_PRO", "price_pro_monthly")
+
+	cfg := Load()
+	if price, ok := cfg.StripePrice("pro"); !ok || price != "price_pro_monthly" {
+		t.Errorf("expected the pro price, got %q", price)
+	}
+	if _, ok := cfg.StripePrice("free"); ok {
+		t.Error("expected no price for plans not sold")
+	}
+}
+
+func TestActivityRetention(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("ACTIVITY_RETENTION_DAYS_PRO", "180")
+	t.Setenv("ACTIVITY_RETENTION_DAYS_FREE", "forever")
+	t.Setenv("ACTIVITY_ARCHIVE", "true")
+
+	cfg := Load()
+	if days := cfg.ActivityRetention("Pro", 90); days != 180 {
+		t.Errorf("expected the pro override, got %d", days)
+	}
+	if days := cfg.ActivityRetention("free", 30); days != 30 {
+		t.Errorf("expected invalid overrides to be ignored, got %d", days)
+	}
+	if days := cfg.ActivityRetention("enterprise", 365); days != 365 {
+		t.Errorf("expected the default, got %d", days)
+	}
+	if !cfg.ActivityArchive {
+		t.Error("expected archiving to be enabled")
+	}
+}
+
+func TestSigningKey(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("JWT_SECRET", "jwt-secret")
+
+	cfg := Load()
+	if key := cfg.SigningKey(); key != "jwt-secret" {
+		t.Errorf("expected JWT secret fallback, got %q", key)
+	}
+
+	t.Setenv("URL_SIGNING_KEY", "url-key")
+	cfg = Load()
+	if key := cfg.SigningKey(); key != "url-key" {
+		t.Errorf("expected URL_SIGNING_KEY, got %q", key)
+	}
+}
+
+func TestMTLSVerifyURL(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("PLATFORM_DOMAIN", "cloud.example.com")
+
+	cfg := Load()
+	if got := cfg.MTLSVerifyURL("web"); got != "https://cloud.example.com/api/mtls/verify?app=web" {
+		t.Errorf("unexpected verify URL %q", got)
+	}
+}
+
 func TestIsDevelopment_True(t *testing.T) {
 	clearConfigEnv(t)
 	t.Setenv("ENVIRONMENT", "development")
@@ -422,3 +660,24 @@ func TestLoad_Concurrency(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckDemoMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"demo", Config{DemoMode: true}, false},
+		{"cluster", Config{Kubeconfig: "/etc/kube/config"}, false},
+		{"demo with a cluster", Config{DemoMode: true, Kubeconfig: "/etc/kube/config"}, true},
+		{"demo with a region cluster", Config{DemoMode: true, RegionKubeconfigs: map[string]string{"mex": "/etc/kube/mex"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.CheckDemoMode(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckDemoMode() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// Package cors holds the platform's cross-origin policy: which browser
+// origins may call the API with the user's credentials. Origins come from
+// CORS_ALLOWED_ORIGINS or per-environment defaults and may use a wildcard
+// for subdomains, e.g. https://*.nexo.build.
+package cors
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Methods and Headers are allowed for cross-origin requests
+var (
+	Methods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	Headers = []string{"Authorization", "Content-Type", "X-Request-ID", "X-CSRF-Token"}
+)
+
+// MaxAge is how long browsers may cache a preflight response
+const MaxAge = 24 * time.Hour
+
+// Policy decides which origins may make cross-origin requests
+type Policy struct {
+	// Origins are the allowed origins as configured
+	Origins []string
+	// AnyOrigin is set by a "*" entry. Any origin may then read responses,
+	// but without credentials.
+	AnyOrigin bool
+}
+
+// Parse validates the allowed origins. Each is "*", an origin such as
+// https://app.example.com or http://localhost:3000, or an origin whose host
+// starts with "*." to allow every subdomain (but not the domain itself).
+func Parse(origins []string) (*Policy, error) {
+	policy := &Policy{}
+	for _, origin := range origins {
+		origin = strings.TrimSuffix(strings.TrimSpace(origin), "/")
+		if origin == "*" {
+			policy.AnyOrigin = true
+			policy.Origins = append(policy.Origins, origin)
+			continue
+		}
+
+		u, err := url.Parse(strings.Replace(origin, "*.", "wildcard.", 1))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+			u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+			return nil, fmt.Errorf("invalid origin %q: expected scheme://host[:port]", origin)
+		}
+		if strings.Contains(origin, "*") && !strings.HasPrefix(u.Host, "wildcard.") {
+			return nil, fmt.Errorf("invalid origin %q: a wildcard must be the first label of the host", origin)
+		}
+		if strings.Count(origin, "*") > 1 {
+			return nil, fmt.Errorf("invalid origin %q: only one wildcard is allowed", origin)
+		}
+		policy.Origins = append(policy.Origins, strings.ToLower(origin))
+	}
+	return policy, nil
+}
+
+// Allowed reports whether an origin may make cross-origin requests
+func (p *Policy) Allowed(origin string) bool {
+	if origin == "" || origin == "null" {
+		return false
+	}
+	if p.AnyOrigin {
+		return true
+	}
+	return p.Trusted(origin)
+}
+
+// Trusted reports whether an origin is explicitly allowed, i.e. it may make
+// credentialed requests. A "*" entry does not make every origin trusted.
+func (p *Policy) Trusted(origin string) bool {
+	for _, pattern := range p.Origins {
+		if Match(pattern, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether an origin matches an allowed origin pattern. "*"
+// matches nothing: it only relaxes the policy, see Policy.AnyOrigin.
+func Match(pattern, origin string) bool {
+	pattern = strings.ToLower(strings.TrimSuffix(pattern, "/"))
+	origin = strings.ToLower(strings.TrimSuffix(origin, "/"))
+	if pattern == "*" || origin == "" {
+		return false
+	}
+
+	prefix, suffix, wildcard := strings.Cut(pattern, "*.")
+	if !wildcard {
+		return pattern == origin
+	}
+	// prefix is the scheme and suffix the parent domain and port; the
+	// wildcard must stand for at least one label
+	if !strings.HasPrefix(origin, prefix) {
+		return false
+	}
+	host := strings.TrimPrefix(origin, prefix)
+	sub, ok := strings.CutSuffix(host, "."+suffix)
+	return ok && sub != "" && !strings.ContainsAny(sub, "/:@?#")
+}
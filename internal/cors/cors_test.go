@@ -0,0 +1,98 @@
+package cors
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	valid := []string{
+		"*",
+		"https://cloud.nexo.build",
+		"https://cloud.nexo.build/",
+		"http://localhost:5173",
+		"https://*.nexo.build",
+		"https://*.preview.nexo.build:8443",
+	}
+	for _, origin := range valid {
+		if _, err := Parse([]string{origin}); err != nil {
+			t.Errorf("expected %q to be valid, got %v", origin, err)
+		}
+	}
+
+	invalid := []string{
+		"cloud.nexo.build",
+		"*.nexo.build",
+		"ftp://files.nexo.build",
+		"https://cloud.nexo.build/dashboard",
+		"https://cloud.nexo.build?x=1",
+		"https://app.*.nexo.build",
+		"https://*nexo.build",
+		"https://*.*.nexo.build",
+		"https://",
+	}
+	for _, origin := range invalid {
+		if _, err := Parse([]string{origin}); err == nil {
+			t.Errorf("expected %q to be invalid", origin)
+		}
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	policy, err := Parse([]string{"https://cloud.nexo.build", "https://*.nexo.build", "http://localhost:3000"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://cloud.nexo.build", true},
+		{"https://Cloud.Nexo.Build", true},
+		{"https://myapp.nexo.build", true},
+		{"https://a.b.nexo.build", true},
+		{"http://localhost:3000", true},
+		{"https://nexo.build", false},
+		{"http://myapp.nexo.build", false},
+		{"https://myapp.nexo.build:8443", false},
+		{"https://evilnexo.build", false},
+		{"https://nexo.build.evil.com", false},
+		{"http://localhost:5173", false},
+		{"null", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := policy.Allowed(tt.origin); got != tt.allowed {
+			t.Errorf("Allowed(%q) = %v, want %v", tt.origin, got, tt.allowed)
+		}
+		if got := policy.Trusted(tt.origin); got != tt.allowed {
+			t.Errorf("Trusted(%q) = %v, want %v", tt.origin, got, tt.allowed)
+		}
+	}
+}
+
+func TestPolicy_AnyOrigin(t *testing.T) {
+	policy, err := Parse([]string{"*", "https://cloud.nexo.build"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !policy.Allowed("https://anything.example") {
+		t.Error("expected any origin to be allowed")
+	}
+	if policy.Trusted("https://anything.example") {
+		t.Error("expected * not to trust every origin with credentials")
+	}
+	if !policy.Trusted("https://cloud.nexo.build") {
+		t.Error("expected listed origins to stay trusted")
+	}
+}
+
+func TestParse_Empty(t *testing.T) {
+	policy, err := Parse(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if policy.Allowed("https://cloud.nexo.build") {
+		t.Error("expected no cross-origin access without allowed origins")
+	}
+}
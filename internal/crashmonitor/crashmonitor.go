@@ -0,0 +1,199 @@
+// Package crashmonitor watches the pods of running deployments for
+// containers killed for running out of memory or stuck in CrashLoopBackOff.
+// It counts them on the deployment and alerts the owner with a
+// recommendation, such as increasing the memory or fixing the start command.
+package crashmonitor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/events"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Events published on alerts
+const (
+	EventOOMKilled    = "deployment.oom_killed"
+	EventCrashLooping = "deployment.crash_looping"
+)
+
+// alertInterval limits how often the same deployment is alerted about
+const alertInterval = time.Hour
+
+// Monitor counts container crashes of running deployments
+type Monitor struct {
+	queries *db.Queries
+	cfg     *config.Config
+	clients func(kubeconfig string) (k8s.Interface, error)
+	events  events.Publisher
+	now     func() time.Time
+}
+
+// New creates a monitor connecting to clusters with clients
+func New(queries *db.Queries, cfg *config.Config, clients func(kubeconfig string) (k8s.Interface, error), publisher events.Publisher) *Monitor {
+	return &Monitor{
+		queries: queries,
+		cfg:     cfg,
+		clients: clients,
+		events:  publisher,
+		now:     time.Now,
+	}
+}
+
+// Check reads the pods of every running deployment once. Only the last
+// termination of a container is visible, so restarts between two checks
+// count once.
+func (m *Monitor) Check(ctx context.Context) error {
+	deployments, err := m.queries.ListRunningDeployments(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list deployments: %w", err)
+	}
+
+	regions := k8s.NewRegions(m.cfg.KubeconfigForRegion, m.clients)
+	for _, d := range deployments {
+		client, err := regions.Client(d.Region)
+		if err != nil {
+			slog.Warn("kubernetes not available for region", "region", d.Region, "error", err)
+			continue
+		}
+		crashes, err := client.ListCrashes(ctx, d.AppName)
+		if err != nil {
+			slog.Warn("failed to list crashes", "app", d.AppName, "error", err)
+			continue
+		}
+
+		m.update(ctx, d, crashes)
+	}
+
+	return nil
+}
+
+// update counts the crashes since the previous check and alerts when needed
+func (m *Monitor) update(ctx context.Context, d db.ListRunningDeploymentsRow, crashes []k8s.ContainerCrash) {
+	counts := Count(d, crashes)
+	if counts.OOMKills == 0 && counts.CrashLoops == 0 {
+		return
+	}
+
+	_, err := m.queries.RecordDeploymentCrashes(ctx, db.RecordDeploymentCrashesParams{
+		ID:          d.ID,
+		OomKills:    counts.OOMKills,
+		CrashLoops:  counts.CrashLoops,
+		LastCrashAt: pgtype.Timestamptz{Time: counts.Latest, Valid: true},
+	})
+	if err != nil {
+		slog.Error("failed to record crashes", "app", d.AppName, "error", err)
+		return
+	}
+
+	event, ok := m.alert(d, counts)
+	if !ok {
+		return
+	}
+	if err := m.events.Publish(ctx, event); err != nil {
+		slog.Error("failed to send crash alert", "app", d.AppName, "error", err)
+		return
+	}
+	_ = m.queries.MarkDeploymentCrashAlerted(ctx, d.ID)
+}
+
+// Counts are the crashes of a deployment since its previous check
+type Counts struct {
+	OOMKills   int32
+	CrashLoops int32
+	// Latest is when the most recent counted crash happened
+	Latest time.Time
+	// Worst is the crash to alert about: out of memory kills take
+	// precedence, since they usually cause the crash loop
+	Worst *k8s.ContainerCrash
+}
+
+// Count returns the crashes that happened after the deployment's last
+// recorded crash. Terminations of pods from before the deployment are
+// skipped.
+func Count(d db.ListRunningDeploymentsRow, crashes []k8s.ContainerCrash) Counts {
+	since := d.CreatedAt
+	if d.LastCrashAt.Valid {
+		since = d.LastCrashAt.Time
+	}
+
+	var counts Counts
+	for i := range crashes {
+		crash := &crashes[i]
+		if !crash.FinishedAt.After(since) {
+			continue
+		}
+
+		switch {
+		case crash.OOMKilled():
+			counts.OOMKills++
+			if counts.Worst == nil || !counts.Worst.OOMKilled() {
+				counts.Worst = crash
+			}
+		case crash.CrashLooping:
+			counts.CrashLoops++
+			if counts.Worst == nil {
+				counts.Worst = crash
+			}
+		default:
+			continue
+		}
+
+		if crash.FinishedAt.After(counts.Latest) {
+			counts.Latest = crash.FinishedAt
+		}
+	}
+	return counts
+}
+
+// alert returns the alert event due for new crashes, if any. The same
+// deployment is alerted at most once per alertInterval.
+func (m *Monitor) alert(d db.ListRunningDeploymentsRow, counts Counts) (events.Event, bool) {
+	if counts.Worst == nil {
+		return events.Event{}, false
+	}
+	if d.CrashAlertedAt.Valid && m.now().Sub(d.CrashAlertedAt.Time) < alertInterval {
+		return events.Event{}, false
+	}
+
+	n := events.Event{
+		Type:    EventCrashLooping,
+		UserID:  d.UserID,
+		AppID:   d.AppID,
+		AppName: d.AppName,
+		Message: Recommend(counts.Worst),
+		Payload: map[string]any{
+			"deployment_id": d.ID,
+			"version":       d.Version,
+			"process":       counts.Worst.Process,
+			"pod":           counts.Worst.Pod,
+			"oom_kills":     d.OomKills + counts.OOMKills,
+			"crash_loops":   d.CrashLoops + counts.CrashLoops,
+		},
+	}
+	if counts.Worst.OOMKilled() {
+		n.Type = EventOOMKilled
+	}
+	return n, true
+}
+
+// Recommend tells the owner what to do about a crash
+func Recommend(crash *k8s.ContainerCrash) string {
+	switch {
+	case crash.OOMKilled() && crash.MemoryLimit != "":
+		return fmt.Sprintf("the %s process ran out of its %s memory limit, resize it with more memory or move the app to a larger size", crash.Process, crash.MemoryLimit)
+	case crash.OOMKilled():
+		return fmt.Sprintf("the %s process was killed for running out of memory, move the app to a larger size or resize it with a memory limit", crash.Process)
+	case crash.ExitCode == 126 || crash.ExitCode == 127:
+		return fmt.Sprintf("the start command of the %s process cannot be run (exit code %d), fix the command in the process formation", crash.Process, crash.ExitCode)
+	case crash.CrashLooping:
+		return fmt.Sprintf("the %s process keeps exiting with code %d, check its logs and start command", crash.Process, crash.ExitCode)
+	}
+	return fmt.Sprintf("the %s process exited with code %d, check its logs", crash.Process, crash.ExitCode)
+}
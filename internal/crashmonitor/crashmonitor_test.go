@@ -0,0 +1,84 @@
+package crashmonitor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestCount(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	d := db.ListRunningDeploymentsRow{ID: uuid.New(), CreatedAt: now.Add(-time.Hour)}
+	crashes := []k8s.ContainerCrash{
+		{Pod: "web-1", Process: "web", Reason: "Error", ExitCode: 1, CrashLooping: true, FinishedAt: now.Add(-time.Minute)},
+		{Pod: "worker-1", Process: "worker", Reason: k8s.TerminationOOMKilled, ExitCode: 137, FinishedAt: now.Add(-2 * time.Minute)},
+		{Pod: "web-2", Process: "web", Reason: "Error", ExitCode: 1, FinishedAt: now.Add(-3 * time.Minute)},
+		{Pod: "old-1", Process: "web", Reason: k8s.TerminationOOMKilled, FinishedAt: now.Add(-2 * time.Hour)},
+	}
+
+	counts := Count(d, crashes)
+	if counts.OOMKills != 1 || counts.CrashLoops != 1 {
+		t.Errorf("expected one OOM kill and one crash loop, got %+v", counts)
+	}
+	if !counts.Latest.Equal(now.Add(-time.Minute)) {
+		t.Errorf("unexpected latest crash %v", counts.Latest)
+	}
+	if counts.Worst == nil || counts.Worst.Pod != "worker-1" {
+		t.Errorf("expected the OOM kill to be the worst crash, got %+v", counts.Worst)
+	}
+
+	d.LastCrashAt = pgtype.Timestamptz{Time: counts.Latest, Valid: true}
+	if counts := Count(d, crashes); counts.OOMKills != 0 || counts.CrashLoops != 0 || counts.Worst != nil {
+		t.Errorf("expected recorded crashes not to count again, got %+v", counts)
+	}
+}
+
+func TestAlert(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	m := &Monitor{now: func() time.Time { return now }}
+	d := db.ListRunningDeploymentsRow{ID: uuid.New(), AppName: "web", UserID: uuid.New(), OomKills: 2}
+
+	if _, ok := m.alert(d, Counts{}); ok {
+		t.Error("expected no alert without crashes")
+	}
+
+	oom := &k8s.ContainerCrash{Process: "web", Reason: k8s.TerminationOOMKilled, MemoryLimit: "512Mi"}
+	n, ok := m.alert(d, Counts{OOMKills: 1, Worst: oom})
+	if !ok || n.Type != EventOOMKilled || n.UserID != d.UserID || n.Payload["oom_kills"] != int32(3) {
+		t.Errorf("unexpected alert %+v", n)
+	}
+
+	loop := &k8s.ContainerCrash{Process: "web", Reason: "Error", ExitCode: 1, CrashLooping: true}
+	if n, ok := m.alert(d, Counts{CrashLoops: 1, Worst: loop}); !ok || n.Type != EventCrashLooping {
+		t.Errorf("expected a crash loop alert, got %+v", n)
+	}
+
+	d.CrashAlertedAt = pgtype.Timestamptz{Time: now.Add(-time.Minute), Valid: true}
+	if _, ok := m.alert(d, Counts{OOMKills: 1, Worst: oom}); ok {
+		t.Error("expected recent alerts to be throttled")
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	tests := []struct {
+		crash k8s.ContainerCrash
+		want  string
+	}{
+		{k8s.ContainerCrash{Process: "web", Reason: k8s.TerminationOOMKilled, MemoryLimit: "512Mi"}, "512Mi memory limit"},
+		{k8s.ContainerCrash{Process: "web", Reason: k8s.TerminationOOMKilled}, "larger size"},
+		{k8s.ContainerCrash{Process: "worker", Reason: "Error", ExitCode: 127, CrashLooping: true}, "fix the command"},
+		{k8s.ContainerCrash{Process: "worker", Reason: "Error", ExitCode: 1, CrashLooping: true}, "keeps exiting with code 1"},
+		{k8s.ContainerCrash{Process: "worker", Reason: "Error", ExitCode: 2}, "exited with code 2"},
+	}
+
+	for _, tt := range tests {
+		if got := Recommend(&tt.crash); !strings.Contains(got, tt.want) {
+			t.Errorf("Recommend(%+v) = %q, want it to contain %q", tt.crash, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+// Package credits keeps the ledger of account credits. Admins grant credits
+// and referrals earn them; when Stripe drafts an invoice, the balance is
+// consumed up to the amount due by crediting the customer's Stripe balance,
+// which Stripe applies before charging the card.
+package credits
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/billing"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/events"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/suspension"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Reasons of ledger entries
+const (
+	ReasonGrant    = "grant"
+	ReasonReferral = "referral"
+	ReasonInvoice  = "invoice"
+)
+
+// Events published when credits are granted and when an invoice consumes
+// them. They carry a message, so owners are notified of them.
+const (
+	EventGranted = "billing.credits_granted"
+	EventApplied = "billing.credits_applied"
+)
+
+const (
+	// Currency is the currency of credits; invoices in others are charged
+	// in full
+	Currency = "usd"
+	// ReferralAmount is what a referrer is credited, in cents, once a user
+	// they referred pays their first invoice
+	ReferralAmount = 2000
+	// MaxGrant bounds a single grant, in cents
+	MaxGrant = 1_000_000
+)
+
+// ErrSelfReferral is returned for users following their own referral link
+var ErrSelfReferral = errors.New("users cannot refer themselves")
+
+// Consume returns how much of a balance an invoice of amountDue consumes
+func Consume(balance, amountDue int64) int64 {
+	if balance <= 0 || amountDue <= 0 {
+		return 0
+	}
+	return min(balance, amountDue)
+}
+
+// ReferralLink is the login path crediting username for the users signing
+// up through it
+func ReferralLink(username string) string {
+	return "/api/auth?ref=" + username
+}
+
+// Format renders an amount of cents in dollars
+func Format(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s$%d.%02d", sign, cents/100, cents%100)
+}
+
+// Ledger grants and consumes account credits
+type Ledger struct {
+	pool    *pgxpool.Pool
+	queries *db.Queries
+	billing *billing.Client
+}
+
+// New creates a ledger. billingClient may be nil, in which case invoices
+// are not credited.
+func New(pool *pgxpool.Pool, billingClient *billing.Client) *Ledger {
+	return &Ledger{
+		pool:    pool,
+		queries: db.New(pool),
+		billing: billingClient,
+	}
+}
+
+// Grant adds credits to an account. grantedBy is the admin granting them,
+// or uuid.Nil for credits the platform grants.
+func (l *Ledger) Grant(ctx context.Context, userID uuid.UUID, amount int64, reason, description string, grantedBy uuid.UUID) (db.CreditLedger, error) {
+	if amount <= 0 || amount > MaxGrant {
+		return db.CreditLedger{}, fmt.Errorf("amount must be between 1 and %d cents", MaxGrant)
+	}
+
+	var entry db.CreditLedger
+	err := l.inTx(ctx, func(queries *db.Queries) error {
+		var err error
+		entry, err = grant(ctx, queries, userID, amount, reason, description, grantedBy)
+		return err
+	})
+	return entry, err
+}
+
+func grant(ctx context.Context, queries *db.Queries, userID uuid.UUID, amount int64, reason, description string, grantedBy uuid.UUID) (db.CreditLedger, error) {
+	entry, err := queries.CreateCreditEntry(ctx, db.CreateCreditEntryParams{
+		UserID:      userID,
+		Amount:      amount,
+		Reason:      reason,
+		Description: description,
+		GrantedBy:   pgtype.UUID{Bytes: grantedBy, Valid: grantedBy != uuid.Nil},
+	})
+	if err != nil {
+		return db.CreditLedger{}, fmt.Errorf("failed to grant credits: %w", err)
+	}
+
+	message := fmt.Sprintf("You were granted %s in credits.", Format(amount))
+	if description != "" {
+		message = fmt.Sprintf("You were granted %s in credits: %s.", Format(amount), strings.TrimSuffix(description, "."))
+	}
+	return entry, events.Publish(ctx, queries, events.Event{
+		Type:    EventGranted,
+		UserID:  userID,
+		Message: message,
+		Payload: map[string]any{
+			"amount":      amount,
+			"reason":      reason,
+			"description": description,
+		},
+	})
+}
+
+// Apply consumes an account's credits for a draft invoice, up to the amount
+// due, and credits them to the customer's Stripe balance so they are spent
+// when the invoice is finalized. Each invoice is credited once; it returns
+// the amount applied.
+func (l *Ledger) Apply(ctx context.Context, userID uuid.UUID, customer string, invoice billing.Invoice) (int64, error) {
+	if l.billing == nil || invoice.Status != "draft" || invoice.Currency != Currency || invoice.AmountDue <= 0 {
+		return 0, nil
+	}
+
+	var applied int64
+	err := l.inTx(ctx, func(queries *db.Queries) error {
+		if err := queries.LockCreditBalance(ctx, userID); err != nil {
+			return fmt.Errorf("failed to lock credits: %w", err)
+		}
+		// A redelivered event finds the invoice credited
+		entry, err := queries.GetCreditEntryByInvoice(ctx, &invoice.ID)
+		if err == nil {
+			applied = -entry.Amount
+			return nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("failed to get credits: %w", err)
+		}
+
+		balance, err := queries.GetCreditBalance(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get balance: %w", err)
+		}
+		applied = Consume(balance, invoice.AmountDue)
+		if applied == 0 {
+			return nil
+		}
+
+		description := "Credits applied to invoice " + invoice.ID
+		if _, err := queries.CreateCreditEntry(ctx, db.CreateCreditEntryParams{
+			UserID:      userID,
+			Amount:      -applied,
+			Reason:      ReasonInvoice,
+			Description: description,
+			InvoiceID:   &invoice.ID,
+		}); err != nil {
+			return fmt.Errorf("failed to consume credits: %w", err)
+		}
+		if err := events.Publish(ctx, queries, events.Event{
+			Type:    EventApplied,
+			UserID:  userID,
+			Message: fmt.Sprintf("%s in credits were applied to your next invoice, %s remain.", Format(applied), Format(balance-applied)),
+			Payload: map[string]any{
+				"amount":     applied,
+				"balance":    balance - applied,
+				"invoice_id": invoice.ID,
+			},
+		}); err != nil {
+			return err
+		}
+
+		// Stripe is credited last, so the ledger is rolled back when it
+		// fails; the idempotency key keeps a retry from crediting twice
+		_, err = l.billing.CreditBalance(ctx, billing.CreditParams{
+			Customer:       customer,
+			Amount:         applied,
+			Currency:       Currency,
+			Description:    description,
+			IdempotencyKey: "credits-" + invoice.ID,
+		})
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return applied, nil
+}
+
+// Refer records that a new user signed up through the referral link of the
+// user named referrer. Unknown referrers are ignored.
+func Refer(ctx context.Context, queries *db.Queries, userID uuid.UUID, referrer string) error {
+	referring, err := queries.GetUserByUsername(ctx, referrer)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get referrer: %w", err)
+	}
+	if referring.ID == userID {
+		return ErrSelfReferral
+	}
+
+	_, err = queries.CreateReferral(ctx, db.CreateReferralParams{
+		UserID:     userID,
+		ReferrerID: referring.ID,
+	})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to record referral: %w", err)
+	}
+	return nil
+}
+
+// Handle is the event bus handler crediting referrers when the users they
+// referred pay their first invoice
+func (l *Ledger) Handle(ctx context.Context, e events.Event) error {
+	if e.Type != suspension.EventPaymentSucceeded || e.UserID == uuid.Nil {
+		return nil
+	}
+	// Invoices of free plans earn nothing
+	if paid, _ := e.Payload["amount_due"].(float64); paid <= 0 {
+		return nil
+	}
+
+	referral, err := l.queries.GetReferral(ctx, e.UserID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get referral: %w", err)
+	}
+	referred, err := l.queries.GetUserByID(ctx, e.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return l.inTx(ctx, func(queries *db.Queries) error {
+		claimed, err := queries.MarkReferralCredited(ctx, e.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to claim referral: %w", err)
+		}
+		if claimed == 0 {
+			return nil
+		}
+		_, err = grant(ctx, queries, referral.ReferrerID, ReferralAmount, ReasonReferral, "Referred "+referred.Username, uuid.Nil)
+		return err
+	})
+}
+
+func (l *Ledger) inTx(ctx context.Context, fn func(queries *db.Queries) error) error {
+	tx, err := l.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	if err := fn(l.queries.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,34 @@
+package credits
+
+import "testing"
+
+func TestConsume(t *testing.T) {
+	tests := []struct {
+		balance, due, want int64
+	}{
+		{0, 2900, 0},
+		{-500, 2900, 0},
+		{1000, 0, 0},
+		{1000, 2900, 1000},
+		{5000, 2900, 2900},
+	}
+	for _, tt := range tests {
+		if got := Consume(tt.balance, tt.due); got != tt.want {
+			t.Errorf("Consume(%d, %d) = %d, want %d", tt.balance, tt.due, got, tt.want)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for cents, want := range map[int64]string{0: "$0.00", 5: "$0.05", 2000: "$20.00", 123456: "$1234.56", -2900: "-$29.00"} {
+		if got := Format(cents); got != want {
+			t.Errorf("Format(%d) = %q, want %q", cents, got, want)
+		}
+	}
+}
+
+func TestReferralLink(t *testing.T) {
+	if got := ReferralLink("alice"); got != "/api/auth?ref=alice" {
+		t.Errorf("unexpected referral link %q", got)
+	}
+}
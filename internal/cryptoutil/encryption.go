@@ -17,19 +17,34 @@ func Encrypt(data map[string]string, key string) ([]byte, error) {
 		return nil, fmt.Errorf("failed to marshal data: %w", err)
 	}
 
-	keyBytes := []byte(key)
-	if len(keyBytes) != 32 {
-		return nil, fmt.Errorf("encryption key must be 32 bytes")
+	return EncryptBytes(plaintext, key)
+}
+
+// Decrypt decrypts data using AES-GCM.
+func Decrypt(ciphertext []byte, key string) (map[string]string, error) {
+	if len(ciphertext) == 0 {
+		return make(map[string]string), nil
 	}
 
-	block, err := aes.NewCipher(keyBytes)
+	plaintext, err := DecryptBytes(ciphertext, key)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create cipher: %w", err)
+		return nil, err
 	}
 
-	gcm, err := cipher.NewGCM(block)
+	var data map[string]string
+	if err := json.Unmarshal(plaintext, &data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal data: %w", err)
+	}
+
+	return data, nil
+}
+
+// EncryptBytes encrypts arbitrary data using AES-GCM. The nonce is prepended
+// to the ciphertext.
+func EncryptBytes(plaintext []byte, key string) ([]byte, error) {
+	gcm, err := newGCM(key)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create GCM: %w", err)
+		return nil, err
 	}
 
 	nonce := make([]byte, gcm.NonceSize())
@@ -37,16 +52,31 @@ func Encrypt(data map[string]string, key string) ([]byte, error) {
 		return nil, fmt.Errorf("failed to generate nonce: %w", err)
 	}
 
-	ciphertext := gcm.Seal(nonce, nonce, plaintext, nil)
-	return ciphertext, nil
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
 }
 
-// Decrypt decrypts data using AES-GCM.
-func Decrypt(ciphertext []byte, key string) (map[string]string, error) {
-	if len(ciphertext) == 0 {
-		return make(map[string]string), nil
+// DecryptBytes decrypts data encrypted with EncryptBytes.
+func DecryptBytes(ciphertext []byte, key string) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := gcm.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+
+	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
 	}
 
+	return plaintext, nil
+}
+
+func newGCM(key string) (cipher.AEAD, error) {
 	keyBytes := []byte(key)
 	if len(keyBytes) != 32 {
 		return nil, fmt.Errorf("encryption key must be 32 bytes")
@@ -62,21 +92,5 @@ func Decrypt(ciphertext []byte, key string) (map[string]string, error) {
 		return nil, fmt.Errorf("failed to create GCM: %w", err)
 	}
 
-	nonceSize := gcm.NonceSize()
-	if len(ciphertext) < nonceSize {
-		return nil, fmt.Errorf("ciphertext too short")
-	}
-
-	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt: %w", err)
-	}
-
-	var data map[string]string
-	if err := json.Unmarshal(plaintext, &data); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal data: %w", err)
-	}
-
-	return data, nil
+	return gcm, nil
 }
@@ -198,3 +198,24 @@ func TestEncryptDeterministic(t *testing.T) {
 		t.Error("both decryptions should produce same result")
 	}
 }
+
+func TestEncryptBytesRoundTrip(t *testing.T) {
+	plaintext := []byte("snapshot archive \x00\x01\x02")
+
+	encrypted, err := EncryptBytes(plaintext, testKey)
+	if err != nil {
+		t.Fatalf("failed to encrypt: %v", err)
+	}
+
+	decrypted, err := DecryptBytes(encrypted, testKey)
+	if err != nil {
+		t.Fatalf("failed to decrypt: %v", err)
+	}
+	if string(decrypted) != string(plaintext) {
+		t.Errorf("expected %q, got %q", plaintext, decrypted)
+	}
+
+	if _, err := DecryptBytes(encrypted, "abcdefghijklmnopqrstuvwxyz012345"); err == nil {
+		t.Error("expected error decrypting with the wrong key")
+	}
+}
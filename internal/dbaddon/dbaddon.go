@@ -0,0 +1,391 @@
+// Package dbaddon renders the env vars apps get from the database add-ons
+// linked to them: Postgres databases hosted elsewhere, such as on Neon or
+// RDS, whose credentials are kept once per account and injected into every
+// app linked to them as DATABASE_URL and the libpq PG* variables. Pooled
+// links point the variables at a PgBouncer sidecar instead.
+package dbaddon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/cryptoutil"
+	"github.com/google/uuid"
+)
+
+// Template variables an add-on can inject
+const (
+	VarDatabaseURL = "DATABASE_URL"
+	VarHost        = "PGHOST"
+	VarPort        = "PGPORT"
+	VarDatabase    = "PGDATABASE"
+	VarUser        = "PGUSER"
+	VarPassword    = "PGPASSWORD"
+	VarSSLMode     = "PGSSLMODE"
+)
+
+// Variables are the template variables, in the order they are rendered;
+// links inject all of them unless they pick some
+var Variables = []string{VarDatabaseURL, VarHost, VarPort, VarDatabase, VarUser, VarPassword, VarSSLMode}
+
+// SSLModes are the sslmode values libpq accepts
+var SSLModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// DefaultPort is the port of add-ons that do not set one
+const DefaultPort int32 = 5432
+
+// Pooling, through a PgBouncer sidecar per pooled link
+const (
+	// PoolerPort is the localhost port of an app's first pooler, the
+	// others listening on the next ones
+	PoolerPort int32 = 6432
+	// DefaultPoolSize and MaxPoolSize bound the connections each pod of an
+	// app opens to a pooled database
+	DefaultPoolSize int32 = 10
+	MaxPoolSize     int32 = 100
+)
+
+// Masked replaces passwords in previews, as redacted env vars are shown
+const Masked = "••••••••"
+
+var (
+	namePattern = regexp.MustCompile(`^[a-z][a-z0-9-]{0,62}$`)
+	// A prefix such as ANALYTICS_ renders ANALYTICS_DATABASE_URL, so apps
+	// can link more than one database
+	prefixPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{0,30}_$`)
+)
+
+// ErrInvalid is wrapped by the errors of invalid add-ons and links
+var ErrInvalid = errors.New("invalid database add-on")
+
+// Credentials are the connection details of a database add-on
+type Credentials struct {
+	Host     string `json:"host"`
+	Port     int32  `json:"port"`
+	Database string `json:"database"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	SSLMode  string `json:"sslmode"`
+}
+
+// ParseURL reads credentials from a postgres:// connection string, as
+// providers hand them out
+func ParseURL(raw string) (Credentials, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+		return Credentials{}, fmt.Errorf("%w: url must be a postgres:// connection string", ErrInvalid)
+	}
+
+	creds := Credentials{
+		Host:     u.Hostname(),
+		Database: strings.TrimPrefix(u.Path, "/"),
+		Username: u.User.Username(),
+		SSLMode:  u.Query().Get("sslmode"),
+	}
+	creds.Password, _ = u.User.Password()
+	if port := u.Port(); port != "" {
+		n, err := strconv.ParseInt(port, 10, 32)
+		if err != nil {
+			return Credentials{}, fmt.Errorf("%w: url has an invalid port", ErrInvalid)
+		}
+		creds.Port = int32(n)
+	}
+	return creds, nil
+}
+
+// Parse returns the credentials given as a connection string, as separate
+// fields, or both, the fields taking precedence, normalized
+func Parse(rawURL string, fields Credentials) (Credentials, error) {
+	creds := fields
+	if rawURL != "" {
+		parsed, err := ParseURL(rawURL)
+		if err != nil {
+			return Credentials{}, err
+		}
+		creds = parsed
+		for _, f := range []struct {
+			dst *string
+			src string
+		}{
+			{&creds.Host, fields.Host},
+			{&creds.Database, fields.Database},
+			{&creds.Username, fields.Username},
+			{&creds.Password, fields.Password},
+			{&creds.SSLMode, fields.SSLMode},
+		} {
+			if f.src != "" {
+				*f.dst = f.src
+			}
+		}
+		if fields.Port != 0 {
+			creds.Port = fields.Port
+		}
+	}
+	return Normalize(creds)
+}
+
+// Normalize fills in the default port and sslmode of credentials and
+// validates them
+func Normalize(creds Credentials) (Credentials, error) {
+	creds.Host = strings.TrimSpace(creds.Host)
+	if creds.Port == 0 {
+		creds.Port = DefaultPort
+	}
+	if creds.SSLMode == "" {
+		creds.SSLMode = "require"
+	}
+
+	switch {
+	case creds.Host == "" || strings.ContainsAny(creds.Host, "/@?# "):
+		return creds, fmt.Errorf("%w: host is required", ErrInvalid)
+	case creds.Port < 1 || creds.Port > 65535:
+		return creds, fmt.Errorf("%w: port must be between 1 and 65535", ErrInvalid)
+	case creds.Database == "":
+		return creds, fmt.Errorf("%w: database is required", ErrInvalid)
+	case creds.Username == "":
+		return creds, fmt.Errorf("%w: username is required", ErrInvalid)
+	case !slices.Contains(SSLModes, creds.SSLMode):
+		return creds, fmt.Errorf("%w: sslmode must be one of %s", ErrInvalid, strings.Join(SSLModes, ", "))
+	}
+	return creds, nil
+}
+
+// URL renders credentials as a connection string
+func (c Credentials) URL() string {
+	return c.url().String()
+}
+
+func (c Credentials) url() *url.URL {
+	u := url.URL{
+		Scheme:   "postgres",
+		Host:     net.JoinHostPort(c.Host, strconv.Itoa(int(c.Port))),
+		Path:     "/" + c.Database,
+		RawQuery: url.Values{"sslmode": {c.SSLMode}}.Encode(),
+	}
+	if c.Password != "" {
+		u.User = url.UserPassword(c.Username, c.Password)
+	} else {
+		u.User = url.User(c.Username)
+	}
+	return &u
+}
+
+// ValidateName checks the name of an add-on, which apps link it by
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("%w: name must start with a letter and contain only lowercase letters, numbers, and hyphens", ErrInvalid)
+	}
+	return nil
+}
+
+// NormalizeLink validates the prefix and variables of a link, defaulting to
+// every variable, and returns the variables in render order
+func NormalizeLink(prefix string, variables []string) ([]string, error) {
+	if prefix != "" && !prefixPattern.MatchString(prefix) {
+		return nil, fmt.Errorf("%w: prefix must be uppercase letters, numbers and underscores ending in an underscore, such as ANALYTICS_", ErrInvalid)
+	}
+	if len(variables) == 0 {
+		return slices.Clone(Variables), nil
+	}
+
+	picked := make([]string, 0, len(variables))
+	for _, v := range Variables {
+		if slices.Contains(variables, v) {
+			picked = append(picked, v)
+		}
+	}
+	for _, v := range variables {
+		if !slices.Contains(Variables, v) {
+			return nil, fmt.Errorf("%w: unknown variable %s, choose from %s", ErrInvalid, v, strings.Join(Variables, ", "))
+		}
+	}
+	return picked, nil
+}
+
+// ValidatePoolSize checks the pool size of a pooled link, defaulting it
+func ValidatePoolSize(size int32) (int32, error) {
+	if size == 0 {
+		return DefaultPoolSize, nil
+	}
+	if size < 1 || size > MaxPoolSize {
+		return 0, fmt.Errorf("%w: pool_size must be between 1 and %d", ErrInvalid, MaxPoolSize)
+	}
+	return size, nil
+}
+
+// Render returns the env vars a link injects
+func Render(creds Credentials, prefix string, variables []string) map[string]string {
+	return render(creds, prefix, variables, false)
+}
+
+func render(creds Credentials, prefix string, variables []string, masked bool) map[string]string {
+	values := map[string]string{
+		VarDatabaseURL: creds.URL(),
+		VarHost:        creds.Host,
+		VarPort:        strconv.Itoa(int(creds.Port)),
+		VarDatabase:    creds.Database,
+		VarUser:        creds.Username,
+		VarPassword:    creds.Password,
+		VarSSLMode:     creds.SSLMode,
+	}
+	if masked && creds.Password != "" {
+		values[VarDatabaseURL] = creds.url().Redacted()
+		values[VarPassword] = Masked
+	}
+	env := make(map[string]string, len(variables))
+	for _, v := range variables {
+		if value, ok := values[v]; ok {
+			env[prefix+v] = value
+		}
+	}
+	return env
+}
+
+// Link is an add-on linked to an app, with its credentials decrypted
+type Link struct {
+	Addon     string
+	Prefix    string
+	Variables []string
+	Pooling   bool
+	PoolSize  int32
+	// ListenPort is the localhost port of a pooled link's sidecar, set by
+	// AssignPoolerPorts
+	ListenPort int32
+	Credentials
+}
+
+// Client returns the credentials the app connects with: the database's, or
+// for pooled links its sidecar's, which TLS is not needed to reach
+func (l Link) Client() Credentials {
+	if !l.Pooling {
+		return l.Credentials
+	}
+	creds := l.Credentials
+	creds.Host = "127.0.0.1"
+	creds.Port = l.ListenPort
+	creds.SSLMode = "disable"
+	return creds
+}
+
+// Env returns the env vars a link injects
+func (l Link) Env() map[string]string {
+	return Render(l.Client(), l.Prefix, l.Variables)
+}
+
+// AssignPoolerPorts gives the pooled links of an app consecutive localhost
+// ports, in link order
+func AssignPoolerPorts(links []Link) {
+	port := PoolerPort
+	for i := range links {
+		links[i].ListenPort = 0
+		if links[i].Pooling {
+			links[i].ListenPort = port
+			port++
+		}
+	}
+}
+
+// Links returns the add-ons linked to an app
+func Links(ctx context.Context, cfg *config.Config, queries *db.Queries, appID uuid.UUID) ([]Link, error) {
+	rows, err := queries.ListAppDatabaseLinks(ctx, appID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list database add-ons: %w", err)
+	}
+
+	links := make([]Link, 0, len(rows))
+	for _, row := range rows {
+		creds, err := Decrypt(cfg, db.DatabaseAddon{
+			Name:              row.Name,
+			Host:              row.Host,
+			Port:              row.Port,
+			Database:          row.Database,
+			Username:          row.Username,
+			PasswordEncrypted: row.PasswordEncrypted,
+			Sslmode:           row.Sslmode,
+		})
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, Link{
+			Addon:       row.Name,
+			Prefix:      row.Prefix,
+			Variables:   row.Variables,
+			Pooling:     row.Pooling,
+			PoolSize:    row.PoolSize,
+			Credentials: creds,
+		})
+	}
+	AssignPoolerPorts(links)
+	return links, nil
+}
+
+// EnvVars returns the env vars the add-ons linked to an app inject
+func EnvVars(ctx context.Context, cfg *config.Config, queries *db.Queries, appID uuid.UUID) (map[string]string, error) {
+	links, err := Links(ctx, cfg, queries, appID)
+	if err != nil {
+		return nil, err
+	}
+	env := map[string]string{}
+	for _, link := range links {
+		for key, value := range link.Env() {
+			env[key] = value
+		}
+	}
+	return env, nil
+}
+
+// PreviewVar is an env var a linked add-on injects, as previewed
+type PreviewVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Addon string `json:"addon"`
+	// Overridden is set for variables the app's own env vars take
+	// precedence over
+	Overridden bool `json:"overridden"`
+}
+
+// Preview renders the env vars links inject, passwords masked, in name
+// order, flagging those the app's own env vars override
+func Preview(links []Link, own map[string]string) []PreviewVar {
+	var vars []PreviewVar
+	for _, link := range links {
+		for name, value := range render(link.Client(), link.Prefix, link.Variables, true) {
+			_, overridden := own[name]
+			vars = append(vars, PreviewVar{Name: name, Value: value, Addon: link.Addon, Overridden: overridden})
+		}
+	}
+	slices.SortFunc(vars, func(a, b PreviewVar) int { return strings.Compare(a.Name, b.Name) })
+	return vars
+}
+
+// Decrypt returns the credentials of an add-on
+func Decrypt(cfg *config.Config, addon db.DatabaseAddon) (Credentials, error) {
+	creds := Credentials{
+		Host:     addon.Host,
+		Port:     addon.Port,
+		Database: addon.Database,
+		Username: addon.Username,
+		SSLMode:  addon.Sslmode,
+	}
+	password, err := cryptoutil.DecryptBytes(addon.PasswordEncrypted, cfg.EncryptionKey)
+	if err != nil {
+		return creds, fmt.Errorf("failed to decrypt password of %s: %w", addon.Name, err)
+	}
+	creds.Password = string(password)
+	return creds, nil
+}
+
+// EncryptPassword encrypts the password of an add-on for storage; an empty
+// password, for databases trusting the network, is encrypted too
+func EncryptPassword(cfg *config.Config, password string) ([]byte, error) {
+	return cryptoutil.EncryptBytes([]byte(password), cfg.EncryptionKey)
+}
@@ -0,0 +1,114 @@
+// Package dbhealth tracks whether Postgres is reachable. The API starts and
+// keeps serving while the database is down: a Monitor pings it, requests
+// needing it are answered with 503 until it returns, and the connection
+// pool reconnects on its own once it does.
+package dbhealth
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// pingTimeout bounds each availability check
+const pingTimeout = 2 * time.Second
+
+// exempt are the paths served without the database: health checks and
+// metrics report on it themselves, and static files never need it
+var exempt = []string{"/api/health", "/api/status", "/api/metrics", "/static/"}
+
+// Pinger checks the database connection, such as a *pgxpool.Pool
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Monitor pings the database to know whether it is reachable
+type Monitor struct {
+	db       Pinger
+	interval time.Duration
+
+	available atomic.Bool
+	mu        sync.Mutex
+	downSince time.Time
+	ready     chan struct{}
+	readyOnce sync.Once
+}
+
+// NewMonitor creates a monitor checking the database every interval
+func NewMonitor(db Pinger, interval time.Duration) *Monitor {
+	return &Monitor{
+		db:       db,
+		interval: interval,
+		ready:    make(chan struct{}),
+	}
+}
+
+// Check pings the database once, logging when it goes away or comes back,
+// and reports whether it is reachable
+func (m *Monitor) Check(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	err := m.db.Ping(ctx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		if m.available.Swap(false) || m.downSince.IsZero() {
+			m.downSince = time.Now()
+			slog.Warn("database unavailable, serving 503 until it returns", "error", err)
+		}
+		return false
+	}
+
+	if !m.available.Swap(true) {
+		if !m.downSince.IsZero() {
+			slog.Info("database available again", "down_for", time.Since(m.downSince).Round(time.Second))
+		}
+		m.downSince = time.Time{}
+		m.readyOnce.Do(func() { close(m.ready) })
+	}
+	return true
+}
+
+// Run checks the database every interval until the context is canceled
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Check(ctx)
+		}
+	}
+}
+
+// Available reports whether the last check reached the database
+func (m *Monitor) Available() bool {
+	return m.available.Load()
+}
+
+// Ready is closed once the database was first reached, for workers that
+// must not start without it
+func (m *Monitor) Ready() <-chan struct{} {
+	return m.ready
+}
+
+// RetryAfter is how soon clients may retry while the database is down
+func (m *Monitor) RetryAfter() time.Duration {
+	return max(m.interval, time.Second)
+}
+
+// Exempt reports whether a path is served while the database is down
+func Exempt(path string) bool {
+	for _, p := range exempt {
+		if path == p || (strings.HasSuffix(p, "/") && strings.HasPrefix(path, p)) {
+			return true
+		}
+	}
+	return false
+}
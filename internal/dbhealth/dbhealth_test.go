@@ -0,0 +1,69 @@
+package dbhealth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeDB struct {
+	err error
+}
+
+func (f *fakeDB) Ping(context.Context) error {
+	return f.err
+}
+
+func TestCheck(t *testing.T) {
+	db := &fakeDB{err: errors.New("connection refused")}
+	m := NewMonitor(db, 5*time.Second)
+
+	if m.Check(context.Background()) || m.Available() {
+		t.Fatal("expected the database to be unavailable")
+	}
+	select {
+	case <-m.Ready():
+		t.Fatal("expected the monitor not to be ready before reaching the database")
+	default:
+	}
+
+	db.err = nil
+	if !m.Check(context.Background()) || !m.Available() {
+		t.Fatal("expected the database to be available")
+	}
+	select {
+	case <-m.Ready():
+	default:
+		t.Fatal("expected the monitor to be ready")
+	}
+
+	db.err = errors.New("connection reset")
+	if m.Check(context.Background()) || m.Available() {
+		t.Error("expected the database to go away")
+	}
+	db.err = nil
+	if !m.Check(context.Background()) {
+		t.Error("expected the database to come back")
+	}
+
+	if m.RetryAfter() != 5*time.Second {
+		t.Errorf("expected to retry after 5s, got %s", m.RetryAfter())
+	}
+}
+
+func TestExempt(t *testing.T) {
+	for path, want := range map[string]bool{
+		"/api/health":         true,
+		"/api/status":         true,
+		"/api/metrics":        true,
+		"/static/css/app.css": true,
+		"/api/apps":           false,
+		"/api/healthz":        false,
+		"/dashboard":          false,
+	} {
+		if got := Exempt(path); got != want {
+			t.Errorf("Exempt(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
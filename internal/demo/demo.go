@@ -0,0 +1,323 @@
+// Package demo seeds a demo user with sample apps, deployments, domains and
+// usage history, and fakes a cluster running them, so the dashboard and API
+// can be explored without GitHub or Kubernetes.
+package demo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appstatus"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Username is the demo user's name
+const Username = "demo"
+
+// GitHubID identifies the demo user; no GitHub account has a negative ID
+const GitHubID int64 = -1
+
+// usageHours is how much resource and bandwidth history is seeded
+const usageHours = 24
+
+// App is a sample app of the demo user
+type App struct {
+	Name        string
+	Region      string
+	Size        string
+	Description string
+	Tags        []string
+	// Deployments are the images deployed, oldest first; the last is current
+	Deployments []Deployment
+	Domain      string
+	// Replicas running in the fake cluster, none when the app is stopped
+	Replicas int32
+	// CPUMillis and MemoryMB are the usage of each replica
+	CPUMillis int64
+	MemoryMB  int64
+}
+
+// Deployment is a sample deployment; Error marks it failed
+type Deployment struct {
+	Image string
+	Error string
+}
+
+// Apps are the sample apps seeded for the demo user
+var Apps = []App{
+	{
+		Name:        "storefront",
+		Region:      "gdl",
+		Size:        "pro",
+		Description: "Online store with a product catalog and checkout",
+		Tags:        []string{"web", "production"},
+		Deployments: []Deployment{
+			{Image: "ghcr.io/nexo-demo/storefront:v1.0.0"},
+			{Image: "ghcr.io/nexo-demo/storefront:v1.1.0", Error: "readiness probe failed: GET /healthz returned 500"},
+			{Image: "ghcr.io/nexo-demo/storefront:v1.1.1"},
+		},
+		Domain:    "shop.example.com",
+		Replicas:  3,
+		CPUMillis: 180,
+		MemoryMB:  210,
+	},
+	{
+		Name:        "orders-api",
+		Region:      "mex",
+		Size:        "starter",
+		Description: "JSON API behind the storefront",
+		Tags:        []string{"api", "production"},
+		Deployments: []Deployment{
+			{Image: "ghcr.io/nexo-demo/orders-api:2024.10.1"},
+			{Image: "ghcr.io/nexo-demo/orders-api:2024.11.0"},
+		},
+		Replicas:  2,
+		CPUMillis: 60,
+		MemoryMB:  96,
+	},
+	{
+		Name:        "report-worker",
+		Region:      "qro",
+		Size:        "starter",
+		Description: "Nightly sales reports",
+		Tags:        []string{"jobs"},
+		Deployments: []Deployment{
+			{Image: "ghcr.io/nexo-demo/report-worker:0.1.0", Error: "container exited with code 1: missing REPORTS_BUCKET"},
+		},
+	},
+}
+
+// Seed creates the demo user and the sample apps it does not have yet, and
+// returns the user. Seeding again leaves existing apps as they are.
+func Seed(ctx context.Context, queries *db.Queries, now time.Time) (db.User, error) {
+	user, err := queries.GetUserByGitHubID(ctx, GitHubID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		user, err = queries.CreateUser(ctx, db.CreateUserParams{
+			GithubID: GitHubID,
+			Username: Username,
+			Email:    "demo@nexo.build",
+		})
+	}
+	if err != nil {
+		return db.User{}, fmt.Errorf("demo user: %w", err)
+	}
+
+	for _, sample := range Apps {
+		_, err := queries.GetAppByName(ctx, db.GetAppByNameParams{UserID: user.ID, Name: sample.Name})
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return db.User{}, err
+		}
+		if err := seedApp(ctx, queries, user, sample, now); err != nil {
+			return db.User{}, fmt.Errorf("demo app %s: %w", sample.Name, err)
+		}
+	}
+	return user, nil
+}
+
+func seedApp(ctx context.Context, queries *db.Queries, user db.User, sample App, now time.Time) error {
+	app, err := queries.CreateApp(ctx, db.CreateAppParams{
+		UserID: user.ID,
+		Name:   sample.Name,
+		Region: sample.Region,
+		Size:   sample.Size,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := queries.UpdateAppMetadata(ctx, db.UpdateAppMetadataParams{
+		ID:          app.ID,
+		Description: sample.Description,
+		Tags:        sample.Tags,
+	}); err != nil {
+		return err
+	}
+	activity(ctx, queries, user.ID, app.ID, "app.created", map[string]any{"name": app.Name})
+
+	var current db.Deployment
+	for i, d := range sample.Deployments {
+		deployment, err := queries.CreateDeployment(ctx, db.CreateDeploymentParams{
+			AppID:            app.ID,
+			Version:          int32(i + 1),
+			Image:            d.Image,
+			Status:           "pending",
+			DeployedByUserID: pgtype.UUID{Bytes: user.ID, Valid: true},
+			DeployedBy:       user.Username,
+		})
+		if err != nil {
+			return err
+		}
+		status := db.UpdateDeploymentStatusParams{ID: deployment.ID, Status: "running"}
+		if d.Error != "" {
+			status.Status = "failed"
+			status.Error = &d.Error
+		}
+		if current, err = queries.UpdateDeploymentStatus(ctx, status); err != nil {
+			return err
+		}
+		if _, err := queries.IncrementDeploymentCount(ctx, app.ID); err != nil {
+			return err
+		}
+		activity(ctx, queries, user.ID, app.ID, "deployment.created", map[string]any{"version": deployment.Version, "image": d.Image})
+	}
+
+	appStatus := db.AppStatusStopped
+	if sample.Replicas > 0 {
+		appStatus = db.AppStatusRunning
+	}
+	if _, err := appstatus.Set(ctx, queries, app, appStatus, pgtype.UUID{Bytes: current.ID, Valid: current.ID != uuid.Nil}); err != nil {
+		return err
+	}
+
+	if sample.Domain != "" {
+		domain, err := queries.CreateDomain(ctx, db.CreateDomainParams{
+			AppID:             app.ID,
+			Domain:            sample.Domain,
+			VerificationToken: "nexo-demo-" + sample.Name,
+			DnsMode:           "cname",
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := queries.UpdateDomainVerified(ctx, domain.ID); err != nil {
+			return err
+		}
+		if _, err := queries.UpdateDomainSSLStatus(ctx, db.UpdateDomainSSLStatusParams{ID: domain.ID, SslStatus: "active"}); err != nil {
+			return err
+		}
+	}
+
+	return seedUsage(ctx, queries, app.ID, sample, now)
+}
+
+// seedUsage records hourly resource and bandwidth usage of the last day,
+// busier during the day than at night
+func seedUsage(ctx context.Context, queries *db.Queries, appID uuid.UUID, sample App, now time.Time) error {
+	if sample.Replicas == 0 {
+		return nil
+	}
+	hour := now.Truncate(time.Hour)
+	for i := usageHours; i > 0; i-- {
+		start := hour.Add(-time.Duration(i) * time.Hour)
+		load := dailyLoad(start.Hour())
+
+		cpu := sample.CPUMillis * load / 100
+		memory := sample.MemoryMB << 20
+		if err := queries.AddAppResourceUsage(ctx, db.AddAppResourceUsageParams{
+			AppID:          appID,
+			PeriodStart:    start,
+			PodSamples:     sample.Replicas,
+			CpuMillisSum:   cpu * int64(sample.Replicas),
+			CpuMillisMax:   cpu * 3 / 2,
+			MemoryBytesSum: memory * int64(sample.Replicas),
+			MemoryBytesMax: memory * 5 / 4,
+		}); err != nil {
+			return err
+		}
+
+		requests := 400 * load * int64(sample.Replicas)
+		if err := queries.AddAppBandwidth(ctx, db.AddAppBandwidthParams{
+			AppID:        appID,
+			PeriodStart:  start,
+			IngressBytes: requests * 900,
+			EgressBytes:  requests * 14_000,
+			Requests:     requests,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dailyLoad is the percentage of peak load at an hour of the day
+func dailyLoad(hour int) int64 {
+	switch {
+	case hour < 7:
+		return 20
+	case hour < 10, hour >= 21:
+		return 55
+	default:
+		return 100
+	}
+}
+
+// activity records an activity log entry, best effort like the handlers
+func activity(ctx context.Context, queries *db.Queries, userID, appID uuid.UUID, action string, details map[string]any) {
+	data, _ := json.Marshal(details)
+	_, _ = queries.CreateActivityLog(ctx, db.CreateActivityLogParams{
+		UserID:  pgtype.UUID{Bytes: userID, Valid: true},
+		AppID:   pgtype.UUID{Bytes: appID, Valid: true},
+		Action:  action,
+		Details: data,
+	})
+}
+
+// Cluster returns a fake cluster running the sample apps, with pods,
+// metrics and logs to show
+func Cluster(namespacePrefix string, now time.Time) *k8s.Fake {
+	cluster := k8s.NewFake()
+	cluster.NamespacePrefix = namespacePrefix
+
+	for _, sample := range Apps {
+		if sample.Replicas == 0 {
+			continue
+		}
+
+		cluster.Statuses[sample.Name] = &k8s.AppStatus{
+			Status:            "running",
+			Replicas:          sample.Replicas,
+			ReadyReplicas:     sample.Replicas,
+			AvailableReplicas: sample.Replicas,
+		}
+		cluster.Replicas[sample.Name+"/web"] = sample.Replicas
+
+		metrics := &k8s.AppMetrics{
+			AppName:   sample.Name,
+			Namespace: cluster.NamespaceForApp(sample.Name),
+			PodCount:  int(sample.Replicas),
+			ReadyPods: int(sample.Replicas),
+		}
+		started := now.Add(-26 * time.Hour)
+		for i := range sample.Replicas {
+			name := fmt.Sprintf("%s-web-7d9f8c6b5-%c", sample.Name, 'a'+rune(i))
+			cluster.Pods[sample.Name] = append(cluster.Pods[sample.Name], k8s.PodInfo{
+				Name:      name,
+				Process:   "web",
+				Phase:     "Running",
+				Ready:     true,
+				Node:      fmt.Sprintf("%s-node-%d", sample.Region, i+1),
+				PodIP:     fmt.Sprintf("10.42.%d.%d", i+1, 10+i),
+				StartedAt: &started,
+			})
+
+			cpu := float64(sample.CPUMillis) / 1000
+			metrics.Pods = append(metrics.Pods, k8s.PodMetrics{
+				Name:        name,
+				CPUCores:    cpu,
+				MemoryBytes: sample.MemoryMB << 20,
+				MemoryMB:    float64(sample.MemoryMB),
+			})
+			metrics.TotalCPU += cpu
+			metrics.TotalMemoryMB += float64(sample.MemoryMB)
+
+			cluster.Logs[sample.Name] = append(cluster.Logs[sample.Name],
+				k8s.LogLine{Pod: name, Container: "app", Message: "server listening on :8080"},
+				k8s.LogLine{Pod: name, Container: "app", Message: "GET /healthz 200 1ms"},
+				k8s.LogLine{Pod: name, Container: "app", Message: "GET / 200 12ms"},
+			)
+		}
+		metrics.AvgCPU = metrics.TotalCPU / float64(sample.Replicas)
+		metrics.AvgMemoryMB = metrics.TotalMemoryMB / float64(sample.Replicas)
+		cluster.Metrics[sample.Name] = metrics
+	}
+	return cluster
+}
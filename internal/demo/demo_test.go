@@ -0,0 +1,51 @@
+package demo
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCluster(t *testing.T) {
+	ctx := context.Background()
+	cluster := Cluster("tenant-", time.Now())
+
+	status, err := cluster.GetAppStatus(ctx, "storefront")
+	if err != nil || status.Status != "running" || status.ReadyReplicas != 3 {
+		t.Errorf("expected storefront running 3 replicas, got %+v, %v", status, err)
+	}
+	if status, _ := cluster.GetAppStatus(ctx, "report-worker"); status.Status != "not_deployed" {
+		t.Errorf("expected the stopped worker not to be deployed, got %q", status.Status)
+	}
+
+	pods, _ := cluster.ListAppPods(ctx, "orders-api")
+	if len(pods) != 2 || !pods[0].Ready {
+		t.Errorf("expected 2 ready pods, got %+v", pods)
+	}
+
+	metrics, _ := cluster.GetAppMetrics(ctx, "storefront")
+	if metrics.PodCount != 3 || metrics.Namespace != "tenant-storefront" || len(metrics.Pods) != 3 {
+		t.Errorf("unexpected metrics %+v", metrics)
+	}
+
+	logs, _ := cluster.GetRecentLogs(ctx, "storefront", 2)
+	if len(logs) != 2 {
+		t.Errorf("expected the last 2 log lines, got %+v", logs)
+	}
+}
+
+func TestApps(t *testing.T) {
+	names := make(map[string]bool)
+	for _, app := range Apps {
+		if names[app.Name] {
+			t.Errorf("duplicate sample app %s", app.Name)
+		}
+		names[app.Name] = true
+		if len(app.Deployments) == 0 {
+			t.Errorf("sample app %s has no deployments", app.Name)
+		}
+		if app.Replicas > 0 && app.Deployments[len(app.Deployments)-1].Error != "" {
+			t.Errorf("sample app %s runs a failed deployment", app.Name)
+		}
+	}
+}
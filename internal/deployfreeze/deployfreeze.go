@@ -0,0 +1,146 @@
+// Package deployfreeze enforces deploy freezes: weekly windows set by an
+// organization's owner, such as Friday 18:00 to Monday 08:00, during which
+// apps of its members and machine users only deploy in an emergency,
+// overriding the freeze through the breakglass package.
+package deployfreeze
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/google/uuid"
+)
+
+const (
+	minutesPerDay  = 24 * 60
+	minutesPerWeek = 7 * minutesPerDay
+)
+
+var nameRegex = regexp.MustCompile(`^[a-z][a-z0-9-]{0,61}[a-z0-9]$`)
+
+// ValidName reports whether name is 2-63 lowercase letters, digits and
+// hyphens, starting with a letter
+func ValidName(name string) bool {
+	return nameRegex.MatchString(name)
+}
+
+// Window is a weekly window. A window ending before it starts wraps around
+// the week, one with equal start and end lasts the whole week.
+type Window struct {
+	StartDay    time.Weekday
+	StartMinute int
+	EndDay      time.Weekday
+	EndMinute   int
+	Location    *time.Location
+}
+
+// NewWindow returns the window of a freeze, false when its time zone is
+// unknown
+func NewWindow(startDay, startMinute, endDay, endMinute int32, timezone string) (Window, bool) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return Window{}, false
+	}
+	return Window{
+		StartDay:    time.Weekday(startDay),
+		StartMinute: int(startMinute),
+		EndDay:      time.Weekday(endDay),
+		EndMinute:   int(endMinute),
+		Location:    location,
+	}, true
+}
+
+// Active reports whether t falls in the window and when the window ends
+func (w Window) Active(t time.Time) (time.Time, bool) {
+	local := t.In(w.Location)
+	now := int(local.Weekday())*minutesPerDay + local.Hour()*60 + local.Minute()
+	start := int(w.StartDay)*minutesPerDay + w.StartMinute
+	end := int(w.EndDay)*minutesPerDay + w.EndMinute
+
+	// Minutes since the window started and until it ends, both wrapping
+	// around the week
+	elapsed := (now - start + minutesPerWeek) % minutesPerWeek
+	length := (end - start + minutesPerWeek) % minutesPerWeek
+	if length == 0 {
+		length = minutesPerWeek
+	}
+	if elapsed >= length {
+		return time.Time{}, false
+	}
+
+	remaining := time.Duration(length-elapsed) * time.Minute
+	return local.Truncate(time.Minute).Add(remaining), true
+}
+
+// Freeze is a freeze in effect for an app
+type Freeze struct {
+	Name   string    `json:"name"`
+	Org    string    `json:"org"`
+	EndsAt time.Time `json:"ends_at"`
+	// OrgOwnerID is told when an emergency deploy overrides the freeze
+	OrgOwnerID uuid.UUID `json:"-"`
+}
+
+// Active returns the freeze in effect at now for apps owned by userID, the
+// one ending last when several are
+func Active(ctx context.Context, queries *db.Queries, userID uuid.UUID, now time.Time) (*Freeze, error) {
+	freezes, err := queries.ListDeployFreezesForUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deploy freezes: %w", err)
+	}
+	return active(freezes, now), nil
+}
+
+func active(freezes []db.ListDeployFreezesForUserRow, now time.Time) *Freeze {
+	var current *Freeze
+	for _, f := range freezes {
+		window, ok := NewWindow(f.StartDay, f.StartMinute, f.EndDay, f.EndMinute, f.Timezone)
+		if !ok {
+			continue
+		}
+		endsAt, ok := window.Active(now)
+		if !ok || (current != nil && !endsAt.After(current.EndsAt)) {
+			continue
+		}
+		current = &Freeze{Name: f.Name, Org: f.OrgName, EndsAt: endsAt, OrgOwnerID: f.OrgOwnerID}
+	}
+	return current
+}
+
+// Message explains a freeze to someone deploying during it
+func (f *Freeze) Message() string {
+	return fmt.Sprintf("deploys are frozen by %s (%s) until %s; set emergency with a justification to deploy anyway",
+		f.Org, f.Name, f.EndsAt.UTC().Format(time.RFC3339))
+}
+
+// ParseDay parses a day of the week such as "fri" or "Friday"
+func ParseDay(s string) (time.Weekday, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) < 3 {
+		return 0, false
+	}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.HasPrefix(strings.ToLower(day.String()), s) {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+// ParseClock parses a time of day such as "18:00" into minutes
+func ParseClock(s string) (int, bool) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// FormatClock formats minutes of the day as a time such as "18:00"
+func FormatClock(minute int32) string {
+	return fmt.Sprintf("%02d:%02d", minute/60, minute%60)
+}
@@ -0,0 +1,99 @@
+package deployfreeze
+
+import (
+	"testing"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/google/uuid"
+)
+
+func TestWindowActive(t *testing.T) {
+	// Friday 18:00 to Monday 08:00 in UTC
+	weekend := Window{StartDay: time.Friday, StartMinute: 18 * 60, EndDay: time.Monday, EndMinute: 8 * 60, Location: time.UTC}
+	monday8 := time.Date(2026, 6, 8, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		at     time.Time
+		active bool
+	}{
+		{"friday afternoon", time.Date(2026, 6, 5, 17, 59, 0, 0, time.UTC), false},
+		{"friday evening", time.Date(2026, 6, 5, 18, 0, 0, 0, time.UTC), true},
+		{"sunday", time.Date(2026, 6, 7, 12, 0, 0, 0, time.UTC), true},
+		{"monday morning", time.Date(2026, 6, 8, 7, 59, 30, 0, time.UTC), true},
+		{"monday at the end", monday8, false},
+		{"wednesday", time.Date(2026, 6, 10, 12, 0, 0, 0, time.UTC), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endsAt, active := weekend.Active(tt.at)
+			if active != tt.active {
+				t.Fatalf("expected active %v, got %v", tt.active, active)
+			}
+			if active && !endsAt.Equal(monday8) {
+				t.Errorf("expected the freeze to end %v, got %v", monday8, endsAt)
+			}
+		})
+	}
+}
+
+func TestWindowActive_TimeZone(t *testing.T) {
+	window, ok := NewWindow(int32(time.Friday), 18*60, int32(time.Monday), 8*60, "America/New_York")
+	if !ok {
+		t.Fatal("expected a valid window")
+	}
+
+	// 20:00 UTC on Friday is 16:00 in New York during daylight saving time
+	if _, active := window.Active(time.Date(2026, 6, 5, 20, 0, 0, 0, time.UTC)); active {
+		t.Error("expected the freeze not to have started in New York")
+	}
+	if _, active := window.Active(time.Date(2026, 6, 5, 23, 0, 0, 0, time.UTC)); !active {
+		t.Error("expected the freeze to be active at 19:00 in New York")
+	}
+
+	if _, ok := NewWindow(0, 0, 1, 0, "Mars/Olympus"); ok {
+		t.Error("expected an unknown time zone to be rejected")
+	}
+}
+
+func TestActive(t *testing.T) {
+	now := time.Date(2026, 6, 6, 12, 0, 0, 0, time.UTC) // Saturday
+	owner := uuid.New()
+	freezes := []db.ListDeployFreezesForUserRow{
+		{Name: "weekend", OrgName: "acme", StartDay: 5, StartMinute: 18 * 60, EndDay: 1, EndMinute: 8 * 60, Timezone: "UTC"},
+		{Name: "launch", OrgName: "acme", OrgOwnerID: owner, StartDay: 6, StartMinute: 0, EndDay: 2, EndMinute: 0, Timezone: "UTC"},
+		{Name: "weekdays", OrgName: "other", StartDay: 1, StartMinute: 0, EndDay: 5, EndMinute: 0, Timezone: "UTC"},
+	}
+
+	freeze := active(freezes, now)
+	if freeze == nil || freeze.Name != "launch" || freeze.Org != "acme" || freeze.OrgOwnerID != owner {
+		t.Fatalf("expected the freeze ending last, got %+v", freeze)
+	}
+	if freeze := active(freezes[2:], now); freeze != nil {
+		t.Errorf("expected no freeze, got %+v", freeze)
+	}
+}
+
+func TestParse(t *testing.T) {
+	if day, ok := ParseDay("Fri"); !ok || day != time.Friday {
+		t.Errorf("expected friday, got %v", day)
+	}
+	if day, ok := ParseDay("thursday"); !ok || day != time.Thursday {
+		t.Errorf("expected thursday, got %v", day)
+	}
+	if _, ok := ParseDay("t"); ok {
+		t.Error("expected an ambiguous day to be rejected")
+	}
+
+	if minute, ok := ParseClock("18:30"); !ok || minute != 18*60+30 {
+		t.Errorf("expected 1110, got %d", minute)
+	}
+	if _, ok := ParseClock("25:00"); ok {
+		t.Error("expected an invalid time to be rejected")
+	}
+	if got := FormatClock(8 * 60); got != "08:00" {
+		t.Errorf("expected 08:00, got %q", got)
+	}
+}
@@ -0,0 +1,250 @@
+// Package deploylock records a lockfile for every deployment: the digest the
+// image resolved to, a hash of the env it was deployed with and hashes of
+// the manifests applied. Lockfiles are signed so an exported copy can later
+// be checked against what the platform recorded, for compliance audits of
+// exactly what ran when.
+package deploylock
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appconfig"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/registry"
+	"github.com/google/uuid"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+// LockfileVersion is the format version of lockfiles
+const LockfileVersion = 1
+
+// Lockfile pins what a deployment ran
+type Lockfile struct {
+	LockfileVersion   int       `json:"lockfile_version"`
+	App               string    `json:"app"`
+	AppID             uuid.UUID `json:"app_id"`
+	DeploymentID      uuid.UUID `json:"deployment_id"`
+	DeploymentVersion int32     `json:"deployment_version"`
+	CreatedAt         time.Time `json:"created_at"`
+	Image             string    `json:"image"`
+	// ImageDigest is the manifest digest the image resolved to, empty when
+	// the registry could not be read
+	ImageDigest string `json:"image_digest,omitempty"`
+	// EnvHash versions the env: the SHA-256 of its sorted KEY=value lines.
+	// Only the keys are listed, values never leave the platform.
+	EnvHash   string         `json:"env_hash"`
+	EnvKeys   []string       `json:"env_keys"`
+	Manifests []ManifestHash `json:"manifests"`
+	// Signature is an HMAC of the rest of the lockfile with the platform's
+	// signing key
+	Signature string `json:"signature,omitempty"`
+}
+
+// ManifestHash is the SHA-256 of a rendered manifest
+type ManifestHash struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+}
+
+// Build computes the lockfile of a deployment from the configuration it is
+// applied with. Secrets are left out of the manifests since they are
+// rendered redacted; the env hash covers them.
+func Build(appConfig *k8s.AppConfig, deployment db.Deployment, imageDigest string) (Lockfile, error) {
+	lock := Lockfile{
+		LockfileVersion:   LockfileVersion,
+		App:               appConfig.Name,
+		AppID:             deployment.AppID,
+		DeploymentID:      deployment.ID,
+		DeploymentVersion: deployment.Version,
+		CreatedAt:         deployment.CreatedAt.UTC(),
+		Image:             deployment.Image,
+		ImageDigest:       imageDigest,
+		EnvKeys:           []string{},
+		Manifests:         []ManifestHash{},
+	}
+
+	env := sha256.New()
+	for _, key := range slices.Sorted(maps.Keys(appConfig.EnvVars)) {
+		fmt.Fprintf(env, "%s=%s\n", key, appConfig.EnvVars[key])
+		lock.EnvKeys = append(lock.EnvKeys, key)
+	}
+	lock.EnvHash = hex.EncodeToString(env.Sum(nil))
+
+	for _, obj := range k8s.Manifests(appConfig) {
+		kind := obj.GetObjectKind().GroupVersionKind().Kind
+		if kind == "Secret" {
+			continue
+		}
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return Lockfile{}, fmt.Errorf("failed to render %s: %w", kind, err)
+		}
+		sum := sha256.Sum256(data)
+		lock.Manifests = append(lock.Manifests, ManifestHash{
+			Kind:   kind,
+			Name:   objectName(obj),
+			SHA256: hex.EncodeToString(sum[:]),
+		})
+	}
+	return lock, nil
+}
+
+// Sign sets the signature of a lockfile
+func Sign(lock *Lockfile, key string) error {
+	signature, err := signature(*lock, key)
+	if err != nil {
+		return err
+	}
+	lock.Signature = signature
+	return nil
+}
+
+// VerifySignature reports whether a lockfile is signed by the platform and
+// unchanged since
+func VerifySignature(lock Lockfile, key string) bool {
+	want, err := signature(lock, key)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal([]byte(lock.Signature), []byte(want))
+}
+
+func signature(lock Lockfile, key string) (string, error) {
+	lock.Signature = ""
+	data, err := json.Marshal(lock)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Difference is a field in which two lockfiles disagree
+type Difference struct {
+	Field string `json:"field"`
+	Want  string `json:"want"`
+	Got   string `json:"got"`
+}
+
+// Compare lists the differences of got from want: the image, its digest, the
+// env and every manifest
+func Compare(want, got Lockfile) []Difference {
+	diffs := []Difference{}
+	add := func(field, w, g string) {
+		if w != g {
+			diffs = append(diffs, Difference{Field: field, Want: w, Got: g})
+		}
+	}
+	add("image", want.Image, got.Image)
+	add("image_digest", want.ImageDigest, got.ImageDigest)
+	add("env_hash", want.EnvHash, got.EnvHash)
+
+	manifests := make(map[string]string, len(got.Manifests))
+	for _, m := range got.Manifests {
+		manifests[m.Kind+"/"+m.Name] = m.SHA256
+	}
+	for _, m := range want.Manifests {
+		key := m.Kind + "/" + m.Name
+		add("manifests."+key, m.SHA256, manifests[key])
+		delete(manifests, key)
+	}
+	for _, key := range slices.Sorted(maps.Keys(manifests)) {
+		add("manifests."+key, "", manifests[key])
+	}
+	return diffs
+}
+
+// Record builds, signs and stores the lockfile of a new deployment. An image
+// whose registry cannot be read is locked without its digest.
+func Record(ctx context.Context, cfg *config.Config, queries *db.Queries, app db.App, deployment db.Deployment) (Lockfile, error) {
+	lock, err := Current(ctx, cfg, queries, app, deployment)
+	if err != nil {
+		return Lockfile{}, err
+	}
+	if err := Sign(&lock, cfg.SigningKey()); err != nil {
+		return Lockfile{}, err
+	}
+
+	data, err := json.Marshal(lock)
+	if err != nil {
+		return Lockfile{}, err
+	}
+	if _, err := queries.CreateDeploymentLockfile(ctx, db.CreateDeploymentLockfileParams{
+		DeploymentID: deployment.ID,
+		Lockfile:     data,
+	}); err != nil {
+		return Lockfile{}, fmt.Errorf("failed to store lockfile: %w", err)
+	}
+	return lock, nil
+}
+
+// Current builds the unsigned lockfile deploying a deployment would have
+// now, with the app's current settings and what its image tag points to now
+func Current(ctx context.Context, cfg *config.Config, queries *db.Queries, app db.App, deployment db.Deployment) (Lockfile, error) {
+	appConfig, err := appconfig.Load(ctx, cfg, queries, app, deployment)
+	if err != nil {
+		return Lockfile{}, err
+	}
+	appConfig.Namespace = cfg.K8sNamespacePrefix + app.Name
+
+	digest, err := ImageDigest(ctx, cfg, deployment.Image)
+	if err != nil {
+		digest = ""
+	}
+	return Build(appConfig, deployment, digest)
+}
+
+// Load returns the stored lockfile of a deployment
+func Load(ctx context.Context, queries *db.Queries, deploymentID uuid.UUID) (Lockfile, error) {
+	record, err := queries.GetDeploymentLockfile(ctx, deploymentID)
+	if err != nil {
+		return Lockfile{}, err
+	}
+	var lock Lockfile
+	if err := json.Unmarshal(record.Lockfile, &lock); err != nil {
+		return Lockfile{}, fmt.Errorf("invalid stored lockfile: %w", err)
+	}
+	return lock, nil
+}
+
+// ImageDigest resolves the digest of an image, reading images of the
+// platform registry with a token of its own
+func ImageDigest(ctx context.Context, cfg *config.Config, image string) (string, error) {
+	ref, err := registry.ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	if cfg.RegistryHost != "" && ref.Registry == cfg.RegistryHost {
+		if strings.HasPrefix(ref.Reference, "sha256:") {
+			return ref.Reference, nil
+		}
+		issuer, err := registry.LoadIssuer(cfg.RegistryHost, cfg.RegistryTokenCertFile, cfg.RegistryTokenKeyFile)
+		if err != nil {
+			return "", err
+		}
+		return registry.NewHosted(cfg.RegistryAPI(), issuer).Digest(ctx, ref.Repository, ref.Reference)
+	}
+	return registry.NewClient().Digest(ctx, image)
+}
+
+func objectName(obj runtime.Object) string {
+	if o, ok := obj.(metav1.Object); ok {
+		return o.GetName()
+	}
+	return ""
+}
@@ -0,0 +1,115 @@
+package deploylock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/google/uuid"
+)
+
+func testConfig() *k8s.AppConfig {
+	return &k8s.AppConfig{
+		Name:         "web",
+		Namespace:    "fuego-web",
+		Image:        "registry.nexo.build/alice/web:v1",
+		Replicas:     1,
+		Port:         3000,
+		EnvVars:      map[string]string{"DATABASE_URL": "postgres://secret", "MODE": "production"},
+		DomainSuffix: "nexo.build",
+	}
+}
+
+func testDeployment() db.Deployment {
+	return db.Deployment{
+		ID:        uuid.New(),
+		AppID:     uuid.New(),
+		Version:   3,
+		Image:     "registry.nexo.build/alice/web:v1",
+		CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+func TestBuild(t *testing.T) {
+	lock, err := Build(testConfig(), testDeployment(), "sha256:abc")
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if lock.App != "web" || lock.DeploymentVersion != 3 || lock.ImageDigest != "sha256:abc" {
+		t.Errorf("unexpected lockfile %+v", lock)
+	}
+	if len(lock.EnvKeys) != 2 || lock.EnvKeys[0] != "DATABASE_URL" || lock.EnvKeys[1] != "MODE" {
+		t.Errorf("expected sorted env keys, got %v", lock.EnvKeys)
+	}
+	if len(lock.Manifests) == 0 {
+		t.Fatal("expected manifest hashes")
+	}
+	for _, m := range lock.Manifests {
+		if m.Kind == "Secret" {
+			t.Error("expected secrets to be left out of the manifests")
+		}
+		if m.Name == "" || len(m.SHA256) != 64 {
+			t.Errorf("unexpected manifest hash %+v", m)
+		}
+	}
+
+	// The env hash versions values as well as keys
+	cfg := testConfig()
+	cfg.EnvVars["MODE"] = "staging"
+	changed, err := Build(cfg, testDeployment(), "sha256:abc")
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if changed.EnvHash == lock.EnvHash {
+		t.Error("expected a changed value to change the env hash")
+	}
+}
+
+func TestSignature(t *testing.T) {
+	lock, err := Build(testConfig(), testDeployment(), "sha256:abc")
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if err := Sign(&lock, "key"); err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if !VerifySignature(lock, "key") {
+		t.Error("expected the signature to verify")
+	}
+	if VerifySignature(lock, "other") {
+		t.Error("expected another key to be rejected")
+	}
+
+	lock.ImageDigest = "sha256:def"
+	if VerifySignature(lock, "key") {
+		t.Error("expected an edited lockfile to be rejected")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	want, err := Build(testConfig(), testDeployment(), "sha256:abc")
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if diffs := Compare(want, want); len(diffs) != 0 {
+		t.Errorf("expected no differences, got %v", diffs)
+	}
+
+	cfg := testConfig()
+	cfg.Replicas = 2
+	got, err := Build(cfg, testDeployment(), "sha256:def")
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	fields := make(map[string]bool)
+	for _, d := range Compare(want, got) {
+		fields[d.Field] = true
+	}
+	if !fields["image_digest"] || !fields["manifests.Deployment/web"] {
+		t.Errorf("expected the digest and deployment to differ, got %v", fields)
+	}
+	if fields["env_hash"] || fields["image"] {
+		t.Errorf("expected the env and image to match, got %v", fields)
+	}
+}
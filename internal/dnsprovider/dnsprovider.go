@@ -0,0 +1,187 @@
+// Package dnsprovider recognizes the DNS host of a domain from its
+// nameservers and phrases the records to publish the way that host's
+// dashboard expects them, for the guided domain setup.
+package dnsprovider
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/domainverify"
+)
+
+// Provider is a DNS host
+type Provider struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	DashboardURL string `json:"dashboard_url,omitempty"`
+	// AliasRecordType is the record type the provider uses for flattened
+	// CNAMEs at the apex, empty if it has none
+	AliasRecordType string `json:"alias_record_type,omitempty"`
+	// Notes are provider-specific pitfalls
+	Notes []string `json:"notes,omitempty"`
+	// patterns identify the provider's nameserver hostnames
+	patterns []string
+}
+
+// Unknown is reported when the nameservers match no known provider
+var Unknown = Provider{ID: "unknown", Name: "your DNS provider"}
+
+var providers = []Provider{
+	{
+		ID:              "cloudflare",
+		Name:            "Cloudflare",
+		DashboardURL:    "https://dash.cloudflare.com",
+		AliasRecordType: "CNAME",
+		Notes: []string{
+			"Set the proxy status of the records to DNS only. Proxied records answer with Cloudflare's addresses, so routing cannot be checked and certificates are not issued.",
+		},
+		patterns: []string{".ns.cloudflare.com"},
+	},
+	{
+		ID:           "route53",
+		Name:         "Amazon Route 53",
+		DashboardURL: "https://console.aws.amazon.com/route53/v2/hostedzones",
+		Notes: []string{
+			"Route 53 ALIAS records only point at AWS resources. Use A records for apex domains.",
+		},
+		patterns: []string{".awsdns-"},
+	},
+	{
+		ID:           "godaddy",
+		Name:         "GoDaddy",
+		DashboardURL: "https://dcc.godaddy.com/control/portfolio",
+		Notes: []string{
+			"GoDaddy appends the domain to the name you enter. Enter only the host shown, such as @ or www.",
+		},
+		patterns: []string{".domaincontrol.com"},
+	},
+	{
+		ID:              "namecheap",
+		Name:            "Namecheap",
+		DashboardURL:    "https://ap.www.namecheap.com/domains/list",
+		AliasRecordType: "ALIAS",
+		Notes: []string{
+			"Records are managed under Manage > Advanced DNS.",
+		},
+		patterns: []string{".registrar-servers.com"},
+	},
+	{
+		ID:           "google",
+		Name:         "Google Cloud DNS",
+		DashboardURL: "https://console.cloud.google.com/net-services/dns/zones",
+		patterns:     []string{".googledomains.com"},
+	},
+	{
+		ID:           "digitalocean",
+		Name:         "DigitalOcean",
+		DashboardURL: "https://cloud.digitalocean.com/networking/domains",
+		patterns:     []string{".digitalocean.com"},
+	},
+	{
+		ID:              "dnsimple",
+		Name:            "DNSimple",
+		DashboardURL:    "https://dnsimple.com/dashboard",
+		AliasRecordType: "ALIAS",
+		patterns:        []string{".dnsimple.com", ".dnsimple-edge.net"},
+	},
+	{
+		ID:              "porkbun",
+		Name:            "Porkbun",
+		DashboardURL:    "https://porkbun.com/account/domainsSpeedy",
+		AliasRecordType: "ALIAS",
+		patterns:        []string{".porkbun.com"},
+	},
+	{
+		ID:              "gandi",
+		Name:            "Gandi",
+		DashboardURL:    "https://admin.gandi.net/domain",
+		AliasRecordType: "ALIAS",
+		patterns:        []string{".gandi.net"},
+	},
+	{
+		ID:              "vercel",
+		Name:            "Vercel",
+		DashboardURL:    "https://vercel.com/dashboard/domains",
+		AliasRecordType: "ALIAS",
+		patterns:        []string{".vercel-dns.com"},
+	},
+}
+
+// Detect returns the provider serving the given nameservers
+func Detect(nameservers []string) Provider {
+	for _, ns := range nameservers {
+		host := "." + strings.ToLower(strings.TrimSuffix(ns, "."))
+		for _, provider := range providers {
+			for _, pattern := range provider.patterns {
+				if strings.Contains(host, pattern) {
+					return provider
+				}
+			}
+		}
+	}
+	return Unknown
+}
+
+// Record is a DNS record as entered in a provider's dashboard
+type Record struct {
+	Type string `json:"type"`
+	// Host is the name relative to the zone, @ for the apex
+	Host  string `json:"host"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Instructions tell a domain owner how to publish records at a provider
+type Instructions struct {
+	Records []Record `json:"records"`
+	Steps   []string `json:"steps"`
+	Notes   []string `json:"notes,omitempty"`
+}
+
+// Host returns name relative to zone, as most dashboards expect it
+func Host(name, zone string) string {
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	zone = strings.TrimSuffix(strings.ToLower(zone), ".")
+	if name == zone {
+		return "@"
+	}
+	return strings.TrimSuffix(name, "."+zone)
+}
+
+// Build returns the steps publishing records in the zone at provider.
+// ALIAS records use the provider's own flattening record type.
+func Build(provider Provider, zone string, records []domainverify.Record) Instructions {
+	instructions := Instructions{Notes: slices.Clone(provider.Notes)}
+
+	step := fmt.Sprintf("Sign in to %s and open the DNS records of %s", provider.Name, zone)
+	if provider.DashboardURL != "" {
+		step += " (" + provider.DashboardURL + ")"
+	}
+	instructions.Steps = append(instructions.Steps, step)
+
+	routing := false
+	for _, record := range records {
+		recordType := record.Type
+		if recordType == "ALIAS" {
+			if provider.AliasRecordType == "" {
+				instructions.Notes = append(instructions.Notes, fmt.Sprintf("%s has no ALIAS records. Switch the domain to dns_mode a to use A records instead.", provider.Name))
+			} else {
+				recordType = provider.AliasRecordType
+			}
+		}
+		host := Host(record.Name, zone)
+		instructions.Records = append(instructions.Records, Record{Type: recordType, Host: host, Name: record.Name, Value: record.Value})
+		instructions.Steps = append(instructions.Steps, fmt.Sprintf("Add a %s record with host %s and value %s", recordType, host, record.Value))
+		if recordType != "TXT" {
+			routing = true
+		}
+	}
+	if routing {
+		instructions.Steps = append(instructions.Steps, "Delete older A, AAAA or CNAME records on the same hosts that point elsewhere")
+	}
+
+	instructions.Steps = append(instructions.Steps, "Save the changes and run the verification check. Changes usually appear within minutes but can take up to 48 hours to propagate")
+	return instructions
+}
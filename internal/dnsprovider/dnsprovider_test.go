@@ -0,0 +1,83 @@
+package dnsprovider
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/domainverify"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		nameservers []string
+		want        string
+	}{
+		{[]string{"ada.ns.cloudflare.com.", "bob.ns.cloudflare.com."}, "cloudflare"},
+		{[]string{"ns-1234.awsdns-12.org", "ns-99.awsdns-01.com"}, "route53"},
+		{[]string{"NS51.DOMAINCONTROL.COM"}, "godaddy"},
+		{[]string{"dns1.registrar-servers.com"}, "namecheap"},
+		{[]string{"ns-cloud-a1.googledomains.com"}, "google"},
+		{[]string{"ns1.example.net", "ns1.dnsimple.com"}, "dnsimple"},
+		{[]string{"ns1.example.net"}, "unknown"},
+		{nil, "unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := Detect(tt.nameservers); got.ID != tt.want {
+			t.Errorf("Detect(%v) = %q, want %q", tt.nameservers, got.ID, tt.want)
+		}
+	}
+}
+
+func TestHost(t *testing.T) {
+	tests := []struct {
+		name, zone, want string
+	}{
+		{"example.com", "example.com", "@"},
+		{"www.example.com.", "example.com", "www"},
+		{"_fuego-verify.shop.example.com.mx", "example.com.mx", "_fuego-verify.shop"},
+	}
+
+	for _, tt := range tests {
+		if got := Host(tt.name, tt.zone); got != tt.want {
+			t.Errorf("Host(%q, %q) = %q, want %q", tt.name, tt.zone, got, tt.want)
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	records := []domainverify.Record{
+		{Type: "TXT", Name: "_fuego-verify.example.com", Value: "token123"},
+		{Type: "ALIAS", Name: "example.com", Value: "web.nexo.build"},
+	}
+
+	cloudflare := Detect([]string{"ada.ns.cloudflare.com"})
+	instructions := Build(cloudflare, "example.com", records)
+	if len(instructions.Records) != 2 {
+		t.Fatalf("expected 2 records, got %v", instructions.Records)
+	}
+	if got := instructions.Records[1]; got.Type != "CNAME" || got.Host != "@" {
+		t.Errorf("expected a flattened CNAME at the apex, got %+v", got)
+	}
+	if instructions.Records[0].Host != "_fuego-verify" {
+		t.Errorf("unexpected TXT host %q", instructions.Records[0].Host)
+	}
+	if !strings.Contains(instructions.Steps[0], "Cloudflare") {
+		t.Errorf("expected the first step to name the provider, got %q", instructions.Steps[0])
+	}
+	if len(instructions.Notes) != 1 {
+		t.Errorf("expected the proxy note, got %v", instructions.Notes)
+	}
+
+	godaddy := Detect([]string{"ns51.domaincontrol.com"})
+	instructions = Build(godaddy, "example.com", records)
+	if instructions.Records[1].Type != "ALIAS" {
+		t.Errorf("expected ALIAS to be kept, got %+v", instructions.Records[1])
+	}
+	if len(instructions.Notes) != 2 || !strings.Contains(instructions.Notes[1], "dns_mode a") {
+		t.Errorf("expected a note suggesting A records, got %v", instructions.Notes)
+	}
+	if len(godaddy.Notes) != 1 {
+		t.Errorf("Build must not modify the provider notes, got %v", godaddy.Notes)
+	}
+}
@@ -0,0 +1,220 @@
+// Package dnszone onboards subdomain zones delegated to the platform's
+// nameservers. A user adds a zone such as apps.example.com and publishes NS
+// records for it at their DNS host. Once the delegation is seen the zone is
+// active: domains of the user's apps in it count as verified, since only the
+// zone's owner can delegate it, and the platform's nameservers answer for
+// them with the records routing them to their app. Certificates are then
+// issued on deploy like for any verified domain, with a CAA record at the
+// zone apex allowing the platform's certificate authority.
+package dnszone
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/domainrecords"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/domainverify"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/events"
+)
+
+// Zone statuses
+const (
+	StatusPending = "pending"
+	StatusActive  = "active"
+)
+
+// EventDelegated is published when a zone's delegation is first seen
+const EventDelegated = "dns_zone.delegated"
+
+var (
+	ErrInvalidZone  = errors.New("zone must be a subdomain such as apps.example.com")
+	ErrPlatformZone = errors.New("zones of the platform's own domains cannot be delegated")
+)
+
+var zoneRegex = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,}$`)
+
+// Normalize lowercases a zone or domain name and drops its trailing dot
+func Normalize(name string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+}
+
+// Validate checks that a normalized zone can be delegated: a subdomain, as
+// a whole domain is delegated at its registrar rather than with NS records,
+// outside the platform's own domains
+func Validate(cfg *config.Config, zone string) error {
+	if len(zone) > 253 || !zoneRegex.MatchString(zone) || domainrecords.IsApex(zone) {
+		return ErrInvalidZone
+	}
+	for _, platform := range []string{cfg.PlatformDomain, cfg.AppsDomainSuffix} {
+		platform = Normalize(platform)
+		if platform != "" && (Contains(zone, platform) || Contains(platform, zone)) {
+			return ErrPlatformZone
+		}
+	}
+	return nil
+}
+
+// Contains reports whether name is the zone's apex or inside the zone
+func Contains(zone, name string) bool {
+	return name == zone || strings.HasSuffix(name, "."+zone)
+}
+
+// Delegation returns the NS records to publish in the parent zone
+func Delegation(cfg *config.Config, zone string) []domainverify.Record {
+	records := make([]domainverify.Record, 0, len(cfg.Nameservers))
+	for _, ns := range cfg.Nameservers {
+		records = append(records, domainverify.Record{Type: "NS", Name: zone, Value: Normalize(ns)})
+	}
+	return records
+}
+
+// Delegated reports whether public DNS delegates a zone to the platform's
+// nameservers and only to them, with the nameservers it found
+func Delegated(ctx context.Context, checker *domainrecords.Checker, cfg *config.Config, zone string) (bool, []string) {
+	found, err := checker.Delegation(ctx, zone)
+	if err != nil || len(found) == 0 {
+		return false, nil
+	}
+	ours := make([]string, len(cfg.Nameservers))
+	for i, ns := range cfg.Nameservers {
+		ours[i] = Normalize(ns)
+	}
+	for _, ns := range found {
+		if !slices.Contains(ours, ns) {
+			return false, found
+		}
+	}
+	return true, found
+}
+
+// Activate marks a zone as delegated and verifies the domains of the owner's
+// apps in it, returning how many were verified
+func Activate(ctx context.Context, queries *db.Queries, zone db.DnsZone) (db.DnsZone, int64, error) {
+	zone, err := queries.ActivateDNSZone(ctx, zone.ID)
+	if err != nil {
+		return db.DnsZone{}, 0, fmt.Errorf("failed to activate zone: %w", err)
+	}
+	verified, err := queries.VerifyDomainsInDNSZone(ctx, db.VerifyDomainsInDNSZoneParams{
+		UserID: zone.UserID,
+		Zone:   zone.Zone,
+	})
+	if err != nil {
+		return zone, 0, fmt.Errorf("failed to verify the zone's domains: %w", err)
+	}
+	return zone, verified, nil
+}
+
+// DelegatedEvent is published when a zone is activated
+func DelegatedEvent(zone db.DnsZone, verified int64) events.Event {
+	return events.Event{
+		Type:    EventDelegated,
+		UserID:  zone.UserID,
+		Message: fmt.Sprintf("%s is delegated to the platform's nameservers; its domains are now managed automatically", zone.Zone),
+		Payload: map[string]any{
+			"zone":             zone.Zone,
+			"verified_domains": verified,
+		},
+	}
+}
+
+// Records returns the records the platform serves for a domain in a zone.
+// The zone apex cannot be a CNAME, so it and domains set up as A or ALIAS
+// point at the region's ingress IPs; other domains are CNAMEs of the app
+// hostname. Without ingress IPs the apex gets no records.
+func Records(cfg *config.Config, row db.ListDNSZoneRecordsRow) []domainverify.Record {
+	target := row.AppName + "." + cfg.AppsDomainSuffix
+	mode := domainrecords.ModeCNAME
+	if row.Domain == row.Zone || domainrecords.Mode(row.DnsMode) != domainrecords.ModeCNAME {
+		mode = domainrecords.ModeA
+	}
+	records, err := domainrecords.Records(row.Domain, mode, target, cfg.IngressIPsForRegion(row.Region))
+	if err == nil {
+		return records
+	}
+	if row.Domain == row.Zone {
+		return nil
+	}
+	records, _ = domainrecords.Records(row.Domain, domainrecords.ModeCNAME, target, nil)
+	return records
+}
+
+// Table returns the records of the domains of every active zone, by zone
+func Table(ctx context.Context, cfg *config.Config, queries *db.Queries) (map[string][]domainverify.Record, error) {
+	zones, err := queries.ListActiveDNSZones(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list zones: %w", err)
+	}
+	table := make(map[string][]domainverify.Record, len(zones))
+	for _, zone := range zones {
+		table[zone.Zone] = []domainverify.Record{}
+	}
+
+	rows, err := queries.ListDNSZoneRecords(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list zone records: %w", err)
+	}
+	for _, row := range rows {
+		table[row.Zone] = append(table[row.Zone], Records(cfg, row)...)
+	}
+	return table, nil
+}
+
+// Checker activates pending zones once their delegation is seen
+type Checker struct {
+	queries *db.Queries
+	cfg     *config.Config
+	events  events.Publisher
+	dns     *domainrecords.Checker
+}
+
+// NewChecker creates a checker looking delegations up on public resolvers
+func NewChecker(queries *db.Queries, cfg *config.Config, publisher events.Publisher) *Checker {
+	return &Checker{
+		queries: queries,
+		cfg:     cfg,
+		events:  publisher,
+		dns:     domainrecords.NewChecker(domainverify.PublicResolvers),
+	}
+}
+
+// Check looks up the delegation of every pending zone, activating the
+// delegated ones
+func (c *Checker) Check(ctx context.Context) error {
+	if len(c.cfg.Nameservers) == 0 {
+		return nil
+	}
+	zones, err := c.queries.ListPendingDNSZones(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list pending zones: %w", err)
+	}
+
+	for _, zone := range zones {
+		lookupCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		delegated, _ := Delegated(lookupCtx, c.dns, c.cfg, zone.Zone)
+		cancel()
+		if !delegated {
+			if err := c.queries.MarkDNSZoneChecked(ctx, zone.ID); err != nil {
+				slog.Warn("failed to record zone check", "zone", zone.Zone, "error", err)
+			}
+			continue
+		}
+
+		zone, verified, err := Activate(ctx, c.queries, zone)
+		if err != nil {
+			slog.Error("failed to activate zone", "zone", zone.Zone, "error", err)
+			continue
+		}
+		if err := c.events.Publish(ctx, DelegatedEvent(zone, verified)); err != nil {
+			slog.Error("failed to publish zone delegation", "zone", zone.Zone, "error", err)
+		}
+	}
+	return nil
+}
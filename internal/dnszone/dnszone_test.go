@@ -0,0 +1,59 @@
+package dnszone
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+)
+
+func TestValidate(t *testing.T) {
+	cfg := &config.Config{PlatformDomain: "cloud.nexo.build", AppsDomainSuffix: "nexo.build"}
+	tests := map[string]error{
+		"apps.example.com":     nil,
+		"a.b.example.com.mx":   nil,
+		"example.com":          ErrInvalidZone,
+		"example.com.mx":       ErrInvalidZone,
+		"bad_zone.example.com": ErrInvalidZone,
+		"apps.nexo.build":      ErrPlatformZone,
+		"build":                ErrInvalidZone,
+	}
+
+	for zone, want := range tests {
+		if err := Validate(cfg, zone); !errors.Is(err, want) {
+			t.Errorf("Validate(%q) = %v, want %v", zone, err, want)
+		}
+	}
+}
+
+func TestContains(t *testing.T) {
+	if !Contains("apps.example.com", "apps.example.com") || !Contains("apps.example.com", "web.apps.example.com") {
+		t.Error("expected the apex and subdomains to be in the zone")
+	}
+	if Contains("apps.example.com", "myapps.example.com") {
+		t.Error("expected a sibling sharing a suffix not to be in the zone")
+	}
+}
+
+func TestRecords(t *testing.T) {
+	cfg := &config.Config{AppsDomainSuffix: "nexo.build"}
+	row := db.ListDNSZoneRecordsRow{Zone: "apps.example.com", Domain: "web.apps.example.com", DnsMode: "cname", AppName: "web", Region: "us"}
+
+	records := Records(cfg, row)
+	if len(records) != 1 || records[0].Type != "CNAME" || records[0].Value != "web.nexo.build" {
+		t.Errorf("expected a CNAME to the app, got %+v", records)
+	}
+
+	// The apex cannot be a CNAME and gets nothing without ingress IPs
+	row.Domain = row.Zone
+	if records := Records(cfg, row); len(records) != 0 {
+		t.Errorf("expected no records for the apex, got %+v", records)
+	}
+
+	cfg.IngressIPs = []string{"203.0.113.10"}
+	records = Records(cfg, row)
+	if len(records) != 1 || records[0].Type != "A" || records[0].Value != "203.0.113.10" {
+		t.Errorf("expected an A record for the apex, got %+v", records)
+	}
+}
@@ -0,0 +1,365 @@
+package domainrecords
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/domainverify"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// CAAIssuer is the certificate authority cert-manager requests certificates
+// from. A CAA record set without it blocks issuance for the domain.
+const CAAIssuer = "letsencrypt.org"
+
+// typeCAA is the CAA resource record type, which dnsmessage does not name
+const typeCAA = dnsmessage.Type(257)
+
+// Reason is a machine-readable cause of a domain not serving the app
+type Reason string
+
+const (
+	// ReasonRecordsMissing means the domain does not resolve at all
+	ReasonRecordsMissing Reason = "records_missing"
+	// ReasonWrongTarget means the domain resolves somewhere else
+	ReasonWrongTarget Reason = "wrong_target"
+	// ReasonPropagationPending means the domain's nameservers serve the
+	// right records but public resolvers still see the old ones
+	ReasonPropagationPending Reason = "propagation_pending"
+	// ReasonCAABlocking means routing works but CAA records forbid the
+	// platform's certificate authority from issuing a certificate
+	ReasonCAABlocking Reason = "caa_blocking"
+)
+
+// Answer is what one set of nameservers returns for a domain
+type Answer struct {
+	// Configured reports whether the domain routes to the platform
+	Configured bool
+	// Values are the CNAME target or the addresses the domain resolves to
+	Values []string
+}
+
+// CAA is a certification authority authorization record
+type CAA struct {
+	Flags uint8
+	Tag   string
+	Value string
+}
+
+// Diagnosis explains whether a domain routes to the platform and, if not,
+// what the owner has to fix
+type Diagnosis struct {
+	Configured bool     `json:"configured"`
+	Reason     Reason   `json:"reason,omitempty"`
+	Message    string   `json:"message,omitempty"`
+	Found      []string `json:"found,omitempty"`
+}
+
+// Checker looks up the records routing a domain
+type Checker struct {
+	lookupCNAME func(ctx context.Context, host string) (string, error)
+	lookupIP    func(ctx context.Context, network, host string) ([]netip.Addr, error)
+	lookupNS    func(ctx context.Context, name string) ([]*net.NS, error)
+	lookupCAA   func(ctx context.Context, name string) ([]CAA, error)
+}
+
+// NewChecker creates a checker querying the given DNS servers in order
+func NewChecker(servers []string) *Checker {
+	checker := newChecker(domainverify.NewResolver(servers))
+	checker.lookupCAA = func(ctx context.Context, name string) ([]CAA, error) {
+		return queryCAA(ctx, servers, name)
+	}
+	return checker
+}
+
+// Authoritative creates a checker querying a domain's own nameservers, which
+// see record changes before public resolvers do
+func Authoritative(nameservers []string) *Checker {
+	return NewChecker(domainverify.NameserverAddrs(nameservers))
+}
+
+func newChecker(resolver *net.Resolver) *Checker {
+	return &Checker{
+		lookupCNAME: resolver.LookupCNAME,
+		lookupIP:    resolver.LookupNetIP,
+		lookupNS:    resolver.LookupNS,
+	}
+}
+
+// Nameservers returns the nameservers of the zone domain belongs to
+func (c *Checker) Nameservers(ctx context.Context, domain string) ([]string, error) {
+	return c.Delegation(ctx, Apex(domain))
+}
+
+// Delegation returns the nameservers a zone is delegated to, which for a
+// subdomain zone are the ones named by its NS records in the parent zone
+func (c *Checker) Delegation(ctx context.Context, zone string) ([]string, error) {
+	records, err := c.lookupNS(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	nameservers := make([]string, 0, len(records))
+	for _, ns := range records {
+		nameservers = append(nameservers, strings.ToLower(strings.TrimSuffix(ns.Host, ".")))
+	}
+	slices.Sort(nameservers)
+	return nameservers, nil
+}
+
+// Check looks up how domain currently resolves. CNAME domains must resolve
+// to target; A and ALIAS domains must resolve to one of the ingress IPs, as
+// flattened CNAMEs answer with the target's addresses.
+func (c *Checker) Check(ctx context.Context, domain string, mode Mode, target string, ingressIPs []string) Answer {
+	if mode == ModeCNAME {
+		cname, err := c.lookupCNAME(ctx, domain)
+		if err != nil {
+			return Answer{}
+		}
+		cname = strings.TrimSuffix(cname, ".")
+		if strings.EqualFold(cname, strings.TrimSuffix(target, ".")) {
+			return Answer{Configured: true, Values: []string{cname}}
+		}
+		// A name without a CNAME is its own canonical name; report the
+		// addresses it points at instead
+		if !strings.EqualFold(cname, strings.TrimSuffix(domain, ".")) {
+			return Answer{Values: []string{cname}}
+		}
+	}
+
+	addrs, err := c.lookupIP(ctx, "ip", domain)
+	if err != nil {
+		return Answer{}
+	}
+	var answer Answer
+	for _, addr := range addrs {
+		ip := addr.Unmap().String()
+		answer.Values = append(answer.Values, ip)
+		if mode != ModeCNAME && slices.Contains(ingressIPs, ip) {
+			answer.Configured = true
+		}
+	}
+	return answer
+}
+
+// CAA returns the CAA records applying to domain: those of the closest
+// name, climbing towards the zone apex and its parents, that has any
+func (c *Checker) CAA(ctx context.Context, domain string) ([]CAA, error) {
+	if c.lookupCAA == nil {
+		return nil, nil
+	}
+	name := strings.TrimSuffix(strings.ToLower(domain), ".")
+	for strings.Contains(name, ".") {
+		records, err := c.lookupCAA(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if len(records) > 0 {
+			return records, nil
+		}
+		_, name, _ = strings.Cut(name, ".")
+	}
+	return nil, nil
+}
+
+// CAAPermits reports whether records allow issuer to issue certificates.
+// An empty set, or one without issue properties, allows every issuer.
+func CAAPermits(records []CAA, issuer string) bool {
+	restricted := false
+	for _, record := range records {
+		if !strings.EqualFold(record.Tag, "issue") {
+			continue
+		}
+		restricted = true
+		domain, _, _ := strings.Cut(record.Value, ";")
+		if strings.EqualFold(strings.TrimSpace(domain), issuer) {
+			return true
+		}
+	}
+	return !restricted
+}
+
+// Diagnose checks domain against public resolvers and, when given, its
+// authoritative nameservers, telling propagation delays apart from wrong
+// records. CAA records are only considered once routing works.
+func Diagnose(ctx context.Context, public, authoritative *Checker, domain string, mode Mode, target string, ingressIPs []string) Diagnosis {
+	publicAnswer := public.Check(ctx, domain, mode, target, ingressIPs)
+	var authoritativeAnswer Answer
+	if authoritative != nil {
+		authoritativeAnswer = authoritative.Check(ctx, domain, mode, target, ingressIPs)
+	}
+	var caa []CAA
+	if publicAnswer.Configured {
+		// A failed lookup must not block the owner; cert-manager reports
+		// issuance errors separately
+		caa, _ = public.CAA(ctx, domain)
+	}
+	expected := target
+	if mode != ModeCNAME {
+		expected = strings.Join(ingressIPs, ", ")
+	}
+	return diagnose(publicAnswer, authoritativeAnswer, caa, expected)
+}
+
+// diagnose compares the answers of public resolvers and the authoritative
+// nameservers; expected is the target or the ingress IPs, for messages
+func diagnose(public, authoritative Answer, caa []CAA, expected string) Diagnosis {
+	switch {
+	case public.Configured && !CAAPermits(caa, CAAIssuer):
+		return Diagnosis{
+			Configured: true,
+			Reason:     ReasonCAABlocking,
+			Message:    fmt.Sprintf("The domain routes to the app, but its CAA records do not allow %s to issue a certificate. Add a CAA record with tag issue and value %s", CAAIssuer, CAAIssuer),
+			Found:      caaValues(caa),
+		}
+	case public.Configured:
+		return Diagnosis{Configured: true, Found: public.Values}
+	case authoritative.Configured:
+		return Diagnosis{
+			Reason:  ReasonPropagationPending,
+			Message: "The DNS records are correct on the domain's nameservers but public resolvers do not see them yet. Changes can take up to 48 hours to propagate",
+			Found:   public.Values,
+		}
+	case len(authoritative.Values) > 0:
+		return Diagnosis{
+			Reason:  ReasonWrongTarget,
+			Message: fmt.Sprintf("The domain points at %s instead of %s. Update the DNS records below", strings.Join(authoritative.Values, ", "), expected),
+			Found:   authoritative.Values,
+		}
+	case len(public.Values) > 0:
+		return Diagnosis{
+			Reason:  ReasonWrongTarget,
+			Message: fmt.Sprintf("The domain points at %s instead of %s. Update the DNS records below", strings.Join(public.Values, ", "), expected),
+			Found:   public.Values,
+		}
+	}
+	return Diagnosis{
+		Reason:  ReasonRecordsMissing,
+		Message: "The domain does not resolve yet. Publish the DNS records below",
+	}
+}
+
+func caaValues(records []CAA) []string {
+	values := make([]string, 0, len(records))
+	for _, record := range records {
+		values = append(values, fmt.Sprintf("%d %s %q", record.Flags, record.Tag, record.Value))
+	}
+	return values
+}
+
+// queryCAA asks the given DNS servers in order for the CAA records of name.
+// The standard resolver cannot look up CAA records.
+func queryCAA(ctx context.Context, servers []string, name string) ([]CAA, error) {
+	qname, err := dnsmessage.NewName(strings.TrimSuffix(name, ".") + ".")
+	if err != nil {
+		return nil, err
+	}
+	query := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: uint16(rand.Uint32()), RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: qname, Type: typeCAA, Class: dnsmessage.ClassINET}},
+	}
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	lastErr := errors.New("no DNS servers to query")
+	for _, server := range servers {
+		response, err := exchange(ctx, server, packed)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		records, err := parseCAAResponse(response, query.Header.ID)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return records, nil
+	}
+	return nil, fmt.Errorf("failed to look up CAA records of %s: %w", name, lastErr)
+}
+
+func exchange(ctx context.Context, server string, query []byte) ([]byte, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 4096)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+func parseCAAResponse(response []byte, id uint16) ([]CAA, error) {
+	var p dnsmessage.Parser
+	header, err := p.Start(response)
+	if err != nil {
+		return nil, err
+	}
+	if header.ID != id {
+		return nil, errors.New("mismatched DNS response")
+	}
+	switch header.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("DNS server answered %s", header.RCode)
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		return nil, err
+	}
+
+	var records []CAA
+	for {
+		h, err := p.AnswerHeader()
+		if errors.Is(err, dnsmessage.ErrSectionDone) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if h.Type != typeCAA {
+			if err := p.SkipAnswer(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		resource, err := p.UnknownResource()
+		if err != nil {
+			return nil, err
+		}
+		if record, ok := parseCAA(resource.Data); ok {
+			records = append(records, record)
+		}
+	}
+}
+
+// parseCAA decodes the RDATA of a CAA record: flags, tag length, tag, value
+func parseCAA(data []byte) (CAA, bool) {
+	if len(data) < 2 {
+		return CAA{}, false
+	}
+	tagLen := int(data[1])
+	if tagLen == 0 || len(data) < 2+tagLen {
+		return CAA{}, false
+	}
+	return CAA{Flags: data[0], Tag: string(data[2 : 2+tagLen]), Value: string(data[2+tagLen:])}, true
+}
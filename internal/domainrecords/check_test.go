@@ -0,0 +1,190 @@
+package domainrecords
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func fakeChecker(cnames map[string]string, addrs map[string][]string, caa map[string][]CAA) *Checker {
+	notFound := func(name string) error {
+		return &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return &Checker{
+		lookupCNAME: func(_ context.Context, host string) (string, error) {
+			if cname, ok := cnames[host]; ok {
+				return cname + ".", nil
+			}
+			if _, ok := addrs[host]; ok {
+				return host + ".", nil
+			}
+			return "", notFound(host)
+		},
+		lookupIP: func(_ context.Context, _, host string) ([]netip.Addr, error) {
+			ips, ok := addrs[host]
+			if !ok {
+				return nil, notFound(host)
+			}
+			var parsed []netip.Addr
+			for _, ip := range ips {
+				parsed = append(parsed, netip.MustParseAddr(ip))
+			}
+			return parsed, nil
+		},
+		lookupCAA: func(_ context.Context, name string) ([]CAA, error) {
+			return caa[name], nil
+		},
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ingressIPs := []string{"203.0.113.10"}
+	checker := fakeChecker(
+		map[string]string{"www.example.com": "web.nexo.build", "old.example.com": "old.host.net"},
+		map[string][]string{"example.com": {"203.0.113.10"}, "other.com": {"198.51.100.7"}, "bare.example.com": {"198.51.100.7"}},
+		nil,
+	)
+
+	tests := []struct {
+		name       string
+		domain     string
+		mode       Mode
+		configured bool
+		values     []string
+	}{
+		{"cname", "www.example.com", ModeCNAME, true, []string{"web.nexo.build"}},
+		{"wrong cname", "old.example.com", ModeCNAME, false, []string{"old.host.net"}},
+		{"a record instead of cname", "bare.example.com", ModeCNAME, false, []string{"198.51.100.7"}},
+		{"missing cname", "new.example.com", ModeCNAME, false, nil},
+		{"a", "example.com", ModeA, true, []string{"203.0.113.10"}},
+		{"wrong a", "other.com", ModeA, false, []string{"198.51.100.7"}},
+		{"missing a", "missing.com", ModeAlias, false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answer := checker.Check(context.Background(), tt.domain, tt.mode, "web.nexo.build", ingressIPs)
+			if answer.Configured != tt.configured {
+				t.Errorf("Configured = %v, want %v", answer.Configured, tt.configured)
+			}
+			if len(answer.Values) != len(tt.values) || (len(tt.values) > 0 && answer.Values[0] != tt.values[0]) {
+				t.Errorf("Values = %v, want %v", answer.Values, tt.values)
+			}
+		})
+	}
+}
+
+func TestCAA_ClimbsToParent(t *testing.T) {
+	checker := fakeChecker(nil, nil, map[string][]CAA{
+		"example.com": {{Tag: "issue", Value: "digicert.com"}},
+		"com":         {{Tag: "issue", Value: "never.queried"}},
+	})
+
+	records, err := checker.CAA(context.Background(), "www.app.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(records) != 1 || records[0].Value != "digicert.com" {
+		t.Errorf("expected the zone's CAA records, got %v", records)
+	}
+
+	records, _ = checker.CAA(context.Background(), "other.org")
+	if len(records) != 0 {
+		t.Errorf("expected no CAA records, got %v", records)
+	}
+}
+
+func TestCAAPermits(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []CAA
+		want    bool
+	}{
+		{"no records", nil, true},
+		{"only iodef", []CAA{{Tag: "iodef", Value: "mailto:security@example.com"}}, true},
+		{"allowed", []CAA{{Tag: "issue", Value: "digicert.com"}, {Tag: "issue", Value: "letsencrypt.org; validationmethods=http-01"}}, true},
+		{"other issuer", []CAA{{Tag: "issue", Value: "digicert.com"}}, false},
+		{"deny all", []CAA{{Tag: "issue", Value: ";"}}, false},
+		{"wildcard only", []CAA{{Tag: "issuewild", Value: "digicert.com"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CAAPermits(tt.records, CAAIssuer); got != tt.want {
+				t.Errorf("CAAPermits() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	configured := Answer{Configured: true, Values: []string{"web.nexo.build"}}
+	stale := Answer{Values: []string{"old.host.net"}}
+	blocking := []CAA{{Tag: "issue", Value: "digicert.com"}}
+
+	tests := []struct {
+		name          string
+		public        Answer
+		authoritative Answer
+		caa           []CAA
+		configured    bool
+		reason        Reason
+	}{
+		{"configured", configured, configured, nil, true, ""},
+		{"caa blocking", configured, configured, blocking, true, ReasonCAABlocking},
+		{"propagation pending", stale, configured, nil, false, ReasonPropagationPending},
+		{"nothing published yet", Answer{}, configured, nil, false, ReasonPropagationPending},
+		{"wrong target", stale, stale, nil, false, ReasonWrongTarget},
+		{"wrong target without nameservers", stale, Answer{}, nil, false, ReasonWrongTarget},
+		{"missing", Answer{}, Answer{}, nil, false, ReasonRecordsMissing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagnosis := diagnose(tt.public, tt.authoritative, tt.caa, "web.nexo.build")
+			if diagnosis.Configured != tt.configured || diagnosis.Reason != tt.reason {
+				t.Errorf("diagnose() = %+v, want configured %v and reason %q", diagnosis, tt.configured, tt.reason)
+			}
+			if tt.reason != "" && diagnosis.Message == "" {
+				t.Error("expected a message explaining the reason")
+			}
+		})
+	}
+}
+
+func TestParseCAAResponse(t *testing.T) {
+	name := dnsmessage.MustNewName("example.com.")
+	msg := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: 42, Response: true},
+		Questions: []dnsmessage.Question{{Name: name, Type: typeCAA, Class: dnsmessage.ClassINET}},
+		Answers: []dnsmessage.Resource{
+			{
+				Header: dnsmessage.ResourceHeader{Name: name, Type: dnsmessage.TypeCNAME, Class: dnsmessage.ClassINET},
+				Body:   &dnsmessage.CNAMEResource{CNAME: name},
+			},
+			{
+				Header: dnsmessage.ResourceHeader{Name: name, Type: typeCAA, Class: dnsmessage.ClassINET},
+				Body:   &dnsmessage.UnknownResource{Type: typeCAA, Data: append([]byte{0, 5}, "issueletsencrypt.org"...)},
+			},
+		},
+	}
+	packed, err := msg.Pack()
+	if err != nil {
+		t.Fatalf("failed to pack message: %v", err)
+	}
+
+	records, err := parseCAAResponse(packed, 42)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(records) != 1 || records[0].Tag != "issue" || records[0].Value != "letsencrypt.org" {
+		t.Errorf("unexpected records %+v", records)
+	}
+
+	if _, err := parseCAAResponse(packed, 7); err == nil {
+		t.Error("expected an error for a mismatched response ID")
+	}
+}
@@ -0,0 +1,151 @@
+// Package domainrecords decides how a custom domain is routed to the
+// platform and generates the DNS records its owner must publish. Zone apex
+// domains cannot be CNAMEs, so they point at the region's ingress IPs with
+// A/AAAA records or use a flattened CNAME (ALIAS) where the DNS host supports it.
+package domainrecords
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"strings"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/domainverify"
+)
+
+// Mode is how a custom domain points at the platform
+type Mode string
+
+const (
+	// ModeCNAME points a subdomain at the app hostname
+	ModeCNAME Mode = "cname"
+	// ModeA points the domain at the region's ingress IPs with A/AAAA records
+	ModeA Mode = "a"
+	// ModeAlias uses a flattened CNAME (Cloudflare) or ALIAS/ANAME record
+	ModeAlias Mode = "alias"
+)
+
+var (
+	ErrInvalidMode  = errors.New("dns_mode must be cname, a or alias")
+	ErrApexCNAME    = errors.New("apex domains cannot use a CNAME record, use dns_mode a or alias")
+	ErrNoIngressIPs = errors.New("no ingress IPs are published for the app's region")
+)
+
+// secondLevelSuffixes are public suffixes with two labels common among our
+// customers, so e.g. example.com.mx is recognized as an apex
+var secondLevelSuffixes = map[string]bool{
+	"com.mx": true, "org.mx": true, "gob.mx": true, "edu.mx": true, "net.mx": true,
+	"co.uk": true, "org.uk": true, "com.au": true, "net.au": true,
+	"com.br": true, "com.ar": true, "com.co": true, "co.jp": true, "co.nz": true,
+}
+
+// IsApex reports whether domain is the apex of its zone
+func IsApex(domain string) bool {
+	labels := strings.Split(strings.TrimSuffix(strings.ToLower(domain), "."), ".")
+	switch {
+	case len(labels) <= 2:
+		return true
+	case len(labels) == 3:
+		return secondLevelSuffixes[labels[1]+"."+labels[2]]
+	}
+	return false
+}
+
+// Apex returns the apex of the zone domain belongs to
+func Apex(domain string) string {
+	zone := strings.TrimSuffix(strings.ToLower(domain), ".")
+	for !IsApex(zone) {
+		_, zone, _ = strings.Cut(zone, ".")
+	}
+	return zone
+}
+
+// Resolve validates a requested mode for domain. An empty mode picks CNAME
+// for subdomains and A/AAAA records for apex domains.
+func Resolve(domain, requested string) (Mode, error) {
+	apex := IsApex(domain)
+	switch Mode(requested) {
+	case "":
+		if apex {
+			return ModeA, nil
+		}
+		return ModeCNAME, nil
+	case ModeCNAME:
+		if apex {
+			return "", ErrApexCNAME
+		}
+		return ModeCNAME, nil
+	case ModeA, ModeAlias:
+		return Mode(requested), nil
+	}
+	return "", ErrInvalidMode
+}
+
+// Records returns the records that route domain to target (the app
+// hostname) or, in A mode, to the ingress IPs
+func Records(domain string, mode Mode, target string, ingressIPs []string) ([]domainverify.Record, error) {
+	switch mode {
+	case ModeCNAME:
+		return []domainverify.Record{{Type: "CNAME", Name: domain, Value: target}}, nil
+	case ModeAlias:
+		return []domainverify.Record{{Type: "ALIAS", Name: domain, Value: target}}, nil
+	}
+
+	var records []domainverify.Record
+	for _, ip := range ingressIPs {
+		addr, err := netip.ParseAddr(ip)
+		if err != nil {
+			continue
+		}
+		recordType := "A"
+		if addr.Is6() && !addr.Is4In6() {
+			recordType = "AAAA"
+		}
+		records = append(records, domainverify.Record{Type: recordType, Name: domain, Value: addr.String()})
+	}
+	if len(records) == 0 {
+		return nil, ErrNoIngressIPs
+	}
+	return records, nil
+}
+
+// Address families a domain is served over
+const (
+	FamilyIPv4 = "ipv4"
+	FamilyIPv6 = "ipv6"
+)
+
+// Families returns the address families the ingress IPs of a region serve
+// its domains over, IPv4 first. Every mode ends at those addresses: CNAME
+// and ALIAS domains through the app hostname, A domains directly.
+func Families(ingressIPs []string) []string {
+	var v4, v6 bool
+	for _, ip := range ingressIPs {
+		addr, err := netip.ParseAddr(ip)
+		if err != nil {
+			continue
+		}
+		if addr.Is6() && !addr.Is4In6() {
+			v6 = true
+		} else {
+			v4 = true
+		}
+	}
+
+	var families []string
+	if v4 {
+		families = append(families, FamilyIPv4)
+	}
+	if v6 {
+		families = append(families, FamilyIPv6)
+	}
+	return families
+}
+
+// Configured reports whether domain already routes to the platform. CNAME
+// domains must resolve to target; A and ALIAS domains must resolve to one of
+// the ingress IPs, as flattened CNAMEs answer with the target's addresses.
+func Configured(ctx context.Context, resolver *net.Resolver, domain string, mode Mode, target string, ingressIPs []string) bool {
+	return newChecker(resolver).Check(ctx, domain, mode, target, ingressIPs).Configured
+}
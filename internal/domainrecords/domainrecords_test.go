@@ -0,0 +1,109 @@
+package domainrecords
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestIsApex(t *testing.T) {
+	tests := map[string]bool{
+		"example.com":        true,
+		"example.com.":       true,
+		"example.com.mx":     true,
+		"app.example.com":    false,
+		"app.example.com.mx": false,
+		"my.app.example.com": false,
+	}
+
+	for domain, apex := range tests {
+		if got := IsApex(domain); got != apex {
+			t.Errorf("IsApex(%q) = %v, want %v", domain, got, apex)
+		}
+	}
+}
+
+func TestApex(t *testing.T) {
+	tests := map[string]string{
+		"example.com":        "example.com",
+		"www.example.com.":   "example.com",
+		"a.b.example.com.mx": "example.com.mx",
+		"shop.example.co.uk": "example.co.uk",
+	}
+
+	for domain, apex := range tests {
+		if got := Apex(domain); got != apex {
+			t.Errorf("Apex(%q) = %q, want %q", domain, got, apex)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		domain    string
+		requested string
+		mode      Mode
+		err       error
+	}{
+		{"app.example.com", "", ModeCNAME, nil},
+		{"example.com", "", ModeA, nil},
+		{"example.com", "alias", ModeAlias, nil},
+		{"app.example.com", "a", ModeA, nil},
+		{"example.com", "cname", "", ErrApexCNAME},
+		{"example.com", "mx", "", ErrInvalidMode},
+	}
+
+	for _, tt := range tests {
+		mode, err := Resolve(tt.domain, tt.requested)
+		if mode != tt.mode || !errors.Is(err, tt.err) {
+			t.Errorf("Resolve(%q, %q) = %q, %v; want %q, %v", tt.domain, tt.requested, mode, err, tt.mode, tt.err)
+		}
+	}
+}
+
+func TestRecords(t *testing.T) {
+	records, err := Records("example.com", ModeA, "web.nexo.build", []string{"203.0.113.10", "2001:db8::1", "bogus"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %v", records)
+	}
+	if records[0].Type != "A" || records[0].Value != "203.0.113.10" {
+		t.Errorf("unexpected A record %+v", records[0])
+	}
+	if records[1].Type != "AAAA" || records[1].Value != "2001:db8::1" {
+		t.Errorf("unexpected AAAA record %+v", records[1])
+	}
+
+	if _, err := Records("example.com", ModeA, "web.nexo.build", nil); !errors.Is(err, ErrNoIngressIPs) {
+		t.Errorf("expected ErrNoIngressIPs, got %v", err)
+	}
+
+	records, _ = Records("example.com", ModeAlias, "web.nexo.build", nil)
+	if len(records) != 1 || records[0].Type != "ALIAS" || records[0].Value != "web.nexo.build" {
+		t.Errorf("unexpected alias records %v", records)
+	}
+
+	records, _ = Records("app.example.com", ModeCNAME, "web.nexo.build", nil)
+	if len(records) != 1 || records[0].Type != "CNAME" {
+		t.Errorf("unexpected cname records %v", records)
+	}
+}
+
+func TestFamilies(t *testing.T) {
+	tests := []struct {
+		ips  []string
+		want []string
+	}{
+		{[]string{"2001:db8::1", "203.0.113.10"}, []string{FamilyIPv4, FamilyIPv6}},
+		{[]string{"203.0.113.10", "::ffff:198.51.100.1"}, []string{FamilyIPv4}},
+		{[]string{"2001:db8::1", "bogus"}, []string{FamilyIPv6}},
+		{nil, nil},
+	}
+	for _, tt := range tests {
+		if got := Families(tt.ips); !slices.Equal(got, tt.want) {
+			t.Errorf("Families(%v) = %v, want %v", tt.ips, got, tt.want)
+		}
+	}
+}
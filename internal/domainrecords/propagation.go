@@ -0,0 +1,246 @@
+package domainrecords
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Resolver is a public DNS resolver propagation is checked against
+type Resolver struct {
+	Name     string `json:"name"`
+	Location string `json:"location"`
+	Addr     string `json:"addr"`
+}
+
+// PropagationResolvers are the resolvers a propagation check asks: global
+// anycast resolvers and regional ones, which cache independently of them
+var PropagationResolvers = []Resolver{
+	{Name: "Cloudflare", Location: "Global", Addr: "1.1.1.1:53"},
+	{Name: "Google", Location: "Global", Addr: "8.8.8.8:53"},
+	{Name: "Quad9", Location: "Global", Addr: "9.9.9.9:53"},
+	{Name: "OpenDNS", Location: "United States", Addr: "208.67.222.222:53"},
+	{Name: "Level3", Location: "United States", Addr: "4.2.2.1:53"},
+	{Name: "DNS.WATCH", Location: "Germany", Addr: "84.200.69.80:53"},
+}
+
+// PropagationTypes are the record types a propagation check can look up
+var PropagationTypes = map[string]dnsmessage.Type{
+	"A":     dnsmessage.TypeA,
+	"AAAA":  dnsmessage.TypeAAAA,
+	"CNAME": dnsmessage.TypeCNAME,
+	"TXT":   dnsmessage.TypeTXT,
+	"NS":    dnsmessage.TypeNS,
+	"MX":    dnsmessage.TypeMX,
+	"CAA":   typeCAA,
+}
+
+// ErrUnsupportedType is returned for a record type propagation checks
+// cannot look up
+var ErrUnsupportedType = errors.New("type must be one of A, AAAA, CNAME, TXT, NS, MX or CAA")
+
+// Statuses of a resolver's answer in a propagation check
+const (
+	// StatusMatch means the resolver sees the expected record, or any record
+	// of the type when none is expected
+	StatusMatch = "match"
+	// StatusMismatch means the resolver sees records of the type, but not
+	// the expected one
+	StatusMismatch = "mismatch"
+	// StatusMissing means the resolver sees no record of the type
+	StatusMissing = "missing"
+	// StatusError means the resolver could not be asked
+	StatusError = "error"
+)
+
+// resolverTimeout bounds how long a propagation check waits for a resolver
+const resolverTimeout = 3 * time.Second
+
+// Value is a record value a resolver answered, with its remaining TTL
+type Value struct {
+	Value string `json:"value"`
+	TTL   uint32 `json:"ttl"`
+}
+
+// ResolverResult is what one resolver answered
+type ResolverResult struct {
+	Resolver
+	Status string  `json:"status"`
+	Values []Value `json:"values"`
+	Error  string  `json:"error,omitempty"`
+	// DurationMS is how long the resolver took to answer
+	DurationMS int64 `json:"duration_ms"`
+}
+
+// Propagation reports how many resolvers see a record
+type Propagation struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Expect string `json:"expect,omitempty"`
+	// Seen counts the resolvers seeing the record, out of Total
+	Seen       int              `json:"seen"`
+	Total      int              `json:"total"`
+	Propagated bool             `json:"propagated"`
+	Message    string           `json:"message"`
+	Resolvers  []ResolverResult `json:"resolvers"`
+}
+
+// CheckPropagation asks every resolver in parallel for the records of a
+// type at name. A resolver sees the record when one of its values equals
+// expect, compared without case or trailing dot, or has any value when
+// expect is empty.
+func CheckPropagation(ctx context.Context, resolvers []Resolver, name, recordType, expect string) (Propagation, error) {
+	recordType = strings.ToUpper(recordType)
+	qtype, ok := PropagationTypes[recordType]
+	if !ok {
+		return Propagation{}, ErrUnsupportedType
+	}
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	qname, err := dnsmessage.NewName(name + ".")
+	if err != nil {
+		return Propagation{}, err
+	}
+
+	report := Propagation{
+		Name:      name,
+		Type:      recordType,
+		Expect:    expect,
+		Total:     len(resolvers),
+		Resolvers: make([]ResolverResult, len(resolvers)),
+	}
+	var wg sync.WaitGroup
+	for i, resolver := range resolvers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Resolvers[i] = askResolver(ctx, resolver, qname, qtype, expect)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range report.Resolvers {
+		if result.Status == StatusMatch {
+			report.Seen++
+		}
+	}
+	report.Propagated = report.Total > 0 && report.Seen == report.Total
+	switch {
+	case report.Propagated:
+		report.Message = fmt.Sprintf("propagated: all %d resolvers see your record", report.Total)
+	case report.Seen > 0:
+		report.Message = fmt.Sprintf("propagating: %d/%d resolvers see your record", report.Seen, report.Total)
+	default:
+		report.Message = fmt.Sprintf("not propagated: none of the %d resolvers see your record yet", report.Total)
+	}
+	return report, nil
+}
+
+func askResolver(ctx context.Context, resolver Resolver, name dnsmessage.Name, qtype dnsmessage.Type, expect string) ResolverResult {
+	ctx, cancel := context.WithTimeout(ctx, resolverTimeout)
+	defer cancel()
+
+	start := time.Now()
+	values, err := query(ctx, resolver.Addr, name, qtype)
+	result := ResolverResult{
+		Resolver:   resolver,
+		Values:     values,
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+	switch {
+	case err != nil:
+		result.Status = StatusError
+		result.Error = err.Error()
+		result.Values = []Value{}
+	case len(values) == 0:
+		result.Status = StatusMissing
+	case expect == "" || containsValue(values, expect):
+		result.Status = StatusMatch
+	default:
+		result.Status = StatusMismatch
+	}
+	return result
+}
+
+func containsValue(values []Value, expect string) bool {
+	expect = strings.TrimSuffix(strings.TrimSpace(expect), ".")
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSuffix(v.Value, "."), expect) {
+			return true
+		}
+	}
+	return false
+}
+
+// query asks server for the records of a type at name, returning their
+// values. Records of other types, such as the CNAME an A lookup follows,
+// are left out. A name that does not exist has no values.
+func query(ctx context.Context, server string, name dnsmessage.Name, qtype dnsmessage.Type) ([]Value, error) {
+	q := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: uint16(rand.Uint32()), RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: name, Type: qtype, Class: dnsmessage.ClassINET}},
+	}
+	packed, err := q.Pack()
+	if err != nil {
+		return nil, err
+	}
+	response, err := exchange(ctx, server, packed)
+	if err != nil {
+		return nil, err
+	}
+
+	var m dnsmessage.Message
+	if err := m.Unpack(response); err != nil {
+		return nil, err
+	}
+	if m.ID != q.ID {
+		return nil, errors.New("mismatched DNS response")
+	}
+	switch m.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return []Value{}, nil
+	default:
+		return nil, fmt.Errorf("DNS server answered %s", m.RCode)
+	}
+
+	values := []Value{}
+	for _, answer := range m.Answers {
+		if answer.Header.Type != qtype {
+			continue
+		}
+		if value, ok := formatValue(answer.Body); ok {
+			values = append(values, Value{Value: value, TTL: answer.Header.TTL})
+		}
+	}
+	return values, nil
+}
+
+// formatValue renders a record the way DNS hosts display it
+func formatValue(body dnsmessage.ResourceBody) (string, bool) {
+	switch b := body.(type) {
+	case *dnsmessage.AResource:
+		return netip.AddrFrom4(b.A).String(), true
+	case *dnsmessage.AAAAResource:
+		return netip.AddrFrom16(b.AAAA).String(), true
+	case *dnsmessage.CNAMEResource:
+		return strings.TrimSuffix(b.CNAME.String(), "."), true
+	case *dnsmessage.NSResource:
+		return strings.TrimSuffix(b.NS.String(), "."), true
+	case *dnsmessage.MXResource:
+		return fmt.Sprintf("%d %s", b.Pref, strings.TrimSuffix(b.MX.String(), ".")), true
+	case *dnsmessage.TXTResource:
+		return strings.Join(b.TXT, ""), true
+	case *dnsmessage.UnknownResource:
+		if record, ok := parseCAA(b.Data); ok {
+			return fmt.Sprintf("%d %s %q", record.Flags, record.Tag, record.Value), true
+		}
+	}
+	return "", false
+}
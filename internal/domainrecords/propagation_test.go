@@ -0,0 +1,99 @@
+package domainrecords
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// fakeResolver answers A queries for web.example.com with a CNAME to the app
+// and the app's address, and NXDOMAIN for every other name
+func fakeResolver(t *testing.T, addr [4]byte, ttl uint32) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on UDP: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var q dnsmessage.Message
+			if err := q.Unpack(buf[:n]); err != nil {
+				continue
+			}
+			response := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: q.ID, Response: true, RCode: dnsmessage.RCodeNameError},
+				Questions: q.Questions,
+			}
+			if q.Questions[0].Name.String() == "web.example.com." {
+				response.RCode = dnsmessage.RCodeSuccess
+				app := dnsmessage.MustNewName("web.nexo.build.")
+				response.Answers = []dnsmessage.Resource{
+					{
+						Header: dnsmessage.ResourceHeader{Name: q.Questions[0].Name, Type: dnsmessage.TypeCNAME, Class: dnsmessage.ClassINET, TTL: ttl},
+						Body:   &dnsmessage.CNAMEResource{CNAME: app},
+					},
+					{
+						Header: dnsmessage.ResourceHeader{Name: app, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: ttl},
+						Body:   &dnsmessage.AResource{A: addr},
+					},
+				}
+			}
+			packed, _ := response.Pack()
+			_, _ = conn.WriteTo(packed, from)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestCheckPropagation(t *testing.T) {
+	resolvers := []Resolver{
+		{Name: "updated", Addr: fakeResolver(t, [4]byte{203, 0, 113, 10}, 120)},
+		{Name: "stale", Addr: fakeResolver(t, [4]byte{198, 51, 100, 7}, 3600)},
+	}
+
+	report, err := CheckPropagation(context.Background(), resolvers, "Web.Example.com.", "a", "203.0.113.10")
+	if err != nil {
+		t.Fatalf("CheckPropagation: %v", err)
+	}
+	if report.Name != "web.example.com" || report.Type != "A" {
+		t.Errorf("expected the name and type to be normalized, got %q %q", report.Name, report.Type)
+	}
+	if report.Seen != 1 || report.Total != 2 || report.Propagated {
+		t.Errorf("expected 1/2 resolvers to see the record, got %+v", report)
+	}
+	if report.Message != "propagating: 1/2 resolvers see your record" {
+		t.Errorf("unexpected message %q", report.Message)
+	}
+
+	updated, stale := report.Resolvers[0], report.Resolvers[1]
+	if updated.Status != StatusMatch || len(updated.Values) != 1 || updated.Values[0].TTL != 120 {
+		t.Errorf("unexpected answer of the updated resolver %+v", updated)
+	}
+	if stale.Status != StatusMismatch || stale.Values[0].Value != "198.51.100.7" {
+		t.Errorf("unexpected answer of the stale resolver %+v", stale)
+	}
+
+	report, _ = CheckPropagation(context.Background(), resolvers, "web.example.com", "CNAME", "web.nexo.build.")
+	if !report.Propagated {
+		t.Errorf("expected the CNAME to be seen everywhere, got %+v", report)
+	}
+
+	report, _ = CheckPropagation(context.Background(), resolvers, "missing.example.com", "A", "")
+	if report.Seen != 0 || report.Resolvers[0].Status != StatusMissing {
+		t.Errorf("expected the record to be missing, got %+v", report)
+	}
+
+	if _, err := CheckPropagation(context.Background(), resolvers, "web.example.com", "SRV", ""); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("expected ErrUnsupportedType, got %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+// Package domainverify proves ownership of custom domains through a TXT
+// record challenge checked against public DNS resolvers.
+package domainverify
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// RecordPrefix is prepended to a domain to form the challenge record name
+const RecordPrefix = "_fuego-verify."
+
+// ClaimTTL is how long an unverified claim blocks other users from adding
+// the same domain
+const ClaimTTL = 72 * time.Hour
+
+// PublicResolvers are queried instead of the system resolver so split-horizon
+// or cached answers on the platform's network cannot fake ownership
+var PublicResolvers = []string{"1.1.1.1:53", "8.8.8.8:53"}
+
+// ErrRecordNotFound is returned when no TXT record carries the token
+var ErrRecordNotFound = errors.New("verification record not found")
+
+// ErrTokenMismatch is returned when the challenge record exists but holds a
+// different token, e.g. one copied from an earlier claim. It wraps
+// ErrRecordNotFound.
+var ErrTokenMismatch = fmt.Errorf("%w: the record holds a different token", ErrRecordNotFound)
+
+// Reason is a machine-readable cause of a failed ownership check
+type Reason string
+
+const (
+	// ReasonTXTMissing means no challenge record is published
+	ReasonTXTMissing Reason = "txt_missing"
+	// ReasonTXTMismatch means the challenge record holds the wrong token
+	ReasonTXTMismatch Reason = "txt_mismatch"
+	// ReasonPropagationPending means the domain's nameservers serve the
+	// record but public resolvers do not see it yet
+	ReasonPropagationPending Reason = "propagation_pending"
+)
+
+// RecordName returns the TXT record name the owner of domain must create
+func RecordName(domain string) string {
+	return RecordPrefix + strings.TrimSuffix(domain, ".")
+}
+
+// Record is the DNS record the owner of a domain must publish
+type Record struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Challenge returns the TXT record proving ownership of domain
+func Challenge(domain, token string) *Record {
+	return &Record{Type: "TXT", Name: RecordName(domain), Value: token}
+}
+
+// GenerateToken creates a per-domain challenge token
+func GenerateToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Verifier looks up challenge records
+type Verifier struct {
+	lookupTXT func(ctx context.Context, name string) ([]string, error)
+}
+
+// NewVerifier creates a verifier querying the given DNS servers in order
+func NewVerifier(servers []string) *Verifier {
+	return &Verifier{lookupTXT: NewResolver(servers).LookupTXT}
+}
+
+// NewResolver creates a resolver querying the given DNS servers in order
+func NewResolver(servers []string) *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			var lastErr error
+			for _, server := range servers {
+				conn, err := d.DialContext(ctx, network, server)
+				if err == nil {
+					return conn, nil
+				}
+				lastErr = err
+			}
+			if lastErr == nil {
+				lastErr = errors.New("no DNS servers to query")
+			}
+			return nil, lastErr
+		},
+	}
+}
+
+// NameserverAddrs returns the DNS server addresses of nameserver hostnames,
+// for querying a zone's authoritative servers directly
+func NameserverAddrs(nameservers []string) []string {
+	servers := make([]string, 0, len(nameservers))
+	for _, ns := range nameservers {
+		servers = append(servers, net.JoinHostPort(ns, "53"))
+	}
+	return servers
+}
+
+// Verify reports whether the challenge record of domain contains token
+func (v *Verifier) Verify(ctx context.Context, domain, token string) error {
+	records, err := v.lookupTXT(ctx, RecordName(domain))
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return ErrRecordNotFound
+		}
+		return fmt.Errorf("failed to look up %s: %w", RecordName(domain), err)
+	}
+
+	for _, record := range records {
+		if strings.TrimSpace(record) == token {
+			return nil
+		}
+	}
+	if len(records) > 0 {
+		return ErrTokenMismatch
+	}
+	return ErrRecordNotFound
+}
+
+// Diagnose explains a failed check against public resolvers given the
+// result of the same check against the domain's authoritative nameservers
+func Diagnose(publicErr, authoritativeErr error) (Reason, string) {
+	switch {
+	case authoritativeErr == nil:
+		return ReasonPropagationPending, "The TXT record is published but public DNS resolvers do not see it yet. Retry in a few minutes"
+	case errors.Is(publicErr, ErrTokenMismatch) || errors.Is(authoritativeErr, ErrTokenMismatch):
+		return ReasonTXTMismatch, "The TXT record exists but holds a different value. Replace it with the value below"
+	}
+	return ReasonTXTMissing, "The TXT record was not found. Create the TXT record below and retry once it has propagated"
+}
+
+// ClaimExpired reports whether an unverified claim created at createdAt no
+// longer blocks other users
+func ClaimExpired(createdAt, now time.Time) bool {
+	return now.Sub(createdAt) > ClaimTTL
+}
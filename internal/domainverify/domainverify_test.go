@@ -0,0 +1,89 @@
+package domainverify
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func fakeVerifier(records map[string][]string) *Verifier {
+	return &Verifier{lookupTXT: func(_ context.Context, name string) ([]string, error) {
+		if txt, ok := records[name]; ok {
+			return txt, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}}
+}
+
+func TestRecordName(t *testing.T) {
+	if name := RecordName("app.example.com."); name != "_fuego-verify.app.example.com" {
+		t.Errorf("unexpected record name %q", name)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	v := fakeVerifier(map[string][]string{
+		"_fuego-verify.example.com": {"v=spf1 -all", " token123 "},
+		"_fuego-verify.other.com":   {"wrong"},
+	})
+
+	if err := v.Verify(context.Background(), "example.com", "token123"); err != nil {
+		t.Errorf("expected record to verify, got %v", err)
+	}
+	if err := v.Verify(context.Background(), "other.com", "token123"); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected ErrRecordNotFound for wrong token, got %v", err)
+	}
+	if err := v.Verify(context.Background(), "other.com", "token123"); !errors.Is(err, ErrTokenMismatch) {
+		t.Errorf("expected ErrTokenMismatch for wrong token, got %v", err)
+	}
+	if err := v.Verify(context.Background(), "missing.com", "token123"); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expected ErrRecordNotFound for missing record, got %v", err)
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	tests := []struct {
+		name             string
+		publicErr        error
+		authoritativeErr error
+		want             Reason
+	}{
+		{"propagation pending", ErrRecordNotFound, nil, ReasonPropagationPending},
+		{"stale token still cached", ErrTokenMismatch, nil, ReasonPropagationPending},
+		{"wrong token", ErrTokenMismatch, ErrTokenMismatch, ReasonTXTMismatch},
+		{"wrong token published", ErrRecordNotFound, ErrTokenMismatch, ReasonTXTMismatch},
+		{"missing", ErrRecordNotFound, ErrRecordNotFound, ReasonTXTMissing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, message := Diagnose(tt.publicErr, tt.authoritativeErr)
+			if reason != tt.want || message == "" {
+				t.Errorf("Diagnose() = %q, %q, want reason %q", reason, message, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateToken(t *testing.T) {
+	a, err := GenerateToken()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, _ := GenerateToken()
+	if len(a) != 32 || a == b {
+		t.Errorf("expected unique 32 character tokens, got %q and %q", a, b)
+	}
+}
+
+func TestClaimExpired(t *testing.T) {
+	now := time.Now()
+	if ClaimExpired(now.Add(-time.Hour), now) {
+		t.Error("expected a fresh claim to block other users")
+	}
+	if !ClaimExpired(now.Add(-ClaimTTL-time.Minute), now) {
+		t.Error("expected an old claim to expire")
+	}
+}
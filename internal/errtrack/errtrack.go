@@ -0,0 +1,89 @@
+// Package errtrack reports platform errors to Sentry so they can be
+// triaged outside of the logs: panics and failed API requests, failing
+// background jobs, and events and outbox jobs dropped after repeated
+// failures. Reports are tagged with what they concern, such as the user,
+// app, request or job. Without SENTRY_DSN nothing is reported.
+package errtrack
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/getsentry/sentry-go"
+)
+
+// Tag keys shared by reports
+const (
+	TagUserID    = "user_id"
+	TagApp       = "app"
+	TagRequestID = "request_id"
+)
+
+// flushTimeout bounds how long shutdown waits for queued reports
+const flushTimeout = 2 * time.Second
+
+var enabled atomic.Bool
+
+// Init starts reporting to the configured Sentry DSN, if any
+func Init(cfg *config.Config) error {
+	if cfg.SentryDSN == "" {
+		return nil
+	}
+	err := sentry.Init(sentry.ClientOptions{
+		Dsn:              cfg.SentryDSN,
+		Environment:      cfg.Environment,
+		Release:          cfg.SentryRelease,
+		AttachStacktrace: true,
+	})
+	if err != nil {
+		return err
+	}
+	enabled.Store(true)
+	return nil
+}
+
+// Enabled reports whether errors are reported
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Flush waits for queued reports to be sent, before the process exits
+func Flush() {
+	if Enabled() {
+		sentry.Flush(flushTimeout)
+	}
+}
+
+// CaptureError reports an error with tags
+func CaptureError(err error, tags map[string]string) {
+	if !Enabled() || err == nil {
+		return
+	}
+	hub(tags).CaptureException(err)
+}
+
+// CapturePanic reports a recovered panic with tags
+func CapturePanic(value any, tags map[string]string) {
+	if !Enabled() {
+		return
+	}
+	hub(tags).Recover(value)
+}
+
+// hub returns a hub whose scope carries the tags, the user_id tag also
+// identifying the user
+func hub(tags map[string]string) *sentry.Hub {
+	h := sentry.CurrentHub().Clone()
+	h.ConfigureScope(func(scope *sentry.Scope) {
+		for key, value := range tags {
+			if value != "" {
+				scope.SetTag(key, value)
+			}
+		}
+		if userID := tags[TagUserID]; userID != "" {
+			scope.SetUser(sentry.User{ID: userID})
+		}
+	})
+	return h
+}
@@ -0,0 +1,37 @@
+package errtrack
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/getsentry/sentry-go"
+)
+
+func TestInit_Disabled(t *testing.T) {
+	if err := Init(&config.Config{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if Enabled() {
+		t.Fatal("expected reporting to be off without a DSN")
+	}
+	// No-ops without a client
+	CaptureError(errors.New("boom"), nil)
+	CapturePanic("boom", nil)
+	Flush()
+}
+
+func TestHub_Tags(t *testing.T) {
+	h := hub(map[string]string{TagUserID: "42", TagApp: "shop", TagRequestID: ""})
+
+	event := h.Scope().ApplyToEvent(sentry.NewEvent(), nil, nil)
+	if event.Tags[TagApp] != "shop" || event.Tags[TagUserID] != "42" {
+		t.Errorf("unexpected tags %v", event.Tags)
+	}
+	if _, ok := event.Tags[TagRequestID]; ok {
+		t.Error("expected empty tags to be left out")
+	}
+	if event.User.ID != "42" {
+		t.Errorf("expected the user to be identified, got %+v", event.User)
+	}
+}
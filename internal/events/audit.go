@@ -0,0 +1,42 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Audit returns a handler recording events in the activity log under their
+// type, with the message and payload as details
+func Audit(queries *db.Queries) Handler {
+	return func(ctx context.Context, e Event) error {
+		details := make(map[string]any, len(e.Payload)+1)
+		for k, v := range e.Payload {
+			details[k] = v
+		}
+		if e.Message != "" {
+			details["message"] = e.Message
+		}
+		data, _ := json.Marshal(details)
+
+		params := db.CreateActivityLogParams{
+			UserID:    pgtype.UUID{Bytes: e.UserID, Valid: e.UserID != uuid.Nil},
+			AppID:     pgtype.UUID{Bytes: e.AppID, Valid: e.AppID != uuid.Nil},
+			Action:    e.Type,
+			Details:   data,
+			IpAddress: e.IP,
+		}
+		if e.TokenID != nil {
+			params.ApiTokenID = pgtype.UUID{Bytes: *e.TokenID, Valid: true}
+		}
+		_, err := queries.CreateActivityLog(ctx, params)
+		if err != nil {
+			return fmt.Errorf("failed to record event: %w", err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,279 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/errtrack"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// channel is notified by the events table trigger on every insert
+const channel = "platform_events"
+
+const (
+	// batchSize is how many events a subscriber reads at once
+	batchSize = 100
+	// MaxAttempts is how often an event is delivered to a failing handler
+	// before the subscriber gives up on it and moves on
+	MaxAttempts = 10
+	// Retention is how long delivered events are kept
+	Retention = 7 * 24 * time.Hour
+)
+
+// Bus dispatches published events to its subscribers. Each subscriber keeps
+// a cursor in the database and receives events in order; a failed event is
+// retried with backoff before later events are delivered.
+type Bus struct {
+	pool    *pgxpool.Pool
+	queries *db.Queries
+	subs    []*subscription
+	// poll catches up when a notification is missed, e.g. while the
+	// listening connection reconnects
+	poll  time.Duration
+	retry time.Duration
+}
+
+type subscription struct {
+	name     string
+	patterns []string
+	handler  Handler
+	wake     chan struct{}
+
+	// failing is the event the handler last failed on and attempts how
+	// often it has been tried
+	failing  int64
+	attempts int
+}
+
+// NewBus creates a bus on the given database
+func NewBus(pool *pgxpool.Pool) *Bus {
+	return &Bus{
+		pool:    pool,
+		queries: db.New(pool),
+		poll:    30 * time.Second,
+		retry:   5 * time.Second,
+	}
+}
+
+// Publish implements Publisher
+func (b *Bus) Publish(ctx context.Context, e Event) error {
+	return Publish(ctx, b.queries, e)
+}
+
+// Subscribe registers a handler for the events matching any of the patterns
+// (see Match), all events when none are given. The name identifies the
+// subscriber's cursor and must stay stable across restarts. Subscribe must
+// be called before Run.
+func (b *Bus) Subscribe(name string, handler Handler, patterns ...string) {
+	if len(patterns) == 0 {
+		patterns = []string{"*"}
+	}
+	b.subs = append(b.subs, &subscription{
+		name:     name,
+		patterns: patterns,
+		handler:  handler,
+		wake:     make(chan struct{}, 1),
+	})
+}
+
+// Run delivers events until the context is canceled. It returns once every
+// subscriber has stopped, so the bus can be handed over to another replica
+// without two of them delivering at once.
+func (b *Bus) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for _, sub := range b.subs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.dispatch(ctx, sub)
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		b.prune(ctx)
+	}()
+
+	for {
+		if err := b.listen(ctx); err != nil && ctx.Err() == nil {
+			slog.Warn("event bus listener disconnected", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(b.retry):
+		}
+	}
+}
+
+// listen wakes the subscribers whenever an event is published
+func (b *Bus) listen(ctx context.Context) error {
+	conn, err := b.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+channel); err != nil {
+		return err
+	}
+	// Events published while disconnected
+	b.wakeAll()
+
+	for {
+		if _, err := conn.Conn().WaitForNotification(ctx); err != nil {
+			return err
+		}
+		b.wakeAll()
+	}
+}
+
+func (b *Bus) wakeAll() {
+	for _, sub := range b.subs {
+		select {
+		case sub.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (b *Bus) dispatch(ctx context.Context, sub *subscription) {
+	ticker := time.NewTicker(b.poll)
+	defer ticker.Stop()
+
+	var cursor int64
+	for {
+		c, err := b.queries.EnsureEventCursor(ctx, sub.name)
+		if err == nil {
+			cursor = c.LastEventID
+			break
+		}
+		slog.Error("failed to load event cursor", "subscriber", sub.name, "error", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(b.retry):
+		}
+	}
+
+	for {
+		n, err := b.deliver(ctx, sub, &cursor)
+		if err != nil {
+			slog.Warn("event delivery failed, retrying", "subscriber", sub.name, "attempts", sub.attempts, "error", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(b.retry * time.Duration(sub.attempts)):
+			}
+			continue
+		}
+		if n == batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-sub.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliver hands the next batch of events to a subscriber, advancing its
+// cursor past every event handled. It returns how many events it read.
+func (b *Bus) deliver(ctx context.Context, sub *subscription, cursor *int64) (int, error) {
+	rows, err := b.queries.ListEventsAfter(ctx, db.ListEventsAfterParams{ID: *cursor, Limit: batchSize})
+	if err != nil {
+		return 0, err
+	}
+
+	start := *cursor
+	advance := func() {
+		err := b.queries.AdvanceEventCursor(ctx, db.AdvanceEventCursorParams{Subscriber: sub.name, LastEventID: *cursor})
+		if err != nil {
+			slog.Error("failed to advance event cursor", "subscriber", sub.name, "error", err)
+		}
+	}
+
+	for _, row := range rows {
+		if err := sub.process(ctx, fromRow(row)); err != nil {
+			if *cursor != start {
+				advance()
+			}
+			return 0, err
+		}
+		*cursor = row.ID
+	}
+	if len(rows) > 0 {
+		advance()
+	}
+	return len(rows), nil
+}
+
+// process delivers one event to the handler if it matches the subscription.
+// An error means the event should be retried; after MaxAttempts the event is
+// dropped so it cannot block the subscriber forever.
+func (s *subscription) process(ctx context.Context, e Event) error {
+	if !s.matches(e.Type) {
+		return nil
+	}
+
+	err := s.handler(ctx, e)
+	if err == nil {
+		s.failing, s.attempts = 0, 0
+		return nil
+	}
+
+	if s.failing != e.ID {
+		s.failing, s.attempts = e.ID, 0
+	}
+	s.attempts++
+	if s.attempts >= MaxAttempts {
+		slog.Error("dropping event after repeated failures", "subscriber", s.name, "event", e.ID, "type", e.Type, "error", err)
+		tags := map[string]string{"subscriber": s.name, "event_type": e.Type, errtrack.TagApp: e.AppName}
+		if e.UserID != uuid.Nil {
+			tags[errtrack.TagUserID] = e.UserID.String()
+		}
+		errtrack.CaptureError(err, tags)
+		s.failing, s.attempts = 0, 0
+		return nil
+	}
+	return err
+}
+
+func (s *subscription) matches(eventType string) bool {
+	for _, pattern := range s.patterns {
+		if Match(pattern, eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// prune deletes events every subscriber has received once they are older
+// than Retention
+func (b *Bus) prune(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := b.queries.DeleteDeliveredEvents(ctx, time.Now().Add(-Retention)); err != nil {
+			slog.Error("failed to prune events", "error", err)
+		}
+	}
+}
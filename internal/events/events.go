@@ -0,0 +1,118 @@
+// Package events is the platform event bus. Subsystems publish events such as
+// deployments, security and billing changes to the events table; the
+// dispatcher delivers them in order to every subscriber (audit log,
+// notifications, metrics...) with at-least-once semantics, so integrations
+// subscribe to the bus instead of being called directly.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Event is something that happened on the platform. Message is a human
+// readable summary; events carrying one are alerts sent to notification
+// channels. TokenID is the API token the user acted with, if any.
+type Event struct {
+	ID        int64          `json:"id"`
+	Type      string         `json:"type"`
+	UserID    uuid.UUID      `json:"user_id,omitempty"`
+	TokenID   *uuid.UUID     `json:"api_token_id,omitempty"`
+	AppID     uuid.UUID      `json:"app_id,omitempty"`
+	AppName   string         `json:"app_name,omitempty"`
+	Message   string         `json:"message,omitempty"`
+	IP        *netip.Addr    `json:"ip_address,omitempty"`
+	Payload   map[string]any `json:"payload,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// Publisher publishes events
+type Publisher interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+// Handler processes an event delivered to a subscriber. Returning an error
+// redelivers the event, so handlers must tolerate duplicates.
+type Handler func(ctx context.Context, e Event) error
+
+// Publish appends an event to the bus. With queries bound to a transaction
+// the event is only delivered if the transaction commits.
+func Publish(ctx context.Context, queries *db.Queries, e Event) error {
+	payload := []byte("{}")
+	if e.Payload != nil {
+		var err error
+		if payload, err = json.Marshal(e.Payload); err != nil {
+			return fmt.Errorf("failed to marshal event payload: %w", err)
+		}
+	}
+
+	params := db.CreateEventParams{
+		Type:      e.Type,
+		UserID:    pgtype.UUID{Bytes: e.UserID, Valid: e.UserID != uuid.Nil},
+		AppID:     pgtype.UUID{Bytes: e.AppID, Valid: e.AppID != uuid.Nil},
+		IpAddress: e.IP,
+		Payload:   payload,
+	}
+	if e.TokenID != nil {
+		params.ApiTokenID = pgtype.UUID{Bytes: *e.TokenID, Valid: true}
+	}
+	if e.AppName != "" {
+		params.AppName = &e.AppName
+	}
+	if e.Message != "" {
+		params.Message = &e.Message
+	}
+
+	if _, err := queries.CreateEvent(ctx, params); err != nil {
+		return fmt.Errorf("failed to publish %s event: %w", e.Type, err)
+	}
+	return nil
+}
+
+// Match reports whether an event type matches a subscription pattern: "*"
+// matches everything, "deployment.*" every deployment event, anything else
+// only itself
+func Match(pattern, eventType string) bool {
+	if pattern == "*" || pattern == eventType {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(eventType, prefix)
+	}
+	return false
+}
+
+func fromRow(row db.Event) Event {
+	e := Event{
+		ID:        row.ID,
+		Type:      row.Type,
+		IP:        row.IpAddress,
+		CreatedAt: row.CreatedAt,
+	}
+	if row.UserID.Valid {
+		e.UserID = row.UserID.Bytes
+	}
+	if row.ApiTokenID.Valid {
+		tokenID := uuid.UUID(row.ApiTokenID.Bytes)
+		e.TokenID = &tokenID
+	}
+	if row.AppID.Valid {
+		e.AppID = row.AppID.Bytes
+	}
+	if row.AppName != nil {
+		e.AppName = *row.AppName
+	}
+	if row.Message != nil {
+		e.Message = *row.Message
+	}
+	_ = json.Unmarshal(row.Payload, &e.Payload)
+	return e
+}
@@ -0,0 +1,70 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		eventType string
+		match     bool
+	}{
+		{"*", "deployment.failed", true},
+		{"deployment.*", "deployment.failed", true},
+		{"deployment.*", "deployments.failed", false},
+		{"deployment.failed", "deployment.failed", true},
+		{"deployment.failed", "deployment.ready", false},
+		{"certificate.*", "mirror.expired", false},
+	}
+
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.eventType); got != tt.match {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.eventType, got, tt.match)
+		}
+	}
+}
+
+func TestSubscriptionProcess(t *testing.T) {
+	var handled []int64
+	failing := true
+	sub := &subscription{
+		name:     "test",
+		patterns: []string{"deployment.*"},
+		handler: func(_ context.Context, e Event) error {
+			if failing {
+				return errors.New("unavailable")
+			}
+			handled = append(handled, e.ID)
+			return nil
+		},
+	}
+	ctx := context.Background()
+
+	if err := sub.process(ctx, Event{ID: 1, Type: "mirror.expired"}); err != nil {
+		t.Errorf("expected unsubscribed events to be skipped, got %v", err)
+	}
+
+	// A failing event is retried until MaxAttempts, then dropped
+	for attempt := 1; attempt < MaxAttempts; attempt++ {
+		if err := sub.process(ctx, Event{ID: 2, Type: "deployment.failed"}); err == nil {
+			t.Fatalf("expected attempt %d to ask for a retry", attempt)
+		}
+	}
+	if err := sub.process(ctx, Event{ID: 2, Type: "deployment.failed"}); err != nil {
+		t.Errorf("expected the event to be dropped after %d attempts, got %v", MaxAttempts, err)
+	}
+
+	failing = false
+	if err := sub.process(ctx, Event{ID: 3, Type: "deployment.ready"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(handled) != 1 || handled[0] != 3 {
+		t.Errorf("expected event 3 to be handled, got %v", handled)
+	}
+	if sub.attempts != 0 {
+		t.Errorf("expected attempts to reset after a success, got %d", sub.attempts)
+	}
+}
@@ -0,0 +1,261 @@
+// Package imageretention deletes the deployment images of apps from the
+// platform registry once they fall out of the app's retention policy, so
+// old builds stop counting toward the owner's registry quota.
+package imageretention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/events"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/legalhold"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/registry"
+)
+
+// EventPruned is published when images of an app are deleted
+const EventPruned = "images.pruned"
+
+// Reasons an image outside the last deployments is kept
+const (
+	ReasonRecent  = "recent"
+	ReasonCurrent = "current"
+	ReasonInUse   = "in_use"
+	// ReasonShared is an image with the same digest as a kept one; deleting
+	// its manifest would delete the kept image too
+	ReasonShared = "shared"
+)
+
+// Image is a deployment image of an app in the platform registry
+type Image struct {
+	Image string `json:"image"`
+	// Version is the latest deployment of the app that used the image
+	Version int32  `json:"version"`
+	Digest  string `json:"digest,omitempty"`
+	// Reason says why a kept image is kept
+	Reason string `json:"reason,omitempty"`
+}
+
+// Report lists the images of an app a retention policy keeps and the ones it
+// deletes
+type Report struct {
+	KeepLast int32   `json:"keep_last"`
+	Kept     []Image `json:"kept"`
+	Expired  []Image `json:"expired"`
+}
+
+// Select splits the images of an app's deployments, newest first, into the
+// ones kept and the expired ones: the images of the last keepLast distinct
+// deployments are kept, as are the current deployment's and images other apps
+// run. Only images in the namespace of the registry host are considered.
+func Select(deployments []db.ListDeploymentImagesByAppRow, keepLast int, current string, inUse map[string]bool, host, namespace string) (kept, expired []Image) {
+	seen := make(map[string]bool)
+	for _, d := range deployments {
+		if seen[d.Image] {
+			continue
+		}
+		seen[d.Image] = true
+
+		ref, err := registry.ParseReference(d.Image)
+		if err != nil || ref.Registry != host || registry.Namespace(ref.Repository) != namespace {
+			continue
+		}
+
+		image := Image{Image: d.Image, Version: d.Version}
+		switch {
+		case len(kept) < keepLast:
+			image.Reason = ReasonRecent
+		case d.Image == current:
+			image.Reason = ReasonCurrent
+		case inUse[d.Image]:
+			image.Reason = ReasonInUse
+		}
+		if image.Reason != "" {
+			kept = append(kept, image)
+		} else {
+			expired = append(expired, image)
+		}
+	}
+	return kept, expired
+}
+
+// Pruner applies the retention policies of apps
+type Pruner struct {
+	queries *db.Queries
+	cfg     *config.Config
+	events  events.Publisher
+}
+
+// New creates a pruner
+func New(queries *db.Queries, cfg *config.Config, publisher events.Publisher) *Pruner {
+	return &Pruner{queries: queries, cfg: cfg, events: publisher}
+}
+
+// Plan returns what keeping the last keepLast images of an app would delete,
+// without deleting anything. Expired images already gone from the registry
+// are left out.
+func (p *Pruner) Plan(ctx context.Context, app db.App, keepLast int32) (Report, error) {
+	hosted, err := p.hosted()
+	if err != nil {
+		return Report{}, err
+	}
+	return p.plan(ctx, hosted, app, keepLast)
+}
+
+func (p *Pruner) plan(ctx context.Context, hosted *registry.Hosted, app db.App, keepLast int32) (Report, error) {
+	owner, err := p.queries.GetUserByID(ctx, app.UserID)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to get app owner: %w", err)
+	}
+	deployments, err := p.queries.ListDeploymentImagesByApp(ctx, app.ID)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to list deployment images: %w", err)
+	}
+	running, err := p.queries.ListCurrentDeploymentImages(ctx)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to list running images: %w", err)
+	}
+
+	var current string
+	inUse := make(map[string]bool, len(running))
+	for _, image := range running {
+		inUse[image] = true
+	}
+	if app.CurrentDeploymentID.Valid {
+		if deployment, err := p.queries.GetDeploymentByID(ctx, app.CurrentDeploymentID.Bytes); err == nil {
+			current = deployment.Image
+		}
+	}
+
+	// The registry namespace of a mixed-case login is its lowercased form
+	kept, expired := Select(deployments, int(keepLast), current, inUse, p.cfg.RegistryHost, strings.ToLower(owner.Username))
+	report := Report{KeepLast: keepLast, Kept: []Image{}, Expired: []Image{}}
+
+	keptDigests := make(map[string]bool)
+	for _, image := range kept {
+		digest, err := digest(ctx, hosted, image.Image)
+		if err != nil && !errors.Is(err, registry.ErrNotFound) {
+			return Report{}, err
+		}
+		image.Digest = digest
+		if digest != "" {
+			keptDigests[digest] = true
+		}
+		report.Kept = append(report.Kept, image)
+	}
+
+	for _, image := range expired {
+		digest, err := digest(ctx, hosted, image.Image)
+		if errors.Is(err, registry.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return Report{}, err
+		}
+		image.Digest = digest
+		if keptDigests[digest] {
+			image.Reason = ReasonShared
+			report.Kept = append(report.Kept, image)
+			continue
+		}
+		report.Expired = append(report.Expired, image)
+	}
+	return report, nil
+}
+
+// Prune deletes the expired images of every app with a retention policy. An
+// app whose images cannot be read is skipped until the next run, and one a
+// legal hold covers until the hold is released.
+func (p *Pruner) Prune(ctx context.Context) error {
+	hosted, err := p.hosted()
+	if errors.Is(err, registry.ErrNotConfigured) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	policies, err := p.queries.ListAppImageRetentions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list image retention policies: %w", err)
+	}
+
+	for _, policy := range policies {
+		app, err := p.queries.GetAppByID(ctx, policy.AppID)
+		if err != nil {
+			slog.Warn("failed to get app of image retention policy", "app_id", policy.AppID, "error", err)
+			continue
+		}
+		holds, err := legalhold.Covers(ctx, p.queries, app.UserID)
+		if err != nil {
+			slog.Warn("failed to list legal holds", "app", app.Name, "error", err)
+			continue
+		}
+		if len(holds) > 0 {
+			slog.Info("app under legal hold, keeping its images", "app", app.Name, "orgs", legalhold.Orgs(holds))
+			continue
+		}
+		report, err := p.plan(ctx, hosted, app, policy.KeepLast)
+		if err != nil {
+			slog.Warn("failed to plan image retention", "app", app.Name, "error", err)
+			continue
+		}
+
+		var deleted []string
+		for _, image := range report.Expired {
+			ref, _ := registry.ParseReference(image.Image)
+			err := hosted.DeleteManifest(ctx, ref.Repository, image.Digest)
+			if err != nil && !errors.Is(err, registry.ErrNotFound) {
+				slog.Warn("failed to delete image", "app", app.Name, "image", image.Image, "error", err)
+				continue
+			}
+			deleted = append(deleted, image.Image)
+		}
+
+		if err := p.queries.MarkAppImagesPruned(ctx, app.ID); err != nil {
+			slog.Error("failed to record image pruning", "app", app.Name, "error", err)
+		}
+		if len(deleted) == 0 {
+			continue
+		}
+		if err := p.events.Publish(ctx, prunedEvent(app, policy.KeepLast, deleted)); err != nil {
+			slog.Error("failed to publish image pruning", "app", app.Name, "error", err)
+		}
+	}
+	return nil
+}
+
+func (p *Pruner) hosted() (*registry.Hosted, error) {
+	issuer, err := registry.LoadIssuer(p.cfg.RegistryHost, p.cfg.RegistryTokenCertFile, p.cfg.RegistryTokenKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return registry.NewHosted(p.cfg.RegistryAPI(), issuer), nil
+}
+
+// digest resolves the manifest digest of an image, given by digest or tag
+func digest(ctx context.Context, hosted *registry.Hosted, image string) (string, error) {
+	ref, err := registry.ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	return hosted.Digest(ctx, ref.Repository, ref.Reference)
+}
+
+func prunedEvent(app db.App, keepLast int32, images []string) events.Event {
+	return events.Event{
+		Type:    EventPruned,
+		UserID:  app.UserID,
+		AppID:   app.ID,
+		AppName: app.Name,
+		Message: fmt.Sprintf("deleted %d images of %s outside its last %d deployments", len(images), app.Name, keepLast),
+		Payload: map[string]any{
+			"images":    images,
+			"keep_last": keepLast,
+		},
+	}
+}
@@ -0,0 +1,57 @@
+package imageretention
+
+import (
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/google/uuid"
+)
+
+func TestSelect(t *testing.T) {
+	deployments := []db.ListDeploymentImagesByAppRow{
+		{Version: 7, Image: "registry.nexo.build/alice/web:v5"},
+		{Version: 6, Image: "registry.nexo.build/alice/web:v4"},
+		// A rollback redeploys an older image
+		{Version: 5, Image: "registry.nexo.build/alice/web:v5"},
+		{Version: 4, Image: "registry.nexo.build/alice/web:v3"},
+		{Version: 3, Image: "ghcr.io/alice/web:v2"},
+		{Version: 2, Image: "registry.nexo.build/bob/web:v1"},
+		{Version: 1, Image: "registry.nexo.build/alice/web:v1"},
+		{Version: 0, Image: "registry.nexo.build/alice/web:v0"},
+	}
+	inUse := map[string]bool{"registry.nexo.build/alice/web:v1": true}
+
+	kept, expired := Select(deployments, 2, "registry.nexo.build/alice/web:v3", inUse, "registry.nexo.build", "alice")
+
+	want := []Image{
+		{Image: "registry.nexo.build/alice/web:v5", Version: 7, Reason: ReasonRecent},
+		{Image: "registry.nexo.build/alice/web:v4", Version: 6, Reason: ReasonRecent},
+		{Image: "registry.nexo.build/alice/web:v3", Version: 4, Reason: ReasonCurrent},
+		{Image: "registry.nexo.build/alice/web:v1", Version: 1, Reason: ReasonInUse},
+	}
+	if len(kept) != len(want) {
+		t.Fatalf("expected %d kept images, got %+v", len(want), kept)
+	}
+	for i := range want {
+		if kept[i] != want[i] {
+			t.Errorf("expected %+v, got %+v", want[i], kept[i])
+		}
+	}
+
+	// Images of other registries and namespaces are never touched
+	if len(expired) != 1 || expired[0].Image != "registry.nexo.build/alice/web:v0" {
+		t.Errorf("expected only v0 to expire, got %+v", expired)
+	}
+}
+
+func TestPrunedEvent(t *testing.T) {
+	app := db.App{ID: uuid.New(), UserID: uuid.New(), Name: "web"}
+	e := prunedEvent(app, 5, []string{"registry.nexo.build/alice/web:v1"})
+
+	if e.Type != EventPruned || e.AppID != app.ID || e.UserID != app.UserID {
+		t.Errorf("unexpected event %+v", e)
+	}
+	if e.Message != "deleted 1 images of web outside its last 5 deployments" {
+		t.Errorf("unexpected message %q", e.Message)
+	}
+}
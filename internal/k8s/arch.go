@@ -0,0 +1,100 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// ErrIncompatibleArchitecture is returned when no node of the cluster runs an
+// architecture the image is built for
+var ErrIncompatibleArchitecture = errors.New("no nodes match the image architecture")
+
+// NodeArchitectures returns the architectures of the ready, schedulable nodes
+// matching the selector, sorted
+func (c *Client) NodeArchitectures(ctx context.Context, nodeSelector map[string]string) ([]string, error) {
+	nodes, err := c.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(nodeSelector).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	var architectures []string
+	for _, node := range nodes.Items {
+		if node.Spec.Unschedulable || !nodeReady(&node) {
+			continue
+		}
+		arch := node.Labels[corev1.LabelArchStable]
+		if arch != "" && !slices.Contains(architectures, arch) {
+			architectures = append(architectures, arch)
+		}
+	}
+	slices.Sort(architectures)
+	return architectures, nil
+}
+
+// CheckArchitectures returns the architectures of an image that the app's
+// node pool runs, which its pods are then scheduled onto. The error wraps
+// ErrIncompatibleArchitecture when there are none.
+func (c *Client) CheckArchitectures(ctx context.Context, cfg *AppConfig, imageArchitectures []string) ([]string, error) {
+	var nodeSelector map[string]string
+	if cfg.Placement != nil {
+		nodeSelector = cfg.Placement.NodeSelector
+	}
+
+	nodeArchitectures, err := c.NodeArchitectures(ctx, nodeSelector)
+	if err != nil {
+		return nil, err
+	}
+	return CompatibleArchitectures(imageArchitectures, nodeArchitectures)
+}
+
+// CompatibleArchitectures returns the image architectures that nodes run.
+// Without labeled nodes every image architecture is kept.
+func CompatibleArchitectures(image, nodes []string) ([]string, error) {
+	if len(nodes) == 0 {
+		return image, nil
+	}
+
+	var compatible []string
+	for _, arch := range image {
+		if slices.Contains(nodes, arch) {
+			compatible = append(compatible, arch)
+		}
+	}
+	if len(compatible) == 0 {
+		return nil, fmt.Errorf("%w: the image is built for %s but the nodes run %s, build the image for %s",
+			ErrIncompatibleArchitecture, strings.Join(image, ", "), strings.Join(nodes, ", "), strings.Join(nodes, " or "))
+	}
+	return compatible, nil
+}
+
+// applyArchitectures requires pods to run on nodes of the given
+// architectures, leaving scheduling to the cluster when none are known
+func applyArchitectures(spec *corev1.PodSpec, architectures []string) {
+	if len(architectures) == 0 {
+		spec.Affinity = nil
+		return
+	}
+
+	spec.Affinity = &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+					MatchExpressions: []corev1.NodeSelectorRequirement{{
+						Key:      corev1.LabelArchStable,
+						Operator: corev1.NodeSelectorOpIn,
+						Values:   slices.Clone(architectures),
+					}},
+				}},
+			},
+		},
+	}
+}
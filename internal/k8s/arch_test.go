@@ -0,0 +1,78 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func archNode(name, arch string, ready bool) *corev1.Node {
+	node := capacityNode(name, "4", "8Gi", ready)
+	node.Labels = map[string]string{corev1.LabelArchStable: arch, "pool": "general"}
+	return node
+}
+
+func TestNodeArchitectures(t *testing.T) {
+	clientset := fake.NewClientset(
+		archNode("amd-1", "amd64", true),
+		archNode("amd-2", "amd64", true),
+		archNode("arm-1", "arm64", true),
+		archNode("s390x-1", "s390x", false),
+	)
+	client := NewClientWithInterface(clientset, "fuego-")
+
+	archs, err := client.NodeArchitectures(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(archs, []string{"amd64", "arm64"}) {
+		t.Errorf("expected amd64 and arm64 from ready nodes, got %v", archs)
+	}
+
+	cfg := &AppConfig{Name: "myapp", Placement: &Placement{NodeSelector: map[string]string{"pool": "general"}}}
+	if _, err := client.CheckArchitectures(context.Background(), cfg, []string{"ppc64le"}); !errors.Is(err, ErrIncompatibleArchitecture) {
+		t.Errorf("expected ErrIncompatibleArchitecture, got %v", err)
+	}
+	if reason, _ := FailureOf(TranslateError("check architectures", ErrIncompatibleArchitecture)); reason != FailureArchMismatch {
+		t.Errorf("expected %q reason, got %q", FailureArchMismatch, reason)
+	}
+}
+
+func TestCompatibleArchitectures(t *testing.T) {
+	compatible, err := CompatibleArchitectures([]string{"amd64", "arm64"}, []string{"arm64"})
+	if err != nil || !slices.Equal(compatible, []string{"arm64"}) {
+		t.Errorf("expected arm64, got %v, %v", compatible, err)
+	}
+
+	if compatible, err := CompatibleArchitectures([]string{"arm64"}, nil); err != nil || !slices.Equal(compatible, []string{"arm64"}) {
+		t.Errorf("expected unlabeled nodes to accept the image, got %v, %v", compatible, err)
+	}
+
+	_, err = CompatibleArchitectures([]string{"arm64"}, []string{"amd64"})
+	if !errors.Is(err, ErrIncompatibleArchitecture) || !strings.Contains(err.Error(), "built for arm64 but the nodes run amd64") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestGenerateDeployment_Architectures(t *testing.T) {
+	cfg := &AppConfig{Name: "myapp", Namespace: "fuego-myapp", Image: "nginx", Replicas: 1, Architectures: []string{"arm64"}}
+
+	affinity := GenerateDeployment(cfg).Spec.Template.Spec.Affinity
+	if affinity == nil || affinity.NodeAffinity == nil {
+		t.Fatal("expected node affinity")
+	}
+	requirement := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions[0]
+	if requirement.Key != corev1.LabelArchStable || requirement.Operator != corev1.NodeSelectorOpIn || !slices.Equal(requirement.Values, []string{"arm64"}) {
+		t.Errorf("unexpected requirement %+v", requirement)
+	}
+
+	cfg.Architectures = nil
+	if GenerateDeployment(cfg).Spec.Template.Spec.Affinity != nil {
+		t.Error("expected no affinity without known architectures")
+	}
+}
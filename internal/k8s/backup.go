@@ -0,0 +1,140 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// managedBySelector selects the namespaces the platform created
+const managedBySelector = "app.kubernetes.io/managed-by=nexo-cloud"
+
+// ErrBackupUnavailable is returned when the client cannot read arbitrary
+// resources
+var ErrBackupUnavailable = errors.New("backup resources are not available")
+
+// backupResources are the namespaced resources the platform manages, in the
+// order they are restored: secrets and services before the workloads and
+// routes using them. Pods, jobs and replica sets are recreated by their
+// controllers.
+var backupResources = []struct {
+	gvr  schema.GroupVersionResource
+	kind string
+}{
+	{schema.GroupVersionResource{Version: "v1", Resource: "secrets"}, "Secret"},
+	{schema.GroupVersionResource{Version: "v1", Resource: "services"}, "Service"},
+	{schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, "Deployment"},
+	{schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "cronjobs"}, "CronJob"},
+	{schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}, "Ingress"},
+	{CertificateGVR, "Certificate"},
+	{TLSOptionGVR, "TLSOption"},
+	{MiddlewareGVR, "Middleware"},
+	{TraefikServiceGVR, "TraefikService"},
+	{IngressRouteGVR, "IngressRoute"},
+}
+
+// NamespaceBackup holds a managed namespace and its resources, stripped of
+// server-populated fields so they can be created on another cluster
+type NamespaceBackup struct {
+	Name   string                      `json:"name"`
+	Labels map[string]string           `json:"labels,omitempty"`
+	Items  []unstructured.Unstructured `json:"items"`
+}
+
+// ExportNamespaces returns every namespace managed by the platform with its
+// resources. Custom resources whose CRDs are not installed are skipped.
+func (c *Client) ExportNamespaces(ctx context.Context) ([]NamespaceBackup, error) {
+	if c.dynamic == nil {
+		return nil, ErrBackupUnavailable
+	}
+
+	namespaces, err := c.clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{LabelSelector: managedBySelector})
+	if err != nil {
+		return nil, TranslateError("list namespaces", err)
+	}
+
+	backups := make([]NamespaceBackup, 0, len(namespaces.Items))
+	for _, ns := range namespaces.Items {
+		backup := NamespaceBackup{Name: ns.Name, Labels: ns.Labels, Items: []unstructured.Unstructured{}}
+
+		for _, resource := range backupResources {
+			list, err := c.dynamic.Resource(resource.gvr).Namespace(ns.Name).List(ctx, metav1.ListOptions{})
+			if k8serrors.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to list %s in %s: %w", resource.gvr.Resource, ns.Name, err)
+			}
+
+			for _, item := range list.Items {
+				if len(item.GetOwnerReferences()) > 0 {
+					// Recreated by its owner
+					continue
+				}
+				if item.GetKind() == "Secret" && item.Object["type"] == string(corev1.SecretTypeServiceAccountToken) {
+					continue
+				}
+				item.SetAPIVersion(resource.gvr.GroupVersion().String())
+				item.SetKind(resource.kind)
+				backup.Items = append(backup.Items, sanitizeForBackup(item))
+			}
+		}
+
+		backups = append(backups, backup)
+	}
+
+	return backups, nil
+}
+
+// RestoreNamespace recreates a namespace and its resources. Existing
+// resources are updated, so restoring twice converges on the snapshot.
+func (c *Client) RestoreNamespace(ctx context.Context, backup NamespaceBackup) error {
+	if c.dynamic == nil {
+		return ErrBackupUnavailable
+	}
+
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: backup.Name, Labels: backup.Labels}}
+	_, err := c.clientset.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{})
+	if err != nil && !k8serrors.IsAlreadyExists(err) {
+		return TranslateError("create namespace", err)
+	}
+
+	for _, resource := range backupResources {
+		for _, item := range backup.Items {
+			if item.GroupVersionKind().GroupKind() != (schema.GroupKind{Group: resource.gvr.Group, Kind: resource.kind}) {
+				continue
+			}
+			obj := item.DeepCopy()
+			obj.SetNamespace(backup.Name)
+			if err := c.applyUnstructured(ctx, resource.gvr, obj); err != nil {
+				return fmt.Errorf("failed to restore %s %s/%s: %w", resource.kind, backup.Name, obj.GetName(), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// sanitizeForBackup drops the fields the API server sets so the object can
+// be created again
+func sanitizeForBackup(item unstructured.Unstructured) unstructured.Unstructured {
+	obj := item.DeepCopy()
+	for _, field := range []string{"uid", "resourceVersion", "generation", "creationTimestamp", "managedFields", "selfLink"} {
+		unstructured.RemoveNestedField(obj.Object, "metadata", field)
+	}
+	unstructured.RemoveNestedField(obj.Object, "metadata", "annotations", "kubectl.kubernetes.io/last-applied-configuration")
+	unstructured.RemoveNestedField(obj.Object, "status")
+
+	if obj.GetKind() == "Service" {
+		// Cluster IPs are allocated by the target cluster
+		unstructured.RemoveNestedField(obj.Object, "spec", "clusterIP")
+		unstructured.RemoveNestedField(obj.Object, "spec", "clusterIPs")
+	}
+	return *obj
+}
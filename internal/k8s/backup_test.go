@@ -0,0 +1,119 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func backupListKinds() map[schema.GroupVersionResource]string {
+	listKinds := make(map[schema.GroupVersionResource]string, len(backupResources))
+	for _, resource := range backupResources {
+		listKinds[resource.gvr] = resource.kind + "List"
+	}
+	return listKinds
+}
+
+func TestExportAndRestoreNamespaces(t *testing.T) {
+	ctx := context.Background()
+
+	managed := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "fuego-web",
+		Labels: map[string]string{"app.kubernetes.io/managed-by": "nexo-cloud", "app.kubernetes.io/name": "web"},
+	}}
+	unmanaged := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}}
+
+	service := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata": map[string]any{
+			"name":            "web",
+			"namespace":       "fuego-web",
+			"uid":             "1234",
+			"resourceVersion": "42",
+		},
+		"spec":   map[string]any{"clusterIP": "10.0.0.10", "ports": []any{map[string]any{"port": int64(80)}}},
+		"status": map[string]any{"loadBalancer": map[string]any{}},
+	}}
+	owned := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]any{
+			"name":            "web-tls",
+			"namespace":       "fuego-web",
+			"ownerReferences": []any{map[string]any{"kind": "Certificate", "name": "web", "apiVersion": "cert-manager.io/v1", "uid": "1"}},
+		},
+	}}
+	system := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata":   map[string]any{"name": "kube-dns", "namespace": "kube-system"},
+	}}
+
+	source := NewClientWithDynamic(
+		fake.NewClientset(managed, unmanaged),
+		dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), backupListKinds(), service, owned, system),
+		"fuego-",
+	)
+
+	backups, err := source.ExportNamespaces(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(backups) != 1 || backups[0].Name != "fuego-web" {
+		t.Fatalf("expected only the managed namespace, got %+v", backups)
+	}
+	if len(backups[0].Items) != 1 {
+		t.Fatalf("expected the owned secret to be skipped, got %d items", len(backups[0].Items))
+	}
+
+	item := backups[0].Items[0]
+	if item.GetUID() != "" || item.GetResourceVersion() != "" {
+		t.Error("expected server-populated metadata to be stripped")
+	}
+	if _, ok, _ := unstructured.NestedString(item.Object, "spec", "clusterIP"); ok {
+		t.Error("expected the cluster IP to be stripped")
+	}
+	if _, ok := item.Object["status"]; ok {
+		t.Error("expected status to be stripped")
+	}
+
+	targetClientset := fake.NewClientset()
+	targetDynamic := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), backupListKinds())
+	target := NewClientWithDynamic(targetClientset, targetDynamic, "fuego-")
+
+	if err := target.RestoreNamespace(ctx, backups[0]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ns, err := targetClientset.CoreV1().Namespaces().Get(ctx, "fuego-web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected namespace to be created: %v", err)
+	}
+	if ns.Labels["app.kubernetes.io/managed-by"] != "nexo-cloud" {
+		t.Errorf("expected namespace labels to be restored, got %v", ns.Labels)
+	}
+	if _, err := targetDynamic.Resource(backupResources[1].gvr).Namespace("fuego-web").Get(ctx, "web", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected service to be restored: %v", err)
+	}
+
+	// Restoring again updates in place
+	if err := target.RestoreNamespace(ctx, backups[0]); err != nil {
+		t.Fatalf("unexpected error on second restore: %v", err)
+	}
+}
+
+func TestExportNamespaces_Unavailable(t *testing.T) {
+	client := NewClientWithInterface(fake.NewClientset(), "fuego-")
+
+	if _, err := client.ExportNamespaces(context.Background()); !errors.Is(err, ErrBackupUnavailable) {
+		t.Errorf("expected ErrBackupUnavailable, got %v", err)
+	}
+}
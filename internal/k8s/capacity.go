@@ -0,0 +1,145 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// ErrInsufficientCapacity is returned when the cluster cannot schedule an app
+var ErrInsufficientCapacity = errors.New("insufficient cluster capacity")
+
+// Resources is an amount of schedulable CPU, memory and pods
+type Resources struct {
+	CPU    resource.Quantity `json:"cpu"`
+	Memory resource.Quantity `json:"memory"`
+	Pods   int64             `json:"pods"`
+}
+
+// AppRequests sums what every pod of an app requests across all process types
+func AppRequests(cfg *AppConfig) Resources {
+	var total Resources
+
+	web := cfg.Process(ProcessTypeWeb)
+	if web == nil {
+		web = &ProcessConfig{Type: ProcessTypeWeb, Replicas: cfg.Replicas}
+	}
+
+	add := func(proc *ProcessConfig) {
+		requests := processResources(proc).Requests
+		for i := int32(0); i < proc.Replicas; i++ {
+			total.CPU.Add(requests[corev1.ResourceCPU])
+			total.Memory.Add(requests[corev1.ResourceMemory])
+		}
+		total.Pods += int64(proc.Replicas)
+	}
+
+	add(web)
+	for i := range cfg.Processes {
+		if cfg.Processes[i].Type != ProcessTypeWeb {
+			add(&cfg.Processes[i])
+		}
+	}
+
+	return total
+}
+
+// FreeCapacity returns the allocatable resources of the ready, schedulable
+// nodes matching the selector minus what running pods already request. Pods
+// in excludeNamespace are not counted since a new rollout replaces them.
+func (c *Client) FreeCapacity(ctx context.Context, nodeSelector map[string]string, excludeNamespace string) (*Resources, error) {
+	nodes, err := c.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(nodeSelector).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	free := &Resources{}
+	eligible := make(map[string]bool, len(nodes.Items))
+	for _, node := range nodes.Items {
+		if node.Spec.Unschedulable || !nodeReady(&node) {
+			continue
+		}
+		eligible[node.Name] = true
+		free.CPU.Add(node.Status.Allocatable[corev1.ResourceCPU])
+		free.Memory.Add(node.Status.Allocatable[corev1.ResourceMemory])
+		free.Pods += node.Status.Allocatable.Pods().Value()
+	}
+
+	pods, err := c.clientset.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	for _, pod := range pods.Items {
+		if !eligible[pod.Spec.NodeName] || pod.Namespace == excludeNamespace {
+			continue
+		}
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		for _, container := range pod.Spec.Containers {
+			free.CPU.Sub(container.Resources.Requests[corev1.ResourceCPU])
+			free.Memory.Sub(container.Resources.Requests[corev1.ResourceMemory])
+		}
+		free.Pods--
+	}
+
+	return free, nil
+}
+
+// CheckCapacity verifies the app fits on its node pool before a deployment is
+// accepted, so pods do not sit Pending. The error wraps ErrInsufficientCapacity
+// and says which resource is short.
+func (c *Client) CheckCapacity(ctx context.Context, cfg *AppConfig) error {
+	nodeSelector := make(map[string]string)
+	if cfg.Placement != nil {
+		maps.Copy(nodeSelector, cfg.Placement.NodeSelector)
+	}
+	// Only nodes of the image's architecture can run a single-arch image
+	if len(cfg.Architectures) == 1 {
+		nodeSelector[corev1.LabelArchStable] = cfg.Architectures[0]
+	}
+
+	free, err := c.FreeCapacity(ctx, nodeSelector, c.NamespaceForApp(cfg.Name))
+	if err != nil {
+		return err
+	}
+
+	requested := AppRequests(cfg)
+
+	switch {
+	case requested.Pods > free.Pods:
+		return fmt.Errorf("%w: the app needs %d pods but only %d more fit on the cluster", ErrInsufficientCapacity, requested.Pods, max(free.Pods, 0))
+	case requested.CPU.Cmp(free.CPU) > 0:
+		return fmt.Errorf("%w: the app requests %s CPU but only %s is free", ErrInsufficientCapacity, requested.CPU.String(), nonNegative(free.CPU))
+	case requested.Memory.Cmp(free.Memory) > 0:
+		return fmt.Errorf("%w: the app requests %s memory but only %s is free", ErrInsufficientCapacity, requested.Memory.String(), nonNegative(free.Memory))
+	}
+
+	return nil
+}
+
+func nodeReady(node *corev1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// nonNegative formats a free amount, clamping overcommitted nodes to zero
+func nonNegative(q resource.Quantity) string {
+	if q.Sign() < 0 {
+		return "0"
+	}
+	return q.String()
+}
@@ -0,0 +1,110 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func capacityNode(name, cpu, memory string, ready bool) *corev1.Node {
+	status := corev1.ConditionTrue
+	if !ready {
+		status = corev1.ConditionFalse
+	}
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+				corev1.ResourcePods:   resource.MustParse("10"),
+			},
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}},
+		},
+	}
+}
+
+func capacityPod(namespace, name, node, cpu, memory string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: corev1.PodSpec{
+			NodeName: node,
+			Containers: []corev1.Container{{
+				Name: "app",
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(cpu),
+					corev1.ResourceMemory: resource.MustParse(memory),
+				}},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func TestAppRequests(t *testing.T) {
+	cfg := &AppConfig{
+		Name: "myapp",
+		Processes: []ProcessConfig{
+			{Type: ProcessTypeWeb, Replicas: 2, CPU: "500m", Memory: "256Mi"},
+			{Type: "worker", Command: "bin/worker", Replicas: 1, CPU: "1", Memory: "1Gi"},
+		},
+	}
+
+	requests := AppRequests(cfg)
+
+	if requests.CPU.String() != "2" {
+		t.Errorf("expected 2 CPU, got %s", requests.CPU.String())
+	}
+	if requests.Memory.String() != "1536Mi" {
+		t.Errorf("expected 1536Mi memory, got %s", requests.Memory.String())
+	}
+	if requests.Pods != 3 {
+		t.Errorf("expected 3 pods, got %d", requests.Pods)
+	}
+
+	if requests := AppRequests(&AppConfig{Name: "myapp", Replicas: 2}); requests.Pods != 2 {
+		t.Errorf("expected app replicas without a formation, got %d pods", requests.Pods)
+	}
+}
+
+func TestCheckCapacity(t *testing.T) {
+	fakeClient := fake.NewClientset(
+		capacityNode("node-1", "2", "4Gi", true),
+		capacityNode("node-2", "8", "16Gi", false),
+		capacityPod("fuego-other", "other-1", "node-1", "1", "1Gi"),
+		capacityPod("fuego-myapp", "myapp-1", "node-1", "1", "1Gi"),
+	)
+	client := NewClientWithInterface(fakeClient, "fuego-")
+
+	free, err := client.FreeCapacity(context.Background(), nil, "fuego-myapp")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if free.CPU.String() != "1" || free.Memory.String() != "3Gi" {
+		t.Errorf("expected 1 CPU and 3Gi free, got %s and %s", free.CPU.String(), free.Memory.String())
+	}
+
+	fits := &AppConfig{Name: "myapp", Processes: []ProcessConfig{{Type: ProcessTypeWeb, Replicas: 1, CPU: "1", Memory: "1Gi"}}}
+	if err := client.CheckCapacity(context.Background(), fits); err != nil {
+		t.Errorf("expected app to fit, got %v", err)
+	}
+
+	tooBig := &AppConfig{Name: "myapp", Processes: []ProcessConfig{{Type: ProcessTypeWeb, Replicas: 2, CPU: "1", Memory: "1Gi"}}}
+	err = client.CheckCapacity(context.Background(), tooBig)
+	if !errors.Is(err, ErrInsufficientCapacity) {
+		t.Fatalf("expected insufficient capacity, got %v", err)
+	}
+	if reason, _ := FailureOf(TranslateError("check capacity", err)); reason != FailureNoCapacity {
+		t.Errorf("expected %q reason, got %q", FailureNoCapacity, reason)
+	}
+
+	pinned := &AppConfig{Name: "myapp", Replicas: 1, Placement: &Placement{NodeSelector: map[string]string{"pool": "gpu"}}}
+	if err := client.CheckCapacity(context.Background(), pinned); !errors.Is(err, ErrInsufficientCapacity) {
+		t.Errorf("expected empty node pool to have no capacity, got %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// CertificateGVR identifies cert-manager Certificate resources
+var CertificateGVR = schema.GroupVersionResource{
+	Group:    "cert-manager.io",
+	Version:  "v1",
+	Resource: "certificates",
+}
+
+// Certificate states, matching the ssl_status of domains
+const (
+	CertStatePending      = "pending"
+	CertStateProvisioning = "provisioning"
+	CertStateActive       = "active"
+	CertStateError        = "error"
+	CertStateExpired      = "expired"
+)
+
+// ErrCertificatesUnavailable is returned when the client cannot read custom resources
+var ErrCertificatesUnavailable = errors.New("certificate resources are not available")
+
+// CertificateStatus is the issuance state of a cert-manager Certificate
+type CertificateStatus struct {
+	Name        string     `json:"name"`
+	DNSNames    []string   `json:"dns_names"`
+	State       string     `json:"state"`
+	Message     string     `json:"message,omitempty"`
+	NotAfter    *time.Time `json:"not_after,omitempty"`
+	RenewalTime *time.Time `json:"renewal_time,omitempty"`
+}
+
+// Covers reports whether the certificate is valid for domain
+func (s CertificateStatus) Covers(domain string) bool {
+	return slices.ContainsFunc(s.DNSNames, func(name string) bool {
+		return strings.EqualFold(name, domain)
+	})
+}
+
+// ListCertificates returns the state of the Certificates in an app's namespace
+func (c *Client) ListCertificates(ctx context.Context, appName string) ([]CertificateStatus, error) {
+	if c.dynamic == nil {
+		return nil, ErrCertificatesUnavailable
+	}
+
+	list, err := c.dynamic.Resource(CertificateGVR).Namespace(c.NamespaceForApp(appName)).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list certificates: %w", err)
+	}
+
+	statuses := make([]CertificateStatus, 0, len(list.Items))
+	for i := range list.Items {
+		statuses = append(statuses, certificateStatus(&list.Items[i], time.Now()))
+	}
+	return statuses, nil
+}
+
+// certificateStatus derives the state from the Ready and Issuing conditions
+func certificateStatus(obj *unstructured.Unstructured, now time.Time) CertificateStatus {
+	status := CertificateStatus{
+		Name:  obj.GetName(),
+		State: CertStatePending,
+	}
+	status.DNSNames, _, _ = unstructured.NestedStringSlice(obj.Object, "spec", "dnsNames")
+	status.NotAfter = nestedTime(obj, "status", "notAfter")
+	status.RenewalTime = nestedTime(obj, "status", "renewalTime")
+
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	var ready, issuing map[string]any
+	for _, raw := range conditions {
+		condition, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		switch condition["type"] {
+		case "Ready":
+			ready = condition
+		case "Issuing":
+			issuing = condition
+		}
+	}
+
+	switch {
+	case status.NotAfter != nil && now.After(*status.NotAfter):
+		status.State = CertStateExpired
+	case ready != nil && ready["status"] == "True":
+		status.State = CertStateActive
+	case issuing != nil && issuing["status"] == "False" && issuing["reason"] == "Failed":
+		status.State = CertStateError
+		status.Message, _ = issuing["message"].(string)
+	case ready != nil && ready["status"] == "False" && ready["reason"] == "Failed":
+		status.State = CertStateError
+		status.Message, _ = ready["message"].(string)
+	case issuing != nil || ready != nil:
+		status.State = CertStateProvisioning
+		if ready != nil {
+			status.Message, _ = ready["message"].(string)
+		}
+	}
+
+	return status
+}
+
+func nestedTime(obj *unstructured.Unstructured, fields ...string) *time.Time {
+	value, ok, _ := unstructured.NestedString(obj.Object, fields...)
+	if !ok {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,102 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func testCertificate(name, namespace string, status map[string]any) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "Certificate",
+		"metadata":   map[string]any{"name": name, "namespace": namespace},
+		"spec":       map[string]any{"dnsNames": []any{"www.example.com"}},
+		"status":     status,
+	}}
+}
+
+func condition(conditionType, status, reason, message string) map[string]any {
+	return map[string]any{"type": conditionType, "status": status, "reason": reason, "message": message}
+}
+
+func TestCertificateStatus(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		status map[string]any
+		state  string
+	}{
+		{"no status", map[string]any{}, CertStatePending},
+		{"ready", map[string]any{
+			"notAfter":   "2026-08-01T00:00:00Z",
+			"conditions": []any{condition("Ready", "True", "Ready", "Certificate is up to date")},
+		}, CertStateActive},
+		{"issuing", map[string]any{
+			"conditions": []any{
+				condition("Ready", "False", "DoesNotExist", "Issuing certificate"),
+				condition("Issuing", "True", "Requested", ""),
+			},
+		}, CertStateProvisioning},
+		{"failed", map[string]any{
+			"conditions": []any{
+				condition("Ready", "False", "DoesNotExist", ""),
+				condition("Issuing", "False", "Failed", "ACME challenge failed"),
+			},
+		}, CertStateError},
+		{"expired", map[string]any{
+			"notAfter":   "2026-05-01T00:00:00Z",
+			"conditions": []any{condition("Ready", "True", "Ready", "")},
+		}, CertStateExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := certificateStatus(testCertificate("web-tls", "fuego-web", tt.status), now)
+			if status.State != tt.state {
+				t.Errorf("expected state %q, got %q", tt.state, status.State)
+			}
+		})
+	}
+
+	failed := certificateStatus(testCertificate("web-tls", "fuego-web", tests[3].status), now)
+	if failed.Message != "ACME challenge failed" {
+		t.Errorf("expected failure message, got %q", failed.Message)
+	}
+	if !failed.Covers("WWW.example.com") || failed.Covers("example.com") {
+		t.Errorf("unexpected coverage for dns names %v", failed.DNSNames)
+	}
+}
+
+func TestListCertificates(t *testing.T) {
+	scheme := runtime.NewScheme()
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(scheme,
+		map[schema.GroupVersionResource]string{CertificateGVR: "CertificateList"},
+		testCertificate("web-tls", "fuego-web", map[string]any{
+			"conditions": []any{condition("Ready", "True", "Ready", "")},
+		}),
+		testCertificate("other-tls", "fuego-other", map[string]any{}),
+	)
+	client := NewClientWithDynamic(fake.NewClientset(), dynamicClient, "fuego-")
+
+	certs, err := client.ListCertificates(context.Background(), "web")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(certs) != 1 || certs[0].Name != "web-tls" || certs[0].State != CertStateActive {
+		t.Errorf("unexpected certificates %+v", certs)
+	}
+
+	_, err = NewClientWithInterface(fake.NewClientset(), "fuego-").ListCertificates(context.Background(), "web")
+	if !errors.Is(err, ErrCertificatesUnavailable) {
+		t.Errorf("expected ErrCertificatesUnavailable, got %v", err)
+	}
+}
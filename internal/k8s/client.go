@@ -2,17 +2,22 @@
 package k8s
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
 type Client struct {
-	clientset       kubernetes.Interface
+	clientset kubernetes.Interface
+	// dynamic reads custom resources such as cert-manager Certificates
+	dynamic         dynamic.Interface
 	config          *rest.Config
 	namespacePrefix string
 }
@@ -28,8 +33,14 @@ func NewClient(kubeconfig, namespacePrefix string) (*Client, error) {
 		return nil, fmt.Errorf("failed to create kubernetes clientset: %w", err)
 	}
 
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes dynamic client: %w", err)
+	}
+
 	return &Client{
 		clientset:       clientset,
+		dynamic:         dynamicClient,
 		config:          config,
 		namespacePrefix: namespacePrefix,
 	}, nil
@@ -44,6 +55,16 @@ func NewClientWithInterface(clientset kubernetes.Interface, namespacePrefix stri
 	}
 }
 
+// NewClientWithDynamic creates a Client that can also read custom resources.
+// This is useful for testing with fake clients
+func NewClientWithDynamic(clientset kubernetes.Interface, dynamicClient dynamic.Interface, namespacePrefix string) *Client {
+	return &Client{
+		clientset:       clientset,
+		dynamic:         dynamicClient,
+		namespacePrefix: namespacePrefix,
+	}
+}
+
 func getConfig(kubeconfig string) (*rest.Config, error) {
 	if kubeconfig != "" {
 		return clientcmd.BuildConfigFromFlags("", kubeconfig)
@@ -75,3 +96,21 @@ func (c *Client) Config() *rest.Config {
 func (c *Client) NamespaceForApp(appName string) string {
 	return c.namespacePrefix + appName
 }
+
+// Ping measures the round trip to the API server, honoring the context deadline
+func (c *Client) Ping(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	done := make(chan error, 1)
+
+	go func() {
+		_, err := c.clientset.Discovery().ServerVersion()
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return time.Since(start), err
+	case <-ctx.Done():
+		return time.Since(start), ctx.Err()
+	}
+}
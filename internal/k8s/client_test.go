@@ -1,9 +1,12 @@
 package k8s
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestNamespaceForApp(t *testing.T) {
@@ -321,3 +324,12 @@ users:
 		t.Errorf("expected explicit config host, got %s", config.Host)
 	}
 }
+
+func TestClient_Ping(t *testing.T) {
+	client := NewClientWithInterface(fake.NewClientset(), "fuego-")
+
+	if _, err := client.Ping(context.Background()); err != nil {
+		t.Errorf("expected ping to succeed, got %v", err)
+	}
+
+}
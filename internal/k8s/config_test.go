@@ -0,0 +1,70 @@
+package k8s
+
+// testAppConfig returns the config of myapp in namespace fuego-myapp with
+// the features passed turned on. Tests of a feature start from it instead of
+// building a config of their own.
+func testAppConfig(features ...func(*AppConfig)) *AppConfig {
+	cfg := &AppConfig{
+		Name:         "myapp",
+		Namespace:    "fuego-myapp",
+		Image:        "myapp:v1",
+		Replicas:     1,
+		Port:         3000,
+		DomainSuffix: "nexo.build",
+	}
+	for _, feature := range features {
+		feature(cfg)
+	}
+	return cfg
+}
+
+// withWorkloads gives the app a secret env var, a worker and a cron job
+func withWorkloads(cfg *AppConfig) {
+	cfg.EnvVars = map[string]string{"DATABASE_URL": "postgres://secret"}
+	cfg.Processes = []ProcessConfig{{Type: "worker", Command: "bin/worker", Replicas: 1}}
+	cfg.CronJobs = []CronJobConfig{{Name: "cleanup", Schedule: "0 3 * * *", Command: "bin/cleanup"}}
+}
+
+// withPoolers gives the app a connection pooler in front of a database
+func withPoolers(cfg *AppConfig) {
+	cfg.Poolers = []PoolerConfig{{
+		Name:       "main",
+		Host:       "ep-cool.neon.tech",
+		Port:       5432,
+		Database:   "shop",
+		Username:   "app",
+		Password:   "secret",
+		SSLMode:    "require",
+		ListenPort: 6432,
+		PoolSize:   5,
+	}}
+}
+
+// withOTelCollector gives the app a collector exporting to Honeycomb
+func withOTelCollector(cfg *AppConfig) {
+	cfg.OTelCollector = &OTelCollectorConfig{
+		Endpoint: "https://api.honeycomb.io:443",
+		Protocol: OTelProtocolGRPC,
+		Headers:  map[string]string{"x-honeycomb-team": "secret"},
+	}
+}
+
+// withMTLS makes the app require client certificates
+func withMTLS(cfg *AppConfig) {
+	cfg.MTLS = &MTLSConfig{
+		CACert:    []byte("ca"),
+		CRL:       []byte("crl"),
+		VerifyURL: "https://cloud.nexo.build/api/mtls/verify?app=myapp",
+	}
+}
+
+// withTLSPolicy gives the app a custom domain with a preloaded HSTS policy
+func withTLSPolicy(cfg *AppConfig) {
+	cfg.Domain = "shop.example.com"
+	cfg.TLSPolicy = &TLSPolicy{
+		ForceHTTPS:            true,
+		HSTSMaxAge:            MinHSTSPreloadMaxAge,
+		HSTSIncludeSubdomains: true,
+		HSTSPreload:           true,
+	}
+}
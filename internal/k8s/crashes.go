@@ -0,0 +1,91 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Termination and waiting reasons of crashing containers
+const (
+	TerminationOOMKilled    = "OOMKilled"
+	WaitingCrashLoopBackOff = "CrashLoopBackOff"
+)
+
+// ContainerCrash is the last abnormal termination of a container: killed
+// for running out of memory or exited with a non-zero code
+type ContainerCrash struct {
+	Pod       string `json:"pod"`
+	Process   string `json:"process"`
+	Container string `json:"container"`
+	// Reason is the kubelet's termination reason, OOMKilled or Error
+	Reason   string `json:"reason"`
+	ExitCode int32  `json:"exit_code"`
+	Restarts int32  `json:"restarts"`
+	// CrashLooping is true while the kubelet backs off restarting it
+	CrashLooping bool      `json:"crash_looping"`
+	FinishedAt   time.Time `json:"finished_at"`
+	// MemoryLimit is the container's memory limit, empty when unlimited
+	MemoryLimit string `json:"memory_limit,omitempty"`
+}
+
+// OOMKilled reports whether the container was killed for exceeding its
+// memory limit
+func (c *ContainerCrash) OOMKilled() bool {
+	return c.Reason == TerminationOOMKilled
+}
+
+// ContainerCrashes returns the last abnormal termination of every container
+// of pods. Clean exits are ignored.
+func ContainerCrashes(pods []corev1.Pod) []ContainerCrash {
+	var crashes []ContainerCrash
+	for _, pod := range pods {
+		process := pod.Labels[processLabel]
+		if process == "" {
+			process = ProcessTypeWeb
+		}
+
+		for _, status := range pod.Status.ContainerStatuses {
+			terminated := status.State.Terminated
+			if terminated == nil {
+				terminated = status.LastTerminationState.Terminated
+			}
+			if terminated == nil || (terminated.Reason != TerminationOOMKilled && terminated.ExitCode == 0) {
+				continue
+			}
+
+			crashes = append(crashes, ContainerCrash{
+				Pod:          pod.Name,
+				Process:      process,
+				Container:    status.Name,
+				Reason:       terminated.Reason,
+				ExitCode:     terminated.ExitCode,
+				Restarts:     status.RestartCount,
+				CrashLooping: status.State.Waiting != nil && status.State.Waiting.Reason == WaitingCrashLoopBackOff,
+				FinishedAt:   terminated.FinishedAt.Time,
+				MemoryLimit:  memoryLimit(&pod, status.Name),
+			})
+		}
+	}
+	return crashes
+}
+
+// ListCrashes returns the container crashes of every pod of an app
+func (c *Client) ListCrashes(ctx context.Context, appName string) ([]ContainerCrash, error) {
+	pods, err := c.GetPods(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	return ContainerCrashes(pods.Items), nil
+}
+
+func memoryLimit(pod *corev1.Pod, container string) string {
+	for _, spec := range pod.Spec.Containers {
+		if spec.Name == container {
+			return quantity(spec.Resources, corev1.ResourceMemory)
+		}
+	}
+	return ""
+}
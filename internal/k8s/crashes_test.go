@@ -0,0 +1,72 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestContainerCrashes(t *testing.T) {
+	oomKilled := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp-worker-1", Labels: map[string]string{processLabel: "worker"}},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name:      "worker",
+			Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")}},
+		}}},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			Name:                 "worker",
+			RestartCount:         4,
+			State:                corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+			LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: TerminationOOMKilled, ExitCode: 137}},
+		}}},
+	}
+	crashLooping := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp-1"},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			Name:                 "myapp",
+			State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: WaitingCrashLoopBackOff}},
+			LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Error", ExitCode: 127}},
+		}}},
+	}
+	cleanExit := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp-2"},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			Name:                 "myapp",
+			LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Completed"}},
+		}}},
+	}
+
+	crashes := ContainerCrashes([]corev1.Pod{oomKilled, crashLooping, cleanExit})
+	if len(crashes) != 2 {
+		t.Fatalf("expected 2 crashes, got %+v", crashes)
+	}
+	if !crashes[0].OOMKilled() || crashes[0].Process != "worker" || crashes[0].MemoryLimit != "256Mi" || crashes[0].Restarts != 4 {
+		t.Errorf("unexpected OOM kill %+v", crashes[0])
+	}
+	if crashes[1].OOMKilled() || !crashes[1].CrashLooping || crashes[1].Process != ProcessTypeWeb || crashes[1].ExitCode != 127 {
+		t.Errorf("unexpected crash loop %+v", crashes[1])
+	}
+}
+
+func TestListCrashes(t *testing.T) {
+	clientset := fake.NewClientset(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp-1", Namespace: "fuego-myapp", Labels: map[string]string{"app.kubernetes.io/name": "myapp"}},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			Name:  "myapp",
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: TerminationOOMKilled, ExitCode: 137}},
+		}}},
+	})
+	client := NewClientWithInterface(clientset, "fuego-")
+
+	crashes, err := client.ListCrashes(context.Background(), "myapp")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(crashes) != 1 || crashes[0].Pod != "myapp-1" {
+		t.Errorf("unexpected crashes %+v", crashes)
+	}
+}
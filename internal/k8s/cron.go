@@ -0,0 +1,391 @@
+package k8s
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Cron concurrency policies, mirroring batchv1.ConcurrencyPolicy
+const (
+	CronConcurrencyAllow   = "allow"
+	CronConcurrencyForbid  = "forbid"
+	CronConcurrencyReplace = "replace"
+)
+
+// Cron run statuses
+const (
+	CronRunPending   = "pending"
+	CronRunRunning   = "running"
+	CronRunSucceeded = "succeeded"
+	CronRunFailed    = "failed"
+)
+
+// CronJobConfig describes a scheduled command for an app
+type CronJobConfig struct {
+	Name                    string
+	Schedule                string
+	Command                 string
+	TimeZone                string
+	ConcurrencyPolicy       string
+	StartingDeadlineSeconds *int64
+	ActiveDeadlineSeconds   *int64
+	SuccessfulHistoryLimit  int32
+	FailedHistoryLimit      int32
+	Suspended               bool
+}
+
+// CronRun describes a single execution of a cron job
+type CronRun struct {
+	JobName    string     `json:"job_name"`
+	Status     string     `json:"status"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Logs       []LogLine  `json:"logs,omitempty"`
+}
+
+// Cron job limits
+const (
+	// MaxCronJobNameLength is the Kubernetes limit for CronJob names, which
+	// leaves room for the suffix added to the Jobs it spawns
+	MaxCronJobNameLength = 52
+	// MaxCronHistoryLimit caps how many finished runs are retained per outcome
+	MaxCronHistoryLimit = 50
+	// MinCronStartingDeadline is the smallest useful deadline, as the CronJob
+	// controller only checks schedules every 10 seconds
+	MinCronStartingDeadline = 10
+)
+
+var (
+	cronNameRegex  = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+	cronFieldRegex = regexp.MustCompile(`^[0-9A-Za-z*/,?-]+$`)
+)
+
+var cronDescriptors = map[string]bool{
+	"@yearly":   true,
+	"@annually": true,
+	"@monthly":  true,
+	"@weekly":   true,
+	"@daily":    true,
+	"@midnight": true,
+	"@hourly":   true,
+}
+
+// ValidateCronJob checks a cron job configuration for an app
+func ValidateCronJob(appName string, cron *CronJobConfig) error {
+	if !cronNameRegex.MatchString(cron.Name) {
+		return errors.New("name must contain only lowercase letters, numbers, and hyphens")
+	}
+
+	if len(CronJobResourceName(appName, cron.Name)) > MaxCronJobNameLength {
+		return fmt.Errorf("name is too long, app and cron names together must be at most %d characters", MaxCronJobNameLength-len("-cron-"))
+	}
+
+	if err := ValidateCronSchedule(cron.Schedule); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(cron.Command) == "" {
+		return errors.New("command is required")
+	}
+
+	if cron.TimeZone != "" {
+		if _, err := time.LoadLocation(cron.TimeZone); err != nil {
+			return fmt.Errorf("unknown timezone %q", cron.TimeZone)
+		}
+	}
+
+	switch cron.ConcurrencyPolicy {
+	case "", CronConcurrencyAllow, CronConcurrencyForbid, CronConcurrencyReplace:
+	default:
+		return errors.New("concurrency_policy must be one of allow, forbid, replace")
+	}
+
+	if cron.StartingDeadlineSeconds != nil && *cron.StartingDeadlineSeconds < MinCronStartingDeadline {
+		return fmt.Errorf("starting_deadline_seconds must be at least %d", MinCronStartingDeadline)
+	}
+
+	if cron.ActiveDeadlineSeconds != nil && *cron.ActiveDeadlineSeconds <= 0 {
+		return errors.New("active_deadline_seconds must be positive")
+	}
+
+	if cron.SuccessfulHistoryLimit < 0 || cron.SuccessfulHistoryLimit > MaxCronHistoryLimit ||
+		cron.FailedHistoryLimit < 0 || cron.FailedHistoryLimit > MaxCronHistoryLimit {
+		return fmt.Errorf("history limits must be between 0 and %d", MaxCronHistoryLimit)
+	}
+
+	return nil
+}
+
+// ValidateCronSchedule checks a standard five field schedule or a descriptor
+// such as @daily. Timezones are set separately, so CRON_TZ prefixes are rejected.
+func ValidateCronSchedule(schedule string) error {
+	schedule = strings.TrimSpace(schedule)
+	if schedule == "" {
+		return errors.New("schedule is required")
+	}
+
+	if strings.Contains(schedule, "TZ=") {
+		return errors.New("schedule must not contain a timezone, use the timezone field instead")
+	}
+
+	if strings.HasPrefix(schedule, "@") {
+		if !cronDescriptors[schedule] {
+			return fmt.Errorf("unknown schedule descriptor %q", schedule)
+		}
+		return nil
+	}
+
+	fields := strings.Fields(schedule)
+	if len(fields) != 5 {
+		return errors.New("schedule must have 5 fields: minute hour day-of-month month day-of-week")
+	}
+
+	for _, field := range fields {
+		if !cronFieldRegex.MatchString(field) {
+			return fmt.Errorf("invalid schedule field %q", field)
+		}
+	}
+
+	return nil
+}
+
+// CronJobResourceName returns the Kubernetes name of an app cron job
+func CronJobResourceName(appName, cronName string) string {
+	return appName + "-cron-" + cronName
+}
+
+func toConcurrencyPolicy(policy string) batchv1.ConcurrencyPolicy {
+	switch policy {
+	case CronConcurrencyForbid:
+		return batchv1.ForbidConcurrent
+	case CronConcurrencyReplace:
+		return batchv1.ReplaceConcurrent
+	default:
+		return batchv1.AllowConcurrent
+	}
+}
+
+// GenerateCronJob builds the CronJob for a scheduled command, running the app
+// image with the app env secret.
+func GenerateCronJob(cfg *AppConfig, cron *CronJobConfig) *batchv1.CronJob {
+	labels := appLabels(cfg, map[string]string{
+		"app.kubernetes.io/name":       cfg.Name,
+		"app.kubernetes.io/managed-by": "nexo-cloud",
+		"nexo.build/cron":              cron.Name,
+	})
+
+	backoffLimit := int32(0)
+	successful := cron.SuccessfulHistoryLimit
+	failed := cron.FailedHistoryLimit
+	suspend := cron.Suspended
+
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      CronJobResourceName(cfg.Name, cron.Name),
+			Namespace: cfg.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:                   cron.Schedule,
+			ConcurrencyPolicy:          toConcurrencyPolicy(cron.ConcurrencyPolicy),
+			StartingDeadlineSeconds:    cron.StartingDeadlineSeconds,
+			SuccessfulJobsHistoryLimit: &successful,
+			FailedJobsHistoryLimit:     &failed,
+			Suspend:                    &suspend,
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: batchv1.JobSpec{
+					BackoffLimit:          &backoffLimit,
+					ActiveDeadlineSeconds: cron.ActiveDeadlineSeconds,
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: labels,
+						},
+						Spec: corev1.PodSpec{
+							RestartPolicy: corev1.RestartPolicyNever,
+							Containers: []corev1.Container{
+								{
+									Name:    "cron",
+									Image:   cfg.Image,
+									Command: []string{"/bin/sh", "-c", cron.Command},
+									EnvFrom: []corev1.EnvFromSource{
+										{
+											SecretRef: &corev1.SecretEnvSource{
+												LocalObjectReference: corev1.LocalObjectReference{
+													Name: cfg.Name + "-env",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if cron.TimeZone != "" {
+		tz := cron.TimeZone
+		cronJob.Spec.TimeZone = &tz
+	}
+
+	cfg.Placement.applyTo(&cronJob.Spec.JobTemplate.Spec.Template.Spec)
+	applyArchitectures(&cronJob.Spec.JobTemplate.Spec.Template.Spec, cfg.Architectures)
+	applyPoolers(&cronJob.Spec.JobTemplate.Spec.Template.Spec, cfg)
+	applyImagePullSecret(&cronJob.Spec.JobTemplate.Spec.Template.Spec, cfg)
+
+	return cronJob
+}
+
+// ApplyCronJob creates or updates an app cron job
+func (c *Client) ApplyCronJob(ctx context.Context, cfg *AppConfig, cron *CronJobConfig) error {
+	cfg.Namespace = c.NamespaceForApp(cfg.Name)
+	cronJobs := c.clientset.BatchV1().CronJobs(cfg.Namespace)
+
+	return retryOnConflict(func() error {
+		cronJob := GenerateCronJob(cfg, cron)
+
+		existing, err := cronJobs.Get(ctx, cronJob.Name, metav1.GetOptions{})
+		if err == nil {
+			cronJob.ResourceVersion = existing.ResourceVersion
+			_, err = cronJobs.Update(ctx, cronJob, metav1.UpdateOptions{})
+			return err
+		}
+
+		if k8serrors.IsNotFound(err) {
+			_, err = cronJobs.Create(ctx, cronJob, metav1.CreateOptions{})
+			return err
+		}
+
+		return err
+	})
+}
+
+// DeleteCronJob removes an app cron job and its remaining runs
+func (c *Client) DeleteCronJob(ctx context.Context, appName, cronName string) error {
+	namespace := c.NamespaceForApp(appName)
+	propagation := metav1.DeletePropagationBackground
+
+	err := c.clientset.BatchV1().CronJobs(namespace).Delete(ctx, CronJobResourceName(appName, cronName), metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+// ListCronRuns returns the retained runs of a cron job, newest first.
+// When logLines is positive the tail of each run's pod log is included.
+func (c *Client) ListCronRuns(ctx context.Context, appName, cronName string, logLines int64) ([]CronRun, error) {
+	namespace := c.NamespaceForApp(appName)
+
+	jobs, err := c.clientset.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("app.kubernetes.io/name=%s,nexo.build/cron=%s", appName, cronName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cron runs: %w", err)
+	}
+
+	runs := make([]CronRun, 0, len(jobs.Items))
+	for _, job := range jobs.Items {
+		run := CronRun{
+			JobName: job.Name,
+			Status:  cronRunStatus(&job),
+		}
+
+		if job.Status.StartTime != nil {
+			started := job.Status.StartTime.Time
+			run.StartedAt = &started
+		}
+
+		if job.Status.CompletionTime != nil {
+			finished := job.Status.CompletionTime.Time
+			run.FinishedAt = &finished
+		}
+
+		if logLines > 0 {
+			run.Logs = c.jobLogs(ctx, namespace, job.Name, logLines)
+		}
+
+		runs = append(runs, run)
+	}
+
+	sort.Slice(runs, func(i, j int) bool {
+		if runs[i].StartedAt == nil || runs[j].StartedAt == nil {
+			return runs[j].StartedAt == nil
+		}
+		return runs[i].StartedAt.After(*runs[j].StartedAt)
+	})
+
+	return runs, nil
+}
+
+func cronRunStatus(job *batchv1.Job) string {
+	for _, cond := range job.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case batchv1.JobComplete:
+			return CronRunSucceeded
+		case batchv1.JobFailed:
+			return CronRunFailed
+		}
+	}
+
+	if job.Status.Active > 0 {
+		return CronRunRunning
+	}
+
+	return CronRunPending
+}
+
+// jobLogs reads the tail of the logs of every pod belonging to a job
+func (c *Client) jobLogs(ctx context.Context, namespace, jobName string, tailLines int64) []LogLine {
+	pods, err := c.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("job-name=%s", jobName),
+	})
+	if err != nil {
+		return nil
+	}
+
+	var logs []LogLine
+	for _, pod := range pods.Items {
+		req := c.clientset.CoreV1().Pods(namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+			TailLines:  &tailLines,
+			Timestamps: true,
+		})
+		stream, err := req.Stream(ctx)
+		if err != nil {
+			continue
+		}
+
+		scanner := bufio.NewScanner(stream)
+		for scanner.Scan() {
+			logs = append(logs, LogLine{
+				Pod:     pod.Name,
+				Message: scanner.Text(),
+			})
+		}
+		_ = stream.Close()
+	}
+
+	return logs
+}
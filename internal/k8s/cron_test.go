@@ -0,0 +1,195 @@
+package k8s
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGenerateCronJob(t *testing.T) {
+	cfg := &AppConfig{
+		Name:      "myapp",
+		Namespace: "fuego-myapp",
+		Image:     "ghcr.io/user/myapp:v2",
+	}
+	deadline := int64(120)
+
+	cronJob := GenerateCronJob(cfg, &CronJobConfig{
+		Name:                    "cleanup",
+		Schedule:                "0 3 * * *",
+		Command:                 "bin/cleanup",
+		TimeZone:                "Europe/Berlin",
+		ConcurrencyPolicy:       CronConcurrencyForbid,
+		StartingDeadlineSeconds: &deadline,
+		SuccessfulHistoryLimit:  5,
+		FailedHistoryLimit:      2,
+	})
+
+	if cronJob.Name != "myapp-cron-cleanup" {
+		t.Errorf("expected name 'myapp-cron-cleanup', got %q", cronJob.Name)
+	}
+
+	if cronJob.Spec.TimeZone == nil || *cronJob.Spec.TimeZone != "Europe/Berlin" {
+		t.Errorf("expected timezone 'Europe/Berlin', got %v", cronJob.Spec.TimeZone)
+	}
+
+	if cronJob.Spec.ConcurrencyPolicy != batchv1.ForbidConcurrent {
+		t.Errorf("expected Forbid concurrency policy, got %v", cronJob.Spec.ConcurrencyPolicy)
+	}
+
+	if *cronJob.Spec.StartingDeadlineSeconds != 120 {
+		t.Errorf("expected starting deadline 120, got %d", *cronJob.Spec.StartingDeadlineSeconds)
+	}
+
+	if *cronJob.Spec.SuccessfulJobsHistoryLimit != 5 || *cronJob.Spec.FailedJobsHistoryLimit != 2 {
+		t.Errorf("unexpected history limits %d/%d", *cronJob.Spec.SuccessfulJobsHistoryLimit, *cronJob.Spec.FailedJobsHistoryLimit)
+	}
+
+	container := cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0]
+	if container.Image != "ghcr.io/user/myapp:v2" {
+		t.Errorf("expected cron to use app image, got %q", container.Image)
+	}
+
+	if strings.Join(container.Command, " ") != "/bin/sh -c bin/cleanup" {
+		t.Errorf("unexpected command %v", container.Command)
+	}
+
+	if cronJob.Spec.JobTemplate.Spec.Template.Labels["nexo.build/cron"] != "cleanup" {
+		t.Errorf("expected cron label on pods, got %v", cronJob.Spec.JobTemplate.Spec.Template.Labels)
+	}
+}
+
+func TestGenerateCronJob_Defaults(t *testing.T) {
+	cfg := &AppConfig{Name: "myapp", Namespace: "fuego-myapp", Image: "myapp:v1"}
+
+	cronJob := GenerateCronJob(cfg, &CronJobConfig{Name: "tick", Schedule: "@hourly", Command: "true"})
+
+	if cronJob.Spec.TimeZone != nil {
+		t.Errorf("expected no timezone, got %q", *cronJob.Spec.TimeZone)
+	}
+
+	if cronJob.Spec.ConcurrencyPolicy != batchv1.AllowConcurrent {
+		t.Errorf("expected Allow concurrency policy, got %v", cronJob.Spec.ConcurrencyPolicy)
+	}
+}
+
+func TestValidateCronJob(t *testing.T) {
+	short := int64(5)
+
+	tests := []struct {
+		name    string
+		cron    CronJobConfig
+		wantErr bool
+	}{
+		{"valid", CronJobConfig{Name: "cleanup", Schedule: "*/15 * * * *", Command: "bin/cleanup", TimeZone: "America/New_York"}, false},
+		{"descriptor", CronJobConfig{Name: "cleanup", Schedule: "@daily", Command: "bin/cleanup"}, false},
+		{"bad name", CronJobConfig{Name: "Clean_Up", Schedule: "@daily", Command: "x"}, true},
+		{"long name", CronJobConfig{Name: strings.Repeat("a", 50), Schedule: "@daily", Command: "x"}, true},
+		{"four fields", CronJobConfig{Name: "a", Schedule: "* * * *", Command: "x"}, true},
+		{"tz prefix", CronJobConfig{Name: "a", Schedule: "CRON_TZ=UTC 0 * * * *", Command: "x"}, true},
+		{"unknown descriptor", CronJobConfig{Name: "a", Schedule: "@sometimes", Command: "x"}, true},
+		{"no command", CronJobConfig{Name: "a", Schedule: "@daily"}, true},
+		{"bad timezone", CronJobConfig{Name: "a", Schedule: "@daily", Command: "x", TimeZone: "Mars/Olympus"}, true},
+		{"bad policy", CronJobConfig{Name: "a", Schedule: "@daily", Command: "x", ConcurrencyPolicy: "queue"}, true},
+		{"short deadline", CronJobConfig{Name: "a", Schedule: "@daily", Command: "x", StartingDeadlineSeconds: &short}, true},
+		{"history too large", CronJobConfig{Name: "a", Schedule: "@daily", Command: "x", SuccessfulHistoryLimit: 51}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCronJob("myapp", &tt.cron)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCronJob() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyCronJob(t *testing.T) {
+	fakeClient := fake.NewClientset()
+	client := NewClientWithInterface(fakeClient, "fuego-")
+	cfg := &AppConfig{Name: "myapp", Image: "myapp:v1"}
+	cron := &CronJobConfig{Name: "cleanup", Schedule: "@daily", Command: "bin/cleanup"}
+
+	if err := client.ApplyCronJob(context.Background(), cfg, cron); err != nil {
+		t.Fatalf("unexpected error creating cron job: %v", err)
+	}
+
+	cron.Schedule = "@hourly"
+	cfg.Image = "myapp:v2"
+	if err := client.ApplyCronJob(context.Background(), cfg, cron); err != nil {
+		t.Fatalf("unexpected error updating cron job: %v", err)
+	}
+
+	cronJob, err := fakeClient.BatchV1().CronJobs("fuego-myapp").Get(context.Background(), "myapp-cron-cleanup", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected cron job to exist: %v", err)
+	}
+
+	if cronJob.Spec.Schedule != "@hourly" {
+		t.Errorf("expected schedule to be updated, got %q", cronJob.Spec.Schedule)
+	}
+
+	if image := cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Image; image != "myapp:v2" {
+		t.Errorf("expected image to be updated, got %q", image)
+	}
+
+	if err := client.DeleteCronJob(context.Background(), "myapp", "cleanup"); err != nil {
+		t.Fatalf("unexpected error deleting cron job: %v", err)
+	}
+
+	if err := client.DeleteCronJob(context.Background(), "myapp", "cleanup"); err != nil {
+		t.Errorf("expected deleting a missing cron job to succeed, got %v", err)
+	}
+}
+
+func TestListCronRuns(t *testing.T) {
+	labels := map[string]string{
+		"app.kubernetes.io/name": "myapp",
+		"nexo.build/cron":        "cleanup",
+	}
+	older := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	newer := metav1.NewTime(time.Now().Add(-1 * time.Hour))
+
+	fakeClient := fake.NewClientset(
+		&batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "myapp-cron-cleanup-1", Namespace: "fuego-myapp", Labels: labels},
+			Status: batchv1.JobStatus{
+				StartTime:      &older,
+				CompletionTime: &older,
+				Conditions:     []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}},
+			},
+		},
+		&batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "myapp-cron-cleanup-2", Namespace: "fuego-myapp", Labels: labels},
+			Status: batchv1.JobStatus{
+				StartTime:  &newer,
+				Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}},
+			},
+		},
+	)
+	client := NewClientWithInterface(fakeClient, "fuego-")
+
+	runs, err := client.ListCronRuns(context.Background(), "myapp", "cleanup", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(runs) != 2 {
+		t.Fatalf("expected 2 runs, got %d", len(runs))
+	}
+
+	if runs[0].JobName != "myapp-cron-cleanup-2" || runs[0].Status != CronRunFailed {
+		t.Errorf("expected newest failed run first, got %+v", runs[0])
+	}
+
+	if runs[1].Status != CronRunSucceeded || runs[1].FinishedAt == nil {
+		t.Errorf("expected older succeeded run with finish time, got %+v", runs[1])
+	}
+}
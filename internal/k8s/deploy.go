@@ -2,7 +2,9 @@ package k8s
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -14,149 +16,343 @@ import (
 )
 
 type DeployResult struct {
-	Success   bool   `json:"success"`
-	Message   string `json:"message"`
-	Namespace string `json:"namespace"`
-	URL       string `json:"url"`
+	Success   bool          `json:"success"`
+	Message   string        `json:"message"`
+	Reason    FailureReason `json:"reason,omitempty"`
+	Namespace string        `json:"namespace"`
+	URL       string        `json:"url"`
+	Hooks     []*HookResult `json:"hooks,omitempty"`
 }
 
+// Deploy applies an app and waits for its rollout. A rollout failing after
+// the pre-deploy hook returns the hooks that ran alongside the error.
 func (c *Client) Deploy(ctx context.Context, cfg *AppConfig) (*DeployResult, error) {
 	cfg.Namespace = c.NamespaceForApp(cfg.Name)
 
 	if err := c.ensureNamespace(ctx, cfg); err != nil {
-		return nil, fmt.Errorf("failed to create namespace: %w", err)
+		return nil, TranslateError("create namespace", err)
 	}
 
-	if err := c.applySecret(ctx, cfg); err != nil {
-		return nil, fmt.Errorf("failed to apply secret: %w", err)
+	// The env Secret of the running pods changes once the pre-deploy hook
+	// succeeded; the hook runs with its own copy
+	if err := c.applyPoolerSecret(ctx, cfg); err != nil {
+		return nil, TranslateError("apply secret", err)
+	}
+	if err := c.applyPullSecret(ctx, cfg); err != nil {
+		return nil, TranslateError("apply secret", err)
+	}
+
+	// The collector starts ahead of the app, which sends it telemetry on boot
+	if cfg.OTelCollector != nil {
+		if err := c.applyOTelCollector(ctx, cfg); err != nil {
+			return nil, TranslateError("apply otel collector", err)
+		}
+	} else if err := c.RemoveOTelCollector(ctx, cfg.Name); err != nil {
+		return nil, TranslateError("remove otel collector", err)
+	}
+
+	var hooks []*HookResult
+	// failed keeps the hooks that ran on the result of a failed rollout
+	failed := func(err error) (*DeployResult, error) {
+		return &DeployResult{Namespace: cfg.Namespace, Hooks: hooks}, err
+	}
+
+	// Pre-deploy hook runs before the new version receives traffic; a failure aborts the rollout
+	preHook, err := c.RunHook(ctx, cfg, HookPhasePreDeploy)
+	if err != nil {
+		return failed(TranslateError("run pre-deploy hook", err))
+	}
+	if preHook != nil {
+		hooks = append(hooks, preHook)
+		if !preHook.Succeeded() {
+			return &DeployResult{
+				Success:   false,
+				Message:   fmt.Sprintf("pre-deploy hook %s, rollout aborted", preHook.Status),
+				Reason:    FailureHookFailed,
+				Namespace: cfg.Namespace,
+				Hooks:     hooks,
+			}, nil
+		}
+	}
+
+	if err := c.putSecret(ctx, GenerateSecret(cfg)); err != nil {
+		return failed(TranslateError("apply secret", err))
 	}
 
 	if err := c.applyDeployment(ctx, cfg); err != nil {
-		return nil, fmt.Errorf("failed to apply deployment: %w", err)
+		return failed(TranslateError("apply deployment", err))
+	}
+
+	if err := c.ApplyProcesses(ctx, cfg); err != nil {
+		return failed(TranslateError("apply processes", err))
 	}
 
 	if err := c.applyService(ctx, cfg); err != nil {
-		return nil, fmt.Errorf("failed to apply service: %w", err)
+		return failed(TranslateError("apply service", err))
+	}
+
+	if cfg.MTLS != nil {
+		if err := c.applyMTLS(ctx, cfg); err != nil {
+			return failed(TranslateError("apply mtls", err))
+		}
 	}
 
-	if err := c.applyIngress(ctx, cfg); err != nil {
-		return nil, fmt.Errorf("failed to apply ingress: %w", err)
+	if err := c.applyTLSPolicy(ctx, cfg); err != nil {
+		return failed(TranslateError("apply tls policy", err))
+	}
+
+	if cfg.Tunnel != nil {
+		if err := c.applyTunnel(ctx, cfg); err != nil {
+			return failed(TranslateError("apply tunnel", err))
+		}
+		if err := c.removeIngress(ctx, cfg); err != nil {
+			return failed(TranslateError("remove ingress", err))
+		}
+	} else {
+		if err := c.applyIngress(ctx, cfg); err != nil {
+			return failed(TranslateError("apply ingress", err))
+		}
+		if err := c.removeTunnel(ctx, cfg.Name); err != nil {
+			return failed(TranslateError("remove tunnel", err))
+		}
+	}
+
+	if err := c.pruneTLSPolicy(ctx, cfg); err != nil {
+		return failed(TranslateError("prune tls policy", err))
+	}
+
+	if cfg.MTLS == nil {
+		if err := c.removeMTLS(ctx, cfg); err != nil {
+			return failed(TranslateError("remove mtls", err))
+		}
+	}
+
+	if cfg.Mirror != nil {
+		if err := c.applyMirror(ctx, cfg); err != nil {
+			return failed(TranslateError("apply mirror", err))
+		}
+	} else if err := c.RemoveMirror(ctx, cfg.Name); err != nil {
+		return failed(TranslateError("remove mirror", err))
+	}
+
+	if cfg.Egress != nil {
+		if err := c.applyEgress(ctx, cfg); err != nil {
+			return failed(TranslateError("apply egress", err))
+		}
+	} else if err := c.RemoveEgress(ctx, cfg.Name); err != nil {
+		return failed(TranslateError("remove egress", err))
 	}
 
 	if err := c.waitForDeployment(ctx, cfg); err != nil {
+		reason, message := FailureNotReady, fmt.Sprintf("deployment did not become ready: %v", err)
+		var pullErr *DeployError
+		if errors.As(err, &pullErr) {
+			reason, message = pullErr.Reason, pullErr.Message
+		}
 		return &DeployResult{
 			Success:   false,
-			Message:   fmt.Sprintf("deployment did not become ready: %v", err),
+			Message:   message,
+			Reason:    reason,
 			Namespace: cfg.Namespace,
+			Hooks:     hooks,
 		}, nil
 	}
 
+	// Cron jobs follow the promoted image
+	for i := range cfg.CronJobs {
+		if err := c.ApplyCronJob(ctx, cfg, &cfg.CronJobs[i]); err != nil {
+			return failed(TranslateError("apply cron job "+cfg.CronJobs[i].Name, err))
+		}
+	}
+
 	url := fmt.Sprintf("https://%s.%s", cfg.Name, cfg.DomainSuffix)
 	if cfg.Domain != "" {
 		url = fmt.Sprintf("https://%s", cfg.Domain)
 	}
 
+	// Post-deploy hook runs after promotion; the new version stays live if it fails
+	postHook, err := c.RunHook(ctx, cfg, HookPhasePostDeploy)
+	if err != nil {
+		return failed(TranslateError("run post-deploy hook", err))
+	}
+	if postHook != nil {
+		hooks = append(hooks, postHook)
+		if !postHook.Succeeded() {
+			return &DeployResult{
+				Success:   false,
+				Message:   fmt.Sprintf("deployment is live but post-deploy hook %s", postHook.Status),
+				Reason:    FailureHookFailed,
+				Namespace: cfg.Namespace,
+				URL:       url,
+				Hooks:     hooks,
+			}, nil
+		}
+	}
+
 	return &DeployResult{
 		Success:   true,
 		Message:   "deployment successful",
 		Namespace: cfg.Namespace,
 		URL:       url,
+		Hooks:     hooks,
 	}, nil
 }
 
 func (c *Client) ensureNamespace(ctx context.Context, cfg *AppConfig) error {
 	ns := GenerateNamespace(cfg)
 
-	_, err := c.clientset.CoreV1().Namespaces().Get(ctx, ns.Name, metav1.GetOptions{})
+	existing, err := c.clientset.CoreV1().Namespaces().Get(ctx, ns.Name, metav1.GetOptions{})
 	if err == nil {
-		return nil
+		// Keep the namespace labels in step with the app's labels, leaving
+		// those Kubernetes sets such as kubernetes.io/metadata.name
+		labels := maps.Clone(ns.Labels)
+		for key, value := range existing.Labels {
+			if isReservedLabel(key) {
+				labels[key] = value
+			}
+		}
+		if maps.Equal(existing.Labels, labels) {
+			return nil
+		}
+		return retryOnConflict(func() error {
+			existing, err := c.clientset.CoreV1().Namespaces().Get(ctx, ns.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			existing.Labels = labels
+			_, err = c.clientset.CoreV1().Namespaces().Update(ctx, existing, metav1.UpdateOptions{})
+			return err
+		})
 	}
 
 	if k8serrors.IsNotFound(err) {
 		_, err = c.clientset.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{})
+		// Another deploy of the same app may have created it in the meantime
+		if k8serrors.IsAlreadyExists(err) {
+			return nil
+		}
 		return err
 	}
 
 	return err
 }
 
-func (c *Client) applySecret(ctx context.Context, cfg *AppConfig) error {
-	secret := GenerateSecret(cfg)
-	secrets := c.clientset.CoreV1().Secrets(cfg.Namespace)
+// The apply helpers below read the current object and then write it back.
+// Controllers and concurrent deploys can bump the resourceVersion in between,
+// so the whole read-then-write is retried on conflicts. Re-running Deploy
+// after a partial failure converges on the same state.
 
-	existing, err := secrets.Get(ctx, secret.Name, metav1.GetOptions{})
-	if err == nil {
-		secret.ResourceVersion = existing.ResourceVersion
-		_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+func (c *Client) applySecret(ctx context.Context, cfg *AppConfig) error {
+	if err := c.putSecret(ctx, GenerateSecret(cfg)); err != nil {
 		return err
 	}
-
-	if k8serrors.IsNotFound(err) {
-		_, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+	if err := c.applyPoolerSecret(ctx, cfg); err != nil {
 		return err
 	}
+	return c.applyPullSecret(ctx, cfg)
+}
 
-	return err
+// putSecret creates a secret or replaces the one of the same name
+func (c *Client) putSecret(ctx context.Context, secret *corev1.Secret) error {
+	secrets := c.clientset.CoreV1().Secrets(secret.Namespace)
+
+	return retryOnConflict(func() error {
+		existing, err := secrets.Get(ctx, secret.Name, metav1.GetOptions{})
+		if err == nil {
+			secret.ResourceVersion = existing.ResourceVersion
+			_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+			return err
+		}
+
+		if k8serrors.IsNotFound(err) {
+			_, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+			return err
+		}
+
+		return err
+	})
 }
 
 func (c *Client) applyDeployment(ctx context.Context, cfg *AppConfig) error {
-	deployment := GenerateDeployment(cfg)
 	deployments := c.clientset.AppsV1().Deployments(cfg.Namespace)
 
-	existing, err := deployments.Get(ctx, deployment.Name, metav1.GetOptions{})
-	if err == nil {
-		deployment.ResourceVersion = existing.ResourceVersion
-		_, err = deployments.Update(ctx, deployment, metav1.UpdateOptions{})
-		return err
-	}
+	return retryOnConflict(func() error {
+		deployment := GenerateDeployment(cfg)
 
-	if k8serrors.IsNotFound(err) {
-		_, err = deployments.Create(ctx, deployment, metav1.CreateOptions{})
-		return err
-	}
+		existing, err := deployments.Get(ctx, deployment.Name, metav1.GetOptions{})
+		if err == nil {
+			deployment.ResourceVersion = existing.ResourceVersion
+			_, err = deployments.Update(ctx, deployment, metav1.UpdateOptions{})
+			return err
+		}
 
-	return err
+		if k8serrors.IsNotFound(err) {
+			_, err = deployments.Create(ctx, deployment, metav1.CreateOptions{})
+			return err
+		}
+
+		return err
+	})
 }
 
 func (c *Client) applyService(ctx context.Context, cfg *AppConfig) error {
-	service := GenerateService(cfg)
 	services := c.clientset.CoreV1().Services(cfg.Namespace)
 
-	existing, err := services.Get(ctx, service.Name, metav1.GetOptions{})
-	if err == nil {
-		service.ResourceVersion = existing.ResourceVersion
-		service.Spec.ClusterIP = existing.Spec.ClusterIP
-		_, err = services.Update(ctx, service, metav1.UpdateOptions{})
-		return err
-	}
+	return retryOnConflict(func() error {
+		service := GenerateService(cfg)
+
+		existing, err := services.Get(ctx, service.Name, metav1.GetOptions{})
+		if err == nil {
+			service.ResourceVersion = existing.ResourceVersion
+			service.Spec.ClusterIP = existing.Spec.ClusterIP
+			// The primary family is immutable: dual stack adds the other
+			// one and going back to single stack drops it
+			service.Spec.ClusterIPs = existing.Spec.ClusterIPs
+			service.Spec.IPFamilies = existing.Spec.IPFamilies
+			if !cfg.DualStack && len(service.Spec.ClusterIPs) > 1 {
+				service.Spec.ClusterIPs = service.Spec.ClusterIPs[:1]
+			}
+			if !cfg.DualStack && len(service.Spec.IPFamilies) > 1 {
+				service.Spec.IPFamilies = service.Spec.IPFamilies[:1]
+			}
+			_, err = services.Update(ctx, service, metav1.UpdateOptions{})
+			return err
+		}
 
-	if k8serrors.IsNotFound(err) {
-		_, err = services.Create(ctx, service, metav1.CreateOptions{})
-		return err
-	}
+		if k8serrors.IsNotFound(err) {
+			_, err = services.Create(ctx, service, metav1.CreateOptions{})
+			return err
+		}
 
-	return err
+		return err
+	})
 }
 
 func (c *Client) applyIngress(ctx context.Context, cfg *AppConfig) error {
-	ingress := GenerateIngress(cfg)
 	ingresses := c.clientset.NetworkingV1().Ingresses(cfg.Namespace)
 
-	existing, err := ingresses.Get(ctx, ingress.Name, metav1.GetOptions{})
-	if err == nil {
-		ingress.ResourceVersion = existing.ResourceVersion
-		_, err = ingresses.Update(ctx, ingress, metav1.UpdateOptions{})
-		return err
-	}
+	return retryOnConflict(func() error {
+		ingress := GenerateIngress(cfg)
 
-	if k8serrors.IsNotFound(err) {
-		_, err = ingresses.Create(ctx, ingress, metav1.CreateOptions{})
-		return err
-	}
+		existing, err := ingresses.Get(ctx, ingress.Name, metav1.GetOptions{})
+		if err == nil {
+			ingress.ResourceVersion = existing.ResourceVersion
+			_, err = ingresses.Update(ctx, ingress, metav1.UpdateOptions{})
+			return err
+		}
 
-	return err
+		if k8serrors.IsNotFound(err) {
+			_, err = ingresses.Create(ctx, ingress, metav1.CreateOptions{})
+			return err
+		}
+
+		return err
+	})
 }
 
+// waitForDeployment polls until the web Deployment is ready. A pod stuck
+// pulling its image fails the wait right away with the classified cause
+// instead of running into the timeout.
 func (c *Client) waitForDeployment(ctx context.Context, cfg *AppConfig) error {
 	return wait.PollUntilContextTimeout(ctx, 2*time.Second, 5*time.Minute, true, func(ctx context.Context) (bool, error) {
 		deployment, err := c.clientset.AppsV1().Deployments(cfg.Namespace).Get(ctx, cfg.Name, metav1.GetOptions{})
@@ -168,11 +364,20 @@ func (c *Client) waitForDeployment(ctx context.Context, cfg *AppConfig) error {
 			return true, nil
 		}
 
+		if pullErr, err := c.imagePullFailure(ctx, cfg); err == nil && pullErr != nil {
+			return false, pullErr
+		}
+
 		return false, nil
 	})
 }
 
 func (c *Client) DeleteApp(ctx context.Context, appName string) error {
+	// The egress policy is cluster-scoped and outlives the namespace
+	if err := c.RemoveEgress(ctx, appName); err != nil {
+		return err
+	}
+
 	namespace := c.NamespaceForApp(appName)
 	return c.clientset.CoreV1().Namespaces().Delete(ctx, namespace, metav1.DeleteOptions{})
 }
@@ -199,43 +404,66 @@ func (c *Client) RestartApp(ctx context.Context, appName string) error {
 	namespace := c.NamespaceForApp(appName)
 	deployments := c.clientset.AppsV1().Deployments(namespace)
 
-	deployment, err := deployments.Get(ctx, appName, metav1.GetOptions{})
-	if err != nil {
-		return fmt.Errorf("failed to get deployment: %w", err)
-	}
+	restartedAt := time.Now().Format(time.RFC3339)
 
-	// Add/update restart annotation to trigger rolling restart
-	if deployment.Spec.Template.Annotations == nil {
-		deployment.Spec.Template.Annotations = make(map[string]string)
-	}
-	deployment.Spec.Template.Annotations["kubectl.kubernetes.io/restartedAt"] = time.Now().Format(time.RFC3339)
+	return retryOnConflict(func() error {
+		deployment, err := deployments.Get(ctx, appName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get deployment: %w", err)
+		}
 
-	_, err = deployments.Update(ctx, deployment, metav1.UpdateOptions{})
-	if err != nil {
-		return fmt.Errorf("failed to update deployment: %w", err)
-	}
+		// Add/update restart annotation to trigger rolling restart
+		if deployment.Spec.Template.Annotations == nil {
+			deployment.Spec.Template.Annotations = make(map[string]string)
+		}
+		deployment.Spec.Template.Annotations["kubectl.kubernetes.io/restartedAt"] = restartedAt
+
+		_, err = deployments.Update(ctx, deployment, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to update deployment: %w", err)
+		}
 
-	return nil
+		return nil
+	})
 }
 
-// ScaleApp scales the deployment to the specified number of replicas
+// ScaleApp scales the web deployment to the specified number of replicas
 func (c *Client) ScaleApp(ctx context.Context, appName string, replicas int32) error {
-	namespace := c.NamespaceForApp(appName)
-	deployments := c.clientset.AppsV1().Deployments(namespace)
+	return c.ScaleProcess(ctx, appName, ProcessTypeWeb, replicas)
+}
 
-	deployment, err := deployments.Get(ctx, appName, metav1.GetOptions{})
+// ProcessReplicas returns the replicas the deployment of a process type is scaled to
+func (c *Client) ProcessReplicas(ctx context.Context, appName, processType string) (int32, error) {
+	deployment, err := c.clientset.AppsV1().Deployments(c.NamespaceForApp(appName)).Get(ctx, ProcessDeploymentName(appName, processType), metav1.GetOptions{})
 	if err != nil {
-		return fmt.Errorf("failed to get deployment: %w", err)
+		return 0, fmt.Errorf("failed to get deployment: %w", err)
+	}
+	if deployment.Spec.Replicas == nil {
+		return 1, nil
 	}
+	return *deployment.Spec.Replicas, nil
+}
 
-	deployment.Spec.Replicas = &replicas
+// ScaleProcess scales the deployment of a process type to the specified number of replicas
+func (c *Client) ScaleProcess(ctx context.Context, appName, processType string, replicas int32) error {
+	namespace := c.NamespaceForApp(appName)
+	deployments := c.clientset.AppsV1().Deployments(namespace)
 
-	_, err = deployments.Update(ctx, deployment, metav1.UpdateOptions{})
-	if err != nil {
-		return fmt.Errorf("failed to scale deployment: %w", err)
-	}
+	return retryOnConflict(func() error {
+		deployment, err := deployments.Get(ctx, ProcessDeploymentName(appName, processType), metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get deployment: %w", err)
+		}
+
+		deployment.Spec.Replicas = &replicas
+
+		_, err = deployments.Update(ctx, deployment, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to scale deployment: %w", err)
+		}
 
-	return nil
+		return nil
+	})
 }
 
 // GetAppStatus returns the current status of an app
@@ -315,6 +315,43 @@ func TestApplyService_WithFakeClient(t *testing.T) {
 	}
 }
 
+func TestApplyService_DualStack(t *testing.T) {
+	fakeClient := fake.NewClientset()
+	client := NewClientWithInterface(fakeClient, "test-")
+	ctx := context.Background()
+
+	cfg := &AppConfig{Name: "myapp", Namespace: "test-namespace", Port: 8080, DualStack: true}
+	existing := GenerateService(cfg)
+	existing.Spec.ClusterIP = "10.0.0.10"
+	existing.Spec.ClusterIPs = []string{"10.0.0.10", "fd00::10"}
+	existing.Spec.IPFamilies = []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol}
+	if _, err := fakeClient.CoreV1().Services("test-namespace").Create(ctx, existing, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("failed to seed service: %v", err)
+	}
+
+	// Re-applying keeps both cluster IPs
+	if err := client.applyService(ctx, cfg); err != nil {
+		t.Fatalf("applyService failed: %v", err)
+	}
+	service, _ := fakeClient.CoreV1().Services("test-namespace").Get(ctx, "myapp", metav1.GetOptions{})
+	if len(service.Spec.ClusterIPs) != 2 || len(service.Spec.IPFamilies) != 2 {
+		t.Errorf("expected both families to be kept, got %v %v", service.Spec.ClusterIPs, service.Spec.IPFamilies)
+	}
+
+	// Going back to single stack drops the secondary family
+	cfg.DualStack = false
+	if err := client.applyService(ctx, cfg); err != nil {
+		t.Fatalf("applyService failed: %v", err)
+	}
+	service, _ = fakeClient.CoreV1().Services("test-namespace").Get(ctx, "myapp", metav1.GetOptions{})
+	if len(service.Spec.ClusterIPs) != 1 || service.Spec.ClusterIPs[0] != "10.0.0.10" || len(service.Spec.IPFamilies) != 1 {
+		t.Errorf("expected only the primary family, got %v %v", service.Spec.ClusterIPs, service.Spec.IPFamilies)
+	}
+	if *service.Spec.IPFamilyPolicy != corev1.IPFamilyPolicySingleStack {
+		t.Errorf("expected single stack, got %v", *service.Spec.IPFamilyPolicy)
+	}
+}
+
 func TestApplyIngress_WithFakeClient(t *testing.T) {
 	fakeClient := fake.NewClientset()
 	client := NewClientWithInterface(fakeClient, "test-")
@@ -447,12 +484,53 @@ func TestScaleApp_WithFakeClient(t *testing.T) {
 	}
 
 	// Verify scale
+	if replicas, err := client.ProcessReplicas(ctx, "myapp", ProcessTypeWeb); err != nil || replicas != 5 {
+		t.Errorf("expected 5 replicas, got %d (%v)", replicas, err)
+	}
 	deployment, _ := fakeClient.AppsV1().Deployments("test-myapp").Get(ctx, "myapp", metav1.GetOptions{})
 	if *deployment.Spec.Replicas != 5 {
 		t.Errorf("expected 5 replicas, got %d", *deployment.Spec.Replicas)
 	}
 }
 
+func TestScaleProcess_WithFakeClient(t *testing.T) {
+	fakeClient := fake.NewClientset()
+	client := NewClientWithInterface(fakeClient, "test-")
+
+	ctx := context.Background()
+
+	replicas := int32(1)
+	for _, name := range []string{"myapp", "myapp-worker"} {
+		_, err := fakeClient.AppsV1().Deployments("test-myapp").Create(ctx, &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			t.Fatalf("failed to create deployment: %v", err)
+		}
+	}
+
+	if err := client.ScaleProcess(ctx, "myapp", "worker", 3); err != nil {
+		t.Fatalf("ScaleProcess failed: %v", err)
+	}
+
+	worker, _ := fakeClient.AppsV1().Deployments("test-myapp").Get(ctx, "myapp-worker", metav1.GetOptions{})
+	if *worker.Spec.Replicas != 3 {
+		t.Errorf("expected worker to have 3 replicas, got %d", *worker.Spec.Replicas)
+	}
+
+	web, _ := fakeClient.AppsV1().Deployments("test-myapp").Get(ctx, "myapp", metav1.GetOptions{})
+	if *web.Spec.Replicas != 1 {
+		t.Errorf("expected web to be unchanged, got %d replicas", *web.Spec.Replicas)
+	}
+
+	if err := client.ScaleProcess(ctx, "myapp", "scheduler", 1); err == nil {
+		t.Error("expected error scaling a process without a deployment")
+	}
+}
+
 func TestGetAppStatus_WithFakeClient(t *testing.T) {
 	t.Run("not deployed", func(t *testing.T) {
 		fakeClient := fake.NewClientset()
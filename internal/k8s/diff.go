@@ -0,0 +1,254 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// What a deploy does to a process Deployment
+const (
+	DiffCreate    = "create"
+	DiffUpdate    = "update"
+	DiffDelete    = "delete"
+	DiffUnchanged = "unchanged"
+)
+
+// LiveState is what currently runs in the cluster for an app
+type LiveState struct {
+	// Env is the data of the app's env Secret, nil if it does not exist
+	Env map[string]string
+	// Deployments are the app's web and process Deployments
+	Deployments []appsv1.Deployment
+}
+
+// Change is a value that a deploy replaces
+type Change struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// ReplicaChange is a replica count that a deploy replaces
+type ReplicaChange struct {
+	From int32 `json:"from"`
+	To   int32 `json:"to"`
+}
+
+// EnvDiff lists the env var keys a deploy adds, removes or changes. Values
+// are never included.
+type EnvDiff struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Changed []string `json:"changed"`
+}
+
+// ProcessDiff is what a deploy changes in the Deployment of a process type
+type ProcessDiff struct {
+	Process    string         `json:"process"`
+	Deployment string         `json:"deployment"`
+	Action     string         `json:"action"`
+	Image      *Change        `json:"image,omitempty"`
+	Command    *Change        `json:"command,omitempty"`
+	Replicas   *ReplicaChange `json:"replicas,omitempty"`
+	CPU        *Change        `json:"cpu,omitempty"`
+	Memory     *Change        `json:"memory,omitempty"`
+}
+
+// DeployDiff is the difference between what runs for an app and what a
+// deploy would apply
+type DeployDiff struct {
+	// Deployed is false when nothing of the app runs in the cluster yet
+	Deployed bool `json:"deployed"`
+	// Changed is false when the deploy would only restart the same state
+	Changed bool `json:"changed"`
+	// Image is the change of the web process image
+	Image     *Change       `json:"image,omitempty"`
+	Env       EnvDiff       `json:"env"`
+	Processes []ProcessDiff `json:"processes"`
+}
+
+// LiveState reads the env Secret and Deployments of an app. A missing
+// namespace yields an empty state.
+func (c *Client) LiveState(ctx context.Context, appName string) (*LiveState, error) {
+	namespace := c.NamespaceForApp(appName)
+	state := &LiveState{}
+
+	secret, err := c.clientset.CoreV1().Secrets(namespace).Get(ctx, appName+"-env", metav1.GetOptions{})
+	switch {
+	case err == nil:
+		state.Env = make(map[string]string, len(secret.Data))
+		for k, v := range secret.Data {
+			state.Env[k] = string(v)
+		}
+	case !k8serrors.IsNotFound(err):
+		return nil, fmt.Errorf("failed to get env secret: %w", err)
+	}
+
+	deployments, err := c.clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app.kubernetes.io/name=" + appName + ",app.kubernetes.io/managed-by=nexo-cloud",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	state.Deployments = deployments.Items
+
+	return state, nil
+}
+
+// Diff compares the live state of an app with the resources a deploy of cfg
+// would apply
+func Diff(live *LiveState, cfg *AppConfig) DeployDiff {
+	diff := DeployDiff{
+		Deployed: live.Env != nil || len(live.Deployments) > 0,
+		Env:      diffEnv(live.Env, cfg.EnvVars),
+	}
+
+	existing := make(map[string]*appsv1.Deployment, len(live.Deployments))
+	for i := range live.Deployments {
+		existing[live.Deployments[i].Name] = &live.Deployments[i]
+	}
+
+	desired := []*appsv1.Deployment{GenerateDeployment(cfg)}
+	for i := range cfg.Processes {
+		if cfg.Processes[i].Type != ProcessTypeWeb {
+			desired = append(desired, GenerateProcessDeployment(cfg, &cfg.Processes[i]))
+		}
+	}
+
+	for _, want := range desired {
+		process := processOf(want)
+		have, ok := existing[want.Name]
+		delete(existing, want.Name)
+		if !ok {
+			diff.Processes = append(diff.Processes, ProcessDiff{Process: process, Deployment: want.Name, Action: DiffCreate})
+			continue
+		}
+
+		pd := diffDeployment(have, want)
+		pd.Process = process
+		if process == ProcessTypeWeb {
+			diff.Image = pd.Image
+		}
+		diff.Processes = append(diff.Processes, pd)
+	}
+
+	// Process types removed from the formation are deleted by the deploy
+	var removed []string
+	for name := range existing {
+		removed = append(removed, name)
+	}
+	slices.Sort(removed)
+	for _, name := range removed {
+		diff.Processes = append(diff.Processes, ProcessDiff{
+			Process:    processOf(existing[name]),
+			Deployment: name,
+			Action:     DiffDelete,
+		})
+	}
+
+	diff.Changed = len(diff.Env.Added)+len(diff.Env.Removed)+len(diff.Env.Changed) > 0
+	for _, pd := range diff.Processes {
+		if pd.Action != DiffUnchanged {
+			diff.Changed = true
+		}
+	}
+
+	return diff
+}
+
+func diffEnv(live, desired map[string]string) EnvDiff {
+	diff := EnvDiff{Added: []string{}, Removed: []string{}, Changed: []string{}}
+	for k, v := range desired {
+		old, ok := live[k]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, k)
+		case old != v:
+			diff.Changed = append(diff.Changed, k)
+		}
+	}
+	for k := range live {
+		if _, ok := desired[k]; !ok {
+			diff.Removed = append(diff.Removed, k)
+		}
+	}
+	slices.Sort(diff.Added)
+	slices.Sort(diff.Removed)
+	slices.Sort(diff.Changed)
+	return diff
+}
+
+func diffDeployment(have, want *appsv1.Deployment) ProcessDiff {
+	pd := ProcessDiff{Deployment: want.Name, Action: DiffUnchanged}
+	haveContainer, wantContainer := firstContainer(have), firstContainer(want)
+
+	pd.Image = change(haveContainer.Image, wantContainer.Image)
+	pd.Command = change(commandOf(haveContainer), commandOf(wantContainer))
+	pd.CPU = change(quantity(haveContainer.Resources, corev1.ResourceCPU), quantity(wantContainer.Resources, corev1.ResourceCPU))
+	pd.Memory = change(quantity(haveContainer.Resources, corev1.ResourceMemory), quantity(wantContainer.Resources, corev1.ResourceMemory))
+
+	haveReplicas, wantReplicas := replicasOf(have), replicasOf(want)
+	if haveReplicas != wantReplicas {
+		pd.Replicas = &ReplicaChange{From: haveReplicas, To: wantReplicas}
+	}
+
+	if pd.Image != nil || pd.Command != nil || pd.CPU != nil || pd.Memory != nil || pd.Replicas != nil {
+		pd.Action = DiffUpdate
+	}
+	return pd
+}
+
+// processOf returns the process type of a Deployment; the web Deployment
+// predates the process label on the Deployment itself
+func processOf(deployment *appsv1.Deployment) string {
+	if process := deployment.Spec.Template.Labels[processLabel]; process != "" {
+		return process
+	}
+	if process := deployment.Labels[processLabel]; process != "" {
+		return process
+	}
+	return ProcessTypeWeb
+}
+
+func firstContainer(deployment *appsv1.Deployment) corev1.Container {
+	if len(deployment.Spec.Template.Spec.Containers) == 0 {
+		return corev1.Container{}
+	}
+	return deployment.Spec.Template.Spec.Containers[0]
+}
+
+// commandOf returns the command of a container as entered in the formation
+func commandOf(container corev1.Container) string {
+	if len(container.Command) == 3 && container.Command[0] == "/bin/sh" && container.Command[1] == "-c" {
+		return container.Command[2]
+	}
+	return strings.Join(container.Command, " ")
+}
+
+func replicasOf(deployment *appsv1.Deployment) int32 {
+	if deployment.Spec.Replicas == nil {
+		return 1
+	}
+	return *deployment.Spec.Replicas
+}
+
+// quantity returns the limit of a resource, empty when unset
+func quantity(resources corev1.ResourceRequirements, name corev1.ResourceName) string {
+	if q, ok := resources.Limits[name]; ok {
+		return q.String()
+	}
+	return ""
+}
+
+func change(from, to string) *Change {
+	if from == to {
+		return nil
+	}
+	return &Change{From: from, To: to}
+}
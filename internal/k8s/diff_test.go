@@ -0,0 +1,115 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDiff_NotDeployed(t *testing.T) {
+	diff := Diff(&LiveState{}, testAppConfig(withWorkloads))
+
+	if diff.Deployed || !diff.Changed {
+		t.Errorf("expected an undeployed app to change, got %+v", diff)
+	}
+	if len(diff.Processes) != 2 || diff.Processes[0].Action != DiffCreate || diff.Processes[1].Action != DiffCreate {
+		t.Errorf("expected both deployments to be created, got %+v", diff.Processes)
+	}
+	if len(diff.Env.Added) != 1 || diff.Env.Added[0] != "DATABASE_URL" {
+		t.Errorf("expected DATABASE_URL to be added, got %+v", diff.Env)
+	}
+}
+
+func TestDiff_Unchanged(t *testing.T) {
+	cfg := testAppConfig(withWorkloads)
+	live := &LiveState{
+		Env:         map[string]string{"DATABASE_URL": "postgres://secret"},
+		Deployments: []appsv1.Deployment{*GenerateDeployment(cfg), *GenerateProcessDeployment(cfg, &cfg.Processes[0])},
+	}
+
+	diff := Diff(live, cfg)
+	if !diff.Deployed || diff.Changed {
+		t.Errorf("expected no changes, got %+v", diff)
+	}
+	for _, pd := range diff.Processes {
+		if pd.Action != DiffUnchanged {
+			t.Errorf("expected %s to be unchanged, got %+v", pd.Process, pd)
+		}
+	}
+}
+
+func TestDiff_Changes(t *testing.T) {
+	live := testAppConfig(withWorkloads)
+	live.Processes = append(live.Processes, ProcessConfig{Type: "scheduler", Command: "bin/scheduler", Replicas: 1})
+	state := &LiveState{
+		Env: map[string]string{"DATABASE_URL": "postgres://old", "LEGACY": "1"},
+		Deployments: []appsv1.Deployment{
+			*GenerateDeployment(live),
+			*GenerateProcessDeployment(live, &live.Processes[0]),
+			*GenerateProcessDeployment(live, &live.Processes[1]),
+		},
+	}
+
+	cfg := testAppConfig(withWorkloads)
+	cfg.Image = "myapp:v2"
+	cfg.EnvVars["REDIS_URL"] = "redis://cache"
+	cfg.Processes = []ProcessConfig{
+		{Type: ProcessTypeWeb, Replicas: 3, CPU: "500m", Memory: "512Mi"},
+		{Type: "worker", Command: "bin/worker --fast", Replicas: 2},
+	}
+
+	diff := Diff(state, cfg)
+	if !diff.Changed {
+		t.Fatal("expected changes")
+	}
+	if diff.Image == nil || diff.Image.From != "myapp:v1" || diff.Image.To != "myapp:v2" {
+		t.Errorf("unexpected image change %+v", diff.Image)
+	}
+	if len(diff.Env.Added) != 1 || len(diff.Env.Removed) != 1 || len(diff.Env.Changed) != 1 {
+		t.Errorf("unexpected env diff %+v", diff.Env)
+	}
+
+	if len(diff.Processes) != 3 {
+		t.Fatalf("expected 3 process diffs, got %+v", diff.Processes)
+	}
+	web, worker, scheduler := diff.Processes[0], diff.Processes[1], diff.Processes[2]
+	if web.Action != DiffUpdate || web.Replicas == nil || web.Replicas.To != 3 || web.CPU == nil || web.CPU.To != "500m" || web.Memory == nil {
+		t.Errorf("unexpected web diff %+v", web)
+	}
+	if worker.Command == nil || worker.Command.From != "bin/worker" || worker.Replicas == nil || worker.Replicas.From != 1 {
+		t.Errorf("unexpected worker diff %+v", worker)
+	}
+	if scheduler.Process != "scheduler" || scheduler.Action != DiffDelete {
+		t.Errorf("expected the scheduler to be deleted, got %+v", scheduler)
+	}
+}
+
+func TestLiveState(t *testing.T) {
+	cfg := testAppConfig(withWorkloads)
+	deployment := GenerateDeployment(cfg)
+	clientset := fake.NewClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "myapp-env", Namespace: "fuego-myapp"},
+			Data:       map[string][]byte{"DATABASE_URL": []byte("postgres://secret")},
+		},
+		deployment,
+	)
+	client := NewClientWithInterface(clientset, "fuego-")
+
+	state, err := client.LiveState(context.Background(), "myapp")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state.Env["DATABASE_URL"] != "postgres://secret" || len(state.Deployments) != 1 {
+		t.Errorf("unexpected state %+v", state)
+	}
+
+	state, err = client.LiveState(context.Background(), "other")
+	if err != nil || state.Env != nil || len(state.Deployments) != 0 {
+		t.Errorf("expected an empty state, got %+v, %v", state, err)
+	}
+}
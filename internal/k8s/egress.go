@@ -0,0 +1,114 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// EgressGatewayPolicyGVR is the Cilium policy routing an app's outbound
+// traffic through an egress gateway node. It is cluster-scoped.
+var EgressGatewayPolicyGVR = schema.GroupVersionResource{
+	Group:    "cilium.io",
+	Version:  "v2",
+	Resource: "ciliumegressgatewaypolicies",
+}
+
+// EgressGatewayNodeLabel marks the nodes holding the egress IPs of the pool
+const EgressGatewayNodeLabel = "nexo.build/egress-gateway"
+
+// ErrEgressUnavailable is returned when the client cannot manage egress
+// gateway policies
+var ErrEgressUnavailable = errors.New("egress gateway resources are not available")
+
+// EgressConfig gives an app a static egress IP: every connection its pods
+// open to the outside world leaves the cluster from IP, so third parties can
+// allowlist it.
+type EgressConfig struct {
+	IP string
+}
+
+// EgressPolicyName is the egress gateway policy of an app. Policies are
+// cluster-scoped, so the name carries the namespace.
+func EgressPolicyName(namespace string) string {
+	return namespace + "-egress"
+}
+
+// GenerateEgressPolicy returns the policy sending the outbound traffic of the
+// app's pods through a gateway node with its egress IP
+func GenerateEgressPolicy(cfg *AppConfig) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "cilium.io/v2",
+		"kind":       "CiliumEgressGatewayPolicy",
+		"metadata": map[string]any{
+			"name": EgressPolicyName(cfg.Namespace),
+			"labels": unstructuredLabels(appLabels(cfg, map[string]string{
+				"app.kubernetes.io/name":       cfg.Name,
+				"app.kubernetes.io/managed-by": "nexo-cloud",
+			})),
+		},
+		"spec": map[string]any{
+			"selectors": []any{
+				map[string]any{
+					"podSelector": map[string]any{
+						"matchLabels": map[string]any{
+							"io.kubernetes.pod.namespace": cfg.Namespace,
+							"app.kubernetes.io/name":      cfg.Name,
+						},
+					},
+				},
+			},
+			"destinationCIDRs": []any{"0.0.0.0/0"},
+			"egressGateway": map[string]any{
+				"nodeSelector": map[string]any{
+					"matchLabels": map[string]any{
+						EgressGatewayNodeLabel: "true",
+					},
+				},
+				"egressIP": cfg.Egress.IP,
+			},
+		},
+	}}
+}
+
+// ApplyEgress routes an app's outbound traffic through its static egress IP,
+// or back through its node's IP when cfg.Egress is nil, without a full deploy
+func (c *Client) ApplyEgress(ctx context.Context, cfg *AppConfig) error {
+	cfg.Namespace = c.NamespaceForApp(cfg.Name)
+
+	if cfg.Egress == nil {
+		return c.RemoveEgress(ctx, cfg.Name)
+	}
+	return c.applyEgress(ctx, cfg)
+}
+
+func (c *Client) applyEgress(ctx context.Context, cfg *AppConfig) error {
+	if c.dynamic == nil {
+		return ErrEgressUnavailable
+	}
+
+	obj := GenerateEgressPolicy(cfg)
+	if err := c.applyUnstructured(ctx, EgressGatewayPolicyGVR, obj); err != nil {
+		return fmt.Errorf("failed to apply egress policy: %w", err)
+	}
+	return nil
+}
+
+// RemoveEgress stops routing an app's outbound traffic through a static IP
+func (c *Client) RemoveEgress(ctx context.Context, appName string) error {
+	if c.dynamic == nil {
+		return nil
+	}
+
+	name := EgressPolicyName(c.NamespaceForApp(appName))
+	err := c.dynamic.Resource(EgressGatewayPolicyGVR).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete egress policy: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGenerateEgressPolicy(t *testing.T) {
+	cfg := &AppConfig{Name: "web", Namespace: "fuego-web", Egress: &EgressConfig{IP: "203.0.113.10"}}
+	policy := GenerateEgressPolicy(cfg)
+
+	if policy.GetName() != "fuego-web-egress" || policy.GetNamespace() != "" {
+		t.Errorf("expected cluster-scoped fuego-web-egress, got %s/%s", policy.GetNamespace(), policy.GetName())
+	}
+	if ip, _, _ := unstructured.NestedString(policy.Object, "spec", "egressGateway", "egressIP"); ip != "203.0.113.10" {
+		t.Errorf("unexpected egress IP %q", ip)
+	}
+	selectors, _, _ := unstructured.NestedSlice(policy.Object, "spec", "selectors")
+	if len(selectors) != 1 {
+		t.Fatalf("expected one selector, got %v", selectors)
+	}
+	labels, _, _ := unstructured.NestedStringMap(selectors[0].(map[string]any), "podSelector", "matchLabels")
+	if labels["io.kubernetes.pod.namespace"] != "fuego-web" || labels["app.kubernetes.io/name"] != "web" {
+		t.Errorf("expected the policy to select only the app's pods, got %v", labels)
+	}
+}
+
+func TestApplyEgress(t *testing.T) {
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	client := NewClientWithDynamic(fake.NewClientset(), dynamicClient, "fuego-")
+	ctx := context.Background()
+
+	cfg := &AppConfig{Name: "web", Egress: &EgressConfig{IP: "203.0.113.10"}}
+	if err := client.ApplyEgress(ctx, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := dynamicClient.Resource(EgressGatewayPolicyGVR).Get(ctx, "fuego-web-egress", metav1.GetOptions{}); err != nil {
+		t.Fatalf("expected egress policy: %v", err)
+	}
+
+	cfg.Egress = nil
+	if err := client.ApplyEgress(ctx, cfg); err != nil {
+		t.Fatalf("unexpected error on removal: %v", err)
+	}
+	if _, err := dynamicClient.Resource(EgressGatewayPolicyGVR).Get(ctx, "fuego-web-egress", metav1.GetOptions{}); !k8serrors.IsNotFound(err) {
+		t.Errorf("expected egress policy to be removed, got %v", err)
+	}
+}
+
+func TestApplyEgress_Unavailable(t *testing.T) {
+	client := NewClientWithInterface(fake.NewClientset(), "fuego-")
+
+	err := client.ApplyEgress(context.Background(), &AppConfig{Name: "web", Egress: &EgressConfig{IP: "203.0.113.10"}})
+	if !errors.Is(err, ErrEgressUnavailable) {
+		t.Errorf("expected ErrEgressUnavailable, got %v", err)
+	}
+}
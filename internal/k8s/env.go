@@ -0,0 +1,70 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ApplyEnv rewrites the env secret of a deployed app and rolls the pods of
+// all its processes, which only read the secret when they start, with the
+// app's current database poolers. Cron jobs pick it up on their next run.
+func (c *Client) ApplyEnv(ctx context.Context, cfg *AppConfig) error {
+	cfg.Namespace = c.NamespaceForApp(cfg.Name)
+	if err := c.applySecret(ctx, cfg); err != nil {
+		return fmt.Errorf("failed to apply env secret: %w", err)
+	}
+
+	deployments := c.clientset.AppsV1().Deployments(cfg.Namespace)
+	list, err := deployments.List(ctx, metav1.ListOptions{
+		LabelSelector: "app.kubernetes.io/name=" + cfg.Name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list deployments: %w", err)
+	}
+
+	restartedAt := time.Now().Format(time.RFC3339)
+	for _, item := range list.Items {
+		err := retryOnConflict(func() error {
+			deployment, err := deployments.Get(ctx, item.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			if deployment.Spec.Template.Annotations == nil {
+				deployment.Spec.Template.Annotations = make(map[string]string)
+			}
+			deployment.Spec.Template.Annotations["kubectl.kubernetes.io/restartedAt"] = restartedAt
+			applyPoolers(&deployment.Spec.Template.Spec, cfg)
+			_, err = deployments.Update(ctx, deployment, metav1.UpdateOptions{})
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to restart deployment %s: %w", item.Name, err)
+		}
+	}
+
+	cronJobs := c.clientset.BatchV1().CronJobs(cfg.Namespace)
+	cronList, err := cronJobs.List(ctx, metav1.ListOptions{
+		LabelSelector: "app.kubernetes.io/name=" + cfg.Name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list cron jobs: %w", err)
+	}
+	for _, item := range cronList.Items {
+		err := retryOnConflict(func() error {
+			cronJob, err := cronJobs.Get(ctx, item.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			applyPoolers(&cronJob.Spec.JobTemplate.Spec.Template.Spec, cfg)
+			_, err = cronJobs.Update(ctx, cronJob, metav1.UpdateOptions{})
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update cron job %s: %w", item.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestApplyEnv(t *testing.T) {
+	fakeClient := fake.NewClientset()
+	client := NewClientWithInterface(fakeClient, "test-")
+	ctx := context.Background()
+
+	for _, d := range []struct{ name, app string }{{"myapp", "myapp"}, {"myapp-worker", "myapp"}, {"other", "other"}} {
+		_, err := fakeClient.AppsV1().Deployments("test-myapp").Create(ctx, &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: d.name, Labels: map[string]string{"app.kubernetes.io/name": d.app}},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &AppConfig{Name: "myapp", EnvVars: map[string]string{"DATABASE_URL": "postgres://db"}}
+	if err := client.ApplyEnv(ctx, cfg); err != nil {
+		t.Fatalf("ApplyEnv failed: %v", err)
+	}
+
+	secret, err := fakeClient.CoreV1().Secrets("test-myapp").Get(ctx, "myapp-env", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected the env secret: %v", err)
+	}
+	if secret.StringData["DATABASE_URL"] != "postgres://db" {
+		t.Errorf("unexpected secret %v", secret.StringData)
+	}
+
+	for name, restarted := range map[string]bool{"myapp": true, "myapp-worker": true, "other": false} {
+		deployment, err := fakeClient.AppsV1().Deployments("test-myapp").Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := deployment.Spec.Template.Annotations["kubectl.kubernetes.io/restartedAt"]; ok != restarted {
+			t.Errorf("%s: expected restarted %v", name, restarted)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package k8s
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FailureReason classifies why a deployment failed. It is stored on the
+// deployment record so the dashboard and CLI can explain the failure.
+type FailureReason string
+
+const (
+	FailureForbidden       FailureReason = "forbidden"
+	FailureQuotaExceeded   FailureReason = "quota_exceeded"
+	FailureAdmissionDenied FailureReason = "admission_denied"
+	FailureInvalidImage    FailureReason = "invalid_image"
+	FailureInvalidSpec     FailureReason = "invalid_spec"
+	FailureConflict        FailureReason = "conflict"
+	FailureNoCapacity      FailureReason = "insufficient_capacity"
+	FailureArchMismatch    FailureReason = "incompatible_architecture"
+	FailureUnavailable     FailureReason = "cluster_unavailable"
+	FailureHookFailed      FailureReason = "hook_failed"
+	FailureNotReady        FailureReason = "not_ready"
+
+	// Image pulls stuck in ImagePullBackOff, classified by the registry's answer
+	FailureImagePullUnauthorized FailureReason = "image_pull_unauthorized"
+	FailureImageNotFound         FailureReason = "image_not_found"
+	FailureImagePullRateLimited  FailureReason = "image_pull_rate_limited"
+	FailureImagePull             FailureReason = "image_pull_failed"
+
+	// Rollouts that never reached the cluster or never finished
+	FailureSuperseded  FailureReason = "superseded"
+	FailureInterrupted FailureReason = "interrupted"
+
+	FailureUnknown FailureReason = "unknown"
+)
+
+// DeployError is a Kubernetes API error translated into a message users can act on
+type DeployError struct {
+	Step    string
+	Reason  FailureReason
+	Message string
+	Err     error
+}
+
+func (e *DeployError) Error() string {
+	return fmt.Sprintf("failed to %s: %s", e.Step, e.Message)
+}
+
+func (e *DeployError) Unwrap() error {
+	return e.Err
+}
+
+// TranslateError wraps a Kubernetes client error from a deploy step into a
+// DeployError. Errors that are already translated are returned unchanged.
+func TranslateError(step string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var deployErr *DeployError
+	if errors.As(err, &deployErr) {
+		return err
+	}
+
+	reason, message := translate(err)
+	return &DeployError{
+		Step:    step,
+		Reason:  reason,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// FailureOf returns the failure reason and user-facing message of a deploy error
+func FailureOf(err error) (FailureReason, string) {
+	var deployErr *DeployError
+	if errors.As(err, &deployErr) {
+		return deployErr.Reason, deployErr.Error()
+	}
+	return FailureUnknown, err.Error()
+}
+
+func translate(err error) (FailureReason, string) {
+	msg := statusMessage(err)
+	lower := strings.ToLower(msg)
+
+	switch {
+	case errors.Is(err, ErrInsufficientCapacity):
+		return FailureNoCapacity, msg
+	case errors.Is(err, ErrIncompatibleArchitecture):
+		return FailureArchMismatch, msg
+	case strings.Contains(lower, "admission webhook") && strings.Contains(lower, "denied"):
+		return FailureAdmissionDenied, "the cluster's admission policy rejected the app: " + admissionReason(msg)
+	case k8serrors.IsForbidden(err) && strings.Contains(lower, "exceeded quota"):
+		return FailureQuotaExceeded, "the app exceeds its resource quota, reduce replicas, CPU or memory or upgrade the plan"
+	case k8serrors.IsForbidden(err):
+		return FailureForbidden, "the platform is not allowed to manage this app's resources, contact support"
+	case k8serrors.IsInvalid(err):
+		if field, ok := invalidImageField(err); ok {
+			return FailureInvalidImage, fmt.Sprintf("the image reference is invalid (%s)", field)
+		}
+		return FailureInvalidSpec, "the app configuration was rejected by the cluster: " + invalidCauses(err)
+	case k8serrors.IsConflict(err), k8serrors.IsAlreadyExists(err):
+		return FailureConflict, "the app's resources were changed by another operation, retry the deployment"
+	case k8serrors.IsServerTimeout(err), k8serrors.IsTimeout(err), k8serrors.IsServiceUnavailable(err),
+		k8serrors.IsTooManyRequests(err), k8serrors.IsInternalError(err):
+		return FailureUnavailable, "the cluster is temporarily unavailable, retry the deployment"
+	}
+
+	return FailureUnknown, msg
+}
+
+func statusMessage(err error) string {
+	var status k8serrors.APIStatus
+	if errors.As(err, &status) && status.Status().Message != "" {
+		return status.Status().Message
+	}
+	return err.Error()
+}
+
+// admissionReason strips the webhook name prefix from a denial message, e.g.
+// `admission webhook "policy.example.com" denied the request: privileged containers are not allowed`
+func admissionReason(msg string) string {
+	if _, after, ok := strings.Cut(msg, "denied the request:"); ok {
+		return strings.TrimSpace(after)
+	}
+	return msg
+}
+
+func invalidImageField(err error) (string, bool) {
+	for _, cause := range statusCauses(err) {
+		if strings.HasSuffix(cause.Field, ".image") {
+			return cause.Message, true
+		}
+	}
+	return "", false
+}
+
+func invalidCauses(err error) string {
+	causes := statusCauses(err)
+	if len(causes) == 0 {
+		return statusMessage(err)
+	}
+
+	parts := make([]string, 0, len(causes))
+	for _, cause := range causes {
+		if cause.Field != "" {
+			parts = append(parts, fmt.Sprintf("%s: %s", cause.Field, cause.Message))
+		} else {
+			parts = append(parts, cause.Message)
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+func statusCauses(err error) []metav1.StatusCause {
+	var status k8serrors.APIStatus
+	if !errors.As(err, &status) || status.Status().Details == nil {
+		return nil
+	}
+	return status.Status().Details.Causes
+}
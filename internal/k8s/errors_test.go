@@ -0,0 +1,146 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestTranslateError(t *testing.T) {
+	deployments := schema.GroupResource{Group: "apps", Resource: "deployments"}
+
+	tests := []struct {
+		name   string
+		err    error
+		reason FailureReason
+		want   string
+	}{
+		{
+			name:   "quota exceeded",
+			err:    k8serrors.NewForbidden(deployments, "myapp", errors.New("exceeded quota: compute, requested: limits.memory=2Gi, used: limits.memory=1Gi, limited: limits.memory=2Gi")),
+			reason: FailureQuotaExceeded,
+			want:   "resource quota",
+		},
+		{
+			name:   "forbidden",
+			err:    k8serrors.NewForbidden(deployments, "myapp", errors.New("User \"nexo\" cannot update resource")),
+			reason: FailureForbidden,
+			want:   "not allowed",
+		},
+		{
+			name:   "admission webhook",
+			err:    k8serrors.NewBadRequest(`admission webhook "policy.example.com" denied the request: privileged containers are not allowed`),
+			reason: FailureAdmissionDenied,
+			want:   "privileged containers are not allowed",
+		},
+		{
+			name: "invalid image",
+			err: k8serrors.NewInvalid(schema.GroupKind{Group: "apps", Kind: "Deployment"}, "myapp", field.ErrorList{
+				field.Invalid(field.NewPath("spec", "template", "spec", "containers").Index(0).Child("image"), "Nginx:Latest", "must not contain uppercase characters"),
+			}),
+			reason: FailureInvalidImage,
+			want:   "image reference is invalid",
+		},
+		{
+			name: "invalid spec",
+			err: k8serrors.NewInvalid(schema.GroupKind{Group: "apps", Kind: "Deployment"}, "myapp", field.ErrorList{
+				field.Invalid(field.NewPath("spec", "replicas"), -1, "must be greater than or equal to 0"),
+			}),
+			reason: FailureInvalidSpec,
+			want:   "spec.replicas",
+		},
+		{
+			name:   "conflict",
+			err:    k8serrors.NewConflict(deployments, "myapp", errors.New("the object has been modified")),
+			reason: FailureConflict,
+			want:   "retry",
+		},
+		{
+			name:   "unavailable",
+			err:    k8serrors.NewServiceUnavailable("etcd leader changed"),
+			reason: FailureUnavailable,
+			want:   "temporarily unavailable",
+		},
+		{
+			name:   "unknown",
+			err:    errors.New("connection reset by peer"),
+			reason: FailureUnknown,
+			want:   "connection reset by peer",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := TranslateError("apply deployment", tt.err)
+
+			var deployErr *DeployError
+			if !errors.As(err, &deployErr) {
+				t.Fatalf("expected DeployError, got %T", err)
+			}
+
+			if deployErr.Reason != tt.reason {
+				t.Errorf("expected reason %q, got %q", tt.reason, deployErr.Reason)
+			}
+
+			if !strings.Contains(deployErr.Message, tt.want) {
+				t.Errorf("expected message to contain %q, got %q", tt.want, deployErr.Message)
+			}
+
+			if !strings.HasPrefix(err.Error(), "failed to apply deployment: ") {
+				t.Errorf("expected step prefix, got %q", err.Error())
+			}
+
+			if !errors.Is(err, tt.err) {
+				t.Error("expected original error to be wrapped")
+			}
+		})
+	}
+
+	if TranslateError("apply deployment", nil) != nil {
+		t.Error("expected nil error to stay nil")
+	}
+}
+
+func TestFailureOf(t *testing.T) {
+	err := TranslateError("apply service", k8serrors.NewServiceUnavailable("down"))
+	if reason, _ := FailureOf(TranslateError("apply deployment", err)); reason != FailureUnavailable {
+		t.Errorf("expected already translated error to keep its reason, got %q", reason)
+	}
+
+	reason, message := FailureOf(errors.New("boom"))
+	if reason != FailureUnknown || message != "boom" {
+		t.Errorf("expected unknown failure, got %q %q", reason, message)
+	}
+}
+
+func TestDeploy_TranslatesQuotaError(t *testing.T) {
+	fakeClient := fake.NewClientset()
+	fakeClient.PrependReactor("create", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, k8serrors.NewForbidden(schema.GroupResource{Group: "apps", Resource: "deployments"}, "myapp",
+			errors.New("exceeded quota: compute, requested: pods=3, used: pods=2, limited: pods=2"))
+	})
+	client := NewClientWithInterface(fakeClient, "fuego-")
+
+	_, err := client.Deploy(context.Background(), &AppConfig{Name: "myapp", Image: "myapp:v1", Replicas: 3, Port: 8080})
+
+	reason, message := FailureOf(err)
+	if reason != FailureQuotaExceeded {
+		t.Errorf("expected quota failure, got %q (%v)", reason, err)
+	}
+	if !strings.HasPrefix(message, "failed to apply deployment") {
+		t.Errorf("expected step in message, got %q", message)
+	}
+
+	if _, getErr := fakeClient.CoreV1().Namespaces().Get(context.Background(), "fuego-myapp", metav1.GetOptions{}); getErr != nil {
+		t.Errorf("expected namespace to be created before the failing step: %v", getErr)
+	}
+}
@@ -0,0 +1,227 @@
+package k8s
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+// Export formats
+const (
+	ExportFormatHelm      = "helm"
+	ExportFormatKustomize = "kustomize"
+)
+
+// Placeholders rendered into Helm templates. The YAML encoder quotes them,
+// which Helm renders back into plain strings.
+const (
+	helmNamespace = "{{ .Release.Namespace }}"
+	helmImage     = "{{ .Values.image }}"
+	helmHost      = "{{ .Values.host }}"
+)
+
+var replicasLine = regexp.MustCompile(`(?m)^  replicas: \d+$`)
+
+// helmSecretTemplate renders env values from values.yaml instead of the
+// platform secret so no credentials leave the platform
+const helmSecretTemplate = `apiVersion: v1
+kind: Secret
+metadata:
+  name: %s-env
+  namespace: {{ .Release.Namespace }}
+  labels:
+    app.kubernetes.io/name: %s
+    app.kubernetes.io/managed-by: {{ .Release.Service }}
+type: Opaque
+stringData:
+{{- range $key, $value := .Values.env }}
+  {{ $key }}: {{ $value | quote }}
+{{- end }}
+`
+
+// ExportHelmChart renders a Helm chart equivalent to the resources the
+// platform applies. Image, host, replicas and env are chart values; env
+// values are left empty for the user to fill in.
+func ExportHelmChart(cfg *AppConfig, appVersion string) (map[string][]byte, error) {
+	exported := *cfg
+	exported.Namespace = helmNamespace
+	exported.Image = helmImage
+	exported.Domain = helmHost
+	// mTLS depends on the platform CA and verify endpoint
+	exported.MTLS = nil
+	// The pull credential only works for the platform's clusters
+	exported.PullSecret = nil
+
+	files := map[string][]byte{
+		"Chart.yaml":            []byte(fmt.Sprintf("apiVersion: v2\nname: %s\ndescription: %s exported from nexo-cloud\ntype: application\nversion: 0.1.0\nappVersion: %q\n", cfg.Name, cfg.Name, appVersion)),
+		"templates/secret.yaml": []byte(fmt.Sprintf(helmSecretTemplate, cfg.Name, cfg.Name)),
+	}
+
+	host := cfg.Domain
+	if host == "" {
+		host = cfg.Name + "." + cfg.DomainSuffix
+	}
+
+	values := map[string]any{
+		"image":    cfg.Image,
+		"host":     host,
+		"replicas": GenerateDeployment(cfg).Spec.Replicas,
+		"env":      emptyEnv(cfg.EnvVars),
+	}
+	processValues := map[string]any{}
+	for _, proc := range cfg.Processes {
+		if proc.Type != ProcessTypeWeb {
+			processValues[proc.Type] = map[string]any{"replicas": proc.Replicas}
+		}
+	}
+	if len(processValues) > 0 {
+		values["processes"] = processValues
+	}
+
+	valuesYAML, err := yaml.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render values: %w", err)
+	}
+	files["values.yaml"] = valuesYAML
+
+	for _, obj := range Manifests(&exported) {
+		switch o := obj.(type) {
+		case *corev1.Namespace, *corev1.Secret:
+			// Helm installs into the release namespace and the secret is templated above
+			continue
+		case *appsv1.Deployment:
+			data, err := yaml.Marshal(o)
+			if err != nil {
+				return nil, err
+			}
+			replicas := "{{ .Values.replicas }}"
+			if processType := o.Spec.Template.Labels[processLabel]; processType != ProcessTypeWeb {
+				replicas = fmt.Sprintf("{{ index .Values.processes %q \"replicas\" }}", processType)
+			}
+			files["templates/"+exportFileName(o.Kind, o.Name)] = replicasLine.ReplaceAll(data, []byte("  replicas: "+replicas))
+		default:
+			data, err := yaml.Marshal(obj)
+			if err != nil {
+				return nil, err
+			}
+			files["templates/"+exportFileName(obj.GetObjectKind().GroupVersionKind().Kind, objectName(obj))] = data
+		}
+	}
+
+	return files, nil
+}
+
+// ExportKustomize renders the platform resources as a kustomize base. The env
+// secret is generated from a .env file with empty values.
+func ExportKustomize(cfg *AppConfig) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	var resources []string
+
+	exported := *cfg
+	exported.MTLS = nil
+	exported.PullSecret = nil
+
+	for _, obj := range Manifests(&exported) {
+		if _, ok := obj.(*corev1.Secret); ok {
+			continue
+		}
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		name := exportFileName(obj.GetObjectKind().GroupVersionKind().Kind, objectName(obj))
+		files[name] = data
+		resources = append(resources, name)
+	}
+
+	var env strings.Builder
+	for _, key := range sortedKeys(cfg.EnvVars) {
+		env.WriteString(key + "=\n")
+	}
+	files["app.env"] = []byte(env.String())
+
+	kustomization := map[string]any{
+		"apiVersion": "kustomize.config.k8s.io/v1beta1",
+		"kind":       "Kustomization",
+		"namespace":  cfg.Namespace,
+		"resources":  resources,
+		"secretGenerator": []map[string]any{{
+			"name":    cfg.Name + "-env",
+			"envs":    []string{"app.env"},
+			"options": map[string]any{"disableNameSuffixHash": true},
+		}},
+	}
+	data, err := yaml.Marshal(kustomization)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render kustomization: %w", err)
+	}
+	files["kustomization.yaml"] = data
+
+	return files, nil
+}
+
+// WriteArchive writes files as a gzipped tarball under a root directory
+func WriteArchive(w io.Writer, root string, files map[string][]byte) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+
+	for _, name := range sortedKeys(files) {
+		data := files[name]
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    root + "/" + name,
+			Mode:    0o644,
+			Size:    int64(len(data)),
+			ModTime: now,
+		}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func exportFileName(kind, name string) string {
+	return strings.ToLower(kind) + "-" + name + ".yaml"
+}
+
+func objectName(obj runtime.Object) string {
+	if meta, ok := obj.(metav1.Object); ok {
+		return meta.GetName()
+	}
+	return ""
+}
+
+func emptyEnv(envVars map[string]string) map[string]string {
+	env := make(map[string]string, len(envVars))
+	for key := range envVars {
+		env[key] = ""
+	}
+	return env
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
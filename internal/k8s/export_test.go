@@ -0,0 +1,100 @@
+package k8s
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestExportHelmChart(t *testing.T) {
+	files, err := ExportHelmChart(testAppConfig(withWorkloads), "v3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, name := range []string{
+		"Chart.yaml",
+		"values.yaml",
+		"templates/secret.yaml",
+		"templates/deployment-myapp.yaml",
+		"templates/deployment-myapp-worker.yaml",
+		"templates/service-myapp.yaml",
+		"templates/ingress-myapp.yaml",
+		"templates/cronjob-myapp-cron-cleanup.yaml",
+	} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("expected chart to contain %s", name)
+		}
+	}
+
+	if _, ok := files["templates/namespace-fuego-myapp.yaml"]; ok {
+		t.Error("expected namespace to be left to the Helm release")
+	}
+
+	web := string(files["templates/deployment-myapp.yaml"])
+	for _, want := range []string{"replicas: {{ .Values.replicas }}", "image: '{{ .Values.image }}'", "namespace: '{{ .Release.Namespace }}'"} {
+		if !strings.Contains(web, want) {
+			t.Errorf("expected web deployment template to contain %q, got:\n%s", want, web)
+		}
+	}
+
+	worker := string(files["templates/deployment-myapp-worker.yaml"])
+	if !strings.Contains(worker, `replicas: {{ index .Values.processes "worker" "replicas" }}`) {
+		t.Errorf("expected worker replicas to be a chart value, got:\n%s", worker)
+	}
+
+	values := string(files["values.yaml"])
+	if !strings.Contains(values, "image: myapp:v1") || !strings.Contains(values, "host: myapp.nexo.build") {
+		t.Errorf("unexpected values:\n%s", values)
+	}
+	if strings.Contains(values, "postgres://secret") {
+		t.Error("expected env values to be left out of the chart")
+	}
+}
+
+func TestExportKustomize(t *testing.T) {
+	files, err := ExportKustomize(testAppConfig(withWorkloads))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	kustomization := string(files["kustomization.yaml"])
+	for _, want := range []string{"namespace: fuego-myapp", "- deployment-myapp.yaml", "- app.env", "name: myapp-env"} {
+		if !strings.Contains(kustomization, want) {
+			t.Errorf("expected kustomization to contain %q, got:\n%s", want, kustomization)
+		}
+	}
+
+	if string(files["app.env"]) != "DATABASE_URL=\n" {
+		t.Errorf("expected env keys without values, got %q", files["app.env"])
+	}
+}
+
+func TestWriteArchive(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteArchive(&buf, "myapp", map[string][]byte{"Chart.yaml": []byte("name: myapp\n")}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("expected gzip stream: %v", err)
+	}
+	tr := tar.NewReader(gz)
+
+	header, err := tr.Next()
+	if err != nil {
+		t.Fatalf("expected a tar entry: %v", err)
+	}
+	if header.Name != "myapp/Chart.yaml" {
+		t.Errorf("expected myapp/Chart.yaml, got %q", header.Name)
+	}
+
+	data, _ := io.ReadAll(tr)
+	if string(data) != "name: myapp\n" {
+		t.Errorf("unexpected content %q", data)
+	}
+}
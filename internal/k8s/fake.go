@@ -0,0 +1,476 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fake is an in-memory Interface for tests. It records the calls made to it,
+// answers from the state in its fields, and fails every call returning an
+// error with Err when set. Set fields up before handing the fake to a
+// handler; calls only update them under the fake's lock.
+type Fake struct {
+	NamespacePrefix string
+	Err             error
+
+	// State by app name
+	Statuses map[string]*AppStatus
+	Pods     map[string][]PodInfo
+	Crashes  map[string][]ContainerCrash
+	Metrics  map[string]*AppMetrics
+	Logs     map[string][]LogLine
+	Live     map[string]*LiveState
+	// CronRuns are keyed "app/cron"
+	CronRuns map[string][]CronRun
+	// Replicas are keyed "app/process", set by ScaleApp and ScaleProcess
+	Replicas map[string]int32
+	Usage    map[string][]PodUsage
+	Certs    map[string][]CertificateStatus
+	// Namespaces are what ExportNamespaces returns, updated by RestoreNamespace
+	Namespaces []NamespaceBackup
+
+	// MissingArchitectures is what CheckArchitectures reports missing
+	MissingArchitectures []string
+	// MissingNodePool makes NodePoolExists report false
+	MissingNodePool bool
+	// Rolling, when set, keeps Deploy rolling out until it is closed
+	Rolling chan struct{}
+
+	mu    sync.Mutex
+	calls []string
+}
+
+var _ Interface = (*Fake)(nil)
+
+// NewFake returns an empty fake cluster
+func NewFake() *Fake {
+	return &Fake{
+		NamespacePrefix: "fuego-",
+		Statuses:        make(map[string]*AppStatus),
+		Pods:            make(map[string][]PodInfo),
+		Crashes:         make(map[string][]ContainerCrash),
+		Metrics:         make(map[string]*AppMetrics),
+		Logs:            make(map[string][]LogLine),
+		Live:            make(map[string]*LiveState),
+		CronRuns:        make(map[string][]CronRun),
+		Replicas:        make(map[string]int32),
+		Usage:           make(map[string][]PodUsage),
+		Certs:           make(map[string][]CertificateStatus),
+	}
+}
+
+// Calls returns the calls made so far, such as "ScaleProcess shop web 3"
+func (f *Fake) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.calls)
+}
+
+// Called reports whether a call was made, by the same form as Calls
+func (f *Fake) Called(call string) bool {
+	return slices.Contains(f.Calls(), call)
+}
+
+// record notes a call and returns Err; callers hold no lock
+func (f *Fake) record(method string, args ...any) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	call := method
+	for _, arg := range args {
+		call += " " + fmt.Sprint(arg)
+	}
+	f.calls = append(f.calls, call)
+	return f.Err
+}
+
+func (f *Fake) NamespaceForApp(appName string) string {
+	return f.NamespacePrefix + appName
+}
+
+func (f *Fake) Ping(context.Context) (time.Duration, error) {
+	return time.Millisecond, f.record("Ping")
+}
+
+func (f *Fake) Deploy(ctx context.Context, cfg *AppConfig) (*DeployResult, error) {
+	if err := f.record("Deploy", cfg.Name, cfg.Image); err != nil {
+		return nil, err
+	}
+	if f.Rolling != nil {
+		select {
+		case <-f.Rolling:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Statuses[cfg.Name] = &AppStatus{Status: "running", Replicas: cfg.Replicas, ReadyReplicas: cfg.Replicas, AvailableReplicas: cfg.Replicas}
+	f.Replicas[cfg.Name+"/"+ProcessTypeWeb] = cfg.Replicas
+	return &DeployResult{
+		Success:   true,
+		Message:   "deployed",
+		Namespace: f.NamespaceForApp(cfg.Name),
+		URL:       "https://" + cfg.Name + "." + cfg.DomainSuffix,
+		Hooks:     fakeHooks(cfg),
+	}, nil
+}
+
+// fakeHooks reports the configured deploy hooks as succeeded
+func fakeHooks(cfg *AppConfig) []*HookResult {
+	var hooks []*HookResult
+	now := time.Now()
+	for _, hook := range []struct{ phase, command string }{
+		{HookPhasePreDeploy, cfg.PreDeployHook},
+		{HookPhasePostDeploy, cfg.PostDeployHook},
+	} {
+		if strings.TrimSpace(hook.command) == "" {
+			continue
+		}
+		hooks = append(hooks, &HookResult{
+			Phase:      hook.phase,
+			Command:    hook.command,
+			JobName:    HookJobName(cfg.Name, hook.phase, now),
+			Status:     HookStatusSucceeded,
+			StartedAt:  now,
+			FinishedAt: now,
+		})
+	}
+	return hooks
+}
+
+func (f *Fake) DryRun(_ context.Context, cfg *AppConfig) ([]ManifestCheck, error) {
+	if err := f.record("DryRun", cfg.Name); err != nil {
+		return nil, err
+	}
+	return []ManifestCheck{{Kind: "Deployment", Name: cfg.Name, Valid: true}}, nil
+}
+
+func (f *Fake) LiveState(_ context.Context, appName string) (*LiveState, error) {
+	if err := f.record("LiveState", appName); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if live, ok := f.Live[appName]; ok {
+		return live, nil
+	}
+	return &LiveState{}, nil
+}
+
+func (f *Fake) CheckCapacity(_ context.Context, cfg *AppConfig) error {
+	return f.record("CheckCapacity", cfg.Name)
+}
+
+func (f *Fake) CheckArchitectures(_ context.Context, cfg *AppConfig, _ []string) ([]string, error) {
+	if err := f.record("CheckArchitectures", cfg.Name); err != nil {
+		return nil, err
+	}
+	return f.MissingArchitectures, nil
+}
+
+func (f *Fake) DeleteApp(_ context.Context, appName string) error {
+	if err := f.record("DeleteApp", appName); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.Statuses, appName)
+	delete(f.Pods, appName)
+	maps.DeleteFunc(f.Replicas, func(key string, _ int32) bool {
+		return strings.HasPrefix(key, appName+"/")
+	})
+	return nil
+}
+
+func (f *Fake) ScaleApp(ctx context.Context, appName string, replicas int32) error {
+	return f.ScaleProcess(ctx, appName, ProcessTypeWeb, replicas)
+}
+
+func (f *Fake) ScaleProcess(_ context.Context, appName, processType string, replicas int32) error {
+	if err := f.record("ScaleProcess", appName, processType, replicas); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Replicas[appName+"/"+processType] = replicas
+	return nil
+}
+
+// ProcessReplicas answers from Replicas, a process never scaled running one
+func (f *Fake) ProcessReplicas(_ context.Context, appName, processType string) (int32, error) {
+	if err := f.record("ProcessReplicas", appName, processType); err != nil {
+		return 0, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if replicas, ok := f.Replicas[appName+"/"+processType]; ok {
+		return replicas, nil
+	}
+	return 1, nil
+}
+
+func (f *Fake) ResizeProcess(_ context.Context, appName, processType, cpu, memory string) error {
+	return f.record("ResizeProcess", appName, processType, cpu, memory)
+}
+
+func (f *Fake) ApplyProcesses(_ context.Context, cfg *AppConfig) error {
+	return f.record("ApplyProcesses", cfg.Name)
+}
+
+func (f *Fake) RestartApp(_ context.Context, appName string) error {
+	return f.record("RestartApp", appName)
+}
+
+func (f *Fake) ApplyEnv(_ context.Context, cfg *AppConfig) error {
+	return f.record("ApplyEnv", cfg.Name)
+}
+
+func (f *Fake) ApplyOTelCollector(_ context.Context, cfg *AppConfig) error {
+	return f.record("ApplyOTelCollector", cfg.Name)
+}
+
+func (f *Fake) NodePoolExists(context.Context, map[string]string) (bool, error) {
+	if err := f.record("NodePoolExists"); err != nil {
+		return false, err
+	}
+	return !f.MissingNodePool, nil
+}
+
+func (f *Fake) ApplyPlacement(_ context.Context, appName string, _ *Placement) error {
+	return f.record("ApplyPlacement", appName)
+}
+
+func (f *Fake) GetAppStatus(_ context.Context, appName string) (*AppStatus, error) {
+	if err := f.record("GetAppStatus", appName); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if status, ok := f.Statuses[appName]; ok {
+		return status, nil
+	}
+	return &AppStatus{Status: "not_deployed"}, nil
+}
+
+func (f *Fake) ListAppPods(_ context.Context, appName string) ([]PodInfo, error) {
+	if err := f.record("ListAppPods", appName); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.Pods[appName]), nil
+}
+
+func (f *Fake) DeletePod(_ context.Context, appName, podName string) error {
+	if err := f.record("DeletePod", appName, podName); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pods := f.Pods[appName]
+	i := slices.IndexFunc(pods, func(p PodInfo) bool { return p.Name == podName })
+	if i < 0 {
+		return fmt.Errorf("pod %s not found", podName)
+	}
+	f.Pods[appName] = slices.Delete(slices.Clone(pods), i, i+1)
+	return nil
+}
+
+func (f *Fake) ListCrashes(_ context.Context, appName string) ([]ContainerCrash, error) {
+	if err := f.record("ListCrashes", appName); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.Crashes[appName]), nil
+}
+
+func (f *Fake) GetAppMetrics(_ context.Context, appName string) (*AppMetrics, error) {
+	if err := f.record("GetAppMetrics", appName); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if metrics, ok := f.Metrics[appName]; ok {
+		return metrics, nil
+	}
+	return &AppMetrics{AppName: appName, Namespace: f.NamespaceForApp(appName)}, nil
+}
+
+func (f *Fake) ListPodUsage(_ context.Context, appName string) ([]PodUsage, error) {
+	if err := f.record("ListPodUsage", appName); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.Usage[appName]), nil
+}
+
+func (f *Fake) ListCertificates(_ context.Context, appName string) ([]CertificateStatus, error) {
+	if err := f.record("ListCertificates", appName); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.Certs[appName]), nil
+}
+
+// StreamLogs sends the app's logs, then waits for the context to end when
+// following like the real stream does
+func (f *Fake) StreamLogs(ctx context.Context, appName string, opts LogStreamOptions, outputCh chan<- LogLine) error {
+	if err := f.record("StreamLogs", appName); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	logs := f.Logs[appName]
+	f.mu.Unlock()
+	if len(logs) == 0 {
+		return fmt.Errorf("no pods found for app %s", appName)
+	}
+	if opts.TailLines > 0 && int(opts.TailLines) < len(logs) {
+		logs = logs[len(logs)-int(opts.TailLines):]
+	}
+	for _, line := range logs {
+		select {
+		case outputCh <- line:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if !opts.Follow {
+		return nil
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (f *Fake) GetRecentLogs(_ context.Context, appName string, tailLines int64) ([]LogLine, error) {
+	if err := f.record("GetRecentLogs", appName); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	logs := f.Logs[appName]
+	if tailLines > 0 && int(tailLines) < len(logs) {
+		logs = logs[len(logs)-int(tailLines):]
+	}
+	return slices.Clone(logs), nil
+}
+
+func (f *Fake) LogSources(_ context.Context, appName string) ([]LogSource, error) {
+	if err := f.record("LogSources", appName); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var sources []LogSource
+	for _, line := range f.Logs[appName] {
+		source := LogSource{Pod: line.Pod, Container: line.Container}
+		if !slices.Contains(sources, source) {
+			sources = append(sources, source)
+		}
+	}
+	slices.SortFunc(sources, func(a, b LogSource) int {
+		return strings.Compare(a.Pod+"/"+a.Container, b.Pod+"/"+b.Container)
+	})
+	return sources, nil
+}
+
+func (f *Fake) OpenLogs(_ context.Context, appName string, source LogSource, _ time.Time) (io.ReadCloser, error) {
+	if err := f.record("OpenLogs", appName, source.Pod); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var b strings.Builder
+	for _, line := range f.Logs[appName] {
+		if line.Pod == source.Pod && line.Container == source.Container {
+			b.WriteString(line.Message)
+		}
+	}
+	return io.NopCloser(strings.NewReader(b.String())), nil
+}
+
+func (f *Fake) ApplyCronJob(_ context.Context, cfg *AppConfig, cron *CronJobConfig) error {
+	return f.record("ApplyCronJob", cfg.Name, cron.Name)
+}
+
+func (f *Fake) DeleteCronJob(_ context.Context, appName, cronName string) error {
+	return f.record("DeleteCronJob", appName, cronName)
+}
+
+func (f *Fake) ListCronRuns(_ context.Context, appName, cronName string, _ int64) ([]CronRun, error) {
+	if err := f.record("ListCronRuns", appName, cronName); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.CronRuns[appName+"/"+cronName]), nil
+}
+
+func (f *Fake) ApplyMTLS(_ context.Context, cfg *AppConfig) error {
+	return f.record("ApplyMTLS", cfg.Name)
+}
+
+func (f *Fake) ApplyTLSPolicy(_ context.Context, cfg *AppConfig) error {
+	return f.record("ApplyTLSPolicy", cfg.Name)
+}
+
+func (f *Fake) ApplyMirror(_ context.Context, cfg *AppConfig) error {
+	return f.record("ApplyMirror", cfg.Name)
+}
+
+func (f *Fake) RemoveMirror(_ context.Context, appName string) error {
+	return f.record("RemoveMirror", appName)
+}
+
+func (f *Fake) ApplyEgress(_ context.Context, cfg *AppConfig) error {
+	return f.record("ApplyEgress", cfg.Name)
+}
+
+func (f *Fake) ApplyRouter(_ context.Context, cfg *RouterConfig) error {
+	return f.record("ApplyRouter", cfg.Name)
+}
+
+func (f *Fake) DeleteRouter(_ context.Context, routerName string) error {
+	return f.record("DeleteRouter", routerName)
+}
+
+func (f *Fake) ApplyTrafficSplit(_ context.Context, cfg *TrafficSplitConfig) error {
+	return f.record("ApplyTrafficSplit", cfg.Name)
+}
+
+func (f *Fake) DeleteTrafficSplit(_ context.Context, splitName string) error {
+	return f.record("DeleteTrafficSplit", splitName)
+}
+
+func (f *Fake) ExportNamespaces(context.Context) ([]NamespaceBackup, error) {
+	if err := f.record("ExportNamespaces"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.Namespaces), nil
+}
+
+// RestoreNamespace replaces the namespace of the same name, or adds it
+func (f *Fake) RestoreNamespace(_ context.Context, backup NamespaceBackup) error {
+	if err := f.record("RestoreNamespace", backup.Name); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := slices.IndexFunc(f.Namespaces, func(n NamespaceBackup) bool { return n.Name == backup.Name })
+	if i < 0 {
+		f.Namespaces = append(f.Namespaces, backup)
+	} else {
+		f.Namespaces[i] = backup
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFake_RecordsCalls(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake()
+	f.Pods["shop"] = []PodInfo{{Name: "shop-a"}, {Name: "shop-b"}}
+
+	if err := f.ScaleProcess(ctx, "shop", "worker", 3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := f.DeletePod(ctx, "shop", "shop-a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := f.DeletePod(ctx, "shop", "shop-z"); err == nil {
+		t.Error("expected deleting an unknown pod to fail")
+	}
+
+	if f.Replicas["shop/worker"] != 3 {
+		t.Errorf("expected 3 worker replicas, got %d", f.Replicas["shop/worker"])
+	}
+	if pods, _ := f.ListAppPods(ctx, "shop"); len(pods) != 1 || pods[0].Name != "shop-b" {
+		t.Errorf("expected shop-b to remain, got %v", pods)
+	}
+	if !f.Called("ScaleProcess shop worker 3") || !f.Called("DeletePod shop shop-a") {
+		t.Errorf("unexpected calls %v", f.Calls())
+	}
+	if status, _ := f.GetAppStatus(ctx, "blog"); status.Status != "not_deployed" {
+		t.Errorf("expected an unknown app not to be deployed, got %q", status.Status)
+	}
+
+	f.Err = errors.New("forbidden")
+	if err := f.RestartApp(ctx, "shop"); !errors.Is(err, f.Err) {
+		t.Errorf("expected the injected error, got %v", err)
+	}
+}
+
+func TestFake_StreamLogs(t *testing.T) {
+	f := NewFake()
+	f.Logs["shop"] = []LogLine{{Pod: "shop-a", Message: "one\n"}, {Pod: "shop-a", Message: "two\n"}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	lines := make(chan LogLine, 10)
+	err := f.StreamLogs(ctx, "shop", LogStreamOptions{Follow: true, TailLines: 1}, lines)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected following to last until the context ends, got %v", err)
+	}
+	if len(lines) != 1 || (<-lines).Message != "two\n" {
+		t.Error("expected only the last line")
+	}
+
+	if err := f.StreamLogs(context.Background(), "blog", LogStreamOptions{}, lines); err == nil {
+		t.Error("expected an app without pods to fail")
+	}
+}
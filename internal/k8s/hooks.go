@@ -0,0 +1,234 @@
+package k8s
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// Hook phases
+const (
+	HookPhasePreDeploy  = "pre_deploy"
+	HookPhasePostDeploy = "post_deploy"
+)
+
+// Hook run statuses
+const (
+	HookStatusSucceeded = "succeeded"
+	HookStatusFailed    = "failed"
+	HookStatusTimedOut  = "timed_out"
+)
+
+// DefaultHookTimeout is used when an AppConfig does not set HookTimeout
+const DefaultHookTimeout = 10 * time.Minute
+
+// hookOutputLines caps how much of the hook pod log is kept on the result
+const hookOutputLines = 200
+
+// HookResult describes a single deploy hook execution
+type HookResult struct {
+	Phase      string    `json:"phase"`
+	Command    string    `json:"command"`
+	JobName    string    `json:"job_name"`
+	Status     string    `json:"status"`
+	Output     string    `json:"output,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// Succeeded reports whether the hook completed successfully
+func (r *HookResult) Succeeded() bool {
+	return r.Status == HookStatusSucceeded
+}
+
+// HookJobName returns the Job name used for a hook phase of a deploy
+func HookJobName(appName, phase string, at time.Time) string {
+	suffix := fmt.Sprintf("-%s-%d", strings.ReplaceAll(phase, "_", "-"), at.Unix())
+	if len(appName)+len(suffix) > 63 {
+		appName = strings.TrimRight(appName[:63-len(suffix)], "-")
+	}
+	return appName + suffix
+}
+
+// HookSecretName returns the name of the env Secret deploy hooks run with
+func HookSecretName(appName string) string {
+	return appName + "-hook-env"
+}
+
+// GenerateHookSecret builds the env Secret of an app's deploy hooks. It holds
+// the env of the version being deployed, so a pre-deploy hook sees the new
+// env while the running pods keep theirs until the hook succeeded.
+func GenerateHookSecret(cfg *AppConfig) *corev1.Secret {
+	secret := GenerateSecret(cfg)
+	secret.Name = HookSecretName(cfg.Name)
+	return secret
+}
+
+// GenerateHookJob builds the Job that runs a deploy hook command using the
+// app image and the hook env secret, so hooks see exactly what the new
+// version will.
+func GenerateHookJob(cfg *AppConfig, phase, command, jobName string) *batchv1.Job {
+	labels := appLabels(cfg, map[string]string{
+		"app.kubernetes.io/name":       cfg.Name,
+		"app.kubernetes.io/managed-by": "nexo-cloud",
+		"nexo.build/hook-phase":        phase,
+	})
+
+	backoffLimit := int32(0)
+	ttl := int32(3600)
+	timeout := cfg.HookTimeout
+	if timeout <= 0 {
+		timeout = DefaultHookTimeout
+	}
+	deadline := int64(timeout.Seconds())
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: cfg.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &backoffLimit,
+			ActiveDeadlineSeconds:   &deadline,
+			TTLSecondsAfterFinished: &ttl,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:    "hook",
+							Image:   cfg.Image,
+							Command: []string{"/bin/sh", "-c", command},
+							EnvFrom: []corev1.EnvFromSource{
+								{
+									SecretRef: &corev1.SecretEnvSource{
+										LocalObjectReference: corev1.LocalObjectReference{
+											Name: HookSecretName(cfg.Name),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	cfg.Placement.applyTo(&job.Spec.Template.Spec)
+	applyArchitectures(&job.Spec.Template.Spec, cfg.Architectures)
+	applyPoolers(&job.Spec.Template.Spec, cfg)
+	applyImagePullSecret(&job.Spec.Template.Spec, cfg)
+
+	return job
+}
+
+// RunHook runs a deploy hook as a Job and waits for it to finish.
+// A nil result is returned when no command is configured for the phase.
+func (c *Client) RunHook(ctx context.Context, cfg *AppConfig, phase string) (*HookResult, error) {
+	command := cfg.PreDeployHook
+	if phase == HookPhasePostDeploy {
+		command = cfg.PostDeployHook
+	}
+	if strings.TrimSpace(command) == "" {
+		return nil, nil
+	}
+
+	if err := c.putSecret(ctx, GenerateHookSecret(cfg)); err != nil {
+		return nil, fmt.Errorf("failed to apply hook secret: %w", err)
+	}
+
+	startedAt := time.Now()
+	job := GenerateHookJob(cfg, phase, command, HookJobName(cfg.Name, phase, startedAt))
+	jobs := c.clientset.BatchV1().Jobs(cfg.Namespace)
+
+	if _, err := jobs.Create(ctx, job, metav1.CreateOptions{}); err != nil {
+		return nil, fmt.Errorf("failed to create hook job: %w", err)
+	}
+
+	result := &HookResult{
+		Phase:     phase,
+		Command:   command,
+		JobName:   job.Name,
+		StartedAt: startedAt,
+	}
+
+	timeout := cfg.HookTimeout
+	if timeout <= 0 {
+		timeout = DefaultHookTimeout
+	}
+
+	err := wait.PollUntilContextTimeout(ctx, 2*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		current, err := jobs.Get(ctx, job.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, nil
+		}
+
+		for _, cond := range current.Status.Conditions {
+			if cond.Status != corev1.ConditionTrue {
+				continue
+			}
+			switch cond.Type {
+			case batchv1.JobComplete:
+				result.Status = HookStatusSucceeded
+				return true, nil
+			case batchv1.JobFailed:
+				result.Status = HookStatusFailed
+				result.Error = strings.TrimSpace(cond.Reason + ": " + cond.Message)
+				return true, nil
+			}
+		}
+
+		return false, nil
+	})
+	if err != nil {
+		result.Status = HookStatusTimedOut
+		result.Error = fmt.Sprintf("hook did not finish within %s", timeout)
+	}
+
+	result.FinishedAt = time.Now()
+	result.Output = c.hookOutput(ctx, cfg.Namespace, job.Name)
+
+	return result, nil
+}
+
+// hookOutput collects the tail of the hook pod logs, best effort
+func (c *Client) hookOutput(ctx context.Context, namespace, jobName string) string {
+	pods, err := c.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("job-name=%s", jobName),
+	})
+	if err != nil || len(pods.Items) == 0 {
+		return ""
+	}
+
+	tailLines := int64(hookOutputLines)
+	req := c.clientset.CoreV1().Pods(namespace).GetLogs(pods.Items[0].Name, &corev1.PodLogOptions{
+		TailLines: &tailLines,
+	})
+	stream, err := req.Stream(ctx)
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = stream.Close() }()
+
+	var sb strings.Builder
+	scanner := bufio.NewScanner(stream)
+	for scanner.Scan() {
+		sb.WriteString(scanner.Text())
+		sb.WriteByte('\n')
+	}
+
+	return sb.String()
+}
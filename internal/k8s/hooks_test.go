@@ -0,0 +1,222 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestHookJobName(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+
+	name := HookJobName("myapp", HookPhasePreDeploy, at)
+	if name != "myapp-pre-deploy-1700000000" {
+		t.Errorf("expected 'myapp-pre-deploy-1700000000', got %q", name)
+	}
+
+	long := HookJobName(strings.Repeat("a", 70), HookPhasePostDeploy, at)
+	if len(long) > 63 {
+		t.Errorf("expected job name to fit in 63 characters, got %d", len(long))
+	}
+	if !strings.HasSuffix(long, "-post-deploy-1700000000") {
+		t.Errorf("expected phase suffix to be kept, got %q", long)
+	}
+}
+
+func TestGenerateHookJob(t *testing.T) {
+	cfg := &AppConfig{
+		Name:        "myapp",
+		Namespace:   "fuego-myapp",
+		Image:       "ghcr.io/user/myapp:v2",
+		HookTimeout: 5 * time.Minute,
+	}
+
+	job := GenerateHookJob(cfg, HookPhasePreDeploy, "rails db:migrate", "myapp-pre-deploy-1")
+
+	if job.Name != "myapp-pre-deploy-1" {
+		t.Errorf("expected job name 'myapp-pre-deploy-1', got %q", job.Name)
+	}
+
+	if job.Namespace != "fuego-myapp" {
+		t.Errorf("expected namespace 'fuego-myapp', got %q", job.Namespace)
+	}
+
+	if job.Labels["nexo.build/hook-phase"] != HookPhasePreDeploy {
+		t.Errorf("expected hook phase label, got %v", job.Labels)
+	}
+
+	if *job.Spec.BackoffLimit != 0 {
+		t.Errorf("expected no retries, got backoff limit %d", *job.Spec.BackoffLimit)
+	}
+
+	if *job.Spec.ActiveDeadlineSeconds != 300 {
+		t.Errorf("expected 300s deadline, got %d", *job.Spec.ActiveDeadlineSeconds)
+	}
+
+	podSpec := job.Spec.Template.Spec
+	if podSpec.RestartPolicy != corev1.RestartPolicyNever {
+		t.Errorf("expected restart policy Never, got %v", podSpec.RestartPolicy)
+	}
+
+	container := podSpec.Containers[0]
+	if container.Image != "ghcr.io/user/myapp:v2" {
+		t.Errorf("expected hook to use app image, got %q", container.Image)
+	}
+
+	if strings.Join(container.Command, " ") != "/bin/sh -c rails db:migrate" {
+		t.Errorf("unexpected command %v", container.Command)
+	}
+
+	if container.EnvFrom[0].SecretRef.Name != "myapp-hook-env" {
+		t.Errorf("expected env from 'myapp-hook-env', got %q", container.EnvFrom[0].SecretRef.Name)
+	}
+}
+
+func TestRunHook_NoCommand(t *testing.T) {
+	client := NewClientWithInterface(fake.NewClientset(), "fuego-")
+	cfg := &AppConfig{Name: "myapp", Namespace: "fuego-myapp"}
+
+	result, err := client.RunHook(context.Background(), cfg, HookPhasePreDeploy)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != nil {
+		t.Errorf("expected nil result when no hook is configured, got %+v", result)
+	}
+}
+
+func TestRunHook_JobOutcome(t *testing.T) {
+	tests := []struct {
+		name     string
+		condType batchv1.JobConditionType
+		expected string
+	}{
+		{"job completes", batchv1.JobComplete, HookStatusSucceeded},
+		{"job fails", batchv1.JobFailed, HookStatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := fake.NewClientset()
+			fakeClient.PrependReactor("get", "jobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				name := action.(k8stesting.GetAction).GetName()
+				return true, &batchv1.Job{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: action.GetNamespace()},
+					Status: batchv1.JobStatus{
+						Conditions: []batchv1.JobCondition{
+							{Type: tt.condType, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded"},
+						},
+					},
+				}, nil
+			})
+
+			client := NewClientWithInterface(fakeClient, "fuego-")
+			cfg := &AppConfig{
+				Name:          "myapp",
+				Namespace:     "fuego-myapp",
+				Image:         "myapp:v1",
+				PreDeployHook: "bin/migrate",
+				HookTimeout:   10 * time.Second,
+			}
+
+			result, err := client.RunHook(context.Background(), cfg, HookPhasePreDeploy)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result.Status != tt.expected {
+				t.Errorf("expected status %q, got %q", tt.expected, result.Status)
+			}
+
+			if result.Command != "bin/migrate" {
+				t.Errorf("expected command 'bin/migrate', got %q", result.Command)
+			}
+
+			jobs, _ := fakeClient.BatchV1().Jobs("fuego-myapp").List(context.Background(), metav1.ListOptions{})
+			if len(jobs.Items) != 1 {
+				t.Errorf("expected 1 hook job to be created, got %d", len(jobs.Items))
+			}
+		})
+	}
+}
+
+// hookJobsEnd makes every hook Job report the given condition
+func hookJobsEnd(fakeClient *fake.Clientset, condType batchv1.JobConditionType) {
+	fakeClient.PrependReactor("get", "jobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		name := action.(k8stesting.GetAction).GetName()
+		return true, &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: action.GetNamespace()},
+			Status: batchv1.JobStatus{
+				Conditions: []batchv1.JobCondition{{Type: condType, Status: corev1.ConditionTrue}},
+			},
+		}, nil
+	})
+}
+
+func TestDeploy_KeepsHooksOnError(t *testing.T) {
+	fakeClient := fake.NewClientset()
+	hookJobsEnd(fakeClient, batchv1.JobComplete)
+	fakeClient.PrependReactor("create", "deployments", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("apiserver unavailable")
+	})
+	client := NewClientWithInterface(fakeClient, "fuego-")
+
+	result, err := client.Deploy(context.Background(), &AppConfig{
+		Name:          "myapp",
+		Image:         "myapp:v2",
+		Replicas:      1,
+		Port:          8080,
+		PreDeployHook: "bin/migrate",
+		HookTimeout:   10 * time.Second,
+	})
+	if err == nil {
+		t.Fatal("expected the failed deployment to fail the rollout")
+	}
+	if result == nil || len(result.Hooks) != 1 || result.Hooks[0].Phase != HookPhasePreDeploy || !result.Hooks[0].Succeeded() {
+		t.Fatalf("expected the pre-deploy hook that ran on the result, got %+v", result)
+	}
+}
+
+func TestDeploy_FailedPreDeployHookKeepsEnv(t *testing.T) {
+	ctx := context.Background()
+	fakeClient := fake.NewClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp-env", Namespace: "fuego-myapp"},
+		StringData: map[string]string{"MODE": "v1"},
+	})
+	hookJobsEnd(fakeClient, batchv1.JobFailed)
+	client := NewClientWithInterface(fakeClient, "fuego-")
+
+	result, err := client.Deploy(ctx, &AppConfig{
+		Name:          "myapp",
+		Image:         "myapp:v2",
+		Replicas:      1,
+		Port:          8080,
+		EnvVars:       map[string]string{"MODE": "v2"},
+		PreDeployHook: "bin/migrate",
+		HookTimeout:   10 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Success || result.Reason != FailureHookFailed {
+		t.Fatalf("expected the failed hook to abort the rollout, got %+v", result)
+	}
+
+	env, _ := fakeClient.CoreV1().Secrets("fuego-myapp").Get(ctx, "myapp-env", metav1.GetOptions{})
+	if env.StringData["MODE"] != "v1" {
+		t.Errorf("expected the running pods to keep their env, got %q", env.StringData["MODE"])
+	}
+	hookEnv, err := fakeClient.CoreV1().Secrets("fuego-myapp").Get(ctx, HookSecretName("myapp"), metav1.GetOptions{})
+	if err != nil || hookEnv.StringData["MODE"] != "v2" {
+		t.Errorf("expected the hook to run with the new env, got %v %v", hookEnv, err)
+	}
+}
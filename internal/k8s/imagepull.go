@@ -0,0 +1,133 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Container waiting reasons the kubelet reports for images it cannot pull.
+// ErrImagePull is not included: the kubelet retries it and only backs off
+// once pulls keep failing.
+const (
+	waitingImagePullBackOff = "ImagePullBackOff"
+	waitingInvalidImageName = "InvalidImageName"
+)
+
+// ImagePull is a container stuck pulling its image
+type ImagePull struct {
+	Pod       string
+	Container string
+	Image     string
+	// Message is the kubelet's message, which includes the registry's
+	// error on recent Kubernetes versions
+	Message string
+}
+
+// StuckImagePull returns the first container of pods that is stuck pulling
+// its image
+func StuckImagePull(pods []corev1.Pod) (*ImagePull, bool) {
+	for _, pod := range pods {
+		statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		for _, status := range statuses {
+			waiting := status.State.Waiting
+			if waiting == nil || (waiting.Reason != waitingImagePullBackOff && waiting.Reason != waitingInvalidImageName) {
+				continue
+			}
+			pull := &ImagePull{
+				Pod:       pod.Name,
+				Container: status.Name,
+				Image:     status.Image,
+				Message:   waiting.Message,
+			}
+			if waiting.Reason == waitingInvalidImageName {
+				pull.Message = "InvalidImageName: " + waiting.Message
+			}
+			return pull, true
+		}
+	}
+	return nil, false
+}
+
+// ClassifyImagePull turns the error a registry answered a pull with into a
+// failure reason and a message telling the user what to fix
+func ClassifyImagePull(image, message string) (FailureReason, string) {
+	// The image reference itself may contain digits such as 404
+	lower := strings.ToLower(strings.ReplaceAll(message, image, ""))
+
+	switch {
+	case strings.Contains(lower, "invalidimagename"):
+		return FailureInvalidImage, fmt.Sprintf("the image reference %q is invalid, check its repository name and tag", image)
+	case strings.Contains(lower, "toomanyrequests"), strings.Contains(lower, "429"), strings.Contains(lower, "rate limit"):
+		return FailureImagePullRateLimited, fmt.Sprintf("the registry is rate limiting pulls of %s, retry later or add registry credentials under Settings → Registry to pull with your own quota", image)
+	case strings.Contains(lower, "unauthorized"), strings.Contains(lower, "401"), strings.Contains(lower, "403"),
+		strings.Contains(lower, "authentication required"), strings.Contains(lower, "access denied"),
+		strings.Contains(lower, "insufficient_scope"), strings.Contains(lower, "denied:"):
+		return FailureImagePullUnauthorized, fmt.Sprintf("registry credentials missing or rejected for %s, add them under Settings → Registry", image)
+	case strings.Contains(lower, "not found"), strings.Contains(lower, "manifest unknown"), strings.Contains(lower, "404"),
+		strings.Contains(lower, "name unknown"):
+		return FailureImageNotFound, fmt.Sprintf("the image %s was not found in the registry, check the repository name and tag and that it was pushed", image)
+	}
+
+	return FailureImagePull, fmt.Sprintf("the image %s could not be pulled: %s", image, message)
+}
+
+// imagePullFailure returns a DeployError when a pod of the app is stuck
+// pulling its image. Older kubelets only report "Back-off pulling image", so
+// the cause is then read from the pod's latest Failed event.
+func (c *Client) imagePullFailure(ctx context.Context, cfg *AppConfig) (*DeployError, error) {
+	pods, err := c.clientset.CoreV1().Pods(cfg.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app.kubernetes.io/name=" + cfg.Name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	pull, ok := StuckImagePull(pods.Items)
+	if !ok {
+		return nil, nil
+	}
+
+	message := pull.Message
+	if reason, _ := ClassifyImagePull(pull.Image, message); reason == FailureImagePull {
+		if event := c.latestFailedEvent(ctx, cfg.Namespace, pull.Pod); event != "" {
+			message = event
+		}
+	}
+
+	reason, hint := ClassifyImagePull(pull.Image, message)
+	return &DeployError{
+		Step:    "pull image",
+		Reason:  reason,
+		Message: hint,
+	}, nil
+}
+
+// latestFailedEvent returns the message of the most recent Failed event of
+// a pod, empty if there is none
+func (c *Client) latestFailedEvent(ctx context.Context, namespace, pod string) string {
+	events, err := c.clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: "involvedObject.name=" + pod + ",reason=Failed",
+	})
+	if err != nil {
+		return ""
+	}
+
+	var latest *corev1.Event
+	for i := range events.Items {
+		event := &events.Items[i]
+		if event.InvolvedObject.Name != pod || event.Reason != "Failed" {
+			continue
+		}
+		if latest == nil || event.LastTimestamp.After(latest.LastTimestamp.Time) {
+			latest = event
+		}
+	}
+	if latest == nil {
+		return ""
+	}
+	return latest.Message
+}
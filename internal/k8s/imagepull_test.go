@@ -0,0 +1,108 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func stuckPod(name, reason, message string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "fuego-myapp",
+			Labels:    map[string]string{"app.kubernetes.io/name": "myapp"},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "myapp",
+				Image: "ghcr.io/acme/myapp:404",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason, Message: message}},
+			}},
+		},
+	}
+}
+
+func TestStuckImagePull(t *testing.T) {
+	running := corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+		Name:  "myapp",
+		State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+	}}}}
+	pulling := *stuckPod("myapp-1", "ErrImagePull", "pulling")
+
+	if _, ok := StuckImagePull([]corev1.Pod{running, pulling}); ok {
+		t.Error("expected no stuck pull for running pods and first pull errors")
+	}
+
+	stuck := *stuckPod("myapp-2", waitingImagePullBackOff, "Back-off pulling image")
+	pull, ok := StuckImagePull([]corev1.Pod{running, stuck})
+	if !ok || pull.Pod != "myapp-2" || pull.Container != "myapp" || pull.Image != "ghcr.io/acme/myapp:404" {
+		t.Errorf("unexpected stuck pull %+v", pull)
+	}
+}
+
+func TestClassifyImagePull(t *testing.T) {
+	image := "ghcr.io/acme/myapp:404"
+	tests := []struct {
+		message string
+		want    FailureReason
+	}{
+		{`failed to resolve reference "ghcr.io/acme/myapp:404": 401 Unauthorized`, FailureImagePullUnauthorized},
+		{`pull access denied for ghcr.io/acme/myapp, repository does not exist or may require 'docker login': denied: requested access to the resource is denied`, FailureImagePullUnauthorized},
+		{`failed to resolve reference "ghcr.io/acme/myapp:404": ghcr.io/acme/myapp:404: not found`, FailureImageNotFound},
+		{`manifest unknown: manifest unknown`, FailureImageNotFound},
+		{`toomanyrequests: You have reached your pull rate limit`, FailureImagePullRateLimited},
+		{`InvalidImageName: couldn't parse image reference`, FailureInvalidImage},
+		{`Back-off pulling image "ghcr.io/acme/myapp:404"`, FailureImagePull},
+	}
+
+	for _, tt := range tests {
+		reason, hint := ClassifyImagePull(image, tt.message)
+		if reason != tt.want {
+			t.Errorf("ClassifyImagePull(%q) = %s, want %s", tt.message, reason, tt.want)
+		}
+		if hint == "" {
+			t.Errorf("ClassifyImagePull(%q) returned no hint", tt.message)
+		}
+	}
+}
+
+func TestImagePullFailure(t *testing.T) {
+	cfg := &AppConfig{Name: "myapp", Namespace: "fuego-myapp"}
+	now := time.Now()
+	clientset := fake.NewClientset(
+		stuckPod("myapp-1", waitingImagePullBackOff, `Back-off pulling image "ghcr.io/acme/myapp:404"`),
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "myapp-1.1", Namespace: "fuego-myapp"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "myapp-1"},
+			Reason:         "Failed",
+			Message:        "Failed to pull image: not found",
+			LastTimestamp:  metav1.NewTime(now.Add(-time.Minute)),
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "myapp-1.2", Namespace: "fuego-myapp"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "myapp-1"},
+			Reason:         "Failed",
+			Message:        "Failed to pull image: 401 Unauthorized",
+			LastTimestamp:  metav1.NewTime(now),
+		},
+	)
+	client := NewClientWithInterface(clientset, "fuego-")
+
+	deployErr, err := client.imagePullFailure(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if deployErr == nil || deployErr.Reason != FailureImagePullUnauthorized {
+		t.Errorf("expected the latest event to classify the pull, got %+v", deployErr)
+	}
+
+	deployErr, err = client.imagePullFailure(context.Background(), &AppConfig{Name: "other", Namespace: "fuego-other"})
+	if err != nil || deployErr != nil {
+		t.Errorf("expected no failure, got %+v, %v", deployErr, err)
+	}
+}
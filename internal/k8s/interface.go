@@ -0,0 +1,73 @@
+package k8s
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Interface is what handlers use of a cluster. Client implements it against
+// the Kubernetes API and Fake in memory, so handlers can be tested without a
+// cluster.
+type Interface interface {
+	NamespaceForApp(appName string) string
+	// Ping measures the round trip to the API server
+	Ping(ctx context.Context) (time.Duration, error)
+
+	// Deploys
+	Deploy(ctx context.Context, cfg *AppConfig) (*DeployResult, error)
+	DryRun(ctx context.Context, cfg *AppConfig) ([]ManifestCheck, error)
+	LiveState(ctx context.Context, appName string) (*LiveState, error)
+	CheckCapacity(ctx context.Context, cfg *AppConfig) error
+	CheckArchitectures(ctx context.Context, cfg *AppConfig, imageArchitectures []string) ([]string, error)
+	DeleteApp(ctx context.Context, appName string) error
+
+	// Scaling and restarts
+	ScaleApp(ctx context.Context, appName string, replicas int32) error
+	ScaleProcess(ctx context.Context, appName, processType string, replicas int32) error
+	ProcessReplicas(ctx context.Context, appName, processType string) (int32, error)
+	ResizeProcess(ctx context.Context, appName, processType, cpu, memory string) error
+	ApplyProcesses(ctx context.Context, cfg *AppConfig) error
+	RestartApp(ctx context.Context, appName string) error
+	ApplyEnv(ctx context.Context, cfg *AppConfig) error
+	ApplyOTelCollector(ctx context.Context, cfg *AppConfig) error
+	NodePoolExists(ctx context.Context, nodeSelector map[string]string) (bool, error)
+	ApplyPlacement(ctx context.Context, appName string, p *Placement) error
+
+	// Status, pods and metrics
+	GetAppStatus(ctx context.Context, appName string) (*AppStatus, error)
+	ListAppPods(ctx context.Context, appName string) ([]PodInfo, error)
+	DeletePod(ctx context.Context, appName, podName string) error
+	ListCrashes(ctx context.Context, appName string) ([]ContainerCrash, error)
+	GetAppMetrics(ctx context.Context, appName string) (*AppMetrics, error)
+	ListPodUsage(ctx context.Context, appName string) ([]PodUsage, error)
+	ListCertificates(ctx context.Context, appName string) ([]CertificateStatus, error)
+
+	// Logs
+	StreamLogs(ctx context.Context, appName string, opts LogStreamOptions, outputCh chan<- LogLine) error
+	GetRecentLogs(ctx context.Context, appName string, tailLines int64) ([]LogLine, error)
+	LogSources(ctx context.Context, appName string) ([]LogSource, error)
+	OpenLogs(ctx context.Context, appName string, source LogSource, since time.Time) (io.ReadCloser, error)
+
+	// Cron jobs
+	ApplyCronJob(ctx context.Context, cfg *AppConfig, cron *CronJobConfig) error
+	DeleteCronJob(ctx context.Context, appName, cronName string) error
+	ListCronRuns(ctx context.Context, appName, cronName string, logLines int64) ([]CronRun, error)
+
+	// Traffic
+	ApplyMTLS(ctx context.Context, cfg *AppConfig) error
+	ApplyTLSPolicy(ctx context.Context, cfg *AppConfig) error
+	ApplyMirror(ctx context.Context, cfg *AppConfig) error
+	RemoveMirror(ctx context.Context, appName string) error
+	ApplyEgress(ctx context.Context, cfg *AppConfig) error
+	ApplyRouter(ctx context.Context, cfg *RouterConfig) error
+	DeleteRouter(ctx context.Context, routerName string) error
+	ApplyTrafficSplit(ctx context.Context, cfg *TrafficSplitConfig) error
+	DeleteTrafficSplit(ctx context.Context, splitName string) error
+
+	// Backups
+	ExportNamespaces(ctx context.Context) ([]NamespaceBackup, error)
+	RestoreNamespace(ctx context.Context, backup NamespaceBackup) error
+}
+
+var _ Interface = (*Client)(nil)
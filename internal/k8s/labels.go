@@ -0,0 +1,75 @@
+package k8s
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// MaxAppLabels bounds the user-defined labels of an app
+const MaxAppLabels = 20
+
+// reservedLabelDomains are label prefixes owned by Kubernetes and the
+// platform; user labels under them could break selectors
+var reservedLabelDomains = []string{"kubernetes.io", "k8s.io", "nexo.build"}
+
+// ValidateAppLabels checks that user-defined labels are valid Kubernetes
+// labels outside the reserved prefixes
+func ValidateAppLabels(labels map[string]string) error {
+	if len(labels) > MaxAppLabels {
+		return fmt.Errorf("an app may have at most %d labels", MaxAppLabels)
+	}
+	for key, value := range labels {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("invalid label key %q: %s", key, strings.Join(errs, ", "))
+		}
+		if isReservedLabel(key) {
+			return fmt.Errorf("label key %q uses a reserved prefix", key)
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Errorf("invalid label value %q: %s", value, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
+
+// isReservedLabel reports whether a label key is under a prefix owned by
+// Kubernetes or the platform
+func isReservedLabel(key string) bool {
+	prefix, _, ok := strings.Cut(key, "/")
+	if !ok {
+		return false
+	}
+	for _, domain := range reservedLabelDomains {
+		if prefix == domain || strings.HasSuffix(prefix, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// appLabels returns the platform labels of a resource together with the
+// user labels of its app. Platform labels win, so selectors keep working.
+// Selectors themselves must only use the platform labels: they are immutable
+// and user labels change.
+func appLabels(cfg *AppConfig, base map[string]string) map[string]string {
+	merged := make(map[string]string, len(cfg.Labels)+len(base))
+	for key, value := range cfg.Labels {
+		merged[key] = value
+	}
+	for key, value := range base {
+		merged[key] = value
+	}
+	return merged
+}
+
+// unstructuredLabels converts labels for the metadata of an unstructured
+// resource
+func unstructuredLabels(labels map[string]string) map[string]any {
+	converted := make(map[string]any, len(labels))
+	for key, value := range labels {
+		converted[key] = value
+	}
+	return converted
+}
@@ -0,0 +1,127 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestValidateAppLabels(t *testing.T) {
+	tooMany := map[string]string{}
+	for i := 0; i <= MaxAppLabels; i++ {
+		tooMany[fmt.Sprintf("label-%d", i)] = "x"
+	}
+
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		wantErr bool
+	}{
+		{"empty", map[string]string{}, false},
+		{"plain", map[string]string{"team": "payments", "env": "production"}, false},
+		{"own prefix", map[string]string{"acme.com/cost-center": "cc-1234"}, false},
+		{"empty value", map[string]string{"team": ""}, false},
+		{"bad key", map[string]string{"bad key": "x"}, true},
+		{"bad value", map[string]string{"team": "not valid!"}, true},
+		{"kubernetes prefix", map[string]string{"app.kubernetes.io/name": "other"}, true},
+		{"k8s prefix", map[string]string{"k8s.io/team": "x"}, true},
+		{"platform prefix", map[string]string{"nexo.build/process": "web"}, true},
+		{"too many", tooMany, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAppLabels(tt.labels)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAppLabels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenerate_AppLabels(t *testing.T) {
+	cfg := &AppConfig{
+		Name:         "myapp",
+		Namespace:    "fuego-myapp",
+		Image:        "myimage:latest",
+		Replicas:     1,
+		Port:         3000,
+		DomainSuffix: "nexo.build",
+		Labels: map[string]string{
+			"team":                   "payments",
+			"app.kubernetes.io/name": "spoofed",
+		},
+		Processes: []ProcessConfig{{Type: "worker", Command: "./worker", Replicas: 1}},
+	}
+
+	deployment := GenerateDeployment(cfg)
+	worker := GenerateProcessDeployment(cfg, &cfg.Processes[0])
+	cron := GenerateCronJob(cfg, &CronJobConfig{Name: "cleanup", Schedule: "0 * * * *", Command: "./cleanup"})
+
+	resources := map[string]map[string]string{
+		"namespace":    GenerateNamespace(cfg).Labels,
+		"secret":       GenerateSecret(cfg).Labels,
+		"deployment":   deployment.Labels,
+		"pod template": deployment.Spec.Template.Labels,
+		"worker":       worker.Labels,
+		"worker pods":  worker.Spec.Template.Labels,
+		"service":      GenerateService(cfg).Labels,
+		"ingress":      GenerateIngress(cfg).Labels,
+		"cron job":     cron.Labels,
+		"hook job":     GenerateHookJob(cfg, HookPhasePreDeploy, "./migrate", "myapp-pre-deploy").Labels,
+	}
+	for name, labels := range resources {
+		if labels["team"] != "payments" {
+			t.Errorf("%s: expected team label, got %v", name, labels)
+		}
+		if labels["app.kubernetes.io/name"] != "myapp" {
+			t.Errorf("%s: platform label must win, got %q", name, labels["app.kubernetes.io/name"])
+		}
+	}
+
+	// Selectors are immutable and must not pick up user labels
+	if _, ok := deployment.Spec.Selector.MatchLabels["team"]; ok {
+		t.Errorf("deployment selector must not include user labels: %v", deployment.Spec.Selector.MatchLabels)
+	}
+	if _, ok := worker.Spec.Selector.MatchLabels["team"]; ok {
+		t.Errorf("worker selector must not include user labels: %v", worker.Spec.Selector.MatchLabels)
+	}
+}
+
+func TestEnsureNamespace_UpdatesLabels(t *testing.T) {
+	fakeClient := fake.NewClientset(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "fuego-myapp",
+			Labels: map[string]string{
+				"kubernetes.io/metadata.name":  "fuego-myapp",
+				"app.kubernetes.io/name":       "myapp",
+				"app.kubernetes.io/managed-by": "nexo-cloud",
+				"team":                         "growth",
+			},
+		},
+	})
+	client := NewClientWithInterface(fakeClient, "fuego-")
+
+	cfg := &AppConfig{Name: "myapp", Namespace: "fuego-myapp", Labels: map[string]string{"env": "production"}}
+	if err := client.ensureNamespace(context.Background(), cfg); err != nil {
+		t.Fatalf("ensureNamespace failed: %v", err)
+	}
+
+	ns, err := fakeClient.CoreV1().Namespaces().Get(context.Background(), "fuego-myapp", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get namespace: %v", err)
+	}
+	if ns.Labels["env"] != "production" {
+		t.Errorf("expected env label, got %v", ns.Labels)
+	}
+	if _, ok := ns.Labels["team"]; ok {
+		t.Errorf("expected removed label to be dropped, got %v", ns.Labels)
+	}
+	if ns.Labels["kubernetes.io/metadata.name"] != "fuego-myapp" {
+		t.Errorf("expected system label to be kept, got %v", ns.Labels)
+	}
+}
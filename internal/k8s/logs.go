@@ -5,8 +5,11 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sort"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type LogLine struct {
@@ -130,3 +133,50 @@ func (c *Client) GetRecentLogs(ctx context.Context, appName string, tailLines in
 
 	return logs, nil
 }
+
+// LogSource is a container whose retained logs can be read
+type LogSource struct {
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+}
+
+// LogSources lists the containers of an app's pods, sorted so that the same
+// pods always produce the same list
+func (c *Client) LogSources(ctx context.Context, appName string) ([]LogSource, error) {
+	pods, err := c.GetPods(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pods: %w", err)
+	}
+
+	var sources []LogSource
+	for _, pod := range pods.Items {
+		for _, container := range pod.Spec.Containers {
+			sources = append(sources, LogSource{Pod: pod.Name, Container: container.Name})
+		}
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		if sources[i].Pod != sources[j].Pod {
+			return sources[i].Pod < sources[j].Pod
+		}
+		return sources[i].Container < sources[j].Container
+	})
+	return sources, nil
+}
+
+// OpenLogs opens the logs a container has retained since a time. Each line
+// starts with its RFC3339 timestamp.
+func (c *Client) OpenLogs(ctx context.Context, appName string, source LogSource, since time.Time) (io.ReadCloser, error) {
+	sinceTime := metav1.NewTime(since)
+	logOpts := &corev1.PodLogOptions{
+		Container:  source.Container,
+		SinceTime:  &sinceTime,
+		Timestamps: true,
+	}
+
+	req := c.clientset.CoreV1().Pods(c.NamespaceForApp(appName)).GetLogs(source.Pod, logOpts)
+	stream, err := req.Stream(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open logs of %s/%s: %w", source.Pod, source.Container, err)
+	}
+	return stream, nil
+}
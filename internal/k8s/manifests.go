@@ -1,6 +1,8 @@
 package k8s
 
 import (
+	"time"
+
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
@@ -17,16 +19,78 @@ type AppConfig struct {
 	EnvVars      map[string]string
 	Domain       string
 	DomainSuffix string
+
+	// HealthCheckPath is requested by the web process's liveness and
+	// readiness probes, /api/health when empty
+	HealthCheckPath string
+
+	// Deploy hooks run as Jobs before the rollout and after promotion
+	PreDeployHook  string
+	PostDeployHook string
+	HookTimeout    time.Duration
+
+	// Scheduled commands run as CronJobs with the app image
+	CronJobs []CronJobConfig
+
+	// Process types from the app formation; a "web" entry customizes the
+	// web Deployment, every other type becomes its own Deployment
+	Processes []ProcessConfig
+
+	// Placement pins all app pods to a dedicated node pool
+	Placement *Placement
+
+	// Architectures are the CPU architectures of the image that pods are
+	// scheduled onto, any node when empty
+	Architectures []string
+
+	// MTLS requires clients to present a platform-issued certificate
+	MTLS *MTLSConfig
+
+	// Mirror copies a share of the app's requests to another app
+	Mirror *MirrorConfig
+
+	// Egress sends the app's outbound traffic from a static IP
+	Egress *EgressConfig
+
+	// PullSecret is the credential pods pull the image with, for images in
+	// the platform registry
+	PullSecret *PullSecretConfig
+
+	// Poolers run PgBouncer sidecars for the app's pooled databases;
+	// PoolerImage overrides their image
+	Poolers     []PoolerConfig
+	PoolerImage string
+
+	// OTelCollector runs an OpenTelemetry Collector for the app;
+	// OTelCollectorImage overrides its image
+	OTelCollector      *OTelCollectorConfig
+	OTelCollectorImage string
+
+	// Labels are user-defined labels added to every resource of the app
+	Labels map[string]string
+
+	// TLSPolicy sets the redirect and HSTS behavior of the app's custom
+	// domain
+	TLSPolicy *TLSPolicy
+
+	// DualStack gives the app's Service an IPv6 cluster IP next to the IPv4
+	// one, on clusters with both families enabled
+	DualStack bool
+
+	// Tunnel serves the app through a Cloudflare Tunnel instead of an
+	// Ingress; CloudflaredImage overrides the image of its connectors
+	Tunnel           *TunnelConfig
+	CloudflaredImage string
 }
 
 func GenerateNamespace(cfg *AppConfig) *corev1.Namespace {
 	return &corev1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: cfg.Namespace,
-			Labels: map[string]string{
+			Labels: appLabels(cfg, map[string]string{
 				"app.kubernetes.io/name":       cfg.Name,
 				"app.kubernetes.io/managed-by": "nexo-cloud",
-			},
+			}),
 		},
 	}
 }
@@ -41,10 +105,10 @@ func GenerateSecret(cfg *AppConfig) *corev1.Secret {
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      cfg.Name + "-env",
 			Namespace: cfg.Namespace,
-			Labels: map[string]string{
+			Labels: appLabels(cfg, map[string]string{
 				"app.kubernetes.io/name":       cfg.Name,
 				"app.kubernetes.io/managed-by": "nexo-cloud",
-			},
+			}),
 		},
 		Type:       corev1.SecretTypeOpaque,
 		StringData: stringData,
@@ -57,26 +121,42 @@ func GenerateDeployment(cfg *AppConfig) *appsv1.Deployment {
 		"app.kubernetes.io/managed-by": "nexo-cloud",
 	}
 
-	return &appsv1.Deployment{
+	// The selector predates process types and is immutable, so the process
+	// label is only added to the pod template
+	podLabels := processLabels(cfg.Name, ProcessTypeWeb)
+
+	replicas := cfg.Replicas
+	var command []string
+	resources := corev1.ResourceRequirements{}
+	if web := cfg.Process(ProcessTypeWeb); web != nil {
+		replicas = web.Replicas
+		if web.Command != "" {
+			command = []string{"/bin/sh", "-c", web.Command}
+		}
+		resources = processResources(web)
+	}
+
+	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      cfg.Name,
 			Namespace: cfg.Namespace,
-			Labels:    labels,
+			Labels:    appLabels(cfg, labels),
 		},
 		Spec: appsv1.DeploymentSpec{
-			Replicas: &cfg.Replicas,
+			Replicas: &replicas,
 			Selector: &metav1.LabelSelector{
 				MatchLabels: labels,
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
+					Labels: appLabels(cfg, podLabels),
 				},
 				Spec: corev1.PodSpec{
 					Containers: []corev1.Container{
 						{
-							Name:  cfg.Name,
-							Image: cfg.Image,
+							Name:    cfg.Name,
+							Image:   cfg.Image,
+							Command: command,
 							Ports: []corev1.ContainerPort{
 								{
 									ContainerPort: cfg.Port,
@@ -92,11 +172,11 @@ func GenerateDeployment(cfg *AppConfig) *appsv1.Deployment {
 									},
 								},
 							},
-							Resources: corev1.ResourceRequirements{},
+							Resources: resources,
 							LivenessProbe: &corev1.Probe{
 								ProbeHandler: corev1.ProbeHandler{
 									HTTPGet: &corev1.HTTPGetAction{
-										Path: "/api/health",
+										Path: healthCheckPath(cfg),
 										Port: intstr.FromInt32(cfg.Port),
 									},
 								},
@@ -106,7 +186,7 @@ func GenerateDeployment(cfg *AppConfig) *appsv1.Deployment {
 							ReadinessProbe: &corev1.Probe{
 								ProbeHandler: corev1.ProbeHandler{
 									HTTPGet: &corev1.HTTPGetAction{
-										Path: "/api/health",
+										Path: healthCheckPath(cfg),
 										Port: intstr.FromInt32(cfg.Port),
 									},
 								},
@@ -119,6 +199,13 @@ func GenerateDeployment(cfg *AppConfig) *appsv1.Deployment {
 			},
 		},
 	}
+
+	cfg.Placement.applyTo(&deployment.Spec.Template.Spec)
+	applyArchitectures(&deployment.Spec.Template.Spec, cfg.Architectures)
+	applyPoolers(&deployment.Spec.Template.Spec, cfg)
+	applyImagePullSecret(&deployment.Spec.Template.Spec, cfg)
+
+	return deployment
 }
 
 func GenerateService(cfg *AppConfig) *corev1.Service {
@@ -131,10 +218,10 @@ func GenerateService(cfg *AppConfig) *corev1.Service {
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      cfg.Name,
 			Namespace: cfg.Namespace,
-			Labels:    labels,
+			Labels:    appLabels(cfg, labels),
 		},
 		Spec: corev1.ServiceSpec{
-			Selector: labels,
+			Selector: processLabels(cfg.Name, ProcessTypeWeb),
 			Ports: []corev1.ServicePort{
 				{
 					Name:       "http",
@@ -143,11 +230,38 @@ func GenerateService(cfg *AppConfig) *corev1.Service {
 					Protocol:   corev1.ProtocolTCP,
 				},
 			},
-			Type: corev1.ServiceTypeClusterIP,
+			Type:           corev1.ServiceTypeClusterIP,
+			IPFamilyPolicy: serviceIPFamilyPolicy(cfg),
 		},
 	}
 }
 
+// serviceIPFamilyPolicy prefers both families for dual-stack apps, so a
+// cluster that only has one still serves them
+func serviceIPFamilyPolicy(cfg *AppConfig) *corev1.IPFamilyPolicy {
+	policy := corev1.IPFamilyPolicySingleStack
+	if cfg.DualStack {
+		policy = corev1.IPFamilyPolicyPreferDualStack
+	}
+	return &policy
+}
+
+// healthCheckPath returns the path the web process's probes request
+func healthCheckPath(cfg *AppConfig) string {
+	if cfg.HealthCheckPath != "" {
+		return cfg.HealthCheckPath
+	}
+	return "/api/health"
+}
+
+// appHost returns the host the app's ingress serves
+func appHost(cfg *AppConfig) string {
+	if cfg.Domain != "" {
+		return cfg.Domain
+	}
+	return cfg.Name + "." + cfg.DomainSuffix
+}
+
 func GenerateIngress(cfg *AppConfig) *networkingv1.Ingress {
 	labels := map[string]string{
 		"app.kubernetes.io/name":       cfg.Name,
@@ -157,20 +271,27 @@ func GenerateIngress(cfg *AppConfig) *networkingv1.Ingress {
 	pathType := networkingv1.PathTypePrefix
 	ingressClassName := "traefik"
 
-	host := cfg.Name + "." + cfg.DomainSuffix
-	if cfg.Domain != "" {
-		host = cfg.Domain
+	host := appHost(cfg)
+
+	annotations := map[string]string{
+		"cert-manager.io/cluster-issuer":           "letsencrypt-prod",
+		"traefik.ingress.kubernetes.io/router.tls": "true",
+	}
+	if cfg.MTLS != nil {
+		for k, v := range mtlsIngressAnnotations(cfg) {
+			annotations[k] = v
+		}
+	}
+	if hstsEnabled(cfg) {
+		withMiddleware(annotations, traefikRef(cfg, hstsMiddlewareName(cfg.Name)))
 	}
 
 	return &networkingv1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      cfg.Name,
-			Namespace: cfg.Namespace,
-			Labels:    labels,
-			Annotations: map[string]string{
-				"cert-manager.io/cluster-issuer":           "letsencrypt-prod",
-				"traefik.ingress.kubernetes.io/router.tls": "true",
-			},
+			Name:        cfg.Name,
+			Namespace:   cfg.Namespace,
+			Labels:      appLabels(cfg, labels),
+			Annotations: annotations,
 		},
 		Spec: networkingv1.IngressSpec{
 			IngressClassName: &ingressClassName,
@@ -160,6 +160,19 @@ func TestGenerateService(t *testing.T) {
 	}
 }
 
+func TestGenerateService_DualStack(t *testing.T) {
+	cfg := &AppConfig{Name: "myapp", Namespace: "fuego-myapp", Port: 8080}
+
+	if policy := GenerateService(cfg).Spec.IPFamilyPolicy; policy == nil || *policy != corev1.IPFamilyPolicySingleStack {
+		t.Errorf("expected single stack by default, got %v", policy)
+	}
+
+	cfg.DualStack = true
+	if policy := GenerateService(cfg).Spec.IPFamilyPolicy; policy == nil || *policy != corev1.IPFamilyPolicyPreferDualStack {
+		t.Errorf("expected dual stack to be preferred, got %v", policy)
+	}
+}
+
 func TestGenerateIngress(t *testing.T) {
 	t.Run("with domain suffix", func(t *testing.T) {
 		cfg := &AppConfig{
@@ -250,6 +263,25 @@ func TestGenerateDeploymentDefaults(t *testing.T) {
 	}
 }
 
+func TestGenerateDeploymentHealthCheckPath(t *testing.T) {
+	cfg := &AppConfig{
+		Name:            "testapp",
+		Namespace:       "fuego-testapp",
+		Image:           "nginx:latest",
+		Replicas:        1,
+		Port:            80,
+		HealthCheckPath: "/healthz",
+	}
+
+	container := GenerateDeployment(cfg).Spec.Template.Spec.Containers[0]
+	if container.LivenessProbe.HTTPGet.Path != "/healthz" {
+		t.Errorf("expected liveness probe path '/healthz', got %q", container.LivenessProbe.HTTPGet.Path)
+	}
+	if container.ReadinessProbe.HTTPGet.Path != "/healthz" {
+		t.Errorf("expected readiness probe path '/healthz', got %q", container.ReadinessProbe.HTTPGet.Path)
+	}
+}
+
 func TestAppConfigValidation(t *testing.T) {
 	// Test with minimal config
 	cfg := &AppConfig{
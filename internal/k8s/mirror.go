@@ -0,0 +1,181 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Mirroring limits. Mirrors are meant for short experiments against a
+// staging app, so they always expire.
+const (
+	DefaultMirrorDuration = time.Hour
+	MaxMirrorDuration     = 24 * time.Hour
+	// MirrorMaxBodySize caps the request bodies Traefik buffers to copy;
+	// larger requests reach the app but are not mirrored
+	MirrorMaxBodySize = 1 << 20
+	mirrorLabel       = "nexo.build/mirror"
+	// mirrorRoutePriority makes the mirroring IngressRoute win over the
+	// app's Ingress for the same host
+	mirrorRoutePriority = 10000
+)
+
+// ErrMirrorUnavailable is returned when the client cannot manage Traefik
+// resources
+var ErrMirrorUnavailable = errors.New("mirroring resources are not available")
+
+// MirrorConfig copies Percent of an app's requests to TargetApp. Copies are
+// fire-and-forget: the target's responses are discarded and its failures
+// never affect the app.
+type MirrorConfig struct {
+	TargetApp string
+	Percent   int32
+}
+
+// ValidateMirror checks a mirror of appName's traffic
+func ValidateMirror(appName string, m *MirrorConfig) error {
+	if m.TargetApp == "" {
+		return errors.New("target app is required")
+	}
+	if m.TargetApp == appName {
+		return errors.New("an app cannot mirror traffic to itself")
+	}
+	if m.Percent < 1 || m.Percent > 100 {
+		return errors.New("percent must be between 1 and 100")
+	}
+	return nil
+}
+
+func mirrorResourceName(appName string) string {
+	return appName + "-mirror"
+}
+
+// GenerateMirror returns the proxy service for the target app, the mirroring
+// TraefikService and the IngressRoute sending the app host through it. The
+// route reuses the app's certificate and keeps mTLS enforced.
+func (c *Client) GenerateMirror(cfg *AppConfig) (*corev1.Service, []*unstructured.Unstructured) {
+	proxy := c.proxyService(cfg.Namespace, cfg.Mirror.TargetApp, map[string]string{
+		mirrorLabel:                    cfg.Name,
+		"app.kubernetes.io/managed-by": "nexo-cloud",
+	})
+
+	metadata := map[string]any{
+		"name":      mirrorResourceName(cfg.Name),
+		"namespace": cfg.Namespace,
+		"labels": unstructuredLabels(appLabels(cfg, map[string]string{
+			"app.kubernetes.io/name":       cfg.Name,
+			"app.kubernetes.io/managed-by": "nexo-cloud",
+		})),
+	}
+
+	route := map[string]any{
+		"match":    fmt.Sprintf("Host(`%s`)", appHost(cfg)),
+		"kind":     "Rule",
+		"priority": int64(mirrorRoutePriority),
+		"services": []any{
+			map[string]any{"name": mirrorResourceName(cfg.Name), "kind": "TraefikService"},
+		},
+	}
+	tls := map[string]any{"secretName": cfg.Name + "-tls"}
+	if cfg.MTLS != nil {
+		route["middlewares"] = []any{
+			map[string]any{"name": mtlsResourceName(cfg.Name)},
+			map[string]any{"name": mtlsAuthMiddlewareName(cfg.Name)},
+		}
+		tls["options"] = map[string]any{"name": mtlsResourceName(cfg.Name)}
+	}
+
+	return proxy, []*unstructured.Unstructured{
+		{Object: map[string]any{
+			"apiVersion": "traefik.io/v1alpha1",
+			"kind":       "TraefikService",
+			"metadata":   metadata,
+			"spec": map[string]any{
+				"mirroring": map[string]any{
+					"name":        cfg.Name,
+					"port":        int64(80),
+					"maxBodySize": int64(MirrorMaxBodySize),
+					"mirrors": []any{
+						map[string]any{
+							"name":    proxy.Name,
+							"port":    int64(80),
+							"percent": int64(cfg.Mirror.Percent),
+						},
+					},
+				},
+			},
+		}},
+		{Object: map[string]any{
+			"apiVersion": "traefik.io/v1alpha1",
+			"kind":       "IngressRoute",
+			"metadata":   metadata,
+			"spec": map[string]any{
+				"entryPoints": []any{"websecure"},
+				"routes":      []any{route},
+				"tls":         tls,
+			},
+		}},
+	}
+}
+
+// ApplyMirror starts, updates or stops mirroring an app's traffic according
+// to cfg.Mirror, without a full deploy
+func (c *Client) ApplyMirror(ctx context.Context, cfg *AppConfig) error {
+	cfg.Namespace = c.NamespaceForApp(cfg.Name)
+
+	if cfg.Mirror == nil {
+		return c.RemoveMirror(ctx, cfg.Name)
+	}
+	return c.applyMirror(ctx, cfg)
+}
+
+func (c *Client) applyMirror(ctx context.Context, cfg *AppConfig) error {
+	if c.dynamic == nil {
+		return ErrMirrorUnavailable
+	}
+
+	proxy, objects := c.GenerateMirror(cfg)
+	wanted := []*corev1.Service{proxy}
+	if err := c.applyProxyServices(ctx, cfg.Namespace, wanted); err != nil {
+		return err
+	}
+
+	for _, obj := range objects {
+		gvr := TraefikServiceGVR
+		if obj.GetKind() == "IngressRoute" {
+			gvr = IngressRouteGVR
+		}
+		if err := c.applyUnstructured(ctx, gvr, obj); err != nil {
+			return fmt.Errorf("failed to apply %s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+	}
+
+	// Drop the proxy of a previous target once nothing mirrors to it
+	return c.pruneProxyServices(ctx, cfg.Namespace, mirrorLabel+"="+cfg.Name, wanted)
+}
+
+// RemoveMirror stops mirroring an app's traffic. The IngressRoute goes first
+// so requests fall back to the app's Ingress before the TraefikService it
+// points to disappears.
+func (c *Client) RemoveMirror(ctx context.Context, appName string) error {
+	if c.dynamic == nil {
+		return nil
+	}
+	namespace := c.NamespaceForApp(appName)
+
+	for _, gvr := range []schema.GroupVersionResource{IngressRouteGVR, TraefikServiceGVR} {
+		err := c.dynamic.Resource(gvr).Namespace(namespace).Delete(ctx, mirrorResourceName(appName), metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete mirror %s: %w", gvr.Resource, err)
+		}
+	}
+
+	return c.pruneProxyServices(ctx, namespace, mirrorLabel+"="+appName, nil)
+}
@@ -0,0 +1,112 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestValidateMirror(t *testing.T) {
+	tests := []struct {
+		name   string
+		mirror MirrorConfig
+		valid  bool
+	}{
+		{"ten percent", MirrorConfig{"web-staging", 10}, true},
+		{"everything", MirrorConfig{"web-staging", 100}, true},
+		{"missing target", MirrorConfig{"", 10}, false},
+		{"itself", MirrorConfig{"web", 10}, false},
+		{"zero percent", MirrorConfig{"web-staging", 0}, false},
+		{"over 100 percent", MirrorConfig{"web-staging", 101}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateMirror("web", &tt.mirror)
+			if (err == nil) != tt.valid {
+				t.Errorf("expected valid=%v, got %v", tt.valid, err)
+			}
+		})
+	}
+}
+
+func TestApplyMirror(t *testing.T) {
+	clientset := fake.NewClientset()
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	client := NewClientWithDynamic(clientset, dynamicClient, "fuego-")
+	ctx := context.Background()
+
+	cfg := &AppConfig{
+		Name:         "web",
+		DomainSuffix: "test.local",
+		MTLS:         &MTLSConfig{},
+		Mirror:       &MirrorConfig{TargetApp: "web-staging", Percent: 10},
+	}
+	if err := client.ApplyMirror(ctx, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	service, err := dynamicClient.Resource(TraefikServiceGVR).Namespace("fuego-web").Get(ctx, "web-mirror", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected TraefikService: %v", err)
+	}
+	mirrors, _, _ := unstructured.NestedSlice(service.Object, "spec", "mirroring", "mirrors")
+	if len(mirrors) != 1 || mirrors[0].(map[string]any)["percent"] != int64(10) || mirrors[0].(map[string]any)["name"] != "app-web-staging" {
+		t.Errorf("unexpected mirrors %v", mirrors)
+	}
+	if name, _, _ := unstructured.NestedString(service.Object, "spec", "mirroring", "name"); name != "web" {
+		t.Errorf("expected the app service to stay the main service, got %q", name)
+	}
+
+	route, err := dynamicClient.Resource(IngressRouteGVR).Namespace("fuego-web").Get(ctx, "web-mirror", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected IngressRoute: %v", err)
+	}
+	routes, _, _ := unstructured.NestedSlice(route.Object, "spec", "routes")
+	if len(routes) != 1 || routes[0].(map[string]any)["match"] != "Host(`web.test.local`)" {
+		t.Errorf("unexpected routes %v", routes)
+	}
+	if _, ok := routes[0].(map[string]any)["middlewares"]; !ok {
+		t.Error("expected mirroring to keep mTLS enforced")
+	}
+
+	if _, err := clientset.CoreV1().Services("fuego-web").Get(ctx, "app-web-staging", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected proxy service for web-staging: %v", err)
+	}
+
+	// Switching targets drops the old proxy service
+	cfg.Mirror = &MirrorConfig{TargetApp: "web-canary", Percent: 5}
+	if err := client.ApplyMirror(ctx, cfg); err != nil {
+		t.Fatalf("unexpected error on update: %v", err)
+	}
+	if _, err := clientset.CoreV1().Services("fuego-web").Get(ctx, "app-web-staging", metav1.GetOptions{}); !k8serrors.IsNotFound(err) {
+		t.Errorf("expected old proxy service to be removed, got %v", err)
+	}
+
+	cfg.Mirror = nil
+	if err := client.ApplyMirror(ctx, cfg); err != nil {
+		t.Fatalf("unexpected error on removal: %v", err)
+	}
+	if _, err := dynamicClient.Resource(IngressRouteGVR).Namespace("fuego-web").Get(ctx, "web-mirror", metav1.GetOptions{}); !k8serrors.IsNotFound(err) {
+		t.Errorf("expected IngressRoute to be removed, got %v", err)
+	}
+	if _, err := clientset.CoreV1().Services("fuego-web").Get(ctx, "app-web-canary", metav1.GetOptions{}); !k8serrors.IsNotFound(err) {
+		t.Errorf("expected proxy service to be removed, got %v", err)
+	}
+}
+
+func TestApplyMirror_Unavailable(t *testing.T) {
+	client := NewClientWithInterface(fake.NewClientset(), "fuego-")
+
+	err := client.ApplyMirror(context.Background(), &AppConfig{Name: "web", Mirror: &MirrorConfig{TargetApp: "web-staging", Percent: 10}})
+	if !errors.Is(err, ErrMirrorUnavailable) {
+		t.Errorf("expected ErrMirrorUnavailable, got %v", err)
+	}
+}
@@ -0,0 +1,239 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Traefik custom resources used to enforce mTLS at the ingress
+var (
+	TLSOptionGVR = schema.GroupVersionResource{
+		Group:    "traefik.io",
+		Version:  "v1alpha1",
+		Resource: "tlsoptions",
+	}
+	MiddlewareGVR = schema.GroupVersionResource{
+		Group:    "traefik.io",
+		Version:  "v1alpha1",
+		Resource: "middlewares",
+	}
+)
+
+// ClientCertHeader is the header Traefik forwards the verified client certificate in
+const ClientCertHeader = "X-Forwarded-Tls-Client-Cert"
+
+// ErrMTLSUnavailable is returned when mTLS is requested but the client cannot
+// manage Traefik resources
+var ErrMTLSUnavailable = errors.New("mTLS resources are not available")
+
+// MTLSConfig requires clients of an app to present a certificate issued by
+// the platform CA. Traefik verifies the chain; revocation is checked by
+// forwarding the certificate to VerifyURL.
+type MTLSConfig struct {
+	CACert    []byte
+	CRL       []byte
+	VerifyURL string
+}
+
+// MTLSSecretName is the secret holding an app's client CA bundle and CRL
+func MTLSSecretName(appName string) string {
+	return appName + "-mtls-ca"
+}
+
+func mtlsResourceName(appName string) string {
+	return appName + "-mtls"
+}
+
+func mtlsAuthMiddlewareName(appName string) string {
+	return appName + "-mtls-auth"
+}
+
+// mtlsIngressAnnotations points the app router at its TLS option and middlewares
+func mtlsIngressAnnotations(cfg *AppConfig) map[string]string {
+	return map[string]string{
+		"traefik.ingress.kubernetes.io/router.tls.options": traefikRef(cfg, mtlsResourceName(cfg.Name)),
+		"traefik.ingress.kubernetes.io/router.middlewares": traefikRef(cfg, mtlsResourceName(cfg.Name)) + "," + traefikRef(cfg, mtlsAuthMiddlewareName(cfg.Name)),
+	}
+}
+
+// GenerateMTLSSecret stores the CA bundle Traefik verifies clients against
+func GenerateMTLSSecret(cfg *AppConfig) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      MTLSSecretName(cfg.Name),
+			Namespace: cfg.Namespace,
+			Labels: appLabels(cfg, map[string]string{
+				"app.kubernetes.io/name":       cfg.Name,
+				"app.kubernetes.io/managed-by": "nexo-cloud",
+			}),
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"tls.ca": cfg.MTLS.CACert,
+			"ca.crl": cfg.MTLS.CRL,
+		},
+	}
+}
+
+// GenerateMTLSResources returns the Traefik TLSOption requiring client
+// certificates and the middlewares forwarding them for revocation checks
+func GenerateMTLSResources(cfg *AppConfig) []*unstructured.Unstructured {
+	metadata := func(name string) map[string]any {
+		return map[string]any{
+			"name":      name,
+			"namespace": cfg.Namespace,
+			"labels": unstructuredLabels(appLabels(cfg, map[string]string{
+				"app.kubernetes.io/name":       cfg.Name,
+				"app.kubernetes.io/managed-by": "nexo-cloud",
+			})),
+		}
+	}
+
+	return []*unstructured.Unstructured{
+		{Object: map[string]any{
+			"apiVersion": "traefik.io/v1alpha1",
+			"kind":       "TLSOption",
+			"metadata":   metadata(mtlsResourceName(cfg.Name)),
+			"spec": map[string]any{
+				"minVersion": "VersionTLS12",
+				"clientAuth": map[string]any{
+					"secretNames":    []any{MTLSSecretName(cfg.Name)},
+					"clientAuthType": "RequireAndVerifyClientCert",
+				},
+			},
+		}},
+		{Object: map[string]any{
+			"apiVersion": "traefik.io/v1alpha1",
+			"kind":       "Middleware",
+			"metadata":   metadata(mtlsResourceName(cfg.Name)),
+			"spec": map[string]any{
+				"passTLSClientCert": map[string]any{"pem": true},
+			},
+		}},
+		{Object: map[string]any{
+			"apiVersion": "traefik.io/v1alpha1",
+			"kind":       "Middleware",
+			"metadata":   metadata(mtlsAuthMiddlewareName(cfg.Name)),
+			"spec": map[string]any{
+				"forwardAuth": map[string]any{
+					"address":            cfg.MTLS.VerifyURL,
+					"authRequestHeaders": []any{ClientCertHeader},
+				},
+			},
+		}},
+	}
+}
+
+// ApplyMTLS brings a running app's mTLS enforcement in line with cfg.MTLS,
+// e.g. after certificates are revoked or mTLS is toggled
+func (c *Client) ApplyMTLS(ctx context.Context, cfg *AppConfig) error {
+	cfg.Namespace = c.NamespaceForApp(cfg.Name)
+
+	if cfg.MTLS == nil {
+		if err := c.applyIngress(ctx, cfg); err != nil {
+			return err
+		}
+		return c.removeMTLS(ctx, cfg)
+	}
+
+	if err := c.applyMTLS(ctx, cfg); err != nil {
+		return err
+	}
+	return c.applyIngress(ctx, cfg)
+}
+
+func (c *Client) applyMTLS(ctx context.Context, cfg *AppConfig) error {
+	if c.dynamic == nil {
+		return ErrMTLSUnavailable
+	}
+
+	secrets := c.clientset.CoreV1().Secrets(cfg.Namespace)
+	err := retryOnConflict(func() error {
+		secret := GenerateMTLSSecret(cfg)
+
+		existing, err := secrets.Get(ctx, secret.Name, metav1.GetOptions{})
+		if err == nil {
+			secret.ResourceVersion = existing.ResourceVersion
+			_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+			return err
+		}
+
+		if k8serrors.IsNotFound(err) {
+			_, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+			return err
+		}
+
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply client CA: %w", err)
+	}
+
+	for _, obj := range GenerateMTLSResources(cfg) {
+		if err := c.applyUnstructured(ctx, mtlsGVR(obj), obj); err != nil {
+			return fmt.Errorf("failed to apply %s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+	}
+	return nil
+}
+
+func (c *Client) removeMTLS(ctx context.Context, cfg *AppConfig) error {
+	if c.dynamic == nil {
+		return nil
+	}
+
+	deletes := []struct {
+		gvr  schema.GroupVersionResource
+		name string
+	}{
+		{TLSOptionGVR, mtlsResourceName(cfg.Name)},
+		{MiddlewareGVR, mtlsResourceName(cfg.Name)},
+		{MiddlewareGVR, mtlsAuthMiddlewareName(cfg.Name)},
+	}
+	for _, d := range deletes {
+		err := c.dynamic.Resource(d.gvr).Namespace(cfg.Namespace).Delete(ctx, d.name, metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete %s: %w", d.name, err)
+		}
+	}
+
+	err := c.clientset.CoreV1().Secrets(cfg.Namespace).Delete(ctx, MTLSSecretName(cfg.Name), metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete client CA: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) applyUnstructured(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured) error {
+	resources := c.dynamic.Resource(gvr).Namespace(obj.GetNamespace())
+
+	return retryOnConflict(func() error {
+		existing, err := resources.Get(ctx, obj.GetName(), metav1.GetOptions{})
+		if err == nil {
+			obj.SetResourceVersion(existing.GetResourceVersion())
+			_, err = resources.Update(ctx, obj, metav1.UpdateOptions{})
+			return err
+		}
+
+		if k8serrors.IsNotFound(err) {
+			_, err = resources.Create(ctx, obj, metav1.CreateOptions{})
+			return err
+		}
+
+		return err
+	})
+}
+
+func mtlsGVR(obj *unstructured.Unstructured) schema.GroupVersionResource {
+	if obj.GetKind() == "TLSOption" {
+		return TLSOptionGVR
+	}
+	return MiddlewareGVR
+}
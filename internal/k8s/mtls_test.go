@@ -0,0 +1,99 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGenerateIngress_MTLS(t *testing.T) {
+	cfg := testAppConfig(withMTLS)
+
+	ingress := GenerateIngress(cfg)
+	if got := ingress.Annotations["traefik.ingress.kubernetes.io/router.tls.options"]; got != "fuego-myapp-myapp-mtls@kubernetescrd" {
+		t.Errorf("unexpected tls options annotation %q", got)
+	}
+	if got := ingress.Annotations["traefik.ingress.kubernetes.io/router.middlewares"]; got != "fuego-myapp-myapp-mtls@kubernetescrd,fuego-myapp-myapp-mtls-auth@kubernetescrd" {
+		t.Errorf("unexpected middlewares annotation %q", got)
+	}
+
+	cfg.MTLS = nil
+	if _, ok := GenerateIngress(cfg).Annotations["traefik.ingress.kubernetes.io/router.tls.options"]; ok {
+		t.Error("expected no tls options without mTLS")
+	}
+}
+
+func TestApplyMTLS(t *testing.T) {
+	clientset := fake.NewClientset()
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	client := NewClientWithDynamic(clientset, dynamicClient, "fuego-")
+	ctx := context.Background()
+
+	cfg := testAppConfig(withMTLS)
+	if err := client.ApplyMTLS(ctx, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	secret, err := clientset.CoreV1().Secrets("fuego-myapp").Get(ctx, "myapp-mtls-ca", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected client CA secret: %v", err)
+	}
+	if string(secret.Data["tls.ca"]) != "ca" {
+		t.Errorf("unexpected CA bundle %q", secret.Data["tls.ca"])
+	}
+
+	option, err := dynamicClient.Resource(TLSOptionGVR).Namespace("fuego-myapp").Get(ctx, "myapp-mtls", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected TLSOption: %v", err)
+	}
+	if authType, _, _ := unstructured.NestedString(option.Object, "spec", "clientAuth", "clientAuthType"); authType != "RequireAndVerifyClientCert" {
+		t.Errorf("unexpected client auth type %q", authType)
+	}
+	auth, err := dynamicClient.Resource(MiddlewareGVR).Namespace("fuego-myapp").Get(ctx, "myapp-mtls-auth", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected forward auth middleware: %v", err)
+	}
+	if address, _, _ := unstructured.NestedString(auth.Object, "spec", "forwardAuth", "address"); address != cfg.MTLS.VerifyURL {
+		t.Errorf("unexpected forward auth address %q", address)
+	}
+
+	ingress, err := clientset.NetworkingV1().Ingresses("fuego-myapp").Get(ctx, "myapp", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected ingress: %v", err)
+	}
+	if _, ok := ingress.Annotations["traefik.ingress.kubernetes.io/router.tls.options"]; !ok {
+		t.Error("expected ingress to reference the TLS option")
+	}
+
+	// Re-applying updates in place
+	cfg.MTLS.CRL = []byte("crl-2")
+	if err := client.ApplyMTLS(ctx, cfg); err != nil {
+		t.Fatalf("unexpected error on re-apply: %v", err)
+	}
+
+	cfg.MTLS = nil
+	if err := client.ApplyMTLS(ctx, cfg); err != nil {
+		t.Fatalf("unexpected error on disable: %v", err)
+	}
+	if _, err := clientset.CoreV1().Secrets("fuego-myapp").Get(ctx, "myapp-mtls-ca", metav1.GetOptions{}); !k8serrors.IsNotFound(err) {
+		t.Errorf("expected client CA secret to be deleted, got %v", err)
+	}
+	if _, err := dynamicClient.Resource(TLSOptionGVR).Namespace("fuego-myapp").Get(ctx, "myapp-mtls", metav1.GetOptions{}); !k8serrors.IsNotFound(err) {
+		t.Errorf("expected TLSOption to be deleted, got %v", err)
+	}
+}
+
+func TestApplyMTLS_Unavailable(t *testing.T) {
+	client := NewClientWithInterface(fake.NewClientset(), "fuego-")
+
+	if err := client.ApplyMTLS(context.Background(), testAppConfig(withMTLS)); !errors.Is(err, ErrMTLSUnavailable) {
+		t.Errorf("expected ErrMTLSUnavailable, got %v", err)
+	}
+}
@@ -0,0 +1,353 @@
+package k8s
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"golang.org/x/net/http/httpguts"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultOTelCollectorImage is the OpenTelemetry Collector image of
+// collectors
+const DefaultOTelCollectorImage = "otel/opentelemetry-collector:0.115.1"
+
+// Protocols collectors export with
+const (
+	OTelProtocolGRPC = "grpc"
+	OTelProtocolHTTP = "http"
+)
+
+const (
+	// MaxOTelHeaders bounds the headers a collector exports with
+	MaxOTelHeaders = 10
+
+	otelGRPCPort   = 4317
+	otelHTTPPort   = 4318
+	otelConfigKey  = "config.yaml"
+	otelConfigPath = "/etc/otelcol"
+	// otelConfigHashAnnotation rolls the collector when its config changes
+	otelConfigHashAnnotation = "nexo.build/config-hash"
+	// otelMemoryLimitMiB keeps the collector under its memory limit,
+	// refusing data instead of being OOM killed
+	otelMemoryLimitMiB = 200
+)
+
+// OTelCollectorConfig runs an OpenTelemetry Collector in the app's
+// namespace receiving OTLP traces, metrics and logs from the app and
+// exporting them to the user's backend, such as Honeycomb, Grafana Cloud or
+// Datadog. The app is pointed at it through the standard OTEL_* env vars.
+type OTelCollectorConfig struct {
+	// Endpoint is the OTLP endpoint of the backend
+	Endpoint string
+	// Protocol is grpc or http
+	Protocol string
+	// Headers are sent with every export, such as the backend's API key
+	Headers map[string]string
+}
+
+// ValidateOTelCollector checks the backend of a collector. The endpoint must
+// use HTTPS and carry no credentials, which go in headers.
+func ValidateOTelCollector(c *OTelCollectorConfig) error {
+	if c.Protocol != OTelProtocolGRPC && c.Protocol != OTelProtocolHTTP {
+		return fmt.Errorf("protocol must be %s or %s", OTelProtocolGRPC, OTelProtocolHTTP)
+	}
+
+	u, err := url.Parse(c.Endpoint)
+	if err != nil || u.Host == "" {
+		return errors.New("endpoint must be an absolute URL")
+	}
+	if u.Scheme != "https" {
+		return errors.New("endpoint must use https")
+	}
+	if u.User != nil {
+		return errors.New("endpoint must not contain credentials, send them in a header")
+	}
+
+	if len(c.Headers) > MaxOTelHeaders {
+		return fmt.Errorf("at most %d headers are allowed", MaxOTelHeaders)
+	}
+	for name, value := range c.Headers {
+		if !httpguts.ValidHeaderFieldName(name) || !httpguts.ValidHeaderFieldValue(value) {
+			return fmt.Errorf("invalid header %q", name)
+		}
+	}
+	return nil
+}
+
+// OTelCollectorName is the name of the collector resources of an app
+func OTelCollectorName(appName string) string {
+	return appName + "-otel-collector"
+}
+
+// OTelEnv returns the env vars pointing an app's OpenTelemetry SDK at its
+// collector
+func OTelEnv(appName string) map[string]string {
+	return map[string]string{
+		"OTEL_EXPORTER_OTLP_ENDPOINT": fmt.Sprintf("http://%s:%d", OTelCollectorName(appName), otelHTTPPort),
+		"OTEL_EXPORTER_OTLP_PROTOCOL": "http/protobuf",
+		"OTEL_SERVICE_NAME":           appName,
+	}
+}
+
+// otelLabels are the labels of collector resources. They are not labelled
+// with the app's name, which selects its own pods and deployments.
+func otelLabels(cfg *AppConfig) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":       OTelCollectorName(cfg.Name),
+		"app.kubernetes.io/component":  "otel-collector",
+		"app.kubernetes.io/part-of":    cfg.Name,
+		"app.kubernetes.io/managed-by": "nexo-cloud",
+	}
+}
+
+// OTelCollectorYAML renders the collector's configuration: OTLP in on both
+// protocols, batched and tagged with the app, out to the backend
+func OTelCollectorYAML(cfg *AppConfig) ([]byte, error) {
+	collector := cfg.OTelCollector
+
+	exporter := "otlp"
+	if collector.Protocol == OTelProtocolHTTP {
+		exporter = "otlphttp"
+	}
+	exporterConfig := map[string]any{"endpoint": collector.Endpoint}
+	if len(collector.Headers) > 0 {
+		exporterConfig["headers"] = collector.Headers
+	}
+
+	pipeline := map[string]any{
+		"receivers":  []string{"otlp"},
+		"processors": []string{"memory_limiter", "resource", "batch"},
+		"exporters":  []string{exporter},
+	}
+
+	return yaml.Marshal(map[string]any{
+		"receivers": map[string]any{
+			"otlp": map[string]any{
+				"protocols": map[string]any{
+					"grpc": map[string]any{"endpoint": fmt.Sprintf("0.0.0.0:%d", otelGRPCPort)},
+					"http": map[string]any{"endpoint": fmt.Sprintf("0.0.0.0:%d", otelHTTPPort)},
+				},
+			},
+		},
+		"processors": map[string]any{
+			"memory_limiter": map[string]any{"check_interval": "1s", "limit_mib": otelMemoryLimitMiB},
+			"resource": map[string]any{
+				"attributes": []any{
+					map[string]any{"key": "nexo.app", "value": cfg.Name, "action": "upsert"},
+				},
+			},
+			"batch": map[string]any{},
+		},
+		"exporters": map[string]any{exporter: exporterConfig},
+		"service": map[string]any{
+			"pipelines": map[string]any{
+				"traces":  pipeline,
+				"metrics": pipeline,
+				"logs":    pipeline,
+			},
+		},
+	})
+}
+
+// GenerateOTelCollector returns the secret holding a collector's
+// configuration, with its headers, and the deployment and service running
+// it
+func GenerateOTelCollector(cfg *AppConfig) (*corev1.Secret, *appsv1.Deployment, *corev1.Service, error) {
+	config, err := OTelCollectorYAML(cfg)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to render collector config: %w", err)
+	}
+	hash := sha256.Sum256(config)
+
+	image := cfg.OTelCollectorImage
+	if image == "" {
+		image = DefaultOTelCollectorImage
+	}
+	name := OTelCollectorName(cfg.Name)
+	labels := otelLabels(cfg)
+	selector := map[string]string{"app.kubernetes.io/name": name}
+	replicas := int32(1)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cfg.Namespace,
+			Labels:    appLabels(cfg, labels),
+		},
+		Type:       corev1.SecretTypeOpaque,
+		StringData: map[string]string{otelConfigKey: string(config)},
+	}
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cfg.Namespace,
+			Labels:    appLabels(cfg, labels),
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: selector},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      appLabels(cfg, labels),
+					Annotations: map[string]string{otelConfigHashAnnotation: hex.EncodeToString(hash[:])},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "otel-collector",
+							Image: image,
+							Args:  []string{"--config=" + otelConfigPath + "/" + otelConfigKey},
+							Ports: []corev1.ContainerPort{
+								{Name: "otlp-grpc", ContainerPort: otelGRPCPort, Protocol: corev1.ProtocolTCP},
+								{Name: "otlp-http", ContainerPort: otelHTTPPort, Protocol: corev1.ProtocolTCP},
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "config", MountPath: otelConfigPath, ReadOnly: true},
+							},
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("50m"),
+									corev1.ResourceMemory: resource.MustParse("64Mi"),
+								},
+								Limits: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse("256Mi"),
+								},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "config",
+							VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{SecretName: name},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	cfg.Placement.applyTo(&deployment.Spec.Template.Spec)
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cfg.Namespace,
+			Labels:    appLabels(cfg, labels),
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: selector,
+			Ports: []corev1.ServicePort{
+				{Name: "otlp-grpc", Port: otelGRPCPort, TargetPort: intstr.FromInt32(otelGRPCPort), Protocol: corev1.ProtocolTCP},
+				{Name: "otlp-http", Port: otelHTTPPort, TargetPort: intstr.FromInt32(otelHTTPPort), Protocol: corev1.ProtocolTCP},
+			},
+		},
+	}
+
+	return secret, deployment, service, nil
+}
+
+// ApplyOTelCollector starts, updates or removes an app's collector according
+// to cfg.OTelCollector, without a full deploy. The app's env is applied
+// separately.
+func (c *Client) ApplyOTelCollector(ctx context.Context, cfg *AppConfig) error {
+	cfg.Namespace = c.NamespaceForApp(cfg.Name)
+
+	if cfg.OTelCollector == nil {
+		return c.RemoveOTelCollector(ctx, cfg.Name)
+	}
+	return c.applyOTelCollector(ctx, cfg)
+}
+
+func (c *Client) applyOTelCollector(ctx context.Context, cfg *AppConfig) error {
+	secret, deployment, service, err := GenerateOTelCollector(cfg)
+	if err != nil {
+		return err
+	}
+
+	secrets := c.clientset.CoreV1().Secrets(cfg.Namespace)
+	err = retryOnConflict(func() error {
+		existing, err := secrets.Get(ctx, secret.Name, metav1.GetOptions{})
+		if err == nil {
+			secret.ResourceVersion = existing.ResourceVersion
+			_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+			return err
+		}
+		if k8serrors.IsNotFound(err) {
+			_, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply collector secret: %w", err)
+	}
+
+	deployments := c.clientset.AppsV1().Deployments(cfg.Namespace)
+	err = retryOnConflict(func() error {
+		existing, err := deployments.Get(ctx, deployment.Name, metav1.GetOptions{})
+		if err == nil {
+			deployment.ResourceVersion = existing.ResourceVersion
+			_, err = deployments.Update(ctx, deployment, metav1.UpdateOptions{})
+			return err
+		}
+		if k8serrors.IsNotFound(err) {
+			_, err = deployments.Create(ctx, deployment, metav1.CreateOptions{})
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply collector deployment: %w", err)
+	}
+
+	services := c.clientset.CoreV1().Services(cfg.Namespace)
+	err = retryOnConflict(func() error {
+		existing, err := services.Get(ctx, service.Name, metav1.GetOptions{})
+		if err == nil {
+			service.ResourceVersion = existing.ResourceVersion
+			service.Spec.ClusterIP = existing.Spec.ClusterIP
+			_, err = services.Update(ctx, service, metav1.UpdateOptions{})
+			return err
+		}
+		if k8serrors.IsNotFound(err) {
+			_, err = services.Create(ctx, service, metav1.CreateOptions{})
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply collector service: %w", err)
+	}
+	return nil
+}
+
+// RemoveOTelCollector deletes an app's collector, if it has one
+func (c *Client) RemoveOTelCollector(ctx context.Context, appName string) error {
+	namespace := c.NamespaceForApp(appName)
+	name := OTelCollectorName(appName)
+
+	deletes := []struct {
+		kind   string
+		delete func(context.Context, string, metav1.DeleteOptions) error
+	}{
+		{"service", c.clientset.CoreV1().Services(namespace).Delete},
+		{"deployment", c.clientset.AppsV1().Deployments(namespace).Delete},
+		{"secret", c.clientset.CoreV1().Secrets(namespace).Delete},
+	}
+	for _, d := range deletes {
+		if err := d.delete(ctx, name, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete collector %s: %w", d.kind, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Toleration lets app pods schedule onto tainted nodes of a dedicated pool
+type Toleration struct {
+	Key      string `json:"key"`
+	Operator string `json:"operator,omitempty"`
+	Value    string `json:"value,omitempty"`
+	Effect   string `json:"effect,omitempty"`
+}
+
+// Placement pins app pods to a node pool
+type Placement struct {
+	NodeSelector map[string]string `json:"node_selector"`
+	Tolerations  []Toleration      `json:"tolerations"`
+}
+
+// IsZero reports whether the placement leaves scheduling to the cluster defaults
+func (p *Placement) IsZero() bool {
+	return p == nil || (len(p.NodeSelector) == 0 && len(p.Tolerations) == 0)
+}
+
+// ValidatePlacement checks node selector labels and tolerations
+func ValidatePlacement(p *Placement) error {
+	for key, value := range p.NodeSelector {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("invalid node selector key %q: %s", key, strings.Join(errs, ", "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Errorf("invalid node selector value %q: %s", value, strings.Join(errs, ", "))
+		}
+	}
+
+	for _, t := range p.Tolerations {
+		if t.Key == "" {
+			return errors.New("toleration key is required")
+		}
+		if errs := validation.IsQualifiedName(t.Key); len(errs) > 0 {
+			return fmt.Errorf("invalid toleration key %q: %s", t.Key, strings.Join(errs, ", "))
+		}
+
+		switch corev1.TolerationOperator(t.Operator) {
+		case "", corev1.TolerationOpEqual:
+		case corev1.TolerationOpExists:
+			if t.Value != "" {
+				return fmt.Errorf("toleration %q uses Exists and must not set a value", t.Key)
+			}
+		default:
+			return fmt.Errorf("toleration %q operator must be Equal or Exists", t.Key)
+		}
+
+		switch corev1.TaintEffect(t.Effect) {
+		case "", corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		default:
+			return fmt.Errorf("toleration %q effect must be NoSchedule, PreferNoSchedule or NoExecute", t.Key)
+		}
+	}
+
+	return nil
+}
+
+// applyTo sets the node selector and tolerations on a pod spec
+func (p *Placement) applyTo(spec *corev1.PodSpec) {
+	if p.IsZero() {
+		spec.NodeSelector = nil
+		spec.Tolerations = nil
+		return
+	}
+
+	spec.NodeSelector = make(map[string]string, len(p.NodeSelector))
+	for k, v := range p.NodeSelector {
+		spec.NodeSelector[k] = v
+	}
+
+	spec.Tolerations = make([]corev1.Toleration, len(p.Tolerations))
+	for i, t := range p.Tolerations {
+		spec.Tolerations[i] = corev1.Toleration{
+			Key:      t.Key,
+			Operator: corev1.TolerationOperator(t.Operator),
+			Value:    t.Value,
+			Effect:   corev1.TaintEffect(t.Effect),
+		}
+	}
+}
+
+// NodePoolExists reports whether at least one schedulable node matches the selector
+func (c *Client) NodePoolExists(ctx context.Context, nodeSelector map[string]string) (bool, error) {
+	if len(nodeSelector) == 0 {
+		return true, nil
+	}
+
+	nodes, err := c.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(nodeSelector).String(),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	for _, node := range nodes.Items {
+		if !node.Spec.Unschedulable {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// ApplyPlacement updates the pod placement of every Deployment and CronJob of
+// an app, which rolls the pods onto the new node pool
+func (c *Client) ApplyPlacement(ctx context.Context, appName string, p *Placement) error {
+	namespace := c.NamespaceForApp(appName)
+	deployments := c.clientset.AppsV1().Deployments(namespace)
+
+	list, err := deployments.List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("app.kubernetes.io/name=%s", appName),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list deployments: %w", err)
+	}
+
+	for i := range list.Items {
+		name := list.Items[i].Name
+		err := retryOnConflict(func() error {
+			deployment, err := deployments.Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			p.applyTo(&deployment.Spec.Template.Spec)
+			_, err = deployments.Update(ctx, deployment, metav1.UpdateOptions{})
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update deployment %s: %w", name, err)
+		}
+	}
+
+	cronJobs := c.clientset.BatchV1().CronJobs(namespace)
+	cronList, err := cronJobs.List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("app.kubernetes.io/name=%s", appName),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list cron jobs: %w", err)
+	}
+
+	for i := range cronList.Items {
+		name := cronList.Items[i].Name
+		err := retryOnConflict(func() error {
+			cronJob, err := cronJobs.Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			p.applyTo(&cronJob.Spec.JobTemplate.Spec.Template.Spec)
+			_, err = cronJobs.Update(ctx, cronJob, metav1.UpdateOptions{})
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update cron job %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestValidatePlacement(t *testing.T) {
+	tests := []struct {
+		name      string
+		placement Placement
+		wantErr   bool
+	}{
+		{"empty", Placement{}, false},
+		{"selector and toleration", Placement{
+			NodeSelector: map[string]string{"nexo.build/pool": "dedicated"},
+			Tolerations:  []Toleration{{Key: "dedicated", Operator: "Equal", Value: "acme", Effect: "NoSchedule"}},
+		}, false},
+		{"exists toleration", Placement{Tolerations: []Toleration{{Key: "dedicated", Operator: "Exists"}}}, false},
+		{"bad selector key", Placement{NodeSelector: map[string]string{"bad key": "x"}}, true},
+		{"bad selector value", Placement{NodeSelector: map[string]string{"pool": "not valid!"}}, true},
+		{"missing toleration key", Placement{Tolerations: []Toleration{{Value: "x"}}}, true},
+		{"exists with value", Placement{Tolerations: []Toleration{{Key: "k", Operator: "Exists", Value: "x"}}}, true},
+		{"bad operator", Placement{Tolerations: []Toleration{{Key: "k", Operator: "In"}}}, true},
+		{"bad effect", Placement{Tolerations: []Toleration{{Key: "k", Effect: "Never"}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePlacement(&tt.placement)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePlacement() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenerateDeployment_Placement(t *testing.T) {
+	cfg := &AppConfig{
+		Name:      "myapp",
+		Namespace: "fuego-myapp",
+		Image:     "myapp:v1",
+		Replicas:  1,
+		Port:      8080,
+		Placement: &Placement{
+			NodeSelector: map[string]string{"nexo.build/pool": "dedicated"},
+			Tolerations:  []Toleration{{Key: "dedicated", Operator: "Exists", Effect: "NoSchedule"}},
+		},
+	}
+
+	deployment := GenerateDeployment(cfg)
+	spec := deployment.Spec.Template.Spec
+
+	if spec.NodeSelector["nexo.build/pool"] != "dedicated" {
+		t.Errorf("expected node selector to be set, got %v", spec.NodeSelector)
+	}
+
+	if len(spec.Tolerations) != 1 || spec.Tolerations[0].Effect != corev1.TaintEffectNoSchedule {
+		t.Errorf("expected NoSchedule toleration, got %v", spec.Tolerations)
+	}
+
+	worker := GenerateProcessDeployment(cfg, &ProcessConfig{Type: "worker", Command: "bin/worker"})
+	if worker.Spec.Template.Spec.NodeSelector["nexo.build/pool"] != "dedicated" {
+		t.Error("expected worker pods to share the app placement")
+	}
+
+	cfg.Placement = nil
+	if spec := GenerateDeployment(cfg).Spec.Template.Spec; spec.NodeSelector != nil || spec.Tolerations != nil {
+		t.Error("expected no placement when none is configured")
+	}
+}
+
+func TestNodePoolExists(t *testing.T) {
+	fakeClient := fake.NewClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: map[string]string{"nexo.build/pool": "shared"}}},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-2", Labels: map[string]string{"nexo.build/pool": "cordoned"}},
+			Spec:       corev1.NodeSpec{Unschedulable: true},
+		},
+	)
+	client := NewClientWithInterface(fakeClient, "fuego-")
+
+	tests := []struct {
+		selector map[string]string
+		expected bool
+	}{
+		{nil, true},
+		{map[string]string{"nexo.build/pool": "shared"}, true},
+		{map[string]string{"nexo.build/pool": "cordoned"}, false},
+		{map[string]string{"nexo.build/pool": "gpu"}, false},
+	}
+
+	for _, tt := range tests {
+		exists, err := client.NodePoolExists(context.Background(), tt.selector)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if exists != tt.expected {
+			t.Errorf("NodePoolExists(%v) = %v, want %v", tt.selector, exists, tt.expected)
+		}
+	}
+}
+
+func TestApplyPlacement(t *testing.T) {
+	cfg := &AppConfig{Name: "myapp", Namespace: "fuego-myapp", Image: "myapp:v1", Replicas: 1, Port: 8080}
+	fakeClient := fake.NewClientset(GenerateDeployment(cfg))
+	client := NewClientWithInterface(fakeClient, "fuego-")
+
+	placement := &Placement{NodeSelector: map[string]string{"nexo.build/pool": "dedicated"}}
+	if err := client.ApplyPlacement(context.Background(), "myapp", placement); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deployment, _ := fakeClient.AppsV1().Deployments("fuego-myapp").Get(context.Background(), "myapp", metav1.GetOptions{})
+	if deployment.Spec.Template.Spec.NodeSelector["nexo.build/pool"] != "dedicated" {
+		t.Errorf("expected running deployment to be moved, got %v", deployment.Spec.Template.Spec.NodeSelector)
+	}
+}
@@ -0,0 +1,108 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ErrPodNotFound is returned when a pod does not exist or belongs to another app
+var ErrPodNotFound = errors.New("pod not found")
+
+// PodInfo summarizes a pod of an app
+type PodInfo struct {
+	Name      string     `json:"name"`
+	Process   string     `json:"process,omitempty"`
+	Phase     string     `json:"phase"`
+	Ready     bool       `json:"ready"`
+	Restarts  int32      `json:"restarts"`
+	Node      string     `json:"node,omitempty"`
+	PodIP     string     `json:"pod_ip,omitempty"`
+	HostIP    string     `json:"host_ip,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+	StartedAt *time.Time `json:"started_at,omitempty"`
+}
+
+// ListAppPods returns every pod of an app, including worker processes, sorted by name
+func (c *Client) ListAppPods(ctx context.Context, appName string) ([]PodInfo, error) {
+	pods, err := c.GetPods(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	infos := make([]PodInfo, 0, len(pods.Items))
+	for i := range pods.Items {
+		infos = append(infos, toPodInfo(&pods.Items[i]))
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+
+	return infos, nil
+}
+
+// DeletePod deletes a single pod of an app so its controller replaces it
+func (c *Client) DeletePod(ctx context.Context, appName, podName string) error {
+	namespace := c.NamespaceForApp(appName)
+	pods := c.clientset.CoreV1().Pods(namespace)
+
+	pod, err := pods.Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return ErrPodNotFound
+		}
+		return fmt.Errorf("failed to get pod: %w", err)
+	}
+
+	if pod.Labels["app.kubernetes.io/name"] != appName {
+		return ErrPodNotFound
+	}
+
+	if err := pods.Delete(ctx, podName, metav1.DeleteOptions{}); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return ErrPodNotFound
+		}
+		return fmt.Errorf("failed to delete pod: %w", err)
+	}
+
+	return nil
+}
+
+func toPodInfo(pod *corev1.Pod) PodInfo {
+	info := PodInfo{
+		Name:    pod.Name,
+		Process: pod.Labels[processLabel],
+		Phase:   string(pod.Status.Phase),
+		Node:    pod.Spec.NodeName,
+		PodIP:   pod.Status.PodIP,
+		HostIP:  pod.Status.HostIP,
+		Reason:  pod.Status.Reason,
+	}
+
+	if pod.Status.StartTime != nil {
+		started := pod.Status.StartTime.Time
+		info.StartedAt = &started
+	}
+
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			info.Ready = cond.Status == corev1.ConditionTrue
+		}
+	}
+
+	for _, cs := range pod.Status.ContainerStatuses {
+		info.Restarts += cs.RestartCount
+		if info.Reason == "" && cs.State.Waiting != nil {
+			info.Reason = cs.State.Waiting.Reason
+		}
+	}
+
+	return info
+}
@@ -0,0 +1,83 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func testPod(name, appName string, restarts int32) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "fuego-" + appName,
+			Labels: map[string]string{
+				"app.kubernetes.io/name": appName,
+				"nexo.build/process":     "web",
+			},
+		},
+		Spec: corev1.PodSpec{NodeName: "node-1"},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+			},
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: appName, RestartCount: restarts},
+			},
+		},
+	}
+}
+
+func TestListAppPods(t *testing.T) {
+	fakeClient := fake.NewClientset(
+		testPod("myapp-b", "myapp", 3),
+		testPod("myapp-a", "myapp", 0),
+	)
+	client := NewClientWithInterface(fakeClient, "fuego-")
+
+	pods, err := client.ListAppPods(context.Background(), "myapp")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(pods) != 2 {
+		t.Fatalf("expected 2 pods, got %d", len(pods))
+	}
+
+	if pods[0].Name != "myapp-a" {
+		t.Errorf("expected pods sorted by name, got %q first", pods[0].Name)
+	}
+
+	if pods[1].Restarts != 3 {
+		t.Errorf("expected 3 restarts, got %d", pods[1].Restarts)
+	}
+
+	if pods[1].Node != "node-1" || pods[1].Process != "web" || !pods[1].Ready {
+		t.Errorf("unexpected pod info %+v", pods[1])
+	}
+}
+
+func TestDeletePod(t *testing.T) {
+	other := testPod("other-a", "other", 0)
+	other.Namespace = "fuego-myapp"
+
+	fakeClient := fake.NewClientset(testPod("myapp-a", "myapp", 0), other)
+	client := NewClientWithInterface(fakeClient, "fuego-")
+
+	if err := client.DeletePod(context.Background(), "myapp", "myapp-a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := client.DeletePod(context.Background(), "myapp", "myapp-a"); !errors.Is(err, ErrPodNotFound) {
+		t.Errorf("expected ErrPodNotFound for deleted pod, got %v", err)
+	}
+
+	if err := client.DeletePod(context.Background(), "myapp", "other-a"); !errors.Is(err, ErrPodNotFound) {
+		t.Errorf("expected ErrPodNotFound for pod of another app, got %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultPoolerImage is the PgBouncer image of poolers, configured through
+// environment variables
+const DefaultPoolerImage = "edoburu/pgbouncer:v1.23.1-p2"
+
+const poolerContainerPrefix = "pgbouncer-"
+
+// PoolerConfig runs PgBouncer in transaction pooling mode next to every
+// container of the app, so the connections of all its requests share a few
+// to a database with low connection limits, such as a serverless Neon one.
+// The app connects to it on localhost with the database's credentials.
+type PoolerConfig struct {
+	// Name is the name of the database add-on pooled
+	Name     string
+	Host     string
+	Port     int32
+	Database string
+	Username string
+	Password string
+	// SSLMode is how PgBouncer connects to the database
+	SSLMode string
+	// ListenPort is the localhost port the app connects to
+	ListenPort int32
+	// PoolSize bounds the connections each pod opens to the database
+	PoolSize int32
+}
+
+// PoolerSecretName is the secret holding the database passwords of an
+// app's poolers, kept apart from its env
+func PoolerSecretName(appName string) string {
+	return appName + "-poolers"
+}
+
+func poolerPasswordKey(pooler PoolerConfig) string {
+	return pooler.Name + "-password"
+}
+
+// GeneratePoolerSecret builds the secret of an app's poolers
+func GeneratePoolerSecret(cfg *AppConfig) *corev1.Secret {
+	stringData := make(map[string]string, len(cfg.Poolers))
+	for _, pooler := range cfg.Poolers {
+		stringData[poolerPasswordKey(pooler)] = pooler.Password
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      PoolerSecretName(cfg.Name),
+			Namespace: cfg.Namespace,
+			Labels: appLabels(cfg, map[string]string{
+				"app.kubernetes.io/name":       cfg.Name,
+				"app.kubernetes.io/managed-by": "nexo-cloud",
+			}),
+		},
+		Type:       corev1.SecretTypeOpaque,
+		StringData: stringData,
+	}
+}
+
+// poolerContainers returns the PgBouncer sidecars of an app. They run as
+// native sidecars, init containers that keep running, so they are up
+// before the app starts and do not keep Jobs from completing.
+func poolerContainers(cfg *AppConfig) []corev1.Container {
+	image := cfg.PoolerImage
+	if image == "" {
+		image = DefaultPoolerImage
+	}
+	always := corev1.ContainerRestartPolicyAlways
+
+	containers := make([]corev1.Container, 0, len(cfg.Poolers))
+	for _, pooler := range cfg.Poolers {
+		containers = append(containers, corev1.Container{
+			Name:          poolerContainerPrefix + pooler.Name,
+			Image:         image,
+			RestartPolicy: &always,
+			Env: []corev1.EnvVar{
+				{Name: "DB_HOST", Value: pooler.Host},
+				{Name: "DB_PORT", Value: strconv.Itoa(int(pooler.Port))},
+				{Name: "DB_NAME", Value: pooler.Database},
+				{Name: "DB_USER", Value: pooler.Username},
+				{
+					Name: "DB_PASSWORD",
+					ValueFrom: &corev1.EnvVarSource{
+						SecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: PoolerSecretName(cfg.Name)},
+							Key:                  poolerPasswordKey(pooler),
+						},
+					},
+				},
+				{Name: "POOL_MODE", Value: "transaction"},
+				{Name: "LISTEN_ADDR", Value: "127.0.0.1"},
+				{Name: "LISTEN_PORT", Value: strconv.Itoa(int(pooler.ListenPort))},
+				{Name: "DEFAULT_POOL_SIZE", Value: strconv.Itoa(int(pooler.PoolSize))},
+				{Name: "MAX_DB_CONNECTIONS", Value: strconv.Itoa(int(pooler.PoolSize))},
+				{Name: "MAX_CLIENT_CONN", Value: "1000"},
+				// Prepared statements of drivers such as pgx survive
+				// transaction pooling
+				{Name: "MAX_PREPARED_STATEMENTS", Value: "100"},
+				{Name: "SERVER_TLS_SSLMODE", Value: pooler.SSLMode},
+				// Neon and RDS require SCRAM; PgBouncer passes the
+				// password through
+				{Name: "AUTH_TYPE", Value: "scram-sha-256"},
+			},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("10m"),
+					corev1.ResourceMemory: resource.MustParse("16Mi"),
+				},
+				Limits: corev1.ResourceList{
+					corev1.ResourceMemory: resource.MustParse("64Mi"),
+				},
+			},
+		})
+	}
+	return containers
+}
+
+// applyPoolers replaces the PgBouncer sidecars of a pod spec with the
+// app's poolers, ahead of its other init containers
+func applyPoolers(spec *corev1.PodSpec, cfg *AppConfig) {
+	initContainers := poolerContainers(cfg)
+	for _, container := range spec.InitContainers {
+		if !strings.HasPrefix(container.Name, poolerContainerPrefix) {
+			initContainers = append(initContainers, container)
+		}
+	}
+	if len(initContainers) == 0 {
+		initContainers = nil
+	}
+	spec.InitContainers = initContainers
+}
+
+// applyPoolerSecret applies the secret of an app's poolers, or removes it
+// once the app has none
+func (c *Client) applyPoolerSecret(ctx context.Context, cfg *AppConfig) error {
+	secrets := c.clientset.CoreV1().Secrets(cfg.Namespace)
+
+	if len(cfg.Poolers) == 0 {
+		err := secrets.Delete(ctx, PoolerSecretName(cfg.Name), metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete pooler secret: %w", err)
+		}
+		return nil
+	}
+
+	return retryOnConflict(func() error {
+		secret := GeneratePoolerSecret(cfg)
+
+		existing, err := secrets.Get(ctx, secret.Name, metav1.GetOptions{})
+		if err == nil {
+			secret.ResourceVersion = existing.ResourceVersion
+			_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+			return err
+		}
+
+		if k8serrors.IsNotFound(err) {
+			_, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+			return err
+		}
+
+		return err
+	})
+}
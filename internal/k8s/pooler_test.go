@@ -0,0 +1,115 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func envValue(container corev1.Container, name string) string {
+	for _, env := range container.Env {
+		if env.Name == name {
+			return env.Value
+		}
+	}
+	return ""
+}
+
+func TestGenerateDeployment_Poolers(t *testing.T) {
+	cfg := testAppConfig(withPoolers)
+	spec := GenerateDeployment(cfg).Spec.Template.Spec
+
+	if len(spec.InitContainers) != 1 {
+		t.Fatalf("expected one pooler sidecar, got %d init containers", len(spec.InitContainers))
+	}
+	pooler := spec.InitContainers[0]
+	if pooler.Name != "pgbouncer-main" || pooler.Image != DefaultPoolerImage {
+		t.Errorf("unexpected sidecar %s %s", pooler.Name, pooler.Image)
+	}
+	if pooler.RestartPolicy == nil || *pooler.RestartPolicy != corev1.ContainerRestartPolicyAlways {
+		t.Error("expected the pooler to run as a native sidecar")
+	}
+	for name, want := range map[string]string{
+		"DB_HOST":           "ep-cool.neon.tech",
+		"POOL_MODE":         "transaction",
+		"LISTEN_ADDR":       "127.0.0.1",
+		"LISTEN_PORT":       "6432",
+		"DEFAULT_POOL_SIZE": "5",
+	} {
+		if got := envValue(pooler, name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	for _, env := range pooler.Env {
+		if env.Name == "DB_PASSWORD" && (env.ValueFrom == nil || env.ValueFrom.SecretKeyRef.Name != "myapp-poolers") {
+			t.Error("expected the password to come from the pooler secret")
+		}
+	}
+
+	// Workers and cron jobs get the same sidecars
+	worker := GenerateProcessDeployment(cfg, &ProcessConfig{Type: "worker", Command: "run", Replicas: 1})
+	if len(worker.Spec.Template.Spec.InitContainers) != 1 {
+		t.Error("expected workers to get the pooler")
+	}
+
+	cfg.Poolers = nil
+	if spec := GenerateDeployment(cfg).Spec.Template.Spec; spec.InitContainers != nil {
+		t.Errorf("expected no init containers, got %v", spec.InitContainers)
+	}
+}
+
+func TestApplyPoolers_KeepsOtherInitContainers(t *testing.T) {
+	spec := corev1.PodSpec{InitContainers: []corev1.Container{{Name: "pgbouncer-old"}, {Name: "migrate"}}}
+	applyPoolers(&spec, testAppConfig(withPoolers))
+
+	if len(spec.InitContainers) != 2 || spec.InitContainers[0].Name != "pgbouncer-main" || spec.InitContainers[1].Name != "migrate" {
+		t.Errorf("unexpected init containers %v", spec.InitContainers)
+	}
+}
+
+func TestApplyEnv_Poolers(t *testing.T) {
+	fakeClient := fake.NewClientset()
+	client := NewClientWithInterface(fakeClient, "fuego-")
+	ctx := context.Background()
+
+	_, err := fakeClient.AppsV1().Deployments("fuego-myapp").Create(ctx, &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp", Labels: map[string]string{"app.kubernetes.io/name": "myapp"}},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := testAppConfig(withPoolers)
+	if err := client.ApplyEnv(ctx, cfg); err != nil {
+		t.Fatalf("ApplyEnv failed: %v", err)
+	}
+
+	secret, err := fakeClient.CoreV1().Secrets("fuego-myapp").Get(ctx, "myapp-poolers", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected the pooler secret: %v", err)
+	}
+	if secret.StringData["main-password"] != "secret" {
+		t.Errorf("unexpected pooler secret %v", secret.StringData)
+	}
+	deployment, _ := fakeClient.AppsV1().Deployments("fuego-myapp").Get(ctx, "myapp", metav1.GetOptions{})
+	if len(deployment.Spec.Template.Spec.InitContainers) != 1 {
+		t.Error("expected the pooler to be added to the running deployment")
+	}
+
+	// Turning pooling off removes the sidecar and its secret
+	cfg.Poolers = nil
+	if err := client.ApplyEnv(ctx, cfg); err != nil {
+		t.Fatalf("ApplyEnv failed: %v", err)
+	}
+	if _, err := fakeClient.CoreV1().Secrets("fuego-myapp").Get(ctx, "myapp-poolers", metav1.GetOptions{}); err == nil {
+		t.Error("expected the pooler secret to be deleted")
+	}
+	deployment, _ = fakeClient.AppsV1().Deployments("fuego-myapp").Get(ctx, "myapp", metav1.GetOptions{})
+	if len(deployment.Spec.Template.Spec.InitContainers) != 0 {
+		t.Error("expected the pooler to be removed")
+	}
+}
@@ -0,0 +1,211 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// RedactedValue replaces secret values in rendered manifests
+const RedactedValue = "<redacted>"
+
+// Manifests returns every object Deploy applies for an app, in apply order,
+// with env secret values redacted. Hook Jobs are created per deployment and
+// are not included.
+func Manifests(cfg *AppConfig) []runtime.Object {
+	secret := GenerateSecret(cfg)
+	for key := range secret.StringData {
+		secret.StringData[key] = RedactedValue
+	}
+
+	objects := []runtime.Object{
+		withKind(GenerateNamespace(cfg), "v1", "Namespace"),
+		withKind(secret, "v1", "Secret"),
+		withKind(GenerateDeployment(cfg), "apps/v1", "Deployment"),
+	}
+
+	for i := range cfg.Processes {
+		if cfg.Processes[i].Type == ProcessTypeWeb {
+			continue
+		}
+		objects = append(objects, withKind(GenerateProcessDeployment(cfg, &cfg.Processes[i]), "apps/v1", "Deployment"))
+	}
+
+	objects = append(objects, withKind(GenerateService(cfg), "v1", "Service"))
+	if cfg.Tunnel != nil {
+		tunnelSecret, tunnel := GenerateTunnel(cfg)
+		tunnelSecret.StringData[tunnelTokenKey] = RedactedValue
+		objects = append(objects,
+			withKind(tunnelSecret, "v1", "Secret"),
+			withKind(tunnel, "apps/v1", "Deployment"),
+		)
+	} else {
+		objects = append(objects, withKind(GenerateIngress(cfg), "networking.k8s.io/v1", "Ingress"))
+	}
+
+	for i := range cfg.CronJobs {
+		objects = append(objects, withKind(GenerateCronJob(cfg, &cfg.CronJobs[i]), "batch/v1", "CronJob"))
+	}
+
+	return objects
+}
+
+// withKind sets the TypeMeta that typed clients leave empty so the rendered
+// YAML can be applied with kubectl
+func withKind(obj runtime.Object, apiVersion, kind string) runtime.Object {
+	obj.GetObjectKind().SetGroupVersionKind(schema.FromAPIVersionAndKind(apiVersion, kind))
+	return obj
+}
+
+// ManifestsYAML renders objects as a multi-document YAML stream
+func ManifestsYAML(objects []runtime.Object) ([]byte, error) {
+	var buf bytes.Buffer
+	for i, obj := range objects {
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s: %w", obj.GetObjectKind().GroupVersionKind().Kind, err)
+		}
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
+
+// ManifestCheck is the server-side dry-run result of one manifest
+type ManifestCheck struct {
+	Kind    string        `json:"kind"`
+	Name    string        `json:"name"`
+	Valid   bool          `json:"valid"`
+	Skipped bool          `json:"skipped,omitempty"`
+	Reason  FailureReason `json:"reason,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// DryRun submits the manifests of an app to the API server with dryRun=All so
+// admission webhooks, quotas and schema validation run without persisting
+// anything. Namespaced objects are skipped when the namespace does not exist
+// yet since the API server rejects them until the first deploy creates it.
+func (c *Client) DryRun(ctx context.Context, cfg *AppConfig) ([]ManifestCheck, error) {
+	cfg.Namespace = c.NamespaceForApp(cfg.Name)
+
+	_, err := c.clientset.CoreV1().Namespaces().Get(ctx, cfg.Namespace, metav1.GetOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get namespace: %w", err)
+	}
+	namespaceExists := err == nil
+
+	create := metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}}
+	update := metav1.UpdateOptions{DryRun: []string{metav1.DryRunAll}}
+
+	var checks []ManifestCheck
+	for _, obj := range Manifests(cfg) {
+		meta, _ := obj.(metav1.Object)
+		check := ManifestCheck{
+			Kind: obj.GetObjectKind().GroupVersionKind().Kind,
+			Name: meta.GetName(),
+		}
+
+		var err error
+		switch o := obj.(type) {
+		case *corev1.Namespace:
+			if namespaceExists {
+				check.Valid = true
+				checks = append(checks, check)
+				continue
+			}
+			_, err = c.clientset.CoreV1().Namespaces().Create(ctx, o, create)
+		default:
+			if !namespaceExists {
+				check.Skipped = true
+				checks = append(checks, check)
+				continue
+			}
+			err = c.dryRunApply(ctx, obj, create, update)
+		}
+
+		if err != nil {
+			reason, message := FailureOf(TranslateError("validate "+check.Kind, err))
+			check.Reason = reason
+			check.Error = message
+		} else {
+			check.Valid = true
+		}
+		checks = append(checks, check)
+	}
+
+	return checks, nil
+}
+
+// dryRunApply creates the object or, when it exists, updates it on top of the
+// live resourceVersion
+func (c *Client) dryRunApply(ctx context.Context, obj runtime.Object, create metav1.CreateOptions, update metav1.UpdateOptions) error {
+	get := metav1.GetOptions{}
+
+	switch o := obj.(type) {
+	case *corev1.Secret:
+		secrets := c.clientset.CoreV1().Secrets(o.Namespace)
+		existing, err := secrets.Get(ctx, o.Name, get)
+		if err == nil {
+			o.ResourceVersion = existing.ResourceVersion
+			_, err = secrets.Update(ctx, o, update)
+		} else if k8serrors.IsNotFound(err) {
+			_, err = secrets.Create(ctx, o, create)
+		}
+		return err
+	case *appsv1.Deployment:
+		deployments := c.clientset.AppsV1().Deployments(o.Namespace)
+		existing, err := deployments.Get(ctx, o.Name, get)
+		if err == nil {
+			o.ResourceVersion = existing.ResourceVersion
+			_, err = deployments.Update(ctx, o, update)
+		} else if k8serrors.IsNotFound(err) {
+			_, err = deployments.Create(ctx, o, create)
+		}
+		return err
+	case *corev1.Service:
+		services := c.clientset.CoreV1().Services(o.Namespace)
+		existing, err := services.Get(ctx, o.Name, get)
+		if err == nil {
+			o.ResourceVersion = existing.ResourceVersion
+			o.Spec.ClusterIP = existing.Spec.ClusterIP
+			_, err = services.Update(ctx, o, update)
+		} else if k8serrors.IsNotFound(err) {
+			_, err = services.Create(ctx, o, create)
+		}
+		return err
+	case *networkingv1.Ingress:
+		ingresses := c.clientset.NetworkingV1().Ingresses(o.Namespace)
+		existing, err := ingresses.Get(ctx, o.Name, get)
+		if err == nil {
+			o.ResourceVersion = existing.ResourceVersion
+			_, err = ingresses.Update(ctx, o, update)
+		} else if k8serrors.IsNotFound(err) {
+			_, err = ingresses.Create(ctx, o, create)
+		}
+		return err
+	case *batchv1.CronJob:
+		cronJobs := c.clientset.BatchV1().CronJobs(o.Namespace)
+		existing, err := cronJobs.Get(ctx, o.Name, get)
+		if err == nil {
+			o.ResourceVersion = existing.ResourceVersion
+			_, err = cronJobs.Update(ctx, o, update)
+		} else if k8serrors.IsNotFound(err) {
+			_, err = cronJobs.Create(ctx, o, create)
+		}
+		return err
+	}
+
+	return fmt.Errorf("unsupported manifest %T", obj)
+}
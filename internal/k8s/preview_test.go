@@ -0,0 +1,88 @@
+package k8s
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestManifests(t *testing.T) {
+	objects := Manifests(testAppConfig(withWorkloads))
+
+	var kinds []string
+	for _, obj := range objects {
+		kinds = append(kinds, obj.GetObjectKind().GroupVersionKind().Kind)
+	}
+
+	expected := "Namespace,Secret,Deployment,Deployment,Service,Ingress,CronJob"
+	if strings.Join(kinds, ",") != expected {
+		t.Errorf("expected %s, got %s", expected, strings.Join(kinds, ","))
+	}
+
+	secret := objects[1].(*corev1.Secret)
+	if secret.StringData["DATABASE_URL"] != RedactedValue {
+		t.Errorf("expected secret value to be redacted, got %q", secret.StringData["DATABASE_URL"])
+	}
+}
+
+func TestManifestsYAML(t *testing.T) {
+	data, err := ManifestsYAML(Manifests(testAppConfig(withWorkloads)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rendered := string(data)
+
+	if strings.Count(rendered, "\n---\n") != 6 {
+		t.Errorf("expected 7 YAML documents, got:\n%s", rendered)
+	}
+
+	for _, want := range []string{"apiVersion: apps/v1", "kind: Ingress", "image: myapp:v1", "host: myapp.nexo.build"} {
+		if !strings.Contains(rendered, want) {
+			t.Errorf("expected rendered manifests to contain %q", want)
+		}
+	}
+
+	if strings.Contains(rendered, "postgres://secret") {
+		t.Error("expected env values to be redacted")
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	t.Run("new app", func(t *testing.T) {
+		client := NewClientWithInterface(fake.NewClientset(), "fuego-")
+
+		checks, err := client.DryRun(context.Background(), testAppConfig(withWorkloads))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !checks[0].Valid {
+			t.Errorf("expected namespace to validate, got %+v", checks[0])
+		}
+		for _, check := range checks[1:] {
+			if !check.Skipped {
+				t.Errorf("expected %s to be skipped until the namespace exists", check.Kind)
+			}
+		}
+	})
+
+	t.Run("deployed app", func(t *testing.T) {
+		fakeClient := fake.NewClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "fuego-myapp"}})
+		client := NewClientWithInterface(fakeClient, "fuego-")
+
+		checks, err := client.DryRun(context.Background(), testAppConfig(withWorkloads))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for _, check := range checks {
+			if !check.Valid {
+				t.Errorf("expected %s %s to validate, got %+v", check.Kind, check.Name, check)
+			}
+		}
+	})
+}
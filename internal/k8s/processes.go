@@ -0,0 +1,281 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ProcessTypeWeb is the process type that receives HTTP traffic
+const ProcessTypeWeb = "web"
+
+// Process limits
+const (
+	MaxProcessTypeLength = 20
+	MaxProcessReplicas   = 10
+)
+
+// processLabel marks which process type a pod belongs to
+const processLabel = "nexo.build/process"
+
+var processTypeRegex = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// ProcessConfig describes one process type of an app, Procfile style
+type ProcessConfig struct {
+	Type     string
+	Command  string
+	Replicas int32
+	CPU      string
+	Memory   string
+}
+
+// Process returns the configured process of the given type, or nil
+func (cfg *AppConfig) Process(processType string) *ProcessConfig {
+	for i := range cfg.Processes {
+		if cfg.Processes[i].Type == processType {
+			return &cfg.Processes[i]
+		}
+	}
+	return nil
+}
+
+// ProcessDeploymentName returns the Deployment name for a process type. The
+// web process keeps the app name so existing deployments are reused.
+func ProcessDeploymentName(appName, processType string) string {
+	if processType == ProcessTypeWeb {
+		return appName
+	}
+	return appName + "-" + processType
+}
+
+// ValidateProcess checks a process type configuration
+func ValidateProcess(proc *ProcessConfig) error {
+	if len(proc.Type) > MaxProcessTypeLength || !processTypeRegex.MatchString(proc.Type) {
+		return fmt.Errorf("process type %q must start with a letter and contain only lowercase letters, numbers, and hyphens (max %d)", proc.Type, MaxProcessTypeLength)
+	}
+
+	if proc.Type != ProcessTypeWeb && strings.TrimSpace(proc.Command) == "" {
+		return fmt.Errorf("process %q requires a command", proc.Type)
+	}
+
+	if proc.Replicas < 0 || proc.Replicas > MaxProcessReplicas {
+		return fmt.Errorf("process %q replicas must be between 0 and %d", proc.Type, MaxProcessReplicas)
+	}
+
+	if err := ValidateResources(proc.CPU, proc.Memory); err != nil {
+		return fmt.Errorf("process %q: %w", proc.Type, err)
+	}
+
+	return nil
+}
+
+// ValidateResources checks CPU and memory quantities; empty values are allowed
+func ValidateResources(cpu, memory string) error {
+	if cpu != "" {
+		q, err := resource.ParseQuantity(cpu)
+		if err != nil || q.Sign() <= 0 {
+			return fmt.Errorf("invalid cpu %q", cpu)
+		}
+	}
+
+	if memory != "" {
+		q, err := resource.ParseQuantity(memory)
+		if err != nil || q.Sign() <= 0 {
+			return fmt.Errorf("invalid memory %q", memory)
+		}
+	}
+
+	return nil
+}
+
+// ValidateProcesses checks a full formation for an app
+func ValidateProcesses(appName string, procs []ProcessConfig) error {
+	seen := make(map[string]bool, len(procs))
+	for i := range procs {
+		if err := ValidateProcess(&procs[i]); err != nil {
+			return err
+		}
+		if seen[procs[i].Type] {
+			return fmt.Errorf("process %q is defined more than once", procs[i].Type)
+		}
+		seen[procs[i].Type] = true
+
+		if len(ProcessDeploymentName(appName, procs[i].Type)) > 63 {
+			return errors.New("app and process names together must be at most 62 characters")
+		}
+	}
+	return nil
+}
+
+func processLabels(appName, processType string) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":       appName,
+		"app.kubernetes.io/managed-by": "nexo-cloud",
+		processLabel:                   processType,
+	}
+}
+
+// processResources sets requests and limits to the same values so a process
+// gets exactly what its formation asks for
+func processResources(proc *ProcessConfig) corev1.ResourceRequirements {
+	list := corev1.ResourceList{}
+	if proc.CPU != "" {
+		if q, err := resource.ParseQuantity(proc.CPU); err == nil {
+			list[corev1.ResourceCPU] = q
+		}
+	}
+	if proc.Memory != "" {
+		if q, err := resource.ParseQuantity(proc.Memory); err == nil {
+			list[corev1.ResourceMemory] = q
+		}
+	}
+	if len(list) == 0 {
+		return corev1.ResourceRequirements{}
+	}
+	return corev1.ResourceRequirements{
+		Requests: list,
+		Limits:   list.DeepCopy(),
+	}
+}
+
+// GenerateProcessDeployment builds the Deployment for a non-web process type.
+// Workers share the app image and env secret but expose no ports.
+func GenerateProcessDeployment(cfg *AppConfig, proc *ProcessConfig) *appsv1.Deployment {
+	labels := processLabels(cfg.Name, proc.Type)
+	replicas := proc.Replicas
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ProcessDeploymentName(cfg.Name, proc.Type),
+			Namespace: cfg.Namespace,
+			Labels:    appLabels(cfg, labels),
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: appLabels(cfg, labels),
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:    proc.Type,
+							Image:   cfg.Image,
+							Command: []string{"/bin/sh", "-c", proc.Command},
+							EnvFrom: []corev1.EnvFromSource{
+								{
+									SecretRef: &corev1.SecretEnvSource{
+										LocalObjectReference: corev1.LocalObjectReference{
+											Name: cfg.Name + "-env",
+										},
+									},
+								},
+							},
+							Resources: processResources(proc),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	cfg.Placement.applyTo(&deployment.Spec.Template.Spec)
+	applyArchitectures(&deployment.Spec.Template.Spec, cfg.Architectures)
+	applyPoolers(&deployment.Spec.Template.Spec, cfg)
+	applyImagePullSecret(&deployment.Spec.Template.Spec, cfg)
+
+	return deployment
+}
+
+// ApplyProcesses applies the Deployments of every non-web process type and
+// removes those of process types no longer in the formation
+func (c *Client) ApplyProcesses(ctx context.Context, cfg *AppConfig) error {
+	cfg.Namespace = c.NamespaceForApp(cfg.Name)
+	deployments := c.clientset.AppsV1().Deployments(cfg.Namespace)
+
+	wanted := make(map[string]bool, len(cfg.Processes))
+	for i := range cfg.Processes {
+		proc := &cfg.Processes[i]
+		if proc.Type == ProcessTypeWeb {
+			continue
+		}
+		wanted[proc.Type] = true
+
+		err := retryOnConflict(func() error {
+			deployment := GenerateProcessDeployment(cfg, proc)
+			existing, err := deployments.Get(ctx, deployment.Name, metav1.GetOptions{})
+			switch {
+			case err == nil:
+				deployment.ResourceVersion = existing.ResourceVersion
+				_, err = deployments.Update(ctx, deployment, metav1.UpdateOptions{})
+			case k8serrors.IsNotFound(err):
+				_, err = deployments.Create(ctx, deployment, metav1.CreateOptions{})
+			}
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply %s process: %w", proc.Type, err)
+		}
+	}
+
+	existing, err := deployments.List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("app.kubernetes.io/name=%s,%s,%s!=%s", cfg.Name, processLabel, processLabel, ProcessTypeWeb),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list process deployments: %w", err)
+	}
+
+	for _, deployment := range existing.Items {
+		if wanted[deployment.Labels[processLabel]] {
+			continue
+		}
+		err := deployments.Delete(ctx, deployment.Name, metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("failed to remove %s process: %w", deployment.Labels[processLabel], err)
+		}
+	}
+
+	return nil
+}
+
+// ResizeProcess replaces the CPU and memory of a process type's Deployment.
+// Changing the pod template triggers a rolling restart.
+func (c *Client) ResizeProcess(ctx context.Context, appName, processType, cpu, memory string) error {
+	namespace := c.NamespaceForApp(appName)
+	deployments := c.clientset.AppsV1().Deployments(namespace)
+
+	return retryOnConflict(func() error {
+		deployment, err := deployments.Get(ctx, ProcessDeploymentName(appName, processType), metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get deployment: %w", err)
+		}
+
+		if len(deployment.Spec.Template.Spec.Containers) == 0 {
+			return fmt.Errorf("deployment %s has no containers", deployment.Name)
+		}
+
+		deployment.Spec.Template.Spec.Containers[0].Resources = processResources(&ProcessConfig{
+			Type:   processType,
+			CPU:    cpu,
+			Memory: memory,
+		})
+
+		_, err = deployments.Update(ctx, deployment, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to resize deployment: %w", err)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,193 @@
+package k8s
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestProcessDeploymentName(t *testing.T) {
+	if name := ProcessDeploymentName("myapp", ProcessTypeWeb); name != "myapp" {
+		t.Errorf("expected web process to keep app name, got %q", name)
+	}
+
+	if name := ProcessDeploymentName("myapp", "worker"); name != "myapp-worker" {
+		t.Errorf("expected 'myapp-worker', got %q", name)
+	}
+}
+
+func TestValidateProcesses(t *testing.T) {
+	tests := []struct {
+		name    string
+		procs   []ProcessConfig
+		wantErr bool
+	}{
+		{"web only", []ProcessConfig{{Type: "web", Replicas: 2}}, false},
+		{"web and worker", []ProcessConfig{{Type: "web", Replicas: 1}, {Type: "worker", Command: "bin/worker", Replicas: 1, CPU: "250m", Memory: "512Mi"}}, false},
+		{"worker without command", []ProcessConfig{{Type: "worker", Replicas: 1}}, true},
+		{"invalid type", []ProcessConfig{{Type: "Worker_1", Command: "x"}}, true},
+		{"duplicate type", []ProcessConfig{{Type: "worker", Command: "x"}, {Type: "worker", Command: "y"}}, true},
+		{"too many replicas", []ProcessConfig{{Type: "worker", Command: "x", Replicas: 11}}, true},
+		{"invalid memory", []ProcessConfig{{Type: "worker", Command: "x", Memory: "lots"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateProcesses("myapp", tt.procs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateProcesses() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenerateProcessDeployment(t *testing.T) {
+	cfg := &AppConfig{Name: "myapp", Namespace: "fuego-myapp", Image: "myapp:v1"}
+	proc := &ProcessConfig{Type: "worker", Command: "bin/worker", Replicas: 3, Memory: "512Mi"}
+
+	deployment := GenerateProcessDeployment(cfg, proc)
+
+	if deployment.Name != "myapp-worker" {
+		t.Errorf("expected name 'myapp-worker', got %q", deployment.Name)
+	}
+
+	if *deployment.Spec.Replicas != 3 {
+		t.Errorf("expected 3 replicas, got %d", *deployment.Spec.Replicas)
+	}
+
+	if deployment.Spec.Selector.MatchLabels["nexo.build/process"] != "worker" {
+		t.Errorf("expected selector to include process type, got %v", deployment.Spec.Selector.MatchLabels)
+	}
+
+	container := deployment.Spec.Template.Spec.Containers[0]
+	if len(container.Ports) != 0 || container.ReadinessProbe != nil {
+		t.Error("expected worker container to have no ports or probes")
+	}
+
+	if strings.Join(container.Command, " ") != "/bin/sh -c bin/worker" {
+		t.Errorf("unexpected command %v", container.Command)
+	}
+
+	if container.EnvFrom[0].SecretRef.Name != "myapp-env" {
+		t.Errorf("expected worker to share 'myapp-env', got %q", container.EnvFrom[0].SecretRef.Name)
+	}
+
+	memory := container.Resources.Limits[corev1.ResourceMemory]
+	if memory.String() != "512Mi" {
+		t.Errorf("expected 512Mi memory limit, got %q", memory.String())
+	}
+}
+
+func TestGenerateDeployment_WebProcess(t *testing.T) {
+	cfg := &AppConfig{
+		Name:      "myapp",
+		Namespace: "fuego-myapp",
+		Image:     "myapp:v1",
+		Replicas:  1,
+		Port:      8080,
+		Processes: []ProcessConfig{
+			{Type: ProcessTypeWeb, Command: "bin/server", Replicas: 4, CPU: "500m"},
+			{Type: "worker", Command: "bin/worker", Replicas: 1},
+		},
+	}
+
+	deployment := GenerateDeployment(cfg)
+
+	if *deployment.Spec.Replicas != 4 {
+		t.Errorf("expected web process replicas to win, got %d", *deployment.Spec.Replicas)
+	}
+
+	if _, ok := deployment.Spec.Selector.MatchLabels["nexo.build/process"]; ok {
+		t.Error("expected web selector to stay unchanged")
+	}
+
+	if deployment.Spec.Template.Labels["nexo.build/process"] != ProcessTypeWeb {
+		t.Errorf("expected web pods to carry the process label, got %v", deployment.Spec.Template.Labels)
+	}
+
+	container := deployment.Spec.Template.Spec.Containers[0]
+	if strings.Join(container.Command, " ") != "/bin/sh -c bin/server" {
+		t.Errorf("unexpected command %v", container.Command)
+	}
+
+	service := GenerateService(cfg)
+	if service.Spec.Selector["nexo.build/process"] != ProcessTypeWeb {
+		t.Errorf("expected service to only target web pods, got %v", service.Spec.Selector)
+	}
+}
+
+func TestApplyProcesses(t *testing.T) {
+	stale := GenerateProcessDeployment(&AppConfig{Name: "myapp", Namespace: "fuego-myapp"}, &ProcessConfig{Type: "scheduler", Command: "bin/clock"})
+	web := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myapp",
+			Namespace: "fuego-myapp",
+			Labels:    map[string]string{"app.kubernetes.io/name": "myapp"},
+		},
+	}
+	fakeClient := fake.NewClientset(stale, web)
+	client := NewClientWithInterface(fakeClient, "fuego-")
+
+	cfg := &AppConfig{
+		Name:  "myapp",
+		Image: "myapp:v2",
+		Processes: []ProcessConfig{
+			{Type: ProcessTypeWeb, Replicas: 2},
+			{Type: "worker", Command: "bin/worker", Replicas: 2},
+		},
+	}
+
+	if err := client.ApplyProcesses(context.Background(), cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deployments, _ := fakeClient.AppsV1().Deployments("fuego-myapp").List(context.Background(), metav1.ListOptions{})
+	names := make(map[string]bool)
+	for _, d := range deployments.Items {
+		names[d.Name] = true
+	}
+
+	if !names["myapp-worker"] {
+		t.Error("expected worker deployment to be created")
+	}
+
+	if names["myapp-scheduler"] {
+		t.Error("expected removed scheduler process to be pruned")
+	}
+
+	if !names["myapp"] {
+		t.Error("expected web deployment to be left alone")
+	}
+}
+
+func TestResizeProcess(t *testing.T) {
+	cfg := &AppConfig{Name: "myapp", Namespace: "fuego-myapp", Image: "myapp:v1", Replicas: 1, Port: 8080}
+	fakeClient := fake.NewClientset(GenerateDeployment(cfg))
+	client := NewClientWithInterface(fakeClient, "fuego-")
+
+	if err := client.ResizeProcess(context.Background(), "myapp", ProcessTypeWeb, "1", "1Gi"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deployment, _ := fakeClient.AppsV1().Deployments("fuego-myapp").Get(context.Background(), "myapp", metav1.GetOptions{})
+	resources := deployment.Spec.Template.Spec.Containers[0].Resources
+
+	cpu := resources.Requests[corev1.ResourceCPU]
+	if cpu.String() != "1" {
+		t.Errorf("expected cpu request 1, got %q", cpu.String())
+	}
+
+	memory := resources.Limits[corev1.ResourceMemory]
+	if memory.String() != "1Gi" {
+		t.Errorf("expected memory limit 1Gi, got %q", memory.String())
+	}
+
+	if err := client.ResizeProcess(context.Background(), "myapp", "worker", "1", ""); err == nil {
+		t.Error("expected error resizing a process without a deployment")
+	}
+}
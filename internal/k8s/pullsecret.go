@@ -0,0 +1,100 @@
+package k8s
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PullSecretConfig is the credential the kubelet pulls an app's image from a
+// private registry with
+type PullSecretConfig struct {
+	// Server is the registry host, such as registry.nexo.build
+	Server   string
+	Username string
+	Password string
+}
+
+// PullSecretName is the image pull secret of an app
+func PullSecretName(appName string) string {
+	return appName + "-registry"
+}
+
+// GeneratePullSecret builds the dockerconfigjson secret of an app's registry
+// credential
+func GeneratePullSecret(cfg *AppConfig) (*corev1.Secret, error) {
+	auth := base64.StdEncoding.EncodeToString([]byte(cfg.PullSecret.Username + ":" + cfg.PullSecret.Password))
+	dockerConfig, err := json.Marshal(map[string]any{
+		"auths": map[string]any{
+			cfg.PullSecret.Server: map[string]string{
+				"username": cfg.PullSecret.Username,
+				"password": cfg.PullSecret.Password,
+				"auth":     auth,
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      PullSecretName(cfg.Name),
+			Namespace: cfg.Namespace,
+			Labels: appLabels(cfg, map[string]string{
+				"app.kubernetes.io/name":       cfg.Name,
+				"app.kubernetes.io/managed-by": "nexo-cloud",
+			}),
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{corev1.DockerConfigJsonKey: dockerConfig},
+	}, nil
+}
+
+// applyImagePullSecret makes pods pull with the app's registry credential,
+// when it has one
+func applyImagePullSecret(spec *corev1.PodSpec, cfg *AppConfig) {
+	if cfg.PullSecret == nil {
+		spec.ImagePullSecrets = nil
+		return
+	}
+	spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: PullSecretName(cfg.Name)}}
+}
+
+func (c *Client) applyPullSecret(ctx context.Context, cfg *AppConfig) error {
+	secrets := c.clientset.CoreV1().Secrets(cfg.Namespace)
+
+	if cfg.PullSecret == nil {
+		err := secrets.Delete(ctx, PullSecretName(cfg.Name), metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete pull secret: %w", err)
+		}
+		return nil
+	}
+
+	return retryOnConflict(func() error {
+		secret, err := GeneratePullSecret(cfg)
+		if err != nil {
+			return err
+		}
+
+		existing, err := secrets.Get(ctx, secret.Name, metav1.GetOptions{})
+		if err == nil {
+			secret.ResourceVersion = existing.ResourceVersion
+			_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+			return err
+		}
+
+		if k8serrors.IsNotFound(err) {
+			_, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+			return err
+		}
+
+		return err
+	})
+}
@@ -0,0 +1,96 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGeneratePullSecret(t *testing.T) {
+	cfg := &AppConfig{
+		Name:       "web",
+		Namespace:  "fuego-web",
+		PullSecret: &PullSecretConfig{Server: "registry.nexo.build", Username: "alice", Password: "fgp_secret"},
+	}
+	secret, err := GeneratePullSecret(cfg)
+	if err != nil {
+		t.Fatalf("GeneratePullSecret: %v", err)
+	}
+	if secret.Name != "web-registry" || secret.Type != corev1.SecretTypeDockerConfigJson {
+		t.Errorf("unexpected secret %s of type %s", secret.Name, secret.Type)
+	}
+
+	var dockerConfig struct {
+		Auths map[string]struct {
+			Username string `json:"username"`
+			Auth     string `json:"auth"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &dockerConfig); err != nil {
+		t.Fatalf("invalid docker config: %v", err)
+	}
+	entry := dockerConfig.Auths["registry.nexo.build"]
+	if entry.Username != "alice" || entry.Auth != "YWxpY2U6ZmdwX3NlY3JldA==" {
+		t.Errorf("unexpected auth entry %+v", entry)
+	}
+}
+
+func TestImagePullSecretInPodSpecs(t *testing.T) {
+	cfg := &AppConfig{
+		Name:       "web",
+		Namespace:  "fuego-web",
+		Image:      "registry.nexo.build/alice/web:v1",
+		Port:       3000,
+		Processes:  []ProcessConfig{{Type: "worker", Command: "work", Replicas: 1}},
+		CronJobs:   []CronJobConfig{{Name: "nightly", Schedule: "0 0 * * *", Command: "run"}},
+		PullSecret: &PullSecretConfig{Server: "registry.nexo.build", Username: "alice", Password: "fgp_secret"},
+	}
+
+	specs := map[string]corev1.PodSpec{
+		"web":    GenerateDeployment(cfg).Spec.Template.Spec,
+		"worker": GenerateProcessDeployment(cfg, &cfg.Processes[0]).Spec.Template.Spec,
+		"cron":   GenerateCronJob(cfg, &cfg.CronJobs[0]).Spec.JobTemplate.Spec.Template.Spec,
+	}
+	for name, spec := range specs {
+		if len(spec.ImagePullSecrets) != 1 || spec.ImagePullSecrets[0].Name != "web-registry" {
+			t.Errorf("expected %s pods to pull with web-registry, got %v", name, spec.ImagePullSecrets)
+		}
+	}
+
+	cfg.PullSecret = nil
+	if secrets := GenerateDeployment(cfg).Spec.Template.Spec.ImagePullSecrets; secrets != nil {
+		t.Errorf("expected no pull secret for images elsewhere, got %v", secrets)
+	}
+}
+
+func TestApplyPullSecret(t *testing.T) {
+	clientset := fake.NewClientset()
+	client := NewClientWithInterface(clientset, "fuego-")
+	ctx := context.Background()
+
+	cfg := &AppConfig{
+		Name:       "web",
+		Namespace:  "fuego-web",
+		PullSecret: &PullSecretConfig{Server: "registry.nexo.build", Username: "alice", Password: "fgp_secret"},
+	}
+	if err := client.applyPullSecret(ctx, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := clientset.CoreV1().Secrets("fuego-web").Get(ctx, "web-registry", metav1.GetOptions{}); err != nil {
+		t.Fatalf("expected pull secret: %v", err)
+	}
+
+	// Switching to an image elsewhere removes the credential
+	cfg.PullSecret = nil
+	if err := client.applyPullSecret(ctx, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := clientset.CoreV1().Secrets("fuego-web").Get(ctx, "web-registry", metav1.GetOptions{}); !k8serrors.IsNotFound(err) {
+		t.Errorf("expected the pull secret to be deleted, got %v", err)
+	}
+}
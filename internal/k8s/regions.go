@@ -0,0 +1,34 @@
+package k8s
+
+// Regions connects to the cluster of each region on first use and reuses the
+// client after. Background jobs make one per run, so a region that could not
+// be reached is tried again by the next item and the next run. It is not safe
+// for concurrent use.
+type Regions struct {
+	kubeconfig func(region string) string
+	connect    func(kubeconfig string) (Interface, error)
+	clients    map[string]Interface
+}
+
+// NewRegions returns a cache connecting with connect to the kubeconfig
+// kubeconfig returns for a region, such as config.KubeconfigForRegion
+func NewRegions(kubeconfig func(region string) string, connect func(kubeconfig string) (Interface, error)) *Regions {
+	return &Regions{
+		kubeconfig: kubeconfig,
+		connect:    connect,
+		clients:    make(map[string]Interface),
+	}
+}
+
+// Client returns the client of a region's cluster
+func (r *Regions) Client(region string) (Interface, error) {
+	if client, ok := r.clients[region]; ok {
+		return client, nil
+	}
+	client, err := r.connect(r.kubeconfig(region))
+	if err != nil {
+		return nil, err
+	}
+	r.clients[region] = client
+	return client, nil
+}
@@ -0,0 +1,44 @@
+package k8s
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRegions_ConnectsOncePerRegion(t *testing.T) {
+	connects := map[string]int{}
+	unreachable := errors.New("unreachable")
+	regions := NewRegions(func(region string) string {
+		return "/etc/kube/" + region
+	}, func(kubeconfig string) (Interface, error) {
+		connects[kubeconfig]++
+		if kubeconfig == "/etc/kube/down" {
+			return nil, unreachable
+		}
+		return NewFake(), nil
+	})
+
+	first, err := regions.Client("eu")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, _ := regions.Client("eu")
+	if first != second {
+		t.Error("expected the client of a region to be reused")
+	}
+	if _, err := regions.Client("us"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for range 2 {
+		if _, err := regions.Client("down"); !errors.Is(err, unreachable) {
+			t.Errorf("expected the connect error, got %v", err)
+		}
+	}
+
+	if connects["/etc/kube/eu"] != 1 || connects["/etc/kube/us"] != 1 {
+		t.Errorf("expected one connect per region, got %v", connects)
+	}
+	if connects["/etc/kube/down"] != 2 {
+		t.Errorf("expected a failed connect to be retried, got %d", connects["/etc/kube/down"])
+	}
+}
@@ -0,0 +1,34 @@
+package k8s
+
+import (
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+)
+
+// applyBackoff spaces out retries of read-then-write calls that lost a race
+// with a controller or a concurrent deploy, about 3s in total
+var applyBackoff = wait.Backoff{
+	Steps:    6,
+	Duration: 100 * time.Millisecond,
+	Factor:   2,
+	Jitter:   0.1,
+}
+
+// isRetryable reports whether an API error is transient and the whole
+// read-then-write should be attempted again
+func isRetryable(err error) bool {
+	return k8serrors.IsConflict(err) ||
+		k8serrors.IsAlreadyExists(err) ||
+		k8serrors.IsServerTimeout(err) ||
+		k8serrors.IsTooManyRequests(err) ||
+		k8serrors.IsServiceUnavailable(err)
+}
+
+// retryOnConflict runs fn until it succeeds, fails with a permanent error or
+// the backoff is exhausted. fn must re-read the object it updates.
+func retryOnConflict(fn func() error) error {
+	return retry.OnError(applyBackoff, isRetryable, fn)
+}
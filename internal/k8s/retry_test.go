@@ -0,0 +1,116 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// conflictOnce makes the first matching call fail with 409 Conflict
+func conflictOnce(fakeClient *fake.Clientset, verb, resource string) *int {
+	calls := 0
+	fakeClient.PrependReactor(verb, resource, func(action k8stesting.Action) (bool, runtime.Object, error) {
+		calls++
+		if calls == 1 {
+			return true, nil, k8serrors.NewConflict(schema.GroupResource{Resource: resource}, "", errors.New("the object has been modified"))
+		}
+		return false, nil, nil
+	})
+	return &calls
+}
+
+func TestIsRetryable(t *testing.T) {
+	gr := schema.GroupResource{Group: "apps", Resource: "deployments"}
+
+	if !isRetryable(k8serrors.NewConflict(gr, "myapp", errors.New("modified"))) {
+		t.Error("expected conflict to be retryable")
+	}
+	if !isRetryable(k8serrors.NewAlreadyExists(gr, "myapp")) {
+		t.Error("expected already exists to be retryable")
+	}
+	if isRetryable(k8serrors.NewForbidden(gr, "myapp", errors.New("denied"))) {
+		t.Error("expected forbidden to be permanent")
+	}
+	if isRetryable(k8serrors.NewNotFound(gr, "myapp")) {
+		t.Error("expected not found to be permanent")
+	}
+}
+
+func TestApplyDeployment_RetriesConflict(t *testing.T) {
+	cfg := &AppConfig{Name: "myapp", Namespace: "fuego-myapp", Image: "myapp:v1", Replicas: 1, Port: 8080}
+	fakeClient := fake.NewClientset(GenerateDeployment(cfg))
+	calls := conflictOnce(fakeClient, "update", "deployments")
+	client := NewClientWithInterface(fakeClient, "fuego-")
+
+	cfg.Image = "myapp:v2"
+	if err := client.applyDeployment(context.Background(), cfg); err != nil {
+		t.Fatalf("expected conflict to be retried, got %v", err)
+	}
+
+	if *calls != 2 {
+		t.Errorf("expected 2 update attempts, got %d", *calls)
+	}
+
+	deployment, _ := fakeClient.AppsV1().Deployments("fuego-myapp").Get(context.Background(), "myapp", metav1.GetOptions{})
+	if deployment.Spec.Template.Spec.Containers[0].Image != "myapp:v2" {
+		t.Errorf("expected image to be updated, got %q", deployment.Spec.Template.Spec.Containers[0].Image)
+	}
+}
+
+func TestScaleProcess_RetriesConflict(t *testing.T) {
+	cfg := &AppConfig{Name: "myapp", Namespace: "fuego-myapp", Image: "myapp:v1", Replicas: 1, Port: 8080}
+	fakeClient := fake.NewClientset(GenerateDeployment(cfg))
+	conflictOnce(fakeClient, "update", "deployments")
+	client := NewClientWithInterface(fakeClient, "fuego-")
+
+	if err := client.ScaleProcess(context.Background(), "myapp", ProcessTypeWeb, 3); err != nil {
+		t.Fatalf("expected conflict to be retried, got %v", err)
+	}
+
+	deployment, _ := fakeClient.AppsV1().Deployments("fuego-myapp").Get(context.Background(), "myapp", metav1.GetOptions{})
+	if *deployment.Spec.Replicas != 3 {
+		t.Errorf("expected 3 replicas, got %d", *deployment.Spec.Replicas)
+	}
+}
+
+func TestApplyService_CreateRace(t *testing.T) {
+	cfg := &AppConfig{Name: "myapp", Namespace: "fuego-myapp", Port: 8080}
+	fakeClient := fake.NewClientset()
+	client := NewClientWithInterface(fakeClient, "fuego-")
+
+	// Another deploy creates the service between our get and create
+	raced := false
+	fakeClient.PrependReactor("create", "services", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if raced {
+			return false, nil, nil
+		}
+		raced = true
+		_ = fakeClient.Tracker().Add(GenerateService(cfg))
+		return true, nil, k8serrors.NewAlreadyExists(schema.GroupResource{Resource: "services"}, "myapp")
+	})
+
+	if err := client.applyService(context.Background(), cfg); err != nil {
+		t.Fatalf("expected create race to fall back to update, got %v", err)
+	}
+}
+
+func TestEnsureNamespace_Idempotent(t *testing.T) {
+	fakeClient := fake.NewClientset()
+	fakeClient.PrependReactor("create", "namespaces", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, k8serrors.NewAlreadyExists(schema.GroupResource{Resource: "namespaces"}, "fuego-myapp")
+	})
+	client := NewClientWithInterface(fakeClient, "fuego-")
+
+	cfg := &AppConfig{Name: "myapp"}
+	cfg.Namespace = client.NamespaceForApp(cfg.Name)
+	if err := client.ensureNamespace(context.Background(), cfg); err != nil {
+		t.Errorf("expected existing namespace to be accepted, got %v", err)
+	}
+}
@@ -0,0 +1,286 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Router limits
+const (
+	MaxRouterRoutes       = 20
+	MaxRoutePathLength    = 255
+	routerLabel           = "nexo.build/router"
+	routerNamespacePrefix = "router-"
+)
+
+var routePathRegex = regexp.MustCompile(`^(/[A-Za-z0-9._~-]+)*/?$`)
+
+// RouterConfig composes several apps behind one host. Each route sends a
+// path prefix to an app; the longest matching prefix wins.
+type RouterConfig struct {
+	Name      string
+	Namespace string
+	Host      string
+	Routes    []RouterRoute
+}
+
+// RouterRoute sends requests under PathPrefix to an app
+type RouterRoute struct {
+	PathPrefix string
+	AppName    string
+}
+
+// RouterNamespace returns the namespace holding a router's ingress. Routers
+// get their own namespace so their names never clash with app resources.
+func (c *Client) RouterNamespace(routerName string) string {
+	return c.namespacePrefix + routerNamespacePrefix + routerName
+}
+
+// NormalizeRoutePath trims a trailing slash so "/api/" and "/api" are the same route
+func NormalizeRoutePath(path string) string {
+	if path != "/" {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return path
+}
+
+// ValidateRouter checks the routes of a router
+func ValidateRouter(cfg *RouterConfig) error {
+	if len(cfg.Routes) == 0 {
+		return errors.New("at least one route is required")
+	}
+	if len(cfg.Routes) > MaxRouterRoutes {
+		return fmt.Errorf("a router may have at most %d routes", MaxRouterRoutes)
+	}
+
+	seen := make(map[string]bool, len(cfg.Routes))
+	for _, route := range cfg.Routes {
+		if route.PathPrefix == "" || len(route.PathPrefix) > MaxRoutePathLength || !routePathRegex.MatchString(route.PathPrefix) {
+			return fmt.Errorf("path %q must start with / and contain only URL-safe segments", route.PathPrefix)
+		}
+		if route.AppName == "" {
+			return fmt.Errorf("path %q requires an app", route.PathPrefix)
+		}
+		path := NormalizeRoutePath(route.PathPrefix)
+		if seen[path] {
+			return fmt.Errorf("path %q is routed more than once", path)
+		}
+		seen[path] = true
+	}
+	return nil
+}
+
+// routerServiceName is the ExternalName service standing in for an app in
+// the router namespace
+func routerServiceName(appName string) string {
+	return "app-" + appName
+}
+
+func routerLabels(cfg *RouterConfig) map[string]string {
+	return map[string]string{
+		routerLabel:                    cfg.Name,
+		"app.kubernetes.io/managed-by": "nexo-cloud",
+	}
+}
+
+// GenerateRouterServices returns one ExternalName service per routed app,
+// pointing at the app's service in its own namespace. Ingress backends must
+// live in the ingress namespace, so this is how one Ingress reaches several
+// apps.
+func (c *Client) GenerateRouterServices(cfg *RouterConfig) []*corev1.Service {
+	apps := make(map[string]bool)
+	var services []*corev1.Service
+	for _, route := range cfg.Routes {
+		if apps[route.AppName] {
+			continue
+		}
+		apps[route.AppName] = true
+
+		services = append(services, c.proxyService(cfg.Namespace, route.AppName, routerLabels(cfg)))
+	}
+	return services
+}
+
+// GenerateRouterIngress returns a single Ingress with one backend per route,
+// longest prefix first
+func GenerateRouterIngress(cfg *RouterConfig) *networkingv1.Ingress {
+	pathType := networkingv1.PathTypePrefix
+	ingressClassName := "traefik"
+
+	routes := append([]RouterRoute(nil), cfg.Routes...)
+	sort.SliceStable(routes, func(i, j int) bool {
+		return len(NormalizeRoutePath(routes[i].PathPrefix)) > len(NormalizeRoutePath(routes[j].PathPrefix))
+	})
+
+	paths := make([]networkingv1.HTTPIngressPath, 0, len(routes))
+	for _, route := range routes {
+		paths = append(paths, networkingv1.HTTPIngressPath{
+			Path:     NormalizeRoutePath(route.PathPrefix),
+			PathType: &pathType,
+			Backend: networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: routerServiceName(route.AppName),
+					Port: networkingv1.ServiceBackendPort{Number: 80},
+				},
+			},
+		})
+	}
+
+	return &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cfg.Name,
+			Namespace: cfg.Namespace,
+			Labels:    routerLabels(cfg),
+			Annotations: map[string]string{
+				"cert-manager.io/cluster-issuer":           "letsencrypt-prod",
+				"traefik.ingress.kubernetes.io/router.tls": "true",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: &ingressClassName,
+			TLS: []networkingv1.IngressTLS{
+				{
+					Hosts:      []string{cfg.Host},
+					SecretName: cfg.Name + "-tls",
+				},
+			},
+			Rules: []networkingv1.IngressRule{
+				{
+					Host: cfg.Host,
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{Paths: paths},
+					},
+				},
+			},
+		},
+	}
+}
+
+// ApplyRouter creates or updates the ingress of a router and removes
+// services of apps that are no longer routed
+func (c *Client) ApplyRouter(ctx context.Context, cfg *RouterConfig) error {
+	cfg.Namespace = c.RouterNamespace(cfg.Name)
+
+	err := c.ensureNamespace(ctx, &AppConfig{Name: cfg.Name, Namespace: cfg.Namespace})
+	if err != nil {
+		return TranslateError("create namespace", err)
+	}
+
+	services := c.GenerateRouterServices(cfg)
+	if err := c.applyProxyServices(ctx, cfg.Namespace, services); err != nil {
+		return err
+	}
+
+	ingresses := c.clientset.NetworkingV1().Ingresses(cfg.Namespace)
+	err = retryOnConflict(func() error {
+		ingress := GenerateRouterIngress(cfg)
+
+		existing, err := ingresses.Get(ctx, ingress.Name, metav1.GetOptions{})
+		if err == nil {
+			ingress.ResourceVersion = existing.ResourceVersion
+			_, err = ingresses.Update(ctx, ingress, metav1.UpdateOptions{})
+			return err
+		}
+
+		if k8serrors.IsNotFound(err) {
+			_, err = ingresses.Create(ctx, ingress, metav1.CreateOptions{})
+			return err
+		}
+
+		return err
+	})
+	if err != nil {
+		return TranslateError("apply ingress", err)
+	}
+
+	return c.pruneProxyServices(ctx, cfg.Namespace, routerLabel+"="+cfg.Name, services)
+}
+
+// applyProxyServices applies the ExternalName services standing in for apps
+func (c *Client) applyProxyServices(ctx context.Context, namespace string, wanted []*corev1.Service) error {
+	services := c.clientset.CoreV1().Services(namespace)
+
+	for _, service := range wanted {
+		err := retryOnConflict(func() error {
+			existing, err := services.Get(ctx, service.Name, metav1.GetOptions{})
+			if err == nil {
+				service.ResourceVersion = existing.ResourceVersion
+				_, err = services.Update(ctx, service, metav1.UpdateOptions{})
+				return err
+			}
+
+			if k8serrors.IsNotFound(err) {
+				_, err = services.Create(ctx, service, metav1.CreateOptions{})
+				return err
+			}
+
+			return err
+		})
+		if err != nil {
+			return TranslateError("apply service "+service.Name, err)
+		}
+	}
+	return nil
+}
+
+// pruneProxyServices removes the services matching selector that are no
+// longer wanted, once nothing routes to them
+func (c *Client) pruneProxyServices(ctx context.Context, namespace, selector string, wanted []*corev1.Service) error {
+	services := c.clientset.CoreV1().Services(namespace)
+
+	names := make(map[string]bool, len(wanted))
+	for _, service := range wanted {
+		names[service.Name] = true
+	}
+
+	existing, err := services.List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return TranslateError("list services", err)
+	}
+	for _, service := range existing.Items {
+		if names[service.Name] {
+			continue
+		}
+		if err := services.Delete(ctx, service.Name, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+			return TranslateError("delete service "+service.Name, err)
+		}
+	}
+	return nil
+}
+
+// proxyService is an ExternalName service in a router or split namespace
+// forwarding to an app's service in its own namespace
+func (c *Client) proxyService(namespace, appName string, labels map[string]string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      routerServiceName(appName),
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:         corev1.ServiceTypeExternalName,
+			ExternalName: fmt.Sprintf("%s.%s.svc.cluster.local", appName, c.NamespaceForApp(appName)),
+			Ports: []corev1.ServicePort{
+				{Name: "http", Port: 80},
+			},
+		},
+	}
+}
+
+// DeleteRouter removes a router's namespace and everything in it
+func (c *Client) DeleteRouter(ctx context.Context, routerName string) error {
+	err := c.clientset.CoreV1().Namespaces().Delete(ctx, c.RouterNamespace(routerName), metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestValidateRouter(t *testing.T) {
+	tests := []struct {
+		name   string
+		routes []RouterRoute
+		valid  bool
+	}{
+		{"root and prefix", []RouterRoute{{"/", "web"}, {"/api", "api"}}, true},
+		{"nested prefix", []RouterRoute{{"/api/v2/", "api"}}, true},
+		{"no routes", nil, false},
+		{"relative path", []RouterRoute{{"api", "api"}}, false},
+		{"query in path", []RouterRoute{{"/api?x=1", "api"}}, false},
+		{"double slash", []RouterRoute{{"/api//v2", "api"}}, false},
+		{"missing app", []RouterRoute{{"/api", ""}}, false},
+		{"duplicate after normalizing", []RouterRoute{{"/api", "api"}, {"/api/", "web"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRouter(&RouterConfig{Name: "gw", Routes: tt.routes})
+			if (err == nil) != tt.valid {
+				t.Errorf("expected valid=%v, got %v", tt.valid, err)
+			}
+		})
+	}
+}
+
+func TestGenerateRouterIngress(t *testing.T) {
+	ingress := GenerateRouterIngress(&RouterConfig{
+		Name:      "gw",
+		Namespace: "fuego-router-gw",
+		Host:      "gw.test.local",
+		Routes:    []RouterRoute{{"/", "web"}, {"/api/", "api"}, {"/api/admin", "admin"}},
+	})
+
+	paths := ingress.Spec.Rules[0].HTTP.Paths
+	if len(paths) != 3 {
+		t.Fatalf("expected 3 paths, got %d", len(paths))
+	}
+	want := []struct{ path, service string }{
+		{"/api/admin", "app-admin"},
+		{"/api", "app-api"},
+		{"/", "app-web"},
+	}
+	for i, w := range want {
+		if paths[i].Path != w.path || paths[i].Backend.Service.Name != w.service {
+			t.Errorf("path %d: expected %s -> %s, got %s -> %s", i, w.path, w.service, paths[i].Path, paths[i].Backend.Service.Name)
+		}
+	}
+	if ingress.Spec.TLS[0].Hosts[0] != "gw.test.local" {
+		t.Errorf("unexpected TLS hosts %v", ingress.Spec.TLS[0].Hosts)
+	}
+}
+
+func TestApplyRouter(t *testing.T) {
+	clientset := fake.NewClientset()
+	client := NewClientWithInterface(clientset, "fuego-")
+	ctx := context.Background()
+
+	cfg := &RouterConfig{
+		Name:   "gw",
+		Host:   "gw.test.local",
+		Routes: []RouterRoute{{"/", "web"}, {"/api", "api"}},
+	}
+	if err := client.ApplyRouter(ctx, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	service, err := clientset.CoreV1().Services("fuego-router-gw").Get(ctx, "app-api", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected service for api: %v", err)
+	}
+	if service.Spec.ExternalName != "api.fuego-api.svc.cluster.local" {
+		t.Errorf("unexpected external name %q", service.Spec.ExternalName)
+	}
+
+	// Dropping a route removes the app's service
+	cfg.Routes = cfg.Routes[:1]
+	if err := client.ApplyRouter(ctx, cfg); err != nil {
+		t.Fatalf("unexpected error on update: %v", err)
+	}
+	services, _ := clientset.CoreV1().Services("fuego-router-gw").List(ctx, metav1.ListOptions{})
+	if len(services.Items) != 1 || services.Items[0].Name != "app-web" {
+		t.Errorf("expected only the web service to remain, got %d services", len(services.Items))
+	}
+
+	if err := client.DeleteRouter(ctx, "gw"); err != nil {
+		t.Fatalf("unexpected error deleting router: %v", err)
+	}
+	if err := client.DeleteRouter(ctx, "gw"); err != nil {
+		t.Errorf("expected deleting a missing router to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,217 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Traefik custom resources used for weighted traffic splitting
+var (
+	TraefikServiceGVR = schema.GroupVersionResource{
+		Group:    "traefik.io",
+		Version:  "v1alpha1",
+		Resource: "traefikservices",
+	}
+	IngressRouteGVR = schema.GroupVersionResource{
+		Group:    "traefik.io",
+		Version:  "v1alpha1",
+		Resource: "ingressroutes",
+	}
+)
+
+// Traffic split limits
+const (
+	MinSplitBackends     = 2
+	MaxSplitBackends     = 5
+	splitLabel           = "nexo.build/split"
+	splitNamespacePrefix = "split-"
+)
+
+// ErrTrafficSplitUnavailable is returned when the client cannot manage
+// Traefik resources
+var ErrTrafficSplitUnavailable = errors.New("traffic split resources are not available")
+
+// TrafficSplitConfig spreads requests to one host across apps by weight.
+// With Sticky set, a cookie keeps each client on the app it first reached,
+// which A/B tests usually want.
+type TrafficSplitConfig struct {
+	Name      string
+	Namespace string
+	Host      string
+	Sticky    bool
+	Backends  []SplitBackend
+}
+
+// SplitBackend receives Weight percent of a split's requests
+type SplitBackend struct {
+	AppName string
+	Weight  int32
+}
+
+// SplitNamespace returns the namespace holding a traffic split's resources
+func (c *Client) SplitNamespace(splitName string) string {
+	return c.namespacePrefix + splitNamespacePrefix + splitName
+}
+
+// ValidateTrafficSplit checks that weights are percentages adding up to 100
+func ValidateTrafficSplit(cfg *TrafficSplitConfig) error {
+	if len(cfg.Backends) < MinSplitBackends || len(cfg.Backends) > MaxSplitBackends {
+		return fmt.Errorf("a traffic split needs between %d and %d apps", MinSplitBackends, MaxSplitBackends)
+	}
+
+	seen := make(map[string]bool, len(cfg.Backends))
+	var total int32
+	for _, backend := range cfg.Backends {
+		if backend.AppName == "" {
+			return errors.New("every backend requires an app")
+		}
+		if seen[backend.AppName] {
+			return fmt.Errorf("app %q is listed more than once", backend.AppName)
+		}
+		seen[backend.AppName] = true
+
+		if backend.Weight < 0 || backend.Weight > 100 {
+			return fmt.Errorf("weight of %q must be between 0 and 100", backend.AppName)
+		}
+		total += backend.Weight
+	}
+	if total != 100 {
+		return fmt.Errorf("weights must add up to 100, got %d", total)
+	}
+	return nil
+}
+
+func splitLabels(cfg *TrafficSplitConfig) map[string]any {
+	return map[string]any{
+		splitLabel:                     cfg.Name,
+		"app.kubernetes.io/managed-by": "nexo-cloud",
+	}
+}
+
+func splitMetadata(cfg *TrafficSplitConfig) map[string]any {
+	return map[string]any{
+		"name":      cfg.Name,
+		"namespace": cfg.Namespace,
+		"labels":    splitLabels(cfg),
+	}
+}
+
+// GenerateTrafficSplit returns the cert-manager Certificate for the split
+// host, the weighted TraefikService and the IngressRoute serving it
+func GenerateTrafficSplit(cfg *TrafficSplitConfig) []*unstructured.Unstructured {
+	services := make([]any, 0, len(cfg.Backends))
+	for _, backend := range cfg.Backends {
+		services = append(services, map[string]any{
+			"name":   routerServiceName(backend.AppName),
+			"port":   int64(80),
+			"weight": int64(backend.Weight),
+		})
+	}
+
+	weighted := map[string]any{"services": services}
+	if cfg.Sticky {
+		weighted["sticky"] = map[string]any{
+			"cookie": map[string]any{
+				"name":     "nexo_split_" + cfg.Name,
+				"secure":   true,
+				"httpOnly": true,
+			},
+		}
+	}
+
+	return []*unstructured.Unstructured{
+		{Object: map[string]any{
+			"apiVersion": "cert-manager.io/v1",
+			"kind":       "Certificate",
+			"metadata":   splitMetadata(cfg),
+			"spec": map[string]any{
+				"secretName": cfg.Name + "-tls",
+				"dnsNames":   []any{cfg.Host},
+				"issuerRef": map[string]any{
+					"name": "letsencrypt-prod",
+					"kind": "ClusterIssuer",
+				},
+			},
+		}},
+		{Object: map[string]any{
+			"apiVersion": "traefik.io/v1alpha1",
+			"kind":       "TraefikService",
+			"metadata":   splitMetadata(cfg),
+			"spec":       map[string]any{"weighted": weighted},
+		}},
+		{Object: map[string]any{
+			"apiVersion": "traefik.io/v1alpha1",
+			"kind":       "IngressRoute",
+			"metadata":   splitMetadata(cfg),
+			"spec": map[string]any{
+				"entryPoints": []any{"websecure"},
+				"routes": []any{
+					map[string]any{
+						"match": fmt.Sprintf("Host(`%s`)", cfg.Host),
+						"kind":  "Rule",
+						"services": []any{
+							map[string]any{"name": cfg.Name, "kind": "TraefikService"},
+						},
+					},
+				},
+				"tls": map[string]any{"secretName": cfg.Name + "-tls"},
+			},
+		}},
+	}
+}
+
+// ApplyTrafficSplit creates or updates a traffic split. Weight changes take
+// effect as soon as Traefik picks up the TraefikService.
+func (c *Client) ApplyTrafficSplit(ctx context.Context, cfg *TrafficSplitConfig) error {
+	if c.dynamic == nil {
+		return ErrTrafficSplitUnavailable
+	}
+	cfg.Namespace = c.SplitNamespace(cfg.Name)
+
+	if err := c.ensureNamespace(ctx, &AppConfig{Name: cfg.Name, Namespace: cfg.Namespace}); err != nil {
+		return TranslateError("create namespace", err)
+	}
+
+	labels := map[string]string{
+		splitLabel:                     cfg.Name,
+		"app.kubernetes.io/managed-by": "nexo-cloud",
+	}
+	services := make([]*corev1.Service, 0, len(cfg.Backends))
+	for _, backend := range cfg.Backends {
+		services = append(services, c.proxyService(cfg.Namespace, backend.AppName, labels))
+	}
+	if err := c.applyProxyServices(ctx, cfg.Namespace, services); err != nil {
+		return err
+	}
+
+	for _, obj := range GenerateTrafficSplit(cfg) {
+		gvr := CertificateGVR
+		switch obj.GetKind() {
+		case "TraefikService":
+			gvr = TraefikServiceGVR
+		case "IngressRoute":
+			gvr = IngressRouteGVR
+		}
+		if err := c.applyUnstructured(ctx, gvr, obj); err != nil {
+			return TranslateError("apply "+obj.GetKind(), err)
+		}
+	}
+
+	return c.pruneProxyServices(ctx, cfg.Namespace, splitLabel+"="+cfg.Name, services)
+}
+
+// DeleteTrafficSplit removes a split's namespace and everything in it
+func (c *Client) DeleteTrafficSplit(ctx context.Context, splitName string) error {
+	err := c.clientset.CoreV1().Namespaces().Delete(ctx, c.SplitNamespace(splitName), metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
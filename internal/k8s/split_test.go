@@ -0,0 +1,95 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestValidateTrafficSplit(t *testing.T) {
+	tests := []struct {
+		name     string
+		backends []SplitBackend
+		valid    bool
+	}{
+		{"90/10", []SplitBackend{{"web", 90}, {"web-next", 10}}, true},
+		{"drained backend", []SplitBackend{{"web", 100}, {"web-next", 0}}, true},
+		{"single app", []SplitBackend{{"web", 100}}, false},
+		{"does not add up", []SplitBackend{{"web", 90}, {"web-next", 20}}, false},
+		{"negative weight", []SplitBackend{{"web", 110}, {"web-next", -10}}, false},
+		{"duplicate app", []SplitBackend{{"web", 50}, {"web", 50}}, false},
+		{"missing app", []SplitBackend{{"web", 50}, {"", 50}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTrafficSplit(&TrafficSplitConfig{Name: "ab", Backends: tt.backends})
+			if (err == nil) != tt.valid {
+				t.Errorf("expected valid=%v, got %v", tt.valid, err)
+			}
+		})
+	}
+}
+
+func TestApplyTrafficSplit(t *testing.T) {
+	clientset := fake.NewClientset()
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	client := NewClientWithDynamic(clientset, dynamicClient, "fuego-")
+	ctx := context.Background()
+
+	cfg := &TrafficSplitConfig{
+		Name:     "ab",
+		Host:     "ab.test.local",
+		Sticky:   true,
+		Backends: []SplitBackend{{"web", 90}, {"web-next", 10}},
+	}
+	if err := client.ApplyTrafficSplit(ctx, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	service, err := dynamicClient.Resource(TraefikServiceGVR).Namespace("fuego-split-ab").Get(ctx, "ab", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected TraefikService: %v", err)
+	}
+	weighted, _, _ := unstructured.NestedSlice(service.Object, "spec", "weighted", "services")
+	if len(weighted) != 2 || weighted[1].(map[string]any)["weight"] != int64(10) {
+		t.Errorf("unexpected weighted services %v", weighted)
+	}
+	if _, ok, _ := unstructured.NestedMap(service.Object, "spec", "weighted", "sticky"); !ok {
+		t.Error("expected sticky sessions")
+	}
+
+	route, err := dynamicClient.Resource(IngressRouteGVR).Namespace("fuego-split-ab").Get(ctx, "ab", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected IngressRoute: %v", err)
+	}
+	routes, _, _ := unstructured.NestedSlice(route.Object, "spec", "routes")
+	if len(routes) != 1 || routes[0].(map[string]any)["match"] != "Host(`ab.test.local`)" {
+		t.Errorf("unexpected routes %v", routes)
+	}
+
+	if _, err := clientset.CoreV1().Services("fuego-split-ab").Get(ctx, "app-web-next", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected proxy service for web-next: %v", err)
+	}
+
+	// Re-applying with new weights updates in place
+	cfg.Backends = []SplitBackend{{"web", 50}, {"web-next", 50}}
+	if err := client.ApplyTrafficSplit(ctx, cfg); err != nil {
+		t.Fatalf("unexpected error on update: %v", err)
+	}
+}
+
+func TestApplyTrafficSplit_Unavailable(t *testing.T) {
+	client := NewClientWithInterface(fake.NewClientset(), "fuego-")
+
+	err := client.ApplyTrafficSplit(context.Background(), &TrafficSplitConfig{Name: "ab"})
+	if !errors.Is(err, ErrTrafficSplitUnavailable) {
+		t.Errorf("expected ErrTrafficSplitUnavailable, got %v", err)
+	}
+}
@@ -0,0 +1,238 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ErrTLSPolicyUnavailable is returned when a domain's TLS policy needs
+// Traefik middlewares but the client cannot manage Traefik resources
+var ErrTLSPolicyUnavailable = errors.New("TLS policy resources are not available")
+
+// TLSPolicy is how the ingress of an app's custom domain treats plain HTTP
+// and HSTS. Without one, the app is only served over HTTPS and no HSTS
+// header is added.
+type TLSPolicy struct {
+	// ForceHTTPS redirects plain HTTP to HTTPS. Off, plain HTTP reaches the
+	// app, for domains whose TLS terminates upstream.
+	ForceHTTPS bool
+
+	// HSTSMaxAge is the max-age of the Strict-Transport-Security header in
+	// seconds; 0 sends no header
+	HSTSMaxAge            int32
+	HSTSIncludeSubdomains bool
+	HSTSPreload           bool
+}
+
+// Bounds of the HSTS max-age. Browsers only accept a domain into their
+// preload lists with a max-age of at least a year.
+const (
+	MaxHSTSMaxAge        = 2 * 365 * 24 * 60 * 60
+	MinHSTSPreloadMaxAge = 365 * 24 * 60 * 60
+)
+
+// ValidateTLSPolicy checks that HSTS settings are consistent. Preloading
+// needs what the browser preload lists require: HTTPS forced, subdomains
+// included and a max-age of at least a year.
+func ValidateTLSPolicy(p TLSPolicy) error {
+	switch {
+	case p.HSTSMaxAge < 0 || p.HSTSMaxAge > MaxHSTSMaxAge:
+		return fmt.Errorf("hsts_max_age must be between 0 and %d seconds", MaxHSTSMaxAge)
+	case p.HSTSMaxAge == 0 && (p.HSTSIncludeSubdomains || p.HSTSPreload):
+		return errors.New("hsts_include_subdomains and hsts_preload need an hsts_max_age")
+	case p.HSTSPreload && !p.ForceHTTPS:
+		return errors.New("hsts_preload needs force_https")
+	case p.HSTSPreload && !p.HSTSIncludeSubdomains:
+		return errors.New("hsts_preload needs hsts_include_subdomains")
+	case p.HSTSPreload && p.HSTSMaxAge < MinHSTSPreloadMaxAge:
+		return fmt.Errorf("hsts_preload needs an hsts_max_age of at least %d seconds", MinHSTSPreloadMaxAge)
+	}
+	return nil
+}
+
+// HTTPIngressName is the Ingress serving an app's domain over plain HTTP
+func HTTPIngressName(appName string) string {
+	return appName + "-http"
+}
+
+func httpsRedirectMiddlewareName(appName string) string {
+	return appName + "-https-redirect"
+}
+
+func hstsMiddlewareName(appName string) string {
+	return appName + "-hsts"
+}
+
+// traefikRef names a Traefik resource of the app's namespace in annotations
+func traefikRef(cfg *AppConfig, name string) string {
+	return fmt.Sprintf("%s-%s@kubernetescrd", cfg.Namespace, name)
+}
+
+// hstsEnabled reports whether the app's HTTPS router adds the HSTS header
+func hstsEnabled(cfg *AppConfig) bool {
+	return cfg.TLSPolicy != nil && cfg.TLSPolicy.HSTSMaxAge > 0
+}
+
+// redirectEnabled reports whether the app's HTTP router redirects to HTTPS.
+// Apps requiring client certificates always redirect, as plain HTTP would
+// get around them.
+func redirectEnabled(cfg *AppConfig) bool {
+	return cfg.TLSPolicy != nil && (cfg.TLSPolicy.ForceHTTPS || cfg.MTLS != nil)
+}
+
+// withMiddleware appends a middleware to the router.middlewares annotation
+func withMiddleware(annotations map[string]string, ref string) {
+	const key = "traefik.ingress.kubernetes.io/router.middlewares"
+	if existing := annotations[key]; existing != "" {
+		annotations[key] = existing + "," + ref
+		return
+	}
+	annotations[key] = ref
+}
+
+// GenerateHTTPIngress returns the Ingress serving the app's host on the
+// plain HTTP entrypoint, which redirects to HTTPS when the policy forces it
+func GenerateHTTPIngress(cfg *AppConfig) *networkingv1.Ingress {
+	ingress := GenerateIngress(cfg)
+	ingress.Name = HTTPIngressName(cfg.Name)
+	ingress.Spec.TLS = nil
+	ingress.Annotations = map[string]string{
+		"traefik.ingress.kubernetes.io/router.entrypoints": "web",
+	}
+	if redirectEnabled(cfg) {
+		withMiddleware(ingress.Annotations, traefikRef(cfg, httpsRedirectMiddlewareName(cfg.Name)))
+	}
+	return ingress
+}
+
+// GenerateTLSPolicyMiddlewares returns the Traefik middlewares the app's
+// policy needs: the redirect to HTTPS and the HSTS header
+func GenerateTLSPolicyMiddlewares(cfg *AppConfig) []*unstructured.Unstructured {
+	metadata := func(name string) map[string]any {
+		return map[string]any{
+			"name":      name,
+			"namespace": cfg.Namespace,
+			"labels": unstructuredLabels(appLabels(cfg, map[string]string{
+				"app.kubernetes.io/name":       cfg.Name,
+				"app.kubernetes.io/managed-by": "nexo-cloud",
+			})),
+		}
+	}
+
+	var middlewares []*unstructured.Unstructured
+	if redirectEnabled(cfg) {
+		middlewares = append(middlewares, &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "traefik.io/v1alpha1",
+			"kind":       "Middleware",
+			"metadata":   metadata(httpsRedirectMiddlewareName(cfg.Name)),
+			"spec": map[string]any{
+				"redirectScheme": map[string]any{
+					"scheme":    "https",
+					"permanent": true,
+				},
+			},
+		}})
+	}
+	if hstsEnabled(cfg) {
+		middlewares = append(middlewares, &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "traefik.io/v1alpha1",
+			"kind":       "Middleware",
+			"metadata":   metadata(hstsMiddlewareName(cfg.Name)),
+			"spec": map[string]any{
+				"headers": map[string]any{
+					"stsSeconds":           int64(cfg.TLSPolicy.HSTSMaxAge),
+					"stsIncludeSubdomains": cfg.TLSPolicy.HSTSIncludeSubdomains,
+					"stsPreload":           cfg.TLSPolicy.HSTSPreload,
+				},
+			},
+		}})
+	}
+	return middlewares
+}
+
+// ApplyTLSPolicy brings a running app's ingress in line with cfg.TLSPolicy,
+// e.g. after its owner changes the redirect or HSTS settings of its domain
+func (c *Client) ApplyTLSPolicy(ctx context.Context, cfg *AppConfig) error {
+	cfg.Namespace = c.NamespaceForApp(cfg.Name)
+
+	if err := c.applyTLSPolicy(ctx, cfg); err != nil {
+		return err
+	}
+	if err := c.applyIngress(ctx, cfg); err != nil {
+		return err
+	}
+	return c.pruneTLSPolicy(ctx, cfg)
+}
+
+// applyTLSPolicy creates the middlewares and HTTP Ingress the policy needs.
+// They go first so the routers never reference a missing middleware.
+func (c *Client) applyTLSPolicy(ctx context.Context, cfg *AppConfig) error {
+	if cfg.TLSPolicy == nil {
+		return nil
+	}
+
+	middlewares := GenerateTLSPolicyMiddlewares(cfg)
+	if len(middlewares) > 0 && c.dynamic == nil {
+		return ErrTLSPolicyUnavailable
+	}
+	for _, obj := range middlewares {
+		if err := c.applyUnstructured(ctx, MiddlewareGVR, obj); err != nil {
+			return fmt.Errorf("failed to apply %s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+	}
+
+	ingresses := c.clientset.NetworkingV1().Ingresses(cfg.Namespace)
+	return retryOnConflict(func() error {
+		ingress := GenerateHTTPIngress(cfg)
+
+		existing, err := ingresses.Get(ctx, ingress.Name, metav1.GetOptions{})
+		if err == nil {
+			ingress.ResourceVersion = existing.ResourceVersion
+			_, err = ingresses.Update(ctx, ingress, metav1.UpdateOptions{})
+			return err
+		}
+
+		if k8serrors.IsNotFound(err) {
+			_, err = ingresses.Create(ctx, ingress, metav1.CreateOptions{})
+			return err
+		}
+
+		return err
+	})
+}
+
+// pruneTLSPolicy deletes what the policy no longer needs, once the routers
+// stopped referencing it
+func (c *Client) pruneTLSPolicy(ctx context.Context, cfg *AppConfig) error {
+	if cfg.TLSPolicy == nil {
+		err := c.clientset.NetworkingV1().Ingresses(cfg.Namespace).Delete(ctx, HTTPIngressName(cfg.Name), metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete %s: %w", HTTPIngressName(cfg.Name), err)
+		}
+	}
+
+	if c.dynamic == nil {
+		return nil
+	}
+
+	var unused []string
+	if !redirectEnabled(cfg) {
+		unused = append(unused, httpsRedirectMiddlewareName(cfg.Name))
+	}
+	if !hstsEnabled(cfg) {
+		unused = append(unused, hstsMiddlewareName(cfg.Name))
+	}
+	for _, name := range unused {
+		err := c.dynamic.Resource(MiddlewareGVR).Namespace(cfg.Namespace).Delete(ctx, name, metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestValidateTLSPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy TLSPolicy
+		error  string
+	}{
+		{"defaults", TLSPolicy{ForceHTTPS: true}, ""},
+		{"upstream TLS", TLSPolicy{}, ""},
+		{"staged hsts", TLSPolicy{ForceHTTPS: true, HSTSMaxAge: 300}, ""},
+		{"preload", TLSPolicy{ForceHTTPS: true, HSTSMaxAge: MinHSTSPreloadMaxAge, HSTSIncludeSubdomains: true, HSTSPreload: true}, ""},
+		{"negative max-age", TLSPolicy{ForceHTTPS: true, HSTSMaxAge: -1}, "between 0 and"},
+		{"max-age too long", TLSPolicy{ForceHTTPS: true, HSTSMaxAge: MaxHSTSMaxAge + 1}, "between 0 and"},
+		{"subdomains without max-age", TLSPolicy{ForceHTTPS: true, HSTSIncludeSubdomains: true}, "need an hsts_max_age"},
+		{"preload without redirect", TLSPolicy{HSTSMaxAge: MinHSTSPreloadMaxAge, HSTSIncludeSubdomains: true, HSTSPreload: true}, "needs force_https"},
+		{"preload without subdomains", TLSPolicy{ForceHTTPS: true, HSTSMaxAge: MinHSTSPreloadMaxAge, HSTSPreload: true}, "needs hsts_include_subdomains"},
+		{"preload with short max-age", TLSPolicy{ForceHTTPS: true, HSTSMaxAge: 300, HSTSIncludeSubdomains: true, HSTSPreload: true}, "at least"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTLSPolicy(tt.policy)
+			if tt.error == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Errorf("expected an error containing %q, got %v", tt.error, err)
+			}
+		})
+	}
+}
+
+func TestGenerateIngress_HSTS(t *testing.T) {
+	cfg := testAppConfig(withTLSPolicy)
+
+	if got := GenerateIngress(cfg).Annotations["traefik.ingress.kubernetes.io/router.middlewares"]; got != "fuego-myapp-myapp-hsts@kubernetescrd" {
+		t.Errorf("unexpected middlewares annotation %q", got)
+	}
+
+	// HSTS follows the mTLS middlewares
+	withMTLS(cfg)
+	if got := GenerateIngress(cfg).Annotations["traefik.ingress.kubernetes.io/router.middlewares"]; !strings.HasSuffix(got, "fuego-myapp-myapp-mtls-auth@kubernetescrd,fuego-myapp-myapp-hsts@kubernetescrd") {
+		t.Errorf("unexpected middlewares annotation %q", got)
+	}
+
+	cfg.MTLS = nil
+	cfg.TLSPolicy.HSTSMaxAge = 0
+	if _, ok := GenerateIngress(cfg).Annotations["traefik.ingress.kubernetes.io/router.middlewares"]; ok {
+		t.Error("expected no middlewares without HSTS")
+	}
+}
+
+func TestGenerateHTTPIngress(t *testing.T) {
+	cfg := testAppConfig(withTLSPolicy)
+
+	ingress := GenerateHTTPIngress(cfg)
+	if ingress.Name != "myapp-http" || len(ingress.Spec.TLS) != 0 {
+		t.Errorf("expected a plain HTTP ingress, got %s with %d TLS entries", ingress.Name, len(ingress.Spec.TLS))
+	}
+	if ingress.Spec.Rules[0].Host != "shop.example.com" {
+		t.Errorf("unexpected host %s", ingress.Spec.Rules[0].Host)
+	}
+	if got := ingress.Annotations["traefik.ingress.kubernetes.io/router.middlewares"]; got != "fuego-myapp-myapp-https-redirect@kubernetescrd" {
+		t.Errorf("unexpected middlewares annotation %q", got)
+	}
+
+	// Without the redirect plain HTTP reaches the app
+	cfg.TLSPolicy.ForceHTTPS = false
+	if _, ok := GenerateHTTPIngress(cfg).Annotations["traefik.ingress.kubernetes.io/router.middlewares"]; ok {
+		t.Error("expected no redirect")
+	}
+
+	// unless the app requires client certificates
+	cfg.MTLS = &MTLSConfig{}
+	if _, ok := GenerateHTTPIngress(cfg).Annotations["traefik.ingress.kubernetes.io/router.middlewares"]; !ok {
+		t.Error("expected mTLS apps to always redirect")
+	}
+}
+
+func TestApplyTLSPolicy(t *testing.T) {
+	clientset := fake.NewClientset()
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	client := NewClientWithDynamic(clientset, dynamicClient, "fuego-")
+	ctx := context.Background()
+
+	cfg := testAppConfig(withTLSPolicy)
+	if err := client.ApplyTLSPolicy(ctx, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	hsts, err := dynamicClient.Resource(MiddlewareGVR).Namespace("fuego-myapp").Get(ctx, "myapp-hsts", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected HSTS middleware: %v", err)
+	}
+	if seconds, _, _ := unstructured.NestedInt64(hsts.Object, "spec", "headers", "stsSeconds"); seconds != MinHSTSPreloadMaxAge {
+		t.Errorf("unexpected stsSeconds %d", seconds)
+	}
+	if _, err := dynamicClient.Resource(MiddlewareGVR).Namespace("fuego-myapp").Get(ctx, "myapp-https-redirect", metav1.GetOptions{}); err != nil {
+		t.Fatalf("expected redirect middleware: %v", err)
+	}
+	if _, err := clientset.NetworkingV1().Ingresses("fuego-myapp").Get(ctx, "myapp-http", metav1.GetOptions{}); err != nil {
+		t.Fatalf("expected HTTP ingress: %v", err)
+	}
+
+	// Turning HSTS and the redirect off removes their middlewares
+	cfg.TLSPolicy = &TLSPolicy{}
+	if err := client.ApplyTLSPolicy(ctx, cfg); err != nil {
+		t.Fatalf("unexpected error on update: %v", err)
+	}
+	for _, name := range []string{"myapp-hsts", "myapp-https-redirect"} {
+		if _, err := dynamicClient.Resource(MiddlewareGVR).Namespace("fuego-myapp").Get(ctx, name, metav1.GetOptions{}); !k8serrors.IsNotFound(err) {
+			t.Errorf("expected %s to be deleted, got %v", name, err)
+		}
+	}
+
+	// Without a custom domain the HTTP ingress goes away
+	cfg.TLSPolicy = nil
+	if err := client.ApplyTLSPolicy(ctx, cfg); err != nil {
+		t.Fatalf("unexpected error on removal: %v", err)
+	}
+	if _, err := clientset.NetworkingV1().Ingresses("fuego-myapp").Get(ctx, "myapp-http", metav1.GetOptions{}); !k8serrors.IsNotFound(err) {
+		t.Errorf("expected HTTP ingress to be deleted, got %v", err)
+	}
+}
+
+func TestApplyTLSPolicy_Unavailable(t *testing.T) {
+	client := NewClientWithInterface(fake.NewClientset(), "fuego-")
+
+	if err := client.ApplyTLSPolicy(context.Background(), testAppConfig(withTLSPolicy)); !errors.Is(err, ErrTLSPolicyUnavailable) {
+		t.Errorf("expected ErrTLSPolicyUnavailable, got %v", err)
+	}
+}
@@ -0,0 +1,206 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// DefaultCloudflaredImage is the image of tunnel connectors
+const DefaultCloudflaredImage = "cloudflare/cloudflared:2025.8.1"
+
+const (
+	tunnelTokenKey = "token"
+	// tunnelMetricsPort serves the connector's /ready endpoint
+	tunnelMetricsPort = 2000
+	// tunnelReplicas keeps the app reachable while a connector restarts;
+	// Cloudflare balances between the connections of a tunnel
+	tunnelReplicas = 2
+)
+
+// TunnelConfig exposes an app through a Cloudflare Tunnel instead of the
+// cluster's ingress, for clusters without a public load balancer.
+// cloudflared connectors in the app's namespace dial out to Cloudflare and
+// forward the tunnel's hostnames to the app's Service. Cloudflare terminates
+// TLS, so the app has no Ingress or certificate of its own.
+type TunnelConfig struct {
+	// Token authenticates the connectors as the app's tunnel
+	Token string
+}
+
+// TunnelName is the name of the connector resources of an app
+func TunnelName(appName string) string {
+	return appName + "-cloudflared"
+}
+
+// TunnelService is the origin the tunnel forwards an app's hostnames to
+func TunnelService(appName string) string {
+	return fmt.Sprintf("http://%s:80", appName)
+}
+
+// tunnelLabels are the labels of connector resources. They are not labelled
+// with the app's name, which selects its own pods and deployments.
+func tunnelLabels(cfg *AppConfig) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":       TunnelName(cfg.Name),
+		"app.kubernetes.io/component":  "cloudflared",
+		"app.kubernetes.io/part-of":    cfg.Name,
+		"app.kubernetes.io/managed-by": "nexo-cloud",
+	}
+}
+
+// GenerateTunnel returns the secret holding a tunnel's token and the
+// deployment running its connectors
+func GenerateTunnel(cfg *AppConfig) (*corev1.Secret, *appsv1.Deployment) {
+	image := cfg.CloudflaredImage
+	if image == "" {
+		image = DefaultCloudflaredImage
+	}
+	name := TunnelName(cfg.Name)
+	labels := tunnelLabels(cfg)
+	selector := map[string]string{"app.kubernetes.io/name": name}
+	replicas := int32(tunnelReplicas)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cfg.Namespace,
+			Labels:    appLabels(cfg, labels),
+		},
+		Type:       corev1.SecretTypeOpaque,
+		StringData: map[string]string{tunnelTokenKey: cfg.Tunnel.Token},
+	}
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cfg.Namespace,
+			Labels:    appLabels(cfg, labels),
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: selector},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: appLabels(cfg, labels),
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "cloudflared",
+							Image: image,
+							Args: []string{
+								"tunnel", "--no-autoupdate",
+								"--metrics", fmt.Sprintf("0.0.0.0:%d", tunnelMetricsPort),
+								"run",
+							},
+							Env: []corev1.EnvVar{
+								{
+									Name: "TUNNEL_TOKEN",
+									ValueFrom: &corev1.EnvVarSource{
+										SecretKeyRef: &corev1.SecretKeySelector{
+											LocalObjectReference: corev1.LocalObjectReference{Name: name},
+											Key:                  tunnelTokenKey,
+										},
+									},
+								},
+							},
+							Ports: []corev1.ContainerPort{
+								{Name: "metrics", ContainerPort: tunnelMetricsPort, Protocol: corev1.ProtocolTCP},
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/ready",
+										Port: intstr.FromInt32(tunnelMetricsPort),
+									},
+								},
+								PeriodSeconds: 10,
+							},
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("20m"),
+									corev1.ResourceMemory: resource.MustParse("32Mi"),
+								},
+								Limits: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse("128Mi"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	cfg.Placement.applyTo(&deployment.Spec.Template.Spec)
+
+	return secret, deployment
+}
+
+func (c *Client) applyTunnel(ctx context.Context, cfg *AppConfig) error {
+	secret, deployment := GenerateTunnel(cfg)
+
+	secrets := c.clientset.CoreV1().Secrets(cfg.Namespace)
+	err := retryOnConflict(func() error {
+		existing, err := secrets.Get(ctx, secret.Name, metav1.GetOptions{})
+		if err == nil {
+			secret.ResourceVersion = existing.ResourceVersion
+			_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+			return err
+		}
+		if k8serrors.IsNotFound(err) {
+			_, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply tunnel secret: %w", err)
+	}
+
+	deployments := c.clientset.AppsV1().Deployments(cfg.Namespace)
+	err = retryOnConflict(func() error {
+		existing, err := deployments.Get(ctx, deployment.Name, metav1.GetOptions{})
+		if err == nil {
+			deployment.ResourceVersion = existing.ResourceVersion
+			_, err = deployments.Update(ctx, deployment, metav1.UpdateOptions{})
+			return err
+		}
+		if k8serrors.IsNotFound(err) {
+			_, err = deployments.Create(ctx, deployment, metav1.CreateOptions{})
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply tunnel deployment: %w", err)
+	}
+	return nil
+}
+
+// removeTunnel deletes an app's tunnel connectors, if it has any
+func (c *Client) removeTunnel(ctx context.Context, appName string) error {
+	namespace := c.NamespaceForApp(appName)
+	name := TunnelName(appName)
+
+	if err := c.clientset.AppsV1().Deployments(namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete tunnel deployment: %w", err)
+	}
+	if err := c.clientset.CoreV1().Secrets(namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete tunnel secret: %w", err)
+	}
+	return nil
+}
+
+// removeIngress deletes an app's Ingress once it is served through a tunnel
+func (c *Client) removeIngress(ctx context.Context, cfg *AppConfig) error {
+	err := c.clientset.NetworkingV1().Ingresses(cfg.Namespace).Delete(ctx, cfg.Name, metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
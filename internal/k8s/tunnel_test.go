@@ -0,0 +1,87 @@
+package k8s
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGenerateTunnel(t *testing.T) {
+	cfg := testAppConfig(withWorkloads)
+	cfg.Tunnel = &TunnelConfig{Token: "tunnel-token"}
+
+	secret, deployment := GenerateTunnel(cfg)
+	if secret.Name != "myapp-cloudflared" || secret.StringData["token"] != "tunnel-token" {
+		t.Errorf("unexpected secret %s: %v", secret.Name, secret.StringData)
+	}
+	if deployment.Spec.Selector.MatchLabels["app.kubernetes.io/name"] != "myapp-cloudflared" {
+		t.Errorf("expected connectors not to select the app's pods, got %v", deployment.Spec.Selector.MatchLabels)
+	}
+
+	container := deployment.Spec.Template.Spec.Containers[0]
+	if container.Image != DefaultCloudflaredImage {
+		t.Errorf("expected default image, got %s", container.Image)
+	}
+	if strings.Join(container.Args, " ") != "tunnel --no-autoupdate --metrics 0.0.0.0:2000 run" {
+		t.Errorf("unexpected args %v", container.Args)
+	}
+	if ref := container.Env[0].ValueFrom.SecretKeyRef; ref == nil || ref.Name != "myapp-cloudflared" {
+		t.Errorf("expected the token to come from the secret, got %+v", container.Env[0])
+	}
+
+	cfg.CloudflaredImage = "mirror/cloudflared:latest"
+	if _, deployment := GenerateTunnel(cfg); deployment.Spec.Template.Spec.Containers[0].Image != "mirror/cloudflared:latest" {
+		t.Error("expected the image override to be used")
+	}
+}
+
+func TestManifestsTunnel(t *testing.T) {
+	cfg := testAppConfig(withWorkloads)
+	cfg.Tunnel = &TunnelConfig{Token: "tunnel-token"}
+
+	var kinds []string
+	for _, obj := range Manifests(cfg) {
+		kinds = append(kinds, obj.GetObjectKind().GroupVersionKind().Kind)
+		if secret, ok := obj.(*corev1.Secret); ok && secret.Name == "myapp-cloudflared" && secret.StringData["token"] != RedactedValue {
+			t.Errorf("expected the tunnel token to be redacted, got %q", secret.StringData["token"])
+		}
+	}
+
+	expected := "Namespace,Secret,Deployment,Deployment,Service,Secret,Deployment,CronJob"
+	if strings.Join(kinds, ",") != expected {
+		t.Errorf("expected %s, got %s", expected, strings.Join(kinds, ","))
+	}
+}
+
+func TestApplyTunnel_WithFakeClient(t *testing.T) {
+	clientset := fake.NewClientset()
+	client := NewClientWithInterface(clientset, "test-")
+	ctx := context.Background()
+
+	cfg := &AppConfig{Name: "myapp", Namespace: "test-myapp", Image: "myapp:v1", Port: 3000, Tunnel: &TunnelConfig{Token: "tunnel-token"}}
+	if err := client.applyTunnel(ctx, cfg); err != nil {
+		t.Fatalf("failed to apply tunnel: %v", err)
+	}
+	// Applying again updates in place
+	if err := client.applyTunnel(ctx, cfg); err != nil {
+		t.Fatalf("failed to update tunnel: %v", err)
+	}
+	if _, err := clientset.AppsV1().Deployments("test-myapp").Get(ctx, "myapp-cloudflared", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected the connector deployment: %v", err)
+	}
+
+	if err := client.removeTunnel(ctx, "myapp"); err != nil {
+		t.Fatalf("failed to remove tunnel: %v", err)
+	}
+	if _, err := clientset.CoreV1().Secrets("test-myapp").Get(ctx, "myapp-cloudflared", metav1.GetOptions{}); err == nil {
+		t.Error("expected the tunnel secret to be deleted")
+	}
+	// Removing it again is a no-op
+	if err := client.removeTunnel(ctx, "myapp"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
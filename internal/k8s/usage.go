@@ -0,0 +1,78 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// PodMetricsGVR identifies metrics-server PodMetrics resources
+var PodMetricsGVR = schema.GroupVersionResource{
+	Group:    "metrics.k8s.io",
+	Version:  "v1beta1",
+	Resource: "pods",
+}
+
+// ErrUsageUnavailable is returned when the client cannot read pod metrics
+var ErrUsageUnavailable = errors.New("pod metrics are not available")
+
+// PodUsage is the CPU and memory a pod currently uses, as measured by
+// metrics-server
+type PodUsage struct {
+	Name        string `json:"name"`
+	Process     string `json:"process"`
+	CPUMillis   int64  `json:"cpu_millis"`
+	MemoryBytes int64  `json:"memory_bytes"`
+}
+
+// ListPodUsage returns the measured usage of every pod of an app
+func (c *Client) ListPodUsage(ctx context.Context, appName string) ([]PodUsage, error) {
+	if c.dynamic == nil {
+		return nil, ErrUsageUnavailable
+	}
+
+	list, err := c.dynamic.Resource(PodMetricsGVR).Namespace(c.NamespaceForApp(appName)).List(ctx, metav1.ListOptions{
+		LabelSelector: "app.kubernetes.io/name=" + appName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pod metrics: %w", err)
+	}
+
+	usage := make([]PodUsage, 0, len(list.Items))
+	for i := range list.Items {
+		usage = append(usage, podUsage(&list.Items[i]))
+	}
+	return usage, nil
+}
+
+// podUsage sums the usage of a PodMetrics' containers
+func podUsage(obj *unstructured.Unstructured) PodUsage {
+	usage := PodUsage{Name: obj.GetName(), Process: obj.GetLabels()[processLabel]}
+	if usage.Process == "" {
+		usage.Process = ProcessTypeWeb
+	}
+
+	containers, _, _ := unstructured.NestedSlice(obj.Object, "containers")
+	for _, container := range containers {
+		fields, ok := container.(map[string]any)
+		if !ok {
+			continue
+		}
+		if cpu, ok, _ := unstructured.NestedString(fields, "usage", "cpu"); ok {
+			if q, err := resource.ParseQuantity(cpu); err == nil {
+				usage.CPUMillis += q.MilliValue()
+			}
+		}
+		if memory, ok, _ := unstructured.NestedString(fields, "usage", "memory"); ok {
+			if q, err := resource.ParseQuantity(memory); err == nil {
+				usage.MemoryBytes += q.Value()
+			}
+		}
+	}
+	return usage
+}
@@ -0,0 +1,66 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func testPodMetrics(name, namespace, app string, containers ...map[string]any) *unstructured.Unstructured {
+	items := make([]any, len(containers))
+	for i, container := range containers {
+		items[i] = container
+	}
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "metrics.k8s.io/v1beta1",
+		"kind":       "PodMetrics",
+		"metadata": map[string]any{
+			"name":      name,
+			"namespace": namespace,
+			"labels":    map[string]any{"app.kubernetes.io/name": app},
+		},
+		"containers": items,
+	}}
+}
+
+func containerUsage(cpu, memory string) map[string]any {
+	return map[string]any{"name": "app", "usage": map[string]any{"cpu": cpu, "memory": memory}}
+}
+
+func TestListPodUsage(t *testing.T) {
+	scheme := runtime.NewScheme()
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(scheme,
+		map[schema.GroupVersionResource]string{PodMetricsGVR: "PodMetricsList"},
+	)
+	// PodMetrics are served as "pods", which the fake client cannot guess
+	// from the kind, so they are created through the resource
+	for _, obj := range []*unstructured.Unstructured{
+		testPodMetrics("web-1", "fuego-web", "web", containerUsage("125m", "256Mi"), containerUsage("250000000n", "64Mi")),
+		testPodMetrics("other-1", "fuego-other", "other", containerUsage("1", "1Gi")),
+	} {
+		if _, err := dynamicClient.Resource(PodMetricsGVR).Namespace(obj.GetNamespace()).Create(context.Background(), obj, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	client := NewClientWithDynamic(fake.NewClientset(), dynamicClient, "fuego-")
+
+	usage, err := client.ListPodUsage(context.Background(), "web")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(usage) != 1 || usage[0].Name != "web-1" || usage[0].Process != ProcessTypeWeb || usage[0].CPUMillis != 375 || usage[0].MemoryBytes != 320<<20 {
+		t.Errorf("unexpected usage %+v", usage)
+	}
+
+	_, err = NewClientWithInterface(fake.NewClientset(), "fuego-").ListPodUsage(context.Background(), "web")
+	if !errors.Is(err, ErrUsageUnavailable) {
+		t.Errorf("expected ErrUsageUnavailable, got %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+// Package leader coordinates the background workers of control plane
+// replicas through Postgres advisory locks. An Elector runs singleton
+// workers, such as reapers and the event bus, on exactly one replica at a
+// time, and Partitions spreads partitioned work, such as per-region
+// collection, across all replicas.
+//
+// Advisory locks belong to a database session, so each Elector and
+// Partitions keeps a dedicated pool connection while it holds locks. When
+// that connection breaks the server releases the locks and another replica
+// takes over.
+package leader
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// namespace prefixes lock names so that they don't collide with advisory
+// locks taken by other applications sharing the database
+const namespace = "nexo-cloud:"
+
+// Key returns the advisory lock key of a name
+func Key(name string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(namespace + name))
+	return int64(h.Sum64())
+}
+
+// Elector runs workers while holding a named advisory lock, so that only one
+// replica runs them at a time
+type Elector struct {
+	pool  *pgxpool.Pool
+	name  string
+	key   int64
+	retry time.Duration
+}
+
+// New creates an elector for the named lock. A replica that isn't the leader
+// tries to take the lock every retry, and the leader checks every retry that
+// it still holds it.
+func New(pool *pgxpool.Pool, name string, retry time.Duration) *Elector {
+	return &Elector{
+		pool:  pool,
+		name:  name,
+		key:   Key(name),
+		retry: retry,
+	}
+}
+
+// Run campaigns for leadership until the context is canceled. While this
+// replica leads, lead runs with a context that is canceled when leadership is
+// lost; Run waits for lead to return before giving up the lock, so lead must
+// not leave work running in the background.
+func (e *Elector) Run(ctx context.Context, lead func(ctx context.Context)) {
+	for {
+		if err := e.campaign(ctx, lead); err != nil && ctx.Err() == nil {
+			slog.Warn("leader election failed", "lock", e.name, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(e.retry):
+		}
+	}
+}
+
+// campaign tries to take the lock once and leads until it is lost
+func (e *Elector) campaign(ctx context.Context, lead func(ctx context.Context)) error {
+	conn, err := e.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	var acquired bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", e.key).Scan(&acquired); err != nil {
+		release(conn)
+		return err
+	}
+	if !acquired {
+		conn.Release()
+		return nil
+	}
+
+	slog.Info("became leader", "lock", e.name)
+	leadCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		lead(leadCtx)
+	}()
+
+	err = e.hold(leadCtx, conn, done)
+	cancel()
+	<-done
+	release(conn)
+	slog.Info("stepped down as leader", "lock", e.name)
+	return err
+}
+
+// hold checks the lock's connection until the context is canceled or lead
+// returns. A broken connection means the server released the lock.
+func (e *Elector) hold(ctx context.Context, conn *pgxpool.Conn, done <-chan struct{}) error {
+	ticker := time.NewTicker(e.retry)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-done:
+			return nil
+		case <-ticker.C:
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, e.retry)
+		err := conn.Ping(pingCtx)
+		cancel()
+		if err != nil && ctx.Err() == nil {
+			return fmt.Errorf("lost connection holding the lock: %w", err)
+		}
+	}
+}
+
+// All returns a lead function running every worker concurrently and
+// returning once all of them have stopped
+func All(workers ...func(ctx context.Context)) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		var wg sync.WaitGroup
+		for _, worker := range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				worker(ctx)
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+// release drops every lock of a connection and returns it to the pool. The
+// connection is closed instead when unlocking fails, which ends the session
+// and with it the locks.
+func release(conn *pgxpool.Conn) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_unlock_all()"); err != nil {
+		_ = conn.Conn().Close(ctx)
+	}
+	conn.Release()
+}
@@ -0,0 +1,87 @@
+package leader
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKey(t *testing.T) {
+	if Key("workers") != Key("workers") {
+		t.Error("Key is not stable")
+	}
+	if Key("workers") == Key("metering") {
+		t.Error("different names map to the same key")
+	}
+	if Key("metering/gdl") == Key("metering/mex") {
+		t.Error("different partitions map to the same key")
+	}
+}
+
+func TestLockIDs(t *testing.T) {
+	tests := []struct {
+		key           int64
+		class, object int64
+	}{
+		{0, 0, 0},
+		{1, 0, 1},
+		{1 << 32, 1, 0},
+		{0x12345678_9abcdef0, 0x12345678, 0x9abcdef0},
+		{-1, 0xffffffff, 0xffffffff},
+	}
+
+	for _, tt := range tests {
+		class, object := lockIDs(tt.key)
+		if class != tt.class || object != tt.object {
+			t.Errorf("lockIDs(%#x) = %#x, %#x, want %#x, %#x", tt.key, class, object, tt.class, tt.object)
+		}
+	}
+}
+
+func TestShare(t *testing.T) {
+	tests := []struct {
+		total, members, want int
+	}{
+		{3, 1, 3},
+		{3, 2, 2},
+		{3, 3, 1},
+		{3, 5, 1},
+		{4, 2, 2},
+		{0, 2, 0},
+		// Before the member lock shows up
+		{3, 0, 3},
+	}
+
+	for _, tt := range tests {
+		if got := share(tt.total, tt.members); got != tt.want {
+			t.Errorf("share(%d, %d) = %d, want %d", tt.total, tt.members, got, tt.want)
+		}
+	}
+}
+
+func TestAll(t *testing.T) {
+	var stopped atomic.Int32
+	worker := func(ctx context.Context) {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		stopped.Add(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		All(worker, worker, worker)(ctx)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("All did not return after cancellation")
+	}
+	if n := stopped.Load(); n != 3 {
+		t.Errorf("All returned with %d of 3 workers stopped", n)
+	}
+}
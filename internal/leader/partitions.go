@@ -0,0 +1,153 @@
+package leader
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Partitions claims a fair share of a group of named partitions, e.g.
+// regions, for this replica. Every replica claiming the group registers as a
+// member with a shared lock and holds an exclusive lock on each partition it
+// owns, so a partition is owned by at most one replica and a partition whose
+// owner goes away is picked up by another replica on its next Claim.
+type Partitions struct {
+	pool  *pgxpool.Pool
+	group string
+	conn  *pgxpool.Conn
+	held  map[string]bool
+}
+
+// NewPartitions creates a claimer for the partitions of a group
+func NewPartitions(pool *pgxpool.Pool, group string) *Partitions {
+	return &Partitions{
+		pool:  pool,
+		group: group,
+		held:  make(map[string]bool),
+	}
+}
+
+// Claim rebalances and returns the partitions this replica owns, in the
+// order given. Owned partitions beyond this replica's share are released to
+// replicas that joined since, and unowned partitions are taken up to the
+// share. On error the replica owns nothing until the next successful Claim.
+// Claim is not safe for concurrent use.
+func (p *Partitions) Claim(ctx context.Context, names []string) ([]string, error) {
+	owned, err := p.claim(ctx, names)
+	if err != nil {
+		p.Close()
+		return nil, err
+	}
+	return owned, nil
+}
+
+func (p *Partitions) claim(ctx context.Context, names []string) ([]string, error) {
+	if err := p.join(ctx); err != nil {
+		return nil, err
+	}
+
+	members, err := p.members(ctx)
+	if err != nil {
+		return nil, err
+	}
+	quota := share(len(names), members)
+
+	count := 0
+	for _, name := range names {
+		if !p.held[name] {
+			continue
+		}
+		if count < quota {
+			count++
+			continue
+		}
+		if _, err := p.conn.Exec(ctx, "SELECT pg_advisory_unlock($1)", p.key(name)); err != nil {
+			return nil, err
+		}
+		delete(p.held, name)
+	}
+
+	for _, name := range names {
+		if count >= quota {
+			break
+		}
+		if p.held[name] {
+			continue
+		}
+		var acquired bool
+		if err := p.conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", p.key(name)).Scan(&acquired); err != nil {
+			return nil, err
+		}
+		if acquired {
+			p.held[name] = true
+			count++
+		}
+	}
+
+	var owned []string
+	for _, name := range names {
+		if p.held[name] {
+			owned = append(owned, name)
+		}
+	}
+	return owned, nil
+}
+
+// Close releases every partition and leaves the group
+func (p *Partitions) Close() {
+	if p.conn != nil {
+		release(p.conn)
+		p.conn = nil
+	}
+	p.held = make(map[string]bool)
+}
+
+// join registers this replica as a member of the group
+func (p *Partitions) join(ctx context.Context) error {
+	if p.conn != nil {
+		return nil
+	}
+
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock_shared($1)", Key(p.group)); err != nil {
+		release(conn)
+		return err
+	}
+	p.conn = conn
+	return nil
+}
+
+// members counts the replicas in the group. A bigint advisory lock key shows
+// up in pg_locks split into its high and low 32 bits.
+func (p *Partitions) members(ctx context.Context) (int, error) {
+	classID, objID := lockIDs(Key(p.group))
+
+	var n int
+	err := p.conn.QueryRow(ctx, `
+		SELECT count(*) FROM pg_locks
+		WHERE locktype = 'advisory' AND granted AND mode = 'ShareLock'
+		  AND database = (SELECT oid FROM pg_database WHERE datname = current_database())
+		  AND classid::bigint = $1 AND objid::bigint = $2 AND objsubid = 1`,
+		classID, objID).Scan(&n)
+	return n, err
+}
+
+func (p *Partitions) key(name string) int64 {
+	return Key(p.group + "/" + name)
+}
+
+// lockIDs splits an advisory lock key the way pg_locks reports it
+func lockIDs(key int64) (classID, objID int64) {
+	return int64(uint64(key) >> 32), int64(uint32(key))
+}
+
+// share is how many of total partitions each of members replicas may own
+func share(total, members int) int {
+	if members < 1 {
+		members = 1
+	}
+	return (total + members - 1) / members
+}
@@ -0,0 +1,52 @@
+// Package legalhold enforces legal holds. While an organization has an
+// unreleased hold, the apps, activity logs and database add-ons of its owner,
+// members and machine users cannot be deleted: the database rejects such
+// deletes itself, and jobs that delete data outside it, such as namespaces
+// and registry images, check Covers first.
+package legalhold
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Activity log actions of legal holds
+const (
+	ActionPlaced   = "legal_hold.placed"
+	ActionReleased = "legal_hold.released"
+	// ActionBlocked is a delete a hold rejected
+	ActionBlocked = "legal_hold.blocked"
+)
+
+// SQLState is the error code the database fails deletes of held data with
+const SQLState = "NXH01"
+
+// IsHeld reports whether err is a delete the database rejected because a
+// legal hold covers the data
+func IsHeld(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == SQLState
+}
+
+// Covers returns the holds covering the data of a user, none when it can be
+// deleted
+func Covers(ctx context.Context, queries *db.Queries, userID uuid.UUID) ([]db.ListActiveLegalHoldsForUserRow, error) {
+	return queries.ListActiveLegalHoldsForUser(ctx, userID)
+}
+
+// Orgs lists the organizations holding data, once each
+func Orgs(holds []db.ListActiveLegalHoldsForUserRow) string {
+	var orgs []string
+	for _, h := range holds {
+		if !slices.Contains(orgs, h.OrgName) {
+			orgs = append(orgs, h.OrgName)
+		}
+	}
+	return strings.Join(orgs, ", ")
+}
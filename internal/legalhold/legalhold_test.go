@@ -0,0 +1,29 @@
+package legalhold
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestIsHeld(t *testing.T) {
+	held := fmt.Errorf("failed to delete app: %w", &pgconn.PgError{Code: SQLState})
+	if !IsHeld(held) {
+		t.Error("expected a wrapped hold violation to be recognized")
+	}
+	if IsHeld(&pgconn.PgError{Code: "23503"}) {
+		t.Error("expected a foreign key violation not to be a hold")
+	}
+	if IsHeld(nil) {
+		t.Error("expected no error not to be a hold")
+	}
+}
+
+func TestOrgs(t *testing.T) {
+	holds := []db.ListActiveLegalHoldsForUserRow{{OrgName: "acme"}, {OrgName: "globex"}, {OrgName: "acme"}}
+	if got := Orgs(holds); got != "acme, globex" {
+		t.Errorf("unexpected orgs %q", got)
+	}
+}
@@ -0,0 +1,261 @@
+// Package logarchive streams the logs an app's containers have retained as a
+// gzip archive, compressing and flushing chunk by chunk so a large archive is
+// never held in memory. The archive of a fixed time window is reproducible
+// byte for byte while the logs are retained, which lets an interrupted
+// download resume with a Range request.
+package logarchive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+)
+
+// FlushSize is how much log output is compressed before the archive is
+// flushed to the client
+const FlushSize = 64 << 10
+
+// ErrUnsatisfiable is returned for a Range that lies outside the archive
+var ErrUnsatisfiable = errors.New("range not satisfiable")
+
+// errRangeDone stops writing once a requested range is complete
+var errRangeDone = errors.New("range complete")
+
+// Opener opens the logs a container has retained since a time, each line
+// prefixed with its RFC3339 timestamp
+type Opener func(ctx context.Context, source k8s.LogSource, since time.Time) (io.ReadCloser, error)
+
+// Archive is the logs of an app's containers within [Since, Until]
+type Archive struct {
+	App     string
+	Sources []k8s.LogSource
+	Since   time.Time
+	Until   time.Time
+	Open    Opener
+}
+
+// Filename is the name a downloaded archive is saved under
+func (a *Archive) Filename() string {
+	return fmt.Sprintf("%s-logs-%s.txt.gz", a.App, a.Until.UTC().Format("20060102T150405Z"))
+}
+
+// ETag identifies the archive's content: the same window over the same
+// containers produces the same bytes
+func (a *Archive) ETag() string {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s\n%d\n%d\n", a.App, a.Since.Unix(), a.Until.Unix())
+	for _, source := range a.Sources {
+		fmt.Fprintf(h, "%s/%s\n", source.Pod, source.Container)
+	}
+	return fmt.Sprintf(`"logs-%x"`, h.Sum64())
+}
+
+// WriteTo writes the gzip archive to w and returns the number of compressed
+// bytes written. When w is an http.Flusher it is flushed after every
+// FlushSize of log output and after each container.
+func (a *Archive) WriteTo(ctx context.Context, w io.Writer) (int64, error) {
+	out := &countingWriter{w: w}
+	gz := gzip.NewWriter(out)
+	// A zero ModTime keeps the header identical between downloads
+	gz.Name = strings.TrimSuffix(a.Filename(), ".gz")
+
+	flush := func() error {
+		if err := gz.Flush(); err != nil {
+			return err
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		return nil
+	}
+
+	for _, source := range a.Sources {
+		if err := a.writeSource(ctx, gz, source, flush); err != nil {
+			return out.n, err
+		}
+		if err := flush(); err != nil {
+			return out.n, err
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return out.n, err
+	}
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return out.n, nil
+}
+
+// writeSource copies one container's lines within the window, prefixed with
+// the container they came from
+func (a *Archive) writeSource(ctx context.Context, gz *gzip.Writer, source k8s.LogSource, flush func() error) error {
+	stream, err := a.Open(ctx, source, a.Since)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = stream.Close() }()
+
+	prefix := fmt.Sprintf("[%s/%s] ", source.Pod, source.Container)
+	reader := bufio.NewReader(stream)
+	pending := 0
+	for {
+		line, readErr := reader.ReadString('\n')
+		if line != "" {
+			timestamp, message, _ := strings.Cut(line, " ")
+			if t, err := time.Parse(time.RFC3339Nano, timestamp); err == nil {
+				if t.After(a.Until) {
+					// Lines are in order; the rest is newer still
+					return nil
+				}
+				if t.Before(a.Since) {
+					continue
+				}
+			} else {
+				timestamp, message = "", line
+			}
+
+			entry := timestamp + " " + prefix + strings.TrimSuffix(message, "\n") + "\n"
+			if _, err := io.WriteString(gz, entry); err != nil {
+				return err
+			}
+			if pending += len(entry); pending >= FlushSize {
+				if err := flush(); err != nil {
+					return err
+				}
+				pending = 0
+			}
+		}
+
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return fmt.Errorf("failed to read logs of %s/%s: %w", source.Pod, source.Container, readErr)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+// Size computes the compressed size of the archive by writing it out once
+func (a *Archive) Size(ctx context.Context) (int64, error) {
+	return a.WriteTo(ctx, io.Discard)
+}
+
+// WriteRange writes bytes [start, end] of the archive to w, discarding the
+// rest as it is produced
+func (a *Archive) WriteRange(ctx context.Context, w io.Writer, start, end int64) error {
+	rw := &rangeWriter{w: w, skip: start, remaining: end - start + 1}
+	if _, err := a.WriteTo(ctx, rw); err != nil && !errors.Is(err, errRangeDone) {
+		return err
+	}
+	if rw.remaining > 0 {
+		// The logs changed since the size was taken, e.g. they were rotated
+		return fmt.Errorf("archive ended %d bytes short of the range", rw.remaining)
+	}
+	return nil
+}
+
+// ParseRange parses a Range header for a single byte range, e.g. "bytes=100-"
+// or "bytes=-500", into the inclusive offsets it covers in an archive of the
+// given size. ok is false for headers that are ignored per RFC 9110: other
+// units, several ranges or malformed values; the whole archive is sent then.
+func ParseRange(header string, size int64) (start, end int64, ok bool, err error) {
+	spec, found := strings.CutPrefix(strings.TrimSpace(header), "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return 0, 0, false, nil
+	}
+	first, last, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return 0, 0, false, nil
+	}
+
+	if first == "" {
+		// A suffix range: the last n bytes
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			return 0, 0, false, nil
+		}
+		if n == 0 || size == 0 {
+			return 0, 0, true, ErrUnsatisfiable
+		}
+		return max(size-n, 0), size - 1, true, nil
+	}
+
+	start, err = strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, false, nil
+	}
+	end = size - 1
+	if last != "" {
+		if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+			return 0, 0, false, nil
+		}
+		end = min(end, size-1)
+	}
+	if start >= size {
+		return 0, 0, true, ErrUnsatisfiable
+	}
+	return start, end, true, nil
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// rangeWriter passes on a window of what is written to it
+type rangeWriter struct {
+	w         io.Writer
+	skip      int64
+	remaining int64
+}
+
+func (r *rangeWriter) Write(p []byte) (int, error) {
+	total := len(p)
+	if r.skip > 0 {
+		n := min(r.skip, int64(len(p)))
+		r.skip -= n
+		p = p[n:]
+	}
+	if r.remaining <= 0 {
+		if len(p) > 0 {
+			return total, errRangeDone
+		}
+		return total, nil
+	}
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.w.Write(p)
+	r.remaining -= int64(n)
+	if err != nil {
+		return total - len(p) + n, err
+	}
+	return total, nil
+}
+
+// Flush passes flushes on to the client
+func (r *rangeWriter) Flush() {
+	if flusher, ok := r.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,185 @@
+package logarchive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+)
+
+var (
+	since = time.Date(2026, 3, 11, 10, 0, 0, 0, time.UTC)
+	until = since.Add(time.Hour)
+)
+
+// testArchive returns an archive over two containers whose logs span the
+// window on both sides
+func testArchive(lines int) *Archive {
+	logs := map[string]string{}
+	for _, pod := range []string{"web-b", "web-a"} {
+		var b strings.Builder
+		for i := -2; i < lines+2; i++ {
+			t := since.Add(time.Duration(i) * time.Hour / time.Duration(lines))
+			fmt.Fprintf(&b, "%s %s line %d with some padding to make it longer\n", t.Format(time.RFC3339Nano), pod, i)
+		}
+		logs[pod] = b.String()
+	}
+
+	return &Archive{
+		App:     "web",
+		Sources: []k8s.LogSource{{Pod: "web-a", Container: "app"}, {Pod: "web-b", Container: "app"}},
+		Since:   since,
+		Until:   until,
+		Open: func(_ context.Context, source k8s.LogSource, _ time.Time) (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(logs[source.Pod])), nil
+		},
+	}
+}
+
+func decompress(t *testing.T, data []byte) string {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("decompress: %v", err)
+	}
+	return string(out)
+}
+
+func TestWriteTo_Window(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := testArchive(10).WriteTo(context.Background(), &buf); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	out := decompress(t, buf.Bytes())
+
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	// 0 through 10 inclusive for each of the two containers
+	if len(lines) != 22 {
+		t.Fatalf("expected 22 lines within the window, got %d:\n%s", len(lines), out)
+	}
+	if !strings.Contains(lines[0], "[web-a/app] web-a line 0 ") || !strings.Contains(lines[21], "[web-b/app] web-b line 10 ") {
+		t.Errorf("unexpected first or last line: %q, %q", lines[0], lines[21])
+	}
+	if strings.Contains(out, "line -1") || strings.Contains(out, "line 11") {
+		t.Errorf("expected lines outside the window to be dropped:\n%s", out)
+	}
+}
+
+func TestWriteTo_Reproducible(t *testing.T) {
+	archive := testArchive(5000)
+	var first, second bytes.Buffer
+	n, err := archive.WriteTo(context.Background(), &first)
+	if err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	if _, err := archive.WriteTo(context.Background(), &second); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	if n != int64(first.Len()) {
+		t.Errorf("WriteTo reported %d bytes, wrote %d", n, first.Len())
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Fatal("expected the same archive twice")
+	}
+	if size, err := archive.Size(context.Background()); err != nil || size != n {
+		t.Errorf("Size = %d, %v; want %d", size, err, n)
+	}
+}
+
+type flushRecorder struct {
+	bytes.Buffer
+	flushes int
+}
+
+func (f *flushRecorder) Flush() { f.flushes++ }
+
+func TestWriteTo_Flushes(t *testing.T) {
+	var out flushRecorder
+	if _, err := testArchive(5000).WriteTo(context.Background(), &out); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	// ~700KB of output: several chunk flushes plus one per container
+	if out.flushes < 5 {
+		t.Errorf("expected the archive to be flushed in chunks, got %d flushes", out.flushes)
+	}
+}
+
+func TestWriteRange(t *testing.T) {
+	archive := testArchive(5000)
+	var full bytes.Buffer
+	if _, err := archive.WriteTo(context.Background(), &full); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+
+	size := int64(full.Len())
+	for _, r := range [][2]int64{{0, 0}, {0, 99}, {1000, size / 2}, {size - 10, size - 1}} {
+		var part bytes.Buffer
+		if err := archive.WriteRange(context.Background(), &part, r[0], r[1]); err != nil {
+			t.Fatalf("WriteRange(%d, %d): %v", r[0], r[1], err)
+		}
+		if !bytes.Equal(part.Bytes(), full.Bytes()[r[0]:r[1]+1]) {
+			t.Errorf("WriteRange(%d, %d) returned different bytes", r[0], r[1])
+		}
+	}
+
+	if err := archive.WriteRange(context.Background(), io.Discard, 0, size); err == nil {
+		t.Error("expected an error for a range past the end")
+	}
+}
+
+func TestETag(t *testing.T) {
+	a, b := testArchive(1), testArchive(1)
+	if a.ETag() != b.ETag() {
+		t.Error("expected the same window to have the same ETag")
+	}
+	b.Until = b.Until.Add(time.Second)
+	if a.ETag() == b.ETag() {
+		t.Error("expected a different window to change the ETag")
+	}
+	b = testArchive(1)
+	b.Sources = b.Sources[:1]
+	if a.ETag() == b.ETag() {
+		t.Error("expected different containers to change the ETag")
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		header     string
+		start, end int64
+		ok         bool
+		err        error
+	}{
+		{"bytes=0-99", 0, 99, true, nil},
+		{"bytes=100-", 100, 999, true, nil},
+		{"bytes=900-5000", 900, 999, true, nil},
+		{"bytes=-100", 900, 999, true, nil},
+		{"bytes=-5000", 0, 999, true, nil},
+		{"bytes=1000-", 0, 0, true, ErrUnsatisfiable},
+		{"bytes=-0", 0, 0, true, ErrUnsatisfiable},
+		{"bytes=0-1,5-9", 0, 0, false, nil},
+		{"bytes=9-5", 0, 0, false, nil},
+		{"bytes=a-", 0, 0, false, nil},
+		{"items=0-5", 0, 0, false, nil},
+		{"", 0, 0, false, nil},
+	}
+
+	for _, tt := range tests {
+		start, end, ok, err := ParseRange(tt.header, 1000)
+		if start != tt.start || end != tt.end || ok != tt.ok || !errors.Is(err, tt.err) {
+			t.Errorf("ParseRange(%q) = %d, %d, %v, %v; want %d, %d, %v, %v",
+				tt.header, start, end, ok, err, tt.start, tt.end, tt.ok, tt.err)
+		}
+	}
+}
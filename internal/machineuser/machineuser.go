@@ -0,0 +1,129 @@
+// Package machineuser manages machine users: non-interactive identities
+// owned by an organization for CI pipelines. A machine user is backed by a
+// users row, so it owns apps and API tokens like a person, but it never
+// signs in and keeps working after the members who created it leave.
+package machineuser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// MaxQuota caps the quotas an organization can set
+const MaxQuota = 10000
+
+// DeploymentWindow is the rolling window max_deployments_per_day counts in
+const DeploymentWindow = 24 * time.Hour
+
+// Quota errors
+var (
+	ErrAppQuota        = errors.New("machine user app quota reached")
+	ErrDeploymentQuota = errors.New("machine user daily deployment quota reached")
+)
+
+var nameRegex = regexp.MustCompile(`^[a-z][a-z0-9-]{1,61}[a-z0-9]$`)
+
+// ValidName reports whether name is 3-63 lowercase letters, digits and
+// hyphens, starting with a letter
+func ValidName(name string) bool {
+	return nameRegex.MatchString(name)
+}
+
+// ValidQuota reports whether a quota is unset or between 0 and MaxQuota
+func ValidQuota(quota *int32) bool {
+	return quota == nil || (*quota >= 0 && *quota <= MaxQuota)
+}
+
+// Username is the name of a machine user's backing user. Slashes never
+// appear in GitHub usernames, so it cannot collide with a person.
+func Username(org, name string) string {
+	return org + "/" + name
+}
+
+// Create creates a machine user and its backing user in one transaction.
+// The UserID of params is filled in.
+func Create(ctx context.Context, pool *pgxpool.Pool, orgName string, params db.CreateMachineUserParams) (db.MachineUser, error) {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return db.MachineUser{}, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	qtx := db.New(pool).WithTx(tx)
+
+	account, err := qtx.CreateMachineUserAccount(ctx, Username(orgName, params.Name))
+	if err != nil {
+		return db.MachineUser{}, fmt.Errorf("failed to create account: %w", err)
+	}
+	params.UserID = account.ID
+
+	machine, err := qtx.CreateMachineUser(ctx, params)
+	if err != nil {
+		return db.MachineUser{}, err
+	}
+	return machine, tx.Commit(ctx)
+}
+
+// Lookup returns the machine user backed by userID, or nil for people
+func Lookup(ctx context.Context, queries *db.Queries, userID uuid.UUID) (*db.MachineUser, error) {
+	machine, err := queries.GetMachineUserByUserID(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &machine, nil
+}
+
+// CheckAppQuota returns ErrAppQuota when userID is a machine user that
+// already owns as many apps as its quota allows
+func CheckAppQuota(ctx context.Context, queries *db.Queries, userID uuid.UUID) error {
+	machine, err := Lookup(ctx, queries, userID)
+	if err != nil || machine == nil || machine.MaxApps == nil {
+		return err
+	}
+
+	count, err := queries.CountAppsByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if exceeded(*machine.MaxApps, count) {
+		return fmt.Errorf("%w: %d apps allowed", ErrAppQuota, *machine.MaxApps)
+	}
+	return nil
+}
+
+// CheckDeploymentQuota returns ErrDeploymentQuota when userID is a machine
+// user that deployed as often as its quota allows in the DeploymentWindow
+// before now
+func CheckDeploymentQuota(ctx context.Context, queries *db.Queries, userID uuid.UUID, now time.Time) error {
+	machine, err := Lookup(ctx, queries, userID)
+	if err != nil || machine == nil || machine.MaxDeploymentsPerDay == nil {
+		return err
+	}
+
+	count, err := queries.CountDeploymentsByUserSince(ctx, db.CountDeploymentsByUserSinceParams{
+		UserID:    userID,
+		CreatedAt: now.Add(-DeploymentWindow),
+	})
+	if err != nil {
+		return err
+	}
+	if exceeded(*machine.MaxDeploymentsPerDay, count) {
+		return fmt.Errorf("%w: %d deployments per day allowed", ErrDeploymentQuota, *machine.MaxDeploymentsPerDay)
+	}
+	return nil
+}
+
+// exceeded reports whether creating one more resource would go over limit
+func exceeded(limit int32, count int64) bool {
+	return count >= int64(limit)
+}
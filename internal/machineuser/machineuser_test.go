@@ -0,0 +1,45 @@
+package machineuser
+
+import "testing"
+
+func TestValidName(t *testing.T) {
+	for _, name := range []string{"ci", "-ci", "ci-", "Deploy", "ci_bot", "1ci"} {
+		if ValidName(name) {
+			t.Errorf("ValidName(%q) = true, want false", name)
+		}
+	}
+	for _, name := range []string{"ci-deploy", "github-actions", "bot2"} {
+		if !ValidName(name) {
+			t.Errorf("ValidName(%q) = false, want true", name)
+		}
+	}
+}
+
+func TestValidQuota(t *testing.T) {
+	quota := func(n int32) *int32 { return &n }
+
+	if !ValidQuota(nil) || !ValidQuota(quota(0)) || !ValidQuota(quota(MaxQuota)) {
+		t.Error("expected unset, zero and maximum quotas to be valid")
+	}
+	if ValidQuota(quota(-1)) || ValidQuota(quota(MaxQuota+1)) {
+		t.Error("expected out of range quotas to be invalid")
+	}
+}
+
+func TestUsername(t *testing.T) {
+	if got := Username("acme", "ci-deploy"); got != "acme/ci-deploy" {
+		t.Errorf("Username() = %q, want acme/ci-deploy", got)
+	}
+}
+
+func TestExceeded(t *testing.T) {
+	if exceeded(3, 2) {
+		t.Error("expected room for one more under the limit")
+	}
+	if !exceeded(3, 3) {
+		t.Error("expected the limit to be reached")
+	}
+	if !exceeded(0, 0) {
+		t.Error("expected a zero quota to allow nothing")
+	}
+}
@@ -0,0 +1,63 @@
+// Package maintenance schedules platform maintenance windows. Users see
+// active and upcoming windows as a banner, and non-critical background
+// workers such as reapers skip their runs while a window is in progress.
+// Critical work (event and outbox delivery, usage metering) keeps running.
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+)
+
+// Window limits
+const (
+	MaxTitleLength = 200
+	MaxDuration    = 72 * time.Hour
+)
+
+// Validate checks a window being scheduled at now
+func Validate(title string, startsAt, endsAt, now time.Time) error {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return errors.New("title is required")
+	}
+	if len(title) > MaxTitleLength {
+		return fmt.Errorf("title must be at most %d characters", MaxTitleLength)
+	}
+	if startsAt.IsZero() || endsAt.IsZero() {
+		return errors.New("starts_at and ends_at are required")
+	}
+	if !endsAt.After(startsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+	if !endsAt.After(now) {
+		return errors.New("ends_at must be in the future")
+	}
+	if endsAt.Sub(startsAt) > MaxDuration {
+		return fmt.Errorf("a maintenance window may last at most %s", MaxDuration)
+	}
+	return nil
+}
+
+// Active reports whether a window is in progress at now
+func Active(window db.MaintenanceWindow, now time.Time) bool {
+	return !now.Before(window.StartsAt) && now.Before(window.EndsAt)
+}
+
+// Paused reports whether non-critical background work should be skipped
+// because a maintenance window is in progress. Lookup errors are logged and
+// treated as no maintenance, so a database hiccup never stalls the workers.
+func Paused(ctx context.Context, queries *db.Queries, now time.Time) bool {
+	active, err := queries.CountActiveMaintenanceWindows(ctx, now)
+	if err != nil {
+		slog.Warn("failed to check maintenance windows", "error", err)
+		return false
+	}
+	return active > 0
+}
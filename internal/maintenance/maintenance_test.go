@@ -0,0 +1,54 @@
+package maintenance
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+)
+
+func TestValidate(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		title    string
+		startsAt time.Time
+		endsAt   time.Time
+		valid    bool
+	}{
+		{"upcoming", "Database upgrade", now.Add(time.Hour), now.Add(2 * time.Hour), true},
+		{"in progress", "Database upgrade", now.Add(-time.Hour), now.Add(time.Hour), true},
+		{"missing title", "  ", now.Add(time.Hour), now.Add(2 * time.Hour), false},
+		{"long title", strings.Repeat("a", MaxTitleLength+1), now.Add(time.Hour), now.Add(2 * time.Hour), false},
+		{"ends before start", "Upgrade", now.Add(2 * time.Hour), now.Add(time.Hour), false},
+		{"already over", "Upgrade", now.Add(-2 * time.Hour), now.Add(-time.Hour), false},
+		{"too long", "Upgrade", now, now.Add(MaxDuration + time.Minute), false},
+		{"missing times", "Upgrade", time.Time{}, now.Add(time.Hour), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.title, tt.startsAt, tt.endsAt, now)
+			if (err == nil) != tt.valid {
+				t.Errorf("expected valid=%v, got %v", tt.valid, err)
+			}
+		})
+	}
+}
+
+func TestActive(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	window := db.MaintenanceWindow{StartsAt: start, EndsAt: start.Add(time.Hour)}
+
+	if Active(window, start.Add(-time.Second)) {
+		t.Error("expected window not to be active before it starts")
+	}
+	if !Active(window, start) {
+		t.Error("expected window to be active at its start")
+	}
+	if Active(window, start.Add(time.Hour)) {
+		t.Error("expected window not to be active at its end")
+	}
+}
@@ -0,0 +1,105 @@
+package metering
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// metricDuration is Traefik's request duration histogram of a service
+const metricDuration = "traefik_service_request_duration_seconds_bucket"
+
+// Histogram is a cumulative request duration histogram: the number of
+// requests that took at most each upper bound, in seconds
+type Histogram map[float64]float64
+
+// Sub returns the requests between an earlier reading and h. A bucket lower
+// than before means the ingress restarted, so it counts from zero.
+func (h Histogram) Sub(earlier Histogram) Histogram {
+	sub := make(Histogram, len(h))
+	for bound, count := range h {
+		if count < earlier[bound] {
+			return h
+		}
+		sub[bound] = count - earlier[bound]
+	}
+	return sub
+}
+
+// Quantile estimates the duration below which q of the requests completed,
+// interpolating within the bucket like Prometheus' histogram_quantile. It
+// is false when the histogram counts no requests.
+func (h Histogram) Quantile(q float64) (time.Duration, bool) {
+	bounds := make([]float64, 0, len(h))
+	for bound := range h {
+		bounds = append(bounds, bound)
+	}
+	slices.Sort(bounds)
+	if len(bounds) == 0 || h[bounds[len(bounds)-1]] <= 0 {
+		return 0, false
+	}
+
+	rank := q * h[bounds[len(bounds)-1]]
+	lower, below := 0.0, 0.0
+	for _, bound := range bounds {
+		count := h[bound]
+		if count >= rank {
+			// Requests over the largest finite bound have no upper limit
+			if math.IsInf(bound, 1) {
+				return seconds(lower), true
+			}
+			if count == below {
+				return seconds(bound), true
+			}
+			return seconds(lower + (bound-lower)*(rank-below)/(count-below)), true
+		}
+		lower, below = bound, count
+	}
+	return seconds(lower), true
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// ParseTraefikLatency reads Traefik's Prometheus exposition and sums the
+// request duration histograms per service label, across status codes and
+// methods
+func ParseTraefikLatency(r io.Reader) (map[string]Histogram, error) {
+	histograms := make(map[string]Histogram)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, metricDuration) {
+			continue
+		}
+
+		name, labels, value, ok := parseSample(line)
+		if !ok || name != metricDuration || labels["service"] == "" {
+			continue
+		}
+		bound, err := strconv.ParseFloat(labels["le"], 64)
+		if err != nil {
+			continue
+		}
+
+		h := histograms[labels["service"]]
+		if h == nil {
+			h = make(Histogram)
+			histograms[labels["service"]] = h
+		}
+		h[bound] += value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read metrics: %w", err)
+	}
+
+	return histograms, nil
+}
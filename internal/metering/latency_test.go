@@ -0,0 +1,69 @@
+package metering
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+const traefikLatency = `# TYPE traefik_service_request_duration_seconds histogram
+traefik_service_request_duration_seconds_bucket{code="200",method="GET",protocol="http",service="tenant-web-web-80@kubernetes",le="0.1"} 80
+traefik_service_request_duration_seconds_bucket{code="200",method="GET",protocol="http",service="tenant-web-web-80@kubernetes",le="0.3"} 90
+traefik_service_request_duration_seconds_bucket{code="200",method="GET",protocol="http",service="tenant-web-web-80@kubernetes",le="1.2"} 98
+traefik_service_request_duration_seconds_bucket{code="200",method="GET",protocol="http",service="tenant-web-web-80@kubernetes",le="+Inf"} 100
+traefik_service_request_duration_seconds_bucket{code="500",method="GET",protocol="http",service="tenant-web-web-80@kubernetes",le="0.1"} 0
+traefik_service_request_duration_seconds_bucket{code="500",method="GET",protocol="http",service="tenant-web-web-80@kubernetes",le="0.3"} 0
+traefik_service_request_duration_seconds_bucket{code="500",method="GET",protocol="http",service="tenant-web-web-80@kubernetes",le="1.2"} 0
+traefik_service_request_duration_seconds_bucket{code="500",method="GET",protocol="http",service="tenant-web-web-80@kubernetes",le="+Inf"} 100
+traefik_service_request_duration_seconds_sum{code="200",method="GET",protocol="http",service="tenant-web-web-80@kubernetes"} 42
+traefik_service_requests_total{code="200",method="GET",protocol="http",service="tenant-web-web-80@kubernetes"} 100
+`
+
+func TestParseTraefikLatency(t *testing.T) {
+	histograms, err := ParseTraefikLatency(strings.NewReader(traefikLatency))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	web := histograms[ServiceName("tenant-web", "web")]
+	if len(histograms) != 1 || len(web) != 4 || web[0.1] != 80 || web[1.2] != 98 {
+		t.Fatalf("unexpected histograms %v", histograms)
+	}
+
+	// Half of the 200 requests timed out past the largest bound
+	if p50, ok := web.Quantile(0.5); !ok || p50 != 1200*time.Millisecond {
+		t.Errorf("expected p50 at the largest finite bound, got %v", p50)
+	}
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	h := Histogram{0.1: 80, 0.3: 90, 1.2: 100, posInf(): 100}
+
+	if p95, ok := h.Quantile(0.95); !ok || p95 != 750*time.Millisecond {
+		t.Errorf("expected p95 of 750ms, got %v", p95)
+	}
+	if p50, ok := h.Quantile(0.5); !ok || p50 != 62500*time.Microsecond {
+		t.Errorf("expected p50 of 62.5ms, got %v", p50)
+	}
+	if _, ok := (Histogram{0.1: 0, posInf(): 0}).Quantile(0.95); ok {
+		t.Error("expected no quantile without requests")
+	}
+}
+
+func TestHistogramSub(t *testing.T) {
+	earlier := Histogram{0.1: 50, posInf(): 60}
+
+	if sub := (Histogram{0.1: 80, posInf(): 100}).Sub(earlier); sub[0.1] != 30 || sub[posInf()] != 40 {
+		t.Errorf("unexpected difference %v", sub)
+	}
+
+	// After an ingress restart the histogram starts over
+	if sub := (Histogram{0.1: 5, posInf(): 6}).Sub(earlier); sub[0.1] != 5 || sub[posInf()] != 6 {
+		t.Errorf("expected reset histograms to count from zero, got %v", sub)
+	}
+}
+
+func posInf() float64 {
+	return math.Inf(1)
+}
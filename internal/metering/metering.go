@@ -0,0 +1,154 @@
+// Package metering records per-app network usage. It periodically scrapes the
+// ingress controller's Prometheus metrics in every region and stores the
+// bytes and requests each app served in hourly buckets, which back app
+// metrics and billing.
+package metering
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/leader"
+)
+
+// Period is the size of the usage buckets
+const Period = time.Hour
+
+// Collector periodically meters app bandwidth
+type Collector struct {
+	queries *db.Queries
+	cfg     *config.Config
+	http    *http.Client
+	now     func() time.Time
+
+	// partitions splits the regions between control plane replicas; every
+	// region is collected when nil
+	partitions *leader.Partitions
+
+	// last holds the previous scrape of each region. Usage is the difference
+	// between scrapes, so the first scrape after startup only sets a baseline.
+	last map[string]map[string]Counters
+}
+
+// New creates a collector. With partitions, each replica only scrapes the
+// regions it claims, so no usage is counted twice.
+func New(queries *db.Queries, cfg *config.Config, partitions *leader.Partitions) *Collector {
+	return &Collector{
+		queries:    queries,
+		cfg:        cfg,
+		http:       &http.Client{Timeout: 10 * time.Second},
+		now:        time.Now,
+		partitions: partitions,
+		last:       make(map[string]map[string]Counters),
+	}
+}
+
+// Collect scrapes every region with an ingress metrics endpoint once and
+// records the usage since the previous scrape. A region that fails is
+// logged and skipped until the next collection.
+func (c *Collector) Collect(ctx context.Context) error {
+	regions, err := c.regions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to claim regions: %w", err)
+	}
+
+	for _, region := range regions {
+		counters, err := c.scrape(ctx, c.cfg.TraefikMetricsURLForRegion(region))
+		if err != nil {
+			slog.Warn("failed to scrape ingress metrics", "region", region, "error", err)
+			continue
+		}
+
+		if err := c.record(ctx, region, counters); err != nil {
+			slog.Error("failed to record bandwidth", "region", region, "error", err)
+		}
+	}
+	return nil
+}
+
+// regions returns the regions with an ingress metrics endpoint that this
+// replica meters. The baseline of a region claimed by another replica is
+// dropped: usage metered there must not be counted again if the region
+// comes back.
+func (c *Collector) regions(ctx context.Context) ([]string, error) {
+	var metered []string
+	for _, region := range c.cfg.Regions {
+		if c.cfg.TraefikMetricsURLForRegion(region) != "" {
+			metered = append(metered, region)
+		}
+	}
+	if c.partitions == nil {
+		return metered, nil
+	}
+
+	owned, err := c.partitions.Claim(ctx, metered)
+	if err != nil {
+		clear(c.last)
+		return nil, err
+	}
+	for region := range c.last {
+		if !slices.Contains(owned, region) {
+			delete(c.last, region)
+		}
+	}
+	return owned, nil
+}
+
+func (c *Collector) scrape(ctx context.Context, metricsURL string) (map[string]Counters, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metricsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("metrics endpoint returned status %d", resp.StatusCode)
+	}
+	return ParseTraefikMetrics(resp.Body)
+}
+
+// record stores the usage of each app of a region since the previous scrape
+func (c *Collector) record(ctx context.Context, region string, counters map[string]Counters) error {
+	previous, ok := c.last[region]
+	c.last[region] = counters
+	if !ok {
+		return nil
+	}
+
+	apps, err := c.queries.ListAppsByRegion(ctx, region)
+	if err != nil {
+		return fmt.Errorf("failed to list apps: %w", err)
+	}
+
+	periodStart := c.now().Truncate(Period)
+	for _, app := range apps {
+		service := ServiceName(c.cfg.K8sNamespacePrefix+app.Name, app.Name)
+		usage := counters[service].Sub(previous[service])
+		if usage.IsZero() {
+			continue
+		}
+
+		err := c.queries.AddAppBandwidth(ctx, db.AddAppBandwidthParams{
+			AppID:        app.ID,
+			PeriodStart:  periodStart,
+			IngressBytes: usage.IngressBytes,
+			EgressBytes:  usage.EgressBytes,
+			Requests:     usage.Requests,
+		})
+		if err != nil {
+			slog.Error("failed to record app bandwidth", "app", app.Name, "error", err)
+		}
+	}
+	return nil
+}
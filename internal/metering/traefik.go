@@ -0,0 +1,145 @@
+package metering
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Traefik service metrics, labeled with the router service they count
+const (
+	metricRequestBytes  = "traefik_service_requests_bytes_total"
+	metricResponseBytes = "traefik_service_responses_bytes_total"
+	metricRequests      = "traefik_service_requests_total"
+)
+
+// Counters are cumulative byte and request counts of one ingress service
+type Counters struct {
+	IngressBytes int64
+	EgressBytes  int64
+	Requests     int64
+}
+
+// Sub returns the usage between an earlier reading and c. A counter lower
+// than before means the ingress restarted, so it counts from zero.
+func (c Counters) Sub(earlier Counters) Counters {
+	sub := func(now, before int64) int64 {
+		if now < before {
+			return now
+		}
+		return now - before
+	}
+	return Counters{
+		IngressBytes: sub(c.IngressBytes, earlier.IngressBytes),
+		EgressBytes:  sub(c.EgressBytes, earlier.EgressBytes),
+		Requests:     sub(c.Requests, earlier.Requests),
+	}
+}
+
+// IsZero reports whether no traffic was counted
+func (c Counters) IsZero() bool {
+	return c == Counters{}
+}
+
+// ServiceName returns the name Traefik's Kubernetes Ingress provider gives
+// the backend of an app's ingress, as found in the service metric label
+func ServiceName(namespace, appName string) string {
+	return fmt.Sprintf("%s-%s-80@kubernetes", namespace, appName)
+}
+
+// ParseTraefikMetrics reads Traefik's Prometheus exposition and sums the
+// service counters per service label, across status codes and methods
+func ParseTraefikMetrics(r io.Reader) (map[string]Counters, error) {
+	counters := make(map[string]Counters)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, labels, value, ok := parseSample(line)
+		if !ok {
+			continue
+		}
+		if name != metricRequestBytes && name != metricResponseBytes && name != metricRequests {
+			continue
+		}
+		service := labels["service"]
+		if service == "" {
+			continue
+		}
+
+		c := counters[service]
+		switch name {
+		case metricRequestBytes:
+			c.IngressBytes += int64(value)
+		case metricResponseBytes:
+			c.EgressBytes += int64(value)
+		case metricRequests:
+			c.Requests += int64(value)
+		}
+		counters[service] = c
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read metrics: %w", err)
+	}
+
+	return counters, nil
+}
+
+// parseSample splits a sample line such as
+// name{label="value",...} 123 [timestamp]
+func parseSample(line string) (string, map[string]string, float64, bool) {
+	labels := map[string]string{}
+
+	name := line
+	rest := ""
+	if i := strings.IndexByte(line, '{'); i >= 0 {
+		j := strings.LastIndexByte(line, '}')
+		if j < i {
+			return "", nil, 0, false
+		}
+		name = line[:i]
+		parseLabels(line[i+1:j], labels)
+		rest = line[j+1:]
+	} else if i := strings.IndexByte(line, ' '); i >= 0 {
+		name, rest = line[:i], line[i:]
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return "", nil, 0, false
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return "", nil, 0, false
+	}
+	return name, labels, value, true
+}
+
+func parseLabels(s string, labels map[string]string) {
+	for s != "" {
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 || eq+1 >= len(s) || s[eq+1] != '"' {
+			return
+		}
+		key := strings.TrimSpace(s[:eq])
+
+		var value strings.Builder
+		i := eq + 2
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+			}
+			value.WriteByte(s[i])
+		}
+		labels[key] = value.String()
+
+		s = strings.TrimLeft(s[min(i+1, len(s)):], ", ")
+	}
+}
@@ -0,0 +1,54 @@
+package metering
+
+import (
+	"strings"
+	"testing"
+)
+
+const traefikMetrics = `# HELP traefik_service_requests_total How many HTTP requests processed on a service, partitioned by status code, protocol, and method.
+# TYPE traefik_service_requests_total counter
+traefik_service_requests_total{code="200",method="GET",protocol="http",service="tenant-web-web-80@kubernetes"} 120
+traefik_service_requests_total{code="500",method="POST",protocol="http",service="tenant-web-web-80@kubernetes"} 3
+traefik_service_requests_bytes_total{code="200",method="GET",protocol="http",service="tenant-web-web-80@kubernetes"} 4096
+traefik_service_responses_bytes_total{code="200",method="GET",protocol="http",service="tenant-web-web-80@kubernetes"} 1.048576e+06
+traefik_service_requests_total{code="200",method="GET",protocol="http",service="tenant-api-api-80@kubernetes"} 7 1718000000000
+traefik_entrypoint_requests_total{code="200",entrypoint="websecure",method="GET",protocol="http"} 130
+traefik_service_requests_total{code="200",method="GET",protocol="http"} 5
+`
+
+func TestParseTraefikMetrics(t *testing.T) {
+	counters, err := ParseTraefikMetrics(strings.NewReader(traefikMetrics))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	web := counters[ServiceName("tenant-web", "web")]
+	if web.Requests != 123 || web.IngressBytes != 4096 || web.EgressBytes != 1048576 {
+		t.Errorf("unexpected counters for web: %+v", web)
+	}
+	if api := counters["tenant-api-api-80@kubernetes"]; api.Requests != 7 {
+		t.Errorf("expected 7 requests for api, got %+v", api)
+	}
+	if len(counters) != 2 {
+		t.Errorf("expected only labeled service metrics, got %v", counters)
+	}
+}
+
+func TestCountersSub(t *testing.T) {
+	earlier := Counters{IngressBytes: 100, EgressBytes: 1000, Requests: 10}
+
+	usage := Counters{IngressBytes: 150, EgressBytes: 1600, Requests: 12}.Sub(earlier)
+	if usage != (Counters{IngressBytes: 50, EgressBytes: 600, Requests: 2}) {
+		t.Errorf("unexpected usage %+v", usage)
+	}
+
+	// After an ingress restart the counters start over
+	usage = Counters{IngressBytes: 20, EgressBytes: 300, Requests: 1}.Sub(earlier)
+	if usage != (Counters{IngressBytes: 20, EgressBytes: 300, Requests: 1}) {
+		t.Errorf("expected reset counters to count from zero, got %+v", usage)
+	}
+
+	if !earlier.Sub(earlier).IsZero() {
+		t.Error("expected no usage between identical readings")
+	}
+}
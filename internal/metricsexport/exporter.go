@@ -0,0 +1,152 @@
+package metricsexport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/cryptoutil"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+)
+
+// maxErrorLength bounds the error recorded for a failed push
+const maxErrorLength = 500
+
+// Exporter periodically pushes the metrics of apps with an export
+type Exporter struct {
+	queries *db.Queries
+	cfg     *config.Config
+	clients func(kubeconfig string) (k8s.Interface, error)
+	http    *http.Client
+	now     func() time.Time
+}
+
+// New creates an exporter connecting to clusters with clients
+func New(queries *db.Queries, cfg *config.Config, clients func(kubeconfig string) (k8s.Interface, error)) *Exporter {
+	return &Exporter{
+		queries: queries,
+		cfg:     cfg,
+		clients: clients,
+		http:    &http.Client{Timeout: 10 * time.Second},
+		now:     time.Now,
+	}
+}
+
+// Export pushes the current metrics of every deployed app with an export
+// once, recording the outcome on the export. A region whose cluster cannot
+// be reached is logged and skipped until the next push.
+func (e *Exporter) Export(ctx context.Context) error {
+	now := e.now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	for _, region := range e.cfg.Regions {
+		exports, err := e.queries.ListMetricsExportsByRegion(ctx, region)
+		if err != nil {
+			return fmt.Errorf("failed to list metrics exports: %w", err)
+		}
+		if len(exports) == 0 {
+			continue
+		}
+
+		client, err := e.clients(e.cfg.KubeconfigForRegion(region))
+		if err != nil {
+			slog.Warn("kubernetes not available for region", "region", region, "error", err)
+			continue
+		}
+
+		for _, export := range exports {
+			// Without metrics-server only the bandwidth is pushed
+			usage, err := client.ListPodUsage(ctx, export.Name)
+			if err != nil {
+				slog.Warn("failed to read pod usage", "region", region, "app", export.Name, "error", err)
+			}
+			bandwidth, err := e.queries.GetAppBandwidth(ctx, db.GetAppBandwidthParams{
+				AppID:       export.AppID,
+				PeriodStart: monthStart,
+			})
+			if err != nil {
+				slog.Error("failed to read bandwidth", "app", export.Name, "error", err)
+				continue
+			}
+
+			samples := Samples(export.Name, region, usage, bandwidth)
+			if err := e.push(ctx, export, region, samples, now); err != nil {
+				slog.Warn("failed to push metrics", "app", export.Name, "kind", export.Kind, "error", err)
+				message := err.Error()
+				if len(message) > maxErrorLength {
+					message = message[:maxErrorLength]
+				}
+				message = strings.ToValidUTF8(message, "")
+				_ = e.queries.RecordMetricsExportError(ctx, db.RecordMetricsExportErrorParams{
+					AppID:     export.AppID,
+					LastError: &message,
+				})
+				continue
+			}
+			_ = e.queries.RecordMetricsExportPush(ctx, export.AppID)
+		}
+	}
+	return nil
+}
+
+// push sends samples to an export's endpoint
+func (e *Exporter) push(ctx context.Context, export db.ListMetricsExportsByRegionRow, region string, samples []Sample, at time.Time) error {
+	headers, err := cryptoutil.Decrypt(export.HeadersEncrypted, e.cfg.EncryptionKey)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt headers: %w", err)
+	}
+
+	var body []byte
+	contentType := "application/json"
+	switch export.Kind {
+	case KindRemoteWrite:
+		body = RemoteWrite(samples, at)
+		contentType = "application/x-protobuf"
+	case KindWebhook:
+		body, err = json.Marshal(Payload{
+			App:       export.Name,
+			Region:    region,
+			Timestamp: at.UTC(),
+			Metrics:   samples,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal metrics: %w", err)
+		}
+	default:
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalid, export.Kind)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, export.Url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "nexo-cloud")
+	if export.Kind == KindRemoteWrite {
+		req.Header.Set("Content-Encoding", "snappy")
+		req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	}
+
+	resp, err := e.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push metrics: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return fmt.Errorf("endpoint returned %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+	return nil
+}
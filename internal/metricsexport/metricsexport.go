@@ -0,0 +1,211 @@
+// Package metricsexport pushes the metrics the platform collects for an app
+// to its owner's own monitoring: the CPU and memory of its pods, as measured
+// by metrics-server, and the requests and bytes metered at the ingress. An
+// export is a Prometheus remote-write endpoint, such as Grafana Cloud or
+// Mimir, or a webhook receiving them as JSON, for forwarders such as
+// Datadog's.
+package metricsexport
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"golang.org/x/net/http/httpguts"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	// KindRemoteWrite pushes Prometheus remote-write 1.0 requests
+	KindRemoteWrite = "remote_write"
+	// KindWebhook posts a JSON Payload
+	KindWebhook = "webhook"
+)
+
+// Kinds are the kinds of exports
+var Kinds = []string{KindRemoteWrite, KindWebhook}
+
+const (
+	// MaxURLLength bounds the URL of an export
+	MaxURLLength = 2048
+	// MaxHeaders bounds the headers sent with every push
+	MaxHeaders = 10
+)
+
+// Metric names. Pod metrics are gauges labelled with the pod and its
+// process; the others are counters since the start of the month, resetting
+// when it turns.
+const (
+	MetricCPU          = "nexo_app_cpu_millicores"
+	MetricMemory       = "nexo_app_memory_bytes"
+	MetricPods         = "nexo_app_pods"
+	MetricRequests     = "nexo_app_requests_total"
+	MetricIngressBytes = "nexo_app_ingress_bytes_total"
+	MetricEgressBytes  = "nexo_app_egress_bytes_total"
+)
+
+// ErrInvalid is returned for exports that cannot be pushed
+var ErrInvalid = errors.New("invalid metrics export")
+
+// reservedHeaders are set on every push and cannot be overridden
+var reservedHeaders = []string{
+	"Content-Encoding",
+	"Content-Length",
+	"Content-Type",
+	"Host",
+	"User-Agent",
+	"X-Prometheus-Remote-Write-Version",
+}
+
+// Validate checks the kind, URL and headers of an export. The URL must use
+// HTTPS and carry no credentials, since it is shown back in plain text;
+// they go in headers such as Authorization, which are encrypted.
+func Validate(kind, rawURL string, headers map[string]string) error {
+	if !slices.Contains(Kinds, kind) {
+		return fmt.Errorf("%w: kind must be one of %s", ErrInvalid, strings.Join(Kinds, ", "))
+	}
+
+	if len(rawURL) > MaxURLLength {
+		return fmt.Errorf("%w: url may be at most %d characters", ErrInvalid, MaxURLLength)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute URL", ErrInvalid)
+	}
+	if u.Scheme != "https" {
+		return fmt.Errorf("%w: url must use https", ErrInvalid)
+	}
+	if u.User != nil {
+		return fmt.Errorf("%w: url must not contain credentials, send them in an Authorization header", ErrInvalid)
+	}
+
+	if len(headers) > MaxHeaders {
+		return fmt.Errorf("%w: at most %d headers are allowed", ErrInvalid, MaxHeaders)
+	}
+	for name, value := range headers {
+		if !httpguts.ValidHeaderFieldName(name) || !httpguts.ValidHeaderFieldValue(value) {
+			return fmt.Errorf("%w: invalid header %q", ErrInvalid, name)
+		}
+		if slices.Contains(reservedHeaders, http.CanonicalHeaderKey(name)) {
+			return fmt.Errorf("%w: header %s is set by the platform", ErrInvalid, http.CanonicalHeaderKey(name))
+		}
+	}
+	return nil
+}
+
+// Sample is the value of one series at the time of a push
+type Sample struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels"`
+	Value  float64           `json:"value"`
+}
+
+// Payload is the body posted to webhooks
+type Payload struct {
+	App       string    `json:"app"`
+	Region    string    `json:"region"`
+	Timestamp time.Time `json:"timestamp"`
+	Metrics   []Sample  `json:"metrics"`
+}
+
+// Samples builds the samples of an app from the usage of its pods and its
+// bandwidth since the start of the month. Pod metrics are left out when
+// usage is nil, as when metrics-server cannot be read.
+func Samples(app, region string, usage []k8s.PodUsage, bandwidth db.GetAppBandwidthRow) []Sample {
+	labels := func(extra ...string) map[string]string {
+		l := map[string]string{"app": app, "region": region}
+		for i := 0; i+1 < len(extra); i += 2 {
+			l[extra[i]] = extra[i+1]
+		}
+		return l
+	}
+
+	var samples []Sample
+	if usage != nil {
+		for _, pod := range usage {
+			samples = append(samples,
+				Sample{Name: MetricCPU, Labels: labels("pod", pod.Name, "process", pod.Process), Value: float64(pod.CPUMillis)},
+				Sample{Name: MetricMemory, Labels: labels("pod", pod.Name, "process", pod.Process), Value: float64(pod.MemoryBytes)},
+			)
+		}
+		samples = append(samples, Sample{Name: MetricPods, Labels: labels(), Value: float64(len(usage))})
+	}
+	return append(samples,
+		Sample{Name: MetricRequests, Labels: labels(), Value: float64(bandwidth.Requests)},
+		Sample{Name: MetricIngressBytes, Labels: labels(), Value: float64(bandwidth.IngressBytes)},
+		Sample{Name: MetricEgressBytes, Labels: labels(), Value: float64(bandwidth.EgressBytes)},
+	)
+}
+
+// RemoteWrite encodes samples as the body of a Prometheus remote-write 1.0
+// request: a snappy-compressed WriteRequest protobuf with one time series
+// per sample, all at the given time
+func RemoteWrite(samples []Sample, at time.Time) []byte {
+	var request []byte
+	for _, sample := range samples {
+		var series []byte
+		for _, label := range sortedLabels(sample) {
+			var l []byte
+			l = protowire.AppendTag(l, 1, protowire.BytesType)
+			l = protowire.AppendString(l, label[0])
+			l = protowire.AppendTag(l, 2, protowire.BytesType)
+			l = protowire.AppendString(l, label[1])
+
+			series = protowire.AppendTag(series, 1, protowire.BytesType)
+			series = protowire.AppendBytes(series, l)
+		}
+
+		var s []byte
+		s = protowire.AppendTag(s, 1, protowire.Fixed64Type)
+		s = protowire.AppendFixed64(s, math.Float64bits(sample.Value))
+		s = protowire.AppendTag(s, 2, protowire.VarintType)
+		s = protowire.AppendVarint(s, uint64(at.UnixMilli()))
+		series = protowire.AppendTag(series, 2, protowire.BytesType)
+		series = protowire.AppendBytes(series, s)
+
+		request = protowire.AppendTag(request, 1, protowire.BytesType)
+		request = protowire.AppendBytes(request, series)
+	}
+	return snappyBlock(request)
+}
+
+// sortedLabels returns the labels of a sample with its name as __name__,
+// sorted by name as remote-write requires
+func sortedLabels(sample Sample) [][2]string {
+	labels := [][2]string{{"__name__", sample.Name}}
+	for name, value := range sample.Labels {
+		labels = append(labels, [2]string{name, value})
+	}
+	slices.SortFunc(labels, func(a, b [2]string) int {
+		return strings.Compare(a[0], b[0])
+	})
+	return labels
+}
+
+// snappyBlock frames src in the snappy block format as literals, without
+// compressing it. Pushes are small, and any snappy decoder reads them.
+func snappyBlock(src []byte) []byte {
+	dst := binary.AppendUvarint(nil, uint64(len(src)))
+	for len(src) > 0 {
+		n := min(len(src), 1<<16)
+		switch {
+		case n <= 60:
+			dst = append(dst, byte(n-1)<<2)
+		case n <= 1<<8:
+			dst = append(dst, 60<<2, byte(n-1))
+		default:
+			dst = append(dst, 61<<2, byte(n-1), byte((n-1)>>8))
+		}
+		dst = append(dst, src[:n]...)
+		src = src[n:]
+	}
+	return dst
+}
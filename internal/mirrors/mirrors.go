@@ -0,0 +1,86 @@
+// Package mirrors stops traffic mirrors once their time box runs out, so a
+// forgotten experiment never keeps copying production requests.
+package mirrors
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/events"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+)
+
+// EventExpired is published when a mirror is stopped because it expired
+const EventExpired = "mirror.expired"
+
+// Expirer stops expired mirrors
+type Expirer struct {
+	queries *db.Queries
+	cfg     *config.Config
+	clients func(kubeconfig string) (k8s.Interface, error)
+	events  events.Publisher
+	now     func() time.Time
+}
+
+// New creates an expirer connecting to clusters with clients
+func New(queries *db.Queries, cfg *config.Config, clients func(kubeconfig string) (k8s.Interface, error), publisher events.Publisher) *Expirer {
+	return &Expirer{
+		queries: queries,
+		cfg:     cfg,
+		clients: clients,
+		events:  publisher,
+		now:     time.Now,
+	}
+}
+
+// Expire stops every mirror whose time box has run out. A mirror whose
+// resources cannot be removed is kept and retried on the next run.
+func (e *Expirer) Expire(ctx context.Context) error {
+	expired, err := e.queries.ListExpiredAppMirrors(ctx, e.now())
+	if err != nil {
+		return fmt.Errorf("failed to list expired mirrors: %w", err)
+	}
+
+	regions := k8s.NewRegions(e.cfg.KubeconfigForRegion, e.clients)
+	for _, m := range expired {
+		client, err := regions.Client(m.Region)
+		if err != nil {
+			slog.Warn("kubernetes not available for region", "region", m.Region, "error", err)
+			continue
+		}
+		if err := client.RemoveMirror(ctx, m.AppName); err != nil {
+			slog.Warn("failed to remove mirror", "app", m.AppName, "error", err)
+			continue
+		}
+
+		if err := e.queries.DeleteAppMirror(ctx, m.AppID); err != nil {
+			slog.Error("failed to delete mirror", "app", m.AppName, "error", err)
+			continue
+		}
+
+		if err := e.events.Publish(ctx, expiredEvent(m)); err != nil {
+			slog.Error("failed to publish mirror expiry", "app", m.AppName, "error", err)
+		}
+	}
+
+	return nil
+}
+
+func expiredEvent(m db.ListExpiredAppMirrorsRow) events.Event {
+	return events.Event{
+		Type:    EventExpired,
+		UserID:  m.UserID,
+		AppID:   m.AppID,
+		AppName: m.AppName,
+		Message: fmt.Sprintf("stopped mirroring %d%% of %s traffic to %s", m.Percent, m.AppName, m.TargetAppName),
+		Payload: map[string]any{
+			"target_app": m.TargetAppName,
+			"percent":    m.Percent,
+			"expired_at": m.ExpiresAt,
+		},
+	}
+}
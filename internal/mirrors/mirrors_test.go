@@ -0,0 +1,31 @@
+package mirrors
+
+import (
+	"testing"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/google/uuid"
+)
+
+func TestExpiredEvent(t *testing.T) {
+	m := db.ListExpiredAppMirrorsRow{
+		AppID:         uuid.New(),
+		UserID:        uuid.New(),
+		AppName:       "web",
+		TargetAppName: "web-staging",
+		Percent:       10,
+		ExpiresAt:     time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	n := expiredEvent(m)
+	if n.Type != EventExpired {
+		t.Errorf("expected event %q, got %q", EventExpired, n.Type)
+	}
+	if n.UserID != m.UserID || n.AppID != m.AppID {
+		t.Error("expected the app owner to be notified about the mirrored app")
+	}
+	if want := "stopped mirroring 10% of web traffic to web-staging"; n.Message != want {
+		t.Errorf("expected message %q, got %q", want, n.Message)
+	}
+}
@@ -0,0 +1,402 @@
+// Package nameserver is the authoritative DNS server for zones delegated to
+// the platform. It answers from a table of the records of every active zone,
+// reloaded periodically, adding the NS, SOA and CAA records of each zone's
+// apex. It does not recurse: queries outside the zones are refused.
+package nameserver
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"log/slog"
+	"maps"
+	"net"
+	"net/netip"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/domainrecords"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/domainverify"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// TTL is the TTL of every record served
+const TTL = 300
+
+// maxUDPSize is the largest response sent over UDP; larger ones are
+// truncated so the resolver retries over TCP
+const maxUDPSize = 512
+
+// reloadInterval is how often the records are read again
+const reloadInterval = 30 * time.Second
+
+// typeCAA is the CAA resource record type, which dnsmessage does not name
+const typeCAA = dnsmessage.Type(257)
+
+// Source returns the records of every zone served, by zone
+type Source func(ctx context.Context) (map[string][]domainverify.Record, error)
+
+// Table is a snapshot of the records of the zones served
+type Table struct {
+	serial uint32
+	zones  map[string]*zone
+}
+
+type zone struct {
+	soa dnsmessage.Resource
+	// records are the zone's records by lowercase name without trailing dot
+	records map[string][]dnsmessage.Resource
+}
+
+// NewTable builds the table serving records for zones, with serial as the
+// serial of every zone's SOA. Each zone apex is given NS records for the
+// nameservers and a CAA record allowing the platform's certificate authority.
+func NewTable(nameservers []string, hostmaster string, serial uint32, zones map[string][]domainverify.Record) *Table {
+	t := &Table{serial: serial, zones: make(map[string]*zone, len(zones))}
+	for name, records := range zones {
+		apex, err := fqdn(name)
+		if err != nil || len(nameservers) == 0 {
+			continue
+		}
+		primary, _ := fqdn(nameservers[0])
+		mbox, _ := fqdn(hostmaster)
+		z := &zone{
+			soa: dnsmessage.Resource{
+				Header: header(apex, dnsmessage.TypeSOA),
+				Body: &dnsmessage.SOAResource{
+					NS:      primary,
+					MBox:    mbox,
+					Serial:  serial,
+					Refresh: 3600,
+					Retry:   600,
+					Expire:  604800,
+					MinTTL:  TTL,
+				},
+			},
+			records: make(map[string][]dnsmessage.Resource),
+		}
+		key := normalize(name)
+		z.add(key, z.soa)
+		for _, ns := range nameservers {
+			if target, err := fqdn(ns); err == nil {
+				z.add(key, dnsmessage.Resource{Header: header(apex, dnsmessage.TypeNS), Body: &dnsmessage.NSResource{NS: target}})
+			}
+		}
+		z.add(key, dnsmessage.Resource{Header: header(apex, typeCAA), Body: caa(domainrecords.CAAIssuer)})
+		for _, record := range records {
+			if resource, ok := resourceOf(record); ok {
+				z.add(normalize(record.Name), resource)
+			}
+		}
+		t.zones[key] = z
+	}
+	return t
+}
+
+func (z *zone) add(name string, resource dnsmessage.Resource) {
+	z.records[name] = append(z.records[name], resource)
+}
+
+// Serial returns the serial of the zones' SOA records
+func (t *Table) Serial() uint32 {
+	return t.serial
+}
+
+// Answer returns the response to a DNS query, or nil when the query cannot
+// be parsed at all. Responses over maxSize bytes are truncated. A nil table,
+// before the records are first loaded, answers every query with SERVFAIL.
+func (t *Table) Answer(query []byte, maxSize int) []byte {
+	var parser dnsmessage.Parser
+	h, err := parser.Start(query)
+	if err != nil || h.Response {
+		return nil
+	}
+	response := dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:               h.ID,
+			Response:         true,
+			OpCode:           h.OpCode,
+			RecursionDesired: h.RecursionDesired,
+		},
+	}
+
+	q, err := parser.Question()
+	switch {
+	case err != nil:
+		response.RCode = dnsmessage.RCodeFormatError
+		return pack(response, maxSize)
+	case h.OpCode != 0:
+		response.RCode = dnsmessage.RCodeNotImplemented
+		return pack(response, maxSize)
+	}
+	response.Questions = []dnsmessage.Question{q}
+	if t == nil {
+		response.RCode = dnsmessage.RCodeServerFailure
+		return pack(response, maxSize)
+	}
+	if q.Class != dnsmessage.ClassINET && q.Class != dnsmessage.ClassANY {
+		response.RCode = dnsmessage.RCodeRefused
+		return pack(response, maxSize)
+	}
+
+	name := normalize(q.Name.String())
+	z := t.closest(name)
+	if z == nil {
+		response.RCode = dnsmessage.RCodeRefused
+		return pack(response, maxSize)
+	}
+	response.Authoritative = true
+
+	records := z.records[name]
+	if len(records) == 0 {
+		// A name with records below it exists even without records of its own
+		if !z.hasDescendant(name) {
+			response.RCode = dnsmessage.RCodeNameError
+		}
+		response.Authorities = []dnsmessage.Resource{z.soa}
+		return pack(response, maxSize)
+	}
+
+	for _, r := range records {
+		if r.Header.Type == dnsmessage.TypeCNAME && q.Type != dnsmessage.TypeCNAME {
+			response.Answers = []dnsmessage.Resource{answer(r, q.Name)}
+			return pack(response, maxSize)
+		}
+		if q.Type == dnsmessage.TypeALL || r.Header.Type == q.Type {
+			response.Answers = append(response.Answers, answer(r, q.Name))
+		}
+	}
+	if len(response.Answers) == 0 {
+		response.Authorities = []dnsmessage.Resource{z.soa}
+	}
+	return pack(response, maxSize)
+}
+
+// closest returns the longest zone containing name
+func (t *Table) closest(name string) *zone {
+	for {
+		if z, ok := t.zones[name]; ok {
+			return z
+		}
+		_, parent, ok := strings.Cut(name, ".")
+		if !ok {
+			return nil
+		}
+		name = parent
+	}
+}
+
+func (z *zone) hasDescendant(name string) bool {
+	for record := range z.records {
+		if strings.HasSuffix(record, "."+name) {
+			return true
+		}
+	}
+	return false
+}
+
+// answer returns r named as asked, keeping the case of the question
+func answer(r dnsmessage.Resource, name dnsmessage.Name) dnsmessage.Resource {
+	r.Header.Name = name
+	return r
+}
+
+func pack(m dnsmessage.Message, maxSize int) []byte {
+	data, err := m.Pack()
+	if err != nil {
+		m.Answers, m.Authorities = nil, nil
+		m.RCode = dnsmessage.RCodeServerFailure
+		data, _ = m.Pack()
+		return data
+	}
+	if len(data) > maxSize {
+		m.Answers, m.Authorities = nil, nil
+		m.Truncated = true
+		data, _ = m.Pack()
+	}
+	return data
+}
+
+func resourceOf(record domainverify.Record) (dnsmessage.Resource, bool) {
+	name, err := fqdn(record.Name)
+	if err != nil {
+		return dnsmessage.Resource{}, false
+	}
+	switch record.Type {
+	case "A", "AAAA":
+		addr, err := netip.ParseAddr(record.Value)
+		if err != nil {
+			return dnsmessage.Resource{}, false
+		}
+		if addr.Is4() && record.Type == "A" {
+			return dnsmessage.Resource{Header: header(name, dnsmessage.TypeA), Body: &dnsmessage.AResource{A: addr.As4()}}, true
+		}
+		if addr.Is6() && record.Type == "AAAA" {
+			return dnsmessage.Resource{Header: header(name, dnsmessage.TypeAAAA), Body: &dnsmessage.AAAAResource{AAAA: addr.As16()}}, true
+		}
+	case "CNAME":
+		target, err := fqdn(record.Value)
+		if err != nil {
+			return dnsmessage.Resource{}, false
+		}
+		return dnsmessage.Resource{Header: header(name, dnsmessage.TypeCNAME), Body: &dnsmessage.CNAMEResource{CNAME: target}}, true
+	case "TXT":
+		return dnsmessage.Resource{Header: header(name, dnsmessage.TypeTXT), Body: &dnsmessage.TXTResource{TXT: []string{record.Value}}}, true
+	}
+	return dnsmessage.Resource{}, false
+}
+
+func header(name dnsmessage.Name, recordType dnsmessage.Type) dnsmessage.ResourceHeader {
+	return dnsmessage.ResourceHeader{Name: name, Type: recordType, Class: dnsmessage.ClassINET, TTL: TTL}
+}
+
+// caa returns the body of a CAA record allowing issuer to issue certificates
+func caa(issuer string) *dnsmessage.UnknownResource {
+	data := []byte{0, byte(len("issue"))}
+	data = append(data, "issue"...)
+	data = append(data, issuer...)
+	return &dnsmessage.UnknownResource{Type: typeCAA, Data: data}
+}
+
+func fqdn(name string) (dnsmessage.Name, error) {
+	return dnsmessage.NewName(normalize(name) + ".")
+}
+
+func normalize(name string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+}
+
+// Server serves the records of a source over UDP and TCP
+type Server struct {
+	source      Source
+	nameservers []string
+	hostmaster  string
+
+	table   atomic.Pointer[Table]
+	records map[string][]domainverify.Record
+}
+
+// New creates a server answering for the zones of source as nameservers,
+// with hostmaster as the mailbox of the zones' SOA records
+func New(source Source, nameservers []string, hostmaster string) *Server {
+	return &Server{source: source, nameservers: nameservers, hostmaster: hostmaster}
+}
+
+// Run serves DNS on addr until ctx is done
+func (s *Server) Run(ctx context.Context, addr string) error {
+	udp, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	tcp, err := net.Listen("tcp", addr)
+	if err != nil {
+		udp.Close()
+		return err
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		s.serveUDP(udp)
+	}()
+	go func() {
+		defer wg.Done()
+		s.serveTCP(tcp)
+	}()
+
+	s.reload(ctx)
+	ticker := time.NewTicker(reloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			udp.Close()
+			tcp.Close()
+			wg.Wait()
+			return nil
+		case <-ticker.C:
+			s.reload(ctx)
+		}
+	}
+}
+
+// reload reads the records again. The serial only changes with the records,
+// so secondaries polling the SOA do not transfer unchanged zones.
+func (s *Server) reload(ctx context.Context) {
+	records, err := s.source(ctx)
+	if err != nil {
+		slog.Error("failed to load DNS zones", "error", err)
+		return
+	}
+	current := s.table.Load()
+	if current != nil && reflect.DeepEqual(records, s.records) {
+		return
+	}
+
+	serial := uint32(time.Now().Unix())
+	if current != nil && serial <= current.serial {
+		serial = current.serial + 1
+	}
+	s.records = maps.Clone(records)
+	s.table.Store(NewTable(s.nameservers, s.hostmaster, serial, records))
+	slog.Info("loaded DNS zones", "zones", len(records), "serial", serial)
+}
+
+func (s *Server) serveUDP(conn net.PacketConn) {
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			continue
+		}
+		if response := s.table.Load().Answer(buf[:n], maxUDPSize); response != nil {
+			_, _ = conn.WriteTo(response, addr)
+		}
+	}
+}
+
+func (s *Server) serveTCP(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			continue
+		}
+		go s.handleTCP(conn)
+	}
+}
+
+// handleTCP answers the length-prefixed queries of a connection until the
+// client closes it or idles
+func (s *Server) handleTCP(conn net.Conn) {
+	defer conn.Close()
+	for {
+		_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+		var length uint16
+		if err := binary.Read(conn, binary.BigEndian, &length); err != nil {
+			return
+		}
+		query := make([]byte, length)
+		if _, err := io.ReadFull(conn, query); err != nil {
+			return
+		}
+		response := s.table.Load().Answer(query, 65535)
+		if response == nil {
+			return
+		}
+		out := binary.BigEndian.AppendUint16(nil, uint16(len(response)))
+		if _, err := conn.Write(append(out, response...)); err != nil {
+			return
+		}
+	}
+}
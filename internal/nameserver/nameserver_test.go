@@ -0,0 +1,108 @@
+package nameserver
+
+import (
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/domainverify"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func testTable() *Table {
+	return NewTable([]string{"ns1.nexo.build", "ns2.nexo.build"}, "hostmaster.cloud.nexo.build", 42, map[string][]domainverify.Record{
+		"apps.example.com": {
+			{Type: "A", Name: "apps.example.com", Value: "203.0.113.10"},
+			{Type: "CNAME", Name: "web.apps.example.com", Value: "web.nexo.build"},
+			{Type: "CNAME", Name: "api.eu.apps.example.com", Value: "api.nexo.build"},
+		},
+	})
+}
+
+func query(t *testing.T, table *Table, name string, qtype dnsmessage.Type) dnsmessage.Message {
+	t.Helper()
+	q := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: 7, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: dnsmessage.MustNewName(name), Type: qtype, Class: dnsmessage.ClassINET}},
+	}
+	data, err := q.Pack()
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	var response dnsmessage.Message
+	if err := response.Unpack(table.Answer(data, maxUDPSize)); err != nil {
+		t.Fatalf("Unpack: %v", err)
+	}
+	if response.ID != 7 || !response.Response {
+		t.Fatalf("unexpected header %+v", response.Header)
+	}
+	return response
+}
+
+func TestAnswer(t *testing.T) {
+	table := testTable()
+
+	response := query(t, table, "apps.example.com.", dnsmessage.TypeA)
+	if !response.Authoritative || len(response.Answers) != 1 {
+		t.Fatalf("expected one authoritative answer, got %+v", response)
+	}
+	if a := response.Answers[0].Body.(*dnsmessage.AResource); a.A != [4]byte{203, 0, 113, 10} {
+		t.Errorf("unexpected address %v", a.A)
+	}
+
+	response = query(t, table, "WEB.apps.example.com.", dnsmessage.TypeA)
+	if len(response.Answers) != 1 || response.Answers[0].Header.Type != dnsmessage.TypeCNAME {
+		t.Fatalf("expected the CNAME, got %+v", response.Answers)
+	}
+	if response.Answers[0].Header.Name.String() != "WEB.apps.example.com." {
+		t.Errorf("expected the question's case to be kept, got %s", response.Answers[0].Header.Name)
+	}
+
+	response = query(t, table, "apps.example.com.", dnsmessage.TypeNS)
+	if len(response.Answers) != 2 {
+		t.Errorf("expected both nameservers, got %+v", response.Answers)
+	}
+
+	response = query(t, table, "apps.example.com.", dnsmessage.TypeSOA)
+	if soa, ok := response.Answers[0].Body.(*dnsmessage.SOAResource); !ok || soa.Serial != 42 {
+		t.Errorf("unexpected SOA %+v", response.Answers)
+	}
+
+	response = query(t, table, "web.apps.example.com.", typeCAA)
+	if len(response.Answers) != 1 {
+		t.Errorf("expected the CNAME for the CAA query, got %+v", response.Answers)
+	}
+	response = query(t, table, "apps.example.com.", typeCAA)
+	if len(response.Answers) != 1 || response.Answers[0].Header.Type != typeCAA {
+		t.Errorf("expected the apex CAA record, got %+v", response.Answers)
+	}
+}
+
+func TestAnswerNegative(t *testing.T) {
+	table := testTable()
+
+	response := query(t, table, "missing.apps.example.com.", dnsmessage.TypeA)
+	if response.RCode != dnsmessage.RCodeNameError || len(response.Authorities) != 1 {
+		t.Errorf("expected NXDOMAIN with the SOA, got %+v", response)
+	}
+
+	// eu.apps.example.com has no records but a name below it does
+	response = query(t, table, "eu.apps.example.com.", dnsmessage.TypeA)
+	if response.RCode != dnsmessage.RCodeSuccess || len(response.Answers) != 0 {
+		t.Errorf("expected NODATA for an empty non-terminal, got %+v", response)
+	}
+
+	response = query(t, table, "apps.example.com.", dnsmessage.TypeAAAA)
+	if response.RCode != dnsmessage.RCodeSuccess || len(response.Answers) != 0 || len(response.Authorities) != 1 {
+		t.Errorf("expected NODATA with the SOA, got %+v", response)
+	}
+
+	response = query(t, table, "example.com.", dnsmessage.TypeA)
+	if response.RCode != dnsmessage.RCodeRefused || response.Authoritative {
+		t.Errorf("expected a name outside the zones to be refused, got %+v", response)
+	}
+
+	var unloaded *Table
+	response = query(t, unloaded, "apps.example.com.", dnsmessage.TypeA)
+	if response.RCode != dnsmessage.RCodeServerFailure {
+		t.Errorf("expected SERVFAIL before the records are loaded, got %+v", response)
+	}
+}
@@ -0,0 +1,85 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EmailSender delivers email jobs from the outbox through an SMTP server
+type EmailSender struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewEmailSender creates an email sender. Credentials are optional, e.g.
+// for a local relay.
+func NewEmailSender(addr, from, username, password string) *EmailSender {
+	sender := &EmailSender{addr: addr, from: from}
+	if username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		sender.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return sender
+}
+
+// Send implements outbox.Sender
+func (s *EmailSender) Send(_ context.Context, destination string, payload []byte) error {
+	var n Notification
+	if err := json.Unmarshal(payload, &n); err != nil {
+		return fmt.Errorf("invalid notification payload: %w", err)
+	}
+
+	from, err := mail.ParseAddress(s.from)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+	to, err := mail.ParseAddress(destination)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %w", err)
+	}
+
+	if err := smtp.SendMail(s.addr, s.auth, from.Address, []string{to.Address}, EmailMessage(s.from, to.Address, n)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// EmailMessage renders a notification as a plain text email
+func EmailMessage(from, to string, n Notification) []byte {
+	subject := n.Message
+	if n.AppName != "" {
+		subject = fmt.Sprintf("[%s] %s", n.AppName, n.Message)
+	}
+	// Header values must stay on one line
+	subject = strings.NewReplacer("\r", " ", "\n", " ").Replace(subject)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(n.Message)
+	b.WriteString("\r\n\r\n")
+	fmt.Fprintf(&b, "Event: %s\r\n", n.Event)
+	keys := make([]string, 0, len(n.Details))
+	for key := range n.Details {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&b, "%s: %v\r\n", key, n.Details[key])
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,118 @@
+// Package notify alerts app owners about platform events. It subscribes to
+// the event bus and posts every event carrying a message, such as failing
+// certificates or expired mirrors, to the configured webhook.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/events"
+	"github.com/google/uuid"
+)
+
+// Notification is an alert about one of a user's apps
+type Notification struct {
+	UserID  uuid.UUID      `json:"user_id"`
+	AppID   uuid.UUID      `json:"app_id,omitempty"`
+	AppName string         `json:"app_name,omitempty"`
+	Event   string         `json:"event"`
+	Message string         `json:"message"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// Notifier delivers notifications
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// Handler returns an event bus handler delivering alert events, those
+// carrying a message, through notifier
+func Handler(notifier Notifier) events.Handler {
+	return func(ctx context.Context, e events.Event) error {
+		if e.Message == "" {
+			return nil
+		}
+		return notifier.Notify(ctx, FromEvent(e))
+	}
+}
+
+// FromEvent converts an event to the notification sent to the app owner
+func FromEvent(e events.Event) Notification {
+	return Notification{
+		UserID:  e.UserID,
+		AppID:   e.AppID,
+		AppName: e.AppName,
+		Event:   e.Type,
+		Message: e.Message,
+		Details: e.Payload,
+	}
+}
+
+// Multi delivers to every notifier, returning the first error
+type Multi []Notifier
+
+// Notify implements Notifier
+func (m Multi) Notify(ctx context.Context, n Notification) error {
+	var firstErr error
+	for _, notifier := range m {
+		if err := notifier.Notify(ctx, n); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// WebhookNotifier posts notifications as JSON. The text field makes the
+// payload readable by Slack-compatible incoming webhooks.
+type WebhookNotifier struct {
+	url  string
+	http *http.Client
+}
+
+// NewWebhookNotifier creates a webhook notifier
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:  url,
+		http: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Notify implements Notifier
+func (w *WebhookNotifier) Notify(ctx context.Context, n Notification) error {
+	text := n.Message
+	if n.AppName != "" {
+		text = fmt.Sprintf("[%s] %s", n.AppName, n.Message)
+	}
+
+	body, err := json.Marshal(struct {
+		Text string `json:"text"`
+		Notification
+	}{text, n})
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 300 {
+		slog.Warn("notification webhook rejected notification", "status", resp.StatusCode, "event", n.Event)
+		return fmt.Errorf("notification webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/events"
+	"github.com/google/uuid"
+)
+
+type recorder struct {
+	got []Notification
+	err error
+}
+
+func (r *recorder) Notify(_ context.Context, n Notification) error {
+	r.got = append(r.got, n)
+	return r.err
+}
+
+func TestMulti(t *testing.T) {
+	failing := &recorder{err: errors.New("boom")}
+	ok := &recorder{}
+
+	err := Multi{failing, ok}.Notify(context.Background(), Notification{Event: "test"})
+	if err == nil {
+		t.Error("expected the first error to be returned")
+	}
+	if len(ok.got) != 1 {
+		t.Error("expected every notifier to be called despite errors")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	r := &recorder{}
+	handler := Handler(r)
+	ctx := context.Background()
+
+	if err := handler(ctx, events.Event{Type: "deployment.created"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(r.got) != 0 {
+		t.Error("expected events without a message not to notify")
+	}
+
+	userID := uuid.New()
+	err := handler(ctx, events.Event{
+		Type:    "mirror.expired",
+		UserID:  userID,
+		AppName: "web",
+		Message: "stopped mirroring",
+		Payload: map[string]any{"percent": 10},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(r.got) != 1 || r.got[0].Event != "mirror.expired" || r.got[0].UserID != userID || r.got[0].Details["percent"] != 10 {
+		t.Errorf("unexpected notifications %+v", r.got)
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var payload map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	err := NewWebhookNotifier(server.URL).Notify(context.Background(), Notification{
+		UserID:  uuid.New(),
+		AppName: "web",
+		Event:   "certificate.expiring",
+		Message: "certificate expires in 5 days",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if payload["text"] != "[web] certificate expires in 5 days" || payload["event"] != "certificate.expiring" {
+		t.Errorf("unexpected payload %v", payload)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	if err := NewWebhookNotifier(failing.URL).Notify(context.Background(), Notification{Event: "test"}); err == nil {
+		t.Error("expected an error for a failing webhook")
+	}
+}
+
+func TestEmailMessage(t *testing.T) {
+	msg := string(EmailMessage("nexo cloud <noreply@nexo.build>", "owner@example.com", Notification{
+		AppName: "web",
+		Event:   "certificate.expiring",
+		Message: "certificate expires in 5 days\r\nBcc: victim@example.com",
+		Details: map[string]any{"domain": "www.example.com"},
+	}))
+
+	if !strings.Contains(msg, "To: owner@example.com\r\n") {
+		t.Errorf("expected recipient header, got %q", msg)
+	}
+	headers, body, _ := strings.Cut(msg, "\r\n\r\n")
+	if strings.Contains(headers, "\r\nBcc:") {
+		t.Errorf("expected the message not to inject headers, got %q", headers)
+	}
+	if !strings.Contains(body, "domain: www.example.com") || !strings.Contains(body, "Event: certificate.expiring") {
+		t.Errorf("expected event details in the body, got %q", body)
+	}
+}
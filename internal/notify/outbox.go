@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/outbox"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/usersettings"
+	"github.com/google/uuid"
+)
+
+// Queue is a Notifier persisting deliveries to the outbox instead of sending
+// them inline: a webhook post when webhookURL is set and, with email
+// enabled, an email to the app owner unless they turned notification
+// emails off in their settings
+type Queue struct {
+	queries    *db.Queries
+	webhookURL string
+	email      bool
+}
+
+// NewQueue creates a notifier enqueuing to the outbox
+func NewQueue(queries *db.Queries, webhookURL string, email bool) *Queue {
+	return &Queue{queries: queries, webhookURL: webhookURL, email: email}
+}
+
+// Notify implements Notifier
+func (q *Queue) Notify(ctx context.Context, n Notification) error {
+	if q.webhookURL != "" {
+		if err := outbox.Enqueue(ctx, q.queries, outbox.KindWebhook, q.webhookURL, n); err != nil {
+			return err
+		}
+	}
+
+	if q.email && n.UserID != uuid.Nil {
+		user, err := q.queries.GetUserByID(ctx, n.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to get notification recipient: %w", err)
+		}
+		settings, err := usersettings.Get(ctx, q.queries, n.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to get notification settings: %w", err)
+		}
+		if user.Email != "" && settings.EmailNotifications {
+			if err := outbox.Enqueue(ctx, q.queries, outbox.KindEmail, user.Email, n); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WebhookSender delivers webhook jobs from the outbox
+type WebhookSender struct{}
+
+// Send implements outbox.Sender
+func (WebhookSender) Send(ctx context.Context, destination string, payload []byte) error {
+	var n Notification
+	if err := json.Unmarshal(payload, &n); err != nil {
+		return fmt.Errorf("invalid notification payload: %w", err)
+	}
+	return NewWebhookNotifier(destination).Notify(ctx, n)
+}
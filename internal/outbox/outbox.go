@@ -0,0 +1,159 @@
+// Package outbox delivers outgoing webhooks and emails reliably. Jobs are
+// persisted before delivery is attempted, retried with exponential backoff
+// and marked dead after too many failures, so a crash or an unreachable
+// endpoint never silently drops an alert. Dead jobs can be requeued through
+// the admin API.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/errtrack"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Job kinds
+const (
+	KindWebhook = "webhook"
+	KindEmail   = "email"
+)
+
+// Job statuses
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusDead      = "dead"
+)
+
+const (
+	// Lease is how long a claimed job is reserved for the worker delivering
+	// it. A worker that dies mid-delivery leaves the job to be claimed again
+	// once the lease runs out.
+	Lease = 5 * time.Minute
+	// Retention is how long delivered jobs are kept
+	Retention = 7 * 24 * time.Hour
+
+	batchSize  = 20
+	minBackoff = 30 * time.Second
+	maxBackoff = time.Hour
+)
+
+// Sender delivers a job to its destination
+type Sender interface {
+	Send(ctx context.Context, destination string, payload []byte) error
+}
+
+// Enqueue persists a job for delivery. With queries bound to a transaction
+// the job is only delivered if the transaction commits.
+func Enqueue(ctx context.Context, queries *db.Queries, kind, destination string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s payload: %w", kind, err)
+	}
+
+	_, err = queries.CreateOutboxJob(ctx, db.CreateOutboxJobParams{
+		Kind:        kind,
+		Destination: destination,
+		Payload:     data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enqueue %s: %w", kind, err)
+	}
+	return nil
+}
+
+// Backoff returns how long to wait before the next attempt after the given
+// number of failed attempts: 30s, 1m, 2m... capped at an hour
+func Backoff(attempts int32) time.Duration {
+	backoff := minBackoff
+	for i := int32(1); i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxBackoff)
+}
+
+// Worker delivers due jobs
+type Worker struct {
+	queries   *db.Queries
+	senders   map[string]Sender
+	now       func() time.Time
+	lastPrune time.Time
+}
+
+// NewWorker creates a worker delivering jobs of each kind with its sender
+func NewWorker(queries *db.Queries, senders map[string]Sender) *Worker {
+	return &Worker{
+		queries: queries,
+		senders: senders,
+		now:     time.Now,
+	}
+}
+
+// Process claims the jobs that are due and attempts to deliver each once
+func (w *Worker) Process(ctx context.Context) error {
+	now := w.now()
+	jobs, err := w.queries.ClaimOutboxJobs(ctx, db.ClaimOutboxJobsParams{
+		LeaseUntil: now.Add(Lease),
+		Now:        now,
+		MaxJobs:    batchSize,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to claim jobs: %w", err)
+	}
+
+	for _, job := range jobs {
+		w.deliver(ctx, job)
+	}
+
+	if now.Sub(w.lastPrune) >= time.Hour {
+		w.lastPrune = now
+		before := pgtype.Timestamptz{Time: now.Add(-Retention), Valid: true}
+		if _, err := w.queries.DeleteDeliveredOutboxJobs(ctx, before); err != nil {
+			slog.Error("failed to prune outbox", "error", err)
+		}
+	}
+	return nil
+}
+
+func (w *Worker) deliver(ctx context.Context, job db.Outbox) {
+	err := w.send(ctx, job)
+	if err == nil {
+		if err := w.queries.MarkOutboxDelivered(ctx, job.ID); err != nil {
+			slog.Error("failed to mark outbox job delivered", "job", job.ID, "error", err)
+		}
+		return
+	}
+
+	message := err.Error()
+	if job.Attempts >= job.MaxAttempts {
+		slog.Error("outbox job dead after repeated failures", "job", job.ID, "kind", job.Kind, "attempts", job.Attempts, "error", err)
+		errtrack.CaptureError(err, map[string]string{"outbox_kind": job.Kind})
+		if err := w.queries.MarkOutboxDead(ctx, db.MarkOutboxDeadParams{ID: job.ID, LastError: &message}); err != nil {
+			slog.Error("failed to mark outbox job dead", "job", job.ID, "error", err)
+		}
+		return
+	}
+
+	slog.Warn("outbox delivery failed, retrying", "job", job.ID, "kind", job.Kind, "attempts", job.Attempts, "error", err)
+	err = w.queries.RetryOutboxJob(ctx, db.RetryOutboxJobParams{
+		ID:            job.ID,
+		NextAttemptAt: w.now().Add(Backoff(job.Attempts)),
+		LastError:     &message,
+	})
+	if err != nil {
+		slog.Error("failed to reschedule outbox job", "job", job.ID, "error", err)
+	}
+}
+
+func (w *Worker) send(ctx context.Context, job db.Outbox) error {
+	sender, ok := w.senders[job.Kind]
+	if !ok {
+		return fmt.Errorf("no sender for %s jobs", job.Kind)
+	}
+	return sender.Send(ctx, job.Destination, job.Payload)
+}
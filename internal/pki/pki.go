@@ -0,0 +1,256 @@
+// Package pki issues client certificates from the platform CA for apps that
+// enforce mutual TLS at the ingress, and publishes revocation lists.
+package pki
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Client certificate lifetimes
+const (
+	DefaultValidity = 90 * 24 * time.Hour
+	MaxValidity     = 365 * 24 * time.Hour
+)
+
+// ErrNotConfigured is returned when no platform CA is configured
+var ErrNotConfigured = errors.New("platform CA is not configured")
+
+// CA is the platform certificate authority
+type CA struct {
+	Cert    *x509.Certificate
+	CertPEM []byte
+	key     crypto.Signer
+}
+
+// IssuedCert is a newly issued client certificate. The private key is only
+// returned once and never stored.
+type IssuedCert struct {
+	CertPEM     []byte
+	KeyPEM      []byte
+	Serial      string
+	Fingerprint string
+	NotAfter    time.Time
+}
+
+// RevokedCert identifies a revoked certificate in a CRL
+type RevokedCert struct {
+	Serial    string
+	RevokedAt time.Time
+}
+
+// LoadCAFiles loads the CA from PEM files. Empty paths mean no CA.
+func LoadCAFiles(certFile, keyFile string) (*CA, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, ErrNotConfigured
+	}
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+	keyPEM, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA key: %w", err)
+	}
+	return LoadCA(certPEM, keyPEM)
+}
+
+// LoadCA parses a PEM encoded CA certificate and its PKCS#8, EC or PKCS#1 key
+func LoadCA(certPEM, keyPEM []byte) (*CA, error) {
+	certBlock, _ := pem.Decode(certPEM)
+	if certBlock == nil {
+		return nil, errors.New("invalid CA certificate PEM")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CA certificate: %w", err)
+	}
+	if !cert.IsCA {
+		return nil, errors.New("certificate is not a CA")
+	}
+
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		return nil, errors.New("invalid CA key PEM")
+	}
+	key, err := ParseKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CA{Cert: cert, CertPEM: certPEM, key: key}, nil
+}
+
+// ParseKey parses a DER encoded PKCS#8, EC or PKCS#1 private key
+func ParseKey(der []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("unsupported key format")
+}
+
+// NewCA creates a self-signed CA, e.g. for development and tests
+func NewCA(commonName string, validity time.Duration) (*CA, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate CA key: %w", err)
+	}
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(validity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CA{
+		Cert:    cert,
+		CertPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		key:     key,
+	}, nil
+}
+
+// IssueClientCert issues a client authentication certificate
+func (ca *CA) IssueClientCert(commonName, organization string, validity time.Duration) (*IssuedCert, error) {
+	if validity <= 0 {
+		validity = DefaultValidity
+	}
+	if validity > MaxValidity {
+		return nil, fmt.Errorf("certificates may be valid for at most %d days", int(MaxValidity.Hours()/24))
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	notAfter := now.Add(validity)
+	if notAfter.After(ca.Cert.NotAfter) {
+		notAfter = ca.Cert.NotAfter
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{organization}},
+		NotBefore:    now.Add(-time.Minute),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.Cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode key: %w", err)
+	}
+
+	fingerprint := sha256.Sum256(der)
+	return &IssuedCert{
+		CertPEM:     pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		KeyPEM:      pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+		Serial:      SerialString(serial),
+		Fingerprint: hex.EncodeToString(fingerprint[:]),
+		NotAfter:    notAfter,
+	}, nil
+}
+
+// CRL returns a PEM encoded revocation list of the given certificates
+func (ca *CA) CRL(revoked []RevokedCert, number int64, now time.Time) ([]byte, error) {
+	entries := make([]x509.RevocationListEntry, 0, len(revoked))
+	for _, r := range revoked {
+		serial, ok := new(big.Int).SetString(r.Serial, 16)
+		if !ok {
+			return nil, fmt.Errorf("invalid serial %q", r.Serial)
+		}
+		entries = append(entries, x509.RevocationListEntry{SerialNumber: serial, RevocationTime: r.RevokedAt})
+	}
+
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(number),
+		ThisUpdate:                now,
+		NextUpdate:                now.Add(7 * 24 * time.Hour),
+		RevokedCertificateEntries: entries,
+	}, ca.Cert, ca.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create crl: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}), nil
+}
+
+// ParseForwardedCert parses the client certificate Traefik's PassTLSClientCert
+// middleware forwards: the URL-escaped base64 DER of each certificate in the
+// chain, comma separated, leaf first
+func ParseForwardedCert(value string) (*x509.Certificate, error) {
+	unescaped, err := url.QueryUnescape(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid forwarded certificate: %w", err)
+	}
+	leaf := strings.TrimSpace(strings.Split(unescaped, ",")[0])
+	if leaf == "" {
+		return nil, errors.New("no client certificate")
+	}
+	der, err := base64.StdEncoding.DecodeString(leaf)
+	if err != nil {
+		return nil, fmt.Errorf("invalid forwarded certificate: %w", err)
+	}
+	return x509.ParseCertificate(der)
+}
+
+// SerialString formats a serial number the way it is stored
+func SerialString(serial *big.Int) string {
+	return fmt.Sprintf("%x", serial)
+}
+
+func randomSerial() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial: %w", err)
+	}
+	return serial, nil
+}
@@ -0,0 +1,109 @@
+package pki
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestIssueClientCert(t *testing.T) {
+	ca, err := NewCA("Nexo Test CA", 2*MaxValidity)
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+
+	issued, err := ca.IssueClientCert("web:ci-runner", "web", 0)
+	if err != nil {
+		t.Fatalf("failed to issue certificate: %v", err)
+	}
+
+	block, _ := pem.Decode(issued.CertPEM)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse issued certificate: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Cert)
+	_, err = cert.Verify(x509.VerifyOptions{Roots: pool, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+	if err != nil {
+		t.Errorf("expected certificate to verify for client auth: %v", err)
+	}
+	if SerialString(cert.SerialNumber) != issued.Serial {
+		t.Errorf("expected serial %s, got %s", issued.Serial, SerialString(cert.SerialNumber))
+	}
+	if got := cert.NotAfter.Sub(time.Now()); got < DefaultValidity-time.Hour || got > DefaultValidity {
+		t.Errorf("expected default validity, got %v", got)
+	}
+
+	if _, err := ca.IssueClientCert("web:too-long", "web", MaxValidity+time.Hour); err == nil {
+		t.Error("expected validity above the maximum to be rejected")
+	}
+}
+
+func TestLoadCA(t *testing.T) {
+	ca, _ := NewCA("Nexo Test CA", time.Hour)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(ca.key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	loaded, err := LoadCA(ca.CertPEM, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}))
+	if err != nil {
+		t.Fatalf("failed to load CA: %v", err)
+	}
+	if !loaded.Cert.Equal(ca.Cert) {
+		t.Error("expected loaded certificate to match")
+	}
+
+	if _, err := LoadCA([]byte("garbage"), nil); err == nil {
+		t.Error("expected invalid PEM to be rejected")
+	}
+	if _, err := LoadCAFiles("", ""); err != ErrNotConfigured {
+		t.Errorf("expected ErrNotConfigured, got %v", err)
+	}
+}
+
+func TestCRL(t *testing.T) {
+	ca, _ := NewCA("Nexo Test CA", time.Hour)
+	issued, _ := ca.IssueClientCert("web:laptop", "web", time.Hour)
+
+	crlPEM, err := ca.CRL([]RevokedCert{{Serial: issued.Serial, RevokedAt: time.Now()}}, 1, time.Now())
+	if err != nil {
+		t.Fatalf("failed to create crl: %v", err)
+	}
+
+	block, _ := pem.Decode(crlPEM)
+	crl, err := x509.ParseRevocationList(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse crl: %v", err)
+	}
+	if err := crl.CheckSignatureFrom(ca.Cert); err != nil {
+		t.Errorf("expected crl to be signed by the CA: %v", err)
+	}
+	if len(crl.RevokedCertificateEntries) != 1 || SerialString(crl.RevokedCertificateEntries[0].SerialNumber) != issued.Serial {
+		t.Errorf("expected the revoked serial, got %+v", crl.RevokedCertificateEntries)
+	}
+}
+
+func TestParseForwardedCert(t *testing.T) {
+	ca, _ := NewCA("Nexo Test CA", time.Hour)
+	issued, _ := ca.IssueClientCert("web:laptop", "web", time.Hour)
+	block, _ := pem.Decode(issued.CertPEM)
+
+	header := url.QueryEscape(base64.StdEncoding.EncodeToString(block.Bytes)) + "," + url.QueryEscape(base64.StdEncoding.EncodeToString(ca.Cert.Raw))
+	cert, err := ParseForwardedCert(header)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if SerialString(cert.SerialNumber) != issued.Serial {
+		t.Errorf("expected the leaf certificate, got serial %s", SerialString(cert.SerialNumber))
+	}
+
+	if _, err := ParseForwardedCert(""); err == nil {
+		t.Error("expected a missing certificate to be rejected")
+	}
+}
@@ -0,0 +1,57 @@
+// Package plans defines the limits and prices attached to each app size.
+package plans
+
+import "slices"
+
+// Plan describes the limits of an app size.
+type Plan struct {
+	Name        string
+	MaxReplicas int32
+	// DedicatedNodes allows pinning apps to dedicated node pools.
+	DedicatedNodes bool
+	// Burst allows temporarily doubling web replicas under load.
+	Burst bool
+	// CPUMillis and MemoryBytes are the resources included per replica.
+	CPUMillis   int64
+	MemoryBytes int64
+	// MonthlyPriceCents is the price of a replica per month in USD cents.
+	MonthlyPriceCents int64
+}
+
+// Default is the plan used for apps without a known size.
+const Default = "starter"
+
+// Sizes lists the app sizes from smallest to largest.
+var Sizes = []string{"starter", "pro", "enterprise"}
+
+var plans = map[string]Plan{
+	"starter":    {Name: "starter", MaxReplicas: 2, CPUMillis: 250, MemoryBytes: 512 << 20, MonthlyPriceCents: 700},
+	"pro":        {Name: "pro", MaxReplicas: 5, Burst: true, CPUMillis: 1000, MemoryBytes: 2 << 30, MonthlyPriceCents: 2500},
+	"enterprise": {Name: "enterprise", MaxReplicas: 10, DedicatedNodes: true, Burst: true, CPUMillis: 4000, MemoryBytes: 8 << 30, MonthlyPriceCents: 10000},
+}
+
+// ForSize returns the plan for an app size, falling back to the default plan.
+func ForSize(size string) Plan {
+	if plan, ok := plans[size]; ok {
+		return plan
+	}
+	return plans[Default]
+}
+
+// Smaller returns the plan of the next smaller size, false for the smallest.
+func Smaller(size string) (Plan, bool) {
+	i := slices.Index(Sizes, ForSize(size).Name)
+	if i <= 0 {
+		return Plan{}, false
+	}
+	return plans[Sizes[i-1]], true
+}
+
+// Larger returns the plan of the next larger size, false for the largest.
+func Larger(size string) (Plan, bool) {
+	i := slices.Index(Sizes, ForSize(size).Name)
+	if i == len(Sizes)-1 {
+		return Plan{}, false
+	}
+	return plans[Sizes[i+1]], true
+}
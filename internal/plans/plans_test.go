@@ -0,0 +1,107 @@
+package plans
+
+import "testing"
+
+func TestForSize(t *testing.T) {
+	tests := []struct {
+		size        string
+		name        string
+		maxReplicas int32
+	}{
+		{"starter", "starter", 2},
+		{"pro", "pro", 5},
+		{"enterprise", "enterprise", 10},
+		{"", "starter", 2},
+		{"unknown", "starter", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.size, func(t *testing.T) {
+			plan := ForSize(tt.size)
+			if plan.Name != tt.name {
+				t.Errorf("expected plan %q, got %q", tt.name, plan.Name)
+			}
+			if plan.MaxReplicas != tt.maxReplicas {
+				t.Errorf("expected max replicas %d, got %d", tt.maxReplicas, plan.MaxReplicas)
+			}
+		})
+	}
+}
+
+func TestDedicatedNodes(t *testing.T) {
+	if !ForSize("enterprise").DedicatedNodes {
+		t.Error("expected enterprise plan to allow dedicated nodes")
+	}
+	if ForSize("pro").DedicatedNodes || ForSize("starter").DedicatedNodes {
+		t.Error("expected only enterprise plan to allow dedicated nodes")
+	}
+}
+
+func TestBurst(t *testing.T) {
+	if ForSize("starter").Burst {
+		t.Error("expected starter plan not to allow burst mode")
+	}
+	if !ForSize("pro").Burst || !ForSize("enterprise").Burst {
+		t.Error("expected pro and enterprise plans to allow burst mode")
+	}
+}
+
+func TestSmallerLarger(t *testing.T) {
+	if _, ok := Smaller("starter"); ok {
+		t.Error("expected no size below starter")
+	}
+	if plan, ok := Smaller("enterprise"); !ok || plan.Name != "pro" {
+		t.Errorf("expected pro below enterprise, got %q", plan.Name)
+	}
+	if plan, ok := Larger("unknown"); !ok || plan.Name != "pro" {
+		t.Errorf("expected pro above the default size, got %q", plan.Name)
+	}
+	if _, ok := Larger("enterprise"); ok {
+		t.Error("expected no size above enterprise")
+	}
+
+	for i := 1; i < len(Sizes); i++ {
+		smaller, larger := ForSize(Sizes[i-1]), ForSize(Sizes[i])
+		if larger.CPUMillis <= smaller.CPUMillis || larger.MemoryBytes <= smaller.MemoryBytes || larger.MonthlyPriceCents <= smaller.MonthlyPriceCents {
+			t.Errorf("expected %s to be larger than %s", larger.Name, smaller.Name)
+		}
+	}
+}
+
+func TestEstimateMonthly(t *testing.T) {
+	estimate, err := EstimateMonthly("enterprise", 3, []string{AddOnMTLS, AddOnDedicatedNodes}, IncludedEgressBytes+(10<<30)+1)
+	if err != nil {
+		t.Fatalf("EstimateMonthly: %v", err)
+	}
+	// 3 replicas, both add-ons and 11 GB over the included bandwidth
+	want := 3*ForSize("enterprise").MonthlyPriceCents + 5000 + 1000 + 11*EgressCentsPerGB
+	if estimate.TotalCents != want {
+		t.Errorf("expected %d cents, got %d (%+v)", want, estimate.TotalCents, estimate.Items)
+	}
+	if len(estimate.Items) != 4 || estimate.AddOns[0] != AddOnDedicatedNodes {
+		t.Errorf("expected items in billing order, got %+v", estimate)
+	}
+
+	if within, _ := EstimateMonthly("starter", 1, nil, IncludedEgressBytes); within.TotalCents != ForSize("starter").MonthlyPriceCents {
+		t.Errorf("expected included bandwidth to be free, got %+v", within)
+	}
+
+	invalid := []struct {
+		name     string
+		size     string
+		replicas int32
+		addOns   []string
+		egress   int64
+	}{
+		{"unknown size", "huge", 1, nil, 0},
+		{"too many replicas", "starter", 3, nil, 0},
+		{"unknown add-on", "pro", 1, []string{"gpu"}, 0},
+		{"add-on not on plan", "pro", 1, []string{AddOnDedicatedNodes}, 0},
+		{"negative bandwidth", "pro", 1, nil, -1},
+	}
+	for _, tt := range invalid {
+		if _, err := EstimateMonthly(tt.size, tt.replicas, tt.addOns, tt.egress); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package plans
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Add-ons billed monthly per app on top of its replicas.
+const (
+	AddOnDedicatedNodes = "dedicated_nodes"
+	AddOnMTLS           = "mtls"
+	AddOnStaticEgress   = "static_egress"
+)
+
+// AddOn is an optional feature of an app with a monthly price.
+type AddOn struct {
+	Name string
+	// MonthlyPriceCents is the price per app per month in USD cents.
+	MonthlyPriceCents int64
+	// Requires reports whether a plan offers the add-on; nil for every plan.
+	Requires func(Plan) bool
+}
+
+var addOns = map[string]AddOn{
+	AddOnDedicatedNodes: {Name: AddOnDedicatedNodes, MonthlyPriceCents: 5000, Requires: func(p Plan) bool { return p.DedicatedNodes }},
+	AddOnMTLS:           {Name: AddOnMTLS, MonthlyPriceCents: 1000},
+	AddOnStaticEgress:   {Name: AddOnStaticEgress, MonthlyPriceCents: 500},
+}
+
+// AddOns lists the add-on names in billing order.
+var AddOns = []string{AddOnDedicatedNodes, AddOnMTLS, AddOnStaticEgress}
+
+// Outbound bandwidth is free up to IncludedEgressBytes per app per month and
+// then billed per GB.
+const (
+	IncludedEgressBytes = 100 << 30
+	EgressCentsPerGB    = 9
+)
+
+// LineItem is one part of a monthly estimate.
+type LineItem struct {
+	Description string `json:"description"`
+	AmountCents int64  `json:"amount_cents"`
+}
+
+// Estimate is the expected monthly cost of an app in USD cents.
+type Estimate struct {
+	Size        string     `json:"size"`
+	Replicas    int32      `json:"replicas"`
+	AddOns      []string   `json:"add_ons"`
+	EgressBytes int64      `json:"egress_bytes"`
+	Items       []LineItem `json:"items"`
+	TotalCents  int64      `json:"total_cents"`
+}
+
+// EstimateMonthly prices replicas of size, the add-ons and the expected
+// outbound bandwidth per month with the billing prices. The size must be
+// known and offer the add-ons, and replicas must fit the size.
+func EstimateMonthly(size string, replicas int32, names []string, egressBytes int64) (Estimate, error) {
+	plan, ok := plans[size]
+	if !ok {
+		return Estimate{}, fmt.Errorf("size must be one of %v", Sizes)
+	}
+	if replicas < 0 || replicas > plan.MaxReplicas {
+		return Estimate{}, fmt.Errorf("replicas must be between 0 and %d on the %s plan", plan.MaxReplicas, plan.Name)
+	}
+	for _, name := range names {
+		if _, ok := addOns[name]; !ok {
+			return Estimate{}, fmt.Errorf("add-ons must be among %v", AddOns)
+		}
+	}
+	if egressBytes < 0 {
+		return Estimate{}, errors.New("expected bandwidth cannot be negative")
+	}
+
+	estimate := Estimate{
+		Size:        plan.Name,
+		Replicas:    replicas,
+		AddOns:      []string{},
+		EgressBytes: egressBytes,
+	}
+	estimate.add(fmt.Sprintf("%d %s replicas", replicas, plan.Name), int64(replicas)*plan.MonthlyPriceCents)
+
+	for _, name := range AddOns {
+		if !slices.Contains(names, name) {
+			continue
+		}
+		addOn := addOns[name]
+		if addOn.Requires != nil && !addOn.Requires(plan) {
+			return Estimate{}, fmt.Errorf("the %s add-on is not available on the %s plan", name, plan.Name)
+		}
+		estimate.AddOns = append(estimate.AddOns, name)
+		estimate.add(name+" add-on", addOn.MonthlyPriceCents)
+	}
+
+	if billed := egressBytes - IncludedEgressBytes; billed > 0 {
+		gb := int64(math.Ceil(float64(billed) / (1 << 30)))
+		estimate.add(fmt.Sprintf("%d GB outbound bandwidth over %d GB included", gb, IncludedEgressBytes>>30), gb*EgressCentsPerGB)
+	}
+	return estimate, nil
+}
+
+func (e *Estimate) add(description string, cents int64) {
+	e.Items = append(e.Items, LineItem{Description: description, AmountCents: cents})
+	e.TotalCents += cents
+}
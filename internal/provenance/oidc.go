@@ -0,0 +1,273 @@
+package provenance
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// GitHubIssuer issues the OIDC tokens of GitHub Actions runs
+const GitHubIssuer = "https://token.actions.githubusercontent.com"
+
+// ErrInvalidToken is returned for OIDC tokens that do not verify, such as
+// expired tokens or tokens minted for another audience
+var ErrInvalidToken = errors.New("invalid CI OIDC token")
+
+// ErrMismatch is returned when the CI headers claim something the OIDC
+// token does not
+var ErrMismatch = errors.New("CI headers do not match the OIDC token")
+
+// ErrKeysUnavailable is returned when the provider's signing keys cannot be
+// fetched
+var ErrKeysUnavailable = errors.New("failed to fetch the CI provider's signing keys")
+
+const (
+	// keysTTL is how long the signing keys of an issuer are cached
+	keysTTL = time.Hour
+	// minRefresh is how often keys are refetched for an unknown key ID,
+	// which providers rotating their keys sign with first
+	minRefresh = time.Minute
+)
+
+// Verifier verifies the OIDC tokens of CI runs
+type Verifier struct {
+	// Audience tokens must be minted for
+	Audience string
+	// Issuers maps each provider to the issuer of its tokens
+	Issuers map[string]string
+	http    *http.Client
+}
+
+// NewVerifier creates a verifier of GitHub Actions tokens and of GitLab CI
+// tokens of the GitLab instance at gitlabURL
+func NewVerifier(audience, gitlabURL string) *Verifier {
+	return &Verifier{
+		Audience: audience,
+		Issuers: map[string]string{
+			ProviderGitHub: GitHubIssuer,
+			ProviderGitLab: strings.TrimSuffix(gitlabURL, "/"),
+		},
+		http: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// keySet is the cached signing keys of an issuer
+type keySet struct {
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+// keyCache is shared by verifiers so keys are fetched once per issuer
+var keyCache = struct {
+	sync.Mutex
+	issuers map[string]keySet
+}{issuers: map[string]keySet{}}
+
+// Verify verifies a run's OIDC token and returns the provenance its claims
+// vouch for. The error wraps ErrMismatch when the headers in p disagree
+// with the claims.
+func (v *Verifier) Verify(ctx context.Context, p Provenance, token string) (Provenance, error) {
+	issuer, ok := v.Issuers[p.Provider]
+	if !ok || issuer == "" {
+		return p, fmt.Errorf("%w: unknown provider %q", ErrInvalidToken, p.Provider)
+	}
+
+	claims := jwt.MapClaims{}
+	var keyErr error
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, err := v.key(ctx, issuer, kid)
+		keyErr = err
+		return key, err
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(issuer),
+		jwt.WithAudience(v.Audience),
+		jwt.WithExpirationRequired(),
+	)
+	if errors.Is(keyErr, ErrKeysUnavailable) {
+		return p, keyErr
+	}
+	if err != nil {
+		return p, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	verified := fromClaims(p.Provider, issuer, claims)
+	if err := matches(p, verified); err != nil {
+		return p, err
+	}
+	return verified, nil
+}
+
+// fromClaims returns the provenance in the claims of a provider's token
+func fromClaims(provider, issuer string, claims jwt.MapClaims) Provenance {
+	p := Provenance{
+		Provider: provider,
+		Commit:   claim(claims, "sha"),
+		Ref:      claim(claims, "ref"),
+		Verified: true,
+		Issuer:   issuer,
+		Subject:  claim(claims, "sub"),
+	}
+
+	switch provider {
+	case ProviderGitHub:
+		p.RunID = claim(claims, "run_id")
+		p.RunNumber = claim(claims, "run_number")
+		p.Actor = claim(claims, "actor")
+		p.Repository = claim(claims, "repository")
+		p.Workflow = claim(claims, "workflow")
+		if p.Repository != "" && p.RunID != "" {
+			p.RunURL = "https://github.com/" + p.Repository + "/actions/runs/" + p.RunID
+		}
+	case ProviderGitLab:
+		p.RunID = claim(claims, "pipeline_id")
+		p.RunNumber = p.RunID
+		p.Actor = claim(claims, "user_login")
+		p.Repository = claim(claims, "project_path")
+		p.Workflow = claim(claims, "pipeline_source")
+		if p.Repository != "" && p.RunID != "" {
+			p.RunURL = issuer + "/" + p.Repository + "/-/pipelines/" + p.RunID
+		}
+	}
+	return p
+}
+
+// matches checks the headers a run sent against its verified provenance.
+// Headers left out are filled in from the token; a short commit SHA
+// matches the full one.
+func matches(headers, verified Provenance) error {
+	mismatch := func(field string) error {
+		return fmt.Errorf("%w: %s", ErrMismatch, field)
+	}
+
+	if headers.Commit != "" && (len(headers.Commit) < 7 || !strings.HasPrefix(strings.ToLower(verified.Commit), strings.ToLower(headers.Commit))) {
+		return mismatch("commit")
+	}
+	if headers.Actor != "" && !strings.EqualFold(headers.Actor, verified.Actor) {
+		return mismatch("actor")
+	}
+	if headers.RunNumber != "" && headers.RunNumber != verified.RunNumber {
+		return mismatch("run number")
+	}
+	// GitHub run URLs of a retried run end in /attempts/N
+	if headers.RunURL != "" && (verified.RunURL == "" || !strings.HasPrefix(headers.RunURL, verified.RunURL)) {
+		return mismatch("run URL")
+	}
+	return nil
+}
+
+// claim returns a claim as a string; GitLab sends some IDs as numbers
+func claim(claims jwt.MapClaims, name string) string {
+	switch v := claims[name].(type) {
+	case string:
+		return v
+	case float64:
+		return fmt.Sprintf("%.0f", v)
+	}
+	return ""
+}
+
+// key returns the signing key of an issuer with the given key ID
+func (v *Verifier) key(ctx context.Context, issuer, kid string) (*rsa.PublicKey, error) {
+	now := time.Now()
+	keyCache.Lock()
+	set, cached := keyCache.issuers[issuer]
+	keyCache.Unlock()
+
+	if cached && now.Sub(set.fetchedAt) < keysTTL {
+		if key, ok := set.keys[kid]; ok {
+			return key, nil
+		}
+		if now.Sub(set.fetchedAt) < minRefresh {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+	}
+
+	keys, err := v.fetchKeys(ctx, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeysUnavailable, err)
+	}
+	keyCache.Lock()
+	keyCache.issuers[issuer] = keySet{keys: keys, fetchedAt: now}
+	keyCache.Unlock()
+
+	key, ok := keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+// fetchKeys reads the RSA signing keys an issuer publishes through OIDC
+// discovery
+func (v *Verifier) fetchKeys(ctx context.Context, issuer string) (map[string]*rsa.PublicKey, error) {
+	var discovery struct {
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := v.getJSON(ctx, issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, err
+	}
+	if discovery.JWKSURI == "" {
+		return nil, errors.New("issuer publishes no jwks_uri")
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := v.getJSON(ctx, discovery.JWKSURI, &jwks); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
+
+func (v *Verifier) getJSON(ctx context.Context, url string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,121 @@
+// Package provenance records which CI run made a deployment. GitHub Actions
+// and GitLab CI send the run's metadata as headers along with an OIDC ID
+// token the run exchanged its job token for. The token is verified against
+// the provider's published keys and its claims must match the headers, so a
+// verified deployment traces back to a repository, commit, run and actor.
+// Apps trusting a repository let its runs exchange the token for a
+// short-lived deploy token instead of keeping an API token as a CI secret.
+package provenance
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/google/uuid"
+)
+
+// Headers sent by CI runs with a deployment
+const (
+	HeaderProvider  = "X-CI-Provider"
+	HeaderRunURL    = "X-CI-Run-URL"
+	HeaderRunNumber = "X-CI-Run-Number"
+	HeaderCommit    = "X-CI-Commit"
+	HeaderActor     = "X-CI-Actor"
+	HeaderToken     = "X-CI-OIDC-Token"
+)
+
+// Providers
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+)
+
+// Provenance is where a deployment came from
+type Provenance struct {
+	Provider   string `json:"provider"`
+	RunID      string `json:"run_id,omitempty"`
+	RunNumber  string `json:"run_number,omitempty"`
+	RunURL     string `json:"run_url,omitempty"`
+	Commit     string `json:"commit,omitempty"`
+	Actor      string `json:"actor,omitempty"`
+	Repository string `json:"repository,omitempty"`
+	Ref        string `json:"ref,omitempty"`
+	Workflow   string `json:"workflow,omitempty"`
+	// Verified is true when an OIDC token of the provider vouched for the
+	// run, identified by the token's issuer and subject
+	Verified bool   `json:"verified"`
+	Issuer   string `json:"issuer,omitempty"`
+	Subject  string `json:"subject,omitempty"`
+}
+
+// FromHeaders reads the CI headers of a request, returning the OIDC token
+// separately. ok is false when the request does not come from CI.
+func FromHeaders(header func(string) string) (p Provenance, token string, ok bool) {
+	p = Provenance{
+		Provider:  strings.ToLower(strings.TrimSpace(header(HeaderProvider))),
+		RunURL:    strings.TrimSpace(header(HeaderRunURL)),
+		RunNumber: strings.TrimPrefix(strings.TrimSpace(header(HeaderRunNumber)), "#"),
+		Commit:    strings.TrimSpace(header(HeaderCommit)),
+		Actor:     strings.TrimSpace(header(HeaderActor)),
+	}
+	token = strings.TrimSpace(header(HeaderToken))
+	if p.Provider == "" && token == "" {
+		return Provenance{}, "", false
+	}
+	return p, token, true
+}
+
+// ValidProvider reports whether provenance from provider is understood
+func ValidProvider(provider string) bool {
+	return provider == ProviderGitHub || provider == ProviderGitLab
+}
+
+// DeployedBy summarizes the provenance, such as "CI run #123"
+func (p Provenance) DeployedBy() string {
+	by := "CI"
+	if p.RunNumber != "" {
+		by = "CI run #" + p.RunNumber
+	}
+	if p.Actor != "" {
+		by = fmt.Sprintf("%s (%s)", by, p.Actor)
+	}
+	return by
+}
+
+// CreateParams returns the parameters storing the provenance of a deployment
+func (p Provenance) CreateParams(deploymentID uuid.UUID) db.CreateDeploymentProvenanceParams {
+	return db.CreateDeploymentProvenanceParams{
+		DeploymentID: deploymentID,
+		Provider:     p.Provider,
+		RunID:        p.RunID,
+		RunNumber:    p.RunNumber,
+		RunUrl:       p.RunURL,
+		CommitSha:    p.Commit,
+		Actor:        p.Actor,
+		Repository:   p.Repository,
+		Ref:          p.Ref,
+		Workflow:     p.Workflow,
+		Verified:     p.Verified,
+		Issuer:       p.Issuer,
+		Subject:      p.Subject,
+	}
+}
+
+// FromRecord returns the stored provenance of a deployment
+func FromRecord(r db.DeploymentProvenance) Provenance {
+	return Provenance{
+		Provider:   r.Provider,
+		RunID:      r.RunID,
+		RunNumber:  r.RunNumber,
+		RunURL:     r.RunUrl,
+		Commit:     r.CommitSha,
+		Actor:      r.Actor,
+		Repository: r.Repository,
+		Ref:        r.Ref,
+		Workflow:   r.Workflow,
+		Verified:   r.Verified,
+		Issuer:     r.Issuer,
+		Subject:    r.Subject,
+	}
+}
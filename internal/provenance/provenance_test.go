@@ -0,0 +1,183 @@
+package provenance
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestFromHeaders(t *testing.T) {
+	headers := map[string]string{
+		HeaderProvider:  "GitHub",
+		HeaderRunNumber: "#123",
+		HeaderCommit:    "abc1234",
+		HeaderToken:     "token",
+	}
+	p, token, ok := FromHeaders(func(name string) string { return headers[name] })
+	if !ok || token != "token" || p.Provider != ProviderGitHub || p.RunNumber != "123" || p.Commit != "abc1234" {
+		t.Errorf("unexpected provenance %+v, %q, %v", p, token, ok)
+	}
+
+	if _, _, ok := FromHeaders(func(string) string { return "" }); ok {
+		t.Error("expected a request without CI headers not to come from CI")
+	}
+}
+
+func TestDeployedBy(t *testing.T) {
+	tests := []struct {
+		p    Provenance
+		want string
+	}{
+		{Provenance{RunNumber: "123"}, "CI run #123"},
+		{Provenance{RunNumber: "123", Actor: "octocat"}, "CI run #123 (octocat)"},
+		{Provenance{}, "CI"},
+	}
+	for _, tt := range tests {
+		if got := tt.p.DeployedBy(); got != tt.want {
+			t.Errorf("DeployedBy() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+// issuer serves the OIDC discovery document and signing keys of a test
+// provider
+func issuer(t *testing.T) (*httptest.Server, *rsa.PrivateKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(w).Encode(map[string]string{"jwks_uri": server.URL + "/jwks"})
+		case "/jwks":
+			_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, key
+}
+
+func sign(t *testing.T, key *rsa.PrivateKey, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test"
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestVerify(t *testing.T) {
+	server, key := issuer(t)
+	verifier := NewVerifier("nexo-cloud", "https://gitlab.example.com")
+	verifier.Issuers[ProviderGitHub] = server.URL
+
+	claims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":        server.URL,
+			"aud":        "nexo-cloud",
+			"sub":        "repo:acme/shop:ref:refs/heads/main",
+			"exp":        time.Now().Add(5 * time.Minute).Unix(),
+			"repository": "acme/shop",
+			"sha":        "abc1234def5678",
+			"actor":      "octocat",
+			"run_id":     "987",
+			"run_number": "123",
+			"ref":        "refs/heads/main",
+			"workflow":   "deploy",
+		}
+	}
+	headers := Provenance{Provider: ProviderGitHub, RunNumber: "123", Commit: "abc1234", Actor: "Octocat"}
+
+	p, err := verifier.Verify(context.Background(), headers, sign(t, key, claims()))
+	if err != nil {
+		t.Fatalf("expected the token to verify, got %v", err)
+	}
+	if !p.Verified || p.Commit != "abc1234def5678" || p.RunURL != "https://github.com/acme/shop/actions/runs/987" || p.Subject != "repo:acme/shop:ref:refs/heads/main" {
+		t.Errorf("unexpected provenance %+v", p)
+	}
+
+	forged := headers
+	forged.RunNumber = "124"
+	if _, err := verifier.Verify(context.Background(), forged, sign(t, key, claims())); !errors.Is(err, ErrMismatch) {
+		t.Errorf("expected a mismatch, got %v", err)
+	}
+
+	other := claims()
+	other["aud"] = "someone-else"
+	if _, err := verifier.Verify(context.Background(), headers, sign(t, key, other)); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected a token for another audience to be rejected, got %v", err)
+	}
+
+	expired := claims()
+	expired["exp"] = time.Now().Add(-time.Minute).Unix()
+	if _, err := verifier.Verify(context.Background(), headers, sign(t, key, expired)); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected an expired token to be rejected, got %v", err)
+	}
+
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	if _, err := verifier.Verify(context.Background(), headers, sign(t, otherKey, claims())); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected a token signed with another key to be rejected, got %v", err)
+	}
+}
+
+func TestFromClaims_GitLab(t *testing.T) {
+	p := fromClaims(ProviderGitLab, "https://gitlab.com", jwt.MapClaims{
+		"project_path": "acme/shop",
+		"pipeline_id":  float64(4567),
+		"user_login":   "dev",
+		"sha":          "abc",
+	})
+	if p.RunNumber != "4567" || p.RunURL != "https://gitlab.com/acme/shop/-/pipelines/4567" || p.Actor != "dev" {
+		t.Errorf("unexpected provenance %+v", p)
+	}
+}
+
+func TestTrusts(t *testing.T) {
+	run := Provenance{Provider: ProviderGitHub, Repository: "Acme/Shop", Ref: "refs/heads/main", Verified: true}
+
+	if !Trusts(ProviderGitHub, "acme/shop", "", run) || !Trusts(ProviderGitHub, "acme/shop", "refs/heads/main", run) {
+		t.Error("expected the repository's runs to be trusted")
+	}
+	if Trusts(ProviderGitHub, "acme/shop", "refs/heads/release", run) {
+		t.Error("expected runs of another ref not to be trusted")
+	}
+	if Trusts(ProviderGitLab, "acme/shop", "", run) || Trusts(ProviderGitHub, "acme/other", "", run) {
+		t.Error("expected runs of another provider or repository not to be trusted")
+	}
+
+	unverified := run
+	unverified.Verified = false
+	if Trusts(ProviderGitHub, "acme/shop", "", unverified) {
+		t.Error("expected unverified runs not to be trusted")
+	}
+
+	if repo, ok := NormalizeRepository(" Acme/Shop "); !ok || repo != "acme/shop" {
+		t.Errorf("expected acme/shop, got %q", repo)
+	}
+	if _, ok := NormalizeRepository("shop"); ok {
+		t.Error("expected a repository without an owner to be rejected")
+	}
+}
@@ -0,0 +1,30 @@
+package provenance
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DeployTokenTTL is how long the deploy token a CI run exchanges its OIDC
+// token for lasts
+const DeployTokenTTL = 15 * time.Minute
+
+// repositoryRegex matches owner/name, or group/subgroup/name on GitLab
+var repositoryRegex = regexp.MustCompile(`^[a-z0-9_.-]+(/[a-z0-9_.-]+)+$`)
+
+// NormalizeRepository lowercases a repository such as Acme/Shop, false when
+// it is not a repository path
+func NormalizeRepository(repository string) (string, bool) {
+	repository = strings.ToLower(strings.Trim(strings.TrimSpace(repository), "/"))
+	return repository, repositoryRegex.MatchString(repository)
+}
+
+// Trusts reports whether an app's trust for runs of repository, on ref when
+// set, covers a verified run
+func Trusts(provider, repository, ref string, p Provenance) bool {
+	if !p.Verified || p.Provider != provider || !strings.EqualFold(p.Repository, repository) {
+		return false
+	}
+	return ref == "" || ref == p.Ref
+}
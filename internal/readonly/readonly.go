@@ -0,0 +1,159 @@
+// Package readonly is the operator switch putting the API in read-only mode
+// during incidents or migrations: reads keep working while mutations are
+// answered with 503 and the operator's notice. The switch is kept in
+// Postgres so it applies to every replica; each replica polls it and serves
+// requests from the last state it read.
+package readonly
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// DefaultMessage is the notice when the operator gives none
+const DefaultMessage = "The platform is in read-only mode, changes are paused. Please try again later."
+
+// MaxMessageLength caps the operator's notice
+const MaxMessageLength = 500
+
+// exempt are the paths still accepting non-GET requests: the switch itself,
+// so it can be turned off, signing in and out, so sessions outlive the
+// freeze, and cost estimates, which change nothing
+var exempt = []string{"/api/admin/readonly", "/api/auth/", "/logout", "/api/estimate"}
+
+// State is whether the API is read-only and why
+type State struct {
+	Enabled bool       `json:"enabled"`
+	Message string     `json:"message,omitempty"`
+	Since   *time.Time `json:"since,omitempty"`
+}
+
+// Store reads and writes the switch, such as *db.Queries
+type Store interface {
+	GetReadOnlyMode(ctx context.Context) (db.PlatformReadOnly, error)
+	SetReadOnlyMode(ctx context.Context, arg db.SetReadOnlyModeParams) (db.PlatformReadOnly, error)
+}
+
+// Switch holds the read-only state of the API
+type Switch struct {
+	store    Store
+	interval time.Duration
+
+	mu    sync.RWMutex
+	state State
+}
+
+// NewSwitch creates a switch reading its state from store every interval
+func NewSwitch(store Store, interval time.Duration) *Switch {
+	return &Switch{store: store, interval: interval}
+}
+
+// Refresh reads the state from the store. On errors the last state is kept,
+// so a database hiccup neither lifts nor imposes read-only mode.
+func (s *Switch) Refresh(ctx context.Context) error {
+	row, err := s.store.GetReadOnlyMode(ctx)
+	if errors.Is(err, pgx.ErrNoRows) {
+		s.update(State{})
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s.update(fromRow(row))
+	return nil
+}
+
+// Run refreshes the state every interval until the context is canceled
+func (s *Switch) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Refresh(ctx); err != nil {
+				slog.Warn("failed to refresh read-only mode", "error", err)
+			}
+		}
+	}
+}
+
+// Set turns read-only mode on or off for every replica. This replica
+// applies it right away, the others within an interval.
+func (s *Switch) Set(ctx context.Context, enabled bool, message string, by pgtype.UUID) (State, error) {
+	message = strings.TrimSpace(message)
+	if enabled && message == "" {
+		message = DefaultMessage
+	}
+	if !enabled {
+		message = ""
+	}
+	row, err := s.store.SetReadOnlyMode(ctx, db.SetReadOnlyModeParams{
+		Enabled:   enabled,
+		Message:   message,
+		UpdatedBy: by,
+	})
+	if err != nil {
+		return State{}, err
+	}
+	state := fromRow(row)
+	s.update(state)
+	return state, nil
+}
+
+// State returns the last known state; a nil switch is never read-only
+func (s *Switch) State() State {
+	if s == nil {
+		return State{}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state
+}
+
+// RetryAfter is how soon clients may retry a rejected mutation
+func (s *Switch) RetryAfter() time.Duration {
+	return max(s.interval, time.Second)
+}
+
+func (s *Switch) update(state State) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if state.Enabled != s.state.Enabled {
+		slog.Info("read-only mode changed", "enabled", state.Enabled, "message", state.Message)
+	}
+	s.state = state
+}
+
+func fromRow(row db.PlatformReadOnly) State {
+	if !row.Enabled {
+		return State{}
+	}
+	since := row.UpdatedAt
+	return State{Enabled: true, Message: row.Message, Since: &since}
+}
+
+// Blocks reports whether read-only mode rejects a request: every method but
+// GET, HEAD and OPTIONS, outside the exempt paths
+func Blocks(method, path string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	for _, p := range exempt {
+		if path == p || (strings.HasSuffix(p, "/") && strings.HasPrefix(path, p)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package readonly
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// fakeStore is the switch's row, shared like the database is by replicas
+type fakeStore struct {
+	row *db.PlatformReadOnly
+	err error
+}
+
+func (f *fakeStore) GetReadOnlyMode(context.Context) (db.PlatformReadOnly, error) {
+	if f.err != nil {
+		return db.PlatformReadOnly{}, f.err
+	}
+	if f.row == nil {
+		return db.PlatformReadOnly{}, pgx.ErrNoRows
+	}
+	return *f.row, nil
+}
+
+func (f *fakeStore) SetReadOnlyMode(_ context.Context, arg db.SetReadOnlyModeParams) (db.PlatformReadOnly, error) {
+	if f.err != nil {
+		return db.PlatformReadOnly{}, f.err
+	}
+	f.row = &db.PlatformReadOnly{ID: true, Enabled: arg.Enabled, Message: arg.Message, UpdatedBy: arg.UpdatedBy, UpdatedAt: time.Now()}
+	return *f.row, nil
+}
+
+func TestSwitch(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeStore{}
+	replica, other := NewSwitch(store, time.Second), NewSwitch(store, time.Second)
+
+	if err := replica.Refresh(ctx); err != nil || replica.State().Enabled {
+		t.Fatalf("expected read-write without a row, got %+v, %v", replica.State(), err)
+	}
+
+	state, err := replica.Set(ctx, true, "  ", pgtype.UUID{})
+	if err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if !state.Enabled || state.Message != DefaultMessage || state.Since == nil {
+		t.Errorf("expected read-only with the default notice, got %+v", state)
+	}
+	if !replica.State().Enabled {
+		t.Error("expected the switching replica to apply it right away")
+	}
+
+	if other.State().Enabled {
+		t.Error("expected other replicas to apply it on their next refresh")
+	}
+	if err := other.Refresh(ctx); err != nil || !other.State().Enabled {
+		t.Errorf("expected other replicas to read it, got %+v, %v", other.State(), err)
+	}
+
+	store.err = errors.New("connection refused")
+	if err := other.Refresh(ctx); err == nil || !other.State().Enabled {
+		t.Errorf("expected the last state to be kept on errors, got %+v, %v", other.State(), err)
+	}
+	store.err = nil
+
+	if state, _ := replica.Set(ctx, false, "ignored", pgtype.UUID{}); state.Enabled || state.Message != "" {
+		t.Errorf("expected read-write without a notice, got %+v", state)
+	}
+
+	var none *Switch
+	if none.State().Enabled {
+		t.Error("expected a nil switch to be read-write")
+	}
+}
+
+func TestBlocks(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   bool
+	}{
+		{http.MethodGet, "/api/apps", false},
+		{http.MethodHead, "/api/apps/shop", false},
+		{http.MethodOptions, "/api/apps", false},
+		{http.MethodPost, "/api/apps", true},
+		{http.MethodPut, "/api/apps/shop/env", true},
+		{http.MethodDelete, "/api/apps/shop", true},
+		{http.MethodPost, "/dashboard/apps/new", true},
+		{http.MethodPut, "/api/admin/readonly", false},
+		{http.MethodPost, "/api/auth/refresh", false},
+		{http.MethodPost, "/logout", false},
+		{http.MethodPost, "/api/estimate", false},
+		{http.MethodPost, "/api/admin/readonly-extra", true},
+	}
+	for _, tt := range tests {
+		if got := Blocks(tt.method, tt.path); got != tt.want {
+			t.Errorf("Blocks(%s %s) = %v, want %v", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
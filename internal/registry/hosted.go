@@ -0,0 +1,263 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Hosted reads the platform registry, authenticating with tokens the
+// platform signs for itself
+type Hosted struct {
+	baseURL string
+	issuer  *Issuer
+	http    *http.Client
+}
+
+// NewHosted creates a client of the platform registry at baseURL
+func NewHosted(baseURL string, issuer *Issuer) *Hosted {
+	return &Hosted{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		issuer:  issuer,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// NamespaceUsage is the storage a namespace takes in the registry
+type NamespaceUsage struct {
+	// Bytes is the size of the distinct blobs its tagged images reference
+	Bytes        int64
+	Repositories int
+}
+
+type descriptor struct {
+	Digest string `json:"digest"`
+	Size   int64  `json:"size"`
+}
+
+type hostedManifest struct {
+	Config    descriptor   `json:"config"`
+	Layers    []descriptor `json:"layers"`
+	Manifests []descriptor `json:"manifests"`
+}
+
+// Usage returns the storage of every namespace. Only blobs of tagged images
+// count, as untagged ones go with the next garbage collection; a blob
+// shared by several images of a namespace counts once.
+func (h *Hosted) Usage(ctx context.Context) (map[string]NamespaceUsage, error) {
+	repositories, err := h.Catalog(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	blobs := make(map[string]map[string]int64)
+	usage := make(map[string]NamespaceUsage)
+	for _, repository := range repositories {
+		namespace := Namespace(repository)
+		if blobs[namespace] == nil {
+			blobs[namespace] = make(map[string]int64)
+		}
+		tags, err := h.Tags(ctx, repository)
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range tags {
+			if err := h.addBlobs(ctx, repository, tag, blobs[namespace], 0); err != nil {
+				return nil, err
+			}
+		}
+
+		u := usage[namespace]
+		u.Repositories++
+		usage[namespace] = u
+	}
+
+	for namespace, sizes := range blobs {
+		u := usage[namespace]
+		for _, size := range sizes {
+			u.Bytes += size
+		}
+		usage[namespace] = u
+	}
+	return usage, nil
+}
+
+// addBlobs records the blobs of a manifest, following the per platform
+// manifests of an index
+func (h *Hosted) addBlobs(ctx context.Context, repository, reference string, blobs map[string]int64, depth int) error {
+	var m hostedManifest
+	err := h.getJSON(ctx, repository+"/manifests/"+reference, pullAccess(repository), &m)
+	if errors.Is(err, ErrNotFound) {
+		// Deleted since the tags were listed
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if m.Config.Digest != "" {
+		blobs[m.Config.Digest] = m.Config.Size
+	}
+	for _, layer := range m.Layers {
+		blobs[layer.Digest] = layer.Size
+	}
+	if depth > 0 {
+		return nil
+	}
+	for _, child := range m.Manifests {
+		if err := h.addBlobs(ctx, repository, child.Digest, blobs, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Catalog returns every repository of the registry
+func (h *Hosted) Catalog(ctx context.Context) ([]string, error) {
+	access := []Access{{Type: "registry", Name: "catalog", Actions: []string{"*"}}}
+
+	var repositories []string
+	for path := "_catalog?n=100"; path != ""; {
+		var page struct {
+			Repositories []string `json:"repositories"`
+		}
+		next, err := h.getPage(ctx, path, access, &page)
+		if err != nil {
+			return nil, err
+		}
+		repositories = append(repositories, page.Repositories...)
+		path = next
+	}
+	return repositories, nil
+}
+
+// Tags returns the tags of a repository
+func (h *Hosted) Tags(ctx context.Context, repository string) ([]string, error) {
+	var tags []string
+	for path := repository + "/tags/list?n=100"; path != ""; {
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+		next, err := h.getPage(ctx, path, pullAccess(repository), &page)
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, page.Tags...)
+		path = next
+	}
+	return tags, nil
+}
+
+func pullAccess(repository string) []Access {
+	return []Access{{Type: "repository", Name: repository, Actions: []string{ActionPull}}}
+}
+
+func (h *Hosted) getJSON(ctx context.Context, path string, access []Access, v any) error {
+	_, err := h.getPage(ctx, path, access, v)
+	return err
+}
+
+// Digest returns the digest of the manifest a tag of a repository points to
+func (h *Hosted) Digest(ctx context.Context, repository, tag string) (string, error) {
+	resp, err := h.do(ctx, http.MethodHead, repository+"/manifests/"+tag, pullAccess(repository))
+	if err != nil {
+		return "", err
+	}
+	_ = resp.Body.Close()
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", errors.New("registry returned no manifest digest")
+	}
+	return digest, nil
+}
+
+// DeleteManifest deletes a manifest and every tag pointing to it. Its layers
+// are freed by the registry's next garbage collection.
+func (h *Hosted) DeleteManifest(ctx context.Context, repository, digest string) error {
+	access := []Access{{Type: "repository", Name: repository, Actions: []string{ActionPull, ActionDelete}}}
+	resp, err := h.do(ctx, http.MethodDelete, repository+"/manifests/"+digest, access)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// getPage fetches a registry API path and returns the path of the next page
+// from the Link header, empty on the last page
+func (h *Hosted) getPage(ctx context.Context, path string, access []Access, v any) (string, error) {
+	resp, err := h.do(ctx, http.MethodGet, path, access)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 4<<20)).Decode(v); err != nil {
+		return "", fmt.Errorf("invalid registry response: %w", err)
+	}
+	return nextPage(resp.Header.Get("Link")), nil
+}
+
+// do sends a request to a registry API path with a token granting access,
+// returning the response of a successful request for the caller to close
+func (h *Hosted) do(ctx context.Context, method, path string, access []Access) (*http.Response, error) {
+	token, err := h.issuer.Issue(TokenIssuer, access, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, h.baseURL+"/v2/"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.Token)
+	req.Header.Set("Accept", strings.Join([]string{MediaTypeOCIIndex, MediaTypeDockerManifestList, MediaTypeOCIManifest, MediaTypeDockerManifest, "application/json"}, ", "))
+
+	resp, err := h.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusAccepted:
+		return resp, nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		err = ErrUnauthorized
+	case http.StatusNotFound:
+		err = ErrNotFound
+	default:
+		err = fmt.Errorf("registry returned status %d", resp.StatusCode)
+	}
+	_ = resp.Body.Close()
+	return nil, err
+}
+
+// nextPage returns the API path a Link header such as
+// </v2/_catalog?last=alice%2Fweb&n=100>; rel="next" points to
+func nextPage(link string) string {
+	target, params, ok := strings.Cut(link, ";")
+	if !ok || !strings.Contains(params, `rel="next"`) {
+		return ""
+	}
+	u, err := url.Parse(strings.Trim(strings.TrimSpace(target), "<>"))
+	if err != nil {
+		return ""
+	}
+	path, ok := strings.CutPrefix(u.Path, "/v2/")
+	if !ok {
+		return ""
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return path
+}
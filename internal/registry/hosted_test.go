@@ -0,0 +1,104 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHostedUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v2/_catalog":
+			if r.URL.Query().Get("last") == "" {
+				w.Header().Set("Link", `</v2/_catalog?last=alice%2Fweb&n=100>; rel="next"`)
+				_, _ = w.Write([]byte(`{"repositories":["alice/web"]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"repositories":["alice/worker","bob/api"]}`))
+		case "/v2/alice/web/tags/list":
+			_, _ = w.Write([]byte(`{"name":"alice/web","tags":["v1","v2"]}`))
+		case "/v2/alice/worker/tags/list":
+			_, _ = w.Write([]byte(`{"name":"alice/worker","tags":["latest"]}`))
+		case "/v2/bob/api/tags/list":
+			// Every tag deleted
+			_, _ = w.Write([]byte(`{"name":"bob/api","tags":null}`))
+		case "/v2/alice/web/manifests/v1":
+			_, _ = w.Write([]byte(`{"config":{"digest":"sha256:c1","size":10},"layers":[{"digest":"sha256:base","size":1000},{"digest":"sha256:app1","size":100}]}`))
+		case "/v2/alice/web/manifests/v2":
+			_, _ = w.Write([]byte(`{"config":{"digest":"sha256:c2","size":10},"layers":[{"digest":"sha256:base","size":1000},{"digest":"sha256:app2","size":200}]}`))
+		case "/v2/alice/worker/manifests/latest":
+			_, _ = w.Write([]byte(`{"manifests":[{"digest":"sha256:amd64"},{"digest":"sha256:arm64"}]}`))
+		case "/v2/alice/worker/manifests/sha256:amd64":
+			_, _ = w.Write([]byte(`{"config":{"digest":"sha256:c3","size":10},"layers":[{"digest":"sha256:base","size":1000}]}`))
+		case "/v2/alice/worker/manifests/sha256:arm64":
+			_, _ = w.Write([]byte(`{"config":{"digest":"sha256:c4","size":10},"layers":[{"digest":"sha256:base-arm","size":900}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	usage, err := NewHosted(server.URL, newTestIssuer(t)).Usage(context.Background())
+	if err != nil {
+		t.Fatalf("Usage: %v", err)
+	}
+
+	// The shared base layer counts once across tags, repositories and platforms
+	alice := usage["alice"]
+	if want := int64(10 + 10 + 10 + 10 + 1000 + 100 + 200 + 900); alice.Bytes != want || alice.Repositories != 2 {
+		t.Errorf("expected %d bytes in 2 repositories, got %+v", want, alice)
+	}
+	if bob := usage["bob"]; bob.Bytes != 0 || bob.Repositories != 1 {
+		t.Errorf("expected an empty repository for bob, got %+v", bob)
+	}
+}
+
+func TestHostedDeleteManifest(t *testing.T) {
+	var deleted string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodHead && r.URL.Path == "/v2/alice/web/manifests/v1":
+			w.Header().Set("Docker-Content-Digest", "sha256:abc")
+		case r.Method == http.MethodDelete && r.URL.Path == "/v2/alice/web/manifests/sha256:abc":
+			deleted = r.URL.Path
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	hosted := NewHosted(server.URL, newTestIssuer(t))
+	digest, err := hosted.Digest(context.Background(), "alice/web", "v1")
+	if err != nil || digest != "sha256:abc" {
+		t.Fatalf("expected digest sha256:abc, got %q (%v)", digest, err)
+	}
+	if err := hosted.DeleteManifest(context.Background(), "alice/web", digest); err != nil {
+		t.Fatalf("DeleteManifest: %v", err)
+	}
+	if deleted == "" {
+		t.Error("expected the manifest to be deleted")
+	}
+
+	if _, err := hosted.Digest(context.Background(), "alice/web", "gone"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing tag, got %v", err)
+	}
+}
+
+func TestNextPage(t *testing.T) {
+	if next := nextPage(`</v2/_catalog?last=alice%2Fweb&n=100>; rel="next"`); next != "_catalog?last=alice%2Fweb&n=100" {
+		t.Errorf("unexpected next page %q", next)
+	}
+	if next := nextPage(""); next != "" {
+		t.Errorf("expected no next page, got %q", next)
+	}
+}
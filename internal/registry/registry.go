@@ -0,0 +1,333 @@
+// Package registry reads image manifests from OCI distribution registries to
+// find which CPU architectures an image is built for and which digest a tag
+// points to. Registries are read
+// anonymously, following the bearer token challenge public registries such
+// as Docker Hub answer with.
+//
+// It also backs the platform registry, where each user pushes to the
+// repositories of their namespace: it signs the bearer tokens granting
+// scoped pull and push access, and measures the storage each namespace takes
+// against its quota.
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Manifest media types
+const (
+	MediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+)
+
+// ErrUnauthorized is returned for images the registry does not let anonymous
+// clients read, such as private repositories
+var ErrUnauthorized = errors.New("registry requires credentials")
+
+// ErrNotFound is returned when the image or its tag does not exist
+var ErrNotFound = errors.New("image not found in registry")
+
+// dockerHub is the registry of image names without a registry host
+const (
+	dockerHub     = "docker.io"
+	dockerHubHost = "registry-1.docker.io"
+)
+
+// Reference is a parsed image reference
+type Reference struct {
+	Registry   string
+	Repository string
+	// Reference is the tag or digest of the image
+	Reference string
+}
+
+// ParseReference parses an image reference such as nginx, ghcr.io/org/app:v1
+// or registry.example.com:5000/app@sha256:..., defaulting to Docker Hub and
+// the latest tag
+func ParseReference(image string) (Reference, error) {
+	if image == "" || strings.ContainsAny(image, " \t\n") {
+		return Reference{}, fmt.Errorf("invalid image reference %q", image)
+	}
+
+	ref := Reference{Registry: dockerHub}
+	name := image
+	if first, rest, ok := strings.Cut(image, "/"); ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		ref.Registry, name = first, rest
+	}
+
+	switch {
+	case strings.Contains(name, "@"):
+		name, ref.Reference, _ = strings.Cut(name, "@")
+	case strings.LastIndex(name, ":") > strings.LastIndex(name, "/"):
+		i := strings.LastIndex(name, ":")
+		name, ref.Reference = name[:i], name[i+1:]
+	default:
+		ref.Reference = "latest"
+	}
+	if name == "" || ref.Reference == "" {
+		return Reference{}, fmt.Errorf("invalid image reference %q", image)
+	}
+
+	if ref.Registry == dockerHub && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	ref.Repository = name
+	return ref, nil
+}
+
+// host is the address of the registry API
+func (r Reference) host() string {
+	if r.Registry == dockerHub {
+		return dockerHubHost
+	}
+	return r.Registry
+}
+
+// Client reads manifests from registries
+type Client struct {
+	http *http.Client
+	// scheme is overridden by tests against plain HTTP registries
+	scheme string
+}
+
+// NewClient creates a registry client
+func NewClient() *Client {
+	return &Client{
+		http:   &http.Client{Timeout: 15 * time.Second},
+		scheme: "https",
+	}
+}
+
+type manifest struct {
+	MediaType string `json:"mediaType"`
+	// Manifests are the per platform images of an index
+	Manifests []struct {
+		Platform *platform `json:"platform"`
+	} `json:"manifests"`
+	// Config is the image configuration of a single platform manifest
+	Config struct {
+		Digest string `json:"digest"`
+	} `json:"config"`
+}
+
+type platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+}
+
+// Architectures returns the linux CPU architectures an image is built for,
+// sorted, such as [amd64 arm64]. Attestation entries of an index, which
+// have an unknown platform, are skipped.
+func (c *Client) Architectures(ctx context.Context, image string) ([]string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return nil, err
+	}
+
+	accept := strings.Join([]string{MediaTypeOCIIndex, MediaTypeDockerManifestList, MediaTypeOCIManifest, MediaTypeDockerManifest}, ", ")
+	body, mediaType, err := c.get(ctx, ref, "manifests/"+ref.Reference, accept)
+	if err != nil {
+		return nil, err
+	}
+
+	var m manifest
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if m.MediaType != "" {
+		mediaType = m.MediaType
+	}
+
+	var platforms []platform
+	switch mediaType {
+	case MediaTypeOCIIndex, MediaTypeDockerManifestList:
+		for _, entry := range m.Manifests {
+			if entry.Platform != nil {
+				platforms = append(platforms, *entry.Platform)
+			}
+		}
+	case MediaTypeOCIManifest, MediaTypeDockerManifest:
+		config, _, err := c.get(ctx, ref, "blobs/"+m.Config.Digest, "*/*")
+		if err != nil {
+			return nil, err
+		}
+		var p platform
+		if err := json.Unmarshal(config, &p); err != nil {
+			return nil, fmt.Errorf("invalid image config: %w", err)
+		}
+		platforms = append(platforms, p)
+	default:
+		return nil, fmt.Errorf("unsupported manifest type %q", mediaType)
+	}
+
+	var architectures []string
+	for _, p := range platforms {
+		if p.OS == "linux" && p.Architecture != "" && !slices.Contains(architectures, p.Architecture) {
+			architectures = append(architectures, p.Architecture)
+		}
+	}
+	slices.Sort(architectures)
+	return architectures, nil
+}
+
+// Digest returns the digest of the manifest an image reference resolves to,
+// pinning a tag to the exact image it points to now
+func (c *Client) Digest(ctx context.Context, image string) (string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(ref.Reference, "sha256:") {
+		return ref.Reference, nil
+	}
+
+	accept := strings.Join([]string{MediaTypeOCIIndex, MediaTypeDockerManifestList, MediaTypeOCIManifest, MediaTypeDockerManifest}, ", ")
+	body, _, err := c.get(ctx, ref, "manifests/"+ref.Reference, accept)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(body)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// get fetches a registry API path of the repository, answering a bearer
+// token challenge once
+func (c *Client) get(ctx context.Context, ref Reference, path, accept string) ([]byte, string, error) {
+	endpoint := fmt.Sprintf("%s://%s/v2/%s/%s", c.scheme, ref.host(), ref.Repository, path)
+
+	resp, err := c.do(ctx, endpoint, accept, "")
+	if err != nil {
+		return nil, "", err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		_ = resp.Body.Close()
+
+		token, err := c.token(ctx, challenge)
+		if err != nil {
+			return nil, "", err
+		}
+		if resp, err = c.do(ctx, endpoint, accept, token); err != nil {
+			return nil, "", err
+		}
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, "", ErrUnauthorized
+	case http.StatusNotFound:
+		return nil, "", ErrNotFound
+	default:
+		return nil, "", fmt.Errorf("registry returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, "", err
+	}
+	mediaType, _, _ := strings.Cut(resp.Header.Get("Content-Type"), ";")
+	return body, strings.TrimSpace(mediaType), nil
+}
+
+func (c *Client) do(ctx context.Context, endpoint, accept, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return c.http.Do(req)
+}
+
+// token requests an anonymous pull token for a bearer challenge such as
+// Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/nginx:pull"
+func (c *Client) token(ctx context.Context, challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", ErrUnauthorized
+	}
+
+	values := ParseChallenge(params)
+	realm := values["realm"]
+	if realm == "" {
+		return "", ErrUnauthorized
+	}
+	query := url.Values{}
+	for _, key := range []string{"service", "scope"} {
+		if values[key] != "" {
+			query.Set(key, values[key])
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", ErrUnauthorized
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("invalid token response: %w", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+// ParseChallenge parses the comma separated key="value" parameters of a
+// WWW-Authenticate challenge
+func ParseChallenge(params string) map[string]string {
+	values := make(map[string]string)
+	for params != "" {
+		key, rest, ok := strings.Cut(params, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(key), ",")))
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+
+		values[key] = value
+		params = rest
+	}
+	return values
+}
@@ -0,0 +1,125 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		image string
+		want  Reference
+	}{
+		{"nginx", Reference{Registry: "docker.io", Repository: "library/nginx", Reference: "latest"}},
+		{"bitnami/redis:7.2", Reference{Registry: "docker.io", Repository: "bitnami/redis", Reference: "7.2"}},
+		{"ghcr.io/org/app:v1", Reference{Registry: "ghcr.io", Repository: "org/app", Reference: "v1"}},
+		{"localhost:5000/app", Reference{Registry: "localhost:5000", Repository: "app", Reference: "latest"}},
+		{"registry.example.com/app@sha256:abc", Reference{Registry: "registry.example.com", Repository: "app", Reference: "sha256:abc"}},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseReference(tt.image)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseReference(%q) = %+v, %v, want %+v", tt.image, got, err, tt.want)
+		}
+	}
+
+	for _, image := range []string{"", "app:", "bad image"} {
+		if _, err := ParseReference(image); err == nil {
+			t.Errorf("expected %q to be rejected", image)
+		}
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	values := ParseChallenge(`realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/nginx:pull"`)
+	if values["realm"] != "https://auth.docker.io/token" || values["service"] != "registry.docker.io" || values["scope"] != "repository:library/nginx:pull" {
+		t.Errorf("unexpected challenge %+v", values)
+	}
+}
+
+func TestArchitectures(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			_, _ = w.Write([]byte(`{"token":"anonymous"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer anonymous" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v2/multi/manifests/latest":
+			w.Header().Set("Content-Type", MediaTypeOCIIndex)
+			_, _ = w.Write([]byte(`{"manifests":[
+				{"platform":{"os":"linux","architecture":"arm64"}},
+				{"platform":{"os":"linux","architecture":"amd64"}},
+				{"platform":{"os":"unknown","architecture":"unknown"}},
+				{"platform":{"os":"windows","architecture":"amd64"}}
+			]}`))
+		case "/v2/single/manifests/v1":
+			w.Header().Set("Content-Type", MediaTypeDockerManifest)
+			_, _ = w.Write([]byte(`{"config":{"digest":"sha256:config"}}`))
+		case "/v2/single/blobs/sha256:config":
+			_, _ = w.Write([]byte(`{"os":"linux","architecture":"arm64"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	client.scheme = "http"
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	archs, err := client.Architectures(context.Background(), host+"/multi")
+	if err != nil || !slices.Equal(archs, []string{"amd64", "arm64"}) {
+		t.Errorf("expected amd64 and arm64, got %v, %v", archs, err)
+	}
+
+	archs, err = client.Architectures(context.Background(), host+"/single:v1")
+	if err != nil || !slices.Equal(archs, []string{"arm64"}) {
+		t.Errorf("expected arm64, got %v, %v", archs, err)
+	}
+
+	if _, err := client.Architectures(context.Background(), host+"/missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestDigest(t *testing.T) {
+	manifest := `{"config":{"digest":"sha256:config"}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/app/manifests/v1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(manifest))
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	client.scheme = "http"
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	sum := sha256.Sum256([]byte(manifest))
+	want := "sha256:" + hex.EncodeToString(sum[:])
+	if digest, err := client.Digest(context.Background(), host+"/app:v1"); err != nil || digest != want {
+		t.Errorf("expected %s, got %s, %v", want, digest, err)
+	}
+
+	// References by digest are already pinned
+	if digest, err := client.Digest(context.Background(), host+"/app@sha256:abc"); err != nil || digest != "sha256:abc" {
+		t.Errorf("expected sha256:abc, got %s, %v", digest, err)
+	}
+}
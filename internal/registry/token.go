@@ -0,0 +1,244 @@
+package registry
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/pki"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Actions on repositories of the platform registry
+const (
+	ActionPull   = "pull"
+	ActionPush   = "push"
+	ActionDelete = "delete"
+)
+
+// TokenIssuer is the issuer the platform registry expects in its tokens
+const TokenIssuer = "nexo-cloud"
+
+// TokenTTL is how long a registry token is valid. Clients ask for a new one
+// when it runs out, so quotas and revoked credentials apply soon.
+const TokenTTL = 5 * time.Minute
+
+// PullPasswordPrefix starts the passwords of the pull credentials the
+// platform gives clusters to pull a user's images
+const PullPasswordPrefix = "fgp_"
+
+// ErrNotConfigured is returned when the platform registry or its token
+// signing key is not configured
+var ErrNotConfigured = errors.New("platform registry is not configured")
+
+// Access is an access to a resource of the registry, as requested in a
+// scope and granted in a token
+type Access struct {
+	Type    string   `json:"type"`
+	Name    string   `json:"name"`
+	Actions []string `json:"actions"`
+}
+
+// ParseScope parses a scope such as repository:alice/web:pull,push
+func ParseScope(scope string) (Access, error) {
+	typ, rest, ok := strings.Cut(scope, ":")
+	i := strings.LastIndex(rest, ":")
+	if !ok || i <= 0 || typ == "" {
+		return Access{}, fmt.Errorf("invalid scope %q", scope)
+	}
+	return Access{
+		Type:    typ,
+		Name:    rest[:i],
+		Actions: strings.Split(rest[i+1:], ","),
+	}, nil
+}
+
+// Namespace returns the namespace of a repository, its first path segment,
+// which is the lowercased username of the user owning it
+func Namespace(repository string) string {
+	namespace, _, _ := strings.Cut(repository, "/")
+	return namespace
+}
+
+// Grant returns the part of the requested accesses a user gets: only
+// repositories of their namespace, pulls always, pushes and deletes when
+// allowed. Deletes stay allowed over quota so users can free space.
+// Repository names are lowercase, so the namespace of a mixed-case login is
+// its lowercased form.
+func Grant(username string, requested []Access, push, overQuota bool) []Access {
+	namespace := strings.ToLower(username)
+	granted := []Access{}
+	for _, access := range requested {
+		if access.Type != "repository" || Namespace(access.Name) != namespace || !strings.Contains(access.Name, "/") {
+			continue
+		}
+		var actions []string
+		for _, action := range access.Actions {
+			allowed := action == ActionPull ||
+				(action == ActionPush && push && !overQuota) ||
+				(action == ActionDelete && push)
+			if allowed && !slices.Contains(actions, action) {
+				actions = append(actions, action)
+			}
+		}
+		if len(actions) > 0 {
+			granted = append(granted, Access{Type: access.Type, Name: access.Name, Actions: actions})
+		}
+	}
+	return granted
+}
+
+// PullPassword returns the password of the pull-only credential for a user's
+// repositories, derived from the platform's signing key so it needs no
+// storage. Rotating the key revokes every pull credential.
+func PullPassword(key, username string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte("registry-pull:" + username))
+	return PullPasswordPrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyPullPassword reports whether password is the pull credential of
+// username
+func VerifyPullPassword(key, username, password string) bool {
+	return hmac.Equal([]byte(password), []byte(PullPassword(key, username)))
+}
+
+// Issuer signs the bearer tokens the platform registry accepts, with the key
+// of the certificate in its root certificate bundle
+type Issuer struct {
+	// Service is the registry the tokens are for, its host
+	Service string
+	cert    *x509.Certificate
+	key     crypto.Signer
+	method  jwt.SigningMethod
+}
+
+// LoadIssuer loads the token signing certificate and key from PEM files
+func LoadIssuer(service, certFile, keyFile string) (*Issuer, error) {
+	if service == "" || certFile == "" || keyFile == "" {
+		return nil, ErrNotConfigured
+	}
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token certificate: %w", err)
+	}
+	keyPEM, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token key: %w", err)
+	}
+
+	certBlock, _ := pem.Decode(certPEM)
+	if certBlock == nil {
+		return nil, errors.New("invalid token certificate PEM")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse token certificate: %w", err)
+	}
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		return nil, errors.New("invalid token key PEM")
+	}
+	key, err := pki.ParseKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	return NewIssuer(service, cert, key)
+}
+
+// NewIssuer creates an issuer signing with an ECDSA P-256 or RSA key
+func NewIssuer(service string, cert *x509.Certificate, key crypto.Signer) (*Issuer, error) {
+	var method jwt.SigningMethod
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		if k.Curve.Params().BitSize != 256 {
+			return nil, errors.New("token key must be an ECDSA P-256 or RSA key")
+		}
+		method = jwt.SigningMethodES256
+	case *rsa.PrivateKey:
+		method = jwt.SigningMethodRS256
+	default:
+		return nil, errors.New("token key must be an ECDSA P-256 or RSA key")
+	}
+	return &Issuer{Service: service, cert: cert, key: key, method: method}, nil
+}
+
+// Token is a signed registry token
+type Token struct {
+	Token     string    `json:"token"`
+	ExpiresIn int       `json:"expires_in"`
+	IssuedAt  time.Time `json:"issued_at"`
+}
+
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Access []Access `json:"access"`
+}
+
+// Issue signs a token granting access to subject. The certificate goes in
+// the x5c header and its key ID in kid, so registries verifying either way
+// accept it.
+func (i *Issuer) Issue(subject string, access []Access, now time.Time) (Token, error) {
+	if access == nil {
+		access = []Access{}
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return Token{}, err
+	}
+
+	token := jwt.NewWithClaims(i.method, tokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    TokenIssuer,
+			Subject:   subject,
+			Audience:  jwt.ClaimStrings{i.Service},
+			ExpiresAt: jwt.NewNumericDate(now.Add(TokenTTL)),
+			NotBefore: jwt.NewNumericDate(now.Add(-time.Minute)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        hex.EncodeToString(id),
+		},
+		Access: access,
+	})
+	token.Header["x5c"] = []string{base64.StdEncoding.EncodeToString(i.cert.Raw)}
+	if kid, err := keyID(i.key.Public()); err == nil {
+		token.Header["kid"] = kid
+	}
+
+	signed, err := token.SignedString(i.key)
+	if err != nil {
+		return Token{}, fmt.Errorf("failed to sign registry token: %w", err)
+	}
+	return Token{Token: signed, ExpiresIn: int(TokenTTL.Seconds()), IssuedAt: now.UTC()}, nil
+}
+
+// keyID returns the libtrust fingerprint registries identify token keys by:
+// the first 240 bits of the SHA-256 of the public key, base32 encoded in
+// groups of four
+func keyID(public crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	encoded := base32.StdEncoding.EncodeToString(sum[:30])
+
+	groups := make([]string, 0, len(encoded)/4)
+	for i := 0; i < len(encoded); i += 4 {
+		groups = append(groups, encoded[i:i+4])
+	}
+	return strings.Join(groups, ":"), nil
+}
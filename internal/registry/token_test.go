@@ -0,0 +1,148 @@
+package registry
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newTestIssuer(t *testing.T) *Issuer {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "registry token"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer, err := NewIssuer("registry.nexo.build", cert, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return issuer
+}
+
+func TestParseScope(t *testing.T) {
+	access, err := ParseScope("repository:alice/web:pull,push")
+	if err != nil {
+		t.Fatalf("ParseScope: %v", err)
+	}
+	if access.Type != "repository" || access.Name != "alice/web" || len(access.Actions) != 2 || access.Actions[1] != ActionPush {
+		t.Errorf("unexpected access %+v", access)
+	}
+
+	for _, scope := range []string{"", "repository", "repository:alice/web", ":alice/web:pull"} {
+		if _, err := ParseScope(scope); err == nil {
+			t.Errorf("expected %q to be rejected", scope)
+		}
+	}
+}
+
+func TestGrant(t *testing.T) {
+	requested := []Access{
+		{Type: "repository", Name: "alice/web", Actions: []string{"pull", "push", "delete"}},
+		{Type: "repository", Name: "bob/web", Actions: []string{"pull"}},
+		{Type: "repository", Name: "alice", Actions: []string{"pull"}},
+		{Type: "registry", Name: "catalog", Actions: []string{"*"}},
+	}
+
+	tests := []struct {
+		name      string
+		push      bool
+		overQuota bool
+		want      string
+	}{
+		{"push credential", true, false, "pull,push,delete"},
+		{"pull credential", false, false, "pull"},
+		{"over quota", true, true, "pull,delete"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			granted := Grant("alice", requested, tt.push, tt.overQuota)
+			if len(granted) != 1 || granted[0].Name != "alice/web" {
+				t.Fatalf("expected only alice/web to be granted, got %+v", granted)
+			}
+			if actions := strings.Join(granted[0].Actions, ","); actions != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, actions)
+			}
+		})
+	}
+}
+
+func TestGrant_MixedCaseLogin(t *testing.T) {
+	requested := []Access{
+		{Type: "repository", Name: "alice/web", Actions: []string{"pull", "push"}},
+		{Type: "repository", Name: "Alice/web", Actions: []string{"pull"}},
+	}
+
+	granted := Grant("Alice", requested, true, false)
+	if len(granted) != 1 || granted[0].Name != "alice/web" {
+		t.Fatalf("expected only alice/web to be granted, got %+v", granted)
+	}
+	if actions := strings.Join(granted[0].Actions, ","); actions != "pull,push" {
+		t.Errorf("expected pull,push, got %s", actions)
+	}
+}
+
+func TestPullPassword(t *testing.T) {
+	password := PullPassword("signing-key", "alice")
+	if !strings.HasPrefix(password, PullPasswordPrefix) {
+		t.Errorf("expected the %s prefix, got %s", PullPasswordPrefix, password)
+	}
+	if !VerifyPullPassword("signing-key", "alice", password) {
+		t.Error("expected the password to verify")
+	}
+	if VerifyPullPassword("signing-key", "bob", password) || VerifyPullPassword("other-key", "alice", password) {
+		t.Error("expected the password to only verify for its user and key")
+	}
+}
+
+func TestIssue(t *testing.T) {
+	issuer := newTestIssuer(t)
+	access := []Access{{Type: "repository", Name: "alice/web", Actions: []string{"pull"}}}
+
+	token, err := issuer.Issue("alice", access, time.Now())
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if token.ExpiresIn != int(TokenTTL.Seconds()) {
+		t.Errorf("unexpected expires_in %d", token.ExpiresIn)
+	}
+
+	var claims tokenClaims
+	parsed, err := jwt.ParseWithClaims(token.Token, &claims, func(*jwt.Token) (any, error) {
+		return issuer.cert.PublicKey, nil
+	}, jwt.WithAudience("registry.nexo.build"), jwt.WithIssuer(TokenIssuer))
+	if err != nil {
+		t.Fatalf("expected a token verifiable with the certificate: %v", err)
+	}
+	if claims.Subject != "alice" || len(claims.Access) != 1 || claims.Access[0].Name != "alice/web" {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+	if _, ok := parsed.Header["x5c"]; !ok {
+		t.Error("expected the certificate in the x5c header")
+	}
+	kid, _ := parsed.Header["kid"].(string)
+	if groups := strings.Split(kid, ":"); len(groups) != 12 || len(groups[0]) != 4 {
+		t.Errorf("expected a libtrust key ID, got %q", kid)
+	}
+}
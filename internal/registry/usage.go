@@ -0,0 +1,66 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+)
+
+// UsageCollector records how much of the platform registry each user's
+// repositories take, which the token route checks against the quota before
+// granting pushes
+type UsageCollector struct {
+	queries *db.Queries
+	cfg     *config.Config
+}
+
+// NewUsageCollector creates a usage collector
+func NewUsageCollector(queries *db.Queries, cfg *config.Config) *UsageCollector {
+	return &UsageCollector{queries: queries, cfg: cfg}
+}
+
+// Collect walks the registry and stores the usage of every user with
+// repositories. Namespaces that are no user's are left alone; users whose
+// repositories are all gone drop back to no usage.
+func (u *UsageCollector) Collect(ctx context.Context) error {
+	issuer, err := LoadIssuer(u.cfg.RegistryHost, u.cfg.RegistryTokenCertFile, u.cfg.RegistryTokenKeyFile)
+	if errors.Is(err, ErrNotConfigured) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	usage, err := NewHosted(u.cfg.RegistryAPI(), issuer).Usage(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read registry usage: %w", err)
+	}
+
+	for namespace, nu := range usage {
+		user, err := u.queries.GetUserByRegistryNamespace(ctx, namespace)
+		if err != nil {
+			continue
+		}
+		if err := u.queries.UpsertRegistryUsage(ctx, db.UpsertRegistryUsageParams{
+			UserID:       user.ID,
+			Bytes:        nu.Bytes,
+			Repositories: int32(nu.Repositories),
+		}); err != nil {
+			slog.Error("failed to record registry usage", "user", namespace, "error", err)
+		}
+	}
+
+	return u.queries.DeleteStaleRegistryUsage(ctx, start)
+}
+
+// QuotaBytes returns the registry storage each user may fill before pushes
+// are refused
+func QuotaBytes(cfg *config.Config) int64 {
+	return int64(cfg.RegistryQuotaGB) << 30
+}
@@ -0,0 +1,102 @@
+// Package reqbody decodes JSON request bodies strictly, so client mistakes
+// fail loudly: a field the route does not know, such as a misspelled
+// "regon", a value of the wrong type or data after the JSON value is
+// rejected instead of silently ignored. ALLOW_UNKNOWN_JSON_FIELDS turns the
+// unknown field check off for clients that cannot be fixed right away.
+// Bodies over MAX_REQUEST_BODY_BYTES are cut off by the body limit
+// middleware and reported here as too large.
+package reqbody
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/compression"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+)
+
+var (
+	// ErrInvalid is returned for a body that is not the JSON a route expects
+	ErrInvalid = errors.New("invalid request body")
+	// ErrTooLarge is returned for a body over the size limit
+	ErrTooLarge = errors.New("request body too large")
+)
+
+// Bind decodes the JSON body of a request into v, rejecting unknown fields
+// unless the config allows them
+func Bind(c *fuego.Context, v any) error {
+	allowUnknown := false
+	if cfg := services.From(c).Config; cfg != nil {
+		allowUnknown = cfg.AllowUnknownJSONFields
+	}
+	if c.Request.Body == nil {
+		return fmt.Errorf("%w: empty request body", ErrInvalid)
+	}
+	return Decode(c.Request.Body, v, allowUnknown)
+}
+
+// Decode decodes a single JSON value from r into v
+func Decode(r io.Reader, v any, allowUnknownFields bool) error {
+	dec := json.NewDecoder(r)
+	if !allowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil {
+		return describe(err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		if tooLarge(err) {
+			return describe(err)
+		}
+		return fmt.Errorf("%w: unexpected data after the JSON value", ErrInvalid)
+	}
+	return nil
+}
+
+// Reject responds to a body Bind failed on: 413 when it is too large, 400
+// naming the mistake otherwise
+func Reject(c *fuego.Context, err error) error {
+	if errors.Is(err, ErrTooLarge) {
+		return c.JSON(413, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(400, map[string]string{"error": err.Error()})
+}
+
+// describe turns a decoding error into one a client can act on
+func describe(err error) error {
+	var maxBytes *http.MaxBytesError
+	var syntax *json.SyntaxError
+	var unmarshalType *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &maxBytes):
+		return fmt.Errorf("%w: larger than %d bytes", ErrTooLarge, maxBytes.Limit)
+	case errors.Is(err, compression.ErrTooLarge):
+		return fmt.Errorf("%w: larger than %d bytes decompressed", ErrTooLarge, compression.MaxDecodedSize)
+	case errors.Is(err, io.EOF):
+		return fmt.Errorf("%w: empty request body", ErrInvalid)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return fmt.Errorf("%w: truncated JSON", ErrInvalid)
+	case errors.As(err, &syntax):
+		return fmt.Errorf("%w: malformed JSON at byte %d", ErrInvalid, syntax.Offset)
+	case errors.As(err, &unmarshalType):
+		if unmarshalType.Field == "" {
+			return fmt.Errorf("%w: expected %s", ErrInvalid, unmarshalType.Type)
+		}
+		return fmt.Errorf("%w: %s must be %s", ErrInvalid, unmarshalType.Field, unmarshalType.Type)
+	}
+	// encoding/json has no type for unknown fields
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return fmt.Errorf("%w: unknown field %s", ErrInvalid, field)
+	}
+	return fmt.Errorf("%w: %v", ErrInvalid, err)
+}
+
+func tooLarge(err error) bool {
+	var maxBytes *http.MaxBytesError
+	return errors.As(err, &maxBytes) || errors.Is(err, compression.ErrTooLarge)
+}
@@ -0,0 +1,76 @@
+package reqbody
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type createApp struct {
+	Name   string `json:"name"`
+	Region string `json:"region"`
+	Port   int    `json:"port"`
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		error string
+	}{
+		{"valid", `{"name": "api", "region": "gdl"}`, ""},
+		{"trailing whitespace", "{\"name\": \"api\"}\n", ""},
+		{"unknown field", `{"name": "api", "regon": "gdl"}`, `unknown field "regon"`},
+		{"wrong type", `{"name": "api", "port": "8080"}`, "port must be int"},
+		{"not an object", `["api"]`, "expected reqbody.createApp"},
+		{"malformed", `{"name": api}`, "malformed JSON"},
+		{"truncated", `{"name": "api"`, "truncated JSON"},
+		{"empty", ``, "empty request body"},
+		{"trailing data", `{"name": "api"}{"name": "web"}`, "unexpected data after the JSON value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req createApp
+			err := Decode(strings.NewReader(tt.body), &req, false)
+			if tt.error == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalid) {
+				t.Fatalf("expected ErrInvalid, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.error) {
+				t.Errorf("expected %q in %q", tt.error, err.Error())
+			}
+		})
+	}
+}
+
+func TestDecodeAllowUnknownFields(t *testing.T) {
+	var req createApp
+	if err := Decode(strings.NewReader(`{"name": "api", "regon": "gdl"}`), &req, true); err != nil {
+		t.Fatalf("expected unknown fields to be ignored, got %v", err)
+	}
+	if req.Name != "api" {
+		t.Errorf("expected name api, got %q", req.Name)
+	}
+}
+
+func TestDecodeTooLarge(t *testing.T) {
+	body := `{"name": "` + strings.Repeat("a", 100) + `"}`
+	r := http.MaxBytesReader(httptest.NewRecorder(), io.NopCloser(strings.NewReader(body)), 32)
+
+	var req createApp
+	err := Decode(r, &req, false)
+	if !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("expected ErrTooLarge, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "32 bytes") {
+		t.Errorf("expected the limit in %q", err.Error())
+	}
+}
@@ -0,0 +1,249 @@
+// Package retention keeps the activity log from growing unbounded: entries
+// are deleted once they are older than the retention of the plan of the
+// account they belong to, in batches, and can be exported to object storage
+// first. Exports are encrypted with ENCRYPTION_KEY, since entries hold
+// client IPs.
+package retention
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/backup"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/cryptoutil"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// DefaultDays is how many days activity logs are kept for plans without a
+// retention of their own
+const DefaultDays = 30
+
+// Days is how many days the activity logs of each plan's accounts are kept,
+// unless ACTIVITY_RETENTION_DAYS_<PLAN> overrides it
+var Days = map[string]int{
+	"free":       30,
+	"pro":        90,
+	"enterprise": 365,
+}
+
+const (
+	// BatchSize is how many entries are exported and deleted at once
+	BatchSize = 1000
+	// maxBatches bounds a run, so a backlog is worked off over several runs
+	maxBatches = 50
+
+	archivePrefix = "activity/"
+	archiveSuffix = ".jsonl.gz.enc"
+)
+
+// Policy is the retention of every plan at a point in time
+type Policy struct {
+	Plans   []string
+	Cutoffs []time.Time
+	// Default applies to plans not listed
+	Default time.Time
+	// Newest is the latest cutoff; no entry after it is expired
+	Newest time.Time
+}
+
+// Retention returns how many days the activity logs of a plan's accounts
+// are kept
+func Retention(cfg *config.Config, plan string) int {
+	days, ok := Days[strings.ToLower(plan)]
+	if !ok {
+		days = DefaultDays
+	}
+	return cfg.ActivityRetention(plan, days)
+}
+
+// NewPolicy computes the cutoff of every plan at now
+func NewPolicy(cfg *config.Config, now time.Time) Policy {
+	cutoff := func(days int) time.Time { return now.AddDate(0, 0, -days) }
+
+	names := slices.Sorted(maps.Keys(Days))
+	for plan := range cfg.ActivityRetentionDays {
+		if !slices.Contains(names, plan) {
+			names = append(names, plan)
+		}
+	}
+
+	policy := Policy{Default: cutoff(DefaultDays)}
+	policy.Newest = policy.Default
+	for _, plan := range names {
+		at := cutoff(Retention(cfg, plan))
+		policy.Plans = append(policy.Plans, plan)
+		policy.Cutoffs = append(policy.Cutoffs, at)
+		if at.After(policy.Newest) {
+			policy.Newest = at
+		}
+	}
+	return policy
+}
+
+// Pruner deletes expired activity logs
+type Pruner struct {
+	queries *db.Queries
+	cfg     *config.Config
+	store   backup.Store
+	now     func() time.Time
+}
+
+// New creates a pruner. Expired entries are exported to store before they
+// are deleted, unless it is nil.
+func New(queries *db.Queries, cfg *config.Config, store backup.Store) *Pruner {
+	return &Pruner{
+		queries: queries,
+		cfg:     cfg,
+		store:   store,
+		now:     time.Now,
+	}
+}
+
+// Prune deletes expired activity logs, oldest first. A batch whose export
+// fails is kept for the next run. Entries a legal hold covers are never
+// expired.
+func (p *Pruner) Prune(ctx context.Context) error {
+	policy := NewPolicy(p.cfg, p.now())
+
+	var deleted int64
+	for range maxBatches {
+		logs, err := p.queries.ListExpiredActivityLogs(ctx, db.ListExpiredActivityLogsParams{
+			NewestCutoff:  policy.Newest,
+			Plans:         policy.Plans,
+			Cutoffs:       policy.Cutoffs,
+			DefaultCutoff: policy.Default,
+			BatchSize:     BatchSize,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list expired activity logs: %w", err)
+		}
+		if len(logs) == 0 {
+			break
+		}
+
+		if p.store != nil {
+			data, err := Archive(logs, p.cfg.EncryptionKey)
+			if err != nil {
+				return err
+			}
+			if err := p.store.Put(ctx, ArchiveKey(logs), data); err != nil {
+				return fmt.Errorf("failed to export activity logs: %w", err)
+			}
+		}
+
+		ids := make([]uuid.UUID, len(logs))
+		for i, log := range logs {
+			ids[i] = log.ID
+		}
+		n, err := p.queries.DeleteActivityLogs(ctx, ids)
+		if err != nil {
+			return fmt.Errorf("failed to delete activity logs: %w", err)
+		}
+		deleted += n
+
+		if len(logs) < BatchSize {
+			break
+		}
+	}
+
+	if deleted > 0 {
+		slog.Info("pruned activity logs", "deleted", deleted, "archived", p.store != nil)
+	}
+	return nil
+}
+
+// Entry is an activity log entry as exported
+type Entry struct {
+	ID         uuid.UUID       `json:"id"`
+	UserID     *uuid.UUID      `json:"user_id,omitempty"`
+	AppID      *uuid.UUID      `json:"app_id,omitempty"`
+	APITokenID *uuid.UUID      `json:"api_token_id,omitempty"`
+	Action     string          `json:"action"`
+	Details    json.RawMessage `json:"details,omitempty"`
+	IPAddress  string          `json:"ip_address,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// ArchiveKey is where a batch is exported: under the day of its oldest
+// entry, named after it, so a batch exported again after failing to delete
+// replaces its earlier export
+func ArchiveKey(logs []db.ActivityLog) string {
+	oldest := logs[0]
+	return fmt.Sprintf("%s%s/%s%s", archivePrefix, oldest.CreatedAt.UTC().Format("2006/01/02"), oldest.ID, archiveSuffix)
+}
+
+// Archive renders entries as gzip-compressed JSON lines, encrypted with
+// encryptionKey
+func Archive(logs []db.ActivityLog, encryptionKey string) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	encoder := json.NewEncoder(gz)
+	for _, log := range logs {
+		entry := Entry{
+			ID:         log.ID,
+			UserID:     optionalUUID(log.UserID),
+			AppID:      optionalUUID(log.AppID),
+			APITokenID: optionalUUID(log.ApiTokenID),
+			Action:     log.Action,
+			Details:    log.Details,
+			CreatedAt:  log.CreatedAt,
+		}
+		if log.IpAddress != nil {
+			entry.IPAddress = log.IpAddress.String()
+		}
+		if err := encoder.Encode(entry); err != nil {
+			return nil, fmt.Errorf("failed to archive activity log %s: %w", log.ID, err)
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return cryptoutil.EncryptBytes(buf.Bytes(), encryptionKey)
+}
+
+// OpenArchive decrypts and decodes an export
+func OpenArchive(data []byte, encryptionKey string) ([]Entry, error) {
+	plaintext, err := cryptoutil.DecryptBytes(data, encryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt archive: %w", err)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(plaintext))
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive: %w", err)
+	}
+
+	var entries []Entry
+	decoder := json.NewDecoder(gz)
+	for {
+		var entry Entry
+		err := decoder.Decode(&entry)
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid archive: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+}
+
+func optionalUUID(id pgtype.UUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	value := uuid.UUID(id.Bytes)
+	return &value
+}
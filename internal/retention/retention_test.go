@@ -0,0 +1,97 @@
+package retention
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const testKey = "0123456789abcdef0123456789abcdef"
+
+func TestRetention(t *testing.T) {
+	cfg := &config.Config{ActivityRetentionDays: map[string]string{"pro": "180"}}
+
+	tests := map[string]int{
+		"free":       30,
+		"pro":        180,
+		"Enterprise": 365,
+		"unknown":    DefaultDays,
+	}
+	for plan, want := range tests {
+		if got := Retention(cfg, plan); got != want {
+			t.Errorf("Retention(%q) = %d, want %d", plan, got, want)
+		}
+	}
+}
+
+func TestNewPolicy(t *testing.T) {
+	now := time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC)
+	cfg := &config.Config{ActivityRetentionDays: map[string]string{"team": "7"}}
+
+	policy := NewPolicy(cfg, now)
+	cutoffs := map[string]time.Time{}
+	for i, plan := range policy.Plans {
+		cutoffs[plan] = policy.Cutoffs[i]
+	}
+	if len(cutoffs) != 4 || !cutoffs["enterprise"].Equal(now.AddDate(0, 0, -365)) || !cutoffs["pro"].Equal(now.AddDate(0, 0, -90)) {
+		t.Errorf("unexpected cutoffs %v", cutoffs)
+	}
+	// Plans only known from the configuration get their override
+	if !cutoffs["team"].Equal(now.AddDate(0, 0, -7)) {
+		t.Errorf("expected the team override, got %v", cutoffs["team"])
+	}
+	if !policy.Default.Equal(now.AddDate(0, 0, -DefaultDays)) || !policy.Newest.Equal(cutoffs["team"]) {
+		t.Errorf("unexpected default %v and newest %v cutoffs", policy.Default, policy.Newest)
+	}
+}
+
+func TestArchive(t *testing.T) {
+	ip := netip.MustParseAddr("203.0.113.7")
+	userID := uuid.New()
+	created := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	logs := []db.ActivityLog{
+		{ID: uuid.New(), UserID: pgtype.UUID{Bytes: userID, Valid: true}, Action: "app.deployed", Details: []byte(`{"version": 3}`), IpAddress: &ip, CreatedAt: created},
+		{ID: uuid.New(), Action: "security.auth_failed", CreatedAt: created.Add(time.Hour)},
+	}
+
+	data, err := Archive(logs, testKey)
+	if err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+	if strings.Contains(string(data), "app.deployed") {
+		t.Error("expected the archive to be encrypted")
+	}
+
+	entries, err := OpenArchive(data, testKey)
+	if err != nil {
+		t.Fatalf("OpenArchive failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	first := entries[0]
+	if first.ID != logs[0].ID || first.UserID == nil || *first.UserID != userID || first.IPAddress != "203.0.113.7" || !first.CreatedAt.Equal(created) {
+		t.Errorf("unexpected entry %+v", first)
+	}
+	if string(first.Details) != `{"version":3}` {
+		t.Errorf("expected the details, got %s", first.Details)
+	}
+	if second := entries[1]; second.UserID != nil || second.AppID != nil || second.IPAddress != "" || second.Details != nil {
+		t.Errorf("expected empty fields to be omitted, got %+v", second)
+	}
+
+	if _, err := OpenArchive(data, "fedcba9876543210fedcba9876543210"); err == nil {
+		t.Error("expected the wrong key to fail")
+	}
+
+	want := "activity/2025/07/01/" + logs[0].ID.String() + ".jsonl.gz.enc"
+	if key := ArchiveKey(logs); key != want {
+		t.Errorf("expected key %s, got %s", want, key)
+	}
+}
@@ -0,0 +1,108 @@
+package rightsizing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/events"
+	"github.com/google/uuid"
+)
+
+// EventWeeklyReport is published to owners with apps that should be resized
+const EventWeeklyReport = "rightsizing.weekly_report"
+
+// Window is how much usage recommendations are based on
+const Window = 7 * 24 * time.Hour
+
+// AppRecommendation is the recommendation for one app of an owner
+type AppRecommendation struct {
+	AppName string
+	Recommendation
+}
+
+// Reporter sends the weekly right-sizing summary
+type Reporter struct {
+	queries *db.Queries
+	events  events.Publisher
+	now     func() time.Time
+}
+
+// NewReporter creates a reporter
+func NewReporter(queries *db.Queries, publisher events.Publisher) *Reporter {
+	return &Reporter{
+		queries: queries,
+		events:  publisher,
+		now:     time.Now,
+	}
+}
+
+// Report publishes a summary to every owner with an app that should be
+// resized, based on the usage of the last Window
+func (r *Reporter) Report(ctx context.Context) error {
+	rows, err := r.queries.ListAppResourceUsage(ctx, r.now().Add(-Window))
+	if err != nil {
+		return fmt.Errorf("failed to list usage: %w", err)
+	}
+
+	// Rows are ordered by owner
+	for start := 0; start < len(rows); {
+		end := start
+		var recs []AppRecommendation
+		for ; end < len(rows) && rows[end].UserID == rows[start].UserID; end++ {
+			row := rows[end]
+			recs = append(recs, AppRecommendation{
+				AppName: row.AppName,
+				Recommendation: Recommend(row.Size, NewUsage(db.GetAppResourceUsageRow{
+					Hours:          row.Hours,
+					Samples:        row.Samples,
+					PodSamples:     row.PodSamples,
+					CpuMillisSum:   row.CpuMillisSum,
+					CpuMillisMax:   row.CpuMillisMax,
+					MemoryBytesSum: row.MemoryBytesSum,
+					MemoryBytesMax: row.MemoryBytesMax,
+				})),
+			})
+		}
+
+		if event, ok := Summarize(rows[start].UserID, recs); ok {
+			if err := r.events.Publish(ctx, event); err != nil {
+				slog.Error("failed to send right-sizing report", "user_id", rows[start].UserID, "error", err)
+			}
+		}
+		start = end
+	}
+	return nil
+}
+
+// Summarize builds the weekly report of an owner, false when none of the
+// apps should be resized. Every resized app is a payload entry, so the
+// email lists one per line.
+func Summarize(userID uuid.UUID, recs []AppRecommendation) (events.Event, bool) {
+	var changes []string
+	var delta int64
+	payload := map[string]any{}
+	for _, rec := range recs {
+		if rec.Direction == DirectionKeep {
+			continue
+		}
+		change := fmt.Sprintf("%s → %s (%s/month)", rec.Size, rec.RecommendedSize, FormatCents(rec.MonthlyCostDeltaCents))
+		changes = append(changes, rec.AppName+" "+change)
+		payload[rec.AppName] = change + ": " + rec.Reason
+		delta += rec.MonthlyCostDeltaCents
+	}
+	if len(changes) == 0 {
+		return events.Event{}, false
+	}
+	payload["monthly_cost_delta_cents"] = delta
+
+	return events.Event{
+		Type:    EventWeeklyReport,
+		UserID:  userID,
+		Message: fmt.Sprintf("weekly right-sizing: %s, %s/month in total", strings.Join(changes, ", "), FormatCents(delta)),
+		Payload: payload,
+	}, true
+}
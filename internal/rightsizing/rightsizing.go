@@ -0,0 +1,154 @@
+// Package rightsizing recommends app sizes from measured usage. A sampler
+// records the CPU and memory of every app's pods from metrics-server in
+// hourly buckets; the last week is compared with the resources of the app's
+// size to suggest a smaller or larger one with its monthly cost delta, and
+// owners get a weekly summary.
+package rightsizing
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/plans"
+)
+
+// Directions of a recommendation
+const (
+	DirectionKeep    = "keep"
+	DirectionSmaller = "smaller"
+	DirectionLarger  = "larger"
+)
+
+// MinHours is how many hours of usage an app needs before it is
+// recommended another size
+const MinHours = 24
+
+// Thresholds as fractions of the resources of a size. CPU is compared on
+// average since it is throttled, memory at its peak since it is not.
+const (
+	// Above these the app needs a larger size
+	maxAvgCPU     = 0.8
+	maxPeakMemory = 0.9
+	// Peaks below this of the smaller size leave it enough headroom
+	smallerHeadroom = 0.6
+)
+
+// Usage is the per pod usage of an app over the analyzed window
+type Usage struct {
+	Hours           int64   `json:"hours"`
+	AvgReplicas     float64 `json:"avg_replicas"`
+	AvgCPUMillis    int64   `json:"avg_cpu_millis"`
+	PeakCPUMillis   int64   `json:"peak_cpu_millis"`
+	AvgMemoryBytes  int64   `json:"avg_memory_bytes"`
+	PeakMemoryBytes int64   `json:"peak_memory_bytes"`
+}
+
+// NewUsage derives the usage from the summed hourly buckets of an app
+func NewUsage(row db.GetAppResourceUsageRow) Usage {
+	usage := Usage{
+		Hours:           row.Hours,
+		PeakCPUMillis:   row.CpuMillisMax,
+		PeakMemoryBytes: row.MemoryBytesMax,
+	}
+	if row.Samples > 0 {
+		usage.AvgReplicas = float64(row.PodSamples) / float64(row.Samples)
+	}
+	if row.PodSamples > 0 {
+		usage.AvgCPUMillis = row.CpuMillisSum / row.PodSamples
+		usage.AvgMemoryBytes = row.MemoryBytesSum / row.PodSamples
+	}
+	return usage
+}
+
+// Recommendation is the size an app should run at
+type Recommendation struct {
+	Size            string `json:"size"`
+	RecommendedSize string `json:"recommended_size"`
+	Direction       string `json:"direction"`
+	Reason          string `json:"reason"`
+	// MonthlyCostDeltaCents is what the recommended size changes the
+	// monthly bill by in USD cents at the average replica count; negative
+	// values are savings
+	MonthlyCostDeltaCents int64 `json:"monthly_cost_delta_cents"`
+	Usage                 Usage `json:"usage"`
+}
+
+// Recommend compares the usage of an app with the resources of its size
+func Recommend(size string, usage Usage) Recommendation {
+	plan := plans.ForSize(size)
+	rec := Recommendation{
+		Size:            plan.Name,
+		RecommendedSize: plan.Name,
+		Direction:       DirectionKeep,
+		Usage:           usage,
+	}
+
+	if usage.Hours < MinHours {
+		rec.Reason = fmt.Sprintf("not enough usage measured yet (%d of %d hours)", usage.Hours, MinHours)
+		return rec
+	}
+
+	cpuPressure := float64(usage.AvgCPUMillis) > maxAvgCPU*float64(plan.CPUMillis)
+	memoryPressure := float64(usage.PeakMemoryBytes) > maxPeakMemory*float64(plan.MemoryBytes)
+	if cpuPressure || memoryPressure {
+		larger, ok := plans.Larger(plan.Name)
+		if !ok {
+			rec.Reason = fmt.Sprintf("usage is close to the resources of the largest size, scale out with more replicas (%s)", pressure(plan, usage, cpuPressure))
+			return rec
+		}
+		rec.RecommendedSize = larger.Name
+		rec.Direction = DirectionLarger
+		rec.Reason = pressure(plan, usage, cpuPressure)
+		rec.MonthlyCostDeltaCents = costDelta(plan, larger, usage)
+		return rec
+	}
+
+	if smaller, ok := plans.Smaller(plan.Name); ok &&
+		float64(usage.PeakCPUMillis) <= smallerHeadroom*float64(smaller.CPUMillis) &&
+		float64(usage.PeakMemoryBytes) <= smallerHeadroom*float64(smaller.MemoryBytes) {
+		rec.RecommendedSize = smaller.Name
+		rec.Direction = DirectionSmaller
+		rec.Reason = fmt.Sprintf("peak usage of %dm CPU and %s memory per replica fits the %s size (%dm CPU, %s)",
+			usage.PeakCPUMillis, mib(usage.PeakMemoryBytes), smaller.Name, smaller.CPUMillis, mib(smaller.MemoryBytes))
+		rec.MonthlyCostDeltaCents = costDelta(plan, smaller, usage)
+		return rec
+	}
+
+	rec.Reason = fmt.Sprintf("usage fits the %s size", plan.Name)
+	return rec
+}
+
+// pressure explains which resource outgrows a size
+func pressure(plan plans.Plan, usage Usage, cpu bool) string {
+	if cpu {
+		return fmt.Sprintf("average CPU of %dm per replica uses %d%% of the %dm of the %s size",
+			usage.AvgCPUMillis, percent(usage.AvgCPUMillis, plan.CPUMillis), plan.CPUMillis, plan.Name)
+	}
+	return fmt.Sprintf("peak memory of %s per replica uses %d%% of the %s of the %s size",
+		mib(usage.PeakMemoryBytes), percent(usage.PeakMemoryBytes, plan.MemoryBytes), mib(plan.MemoryBytes), plan.Name)
+}
+
+// costDelta is the monthly price difference of the sizes at the average
+// replica count, counting at least one replica
+func costDelta(from, to plans.Plan, usage Usage) int64 {
+	replicas := max(int64(math.Round(usage.AvgReplicas)), 1)
+	return (to.MonthlyPriceCents - from.MonthlyPriceCents) * replicas
+}
+
+func percent(value, of int64) int64 {
+	return value * 100 / of
+}
+
+func mib(bytes int64) string {
+	return fmt.Sprintf("%d MiB", bytes>>20)
+}
+
+// FormatCents formats a cost delta in USD cents, such as -$18.00
+func FormatCents(cents int64) string {
+	sign := "+"
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s$%d.%02d", sign, cents/100, cents%100)
+}
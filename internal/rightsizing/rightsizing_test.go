@@ -0,0 +1,115 @@
+package rightsizing
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/google/uuid"
+)
+
+func TestNewUsage(t *testing.T) {
+	usage := NewUsage(db.GetAppResourceUsageRow{
+		Hours:          48,
+		Samples:        576,
+		PodSamples:     1152,
+		CpuMillisSum:   115200,
+		CpuMillisMax:   300,
+		MemoryBytesSum: 1152 * (200 << 20),
+		MemoryBytesMax: 400 << 20,
+	})
+
+	if usage.AvgReplicas != 2 || usage.AvgCPUMillis != 100 || usage.AvgMemoryBytes != 200<<20 {
+		t.Errorf("unexpected averages %+v", usage)
+	}
+	if usage.PeakCPUMillis != 300 || usage.PeakMemoryBytes != 400<<20 {
+		t.Errorf("unexpected peaks %+v", usage)
+	}
+
+	if empty := NewUsage(db.GetAppResourceUsageRow{}); empty.AvgReplicas != 0 || empty.AvgCPUMillis != 0 {
+		t.Errorf("expected no usage, got %+v", empty)
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      string
+		usage     Usage
+		direction string
+		target    string
+		delta     int64
+	}{
+		{"too little data", "pro", Usage{Hours: 3}, DirectionKeep, "pro", 0},
+		{"memory pressure", "starter", Usage{Hours: 168, AvgReplicas: 2, AvgCPUMillis: 50, PeakCPUMillis: 100, PeakMemoryBytes: 500 << 20}, DirectionLarger, "pro", 3600},
+		{"cpu pressure", "starter", Usage{Hours: 168, AvgReplicas: 1, AvgCPUMillis: 220, PeakCPUMillis: 250, PeakMemoryBytes: 100 << 20}, DirectionLarger, "pro", 1800},
+		{"oversized", "pro", Usage{Hours: 168, AvgReplicas: 3, AvgCPUMillis: 40, PeakCPUMillis: 120, PeakMemoryBytes: 200 << 20}, DirectionSmaller, "starter", -5400},
+		{"fits", "pro", Usage{Hours: 168, AvgReplicas: 1, AvgCPUMillis: 400, PeakCPUMillis: 900, PeakMemoryBytes: 1 << 30}, DirectionKeep, "pro", 0},
+		{"largest size", "enterprise", Usage{Hours: 168, AvgReplicas: 1, AvgCPUMillis: 3900, PeakMemoryBytes: 1 << 30}, DirectionKeep, "enterprise", 0},
+		{"smallest size", "starter", Usage{Hours: 168, AvgReplicas: 1, AvgCPUMillis: 1, PeakCPUMillis: 2, PeakMemoryBytes: 1 << 20}, DirectionKeep, "starter", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := Recommend(tt.size, tt.usage)
+			if rec.Direction != tt.direction || rec.RecommendedSize != tt.target || rec.MonthlyCostDeltaCents != tt.delta {
+				t.Errorf("expected %s to %s (%d), got %+v", tt.direction, tt.target, tt.delta, rec)
+			}
+			if rec.Reason == "" {
+				t.Error("expected a reason")
+			}
+		})
+	}
+}
+
+func TestFormatCents(t *testing.T) {
+	for cents, want := range map[int64]string{1800: "+$18.00", -5405: "-$54.05", 0: "+$0.00"} {
+		if got := FormatCents(cents); got != want {
+			t.Errorf("FormatCents(%d) = %q, want %q", cents, got, want)
+		}
+	}
+}
+
+func TestBucket(t *testing.T) {
+	appID := uuid.New()
+	hour := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	params := Bucket(appID, hour, []k8s.PodUsage{
+		{Name: "web-1", CPUMillis: 100, MemoryBytes: 300},
+		{Name: "web-2", CPUMillis: 250, MemoryBytes: 200},
+	})
+
+	if params.AppID != appID || !params.PeriodStart.Equal(hour) || params.PodSamples != 2 {
+		t.Errorf("unexpected bucket %+v", params)
+	}
+	if params.CpuMillisSum != 350 || params.CpuMillisMax != 250 || params.MemoryBytesSum != 500 || params.MemoryBytesMax != 300 {
+		t.Errorf("unexpected usage %+v", params)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	userID := uuid.New()
+	keep := AppRecommendation{AppName: "api", Recommendation: Recommendation{Direction: DirectionKeep}}
+	if _, ok := Summarize(userID, []AppRecommendation{keep}); ok {
+		t.Error("expected no report when every app fits its size")
+	}
+
+	smaller := AppRecommendation{AppName: "web", Recommendation: Recommendation{
+		Size: "pro", RecommendedSize: "starter", Direction: DirectionSmaller, Reason: "fits", MonthlyCostDeltaCents: -1800,
+	}}
+	larger := AppRecommendation{AppName: "worker", Recommendation: Recommendation{
+		Size: "starter", RecommendedSize: "pro", Direction: DirectionLarger, Reason: "memory", MonthlyCostDeltaCents: 3600,
+	}}
+
+	event, ok := Summarize(userID, []AppRecommendation{keep, smaller, larger})
+	if !ok || event.Type != EventWeeklyReport || event.UserID != userID {
+		t.Fatalf("unexpected report %+v", event)
+	}
+	if !strings.Contains(event.Message, "web pro → starter (-$18.00/month)") || !strings.Contains(event.Message, "+$18.00/month in total") {
+		t.Errorf("unexpected message %q", event.Message)
+	}
+	if _, ok := event.Payload["api"]; ok || event.Payload["worker"] == nil || event.Payload["monthly_cost_delta_cents"] != int64(1800) {
+		t.Errorf("unexpected payload %+v", event.Payload)
+	}
+}
@@ -0,0 +1,97 @@
+package rightsizing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/google/uuid"
+)
+
+// Period is the size of the usage buckets
+const Period = time.Hour
+
+// Retention is how long usage buckets are kept
+const Retention = 30 * 24 * time.Hour
+
+// Sampler records the resource usage of apps
+type Sampler struct {
+	queries *db.Queries
+	cfg     *config.Config
+	clients func(kubeconfig string) (k8s.Interface, error)
+	now     func() time.Time
+}
+
+// NewSampler creates a sampler connecting to clusters with clients
+func NewSampler(queries *db.Queries, cfg *config.Config, clients func(kubeconfig string) (k8s.Interface, error)) *Sampler {
+	return &Sampler{
+		queries: queries,
+		cfg:     cfg,
+		clients: clients,
+		now:     time.Now,
+	}
+}
+
+// Sample records the current usage of the pods of every app once and drops
+// buckets past the retention. A region whose metrics cannot be read is
+// logged and skipped until the next sample.
+func (s *Sampler) Sample(ctx context.Context) error {
+	periodStart := s.now().Truncate(Period)
+
+	for _, region := range s.cfg.Regions {
+		apps, err := s.queries.ListAppsByRegion(ctx, region)
+		if err != nil {
+			return fmt.Errorf("failed to list apps: %w", err)
+		}
+		if len(apps) == 0 {
+			continue
+		}
+
+		client, err := s.clients(s.cfg.KubeconfigForRegion(region))
+		if err != nil {
+			slog.Warn("kubernetes not available for region", "region", region, "error", err)
+			continue
+		}
+
+		for _, app := range apps {
+			usage, err := client.ListPodUsage(ctx, app.Name)
+			if err != nil {
+				slog.Warn("failed to read pod usage", "region", region, "app", app.Name, "error", err)
+				break
+			}
+			if len(usage) == 0 {
+				continue
+			}
+
+			if err := s.queries.AddAppResourceUsage(ctx, Bucket(app.ID, periodStart, usage)); err != nil {
+				slog.Error("failed to record resource usage", "app", app.Name, "error", err)
+			}
+		}
+	}
+
+	if err := s.queries.DeleteAppResourceUsageBefore(ctx, s.now().Add(-Retention)); err != nil {
+		return fmt.Errorf("failed to delete old usage: %w", err)
+	}
+	return nil
+}
+
+// Bucket sums one sample of an app's pods into the parameters adding it to
+// its hourly bucket
+func Bucket(appID uuid.UUID, periodStart time.Time, usage []k8s.PodUsage) db.AddAppResourceUsageParams {
+	params := db.AddAppResourceUsageParams{
+		AppID:       appID,
+		PeriodStart: periodStart,
+		PodSamples:  int32(len(usage)),
+	}
+	for _, pod := range usage {
+		params.CpuMillisSum += pod.CPUMillis
+		params.CpuMillisMax = max(params.CpuMillisMax, pod.CPUMillis)
+		params.MemoryBytesSum += pod.MemoryBytes
+		params.MemoryBytesMax = max(params.MemoryBytesMax, pod.MemoryBytes)
+	}
+	return params
+}
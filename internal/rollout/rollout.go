@@ -0,0 +1,188 @@
+// Package rollout applies an accepted deployment to the cluster of its app's
+// region. It runs the deploy hooks and the rollout, records every hook run on
+// the deployment and moves the deployment, and the app while the deployment
+// is still its current one, to running or failed.
+package rollout
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appconfig"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appstatus"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Timeout bounds a rollout, hooks and the wait for an earlier rollout of
+// the app included
+const Timeout = 30 * time.Minute
+
+// locks holds a lock per app ID, so the rollouts of an app in this process
+// run one at a time in the order they take it
+var locks sync.Map
+
+// Start rolls a deployment out in the background, so the request accepting
+// it returns while the rollout runs. release is called once the rollout
+// ended, freeing the deploy slot the request took.
+func Start(cfg *config.Config, queries *db.Queries, cluster k8s.Interface, app db.App, deployment db.Deployment, release func()) {
+	go func() {
+		defer release()
+		ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+		defer cancel()
+		if err := Run(ctx, cfg, queries, cluster, app, deployment); err != nil {
+			slog.Error("rollout failed", "app", app.Name, "deployment", deployment.ID, "error", err)
+		}
+	}()
+}
+
+// Run rolls a deployment out and records its outcome. A failed rollout is
+// recorded on the deployment; the error is for failing to record it. ctx
+// bounds the rollout, the outcome is recorded after it ends.
+//
+// Rollouts of an app wait for each other. A deployment a newer one replaced
+// as the app's current deployment while it waited is failed as superseded
+// without touching the cluster, so an older image never replaces a newer one.
+func Run(ctx context.Context, cfg *config.Config, queries *db.Queries, cluster k8s.Interface, app db.App, deployment db.Deployment) error {
+	record := context.WithoutCancel(ctx)
+
+	unlock, err := lock(ctx, app.ID)
+	if err != nil {
+		return fail(record, queries, deployment, k8s.FailureInterrupted, "timed out waiting for an earlier rollout of the app")
+	}
+	defer unlock()
+
+	current, err := queries.GetAppByID(ctx, app.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get app: %w", err)
+	}
+	if current.CurrentDeploymentID != (pgtype.UUID{Bytes: deployment.ID, Valid: true}) {
+		return fail(record, queries, deployment, k8s.FailureSuperseded, "a newer deployment replaced this one before it rolled out")
+	}
+
+	if _, err := queries.UpdateDeploymentStarted(ctx, deployment.ID); err != nil {
+		return fmt.Errorf("failed to start deployment: %w", err)
+	}
+
+	appConfig, err := appconfig.Load(ctx, cfg, queries, current, deployment)
+	if err != nil {
+		return fail(record, queries, deployment, k8s.FailureUnknown, "failed to load app configuration: "+err.Error())
+	}
+
+	// A failed rollout still reports the hooks that ran before it failed
+	result, err := cluster.Deploy(ctx, appConfig)
+	if result != nil {
+		if err := RecordHooks(record, queries, deployment.ID, result.Hooks); err != nil {
+			return err
+		}
+	}
+	if err != nil {
+		reason, message := k8s.FailureOf(err)
+		return fail(record, queries, deployment, reason, message)
+	}
+
+	if !result.Success {
+		return fail(record, queries, deployment, result.Reason, result.Message)
+	}
+
+	if _, err := queries.UpdateDeploymentReady(record, deployment.ID); err != nil {
+		return fmt.Errorf("failed to mark deployment ready: %w", err)
+	}
+	return finish(record, queries, deployment, db.AppStatusRunning)
+}
+
+// Recover fails the deployments whose rollout ended without recording an
+// outcome, such as when the process running it restarted. No rollout runs
+// longer than Timeout, so only deployments building for longer are failed.
+func Recover(ctx context.Context, queries *db.Queries) error {
+	stale, err := queries.ListStaleDeployments(ctx, time.Now().Add(-Timeout))
+	if err != nil {
+		return fmt.Errorf("failed to list stale deployments: %w", err)
+	}
+	for _, deployment := range stale {
+		if err := fail(ctx, queries, deployment, k8s.FailureInterrupted, "the rollout was interrupted before it finished"); err != nil {
+			return err
+		}
+		slog.Warn("failed interrupted rollout", "deployment", deployment.ID)
+	}
+	return nil
+}
+
+// lock takes the rollout lock of an app, waiting until ctx ends
+func lock(ctx context.Context, appID uuid.UUID) (unlock func(), err error) {
+	l, _ := locks.LoadOrStore(appID, make(chan struct{}, 1))
+	ch := l.(chan struct{})
+	select {
+	case ch <- struct{}{}:
+		return func() { <-ch }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// RecordHooks stores the hook runs of a rollout on its deployment
+func RecordHooks(ctx context.Context, queries *db.Queries, deploymentID uuid.UUID, hooks []*k8s.HookResult) error {
+	for _, hook := range hooks {
+		if _, err := queries.CreateDeploymentHookRun(ctx, db.CreateDeploymentHookRunParams{
+			DeploymentID: deploymentID,
+			Phase:        hook.Phase,
+			Command:      hook.Command,
+			JobName:      hook.JobName,
+			Status:       hook.Status,
+			Output:       optional(hook.Output),
+			Error:        optional(hook.Error),
+			StartedAt:    timestamp(hook.StartedAt),
+			FinishedAt:   timestamp(hook.FinishedAt),
+		}); err != nil {
+			return fmt.Errorf("failed to record %s hook: %w", hook.Phase, err)
+		}
+	}
+	return nil
+}
+
+// fail records a failed rollout on the deployment and its app
+func fail(ctx context.Context, queries *db.Queries, deployment db.Deployment, reason k8s.FailureReason, message string) error {
+	failureReason := string(reason)
+	if _, err := queries.UpdateDeploymentFailed(ctx, db.UpdateDeploymentFailedParams{
+		ID:            deployment.ID,
+		Error:         &message,
+		FailureReason: &failureReason,
+	}); err != nil {
+		return fmt.Errorf("failed to mark deployment failed: %w", err)
+	}
+	return finish(ctx, queries, deployment, db.AppStatusFailed)
+}
+
+// finish moves the app to status unless a newer deployment replaced this one
+// or the app moved on, such as being paused, while it rolled out
+func finish(ctx context.Context, queries *db.Queries, deployment db.Deployment, status db.AppStatus) error {
+	app, err := queries.GetAppByID(ctx, deployment.AppID)
+	if err != nil {
+		return fmt.Errorf("failed to get app: %w", err)
+	}
+	current := pgtype.UUID{Bytes: deployment.ID, Valid: true}
+	if app.CurrentDeploymentID != current || app.Status != db.AppStatusDeploying {
+		return nil
+	}
+	if _, err := appstatus.Set(ctx, queries, app, status, current); err != nil {
+		return fmt.Errorf("failed to update app status: %w", err)
+	}
+	return nil
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func timestamp(t time.Time) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: t, Valid: !t.IsZero()}
+}
@@ -0,0 +1,39 @@
+package rollout
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestLock(t *testing.T) {
+	app, other := uuid.New(), uuid.New()
+
+	unlock, err := lock(context.Background(), app)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Another app's rollouts do not wait
+	unlockOther, err := lock(context.Background(), other)
+	if err != nil {
+		t.Fatalf("expected another app's lock to be free, got %v", err)
+	}
+	unlockOther()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := lock(ctx, app); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a second rollout of the app to wait, got %v", err)
+	}
+
+	unlock()
+	unlock, err = lock(context.Background(), app)
+	if err != nil {
+		t.Fatalf("expected the lock once released, got %v", err)
+	}
+	unlock()
+}
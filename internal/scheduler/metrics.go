@@ -0,0 +1,103 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// results are the outcomes counted per job
+var results = []string{"success", "failure", "panic", "skipped"}
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]*stats{}
+)
+
+// stats are the metrics of a job
+type stats struct {
+	mu          sync.Mutex
+	runs        map[string]uint64
+	lastRun     time.Time
+	lastSuccess time.Time
+	lastTook    time.Duration
+}
+
+// register returns the metrics of a job, creating them on first use
+func register(name string) *stats {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	st, ok := registry[name]
+	if !ok {
+		st = &stats{runs: make(map[string]uint64)}
+		registry[name] = st
+	}
+	return st
+}
+
+func (st *stats) finished(start time.Time, took time.Duration, err error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.lastRun = start
+	st.lastTook = took
+	var panicErr *PanicError
+	switch {
+	case errors.As(err, &panicErr):
+		st.runs["panic"]++
+	case err != nil:
+		st.runs["failure"]++
+	default:
+		st.runs["success"]++
+		st.lastSuccess = start
+	}
+}
+
+func (st *stats) skipped() {
+	st.mu.Lock()
+	st.runs["skipped"]++
+	st.mu.Unlock()
+}
+
+// Metrics renders the job metrics in Prometheus exposition format
+func Metrics() string {
+	registryMu.Lock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	registryMu.Unlock()
+	sort.Strings(names)
+
+	var runs, durations, successes strings.Builder
+	for _, name := range names {
+		registryMu.Lock()
+		st := registry[name]
+		registryMu.Unlock()
+
+		st.mu.Lock()
+		for _, result := range results {
+			fmt.Fprintf(&runs, "fuego_cloud_job_runs_total{job=%q,result=%q} %d\n", name, result, st.runs[result])
+		}
+		if !st.lastRun.IsZero() {
+			fmt.Fprintf(&durations, "fuego_cloud_job_last_duration_seconds{job=%q} %.3f\n", name, st.lastTook.Seconds())
+		}
+		if !st.lastSuccess.IsZero() {
+			fmt.Fprintf(&successes, "fuego_cloud_job_last_success_timestamp_seconds{job=%q} %d\n", name, st.lastSuccess.Unix())
+		}
+		st.mu.Unlock()
+	}
+
+	var b strings.Builder
+	b.WriteString("\n# HELP fuego_cloud_job_runs_total Background job runs by result\n# TYPE fuego_cloud_job_runs_total counter\n")
+	b.WriteString(runs.String())
+	b.WriteString("\n# HELP fuego_cloud_job_last_duration_seconds Duration of the last run of each background job\n# TYPE fuego_cloud_job_last_duration_seconds gauge\n")
+	b.WriteString(durations.String())
+	b.WriteString("\n# HELP fuego_cloud_job_last_success_timestamp_seconds Start time of the last successful run of each background job\n# TYPE fuego_cloud_job_last_success_timestamp_seconds gauge\n")
+	b.WriteString(successes.String())
+	return b.String()
+}
@@ -0,0 +1,177 @@
+// Package scheduler runs the control plane's periodic background jobs
+// (collectors, reapers, monitors...) on cron schedules. It adds jitter to
+// spread runs, skips pausable jobs during maintenance windows, recovers and
+// reports panics, keeps per-job metrics and lets operators disable jobs or
+// override their schedules through the configuration.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/errtrack"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/events"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/maintenance"
+)
+
+// Job is a periodic task
+type Job struct {
+	// Name identifies the job in logs, metrics, DISABLED_JOBS and
+	// JOB_SCHEDULE_<NAME>
+	Name string
+	// Schedule is the default schedule, see Parse
+	Schedule string
+	// Jitter delays every run by a random duration up to it
+	Jitter time.Duration
+	// Pausable jobs are skipped during maintenance windows
+	Pausable bool
+	Run      func(ctx context.Context) error
+}
+
+// PanicError is returned for a job that panicked
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Scheduler runs jobs on their schedules
+type Scheduler struct {
+	cfg     *config.Config
+	queries *db.Queries
+	events  events.Publisher
+	entries []*entry
+	now     func() time.Time
+}
+
+type entry struct {
+	job      Job
+	schedule Schedule
+	stats    *stats
+}
+
+// New creates a scheduler. Maintenance windows are looked up with queries
+// and panics are published as job.panicked events; either may be nil.
+func New(cfg *config.Config, queries *db.Queries, publisher events.Publisher) *Scheduler {
+	return &Scheduler{
+		cfg:     cfg,
+		queries: queries,
+		events:  publisher,
+		now:     time.Now,
+	}
+}
+
+// Add registers a job, applying the configured schedule override. A
+// disabled job is skipped. Add must be called before Run.
+func (s *Scheduler) Add(job Job) error {
+	if !s.cfg.JobEnabled(job.Name) {
+		slog.Info("background job disabled", "job", job.Name)
+		return nil
+	}
+
+	spec := s.cfg.JobSchedule(job.Name, job.Schedule)
+	schedule, err := Parse(spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", job.Name, err)
+	}
+	s.entries = append(s.entries, &entry{job: job, schedule: schedule, stats: register(job.Name)})
+	return nil
+}
+
+// Run runs the jobs until the context is canceled and returns once every
+// running job has stopped
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, e := range s.entries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(ctx, e)
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, e *entry) {
+	for {
+		next := e.schedule.Next(s.now())
+		if next.IsZero() {
+			slog.Error("background job has no upcoming runs", "job", e.job.Name)
+			return
+		}
+
+		delay := next.Sub(s.now())
+		if e.job.Jitter > 0 {
+			delay += rand.N(e.job.Jitter)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.run(ctx, e)
+	}
+}
+
+// run runs a job once and records the outcome
+func (s *Scheduler) run(ctx context.Context, e *entry) {
+	if e.job.Pausable && s.queries != nil && maintenance.Paused(ctx, s.queries, s.now()) {
+		slog.Info("skipping background job during maintenance", "job", e.job.Name)
+		e.stats.skipped()
+		return
+	}
+
+	start := time.Now()
+	err := call(ctx, e.job)
+	e.stats.finished(start, time.Since(start), err)
+
+	var panicErr *PanicError
+	switch {
+	case errors.As(err, &panicErr):
+		slog.Error("background job panicked", "job", e.job.Name, "panic", panicErr.Value, "stack", string(panicErr.Stack))
+		s.report(ctx, e.job.Name, panicErr)
+		errtrack.CaptureError(panicErr, map[string]string{"job": e.job.Name})
+	case err != nil && ctx.Err() == nil:
+		slog.Error("background job failed", "job", e.job.Name, "error", err)
+		errtrack.CaptureError(err, map[string]string{"job": e.job.Name})
+	}
+}
+
+// call runs a job, turning a panic into a PanicError
+func call(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return job.Run(ctx)
+}
+
+// report alerts the operators of a panic through the event bus
+func (s *Scheduler) report(ctx context.Context, name string, panicErr *PanicError) {
+	if s.events == nil {
+		return
+	}
+	err := s.events.Publish(ctx, events.Event{
+		Type:    "job.panicked",
+		Message: fmt.Sprintf("background job %s panicked: %v", name, panicErr.Value),
+		Payload: map[string]any{"job": name},
+	})
+	if err != nil {
+		slog.Error("failed to publish job panic", "job", name, "error", err)
+	}
+}
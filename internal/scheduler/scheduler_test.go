@@ -0,0 +1,210 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/events"
+)
+
+func mustParse(t *testing.T, spec string) Schedule {
+	t.Helper()
+	schedule, err := Parse(spec)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", spec, err)
+	}
+	return schedule
+}
+
+func TestParse_Next(t *testing.T) {
+	// A Wednesday
+	from := time.Date(2026, 3, 11, 10, 17, 30, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 3, 11, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 3, 11, 10, 30, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2026, 3, 11, 11, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 3, 11, 11, 0, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2026, 3, 12, 2, 30, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 3, 12, 0, 0, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2026, 3, 11, 13, 0, 0, 0, time.UTC)},
+		{"0 0 * * sun", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * mon-fri", time.Date(2026, 3, 12, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 * *", time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Either day matches when both are restricted
+		{"0 0 13 * 5", time.Date(2026, 3, 13, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * 1", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", from.Add(90 * time.Second)},
+	}
+
+	for _, tt := range tests {
+		if got := mustParse(t, tt.spec).Next(from); !got.Equal(tt.want) {
+			t.Errorf("%q.Next(%s) = %s, want %s", tt.spec, from, got, tt.want)
+		}
+	}
+}
+
+func TestParse_NeverMatches(t *testing.T) {
+	if next := mustParse(t, "0 0 30 2 *").Next(time.Now()); !next.IsZero() {
+		t.Errorf("expected no run on February 30th, got %s", next)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"@every",
+		"@every soon",
+		"@every 10ms",
+		"@fortnightly",
+	} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("expected %q to be invalid", spec)
+		}
+	}
+}
+
+type recorder struct {
+	mu     sync.Mutex
+	events []events.Event
+}
+
+func (r *recorder) Publish(_ context.Context, e events.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+	return nil
+}
+
+func TestRun_RecoversPanics(t *testing.T) {
+	publisher := &recorder{}
+	s := New(&config.Config{}, nil, publisher)
+	e := &entry{
+		job:   Job{Name: "test_panics", Run: func(context.Context) error { panic("boom") }},
+		stats: register("test_panics"),
+	}
+
+	s.run(context.Background(), e)
+	s.run(context.Background(), e)
+
+	if n := e.stats.runs["panic"]; n != 2 {
+		t.Errorf("expected 2 panics counted, got %d", n)
+	}
+	if len(publisher.events) != 2 || publisher.events[0].Type != "job.panicked" {
+		t.Fatalf("expected job.panicked events, got %+v", publisher.events)
+	}
+	if !strings.Contains(publisher.events[0].Message, "boom") {
+		t.Errorf("expected the panic value in the alert, got %q", publisher.events[0].Message)
+	}
+}
+
+func TestRun_Metrics(t *testing.T) {
+	s := New(&config.Config{}, nil, nil)
+	fail := true
+	e := &entry{
+		job: Job{Name: "test_metrics", Run: func(context.Context) error {
+			if fail {
+				return errors.New("failed")
+			}
+			return nil
+		}},
+		stats: register("test_metrics"),
+	}
+
+	s.run(context.Background(), e)
+	fail = false
+	s.run(context.Background(), e)
+	s.run(context.Background(), e)
+
+	out := Metrics()
+	for _, want := range []string{
+		`fuego_cloud_job_runs_total{job="test_metrics",result="success"} 2`,
+		`fuego_cloud_job_runs_total{job="test_metrics",result="failure"} 1`,
+		`fuego_cloud_job_runs_total{job="test_metrics",result="panic"} 0`,
+		`fuego_cloud_job_last_duration_seconds{job="test_metrics"}`,
+		`fuego_cloud_job_last_success_timestamp_seconds{job="test_metrics"}`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected metrics to contain %s, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestAdd_Configuration(t *testing.T) {
+	cfg := &config.Config{
+		DisabledJobs: []string{"disabled"},
+		JobSchedules: map[string]string{"overridden": "*/5 * * * *", "broken": "every day"},
+	}
+	s := New(cfg, nil, nil)
+	noop := func(context.Context) error { return nil }
+
+	if err := s.Add(Job{Name: "disabled", Schedule: "@hourly", Run: noop}); err != nil {
+		t.Fatalf("Add disabled: %v", err)
+	}
+	if err := s.Add(Job{Name: "overridden", Schedule: "@hourly", Run: noop}); err != nil {
+		t.Fatalf("Add overridden: %v", err)
+	}
+	if err := s.Add(Job{Name: "broken", Schedule: "@hourly", Run: noop}); err == nil {
+		t.Error("expected an invalid schedule override to be rejected")
+	}
+
+	if len(s.entries) != 1 || s.entries[0].job.Name != "overridden" {
+		t.Fatalf("expected only the overridden job to be scheduled, got %d jobs", len(s.entries))
+	}
+	from := time.Date(2026, 3, 11, 10, 17, 0, 0, time.UTC)
+	if next := s.entries[0].schedule.Next(from); !next.Equal(time.Date(2026, 3, 11, 10, 20, 0, 0, time.UTC)) {
+		t.Errorf("expected the overridden schedule, next run at %s", next)
+	}
+}
+
+func TestRun_StopsOnCancel(t *testing.T) {
+	s := New(&config.Config{}, nil, nil)
+	ran := make(chan struct{}, 10)
+	if err := s.Add(Job{Name: "test_cancel", Schedule: "@every 1s", Run: func(context.Context) error {
+		ran <- struct{}{}
+		return nil
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-ran:
+	case <-time.After(3 * time.Second):
+		t.Fatal("job did not run")
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after cancellation")
+	}
+}
@@ -0,0 +1,109 @@
+package scim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// BasePath is where the SCIM Users endpoint is mounted
+const BasePath = "/api/scim/v2/users"
+
+// ErrUnauthorized is returned when the bearer token does not match an organization
+var ErrUnauthorized = errors.New("invalid scim token")
+
+// Authenticate resolves the organization owning a SCIM bearer token
+func Authenticate(ctx context.Context, queries *db.Queries, authorization string) (*db.Organization, error) {
+	token := auth.ExtractBearerToken(authorization)
+	if token == "" {
+		return nil, ErrUnauthorized
+	}
+
+	org, err := queries.GetOrganizationBySCIMTokenHash(ctx, optional(auth.HashToken(token)))
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	return &org, nil
+}
+
+// FromMember renders an organization member as a SCIM User
+func FromMember(member db.OrganizationMember) User {
+	user := User{
+		Schemas:  []string{SchemaUser},
+		ID:       member.ID.String(),
+		UserName: member.UserName,
+		Active:   &member.Active,
+		Meta: &Meta{
+			ResourceType: "User",
+			Created:      member.CreatedAt,
+			LastModified: member.UpdatedAt,
+			Location:     BasePath + "/" + member.ID.String(),
+		},
+	}
+	if member.ExternalID != nil {
+		user.ExternalID = *member.ExternalID
+	}
+	if member.DisplayName != nil {
+		user.DisplayName = *member.DisplayName
+		user.Name = &Name{Formatted: *member.DisplayName}
+	}
+	if member.Email != nil {
+		user.Emails = []Email{{Value: *member.Email, Type: "work", Primary: true}}
+	}
+	return user
+}
+
+// UpdateParams maps a SCIM User onto the member row it replaces. A member is
+// linked to the platform user with the same GitHub username when one exists.
+func UpdateParams(ctx context.Context, queries *db.Queries, member db.OrganizationMember, user User) db.UpdateOrganizationMemberParams {
+	params := db.UpdateOrganizationMemberParams{
+		ID:          member.ID,
+		OrgID:       member.OrgID,
+		UserID:      member.UserID,
+		UserName:    user.UserName,
+		ExternalID:  optional(user.ExternalID),
+		Email:       optional(user.PrimaryEmail()),
+		DisplayName: optional(user.FormattedName()),
+		Active:      user.IsActive(),
+	}
+	if user.UserName != member.UserName {
+		params.UserID = LinkedUser(ctx, queries, user.UserName)
+	}
+	return params
+}
+
+// LinkedUser returns the id of the platform user with a GitHub username, if any
+func LinkedUser(ctx context.Context, queries *db.Queries, userName string) pgtype.UUID {
+	user, err := queries.GetUserByUsername(ctx, userName)
+	if err != nil {
+		return pgtype.UUID{}
+	}
+	return pgtype.UUID{Bytes: user.ID, Valid: true}
+}
+
+// Deprovision cuts off a deactivated or deleted member's access by deleting
+// their API tokens and revoking every session issued so far
+func Deprovision(ctx context.Context, queries *db.Queries, member db.OrganizationMember) error {
+	if !member.UserID.Valid {
+		return nil
+	}
+
+	if err := queries.DeleteAPITokensByUser(ctx, member.UserID.Bytes); err != nil {
+		return fmt.Errorf("failed to revoke api tokens: %w", err)
+	}
+	if err := queries.RevokeUserSessions(ctx, member.UserID.Bytes); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return nil
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,305 @@
+// Package scim implements the parts of SCIM 2.0 (RFC 7643/7644) used to
+// provision organization members from an identity provider.
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema URNs
+const (
+	SchemaUser         = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaListResponse = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp      = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError        = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// ContentType is the media type of SCIM requests and responses
+const ContentType = "application/scim+json"
+
+// Error types from RFC 7644 section 3.12
+const (
+	ErrorInvalidFilter = "invalidFilter"
+	ErrorInvalidValue  = "invalidValue"
+	ErrorInvalidPath   = "invalidPath"
+	ErrorUniqueness    = "uniqueness"
+	ErrorNoTarget      = "noTarget"
+)
+
+// User is a SCIM User resource. UserName is the member's GitHub username.
+type User struct {
+	Schemas     []string `json:"schemas"`
+	ID          string   `json:"id,omitempty"`
+	ExternalID  string   `json:"externalId,omitempty"`
+	UserName    string   `json:"userName"`
+	Name        *Name    `json:"name,omitempty"`
+	DisplayName string   `json:"displayName,omitempty"`
+	Emails      []Email  `json:"emails,omitempty"`
+	Active      *bool    `json:"active,omitempty"`
+	Meta        *Meta    `json:"meta,omitempty"`
+}
+
+// Name is the components of a user's name
+type Name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// Email is a user's email address
+type Email struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// Meta is resource metadata
+type Meta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location,omitempty"`
+}
+
+// ListResponse is a page of resources
+type ListResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int64    `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []User   `json:"Resources"`
+}
+
+// Error is a SCIM error response. Status is a string per the spec.
+type Error struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+// NewError builds an error response
+func NewError(status int, scimType, detail string) Error {
+	return Error{
+		Schemas:  []string{SchemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Detail
+}
+
+func badRequest(scimType, format string, args ...any) *Error {
+	err := NewError(http.StatusBadRequest, scimType, fmt.Sprintf(format, args...))
+	return &err
+}
+
+// PatchRequest is a PATCH body
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+// PatchOperation is one add, replace or remove operation
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// IsActive reports whether the user is active, which is the default
+func (u *User) IsActive() bool {
+	return u.Active == nil || *u.Active
+}
+
+// PrimaryEmail returns the primary email, or the first one
+func (u *User) PrimaryEmail() string {
+	for _, email := range u.Emails {
+		if email.Primary {
+			return email.Value
+		}
+	}
+	if len(u.Emails) > 0 {
+		return u.Emails[0].Value
+	}
+	return ""
+}
+
+// FormattedName returns the display name, falling back to the name components
+func (u *User) FormattedName() string {
+	if u.DisplayName != "" {
+		return u.DisplayName
+	}
+	if u.Name == nil {
+		return ""
+	}
+	if u.Name.Formatted != "" {
+		return u.Name.Formatted
+	}
+	return strings.TrimSpace(u.Name.GivenName + " " + u.Name.FamilyName)
+}
+
+// ApplyPatch applies PATCH operations to the user. Both path-based
+// operations and the path-less form with an attribute object, as sent by
+// Azure AD and Okta, are supported.
+func (u *User) ApplyPatch(ops []PatchOperation) error {
+	for _, op := range ops {
+		switch strings.ToLower(op.Op) {
+		case "add", "replace":
+			if op.Path == "" {
+				var attrs map[string]json.RawMessage
+				if err := json.Unmarshal(op.Value, &attrs); err != nil {
+					return badRequest(ErrorInvalidValue, "value must be an object when path is omitted")
+				}
+				for path, value := range attrs {
+					if err := u.set(path, value); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if err := u.set(op.Path, op.Value); err != nil {
+				return err
+			}
+		case "remove":
+			if err := u.remove(op.Path); err != nil {
+				return err
+			}
+		default:
+			return badRequest(ErrorInvalidValue, "unsupported operation %q", op.Op)
+		}
+	}
+	return nil
+}
+
+func (u *User) set(path string, value json.RawMessage) error {
+	switch strings.ToLower(path) {
+	case "active":
+		active, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+		u.Active = &active
+		return nil
+	case "username":
+		return unmarshalString(value, &u.UserName)
+	case "externalid":
+		return unmarshalString(value, &u.ExternalID)
+	case "displayname":
+		return unmarshalString(value, &u.DisplayName)
+	case "name.formatted":
+		if u.Name == nil {
+			u.Name = &Name{}
+		}
+		return unmarshalString(value, &u.Name.Formatted)
+	case "name":
+		return unmarshalValue(value, &u.Name)
+	case "emails":
+		return unmarshalValue(value, &u.Emails)
+	case `emails[type eq "work"].value`, "emails[primary eq true].value":
+		var email string
+		if err := unmarshalString(value, &email); err != nil {
+			return err
+		}
+		u.Emails = []Email{{Value: email, Type: "work", Primary: true}}
+		return nil
+	}
+	return badRequest(ErrorInvalidPath, "unsupported attribute %q", path)
+}
+
+func (u *User) remove(path string) error {
+	switch strings.ToLower(path) {
+	case "externalid":
+		u.ExternalID = ""
+	case "displayname":
+		u.DisplayName = ""
+	case "name":
+		u.Name = nil
+	case "emails":
+		u.Emails = nil
+	case "":
+		return badRequest(ErrorNoTarget, "remove requires a path")
+	default:
+		return badRequest(ErrorInvalidPath, "unsupported attribute %q", path)
+	}
+	return nil
+}
+
+// parseBool accepts JSON booleans and the "True"/"False" strings some IdPs send
+func parseBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b, nil
+		}
+	}
+	return false, badRequest(ErrorInvalidValue, "active must be a boolean")
+}
+
+func unmarshalString(value json.RawMessage, dst *string) error {
+	if err := json.Unmarshal(value, dst); err != nil {
+		return badRequest(ErrorInvalidValue, "expected a string")
+	}
+	return nil
+}
+
+func unmarshalValue(value json.RawMessage, dst any) error {
+	if err := json.Unmarshal(value, dst); err != nil {
+		return badRequest(ErrorInvalidValue, "%v", err)
+	}
+	return nil
+}
+
+// Filter is an equality filter on a single attribute
+type Filter struct {
+	Attribute string
+	Value     string
+}
+
+// ParseFilter parses the `attr eq "value"` filters IdPs use to look up users
+// before provisioning them. Only userName and externalId are supported.
+func ParseFilter(filter string) (*Filter, error) {
+	if strings.TrimSpace(filter) == "" {
+		return nil, nil
+	}
+
+	parts := strings.SplitN(strings.TrimSpace(filter), " ", 3)
+	if len(parts) != 3 || !strings.EqualFold(parts[1], "eq") {
+		return nil, badRequest(ErrorInvalidFilter, "only `attribute eq \"value\"` filters are supported")
+	}
+
+	value, err := strconv.Unquote(parts[2])
+	if err != nil {
+		return nil, badRequest(ErrorInvalidFilter, "filter value must be a quoted string")
+	}
+
+	switch strings.ToLower(parts[0]) {
+	case "username":
+		return &Filter{Attribute: "userName", Value: value}, nil
+	case "externalid":
+		return &Filter{Attribute: "externalId", Value: value}, nil
+	}
+	return nil, badRequest(ErrorInvalidFilter, "filtering on %q is not supported", parts[0])
+}
+
+// Write sends a SCIM response
+func Write(w http.ResponseWriter, status int, v any) error {
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(status)
+	if v == nil {
+		return nil
+	}
+	return json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,123 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		filter    string
+		attribute string
+		value     string
+		wantErr   bool
+	}{
+		{`userName eq "octocat"`, "userName", "octocat", false},
+		{`username EQ "octocat"`, "userName", "octocat", false},
+		{`externalId eq "00u1abc"`, "externalId", "00u1abc", false},
+		{`userName eq "jane doe"`, "userName", "jane doe", false},
+		{`userName co "octo"`, "", "", true},
+		{`emails eq "a@example.com"`, "", "", true},
+		{`userName eq octocat`, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			filter, err := ParseFilter(tt.filter)
+			if tt.wantErr {
+				var scimErr *Error
+				if !errors.As(err, &scimErr) || scimErr.ScimType != ErrorInvalidFilter {
+					t.Fatalf("expected invalidFilter error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if filter.Attribute != tt.attribute || filter.Value != tt.value {
+				t.Errorf("expected %s=%q, got %s=%q", tt.attribute, tt.value, filter.Attribute, filter.Value)
+			}
+		})
+	}
+}
+
+func TestParseFilter_Empty(t *testing.T) {
+	filter, err := ParseFilter("")
+	if err != nil || filter != nil {
+		t.Errorf("expected no filter, got %v, %v", filter, err)
+	}
+}
+
+func TestApplyPatch_Deactivate(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"path", `{"Operations":[{"op":"replace","path":"active","value":false}]}`},
+		{"no path", `{"Operations":[{"op":"Replace","value":{"active":false}}]}`},
+		{"string value", `{"Operations":[{"op":"Replace","path":"active","value":"False"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req PatchRequest
+			if err := json.Unmarshal([]byte(tt.body), &req); err != nil {
+				t.Fatal(err)
+			}
+
+			user := User{UserName: "octocat"}
+			if err := user.ApplyPatch(req.Operations); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if user.IsActive() {
+				t.Error("expected user to be deactivated")
+			}
+		})
+	}
+}
+
+func TestApplyPatch_Attributes(t *testing.T) {
+	body := `{"Operations":[
+		{"op":"replace","path":"userName","value":"octocat2"},
+		{"op":"replace","path":"emails[type eq \"work\"].value","value":"octo@example.com"},
+		{"op":"add","path":"name.formatted","value":"Octo Cat"},
+		{"op":"remove","path":"externalId"}
+	]}`
+
+	var req PatchRequest
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatal(err)
+	}
+
+	user := User{UserName: "octocat", ExternalID: "00u1abc"}
+	if err := user.ApplyPatch(req.Operations); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if user.UserName != "octocat2" {
+		t.Errorf("expected userName to be replaced, got %q", user.UserName)
+	}
+	if user.PrimaryEmail() != "octo@example.com" {
+		t.Errorf("expected email to be replaced, got %q", user.PrimaryEmail())
+	}
+	if user.FormattedName() != "Octo Cat" {
+		t.Errorf("expected formatted name, got %q", user.FormattedName())
+	}
+	if user.ExternalID != "" {
+		t.Errorf("expected externalId to be removed, got %q", user.ExternalID)
+	}
+}
+
+func TestApplyPatch_InvalidPath(t *testing.T) {
+	user := User{UserName: "octocat"}
+	err := user.ApplyPatch([]PatchOperation{{Op: "replace", Path: "title", Value: json.RawMessage(`"CTO"`)}})
+
+	var scimErr *Error
+	if !errors.As(err, &scimErr) || scimErr.ScimType != ErrorInvalidPath {
+		t.Fatalf("expected invalidPath error, got %v", err)
+	}
+	if scimErr.Status != "400" {
+		t.Errorf("expected status 400, got %q", scimErr.Status)
+	}
+}
@@ -0,0 +1,134 @@
+// Package search ranks the results of GET /api/search, which looks for a
+// term across a user's apps, domains, deployment images and activity
+// actions for the dashboard's command palette. The database finds the
+// candidates containing the term; Rank orders them by how well they match.
+package search
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/google/uuid"
+)
+
+// Kinds of results
+const (
+	KindApp        = "app"
+	KindDomain     = "domain"
+	KindDeployment = "deployment"
+	KindActivity   = "activity"
+)
+
+// Scores of a match, before the kind's weight is added
+const (
+	ScoreExact     = 100
+	ScorePrefix    = 75
+	ScoreWordStart = 50
+	ScoreSubstring = 25
+)
+
+// kindWeights break ties between kinds matching equally well, favoring
+// what a palette user most likely jumps to
+var kindWeights = map[string]int{
+	KindApp:        3,
+	KindDomain:     2,
+	KindDeployment: 1,
+	KindActivity:   0,
+}
+
+// Result is a ranked search result
+type Result struct {
+	Kind string    `json:"kind"`
+	ID   uuid.UUID `json:"id"`
+	// App is the app the result belongs to, the app itself for apps
+	App string `json:"app"`
+	// Title is the text that matched: the app's name, the domain, the
+	// deployment's image or the activity's action
+	Title string `json:"title"`
+	// URL is the dashboard page to open for the result
+	URL       string    `json:"url"`
+	Score     int       `json:"score"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Score rates how well text matches the term, ignoring case: exactly, as a
+// prefix, at the start of a word, or anywhere. It is 0 without a match.
+func Score(term, text string) int {
+	term, text = strings.ToLower(term), strings.ToLower(text)
+	switch {
+	case term == "":
+		return 0
+	case text == term:
+		return ScoreExact
+	case strings.HasPrefix(text, term):
+		return ScorePrefix
+	}
+
+	index := strings.Index(text, term)
+	if index < 0 {
+		return 0
+	}
+	for i := index; i >= 0; i = nextIndex(text, term, i) {
+		if strings.ContainsRune(".-_/:@ ", rune(text[i-1])) {
+			return ScoreWordStart
+		}
+	}
+	return ScoreSubstring
+}
+
+// nextIndex returns the next index of term in text after i, or -1
+func nextIndex(text, term string, i int) int {
+	next := strings.Index(text[i+1:], term)
+	if next < 0 {
+		return -1
+	}
+	return i + 1 + next
+}
+
+// Rank scores the candidates for the term and returns the best limit of
+// them, the best match first, then by kind and newest first
+func Rank(term string, candidates []db.SearchUserResourcesRow, limit int) []Result {
+	results := make([]Result, 0, len(candidates))
+	for _, c := range candidates {
+		score := Score(term, c.Match)
+		if score == 0 {
+			continue
+		}
+		results = append(results, Result{
+			Kind:      c.Kind,
+			ID:        c.ID,
+			App:       c.AppName,
+			Title:     c.Match,
+			URL:       dashboardURL(c),
+			Score:     score + kindWeights[c.Kind],
+			CreatedAt: c.CreatedAt,
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].CreatedAt.After(results[j].CreatedAt)
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// dashboardURL is the dashboard page showing a candidate
+func dashboardURL(c db.SearchUserResourcesRow) string {
+	app := "/dashboard/apps/" + url.PathEscape(c.AppName)
+	switch c.Kind {
+	case KindDomain:
+		return app + "/domains/" + url.PathEscape(c.Match)
+	case KindDeployment:
+		return app + "?tab=deployments"
+	default:
+		return app
+	}
+}
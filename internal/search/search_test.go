@@ -0,0 +1,68 @@
+package search
+
+import (
+	"testing"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/google/uuid"
+)
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		term string
+		text string
+		want int
+	}{
+		{"shop", "shop", ScoreExact},
+		{"SHOP", "Shop", ScoreExact},
+		{"shop", "shop-api", ScorePrefix},
+		{"api", "shop-api", ScoreWordStart},
+		{"shop", "www.shop.example.com", ScoreWordStart},
+		{"v2", "registry.example.com/shop:v2", ScoreWordStart},
+		{"deploy", "app.redeployed", ScoreSubstring},
+		// A later occurrence at a word start still counts as one
+		{"api", "rapid-api", ScoreWordStart},
+		{"billing", "shop", 0},
+		{"", "shop", 0},
+	}
+	for _, tt := range tests {
+		if got := Score(tt.term, tt.text); got != tt.want {
+			t.Errorf("Score(%q, %q) = %d, want %d", tt.term, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestRank(t *testing.T) {
+	now := time.Now()
+	candidates := []db.SearchUserResourcesRow{
+		{Kind: KindActivity, ID: uuid.New(), AppName: "shop", Match: "deployment.created", CreatedAt: now},
+		{Kind: KindDomain, ID: uuid.New(), AppName: "shop", Match: "shop.example.com", CreatedAt: now},
+		{Kind: KindApp, ID: uuid.New(), AppName: "shop-api", Match: "shop-api", CreatedAt: now.Add(-time.Hour)},
+		{Kind: KindApp, ID: uuid.New(), AppName: "shop", Match: "shop", CreatedAt: now.Add(-2 * time.Hour)},
+		{Kind: KindDeployment, ID: uuid.New(), AppName: "shop", Match: "registry.example.com/shop:v1", CreatedAt: now},
+	}
+
+	results := Rank("shop", candidates, 10)
+	if len(results) != 4 {
+		t.Fatalf("expected the activity without a match to be left out, got %+v", results)
+	}
+	want := []string{"shop", "shop-api", "shop.example.com", "registry.example.com/shop:v1"}
+	for i, title := range want {
+		if results[i].Title != title {
+			t.Errorf("result %d: got %s, want %s", i, results[i].Title, title)
+		}
+	}
+	if results[0].URL != "/dashboard/apps/shop" || results[2].URL != "/dashboard/apps/shop/domains/shop.example.com" || results[3].URL != "/dashboard/apps/shop?tab=deployments" {
+		t.Errorf("unexpected URLs %s, %s, %s", results[0].URL, results[2].URL, results[3].URL)
+	}
+
+	// Equal matches of the same kind put the newest first
+	older := db.SearchUserResourcesRow{Kind: KindDomain, ID: uuid.New(), AppName: "blog", Match: "shop.blog.dev", CreatedAt: now.Add(-time.Hour)}
+	if results := Rank("shop", []db.SearchUserResourcesRow{older, candidates[1]}, 10); results[0].Title != "shop.example.com" {
+		t.Errorf("expected the newer domain first, got %+v", results)
+	}
+	if results := Rank("shop", candidates, 2); len(results) != 2 {
+		t.Errorf("expected the limit to apply, got %d results", len(results))
+	}
+}
@@ -0,0 +1,93 @@
+// Package services is the container of dependencies shared by handlers. main
+// builds one Services and installs it with Middleware; handlers read typed
+// fields from From(c) instead of asserting loose context values, so a missing
+// or misnamed dependency is a nil field to check rather than a panicking type
+// assertion.
+package services
+
+import (
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/billing"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/cloudflare"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/concurrency"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/readonly"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/store"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/streams"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// contextKey is the fuego context key the container is stored under
+const contextKey = "services"
+
+// Services holds the dependencies of handlers. Config, DB and Store are
+// always set by main; the others are nil when not configured.
+type Services struct {
+	Config *config.Config
+	DB     *pgxpool.Pool
+	Store  *store.Store
+	// K8s is the client of the default cluster
+	K8s k8s.Interface
+	// K8sClients connects to the cluster of a kubeconfig, k8s.NewClient
+	// when nil; tests return a k8s.Fake
+	K8sClients  func(kubeconfig string) (k8s.Interface, error)
+	Cloudflare  *cloudflare.Client
+	Concurrency *concurrency.Limiter
+	Streams     *streams.Manager
+	// Billing reads invoices from Stripe
+	Billing *billing.Client
+	// ReadOnly is the platform's read-only switch
+	ReadOnly *readonly.Switch
+}
+
+// Kubernetes returns a client of the cluster at kubeconfig
+func (s *Services) Kubernetes(kubeconfig string) (k8s.Interface, error) {
+	if s.K8sClients != nil {
+		return s.K8sClients(kubeconfig)
+	}
+	prefix := ""
+	if s.Config != nil {
+		prefix = s.Config.K8sNamespacePrefix
+	}
+	client, err := k8s.NewClient(kubeconfig, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// Cluster returns a client of the cluster an app in region runs on. App
+// handlers reach the cluster through it rather than the default kubeconfig.
+func (s *Services) Cluster(region string) (k8s.Interface, error) {
+	kubeconfig := ""
+	if s.Config != nil {
+		kubeconfig = s.Config.KubeconfigForRegion(region)
+	}
+	return s.Kubernetes(kubeconfig)
+}
+
+// Middleware installs the container on every request
+func Middleware(s *Services) fuego.MiddlewareFunc {
+	return func(next fuego.HandlerFunc) fuego.HandlerFunc {
+		return func(c *fuego.Context) error {
+			Set(c, s)
+			return next(c)
+		}
+	}
+}
+
+// Set installs the container on one request, for tests calling handlers
+// directly
+func Set(c *fuego.Context, s *Services) {
+	c.Set(contextKey, s)
+}
+
+// From returns the request's container. Without one it returns an empty
+// container, so optional dependencies read as nil.
+func From(c *fuego.Context) *Services {
+	if s, ok := c.Get(contextKey).(*Services); ok && s != nil {
+		return s
+	}
+	return &Services{}
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+)
+
+func TestFrom(t *testing.T) {
+	c := fuego.NewContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if s := From(c); s == nil || s.Config != nil || s.K8s != nil {
+		t.Fatalf("expected an empty container without one installed, got %+v", s)
+	}
+
+	cfg := &config.Config{Environment: "test"}
+	handler := Middleware(&Services{Config: cfg})(func(c *fuego.Context) error {
+		if From(c).Config != cfg {
+			t.Error("expected the installed container")
+		}
+		return nil
+	})
+	if err := handler(c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCluster(t *testing.T) {
+	var got []string
+	s := &Services{
+		Config: &config.Config{
+			Kubeconfig:        "/default",
+			RegionKubeconfigs: map[string]string{"eu": "/eu"},
+		},
+		K8sClients: func(kubeconfig string) (k8s.Interface, error) {
+			got = append(got, kubeconfig)
+			return k8s.NewFake(), nil
+		},
+	}
+
+	for _, region := range []string{"eu", "us", ""} {
+		if _, err := s.Cluster(region); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	want := []string{"/eu", "/default", "/default"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("region %d: expected kubeconfig %q, got %q", i, want[i], got[i])
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Package showcase is the public directory of apps their owners opted in
+// to, for a community gallery. GET /api/showcase lists them without
+// authentication, each with a badge telling whether it is up. Only what an
+// owner already presents about an app is shown - its name, description,
+// icon, tags and URL - and suspended or purged apps drop out of it.
+package showcase
+
+import (
+	"fmt"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/badge"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+)
+
+// Pages of the directory
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 100
+)
+
+// App is how an app is shown in the directory
+type App struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	IconURL     string   `json:"icon_url,omitempty"`
+	Tags        []string `json:"tags"`
+	URL         string   `json:"url"`
+	BadgeURL    string   `json:"badge_url"`
+}
+
+// View returns an app as shown in the directory
+func View(app db.App, domainSuffix, platformDomain string) App {
+	tags := app.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return App{
+		ID:          app.ID.String(),
+		Name:        app.Name,
+		Description: app.Description,
+		IconURL:     app.IconUrl,
+		Tags:        tags,
+		URL:         "https://" + app.Name + "." + domainSuffix,
+		BadgeURL:    "https://" + platformDomain + "/api/showcase/" + app.ID.String() + "/badge",
+	}
+}
+
+// Uptime returns the message and color of an app's uptime badge. A running app
+// shows the share of its pods that are ready, or "up" when its cluster did
+// not report them.
+func Uptime(status db.AppStatus, metrics *k8s.AppMetrics) (string, string) {
+	switch status {
+	case db.AppStatusRunning:
+	case db.AppStatusDeploying:
+		return "deploying", badge.ColorBlue
+	case db.AppStatusPaused:
+		return "paused", badge.ColorGrey
+	default:
+		return "down", badge.ColorRed
+	}
+
+	if metrics == nil || metrics.PodCount == 0 {
+		return "up", badge.ColorGreen
+	}
+	switch {
+	case metrics.ReadyPods == 0:
+		return "down", badge.ColorRed
+	case metrics.ReadyPods == metrics.PodCount:
+		return "100%", badge.ColorGreen
+	default:
+		percent := float64(metrics.ReadyPods) / float64(metrics.PodCount) * 100
+		return fmt.Sprintf("%.0f%%", percent), badge.ColorYellow
+	}
+}
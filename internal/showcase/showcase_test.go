@@ -0,0 +1,51 @@
+package showcase
+
+import (
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/badge"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/google/uuid"
+)
+
+func TestUptime(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  db.AppStatus
+		metrics *k8s.AppMetrics
+		message string
+		color   string
+	}{
+		{"all pods ready", db.AppStatusRunning, &k8s.AppMetrics{PodCount: 2, ReadyPods: 2}, "100%", badge.ColorGreen},
+		{"some pods ready", db.AppStatusRunning, &k8s.AppMetrics{PodCount: 3, ReadyPods: 2}, "67%", badge.ColorYellow},
+		{"no pods ready", db.AppStatusRunning, &k8s.AppMetrics{PodCount: 2}, "down", badge.ColorRed},
+		{"cluster unreachable", db.AppStatusRunning, nil, "up", badge.ColorGreen},
+		{"deploying", db.AppStatusDeploying, nil, "deploying", badge.ColorBlue},
+		{"paused", db.AppStatusPaused, nil, "paused", badge.ColorGrey},
+		{"failed", db.AppStatusFailed, &k8s.AppMetrics{PodCount: 1, ReadyPods: 1}, "down", badge.ColorRed},
+		{"stopped", db.AppStatusStopped, nil, "down", badge.ColorRed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, color := Uptime(tt.status, tt.metrics)
+			if message != tt.message || color != tt.color {
+				t.Errorf("got %s %s, want %s %s", message, color, tt.message, tt.color)
+			}
+		})
+	}
+}
+
+func TestView(t *testing.T) {
+	app := db.App{ID: uuid.New(), Name: "storefront", Description: "Shop", EnvVarsEncrypted: []byte("secret")}
+	view := View(app, "nexo.app", "nexo.build")
+	if view.URL != "https://storefront.nexo.app" {
+		t.Errorf("unexpected URL %s", view.URL)
+	}
+	if view.BadgeURL != "https://nexo.build/api/showcase/"+app.ID.String()+"/badge" {
+		t.Errorf("unexpected badge URL %s", view.BadgeURL)
+	}
+	if view.Tags == nil {
+		t.Error("expected tags to be an empty list")
+	}
+}
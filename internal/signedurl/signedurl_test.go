@@ -0,0 +1,72 @@
+package signedurl
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	secret := "test-secret"
+	userID := uuid.New()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	signed := Sign(secret, "/api/apps/web/logs", url.Values{"tail": {"500"}}, userID, now.Add(DefaultTTL))
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("failed to parse signed url: %v", err)
+	}
+	if !IsSigned(u) {
+		t.Fatal("expected url to be signed")
+	}
+	if u.Query().Get("tail") != "500" {
+		t.Errorf("expected original query to be kept, got %q", u.RawQuery)
+	}
+
+	got, err := Verify(secret, u, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != userID {
+		t.Errorf("expected user %s, got %s", userID, got)
+	}
+
+	if _, err := Verify(secret, u, now.Add(DefaultTTL+time.Second)); !errors.Is(err, ErrExpired) {
+		t.Errorf("expected ErrExpired, got %v", err)
+	}
+	if _, err := Verify("other-secret", u, now); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature for wrong secret, got %v", err)
+	}
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	secret := "test-secret"
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	signed, _ := url.Parse(Sign(secret, "/api/apps/web/logs", url.Values{"tail": {"500"}}, uuid.New(), now.Add(time.Minute)))
+
+	tamper := func(f func(u *url.URL, q url.Values)) *url.URL {
+		u := *signed
+		q := u.Query()
+		f(&u, q)
+		u.RawQuery = q.Encode()
+		return &u
+	}
+
+	tests := map[string]*url.URL{
+		"query":     tamper(func(_ *url.URL, q url.Values) { q.Set("tail", "100000") }),
+		"extended":  tamper(func(_ *url.URL, q url.Values) { q.Set(ParamExpires, "9999999999") }),
+		"user":      tamper(func(_ *url.URL, q url.Values) { q.Set(ParamUser, uuid.NewString()) }),
+		"path":      tamper(func(u *url.URL, _ url.Values) { u.Path = "/api/apps/web/export" }),
+		"signature": tamper(func(_ *url.URL, q url.Values) { q.Set(ParamSignature, "zz") }),
+		"unsigned":  tamper(func(_ *url.URL, q url.Values) { q.Del(ParamSignature) }),
+	}
+
+	for name, u := range tests {
+		if _, err := Verify(secret, u, now); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: expected ErrInvalidSignature, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,447 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// memory holds the records of an in-memory store, guarded by one lock
+type memory struct {
+	mu          sync.Mutex
+	users       map[uuid.UUID]db.User
+	apps        map[uuid.UUID]db.App
+	deployments map[uuid.UUID]db.Deployment
+	domains     map[uuid.UUID]db.Domain
+	activity    []db.ActivityLog
+}
+
+// NewMemory returns an empty store kept in memory, applying the defaults,
+// unique and foreign keys, ordering and cascading deletes of the database
+// schema
+func NewMemory() *Store {
+	m := &memory{
+		users:       make(map[uuid.UUID]db.User),
+		apps:        make(map[uuid.UUID]db.App),
+		deployments: make(map[uuid.UUID]db.Deployment),
+		domains:     make(map[uuid.UUID]db.Domain),
+	}
+	return &Store{
+		Users:       memUsers{m},
+		Apps:        memApps{m},
+		Deployments: memDeployments{m},
+		Domains:     memDomains{m},
+		Activity:    memActivity{m},
+	}
+}
+
+// newest sorts records newest first, as ORDER BY created_at DESC does
+func newest[T any](records []T, createdAt func(T) time.Time) {
+	sort.SliceStable(records, func(i, j int) bool {
+		return createdAt(records[i]).After(createdAt(records[j]))
+	})
+}
+
+// page applies LIMIT and OFFSET
+func page[T any](records []T, limit, offset int32) []T {
+	if int(offset) >= len(records) {
+		return []T{}
+	}
+	records = records[offset:]
+	if int(limit) < len(records) {
+		records = records[:limit]
+	}
+	return records
+}
+
+// ilike reports whether s matches an ILIKE pattern, where % matches any run
+// of characters, _ any single one and \ escapes either
+func ilike(s, pattern string) bool {
+	s, pattern = strings.ToLower(s), strings.ToLower(pattern)
+	return likeMatch([]rune(s), []rune(pattern))
+}
+
+func likeMatch(s, p []rune) bool {
+	for len(p) > 0 {
+		switch p[0] {
+		case '%':
+			for i := 0; i <= len(s); i++ {
+				if likeMatch(s[i:], p[1:]) {
+					return true
+				}
+			}
+			return false
+		case '_':
+			if len(s) == 0 {
+				return false
+			}
+		default:
+			if p[0] == '\\' && len(p) > 1 {
+				p = p[1:]
+			}
+			if len(s) == 0 || s[0] != p[0] {
+				return false
+			}
+		}
+		s, p = s[1:], p[1:]
+	}
+	return len(s) == 0
+}
+
+type memUsers struct{ m *memory }
+
+func (s memUsers) Get(_ context.Context, id uuid.UUID) (db.User, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	user, ok := s.m.users[id]
+	if !ok {
+		return db.User{}, ErrNotFound
+	}
+	return user, nil
+}
+
+func (s memUsers) GetByGitHubID(_ context.Context, githubID int64) (db.User, error) {
+	return s.find(func(u db.User) bool { return u.GithubID == githubID })
+}
+
+func (s memUsers) GetByUsername(_ context.Context, username string) (db.User, error) {
+	return s.find(func(u db.User) bool { return u.Username == username })
+}
+
+func (s memUsers) find(match func(db.User) bool) (db.User, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	for _, user := range s.m.users {
+		if match(user) {
+			return user, nil
+		}
+	}
+	return db.User{}, ErrNotFound
+}
+
+func (s memUsers) Create(_ context.Context, params db.CreateUserParams) (db.User, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	for _, user := range s.m.users {
+		if user.GithubID == params.GithubID || user.Username == params.Username {
+			return db.User{}, ErrConflict
+		}
+	}
+	now := time.Now()
+	user := db.User{
+		ID:        uuid.New(),
+		GithubID:  params.GithubID,
+		Username:  params.Username,
+		Email:     params.Email,
+		AvatarUrl: params.AvatarUrl,
+		Plan:      "free",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	s.m.users[user.ID] = user
+	return user, nil
+}
+
+type memApps struct{ m *memory }
+
+func (s memApps) Get(_ context.Context, id uuid.UUID) (db.App, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	app, ok := s.m.apps[id]
+	if !ok {
+		return db.App{}, ErrNotFound
+	}
+	return app, nil
+}
+
+func (s memApps) GetByName(_ context.Context, userID uuid.UUID, name string) (db.App, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	for _, app := range s.m.apps {
+		if app.UserID == userID && app.Name == name {
+			return app, nil
+		}
+	}
+	return db.App{}, ErrNotFound
+}
+
+func (s memApps) Search(_ context.Context, params db.SearchAppsByUserParams) ([]db.App, error) {
+	return s.list(params.UserID, params.MaxApps, params.Skip, func(app db.App) bool {
+		matches := params.Search == "" ||
+			ilike(app.Name, params.Pattern) ||
+			ilike(app.Description, params.Pattern) ||
+			slices.Contains(app.Tags, params.Search)
+		for _, tag := range params.Tags {
+			if !slices.Contains(app.Tags, tag) {
+				return false
+			}
+		}
+		return matches
+	}), nil
+}
+
+func (s memApps) list(userID uuid.UUID, limit, offset int32, match func(db.App) bool) []db.App {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	var apps []db.App
+	for _, app := range s.m.apps {
+		if app.UserID == userID && match(app) {
+			apps = append(apps, app)
+		}
+	}
+	newest(apps, func(a db.App) time.Time { return a.CreatedAt })
+	return page(apps, limit, offset)
+}
+
+func (s memApps) Create(_ context.Context, params db.CreateAppParams) (db.App, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	if _, ok := s.m.users[params.UserID]; !ok {
+		return db.App{}, ErrNotFound
+	}
+	if s.taken(params.UserID, params.Name, uuid.Nil) {
+		return db.App{}, ErrConflict
+	}
+	now := time.Now()
+	app := db.App{
+		ID:        uuid.New(),
+		UserID:    params.UserID,
+		Name:      params.Name,
+		Region:    params.Region,
+		Size:      params.Size,
+		Status:    db.AppStatusStopped,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Tags:      []string{},
+		Labels:    []byte("{}"),
+
+		HealthCheckPath: "/api/health",
+	}
+	s.m.apps[app.ID] = app
+	return app, nil
+}
+
+func (s memApps) Update(_ context.Context, params db.UpdateAppParams) (db.App, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	app, ok := s.m.apps[params.ID]
+	if !ok {
+		return db.App{}, ErrNotFound
+	}
+	if s.taken(app.UserID, params.Name, app.ID) {
+		return db.App{}, ErrConflict
+	}
+	app.Name, app.Region, app.Size = params.Name, params.Region, params.Size
+	app.UpdatedAt = time.Now()
+	s.m.apps[app.ID] = app
+	return app, nil
+}
+
+func (s memApps) UpdateMetadata(_ context.Context, params db.UpdateAppMetadataParams) (db.App, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	app, ok := s.m.apps[params.ID]
+	if !ok {
+		return db.App{}, ErrNotFound
+	}
+	app.Description = params.Description
+	app.IconUrl = params.IconUrl
+	app.RepositoryUrl = params.RepositoryUrl
+	app.Tags = slices.Clone(params.Tags)
+	app.UpdatedAt = time.Now()
+	s.m.apps[app.ID] = app
+	return app, nil
+}
+
+func (s memApps) UpdateShowcase(_ context.Context, params db.UpdateAppShowcaseParams) (db.App, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	app, ok := s.m.apps[params.ID]
+	if !ok {
+		return db.App{}, ErrNotFound
+	}
+	app.Showcase = params.Showcase
+	app.UpdatedAt = time.Now()
+	s.m.apps[app.ID] = app
+	return app, nil
+}
+
+func (s memApps) Delete(_ context.Context, id uuid.UUID) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	delete(s.m.apps, id)
+	for deploymentID, deployment := range s.m.deployments {
+		if deployment.AppID == id {
+			delete(s.m.deployments, deploymentID)
+		}
+	}
+	for domainID, domain := range s.m.domains {
+		if domain.AppID == id {
+			delete(s.m.domains, domainID)
+		}
+	}
+	s.m.activity = slices.DeleteFunc(s.m.activity, func(log db.ActivityLog) bool {
+		return log.AppID.Valid && log.AppID.Bytes == id
+	})
+	return nil
+}
+
+// taken reports whether another app of the user has the name; callers hold
+// the lock
+func (s memApps) taken(userID uuid.UUID, name string, except uuid.UUID) bool {
+	for _, app := range s.m.apps {
+		if app.UserID == userID && app.Name == name && app.ID != except {
+			return true
+		}
+	}
+	return false
+}
+
+type memDeployments struct{ m *memory }
+
+func (s memDeployments) Get(_ context.Context, id uuid.UUID) (db.Deployment, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	deployment, ok := s.m.deployments[id]
+	if !ok {
+		return db.Deployment{}, ErrNotFound
+	}
+	return deployment, nil
+}
+
+func (s memDeployments) ListByApp(_ context.Context, appID uuid.UUID, limit, offset int32) ([]db.Deployment, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	var deployments []db.Deployment
+	for _, deployment := range s.m.deployments {
+		if deployment.AppID == appID {
+			deployments = append(deployments, deployment)
+		}
+	}
+	newest(deployments, func(d db.Deployment) time.Time { return d.CreatedAt })
+	return page(deployments, limit, offset), nil
+}
+
+func (s memDeployments) Create(_ context.Context, params db.CreateDeploymentParams) (db.Deployment, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	if _, ok := s.m.apps[params.AppID]; !ok {
+		return db.Deployment{}, ErrNotFound
+	}
+	deployment := db.Deployment{
+		ID:                uuid.New(),
+		AppID:             params.AppID,
+		Version:           params.Version,
+		Image:             params.Image,
+		Status:            params.Status,
+		CreatedAt:         time.Now(),
+		Architectures:     slices.Clone(params.Architectures),
+		DeployedByUserID:  params.DeployedByUserID,
+		DeployedByTokenID: params.DeployedByTokenID,
+		DeployedBy:        params.DeployedBy,
+	}
+	s.m.deployments[deployment.ID] = deployment
+	return deployment, nil
+}
+
+type memDomains struct{ m *memory }
+
+func (s memDomains) GetByName(_ context.Context, name string) (db.Domain, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	for _, domain := range s.m.domains {
+		if domain.Domain == name {
+			return domain, nil
+		}
+	}
+	return db.Domain{}, ErrNotFound
+}
+
+func (s memDomains) Create(_ context.Context, params db.CreateDomainParams) (db.Domain, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	if _, ok := s.m.apps[params.AppID]; !ok {
+		return db.Domain{}, ErrNotFound
+	}
+	for _, domain := range s.m.domains {
+		if domain.Domain == params.Domain {
+			return db.Domain{}, ErrConflict
+		}
+	}
+	domain := db.Domain{
+		ID:                uuid.New(),
+		AppID:             params.AppID,
+		Domain:            params.Domain,
+		SslStatus:         "pending",
+		CreatedAt:         time.Now(),
+		VerificationToken: params.VerificationToken,
+		DnsMode:           params.DnsMode,
+		ForceHttps:        true,
+	}
+	s.m.domains[domain.ID] = domain
+	return domain, nil
+}
+
+type memActivity struct{ m *memory }
+
+func (s memActivity) Create(_ context.Context, params db.CreateActivityLogParams) (db.ActivityLog, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	if params.AppID.Valid {
+		if _, ok := s.m.apps[params.AppID.Bytes]; !ok {
+			return db.ActivityLog{}, ErrNotFound
+		}
+	}
+	log := db.ActivityLog{
+		ID:         uuid.New(),
+		UserID:     params.UserID,
+		AppID:      params.AppID,
+		Action:     params.Action,
+		Details:    slices.Clone(params.Details),
+		IpAddress:  params.IpAddress,
+		CreatedAt:  time.Now(),
+		ApiTokenID: params.ApiTokenID,
+	}
+	s.m.activity = append(s.m.activity, log)
+	return log, nil
+}
+
+func (s memActivity) ListByApp(_ context.Context, params db.FilterActivityLogsByAppParams) ([]db.ActivityLog, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	logs := s.filter(params.AppID, params.UserID, params.ApiTokenID)
+	newest(logs, func(l db.ActivityLog) time.Time { return l.CreatedAt })
+	return page(logs, params.LimitCount, params.OffsetCount), nil
+}
+
+func (s memActivity) CountByApp(_ context.Context, params db.CountFilteredActivityLogsByAppParams) (int64, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	return int64(len(s.filter(params.AppID, params.UserID, params.ApiTokenID))), nil
+}
+
+// filter returns an app's activity, of the user and token when valid;
+// callers hold the lock
+func (s memActivity) filter(appID, userID, tokenID pgtype.UUID) []db.ActivityLog {
+	logs := []db.ActivityLog{}
+	for _, log := range s.m.activity {
+		if log.AppID != appID {
+			continue
+		}
+		if userID.Valid && log.UserID != userID {
+			continue
+		}
+		if tokenID.Valid && log.ApiTokenID != tokenID {
+			continue
+		}
+		logs = append(logs, log)
+	}
+	return logs
+}
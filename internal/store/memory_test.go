@@ -0,0 +1,172 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appmeta"
+	"github.com/google/uuid"
+)
+
+func seedUser(t *testing.T, s *Store, username string, githubID int64) db.User {
+	t.Helper()
+	user, err := s.Users.Create(context.Background(), db.CreateUserParams{
+		GithubID: githubID,
+		Username: username,
+		Email:    username + "@example.com",
+	})
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	return user
+}
+
+func seedApp(t *testing.T, s *Store, userID uuid.UUID, name string) db.App {
+	t.Helper()
+	app, err := s.Apps.Create(context.Background(), db.CreateAppParams{
+		UserID: userID,
+		Name:   name,
+		Region: "gdl",
+		Size:   "starter",
+	})
+	if err != nil {
+		t.Fatalf("failed to create app: %v", err)
+	}
+	return app
+}
+
+func TestMemory_Users(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemory()
+	user := seedUser(t, s, "alice", 1)
+
+	if user.Plan != "free" {
+		t.Errorf("expected the free plan by default, got %q", user.Plan)
+	}
+	if got, err := s.Users.GetByGitHubID(ctx, 1); err != nil || got.ID != user.ID {
+		t.Errorf("GetByGitHubID = %v, %v", got.ID, err)
+	}
+	if got, err := s.Users.GetByUsername(ctx, "alice"); err != nil || got.ID != user.ID {
+		t.Errorf("GetByUsername = %v, %v", got.ID, err)
+	}
+	if _, err := s.Users.Get(ctx, uuid.New()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	_, err := s.Users.Create(ctx, db.CreateUserParams{GithubID: 2, Username: "alice"})
+	if !errors.Is(err, ErrConflict) {
+		t.Errorf("expected a duplicate username to conflict, got %v", err)
+	}
+}
+
+func TestMemory_Apps(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemory()
+	alice := seedUser(t, s, "alice", 1)
+	bob := seedUser(t, s, "bob", 2)
+
+	app := seedApp(t, s, alice.ID, "shop")
+	if app.Status != "stopped" || app.Tags == nil {
+		t.Errorf("expected schema defaults, got status %q and tags %v", app.Status, app.Tags)
+	}
+	if _, err := s.Apps.Create(ctx, db.CreateAppParams{UserID: alice.ID, Name: "shop"}); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected a duplicate name to conflict, got %v", err)
+	}
+	if _, err := s.Apps.Create(ctx, db.CreateAppParams{UserID: uuid.New(), Name: "shop"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected an unknown user to be rejected, got %v", err)
+	}
+	seedApp(t, s, bob.ID, "shop")
+
+	if _, err := s.Apps.GetByName(ctx, bob.ID, "blog"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	blog := seedApp(t, s, alice.ID, "blog")
+	if _, err := s.Apps.Update(ctx, db.UpdateAppParams{ID: blog.ID, Name: "shop"}); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected renaming onto another app to conflict, got %v", err)
+	}
+}
+
+func TestMemory_Search(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemory()
+	user := seedUser(t, s, "alice", 1)
+
+	shop := seedApp(t, s, user.ID, "shop")
+	if _, err := s.Apps.UpdateMetadata(ctx, db.UpdateAppMetadataParams{
+		ID:          shop.ID,
+		Description: "100% organic storefront",
+		Tags:        []string{"prod", "web"},
+	}); err != nil {
+		t.Fatalf("failed to update metadata: %v", err)
+	}
+	seedApp(t, s, user.ID, "worker_1")
+
+	search := func(term string, tags ...string) int {
+		apps, err := s.Apps.Search(ctx, db.SearchAppsByUserParams{
+			UserID:  user.ID,
+			Search:  term,
+			Pattern: appmeta.SearchPattern(term),
+			Tags:    tags,
+			MaxApps: 10,
+		})
+		if err != nil {
+			t.Fatalf("search failed: %v", err)
+		}
+		return len(apps)
+	}
+
+	for _, tc := range []struct {
+		term string
+		tags []string
+		want int
+	}{
+		{"", nil, 2},
+		{"SHOP", nil, 1},
+		{"storefront", nil, 1},
+		{"100%", nil, 1},
+		{"0% o", nil, 1},
+		{"r_", nil, 1},
+		{"web", nil, 1},
+		{"", []string{"prod"}, 1},
+		{"", []string{"prod", "staging"}, 0},
+		{"worker", []string{"prod"}, 0},
+	} {
+		if got := search(tc.term, tc.tags...); got != tc.want {
+			t.Errorf("search %q with tags %v = %d apps, want %d", tc.term, tc.tags, got, tc.want)
+		}
+	}
+}
+
+func TestMemory_DeleteCascades(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemory()
+	user := seedUser(t, s, "alice", 1)
+	app := seedApp(t, s, user.ID, "shop")
+
+	deployment, err := s.Deployments.Create(ctx, db.CreateDeploymentParams{AppID: app.ID, Version: 1, Image: "shop:1", Status: "pending"})
+	if err != nil {
+		t.Fatalf("failed to create deployment: %v", err)
+	}
+	domain, err := s.Domains.Create(ctx, db.CreateDomainParams{AppID: app.ID, Domain: "shop.example.com", DnsMode: "cname"})
+	if err != nil {
+		t.Fatalf("failed to create domain: %v", err)
+	}
+	if domain.Verified || domain.SslStatus != "pending" {
+		t.Errorf("expected an unverified domain pending SSL, got %+v", domain)
+	}
+	if _, err := s.Domains.Create(ctx, db.CreateDomainParams{AppID: app.ID, Domain: "shop.example.com"}); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected a duplicate domain to conflict, got %v", err)
+	}
+
+	if err := s.Apps.Delete(ctx, app.ID); err != nil {
+		t.Fatalf("failed to delete app: %v", err)
+	}
+	if _, err := s.Deployments.Get(ctx, deployment.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the deployment to be deleted with the app, got %v", err)
+	}
+	if _, err := s.Domains.GetByName(ctx, domain.Domain); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the domain to be deleted with the app, got %v", err)
+	}
+}
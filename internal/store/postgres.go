@@ -0,0 +1,158 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/legalhold"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Postgres error codes mapped onto the store errors
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+	legalHoldViolation  = legalhold.SQLState
+)
+
+// New returns a store backed by the generated queries
+func New(q *db.Queries) *Store {
+	return &Store{
+		Users:       pgUsers{q},
+		Apps:        pgApps{q},
+		Deployments: pgDeployments{q},
+		Domains:     pgDomains{q},
+		Activity:    pgActivity{q},
+	}
+}
+
+// wrap maps Postgres errors onto the store errors
+func wrap(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case uniqueViolation:
+			return ErrConflict
+		case foreignKeyViolation:
+			return ErrNotFound
+		case legalHoldViolation:
+			return ErrLegalHold
+		}
+	}
+	return err
+}
+
+type pgUsers struct{ q *db.Queries }
+
+func (s pgUsers) Get(ctx context.Context, id uuid.UUID) (db.User, error) {
+	user, err := s.q.GetUserByID(ctx, id)
+	return user, wrap(err)
+}
+
+func (s pgUsers) GetByGitHubID(ctx context.Context, githubID int64) (db.User, error) {
+	user, err := s.q.GetUserByGitHubID(ctx, githubID)
+	return user, wrap(err)
+}
+
+func (s pgUsers) GetByUsername(ctx context.Context, username string) (db.User, error) {
+	user, err := s.q.GetUserByUsername(ctx, username)
+	return user, wrap(err)
+}
+
+func (s pgUsers) Create(ctx context.Context, params db.CreateUserParams) (db.User, error) {
+	user, err := s.q.CreateUser(ctx, params)
+	return user, wrap(err)
+}
+
+type pgApps struct{ q *db.Queries }
+
+func (s pgApps) Get(ctx context.Context, id uuid.UUID) (db.App, error) {
+	app, err := s.q.GetAppByID(ctx, id)
+	return app, wrap(err)
+}
+
+func (s pgApps) GetByName(ctx context.Context, userID uuid.UUID, name string) (db.App, error) {
+	app, err := s.q.GetAppByName(ctx, db.GetAppByNameParams{UserID: userID, Name: name})
+	return app, wrap(err)
+}
+
+func (s pgApps) Search(ctx context.Context, params db.SearchAppsByUserParams) ([]db.App, error) {
+	apps, err := s.q.SearchAppsByUser(ctx, params)
+	return apps, wrap(err)
+}
+
+func (s pgApps) Create(ctx context.Context, params db.CreateAppParams) (db.App, error) {
+	app, err := s.q.CreateApp(ctx, params)
+	return app, wrap(err)
+}
+
+func (s pgApps) Update(ctx context.Context, params db.UpdateAppParams) (db.App, error) {
+	app, err := s.q.UpdateApp(ctx, params)
+	return app, wrap(err)
+}
+
+func (s pgApps) UpdateMetadata(ctx context.Context, params db.UpdateAppMetadataParams) (db.App, error) {
+	app, err := s.q.UpdateAppMetadata(ctx, params)
+	return app, wrap(err)
+}
+
+func (s pgApps) UpdateShowcase(ctx context.Context, params db.UpdateAppShowcaseParams) (db.App, error) {
+	app, err := s.q.UpdateAppShowcase(ctx, params)
+	return app, wrap(err)
+}
+
+func (s pgApps) Delete(ctx context.Context, id uuid.UUID) error {
+	return wrap(s.q.DeleteApp(ctx, id))
+}
+
+type pgDeployments struct{ q *db.Queries }
+
+func (s pgDeployments) Get(ctx context.Context, id uuid.UUID) (db.Deployment, error) {
+	deployment, err := s.q.GetDeploymentByID(ctx, id)
+	return deployment, wrap(err)
+}
+
+func (s pgDeployments) ListByApp(ctx context.Context, appID uuid.UUID, limit, offset int32) ([]db.Deployment, error) {
+	deployments, err := s.q.ListDeploymentsByApp(ctx, db.ListDeploymentsByAppParams{AppID: appID, Limit: limit, Offset: offset})
+	return deployments, wrap(err)
+}
+
+func (s pgDeployments) Create(ctx context.Context, params db.CreateDeploymentParams) (db.Deployment, error) {
+	deployment, err := s.q.CreateDeployment(ctx, params)
+	return deployment, wrap(err)
+}
+
+type pgDomains struct{ q *db.Queries }
+
+func (s pgDomains) GetByName(ctx context.Context, domain string) (db.Domain, error) {
+	d, err := s.q.GetDomainByName(ctx, domain)
+	return d, wrap(err)
+}
+
+func (s pgDomains) Create(ctx context.Context, params db.CreateDomainParams) (db.Domain, error) {
+	domain, err := s.q.CreateDomain(ctx, params)
+	return domain, wrap(err)
+}
+
+type pgActivity struct{ q *db.Queries }
+
+func (s pgActivity) Create(ctx context.Context, params db.CreateActivityLogParams) (db.ActivityLog, error) {
+	log, err := s.q.CreateActivityLog(ctx, params)
+	return log, wrap(err)
+}
+
+func (s pgActivity) ListByApp(ctx context.Context, params db.FilterActivityLogsByAppParams) ([]db.ActivityLog, error) {
+	logs, err := s.q.FilterActivityLogsByApp(ctx, params)
+	return logs, wrap(err)
+}
+
+func (s pgActivity) CountByApp(ctx context.Context, params db.CountFilteredActivityLogsByAppParams) (int64, error) {
+	count, err := s.q.CountFilteredActivityLogsByApp(ctx, params)
+	return count, wrap(err)
+}
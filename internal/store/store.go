@@ -0,0 +1,85 @@
+// Package store is the storage layer handlers depend on. Each interface
+// covers one aggregate, such as Apps or Deployments. New implements them on
+// Postgres over the generated sqlc queries, and NewMemory keeps them in
+// memory with the same semantics, so handlers can be unit tested without a
+// database. Lookups that find nothing return ErrNotFound, writes that break
+// a uniqueness constraint return ErrConflict and deletes of held data return
+// ErrLegalHold. The interfaces hold only the methods handlers and test seeds
+// call; a handler moving off the generated queries adds the ones it needs.
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrNotFound is returned when no record matches, or a record refers to
+	// one that does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when a record with the same unique key exists
+	ErrConflict = errors.New("already exists")
+	// ErrLegalHold is returned when deleting a record a legal hold of an
+	// organization covers
+	ErrLegalHold = errors.New("under legal hold")
+)
+
+// Users stores platform accounts
+type Users interface {
+	Get(ctx context.Context, id uuid.UUID) (db.User, error)
+	GetByGitHubID(ctx context.Context, githubID int64) (db.User, error)
+	GetByUsername(ctx context.Context, username string) (db.User, error)
+	Create(ctx context.Context, params db.CreateUserParams) (db.User, error)
+}
+
+// Apps stores apps, unique by name per user
+type Apps interface {
+	Get(ctx context.Context, id uuid.UUID) (db.App, error)
+	GetByName(ctx context.Context, userID uuid.UUID, name string) (db.App, error)
+	// Search returns a user's apps whose name or description matches the
+	// pattern or with the search as a tag, having every one of the tags
+	Search(ctx context.Context, params db.SearchAppsByUserParams) ([]db.App, error)
+	Create(ctx context.Context, params db.CreateAppParams) (db.App, error)
+	Update(ctx context.Context, params db.UpdateAppParams) (db.App, error)
+	UpdateMetadata(ctx context.Context, params db.UpdateAppMetadataParams) (db.App, error)
+	// UpdateShowcase lists an app in the public showcase or takes it out
+	UpdateShowcase(ctx context.Context, params db.UpdateAppShowcaseParams) (db.App, error)
+	// Delete deletes an app with its deployments and domains, unless a
+	// legal hold covers its owner
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// Deployments stores the deployments of apps
+type Deployments interface {
+	Get(ctx context.Context, id uuid.UUID) (db.Deployment, error)
+	// ListByApp returns an app's deployments, newest first
+	ListByApp(ctx context.Context, appID uuid.UUID, limit, offset int32) ([]db.Deployment, error)
+	Create(ctx context.Context, params db.CreateDeploymentParams) (db.Deployment, error)
+}
+
+// Domains stores custom domains, unique across apps
+type Domains interface {
+	GetByName(ctx context.Context, domain string) (db.Domain, error)
+	Create(ctx context.Context, params db.CreateDomainParams) (db.Domain, error)
+}
+
+// Activity stores the audit trail of actions on apps
+type Activity interface {
+	Create(ctx context.Context, params db.CreateActivityLogParams) (db.ActivityLog, error)
+	// ListByApp returns an app's activity, newest first, only that of a user
+	// and of an API token when given
+	ListByApp(ctx context.Context, params db.FilterActivityLogsByAppParams) ([]db.ActivityLog, error)
+	CountByApp(ctx context.Context, params db.CountFilteredActivityLogsByAppParams) (int64, error)
+}
+
+// Store groups the repositories
+type Store struct {
+	Users       Users
+	Apps        Apps
+	Deployments Deployments
+	Domains     Domains
+	Activity    Activity
+}
@@ -0,0 +1,208 @@
+// Package streams manages the long-lived connections clients hold open to
+// follow an app, such as SSE log streams. The manager caps how many streams
+// a user keeps open, paces heartbeats that keep proxies from dropping quiet
+// connections and reveal clients that went away, closes streams idle for
+// too long and counts open streams for /api/metrics. It does not depend on
+// the transport: an SSE handler writes a comment on each heartbeat, a
+// WebSocket handler would send a ping and expect the pong.
+package streams
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of streams
+const (
+	KindLogs = "logs"
+)
+
+// ErrTooMany is returned when a user already has as many streams open as
+// allowed
+var ErrTooMany = errors.New("too many open streams")
+
+// Manager tracks the open streams of an API replica
+type Manager struct {
+	maxPerUser int
+	heartbeat  time.Duration
+	idle       time.Duration
+
+	mu       sync.Mutex
+	perUser  map[uuid.UUID]int
+	open     map[string]int
+	opened   map[string]uint64
+	rejected map[string]uint64
+	idled    map[string]uint64
+}
+
+// NewManager creates a manager allowing maxPerUser open streams per user,
+// with heartbeats every heartbeat and closing streams without activity for
+// idle. Zero disables the cap, heartbeats or idle timeout.
+func NewManager(maxPerUser int, heartbeat, idle time.Duration) *Manager {
+	return &Manager{
+		maxPerUser: maxPerUser,
+		heartbeat:  heartbeat,
+		idle:       idle,
+		perUser:    make(map[uuid.UUID]int),
+		open:       make(map[string]int),
+		opened:     make(map[string]uint64),
+		rejected:   make(map[string]uint64),
+		idled:      make(map[string]uint64),
+	}
+}
+
+// Stream is an open stream of a user
+type Stream struct {
+	m      *Manager
+	user   uuid.UUID
+	kind   string
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	ticker *time.Ticker
+	timer  *time.Timer
+	isIdle atomic.Bool
+	once   sync.Once
+}
+
+// Open opens a stream of a user, or returns ErrTooMany at the user's cap.
+// The stream's context ends with ctx, on Close or once the stream idles.
+// A nil manager opens streams without limits, heartbeats or timeouts.
+func (m *Manager) Open(ctx context.Context, user uuid.UUID, kind string) (*Stream, error) {
+	s := &Stream{m: m, user: user, kind: kind}
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	if m == nil {
+		return s, nil
+	}
+
+	m.mu.Lock()
+	if m.maxPerUser > 0 && m.perUser[user] >= m.maxPerUser {
+		m.rejected[kind]++
+		m.mu.Unlock()
+		s.cancel()
+		return nil, ErrTooMany
+	}
+	m.perUser[user]++
+	m.open[kind]++
+	m.opened[kind]++
+	m.mu.Unlock()
+
+	if m.heartbeat > 0 {
+		s.ticker = time.NewTicker(m.heartbeat)
+	}
+	if m.idle > 0 {
+		s.timer = time.AfterFunc(m.idle, func() {
+			s.isIdle.Store(true)
+			s.cancel()
+		})
+	}
+	return s, nil
+}
+
+// Context ends when the stream must stop
+func (s *Stream) Context() context.Context {
+	return s.ctx
+}
+
+// Heartbeats delivers a tick whenever the client is due a heartbeat. A
+// heartbeat that cannot be sent means the client went away.
+func (s *Stream) Heartbeats() <-chan time.Time {
+	if s.ticker == nil {
+		return nil
+	}
+	return s.ticker.C
+}
+
+// Active records data sent on the stream, postponing its idle timeout
+func (s *Stream) Active() {
+	if s.timer != nil && !s.isIdle.Load() {
+		s.timer.Reset(s.m.idle)
+	}
+}
+
+// Idle reports whether the stream was closed for idling
+func (s *Stream) Idle() bool {
+	return s.isIdle.Load()
+}
+
+// Close closes the stream, freeing its place under the user's cap
+func (s *Stream) Close() {
+	s.once.Do(func() {
+		s.cancel()
+		if s.m == nil {
+			return
+		}
+		if s.ticker != nil {
+			s.ticker.Stop()
+		}
+		if s.timer != nil {
+			s.timer.Stop()
+		}
+
+		m := s.m
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.perUser[s.user]--; m.perUser[s.user] <= 0 {
+			delete(m.perUser, s.user)
+		}
+		m.open[s.kind]--
+		if s.isIdle.Load() {
+			m.idled[s.kind]++
+		}
+	})
+}
+
+// OpenBy returns how many streams a user has open
+func (m *Manager) OpenBy(user uuid.UUID) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.perUser[user]
+}
+
+// Metrics renders the stream gauges and counters in Prometheus exposition
+// format
+func (m *Manager) Metrics() string {
+	if m == nil {
+		return ""
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kinds := make([]string, 0, len(m.opened)+len(m.rejected))
+	for kind := range m.opened {
+		kinds = append(kinds, kind)
+	}
+	for kind := range m.rejected {
+		if _, ok := m.opened[kind]; !ok {
+			kinds = append(kinds, kind)
+		}
+	}
+	sort.Strings(kinds)
+
+	var b strings.Builder
+	b.WriteString("\n# HELP fuego_cloud_streams_open Streams currently open\n# TYPE fuego_cloud_streams_open gauge\n")
+	for _, kind := range kinds {
+		fmt.Fprintf(&b, "fuego_cloud_streams_open{kind=%q} %d\n", kind, m.open[kind])
+	}
+	b.WriteString("\n# HELP fuego_cloud_streams_opened_total Streams opened\n# TYPE fuego_cloud_streams_opened_total counter\n")
+	for _, kind := range kinds {
+		fmt.Fprintf(&b, "fuego_cloud_streams_opened_total{kind=%q} %d\n", kind, m.opened[kind])
+	}
+	b.WriteString("\n# HELP fuego_cloud_streams_rejected_total Streams refused to users at their cap\n# TYPE fuego_cloud_streams_rejected_total counter\n")
+	for _, kind := range kinds {
+		fmt.Fprintf(&b, "fuego_cloud_streams_rejected_total{kind=%q} %d\n", kind, m.rejected[kind])
+	}
+	b.WriteString("\n# HELP fuego_cloud_streams_idle_closed_total Streams closed for idling\n# TYPE fuego_cloud_streams_idle_closed_total counter\n")
+	for _, kind := range kinds {
+		fmt.Fprintf(&b, "fuego_cloud_streams_idle_closed_total{kind=%q} %d\n", kind, m.idled[kind])
+	}
+	return b.String()
+}
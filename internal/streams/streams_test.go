@@ -0,0 +1,106 @@
+package streams
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestOpen_Cap(t *testing.T) {
+	m := NewManager(2, 0, 0)
+	user := uuid.New()
+
+	first, err := m.Open(context.Background(), user, KindLogs)
+	if err != nil {
+		t.Fatalf("expected a stream, got %v", err)
+	}
+	second, err := m.Open(context.Background(), user, KindLogs)
+	if err != nil {
+		t.Fatalf("expected a second stream, got %v", err)
+	}
+	if _, err := m.Open(context.Background(), user, KindLogs); !errors.Is(err, ErrTooMany) {
+		t.Fatalf("expected the cap to be enforced, got %v", err)
+	}
+	if other, err := m.Open(context.Background(), uuid.New(), KindLogs); err != nil {
+		t.Errorf("expected another user to open a stream, got %v", err)
+	} else {
+		other.Close()
+	}
+
+	first.Close()
+	first.Close() // closing twice frees one place only
+	if got := m.OpenBy(user); got != 1 {
+		t.Errorf("expected 1 open stream, got %d", got)
+	}
+	if first.Context().Err() == nil {
+		t.Error("expected a closed stream's context to end")
+	}
+	second.Close()
+
+	metrics := m.Metrics()
+	for _, want := range []string{
+		`fuego_cloud_streams_open{kind="logs"} 0`,
+		`fuego_cloud_streams_opened_total{kind="logs"} 3`,
+		`fuego_cloud_streams_rejected_total{kind="logs"} 1`,
+	} {
+		if !strings.Contains(metrics, want) {
+			t.Errorf("expected metrics to contain %q, got\n%s", want, metrics)
+		}
+	}
+}
+
+func TestOpen_Idle(t *testing.T) {
+	m := NewManager(0, 10*time.Millisecond, 100*time.Millisecond)
+
+	s, err := m.Open(context.Background(), uuid.New(), KindLogs)
+	if err != nil {
+		t.Fatalf("expected a stream, got %v", err)
+	}
+	defer s.Close()
+
+	select {
+	case <-s.Heartbeats():
+	case <-time.After(time.Second):
+		t.Fatal("expected a heartbeat")
+	}
+
+	// Activity postpones the idle timeout
+	time.Sleep(60 * time.Millisecond)
+	s.Active()
+	time.Sleep(60 * time.Millisecond)
+	if s.Context().Err() != nil {
+		t.Fatal("expected an active stream to stay open")
+	}
+
+	select {
+	case <-s.Context().Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected the idle stream to be closed")
+	}
+	if !s.Idle() {
+		t.Error("expected the stream to be closed for idling")
+	}
+	s.Close()
+	if !strings.Contains(m.Metrics(), `fuego_cloud_streams_idle_closed_total{kind="logs"} 1`) {
+		t.Errorf("expected the idle close to be counted, got\n%s", m.Metrics())
+	}
+}
+
+func TestOpen_NilManager(t *testing.T) {
+	var m *Manager
+	s, err := m.Open(context.Background(), uuid.New(), KindLogs)
+	if err != nil {
+		t.Fatalf("expected a nil manager to open streams, got %v", err)
+	}
+	if s.Heartbeats() != nil {
+		t.Error("expected no heartbeats")
+	}
+	s.Close()
+	if m.Metrics() != "" {
+		t.Error("expected no metrics")
+	}
+}
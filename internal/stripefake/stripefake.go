@@ -0,0 +1,318 @@
+// Package stripefake builds Stripe webhook events with the payloads of the
+// real API and delivers them signed like Stripe does, so billing flows can be
+// exercised without a Stripe account or the Stripe CLI.
+package stripefake
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// APIVersion is the Stripe API version events are rendered in
+const APIVersion = "2024-06-20"
+
+// Event types the builders produce
+const (
+	CheckoutSessionCompleted    = "checkout.session.completed"
+	CustomerSubscriptionCreated = "customer.subscription.created"
+	CustomerSubscriptionUpdated = "customer.subscription.updated"
+	CustomerSubscriptionDeleted = "customer.subscription.deleted"
+	InvoiceCreated              = "invoice.created"
+	InvoicePaid                 = "invoice.paid"
+	InvoicePaymentFailed        = "invoice.payment_failed"
+)
+
+// Types lists the event types the builders produce
+var Types = []string{
+	CheckoutSessionCompleted,
+	CustomerSubscriptionCreated,
+	CustomerSubscriptionUpdated,
+	CustomerSubscriptionDeleted,
+	InvoiceCreated,
+	InvoicePaid,
+	InvoicePaymentFailed,
+}
+
+// Event is a Stripe webhook event
+type Event struct {
+	ID              string       `json:"id"`
+	Object          string       `json:"object"`
+	APIVersion      string       `json:"api_version"`
+	Created         int64        `json:"created"`
+	Data            EventData    `json:"data"`
+	Livemode        bool         `json:"livemode"`
+	PendingWebhooks int          `json:"pending_webhooks"`
+	Request         EventRequest `json:"request"`
+	Type            string       `json:"type"`
+}
+
+// EventData holds the object the event is about
+type EventData struct {
+	Object map[string]any `json:"object"`
+	// PreviousAttributes are the changed fields of updated objects
+	PreviousAttributes map[string]any `json:"previous_attributes,omitempty"`
+}
+
+// EventRequest is the API request that caused the event
+type EventRequest struct {
+	ID             *string `json:"id"`
+	IdempotencyKey *string `json:"idempotency_key"`
+}
+
+// Subscription describes the customer and plan events are about
+type Subscription struct {
+	Customer string
+	Email    string
+	// ID is the subscription; one is generated when empty
+	ID string
+	// Price is the price ID of the plan, such as price_pro_monthly
+	Price string
+	// Amount is the price in cents, in USD
+	Amount int64
+	// Metadata is attached to the checkout session and subscription, such
+	// as the user ID the checkout was started for
+	Metadata map[string]string
+}
+
+// New returns an event of type for sub, created at now. It panics on event
+// types not in Types.
+func New(eventType string, sub Subscription, now time.Time) Event {
+	if sub.ID == "" {
+		sub.ID = newID("sub")
+	}
+	if sub.Metadata == nil {
+		sub.Metadata = map[string]string{}
+	}
+
+	event := Event{
+		ID:         newID("evt"),
+		Object:     "event",
+		APIVersion: APIVersion,
+		Created:    now.Unix(),
+		Type:       eventType,
+	}
+	switch eventType {
+	case CheckoutSessionCompleted:
+		event.Data.Object = checkoutSession(sub, now)
+	case CustomerSubscriptionCreated:
+		event.Data.Object = subscription(sub, "active", now)
+		event.Request = apiRequest()
+	case CustomerSubscriptionUpdated:
+		event.Data.Object = subscription(sub, "active", now)
+		event.Data.PreviousAttributes = map[string]any{"status": "incomplete"}
+		event.Request = apiRequest()
+	case CustomerSubscriptionDeleted:
+		object := subscription(sub, "canceled", now)
+		object["canceled_at"] = now.Unix()
+		object["ended_at"] = now.Unix()
+		event.Data.Object = object
+		event.Request = apiRequest()
+	case InvoiceCreated:
+		event.Data.Object = invoice(sub, "draft", now)
+	case InvoicePaid:
+		event.Data.Object = invoice(sub, "paid", now)
+	case InvoicePaymentFailed:
+		event.Data.Object = invoice(sub, "open", now)
+	default:
+		panic("stripefake: unknown event type " + eventType)
+	}
+	return event
+}
+
+func checkoutSession(sub Subscription, now time.Time) map[string]any {
+	return map[string]any{
+		"id":                  newID("cs_test"),
+		"object":              "checkout.session",
+		"amount_subtotal":     sub.Amount,
+		"amount_total":        sub.Amount,
+		"client_reference_id": sub.Metadata["user_id"],
+		"created":             now.Add(-2 * time.Minute).Unix(),
+		"currency":            "usd",
+		"customer":            sub.Customer,
+		"customer_details": map[string]any{
+			"email": sub.Email,
+		},
+		"customer_email": sub.Email,
+		"expires_at":     now.Add(22 * time.Hour).Unix(),
+		"invoice":        newID("in"),
+		"livemode":       false,
+		"metadata":       sub.Metadata,
+		"mode":           "subscription",
+		"payment_status": "paid",
+		"status":         "complete",
+		"subscription":   sub.ID,
+		"success_url":    "https://cloud.nexo.build/dashboard/billing?session_id={CHECKOUT_SESSION_ID}",
+		"url":            nil,
+	}
+}
+
+func subscription(sub Subscription, status string, now time.Time) map[string]any {
+	periodEnd := now.AddDate(0, 1, 0)
+	return map[string]any{
+		"id":                   sub.ID,
+		"object":               "subscription",
+		"cancel_at":            nil,
+		"cancel_at_period_end": false,
+		"canceled_at":          nil,
+		"collection_method":    "charge_automatically",
+		"created":              now.Unix(),
+		"currency":             "usd",
+		"current_period_end":   periodEnd.Unix(),
+		"current_period_start": now.Unix(),
+		"customer":             sub.Customer,
+		"ended_at":             nil,
+		"items": map[string]any{
+			"object": "list",
+			"data": []map[string]any{{
+				"id":       newID("si"),
+				"object":   "subscription_item",
+				"price":    price(sub),
+				"quantity": 1,
+			}},
+			"has_more": false,
+			"url":      "/v1/subscription_items?subscription=" + sub.ID,
+		},
+		"latest_invoice": newID("in"),
+		"livemode":       false,
+		"metadata":       sub.Metadata,
+		"plan":           price(sub),
+		"quantity":       1,
+		"start_date":     now.Unix(),
+		"status":         status,
+	}
+}
+
+// invoice renders an invoice that is paid, open after failed payments or a
+// draft awaiting finalization
+func invoice(sub Subscription, status string, now time.Time) map[string]any {
+	amountPaid, attempts := sub.Amount, 1
+	var nextAttempt any
+	switch status {
+	case "open":
+		amountPaid, attempts = 0, 2
+		nextAttempt = now.Add(72 * time.Hour).Unix()
+	case "draft":
+		// Stripe finalizes and charges subscription drafts after an hour
+		amountPaid, attempts = 0, 0
+		nextAttempt = now.Add(time.Hour).Unix()
+	}
+	paid := status == "paid"
+	return map[string]any{
+		"id":                   newID("in"),
+		"object":               "invoice",
+		"amount_due":           sub.Amount,
+		"amount_paid":          amountPaid,
+		"amount_remaining":     sub.Amount - amountPaid,
+		"attempt_count":        attempts,
+		"attempted":            attempts > 0,
+		"billing_reason":       "subscription_cycle",
+		"created":              now.Add(-time.Hour).Unix(),
+		"currency":             "usd",
+		"customer":             sub.Customer,
+		"customer_email":       sub.Email,
+		"hosted_invoice_url":   "https://invoice.stripe.com/i/acct_test/test_" + sub.ID,
+		"livemode":             false,
+		"next_payment_attempt": nextAttempt,
+		"paid":                 paid,
+		"period_end":           now.Unix(),
+		"period_start":         now.AddDate(0, -1, 0).Unix(),
+		"status":               status,
+		"subscription":         sub.ID,
+		"subtotal":             sub.Amount,
+		"total":                sub.Amount,
+		"lines": map[string]any{
+			"object": "list",
+			"data": []map[string]any{{
+				"id":       newID("il"),
+				"object":   "line_item",
+				"amount":   sub.Amount,
+				"currency": "usd",
+				"price":    price(sub),
+				"quantity": 1,
+				"type":     "subscription",
+			}},
+			"has_more": false,
+		},
+	}
+}
+
+func price(sub Subscription) map[string]any {
+	return map[string]any{
+		"id":          sub.Price,
+		"object":      "price",
+		"active":      true,
+		"currency":    "usd",
+		"livemode":    false,
+		"lookup_key":  sub.Price,
+		"product":     "prod_" + sub.Price,
+		"recurring":   map[string]any{"interval": "month", "interval_count": 1, "usage_type": "licensed"},
+		"type":        "recurring",
+		"unit_amount": sub.Amount,
+	}
+}
+
+func apiRequest() EventRequest {
+	id := newID("req")
+	return EventRequest{ID: &id}
+}
+
+// newID returns a random ID with prefix, shaped like Stripe's
+func newID(prefix string) string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return prefix + "_" + hex.EncodeToString(b)
+}
+
+// Sign returns the Stripe-Signature header of payload sent at t, signed
+// with the webhook endpoint's secret
+func Sign(payload []byte, secret string, t time.Time) string {
+	timestamp := fmt.Sprint(t.Unix())
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + string(payload)))
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sender delivers events to a webhook endpoint as Stripe does
+type Sender struct {
+	URL    string
+	Secret string
+	Client *http.Client
+}
+
+// Send posts event signed with the sender's secret and returns the
+// endpoint's status code
+func (s *Sender) Send(ctx context.Context, event Event) (int, error) {
+	payload, err := json.MarshalIndent(event, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("User-Agent", "Stripe/1.0 (+https://stripe.com/docs/webhooks)")
+	req.Header.Set("Stripe-Signature", Sign(payload, s.Secret, time.Now()))
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("deliver %s: %w", event.Type, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
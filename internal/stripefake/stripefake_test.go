@@ -0,0 +1,91 @@
+package stripefake
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	payload := []byte(`{"id":"evt_1"}`)
+	at := time.Unix(1700000000, 0)
+
+	header := Sign(payload, "whsec_test", at)
+
+	mac := hmac.New(sha256.New, []byte("whsec_test"))
+	mac.Write([]byte("1700000000." + string(payload)))
+	want := "t=1700000000,v1=" + hex.EncodeToString(mac.Sum(nil))
+	if header != want {
+		t.Errorf("Sign = %s, want %s", header, want)
+	}
+}
+
+func TestNew(t *testing.T) {
+	now := time.Now()
+	sub := Subscription{Customer: "cus_1", Email: "a@example.com", Price: "price_pro", Amount: 2000, Metadata: map[string]string{"user_id": "u1"}}
+
+	for _, eventType := range Types {
+		event := New(eventType, sub, now)
+		if event.Type != eventType || event.Object != "event" || !strings.HasPrefix(event.ID, "evt_") {
+			t.Errorf("%s: unexpected event %+v", eventType, event)
+		}
+		if event.Data.Object["customer"] != "cus_1" {
+			t.Errorf("%s: expected customer cus_1, got %v", eventType, event.Data.Object["customer"])
+		}
+	}
+
+	if session := New(CheckoutSessionCompleted, sub, now).Data.Object; session["client_reference_id"] != "u1" || session["mode"] != "subscription" {
+		t.Errorf("unexpected checkout session %v", session)
+	}
+	if canceled := New(CustomerSubscriptionDeleted, sub, now).Data.Object; canceled["status"] != "canceled" {
+		t.Errorf("expected a canceled subscription, got %v", canceled["status"])
+	}
+	if failed := New(InvoicePaymentFailed, sub, now).Data.Object; failed["paid"] != false || failed["amount_remaining"] != int64(2000) {
+		t.Errorf("unexpected failed invoice %v", failed)
+	}
+	if draft := New(InvoiceCreated, sub, now).Data.Object; draft["status"] != "draft" || draft["attempted"] != false || draft["amount_remaining"] != int64(2000) {
+		t.Errorf("unexpected draft invoice %v", draft)
+	}
+}
+
+func TestSender_Send(t *testing.T) {
+	var got Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := io.ReadAll(r.Body)
+		signature := r.Header.Get("Stripe-Signature")
+		timestamp := strings.TrimPrefix(strings.Split(signature, ",")[0], "t=")
+		mac := hmac.New(sha256.New, []byte("whsec_test"))
+		mac.Write([]byte(timestamp + "." + string(payload)))
+		if !strings.HasSuffix(signature, ",v1="+hex.EncodeToString(mac.Sum(nil))) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.Unmarshal(payload, &got)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	event := New(InvoicePaid, Subscription{Customer: "cus_1", Price: "price_pro", Amount: 2000}, time.Now())
+
+	sender := &Sender{URL: server.URL, Secret: "whsec_test"}
+	status, err := sender.Send(context.Background(), event)
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("Send = %d, %v", status, err)
+	}
+	if got.ID != event.ID || got.Type != InvoicePaid {
+		t.Errorf("endpoint received %+v", got)
+	}
+
+	wrongSecret := &Sender{URL: server.URL, Secret: "whsec_other"}
+	if status, _ := wrongSecret.Send(context.Background(), event); status != http.StatusBadRequest {
+		t.Errorf("expected a wrongly signed event to be rejected, got %d", status)
+	}
+}
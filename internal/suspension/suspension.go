@@ -0,0 +1,539 @@
+// Package suspension runs the pipeline for accounts whose payments failed.
+// A failed payment starts it with an email warning and a final warning
+// follows; when the grace period ends the account's running apps are scaled
+// to zero, and when it stays unpaid for the retention period their
+// namespaces are deleted. Payments drive it through events published by the
+// Stripe webhook, each step is published as an event of its own and a
+// payment reverses it at any point: suspended apps are scaled back up,
+// purged ones wait for a redeploy.
+package suspension
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appstatus"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/billing"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/events"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/legalhold"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Events published by the Stripe webhook, which drive the pipeline
+const (
+	EventPaymentFailed    = "billing.payment_failed"
+	EventPaymentSucceeded = "billing.payment_succeeded"
+)
+
+// Events published for each step of the pipeline. They carry a message, so
+// owners are notified of them.
+const (
+	EventWarning    = "billing.suspension_warning"
+	EventSuspended  = "billing.account_suspended"
+	EventPurged     = "billing.account_purged"
+	EventReinstated = "billing.account_reinstated"
+)
+
+// Stages of an account in the pipeline
+const (
+	StageWarning   = "warning"
+	StageSuspended = "suspended"
+	StagePurged    = "purged"
+)
+
+const (
+	// Warnings is how many warnings are sent before apps are suspended
+	Warnings = 2
+	// FinalWarningAfter is when the final warning is sent, counted from
+	// the failed payment
+	FinalWarningAfter = 7 * 24 * time.Hour
+	// SuspendAfter is when apps are scaled to zero, counted from the failed
+	// payment
+	SuspendAfter = billing.GracePeriod
+	// Retention is how long the namespaces of suspended apps are kept
+	Retention = 14 * 24 * time.Hour
+)
+
+// Step is what the pipeline does next to an account
+type Step int
+
+const (
+	StepNone Step = iota
+	StepWarn
+	StepSuspend
+	StepPurge
+)
+
+// Due returns the step due for an account at now
+func Due(s db.AccountSuspension, now time.Time) Step {
+	switch s.Stage {
+	case StageWarning:
+		if !now.Before(s.FailedAt.Add(SuspendAfter)) {
+			return StepSuspend
+		}
+		if s.Warnings < Warnings && !now.Before(s.FailedAt.Add(FinalWarningAfter)) {
+			return StepWarn
+		}
+	case StageSuspended:
+		if s.SuspendedAt.Valid && !now.Before(s.SuspendedAt.Time.Add(Retention)) {
+			return StepPurge
+		}
+	}
+	return StepNone
+}
+
+// State is where an account stands in the pipeline, with when its next
+// step is due
+type State struct {
+	Stage       string     `json:"stage"`
+	InvoiceID   string     `json:"invoice_id,omitempty"`
+	FailedAt    time.Time  `json:"failed_at"`
+	Warnings    int32      `json:"warnings"`
+	SuspendsAt  *time.Time `json:"suspends_at,omitempty"`
+	SuspendedAt *time.Time `json:"suspended_at,omitempty"`
+	PurgesAt    *time.Time `json:"purges_at,omitempty"`
+	PurgedAt    *time.Time `json:"purged_at,omitempty"`
+}
+
+// StateOf returns the state of an account in the pipeline
+func StateOf(s db.AccountSuspension) State {
+	state := State{
+		Stage:     s.Stage,
+		InvoiceID: s.InvoiceID,
+		FailedAt:  s.FailedAt,
+		Warnings:  s.Warnings,
+	}
+	switch s.Stage {
+	case StageWarning:
+		suspends := s.FailedAt.Add(SuspendAfter)
+		state.SuspendsAt = &suspends
+	case StageSuspended:
+		if s.SuspendedAt.Valid {
+			purges := s.SuspendedAt.Time.Add(Retention)
+			state.SuspendedAt = &s.SuspendedAt.Time
+			state.PurgesAt = &purges
+		}
+	case StagePurged:
+		if s.SuspendedAt.Valid {
+			state.SuspendedAt = &s.SuspendedAt.Time
+		}
+		if s.PurgedAt.Valid {
+			state.PurgedAt = &s.PurgedAt.Time
+		}
+	}
+	return state
+}
+
+// Suspended reports whether an account's apps are suspended or purged
+func Suspended(s db.AccountSuspension) bool {
+	return s.Stage == StageSuspended || s.Stage == StagePurged
+}
+
+// Blocks reports whether a suspended account is refused a request: changes
+// to apps other than deleting them
+func Blocks(method, path string) bool {
+	if path != "/api/apps" && !strings.HasPrefix(path, "/api/apps/") {
+		return false
+	}
+	switch method {
+	case "POST", "PUT", "PATCH":
+		return true
+	}
+	return false
+}
+
+// Pipeline moves accounts with failed payments through the stages
+type Pipeline struct {
+	pool    *pgxpool.Pool
+	queries *db.Queries
+	cfg     *config.Config
+	clients func(kubeconfig string) (k8s.Interface, error)
+	// billing confirms with Stripe that no failed payment is left before
+	// reinstating an account; without it any payment reinstates
+	billing *billing.Client
+	now     func() time.Time
+}
+
+// New creates a pipeline connecting to clusters with clients.
+// billingClient may be nil.
+func New(pool *pgxpool.Pool, cfg *config.Config, clients func(kubeconfig string) (k8s.Interface, error), billingClient *billing.Client) *Pipeline {
+	return &Pipeline{
+		pool:    pool,
+		queries: db.New(pool),
+		cfg:     cfg,
+		clients: clients,
+		billing: billingClient,
+		now:     time.Now,
+	}
+}
+
+// Handle is the event bus handler starting the pipeline on failed payments
+// and reinstating accounts on payments
+func (p *Pipeline) Handle(ctx context.Context, e events.Event) error {
+	if e.UserID == uuid.Nil {
+		return nil
+	}
+	switch e.Type {
+	case EventPaymentFailed:
+		return p.start(ctx, e)
+	case EventPaymentSucceeded:
+		return p.settle(ctx, e)
+	}
+	return nil
+}
+
+// start puts an account in the pipeline and sends the first warning. An
+// account already in it stays where it is.
+func (p *Pipeline) start(ctx context.Context, e events.Event) error {
+	invoiceID, _ := e.Payload["invoice_id"].(string)
+	return p.inTx(ctx, func(queries *db.Queries) error {
+		s, err := queries.CreateAccountSuspension(ctx, db.CreateAccountSuspensionParams{
+			UserID:    e.UserID,
+			InvoiceID: invoiceID,
+			FailedAt:  e.CreatedAt,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to start suspension: %w", err)
+		}
+		return events.Publish(ctx, queries, events.Event{
+			Type:    EventWarning,
+			UserID:  s.UserID,
+			Message: fmt.Sprintf("A payment failed. Settle invoice %s by %s or your apps are scaled to zero.", s.InvoiceID, day(s.FailedAt.Add(SuspendAfter))),
+			Payload: payload(s),
+		})
+	})
+}
+
+// settle reinstates an account once none of its payments failed
+func (p *Pipeline) settle(ctx context.Context, e events.Event) error {
+	s, err := p.queries.GetAccountSuspension(ctx, e.UserID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get suspension: %w", err)
+	}
+
+	if p.billing != nil {
+		customer, _ := e.Payload["customer"].(string)
+		if customer != "" {
+			open, _, err := p.billing.ListInvoices(ctx, billing.ListParams{Customer: customer, Status: "open", Limit: billing.MaxLimit})
+			if err != nil {
+				return err
+			}
+			if billing.DunningState(open, p.now()).Status != billing.DunningOK {
+				slog.Info("payment leaves failed invoices, account stays in suspension", "user_id", e.UserID)
+				return nil
+			}
+		}
+	}
+
+	return p.Reinstate(ctx, s, "payment received")
+}
+
+// Check runs the steps due for every account in the pipeline. An account
+// whose step fails is retried on the next run.
+func (p *Pipeline) Check(ctx context.Context) error {
+	rows, err := p.queries.ListAccountSuspensions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list suspensions: %w", err)
+	}
+
+	now := p.now()
+	regions := k8s.NewRegions(p.cfg.KubeconfigForRegion, p.clients)
+	for _, row := range rows {
+		s := db.AccountSuspension{
+			UserID:      row.UserID,
+			Stage:       row.Stage,
+			InvoiceID:   row.InvoiceID,
+			FailedAt:    row.FailedAt,
+			Warnings:    row.Warnings,
+			SuspendedAt: row.SuspendedAt,
+			PurgedAt:    row.PurgedAt,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt,
+		}
+
+		var err error
+		switch Due(s, now) {
+		case StepWarn:
+			err = p.warn(ctx, s)
+		case StepSuspend:
+			err = p.suspend(ctx, regions, s, now)
+		case StepPurge:
+			err = p.purge(ctx, regions, s, now)
+		}
+		if err != nil {
+			slog.Error("suspension step failed", "user", row.Username, "stage", row.Stage, "error", err)
+		}
+	}
+	return nil
+}
+
+// warn sends the final warning
+func (p *Pipeline) warn(ctx context.Context, s db.AccountSuspension) error {
+	return p.inTx(ctx, func(queries *db.Queries) error {
+		s, err := queries.UpdateAccountSuspension(ctx, db.UpdateAccountSuspensionParams{
+			UserID:   s.UserID,
+			Stage:    StageWarning,
+			Warnings: s.Warnings + 1,
+		})
+		if err != nil {
+			return err
+		}
+		return events.Publish(ctx, queries, events.Event{
+			Type:    EventWarning,
+			UserID:  s.UserID,
+			Message: fmt.Sprintf("Final notice: your apps are scaled to zero on %s unless invoice %s is settled.", day(s.FailedAt.Add(SuspendAfter)), s.InvoiceID),
+			Payload: payload(s),
+		})
+	})
+}
+
+// suspend scales the account's running apps to zero. Their formation is
+// kept to scale them back to.
+func (p *Pipeline) suspend(ctx context.Context, regions *k8s.Regions, s db.AccountSuspension, now time.Time) error {
+	apps, err := p.queries.ListAppsByUserAndStatus(ctx, db.ListAppsByUserAndStatusParams{UserID: s.UserID, Status: db.AppStatusRunning})
+	if err != nil {
+		return fmt.Errorf("failed to list apps: %w", err)
+	}
+
+	var names []string
+	for _, app := range apps {
+		client, err := regions.Client(app.Region)
+		if err != nil {
+			return fmt.Errorf("kubernetes not available for region %s: %w", app.Region, err)
+		}
+		if err := p.scaleToZero(ctx, client, app); err != nil {
+			return fmt.Errorf("failed to suspend %s: %w", app.Name, err)
+		}
+		names = append(names, app.Name)
+	}
+
+	return p.inTx(ctx, func(queries *db.Queries) error {
+		s, err := queries.UpdateAccountSuspension(ctx, db.UpdateAccountSuspensionParams{
+			UserID:      s.UserID,
+			Stage:       StageSuspended,
+			Warnings:    s.Warnings,
+			SuspendedAt: pgtype.Timestamptz{Time: now, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		data := payload(s)
+		data["apps"] = names
+		return events.Publish(ctx, queries, events.Event{
+			Type:    EventSuspended,
+			UserID:  s.UserID,
+			Message: fmt.Sprintf("Your apps were scaled to zero because invoice %s is unpaid. They are deleted on %s unless it is settled.", s.InvoiceID, day(now.Add(Retention))),
+			Payload: data,
+		})
+	})
+}
+
+func (p *Pipeline) scaleToZero(ctx context.Context, client k8s.Interface, app db.App) error {
+	// A burst in progress would scale the web process back up when it ends
+	if err := p.queries.EndAppBurst(ctx, app.ID); err != nil {
+		return err
+	}
+	for _, process := range p.processes(ctx, app) {
+		if err := client.ScaleProcess(ctx, app.Name, process, 0); err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+	_, err := appstatus.Set(ctx, p.queries, app, db.AppStatusSuspended, app.CurrentDeploymentID)
+	return err
+}
+
+// purge deletes the namespaces of the account's suspended apps. The apps
+// and their configuration are kept for a redeploy. An account a legal hold
+// covers stays suspended and is purged on the first run after its release.
+func (p *Pipeline) purge(ctx context.Context, regions *k8s.Regions, s db.AccountSuspension, now time.Time) error {
+	holds, err := legalhold.Covers(ctx, p.queries, s.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to list legal holds: %w", err)
+	}
+	if len(holds) > 0 {
+		slog.Info("suspended account under legal hold, not purging", "user_id", s.UserID, "orgs", legalhold.Orgs(holds))
+		return nil
+	}
+
+	apps, err := p.queries.ListAppsByUserAndStatus(ctx, db.ListAppsByUserAndStatusParams{UserID: s.UserID, Status: db.AppStatusSuspended})
+	if err != nil {
+		return fmt.Errorf("failed to list apps: %w", err)
+	}
+
+	var names []string
+	for _, app := range apps {
+		client, err := regions.Client(app.Region)
+		if err != nil {
+			return fmt.Errorf("kubernetes not available for region %s: %w", app.Region, err)
+		}
+		if err := client.DeleteApp(ctx, app.Name); err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete namespace of %s: %w", app.Name, err)
+		}
+		if _, err := appstatus.Set(ctx, p.queries, app, db.AppStatusPurged, app.CurrentDeploymentID); err != nil {
+			return err
+		}
+		names = append(names, app.Name)
+	}
+
+	return p.inTx(ctx, func(queries *db.Queries) error {
+		s, err := queries.UpdateAccountSuspension(ctx, db.UpdateAccountSuspensionParams{
+			UserID:      s.UserID,
+			Stage:       StagePurged,
+			Warnings:    s.Warnings,
+			SuspendedAt: s.SuspendedAt,
+			PurgedAt:    pgtype.Timestamptz{Time: now, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		data := payload(s)
+		data["apps"] = names
+		return events.Publish(ctx, queries, events.Event{
+			Type:    EventPurged,
+			UserID:  s.UserID,
+			Message: fmt.Sprintf("The namespaces of your suspended apps were deleted because invoice %s stayed unpaid. Settle it and redeploy to restore them.", s.InvoiceID),
+			Payload: data,
+		})
+	})
+}
+
+// Reinstate takes an account out of the pipeline: suspended apps are scaled
+// back to their formation and purged ones are left stopped for a redeploy
+func (p *Pipeline) Reinstate(ctx context.Context, s db.AccountSuspension, reason string) error {
+	regions := k8s.NewRegions(p.cfg.KubeconfigForRegion, p.clients)
+
+	restored, err := p.queries.ListAppsByUserAndStatus(ctx, db.ListAppsByUserAndStatusParams{UserID: s.UserID, Status: db.AppStatusSuspended})
+	if err != nil {
+		return fmt.Errorf("failed to list apps: %w", err)
+	}
+	var names []string
+	for _, app := range restored {
+		client, err := regions.Client(app.Region)
+		if err != nil {
+			return fmt.Errorf("kubernetes not available for region %s: %w", app.Region, err)
+		}
+		if err := p.scaleBack(ctx, client, app); err != nil {
+			return fmt.Errorf("failed to scale %s back: %w", app.Name, err)
+		}
+		names = append(names, app.Name)
+	}
+
+	purged, err := p.queries.ListAppsByUserAndStatus(ctx, db.ListAppsByUserAndStatusParams{UserID: s.UserID, Status: db.AppStatusPurged})
+	if err != nil {
+		return fmt.Errorf("failed to list apps: %w", err)
+	}
+	var redeploy []string
+	for _, app := range purged {
+		if _, err := appstatus.Set(ctx, p.queries, app, db.AppStatusStopped, app.CurrentDeploymentID); err != nil {
+			return err
+		}
+		redeploy = append(redeploy, app.Name)
+	}
+
+	message := "Your account is in good standing again."
+	if len(names) > 0 {
+		message += " Your apps were scaled back up."
+	}
+	if len(redeploy) > 0 {
+		message += " Apps whose namespaces were deleted need a redeploy: " + strings.Join(redeploy, ", ") + "."
+	}
+
+	return p.inTx(ctx, func(queries *db.Queries) error {
+		deleted, err := queries.DeleteAccountSuspension(ctx, s.UserID)
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			// Reinstated concurrently
+			return nil
+		}
+		data := payload(s)
+		data["reason"] = reason
+		data["apps"] = names
+		data["redeploy"] = redeploy
+		return events.Publish(ctx, queries, events.Event{
+			Type:    EventReinstated,
+			UserID:  s.UserID,
+			Message: message,
+			Payload: data,
+		})
+	})
+}
+
+func (p *Pipeline) scaleBack(ctx context.Context, client k8s.Interface, app db.App) error {
+	formation, err := p.queries.ListAppProcesses(ctx, app.ID)
+	if err != nil {
+		return err
+	}
+	replicas := map[string]int32{k8s.ProcessTypeWeb: 1}
+	for _, process := range formation {
+		replicas[process.ProcessType] = process.Replicas
+	}
+	for process, n := range replicas {
+		if err := client.ScaleProcess(ctx, app.Name, process, n); err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+	_, err = appstatus.Set(ctx, p.queries, app, db.AppStatusRunning, app.CurrentDeploymentID)
+	return err
+}
+
+// processes returns the process types of an app, web first
+func (p *Pipeline) processes(ctx context.Context, app db.App) []string {
+	types := []string{k8s.ProcessTypeWeb}
+	formation, err := p.queries.ListAppProcesses(ctx, app.ID)
+	if err != nil {
+		slog.Warn("failed to list processes", "app", app.Name, "error", err)
+		return types
+	}
+	for _, process := range formation {
+		if process.ProcessType != k8s.ProcessTypeWeb {
+			types = append(types, process.ProcessType)
+		}
+	}
+	return types
+}
+
+// inTx runs fn in a transaction, so a step is recorded together with its
+// event
+func (p *Pipeline) inTx(ctx context.Context, fn func(queries *db.Queries) error) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	if err := fn(p.queries.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func payload(s db.AccountSuspension) map[string]any {
+	return map[string]any{
+		"stage":      s.Stage,
+		"invoice_id": s.InvoiceID,
+		"failed_at":  s.FailedAt,
+		"warnings":   s.Warnings,
+	}
+}
+
+func day(t time.Time) string {
+	return t.UTC().Format("January 2, 2006")
+}
@@ -0,0 +1,76 @@
+package suspension
+
+import (
+	"testing"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestDue(t *testing.T) {
+	failed := time.Date(2026, 9, 1, 12, 0, 0, 0, time.UTC)
+	suspended := pgtype.Timestamptz{Time: failed.Add(SuspendAfter), Valid: true}
+
+	tests := []struct {
+		name string
+		s    db.AccountSuspension
+		now  time.Time
+		want Step
+	}{
+		{"first warning sent", db.AccountSuspension{Stage: StageWarning, FailedAt: failed, Warnings: 1}, failed.Add(time.Hour), StepNone},
+		{"final warning due", db.AccountSuspension{Stage: StageWarning, FailedAt: failed, Warnings: 1}, failed.Add(FinalWarningAfter), StepWarn},
+		{"final warning sent", db.AccountSuspension{Stage: StageWarning, FailedAt: failed, Warnings: Warnings}, failed.Add(FinalWarningAfter + time.Hour), StepNone},
+		{"grace period over", db.AccountSuspension{Stage: StageWarning, FailedAt: failed, Warnings: 1}, failed.Add(SuspendAfter), StepSuspend},
+		{"namespaces retained", db.AccountSuspension{Stage: StageSuspended, FailedAt: failed, SuspendedAt: suspended}, suspended.Time.Add(Retention - time.Minute), StepNone},
+		{"retention over", db.AccountSuspension{Stage: StageSuspended, FailedAt: failed, SuspendedAt: suspended}, suspended.Time.Add(Retention), StepPurge},
+		{"purged", db.AccountSuspension{Stage: StagePurged, FailedAt: failed, SuspendedAt: suspended}, suspended.Time.Add(90 * 24 * time.Hour), StepNone},
+	}
+	for _, tt := range tests {
+		if got := Due(tt.s, tt.now); got != tt.want {
+			t.Errorf("%s: Due = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStateOf(t *testing.T) {
+	failed := time.Date(2026, 9, 1, 12, 0, 0, 0, time.UTC)
+
+	warning := StateOf(db.AccountSuspension{Stage: StageWarning, InvoiceID: "in_1", FailedAt: failed, Warnings: 1})
+	if warning.SuspendsAt == nil || !warning.SuspendsAt.Equal(failed.Add(SuspendAfter)) || warning.PurgesAt != nil {
+		t.Errorf("expected when apps are suspended, got %+v", warning)
+	}
+
+	suspendedAt := failed.Add(SuspendAfter)
+	suspended := StateOf(db.AccountSuspension{Stage: StageSuspended, FailedAt: failed, SuspendedAt: pgtype.Timestamptz{Time: suspendedAt, Valid: true}})
+	if suspended.SuspendsAt != nil || suspended.PurgesAt == nil || !suspended.PurgesAt.Equal(suspendedAt.Add(Retention)) {
+		t.Errorf("expected when namespaces are deleted, got %+v", suspended)
+	}
+	if !Suspended(db.AccountSuspension{Stage: StageSuspended}) || Suspended(db.AccountSuspension{Stage: StageWarning}) {
+		t.Error("expected only suspended and purged accounts to be suspended")
+	}
+}
+
+func TestBlocks(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   bool
+	}{
+		{"POST", "/api/apps", true},
+		{"POST", "/api/apps/shop/deployments", true},
+		{"POST", "/api/apps/shop/scale", true},
+		{"PUT", "/api/apps/shop/env", true},
+		{"PATCH", "/api/apps/shop", true},
+		{"GET", "/api/apps/shop", false},
+		{"DELETE", "/api/apps/shop", false},
+		{"GET", "/api/users/me/invoices", false},
+		{"POST", "/api/auth/refresh", false},
+		{"POST", "/api/appsx", false},
+	}
+	for _, tt := range tests {
+		if got := Blocks(tt.method, tt.path); got != tt.want {
+			t.Errorf("Blocks(%s %s) = %v, want %v", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Package tax validates the tax profiles of users and organizations, the
+// billing country and tax ID Stripe Tax needs to charge the right tax, and
+// keeps them on the Stripe customer billed under them. Tax IDs are taken
+// from EU countries, as VAT numbers, and from Mexico, as RFCs.
+package tax
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/billing"
+)
+
+// Stripe tax ID types
+const (
+	TypeEUVAT = "eu_vat"
+	TypeMXRFC = "mx_rfc"
+)
+
+// euVATPrefixes are the VAT number prefixes of the EU member states; Greece
+// uses EL rather than its ISO code
+var euVATPrefixes = map[string]string{
+	"AT": "AT", "BE": "BE", "BG": "BG", "CY": "CY", "CZ": "CZ", "DE": "DE",
+	"DK": "DK", "EE": "EE", "ES": "ES", "FI": "FI", "FR": "FR", "GR": "EL",
+	"HR": "HR", "HU": "HU", "IE": "IE", "IT": "IT", "LT": "LT", "LU": "LU",
+	"LV": "LV", "MT": "MT", "NL": "NL", "PL": "PL", "PT": "PT", "RO": "RO",
+	"SE": "SE", "SI": "SI", "SK": "SK",
+}
+
+var (
+	countryPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+	// Stripe checks each member state's format; this catches typos early
+	euVATPattern = regexp.MustCompile(`^[A-Z]{2}[0-9A-Z+*]{2,12}$`)
+	// RFCs have 3 letters for companies and 4 for individuals, the date of
+	// incorporation or birth and a 3 character homoclave
+	mxRFCPattern = regexp.MustCompile(`^[A-ZÑ&]{3,4}[0-9]{6}[A-Z0-9]{3}$`)
+	postalCode   = regexp.MustCompile(`^[A-Za-z0-9 -]{2,20}$`)
+)
+
+// ErrInvalid is wrapped by the errors of invalid profiles
+var ErrInvalid = errors.New("invalid tax profile")
+
+// Profile is a tax profile as entered
+type Profile struct {
+	Country      string `json:"country"`
+	PostalCode   string `json:"postal_code"`
+	BusinessName string `json:"business_name"`
+	TaxID        string `json:"tax_id"`
+}
+
+// TaxIDType returns the Stripe tax ID type taken from a country, or an
+// empty string for countries whose tax IDs are not collected
+func TaxIDType(country string) string {
+	if _, ok := euVATPrefixes[country]; ok {
+		return TypeEUVAT
+	}
+	if country == "MX" {
+		return TypeMXRFC
+	}
+	return ""
+}
+
+// Normalize validates a profile and returns it cleaned up, with its tax ID
+// uppercased, stripped of separators and, for EU VAT numbers, prefixed
+// with the country, along with the tax ID's type
+func Normalize(p Profile) (Profile, string, error) {
+	p.Country = strings.ToUpper(strings.TrimSpace(p.Country))
+	p.PostalCode = strings.TrimSpace(p.PostalCode)
+	p.BusinessName = strings.TrimSpace(p.BusinessName)
+	p.TaxID = strings.ToUpper(strings.NewReplacer(" ", "", ".", "", "-", "").Replace(p.TaxID))
+
+	if !countryPattern.MatchString(p.Country) {
+		return p, "", fmt.Errorf("%w: country must be an ISO 3166-1 alpha-2 code", ErrInvalid)
+	}
+	if p.PostalCode != "" && !postalCode.MatchString(p.PostalCode) {
+		return p, "", fmt.Errorf("%w: postal_code is not valid", ErrInvalid)
+	}
+	if len(p.BusinessName) > 255 {
+		return p, "", fmt.Errorf("%w: business_name must be at most 255 characters", ErrInvalid)
+	}
+	if p.TaxID == "" {
+		return p, "", nil
+	}
+
+	taxIDType := TaxIDType(p.Country)
+	switch taxIDType {
+	case TypeEUVAT:
+		prefix := euVATPrefixes[p.Country]
+		if !strings.HasPrefix(p.TaxID, prefix) {
+			p.TaxID = prefix + p.TaxID
+		}
+		if !euVATPattern.MatchString(p.TaxID) {
+			return p, "", fmt.Errorf("%w: tax_id is not a valid %s VAT number", ErrInvalid, p.Country)
+		}
+	case TypeMXRFC:
+		if !mxRFCPattern.MatchString(p.TaxID) {
+			return p, "", fmt.Errorf("%w: tax_id is not a valid RFC", ErrInvalid)
+		}
+		if p.PostalCode == "" {
+			// CFDI invoices need the fiscal address's postal code
+			return p, "", fmt.Errorf("%w: postal_code is required with an RFC", ErrInvalid)
+		}
+	default:
+		return p, "", fmt.Errorf("%w: tax IDs are only collected in the EU and Mexico", ErrInvalid)
+	}
+	if p.BusinessName == "" {
+		return p, "", fmt.Errorf("%w: business_name is required with a tax ID", ErrInvalid)
+	}
+	return p, taxIDType, nil
+}
+
+// Sync puts a tax profile on the Stripe customer billed under it: the
+// billing address and business name, and the profile's tax ID in place of
+// any other. It records the tax ID Stripe keeps and its verification on
+// the profile.
+func Sync(ctx context.Context, client *billing.Client, queries *db.Queries, customer string, profile db.TaxProfile) (db.TaxProfile, error) {
+	if err := client.UpdateCustomer(ctx, customer, CustomerParams(profile)); err != nil {
+		return profile, err
+	}
+
+	existing, err := client.ListTaxIDs(ctx, customer)
+	if err != nil {
+		return profile, err
+	}
+	var kept billing.TaxID
+	for _, taxID := range existing {
+		if profile.TaxID != "" && taxID.Type == profile.TaxIDType && taxID.Value == profile.TaxID {
+			kept = taxID
+			continue
+		}
+		if err := client.DeleteTaxID(ctx, customer, taxID.ID); err != nil {
+			return profile, err
+		}
+	}
+	if profile.TaxID != "" && kept.ID == "" {
+		if kept, err = client.AddTaxID(ctx, customer, profile.TaxIDType, profile.TaxID); err != nil {
+			return profile, err
+		}
+	}
+
+	if kept.ID == profile.StripeTaxID && kept.Status == profile.TaxIDStatus {
+		return profile, nil
+	}
+	return queries.UpdateTaxProfileStripeTaxID(ctx, db.UpdateTaxProfileStripeTaxIDParams{
+		ID:          profile.ID,
+		StripeTaxID: kept.ID,
+		TaxIDStatus: kept.Status,
+	})
+}
+
+// CustomerParams returns the billing details of a Stripe customer billed
+// under a profile
+func CustomerParams(profile db.TaxProfile) billing.CustomerParams {
+	return billing.CustomerParams{
+		Name:       profile.BusinessName,
+		Country:    profile.Country,
+		PostalCode: profile.PostalCode,
+	}
+}
@@ -0,0 +1,74 @@
+package tax
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name      string
+		profile   Profile
+		want      Profile
+		taxIDType string
+	}{
+		{
+			name:    "country only",
+			profile: Profile{Country: " us "},
+			want:    Profile{Country: "US"},
+		},
+		{
+			name:      "VAT number without prefix",
+			profile:   Profile{Country: "de", BusinessName: "Acme GmbH", TaxID: "123 456 789"},
+			want:      Profile{Country: "DE", BusinessName: "Acme GmbH", TaxID: "DE123456789"},
+			taxIDType: TypeEUVAT,
+		},
+		{
+			name:      "Greek VAT number",
+			profile:   Profile{Country: "GR", BusinessName: "Acme AE", TaxID: "094259216"},
+			want:      Profile{Country: "GR", BusinessName: "Acme AE", TaxID: "EL094259216"},
+			taxIDType: TypeEUVAT,
+		},
+		{
+			name:      "RFC",
+			profile:   Profile{Country: "MX", PostalCode: "44100", BusinessName: "Acme SA de CV", TaxID: "abc-010203-ab1"},
+			want:      Profile{Country: "MX", PostalCode: "44100", BusinessName: "Acme SA de CV", TaxID: "ABC010203AB1"},
+			taxIDType: TypeMXRFC,
+		},
+	}
+	for _, tt := range tests {
+		got, taxIDType, err := Normalize(tt.profile)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		if got != tt.want || taxIDType != tt.taxIDType {
+			t.Errorf("%s: Normalize = %+v, %q, want %+v, %q", tt.name, got, taxIDType, tt.want, tt.taxIDType)
+		}
+	}
+}
+
+func TestNormalize_Invalid(t *testing.T) {
+	for name, profile := range map[string]Profile{
+		"no country":            {},
+		"country name":          {Country: "Germany"},
+		"tax ID outside EU/MX":  {Country: "US", BusinessName: "Acme Inc", TaxID: "12-3456789"},
+		"short VAT number":      {Country: "FR", BusinessName: "Acme SAS", TaxID: "1"},
+		"malformed RFC":         {Country: "MX", PostalCode: "44100", BusinessName: "Acme", TaxID: "ABC12345"},
+		"RFC without postcode":  {Country: "MX", BusinessName: "Acme", TaxID: "ABC010203AB1"},
+		"tax ID without name":   {Country: "DE", TaxID: "DE123456789"},
+		"malformed postal code": {Country: "DE", PostalCode: "<script>"},
+	} {
+		if _, _, err := Normalize(profile); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: expected an invalid profile, got %v", name, err)
+		}
+	}
+}
+
+func TestTaxIDType(t *testing.T) {
+	for country, want := range map[string]string{"DE": TypeEUVAT, "GR": TypeEUVAT, "MX": TypeMXRFC, "US": "", "GB": ""} {
+		if got := TaxIDType(country); got != want {
+			t.Errorf("TaxIDType(%s) = %q, want %q", country, got, want)
+		}
+	}
+}
@@ -1,25 +1,31 @@
-// Package testutil provides testing utilities and mocks.
+// Package testutil provides helpers for handler tests over an in-memory store.
 package testutil
 
 import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
-	"time"
 
 	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/actor"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/store"
 	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
 	"github.com/google/uuid"
 )
 
 type TestApp struct {
-	App    *fuego.App
-	Config *config.Config
+	App      *fuego.App
+	Config   *config.Config
+	Services *services.Services
 }
 
 func NewTestApp() *TestApp {
@@ -33,35 +39,54 @@ func NewTestApp() *TestApp {
 		PlatformDomain:   "cloud.test.nexo.build",
 	}
 
+	svc := &services.Services{Config: cfg}
+
 	app := fuego.New()
-	app.Use(func(next fuego.HandlerFunc) fuego.HandlerFunc {
-		return func(c *fuego.Context) error {
-			c.Set("config", cfg)
-			return next(c)
-		}
-	})
+	app.Use(services.Middleware(svc))
 
 	return &TestApp{
-		App:    app,
-		Config: cfg,
+		App:      app,
+		Config:   cfg,
+		Services: svc,
 	}
 }
 
-func (ta *TestApp) WithMockDB(mockDB *MockDB) *TestApp {
+// WithStore makes handlers use the store, usually store.NewMemory()
+func (ta *TestApp) WithStore(s *store.Store) *TestApp {
+	ta.Services.Store = s
+	return ta
+}
+
+// WithK8s makes handlers use the cluster for every kubeconfig, usually a
+// k8s.Fake
+func (ta *TestApp) WithK8s(cluster k8s.Interface) *TestApp {
+	ta.Services.K8s = cluster
+	ta.Services.K8sClients = func(string) (k8s.Interface, error) {
+		return cluster, nil
+	}
+	return ta
+}
+
+func (ta *TestApp) WithAuth(userID uuid.UUID, username string) *TestApp {
 	ta.App.Use(func(next fuego.HandlerFunc) fuego.HandlerFunc {
 		return func(c *fuego.Context) error {
-			c.Set("db", mockDB)
+			c.Set("user_id", userID)
+			c.Set("username", username)
 			return next(c)
 		}
 	})
 	return ta
 }
 
-func (ta *TestApp) WithAuth(userID uuid.UUID, username string) *TestApp {
+// WithAPIToken authenticates requests as the user acting with one of their
+// API tokens
+func (ta *TestApp) WithAPIToken(userID uuid.UUID, username string, tokenID uuid.UUID, tokenName string) *TestApp {
 	ta.App.Use(func(next fuego.HandlerFunc) fuego.HandlerFunc {
 		return func(c *fuego.Context) error {
 			c.Set("user_id", userID)
 			c.Set("username", username)
+			c.Set(actor.TokenIDKey, tokenID)
+			c.Set(actor.TokenNameKey, tokenName)
 			return next(c)
 		}
 	})
@@ -131,177 +156,59 @@ func AssertJSONContains(t *testing.T, w *httptest.ResponseRecorder, key, expecte
 	}
 }
 
-type MockDB struct {
-	Users       map[uuid.UUID]db.User
-	Apps        map[uuid.UUID]db.App
-	Deployments map[uuid.UUID]db.Deployment
-	Domains     map[uuid.UUID]db.Domain
-	APITokens   map[uuid.UUID]db.ApiToken
-	OAuthStates map[string]db.OauthState
-}
-
-func NewMockDB() *MockDB {
-	return &MockDB{
-		Users:       make(map[uuid.UUID]db.User),
-		Apps:        make(map[uuid.UUID]db.App),
-		Deployments: make(map[uuid.UUID]db.Deployment),
-		Domains:     make(map[uuid.UUID]db.Domain),
-		APITokens:   make(map[uuid.UUID]db.ApiToken),
-		OAuthStates: make(map[string]db.OauthState),
-	}
-}
+var githubIDs atomic.Int64
 
-func (m *MockDB) SeedUser(id uuid.UUID, username, email string) db.User {
-	user := db.User{
-		ID:        id,
-		GithubID:  12345,
-		Username:  username,
-		Email:     email,
-		Plan:      "free",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+// SeedUser creates a user with a unique GitHub ID
+func SeedUser(t *testing.T, s *store.Store, username string) db.User {
+	t.Helper()
+	user, err := s.Users.Create(context.Background(), db.CreateUserParams{
+		GithubID: 10000 + githubIDs.Add(1),
+		Username: username,
+		Email:    username + "@example.com",
+	})
+	if err != nil {
+		t.Fatalf("failed to seed user: %v", err)
 	}
-	m.Users[id] = user
 	return user
 }
 
-func (m *MockDB) SeedApp(id, userID uuid.UUID, name string) db.App {
-	app := db.App{
-		ID:              id,
-		UserID:          userID,
-		Name:            name,
-		Region:          "gdl",
-		Size:            "starter",
-		Status:          "running",
-		DeploymentCount: 0,
-		CreatedAt:       time.Now(),
-		UpdatedAt:       time.Now(),
+func SeedApp(t *testing.T, s *store.Store, userID uuid.UUID, name string) db.App {
+	t.Helper()
+	app, err := s.Apps.Create(context.Background(), db.CreateAppParams{
+		UserID: userID,
+		Name:   name,
+		Region: "gdl",
+		Size:   "starter",
+	})
+	if err != nil {
+		t.Fatalf("failed to seed app: %v", err)
 	}
-	m.Apps[id] = app
 	return app
 }
 
-func (m *MockDB) SeedDeployment(id, appID uuid.UUID, version int32) db.Deployment {
-	deployment := db.Deployment{
-		ID:        id,
-		AppID:     appID,
-		Version:   version,
-		Image:     "ghcr.io/test/image:v" + string(rune('0'+version)),
-		Status:    "running",
-		CreatedAt: time.Now(),
+func SeedDeployment(t *testing.T, s *store.Store, appID uuid.UUID, version int32) db.Deployment {
+	t.Helper()
+	deployment, err := s.Deployments.Create(context.Background(), db.CreateDeploymentParams{
+		AppID:   appID,
+		Version: version,
+		Image:   fmt.Sprintf("ghcr.io/test/image:v%d", version),
+		Status:  "running",
+	})
+	if err != nil {
+		t.Fatalf("failed to seed deployment: %v", err)
 	}
-	m.Deployments[id] = deployment
 	return deployment
 }
 
-func (m *MockDB) SeedDomain(id, appID uuid.UUID, domain string) db.Domain {
-	d := db.Domain{
-		ID:        id,
-		AppID:     appID,
-		Domain:    domain,
-		Verified:  false,
-		SslStatus: "pending",
-		CreatedAt: time.Now(),
+func SeedDomain(t *testing.T, s *store.Store, appID uuid.UUID, domain string) db.Domain {
+	t.Helper()
+	d, err := s.Domains.Create(context.Background(), db.CreateDomainParams{
+		AppID:   appID,
+		Domain:  domain,
+		DnsMode: "cname",
+	})
+	if err != nil {
+		t.Fatalf("failed to seed domain: %v", err)
 	}
-	m.Domains[id] = d
 	return d
 }
-
-type MockQueries struct {
-	db *MockDB
-}
-
-func NewMockQueries(mockDB *MockDB) *MockQueries {
-	return &MockQueries{db: mockDB}
-}
-
-func (q *MockQueries) GetUserByGithubID(_ context.Context, githubID int64) (db.User, error) {
-	for _, u := range q.db.Users {
-		if u.GithubID == githubID {
-			return u, nil
-		}
-	}
-	return db.User{}, context.DeadlineExceeded
-}
-
-func (q *MockQueries) GetUserByID(_ context.Context, id uuid.UUID) (db.User, error) {
-	if user, ok := q.db.Users[id]; ok {
-		return user, nil
-	}
-	return db.User{}, context.DeadlineExceeded
-}
-
-func (q *MockQueries) ListAppsByUser(_ context.Context, userID uuid.UUID) ([]db.App, error) {
-	var apps []db.App
-	for _, app := range q.db.Apps {
-		if app.UserID == userID {
-			apps = append(apps, app)
-		}
-	}
-	return apps, nil
-}
-
-func (q *MockQueries) GetAppByName(_ context.Context, params db.GetAppByNameParams) (db.App, error) {
-	for _, app := range q.db.Apps {
-		if app.UserID == params.UserID && app.Name == params.Name {
-			return app, nil
-		}
-	}
-	return db.App{}, context.DeadlineExceeded
-}
-
-func (q *MockQueries) CreateApp(_ context.Context, params db.CreateAppParams) (db.App, error) {
-	app := db.App{
-		ID:              uuid.New(),
-		UserID:          params.UserID,
-		Name:            params.Name,
-		Region:          params.Region,
-		Size:            params.Size,
-		Status:          "created",
-		DeploymentCount: 0,
-		CreatedAt:       time.Now(),
-		UpdatedAt:       time.Now(),
-	}
-	q.db.Apps[app.ID] = app
-	return app, nil
-}
-
-func (q *MockQueries) UpdateApp(_ context.Context, params db.UpdateAppParams) (db.App, error) {
-	if app, ok := q.db.Apps[params.ID]; ok {
-		app.Name = params.Name
-		app.Region = params.Region
-		app.Size = params.Size
-		app.UpdatedAt = time.Now()
-		q.db.Apps[params.ID] = app
-		return app, nil
-	}
-	return db.App{}, context.DeadlineExceeded
-}
-
-func (q *MockQueries) DeleteApp(_ context.Context, id uuid.UUID) error {
-	if _, ok := q.db.Apps[id]; ok {
-		delete(q.db.Apps, id)
-		return nil
-	}
-	return context.DeadlineExceeded
-}
-
-func (q *MockQueries) ListDeploymentsByApp(_ context.Context, params db.ListDeploymentsByAppParams) ([]db.Deployment, error) {
-	var deps []db.Deployment
-	for _, d := range q.db.Deployments {
-		if d.AppID == params.AppID {
-			deps = append(deps, d)
-		}
-	}
-	return deps, nil
-}
-
-func (q *MockQueries) ListDomainsByApp(_ context.Context, appID uuid.UUID) ([]db.Domain, error) {
-	var domains []db.Domain
-	for _, d := range q.db.Domains {
-		if d.AppID == appID {
-			domains = append(domains, d)
-		}
-	}
-	return domains, nil
-}
@@ -6,7 +6,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
-	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/store"
 	"github.com/google/uuid"
 )
 
@@ -33,14 +33,13 @@ func TestNewTestApp(t *testing.T) {
 	}
 }
 
-func TestTestApp_WithMockDB(t *testing.T) {
+func TestTestApp_WithStore(t *testing.T) {
 	ta := NewTestApp()
-	mockDB := NewMockDB()
 
-	result := ta.WithMockDB(mockDB)
+	result := ta.WithStore(store.NewMemory())
 
 	if result != ta {
-		t.Error("expected WithMockDB to return same TestApp instance")
+		t.Error("expected WithStore to return same TestApp instance")
 	}
 }
 
@@ -154,394 +153,78 @@ func TestAssertJSONContains(t *testing.T) {
 	})
 }
 
-func TestNewMockDB(t *testing.T) {
-	mockDB := NewMockDB()
+func TestSeedUser(t *testing.T) {
+	s := store.NewMemory()
 
-	if mockDB == nil {
-		t.Fatal("expected non-nil MockDB")
-	}
-	if mockDB.Users == nil {
-		t.Error("expected non-nil Users map")
-	}
-	if mockDB.Apps == nil {
-		t.Error("expected non-nil Apps map")
-	}
-	if mockDB.Deployments == nil {
-		t.Error("expected non-nil Deployments map")
-	}
-	if mockDB.Domains == nil {
-		t.Error("expected non-nil Domains map")
-	}
-	if mockDB.APITokens == nil {
-		t.Error("expected non-nil APITokens map")
-	}
-	if mockDB.OAuthStates == nil {
-		t.Error("expected non-nil OAuthStates map")
-	}
-}
+	user := SeedUser(t, s, "testuser")
+	other := SeedUser(t, s, "otheruser")
 
-func TestMockDB_SeedUser(t *testing.T) {
-	mockDB := NewMockDB()
-	userID := uuid.New()
-
-	user := mockDB.SeedUser(userID, "testuser", "test@example.com")
-
-	if user.ID != userID {
-		t.Errorf("expected ID %s, got %s", userID, user.ID)
-	}
 	if user.Username != "testuser" {
 		t.Errorf("expected username 'testuser', got %q", user.Username)
 	}
-	if user.Email != "test@example.com" {
-		t.Errorf("expected email 'test@example.com', got %q", user.Email)
-	}
-	if user.Plan != "free" {
-		t.Errorf("expected plan 'free', got %q", user.Plan)
-	}
-
-	// Verify user was added to map
-	if _, ok := mockDB.Users[userID]; !ok {
-		t.Error("expected user to be in Users map")
-	}
-}
-
-func TestMockDB_SeedApp(t *testing.T) {
-	mockDB := NewMockDB()
-	appID := uuid.New()
-	userID := uuid.New()
-
-	app := mockDB.SeedApp(appID, userID, "myapp")
-
-	if app.ID != appID {
-		t.Errorf("expected ID %s, got %s", appID, app.ID)
-	}
-	if app.UserID != userID {
-		t.Errorf("expected UserID %s, got %s", userID, app.UserID)
-	}
-	if app.Name != "myapp" {
-		t.Errorf("expected name 'myapp', got %q", app.Name)
+	if user.Email != "testuser@example.com" {
+		t.Errorf("expected email 'testuser@example.com', got %q", user.Email)
 	}
-	if app.Region != "gdl" {
-		t.Errorf("expected region 'gdl', got %q", app.Region)
-	}
-	if app.Size != "starter" {
-		t.Errorf("expected size 'starter', got %q", app.Size)
-	}
-	if app.Status != "running" {
-		t.Errorf("expected status 'running', got %q", app.Status)
+	if user.GithubID == other.GithubID {
+		t.Error("expected seeded users to have distinct GitHub IDs")
 	}
 
-	// Verify app was added to map
-	if _, ok := mockDB.Apps[appID]; !ok {
-		t.Error("expected app to be in Apps map")
+	got, err := s.Users.Get(context.Background(), user.ID)
+	if err != nil || got.Username != "testuser" {
+		t.Errorf("expected user to be stored, got %v", err)
 	}
 }
 
-func TestMockDB_SeedDeployment(t *testing.T) {
-	mockDB := NewMockDB()
-	deploymentID := uuid.New()
-	appID := uuid.New()
+func TestSeedApp(t *testing.T) {
+	s := store.NewMemory()
+	user := SeedUser(t, s, "testuser")
 
-	deployment := mockDB.SeedDeployment(deploymentID, appID, 1)
+	app := SeedApp(t, s, user.ID, "myapp")
 
-	if deployment.ID != deploymentID {
-		t.Errorf("expected ID %s, got %s", deploymentID, deployment.ID)
-	}
-	if deployment.AppID != appID {
-		t.Errorf("expected AppID %s, got %s", appID, deployment.AppID)
-	}
-	if deployment.Version != 1 {
-		t.Errorf("expected version 1, got %d", deployment.Version)
+	if app.UserID != user.ID {
+		t.Errorf("expected UserID %s, got %s", user.ID, app.UserID)
 	}
-	if deployment.Status != "running" {
-		t.Errorf("expected status 'running', got %q", deployment.Status)
+	if app.Region != "gdl" || app.Size != "starter" {
+		t.Errorf("expected gdl/starter, got %s/%s", app.Region, app.Size)
 	}
 
-	// Verify deployment was added to map
-	if _, ok := mockDB.Deployments[deploymentID]; !ok {
-		t.Error("expected deployment to be in Deployments map")
-	}
-}
-
-func TestMockDB_SeedDomain(t *testing.T) {
-	mockDB := NewMockDB()
-	domainID := uuid.New()
-	appID := uuid.New()
-
-	domain := mockDB.SeedDomain(domainID, appID, "example.com")
-
-	if domain.ID != domainID {
-		t.Errorf("expected ID %s, got %s", domainID, domain.ID)
-	}
-	if domain.AppID != appID {
-		t.Errorf("expected AppID %s, got %s", appID, domain.AppID)
-	}
-	if domain.Domain != "example.com" {
-		t.Errorf("expected domain 'example.com', got %q", domain.Domain)
-	}
-	if domain.Verified {
-		t.Error("expected Verified to be false")
-	}
-	if domain.SslStatus != "pending" {
-		t.Errorf("expected SslStatus 'pending', got %q", domain.SslStatus)
-	}
-
-	// Verify domain was added to map
-	if _, ok := mockDB.Domains[domainID]; !ok {
-		t.Error("expected domain to be in Domains map")
-	}
-}
-
-func TestNewMockQueries(t *testing.T) {
-	mockDB := NewMockDB()
-	queries := NewMockQueries(mockDB)
-
-	if queries == nil {
-		t.Fatal("expected non-nil MockQueries")
-	}
-	if queries.db != mockDB {
-		t.Error("expected MockQueries.db to reference MockDB")
-	}
-}
-
-func TestMockQueries_GetUserByGithubID(t *testing.T) {
-	mockDB := NewMockDB()
-	userID := uuid.New()
-	mockDB.SeedUser(userID, "testuser", "test@example.com")
-
-	queries := NewMockQueries(mockDB)
-
-	t.Run("user found", func(t *testing.T) {
-		user, err := queries.GetUserByGithubID(context.TODO(), 12345)
-		if err != nil {
-			t.Fatalf("expected no error, got %v", err)
-		}
-		if user.ID != userID {
-			t.Errorf("expected user ID %s, got %s", userID, user.ID)
-		}
-	})
-
-	t.Run("user not found", func(t *testing.T) {
-		_, err := queries.GetUserByGithubID(context.TODO(), 99999)
-		if err == nil {
-			t.Error("expected error for non-existent user")
-		}
-	})
-}
-
-func TestMockQueries_GetUserByID(t *testing.T) {
-	mockDB := NewMockDB()
-	userID := uuid.New()
-	mockDB.SeedUser(userID, "testuser", "test@example.com")
-
-	queries := NewMockQueries(mockDB)
-
-	t.Run("user found", func(t *testing.T) {
-		user, err := queries.GetUserByID(context.TODO(), userID)
-		if err != nil {
-			t.Fatalf("expected no error, got %v", err)
-		}
-		if user.Username != "testuser" {
-			t.Errorf("expected username 'testuser', got %q", user.Username)
-		}
-	})
-
-	t.Run("user not found", func(t *testing.T) {
-		_, err := queries.GetUserByID(context.TODO(), uuid.New())
-		if err == nil {
-			t.Error("expected error for non-existent user")
-		}
-	})
-}
-
-func TestMockQueries_ListAppsByUser(t *testing.T) {
-	mockDB := NewMockDB()
-	userID := uuid.New()
-	otherUserID := uuid.New()
-
-	mockDB.SeedApp(uuid.New(), userID, "app1")
-	mockDB.SeedApp(uuid.New(), userID, "app2")
-	mockDB.SeedApp(uuid.New(), otherUserID, "other-app")
-
-	queries := NewMockQueries(mockDB)
-
-	apps, err := queries.ListAppsByUser(context.TODO(), userID)
-	if err != nil {
-		t.Fatalf("expected no error, got %v", err)
-	}
-	if len(apps) != 2 {
-		t.Errorf("expected 2 apps, got %d", len(apps))
+	got, err := s.Apps.GetByName(context.Background(), user.ID, "myapp")
+	if err != nil || got.ID != app.ID {
+		t.Errorf("expected app to be stored, got %v", err)
 	}
 }
 
-func TestMockQueries_GetAppByName(t *testing.T) {
-	mockDB := NewMockDB()
-	userID := uuid.New()
-	mockDB.SeedApp(uuid.New(), userID, "myapp")
-
-	queries := NewMockQueries(mockDB)
-
-	t.Run("app found", func(t *testing.T) {
-		app, err := queries.GetAppByName(context.TODO(), db.GetAppByNameParams{
-			UserID: userID,
-			Name:   "myapp",
-		})
-		if err != nil {
-			t.Fatalf("expected no error, got %v", err)
-		}
-		if app.Name != "myapp" {
-			t.Errorf("expected name 'myapp', got %q", app.Name)
-		}
-	})
-
-	t.Run("app not found", func(t *testing.T) {
-		_, err := queries.GetAppByName(context.TODO(), db.GetAppByNameParams{
-			UserID: userID,
-			Name:   "nonexistent",
-		})
-		if err == nil {
-			t.Error("expected error for non-existent app")
-		}
-	})
-}
-
-func TestMockQueries_CreateApp(t *testing.T) {
-	mockDB := NewMockDB()
-	queries := NewMockQueries(mockDB)
-	userID := uuid.New()
+func TestSeedDeployment(t *testing.T) {
+	s := store.NewMemory()
+	app := SeedApp(t, s, SeedUser(t, s, "testuser").ID, "myapp")
 
-	app, err := queries.CreateApp(context.TODO(), db.CreateAppParams{
-		UserID: userID,
-		Name:   "newapp",
-		Region: "mex",
-		Size:   "pro",
-	})
-	if err != nil {
-		t.Fatalf("expected no error, got %v", err)
-	}
+	deployment := SeedDeployment(t, s, app.ID, 3)
 
-	if app.Name != "newapp" {
-		t.Errorf("expected name 'newapp', got %q", app.Name)
-	}
-	if app.Region != "mex" {
-		t.Errorf("expected region 'mex', got %q", app.Region)
-	}
-	if app.Size != "pro" {
-		t.Errorf("expected size 'pro', got %q", app.Size)
+	if deployment.Version != 3 || deployment.Status != "running" {
+		t.Errorf("expected running version 3, got %d %q", deployment.Version, deployment.Status)
 	}
-	if app.Status != "created" {
-		t.Errorf("expected status 'created', got %q", app.Status)
+	if deployment.Image != "ghcr.io/test/image:v3" {
+		t.Errorf("unexpected image %q", deployment.Image)
 	}
 
-	// Verify app was added to map
-	if _, ok := mockDB.Apps[app.ID]; !ok {
-		t.Error("expected app to be in Apps map")
+	deployments, err := s.Deployments.ListByApp(context.Background(), app.ID, 10, 0)
+	if err != nil || len(deployments) != 1 {
+		t.Errorf("expected 1 deployment, got %d (%v)", len(deployments), err)
 	}
 }
 
-func TestMockQueries_UpdateApp(t *testing.T) {
-	mockDB := NewMockDB()
-	appID := uuid.New()
-	userID := uuid.New()
-	mockDB.SeedApp(appID, userID, "myapp")
-
-	queries := NewMockQueries(mockDB)
-
-	t.Run("app exists", func(t *testing.T) {
-		updated, err := queries.UpdateApp(context.TODO(), db.UpdateAppParams{
-			ID:     appID,
-			Name:   "updated-app",
-			Region: "qro",
-			Size:   "enterprise",
-		})
-		if err != nil {
-			t.Fatalf("expected no error, got %v", err)
-		}
-		if updated.Name != "updated-app" {
-			t.Errorf("expected name 'updated-app', got %q", updated.Name)
-		}
-		if updated.Region != "qro" {
-			t.Errorf("expected region 'qro', got %q", updated.Region)
-		}
-	})
+func TestSeedDomain(t *testing.T) {
+	s := store.NewMemory()
+	app := SeedApp(t, s, SeedUser(t, s, "testuser").ID, "myapp")
 
-	t.Run("app not found", func(t *testing.T) {
-		_, err := queries.UpdateApp(context.TODO(), db.UpdateAppParams{
-			ID:     uuid.New(),
-			Name:   "updated-app",
-			Region: "qro",
-			Size:   "enterprise",
-		})
-		if err == nil {
-			t.Error("expected error for non-existent app")
-		}
-	})
-}
-
-func TestMockQueries_DeleteApp(t *testing.T) {
-	mockDB := NewMockDB()
-	appID := uuid.New()
-	userID := uuid.New()
-	mockDB.SeedApp(appID, userID, "myapp")
-
-	queries := NewMockQueries(mockDB)
-
-	t.Run("app exists", func(t *testing.T) {
-		err := queries.DeleteApp(context.TODO(), appID)
-		if err != nil {
-			t.Fatalf("expected no error, got %v", err)
-		}
-		if _, ok := mockDB.Apps[appID]; ok {
-			t.Error("expected app to be deleted from map")
-		}
-	})
+	domain := SeedDomain(t, s, app.ID, "example.com")
 
-	t.Run("app not found", func(t *testing.T) {
-		err := queries.DeleteApp(context.TODO(), uuid.New())
-		if err == nil {
-			t.Error("expected error for non-existent app")
-		}
-	})
-}
-
-func TestMockQueries_ListDeploymentsByApp(t *testing.T) {
-	mockDB := NewMockDB()
-	appID := uuid.New()
-	otherAppID := uuid.New()
-
-	mockDB.SeedDeployment(uuid.New(), appID, 1)
-	mockDB.SeedDeployment(uuid.New(), appID, 2)
-	mockDB.SeedDeployment(uuid.New(), otherAppID, 1)
-
-	queries := NewMockQueries(mockDB)
-
-	deployments, err := queries.ListDeploymentsByApp(context.TODO(), db.ListDeploymentsByAppParams{
-		AppID: appID,
-	})
-	if err != nil {
-		t.Fatalf("expected no error, got %v", err)
-	}
-	if len(deployments) != 2 {
-		t.Errorf("expected 2 deployments, got %d", len(deployments))
+	if domain.Verified || domain.SslStatus != "pending" {
+		t.Errorf("expected an unverified domain pending SSL, got %+v", domain)
 	}
-}
-
-func TestMockQueries_ListDomainsByApp(t *testing.T) {
-	mockDB := NewMockDB()
-	appID := uuid.New()
-	otherAppID := uuid.New()
 
-	mockDB.SeedDomain(uuid.New(), appID, "example.com")
-	mockDB.SeedDomain(uuid.New(), appID, "api.example.com")
-	mockDB.SeedDomain(uuid.New(), otherAppID, "other.com")
-
-	queries := NewMockQueries(mockDB)
-
-	domains, err := queries.ListDomainsByApp(context.TODO(), appID)
-	if err != nil {
-		t.Fatalf("expected no error, got %v", err)
-	}
-	if len(domains) != 2 {
-		t.Errorf("expected 2 domains, got %d", len(domains))
+	got, err := s.Domains.GetByName(context.Background(), "example.com")
+	if err != nil || got.ID != domain.ID {
+		t.Errorf("expected domain to be stored, got %v", err)
 	}
 }
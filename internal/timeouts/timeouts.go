@@ -0,0 +1,102 @@
+// Package timeouts holds how long each API route may run before its request
+// context is canceled and the client gets a 504. Routes have built-in
+// ceilings, such as a minute to trigger a deploy and a few seconds for
+// /api/metrics, which ROUTE_TIMEOUTS overrides; other routes get the
+// REQUEST_TIMEOUT_SECONDS default. Streams, such as followed logs, are not
+// bounded.
+package timeouts
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Rule bounds the requests of a method to paths matching Pattern, where a
+// "*" segment matches any one segment. A zero Timeout leaves them unbounded.
+type Rule struct {
+	Method  string
+	Pattern string
+	Timeout time.Duration
+}
+
+// Rules are the built-in route ceilings
+var Rules = []Rule{
+	{Method: http.MethodPost, Pattern: "/api/apps/*/deployments", Timeout: time.Minute},
+	{Method: http.MethodPost, Pattern: "/api/apps/*/deployments/*", Timeout: time.Minute},
+	{Method: http.MethodGet, Pattern: "/api/apps/*/export", Timeout: time.Minute},
+	{Method: http.MethodGet, Pattern: "/api/apps/*/logs/download", Timeout: 0},
+	{Method: http.MethodPost, Pattern: "/api/admin/backups", Timeout: 10 * time.Minute},
+	{Method: http.MethodGet, Pattern: "/api/metrics", Timeout: 5 * time.Second},
+	{Method: http.MethodGet, Pattern: "/api/health", Timeout: 5 * time.Second},
+}
+
+// Policy decides the timeout of each request
+type Policy struct {
+	// Default bounds requests no rule matches
+	Default time.Duration
+	// Rules are tried in order, overrides before the built-in ones
+	Rules []Rule
+}
+
+// Parse builds the policy from the default timeout and overrides written as
+// "METHOD /path=duration", e.g. "POST /api/apps/*/deployments=2m".
+func Parse(defaultTimeout time.Duration, overrides []string) (*Policy, error) {
+	policy := &Policy{Default: defaultTimeout}
+	for _, override := range overrides {
+		route, value, ok := strings.Cut(strings.TrimSpace(override), "=")
+		method, pattern, hasPath := strings.Cut(strings.TrimSpace(route), " ")
+		pattern = strings.TrimSpace(pattern)
+		if !ok || !hasPath || !strings.HasPrefix(pattern, "/") {
+			return nil, fmt.Errorf("invalid route timeout %q: expected METHOD /path=duration", override)
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || timeout < 0 {
+			return nil, fmt.Errorf("invalid route timeout %q: %q is not a duration", override, value)
+		}
+		policy.Rules = append(policy.Rules, Rule{
+			Method:  strings.ToUpper(method),
+			Pattern: strings.TrimSuffix(pattern, "/"),
+			Timeout: timeout,
+		})
+	}
+	policy.Rules = append(policy.Rules, Rules...)
+	return policy, nil
+}
+
+// For returns the timeout of a request, zero when it is not bounded
+func (p *Policy) For(r *http.Request) time.Duration {
+	if Streaming(r) {
+		return 0
+	}
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	for _, rule := range p.Rules {
+		if (rule.Method == r.Method || rule.Method == "*") && matches(rule.Pattern, path) {
+			return rule.Timeout
+		}
+	}
+	return p.Default
+}
+
+// Streaming reports whether a request opens a stream that lasts as long as
+// the client follows it
+func Streaming(r *http.Request) bool {
+	return r.URL.Query().Get("follow") == "true" ||
+		strings.EqualFold(r.Header.Get("Upgrade"), "websocket") ||
+		strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+func matches(pattern, path string) bool {
+	want := strings.Split(pattern, "/")
+	got := strings.Split(path, "/")
+	if len(want) != len(got) {
+		return false
+	}
+	for i := range want {
+		if want[i] != "*" && want[i] != got[i] {
+			return false
+		}
+	}
+	return true
+}
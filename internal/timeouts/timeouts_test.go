@@ -0,0 +1,72 @@
+package timeouts
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFor(t *testing.T) {
+	policy, err := Parse(30*time.Second, []string{"post /api/apps/*/deployments = 2m", "GET /api/apps/*/env=1s"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		method, target string
+		header         map[string]string
+		want           time.Duration
+	}{
+		{"POST", "/api/apps/shop/deployments", nil, 2 * time.Minute},
+		{"POST", "/api/apps/shop/deployments/", nil, 2 * time.Minute},
+		{"GET", "/api/apps/shop/deployments", nil, 30 * time.Second},
+		{"POST", "/api/apps/shop/deployments/123", nil, time.Minute},
+		{"GET", "/api/apps/shop/env", nil, time.Second},
+		{"GET", "/api/metrics", nil, 5 * time.Second},
+		{"GET", "/api/apps/shop/logs/download", nil, 0},
+		{"GET", "/api/apps/shop/logs?follow=true", nil, 0},
+		{"GET", "/api/apps/shop/logs", map[string]string{"Accept": "text/event-stream"}, 0},
+		{"GET", "/api/apps/shop/logs", nil, 30 * time.Second},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.target, nil)
+		for k, v := range tt.header {
+			r.Header.Set(k, v)
+		}
+		if got := policy.For(r); got != tt.want {
+			t.Errorf("For(%s %s) = %s, want %s", tt.method, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, override := range []string{"/api/metrics=5s", "GET /api/metrics", "GET api/metrics=5s", "GET /api/metrics=soon", "GET /api/metrics=-1s"} {
+		if _, err := Parse(time.Second, []string{override}); err == nil {
+			t.Errorf("expected %q to be rejected", override)
+		}
+	}
+}
+
+func TestWriter(t *testing.T) {
+	w := NewWriter()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write([]byte(`{"ok":true}`))
+
+	rec := httptest.NewRecorder()
+	rec.Header().Set("X-Request-ID", "abc")
+	if err := w.Send(rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusCreated || rec.Body.String() != `{"ok":true}` ||
+		rec.Header().Get("Content-Type") != "application/json" || rec.Header().Get("X-Request-ID") != "abc" {
+		t.Errorf("unexpected response %d %q %v", rec.Code, rec.Body.String(), rec.Header())
+	}
+
+	late := NewWriter()
+	late.TimeOut()
+	if _, err := late.Write([]byte("too late")); err != http.ErrHandlerTimeout {
+		t.Errorf("expected writes after the timeout to fail, got %v", err)
+	}
+}
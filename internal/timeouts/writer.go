@@ -0,0 +1,74 @@
+package timeouts
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+)
+
+// Writer buffers the response of a bounded request, so it is sent whole
+// when the handler finishes in time and dropped when the timeout responds
+// instead
+type Writer struct {
+	mu       sync.Mutex
+	header   http.Header
+	body     bytes.Buffer
+	status   int
+	timedOut bool
+}
+
+// NewWriter creates an empty response buffer
+func NewWriter() *Writer {
+	return &Writer{header: make(http.Header)}
+}
+
+// Header returns the buffered headers, added to those already set on send
+func (w *Writer) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader buffers the status code
+func (w *Writer) WriteHeader(status int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.timedOut && w.status == 0 {
+		w.status = status
+	}
+}
+
+// Write buffers the body, failing with http.ErrHandlerTimeout once the
+// request timed out
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(p)
+}
+
+// TimeOut drops the response for the timeout to answer instead
+func (w *Writer) TimeOut() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.timedOut = true
+	w.body.Reset()
+}
+
+// Send writes the buffered response to dst
+func (w *Writer) Send(dst http.ResponseWriter) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.status == 0 {
+		return nil
+	}
+	for key, values := range w.header {
+		dst.Header()[key] = values
+	}
+	dst.WriteHeader(w.status)
+	_, err := dst.Write(w.body.Bytes())
+	return err
+}
@@ -0,0 +1,250 @@
+// Package tokenpolicy enforces API token network restrictions and the
+// maximum token lifetime organizations set for their members.
+package tokenpolicy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrLifetimeExceeded is returned when a requested token outlives the policy
+var ErrLifetimeExceeded = errors.New("token lifetime exceeds organization policy")
+
+// ErrInvalidUnusedDays is returned for an out of range auto-expire option
+var ErrInvalidUnusedDays = errors.New("expire_after_unused_days must be between 1 and 365")
+
+// Errors of Check, one per rule a token breaks
+var (
+	ErrExpired           = errors.New("token expired")
+	ErrDisallowedAddress = errors.New("token not allowed from this address")
+	ErrNonCompliant      = errors.New("token violates organization lifetime policy")
+	ErrUnused            = errors.New("token expired after going unused")
+)
+
+const (
+	// StaleAfter is how long a token may go unused before its owner is warned
+	StaleAfter = 90 * 24 * time.Hour
+	// UsageDays is how many days of per-day usage counts are kept
+	UsageDays = 90
+	// maxUserAgentLength bounds the stored user agent of a token's last use
+	maxUserAgentLength = 256
+)
+
+// ParseCIDRs validates and normalizes CIDR ranges. Bare addresses are
+// treated as single-host ranges.
+func ParseCIDRs(cidrs []string) ([]string, error) {
+	normalized := make([]string, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if !strings.Contains(cidr, "/") {
+			addr, err := netip.ParseAddr(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid cidr %q", cidr)
+			}
+			normalized = append(normalized, netip.PrefixFrom(addr, addr.BitLen()).String())
+			continue
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cidr %q", cidr)
+		}
+		normalized = append(normalized, prefix.Masked().String())
+	}
+	return normalized, nil
+}
+
+// Allowed reports whether a client IP falls within a token's CIDR ranges.
+// Tokens without ranges are allowed from anywhere.
+func Allowed(cidrs []string, ip string) bool {
+	if len(cidrs) == 0 {
+		return true
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		// RemoteAddr includes the port
+		addrPort, err := netip.ParseAddrPort(ip)
+		if err != nil {
+			return false
+		}
+		addr = addrPort.Addr()
+	}
+	addr = addr.Unmap()
+
+	for _, cidr := range cidrs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err == nil && prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check enforces a token's expiry, its CIDR ranges for a client at ip, its
+// owner's organization lifetime policy and its auto-expire option. The
+// sweeper deletes such tokens too; checking on use applies a lowered limit
+// immediately. Every way of signing in with a token goes through it.
+func Check(ctx context.Context, queries *db.Queries, token db.ApiToken, ip string, now time.Time) error {
+	maxDays, err := queries.GetMaxTokenLifetimeForUser(ctx, pgtype.UUID{Bytes: token.UserID, Valid: true})
+	if err != nil {
+		// A failed lookup applies no lifetime policy
+		maxDays = 0
+	}
+	return check(token, ip, maxDays, now)
+}
+
+func check(token db.ApiToken, ip string, maxDays int32, now time.Time) error {
+	if token.ExpiresAt.Valid && token.ExpiresAt.Time.Before(now) {
+		return ErrExpired
+	}
+	if !Allowed(token.AllowedCidrs, ip) {
+		return ErrDisallowedAddress
+	}
+	if !Compliant(token.CreatedAt, token.ExpiresAt, maxDays) {
+		return ErrNonCompliant
+	}
+	if expiry := UnusedExpiry(token.CreatedAt, token.LastUsedAt, token.ExpireAfterUnusedDays); !expiry.IsZero() && now.After(expiry) {
+		return ErrUnused
+	}
+	return nil
+}
+
+// Compliant reports whether a token's lifetime is within maxDays. Tokens
+// without an expiry never comply with a policy; maxDays of zero means no policy.
+func Compliant(createdAt time.Time, expiresAt pgtype.Timestamptz, maxDays int32) bool {
+	if maxDays <= 0 {
+		return true
+	}
+	if !expiresAt.Valid {
+		return false
+	}
+	return !expiresAt.Time.After(createdAt.Add(days(maxDays)))
+}
+
+// LastActivity returns when a token was last used, or when it was created
+// if it never was
+func LastActivity(createdAt time.Time, lastUsedAt pgtype.Timestamptz) time.Time {
+	if lastUsedAt.Valid {
+		return lastUsedAt.Time
+	}
+	return createdAt
+}
+
+// Stale reports whether a token has gone unused for StaleAfter
+func Stale(createdAt time.Time, lastUsedAt pgtype.Timestamptz, now time.Time) bool {
+	return now.Sub(LastActivity(createdAt, lastUsedAt)) >= StaleAfter
+}
+
+// UnusedExpiry returns when a token that expires after going unused for
+// unusedDays is revoked unless used again. The zero time means the token
+// has no such option.
+func UnusedExpiry(createdAt time.Time, lastUsedAt pgtype.Timestamptz, unusedDays *int32) time.Time {
+	if unusedDays == nil || *unusedDays <= 0 {
+		return time.Time{}
+	}
+	return LastActivity(createdAt, lastUsedAt).Add(days(*unusedDays))
+}
+
+// ParseUnusedDays validates the auto-expire option of a new token. Zero
+// means the token does not expire when unused.
+func ParseUnusedDays(n int) (*int32, error) {
+	if n == 0 {
+		return nil, nil
+	}
+	if n < 1 || n > 365 {
+		return nil, ErrInvalidUnusedDays
+	}
+	unusedDays := int32(n) //nolint:gosec // Bounded above
+	return &unusedDays, nil
+}
+
+// UserAgent trims a client's user agent for storage
+func UserAgent(userAgent string) *string {
+	userAgent = strings.TrimSpace(userAgent)
+	if userAgent == "" {
+		return nil
+	}
+	if runes := []rune(userAgent); len(runes) > maxUserAgentLength {
+		userAgent = string(runes[:maxUserAgentLength])
+	}
+	return &userAgent
+}
+
+// ResolveExpiry returns the expiry of a token requested to live for
+// expiresIn. Under a policy, tokens without a requested lifetime get the
+// maximum one and longer requests are rejected. The zero time means the
+// token never expires.
+func ResolveExpiry(now time.Time, expiresIn time.Duration, maxDays int32) (time.Time, error) {
+	if maxDays <= 0 {
+		if expiresIn <= 0 {
+			return time.Time{}, nil
+		}
+		return now.Add(expiresIn), nil
+	}
+
+	if expiresIn <= 0 {
+		return now.Add(days(maxDays)), nil
+	}
+	if expiresIn > days(maxDays) {
+		return time.Time{}, fmt.Errorf("%w: tokens may live at most %d days", ErrLifetimeExceeded, maxDays)
+	}
+	return now.Add(expiresIn), nil
+}
+
+func days(n int32) time.Duration {
+	return time.Duration(n) * 24 * time.Hour
+}
+
+// Sweeper deletes expired tokens, tokens left unused past their auto-expire
+// option and tokens that violate their owner's organization policy, e.g.
+// after an org lowers its maximum lifetime, along with old usage counts and
+// abandoned device authorizations
+type Sweeper struct {
+	queries *db.Queries
+}
+
+// NewSweeper creates a sweeper
+func NewSweeper(queries *db.Queries) *Sweeper {
+	return &Sweeper{queries: queries}
+}
+
+// Sweep revokes expired and non-compliant tokens once
+func (s *Sweeper) Sweep(ctx context.Context) error {
+	if err := s.queries.DeleteExpiredAPITokens(ctx); err != nil {
+		return fmt.Errorf("failed to delete expired tokens: %w", err)
+	}
+
+	revoked, err := s.queries.DeleteNonCompliantAPITokens(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete non-compliant tokens: %w", err)
+	}
+	if revoked > 0 {
+		slog.Info("revoked api tokens violating organization lifetime policy", "count", revoked)
+	}
+
+	unused, err := s.queries.DeleteUnusedAPITokens(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete unused tokens: %w", err)
+	}
+	if unused > 0 {
+		slog.Info("revoked api tokens left unused past their auto-expire option", "count", unused)
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -UsageDays)
+	if err := s.queries.DeleteOldAPITokenUsage(ctx, pgtype.Date{Time: cutoff, Valid: true}); err != nil {
+		return fmt.Errorf("failed to delete old token usage: %w", err)
+	}
+
+	if err := s.queries.DeleteExpiredDeviceAuthorizations(ctx); err != nil {
+		return fmt.Errorf("failed to delete expired device authorizations: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package tokenpolicy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestParseCIDRs(t *testing.T) {
+	cidrs, err := ParseCIDRs([]string{"10.0.0.0/8", " 192.168.1.7/24", "203.0.113.5", "2001:db8::/32"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"10.0.0.0/8", "192.168.1.0/24", "203.0.113.5/32", "2001:db8::/32"}
+	for i := range expected {
+		if cidrs[i] != expected[i] {
+			t.Errorf("expected %q, got %q", expected[i], cidrs[i])
+		}
+	}
+
+	if _, err := ParseCIDRs([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("expected invalid prefix length to be rejected")
+	}
+	if _, err := ParseCIDRs([]string{"example.com"}); err == nil {
+		t.Error("expected hostname to be rejected")
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	cidrs := []string{"10.0.0.0/8", "2001:db8::/32"}
+
+	tests := []struct {
+		ip      string
+		allowed bool
+	}{
+		{"10.1.2.3", true},
+		{"::ffff:10.1.2.3", true},
+		{"2001:db8::1", true},
+		{"10.1.2.3:54321", true},
+		{"192.168.1.1", false},
+		{"not-an-ip", false},
+	}
+
+	for _, tt := range tests {
+		if got := Allowed(cidrs, tt.ip); got != tt.allowed {
+			t.Errorf("Allowed(%q) = %v, expected %v", tt.ip, got, tt.allowed)
+		}
+	}
+
+	if !Allowed(nil, "192.168.1.1") {
+		t.Error("expected tokens without ranges to be allowed from anywhere")
+	}
+}
+
+func TestCompliant(t *testing.T) {
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	expires := func(d time.Duration) pgtype.Timestamptz {
+		return pgtype.Timestamptz{Time: created.Add(d), Valid: true}
+	}
+
+	if !Compliant(created, pgtype.Timestamptz{}, 0) {
+		t.Error("expected any token to comply without a policy")
+	}
+	if Compliant(created, pgtype.Timestamptz{}, 30) {
+		t.Error("expected a token without expiry to violate the policy")
+	}
+	if !Compliant(created, expires(30*24*time.Hour), 30) {
+		t.Error("expected a token at the maximum lifetime to comply")
+	}
+	if Compliant(created, expires(31*24*time.Hour), 30) {
+		t.Error("expected a longer-lived token to violate the policy")
+	}
+}
+
+func TestResolveExpiry(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	expiry, err := ResolveExpiry(now, 0, 0)
+	if err != nil || !expiry.IsZero() {
+		t.Errorf("expected no expiry without a policy, got %v, %v", expiry, err)
+	}
+
+	expiry, err = ResolveExpiry(now, 0, 30)
+	if err != nil || !expiry.Equal(now.Add(30*24*time.Hour)) {
+		t.Errorf("expected the policy maximum by default, got %v, %v", expiry, err)
+	}
+
+	expiry, err = ResolveExpiry(now, time.Hour, 30)
+	if err != nil || !expiry.Equal(now.Add(time.Hour)) {
+		t.Errorf("expected the requested lifetime, got %v, %v", expiry, err)
+	}
+
+	if _, err := ResolveExpiry(now, 31*24*time.Hour, 30); !errors.Is(err, ErrLifetimeExceeded) {
+		t.Errorf("expected ErrLifetimeExceeded, got %v", err)
+	}
+}
+
+func TestStale(t *testing.T) {
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	used := pgtype.Timestamptz{Time: created.Add(60 * 24 * time.Hour), Valid: true}
+
+	if Stale(created, pgtype.Timestamptz{}, created.Add(89*24*time.Hour)) {
+		t.Error("expected a new token not to be stale")
+	}
+	if !Stale(created, pgtype.Timestamptz{}, created.Add(90*24*time.Hour)) {
+		t.Error("expected a token never used for 90 days to be stale")
+	}
+	if Stale(created, used, created.Add(120*24*time.Hour)) {
+		t.Error("expected a recently used token not to be stale")
+	}
+}
+
+func TestUnusedExpiry(t *testing.T) {
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	used := pgtype.Timestamptz{Time: created.Add(10 * 24 * time.Hour), Valid: true}
+	thirty := int32(30)
+
+	if expiry := UnusedExpiry(created, used, nil); !expiry.IsZero() {
+		t.Errorf("expected no expiry without the option, got %v", expiry)
+	}
+	if expiry := UnusedExpiry(created, pgtype.Timestamptz{}, &thirty); !expiry.Equal(created.Add(30 * 24 * time.Hour)) {
+		t.Errorf("expected expiry counted from creation, got %v", expiry)
+	}
+	if expiry := UnusedExpiry(created, used, &thirty); !expiry.Equal(used.Time.Add(30 * 24 * time.Hour)) {
+		t.Errorf("expected expiry counted from last use, got %v", expiry)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	created := now.Add(-60 * 24 * time.Hour)
+	thirty := int32(30)
+
+	tests := []struct {
+		name    string
+		token   db.ApiToken
+		ip      string
+		maxDays int32
+		want    error
+	}{
+		{"valid", db.ApiToken{CreatedAt: created}, "10.0.0.1", 0, nil},
+		{"expired", db.ApiToken{CreatedAt: created, ExpiresAt: pgtype.Timestamptz{Time: now.Add(-time.Hour), Valid: true}}, "10.0.0.1", 0, ErrExpired},
+		{"outside its ranges", db.ApiToken{CreatedAt: created, AllowedCidrs: []string{"192.168.0.0/16"}}, "10.0.0.1", 0, ErrDisallowedAddress},
+		{"no expiry under a policy", db.ApiToken{CreatedAt: created}, "10.0.0.1", 90, ErrNonCompliant},
+		{"unused past its option", db.ApiToken{CreatedAt: created, ExpireAfterUnusedDays: &thirty}, "10.0.0.1", 0, ErrUnused},
+		{"used within its option", db.ApiToken{CreatedAt: created, ExpireAfterUnusedDays: &thirty, LastUsedAt: pgtype.Timestamptz{Time: now.Add(-24 * time.Hour), Valid: true}}, "10.0.0.1", 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := check(tt.token, tt.ip, tt.maxDays, now); !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestParseUnusedDays(t *testing.T) {
+	if days, err := ParseUnusedDays(0); days != nil || err != nil {
+		t.Errorf("expected no option for 0, got %v, %v", days, err)
+	}
+	if days, err := ParseUnusedDays(90); err != nil || days == nil || *days != 90 {
+		t.Errorf("expected 90, got %v, %v", days, err)
+	}
+	for _, n := range []int{-1, 366} {
+		if _, err := ParseUnusedDays(n); !errors.Is(err, ErrInvalidUnusedDays) {
+			t.Errorf("ParseUnusedDays(%d): expected ErrInvalidUnusedDays, got %v", n, err)
+		}
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	if ua := UserAgent("  "); ua != nil {
+		t.Errorf("expected nil for a blank user agent, got %q", *ua)
+	}
+	if ua := UserAgent("fuegoctl/1.2.0"); ua == nil || *ua != "fuegoctl/1.2.0" {
+		t.Errorf("unexpected user agent %v", ua)
+	}
+	if ua := UserAgent(strings.Repeat("a", 1000)); ua == nil || len(*ua) != maxUserAgentLength {
+		t.Error("expected long user agents to be truncated")
+	}
+}
@@ -0,0 +1,160 @@
+// Package tunnel exposes apps through Cloudflare Tunnels on clusters without
+// a public load balancer. Each app gets a tunnel of its own, created when it
+// is first deployed: the app's cloudflared connectors dial out to Cloudflare,
+// and the app's hostname is a proxied CNAME to the tunnel, so nothing in the
+// cluster has to be reachable from the internet.
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/cloudflare"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/cryptoutil"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrHostnameTaken is returned when an app's hostname already has a DNS
+// record pointing elsewhere
+var ErrHostnameTaken = errors.New("hostname already has a DNS record")
+
+// Name is the name of an app's tunnel. It uses the app's ID, as names are
+// unique in the Cloudflare account and app names are reused after deletion.
+func Name(app db.App) string {
+	return "nexo-" + app.ID.String()
+}
+
+// Hostname is the platform hostname of an app
+func Hostname(cfg *config.Config, app db.App) string {
+	return app.Name + "." + cfg.AppsDomainSuffix
+}
+
+// Routes forwards each hostname to the app's Service
+func Routes(appName string, hostnames []string) []cloudflare.TunnelRoute {
+	routes := make([]cloudflare.TunnelRoute, 0, len(hostnames))
+	for _, hostname := range hostnames {
+		routes = append(routes, cloudflare.TunnelRoute{
+			Hostname: hostname,
+			Service:  k8s.TunnelService(appName),
+		})
+	}
+	return routes
+}
+
+// Hostnames returns the hostnames routed through an app's tunnel: its
+// platform hostname and its verified custom domains
+func Hostnames(ctx context.Context, cfg *config.Config, queries *db.Queries, app db.App) ([]string, error) {
+	hostnames := []string{Hostname(cfg, app)}
+
+	domains, err := queries.ListDomainsByApp(ctx, app.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domains: %w", err)
+	}
+	for _, d := range domains {
+		if d.Verified {
+			hostnames = append(hostnames, d.Domain)
+		}
+	}
+	return hostnames, nil
+}
+
+// Ensure creates the tunnel of an app unless it has one, routes the app's
+// current hostnames through it and points the app's hostname at it. It runs
+// before every deployment, so domains verified since the last one are
+// picked up.
+func Ensure(ctx context.Context, cfg *config.Config, queries *db.Queries, cf *cloudflare.Client, app db.App) (db.AppTunnel, error) {
+	stored, err := queries.GetAppTunnel(ctx, app.ID)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		stored, err = create(ctx, cfg, queries, cf, app)
+		if err != nil {
+			return db.AppTunnel{}, fmt.Errorf("failed to create tunnel: %w", err)
+		}
+	case err != nil:
+		return db.AppTunnel{}, fmt.Errorf("failed to get tunnel: %w", err)
+	}
+
+	hostnames, err := Hostnames(ctx, cfg, queries, app)
+	if err != nil {
+		return db.AppTunnel{}, err
+	}
+	if err := cf.ConfigureTunnel(ctx, stored.TunnelID, Routes(app.Name, hostnames)); err != nil {
+		return db.AppTunnel{}, fmt.Errorf("failed to configure tunnel: %w", err)
+	}
+
+	if stored.DnsRecordID != "" {
+		return stored, nil
+	}
+
+	hostname := Hostname(cfg, app)
+	target := stored.TunnelID + "." + cloudflare.TunnelDomain
+	record, err := cf.GetRecordByName(ctx, hostname)
+	if err != nil {
+		return db.AppTunnel{}, fmt.Errorf("failed to look up %s: %w", hostname, err)
+	}
+	switch {
+	case record == nil:
+		record, err = cf.CreateCNAME(ctx, hostname, target)
+		if err != nil {
+			return db.AppTunnel{}, fmt.Errorf("failed to route %s to the tunnel: %w", hostname, err)
+		}
+	case record.Content != target:
+		return db.AppTunnel{}, fmt.Errorf("%w: %s points to %s", ErrHostnameTaken, hostname, record.Content)
+	}
+
+	if err := queries.SetAppTunnelDNSRecord(ctx, db.SetAppTunnelDNSRecordParams{
+		AppID:       app.ID,
+		DnsRecordID: record.ID,
+	}); err != nil {
+		return db.AppTunnel{}, fmt.Errorf("failed to store tunnel record: %w", err)
+	}
+	stored.DnsRecordID = record.ID
+	return stored, nil
+}
+
+// create creates the tunnel of an app and stores it with its token
+func create(ctx context.Context, cfg *config.Config, queries *db.Queries, cf *cloudflare.Client, app db.App) (db.AppTunnel, error) {
+	tunnel, err := cf.CreateTunnel(ctx, Name(app))
+	if err != nil {
+		return db.AppTunnel{}, err
+	}
+
+	stored, err := func() (db.AppTunnel, error) {
+		token, err := cf.TunnelToken(ctx, tunnel.ID)
+		if err != nil {
+			return db.AppTunnel{}, err
+		}
+		encrypted, err := cryptoutil.EncryptBytes([]byte(token), cfg.EncryptionKey)
+		if err != nil {
+			return db.AppTunnel{}, fmt.Errorf("failed to encrypt tunnel token: %w", err)
+		}
+		return queries.CreateAppTunnel(ctx, db.CreateAppTunnelParams{
+			AppID:          app.ID,
+			TunnelID:       tunnel.ID,
+			TokenEncrypted: encrypted,
+		})
+	}()
+	if err != nil {
+		// Free the name for the next attempt
+		if deleteErr := cf.DeleteTunnel(ctx, tunnel.ID); deleteErr != nil {
+			slog.Warn("failed to delete unused tunnel", "app", app.Name, "tunnel_id", tunnel.ID, "error", deleteErr)
+		}
+		return db.AppTunnel{}, err
+	}
+	return stored, nil
+}
+
+// Remove deletes a tunnel and the DNS record of its app's hostname from
+// Cloudflare. The stored row goes with the app.
+func Remove(ctx context.Context, cf *cloudflare.Client, stored db.AppTunnel) error {
+	var recordErr error
+	if stored.DnsRecordID != "" {
+		recordErr = cf.DeleteRecord(ctx, stored.DnsRecordID)
+	}
+	return errors.Join(recordErr, cf.DeleteTunnel(ctx, stored.TunnelID))
+}
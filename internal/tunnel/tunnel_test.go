@@ -0,0 +1,34 @@
+package tunnel
+
+import (
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/google/uuid"
+)
+
+func TestNames(t *testing.T) {
+	app := db.App{ID: uuid.MustParse("0b5c1a52-8f0e-4a43-9a9e-3f4d2a6c7e10"), Name: "shop"}
+	if name := Name(app); name != "nexo-0b5c1a52-8f0e-4a43-9a9e-3f4d2a6c7e10" {
+		t.Errorf("unexpected tunnel name %s", name)
+	}
+	if hostname := Hostname(&config.Config{AppsDomainSuffix: "nexo.build"}, app); hostname != "shop.nexo.build" {
+		t.Errorf("unexpected hostname %s", hostname)
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	routes := Routes("shop", []string{"shop.nexo.build", "www.example.com"})
+	if len(routes) != 2 {
+		t.Fatalf("expected 2 routes, got %v", routes)
+	}
+	for _, route := range routes {
+		if route.Service != "http://shop:80" {
+			t.Errorf("expected %s to go to the app's Service, got %s", route.Hostname, route.Service)
+		}
+	}
+	if routes[1].Hostname != "www.example.com" {
+		t.Errorf("unexpected routes %v", routes)
+	}
+}
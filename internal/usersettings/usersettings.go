@@ -0,0 +1,102 @@
+// Package usersettings holds a user's account defaults: the region, size
+// and health check path given to the apps they create without one, and
+// whether they are sent notification emails. A user without saved settings
+// gets the platform's defaults.
+package usersettings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Platform defaults of new apps
+const (
+	DefaultRegion          = "gdl"
+	DefaultSize            = "starter"
+	DefaultHealthCheckPath = "/api/health"
+)
+
+// MaxHealthCheckPathLength bounds a health check path
+const MaxHealthCheckPathLength = 200
+
+// Regions and Sizes an app can have
+var (
+	Regions = []string{"gdl", "mex", "qro"}
+	Sizes   = []string{"starter", "pro", "enterprise"}
+)
+
+// ErrInvalid is returned for settings that cannot be saved
+var ErrInvalid = errors.New("invalid settings")
+
+// Get returns a user's settings, the platform's defaults when they saved none
+func Get(ctx context.Context, queries *db.Queries, userID uuid.UUID) (db.UserSetting, error) {
+	settings, err := queries.GetUserSettings(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.UserSetting{UserID: userID, EmailNotifications: true}, nil
+	}
+	return settings, err
+}
+
+// Validate checks settings before they are saved. Empty defaults are valid
+// and fall back to the platform's.
+func Validate(params db.UpsertUserSettingsParams) error {
+	if params.DefaultRegion != "" && !ValidRegion(params.DefaultRegion) {
+		return fmt.Errorf("%w: region must be one of %s", ErrInvalid, strings.Join(Regions, ", "))
+	}
+	if params.DefaultSize != "" && !ValidSize(params.DefaultSize) {
+		return fmt.Errorf("%w: size must be one of %s", ErrInvalid, strings.Join(Sizes, ", "))
+	}
+	if params.DefaultHealthCheckPath != "" {
+		if err := ValidateHealthCheckPath(params.DefaultHealthCheckPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidRegion reports whether apps can run in the region
+func ValidRegion(region string) bool {
+	return slices.Contains(Regions, region)
+}
+
+// ValidSize reports whether apps can have the size
+func ValidSize(size string) bool {
+	return slices.Contains(Sizes, size)
+}
+
+// ValidateHealthCheckPath checks the path probes request on an app
+func ValidateHealthCheckPath(path string) error {
+	switch {
+	case !strings.HasPrefix(path, "/"):
+		return fmt.Errorf("%w: health check path must start with /", ErrInvalid)
+	case len(path) > MaxHealthCheckPathLength:
+		return fmt.Errorf("%w: health check path must be at most %d characters", ErrInvalid, MaxHealthCheckPathLength)
+	case strings.ContainsAny(path, " \t\r\n?#"):
+		return fmt.Errorf("%w: health check path must be a path without spaces, query or fragment", ErrInvalid)
+	}
+	return nil
+}
+
+// AppDefaults fills in what a new app was not given: the user's default
+// when set, the platform's otherwise
+func AppDefaults(settings db.UserSetting, region, size, healthCheckPath string) (string, string, string) {
+	return pick(region, settings.DefaultRegion, DefaultRegion),
+		pick(size, settings.DefaultSize, DefaultSize),
+		pick(healthCheckPath, settings.DefaultHealthCheckPath, DefaultHealthCheckPath)
+}
+
+func pick(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
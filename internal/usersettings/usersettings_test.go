@@ -0,0 +1,55 @@
+package usersettings
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		params db.UpsertUserSettingsParams
+		valid  bool
+	}{
+		{"platform defaults", db.UpsertUserSettingsParams{}, true},
+		{"all set", db.UpsertUserSettingsParams{DefaultRegion: "mex", DefaultSize: "pro", DefaultHealthCheckPath: "/healthz"}, true},
+		{"unknown region", db.UpsertUserSettingsParams{DefaultRegion: "nyc"}, false},
+		{"unknown size", db.UpsertUserSettingsParams{DefaultSize: "huge"}, false},
+		{"relative path", db.UpsertUserSettingsParams{DefaultHealthCheckPath: "healthz"}, false},
+		{"path with query", db.UpsertUserSettingsParams{DefaultHealthCheckPath: "/healthz?deep=1"}, false},
+		{"path too long", db.UpsertUserSettingsParams{DefaultHealthCheckPath: "/" + strings.Repeat("a", MaxHealthCheckPathLength)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.params)
+			if tt.valid && err != nil {
+				t.Errorf("expected valid, got %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalid) {
+				t.Errorf("expected ErrInvalid, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAppDefaults(t *testing.T) {
+	region, size, path := AppDefaults(db.UserSetting{}, "", "", "")
+	if region != DefaultRegion || size != DefaultSize || path != DefaultHealthCheckPath {
+		t.Errorf("expected the platform defaults, got %s, %s, %s", region, size, path)
+	}
+
+	settings := db.UserSetting{DefaultRegion: "qro", DefaultSize: "pro", DefaultHealthCheckPath: "/healthz"}
+	region, size, path = AppDefaults(settings, "", "", "")
+	if region != "qro" || size != "pro" || path != "/healthz" {
+		t.Errorf("expected the user's defaults, got %s, %s, %s", region, size, path)
+	}
+
+	// What the app is created with wins over any default
+	region, size, path = AppDefaults(settings, "mex", "enterprise", "/ready")
+	if region != "mex" || size != "enterprise" || path != "/ready" {
+		t.Errorf("expected the app's own values, got %s, %s, %s", region, size, path)
+	}
+}
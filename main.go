@@ -2,25 +2,85 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/abdul-hamid-achik/nexo-cloud/app/api"
+	"github.com/abdul-hamid-achik/nexo-cloud/app/api/metrics"
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/apitime"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/backup"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/billing"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/bulk"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/burst"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/certmonitor"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/cloudflare"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/concurrency"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/cors"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/crashmonitor"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/credits"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/dbhealth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/demo"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/dnszone"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/domainverify"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/errtrack"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/events"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/imageretention"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/leader"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/metering"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/metricsexport"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/mirrors"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/nameserver"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/notify"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/outbox"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/readonly"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/registry"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/retention"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/rightsizing"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/rollout"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/scheduler"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/store"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/streams"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/suspension"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/timeouts"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/tokenpolicy"
 	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 )
 
 func main() {
+	// Responses carry timestamps in UTC whatever the zone of the host
+	apitime.UseUTC()
+
+	demoMode := flag.Bool("demo", false, "seed a demo user with sample apps and fake the cluster (DEMO_MODE=true)")
+	flag.Parse()
+
 	_ = godotenv.Load()
 
 	cfg := config.Load()
+	if *demoMode {
+		cfg.DemoMode = true
+	}
+	if err := cfg.CheckDemoMode(); err != nil {
+		slog.Error("invalid demo mode", "error", err)
+		os.Exit(1)
+	}
+
+	if err := errtrack.Init(cfg); err != nil {
+		slog.Error("invalid SENTRY_DSN", "error", err)
+		os.Exit(1)
+	}
+	defer errtrack.Flush()
 
 	pool, err := pgxpool.New(context.Background(), cfg.DatabaseURL)
 	if err != nil {
@@ -29,55 +89,141 @@ func main() {
 	}
 	defer pool.Close()
 
-	if err := pool.Ping(context.Background()); err != nil {
-		slog.Warn("database not available, continuing without database", "error", err)
-		pool = nil
-	} else {
+	// Serve without the database until it is reachable; the pool
+	// reconnects by itself once Postgres returns
+	dbMonitor := dbhealth.NewMonitor(pool, 5*time.Second)
+	if dbMonitor.Check(context.Background()) {
 		slog.Info("connected to database")
+
+		// Fail the rollouts a stopped replica left building
+		if err := rollout.Recover(context.Background(), db.New(pool)); err != nil {
+			slog.Error("failed to recover interrupted rollouts", "error", err)
+		}
 	}
 
-	// Initialize Kubernetes client
-	var k8sClient *k8s.Client
-	if cfg.Kubeconfig != "" || os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
-		k8sClient, err = k8s.NewClient(cfg.Kubeconfig, cfg.K8sNamespacePrefix)
+	// Operators put the API in read-only mode through the admin API; every
+	// replica reads the switch from the database
+	readOnly := readonly.NewSwitch(db.New(pool), 5*time.Second)
+	if dbMonitor.Available() {
+		if err := readOnly.Refresh(context.Background()); err != nil {
+			slog.Warn("failed to read read-only mode", "error", err)
+		}
+	}
+
+	// Initialize Kubernetes client. Demo mode never connects to a cluster,
+	// not even the one it runs in.
+	var k8sClient k8s.Interface
+	if !cfg.DemoMode && (cfg.Kubeconfig != "" || os.Getenv("KUBERNETES_SERVICE_HOST") != "") {
+		client, err := k8s.NewClient(cfg.Kubeconfig, cfg.K8sNamespacePrefix)
 		if err != nil {
 			slog.Warn("kubernetes not available", "error", err)
 		} else {
+			k8sClient = client
 			slog.Info("connected to kubernetes")
 		}
 	}
 
+	// Demo mode explores sample apps without a cluster: they run in a fake
+	// one, which every region uses
+	var k8sClients func(kubeconfig string) (k8s.Interface, error)
+	if cfg.DemoMode {
+		if _, err := demo.Seed(context.Background(), db.New(pool), time.Now()); err != nil {
+			slog.Error("failed to seed demo data", "error", err)
+		} else {
+			slog.Info("seeded demo data", "user", demo.Username)
+		}
+		cluster := demo.Cluster(cfg.K8sNamespacePrefix, time.Now())
+		k8sClient = cluster
+		k8sClients = func(string) (k8s.Interface, error) { return cluster, nil }
+		slog.Info("serving demo apps from a fake cluster")
+	}
+
 	// Initialize Cloudflare client
 	var cfClient *cloudflare.Client
 	if cfg.CloudflareAPIToken != "" && cfg.CloudflareZoneID != "" {
 		cfClient = cloudflare.NewClient(cfg.CloudflareAPIToken, cfg.CloudflareZoneID)
+		if cfg.CloudflareAPIURL != "" {
+			cfClient.WithBaseURL(cfg.CloudflareAPIURL)
+		}
+		if cfg.CloudflareAccountID != "" {
+			cfClient.WithAccount(cfg.CloudflareAccountID)
+		}
 		slog.Info("cloudflare client initialized")
 	}
 
+	// Initialize Stripe client
+	var billingClient *billing.Client
+	if cfg.StripeSecretKey != "" {
+		billingClient = billing.NewClient(cfg.StripeSecretKey)
+		if cfg.StripeAPIURL != "" {
+			billingClient.WithBaseURL(cfg.StripeAPIURL)
+		}
+		slog.Info("stripe client initialized")
+	}
+
+	trustedProxies, err := clientip.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		slog.Error("invalid TRUSTED_PROXIES", "error", err)
+		os.Exit(1)
+	}
+	resolver := clientip.New(trustedProxies)
+
+	corsPolicy, err := cors.Parse(cfg.CORSAllowedOrigins)
+	if err != nil {
+		slog.Error("invalid CORS_ALLOWED_ORIGINS", "error", err)
+		os.Exit(1)
+	}
+
+	timeoutPolicy, err := timeouts.Parse(time.Duration(cfg.RequestTimeoutSeconds)*time.Second, cfg.RouteTimeouts)
+	if err != nil {
+		slog.Error("invalid ROUTE_TIMEOUTS", "error", err)
+		os.Exit(1)
+	}
+	maxBody := int64(cfg.MaxRequestBodyBytes)
+
+	limiter := concurrency.NewLimiter(map[concurrency.Operation]int{
+		concurrency.Deploy:    cfg.ConcurrentDeploys,
+		concurrency.LogStream: cfg.ConcurrentLogStreams,
+	}, time.Duration(cfg.ConcurrencyQueueSeconds)*time.Second)
+
+	streamManager := streams.NewManager(cfg.StreamsPerUser,
+		time.Duration(cfg.StreamHeartbeatSeconds)*time.Second,
+		time.Duration(cfg.StreamIdleMinutes)*time.Minute)
+
 	app := fuego.New()
 
 	// Add security middleware stack
-	app.Use(api.RecoveryMiddleware())        // Panic recovery (outermost)
-	app.Use(api.RequestIDMiddleware())       // Request ID tracking
-	app.Use(api.RequestLoggingMiddleware())  // Request logging
-	app.Use(api.SecurityHeadersMiddleware()) // Security headers
-	app.Use(api.RateLimitMiddleware())       // Rate limiting
-	app.Use(api.CORSMiddleware([]string{     // CORS
-		"http://localhost:3000",
-		"http://localhost:5173",
-		"https://cloud.nexo.build",
-	}))
+	app.Use(api.RecoveryMiddleware())             // Panic recovery (outermost)
+	app.Use(api.RequestIDMiddleware())            // Request ID tracking
+	app.Use(api.ErrorTrackingMiddleware())        // Report failed requests
+	app.Use(api.ClientIPMiddleware(resolver))     // Client address behind trusted proxies
+	app.Use(api.RequestLoggingMiddleware())       // Request logging
+	app.Use(api.SecurityHeadersMiddleware())      // Security headers
+	app.Use(api.CompressionMiddleware())          // gzip responses and request bodies
+	app.Use(api.BodyLimitMiddleware(maxBody))     // 413 on bodies over MAX_REQUEST_BODY_BYTES
+	app.Use(api.RateLimitMiddleware())            // Rate limiting
+	app.Use(api.CORSMiddleware(corsPolicy))       // CORS
+	app.Use(api.DatabaseMiddleware(dbMonitor))    // 503 while the database is down
+	app.Use(api.ReadOnlyMiddleware(readOnly))     // 503 on mutations in read-only mode
+	app.Use(api.TimeoutMiddleware(timeoutPolicy)) // Per-route request timeouts
 
 	// Inject dependencies
-	app.Use(func(next fuego.HandlerFunc) fuego.HandlerFunc {
-		return func(c *fuego.Context) error {
-			c.Set("db", pool)
-			c.Set("config", cfg)
-			c.Set("k8s", k8sClient)
-			c.Set("cloudflare", cfClient)
-			return next(c)
-		}
-	})
+	svc := &services.Services{
+		Config:      cfg,
+		DB:          pool,
+		Store:       store.New(db.New(pool)),
+		K8s:         k8sClient,
+		K8sClients:  k8sClients,
+		Cloudflare:  cfClient,
+		Concurrency: limiter,
+		Streams:     streamManager,
+		Billing:     billingClient,
+		ReadOnly:    readOnly,
+	}
+	app.Use(services.Middleware(svc))
+
+	// CSRF protection for cookie-authenticated requests
+	app.Use(api.CSRFMiddleware(cfg.CORSAllowedOrigins))
 
 	RegisterRoutes(app)
 
@@ -86,6 +232,57 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	go dbMonitor.Run(ctx)
+	go readOnly.Run(ctx)
+
+	// Background workers. Replicas elect a leader to run the singleton
+	// workers and share out the rest, so the API can be scaled out. They
+	// start once the database is reachable.
+	go func() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-dbMonitor.Ready():
+		}
+
+		// Deliver platform events to the audit log, alert channels and metrics
+		bus := events.NewBus(pool)
+		bus.Subscribe("audit", events.Audit(db.New(pool)))
+		email := cfg.SMTPAddr != ""
+		if cfg.NotifyWebhookURL != "" || email {
+			bus.Subscribe("notifications", notify.Handler(notify.NewQueue(db.New(pool), cfg.NotifyWebhookURL, email)))
+		}
+		bus.Subscribe("metrics", metrics.RecordEvent)
+		// Failed payments start the suspension pipeline, payments end it
+		pipeline := suspension.New(pool, cfg, svc.Kubernetes, billingClient)
+		bus.Subscribe("suspension", pipeline.Handle, suspension.EventPaymentFailed, suspension.EventPaymentSucceeded)
+		// First payments of referred users credit their referrers
+		bus.Subscribe("referrals", credits.New(pool, billingClient).Handle, suspension.EventPaymentSucceeded)
+
+		singletons, replicated, err := newSchedulers(cfg, pool, svc.Kubernetes, bus, pipeline)
+		if err != nil {
+			slog.Error("invalid job schedule", "error", err)
+			os.Exit(1)
+		}
+		go leader.New(pool, "workers", 10*time.Second).Run(ctx, leader.All(bus.Run, singletons.Run))
+		go replicated.Run(ctx)
+	}()
+
+	// Authoritative DNS for the zones users delegate to the platform. Every
+	// replica serves it, reading the zones from the database.
+	if len(cfg.Nameservers) > 0 {
+		source := func(ctx context.Context) (map[string][]domainverify.Record, error) {
+			return dnszone.Table(ctx, cfg, db.New(pool))
+		}
+		dns := nameserver.New(source, cfg.Nameservers, "hostmaster."+cfg.PlatformDomain)
+		go func() {
+			slog.Info("starting nameserver", "addr", cfg.DNSAddr)
+			if err := dns.Run(ctx, cfg.DNSAddr); err != nil {
+				slog.Error("nameserver error", "error", err)
+			}
+		}()
+	}
+
 	go func() {
 		addr := fmt.Sprintf(":%d", cfg.Port)
 		slog.Info("starting server", "host", cfg.Host, "port", cfg.Port)
@@ -97,3 +294,92 @@ func main() {
 	<-ctx.Done()
 	slog.Info("shutting down")
 }
+
+// newSchedulers registers the periodic background jobs. Singleton jobs run on
+// the elected leader only; replicated jobs run on every replica and split
+// their work through row locks or partitions.
+func newSchedulers(cfg *config.Config, pool *pgxpool.Pool, clients func(kubeconfig string) (k8s.Interface, error), bus *events.Bus, pipeline *suspension.Pipeline) (singletons, replicated *scheduler.Scheduler, err error) {
+	queries := db.New(pool)
+	singletons = scheduler.New(cfg, queries, bus)
+	replicated = scheduler.New(cfg, queries, bus)
+
+	jobs := []scheduler.Job{
+		// Revoke expired API tokens and tokens violating org lifetime policies
+		{Name: "token_sweep", Schedule: "@hourly", Jitter: time.Minute, Pausable: true, Run: tokenpolicy.NewSweeper(queries).Sweep},
+		// Track custom domain certificates and alert on failures and expiry
+		{Name: "certificate_check", Schedule: "@every 15m", Jitter: time.Minute, Pausable: true, Run: certmonitor.New(queries, cfg, clients, bus).Check},
+		// Double web replicas of apps over their burst thresholds and scale them back
+		{Name: "burst_check", Schedule: "@every 1m", Jitter: 5 * time.Second, Run: burst.New(queries, cfg, clients, bus).Check},
+		// Count OOM kills and crash loops of running deployments and alert owners
+		{Name: "crash_check", Schedule: "@every 1m", Jitter: 5 * time.Second, Pausable: true, Run: crashmonitor.New(queries, cfg, clients, bus).Check},
+		// Stop traffic mirrors when their time box runs out
+		{Name: "mirror_expiry", Schedule: "@every 1m", Jitter: 5 * time.Second, Pausable: true, Run: mirrors.New(queries, cfg, clients, bus).Expire},
+		// Sample pod usage for right-sizing and email owners a weekly summary
+		{Name: "usage_sample", Schedule: "@every 5m", Jitter: 30 * time.Second, Pausable: true, Run: rightsizing.NewSampler(queries, cfg, clients).Sample},
+		{Name: "rightsizing_report", Schedule: "0 9 * * 1", Jitter: 5 * time.Minute, Pausable: true, Run: rightsizing.NewReporter(queries, bus).Report},
+		// Push app metrics to the remote-write endpoints and webhooks their owners configured
+		{Name: "metrics_export", Schedule: "@every 1m", Jitter: 5 * time.Second, Pausable: true, Run: metricsexport.New(queries, cfg, clients).Export},
+		// Fail the rollouts of replicas that stopped while rolling out
+		{Name: "rollout_recovery", Schedule: "@every 5m", Jitter: 30 * time.Second, Run: func(ctx context.Context) error { return rollout.Recover(ctx, queries) }},
+		// Warn, suspend and purge accounts with failed payments as their grace period runs out
+		{Name: "suspension_check", Schedule: "@every 15m", Jitter: time.Minute, Pausable: true, Run: pipeline.Check},
+		// Measure the storage each user's images take in the platform registry
+		{Name: "registry_usage", Schedule: "@every 15m", Jitter: time.Minute, Pausable: true, Run: registry.NewUsageCollector(queries, cfg).Collect},
+		// Delete deployment images outside their app's retention policy, ahead
+		// of the registry's nightly garbage collection
+		{Name: "image_retention", Schedule: "0 3 * * *", Jitter: 5 * time.Minute, Pausable: true, Run: imageretention.New(queries, cfg, bus).Prune},
+		// Activate delegated zones once public DNS points them at the platform's nameservers
+		{Name: "zone_delegation", Schedule: "@every 15m", Jitter: time.Minute, Pausable: true, Run: dnszone.NewChecker(queries, cfg, bus).Check},
+	}
+
+	// Write disaster-recovery snapshots to object storage
+	if cfg.BackupURL != "" && cfg.BackupIntervalHours > 0 {
+		store, err := backup.NewStore(cfg)
+		if err != nil {
+			slog.Error("backups disabled", "error", err)
+		} else {
+			jobs = append(jobs, scheduler.Job{Name: "backup", Schedule: fmt.Sprintf("@every %dh", cfg.BackupIntervalHours), Run: backup.New(pool, cfg, clients, store).Take})
+		}
+	}
+
+	// Delete activity logs past the retention of their account's plan,
+	// exporting them to object storage first with ACTIVITY_ARCHIVE. The job
+	// does not run when the export is asked for but cannot be written.
+	var archive backup.Store
+	if cfg.ActivityArchive {
+		var archiveErr error
+		if archive, archiveErr = backup.NewStore(cfg); archiveErr != nil {
+			slog.Error("activity retention disabled, logs cannot be archived", "error", archiveErr)
+		}
+	}
+	if !cfg.ActivityArchive || archive != nil {
+		jobs = append(jobs, scheduler.Job{Name: "activity_retention", Schedule: "@hourly", Jitter: 5 * time.Minute, Pausable: true, Run: retention.New(queries, cfg, archive).Prune})
+	}
+
+	for _, job := range jobs {
+		if err := singletons.Add(job); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Deliver queued webhooks and emails with retries
+	senders := map[string]outbox.Sender{outbox.KindWebhook: notify.WebhookSender{}}
+	if cfg.SMTPAddr != "" {
+		senders[outbox.KindEmail] = notify.NewEmailSender(cfg.SMTPAddr, cfg.SMTPFrom, cfg.SMTPUsername, cfg.SMTPPassword)
+	}
+
+	jobs = []scheduler.Job{
+		{Name: "outbox", Schedule: "@every 5s", Run: outbox.NewWorker(queries, senders).Process},
+		// Carry out queued bulk operations, claimed with row locks
+		{Name: "bulk_operations", Schedule: "@every 5s", Run: bulk.NewWorker(queries, cfg, clients).Process},
+		// Meter per-app bandwidth from the ingress metrics of each region,
+		// with the regions split between replicas
+		{Name: "metering", Schedule: "@every 1m", Jitter: 5 * time.Second, Run: metering.New(queries, cfg, leader.NewPartitions(pool, "metering")).Collect},
+	}
+	for _, job := range jobs {
+		if err := replicated.Add(job); err != nil {
+			return nil, nil, err
+		}
+	}
+	return singletons, replicated, nil
+}
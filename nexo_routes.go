@@ -9,66 +9,339 @@ import (
 
 	app_page "github.com/abdul-hamid-achik/nexo-cloud/app"
 	callback2 "github.com/abdul-hamid-achik/nexo-cloud/app/_auth_/callback"
+	demo2 "github.com/abdul-hamid-achik/nexo-cloud/app/_auth_/demo"
 	login_page "github.com/abdul-hamid-achik/nexo-cloud/app/_auth_/login"
 	logout "github.com/abdul-hamid-achik/nexo-cloud/app/_auth_/logout"
+	databaseaddons "github.com/abdul-hamid-achik/nexo-cloud/app/api/addons/databases"
+	databaseaddon "github.com/abdul-hamid-achik/nexo-cloud/app/api/addons/databases/byaddon"
+	backups "github.com/abdul-hamid-achik/nexo-cloud/app/api/admin/backups"
+	admincors "github.com/abdul-hamid-achik/nexo-cloud/app/api/admin/cors"
+	admincredits "github.com/abdul-hamid-achik/nexo-cloud/app/api/admin/credits/byuser"
+	maintenance "github.com/abdul-hamid-achik/nexo-cloud/app/api/admin/maintenance"
+	maintenancewindow "github.com/abdul-hamid-achik/nexo-cloud/app/api/admin/maintenance/byid"
+	adminoutbox "github.com/abdul-hamid-achik/nexo-cloud/app/api/admin/outbox"
+	outboxrequeue "github.com/abdul-hamid-achik/nexo-cloud/app/api/admin/outbox/byid/requeue"
+	adminreadonly "github.com/abdul-hamid-achik/nexo-cloud/app/api/admin/readonly"
+	adminsuspensions "github.com/abdul-hamid-achik/nexo-cloud/app/api/admin/suspensions"
+	adminsuspension "github.com/abdul-hamid-achik/nexo-cloud/app/api/admin/suspensions/byuser"
 	apps "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps"
 	name "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname"
 	activity "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/activity"
+	burst "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/burst"
+	collaborators "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/collaborators"
+	collaborator "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/collaborators/byuser"
+	crons "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/crons"
+	cron "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/crons/bycron"
+	runs "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/crons/bycron/runs"
+	appdatabases "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/databases"
+	appdatabase "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/databases/byaddon"
+	databasespreview "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/databases/preview"
 	deployments "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/deployments"
 	id "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/deployments/byid"
+	lockfile "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/deployments/byid/lockfile"
+	lockfileverify "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/deployments/byid/lockfile/verify"
+	preview "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/deployments/preview"
+	diagnostics "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/diagnostics"
 	domains "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/domains"
 	domain "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/domains/bydomain"
+	instructions "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/domains/bydomain/instructions"
 	verify "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/domains/bydomain/verify"
+	downloads "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/downloads"
+	egress "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/egress"
 	env "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/env"
+	export "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/export"
+	hooks "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/hooks"
+	labels "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/labels"
 	logs "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/logs"
+	logsdownload "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/logs/download"
+	manifests "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/manifests"
 	metrics "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/metrics"
+	metricsexport "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/metrics/export"
+	mirror "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/mirror"
+	mtls "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/mtls"
+	certs "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/mtls/certs"
+	cert "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/mtls/certs/byid"
+	trusts "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/oidc/trusts"
+	trust "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/oidc/trusts/byid"
+	otel "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/otel"
+	placement "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/placement"
+	pods "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/pods"
+	restart2 "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/pods/bypod/restart"
+	processes "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/processes"
+	recommendations "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/recommendations"
+	resize "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/resize"
 	restart "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/restart"
+	retention "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/retention"
+	retentionreport "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/retention/report"
 	scale "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/scale"
+	bulk "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/bulk"
+	operation "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/bulk/byid"
 	auth "github.com/abdul-hamid-achik/nexo-cloud/app/api/auth"
 	callback "github.com/abdul-hamid-achik/nexo-cloud/app/api/auth/callback"
+	demo "github.com/abdul-hamid-achik/nexo-cloud/app/api/auth/demo"
+	device "github.com/abdul-hamid-achik/nexo-cloud/app/api/auth/device"
+	token3 "github.com/abdul-hamid-achik/nexo-cloud/app/api/auth/device/token"
+	oidc "github.com/abdul-hamid-achik/nexo-cloud/app/api/auth/oidc"
 	token "github.com/abdul-hamid-achik/nexo-cloud/app/api/auth/token"
+	dnscheck "github.com/abdul-hamid-achik/nexo-cloud/app/api/dns/check"
+	estimate "github.com/abdul-hamid-achik/nexo-cloud/app/api/estimate"
 	health "github.com/abdul-hamid-achik/nexo-cloud/app/api/health"
+	logs2 "github.com/abdul-hamid-achik/nexo-cloud/app/api/logs"
 	metrics2 "github.com/abdul-hamid-achik/nexo-cloud/app/api/metrics"
+	mtlsverify "github.com/abdul-hamid-achik/nexo-cloud/app/api/mtls/verify"
+	orgs "github.com/abdul-hamid-achik/nexo-cloud/app/api/orgs"
+	org "github.com/abdul-hamid-achik/nexo-cloud/app/api/orgs/byorg"
+	compliance "github.com/abdul-hamid-achik/nexo-cloud/app/api/orgs/byorg/compliance"
+	complianceverify "github.com/abdul-hamid-achik/nexo-cloud/app/api/orgs/byorg/compliance/verify"
+	freezes "github.com/abdul-hamid-achik/nexo-cloud/app/api/orgs/byorg/freezes"
+	freeze "github.com/abdul-hamid-achik/nexo-cloud/app/api/orgs/byorg/freezes/byfreeze"
+	holds "github.com/abdul-hamid-achik/nexo-cloud/app/api/orgs/byorg/holds"
+	hold "github.com/abdul-hamid-achik/nexo-cloud/app/api/orgs/byorg/holds/byhold"
+	machines "github.com/abdul-hamid-achik/nexo-cloud/app/api/orgs/byorg/machines"
+	machine "github.com/abdul-hamid-achik/nexo-cloud/app/api/orgs/byorg/machines/bymachine"
+	machinetokens "github.com/abdul-hamid-achik/nexo-cloud/app/api/orgs/byorg/machines/bymachine/tokens"
+	machinetoken "github.com/abdul-hamid-achik/nexo-cloud/app/api/orgs/byorg/machines/bymachine/tokens/byid"
+	scim "github.com/abdul-hamid-achik/nexo-cloud/app/api/orgs/byorg/scim"
+	orgtax "github.com/abdul-hamid-achik/nexo-cloud/app/api/orgs/byorg/tax"
+	notices "github.com/abdul-hamid-achik/nexo-cloud/app/api/platform/notices"
+	projects "github.com/abdul-hamid-achik/nexo-cloud/app/api/projects"
+	project "github.com/abdul-hamid-achik/nexo-cloud/app/api/projects/projectname"
+	projectactivity "github.com/abdul-hamid-achik/nexo-cloud/app/api/projects/projectname/activity"
+	projectapps "github.com/abdul-hamid-achik/nexo-cloud/app/api/projects/projectname/apps"
+	projectapp "github.com/abdul-hamid-achik/nexo-cloud/app/api/projects/projectname/apps/byapp"
+	projectenv "github.com/abdul-hamid-achik/nexo-cloud/app/api/projects/projectname/env"
+	projectenvgroup "github.com/abdul-hamid-achik/nexo-cloud/app/api/projects/projectname/env/bygroup"
+	projectmetrics "github.com/abdul-hamid-achik/nexo-cloud/app/api/projects/projectname/metrics"
+	registryauth "github.com/abdul-hamid-achik/nexo-cloud/app/api/registry/auth"
 	token2 "github.com/abdul-hamid-achik/nexo-cloud/app/api/registry/token"
+	routers "github.com/abdul-hamid-achik/nexo-cloud/app/api/routers"
+	router "github.com/abdul-hamid-achik/nexo-cloud/app/api/routers/routername"
+	users "github.com/abdul-hamid-achik/nexo-cloud/app/api/scim/v2/users"
+	id2 "github.com/abdul-hamid-achik/nexo-cloud/app/api/scim/v2/users/byid"
+	search "github.com/abdul-hamid-achik/nexo-cloud/app/api/search"
+	showcase "github.com/abdul-hamid-achik/nexo-cloud/app/api/showcase"
+	showcasebadge "github.com/abdul-hamid-achik/nexo-cloud/app/api/showcase/byid/badge"
+	splits "github.com/abdul-hamid-achik/nexo-cloud/app/api/splits"
+	split "github.com/abdul-hamid-achik/nexo-cloud/app/api/splits/splitname"
+	status "github.com/abdul-hamid-achik/nexo-cloud/app/api/status"
 	me "github.com/abdul-hamid-achik/nexo-cloud/app/api/users/me"
+	checkout "github.com/abdul-hamid-achik/nexo-cloud/app/api/users/me/checkout"
+	collaborations "github.com/abdul-hamid-achik/nexo-cloud/app/api/users/me/collaborations"
+	credits "github.com/abdul-hamid-achik/nexo-cloud/app/api/users/me/credits"
+	devices "github.com/abdul-hamid-achik/nexo-cloud/app/api/users/me/devices"
+	invoices "github.com/abdul-hamid-achik/nexo-cloud/app/api/users/me/invoices"
+	settings "github.com/abdul-hamid-achik/nexo-cloud/app/api/users/me/settings"
+	usertax "github.com/abdul-hamid-achik/nexo-cloud/app/api/users/me/tax"
+	usage "github.com/abdul-hamid-achik/nexo-cloud/app/api/users/me/usage"
+	stripewebhook "github.com/abdul-hamid-achik/nexo-cloud/app/api/webhooks/stripe"
+	zones "github.com/abdul-hamid-achik/nexo-cloud/app/api/zones"
+	zone "github.com/abdul-hamid-achik/nexo-cloud/app/api/zones/byzone"
+	zoneverify "github.com/abdul-hamid-achik/nexo-cloud/app/api/zones/byzone/verify"
+	deploysbadge "github.com/abdul-hamid-achik/nexo-cloud/app/badge/byapp/deploys"
+	statusbadge "github.com/abdul-hamid-achik/nexo-cloud/app/badge/byapp/status"
 	dashboard "github.com/abdul-hamid-achik/nexo-cloud/app/dashboard"
 	apps2 "github.com/abdul-hamid-achik/nexo-cloud/app/dashboard/apps"
 	name2 "github.com/abdul-hamid-achik/nexo-cloud/app/dashboard/apps/appname"
+	add "github.com/abdul-hamid-achik/nexo-cloud/app/dashboard/apps/appname/domains/add"
+	domain2 "github.com/abdul-hamid-achik/nexo-cloud/app/dashboard/apps/appname/domains/bydomain"
+	device2 "github.com/abdul-hamid-achik/nexo-cloud/app/dashboard/device"
 )
 
 // RegisterRoutes registers all file-based routes with the app.
 func RegisterRoutes(app *fuego.App) {
 
+	// GET /api/addons/databases/byaddon (from app/api/addons/databases/byaddon/route.go)
+	app.RegisterRoute("GET", "/api/addons/databases/byaddon", databaseaddon.Get)
+	// PUT /api/addons/databases/byaddon (from app/api/addons/databases/byaddon/route.go)
+	app.RegisterRoute("PUT", "/api/addons/databases/byaddon", databaseaddon.Put)
+	// DELETE /api/addons/databases/byaddon (from app/api/addons/databases/byaddon/route.go)
+	app.RegisterRoute("DELETE", "/api/addons/databases/byaddon", databaseaddon.Delete)
+	// GET /api/addons/databases (from app/api/addons/databases/route.go)
+	app.RegisterRoute("GET", "/api/addons/databases", databaseaddons.Get)
+	// POST /api/addons/databases (from app/api/addons/databases/route.go)
+	app.RegisterRoute("POST", "/api/addons/databases", databaseaddons.Post)
+	// POST /api/admin/backups (from app/api/admin/backups/route.go)
+	app.RegisterRoute("POST", "/api/admin/backups", backups.Post)
+	// GET /api/admin/cors (from app/api/admin/cors/route.go)
+	app.RegisterRoute("GET", "/api/admin/cors", admincors.Get)
+	// GET /api/admin/credits/byuser (from app/api/admin/credits/byuser/route.go)
+	app.RegisterRoute("GET", "/api/admin/credits/byuser", admincredits.Get)
+	// POST /api/admin/credits/byuser (from app/api/admin/credits/byuser/route.go)
+	app.RegisterRoute("POST", "/api/admin/credits/byuser", admincredits.Post)
+	// PUT /api/admin/maintenance/byid (from app/api/admin/maintenance/byid/route.go)
+	app.RegisterRoute("PUT", "/api/admin/maintenance/byid", maintenancewindow.Put)
+	// DELETE /api/admin/maintenance/byid (from app/api/admin/maintenance/byid/route.go)
+	app.RegisterRoute("DELETE", "/api/admin/maintenance/byid", maintenancewindow.Delete)
+	// GET /api/admin/maintenance (from app/api/admin/maintenance/route.go)
+	app.RegisterRoute("GET", "/api/admin/maintenance", maintenance.Get)
+	// POST /api/admin/maintenance (from app/api/admin/maintenance/route.go)
+	app.RegisterRoute("POST", "/api/admin/maintenance", maintenance.Post)
+	// POST /api/admin/outbox/byid/requeue (from app/api/admin/outbox/byid/requeue/route.go)
+	app.RegisterRoute("POST", "/api/admin/outbox/byid/requeue", outboxrequeue.Post)
+	// GET /api/admin/outbox (from app/api/admin/outbox/route.go)
+	app.RegisterRoute("GET", "/api/admin/outbox", adminoutbox.Get)
+	// GET /api/admin/readonly (from app/api/admin/readonly/route.go)
+	app.RegisterRoute("GET", "/api/admin/readonly", adminreadonly.Get)
+	// PUT /api/admin/readonly (from app/api/admin/readonly/route.go)
+	app.RegisterRoute("PUT", "/api/admin/readonly", adminreadonly.Put)
+	// DELETE /api/admin/suspensions/byuser (from app/api/admin/suspensions/byuser/route.go)
+	app.RegisterRoute("DELETE", "/api/admin/suspensions/byuser", adminsuspension.Delete)
+	// GET /api/admin/suspensions (from app/api/admin/suspensions/route.go)
+	app.RegisterRoute("GET", "/api/admin/suspensions", adminsuspensions.Get)
 	// GET /api/apps/appname/activity (from app/api/apps/appname/activity/route.go)
 	app.RegisterRoute("GET", "/api/apps/appname/activity", activity.Get)
+	// GET /api/apps/appname/burst (from app/api/apps/appname/burst/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/burst", burst.Get)
+	// PUT /api/apps/appname/burst (from app/api/apps/appname/burst/route.go)
+	app.RegisterRoute("PUT", "/api/apps/appname/burst", burst.Put)
+	// DELETE /api/apps/appname/burst (from app/api/apps/appname/burst/route.go)
+	app.RegisterRoute("DELETE", "/api/apps/appname/burst", burst.Delete)
+	// PUT /api/apps/appname/collaborators/byuser (from app/api/apps/appname/collaborators/byuser/route.go)
+	app.RegisterRoute("PUT", "/api/apps/appname/collaborators/byuser", collaborator.Put)
+	// DELETE /api/apps/appname/collaborators/byuser (from app/api/apps/appname/collaborators/byuser/route.go)
+	app.RegisterRoute("DELETE", "/api/apps/appname/collaborators/byuser", collaborator.Delete)
+	// GET /api/apps/appname/collaborators (from app/api/apps/appname/collaborators/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/collaborators", collaborators.Get)
+	// POST /api/apps/appname/collaborators (from app/api/apps/appname/collaborators/route.go)
+	app.RegisterRoute("POST", "/api/apps/appname/collaborators", collaborators.Post)
+	// GET /api/apps/appname/crons/bycron (from app/api/apps/appname/crons/bycron/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/crons/bycron", cron.Get)
+	// PUT /api/apps/appname/crons/bycron (from app/api/apps/appname/crons/bycron/route.go)
+	app.RegisterRoute("PUT", "/api/apps/appname/crons/bycron", cron.Put)
+	// DELETE /api/apps/appname/crons/bycron (from app/api/apps/appname/crons/bycron/route.go)
+	app.RegisterRoute("DELETE", "/api/apps/appname/crons/bycron", cron.Delete)
+	// GET /api/apps/appname/crons/bycron/runs (from app/api/apps/appname/crons/bycron/runs/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/crons/bycron/runs", runs.Get)
+	// GET /api/apps/appname/crons (from app/api/apps/appname/crons/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/crons", crons.Get)
+	// POST /api/apps/appname/crons (from app/api/apps/appname/crons/route.go)
+	app.RegisterRoute("POST", "/api/apps/appname/crons", crons.Post)
+	// DELETE /api/apps/appname/databases/byaddon (from app/api/apps/appname/databases/byaddon/route.go)
+	app.RegisterRoute("DELETE", "/api/apps/appname/databases/byaddon", appdatabase.Delete)
+	// GET /api/apps/appname/databases/preview (from app/api/apps/appname/databases/preview/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/databases/preview", databasespreview.Get)
+	// GET /api/apps/appname/databases (from app/api/apps/appname/databases/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/databases", appdatabases.Get)
+	// POST /api/apps/appname/databases (from app/api/apps/appname/databases/route.go)
+	app.RegisterRoute("POST", "/api/apps/appname/databases", appdatabases.Post)
 	// GET /api/apps/appname/deployments/byid (from app/api/apps/appname/deployments/byid/route.go)
 	app.RegisterRoute("GET", "/api/apps/appname/deployments/byid", id.Get)
 	// POST /api/apps/appname/deployments/byid (from app/api/apps/appname/deployments/byid/route.go)
 	app.RegisterRoute("POST", "/api/apps/appname/deployments/byid", id.Post)
+	// GET /api/apps/appname/deployments/byid/lockfile (from app/api/apps/appname/deployments/byid/lockfile/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/deployments/byid/lockfile", lockfile.Get)
+	// POST /api/apps/appname/deployments/byid/lockfile/verify (from app/api/apps/appname/deployments/byid/lockfile/verify/route.go)
+	app.RegisterRoute("POST", "/api/apps/appname/deployments/byid/lockfile/verify", lockfileverify.Post)
+	// GET /api/apps/appname/deployments/preview (from app/api/apps/appname/deployments/preview/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/deployments/preview", preview.Get)
 	// GET /api/apps/appname/deployments (from app/api/apps/appname/deployments/route.go)
 	app.RegisterRoute("GET", "/api/apps/appname/deployments", deployments.Get)
 	// POST /api/apps/appname/deployments (from app/api/apps/appname/deployments/route.go)
 	app.RegisterRoute("POST", "/api/apps/appname/deployments", deployments.Post)
+	// GET /api/apps/appname/diagnostics (from app/api/apps/appname/diagnostics/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/diagnostics", diagnostics.Get)
 	// GET /api/apps/appname/domains/bydomain (from app/api/apps/appname/domains/bydomain/route.go)
 	app.RegisterRoute("GET", "/api/apps/appname/domains/bydomain", domain.Get)
+	// PUT /api/apps/appname/domains/bydomain (from app/api/apps/appname/domains/bydomain/route.go)
+	app.RegisterRoute("PUT", "/api/apps/appname/domains/bydomain", domain.Put)
 	// DELETE /api/apps/appname/domains/bydomain (from app/api/apps/appname/domains/bydomain/route.go)
 	app.RegisterRoute("DELETE", "/api/apps/appname/domains/bydomain", domain.Delete)
+	// GET /api/apps/appname/domains/bydomain/instructions (from app/api/apps/appname/domains/bydomain/instructions/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/domains/bydomain/instructions", instructions.Get)
 	// POST /api/apps/appname/domains/bydomain/verify (from app/api/apps/appname/domains/bydomain/verify/route.go)
 	app.RegisterRoute("POST", "/api/apps/appname/domains/bydomain/verify", verify.Post)
 	// GET /api/apps/appname/domains (from app/api/apps/appname/domains/route.go)
 	app.RegisterRoute("GET", "/api/apps/appname/domains", domains.Get)
 	// POST /api/apps/appname/domains (from app/api/apps/appname/domains/route.go)
 	app.RegisterRoute("POST", "/api/apps/appname/domains", domains.Post)
+	// POST /api/apps/appname/downloads (from app/api/apps/appname/downloads/route.go)
+	app.RegisterRoute("POST", "/api/apps/appname/downloads", downloads.Post)
+	// GET /api/apps/appname/egress (from app/api/apps/appname/egress/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/egress", egress.Get)
+	// PUT /api/apps/appname/egress (from app/api/apps/appname/egress/route.go)
+	app.RegisterRoute("PUT", "/api/apps/appname/egress", egress.Put)
 	// GET /api/apps/appname/env (from app/api/apps/appname/env/route.go)
 	app.RegisterRoute("GET", "/api/apps/appname/env", env.Get)
 	// PUT /api/apps/appname/env (from app/api/apps/appname/env/route.go)
 	app.RegisterRoute("PUT", "/api/apps/appname/env", env.Put)
+	// GET /api/apps/appname/export (from app/api/apps/appname/export/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/export", export.Get)
+	// GET /api/apps/appname/hooks (from app/api/apps/appname/hooks/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/hooks", hooks.Get)
+	// PUT /api/apps/appname/hooks (from app/api/apps/appname/hooks/route.go)
+	app.RegisterRoute("PUT", "/api/apps/appname/hooks", hooks.Put)
+	// GET /api/apps/appname/labels (from app/api/apps/appname/labels/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/labels", labels.Get)
+	// PUT /api/apps/appname/labels (from app/api/apps/appname/labels/route.go)
+	app.RegisterRoute("PUT", "/api/apps/appname/labels", labels.Put)
+	// GET /api/apps/appname/logs/download (from app/api/apps/appname/logs/download/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/logs/download", logsdownload.Get)
 	// GET /api/apps/appname/logs (from app/api/apps/appname/logs/route.go)
 	app.RegisterRoute("GET", "/api/apps/appname/logs", logs.Get)
+	// GET /api/apps/appname/manifests (from app/api/apps/appname/manifests/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/manifests", manifests.Get)
+	// GET /api/apps/appname/metrics/export (from app/api/apps/appname/metrics/export/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/metrics/export", metricsexport.Get)
+	// PUT /api/apps/appname/metrics/export (from app/api/apps/appname/metrics/export/route.go)
+	app.RegisterRoute("PUT", "/api/apps/appname/metrics/export", metricsexport.Put)
+	// DELETE /api/apps/appname/metrics/export (from app/api/apps/appname/metrics/export/route.go)
+	app.RegisterRoute("DELETE", "/api/apps/appname/metrics/export", metricsexport.Delete)
 	// GET /api/apps/appname/metrics (from app/api/apps/appname/metrics/route.go)
 	app.RegisterRoute("GET", "/api/apps/appname/metrics", metrics.Get)
+	// GET /api/apps/appname/mirror (from app/api/apps/appname/mirror/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/mirror", mirror.Get)
+	// PUT /api/apps/appname/mirror (from app/api/apps/appname/mirror/route.go)
+	app.RegisterRoute("PUT", "/api/apps/appname/mirror", mirror.Put)
+	// DELETE /api/apps/appname/mirror (from app/api/apps/appname/mirror/route.go)
+	app.RegisterRoute("DELETE", "/api/apps/appname/mirror", mirror.Delete)
+	// GET /api/apps/appname/mtls/certs/byid (from app/api/apps/appname/mtls/certs/byid/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/mtls/certs/byid", cert.Get)
+	// DELETE /api/apps/appname/mtls/certs/byid (from app/api/apps/appname/mtls/certs/byid/route.go)
+	app.RegisterRoute("DELETE", "/api/apps/appname/mtls/certs/byid", cert.Delete)
+	// GET /api/apps/appname/mtls/certs (from app/api/apps/appname/mtls/certs/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/mtls/certs", certs.Get)
+	// POST /api/apps/appname/mtls/certs (from app/api/apps/appname/mtls/certs/route.go)
+	app.RegisterRoute("POST", "/api/apps/appname/mtls/certs", certs.Post)
+	// GET /api/apps/appname/mtls (from app/api/apps/appname/mtls/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/mtls", mtls.Get)
+	// PUT /api/apps/appname/mtls (from app/api/apps/appname/mtls/route.go)
+	app.RegisterRoute("PUT", "/api/apps/appname/mtls", mtls.Put)
+	// DELETE /api/apps/appname/oidc/trusts/byid (from app/api/apps/appname/oidc/trusts/byid/route.go)
+	app.RegisterRoute("DELETE", "/api/apps/appname/oidc/trusts/byid", trust.Delete)
+	// GET /api/apps/appname/oidc/trusts (from app/api/apps/appname/oidc/trusts/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/oidc/trusts", trusts.Get)
+	// POST /api/apps/appname/oidc/trusts (from app/api/apps/appname/oidc/trusts/route.go)
+	app.RegisterRoute("POST", "/api/apps/appname/oidc/trusts", trusts.Post)
+	// GET /api/apps/appname/otel (from app/api/apps/appname/otel/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/otel", otel.Get)
+	// PUT /api/apps/appname/otel (from app/api/apps/appname/otel/route.go)
+	app.RegisterRoute("PUT", "/api/apps/appname/otel", otel.Put)
+	// DELETE /api/apps/appname/otel (from app/api/apps/appname/otel/route.go)
+	app.RegisterRoute("DELETE", "/api/apps/appname/otel", otel.Delete)
+	// GET /api/apps/appname/placement (from app/api/apps/appname/placement/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/placement", placement.Get)
+	// PUT /api/apps/appname/placement (from app/api/apps/appname/placement/route.go)
+	app.RegisterRoute("PUT", "/api/apps/appname/placement", placement.Put)
+	// POST /api/apps/appname/pods/bypod/restart (from app/api/apps/appname/pods/bypod/restart/route.go)
+	app.RegisterRoute("POST", "/api/apps/appname/pods/bypod/restart", restart2.Post)
+	// GET /api/apps/appname/pods (from app/api/apps/appname/pods/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/pods", pods.Get)
+	// GET /api/apps/appname/processes (from app/api/apps/appname/processes/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/processes", processes.Get)
+	// PUT /api/apps/appname/processes (from app/api/apps/appname/processes/route.go)
+	app.RegisterRoute("PUT", "/api/apps/appname/processes", processes.Put)
+	// GET /api/apps/appname/recommendations (from app/api/apps/appname/recommendations/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/recommendations", recommendations.Get)
+	// POST /api/apps/appname/resize (from app/api/apps/appname/resize/route.go)
+	app.RegisterRoute("POST", "/api/apps/appname/resize", resize.Post)
 	// POST /api/apps/appname/restart (from app/api/apps/appname/restart/route.go)
 	app.RegisterRoute("POST", "/api/apps/appname/restart", restart.Post)
+	// GET /api/apps/appname/retention/report (from app/api/apps/appname/retention/report/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/retention/report", retentionreport.Get)
+	// GET /api/apps/appname/retention (from app/api/apps/appname/retention/route.go)
+	app.RegisterRoute("GET", "/api/apps/appname/retention", retention.Get)
+	// PUT /api/apps/appname/retention (from app/api/apps/appname/retention/route.go)
+	app.RegisterRoute("PUT", "/api/apps/appname/retention", retention.Put)
 	// GET /api/apps/appname (from app/api/apps/appname/route.go)
 	app.RegisterRoute("GET", "/api/apps/appname", name.Get)
 	// PUT /api/apps/appname (from app/api/apps/appname/route.go)
@@ -79,6 +352,12 @@ func RegisterRoutes(app *fuego.App) {
 	app.RegisterRoute("POST", "/api/apps/appname/scale", scale.Post)
 	// GET /api/apps/appname/scale (from app/api/apps/appname/scale/route.go)
 	app.RegisterRoute("GET", "/api/apps/appname/scale", scale.Get)
+	// GET /api/apps/bulk/byid (from app/api/apps/bulk/byid/route.go)
+	app.RegisterRoute("GET", "/api/apps/bulk/byid", operation.Get)
+	// GET /api/apps/bulk (from app/api/apps/bulk/route.go)
+	app.RegisterRoute("GET", "/api/apps/bulk", bulk.Get)
+	// POST /api/apps/bulk (from app/api/apps/bulk/route.go)
+	app.RegisterRoute("POST", "/api/apps/bulk", bulk.Post)
 	// GET /api/apps (from app/api/apps/route.go)
 	app.RegisterRoute("GET", "/api/apps", apps.Get)
 	// POST /api/apps (from app/api/apps/route.go)
@@ -87,34 +366,210 @@ func RegisterRoutes(app *fuego.App) {
 	app.RegisterRoute("GET", "/api/auth/callback", callback.Get)
 	// GET /api/auth (from app/api/auth/route.go)
 	app.RegisterRoute("GET", "/api/auth", auth.Get)
+	// POST /api/auth/demo (from app/api/auth/demo/route.go)
+	app.RegisterRoute("POST", "/api/auth/demo", demo.Post)
+	// POST /api/auth/device (from app/api/auth/device/route.go)
+	app.RegisterRoute("POST", "/api/auth/device", device.Post)
+	// POST /api/auth/device/token (from app/api/auth/device/token/route.go)
+	app.RegisterRoute("POST", "/api/auth/device/token", token3.Post)
+	// POST /api/auth/oidc (from app/api/auth/oidc/route.go)
+	app.RegisterRoute("POST", "/api/auth/oidc", oidc.Post)
 	// POST /api/auth/token (from app/api/auth/token/route.go)
 	app.RegisterRoute("POST", "/api/auth/token", token.Post)
 	// GET /api/auth/token (from app/api/auth/token/route.go)
 	app.RegisterRoute("GET", "/api/auth/token", token.Get)
+	// GET /api/dns/check (from app/api/dns/check/route.go)
+	app.RegisterRoute("GET", "/api/dns/check", dnscheck.Get)
+	// POST /api/estimate (from app/api/estimate/route.go)
+	app.RegisterRoute("POST", "/api/estimate", estimate.Post)
 	// GET /api/health (from app/api/health/route.go)
 	app.RegisterRoute("GET", "/api/health", health.Get)
+	// GET /api/logs (from app/api/logs/route.go)
+	app.RegisterRoute("GET", "/api/logs", logs2.Get)
 	// GET /api/metrics (from app/api/metrics/route.go)
 	app.RegisterRoute("GET", "/api/metrics", metrics2.Get)
+	// GET /api/mtls/verify (from app/api/mtls/verify/route.go)
+	app.RegisterRoute("GET", "/api/mtls/verify", mtlsverify.Get)
+	// GET /api/orgs/byorg/compliance (from app/api/orgs/byorg/compliance/route.go)
+	app.RegisterRoute("GET", "/api/orgs/byorg/compliance", compliance.Get)
+	// POST /api/orgs/byorg/compliance/verify (from app/api/orgs/byorg/compliance/verify/route.go)
+	app.RegisterRoute("POST", "/api/orgs/byorg/compliance/verify", complianceverify.Post)
+	// DELETE /api/orgs/byorg/freezes/byfreeze (from app/api/orgs/byorg/freezes/byfreeze/route.go)
+	app.RegisterRoute("DELETE", "/api/orgs/byorg/freezes/byfreeze", freeze.Delete)
+	// GET /api/orgs/byorg/freezes (from app/api/orgs/byorg/freezes/route.go)
+	app.RegisterRoute("GET", "/api/orgs/byorg/freezes", freezes.Get)
+	// POST /api/orgs/byorg/freezes (from app/api/orgs/byorg/freezes/route.go)
+	app.RegisterRoute("POST", "/api/orgs/byorg/freezes", freezes.Post)
+	// DELETE /api/orgs/byorg/holds/byhold (from app/api/orgs/byorg/holds/byhold/route.go)
+	app.RegisterRoute("DELETE", "/api/orgs/byorg/holds/byhold", hold.Delete)
+	// GET /api/orgs/byorg/holds (from app/api/orgs/byorg/holds/route.go)
+	app.RegisterRoute("GET", "/api/orgs/byorg/holds", holds.Get)
+	// POST /api/orgs/byorg/holds (from app/api/orgs/byorg/holds/route.go)
+	app.RegisterRoute("POST", "/api/orgs/byorg/holds", holds.Post)
+	// GET /api/orgs/byorg/machines/bymachine (from app/api/orgs/byorg/machines/bymachine/route.go)
+	app.RegisterRoute("GET", "/api/orgs/byorg/machines/bymachine", machine.Get)
+	// PUT /api/orgs/byorg/machines/bymachine (from app/api/orgs/byorg/machines/bymachine/route.go)
+	app.RegisterRoute("PUT", "/api/orgs/byorg/machines/bymachine", machine.Put)
+	// DELETE /api/orgs/byorg/machines/bymachine (from app/api/orgs/byorg/machines/bymachine/route.go)
+	app.RegisterRoute("DELETE", "/api/orgs/byorg/machines/bymachine", machine.Delete)
+	// DELETE /api/orgs/byorg/machines/bymachine/tokens/byid (from app/api/orgs/byorg/machines/bymachine/tokens/byid/route.go)
+	app.RegisterRoute("DELETE", "/api/orgs/byorg/machines/bymachine/tokens/byid", machinetoken.Delete)
+	// GET /api/orgs/byorg/machines/bymachine/tokens (from app/api/orgs/byorg/machines/bymachine/tokens/route.go)
+	app.RegisterRoute("GET", "/api/orgs/byorg/machines/bymachine/tokens", machinetokens.Get)
+	// POST /api/orgs/byorg/machines/bymachine/tokens (from app/api/orgs/byorg/machines/bymachine/tokens/route.go)
+	app.RegisterRoute("POST", "/api/orgs/byorg/machines/bymachine/tokens", machinetokens.Post)
+	// GET /api/orgs/byorg/machines (from app/api/orgs/byorg/machines/route.go)
+	app.RegisterRoute("GET", "/api/orgs/byorg/machines", machines.Get)
+	// POST /api/orgs/byorg/machines (from app/api/orgs/byorg/machines/route.go)
+	app.RegisterRoute("POST", "/api/orgs/byorg/machines", machines.Post)
+	// GET /api/orgs/byorg (from app/api/orgs/byorg/route.go)
+	app.RegisterRoute("GET", "/api/orgs/byorg", org.Get)
+	// PUT /api/orgs/byorg (from app/api/orgs/byorg/route.go)
+	app.RegisterRoute("PUT", "/api/orgs/byorg", org.Put)
+	// POST /api/orgs/byorg/scim (from app/api/orgs/byorg/scim/route.go)
+	app.RegisterRoute("POST", "/api/orgs/byorg/scim", scim.Post)
+	// DELETE /api/orgs/byorg/scim (from app/api/orgs/byorg/scim/route.go)
+	app.RegisterRoute("DELETE", "/api/orgs/byorg/scim", scim.Delete)
+	// GET /api/orgs/byorg/tax (from app/api/orgs/byorg/tax/route.go)
+	app.RegisterRoute("GET", "/api/orgs/byorg/tax", orgtax.Get)
+	// PUT /api/orgs/byorg/tax (from app/api/orgs/byorg/tax/route.go)
+	app.RegisterRoute("PUT", "/api/orgs/byorg/tax", orgtax.Put)
+	// GET /api/orgs (from app/api/orgs/route.go)
+	app.RegisterRoute("GET", "/api/orgs", orgs.Get)
+	// POST /api/orgs (from app/api/orgs/route.go)
+	app.RegisterRoute("POST", "/api/orgs", orgs.Post)
+	// GET /api/platform/notices (from app/api/platform/notices/route.go)
+	app.RegisterRoute("GET", "/api/platform/notices", notices.Get)
+	// GET /api/projects/projectname/activity (from app/api/projects/projectname/activity/route.go)
+	app.RegisterRoute("GET", "/api/projects/projectname/activity", projectactivity.Get)
+	// DELETE /api/projects/projectname/apps/byapp (from app/api/projects/projectname/apps/byapp/route.go)
+	app.RegisterRoute("DELETE", "/api/projects/projectname/apps/byapp", projectapp.Delete)
+	// POST /api/projects/projectname/apps (from app/api/projects/projectname/apps/route.go)
+	app.RegisterRoute("POST", "/api/projects/projectname/apps", projectapps.Post)
+	// PUT /api/projects/projectname/env/bygroup (from app/api/projects/projectname/env/bygroup/route.go)
+	app.RegisterRoute("PUT", "/api/projects/projectname/env/bygroup", projectenvgroup.Put)
+	// DELETE /api/projects/projectname/env/bygroup (from app/api/projects/projectname/env/bygroup/route.go)
+	app.RegisterRoute("DELETE", "/api/projects/projectname/env/bygroup", projectenvgroup.Delete)
+	// GET /api/projects/projectname/env (from app/api/projects/projectname/env/route.go)
+	app.RegisterRoute("GET", "/api/projects/projectname/env", projectenv.Get)
+	// GET /api/projects/projectname/metrics (from app/api/projects/projectname/metrics/route.go)
+	app.RegisterRoute("GET", "/api/projects/projectname/metrics", projectmetrics.Get)
+	// GET /api/projects/projectname (from app/api/projects/projectname/route.go)
+	app.RegisterRoute("GET", "/api/projects/projectname", project.Get)
+	// PUT /api/projects/projectname (from app/api/projects/projectname/route.go)
+	app.RegisterRoute("PUT", "/api/projects/projectname", project.Put)
+	// DELETE /api/projects/projectname (from app/api/projects/projectname/route.go)
+	app.RegisterRoute("DELETE", "/api/projects/projectname", project.Delete)
+	// GET /api/projects (from app/api/projects/route.go)
+	app.RegisterRoute("GET", "/api/projects", projects.Get)
+	// POST /api/projects (from app/api/projects/route.go)
+	app.RegisterRoute("POST", "/api/projects", projects.Post)
+	// GET /api/registry/auth (from app/api/registry/auth/route.go)
+	app.RegisterRoute("GET", "/api/registry/auth", registryauth.Get)
 	// GET /api/registry/token (from app/api/registry/token/route.go)
 	app.RegisterRoute("GET", "/api/registry/token", token2.Get)
 	// POST /api/registry/token (from app/api/registry/token/route.go)
 	app.RegisterRoute("POST", "/api/registry/token", token2.Post)
 	// DELETE /api/registry/token (from app/api/registry/token/route.go)
 	app.RegisterRoute("DELETE", "/api/registry/token", token2.Delete)
+	// GET /api/routers (from app/api/routers/route.go)
+	app.RegisterRoute("GET", "/api/routers", routers.Get)
+	// POST /api/routers (from app/api/routers/route.go)
+	app.RegisterRoute("POST", "/api/routers", routers.Post)
+	// GET /api/routers/routername (from app/api/routers/routername/route.go)
+	app.RegisterRoute("GET", "/api/routers/routername", router.Get)
+	// PUT /api/routers/routername (from app/api/routers/routername/route.go)
+	app.RegisterRoute("PUT", "/api/routers/routername", router.Put)
+	// DELETE /api/routers/routername (from app/api/routers/routername/route.go)
+	app.RegisterRoute("DELETE", "/api/routers/routername", router.Delete)
+	// GET /api/scim/v2/users/byid (from app/api/scim/v2/users/byid/route.go)
+	app.RegisterRoute("GET", "/api/scim/v2/users/byid", id2.Get)
+	// PUT /api/scim/v2/users/byid (from app/api/scim/v2/users/byid/route.go)
+	app.RegisterRoute("PUT", "/api/scim/v2/users/byid", id2.Put)
+	// PATCH /api/scim/v2/users/byid (from app/api/scim/v2/users/byid/route.go)
+	app.RegisterRoute("PATCH", "/api/scim/v2/users/byid", id2.Patch)
+	// DELETE /api/scim/v2/users/byid (from app/api/scim/v2/users/byid/route.go)
+	app.RegisterRoute("DELETE", "/api/scim/v2/users/byid", id2.Delete)
+	// GET /api/scim/v2/users (from app/api/scim/v2/users/route.go)
+	app.RegisterRoute("GET", "/api/scim/v2/users", users.Get)
+	// POST /api/scim/v2/users (from app/api/scim/v2/users/route.go)
+	app.RegisterRoute("POST", "/api/scim/v2/users", users.Post)
+	// GET /api/search (from app/api/search/route.go)
+	app.RegisterRoute("GET", "/api/search", search.Get)
+	// GET /api/showcase/byid/badge (from app/api/showcase/byid/badge/route.go)
+	app.RegisterRoute("GET", "/api/showcase/byid/badge", showcasebadge.Get)
+	// GET /api/showcase (from app/api/showcase/route.go)
+	app.RegisterRoute("GET", "/api/showcase", showcase.Get)
+	// GET /api/splits (from app/api/splits/route.go)
+	app.RegisterRoute("GET", "/api/splits", splits.Get)
+	// POST /api/splits (from app/api/splits/route.go)
+	app.RegisterRoute("POST", "/api/splits", splits.Post)
+	// GET /api/splits/splitname (from app/api/splits/splitname/route.go)
+	app.RegisterRoute("GET", "/api/splits/splitname", split.Get)
+	// PUT /api/splits/splitname (from app/api/splits/splitname/route.go)
+	app.RegisterRoute("PUT", "/api/splits/splitname", split.Put)
+	// DELETE /api/splits/splitname (from app/api/splits/splitname/route.go)
+	app.RegisterRoute("DELETE", "/api/splits/splitname", split.Delete)
+	// GET /api/status (from app/api/status/route.go)
+	app.RegisterRoute("GET", "/api/status", status.Get)
 	// GET /api/users/me (from app/api/users/me/route.go)
 	app.RegisterRoute("GET", "/api/users/me", me.Get)
 	// PUT /api/users/me (from app/api/users/me/route.go)
 	app.RegisterRoute("PUT", "/api/users/me", me.Put)
 	// GET /callback (from app/_auth_/callback/route.go)
 	app.RegisterRoute("GET", "/callback", callback2.Get)
+	// GET /demo (from app/_auth_/demo/route.go)
+	app.RegisterRoute("GET", "/demo", demo2.Get)
 	// POST /logout (from app/_auth_/logout/route.go)
 	app.RegisterRoute("POST", "/logout", logout.Post)
 	// GET /logout (from app/_auth_/logout/route.go)
 	app.RegisterRoute("GET", "/logout", logout.Get)
+	// POST /api/users/me/checkout (from app/api/users/me/checkout/route.go)
+	app.RegisterRoute("POST", "/api/users/me/checkout", checkout.Post)
+	// GET /api/users/me/collaborations (from app/api/users/me/collaborations/route.go)
+	app.RegisterRoute("GET", "/api/users/me/collaborations", collaborations.Get)
+	// GET /api/users/me/credits (from app/api/users/me/credits/route.go)
+	app.RegisterRoute("GET", "/api/users/me/credits", credits.Get)
+	// POST /api/users/me/devices (from app/api/users/me/devices/route.go)
+	app.RegisterRoute("POST", "/api/users/me/devices", devices.Post)
+	// GET /api/users/me/invoices (from app/api/users/me/invoices/route.go)
+	app.RegisterRoute("GET", "/api/users/me/invoices", invoices.Get)
+	// GET /api/users/me/settings (from app/api/users/me/settings/route.go)
+	app.RegisterRoute("GET", "/api/users/me/settings", settings.Get)
+	// PUT /api/users/me/settings (from app/api/users/me/settings/route.go)
+	app.RegisterRoute("PUT", "/api/users/me/settings", settings.Put)
+	// GET /api/users/me/tax (from app/api/users/me/tax/route.go)
+	app.RegisterRoute("GET", "/api/users/me/tax", usertax.Get)
+	// PUT /api/users/me/tax (from app/api/users/me/tax/route.go)
+	app.RegisterRoute("PUT", "/api/users/me/tax", usertax.Put)
+	// GET /api/users/me/usage (from app/api/users/me/usage/route.go)
+	app.RegisterRoute("GET", "/api/users/me/usage", usage.Get)
+	// POST /api/webhooks/stripe (from app/api/webhooks/stripe/route.go)
+	app.RegisterRoute("POST", "/api/webhooks/stripe", stripewebhook.Post)
+	// GET /api/zones (from app/api/zones/route.go)
+	app.RegisterRoute("GET", "/api/zones", zones.Get)
+	// POST /api/zones (from app/api/zones/route.go)
+	app.RegisterRoute("POST", "/api/zones", zones.Post)
+	// GET /api/zones/byzone (from app/api/zones/byzone/route.go)
+	app.RegisterRoute("GET", "/api/zones/byzone", zone.Get)
+	// DELETE /api/zones/byzone (from app/api/zones/byzone/route.go)
+	app.RegisterRoute("DELETE", "/api/zones/byzone", zone.Delete)
+	// POST /api/zones/byzone/verify (from app/api/zones/byzone/verify/route.go)
+	app.RegisterRoute("POST", "/api/zones/byzone/verify", zoneverify.Post)
+	// GET /badge/byapp/deploys.svg (from app/badge/byapp/deploys/route.go)
+	app.RegisterRoute("GET", "/badge/byapp/deploys.svg", deploysbadge.Get)
+	// GET /badge/byapp/status.svg (from app/badge/byapp/status/route.go)
+	app.RegisterRoute("GET", "/badge/byapp/status.svg", statusbadge.Get)
 	// GET /dashboard/apps/appname (from app/dashboard/apps/appname/route.go)
+	// GET /dashboard/apps/appname/domains/add (from app/dashboard/apps/appname/domains/add/route.go)
+	app.RegisterRoute("GET", "/dashboard/apps/appname/domains/add", add.Get)
+	// GET /dashboard/apps/appname/domains/bydomain (from app/dashboard/apps/appname/domains/bydomain/route.go)
+	app.RegisterRoute("GET", "/dashboard/apps/appname/domains/bydomain", domain2.Get)
 	app.RegisterRoute("GET", "/dashboard/apps/appname", name2.Get)
 	// GET /dashboard/apps (from app/dashboard/apps/route.go)
 	app.RegisterRoute("GET", "/dashboard/apps", apps2.Get)
+	// GET /dashboard/device (from app/dashboard/device/route.go)
+	app.RegisterRoute("GET", "/dashboard/device", device2.Get)
 	// GET /dashboard (from app/dashboard/route.go)
 	app.RegisterRoute("GET", "/dashboard", dashboard.Get)
 	// Page: /login (from app/_auth_/login/page.templ)
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appstatus"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
 	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
 	"github.com/google/uuid"
@@ -407,8 +408,9 @@ func TestDatabaseAppOperations(t *testing.T) {
 
 		// Update status
 		updated, err := testQueries.UpdateAppStatus(ctx, db.UpdateAppStatusParams{
-			ID:     app.ID,
-			Status: "running",
+			ID:           app.ID,
+			Status:       db.AppStatusRunning,
+			FromStatuses: appstatus.Sources(db.AppStatusRunning),
 		})
 		if err != nil {
 			t.Fatalf("UpdateAppStatus failed: %v", err)
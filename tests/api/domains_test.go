@@ -176,6 +176,37 @@ func TestDomainOperations(t *testing.T) {
 		}
 	})
 
+	t.Run("update domain tls policy", func(t *testing.T) {
+		domainName := "tls-" + uuid.New().String()[:8] + ".example.com"
+
+		domain, err := testQueries.CreateDomain(ctx, db.CreateDomainParams{
+			AppID:  app.ID,
+			Domain: domainName,
+		})
+		if err != nil {
+			t.Fatalf("CreateDomain failed: %v", err)
+		}
+		defer func() { _ = testQueries.DeleteDomain(ctx, domain.ID) }()
+
+		if !domain.ForceHttps || domain.HstsMaxAge != 0 {
+			t.Errorf("expected HTTPS forced without HSTS by default, got %+v", domain)
+		}
+
+		updated, err := testQueries.UpdateDomainTLSPolicy(ctx, db.UpdateDomainTLSPolicyParams{
+			ID:                    domain.ID,
+			ForceHttps:            true,
+			HstsMaxAge:            31536000,
+			HstsIncludeSubdomains: true,
+			HstsPreload:           true,
+		})
+		if err != nil {
+			t.Fatalf("UpdateDomainTLSPolicy failed: %v", err)
+		}
+		if updated.HstsMaxAge != 31536000 || !updated.HstsIncludeSubdomains || !updated.HstsPreload {
+			t.Errorf("expected the policy to be stored, got %+v", updated)
+		}
+	})
+
 	t.Run("delete domain", func(t *testing.T) {
 		domainName := "delete-" + uuid.New().String()[:8] + ".example.com"
 
@@ -1,14 +1,24 @@
 package api_test
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/abdul-hamid-achik/nexo-cloud/app/api"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/cors"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/timeouts"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
 )
 
 // TestRateLimiter tests the RateLimiter type directly
@@ -151,14 +161,43 @@ func TestRateLimiterMultipleIPs(t *testing.T) {
 	}
 }
 
-// TestMiddlewareHelpers tests helper functions from middleware
-func TestGetClientIP_XForwardedFor(_ *testing.T) {
-	req := httptest.NewRequest(http.MethodGet, "/api/test", nil)
-	req.Header.Set("X-Forwarded-For", "203.0.113.50, 70.41.3.18, 150.172.238.178")
+// TestClientIPMiddleware verifies X-Forwarded-For is only believed from
+// trusted proxies
+func TestClientIPMiddleware(t *testing.T) {
+	trusted, err := clientip.ParseTrustedProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	middleware := api.ClientIPMiddleware(clientip.New(trusted))
 
-	// The first IP in X-Forwarded-For chain should be extracted
-	// We can't directly test getClientIP since it's not exported,
-	// but we can verify the middleware behavior
+	tests := []struct {
+		name       string
+		remoteAddr string
+		want       string
+	}{
+		{"from trusted proxy", "10.0.0.5:443", "70.41.3.18"},
+		{"from untrusted client", "203.0.113.50:5000", "203.0.113.50"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/test", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-For", "198.51.100.1, 70.41.3.18")
+
+			var got netip.Addr
+			handler := middleware(func(c *fuego.Context) error {
+				got, _ = c.Get(clientip.ContextKey).(netip.Addr)
+				return nil
+			})
+			if err := handler(fuego.NewContext(httptest.NewRecorder(), req)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
 }
 
 // TestSecurityHeaders verifies security headers are set correctly
@@ -183,61 +222,174 @@ func TestSecurityHeaders(t *testing.T) {
 // TestCORSAllowedOrigins tests CORS origin validation
 func TestCORSAllowedOrigins(t *testing.T) {
 	tests := []struct {
-		name           string
-		allowedOrigins []string
-		requestOrigin  string
-		shouldAllow    bool
+		name            string
+		allowedOrigins  []string
+		requestOrigin   string
+		wantAllowOrigin string
+		wantCredentials bool
 	}{
 		{
-			name:           "exact match",
-			allowedOrigins: []string{"https://example.com"},
-			requestOrigin:  "https://example.com",
-			shouldAllow:    true,
+			name:            "exact match",
+			allowedOrigins:  []string{"https://example.com"},
+			requestOrigin:   "https://example.com",
+			wantAllowOrigin: "https://example.com",
+			wantCredentials: true,
+		},
+		{
+			name:            "wildcard allows all without credentials",
+			allowedOrigins:  []string{"*"},
+			requestOrigin:   "https://any-origin.com",
+			wantAllowOrigin: "*",
 		},
 		{
-			name:           "wildcard allows all",
-			allowedOrigins: []string{"*"},
-			requestOrigin:  "https://any-origin.com",
-			shouldAllow:    true,
+			name:            "wildcard subdomain",
+			allowedOrigins:  []string{"https://*.example.com"},
+			requestOrigin:   "https://app.example.com",
+			wantAllowOrigin: "https://app.example.com",
+			wantCredentials: true,
+		},
+		{
+			name:           "wildcard subdomain excludes parent",
+			allowedOrigins: []string{"https://*.example.com"},
+			requestOrigin:  "https://example.com",
 		},
 		{
 			name:           "no match",
 			allowedOrigins: []string{"https://example.com"},
 			requestOrigin:  "https://other.com",
-			shouldAllow:    false,
 		},
 		{
 			name:           "empty origin",
 			allowedOrigins: []string{"https://example.com"},
 			requestOrigin:  "",
-			shouldAllow:    false,
 		},
 		{
-			name:           "multiple allowed origins",
-			allowedOrigins: []string{"https://a.com", "https://b.com", "https://c.com"},
-			requestOrigin:  "https://b.com",
-			shouldAllow:    true,
+			name:           "no allowed origins",
+			allowedOrigins: nil,
+			requestOrigin:  "https://example.com",
+		},
+		{
+			name:            "multiple allowed origins",
+			allowedOrigins:  []string{"https://a.com", "https://b.com", "https://c.com"},
+			requestOrigin:   "https://b.com",
+			wantAllowOrigin: "https://b.com",
+			wantCredentials: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Build allowed origins map
-			allowed := make(map[string]bool)
-			for _, origin := range tt.allowedOrigins {
-				allowed[origin] = true
+			policy, err := cors.Parse(tt.allowedOrigins)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
 			}
 
-			// Check if origin would be allowed
-			isAllowed := tt.requestOrigin != "" && (len(tt.allowedOrigins) == 0 || allowed[tt.requestOrigin] || allowed["*"])
+			req := httptest.NewRequest(http.MethodGet, "/api/test", nil)
+			if tt.requestOrigin != "" {
+				req.Header.Set("Origin", tt.requestOrigin)
+			}
+			rec := httptest.NewRecorder()
+			handler := api.CORSMiddleware(policy)(func(c *fuego.Context) error { return nil })
+			if err := handler(fuego.NewContext(rec, req)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
 
-			if isAllowed != tt.shouldAllow {
-				t.Errorf("expected shouldAllow=%v, got %v", tt.shouldAllow, isAllowed)
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantAllowOrigin {
+				t.Errorf("expected Access-Control-Allow-Origin %q, got %q", tt.wantAllowOrigin, got)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Credentials") == "true"; got != tt.wantCredentials {
+				t.Errorf("expected credentials %v, got %v", tt.wantCredentials, got)
+			}
+			if rec.Header().Get("Vary") != "Origin" {
+				t.Error("expected responses to vary by Origin")
 			}
 		})
 	}
 }
 
+// TestCompressionMiddleware tests gzip responses and request bodies
+func TestCompressionMiddleware(t *testing.T) {
+	payload := strings.Repeat(`{"message":"log line"},`, 100)
+	handler := api.CompressionMiddleware()(func(c *fuego.Context) error {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(200, map[string]string{"echo": string(body)})
+	})
+
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	_, _ = zw.Write([]byte(payload))
+	_ = zw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/test", &compressed)
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	if err := handler(fuego.NewContext(rec, req)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected a gzip response, got headers %v", rec.Header())
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("invalid gzip response: %v", err)
+	}
+	var response map[string]string
+	if err := json.NewDecoder(zr).Decode(&response); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if response["echo"] != payload {
+		t.Error("expected the handler to read the decompressed request body")
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/test", strings.NewReader(payload))
+	req.Header.Set("Content-Encoding", "zstd")
+	rec = httptest.NewRecorder()
+	if err := handler(fuego.NewContext(rec, req)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("expected 415 for an unsupported encoding, got %d", rec.Code)
+	}
+}
+
+// TestTimeoutMiddleware tests that a timed out request is answered with a
+// 504 only once its handler stopped using the context
+func TestTimeoutMiddleware(t *testing.T) {
+	policy, err := timeouts.Parse(20*time.Millisecond, nil)
+	if err != nil {
+		t.Fatalf("failed to parse timeouts: %v", err)
+	}
+
+	var finished atomic.Bool
+	handler := api.TimeoutMiddleware(policy)(func(c *fuego.Context) error {
+		<-c.Request.Context().Done()
+		// Cleanup after cancellation still uses the context
+		time.Sleep(20 * time.Millisecond)
+		c.Set("cleaned_up", true)
+		finished.Store(true)
+		return c.JSON(200, map[string]string{"status": "too late"})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/test", nil)
+	rec := httptest.NewRecorder()
+	if err := handler(fuego.NewContext(rec, req)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !finished.Load() {
+		t.Error("expected the timeout to wait for the handler to return")
+	}
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("expected 504, got %d", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "too late") {
+		t.Errorf("expected the late response to be dropped, got %s", rec.Body.String())
+	}
+}
+
 // TestRequestIDGeneration tests request ID middleware behavior
 func TestRequestIDGeneration(t *testing.T) {
 	t.Run("generates new ID when missing", func(t *testing.T) {
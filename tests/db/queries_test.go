@@ -2,13 +2,18 @@ package db_test
 
 import (
 	"context"
+	"errors"
 	"net/netip"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/appstatus"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/legalhold"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -185,6 +190,34 @@ func TestGetUserByUsername(t *testing.T) {
 	}
 }
 
+func TestGetUserByRegistryNamespace(t *testing.T) {
+	if testQueries == nil {
+		t.Skip("Database not available")
+	}
+
+	ctx := context.Background()
+	avatarURL := "https://example.com/avatar.png"
+	user, err := testQueries.CreateUser(ctx, db.CreateUserParams{
+		GithubID:  int64(time.Now().UnixNano() % 1000000000),
+		Username:  "TestUser-" + uuid.New().String()[:8],
+		Email:     "test-" + uuid.New().String()[:8] + "@example.com",
+		AvatarUrl: &avatarURL,
+	})
+	if err != nil {
+		t.Fatalf("failed to create test user: %v", err)
+	}
+	defer deleteTestUser(ctx, t, user.ID)
+
+	got, err := testQueries.GetUserByRegistryNamespace(ctx, strings.ToLower(user.Username))
+	if err != nil {
+		t.Fatalf("GetUserByRegistryNamespace failed: %v", err)
+	}
+
+	if got.ID != user.ID {
+		t.Errorf("expected ID %s, got %s", user.ID, got.ID)
+	}
+}
+
 func TestUpdateUser(t *testing.T) {
 	if testQueries == nil {
 		t.Skip("Database not available")
@@ -263,6 +296,254 @@ func TestDeleteUser(t *testing.T) {
 // App Tests
 // ============================================================================
 
+func TestOrganizationUsers(t *testing.T) {
+	if testQueries == nil {
+		t.Skip("Database not available")
+	}
+
+	ctx := context.Background()
+	owner := createTestUser(ctx, t)
+	defer deleteTestUser(ctx, t, owner.ID)
+	member := createTestUser(ctx, t)
+	defer deleteTestUser(ctx, t, member.ID)
+
+	org, err := testQueries.CreateOrganization(ctx, db.CreateOrganizationParams{
+		Name:    "org-" + uuid.New().String()[:8],
+		OwnerID: owner.ID,
+	})
+	if err != nil {
+		t.Fatalf("CreateOrganization failed: %v", err)
+	}
+	defer func() { _ = testQueries.DeleteOrganization(ctx, org.ID) }()
+
+	for _, u := range []db.User{owner, member} {
+		if _, err := testQueries.CreateOrganizationMember(ctx, db.CreateOrganizationMemberParams{
+			OrgID:    org.ID,
+			UserID:   pgtype.UUID{Bytes: u.ID, Valid: true},
+			UserName: u.Username,
+			Active:   true,
+		}); err != nil {
+			t.Fatalf("CreateOrganizationMember failed: %v", err)
+		}
+	}
+	if err := testQueries.UpsertUserTwoFactor(ctx, db.UpsertUserTwoFactorParams{UserID: owner.ID, Enabled: true}); err != nil {
+		t.Fatalf("UpsertUserTwoFactor failed: %v", err)
+	}
+
+	users, err := testQueries.ListOrganizationUsers(ctx, org.ID)
+	if err != nil {
+		t.Fatalf("ListOrganizationUsers failed: %v", err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("expected the owner listed once and the member, got %+v", users)
+	}
+	for _, u := range users {
+		switch u.ID {
+		case owner.ID:
+			if u.Role != "owner" || u.TwoFactor == nil || !*u.TwoFactor {
+				t.Errorf("expected the owner with two-factor enabled, got %+v", u)
+			}
+		case member.ID:
+			if u.Role != "member" || u.TwoFactor != nil {
+				t.Errorf("expected a member of unknown two-factor status, got %+v", u)
+			}
+		}
+	}
+}
+
+func TestLegalHold(t *testing.T) {
+	if testQueries == nil {
+		t.Skip("Database not available")
+	}
+
+	ctx := context.Background()
+	owner := createTestUser(ctx, t)
+	defer deleteTestUser(ctx, t, owner.ID)
+	app := createTestApp(ctx, t, owner.ID)
+	defer deleteTestApp(ctx, t, app.ID)
+
+	org, err := testQueries.CreateOrganization(ctx, db.CreateOrganizationParams{
+		Name:    "org-" + uuid.New().String()[:8],
+		OwnerID: owner.ID,
+	})
+	if err != nil {
+		t.Fatalf("CreateOrganization failed: %v", err)
+	}
+	defer func() { _ = testQueries.DeleteOrganization(ctx, org.ID) }()
+
+	log, err := testQueries.CreateActivityLog(ctx, db.CreateActivityLogParams{
+		UserID: pgtype.UUID{Bytes: owner.ID, Valid: true},
+		AppID:  pgtype.UUID{Bytes: app.ID, Valid: true},
+		Action: "app.restarted",
+	})
+	if err != nil {
+		t.Fatalf("CreateActivityLog failed: %v", err)
+	}
+
+	hold, err := testQueries.CreateLegalHold(ctx, db.CreateLegalHoldParams{
+		OrgID:    org.ID,
+		Reason:   "matter 2026-114",
+		PlacedBy: pgtype.UUID{Bytes: owner.ID, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("CreateLegalHold failed: %v", err)
+	}
+	release := func() {
+		_, _ = testQueries.ReleaseLegalHold(ctx, db.ReleaseLegalHoldParams{ID: hold.ID})
+	}
+	defer release()
+
+	holds, err := testQueries.ListActiveLegalHoldsForUser(ctx, owner.ID)
+	if err != nil {
+		t.Fatalf("ListActiveLegalHoldsForUser failed: %v", err)
+	}
+	if len(holds) != 1 || holds[0].OrgName != org.Name {
+		t.Fatalf("expected the owner covered by the hold, got %+v", holds)
+	}
+
+	if err := testQueries.DeleteApp(ctx, app.ID); !legalhold.IsHeld(err) {
+		t.Errorf("expected deleting a held app to fail, got %v", err)
+	}
+	if _, err := testQueries.DeleteActivityLogs(ctx, []uuid.UUID{log.ID}); !legalhold.IsHeld(err) {
+		t.Errorf("expected deleting held activity to fail, got %v", err)
+	}
+	if err := testQueries.DeleteOrganization(ctx, org.ID); !legalhold.IsHeld(err) {
+		t.Errorf("expected deleting a holding organization to fail, got %v", err)
+	}
+
+	future := time.Now().Add(time.Hour)
+	expired, err := testQueries.ListExpiredActivityLogs(ctx, db.ListExpiredActivityLogsParams{
+		NewestCutoff:  future,
+		Plans:         []string{},
+		Cutoffs:       []time.Time{},
+		DefaultCutoff: future,
+		BatchSize:     1000,
+	})
+	if err != nil {
+		t.Fatalf("ListExpiredActivityLogs failed: %v", err)
+	}
+	for _, l := range expired {
+		if l.ID == log.ID {
+			t.Error("expected held activity not to expire")
+		}
+	}
+
+	release()
+	if holds, _ := testQueries.ListActiveLegalHoldsForUser(ctx, owner.ID); len(holds) != 0 {
+		t.Errorf("expected no active holds after the release, got %+v", holds)
+	}
+	if _, err := testQueries.DeleteActivityLogs(ctx, []uuid.UUID{log.ID}); err != nil {
+		t.Errorf("expected released activity to be deletable, got %v", err)
+	}
+}
+
+func TestDNSZone(t *testing.T) {
+	if testQueries == nil {
+		t.Skip("Database not available")
+	}
+
+	ctx := context.Background()
+	user := createTestUser(ctx, t)
+	defer deleteTestUser(ctx, t, user.ID)
+	app := createTestApp(ctx, t, user.ID)
+	defer deleteTestApp(ctx, t, app.ID)
+
+	name := "apps-" + uuid.New().String()[:8] + ".example.com"
+	zone, err := testQueries.CreateDNSZone(ctx, db.CreateDNSZoneParams{
+		UserID: user.ID,
+		Zone:   name,
+	})
+	if err != nil {
+		t.Fatalf("CreateDNSZone failed: %v", err)
+	}
+	defer func() { _ = testQueries.DeleteDNSZone(ctx, zone.ID) }()
+
+	if overlapping, _ := testQueries.ListOverlappingDNSZones(ctx, "eu."+name); len(overlapping) != 1 {
+		t.Errorf("expected a nested zone to overlap, got %+v", overlapping)
+	}
+	if overlapping, _ := testQueries.ListOverlappingDNSZones(ctx, "my"+name); len(overlapping) != 0 {
+		t.Errorf("expected a sibling zone not to overlap, got %+v", overlapping)
+	}
+
+	domain, err := testQueries.CreateDomain(ctx, db.CreateDomainParams{
+		AppID:  app.ID,
+		Domain: "web." + name,
+	})
+	if err != nil {
+		t.Fatalf("CreateDomain failed: %v", err)
+	}
+	defer func() { _ = testQueries.DeleteDomain(ctx, domain.ID) }()
+
+	if _, err := testQueries.GetActiveDNSZoneForDomain(ctx, domain.Domain); err == nil {
+		t.Error("expected a pending zone not to cover its domains")
+	}
+
+	zone, err = testQueries.ActivateDNSZone(ctx, zone.ID)
+	if err != nil {
+		t.Fatalf("ActivateDNSZone failed: %v", err)
+	}
+	verified, err := testQueries.VerifyDomainsInDNSZone(ctx, db.VerifyDomainsInDNSZoneParams{
+		UserID: user.ID,
+		Zone:   zone.Zone,
+	})
+	if err != nil || verified != 1 {
+		t.Fatalf("expected the zone's domain to be verified, got %d, %v", verified, err)
+	}
+
+	if found, err := testQueries.GetActiveDNSZoneForDomain(ctx, domain.Domain); err != nil || found.ID != zone.ID {
+		t.Errorf("expected the domain to be in the active zone, got %+v, %v", found, err)
+	}
+
+	rows, err := testQueries.ListDNSZoneRecords(ctx)
+	if err != nil {
+		t.Fatalf("ListDNSZoneRecords failed: %v", err)
+	}
+	served := false
+	for _, row := range rows {
+		if row.Domain == domain.Domain && row.Zone == zone.Zone && row.AppName == app.Name {
+			served = true
+		}
+	}
+	if !served {
+		t.Error("expected the verified domain to be served")
+	}
+}
+
+func TestAppTunnel(t *testing.T) {
+	if testQueries == nil {
+		t.Skip("Database not available")
+	}
+
+	ctx := context.Background()
+	user := createTestUser(ctx, t)
+	defer deleteTestUser(ctx, t, user.ID)
+	app := createTestApp(ctx, t, user.ID)
+	defer deleteTestApp(ctx, t, app.ID)
+
+	created, err := testQueries.CreateAppTunnel(ctx, db.CreateAppTunnelParams{
+		AppID:          app.ID,
+		TunnelID:       "c1744f8b-faa1-48a4-9e5c-02ac921467fa",
+		TokenEncrypted: []byte("encrypted"),
+	})
+	if err != nil {
+		t.Fatalf("CreateAppTunnel failed: %v", err)
+	}
+	if created.DnsRecordID != "" {
+		t.Errorf("expected no DNS record yet, got %q", created.DnsRecordID)
+	}
+
+	if err := testQueries.SetAppTunnelDNSRecord(ctx, db.SetAppTunnelDNSRecordParams{
+		AppID:       app.ID,
+		DnsRecordID: "372e67954025e0ba6aaa6d586b9e0b59",
+	}); err != nil {
+		t.Fatalf("SetAppTunnelDNSRecord failed: %v", err)
+	}
+	stored, err := testQueries.GetAppTunnel(ctx, app.ID)
+	if err != nil || stored.DnsRecordID != "372e67954025e0ba6aaa6d586b9e0b59" {
+		t.Errorf("GetAppTunnel = %+v, %v", stored, err)
+	}
+}
+
 func TestCreateApp(t *testing.T) {
 	if testQueries == nil {
 		t.Skip("Database not available")
@@ -358,8 +639,9 @@ func TestUpdateAppStatus(t *testing.T) {
 	deploymentID := uuid.New()
 	updated, err := testQueries.UpdateAppStatus(ctx, db.UpdateAppStatusParams{
 		ID:                  app.ID,
-		Status:              "running",
+		Status:              db.AppStatusRunning,
 		CurrentDeploymentID: pgtype.UUID{Bytes: deploymentID, Valid: true},
+		FromStatuses:        appstatus.Sources(db.AppStatusRunning),
 	})
 	if err != nil {
 		t.Fatalf("UpdateAppStatus failed: %v", err)
@@ -368,6 +650,17 @@ func TestUpdateAppStatus(t *testing.T) {
 	if updated.Status != "running" {
 		t.Errorf("expected status 'running', got %q", updated.Status)
 	}
+
+	// A running app cannot be purged without being suspended first
+	_, err = testQueries.UpdateAppStatus(ctx, db.UpdateAppStatusParams{
+		ID:                  app.ID,
+		Status:              db.AppStatusPurged,
+		CurrentDeploymentID: updated.CurrentDeploymentID,
+		FromStatuses:        appstatus.Sources(db.AppStatusPurged),
+	})
+	if !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("expected the invalid transition to update no row, got %v", err)
+	}
 }
 
 func TestIncrementDeploymentCount(t *testing.T) {
@@ -424,6 +717,188 @@ func TestCountAppsByUser(t *testing.T) {
 	}
 }
 
+func TestShowcaseApps(t *testing.T) {
+	if testQueries == nil {
+		t.Skip("Database not available")
+	}
+
+	ctx := context.Background()
+	user := createTestUser(ctx, t)
+	defer deleteTestUser(ctx, t, user.ID)
+
+	app := createTestApp(ctx, t, user.ID)
+	defer deleteTestApp(ctx, t, app.ID)
+
+	if _, err := testQueries.GetShowcaseApp(ctx, app.ID); err == nil {
+		t.Fatal("expected an app not opted in to be left out of the showcase")
+	}
+
+	updated, err := testQueries.UpdateAppShowcase(ctx, db.UpdateAppShowcaseParams{ID: app.ID, Showcase: true})
+	if err != nil {
+		t.Fatalf("UpdateAppShowcase failed: %v", err)
+	}
+	if !updated.Showcase {
+		t.Error("expected the app to be in the showcase")
+	}
+
+	if _, err := testQueries.GetShowcaseApp(ctx, app.ID); err != nil {
+		t.Fatalf("GetShowcaseApp failed: %v", err)
+	}
+	total, err := testQueries.CountShowcaseApps(ctx)
+	if err != nil {
+		t.Fatalf("CountShowcaseApps failed: %v", err)
+	}
+	apps, err := testQueries.ListShowcaseApps(ctx, db.ListShowcaseAppsParams{Limit: int32(total), Offset: 0})
+	if err != nil {
+		t.Fatalf("ListShowcaseApps failed: %v", err)
+	}
+	found := false
+	for _, a := range apps {
+		found = found || a.ID == app.ID
+	}
+	if !found {
+		t.Error("expected the app to be listed in the showcase")
+	}
+}
+
+func TestAppEgressIPs(t *testing.T) {
+	if testQueries == nil {
+		t.Skip("Database not available")
+	}
+
+	ctx := context.Background()
+	user := createTestUser(ctx, t)
+	defer deleteTestUser(ctx, t, user.ID)
+
+	app := createTestApp(ctx, t, user.ID)
+	defer deleteTestApp(ctx, t, app.ID)
+	other := createTestApp(ctx, t, user.ID)
+	defer deleteTestApp(ctx, t, other.ID)
+
+	ip := netip.AddrFrom4([4]byte{198, 51, 100, byte(time.Now().UnixNano()%250 + 1)}).String()
+	allocated, err := testQueries.AllocateAppEgressIP(ctx, db.AllocateAppEgressIPParams{AppID: app.ID, Region: app.Region, Ip: ip})
+	if err != nil {
+		t.Fatalf("AllocateAppEgressIP failed: %v", err)
+	}
+	if allocated.Ip != ip {
+		t.Errorf("expected %s, got %s", ip, allocated.Ip)
+	}
+
+	// An IP goes to one app only, and an app holds one IP
+	_, err = testQueries.AllocateAppEgressIP(ctx, db.AllocateAppEgressIPParams{AppID: other.ID, Region: app.Region, Ip: ip})
+	if !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("expected a taken IP not to be allocated again, got %v", err)
+	}
+	_, err = testQueries.AllocateAppEgressIP(ctx, db.AllocateAppEgressIPParams{AppID: app.ID, Region: app.Region, Ip: "2001:db8::1"})
+	if !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("expected an app to keep a single IP, got %v", err)
+	}
+
+	ips, err := testQueries.ListAllocatedEgressIPs(ctx, app.Region)
+	if err != nil {
+		t.Fatalf("ListAllocatedEgressIPs failed: %v", err)
+	}
+	found := false
+	for _, allocatedIP := range ips {
+		found = found || allocatedIP == ip
+	}
+	if !found {
+		t.Error("expected the IP to be listed as allocated")
+	}
+
+	if err := testQueries.ReleaseAppEgressIP(ctx, app.ID); err != nil {
+		t.Fatalf("ReleaseAppEgressIP failed: %v", err)
+	}
+	if _, err := testQueries.GetAppEgressIP(ctx, app.ID); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("expected the IP to be released, got %v", err)
+	}
+}
+
+func TestAppImageRetention(t *testing.T) {
+	if testQueries == nil {
+		t.Skip("Database not available")
+	}
+
+	ctx := context.Background()
+	user := createTestUser(ctx, t)
+	defer deleteTestUser(ctx, t, user.ID)
+
+	app := createTestApp(ctx, t, user.ID)
+	defer deleteTestApp(ctx, t, app.ID)
+
+	if _, err := testQueries.UpsertAppImageRetention(ctx, db.UpsertAppImageRetentionParams{AppID: app.ID, KeepLast: 5}); err != nil {
+		t.Fatalf("UpsertAppImageRetention failed: %v", err)
+	}
+	policy, err := testQueries.UpsertAppImageRetention(ctx, db.UpsertAppImageRetentionParams{AppID: app.ID, KeepLast: 3})
+	if err != nil {
+		t.Fatalf("UpsertAppImageRetention failed: %v", err)
+	}
+	if policy.KeepLast != 3 || policy.LastPrunedAt.Valid {
+		t.Errorf("expected an unpruned policy keeping 3, got %+v", policy)
+	}
+
+	if err := testQueries.MarkAppImagesPruned(ctx, app.ID); err != nil {
+		t.Fatalf("MarkAppImagesPruned failed: %v", err)
+	}
+	policy, err = testQueries.GetAppImageRetention(ctx, app.ID)
+	if err != nil {
+		t.Fatalf("GetAppImageRetention failed: %v", err)
+	}
+	if !policy.LastPrunedAt.Valid {
+		t.Error("expected the pruning time to be recorded")
+	}
+
+	if _, err := testQueries.UpsertAppImageRetention(ctx, db.UpsertAppImageRetentionParams{AppID: app.ID, KeepLast: 0}); err == nil {
+		t.Error("expected keeping no images to be rejected")
+	}
+
+	if err := testQueries.DeleteAppImageRetention(ctx, app.ID); err != nil {
+		t.Fatalf("DeleteAppImageRetention failed: %v", err)
+	}
+	if _, err := testQueries.GetAppImageRetention(ctx, app.ID); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("expected the policy to be deleted, got %v", err)
+	}
+}
+
+func TestRegistryUsage(t *testing.T) {
+	if testQueries == nil {
+		t.Skip("Database not available")
+	}
+
+	ctx := context.Background()
+	user := createTestUser(ctx, t)
+	defer deleteTestUser(ctx, t, user.ID)
+
+	start := time.Now()
+	if err := testQueries.UpsertRegistryUsage(ctx, db.UpsertRegistryUsageParams{UserID: user.ID, Bytes: 1 << 20, Repositories: 1}); err != nil {
+		t.Fatalf("UpsertRegistryUsage failed: %v", err)
+	}
+	if err := testQueries.UpsertRegistryUsage(ctx, db.UpsertRegistryUsageParams{UserID: user.ID, Bytes: 2 << 20, Repositories: 2}); err != nil {
+		t.Fatalf("UpsertRegistryUsage failed: %v", err)
+	}
+	usage, err := testQueries.GetRegistryUsage(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetRegistryUsage failed: %v", err)
+	}
+	if usage.Bytes != 2<<20 || usage.Repositories != 2 {
+		t.Errorf("expected the latest usage, got %+v", usage)
+	}
+
+	// Usage not refreshed by a later collection is dropped
+	if err := testQueries.DeleteStaleRegistryUsage(ctx, start.Add(-time.Minute)); err != nil {
+		t.Fatalf("DeleteStaleRegistryUsage failed: %v", err)
+	}
+	if _, err := testQueries.GetRegistryUsage(ctx, user.ID); err != nil {
+		t.Errorf("expected fresh usage to be kept, got %v", err)
+	}
+	if err := testQueries.DeleteStaleRegistryUsage(ctx, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("DeleteStaleRegistryUsage failed: %v", err)
+	}
+	if _, err := testQueries.GetRegistryUsage(ctx, user.ID); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("expected stale usage to be deleted, got %v", err)
+	}
+}
+
 // ============================================================================
 // Deployment Tests
 // ============================================================================
@@ -538,6 +1013,51 @@ func TestGetLatestDeployment(t *testing.T) {
 // Domain Tests
 // ============================================================================
 
+func TestDeploymentLockfile(t *testing.T) {
+	if testQueries == nil {
+		t.Skip("Database not available")
+	}
+
+	ctx := context.Background()
+	user := createTestUser(ctx, t)
+	defer deleteTestUser(ctx, t, user.ID)
+
+	app := createTestApp(ctx, t, user.ID)
+	defer deleteTestApp(ctx, t, app.ID)
+
+	deployment, err := testQueries.CreateDeployment(ctx, db.CreateDeploymentParams{
+		AppID:   app.ID,
+		Version: 1,
+		Image:   "nginx:alpine",
+		Status:  "pending",
+	})
+	if err != nil {
+		t.Fatalf("CreateDeployment failed: %v", err)
+	}
+	defer func() { _ = testQueries.DeleteDeployment(ctx, deployment.ID) }()
+
+	if _, err := testQueries.CreateDeploymentLockfile(ctx, db.CreateDeploymentLockfileParams{
+		DeploymentID: deployment.ID,
+		Lockfile:     []byte(`{"image": "nginx:alpine"}`),
+	}); err != nil {
+		t.Fatalf("CreateDeploymentLockfile failed: %v", err)
+	}
+	if _, err := testQueries.CreateDeploymentLockfile(ctx, db.CreateDeploymentLockfileParams{
+		DeploymentID: deployment.ID,
+		Lockfile:     []byte(`{}`),
+	}); err == nil {
+		t.Error("expected a second lockfile for the deployment to be rejected")
+	}
+
+	record, err := testQueries.GetDeploymentLockfile(ctx, deployment.ID)
+	if err != nil {
+		t.Fatalf("GetDeploymentLockfile failed: %v", err)
+	}
+	if !strings.Contains(string(record.Lockfile), "nginx:alpine") {
+		t.Errorf("unexpected lockfile %s", record.Lockfile)
+	}
+}
+
 func TestCreateDomain(t *testing.T) {
 	if testQueries == nil {
 		t.Skip("Database not available")
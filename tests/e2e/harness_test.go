@@ -0,0 +1,375 @@
+// Package e2e_test runs black-box API scenarios against the real middleware
+// stack, a throwaway Postgres with the full schema and a fake cluster.
+//
+// The database is E2E_DATABASE_URL, in a schema created for the run and
+// dropped after it, or with E2E=1 a postgres container started with docker.
+// Without either the scenarios are skipped.
+package e2e_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/app/api"
+	"github.com/abdul-hamid-achik/nexo-cloud/app/api/apps"
+	name "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname"
+	"github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/deployments"
+	deployment "github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/deployments/byid"
+	"github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/hooks"
+	"github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/logs"
+	"github.com/abdul-hamid-achik/nexo-cloud/app/api/users/me"
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/auth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/clientip"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/concurrency"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/config"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/cors"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/dbhealth"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/store"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/streams"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/timeouts"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const postgresImage = "postgres:16-alpine"
+
+var testPool *pgxpool.Pool
+
+func TestMain(m *testing.M) {
+	pool, cleanup, err := startPostgres(context.Background())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "e2e:", err)
+		os.Exit(1)
+	}
+	if pool == nil {
+		// Skip the scenarios without a database
+		os.Exit(0)
+	}
+	testPool = pool
+
+	code := m.Run()
+	pool.Close()
+	cleanup()
+	os.Exit(code)
+}
+
+// startPostgres returns a pool on an empty database with the schema applied
+// and a cleanup dropping it, or a nil pool when no database is configured
+func startPostgres(ctx context.Context) (*pgxpool.Pool, func(), error) {
+	if url := os.Getenv("E2E_DATABASE_URL"); url != "" {
+		return throwawaySchema(ctx, url)
+	}
+	if os.Getenv("E2E") != "1" {
+		return nil, nil, nil
+	}
+	return postgresContainer(ctx)
+}
+
+// throwawaySchema creates a schema of its own on an existing database, so a
+// run neither sees nor leaves data in the database's tables
+func throwawaySchema(ctx context.Context, url string) (*pgxpool.Pool, func(), error) {
+	admin, err := pgx.Connect(ctx, url)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connect to E2E_DATABASE_URL: %w", err)
+	}
+	defer admin.Close(ctx)
+
+	schema := "e2e_" + randomHex(6)
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		return nil, nil, fmt.Errorf("create schema: %w", err)
+	}
+	drop := func() {
+		conn, err := pgx.Connect(context.Background(), url)
+		if err != nil {
+			return
+		}
+		defer conn.Close(context.Background())
+		_, _ = conn.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+	}
+
+	poolConfig, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		drop()
+		return nil, nil, err
+	}
+	poolConfig.ConnConfig.RuntimeParams["search_path"] = schema
+
+	pool, err := openWithSchema(ctx, poolConfig)
+	if err != nil {
+		drop()
+		return nil, nil, err
+	}
+	return pool, drop, nil
+}
+
+// postgresContainer starts a postgres container on a free local port,
+// removed by the cleanup
+func postgresContainer(ctx context.Context) (*pgxpool.Pool, func(), error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return nil, nil, fmt.Errorf("E2E=1 needs docker or E2E_DATABASE_URL: %w", err)
+	}
+
+	out, err := exec.CommandContext(ctx, "docker", "run", "-d", "--rm",
+		"-e", "POSTGRES_USER=e2e",
+		"-e", "POSTGRES_PASSWORD=e2e",
+		"-e", "POSTGRES_DB=e2e",
+		"-p", "127.0.0.1::5432",
+		postgresImage).Output()
+	if err != nil {
+		return nil, nil, fmt.Errorf("start %s: %w", postgresImage, err)
+	}
+	id := strings.TrimSpace(string(out))
+	remove := func() {
+		_ = exec.Command("docker", "rm", "-f", id).Run()
+	}
+
+	out, err = exec.CommandContext(ctx, "docker", "port", id, "5432/tcp").Output()
+	if err != nil {
+		remove()
+		return nil, nil, fmt.Errorf("find the postgres port: %w", err)
+	}
+	// docker port may list an IPv6 binding after the IPv4 one
+	addr := strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+
+	poolConfig, err := pgxpool.ParseConfig("postgres://e2e:e2e@" + addr + "/e2e?sslmode=disable")
+	if err != nil {
+		remove()
+		return nil, nil, err
+	}
+
+	pool, err := openWithSchema(ctx, poolConfig)
+	if err != nil {
+		remove()
+		return nil, nil, err
+	}
+	return pool, remove, nil
+}
+
+// openWithSchema waits for the database to accept connections and applies
+// db/schema.sql, the migrations in one file
+func openWithSchema(ctx context.Context, poolConfig *pgxpool.Config) (*pgxpool.Pool, error) {
+	schema, err := os.ReadFile("../../db/schema.sql")
+	if err != nil {
+		return nil, fmt.Errorf("read schema: %w", err)
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		err = pool.Ping(ctx)
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			pool.Close()
+			return nil, fmt.Errorf("database not ready: %w", err)
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+
+	if _, err := pool.Exec(ctx, string(schema)); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("apply schema: %w", err)
+	}
+	return pool, nil
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// loopbackProxy trusts the test client as a proxy, so scenarios can act as
+// distinct clients with X-Forwarded-For
+var loopbackProxy = clientip.New([]netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")})
+
+// harness is the API served over HTTP the way main serves it, with a fake
+// cluster in place of Kubernetes
+type harness struct {
+	t       *testing.T
+	server  *httptest.Server
+	config  *config.Config
+	store   *store.Store
+	cluster *k8s.Fake
+}
+
+func newHarness(t *testing.T) *harness {
+	t.Helper()
+
+	cfg := &config.Config{
+		JWTSecret:        "e2e-secret-key-for-testing-purposes-only",
+		AppsDomainSuffix: "apps.e2e.local",
+	}
+	st := store.New(db.New(testPool))
+	cluster := k8s.NewFake()
+
+	corsPolicy, err := cors.Parse(nil)
+	if err != nil {
+		t.Fatalf("failed to parse CORS policy: %v", err)
+	}
+	timeoutPolicy, err := timeouts.Parse(30*time.Second, nil)
+	if err != nil {
+		t.Fatalf("failed to parse timeouts: %v", err)
+	}
+	dbMonitor := dbhealth.NewMonitor(testPool, 5*time.Second)
+	dbMonitor.Check(context.Background())
+
+	// The middleware stack of main, in its order
+	app := fuego.New()
+	app.Use(api.RecoveryMiddleware())
+	app.Use(api.RequestIDMiddleware())
+	app.Use(api.ErrorTrackingMiddleware())
+	app.Use(api.ClientIPMiddleware(loopbackProxy))
+	app.Use(api.RequestLoggingMiddleware())
+	app.Use(api.SecurityHeadersMiddleware())
+	app.Use(api.CompressionMiddleware())
+	app.Use(api.RateLimitMiddleware())
+	app.Use(api.CORSMiddleware(corsPolicy))
+	app.Use(api.DatabaseMiddleware(dbMonitor))
+	app.Use(api.TimeoutMiddleware(timeoutPolicy))
+	app.Use(services.Middleware(&services.Services{
+		Config: cfg,
+		DB:     testPool,
+		Store:  st,
+		K8s:    cluster,
+		K8sClients: func(string) (k8s.Interface, error) {
+			return cluster, nil
+		},
+		Concurrency: concurrency.NewLimiter(map[concurrency.Operation]int{
+			concurrency.Deploy:    2,
+			concurrency.LogStream: 2,
+		}, time.Second),
+		Streams: streams.NewManager(2, time.Second, time.Minute),
+	}))
+	app.Use(api.CSRFMiddleware(nil))
+
+	// The routes the scenarios use. nexo_routes.go registers them in package
+	// main, which tests cannot import.
+	app.Get("/api/users/me", me.Get)
+	app.Get("/api/apps", apps.Get)
+	app.Post("/api/apps", apps.Post)
+	app.Get("/api/apps/{name}", name.Get)
+	app.Put("/api/apps/{name}", name.Put)
+	app.Delete("/api/apps/{name}", name.Delete)
+	app.Get("/api/apps/{name}/deployments", deployments.Get)
+	app.Post("/api/apps/{name}/deployments", deployments.Post)
+	app.Get("/api/apps/{name}/deployments/{id}", deployment.Get)
+	app.Put("/api/apps/{name}/hooks", hooks.Put)
+	app.Get("/api/apps/{name}/logs", logs.Get)
+	app.Mount()
+
+	server := httptest.NewServer(app)
+	t.Cleanup(server.Close)
+
+	return &harness{t: t, server: server, config: cfg, store: st, cluster: cluster}
+}
+
+// signup creates a user the way the GitHub callback does and returns their
+// access token
+func (h *harness) signup(username string) string {
+	h.t.Helper()
+
+	user, err := h.store.Users.Create(context.Background(), db.CreateUserParams{
+		GithubID: time.Now().UnixNano(),
+		Username: username,
+		Email:    username + "@example.com",
+	})
+	if err != nil {
+		h.t.Fatalf("failed to create user: %v", err)
+	}
+	tokens, err := auth.GenerateTokenPair(user.ID, user.Username, h.config.JWTSecret)
+	if err != nil {
+		h.t.Fatalf("failed to generate token: %v", err)
+	}
+	return tokens.AccessToken
+}
+
+// do sends a request with a bearer token and decodes a JSON response into
+// out when given, returning the status code
+func (h *harness) do(method, path, token string, body, out any) int {
+	h.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			h.t.Fatalf("failed to encode body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, h.server.URL+path, reader)
+	if err != nil {
+		h.t.Fatalf("failed to build request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := h.server.Client().Do(req)
+	if err != nil {
+		h.t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		h.t.Fatalf("failed to read response: %v", err)
+	}
+	if out != nil && len(data) > 0 && resp.StatusCode < 300 {
+		if err := json.Unmarshal(data, out); err != nil {
+			h.t.Fatalf("%s %s: failed to decode %s: %v", method, path, data, err)
+		}
+	}
+	if resp.StatusCode >= 300 {
+		h.t.Logf("%s %s = %d %s", method, path, resp.StatusCode, data)
+	}
+	return resp.StatusCode
+}
+
+// rolledOut waits for a deployment of an app to finish rolling out to the
+// fake cluster and returns it
+func (h *harness) rolledOut(appName, id, token string) deployment.DeploymentResponse {
+	h.t.Helper()
+
+	path := "/api/apps/" + appName + "/deployments/" + id
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		var d deployment.DeploymentResponse
+		if code := h.do(http.MethodGet, path, token, nil, &d); code != http.StatusOK {
+			h.t.Fatalf("GET %s = %d, want 200", path, code)
+		}
+		if d.Status == "running" || d.Status == "failed" {
+			return d
+		}
+		if time.Now().After(deadline) {
+			h.t.Fatalf("deployment %s still %s", id, d.Status)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,270 @@
+//go:build load
+
+package e2e_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+)
+
+// The load suite runs with the load build tag (task test:load) on the same
+// database as the scenarios
+const (
+	// loadClients are the concurrent clients, each a user with an app of its
+	// own behind its own address
+	loadClients = 20
+	// loadRate is the requests per second of each client, under the rate
+	// limit so any throttling is a failure
+	loadRate     = 10
+	loadDuration = 10 * time.Second
+
+	// rateLimit and rateBurst are the per-client limits of
+	// api.RateLimitMiddleware
+	rateLimit = 100
+	rateBurst = 200
+
+	// maxAcquireWait is the mean wait for a pooled connection
+	maxAcquireWait = 20 * time.Millisecond
+)
+
+// latencyBudgets are the p95 latencies of each operation under load
+var latencyBudgets = map[string]time.Duration{
+	"list":   200 * time.Millisecond,
+	"logs":   200 * time.Millisecond,
+	"deploy": 500 * time.Millisecond,
+}
+
+// loadMix picks the operation of a client's nth request: mostly listing,
+// some log reads and the odd deploy
+func loadMix(n int) string {
+	switch n % 10 {
+	case 0:
+		return "deploy"
+	case 1, 2, 3:
+		return "logs"
+	default:
+		return "list"
+	}
+}
+
+type loadClient struct {
+	token string
+	ip    string
+}
+
+type sample struct {
+	op      string
+	status  int
+	latency time.Duration
+	err     error
+}
+
+func TestLoad_API(t *testing.T) {
+	if testing.Short() {
+		t.Skip("load test")
+	}
+	h := newHarness(t)
+	h.cluster.Logs["load"] = []k8s.LogLine{{Pod: "load-abc", Container: "app", Message: "GET / 200"}}
+
+	clients := make([]loadClient, loadClients)
+	for i := range clients {
+		clients[i] = loadClient{
+			token: h.signup(fmt.Sprintf("load-%d", i)),
+			ip:    fmt.Sprintf("10.1.0.%d", i+1),
+		}
+		if code := h.do(http.MethodPost, "/api/apps", clients[i].token, map[string]any{"name": "load"}, nil); code != http.StatusCreated {
+			t.Fatalf("POST /api/apps = %d, want 201", code)
+		}
+	}
+
+	httpClient := &http.Client{Transport: &http.Transport{MaxIdleConnsPerHost: loadClients}}
+	before := testPool.Stat()
+
+	ctx, cancel := context.WithTimeout(context.Background(), loadDuration)
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		samples []sample
+		wg      sync.WaitGroup
+	)
+	for _, client := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticker := time.NewTicker(time.Second / loadRate)
+			defer ticker.Stop()
+
+			for n := 0; ; n++ {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+
+				var s sample
+				op := loadMix(n)
+				switch op {
+				case "list":
+					s = hit(httpClient, h.server.URL, http.MethodGet, "/api/apps?limit=20", client, "")
+				case "logs":
+					s = hit(httpClient, h.server.URL, http.MethodGet, "/api/apps/load/logs?tail=50", client, "")
+				case "deploy":
+					s = hit(httpClient, h.server.URL, http.MethodPost, "/api/apps/load/deployments", client,
+						fmt.Sprintf(`{"image":"127.0.0.1:1/load:%d"}`, n))
+				}
+				s.op = op
+
+				mu.Lock()
+				samples = append(samples, s)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	after := testPool.Stat()
+
+	byOp := make(map[string][]time.Duration)
+	for _, s := range samples {
+		want := http.StatusOK
+		if s.op == "deploy" {
+			want = http.StatusCreated
+		}
+		switch {
+		case s.err != nil:
+			t.Errorf("%s failed: %v", s.op, s.err)
+		case s.status != want:
+			t.Errorf("%s = %d, want %d", s.op, s.status, want)
+		}
+		byOp[s.op] = append(byOp[s.op], s.latency)
+	}
+
+	for op, budget := range latencyBudgets {
+		latencies := byOp[op]
+		if len(latencies) == 0 {
+			t.Errorf("no %s requests were made", op)
+			continue
+		}
+		slices.Sort(latencies)
+		p95 := percentile(latencies, 95)
+		t.Logf("%-6s %5d requests  p50 %v  p95 %v  p99 %v", op, len(latencies),
+			percentile(latencies, 50), p95, percentile(latencies, 99))
+		if p95 > budget {
+			t.Errorf("%s p95 latency %v is over its budget of %v", op, p95, budget)
+		}
+	}
+
+	acquires := after.AcquireCount() - before.AcquireCount()
+	if acquires > 0 {
+		wait := (after.AcquireDuration() - before.AcquireDuration()) / time.Duration(acquires)
+		t.Logf("pool: %d acquires, mean wait %v, %d waited for a connection, max %d connections",
+			acquires, wait, after.EmptyAcquireCount()-before.EmptyAcquireCount(), after.MaxConns())
+		if wait > maxAcquireWait {
+			t.Errorf("mean wait for a pooled connection %v is over %v", wait, maxAcquireWait)
+		}
+	}
+}
+
+func TestLoad_RateLimiter(t *testing.T) {
+	if testing.Short() {
+		t.Skip("load test")
+	}
+	h := newHarness(t)
+	noisy := loadClient{token: h.signup("ratelimit-noisy"), ip: "10.2.0.1"}
+	quiet := loadClient{token: h.signup("ratelimit-quiet"), ip: "10.2.0.2"}
+
+	httpClient := &http.Client{Transport: &http.Transport{MaxIdleConnsPerHost: 8}}
+	const requests = 2 * rateBurst
+
+	var (
+		mu        sync.Mutex
+		ok        int
+		throttled int
+		wg        sync.WaitGroup
+	)
+	jobs := make(chan struct{}, requests)
+	for range requests {
+		jobs <- struct{}{}
+	}
+	close(jobs)
+
+	start := time.Now()
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				s := hit(httpClient, h.server.URL, http.MethodGet, "/api/apps", noisy, "")
+				mu.Lock()
+				switch s.status {
+				case http.StatusOK:
+					ok++
+				case http.StatusTooManyRequests:
+					throttled++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	t.Logf("%d requests in %v: %d served, %d throttled", requests, elapsed, ok, throttled)
+	if ok < rateBurst {
+		t.Errorf("expected at least the burst of %d requests to be served, got %d", rateBurst, ok)
+	}
+	if allowed := rateBurst + int(rateLimit*elapsed.Seconds()) + 1; ok > allowed {
+		t.Errorf("expected at most %d requests to be served in %v, got %d", allowed, elapsed, ok)
+	}
+	if throttled == 0 {
+		t.Error("expected a client over the rate limit to be throttled")
+	}
+
+	if s := hit(httpClient, h.server.URL, http.MethodGet, "/api/apps", quiet, ""); s.status != http.StatusOK {
+		t.Errorf("expected another client to be unaffected, got %d %v", s.status, s.err)
+	}
+}
+
+// hit sends one request as client and times it
+func hit(httpClient *http.Client, baseURL, method, path string, client loadClient, body string) sample {
+	var reader io.Reader
+	if body != "" {
+		reader = bytes.NewReader([]byte(body))
+	}
+	req, err := http.NewRequest(method, baseURL+path, reader)
+	if err != nil {
+		return sample{err: err}
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Bearer "+client.token)
+	req.Header.Set("X-Forwarded-For", client.ip)
+
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return sample{err: err, latency: time.Since(start)}
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(io.Discard, resp.Body)
+	return sample{status: resp.StatusCode, latency: time.Since(start), err: err}
+}
+
+// percentile returns the pth percentile of sorted latencies
+func percentile(sorted []time.Duration, p int) time.Duration {
+	i := (len(sorted)*p + 99) / 100
+	if i > 0 {
+		i--
+	}
+	return sorted[i]
+}
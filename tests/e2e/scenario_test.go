@@ -0,0 +1,419 @@
+package e2e_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abdul-hamid-achik/nexo-cloud/app/api"
+	"github.com/abdul-hamid-achik/nexo-cloud/app/api/apps"
+	"github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/deployments"
+	"github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/deployments/preview"
+	"github.com/abdul-hamid-achik/nexo-cloud/app/api/apps/appname/logs"
+	"github.com/abdul-hamid-achik/nexo-cloud/app/api/users/me"
+	"github.com/abdul-hamid-achik/nexo-cloud/generated/db"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/collaborators"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/k8s"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/rollout"
+	"github.com/abdul-hamid-achik/nexo-cloud/internal/services"
+	"github.com/abdul-hamid-achik/fuego/pkg/fuego"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestScenario_AppLifecycle(t *testing.T) {
+	h := newHarness(t)
+	h.cluster.Logs["shop"] = []k8s.LogLine{
+		{Pod: "shop-abc", Container: "app", Message: "listening on :8080"},
+		{Pod: "shop-abc", Container: "app", Message: "GET / 200"},
+	}
+
+	// Sign up
+	token := h.signup("lifecycle")
+	var user me.UserResponse
+	if code := h.do(http.MethodGet, "/api/users/me", token, nil, &user); code != http.StatusOK {
+		t.Fatalf("GET /api/users/me = %d, want 200", code)
+	}
+	if user.Username != "lifecycle" {
+		t.Errorf("expected the signed up user, got %q", user.Username)
+	}
+
+	// Create an app
+	var app apps.AppResponse
+	code := h.do(http.MethodPost, "/api/apps", token, map[string]any{"name": "shop", "tags": []string{"web"}}, &app)
+	if code != http.StatusCreated {
+		t.Fatalf("POST /api/apps = %d, want 201", code)
+	}
+	if app.Name != "shop" || app.Region != "gdl" || app.Status != "stopped" {
+		t.Errorf("unexpected app %+v", app)
+	}
+	if code := h.do(http.MethodPost, "/api/apps", token, map[string]any{"name": "shop"}, nil); code != http.StatusConflict {
+		t.Errorf("expected a second shop to conflict, got %d", code)
+	}
+
+	// Deploy it twice
+	var first, second deployments.DeploymentResponse
+	// A registry that refuses connections, so the image is not inspected
+	image := "127.0.0.1:1/shop:v1"
+	if code := h.do(http.MethodPost, "/api/apps/shop/deployments", token, map[string]any{"image": image}, &first); code != http.StatusCreated {
+		t.Fatalf("POST /api/apps/shop/deployments = %d, want 201", code)
+	}
+	if first.Version != 1 || first.Status != "pending" || first.Image != image {
+		t.Errorf("unexpected deployment %+v", first)
+	}
+	if !h.cluster.Called("CheckCapacity shop") {
+		t.Errorf("expected the deploy to check cluster capacity, got calls %v", h.cluster.Calls())
+	}
+	if code := h.do(http.MethodPost, "/api/apps/shop/deployments", token, map[string]any{"image": "127.0.0.1:1/shop:v2"}, &second); code != http.StatusCreated {
+		t.Fatalf("POST /api/apps/shop/deployments = %d, want 201", code)
+	}
+	if second.Version != 2 {
+		t.Errorf("expected version 2, got %d", second.Version)
+	}
+
+	var history []deployments.DeploymentResponse
+	if code := h.do(http.MethodGet, "/api/apps/shop/deployments", token, nil, &history); code != http.StatusOK {
+		t.Fatalf("GET /api/apps/shop/deployments = %d, want 200", code)
+	}
+	if len(history) != 2 || history[0].ID != second.ID {
+		t.Errorf("expected both deployments newest first, got %+v", history)
+	}
+
+	// Both roll out to the cluster in the background
+	for _, d := range []deployments.DeploymentResponse{first, second} {
+		if rolled := h.rolledOut("shop", d.ID, token); rolled.Status != "running" {
+			t.Errorf("expected version %d running, got %+v", d.Version, rolled)
+		}
+	}
+	if !h.cluster.Called("Deploy shop 127.0.0.1:1/shop:v2") {
+		t.Errorf("expected the second image deployed, got calls %v", h.cluster.Calls())
+	}
+
+	if code := h.do(http.MethodGet, "/api/apps/shop", token, nil, &app); code != http.StatusOK {
+		t.Fatalf("GET /api/apps/shop = %d, want 200", code)
+	}
+	if app.Status != "running" || app.DeploymentCount != 2 {
+		t.Errorf("expected the app running after 2 deployments, got status %q and %d", app.Status, app.DeploymentCount)
+	}
+
+	// Read its logs
+	var recent logs.LogsResponse
+	if code := h.do(http.MethodGet, "/api/apps/shop/logs?tail=1", token, nil, &recent); code != http.StatusOK {
+		t.Fatalf("GET /api/apps/shop/logs = %d, want 200", code)
+	}
+	if len(recent.Logs) != 1 || recent.Logs[0].Message != "GET / 200" {
+		t.Errorf("expected the last log line, got %+v", recent.Logs)
+	}
+
+	// Delete it
+	if code := h.do(http.MethodDelete, "/api/apps/shop", token, nil, nil); code != http.StatusNoContent {
+		t.Fatalf("DELETE /api/apps/shop = %d, want 204", code)
+	}
+	if code := h.do(http.MethodGet, "/api/apps/shop", token, nil, nil); code != http.StatusNotFound {
+		t.Errorf("expected the app to be gone, got %d", code)
+	}
+	if code := h.do(http.MethodGet, "/api/apps/shop/deployments", token, nil, nil); code != http.StatusNotFound {
+		t.Errorf("expected the deployments to be gone with the app, got %d", code)
+	}
+}
+
+func TestScenario_DeployHooks(t *testing.T) {
+	h := newHarness(t)
+	token := h.signup("hooks")
+
+	if code := h.do(http.MethodPost, "/api/apps", token, map[string]any{"name": "hooked"}, nil); code != http.StatusCreated {
+		t.Fatalf("POST /api/apps = %d, want 201", code)
+	}
+	hookCommands := map[string]any{"pre_deploy_command": "bin/migrate", "post_deploy_command": "bin/warm-cache"}
+	if code := h.do(http.MethodPut, "/api/apps/hooked/hooks", token, hookCommands, nil); code != http.StatusOK {
+		t.Fatalf("PUT /api/apps/hooked/hooks = %d, want 200", code)
+	}
+
+	var created deployments.DeploymentResponse
+	if code := h.do(http.MethodPost, "/api/apps/hooked/deployments", token, map[string]any{"image": "127.0.0.1:1/hooked:v1"}, &created); code != http.StatusCreated {
+		t.Fatalf("POST /api/apps/hooked/deployments = %d, want 201", code)
+	}
+
+	// The hook runs of the rollout are recorded on the deployment
+	rolled := h.rolledOut("hooked", created.ID, token)
+	if rolled.Status != "running" {
+		t.Fatalf("expected the deployment running, got %+v", rolled)
+	}
+	if len(rolled.Hooks) != 2 {
+		t.Fatalf("expected the pre and post deploy hook runs, got %+v", rolled.Hooks)
+	}
+	for i, want := range []struct{ phase, command string }{
+		{k8s.HookPhasePreDeploy, "bin/migrate"},
+		{k8s.HookPhasePostDeploy, "bin/warm-cache"},
+	} {
+		run := rolled.Hooks[i]
+		if run.Phase != want.phase || run.Command != want.command || run.Status != k8s.HookStatusSucceeded || run.JobName == "" {
+			t.Errorf("unexpected %s hook run %+v", want.phase, run)
+		}
+	}
+}
+
+func TestScenario_DeploySlots(t *testing.T) {
+	h := newHarness(t)
+	h.cluster.Rolling = make(chan struct{})
+	token := h.signup("slots")
+
+	if code := h.do(http.MethodPost, "/api/apps", token, map[string]any{"name": "slots"}, nil); code != http.StatusCreated {
+		t.Fatalf("POST /api/apps = %d, want 201", code)
+	}
+
+	// Rollouts hold the account's 2 deploy slots after their requests return
+	var rolling []deployments.DeploymentResponse
+	for i := range 2 {
+		var d deployments.DeploymentResponse
+		body := map[string]any{"image": fmt.Sprintf("127.0.0.1:1/slots:v%d", i+1)}
+		if code := h.do(http.MethodPost, "/api/apps/slots/deployments", token, body, &d); code != http.StatusCreated {
+			t.Fatalf("POST /api/apps/slots/deployments = %d, want 201", code)
+		}
+		rolling = append(rolling, d)
+	}
+	if code := h.do(http.MethodPost, "/api/apps/slots/deployments", token, map[string]any{"image": "127.0.0.1:1/slots:v3"}, nil); code != http.StatusTooManyRequests {
+		t.Fatalf("expected a third deploy over the limit while 2 roll out, got %d", code)
+	}
+
+	// Finished rollouts free their slots
+	close(h.cluster.Rolling)
+	for _, d := range rolling {
+		h.rolledOut("slots", d.ID, token)
+	}
+	if code := h.do(http.MethodPost, "/api/apps/slots/deployments", token, map[string]any{"image": "127.0.0.1:1/slots:v3"}, nil); code != http.StatusCreated {
+		t.Errorf("expected a deploy after the rollouts ended, got %d", code)
+	}
+}
+
+func TestScenario_DeploySupersede(t *testing.T) {
+	h := newHarness(t)
+	h.cluster.Rolling = make(chan struct{})
+	token := h.signup("supersede")
+
+	if code := h.do(http.MethodPost, "/api/apps", token, map[string]any{"name": "supersede"}, nil); code != http.StatusCreated {
+		t.Fatalf("POST /api/apps = %d, want 201", code)
+	}
+
+	var older, newer deployments.DeploymentResponse
+	if code := h.do(http.MethodPost, "/api/apps/supersede/deployments", token, map[string]any{"image": "127.0.0.1:1/supersede:v1"}, &older); code != http.StatusCreated {
+		t.Fatalf("POST /api/apps/supersede/deployments = %d, want 201", code)
+	}
+	if code := h.do(http.MethodPost, "/api/apps/supersede/deployments", token, map[string]any{"image": "127.0.0.1:1/supersede:v2"}, &newer); code != http.StatusCreated {
+		t.Fatalf("POST /api/apps/supersede/deployments = %d, want 201", code)
+	}
+	close(h.cluster.Rolling)
+
+	// The older rollout either finished first or never reached the cluster
+	if d := h.rolledOut("supersede", older.ID, token); d.Status == "failed" && (d.FailureReason == nil || *d.FailureReason != "superseded") {
+		t.Errorf("expected the older deployment to run or be superseded, got %s %v", d.Status, d.FailureReason)
+	}
+	if d := h.rolledOut("supersede", newer.ID, token); d.Status != "running" {
+		t.Fatalf("expected the newer deployment to run, got %s", d.Status)
+	}
+
+	var deploys []string
+	for _, call := range h.cluster.Calls() {
+		if strings.HasPrefix(call, "Deploy supersede ") {
+			deploys = append(deploys, call)
+		}
+	}
+	if len(deploys) == 0 || deploys[len(deploys)-1] != "Deploy supersede 127.0.0.1:1/supersede:v2" {
+		t.Errorf("expected the newer image to be rolled out last, got %v", deploys)
+	}
+}
+
+func TestScenario_RolloutRecovery(t *testing.T) {
+	h := newHarness(t)
+	ctx := context.Background()
+	token := h.signup("recovery")
+
+	var app apps.AppResponse
+	if code := h.do(http.MethodPost, "/api/apps", token, map[string]any{"name": "recovery"}, &app); code != http.StatusCreated {
+		t.Fatalf("POST /api/apps = %d, want 201", code)
+	}
+
+	// A rollout a stopped replica left building past the rollout timeout
+	queries := db.New(testPool)
+	deployment, err := queries.CreateDeployment(ctx, db.CreateDeploymentParams{
+		AppID:   uuid.MustParse(app.ID),
+		Version: 1,
+		Image:   "127.0.0.1:1/recovery:v1",
+		Status:  "building",
+	})
+	if err != nil {
+		t.Fatalf("failed to create deployment: %v", err)
+	}
+	if _, err := testPool.Exec(ctx, "UPDATE deployments SET started_at = NOW() - INTERVAL '1 hour' WHERE id = $1", deployment.ID); err != nil {
+		t.Fatalf("failed to age deployment: %v", err)
+	}
+	if _, err := testPool.Exec(ctx, "UPDATE apps SET status = 'deploying', current_deployment_id = $1 WHERE id = $2", deployment.ID, deployment.AppID); err != nil {
+		t.Fatalf("failed to make the deployment current: %v", err)
+	}
+
+	if err := rollout.Recover(ctx, queries); err != nil {
+		t.Fatalf("Recover: %v", err)
+	}
+
+	d := h.rolledOut("recovery", deployment.ID.String(), token)
+	if d.Status != "failed" || d.FailureReason == nil || *d.FailureReason != "interrupted" {
+		t.Errorf("expected the interrupted rollout to fail, got %s %v", d.Status, d.FailureReason)
+	}
+	if code := h.do(http.MethodGet, "/api/apps/recovery", token, nil, &app); code != http.StatusOK || app.Status != "failed" {
+		t.Errorf("expected the app to fail with its rollout, got %d %s", code, app.Status)
+	}
+}
+
+func TestScenario_Isolation(t *testing.T) {
+	h := newHarness(t)
+	owner := h.signup("isolation-owner")
+	other := h.signup("isolation-other")
+
+	if code := h.do(http.MethodPost, "/api/apps", owner, map[string]any{"name": "private"}, nil); code != http.StatusCreated {
+		t.Fatalf("POST /api/apps = %d, want 201", code)
+	}
+
+	for _, tc := range []struct {
+		method string
+		path   string
+		body   any
+	}{
+		{http.MethodGet, "/api/apps/private", nil},
+		{http.MethodGet, "/api/apps/private/deployments", nil},
+		{http.MethodPost, "/api/apps/private/deployments", map[string]any{"image": "127.0.0.1:1/private:v1"}},
+		{http.MethodGet, "/api/apps/private/logs", nil},
+		{http.MethodDelete, "/api/apps/private", nil},
+	} {
+		if code := h.do(tc.method, tc.path, other, tc.body, nil); code != http.StatusNotFound {
+			t.Errorf("%s %s by another user = %d, want 404", tc.method, tc.path, code)
+		}
+	}
+
+	if code := h.do(http.MethodGet, "/api/apps", "", nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("expected an anonymous request to be rejected, got %d", code)
+	}
+	if code := h.do(http.MethodGet, "/api/apps/private", owner, nil, nil); code != http.StatusOK {
+		t.Errorf("expected the owner to still see the app, got %d", code)
+	}
+}
+
+func TestScenario_RegistryTokens(t *testing.T) {
+	h := newHarness(t)
+	owner := h.signup("registry-owner")
+	if code := h.do(http.MethodPost, "/api/apps", owner, map[string]any{"name": "pulled"}, nil); code != http.StatusCreated {
+		t.Fatalf("POST /api/apps = %d, want 201", code)
+	}
+	user, err := h.store.Users.GetByUsername(context.Background(), "registry-owner")
+	if err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+
+	// apiToken stores a token of the user, scoped to the registry when
+	// access is set
+	apiToken := func(access *string) string {
+		token := "fgt_" + randomHex(32)
+		hash, err := bcrypt.GenerateFromPassword([]byte(token), bcrypt.MinCost)
+		if err != nil {
+			t.Fatalf("failed to hash token: %v", err)
+		}
+		if _, err := db.New(testPool).CreateAPIToken(context.Background(), db.CreateAPITokenParams{
+			UserID:         user.ID,
+			Name:           "token-" + randomHex(4),
+			TokenHash:      string(hash),
+			RegistryAccess: access,
+		}); err != nil {
+			t.Fatalf("failed to create token: %v", err)
+		}
+		return token
+	}
+
+	pull := "pull"
+	registryToken := apiToken(&pull)
+	for _, tc := range []struct {
+		method string
+		path   string
+	}{
+		{http.MethodGet, "/api/apps"},
+		{http.MethodGet, "/api/apps/pulled"},
+		{http.MethodDelete, "/api/apps/pulled"},
+	} {
+		if code := h.do(tc.method, tc.path, registryToken, nil, nil); code != http.StatusUnauthorized {
+			t.Errorf("%s %s with a registry token = %d, want 401", tc.method, tc.path, code)
+		}
+	}
+
+	if code := h.do(http.MethodGet, "/api/apps/pulled", apiToken(nil), nil, nil); code != http.StatusOK {
+		t.Errorf("expected an API token to still be accepted, got %d", code)
+	}
+}
+
+func TestScenario_CollaboratorPreview(t *testing.T) {
+	h := newHarness(t)
+	owner := h.signup("preview-owner")
+	viewer := h.signup("preview-viewer")
+	deployer := h.signup("preview-deployer")
+	if code := h.do(http.MethodPost, "/api/apps", owner, map[string]any{"name": "previewed"}, nil); code != http.StatusCreated {
+		t.Fatalf("POST /api/apps = %d, want 201", code)
+	}
+
+	ctx := context.Background()
+	queries := db.New(testPool)
+	ownerUser, err := h.store.Users.GetByUsername(ctx, "preview-owner")
+	if err != nil {
+		t.Fatalf("failed to get owner: %v", err)
+	}
+	app, err := queries.GetAppByName(ctx, db.GetAppByNameParams{UserID: ownerUser.ID, Name: "previewed"})
+	if err != nil {
+		t.Fatalf("failed to get app: %v", err)
+	}
+	for username, role := range map[string]string{"preview-viewer": collaborators.RoleView, "preview-deployer": collaborators.RoleDeploy} {
+		user, err := h.store.Users.GetByUsername(ctx, username)
+		if err != nil {
+			t.Fatalf("failed to get %s: %v", username, err)
+		}
+		if _, err := queries.CreateAppCollaborator(ctx, db.CreateAppCollaboratorParams{
+			AppID:   app.ID,
+			UserID:  user.ID,
+			Role:    role,
+			AddedBy: pgtype.UUID{Bytes: ownerUser.ID, Valid: true},
+		}); err != nil {
+			t.Fatalf("failed to add %s: %v", username, err)
+		}
+	}
+
+	// The preview behind the auth middleware, which resolves collaborators
+	server := fuego.New()
+	server.Use(services.Middleware(&services.Services{
+		Config: h.config,
+		DB:     testPool,
+		Store:  h.store,
+		K8sClients: func(string) (k8s.Interface, error) {
+			return h.cluster, nil
+		},
+	}))
+	server.Use(api.Middleware())
+	server.Get("/api/apps/{name}/deployments/preview", preview.Get)
+	server.Mount()
+	authed := *h
+	authed.server = httptest.NewServer(server)
+	t.Cleanup(authed.server.Close)
+
+	path := "/api/apps/previewed/deployments/preview?image=127.0.0.1:1/previewed:v1"
+	for _, tc := range []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"owner", owner, http.StatusOK},
+		{"deploy collaborator", deployer, http.StatusOK},
+		{"view collaborator", viewer, http.StatusForbidden},
+	} {
+		if code := authed.do(http.MethodGet, path, tc.token, nil, nil); code != tc.want {
+			t.Errorf("GET %s by the %s = %d, want %d", path, tc.name, code, tc.want)
+		}
+	}
+}